@@ -3,6 +3,7 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,12 +11,18 @@ import (
 	"net"
 	"net/http"
 	"net/rpc"
+	"sync"
 	"time"
 
 	golog "github.com/ipfs/go-log"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/qri-io/apiutil"
+	"github.com/qri-io/dataset"
 	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/remote"
 	"github.com/qri-io/qri/version"
 )
 
@@ -41,11 +48,53 @@ func init() {
 // Create one with New, start it up with Serve
 type Server struct {
 	*lib.Instance
+	rpcConns *rpcConns
+	wsConns  *websocketConns
 }
 
 // New creates a new qri server from a p2p node & configuration
 func New(inst *lib.Instance) (s Server) {
-	return Server{Instance: inst}
+	return Server{Instance: inst, rpcConns: &rpcConns{}, wsConns: newWebsocketConns()}
+}
+
+// rpcConns tracks the handshakes of open RPC connections
+type rpcConns struct {
+	lk    sync.Mutex
+	conns map[string]*lib.RPCHandshake
+}
+
+func (c *rpcConns) add(addr string, hs *lib.RPCHandshake) {
+	if c == nil {
+		return
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	if c.conns == nil {
+		c.conns = map[string]*lib.RPCHandshake{}
+	}
+	c.conns[addr] = hs
+}
+
+func (c *rpcConns) remove(addr string) {
+	if c == nil {
+		return
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	delete(c.conns, addr)
+}
+
+func (c *rpcConns) list() map[string]*lib.RPCHandshake {
+	list := map[string]*lib.RPCHandshake{}
+	if c == nil {
+		return list
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	for addr, hs := range c.conns {
+		list[addr] = hs
+	}
+	return list
 }
 
 // Serve starts the server. It will block while the server is running
@@ -111,6 +160,9 @@ func (s Server) Serve(ctx context.Context) (err error) {
 	for _, a := range node.EncapsulatedAddresses() {
 		info = fmt.Sprintf("%s\n  %s", info, a.String())
 	}
+	if node.PrivateNetwork() {
+		info += "\nPrivate network mode active: only peers sharing this node's swarm key can connect"
+	}
 	info += fmt.Sprintf("\nYou are running Qri v%s", APIVersion)
 	info += "\n\n"
 
@@ -161,20 +213,91 @@ func (s Server) ServeRPC(ctx context.Context) {
 		listener.Close()
 	}()
 
-	rpc.Accept(listener)
-	return
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Debugf("RPC accept: %s", err)
+			return
+		}
+		go s.serveRPCConn(conn)
+	}
 }
 
-// HandleIPFSPath responds to IPFS Hash requests with raw data
+// serveRPCConn handshakes with a client before serving RPC calls, closing
+// connections from clients of an incompatible release
+func (s Server) serveRPCConn(conn net.Conn) {
+	addr := conn.RemoteAddr().String()
+	hs, rwc, err := lib.ServerRPCHandshake(conn)
+	if err != nil {
+		log.Infof("RPC connection from %s: %s", addr, err)
+		conn.Close()
+		return
+	}
+
+	s.rpcConns.add(addr, hs)
+	defer s.rpcConns.remove(addr)
+	rpc.ServeConn(rwc)
+}
+
+// HandleIPFSPath responds to IPFS Hash requests with raw data. Requests for
+// the body component of a stored dataset, eg: /ipfs/Qm...?component=body&format=csv
+// respond with the body in the requested format, or the stored format if
+// none is given
 func (s *Server) HandleIPFSPath(w http.ResponseWriter, r *http.Request) {
 	if s.Config().API.ReadOnly {
 		readOnlyResponse(w, "/ipfs/")
 		return
 	}
 
+	if component := r.FormValue("component"); component != "" {
+		if component != "body" {
+			apiutil.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("unsupported component %q, only the body of a stored dataset can be read", component))
+			return
+		}
+		s.fetchCAFSBody(r.URL.Path, w, r)
+		return
+	}
+
 	s.fetchCAFSPath(r.URL.Path, w, r)
 }
 
+// fetchCAFSBody writes the body of the dataset stored at path, converted to
+// the format requested by the "format" form value
+func (s Server) fetchCAFSBody(path string, w http.ResponseWriter, r *http.Request) {
+	df, err := dataset.ParseDataFormatString(r.FormValue("format"))
+	if err != nil {
+		apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+	node := s.Node()
+	ds, err := dsfs.LoadDataset(ctx, node.Repo.Store(), path)
+	if err != nil {
+		apiutil.WriteErrResponse(w, http.StatusNotFound, fmt.Errorf("loading dataset: %s", err))
+		return
+	}
+	if err = base.OpenDataset(ctx, node.Repo.Filesystem(), ds); err != nil {
+		apiutil.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	data, err := base.ReadBody(ds, df, nil, nil, -1, 0, true)
+	if err != nil {
+		apiutil.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	format := df.String()
+	if df == dataset.UnknownDataFormat && ds.Structure != nil {
+		format = ds.Structure.Format
+	}
+	if mime := extensionToMimeType("." + format); mime != "" {
+		w.Header().Set("Content-Type", mime)
+	}
+	w.Write(data)
+}
+
 func (s Server) fetchCAFSPath(path string, w http.ResponseWriter, r *http.Request) {
 	file, err := s.Node().Repo.Store().Get(r.Context(), path)
 	if err != nil {
@@ -226,6 +349,77 @@ func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{ "meta": { "code": 200, "status": "ok", "versionzz":"` + APIVersion + `" }, "data": [] }`))
 }
 
+// HandleHealth is HealthCheckHandler with details of the RPC protocol this
+// node speaks, the version handshakes of connected RPC clients, open websocket
+// connections, and the disk usage of the node's repo
+func (s Server) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	meta := map[string]interface{}{
+		"code":      http.StatusOK,
+		"status":    "ok",
+		"versionzz": APIVersion,
+		"rpc": map[string]interface{}{
+			"version": lib.LocalRPCVersion(),
+			"clients": s.rpcConns.list(),
+		},
+	}
+	wsClients := s.wsConns.list()
+	meta["websocket"] = map[string]interface{}{
+		"connections": len(wsClients),
+		"clients":     wsClients,
+	}
+	status := &lib.P2PStatus{}
+	if err := lib.NewPeerRequests(s.Node(), nil).Status(nil, status); err == nil {
+		meta["p2p"] = status
+	}
+	usage := &lib.DiskUsageResult{}
+	if err := lib.NewRepoMethods(s.Instance).DiskUsage(&lib.DiskUsageParams{}, usage); err == nil {
+		meta["diskUsage"] = usage
+	}
+	res := map[string]interface{}{
+		"meta": meta,
+		"data": []interface{}{},
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		apiutil.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// Capabilities describes what a node supports, letting clients adapt to the
+// node they're connected to
+type Capabilities struct {
+	APIVersion string `json:"apiVersion"`
+	// BodyFormats are the formats a dataset body can be saved in & converted to
+	BodyFormats []string `json:"bodyFormats"`
+	// RemoteProtocols are the protocols the node can reach remotes over
+	RemoteProtocols []string `json:"remoteProtocols"`
+	// Remote is true when the node accepts pushes as a remote
+	Remote   bool `json:"remote"`
+	ReadOnly bool `json:"readOnly"`
+}
+
+// HandleCapabilities responds with the Capabilities of this node
+func (s Server) HandleCapabilities(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		apiutil.EmptyOkHandler(w, r)
+	case "GET":
+		cfg := s.Config()
+		apiutil.WriteResponse(w, Capabilities{
+			APIVersion:      APIVersion,
+			BodyFormats:     base.SupportedBodyFormats(),
+			RemoteProtocols: remote.SupportedProtocols(),
+			Remote:          cfg.Remote != nil && cfg.Remote.Enabled,
+			ReadOnly:        cfg.API != nil && cfg.API.ReadOnly,
+		})
+	default:
+		apiutil.NotFoundHandler(w, r)
+	}
+}
+
 // NewServerRoutes returns a Muxer that has all API routes
 func NewServerRoutes(s Server) *http.ServeMux {
 	node := s.Node()
@@ -233,7 +427,8 @@ func NewServerRoutes(s Server) *http.ServeMux {
 
 	m := http.NewServeMux()
 
-	m.Handle("/health", s.middleware(HealthCheckHandler))
+	m.Handle("/health", s.middleware(s.HandleHealth))
+	m.Handle("/capabilities", s.middleware(s.HandleCapabilities))
 	m.Handle("/ipfs/", s.middleware(s.HandleIPFSPath))
 	m.Handle("/ipns/", s.middleware(s.HandleIPNSPath))
 
@@ -256,6 +451,10 @@ func NewServerRoutes(s Server) *http.ServeMux {
 		m.Handle("/remote/dsync", s.middleware(remh.DsyncHandler))
 		m.Handle("/remote/logsync", s.middleware(remh.LogsyncHandler))
 		m.Handle("/remote/refs", s.middleware(remh.RefsHandler))
+		m.Handle("/remote/dataset/log", s.middleware(remh.LogHandler))
+		m.Handle("/remote/metrics", s.middleware(remh.MetricsHandler))
+		m.Handle("/remote/metrics/prometheus", promhttp.Handler())
+		m.Handle("/remote/access", s.middleware(remh.AccessHandler))
 	}
 
 	dsh := NewDatasetHandlers(s.Instance, cfg.API.ReadOnly)
@@ -271,13 +470,26 @@ func NewServerRoutes(s Server) *http.ServeMux {
 	m.Handle("/diff", s.middleware(dsh.DiffHandler))
 	m.Handle("/body/", s.middleware(dsh.BodyHandler))
 	m.Handle("/stats/", s.middleware(dsh.StatsHandler))
+	m.Handle("/schema/", s.middleware(dsh.SchemaHandler))
+	m.Handle("/rowcount/", s.middleware(dsh.RowCountHandler))
+	m.Handle("/detect-format", s.middleware(dsh.DetectFormatHandler))
+	m.Handle("/query", s.middleware(dsh.QueryHandler))
 	m.Handle("/unpack/", s.middleware(dsh.UnpackHandler))
 
+	imph := NewImportHandlers(s.Instance, cfg.API.ReadOnly)
+	m.Handle("/import/git", s.middleware(imph.GitImportHandler))
+
+	bkh := NewBackupHandlers(s.Instance, cfg.API.ReadOnly)
+	m.Handle("/backup", s.middleware(bkh.BackupHandler))
+
 	remClientH := NewRemoteClientHandlers(s.Instance, cfg.API.ReadOnly)
 	m.Handle("/publish/", s.middleware(remClientH.PublishHandler))
+	m.Handle("/publish-queue", s.middleware(remClientH.PublishQueueHandler))
 	m.Handle("/fetch/", s.middleware(remClientH.NewFetchHandler("/fetch")))
 	m.Handle("/feeds", s.middleware(remClientH.FeedsHandler))
 	m.Handle("/preview/", s.middleware(remClientH.DatasetPreviewHandler))
+	m.Handle("/sync-status", s.middleware(remClientH.SyncStatusHandler))
+	m.Handle("/sync-status/", s.middleware(remClientH.SyncStatusHandler))
 
 	uh := UpdateHandlers{
 		UpdateMethods: lib.NewUpdateMethods(s.Instance),
@@ -288,6 +500,8 @@ func NewServerRoutes(s Server) *http.ServeMux {
 	m.Handle("/update/logs", s.middleware(uh.LogsHandler))
 	m.Handle("/update/logs/file", s.middleware(uh.LogFileHandler))
 	m.Handle("/update/service", s.middleware(uh.ServiceHandler))
+	m.Handle("/update/running", s.middleware(uh.RunningHandler))
+	m.Handle("/update/cancel", s.middleware(uh.CancelHandler))
 
 	fsih := NewFSIHandlers(s.Instance, cfg.API.ReadOnly)
 	m.Handle("/status/", s.middleware(fsih.StatusHandler("/status")))
@@ -300,8 +514,9 @@ func NewServerRoutes(s Server) *http.ServeMux {
 	m.Handle("/render", s.middleware(renderh.RenderHandler))
 	m.Handle("/render/", s.middleware(renderh.RenderHandler))
 
-	lh := NewLogHandlers(node)
+	lh := NewLogHandlersInstance(s.Instance)
 	m.Handle("/history/", s.middleware(lh.LogHandler))
+	m.Handle("/timeline/", s.middleware(lh.TimelineHandler))
 
 	rch := NewRegistryClientHandlers(s.Instance, cfg.API.ReadOnly)
 	m.Handle("/registry/profile/new", s.middleware(rch.CreateProfileHandler))
@@ -310,6 +525,17 @@ func NewServerRoutes(s Server) *http.ServeMux {
 	sh := NewSearchHandlers(s.Instance)
 	m.Handle("/search", s.middleware(sh.SearchHandler))
 
+	rph := NewRepoHandlers(s.Instance)
+	m.Handle("/fsck", s.middleware(rph.FsckHandler))
+	m.Handle("/dedup", s.middleware(rph.DedupHandler))
+
+	dbgh := NewDebugHandlers(s.Instance, cfg.API)
+	m.Handle("/debug/refs", s.middleware(dbgh.RefsHandler))
+
+	pinh := NewPinHandlers(s.Instance, cfg.API.ReadOnly)
+	m.Handle("/pins/", s.middleware(pinh.PinsHandler))
+	m.Handle("/pin-policy/", s.middleware(pinh.PinPolicyHandler))
+
 	rh := NewRootHandler(dsh, ph)
 	m.Handle("/", s.datasetRefMiddleware(s.middleware(rh.Handler)))
 
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,16 +12,19 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/beme/abide"
+	"github.com/google/go-cmp/cmp"
 	golog "github.com/ipfs/go-log"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/lib"
 	"github.com/qri-io/qri/p2p"
 	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
 	"github.com/qri-io/qri/repo/test"
 )
 
@@ -158,6 +162,128 @@ func TestServerRoutes(t *testing.T) {
 	runHandlerTestCases(t, "health check", HealthCheckHandler, healthCheckCases, true)
 }
 
+func TestHandleHealth(t *testing.T) {
+	run := NewAPITestRunner(t)
+	defer run.Delete()
+
+	s := New(run.Inst)
+	s.rpcConns.add("127.0.0.1:5000", &lib.RPCHandshake{Client: lib.LocalRPCVersion(), Server: lib.LocalRPCVersion(), Compatible: true})
+
+	w := httptest.NewRecorder()
+	s.HandleHealth(w, httptest.NewRequest("GET", "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch. expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+
+	res := struct {
+		Meta struct {
+			Version string `json:"versionzz"`
+			RPC     struct {
+				Version lib.RPCVersion               `json:"version"`
+				Clients map[string]*lib.RPCHandshake `json:"clients"`
+			} `json:"rpc"`
+			Websocket struct {
+				Connections int                   `json:"connections"`
+				Clients     []websocketConnStatus `json:"clients"`
+			} `json:"websocket"`
+			DiskUsage *lib.DiskUsageResult `json:"diskUsage"`
+		} `json:"meta"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Meta.Version != APIVersion {
+		t.Errorf("version mismatch. expected: %q, got: %q", APIVersion, res.Meta.Version)
+	}
+	if res.Meta.RPC.Version != lib.LocalRPCVersion() {
+		t.Errorf("rpc version mismatch. expected: %v, got: %v", lib.LocalRPCVersion(), res.Meta.RPC.Version)
+	}
+	if hs := res.Meta.RPC.Clients["127.0.0.1:5000"]; hs == nil || !hs.Compatible {
+		t.Errorf("expected connected rpc client to be listed, got: %v", res.Meta.RPC.Clients)
+	}
+	if res.Meta.Websocket.Connections != 0 || res.Meta.Websocket.Clients == nil {
+		t.Errorf("expected health to report no websocket connections, got: %#v", res.Meta.Websocket)
+	}
+	if res.Meta.DiskUsage == nil {
+		t.Errorf("expected health to report disk usage")
+	}
+
+	s.rpcConns.remove("127.0.0.1:5000")
+	w = httptest.NewRecorder()
+	s.HandleHealth(w, httptest.NewRequest("GET", "/health", nil))
+	if strings.Contains(w.Body.String(), "127.0.0.1:5000") {
+		t.Errorf("expected closed rpc connection to be removed")
+	}
+}
+
+func TestHandleCapabilities(t *testing.T) {
+	run := NewAPITestRunner(t)
+	defer run.Delete()
+
+	s := New(run.Inst)
+	w := httptest.NewRecorder()
+	s.HandleCapabilities(w, httptest.NewRequest("GET", "/capabilities", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch. expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+
+	res := struct {
+		Data Capabilities `json:"data"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	expect := Capabilities{
+		APIVersion:      APIVersion,
+		BodyFormats:     []string{"cbor", "json", "csv", "xlsx"},
+		RemoteProtocols: []string{"p2p", "http"},
+	}
+	if diff := cmp.Diff(expect, res.Data); diff != "" {
+		t.Errorf("capabilities mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHandleIPFSPathBody(t *testing.T) {
+	run := NewAPITestRunner(t)
+	defer run.Delete()
+
+	ref, err := run.Node.Repo.GetRef(reporef.DatasetRef{Peername: "peer", Name: "movies"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(run.Inst)
+
+	cases := []struct {
+		query  string
+		status int
+		prefix string
+	}{
+		{"?component=body&format=csv", http.StatusOK, "title,duration\n"},
+		{"?component=body&format=json", http.StatusOK, "[["},
+		{"?component=body", http.StatusOK, "title,duration\n"},
+		{"?component=body&format=nope", http.StatusBadRequest, ""},
+		{"?component=meta", http.StatusBadRequest, ""},
+	}
+
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		s.HandleIPFSPath(w, httptest.NewRequest("GET", ref.Path+c.query, nil))
+		if w.Code != c.status {
+			t.Errorf("%s: status mismatch. expected: %d, got: %d. body: %s", c.query, c.status, w.Code, w.Body.String())
+			continue
+		}
+		if !strings.HasPrefix(w.Body.String(), c.prefix) {
+			t.Errorf("%s: expected response to start with %q, got: %q", c.query, c.prefix, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.HandleIPFSPath(w, httptest.NewRequest("GET", "/map/QmNotAStoredDataset?component=body", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected a missing dataset to 404, got: %d", w.Code)
+	}
+}
+
 func TestServerReadOnlyRoutes(t *testing.T) {
 	if err := confirmQriNotRunning(); err != nil {
 		t.Skip(err.Error())
@@ -243,6 +369,8 @@ func TestServerReadOnlyRoutes(t *testing.T) {
 		{"GET", "/checkout", 403},
 		{"GET", "/status", 403},
 		{"GET", "/init", 403},
+		{"DELETE", "/pins/peer/movies", 403},
+		{"POST", "/pin-policy/peer/movies", 403},
 
 		// active endpoints:
 		{"GET", "/health", 200},
@@ -255,6 +383,9 @@ func TestServerReadOnlyRoutes(t *testing.T) {
 		{"GET", "/profile/poster?peername=me", 200},
 		{"GET", "/peer/movies", 200},
 		{"GET", "/history/peer/movies", 200},
+		{"GET", "/timeline/peer/movies", 200},
+		// listing pins is allowed, but the test store doesn't pin
+		{"GET", "/pins/peer/movies", 400},
 	}
 
 	for i, c := range cases {
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	util "github.com/qri-io/apiutil"
+	"github.com/qri-io/qri/lib"
+)
+
+// BackupJobState is the lifecycle stage of a backup job
+type BackupJobState string
+
+const (
+	// BackupJobRunning is a backup that hasn't finished
+	BackupJobRunning BackupJobState = "running"
+	// BackupJobSucceeded is a backup with an archive ready to download
+	BackupJobSucceeded BackupJobState = "succeeded"
+	// BackupJobFailed is a backup that stopped with an error
+	BackupJobFailed BackupJobState = "failed"
+)
+
+// BackupJob is a repo backup running in the background
+type BackupJob struct {
+	ID       string              `json:"id"`
+	State    BackupJobState      `json:"state"`
+	Params   lib.BackupParams    `json:"params"`
+	Manifest *lib.BackupManifest `json:"manifest,omitempty"`
+	// Size is the length of the archive in bytes
+	Size     int64      `json:"size,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	Started  time.Time  `json:"started"`
+	Finished *time.Time `json:"finished,omitempty"`
+
+	path string
+}
+
+// BackupHandlers run repo backups as background jobs, keeping finished
+// archives on disk for download. Backups hold off writes to the repo while
+// they run
+type BackupHandlers struct {
+	readOnly bool
+	*lib.RepoMethods
+
+	lk   sync.Mutex
+	seq  int
+	dir  string
+	jobs map[string]*BackupJob
+}
+
+// NewBackupHandlers allocates a BackupHandlers pointer
+func NewBackupHandlers(inst *lib.Instance, readOnly bool) *BackupHandlers {
+	return &BackupHandlers{
+		readOnly:    readOnly,
+		RepoMethods: lib.NewRepoMethods(inst),
+		jobs:        map[string]*BackupJob{},
+	}
+}
+
+// BackupHandler starts a backup with POST, responding with a job id. GET with
+// an id reports the state of a job, adding download=true responds with the
+// archive of a finished job. GET without an id lists all jobs. Backups can
+// hold private keys, read-only servers don't serve them
+func (h *BackupHandlers) BackupHandler(w http.ResponseWriter, r *http.Request) {
+	if h.readOnly {
+		readOnlyResponse(w, "/backup")
+		return
+	}
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.getBackupHandler(w, r)
+	case "POST":
+		h.backupHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *BackupHandlers) backupHandler(w http.ResponseWriter, r *http.Request) {
+	p := lib.BackupParams{
+		// keys are redacted unless explicitly requested
+		RedactPrivateKeys: r.FormValue("redact") != "false",
+	}
+
+	h.lk.Lock()
+	if h.dir == "" {
+		dir, err := ioutil.TempDir("", "qri_backups")
+		if err != nil {
+			h.lk.Unlock()
+			util.WriteErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		h.dir = dir
+	}
+	h.seq++
+	job := &BackupJob{
+		ID:      strconv.Itoa(h.seq),
+		State:   BackupJobRunning,
+		Params:  p,
+		Started: time.Now(),
+	}
+	job.path = filepath.Join(h.dir, fmt.Sprintf("backup_%s.tar", job.ID))
+	h.jobs[job.ID] = job
+	snapshot := *job
+	h.lk.Unlock()
+
+	go h.runBackup(job)
+
+	w.Header().Set("Location", fmt.Sprintf("/backup?id=%s", job.ID))
+	util.WriteResponse(w, snapshot)
+}
+
+func (h *BackupHandlers) runBackup(job *BackupJob) {
+	p := job.Params
+	man, size, err := h.writeBackup(&p, job.path)
+
+	h.lk.Lock()
+	defer h.lk.Unlock()
+	finished := time.Now()
+	job.Finished = &finished
+	if err != nil {
+		log.Infof("backup job %s error: %s", job.ID, err.Error())
+		os.Remove(job.path)
+		job.State = BackupJobFailed
+		job.Error = err.Error()
+		return
+	}
+	job.Manifest = man
+	job.Size = size
+	job.State = BackupJobSucceeded
+}
+
+func (h *BackupHandlers) writeBackup(p *lib.BackupParams, path string) (*lib.BackupManifest, int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	man, err := h.Backup(p, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	return man, fi.Size(), nil
+}
+
+func (h *BackupHandlers) getBackupHandler(w http.ResponseWriter, r *http.Request) {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+
+	if id := r.FormValue("id"); id != "" {
+		job, ok := h.jobs[id]
+		if !ok {
+			util.WriteErrResponse(w, http.StatusNotFound, fmt.Errorf("backup job %q not found", id))
+			return
+		}
+		if r.FormValue("download") == "true" {
+			if job.State != BackupJobSucceeded {
+				util.WriteErrResponse(w, http.StatusConflict, fmt.Errorf("backup job %q is %s", id, job.State))
+				return
+			}
+			w.Header().Set("Content-Type", "application/x-tar")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(job.path)))
+			http.ServeFile(w, r, job.path)
+			return
+		}
+		util.WriteResponse(w, *job)
+		return
+	}
+
+	jobs := make([]BackupJob, 0, len(h.jobs))
+	for i := 1; i <= h.seq; i++ {
+		if job, ok := h.jobs[strconv.Itoa(i)]; ok {
+			jobs = append(jobs, *job)
+		}
+	}
+	util.WriteResponse(w, jobs)
+}
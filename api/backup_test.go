@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBackupHandler(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	inst := newTestInstanceWithProfileFromNode(node)
+	h := NewBackupHandlers(inst, false)
+
+	w := httptest.NewRecorder()
+	h.BackupHandler(w, httptest.NewRequest("GET", "/backup?id=1", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected unknown job to be not found, got status: %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.BackupHandler(w, httptest.NewRequest("POST", "/backup", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got: %d. body: %s", w.Code, w.Body.String())
+	}
+	res := struct {
+		Data BackupJob `json:"data"`
+	}{}
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Data.ID == "" || !res.Data.Params.RedactPrivateKeys {
+		t.Fatalf("expected a job id & keys to be redacted by default, got: %#v", res.Data)
+	}
+
+	// the test node doesn't use IPFS, so the backup fails in the background
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w = httptest.NewRecorder()
+		h.BackupHandler(w, httptest.NewRequest("GET", "/backup?id="+res.Data.ID, nil))
+		if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Data.State != BackupJobRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for backup job to finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if res.Data.State != BackupJobFailed || res.Data.Error == "" {
+		t.Errorf("expected job to fail with an error, got state: %s error: %q", res.Data.State, res.Data.Error)
+	}
+
+	w = httptest.NewRecorder()
+	h.BackupHandler(w, httptest.NewRequest("GET", "/backup?id="+res.Data.ID+"&download=true", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected downloading a failed backup to conflict, got status: %d", w.Code)
+	}
+
+	ro := NewBackupHandlers(inst, true)
+	w = httptest.NewRecorder()
+	ro.BackupHandler(w, httptest.NewRequest("POST", "/backup", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected read-only server to forbid backups, got status: %d", w.Code)
+	}
+}
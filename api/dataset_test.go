@@ -2,14 +2,20 @@ package api
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/lib"
 )
 
 func TestDatasetHandlers(t *testing.T) {
@@ -206,6 +212,260 @@ func TestSaveWithInferredNewName(t *testing.T) {
 	}
 }
 
+func TestSaveWarnings(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	inst := newTestInstanceWithProfileFromNode(node)
+	h := NewDatasetHandlers(inst, false)
+
+	dir, err := ioutil.TempDir("", "TestSaveWarnings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bodyPath := filepath.Join(dir, "body.json")
+	if err := ioutil.WriteFile(bodyPath, []byte(`[1,"two",3]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ds := `{
+		"structure": {
+			"format": "json",
+			"schema": { "type": "array", "items": { "type": "number" } }
+		}
+	}`
+	req := postJSONRequest("/save/me/warned?bodypath="+bodyPath, ds)
+	w := httptest.NewRecorder()
+	h.SaveHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch. expected: %d, got: %d: %s", http.StatusOK, w.Code, resultText(w))
+	}
+
+	res := struct {
+		Meta struct {
+			Warnings []lib.Warning `json:"warnings"`
+		} `json:"meta"`
+		Data struct {
+			Name string `json:"name"`
+		} `json:"data"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Data.Name != "warned" {
+		t.Errorf("expected saved reference in response data, got name: %q", res.Data.Name)
+	}
+	if len(res.Meta.Warnings) != 1 || res.Meta.Warnings[0].Code != base.WarnValidation {
+		t.Errorf("expected a validation warning in response meta, got: %v", res.Meta.Warnings)
+	}
+}
+
+func TestBodySample(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	inst := newTestInstanceWithProfileFromNode(node)
+	h := NewDatasetHandlers(inst, false)
+
+	sample := func(query string) BodySampleResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.BodyHandler(w, httptest.NewRequest("GET", "/body/peer/movies?"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status mismatch. expected: %d, got: %d: %s", http.StatusOK, w.Code, resultText(w))
+		}
+		res := struct {
+			Data BodySampleResponse `json:"data"`
+		}{}
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		return res.Data
+	}
+
+	head := sample("sample=head&size=2")
+	rows := []interface{}{}
+	if err := json.Unmarshal(head.Data, &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Errorf("expected 2 sampled rows, got: %d", len(rows))
+	}
+	if head.Total <= 2 {
+		t.Errorf("expected head sample total to count the entire body, got: %d", head.Total)
+	}
+	if head.Seed != 0 {
+		t.Errorf("expected head sample to have no seed, got: %d", head.Seed)
+	}
+
+	a := sample("sample=random&size=5&seed=1")
+	b := sample("sample=random&size=5&seed=1")
+	if string(a.Data) != string(b.Data) {
+		t.Errorf("expected random samples with the same seed to match.\na: %s\nb: %s", a.Data, b.Data)
+	}
+	if a.Seed != 1 {
+		t.Errorf("expected random sample to return the requested seed, got: %d", a.Seed)
+	}
+	if seeded := sample("sample=random&size=5"); seeded.Seed == 0 {
+		t.Errorf("expected random sample without a seed to be given one")
+	}
+
+	for _, query := range []string{"sample=tail", "sample=head&size=0", "sample=random&seed=nope"} {
+		w := httptest.NewRecorder()
+		h.BodyHandler(w, httptest.NewRequest("GET", "/body/peer/movies?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("case %q status mismatch. expected: %d, got: %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestBodyColumns(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	inst := newTestInstanceWithProfileFromNode(node)
+	h := NewDatasetHandlers(inst, false)
+
+	w := httptest.NewRecorder()
+	h.BodyHandler(w, httptest.NewRequest("GET", "/body/peer/movies?columns=duration&limit=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch. expected: %d, got: %d: %s", http.StatusOK, w.Code, resultText(w))
+	}
+	res := struct {
+		Data DataResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if expect := `[[178],[169]]`; string(res.Data.Data) != expect {
+		t.Errorf("projected body mismatch. expected: %s, got: %s", expect, res.Data.Data)
+	}
+
+	w = httptest.NewRecorder()
+	h.BodyHandler(w, httptest.NewRequest("GET", "/body/peer/movies?columns=duration,rating", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status mismatch for unknown column. expected: %d, got: %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestBodyAcceptHeader(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	inst := newTestInstanceWithProfileFromNode(node)
+	h := NewDatasetHandlers(inst, false)
+
+	body := func(query, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/body/peer/movies?limit=2"+query, nil)
+		r.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		h.BodyHandler(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status mismatch. expected: %d, got: %d: %s", http.StatusOK, w.Code, resultText(w))
+		}
+		return w
+	}
+
+	w := body("", "text/csv;q=0.9, */*")
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("content type mismatch. expected: %q, got: %q", "text/csv", ct)
+	}
+	if json.Valid(w.Body.Bytes()) {
+		t.Errorf("expected a raw csv body, got: %s", w.Body.String())
+	}
+
+	w = body("&format=json", "text/csv")
+	if w.Header().Get("Warning") == "" {
+		t.Error("expected a warning when the format param overrides the Accept header")
+	}
+	res := struct {
+		Data DataResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Errorf("expected the format param to win, got: %s", w.Body.String())
+	}
+
+	w = body("", "application/json")
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Errorf("expected a json envelope, got: %s", w.Body.String())
+	}
+	if w.Header().Get("Warning") != "" {
+		t.Errorf("expected no warning, got: %q", w.Header().Get("Warning"))
+	}
+}
+
+func TestRowCount(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	inst := newTestInstanceWithProfileFromNode(node)
+	h := NewDatasetHandlers(inst, false)
+
+	w := httptest.NewRecorder()
+	h.RowCountHandler(w, httptest.NewRequest("GET", "/rowcount/peer/cities", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch. expected: %d, got: %d: %s", http.StatusOK, w.Code, resultText(w))
+	}
+	res := struct {
+		Data int `json:"data"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Data != 5 {
+		t.Errorf("row count mismatch. expected: %d, got: %d", 5, res.Data)
+	}
+
+	w = httptest.NewRecorder()
+	h.RowCountHandler(w, httptest.NewRequest("GET", "/rowcount/peer/not_a_dataset", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status mismatch for missing dataset. expected: %d, got: %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	inst := newTestInstanceWithProfileFromNode(node)
+	h := NewDatasetHandlers(inst, false)
+
+	tmp, err := ioutil.TempDir("", "api_detect_format")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "body.tsv")
+	if err := ioutil.WriteFile(path, []byte("a\tb\n1\t2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	h.DetectFormatHandler(w, httptest.NewRequest("GET", "/detect-format?path="+url.QueryEscape(path), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch. expected: %d, got: %d: %s", http.StatusOK, w.Code, resultText(w))
+	}
+	if body := w.Body.String(); !strings.Contains(body, `"format":"csv"`) || !strings.Contains(body, `"headerRow":true`) {
+		t.Errorf("expected lowercase detection fields, got: %s", body)
+	}
+	res := struct {
+		Data lib.FormatDetection `json:"data"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Data.Format != "csv" || res.Data.Delimiter != "\t" || !res.Data.HeaderRow {
+		t.Errorf("unexpected detection: %#v", res.Data)
+	}
+
+	w = httptest.NewRecorder()
+	h.DetectFormatHandler(w, httptest.NewRequest("GET", "/detect-format", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status mismatch for missing path. expected: %d, got: %d", http.StatusBadRequest, w.Code)
+	}
+}
+
 func postJSONRequest(url, jsonBody string) *http.Request {
 	req := httptest.NewRequest("POST", url, bytes.NewBuffer([]byte(jsonBody)))
 	req.Header.Set("Content-Type", "application/json")
@@ -222,3 +482,88 @@ func resultText(rec *httptest.ResponseRecorder) string {
 	bytes, _ := ioutil.ReadAll(res.Body)
 	return string(bytes)
 }
+
+func TestSaveMaxBodySize(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	inst := newTestInstanceWithProfileFromNode(node)
+	h := NewDatasetHandlers(inst, false)
+	h.MaxBodySize = 64
+
+	ds := `{ "body": [["toronto", 40000000], ["new york", 8500000], ["chicago", 300000]] }`
+	w := httptest.NewRecorder()
+	h.SaveHandler(w, postJSONRequest("/save/me/too_large", ds))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected request over the limit to be rejected, got status: %d", w.Code)
+	}
+
+	// requests without a content length are rejected as they're read
+	req := postJSONRequest("/save/me/too_large", ds)
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	h.SaveHandler(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected streamed request over the limit to be rejected, got status: %d", w.Code)
+	}
+
+	// bodies read from a path are limited too
+	w = httptest.NewRecorder()
+	h.SaveHandler(w, postJSONRequest("/save/me/too_large?bodypath="+absolutePath("testdata/cities/data.csv"), "{}"))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected body path over the limit to be rejected, got status: %d: %s", w.Code, resultText(w))
+	}
+
+	h.MaxBodySize = 1 << 20
+	w = httptest.NewRecorder()
+	h.SaveHandler(w, postJSONRequest("/save/me/under_limit?bodypath="+absolutePath("testdata/cities/data.csv"), "{}"))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected body under the limit to save, got status: %d: %s", w.Code, resultText(w))
+	}
+}
+
+func TestQueryHandler(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	inst := newTestInstanceWithProfileFromNode(node)
+	h := NewDatasetHandlers(inst, false)
+
+	w := httptest.NewRecorder()
+	h.QueryHandler(w, postJSONRequest("/query", `{"query": "SELECT city FROM me/cities WHERE pop > 40000 ORDER BY city", "limit": 2}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch. expected: %d, got: %d: %s", http.StatusOK, w.Code, resultText(w))
+	}
+	res := struct {
+		Data QueryResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if expect := `[["chicago"],["new york"]]`; string(res.Data.Data) != expect || !res.Data.Truncated {
+		t.Errorf("expected 2 truncated rows %s, got: %s truncated: %t", expect, res.Data.Data, res.Data.Truncated)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/query?query="+url.QueryEscape("SELECT count(*) AS n FROM me/cities"), nil)
+	req.Header.Set("Accept", "text/csv")
+	h.QueryHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch. expected: %d, got: %d: %s", http.StatusOK, w.Code, resultText(w))
+	}
+	if expect := "n\n5\n"; w.Body.String() != expect || w.Header().Get("Content-Type") != "text/csv" {
+		t.Errorf("expected csv %q, got: %q (%s)", expect, w.Body.String(), w.Header().Get("Content-Type"))
+	}
+
+	w = httptest.NewRecorder()
+	h.QueryHandler(w, postJSONRequest("/query", `{"query": "SELECT nope FROM me/cities"}`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status mismatch for an invalid query. expected: %d, got: %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.QueryHandler(w, httptest.NewRequest("GET", "/query", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status mismatch for GET. expected: %d, got: %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -5,23 +5,27 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	util "github.com/qri-io/apiutil"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/base/dsfs/dsutil"
 	"github.com/qri-io/qri/dsref"
-	"github.com/qri-io/qri/fsi"
 	"github.com/qri-io/qri/lib"
 	"github.com/qri-io/qri/p2p"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/profile"
 	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/qri-io/qri/stats"
 )
 
 // DatasetHandlers wraps a requests struct to interface with http.HandlerFunc
@@ -30,12 +34,18 @@ type DatasetHandlers struct {
 	node     *p2p.QriNode
 	repo     repo.Repo
 	ReadOnly bool
+	// MaxBodySize is the largest request body in bytes the save handler
+	// accepts, 0 means no limit
+	MaxBodySize int64
 }
 
 // NewDatasetHandlers allocates a DatasetHandlers pointer
 func NewDatasetHandlers(inst *lib.Instance, readOnly bool) *DatasetHandlers {
 	req := lib.NewDatasetRequestsInstance(inst)
-	h := DatasetHandlers{*req, inst.Node(), inst.Node().Repo, readOnly}
+	h := DatasetHandlers{DatasetRequests: *req, node: inst.Node(), repo: inst.Node().Repo, ReadOnly: readOnly}
+	if cfg := inst.Config(); cfg != nil && cfg.API != nil {
+		h.MaxBodySize = cfg.API.MaxBodySize
+	}
 	return &h
 }
 
@@ -175,6 +185,55 @@ func (h *DatasetHandlers) StatsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// SchemaHandler gets the schema of a dataset
+func (h *DatasetHandlers) SchemaHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.schemaHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// RowCountHandler gets the number of entries in a dataset body
+func (h *DatasetHandlers) RowCountHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.rowCountHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// DetectFormatHandler sniffs the format of a body file named by the path
+// query parameter, without saving it
+func (h *DatasetHandlers) DetectFormatHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.detectFormatHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// QueryHandler runs a SQL query over dataset bodies
+func (h *DatasetHandlers) QueryHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		h.queryHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
 // UnpackHandler unpacks a zip file and sends it back as json
 func (h *DatasetHandlers) UnpackHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -254,6 +313,8 @@ func extensionToMimeType(ext string) string {
 		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
 	case ".zip":
 		return "application/zip"
+	case ".parquet":
+		return "application/vnd.apache.parquet"
 	default:
 		return ""
 	}
@@ -264,6 +325,11 @@ func (h *DatasetHandlers) listHandler(w http.ResponseWriter, r *http.Request) {
 	args.OrderBy = "created"
 
 	args.Term = r.FormValue("term")
+	if ids := r.FormValue("profileIDs"); ids != "" {
+		args.ProfileIDs = strings.Split(ids, ",")
+	}
+	args.WithSyncStatus = r.FormValue("syncStatus") == "true"
+	args.RemoteName = r.FormValue("remote")
 
 	res := []dsref.VersionInfo{}
 	if err := h.List(&args, &res); err != nil {
@@ -283,17 +349,15 @@ func (h *DatasetHandlers) listHandler(w http.ResponseWriter, r *http.Request) {
 // otherwise, resolve the peername and proceed as normal
 func (h *DatasetHandlers) getHandler(w http.ResponseWriter, r *http.Request) {
 	p := lib.GetParams{
-		Path:   HTTPPathToQriPath(r.URL.Path),
-		UseFSI: r.FormValue("fsi") == "true",
+		Path:             HTTPPathToQriPath(r.URL.Path),
+		UseFSI:           r.FormValue("fsi") == "true",
+		StrictSignatures: r.FormValue("strict_signatures") == "true",
+		ExternalBody:     r.FormValue("external_body") == "true",
 	}
 	res := lib.GetResult{}
 	err := h.Get(&p, &res)
 	if err != nil {
-		if err == repo.ErrNoHistory || err == fsi.ErrNoLink {
-			util.WriteErrResponse(w, http.StatusUnprocessableEntity, err)
-			return
-		}
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		writeLibErrResponse(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -333,8 +397,7 @@ func (h *DatasetHandlers) diffHandler(w http.ResponseWriter, r *http.Request) {
 
 	res := &lib.DiffResponse{}
 	if err := h.Diff(req, res); err != nil {
-		fmt.Println(err)
-		util.WriteErrResponse(w, http.StatusInternalServerError, fmt.Errorf("error generating diff: %s", err.Error()))
+		writeLibErrResponse(w, r, fmt.Errorf("error generating diff: %w", err), http.StatusInternalServerError)
 		return
 	}
 
@@ -396,19 +459,60 @@ func (h *DatasetHandlers) addHandler(w http.ResponseWriter, r *http.Request) {
 	res := reporef.DatasetRef{}
 	err = h.Add(p, &res)
 	if err != nil {
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		writeLibErrResponse(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
 	util.WriteResponse(w, res)
 }
 
+// formRulesFile reads a validation rules file uploaded as the "rules" part of
+// a multipart request body. Rules are only accepted as uploads, a path would
+// name a file on the server
+func formRulesFile(r *http.Request, p *lib.SaveParams) error {
+	if r.FormValue("rules") != "" {
+		return fmt.Errorf("validation rules must be uploaded as a \"rules\" file")
+	}
+	f, header, err := r.FormFile("rules")
+	if err == http.ErrMissingFile || err == http.ErrNotMultipart {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("reading validation rules: %s", err)
+	}
+	defer f.Close()
+
+	if p.ValidationRulesData, err = ioutil.ReadAll(f); err != nil {
+		return fmt.Errorf("reading validation rules: %s", err)
+	}
+	p.ValidationRules = header.Filename
+	return nil
+}
+
 func (h *DatasetHandlers) saveHandler(w http.ResponseWriter, r *http.Request) {
 	ds := &dataset.Dataset{}
 
+	// uploads are capped while they stream, a request over the limit is
+	// rejected before it's buffered in full
+	var limit *base.BodySizeLimit
+	if h.MaxBodySize > 0 {
+		limit = base.NewBodySizeLimit(r.Body, h.MaxBodySize)
+		if r.ContentLength > h.MaxBodySize {
+			util.WriteErrResponse(w, http.StatusRequestEntityTooLarge, limit.Err())
+			return
+		}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{limit, r.Body}
+	}
+
 	if r.Header.Get("Content-Type") == "application/json" {
 		err := json.NewDecoder(r.Body).Decode(ds)
 		if err != nil {
+			if limit != nil && limit.Exceeded() {
+				util.WriteErrResponse(w, http.StatusRequestEntityTooLarge, limit.Err())
+				return
+			}
 			util.WriteErrResponse(w, http.StatusBadRequest, err)
 			return
 		}
@@ -416,7 +520,7 @@ func (h *DatasetHandlers) saveHandler(w http.ResponseWriter, r *http.Request) {
 		if strings.Contains(r.URL.Path, "/save/") {
 			args, err := DatasetRefFromPath(r.URL.Path[len("/save/"):])
 			if err != nil {
-				if err == repo.ErrEmptyRef && r.FormValue("new") == "true" {
+				if errors.Is(err, repo.ErrEmptyRef) && r.FormValue("new") == "true" {
 					// If saving a new dataset, name is not necessary
 					err = nil
 				} else {
@@ -431,6 +535,10 @@ func (h *DatasetHandlers) saveHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	} else {
 		if err := dsutil.FormFileDataset(r, ds); err != nil {
+			if limit != nil && limit.Exceeded() {
+				util.WriteErrResponse(w, http.StatusRequestEntityTooLarge, limit.Err())
+				return
+			}
 			util.WriteErrResponse(w, http.StatusBadRequest, err)
 			return
 		}
@@ -445,7 +553,7 @@ func (h *DatasetHandlers) saveHandler(w http.ResponseWriter, r *http.Request) {
 		Peername: ds.Peername,
 	}
 
-	res := &reporef.DatasetRef{}
+	res := &lib.SaveResult{}
 	scriptOutput := &bytes.Buffer{}
 	p := &lib.SaveParams{
 		Ref:          ref.AliasString(),
@@ -459,9 +567,19 @@ func (h *DatasetHandlers) saveHandler(w http.ResponseWriter, r *http.Request) {
 		WriteFSI:     r.FormValue("fsi") == "true",
 		NewName:      r.FormValue("new") == "true",
 		BodyPath:     r.FormValue("bodypath"),
+		ExternalBody: r.FormValue("external_body"),
+
+		EnforceReferences:    r.FormValue("enforce_references") == "true",
+		RejectBreakingSchema: r.FormValue("reject_breaking_schema") == "true",
+		ConvertFormatToPrev:  true,
+		ScriptOutput:         scriptOutput,
+		TransformRunID:       r.FormValue("run_id"),
+		MaxBodySize:          h.MaxBodySize,
+	}
 
-		ConvertFormatToPrev: true,
-		ScriptOutput:        scriptOutput,
+	if err := formRulesFile(r, p); err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
 	}
 
 	if r.FormValue("secrets") != "" {
@@ -476,14 +594,76 @@ func (h *DatasetHandlers) saveHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.Save(p, res); err != nil {
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		if violations, ok := err.(base.RuleViolations); ok {
+			writeErrDataResponse(w, http.StatusUnprocessableEntity, err, violations)
+			return
+		}
+		writeLibErrResponse(w, r, err, http.StatusInternalServerError)
 		return
 	}
 	// Don't leak paths across the API, it's possible they contain absolute paths or tmp dirs.
 	res.Dataset.BodyPath = filepath.Base(res.Dataset.BodyPath)
 
 	msg := scriptOutput.String()
-	util.WriteMessageResponse(w, msg, res)
+	writeWarningsResponse(w, msg, res.Warnings, res.Ref())
+}
+
+// writeWarningsResponse writes a message response, listing any warnings in
+// the response meta
+func writeWarningsResponse(w http.ResponseWriter, message string, warnings []lib.Warning, data interface{}) {
+	if len(warnings) == 0 {
+		util.WriteMessageResponse(w, message, data)
+		return
+	}
+	env := map[string]interface{}{
+		"meta": map[string]interface{}{
+			"code":     http.StatusOK,
+			"message":  message,
+			"warnings": warnings,
+		},
+		"data": data,
+	}
+	res, err := json.Marshal(env)
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// writeErrDataResponse writes an error response that also carries data
+// describing the error, like the rows that caused it
+func writeErrDataResponse(w http.ResponseWriter, code int, err error, data interface{}) {
+	env := map[string]interface{}{
+		"meta": map[string]interface{}{
+			"code":  code,
+			"error": err.Error(),
+		},
+		"data": data,
+	}
+	res, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(res)
+}
+
+// writeNotFoundResponse responds with 404, listing suggested references when
+// err is a reference that couldn't be resolved
+func writeNotFoundResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var e *repo.ErrRefNotFound
+	if errors.As(err, &e) && len(e.Suggestions) > 0 {
+		writeErrDataResponse(w, http.StatusNotFound, err, map[string]interface{}{
+			"suggestions": e.Suggestions,
+		})
+		return
+	}
+	util.NotFoundHandler(w, r)
 }
 
 func (h *DatasetHandlers) removeHandler(w http.ResponseWriter, r *http.Request) {
@@ -492,6 +672,7 @@ func (h *DatasetHandlers) removeHandler(w http.ResponseWriter, r *http.Request)
 		Revision:  dsref.Rev{Field: "ds", Gen: -1},
 		KeepFiles: r.FormValue("keep-files") == "true",
 		Force:     r.FormValue("force") == "true",
+		Permanent: r.FormValue("permanent") == "true",
 	}
 	if r.FormValue("all") == "true" {
 		p.Revision = dsref.NewAllRevisions()
@@ -500,7 +681,7 @@ func (h *DatasetHandlers) removeHandler(w http.ResponseWriter, r *http.Request)
 	res := lib.RemoveResponse{}
 	if err := h.Remove(&p, &res); err != nil {
 		log.Infof("error deleting dataset: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		writeLibErrResponse(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -545,7 +726,7 @@ func (h DatasetHandlers) renameHandler(w http.ResponseWriter, r *http.Request) {
 	res := &dsref.VersionInfo{}
 	if err := h.Rename(p, res); err != nil {
 		log.Infof("error renaming dataset: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		writeLibErrResponse(w, r, err, http.StatusBadRequest)
 		return
 	}
 
@@ -571,6 +752,47 @@ type DataResponse struct {
 	Data json.RawMessage `json:"data"`
 }
 
+// BodySampleResponse is the response to /body requests for a sample of the
+// body
+type BodySampleResponse struct {
+	Path   string          `json:"path"`
+	Data   json.RawMessage `json:"data"`
+	Sample string          `json:"sample"`
+	// Total is the number of entries in the body, -1 if unknown
+	Total int `json:"total"`
+	// Seed of a random sample, requesting a sample with the same seed returns
+	// the same entries
+	Seed int64 `json:"seed,omitempty"`
+}
+
+// setSampleParams reads the sample, size & seed params of a request for a
+// body sample. Random samples without a seed get one
+func setSampleParams(r *http.Request, p *lib.GetParams) error {
+	p.Sample = r.FormValue("sample")
+	if p.Sample != base.SampleHead && p.Sample != base.SampleRandom {
+		return fmt.Errorf("sample must be one of %q or %q", base.SampleHead, base.SampleRandom)
+	}
+
+	p.SampleSize = util.DefaultPageSize
+	if r.FormValue("size") != "" {
+		size, err := util.ReqParamInt("size", r)
+		if err != nil || size < 1 {
+			return fmt.Errorf("size must be a positive integer")
+		}
+		p.SampleSize = size
+	}
+
+	p.SampleSeed = time.Now().UnixNano()
+	if seed := r.FormValue("seed"); seed != "" {
+		n, err := strconv.ParseInt(seed, 10, 64)
+		if err != nil {
+			return fmt.Errorf("seed must be an integer")
+		}
+		p.SampleSeed = n
+	}
+	return nil
+}
+
 // getParamsFromRequest creates getParams from a request. It's currently only used for paginating dataset bodies
 func getParamsFromRequest(r *http.Request, readOnly bool, path string) (*lib.GetParams, error) {
 	listParams := lib.ListParamsFromRequest(r)
@@ -594,6 +816,9 @@ func getParamsFromRequest(r *http.Request, readOnly bool, path string) (*lib.Get
 		Offset:   listParams.Offset,
 		All:      r.FormValue("all") == "true" && !readOnly,
 	}
+	if cols := r.FormValue("columns"); cols != "" {
+		p.Columns = strings.Split(cols, ",")
+	}
 
 	if !readOnly {
 		offset, offsetErr := util.ReqParamInt("offset", r)
@@ -619,6 +844,30 @@ func getParamsFromRequest(r *http.Request, readOnly bool, path string) (*lib.Get
 	return p, nil
 }
 
+// bodyMimeTypes maps body formats to the media types an Accept header asks
+// for them with
+var bodyMimeTypes = map[string]string{
+	"json":    "application/json",
+	"csv":     "text/csv",
+	"cbor":    "application/cbor",
+	"xlsx":    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"parquet": "application/vnd.apache.parquet",
+}
+
+// acceptFormat gives the first body format an Accept header asks for, or the
+// empty string if it doesn't name one
+func acceptFormat(r *http.Request) string {
+	for _, media := range strings.Split(r.Header.Get("Accept"), ",") {
+		media = strings.TrimSpace(strings.Split(media, ";")[0])
+		for format, mime := range bodyMimeTypes {
+			if media == mime {
+				return format
+			}
+		}
+	}
+	return ""
+}
+
 func (h DatasetHandlers) bodyHandler(w http.ResponseWriter, r *http.Request) {
 	refStr := HTTPPathToQriPath(r.URL.Path[len("/body/"):])
 	p, err := getParamsFromRequest(r, h.ReadOnly, refStr)
@@ -627,27 +876,51 @@ func (h DatasetHandlers) bodyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// an Accept header is the strongest hint, but an explicit format param
+	// wins. Formats other than json can't be enveloped, responding with the
+	// body alone
+	download := r.FormValue("download") == "true"
+	raw := download
+	if accept := acceptFormat(r); accept != "" {
+		if param := r.FormValue("format"); param == "" {
+			p.Format = accept
+			raw = raw || accept != "json"
+		} else if param != accept {
+			w.Header().Set("Warning", fmt.Sprintf(`199 - "format %s overrides Accept header asking for %s"`, param, accept))
+		}
+	}
+
+	if r.FormValue("sample") != "" {
+		if err := setSampleParams(r, p); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
 	result := &lib.GetResult{}
 	if err := h.Get(p, result); err != nil {
-		if err == repo.ErrNoHistory {
-			util.WriteErrResponse(w, http.StatusUnprocessableEntity, err)
+		if _, ok := err.(base.UnknownColumnsError); ok {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
 			return
 		}
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		writeLibErrResponse(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
-	download := r.FormValue("download") == "true"
 	if download {
-		filename, err := lib.GenerateFilename(result.Dataset, p.Format)
+		filename, err := lib.GenerateFilename(result.Dataset, result.Format)
 		if err != nil {
 			util.WriteErrResponse(w, http.StatusInternalServerError, err)
 			return
 		}
-		w.Header().Set("Content-Type", extensionToMimeType("."+p.Format))
+		w.Header().Set("Content-Type", extensionToMimeType("."+result.Format))
 		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
 		w.Write(result.Bytes)
 		return
+	} else if raw {
+		w.Header().Set("Content-Type", bodyMimeTypes[result.Format])
+		w.Write(result.Bytes)
+		return
 	}
 
 	page := util.PageFromRequest(r)
@@ -656,6 +929,20 @@ func (h DatasetHandlers) bodyHandler(w http.ResponseWriter, r *http.Request) {
 		path = result.Dataset.Path
 	}
 
+	if p.Sample != "" {
+		res := BodySampleResponse{
+			Path:   path,
+			Data:   json.RawMessage(result.Bytes),
+			Sample: p.Sample,
+			Total:  result.Total,
+		}
+		if p.Sample == base.SampleRandom {
+			res.Seed = p.SampleSeed
+		}
+		util.WriteResponse(w, res)
+		return
+	}
+
 	dataResponse := DataResponse{
 		Path: path,
 		Data: json.RawMessage(result.Bytes),
@@ -665,35 +952,163 @@ func (h DatasetHandlers) bodyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h DatasetHandlers) schemaHandler(w http.ResponseWriter, r *http.Request) {
+	refstr := HTTPPathToQriPath(r.URL.Path[len("/schema/"):])
+	res := lib.SchemaResult{}
+	if err := h.GetSchema(&refstr, &res); err != nil {
+		writeLibErrResponse(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h DatasetHandlers) rowCountHandler(w http.ResponseWriter, r *http.Request) {
+	refstr := HTTPPathToQriPath(r.URL.Path[len("/rowcount/"):])
+	count := 0
+	if err := h.RowCount(&refstr, &count); err != nil {
+		writeLibErrResponse(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	util.WriteResponse(w, count)
+}
+
+func (h DatasetHandlers) detectFormatHandler(w http.ResponseWriter, r *http.Request) {
+	path := r.FormValue("path")
+	if path == "" {
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("path is required"))
+		return
+	}
+	res := lib.FormatDetection{}
+	if err := h.DetectFormat(&path, &res); err != nil {
+		writeLibErrResponse(w, r, err, http.StatusBadRequest)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+// QueryResponse is the json response of a query
+type QueryResponse struct {
+	Columns   []string        `json:"columns"`
+	Data      json.RawMessage `json:"data"`
+	Truncated bool            `json:"truncated,omitempty"`
+}
+
+func (h DatasetHandlers) queryHandler(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Query   string `json:"query"`
+		Format  string `json:"format"`
+		Limit   int    `json:"limit"`
+		Timeout string `json:"timeout"`
+	}
+	if r.Header.Get("Content-Type") == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		params.Query = r.FormValue("query")
+		params.Format = r.FormValue("format")
+		params.Timeout = r.FormValue("timeout")
+		if r.FormValue("limit") != "" {
+			limit, err := util.ReqParamInt("limit", r)
+			if err != nil {
+				util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("limit must be an integer"))
+				return
+			}
+			params.Limit = limit
+		}
+	}
+
+	p := &lib.QueryParams{Query: params.Query, Format: params.Format, Limit: params.Limit}
+	if params.Timeout != "" {
+		timeout, err := time.ParseDuration(params.Timeout)
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid timeout: %s", err))
+			return
+		}
+		p.Timeout = timeout
+	}
+	if p.Format == "" {
+		p.Format = acceptFormat(r)
+	}
+
+	res := &lib.QueryResult{}
+	if err := h.Query(p, res); err != nil {
+		writeLibErrResponse(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	// formats other than json can't be enveloped, responding with results
+	// alone
+	if res.Format != "json" {
+		if res.Truncated {
+			w.Header().Set("Warning", `199 - "results truncated"`)
+		}
+		w.Header().Set("Content-Type", bodyMimeTypes[res.Format])
+		w.Write(res.Data)
+		return
+	}
+	util.WriteResponse(w, QueryResponse{
+		Columns:   res.Columns,
+		Data:      json.RawMessage(res.Data),
+		Truncated: res.Truncated,
+	})
+}
+
 func (h DatasetHandlers) statsHandler(w http.ResponseWriter, r *http.Request) {
-	p := lib.GetParams{
-		Path:     HTTPPathToQriPath(r.URL.Path[len("/stats/"):]),
-		UseFSI:   r.FormValue("fsi") == "true",
-		Selector: "stats",
+	p := lib.StatsParams{
+		Ref:    HTTPPathToQriPath(r.URL.Path[len("/stats/"):]),
+		UseFSI: r.FormValue("fsi") == "true",
 	}
-	res := lib.GetResult{}
-	err := h.Get(&p, &res)
-	if err != nil {
-		if err == repo.ErrNoHistory || err == fsi.ErrNoLink {
+	if r.FormValue("bins") != "" {
+		bins, err := util.ReqParamInt("bins", r)
+		if err != nil || bins < 1 {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("bins must be a positive integer"))
+			return
+		}
+		p.HistogramBins = bins
+	}
+
+	res := lib.StatsResponse{}
+	if err := h.Stats(&p, &res); err != nil {
+		if perr, ok := err.(*stats.ParseError); ok {
+			writeErrDataResponse(w, http.StatusUnprocessableEntity, err, map[string]interface{}{
+				"row":     perr.Row,
+				"message": perr.Err.Error(),
+			})
+			return
+		}
+		if err == stats.ErrNoBody {
 			util.WriteErrResponse(w, http.StatusUnprocessableEntity, err)
 			return
 		}
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		writeLibErrResponse(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
 	statsMap := &[]map[string]interface{}{}
-	if err := json.Unmarshal(res.Bytes, statsMap); err != nil {
+	if err := json.Unmarshal(res.StatsBytes, statsMap); err != nil {
 		log.Errorf("error unmarshalling stats: %s", err)
 		util.WriteErrResponse(w, http.StatusInternalServerError, fmt.Errorf("error writing stats"))
 		return
 	}
-	if err := util.WriteResponse(w, statsMap); err != nil {
+	var err error
+	if res.Uncommitted {
+		err = util.WriteMessageResponse(w, "stats describe the uncommitted working directory body", statsMap)
+	} else {
+		err = util.WriteResponse(w, statsMap)
+	}
+	if err != nil {
 		log.Infof("error writing response: %s", err.Error())
 	}
 }
 
 func (h DatasetHandlers) unpackHandler(w http.ResponseWriter, r *http.Request, postData []byte) {
+	// archives exported by older versions of qri don't have checksums
+	if _, err := dsutil.VerifyZipArchive(bytes.NewReader(postData), int64(len(postData))); err != nil && err != dsutil.ErrNoChecksums {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
 	contents, err := dsutil.UnzipGetContents(postData)
 	if err != nil {
 		util.WriteErrResponse(w, http.StatusInternalServerError, err)
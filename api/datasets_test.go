@@ -1,9 +1,14 @@
 package api
 
 import (
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
+
+	"github.com/qri-io/qri/lib"
 )
 
 func TestGetParamsFromRequest(t *testing.T) {
@@ -138,3 +143,55 @@ func TestGetParamsFromRequest(t *testing.T) {
 		}
 	}
 }
+
+func TestFormRulesFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "form_rules_file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rules.yaml")
+	rules := []byte("- rule: unique\n  field: id\n")
+	if err := ioutil.WriteFile(path, rules, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewFilesRequest("POST", "/save/me/ds", "/save/me/ds", map[string]string{"rules": path}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &lib.SaveParams{}
+	if err := formRulesFile(r, p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ValidationRules != "rules.yaml" || string(p.ValidationRulesData) != string(rules) {
+		t.Errorf("expected uploaded rules to be read, got: %q %q", p.ValidationRules, string(p.ValidationRulesData))
+	}
+
+	// paths would name files on the server & aren't accepted
+	r, err = NewFilesRequest("POST", "/save/me/ds", "/save/me/ds", nil, map[string]string{"rules": path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := formRulesFile(r, &lib.SaveParams{}); err == nil {
+		t.Error("expected a rules path form value to error")
+	}
+
+	r, err = http.NewRequest("POST", "/save/me/ds?rules="+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := formRulesFile(r, &lib.SaveParams{}); err == nil {
+		t.Error("expected a rules path query param to error")
+	}
+
+	// requests without rules are fine
+	r, err = http.NewRequest("POST", "/save/me/ds", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p = &lib.SaveParams{}
+	if err := formRulesFile(r, p); err != nil || p.ValidationRulesData != nil {
+		t.Errorf("expected no rules, got: %v %v", p.ValidationRulesData, err)
+	}
+}
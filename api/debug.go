@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	util "github.com/qri-io/apiutil"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/lib"
+)
+
+// DebugHandlers serve read-only dumps of repo state for bug reports
+type DebugHandlers struct {
+	lib.RepoMethods
+	cfg *config.API
+}
+
+// NewDebugHandlers allocates a DebugHandlers pointer
+func NewDebugHandlers(inst *lib.Instance, cfg *config.API) *DebugHandlers {
+	req := lib.NewRepoMethods(inst)
+	return &DebugHandlers{RepoMethods: *req, cfg: cfg}
+}
+
+// RefsHandler is the endpoint for dumping the refstore, a logbook summary &
+// the redacted config as one JSON document. It's only served when the API
+// Debug flag is set, or to requests with a write scope access token
+func (h *DebugHandlers) RefsHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		util.WriteErrResponse(w, http.StatusForbidden, fmt.Errorf("debug routes require the api debug flag or a write scope access token"))
+		return
+	}
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.refsHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *DebugHandlers) refsHandler(w http.ResponseWriter, r *http.Request) {
+	res := &lib.DebugDump{}
+	if err := h.DebugDump(nil, res); err != nil {
+		log.Infof("debug dump error: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+// authorized checks a request may read debug routes
+func (h *DebugHandlers) authorized(r *http.Request) bool {
+	if h.cfg != nil && h.cfg.Debug {
+		return true
+	}
+	return hasWriteToken(h.cfg, r)
+}
+
+// hasWriteToken checks a request presents a write scope access token, as a
+// "token" query param or a bearer Authorization header
+func hasWriteToken(cfg *config.API, r *http.Request) bool {
+	if cfg == nil {
+		return false
+	}
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	scope, ok := cfg.TokenScope(token)
+	return ok && scope == config.APIScopeWrite
+}
+
+// requireWriteToken wraps a handler, refusing requests without a write scope
+// access token
+func requireWriteToken(cfg *config.API, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !hasWriteToken(cfg, r) {
+			util.WriteErrResponse(w, http.StatusForbidden, fmt.Errorf("route requires a write scope access token"))
+			return
+		}
+		h(w, r)
+	}
+}
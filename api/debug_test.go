@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qri-io/qri/config"
+)
+
+func TestDebugRefsHandler(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	inst := newTestInstanceWithProfileFromNode(node)
+	cfg := &config.API{
+		AccessTokens: []config.APIAccessToken{
+			{Token: "reader", Scope: config.APIScopeRead},
+			{Token: "writer", Scope: config.APIScopeWrite},
+		},
+	}
+	h := NewDebugHandlers(inst, cfg)
+
+	cases := []struct {
+		description string
+		url         string
+		header      string
+		status      int
+	}{
+		{"no token", "/debug/refs", "", http.StatusForbidden},
+		{"read scope token", "/debug/refs?token=reader", "", http.StatusForbidden},
+		{"write scope token", "/debug/refs?token=writer", "", http.StatusOK},
+		{"bearer token", "/debug/refs", "Bearer writer", http.StatusOK},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", c.url, nil)
+		if c.header != "" {
+			r.Header.Set("Authorization", c.header)
+		}
+		w := httptest.NewRecorder()
+		h.RefsHandler(w, r)
+		if w.Code != c.status {
+			t.Errorf("%s: expected status %d, got: %d. body: %s", c.description, c.status, w.Code, w.Body.String())
+		}
+	}
+
+	cfg.Debug = true
+	w := httptest.NewRecorder()
+	h.RefsHandler(w, httptest.NewRequest("GET", "/debug/refs", nil))
+	res := struct {
+		Data struct {
+			Refs   []interface{}          `json:"refs"`
+			Logs   []interface{}          `json:"logs"`
+			Config map[string]interface{} `json:"config"`
+		} `json:"data"`
+	}{}
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Data.Refs) == 0 || res.Data.Config == nil {
+		t.Errorf("expected the dump to include refs & config, got: %#v", res.Data)
+	}
+
+	w = httptest.NewRecorder()
+	h.RefsHandler(w, httptest.NewRequest("POST", "/debug/refs", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected debug refs to be read-only, POST returned status: %d", w.Code)
+	}
+}
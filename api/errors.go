@@ -0,0 +1,33 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	util "github.com/qri-io/apiutil"
+	"github.com/qri-io/qri/lib"
+)
+
+// writeLibErrResponse responds with the status code for the kind of error a
+// lib method returned, responding with fallback for errors that don't have a
+// kind
+func writeLibErrResponse(w http.ResponseWriter, r *http.Request, err error, fallback int) {
+	switch {
+	case errors.Is(err, lib.ErrNotFound):
+		writeNotFoundResponse(w, r, err)
+	case errors.Is(err, lib.ErrInvalidRef):
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+	case errors.Is(err, lib.ErrNoHistory), errors.Is(err, lib.ErrNotLinked), errors.Is(err, lib.ErrNoChanges), errors.Is(err, lib.ErrBreakingSchemaChange), errors.Is(err, lib.ErrInvalidSignature):
+		util.WriteErrResponse(w, http.StatusUnprocessableEntity, err)
+	case errors.Is(err, lib.ErrAlreadyExists):
+		util.WriteErrResponse(w, http.StatusConflict, err)
+	case errors.Is(err, lib.ErrUnauthorizedRemote), errors.Is(err, lib.ErrForbidden):
+		util.WriteErrResponse(w, http.StatusForbidden, err)
+	case errors.Is(err, lib.ErrRemoteUnreachable):
+		util.WriteErrResponse(w, http.StatusBadGateway, err)
+	case errors.Is(err, lib.ErrBodyTooLarge):
+		util.WriteErrResponse(w, http.StatusRequestEntityTooLarge, err)
+	default:
+		util.WriteErrResponse(w, fallback, err)
+	}
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/repo"
+)
+
+func TestWriteLibErrResponse(t *testing.T) {
+	cases := []struct {
+		err    error
+		expect int
+	}{
+		{fmt.Errorf("oh no"), http.StatusTeapot},
+		{repo.ErrNotFound, http.StatusNotFound},
+		{&repo.ErrRefNotFound{Ref: "me/wbp", Suggestions: []string{"peer/world_bank_population"}}, http.StatusNotFound},
+		{fmt.Errorf("%w: '@' is not a valid dataset reference", lib.ErrInvalidRef), http.StatusBadRequest},
+		{lib.ErrNoHistory, http.StatusUnprocessableEntity},
+		{lib.ErrNotLinked, http.StatusUnprocessableEntity},
+		{fmt.Errorf("%w to save", lib.ErrNoChanges), http.StatusUnprocessableEntity},
+		{fmt.Errorf("dataset 'me/wbp' %w", lib.ErrAlreadyExists), http.StatusConflict},
+		{lib.ErrUnauthorizedRemote, http.StatusForbidden},
+		{lib.ErrRemoteUnreachable, http.StatusBadGateway},
+	}
+
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		writeLibErrResponse(w, r, c.err, http.StatusTeapot)
+		if w.Code != c.expect {
+			t.Errorf("error %q status mismatch. expected: %d, got: %d", c.err, c.expect, w.Code)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	util "github.com/qri-io/apiutil"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/lib"
+)
+
+// RepoHandlers wraps a requests struct to interface with http.HandlerFunc
+type RepoHandlers struct {
+	lib.RepoMethods
+}
+
+// NewRepoHandlers allocates a RepoHandlers pointer
+func NewRepoHandlers(inst *lib.Instance) *RepoHandlers {
+	req := lib.NewRepoMethods(inst)
+	return &RepoHandlers{*req}
+}
+
+// FsckHandler is the endpoint for checking repo integrity. The check is
+// read-only, repairs are only available from the command line
+func (h *RepoHandlers) FsckHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.fsckHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *RepoHandlers) fsckHandler(w http.ResponseWriter, r *http.Request) {
+	p := &lib.CheckRepoParams{
+		VerifyBlocks: r.FormValue("blocks") == "true",
+	}
+	res := &lib.CheckRepoResult{}
+	if err := h.Check(p, res); err != nil {
+		log.Infof("fsck error: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+// DedupHandler is the endpoint for reporting how much space blocks shared
+// between dataset versions save
+func (h *RepoHandlers) DedupHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.dedupHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *RepoHandlers) dedupHandler(w http.ResponseWriter, r *http.Request) {
+	p := &lib.DedupParams{}
+	if top := r.FormValue("top"); top != "" {
+		n, err := strconv.Atoi(top)
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("top must be a number"))
+			return
+		}
+		p.Top = n
+	}
+	res := &base.DedupReport{}
+	if err := h.Dedup(p, res); err != nil {
+		log.Infof("dedup error: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFsckHandler(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	inst := newTestInstanceWithProfileFromNode(node)
+	h := NewRepoHandlers(inst)
+
+	for _, method := range []string{"OPTIONS", "GET"} {
+		w := httptest.NewRecorder()
+		h.FsckHandler(w, httptest.NewRequest(method, "/fsck", nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200, got: %d. body: %s", method, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.FsckHandler(w, httptest.NewRequest("GET", "/fsck?blocks=true", nil))
+	res := struct {
+		Data struct {
+			OK     int            `json:"ok"`
+			Counts map[string]int `json:"counts"`
+			Refs   []interface{}  `json:"refs"`
+		} `json:"data"`
+	}{}
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Data.Counts == nil {
+		t.Error("expected response to include problem counts")
+	}
+	if res.Data.OK > len(res.Data.Refs) {
+		t.Errorf("ok count %d is greater than checked refs %d", res.Data.OK, len(res.Data.Refs))
+	}
+
+	w = httptest.NewRecorder()
+	h.FsckHandler(w, httptest.NewRequest("POST", "/fsck", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected fsck to be read-only, POST returned status: %d", w.Code)
+	}
+}
@@ -9,7 +9,6 @@ import (
 	util "github.com/qri-io/apiutil"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/lib"
-	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/profile"
 	reporef "github.com/qri-io/qri/repo/ref"
 )
@@ -76,11 +75,7 @@ func (h *FSIHandlers) statusHandler(routePrefix string) http.HandlerFunc {
 		refStr := ref.String()
 		err = h.StatusAtVersion(&refStr, &res)
 		if err != nil {
-			if err == repo.ErrNoHistory {
-				util.WriteErrResponse(w, http.StatusUnprocessableEntity, err)
-				return
-			}
-			util.WriteErrResponse(w, http.StatusInternalServerError, fmt.Errorf("error getting status: %s", err.Error()))
+			writeLibErrResponse(w, r, fmt.Errorf("error getting status: %w", err), http.StatusInternalServerError)
 			return
 		}
 		util.WriteResponse(w, res)
@@ -126,7 +121,7 @@ func (h *FSIHandlers) initHandler(routePrefix string) http.HandlerFunc {
 
 		var name string
 		if err := h.InitDataset(p, &name); err != nil {
-			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			writeLibErrResponse(w, r, err, http.StatusBadRequest)
 			return
 		}
 
@@ -139,11 +134,7 @@ func (h *FSIHandlers) initHandler(routePrefix string) http.HandlerFunc {
 		res := lib.GetResult{}
 		err := h.dsm.Get(&gp, &res)
 		if err != nil {
-			if err == repo.ErrNotFound {
-				util.NotFoundHandler(w, r)
-				return
-			}
-			util.WriteErrResponse(w, http.StatusInternalServerError, err)
+			writeLibErrResponse(w, r, err, http.StatusInternalServerError)
 			return
 		}
 		if res.Dataset == nil || res.Dataset.IsEmpty() {
@@ -208,7 +199,7 @@ func (h *FSIHandlers) writeHandler(routePrefix string) http.HandlerFunc {
 
 		out := []lib.StatusItem{}
 		if err := h.Write(p, &out); err != nil {
-			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			writeLibErrResponse(w, r, err, http.StatusBadRequest)
 			return
 		}
 
@@ -245,13 +236,14 @@ func (h *FSIHandlers) checkoutHandler(routePrefix string) http.HandlerFunc {
 		}
 
 		p := &lib.CheckoutParams{
-			Dir: r.FormValue("dir"),
-			Ref: ref.String(),
+			Dir:       r.FormValue("dir"),
+			Ref:       ref.String(),
+			BodyRange: r.FormValue("body_range"),
 		}
 
 		var res string
 		if err := h.Checkout(p, &res); err != nil {
-			util.WriteErrResponse(w, http.StatusInternalServerError, err)
+			writeLibErrResponse(w, r, err, http.StatusInternalServerError)
 			return
 		}
 
@@ -298,7 +290,7 @@ func (h *FSIHandlers) restoreHandler(routePrefix string) http.HandlerFunc {
 
 		var res string
 		if err := h.Restore(p, &res); err != nil {
-			util.WriteErrResponse(w, http.StatusInternalServerError, err)
+			writeLibErrResponse(w, r, err, http.StatusInternalServerError)
 			return
 		}
 
@@ -290,7 +290,7 @@ func TestFSIWrite(t *testing.T) {
 		},
 		BodyPath: "testdata/cities/data.csv",
 	}
-	res := reporef.DatasetRef{}
+	res := lib.SaveResult{}
 	if err := dr.Save(&saveParams, &res); err != nil {
 		t.Fatal(err)
 	}
@@ -374,7 +374,7 @@ func TestCheckoutAndRestore(t *testing.T) {
 		},
 		BodyPath: "testdata/cities/data.csv",
 	}
-	res := reporef.DatasetRef{}
+	res := lib.SaveResult{}
 	if err := dr.Save(&saveParams, &res); err != nil {
 		t.Fatal(err)
 	}
@@ -556,3 +556,96 @@ func JSONAPICallWithBody(method, reqURL string, data interface{}, hf http.Handle
 	}
 	return statusCode, string(bodyBytes)
 }
+
+func TestFSIStats(t *testing.T) {
+	run := NewAPITestRunner(t)
+	defer run.Delete()
+
+	workDir := run.MustMakeWorkDir(t, "fsi_stats")
+	files := map[string]string{
+		"structure.json": `{"format":"csv","qri":"st:0"}`,
+		"body.csv":       "one,two,3\nfour,five,6\n",
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(workDir, name), []byte(data), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// link the working directory to a dataset with no history
+	pro, err := run.Node.Repo.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := run.Node.Repo.PutRef(reporef.DatasetRef{
+		Peername:  pro.Peername,
+		ProfileID: pro.ID,
+		Name:      "stats_ds",
+		FSIPath:   workDir,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	dsHandler := NewDatasetHandlers(run.Inst, false)
+
+	// Stats with no history and fsi=false
+	gotStatusCode, _ := APICall("/stats/peer/stats_ds", dsHandler.StatsHandler)
+	if gotStatusCode != 422 {
+		t.Errorf("expected status code 422, got %d", gotStatusCode)
+	}
+
+	// Stats from the working directory body
+	gotStatusCode, gotBodyString := APICall("/stats/peer/stats_ds?fsi=true", dsHandler.StatsHandler)
+	if gotStatusCode != 200 {
+		t.Fatalf("expected status code 200, got %d: %s", gotStatusCode, gotBodyString)
+	}
+	gotBody := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(gotBodyString), &gotBody); err != nil {
+		t.Fatalf("could not unmarshal response into struct: %s", err)
+	}
+	expectMeta := map[string]interface{}{
+		"code":    float64(200),
+		"message": "stats describe the uncommitted working directory body",
+	}
+	if diff := cmp.Diff(expectMeta, gotBody["meta"]); diff != "" {
+		t.Errorf("meta mismatch (-want +got):\n%s", diff)
+	}
+	if cols, ok := gotBody["data"].([]interface{}); !ok || len(cols) != 3 {
+		t.Errorf("expected stats for 3 columns, got: %v", gotBody["data"])
+	}
+
+	// An unparsable working body reports the row it failed at
+	if err := os.Remove(filepath.Join(workDir, "body.csv")); err != nil {
+		t.Fatal(err)
+	}
+	files = map[string]string{
+		"structure.json": `{"format":"json","qri":"st:0"}`,
+		"body.json":      `[{"a":1},{"a":2},{"a" 3}]`,
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(workDir, name), []byte(data), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gotStatusCode, gotBodyString = APICall("/stats/peer/stats_ds?fsi=true", dsHandler.StatsHandler)
+	if gotStatusCode != 422 {
+		t.Fatalf("expected status code 422, got %d: %s", gotStatusCode, gotBodyString)
+	}
+	gotBody = map[string]interface{}{}
+	if err := json.Unmarshal([]byte(gotBodyString), &gotBody); err != nil {
+		t.Fatalf("could not unmarshal response into struct: %s", err)
+	}
+	data, _ := gotBody["data"].(map[string]interface{})
+	if data["row"] != float64(2) {
+		t.Errorf("expected parse error at row 2, got: %s", gotBodyString)
+	}
+
+	// A missing working body
+	if err := os.Remove(filepath.Join(workDir, "body.json")); err != nil {
+		t.Fatal(err)
+	}
+	gotStatusCode, gotBodyString = APICall("/stats/peer/stats_ds?fsi=true", dsHandler.StatsHandler)
+	if gotStatusCode != 422 {
+		t.Errorf("expected status code 422, got %d: %s", gotStatusCode, gotBodyString)
+	}
+}
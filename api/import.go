@@ -0,0 +1,148 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	util "github.com/qri-io/apiutil"
+	"github.com/qri-io/qri/lib"
+)
+
+// ImportJobState is the lifecycle stage of an import job
+type ImportJobState string
+
+const (
+	// ImportJobRunning is an import that hasn't finished
+	ImportJobRunning ImportJobState = "running"
+	// ImportJobSucceeded is an import that finished without error
+	ImportJobSucceeded ImportJobState = "succeeded"
+	// ImportJobFailed is an import that stopped with an error. The job's result
+	// cursor can resume it
+	ImportJobFailed ImportJobState = "failed"
+)
+
+// ImportJob is an import running in the background
+type ImportJob struct {
+	ID       string              `json:"id"`
+	State    ImportJobState      `json:"state"`
+	Params   lib.ImportGitParams `json:"params"`
+	Result   lib.ImportGitResult `json:"result"`
+	Error    string              `json:"error,omitempty"`
+	Started  time.Time           `json:"started"`
+	Finished *time.Time          `json:"finished,omitempty"`
+}
+
+// ImportHandlers run imports of dataset history as background jobs, imports
+// can take far longer than a request should
+type ImportHandlers struct {
+	readOnly bool
+	*lib.DatasetRequests
+
+	lk   sync.Mutex
+	seq  int
+	jobs map[string]*ImportJob
+}
+
+// NewImportHandlers allocates an ImportHandlers pointer
+func NewImportHandlers(inst *lib.Instance, readOnly bool) *ImportHandlers {
+	return &ImportHandlers{
+		readOnly:        readOnly,
+		DatasetRequests: lib.NewDatasetRequestsInstance(inst),
+		jobs:            map[string]*ImportJob{},
+	}
+}
+
+// GitImportHandler starts importing the git history of a file with POST,
+// responding with a job id. GET with an id reports the state of a job, GET
+// without an id lists all jobs
+func (h *ImportHandlers) GitImportHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.getImportHandler(w, r)
+	case "POST":
+		if h.readOnly {
+			readOnlyResponse(w, "/import/git")
+			return
+		}
+		h.gitImportHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *ImportHandlers) gitImportHandler(w http.ResponseWriter, r *http.Request) {
+	p := lib.ImportGitParams{
+		RepoPath: r.FormValue("repo_path"),
+		FilePath: r.FormValue("file_path"),
+		Ref:      r.FormValue("ref"),
+		After:    r.FormValue("after"),
+	}
+	if p.RepoPath == "" || p.FilePath == "" || p.Ref == "" {
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("repo_path, file_path and ref are required"))
+		return
+	}
+
+	h.lk.Lock()
+	h.seq++
+	job := &ImportJob{
+		ID:      strconv.Itoa(h.seq),
+		State:   ImportJobRunning,
+		Params:  p,
+		Started: time.Now(),
+	}
+	h.jobs[job.ID] = job
+	snapshot := *job
+	h.lk.Unlock()
+
+	go h.runGitImport(job)
+
+	w.Header().Set("Location", fmt.Sprintf("/import/git?id=%s", job.ID))
+	util.WriteResponse(w, snapshot)
+}
+
+func (h *ImportHandlers) runGitImport(job *ImportJob) {
+	p := job.Params
+	res := lib.ImportGitResult{}
+	err := h.ImportGit(&p, &res)
+
+	h.lk.Lock()
+	defer h.lk.Unlock()
+	finished := time.Now()
+	job.Result = res
+	job.Finished = &finished
+	if err != nil {
+		log.Infof("import job %s error: %s", job.ID, err.Error())
+		job.State = ImportJobFailed
+		job.Error = err.Error()
+		return
+	}
+	job.State = ImportJobSucceeded
+}
+
+func (h *ImportHandlers) getImportHandler(w http.ResponseWriter, r *http.Request) {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+
+	if id := r.FormValue("id"); id != "" {
+		job, ok := h.jobs[id]
+		if !ok {
+			util.WriteErrResponse(w, http.StatusNotFound, fmt.Errorf("import job %q not found", id))
+			return
+		}
+		util.WriteResponse(w, *job)
+		return
+	}
+
+	jobs := make([]ImportJob, 0, len(h.jobs))
+	for i := 1; i <= h.seq; i++ {
+		if job, ok := h.jobs[strconv.Itoa(i)]; ok {
+			jobs = append(jobs, *job)
+		}
+	}
+	util.WriteResponse(w, jobs)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGitImportHandler(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	inst := newTestInstanceWithProfileFromNode(node)
+	h := NewImportHandlers(inst, false)
+
+	w := httptest.NewRecorder()
+	h.GitImportHandler(w, httptest.NewRequest("POST", "/import/git?ref=me/counts", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected missing params to be a bad request, got status: %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.GitImportHandler(w, httptest.NewRequest("GET", "/import/git?id=1", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected unknown job to be not found, got status: %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.GitImportHandler(w, httptest.NewRequest("POST", "/import/git?repo_path=/not/a/git/repo&file_path=counts.csv&ref=me/counts", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got: %d. body: %s", w.Code, w.Body.String())
+	}
+	res := struct {
+		Data ImportJob `json:"data"`
+	}{}
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Data.ID == "" {
+		t.Fatal("expected response to include a job id")
+	}
+
+	// importing from a missing repository fails in the background
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w = httptest.NewRecorder()
+		h.GitImportHandler(w, httptest.NewRequest("GET", "/import/git?id="+res.Data.ID, nil))
+		if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Data.State != ImportJobRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for import job to finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if res.Data.State != ImportJobFailed || res.Data.Error == "" {
+		t.Errorf("expected job to fail with an error, got state: %s error: %q", res.Data.State, res.Data.Error)
+	}
+
+	ro := NewImportHandlers(inst, true)
+	w = httptest.NewRecorder()
+	ro.GitImportHandler(w, httptest.NewRequest("POST", "/import/git?repo_path=a&file_path=b.csv&ref=me/c", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected read-only server to forbid imports, got status: %d", w.Code)
+	}
+}
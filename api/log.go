@@ -1,14 +1,15 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	util "github.com/qri-io/apiutil"
-	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/lib"
 	"github.com/qri-io/qri/p2p"
-	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/remote"
 )
 
 // LogHandlers wraps a LogRequests with http.HandlerFuncs
@@ -23,6 +24,14 @@ func NewLogHandlers(n *p2p.QriNode) *LogHandlers {
 	return &h
 }
 
+// NewLogHandlersInstance allocates a LogHandlers pointer from an instance,
+// allowing history to be fetched from remotes
+func NewLogHandlersInstance(inst *lib.Instance) *LogHandlers {
+	req := lib.NewLogRequestsInstance(inst)
+	h := LogHandlers{*req}
+	return &h
+}
+
 // LogHandler is the endpoint for dataset logs
 func (h *LogHandlers) LogHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -49,22 +58,124 @@ func (h *LogHandlers) logHandler(w http.ResponseWriter, r *http.Request) {
 
 	lp := lib.ListParamsFromRequest(r)
 	lp.Peername = args.Peername
+	// limit & offset params take precedence over page & pageSize
+	if i, err := util.ReqParamInt("limit", r); err == nil && i > 0 {
+		lp.Limit = i
+	}
+	if i, err := util.ReqParamInt("offset", r); err == nil && i >= 0 {
+		lp.Offset = i
+	}
 
-	params := &lib.LogParams{
-		Ref:        args.String(),
-		ListParams: lp,
+	// history of datasets that aren't local can be fetched from a remote
+	lp.RemoteName = r.FormValue("remote")
+	params := &lib.LogPageParams{
+		LogParams: lib.LogParams{
+			Ref:         args.String(),
+			ListParams:  lp,
+			FetchRemote: lp.RemoteName != "" || r.FormValue("fetchRemote") == "true",
+		},
+		Summaries:    r.FormValue("summaries") == "true",
+		FetchMissing: r.FormValue("fetchMissing") == "true",
 	}
 
-	res := []dsref.VersionInfo{}
-	if err := h.Log(params, &res); err != nil {
-		if err == repo.ErrNoHistory {
-			util.WriteErrResponse(w, http.StatusUnprocessableEntity, err)
-			return
+	res := lib.LogPage{}
+	if err := h.LogPage(params, &res); err != nil {
+		status := http.StatusInternalServerError
+		if params.FetchRemote {
+			status = remote.ErrorCodeOf(err).HTTPStatus()
 		}
+		writeLibErrResponse(w, r, err, status)
+		return
+	}
+	writeLogPageResponse(w, r, res)
+}
+
+// writeLogPageResponse writes a page of history, adding the offset, limit &
+// total number of versions to pagination details
+func writeLogPageResponse(w http.ResponseWriter, r *http.Request, page lib.LogPage) {
+	pagination := map[string]interface{}{
+		"offset": page.Offset,
+		"limit":  page.Limit,
+		"total":  page.Total,
+	}
+	// a full page of unknown total may have more versions after it
+	more := page.Offset+page.Limit < page.Total
+	if page.Total < 0 {
+		more = len(page.Versions) == page.Limit
+	}
+	if more {
+		q := r.URL.Query()
+		q.Del("page")
+		q.Del("pageSize")
+		q.Set("offset", strconv.Itoa(page.Offset+page.Limit))
+		q.Set("limit", strconv.Itoa(page.Limit))
+		next := *r.URL
+		next.RawQuery = q.Encode()
+		pagination["nextUrl"] = next.String()
+	}
+
+	env := map[string]interface{}{
+		"meta": map[string]interface{}{
+			"code": http.StatusOK,
+		},
+		"data":       page.Versions,
+		"pagination": pagination,
+	}
+	res, err := json.Marshal(env)
+	if err != nil {
 		util.WriteErrResponse(w, http.StatusInternalServerError, err)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// TimelineHandler is the endpoint for dataset versions annotated with the
+// changes between each of them
+func (h *LogHandlers) TimelineHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.timelineHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *LogHandlers) timelineHandler(w http.ResponseWriter, r *http.Request) {
+	args, err := DatasetRefFromPath(r.URL.Path[len("/timeline"):])
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if args.Name == "" && args.Path == "" {
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("name of dataset or path needed"))
+		return
+	}
+
+	lp := lib.ListParamsFromRequest(r)
+	lp.Peername = args.Peername
+
+	lp.RemoteName = r.FormValue("remote")
+	params := &lib.HistoryParams{
+		Ref:         args.String(),
+		ListParams:  lp,
+		FetchRemote: lp.RemoteName != "" || r.FormValue("fetchRemote") == "true",
+	}
+
+	res := []lib.HistoryEntry{}
+	if err := h.History(params, &res); err != nil {
+		status := http.StatusInternalServerError
+		if params.FetchRemote {
+			status = remote.ErrorCodeOf(err).HTTPStatus()
+		}
+		writeLibErrResponse(w, r, err, status)
+		return
+	}
 	if err := util.WritePageResponse(w, res, r, params.Page()); err != nil {
-		log.Infof("error list dataset history response: %s", err.Error())
+		log.Infof("error list dataset timeline response: %s", err.Error())
 	}
 }
@@ -1,18 +1,22 @@
 package api
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/lib"
-	reporef "github.com/qri-io/qri/repo/ref"
 )
 
 func TestHistoryHandlers(t *testing.T) {
 	node, teardown := newTestNode(t)
 	defer teardown()
 
-	res := &reporef.DatasetRef{}
+	res := &lib.SaveResult{}
 	p := &lib.SaveParams{
 		Ref: "me/cities",
 		Dataset: &dataset.Dataset{
@@ -38,4 +42,58 @@ func TestHistoryHandlers(t *testing.T) {
 		// {"DELETE", "/", nil},
 	}
 	runHandlerTestCases(t, "log", h.LogHandler, logCases, true)
+
+	timelineCases := []handlerTestCase{
+		{"OPTIONS", "/", nil},
+	}
+	runHandlerTestCases(t, "timeline", h.TimelineHandler, timelineCases, true)
+}
+
+func TestHistoryHandlerPagination(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	for _, title := range []string{"first update", "second update"} {
+		p := &lib.SaveParams{
+			Ref:     "me/cities",
+			Dataset: &dataset.Dataset{Meta: &dataset.Meta{Title: title}},
+		}
+		if err := lib.NewDatasetRequests(node, nil).Save(p, &lib.SaveResult{}); err != nil {
+			t.Fatalf("error writing dataset update: %s", err.Error())
+		}
+	}
+
+	h := NewLogHandlers(node)
+	r := httptest.NewRequest("GET", "/history/me/cities?limit=1&offset=1&summaries=true", nil)
+	w := httptest.NewRecorder()
+	h.LogHandler(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	res := struct {
+		Data       []lib.LogItem
+		Pagination struct {
+			Offset, Limit, Total int
+			NextURL              string `json:"nextUrl"`
+		}
+	}{}
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Data) != 1 {
+		t.Fatalf("expected 1 version, got %d", len(res.Data))
+	}
+	if res.Data[0].Summary == nil {
+		t.Fatalf("expected version summary, got: %#v", res.Data[0])
+	}
+	if diff := cmp.Diff([]string{"meta"}, res.Data[0].Summary.Components); diff != "" {
+		t.Errorf("summary components mismatch (-want +got):\n%s", diff)
+	}
+	if res.Pagination.Offset != 1 || res.Pagination.Limit != 1 || res.Pagination.Total < 3 {
+		t.Errorf("unexpected pagination: %#v", res.Pagination)
+	}
+	if !strings.Contains(res.Pagination.NextURL, "offset=2") {
+		t.Errorf("expected next page to start at offset 2, got: %q", res.Pagination.NextURL)
+	}
 }
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	util "github.com/qri-io/apiutil"
+	"github.com/qri-io/qri/lib"
+)
+
+// PinHandlers wraps a requests struct to interface with http.HandlerFunc
+type PinHandlers struct {
+	lib.RepoMethods
+	readOnly bool
+}
+
+// NewPinHandlers allocates a PinHandlers pointer
+func NewPinHandlers(inst *lib.Instance, readOnly bool) *PinHandlers {
+	req := lib.NewRepoMethods(inst)
+	return &PinHandlers{*req, readOnly}
+}
+
+// PinsHandler is the endpoint for listing & unpinning versions of a dataset.
+// GET lists versions with their pin status, DELETE unpins the versions given
+// as path parameters. Read-only servers only list
+func (h *PinHandlers) PinsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.pinsHandler(w, r)
+	case "DELETE":
+		if h.readOnly {
+			readOnlyResponse(w, "/pins")
+			return
+		}
+		h.unpinHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *PinHandlers) pinsHandler(w http.ResponseWriter, r *http.Request) {
+	p := &lib.PinsParams{
+		Ref: HTTPPathToQriPath(r.URL.Path[len("/pins"):]),
+	}
+	res := &lib.PinsResult{}
+	if err := h.Pins(p, res); err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *PinHandlers) unpinHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	p := &lib.UnpinParams{
+		Ref:   HTTPPathToQriPath(r.URL.Path[len("/pins"):]),
+		Paths: r.Form["path"],
+	}
+	res := &lib.UnpinResult{}
+	if err := h.Unpin(p, res); err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+// PinPolicyHandler sets how many of the newest versions of a dataset stay
+// pinned, from the keep parameter. A keep of 0 removes the policy
+func (h *PinHandlers) PinPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	if h.readOnly {
+		readOnlyResponse(w, "/pin-policy")
+		return
+	}
+
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		h.pinPolicyHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *PinHandlers) pinPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	keep, err := strconv.Atoi(r.FormValue("keep"))
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("keep must be a number of versions"))
+		return
+	}
+	p := &lib.PinPolicyParams{
+		Ref:        HTTPPathToQriPath(r.URL.Path[len("/pin-policy"):]),
+		KeepPinned: keep,
+	}
+	res := &lib.UnpinResult{}
+	if err := h.SetPinPolicy(p, res); err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -8,6 +10,8 @@ import (
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/remote"
+	"github.com/qri-io/qri/repo"
 )
 
 // RemoteClientHandlers provides HTTP handlers for issuing requests to remotes
@@ -46,7 +50,7 @@ func (h *RemoteClientHandlers) NewFetchHandler(prefix string) http.HandlerFunc {
 		}
 		res := []dsref.VersionInfo{}
 		if err := h.Fetch(p, &res); err != nil {
-			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			writeLibErrResponse(w, r, err, http.StatusBadRequest)
 			return
 		}
 
@@ -71,6 +75,11 @@ func (h *RemoteClientHandlers) PublishHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	if refPath := r.URL.Path[len("/publish"):]; refPath == "" || refPath == "/" {
+		h.publishManyHandler(w, r)
+		return
+	}
+
 	ref, err := DatasetRefFromPath(r.URL.Path[len("/publish"):])
 	if err != nil {
 		util.WriteErrResponse(w, http.StatusBadRequest, err)
@@ -80,20 +89,21 @@ func (h *RemoteClientHandlers) PublishHandler(w http.ResponseWriter, r *http.Req
 	p := &lib.PublicationParams{
 		Ref:        ref.String(),
 		RemoteName: r.FormValue("remote"),
+		Queue:      r.FormValue("queue") == "true",
 	}
 
 	var res dsref.Ref
 	switch r.Method {
 	case "POST":
 		if err := h.Publish(p, &res); err != nil {
-			util.WriteErrResponse(w, http.StatusInternalServerError, err)
+			writeLibErrResponse(w, r, err, http.StatusInternalServerError)
 			return
 		}
 		util.WriteResponse(w, "ok")
 		return
 	case "DELETE":
 		if err := h.Unpublish(p, &res); err != nil {
-			util.WriteErrResponse(w, http.StatusInternalServerError, err)
+			writeLibErrResponse(w, r, err, remote.ErrorCodeOf(err).HTTPStatus())
 			return
 		}
 		util.WriteResponse(w, "ok")
@@ -103,6 +113,53 @@ func (h *RemoteClientHandlers) PublishHandler(w http.ResponseWriter, r *http.Req
 	}
 }
 
+// publishManyHandler publishes or unpublishes every reference in a JSON
+// array request body, responding with the outcome for each
+func (h *RemoteClientHandlers) publishManyHandler(w http.ResponseWriter, r *http.Request) {
+	p := &lib.BulkPublicationParams{
+		RemoteName: r.FormValue("remote"),
+		Queue:      r.FormValue("queue") == "true",
+	}
+	switch r.Method {
+	case "POST":
+	case "DELETE":
+		p.Unpublish = true
+	default:
+		util.NotFoundHandler(w, r)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&p.Refs); err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("body must be a json array of dataset references: %w", err))
+		return
+	}
+
+	res := []lib.BulkPublicationResult{}
+	if err := h.PublishMany(p, &res); err != nil {
+		writeLibErrResponse(w, r, err, http.StatusBadRequest)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+// PublishQueueHandler lists datasets waiting to be published in the
+// background
+func (h *RemoteClientHandlers) PublishQueueHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		res := []repo.PublishQueueItem{}
+		if err := h.PublishQueue(&lib.PublishQueueParams{}, &res); err != nil {
+			util.WriteErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		util.WriteResponse(w, res)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
 // FeedsHandler fetches an index of named feeds
 func (h *RemoteClientHandlers) FeedsHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -120,7 +177,34 @@ func (h *RemoteClientHandlers) feedsHandler(w http.ResponseWriter, r *http.Reque
 	remName := r.FormValue("remote")
 	if err := h.Feeds(&remName, &res); err != nil {
 		log.Infof("home error: %s", err.Error())
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		writeLibErrResponse(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteResponse(w, res)
+}
+
+// SyncStatusHandler compares local dataset histories with a remote. Without
+// a dataset reference in the path all published datasets are compared
+func (h *RemoteClientHandlers) SyncStatusHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.syncStatusHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *RemoteClientHandlers) syncStatusHandler(w http.ResponseWriter, r *http.Request) {
+	p := &lib.SyncStatusParams{
+		Ref:        strings.Trim(strings.TrimPrefix(r.URL.Path, "/sync-status"), "/"),
+		RemoteName: r.FormValue("remote"),
+	}
+	res := []remote.SyncStatus{}
+	if err := h.SyncStatus(p, &res); err != nil {
+		writeLibErrResponse(w, r, err, http.StatusBadRequest)
 		return
 	}
 
@@ -146,7 +230,7 @@ func (h *RemoteClientHandlers) previewHandler(w http.ResponseWriter, r *http.Req
 	}
 	res := &dataset.Dataset{}
 	if err := h.Preview(p, res); err != nil {
-		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		writeLibErrResponse(w, r, err, http.StatusBadRequest)
 		return
 	}
 
@@ -177,6 +261,11 @@ type RemoteHandlers struct {
 	DsyncHandler   http.HandlerFunc
 	RefsHandler    http.HandlerFunc
 	LogsyncHandler http.HandlerFunc
+	LogHandler     http.HandlerFunc
+	MetricsHandler http.HandlerFunc
+	// AccessHandler serves dataset access activity to the remote's admin.
+	// Requests require a write scope access token
+	AccessHandler http.HandlerFunc
 }
 
 // NewRemoteHandlers allocates a RemoteHandlers pointer
@@ -186,5 +275,8 @@ func NewRemoteHandlers(inst *lib.Instance) *RemoteHandlers {
 		DsyncHandler:   inst.Remote().DsyncHTTPHandler(),
 		RefsHandler:    inst.Remote().RefsHTTPHandler(),
 		LogsyncHandler: inst.Remote().LogsyncHTTPHandler(),
+		LogHandler:     inst.Remote().LogHTTPHandler(),
+		MetricsHandler: inst.Remote().MetricsHTTPHandler(),
+		AccessHandler:  requireWriteToken(inst.Config().API, inst.Remote().AccessLogHTTPHandler()),
 	}
 }
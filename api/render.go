@@ -47,6 +47,7 @@ func (h *RenderHandlers) RenderHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Old style viz component rendering
 	if r.FormValue("viz") == "true" {
+		p.VizName = r.FormValue("name")
 		data := []byte{}
 		if err := h.RenderViz(p, &data); err != nil {
 			apiutil.WriteErrResponse(w, http.StatusInternalServerError, err)
@@ -5,7 +5,6 @@ import (
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/lib"
-	reporef "github.com/qri-io/qri/repo/ref"
 )
 
 func TestRenderHandler(t *testing.T) {
@@ -42,7 +41,7 @@ func TestRenderReadmeHandler(t *testing.T) {
 		},
 		BodyPath: "testdata/cities/data.csv",
 	}
-	res := reporef.DatasetRef{}
+	res := lib.SaveResult{}
 	if err := dr.Save(&saveParams, &res); err != nil {
 		t.Fatal(err)
 	}
@@ -7,9 +7,7 @@ import (
 	util "github.com/qri-io/apiutil"
 	"github.com/qri-io/qri/base/dsfs/dsutil"
 	"github.com/qri-io/qri/config"
-	"github.com/qri-io/qri/fsi"
 	"github.com/qri-io/qri/lib"
-	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/profile"
 	reporef "github.com/qri-io/qri/repo/ref"
 )
@@ -60,15 +58,7 @@ func (mh *RootHandler) Handler(w http.ResponseWriter, r *http.Request) {
 	res := lib.GetResult{}
 	err := mh.dsh.Get(&p, &res)
 	if err != nil {
-		if err == repo.ErrNotFound {
-			util.NotFoundHandler(w, r)
-			return
-		}
-		if err == fsi.ErrNoLink {
-			util.WriteErrResponse(w, http.StatusUnprocessableEntity, err)
-			return
-		}
-		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		writeLibErrResponse(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -172,6 +172,48 @@ func (h UpdateHandlers) RunHandler(w http.ResponseWriter, r *http.Request) {
 	util.WriteResponse(w, res)
 }
 
+// RunningHandler lists updates that are currently executing
+func (h UpdateHandlers) RunningHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		in := false
+		res := []lib.RunningUpdate{}
+		if err := h.ListRunning(&in, &res); err != nil {
+			log.Errorf("listing running updates: %s", err)
+			util.WriteErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		util.WriteResponse(w, res)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// CancelHandler stops a running update by the id given as a parameter
+func (h UpdateHandlers) CancelHandler(w http.ResponseWriter, r *http.Request) {
+	if h.ReadOnly {
+		util.NotFoundHandler(w, r)
+		return
+	}
+
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		id := r.FormValue("id")
+		res := false
+		if err := h.CancelUpdate(&id, &res); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		util.WriteResponse(w, res)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
 // ServiceHandler configures & reports on the update daemon
 func (h UpdateHandlers) ServiceHandler(w http.ResponseWriter, r *http.Request) {
 	if h.ReadOnly {
@@ -9,11 +9,12 @@ import (
 	"time"
 
 	"github.com/qri-io/qri/base/component"
+	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/event"
 	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/watchfs"
 	"nhooyr.io/websocket"
-	"nhooyr.io/websocket/wsjson"
 )
 
 const (
@@ -46,8 +47,8 @@ func (s Server) ServeWebsocket(ctx context.Context) {
 		defer l.Close()
 
 		// Collect all websocket connections. Should only be one at a time, but that may
-		// change in the future.
-		connections := []*websocket.Conn{}
+		// change in the future. Upgraded connections outlive the request, so only
+		// the handshake has a timeout. Connections are kept alive with pings
 		srv := &http.Server{
 			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				c, err := websocket.Accept(w, r, &websocket.AcceptOptions{
@@ -58,10 +59,15 @@ func (s Server) ServeWebsocket(ctx context.Context) {
 					log.Debugf("Websocket accept error: %s", err)
 					return
 				}
-				connections = append(connections, c)
+				scope, err := authorizeWebsocket(ctx, s.Config().API, r, c)
+				if err != nil {
+					log.Debugf("Websocket %s unauthorized: %s", r.RemoteAddr, err)
+					c.Close(websocketStatusUnauthorized, "unauthorized")
+					return
+				}
+				s.wsConns.add(ctx, c, r.RemoteAddr, scope)
 			}),
-			ReadTimeout:  time.Second * 15,
-			WriteTimeout: time.Second * 15,
+			ReadHeaderTimeout: time.Second * 15,
 		}
 		defer srv.Close()
 
@@ -69,9 +75,11 @@ func (s Server) ServeWebsocket(ctx context.Context) {
 		// TODO(dlong): A good example of tight coupling causing an issue: The Websocket
 		// implementation doesn't need to know about these events, but the FilesystemWatcher
 		// does. Ideally, this Subscribe call would happen along with the latter, not the former.
-		busEvents := s.Instance.Bus().Subscribe(event.ETFSICreateLinkEvent)
+		busEvents := s.Instance.Bus().Subscribe(event.ETFSICreateLinkEvent, event.ETPublishQueueEvent, event.ETAutoPublishEvent, event.ETTransformEvent, event.ETUpdateAvailableEvent)
 
 		known := component.GetKnownFilenames()
+		actions := s.newWatchActionRunner(node)
+		defer actions.Stop()
 
 		// Filesystem events are forwarded to the websocket. In the future, this may be
 		// expanded to handle other types of events, such as SaveDatasetProgressEvent,
@@ -88,15 +96,19 @@ func (s Server) ServeWebsocket(ctx context.Context) {
 							Dsname:   fce.Dsname,
 						})
 					}
+					// publish queue changes, auto publish outcomes & transform
+					// output are forwarded to connections with write scope
+					if e.Topic == event.ETPublishQueueEvent || e.Topic == event.ETAutoPublishEvent || e.Topic == event.ETTransformEvent {
+						s.wsConns.broadcast(ctx, config.APIScopeWrite, e)
+					}
+					if e.Topic == event.ETUpdateAvailableEvent {
+						s.wsConns.broadcast(ctx, config.APIScopeRead, e)
+					}
 				case fse := <-fsmessages:
 					if s.filterEvent(fse, known) {
 						log.Debugf("filesys event: %s\n", fse)
-						for k, c := range connections {
-							err = wsjson.Write(ctx, c, fse)
-							if err != nil {
-								log.Errorf("connection %d: wsjson write error: %s", k, err)
-							}
-						}
+						actions.Handle(fse)
+						s.wsConns.broadcast(ctx, config.APIScopeRead, fse)
 					}
 				}
 			}
@@ -136,6 +148,33 @@ func (s Server) startFilesysWatcher(ctx context.Context, node *p2p.QriNode) (cha
 	return fsmessages, nil
 }
 
+// newWatchActionRunner resolves the dataset references of configured watch
+// actions & auto publish rules. Actions with references that can't be resolved
+// are skipped
+func (s Server) newWatchActionRunner(node *p2p.QriNode) *watchfs.ActionRunner {
+	var actions []watchfs.Action
+	for _, wa := range s.Config().API.WatchActions {
+		ref, err := repo.ParseDatasetRef(wa.Ref)
+		if err != nil {
+			log.Errorf("watch action %q: %s", wa.Ref, err)
+			continue
+		}
+		if err := repo.CanonicalizeDatasetRef(node.Repo, &ref); err != nil && err != repo.ErrNoHistory {
+			log.Errorf("watch action %q: %s", wa.Ref, err)
+			continue
+		}
+		actions = append(actions, watchfs.Action{
+			Username: ref.Peername,
+			Dsname:   ref.Name,
+			Command:  wa.Command,
+			URL:      wa.URL,
+			Debounce: time.Duration(wa.DebounceMs) * time.Millisecond,
+		})
+	}
+	actions = append(actions, s.Instance.AutoPublishActions()...)
+	return watchfs.NewActionRunner(actions)
+}
+
 func (s Server) filterEvent(event watchfs.FilesysEvent, knownFilenames map[string][]string) bool {
 	return component.IsKnownFilename(event.Source, knownFilenames)
 }
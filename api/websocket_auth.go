@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/qri-io/qri/config"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+const (
+	// websocketStatusUnauthorized is the close code sent to websocket
+	// connections that don't present a valid access token. Codes 4000-4999 are
+	// reserved for applications
+	websocketStatusUnauthorized = websocket.StatusCode(4001)
+	// websocketAuthTimeout limits how long a connection has to send its access
+	// token as a first message
+	websocketAuthTimeout = time.Second * 10
+)
+
+// websocketAuthMessage is the first message a websocket client sends to
+// authenticate when it doesn't pass a token query param
+type websocketAuthMessage struct {
+	Token string `json:"token"`
+}
+
+// authorizeWebsocket determines the access scope of a new websocket
+// connection. Clients present an access token as a "token" query param, or as
+// the first message sent after connecting. Access tokens are opt-in: when none
+// are configured every connection gets write scope, which keeps the behaviour
+// of websockets from before tokens existed
+func authorizeWebsocket(ctx context.Context, cfg *config.API, r *http.Request, c *websocket.Conn) (string, error) {
+	if len(cfg.AccessTokens) == 0 {
+		return config.APIScopeWrite, nil
+	}
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		readCtx, cancel := context.WithTimeout(ctx, websocketAuthTimeout)
+		defer cancel()
+		msg := websocketAuthMessage{}
+		if err := wsjson.Read(readCtx, c, &msg); err != nil {
+			return "", fmt.Errorf("reading access token: %s", err)
+		}
+		token = msg.Token
+	}
+
+	scope, ok := cfg.TokenScope(token)
+	if !ok {
+		return "", fmt.Errorf("invalid access token")
+	}
+	return scope, nil
+}
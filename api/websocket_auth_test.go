@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/config"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+func TestAuthorizeWebsocket(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := config.DefaultAPI()
+	scopes := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, &websocket.AcceptOptions{InsecureSkipVerify: true})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		scope, err := authorizeWebsocket(ctx, cfg, r, c)
+		if err != nil {
+			c.Close(websocketStatusUnauthorized, "unauthorized")
+			scopes <- ""
+			return
+		}
+		c.Close(websocket.StatusNormalClosure, "")
+		scopes <- scope
+	}))
+	defer srv.Close()
+	addr := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	connect := func(query string, first interface{}) (string, websocket.StatusCode) {
+		c, _, err := websocket.Dial(ctx, addr+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close(websocket.StatusNormalClosure, "")
+		if first != nil {
+			if err := wsjson.Write(ctx, c, first); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, _, err = c.Read(ctx)
+		var ce websocket.CloseError
+		if !errors.As(err, &ce) {
+			t.Fatalf("expected the connection to be closed, got: %v", err)
+		}
+		select {
+		case scope := <-scopes:
+			return scope, ce.Code
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for authorization")
+		}
+		return "", 0
+	}
+
+	// connections are trusted without configured tokens
+	if scope, _ := connect("", nil); scope != config.APIScopeWrite {
+		t.Errorf("expected local connection to have write scope, got: %q", scope)
+	}
+	remote := httptest.NewRequest("GET", "/", nil)
+	remote.RemoteAddr = "203.0.113.7:4321"
+	if scope, err := authorizeWebsocket(ctx, cfg, remote, nil); err != nil || scope != config.APIScopeWrite {
+		t.Errorf("expected non-local connection to have write scope without configured tokens, got: %q, %v", scope, err)
+	}
+
+	cfg.AccessTokens = []config.APIAccessToken{
+		{Token: "reader", Scope: config.APIScopeRead},
+		{Token: "writer", Scope: config.APIScopeWrite},
+	}
+	if scope, _ := connect("?token=reader", nil); scope != config.APIScopeRead {
+		t.Errorf("expected query param token to grant read scope, got: %q", scope)
+	}
+	if scope, _ := connect("", websocketAuthMessage{Token: "writer"}); scope != config.APIScopeWrite {
+		t.Errorf("expected first message token to grant write scope, got: %q", scope)
+	}
+	if scope, code := connect("?token=nope", nil); scope != "" || code != websocketStatusUnauthorized {
+		t.Errorf("expected invalid token to be closed as unauthorized, got scope %q, code %d", scope, code)
+	}
+	if scope, code := connect("", websocketAuthMessage{}); scope != "" || code != websocketStatusUnauthorized {
+		t.Errorf("expected missing token to be closed as unauthorized, got scope %q, code %d", scope, code)
+	}
+}
+
+func TestScopeAllows(t *testing.T) {
+	cases := []struct {
+		have, need string
+		expect     bool
+	}{
+		{config.APIScopeRead, config.APIScopeRead, true},
+		{config.APIScopeRead, config.APIScopeWrite, false},
+		{config.APIScopeWrite, config.APIScopeRead, true},
+		{config.APIScopeWrite, config.APIScopeWrite, true},
+	}
+	for _, c := range cases {
+		if got := scopeAllows(c.have, c.need); got != c.expect {
+			t.Errorf("scopeAllows(%q, %q) expected %t, got %t", c.have, c.need, c.expect, got)
+		}
+	}
+}
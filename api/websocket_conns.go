@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/qri-io/qri/config"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+const (
+	// websocketPingInterval is how often open websocket connections are pinged
+	websocketPingInterval = time.Second * 30
+	// websocketWriteTimeout limits how long writing one message can take
+	websocketWriteTimeout = time.Second * 15
+)
+
+// websocketConns tracks open websocket connections, keeping them alive with
+// pings. Connections that don't answer a ping within two ping intervals are
+// dropped, idle connections that do answer stay open indefinitely
+type websocketConns struct {
+	pingInterval time.Duration
+
+	lk     sync.Mutex
+	nextID int
+	conns  map[int]*websocketConn
+}
+
+func newWebsocketConns() *websocketConns {
+	return &websocketConns{pingInterval: websocketPingInterval}
+}
+
+// websocketConn is an open connection & a record of when it was last active
+type websocketConn struct {
+	conn      *websocket.Conn
+	addr      string
+	scope     string
+	connected time.Time
+
+	lk           sync.Mutex
+	lastActivity time.Time
+}
+
+func (wc *websocketConn) touch() {
+	wc.lk.Lock()
+	defer wc.lk.Unlock()
+	wc.lastActivity = time.Now()
+}
+
+// websocketConnStatus describes an open websocket connection for debugging
+type websocketConnStatus struct {
+	Addr         string    `json:"addr"`
+	Scope        string    `json:"scope"`
+	Connected    time.Time `json:"connected"`
+	LastActivity time.Time `json:"lastActivity"`
+}
+
+// add starts tracking & pinging a connection authorized with an access scope.
+// The connection is read from to receive pongs, incoming messages are
+// discarded. It stops being tracked when it closes or ctx is cancelled
+func (c *websocketConns) add(ctx context.Context, conn *websocket.Conn, addr, scope string) {
+	now := time.Now()
+	wc := &websocketConn{conn: conn, addr: addr, scope: scope, connected: now, lastActivity: now}
+
+	c.lk.Lock()
+	if c.conns == nil {
+		c.conns = map[int]*websocketConn{}
+	}
+	id := c.nextID
+	c.nextID++
+	c.conns[id] = wc
+	c.lk.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := conn.Read(ctx); err != nil {
+				log.Debugf("websocket %s closed: %s", addr, err)
+				break
+			}
+			wc.touch()
+		}
+		c.remove(id)
+		conn.Close(websocket.StatusNormalClosure, "")
+	}()
+
+	go func() {
+		t := time.NewTicker(c.pingInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				pingCtx, cancel := context.WithTimeout(ctx, c.pingInterval*2)
+				err := conn.Ping(pingCtx)
+				cancel()
+				if err != nil {
+					log.Debugf("websocket %s missed pongs, dropping: %s", addr, err)
+					conn.Close(websocket.StatusGoingAway, "missed pongs")
+					return
+				}
+				wc.touch()
+			}
+		}
+	}()
+}
+
+func (c *websocketConns) remove(id int) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	delete(c.conns, id)
+}
+
+// broadcast writes a value as JSON to every open connection with a scope that
+// allows it
+func (c *websocketConns) broadcast(ctx context.Context, scope string, v interface{}) {
+	c.lk.Lock()
+	conns := make([]*websocketConn, 0, len(c.conns))
+	for _, wc := range c.conns {
+		if scopeAllows(wc.scope, scope) {
+			conns = append(conns, wc)
+		}
+	}
+	c.lk.Unlock()
+
+	for _, wc := range conns {
+		writeCtx, cancel := context.WithTimeout(ctx, websocketWriteTimeout)
+		err := wsjson.Write(writeCtx, wc.conn, v)
+		cancel()
+		if err != nil {
+			log.Errorf("websocket %s: wsjson write error: %s", wc.addr, err)
+			continue
+		}
+		wc.touch()
+	}
+}
+
+// list describes open connections, oldest first
+func (c *websocketConns) list() []websocketConnStatus {
+	list := []websocketConnStatus{}
+	if c == nil {
+		return list
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	ids := make([]int, 0, len(c.conns))
+	for id := range c.conns {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		wc := c.conns[id]
+		wc.lk.Lock()
+		list = append(list, websocketConnStatus{
+			Addr:         wc.addr,
+			Scope:        wc.scope,
+			Connected:    wc.connected,
+			LastActivity: wc.lastActivity,
+		})
+		wc.lk.Unlock()
+	}
+	return list
+}
+
+// scopeAllows reports if a connection with scope have may receive messages
+// that require scope need. Write scope includes read scope
+func scopeAllows(have, need string) bool {
+	return have == need || have == config.APIScopeWrite
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/config"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+func TestWebsocketConnsKeepAlive(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conns := &websocketConns{pingInterval: time.Millisecond * 20}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, &websocket.AcceptOptions{InsecureSkipVerify: true})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conns.add(ctx, c, r.RemoteAddr, config.APIScopeWrite)
+	}))
+	defer srv.Close()
+	addr := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	// a client that reads answers pings & stays connected while idle
+	alive, _, err := websocket.Dial(ctx, addr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer alive.Close(websocket.StatusNormalClosure, "")
+	msgs := make(chan map[string]string, 1)
+	go func() {
+		v := map[string]string{}
+		if err := wsjson.Read(ctx, alive, &v); err == nil {
+			msgs <- v
+		}
+		// keep reading to answer pings
+		alive.Read(ctx)
+	}()
+
+	// a client that never reads doesn't answer pings
+	silent, _, err := websocket.Dial(ctx, addr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close(websocket.StatusNormalClosure, "")
+
+	waitFor := func(n int) []websocketConnStatus {
+		deadline := time.Now().Add(time.Second * 5)
+		for {
+			list := conns.list()
+			if len(list) == n || time.Now().After(deadline) {
+				return list
+			}
+			time.Sleep(time.Millisecond * 5)
+		}
+	}
+	if list := waitFor(2); len(list) != 2 {
+		t.Fatalf("expected 2 connections, got: %d", len(list))
+	}
+
+	list := waitFor(1)
+	if len(list) != 1 {
+		t.Fatalf("expected the silent connection to be dropped, got: %d connections", len(list))
+	}
+	if !list[0].LastActivity.After(list[0].Connected) {
+		t.Errorf("expected answered pings to update last activity, got: %#v", list[0])
+	}
+
+	conns.broadcast(ctx, config.APIScopeRead, map[string]string{"hello": "world"})
+	select {
+	case v := <-msgs:
+		if v["hello"] != "world" {
+			t.Errorf("unexpected broadcast message: %v", v)
+		}
+	case <-time.After(time.Second * 5):
+		t.Error("timed out waiting for broadcast message")
+	}
+
+	// connections are removed when they close
+	alive.Close(websocket.StatusNormalClosure, "")
+	if list := waitFor(0); len(list) != 0 {
+		t.Errorf("expected closed connection to be removed, got: %d", len(list))
+	}
+}
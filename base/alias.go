@@ -0,0 +1,79 @@
+package base
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// ErrNoAliases indicates a repo doesn't keep dataset name aliases
+var ErrNoAliases = fmt.Errorf("repo doesn't keep aliases")
+
+// AliasesOf returns the aliases of a repo, or nil if the repo doesn't keep any
+func AliasesOf(r repo.Repo) *repo.Aliases {
+	if a, ok := r.(repo.Aliaser); ok {
+		return a.Aliases()
+	}
+	return nil
+}
+
+// AddAlias creates an alternate name for a dataset in the repo owner's
+// namespace. Aliases can't shadow a dataset name or replace another alias.
+// Aliases of aliases resolve to the dataset, so the alias always names a
+// dataset directly
+func AddAlias(r repo.Repo, name string, target reporef.DatasetRef) (repo.Alias, error) {
+	aliases := AliasesOf(r)
+	if aliases == nil {
+		return repo.Alias{}, ErrNoAliases
+	}
+	if !dsref.IsValidName(name) {
+		return repo.Alias{}, dsref.ErrDescribeValidName
+	}
+
+	pro, err := r.Profile()
+	if err != nil {
+		return repo.Alias{}, err
+	}
+	if _, ok := aliases.Get(name); ok {
+		return repo.Alias{}, fmt.Errorf("alias '%s/%s' %w", pro.Peername, name, repo.ErrAlreadyExists)
+	}
+	if _, err := r.GetRef(reporef.DatasetRef{Peername: pro.Peername, Name: name}); err == nil {
+		return repo.Alias{}, fmt.Errorf("dataset '%s/%s' %w, an alias can't shadow it", pro.Peername, name, repo.ErrAlreadyExists)
+	}
+
+	if err := repo.CanonicalizeDatasetRef(r, &target); err != nil && err != repo.ErrNoHistory {
+		return repo.Alias{}, err
+	}
+	if target.Peername != pro.Peername {
+		return repo.Alias{}, fmt.Errorf("can only alias datasets in your own namespace")
+	}
+
+	a := repo.Alias{Name: name, Target: target.Name}
+	return a, aliases.Put(a.Name, a.Target)
+}
+
+// RemoveAlias deletes an alias from the repo owner's namespace, leaving the
+// dataset it resolves to untouched
+func RemoveAlias(r repo.Repo, name string) error {
+	aliases := AliasesOf(r)
+	if aliases == nil {
+		return ErrNoAliases
+	}
+	if err := aliases.Delete(name); err == repo.ErrNotFound {
+		return fmt.Errorf("no alias named %q", name)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RejectAlias errors if ref names a dataset by an alias. Aliases only exist in
+// the local repo, publishing or pushing by one must use the dataset's name
+func RejectAlias(r repo.Repo, ref reporef.DatasetRef) error {
+	if target, ok := repo.AliasTarget(r, ref.Peername, ref.Name); ok {
+		return fmt.Errorf("%w, use %s/%s instead", repo.ErrAliasNotPublishable, ref.Peername, target)
+	}
+	return nil
+}
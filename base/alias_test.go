@@ -0,0 +1,55 @@
+package base
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestAddAlias(t *testing.T) {
+	r := newTestRepo(t)
+	ref := addCitiesDataset(t, r)
+
+	a, err := AddAlias(r, "towns", reporef.DatasetRef{Peername: "me", Name: ref.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Target != ref.Name {
+		t.Errorf("expected alias target %q, got: %q", ref.Name, a.Target)
+	}
+
+	// aliases of aliases resolve to the dataset
+	a, err = AddAlias(r, "burgs", reporef.DatasetRef{Peername: "me", Name: "towns"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Target != ref.Name {
+		t.Errorf("expected alias of an alias to target %q, got: %q", ref.Name, a.Target)
+	}
+
+	if _, err := AddAlias(r, "towns", reporef.DatasetRef{Peername: "me", Name: ref.Name}); !errors.Is(err, repo.ErrAlreadyExists) {
+		t.Errorf("expected replacing an alias to fail, got: %v", err)
+	}
+	if _, err := AddAlias(r, ref.Name, reporef.DatasetRef{Peername: "me", Name: ref.Name}); !errors.Is(err, repo.ErrAlreadyExists) {
+		t.Errorf("expected shadowing a dataset to fail, got: %v", err)
+	}
+	if _, err := AddAlias(r, "nope", reporef.DatasetRef{Peername: "me", Name: "missing"}); err == nil {
+		t.Error("expected aliasing a missing dataset to fail")
+	}
+
+	if err := RejectAlias(r, reporef.DatasetRef{Peername: ref.Peername, Name: "towns"}); !errors.Is(err, repo.ErrAliasNotPublishable) {
+		t.Errorf("expected alias to be rejected, got: %v", err)
+	}
+	if err := RejectAlias(r, reporef.DatasetRef{Peername: ref.Peername, Name: ref.Name}); err != nil {
+		t.Errorf("expected dataset name to be accepted, got: %s", err)
+	}
+
+	if err := RemoveAlias(r, "towns"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveAlias(r, "towns"); err == nil {
+		t.Error("expected removing a missing alias to fail")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -16,10 +17,12 @@ import (
 	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/qfs"
 	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
 )
 
-// ReadBody grabs some or all of a dataset's body, writing an output in the desired format
-func ReadBody(ds *dataset.Dataset, format dataset.DataFormat, fcfg dataset.FormatConfig, limit, offset int, all bool) (data []byte, err error) {
+// ReadBody grabs some or all of a dataset's body, writing an output in the desired format.
+// Non-empty columns projects each row to the named columns
+func ReadBody(ds *dataset.Dataset, format dataset.DataFormat, fcfg dataset.FormatConfig, columns []string, limit, offset int, all bool) (data []byte, err error) {
 	if ds == nil {
 		return nil, fmt.Errorf("can't load body from a nil dataset")
 	}
@@ -30,17 +33,8 @@ func ReadBody(ds *dataset.Dataset, format dataset.DataFormat, fcfg dataset.Forma
 		return
 	}
 
-	st := &dataset.Structure{}
-	assign := &dataset.Structure{
-		Format: format.String(),
-		Schema: ds.Structure.Schema,
-	}
-	if fcfg != nil {
-		assign.FormatConfig = fcfg.Map()
-	}
-	st.Assign(ds.Structure, assign)
-
-	data, err = ConvertBodyFile(file, ds.Structure, st, limit, offset, all)
+	st := ReadStructure(ds.Structure, format, fcfg)
+	data, err = ConvertBodyFile(file, ds.Structure, st, columns, limit, offset, all)
 	if err != nil {
 		log.Debug(err.Error())
 		return nil, err
@@ -49,6 +43,70 @@ func ReadBody(ds *dataset.Dataset, format dataset.DataFormat, fcfg dataset.Forma
 	return data, nil
 }
 
+// OutputFormatKey is the meta field a dataset declares the format its body
+// is preferably read in with
+const OutputFormatKey = "outputFormat"
+
+// OutputFormat gives the format a dataset prefers its body is read in, or
+// UnknownDataFormat if it doesn't declare a valid one
+func OutputFormat(ds *dataset.Dataset) dataset.DataFormat {
+	if ds == nil || ds.Meta == nil {
+		return dataset.UnknownDataFormat
+	}
+	str, ok := ds.Meta.Meta()[OutputFormatKey].(string)
+	if !ok {
+		return dataset.UnknownDataFormat
+	}
+	df, err := dataset.ParseDataFormatString(str)
+	if err != nil {
+		return dataset.UnknownDataFormat
+	}
+	return df
+}
+
+// ReadStructure returns the structure to read a body stored with structure
+// st as format. An unknown format reads the body as stored. Format options are
+// specific to a format, so stored options aren't carried into a conversion,
+// instead CSV output gets a header row when the schema names its columns.
+// fcfg overrides format options either way
+func ReadStructure(st *dataset.Structure, format dataset.DataFormat, fcfg dataset.FormatConfig) *dataset.Structure {
+	out := &dataset.Structure{}
+	out.Assign(st)
+	if format != dataset.UnknownDataFormat && format.String() != st.Format {
+		out.Format = format.String()
+		out.FormatConfig = nil
+		if format == dataset.CSVDataFormat && hasColumnTitles(st.Schema) {
+			out.FormatConfig = map[string]interface{}{"headerRow": true}
+		}
+	}
+	if fcfg != nil {
+		out.FormatConfig = fcfg.Map()
+	}
+	return out
+}
+
+// hasColumnTitles reports whether a tabular schema titles every column
+func hasColumnTitles(schema map[string]interface{}) bool {
+	items, ok := schema["items"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	cols, ok := items["items"].([]interface{})
+	if !ok || len(cols) == 0 {
+		return false
+	}
+	for _, col := range cols {
+		c, ok := col.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if title, ok := c["title"].(string); !ok || title == "" {
+			return false
+		}
+	}
+	return true
+}
+
 // ReadEntries reads entries and returns them as a native go array or map
 func ReadEntries(reader dsio.EntryReader) (interface{}, error) {
 	obj := make(map[string]interface{})
@@ -105,7 +163,7 @@ func InlineJSONBody(ds *dataset.Dataset) error {
 		Schema: in.Schema,
 	})
 
-	data, err := ConvertBodyFile(file, in, st, 0, 0, true)
+	data, err := ConvertBodyFile(file, in, st, nil, 0, 0, true)
 	if err != nil {
 		log.Errorf("converting body file to JSON: %s", err)
 		return fmt.Errorf("converting body file to JSON: %s", err)
@@ -116,11 +174,27 @@ func InlineJSONBody(ds *dataset.Dataset) error {
 }
 
 // ConvertBodyFile takes an input file & structure, and converts a specified selection
-// to the structure specified by out
-func ConvertBodyFile(file qfs.File, in, out *dataset.Structure, limit, offset int, all bool) (data []byte, err error) {
+// to the structure specified by out. Compressed input files are decompressed. Non-empty
+// columns projects rows to the named columns before paging, out is written with a schema
+// for the projected columns
+func ConvertBodyFile(file qfs.File, in, out *dataset.Structure, columns []string, limit, offset int, all bool) (data []byte, err error) {
+	proj, err := NewColumnProjection(in, columns)
+	if err != nil {
+		return
+	}
+	out = proj.Structure(out)
+
+	if file, err = dsfs.DecompressBody(file, nil); err != nil {
+		return
+	}
 	buf := &bytes.Buffer{}
 
-	w, err := dsio.NewEntryWriter(out, buf)
+	var w dsio.EntryWriter
+	if out.Format == ParquetFormat {
+		w, err = NewParquetEntryWriter(out, buf)
+	} else {
+		w, err = dsio.NewEntryWriter(out, buf)
+	}
 	if err != nil {
 		return
 	}
@@ -141,6 +215,7 @@ func ConvertBodyFile(file qfs.File, in, out *dataset.Structure, limit, offset in
 		err = fmt.Errorf("error allocating data reader: %s", err)
 		return
 	}
+	rr = proj.Reader(rr)
 
 	if !all {
 		rr = &dsio.PagedReader{
@@ -149,7 +224,9 @@ func ConvertBodyFile(file qfs.File, in, out *dataset.Structure, limit, offset in
 			Offset: offset,
 		}
 	}
-	err = dsio.Copy(rr, w)
+	if err = dsio.Copy(rr, w); err != nil {
+		return nil, err
+	}
 
 	if err := w.Close(); err != nil {
 		return nil, fmt.Errorf("error closing row buffer: %s", err.Error())
@@ -158,6 +235,36 @@ func ConvertBodyFile(file qfs.File, in, out *dataset.Structure, limit, offset in
 	return buf.Bytes(), nil
 }
 
+// CountEntries reads an opened dataset's body, counting the entries it holds
+func CountEntries(ds *dataset.Dataset) (int, error) {
+	file := ds.BodyFile()
+	if file == nil {
+		return 0, fmt.Errorf("no body file to read")
+	}
+	if ds.Structure == nil {
+		return 0, fmt.Errorf("dataset is missing structure")
+	}
+	file, err := dsfs.DecompressBody(file, nil)
+	if err != nil {
+		return 0, err
+	}
+	rr, err := dsio.NewEntryReader(ds.Structure, file)
+	if err != nil {
+		return 0, fmt.Errorf("error allocating data reader: %s", err)
+	}
+
+	entries := 0
+	for {
+		if _, err := rr.ReadEntry(); err != nil {
+			if err == io.EOF {
+				return entries, nil
+			}
+			return 0, fmt.Errorf("error reading entry %d: %s", entries, err)
+		}
+		entries++
+	}
+}
+
 // DatasetBodyFile creates a streaming data file from a Dataset using the following precedence:
 // * ds.BodyBytes not being nil (requires ds.Structure.Format be set to know data format)
 // * ds.BodyPath being a url
@@ -194,7 +301,7 @@ func DatasetBodyFile(ctx context.Context, store cafs.Filestore, ds *dataset.Data
 	}
 
 	if strings.HasPrefix(ds.BodyPath, "/ipfs") || strings.HasPrefix(ds.BodyPath, "/cafs") || strings.HasPrefix(ds.BodyPath, "/map") {
-		return store.Get(ctx, ds.BodyPath)
+		return dsfs.LoadBody(ctx, store, ds)
 	}
 
 	// convert yaml input to json as a hack to support yaml input for now
@@ -221,30 +328,56 @@ func DatasetBodyFile(ctx context.Context, store cafs.Filestore, ds *dataset.Data
 	return qfs.NewMemfileReader(filepath.Base(ds.BodyPath), file), nil
 }
 
+// SupportedBodyFormats lists the formats a body can be stored in, and
+// converted between with ConvertBodyFormat
+func SupportedBodyFormats() []string {
+	formats := dataset.SupportedDataFormats()
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = f.String()
+	}
+	return names
+}
+
 // ConvertBodyFormat rewrites a body from a source format to a destination format.
+// Compressed bodies are decompressed, the result is never compressed. A warning
+// is returned describing any change of format
 // TODO (b5): Combine this with ConvertBodyFile, update callers.
-func ConvertBodyFormat(bodyFile qfs.File, fromSt, toSt *dataset.Structure) (qfs.File, error) {
+func ConvertBodyFormat(bodyFile qfs.File, fromSt, toSt *dataset.Structure) (qfs.File, []Warning, error) {
+	bodyFile, err := dsfs.DecompressBody(bodyFile, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Reader for entries of the source body.
 	r, err := dsio.NewEntryReader(fromSt, bodyFile)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Writes entries to a new body.
 	buffer := &bytes.Buffer{}
 	w, err := dsio.NewEntryWriter(toSt, buffer)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	err = dsio.Copy(r, w)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	err = w.Close()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return qfs.NewMemfileReader(fmt.Sprintf("body.%s", toSt.Format), buffer), nil
+	var warnings []Warning
+	if fromSt.Format != toSt.Format {
+		warnings = append(warnings, Warning{
+			Code:      WarnConvertedFormat,
+			Message:   fmt.Sprintf("converted body from %s to %s", fromSt.Format, toSt.Format),
+			Component: "body",
+		})
+	}
+	return qfs.NewMemfileReader(fmt.Sprintf("body.%s", toSt.Format), buffer), warnings, nil
 }
@@ -0,0 +1,174 @@
+package base
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// ColumnProjection narrows the rows of a body to a set of named columns.
+// Columns are named by the titles of a tabular schema's columns, or the
+// properties of a schema for rows that are objects
+type ColumnProjection struct {
+	columns []string
+	// indices of projected columns in array rows, nil for object rows
+	indices []int
+}
+
+// NewColumnProjection validates columns against the schema of structure st,
+// erroring with every column st doesn't have. Projected rows keep columns in
+// the order they're given. No columns returns a nil projection, which doesn't
+// change anything
+func NewColumnProjection(st *dataset.Structure, columns []string) (*ColumnProjection, error) {
+	if len(columns) == 0 {
+		return nil, nil
+	}
+	if st == nil {
+		return nil, fmt.Errorf("projecting columns requires a structure")
+	}
+	items, _ := st.Schema["items"].(map[string]interface{})
+
+	if cols, ok := items["items"].([]interface{}); ok {
+		index := map[string]int{}
+		for i, col := range cols {
+			if c, ok := col.(map[string]interface{}); ok {
+				if title, ok := c["title"].(string); ok && title != "" {
+					index[title] = i
+				}
+			}
+		}
+		p := &ColumnProjection{columns: columns, indices: make([]int, len(columns))}
+		var unknown []string
+		for i, name := range columns {
+			idx, ok := index[name]
+			if !ok {
+				unknown = append(unknown, name)
+			}
+			p.indices[i] = idx
+		}
+		if len(unknown) > 0 {
+			return nil, UnknownColumnsError(unknown)
+		}
+		return p, nil
+	}
+
+	if props, ok := items["properties"].(map[string]interface{}); ok {
+		var unknown []string
+		for _, name := range columns {
+			if _, ok := props[name]; !ok {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			return nil, UnknownColumnsError(unknown)
+		}
+		return &ColumnProjection{columns: columns}, nil
+	}
+
+	return nil, fmt.Errorf("projecting columns requires a schema that describes the columns of each row")
+}
+
+// UnknownColumnsError lists columns a projection names that a schema doesn't
+// have
+type UnknownColumnsError []string
+
+// Error implements the error interface
+func (e UnknownColumnsError) Error() string {
+	return fmt.Sprintf("unknown columns: %s", strings.Join(e, ", "))
+}
+
+// Structure returns a copy of st with a schema describing projected rows,
+// writing with the projected structure gives csv headers for only the
+// projected columns
+func (p *ColumnProjection) Structure(st *dataset.Structure) *dataset.Structure {
+	if p == nil || st == nil {
+		return st
+	}
+	out := &dataset.Structure{}
+	out.Assign(st)
+
+	items, _ := st.Schema["items"].(map[string]interface{})
+	projItems := map[string]interface{}{}
+	for k, v := range items {
+		projItems[k] = v
+	}
+	if p.indices != nil {
+		cols, _ := items["items"].([]interface{})
+		projCols := make([]interface{}, len(p.indices))
+		for i, idx := range p.indices {
+			projCols[i] = cols[idx]
+		}
+		projItems["items"] = projCols
+	} else {
+		props, _ := items["properties"].(map[string]interface{})
+		projProps := make(map[string]interface{}, len(p.columns))
+		for _, name := range p.columns {
+			projProps[name] = props[name]
+		}
+		projItems["properties"] = projProps
+		if required, ok := items["required"].([]interface{}); ok {
+			projRequired := []interface{}{}
+			for _, r := range required {
+				if _, ok := projProps[fmt.Sprintf("%v", r)]; ok {
+					projRequired = append(projRequired, r)
+				}
+			}
+			projItems["required"] = projRequired
+		}
+	}
+
+	schema := make(map[string]interface{}, len(st.Schema))
+	for k, v := range st.Schema {
+		schema[k] = v
+	}
+	schema["items"] = projItems
+	out.Schema = schema
+	return out
+}
+
+// Reader wraps r, projecting each entry it reads
+func (p *ColumnProjection) Reader(r dsio.EntryReader) dsio.EntryReader {
+	if p == nil {
+		return r
+	}
+	return projectedReader{EntryReader: r, p: p}
+}
+
+type projectedReader struct {
+	dsio.EntryReader
+	p *ColumnProjection
+}
+
+func (r projectedReader) ReadEntry() (dsio.Entry, error) {
+	ent, err := r.EntryReader.ReadEntry()
+	if err != nil {
+		return ent, err
+	}
+
+	switch row := ent.Value.(type) {
+	case []interface{}:
+		if r.p.indices == nil {
+			return ent, fmt.Errorf("entry %d: schema describes object rows, got an array", ent.Index)
+		}
+		proj := make([]interface{}, len(r.p.indices))
+		for i, idx := range r.p.indices {
+			if idx < len(row) {
+				proj[i] = row[idx]
+			}
+		}
+		ent.Value = proj
+	case map[string]interface{}:
+		proj := make(map[string]interface{}, len(r.p.columns))
+		for _, name := range r.p.columns {
+			if v, ok := row[name]; ok {
+				proj[name] = v
+			}
+		}
+		ent.Value = proj
+	default:
+		return ent, fmt.Errorf("entry %d: can't project columns of a %T", ent.Index, ent.Value)
+	}
+	return ent, nil
+}
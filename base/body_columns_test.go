@@ -0,0 +1,103 @@
+package base
+
+import (
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+func TestReadBodyColumns(t *testing.T) {
+	tabular := func() *dataset.Dataset {
+		ds := &dataset.Dataset{
+			Structure: &dataset.Structure{
+				Format: "json",
+				Schema: map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "array",
+						"items": []interface{}{
+							map[string]interface{}{"title": "city", "type": "string"},
+							map[string]interface{}{"title": "pop", "type": "integer"},
+							map[string]interface{}{"title": "in_usa", "type": "boolean"},
+						},
+					},
+				},
+			},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[["toronto",40000000,false],["new york",8500000,true],["chicago",300000,true]]`)))
+		return ds
+	}
+
+	cases := []struct {
+		description   string
+		format        dataset.DataFormat
+		columns       []string
+		limit, offset int
+		all           bool
+		expect        string
+	}{
+		{"reorders columns", dataset.JSONDataFormat, []string{"in_usa", "city"}, 0, 0, true,
+			`[[false,"toronto"],[true,"new york"],[true,"chicago"]]`},
+		{"composes with paging", dataset.JSONDataFormat, []string{"pop"}, 1, 1, false,
+			`[[8500000]]`},
+		{"csv headers reflect projection", dataset.CSVDataFormat, []string{"city", "in_usa"}, 0, 0, true,
+			"city,in_usa\ntoronto,false\nnew york,true\nchicago,true\n"},
+	}
+	for _, c := range cases {
+		data, err := ReadBody(tabular(), c.format, nil, c.columns, c.limit, c.offset, c.all)
+		if err != nil {
+			t.Errorf("case %q unexpected error: %s", c.description, err)
+			continue
+		}
+		if string(data) != c.expect {
+			t.Errorf("case %q body mismatch. expected: %q, got: %q", c.description, c.expect, string(data))
+		}
+	}
+
+	expect := "unknown columns: state, country"
+	if _, err := ReadBody(tabular(), dataset.JSONDataFormat, nil, []string{"city", "state", "country"}, 0, 0, true); err == nil || err.Error() != expect {
+		t.Errorf("error mismatch. expected: %q, got: %v", expect, err)
+	} else if _, ok := err.(UnknownColumnsError); !ok {
+		t.Errorf("expected an UnknownColumnsError, got: %T", err)
+	}
+
+	objects := &dataset.Dataset{
+		Structure: &dataset.Structure{
+			Format: "json",
+			Schema: map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"a": map[string]interface{}{"type": "integer"},
+						"b": map[string]interface{}{"type": "integer"},
+					},
+				},
+			},
+		},
+	}
+	objects.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[{"a":1,"b":2},{"a":3,"b":4}]`)))
+	data, err := ReadBody(objects, dataset.JSONDataFormat, nil, []string{"b"}, 0, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := `[{"b":2},{"b":4}]`; string(data) != expect {
+		t.Errorf("object row projection mismatch. expected: %q, got: %q", expect, string(data))
+	}
+
+	st := tabular().Structure
+	sample, err := SampleBody(tabular().BodyFile(), st, st, []string{"city"}, SampleHead, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := `[["toronto"],["new york"]]`; string(sample.Data) != expect {
+		t.Errorf("sample projection mismatch. expected: %q, got: %q", expect, string(sample.Data))
+	}
+
+	untyped := &dataset.Dataset{Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}}
+	untyped.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[1,2]`)))
+	if _, err := ReadBody(untyped, dataset.JSONDataFormat, nil, []string{"a"}, 0, 0, true); err == nil {
+		t.Error("expected projecting a body without column schema to error")
+	}
+}
@@ -0,0 +1,57 @@
+package base
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/qri-io/qfs"
+)
+
+// ErrBodyTooLarge means a body is larger than the maximum size a save accepts
+var ErrBodyTooLarge = fmt.Errorf("body too large")
+
+// BodySizeLimit caps the bytes read from a body. Reads past the limit fail,
+// so bodies are rejected while they stream rather than after they've been
+// buffered
+type BodySizeLimit struct {
+	r        io.Reader
+	max      int64
+	read     int64
+	exceeded bool
+}
+
+// NewBodySizeLimit wraps r, erroring once more than max bytes are read
+func NewBodySizeLimit(r io.Reader, max int64) *BodySizeLimit {
+	return &BodySizeLimit{r: r, max: max}
+}
+
+// Read implements the io.Reader interface
+func (l *BodySizeLimit) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, l.Err()
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.max {
+		l.exceeded = true
+		return n - int(l.read-l.max), l.Err()
+	}
+	return n, err
+}
+
+// Exceeded reports if a read went past the limit. Readers of the body may
+// replace the error a read returned, check Exceeded to tell what failed
+func (l *BodySizeLimit) Exceeded() bool {
+	return l.exceeded
+}
+
+// Err is the error reads past the limit return, matching ErrBodyTooLarge
+func (l *BodySizeLimit) Err() error {
+	return fmt.Errorf("%w: exceeds the maximum of %d bytes", ErrBodyTooLarge, l.max)
+}
+
+// limitBodyFile caps the bytes read from a body file
+func limitBodyFile(f qfs.File, max int64) (qfs.File, *BodySizeLimit) {
+	l := NewBodySizeLimit(f, max)
+	return qfs.NewMemfileReader(f.FileName(), l), l
+}
@@ -0,0 +1,48 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+func TestBodySizeLimit(t *testing.T) {
+	l := NewBodySizeLimit(strings.NewReader("0123456789"), 10)
+	if data, err := ioutil.ReadAll(l); err != nil || len(data) != 10 || l.Exceeded() {
+		t.Errorf("expected a body at the limit to read in full, got: %q, %v", data, err)
+	}
+
+	l = NewBodySizeLimit(strings.NewReader("0123456789"), 4)
+	data, err := ioutil.ReadAll(l)
+	if !errors.Is(err, ErrBodyTooLarge) || !l.Exceeded() {
+		t.Errorf("expected reading past the limit to error, got: %v", err)
+	}
+	if string(data) != "0123" {
+		t.Errorf("expected only bytes up to the limit to be read, got: %q", data)
+	}
+}
+
+func TestSaveDatasetMaxBodySize(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	newDs := func() *dataset.Dataset {
+		ds := &dataset.Dataset{Name: "limited"}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.csv", []byte("city,pop\ntoronto,40000000\nnew york,8500000\n")))
+		return ds
+	}
+
+	_, _, err := SaveDataset(ctx, r, devNull, newDs(), nil, nil, SaveDatasetSwitches{Pin: true, MaxBodySize: 16})
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("expected a body over the limit to fail with ErrBodyTooLarge, got: %v", err)
+	}
+
+	if _, _, err = SaveDataset(ctx, r, devNull, newDs(), nil, nil, SaveDatasetSwitches{Pin: true, MaxBodySize: 1024}); err != nil {
+		t.Errorf("expected a body under the limit to save, got: %s", err)
+	}
+}
@@ -0,0 +1,142 @@
+package base
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
+)
+
+const (
+	// SampleHead samples the first entries of a body
+	SampleHead = "head"
+	// SampleRandom samples entries chosen at random from the entire body
+	SampleRandom = "random"
+)
+
+// BodySample is a subset of the entries of a body
+type BodySample struct {
+	// Data is the sampled entries, encoded in the requested format
+	Data []byte
+	// Total is the number of entries in the body, -1 if unknown
+	Total int
+}
+
+// SampleBody reads size entries from a body file stored with structure in,
+// writing them with structure out. SampleHead reads only the first entries.
+// SampleRandom reads the entire body once, keeping a uniform random sample of
+// entries in body order. The same seed always samples the same entries of a
+// body. Non-empty columns projects sampled rows to the named columns
+func SampleBody(file qfs.File, in, out *dataset.Structure, columns []string, method string, size int, seed int64) (*BodySample, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("sample size must be a positive number")
+	}
+	if method != SampleHead && method != SampleRandom {
+		return nil, fmt.Errorf("unknown sample method %q, must be one of %q or %q", method, SampleHead, SampleRandom)
+	}
+	proj, err := NewColumnProjection(in, columns)
+	if err != nil {
+		return nil, err
+	}
+	out = proj.Structure(out)
+
+	file, err = dsfs.DecompressBody(file, nil)
+	if err != nil {
+		return nil, err
+	}
+	r, err := dsio.NewEntryReader(in, file)
+	if err != nil {
+		return nil, fmt.Errorf("error allocating data reader: %s", err)
+	}
+	r = proj.Reader(r)
+
+	var (
+		entries []dsio.Entry
+		total   = -1
+	)
+	if method == SampleHead {
+		entries, err = headEntries(r, size)
+		// stored structures count body entries
+		if in.Entries > 0 {
+			total = in.Entries
+		}
+	} else {
+		entries, total, err = reservoirEntries(r, size, seed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := dsio.NewEntryWriter(out, buf)
+	if err != nil {
+		return nil, err
+	}
+	for _, ent := range entries {
+		if err := w.WriteEntry(ent); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("error closing row buffer: %s", err.Error())
+	}
+
+	return &BodySample{Data: buf.Bytes(), Total: total}, nil
+}
+
+// headEntries reads up to the first n entries
+func headEntries(r dsio.EntryReader, n int) ([]dsio.Entry, error) {
+	entries := make([]dsio.Entry, 0, n)
+	for len(entries) < n {
+		ent, err := r.ReadEntry()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		entries = append(entries, ent)
+	}
+	return entries, nil
+}
+
+// reservoirEntries keeps a uniform random sample of n entries in a single
+// pass, returning the sample in the order entries were read & the number of
+// entries read
+func reservoirEntries(r dsio.EntryReader, n int, seed int64) ([]dsio.Entry, int, error) {
+	type sampled struct {
+		i   int
+		ent dsio.Entry
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	reservoir := make([]sampled, 0, n)
+
+	read := 0
+	for ; ; read++ {
+		ent, err := r.ReadEntry()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, 0, err
+		}
+		if read < n {
+			reservoir = append(reservoir, sampled{read, ent})
+		} else if j := rnd.Intn(read + 1); j < n {
+			reservoir[j] = sampled{read, ent}
+		}
+	}
+
+	sort.Slice(reservoir, func(a, b int) bool { return reservoir[a].i < reservoir[b].i })
+	entries := make([]dsio.Entry, len(reservoir))
+	for i, s := range reservoir {
+		entries[i] = s.ent
+	}
+	return entries, read, nil
+}
@@ -0,0 +1,106 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+func TestSampleBody(t *testing.T) {
+	csvBody := "city,pop\n"
+	jsonRows := []string{}
+	for i := 0; i < 50; i++ {
+		csvBody += fmt.Sprintf("city_%d,%d\n", i, i)
+		jsonRows = append(jsonRows, fmt.Sprintf("%d", i))
+	}
+	jsonBody := "[" + strings.Join(jsonRows, ",") + "]"
+
+	csvSt := &dataset.Structure{
+		Format:       "csv",
+		FormatConfig: map[string]interface{}{"headerRow": true},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "city", "type": "string"},
+					map[string]interface{}{"title": "pop", "type": "integer"},
+				},
+			},
+		},
+		Entries: 50,
+	}
+	jsonSt := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	out := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+
+	sample := func(body string, in *dataset.Structure, method string, size int, seed int64) *BodySample {
+		t.Helper()
+		s, err := SampleBody(qfs.NewMemfileBytes("body."+in.Format, []byte(body)), in, out, nil, method, size, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	// the csv header row isn't an entry
+	head := sample(csvBody, csvSt, SampleHead, 2, 0)
+	if diff := cmp.Diff(`[["city_0",0],["city_1",1]]`, string(head.Data)); diff != "" {
+		t.Errorf("head sample mismatch (-want +got):\n%s", diff)
+	}
+	if head.Total != 50 {
+		t.Errorf("expected head sample total from the structure. expected: 50, got: %d", head.Total)
+	}
+
+	head = sample(jsonBody, jsonSt, SampleHead, 100, 0)
+	if head.Total != -1 {
+		t.Errorf("expected head sample of a body without an entry count to have an unknown total, got: %d", head.Total)
+	}
+	if got := sampledInts(t, head.Data); len(got) != 50 {
+		t.Errorf("expected a head sample larger than the body to return the entire body, got %d entries", len(got))
+	}
+
+	a := sample(jsonBody, jsonSt, SampleRandom, 10, 42)
+	b := sample(jsonBody, jsonSt, SampleRandom, 10, 42)
+	if diff := cmp.Diff(string(a.Data), string(b.Data)); diff != "" {
+		t.Errorf("expected samples with the same seed to match (-a +b):\n%s", diff)
+	}
+	if a.Total != 50 {
+		t.Errorf("expected random sample to count body entries. expected: 50, got: %d", a.Total)
+	}
+	got := sampledInts(t, a.Data)
+	if len(got) != 10 {
+		t.Fatalf("expected 10 sampled entries, got: %d", len(got))
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] <= got[i-1] {
+			t.Errorf("expected sampled entries in body order, got: %v", got)
+			break
+		}
+	}
+
+	c := sample(jsonBody, jsonSt, SampleRandom, 10, 7)
+	if string(a.Data) == string(c.Data) {
+		t.Errorf("expected samples with different seeds to differ, both got: %s", a.Data)
+	}
+
+	if _, err := SampleBody(qfs.NewMemfileBytes("body.json", []byte(jsonBody)), jsonSt, out, nil, "tail", 10, 0); err == nil {
+		t.Error("expected unknown sample method to error")
+	}
+	if _, err := SampleBody(qfs.NewMemfileBytes("body.json", []byte(jsonBody)), jsonSt, out, nil, SampleHead, 0, 0); err == nil {
+		t.Error("expected zero sample size to error")
+	}
+}
+
+func sampledInts(t *testing.T, data []byte) []int {
+	t.Helper()
+	ints := []int{}
+	if err := json.Unmarshal(data, &ints); err != nil {
+		t.Fatal(err)
+	}
+	return ints
+}
@@ -23,7 +23,7 @@ func TestReadBody(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	data, err := ReadBody(ds, dataset.JSONDataFormat, nil, 1, 1, false)
+	data, err := ReadBody(ds, dataset.JSONDataFormat, nil, nil, 1, 1, false)
 	if err != nil {
 		t.Error(err.Error())
 	}
@@ -34,6 +34,64 @@ func TestReadBody(t *testing.T) {
 	if ds.BodyPath != "/map/QmcCcPTqmckdXLBwPQXxfyW2BbFcUT6gqv9oGeWDkrNTyD" {
 		t.Errorf("bodypath mismatch")
 	}
+
+	// reading a body in a format it isn't stored in converts it
+	jsonDs := &dataset.Dataset{
+		Structure: &dataset.Structure{
+			Format: "json",
+			Schema: map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "array",
+					"items": []interface{}{
+						map[string]interface{}{"title": "city", "type": "string"},
+						map[string]interface{}{"title": "pop", "type": "integer"},
+					},
+				},
+			},
+		},
+	}
+	jsonDs.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[["toronto",40000000],["new york",8500000]]`)))
+	data, err = ReadBody(jsonDs, dataset.CSVDataFormat, nil, nil, -1, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "city,pop\ntoronto,40000000\nnew york,8500000\n"; string(data) != expect {
+		t.Errorf("converted body mismatch. expected: %q, got: %q", expect, string(data))
+	}
+
+	// bodies that can't be represented in the requested format error
+	objDs := &dataset.Dataset{Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaObject}}
+	objDs.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`{"a":1}`)))
+	if _, err = ReadBody(objDs, dataset.CSVDataFormat, nil, nil, -1, 0, true); err == nil {
+		t.Error("expected converting an object body to csv to error")
+	}
+}
+
+func TestReadStructure(t *testing.T) {
+	csvSt := &dataset.Structure{
+		Format:       "csv",
+		FormatConfig: map[string]interface{}{"headerRow": true, "lazyQuotes": true},
+		Schema:       dataset.BaseSchemaArray,
+	}
+
+	got := ReadStructure(csvSt, dataset.UnknownDataFormat, nil)
+	if got.Format != "csv" || got.FormatConfig["lazyQuotes"] != true {
+		t.Errorf("expected unknown format to read as stored, got: %v", got)
+	}
+	if got == csvSt {
+		t.Error("expected a copy of the stored structure")
+	}
+
+	got = ReadStructure(csvSt, dataset.JSONDataFormat, nil)
+	if got.Format != "json" || got.FormatConfig != nil {
+		t.Errorf("expected csv format options to be dropped when converting to json, got: %v", got)
+	}
+
+	got = ReadStructure(csvSt, dataset.JSONDataFormat, &dataset.JSONOptions{Options: map[string]interface{}{"pretty": true}})
+	if got.FormatConfig["pretty"] != true {
+		t.Errorf("expected format config to override, got: %v", got.FormatConfig)
+	}
 }
 
 func TestDatasetBodyFile(t *testing.T) {
@@ -109,10 +167,13 @@ func TestConvertBodyFormat(t *testing.T) {
 
 	// CSV -> JSON
 	body := qfs.NewMemfileBytes("", []byte("a,b,c"))
-	got, err := ConvertBodyFormat(body, csvStructure, jsonStructure)
+	got, warnings, err := ConvertBodyFormat(body, csvStructure, jsonStructure)
 	if err != nil {
 		t.Error(err.Error())
 	}
+	if len(warnings) != 1 || warnings[0].Code != WarnConvertedFormat || warnings[0].Message != "converted body from csv to json" {
+		t.Errorf("expected a format conversion warning, got: %v", warnings)
+	}
 	data, err := ioutil.ReadAll(got)
 	if err != nil {
 		t.Fatal(err.Error())
@@ -123,7 +184,7 @@ func TestConvertBodyFormat(t *testing.T) {
 
 	// CSV -> JSON, multiple lines
 	body = qfs.NewMemfileBytes("", []byte("a,b,c\n\rd,e,f\n\rg,h,i"))
-	got, err = ConvertBodyFormat(body, csvStructure, jsonStructure)
+	got, _, err = ConvertBodyFormat(body, csvStructure, jsonStructure)
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -137,7 +198,7 @@ func TestConvertBodyFormat(t *testing.T) {
 
 	// JSON -> CSV
 	body = qfs.NewMemfileBytes("", []byte(`[["a","b","c"]]`))
-	got, err = ConvertBodyFormat(body, jsonStructure, csvStructure)
+	got, _, err = ConvertBodyFormat(body, jsonStructure, csvStructure)
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -151,10 +212,13 @@ func TestConvertBodyFormat(t *testing.T) {
 
 	// CSV -> CSV
 	body = qfs.NewMemfileBytes("", []byte("a,b,c"))
-	got, err = ConvertBodyFormat(body, csvStructure, csvStructure)
+	got, warnings, err = ConvertBodyFormat(body, csvStructure, csvStructure)
 	if err != nil {
 		t.Fatal(err.Error())
 	}
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings converting to the same format, got: %v", warnings)
+	}
 	data, err = ioutil.ReadAll(got)
 	if err != nil {
 		t.Fatal(err.Error())
@@ -165,7 +229,7 @@ func TestConvertBodyFormat(t *testing.T) {
 
 	// JSON -> JSON
 	body = qfs.NewMemfileBytes("", []byte(`[["a","b","c"]]`))
-	got, err = ConvertBodyFormat(body, jsonStructure, jsonStructure)
+	got, _, err = ConvertBodyFormat(body, jsonStructure, jsonStructure)
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -0,0 +1,329 @@
+// Package car reads & writes content-addressed archives (CARv1), the IPLD
+// format for streaming a set of blocks along with the roots of the DAGs they
+// form. Readers verify each block against the hash in its CID
+package car
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+// version is the only CAR version this package reads & writes
+const version = 1
+
+// maxSectionSize caps the length of a single block section, guarding against
+// allocating absurd buffers when reading corrupt archives
+const maxSectionSize = 32 << 20
+
+// ErrHashMismatch means a block in an archive doesn't hash to its CID
+var ErrHashMismatch = fmt.Errorf("car: block data doesn't match CID")
+
+// Writer writes blocks to a CAR archive
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter writes the header of a CAR archive listing roots to w
+func NewWriter(w io.Writer, roots []cid.Cid) (*Writer, error) {
+	if err := writeSection(w, encodeHeader(roots)); err != nil {
+		return nil, fmt.Errorf("car: writing header: %w", err)
+	}
+	return &Writer{w: w}, nil
+}
+
+// Put writes a block to the archive. Put doesn't check data hashes to c,
+// callers are expected to write blocks read from a store that does
+func (w *Writer) Put(c cid.Cid, data []byte) error {
+	return writeSection(w.w, c.Bytes(), data)
+}
+
+func writeSection(w io.Writer, parts ...[]byte) error {
+	size := 0
+	for _, p := range parts {
+		size += len(p)
+	}
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, uint64(size))
+	if _, err := w.Write(buf[:n]); err != nil {
+		return err
+	}
+	for _, p := range parts {
+		if _, err := w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reader reads blocks from a CAR archive
+type Reader struct {
+	r     *bufio.Reader
+	Roots []cid.Cid
+}
+
+// NewReader reads the header of a CAR archive from r
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	data, err := readSection(br)
+	if err == io.EOF {
+		return nil, fmt.Errorf("car: archive is empty")
+	} else if err != nil {
+		return nil, fmt.Errorf("car: reading header: %w", err)
+	}
+	roots, err := decodeHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{r: br, Roots: roots}, nil
+}
+
+// Next reads the next block in the archive, returning io.EOF when there are
+// no more blocks & ErrHashMismatch if the block is corrupt
+func (r *Reader) Next() (cid.Cid, []byte, error) {
+	data, err := readSection(r.r)
+	if err != nil {
+		return cid.Undef, nil, err
+	}
+	n, err := cidLen(data)
+	if err != nil {
+		return cid.Undef, nil, err
+	}
+	c, err := cid.Cast(data[:n])
+	if err != nil {
+		return cid.Undef, nil, fmt.Errorf("car: invalid CID: %w", err)
+	}
+	block := data[n:]
+	sum, err := c.Prefix().Sum(block)
+	if err != nil {
+		return cid.Undef, nil, fmt.Errorf("car: hashing block %s: %w", c, err)
+	}
+	if !sum.Equals(c) {
+		return c, nil, fmt.Errorf("%w: %s", ErrHashMismatch, c)
+	}
+	return c, block, nil
+}
+
+func readSection(r *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 || size > maxSectionSize {
+		return nil, fmt.Errorf("car: invalid section length %d", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// cidLen gives the length of the CID that prefixes a section
+func cidLen(data []byte) (int, error) {
+	// CIDv0s are bare sha2-256 multihashes
+	if len(data) >= 34 && data[0] == 0x12 && data[1] == 0x20 {
+		return 34, nil
+	}
+	// CIDv1s are a version, codec & multihash. multihashes are a code & the
+	// length of the digest that follows
+	pos := 0
+	for i := 0; i < 3; i++ {
+		_, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return 0, fmt.Errorf("car: invalid CID")
+		}
+		pos += n
+	}
+	size, n := binary.Uvarint(data[pos:])
+	if n <= 0 || uint64(len(data)-pos-n) < size {
+		return 0, fmt.Errorf("car: invalid CID")
+	}
+	return pos + n + int(size), nil
+}
+
+// the header is the CBOR map {"roots": [cid, ...], "version": 1}, with each
+// root a byte string tagged as an IPLD link
+const (
+	cborUint   = 0
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborCIDTag = 42
+)
+
+func encodeHeader(roots []cid.Cid) []byte {
+	buf := &bytes.Buffer{}
+	writeCBORHead(buf, cborMap, 2)
+	writeCBORText(buf, "roots")
+	writeCBORHead(buf, cborArray, uint64(len(roots)))
+	for _, c := range roots {
+		b := c.Bytes()
+		writeCBORHead(buf, cborTag, cborCIDTag)
+		// links are prefixed with the identity multibase
+		writeCBORHead(buf, cborBytes, uint64(len(b)+1))
+		buf.WriteByte(0)
+		buf.Write(b)
+	}
+	writeCBORText(buf, "version")
+	writeCBORHead(buf, cborUint, version)
+	return buf.Bytes()
+}
+
+func writeCBORHead(buf *bytes.Buffer, major byte, val uint64) {
+	switch {
+	case val < 24:
+		buf.WriteByte(major<<5 | byte(val))
+	case val <= 0xff:
+		buf.Write([]byte{major<<5 | 24, byte(val)})
+	case val <= 0xffff:
+		buf.WriteByte(major<<5 | 25)
+		binary.Write(buf, binary.BigEndian, uint16(val))
+	case val <= 0xffffffff:
+		buf.WriteByte(major<<5 | 26)
+		binary.Write(buf, binary.BigEndian, uint32(val))
+	default:
+		buf.WriteByte(major<<5 | 27)
+		binary.Write(buf, binary.BigEndian, val)
+	}
+}
+
+func writeCBORText(buf *bytes.Buffer, s string) {
+	writeCBORHead(buf, cborText, uint64(len(s)))
+	buf.WriteString(s)
+}
+
+func decodeHeader(data []byte) ([]cid.Cid, error) {
+	d := &cborDecoder{data: data}
+	major, size, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	if major != cborMap {
+		return nil, fmt.Errorf("car: header isn't a map")
+	}
+
+	var (
+		roots      []cid.Cid
+		gotVersion bool
+	)
+	for i := uint64(0); i < size; i++ {
+		key, err := d.text()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "version":
+			major, v, err := d.head()
+			if err != nil {
+				return nil, err
+			}
+			if major != cborUint || v != version {
+				return nil, fmt.Errorf("car: unsupported version %d", v)
+			}
+			gotVersion = true
+		case "roots":
+			if roots, err = d.roots(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("car: unexpected header field %q", key)
+		}
+	}
+	if !gotVersion {
+		return nil, fmt.Errorf("car: header is missing a version")
+	}
+	return roots, nil
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+var errShortHeader = fmt.Errorf("car: header is truncated")
+
+func (d *cborDecoder) head() (major byte, val uint64, err error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, errShortHeader
+	}
+	b := d.data[d.pos]
+	d.pos++
+	major, info := b>>5, b&0x1f
+	if info < 24 {
+		return major, uint64(info), nil
+	}
+	if info > 27 {
+		return 0, 0, fmt.Errorf("car: unsupported header encoding")
+	}
+	n := 1 << (info - 24)
+	if d.pos+n > len(d.data) {
+		return 0, 0, errShortHeader
+	}
+	for _, c := range d.data[d.pos : d.pos+n] {
+		val = val<<8 | uint64(c)
+	}
+	d.pos += n
+	return major, val, nil
+}
+
+func (d *cborDecoder) bytes(want byte) ([]byte, error) {
+	major, size, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	if major != want {
+		return nil, fmt.Errorf("car: unexpected header value")
+	}
+	if uint64(len(d.data)-d.pos) < size {
+		return nil, errShortHeader
+	}
+	b := d.data[d.pos : d.pos+int(size)]
+	d.pos += int(size)
+	return b, nil
+}
+
+func (d *cborDecoder) text() (string, error) {
+	b, err := d.bytes(cborText)
+	return string(b), err
+}
+
+func (d *cborDecoder) roots() ([]cid.Cid, error) {
+	major, size, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	if major != cborArray {
+		return nil, fmt.Errorf("car: header roots aren't a list")
+	}
+	roots := make([]cid.Cid, 0, size)
+	for i := uint64(0); i < size; i++ {
+		if major, tag, err := d.head(); err != nil {
+			return nil, err
+		} else if major != cborTag || tag != cborCIDTag {
+			return nil, fmt.Errorf("car: header root isn't a link")
+		}
+		b, err := d.bytes(cborBytes)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) < 2 || b[0] != 0 {
+			return nil, fmt.Errorf("car: invalid root link")
+		}
+		c, err := cid.Cast(b[1:])
+		if err != nil {
+			return nil, fmt.Errorf("car: invalid root: %w", err)
+		}
+		roots = append(roots, c)
+	}
+	return roots, nil
+}
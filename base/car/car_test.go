@@ -0,0 +1,82 @@
+package car
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	cid "github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+func TestWriteRead(t *testing.T) {
+	v0 := cid.NewPrefixV0(mh.SHA2_256)
+	v1 := cid.NewPrefixV1(cid.Raw, mh.SHA2_256)
+
+	blocks := [][]byte{[]byte("hello"), []byte("world"), bytes.Repeat([]byte("x"), 300)}
+	cids := make([]cid.Cid, len(blocks))
+	for i, b := range blocks {
+		p := v0
+		if i%2 == 1 {
+			p = v1
+		}
+		c, err := p.Sum(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cids[i] = c
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, cids[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, b := range blocks {
+		if err := w.Put(cids[i], b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Roots) != 2 || !r.Roots[0].Equals(cids[0]) || !r.Roots[1].Equals(cids[1]) {
+		t.Errorf("roots mismatch. got: %v", r.Roots)
+	}
+	for i := range blocks {
+		c, data, err := r.Next()
+		if err != nil {
+			t.Fatalf("block %d: %s", i, err)
+		}
+		if !c.Equals(cids[i]) || !bytes.Equal(data, blocks[i]) {
+			t.Errorf("block %d mismatch. got: %s %q", i, c, data)
+		}
+	}
+	if _, _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected EOF after last block, got: %v", err)
+	}
+
+	// corrupt the last byte of the last block
+	corrupt := append([]byte{}, buf.Bytes()...)
+	corrupt[len(corrupt)-1] = 'y'
+	r, err = NewReader(bytes.NewReader(corrupt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range blocks {
+		_, _, err = r.Next()
+		if i < len(blocks)-1 && err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !errors.Is(err, ErrHashMismatch) {
+		t.Errorf("expected hash mismatch reading corrupt block, got: %v", err)
+	}
+
+	if _, err := NewReader(bytes.NewReader(nil)); err == nil {
+		t.Errorf("expected reading an empty archive to error")
+	}
+}
@@ -0,0 +1,264 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/jsonschema"
+)
+
+func init() {
+	// jsonschema treats unknown keywords as subschemas, register "unit" so
+	// schemas with column units still parse
+	jsonschema.RegisterValidator("unit", newUnitKeyword)
+}
+
+// unitKeyword is the "unit" schema keyword. Units describe a column, and
+// never fail validation
+type unitKeyword string
+
+func newUnitKeyword() jsonschema.Validator {
+	return new(unitKeyword)
+}
+
+// Validate implements the jsonschema.Validator interface
+func (unitKeyword) Validate(propPath string, data interface{}, errs *[]jsonschema.ValError) {}
+
+// ColumnMetadataKeys lists the schema keywords that make up column metadata.
+// "title" names columns of arrays of arrays
+var ColumnMetadataKeys = []string{"title", "description", "unit", "enum", "references"}
+
+// Column is a column of a tabular body schema. Columns are arrays of arrays
+// with titled items, or arrays of objects with named properties
+type Column struct {
+	// Name is the item title or property key of the column
+	Name string
+	// Index is the position of the column in a row
+	Index int
+	// Schema is the column subschema, changes to it modify the body schema
+	Schema map[string]interface{}
+}
+
+// Columns lists the columns of a body schema, in row order. Object rows are
+// ordered by property key. Schemas that don't describe rows have no columns
+func Columns(schema map[string]interface{}) []Column {
+	items, ok := schema["items"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var cols []Column
+	if list, ok := items["items"].([]interface{}); ok {
+		for i, v := range list {
+			sch, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			title, _ := sch["title"].(string)
+			cols = append(cols, Column{Name: title, Index: i, Schema: sch})
+		}
+		return cols
+	}
+
+	if props, ok := items["properties"].(map[string]interface{}); ok {
+		keys := make([]string, 0, len(props))
+		for k := range props {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			if sch, ok := props[k].(map[string]interface{}); ok {
+				cols = append(cols, Column{Name: k, Index: i, Schema: sch})
+			}
+		}
+	}
+	return cols
+}
+
+// column finds a column by name
+func column(schema map[string]interface{}, name string) (Column, bool) {
+	for _, c := range Columns(schema) {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+// MergeColumnMetadata copies column metadata from a previous schema onto
+// columns of the same name in schema that don't set it. Schemas inferred from
+// a new body don't describe their columns, merging keeps descriptions, units &
+// enumerations across versions. schema is modified in place
+func MergeColumnMetadata(schema, prev map[string]interface{}) {
+	for _, c := range Columns(schema) {
+		if c.Name == "" {
+			continue
+		}
+		pc, ok := column(prev, c.Name)
+		if !ok {
+			continue
+		}
+		for _, key := range ColumnMetadataKeys {
+			if key == "title" {
+				continue
+			}
+			if _, set := c.Schema[key]; set {
+				continue
+			}
+			if v, ok := pc.Schema[key]; ok {
+				c.Schema[key] = v
+			}
+		}
+	}
+}
+
+// SetColumnMetadata returns a copy of schema with metadata of the named
+// column changed. Changes are keyed by ColumnMetadataKeys, a nil value removes
+// a key. Setting "title" renames a column of an array of arrays
+func SetColumnMetadata(schema map[string]interface{}, name string, changes map[string]interface{}) (map[string]interface{}, error) {
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("no column metadata to update")
+	}
+	for key, v := range changes {
+		if !isColumnMetadataKey(key) {
+			return nil, fmt.Errorf("can't update column %q, only %s can be set", key, strings.Join(ColumnMetadataKeys, ", "))
+		}
+		if key == "enum" && v != nil {
+			if l, ok := v.([]interface{}); !ok || len(l) == 0 {
+				return nil, fmt.Errorf("enum must be a list of one or more values")
+			}
+		}
+		if key == "references" && v != nil {
+			if _, _, err := parseReference(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	cpy, err := copySchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := column(cpy, name)
+	if !ok {
+		return nil, fmt.Errorf("column %q not found", name)
+	}
+	if _, ok := changes["title"]; ok {
+		items := cpy["items"].(map[string]interface{})
+		if _, isList := items["items"].([]interface{}); !isList {
+			return nil, fmt.Errorf("can't rename column %q, object properties are named by their key", name)
+		}
+	}
+	for key, v := range changes {
+		if v == nil {
+			delete(c.Schema, key)
+		} else {
+			c.Schema[key] = v
+		}
+	}
+	return cpy, nil
+}
+
+// copySchema deep-copies a schema, normalizing values to their JSON types
+func copySchema(schema map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	cpy := map[string]interface{}{}
+	err = json.Unmarshal(data, &cpy)
+	return cpy, err
+}
+
+func isColumnMetadataKey(key string) bool {
+	for _, k := range ColumnMetadataKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// describeEnumErrors rewrites validation errors for values outside a column
+// enumeration to name the column & list the allowed values
+func describeEnumErrors(schema map[string]interface{}, errs []jsonschema.ValError) {
+	cols := Columns(schema)
+	if len(cols) == 0 {
+		return
+	}
+	items, _ := schema["items"].(map[string]interface{})
+	_, objectRows := items["properties"]
+	for i, e := range errs {
+		if !strings.HasPrefix(e.Message, "should be one of") {
+			continue
+		}
+		// property paths of row values are /row/column
+		parts := strings.Split(strings.TrimPrefix(e.PropertyPath, "/"), "/")
+		if len(parts) != 2 {
+			continue
+		}
+		for _, c := range cols {
+			if (objectRows && parts[1] != c.Name) || (!objectRows && parts[1] != strconv.Itoa(c.Index)) {
+				continue
+			}
+			enum, ok := c.Schema["enum"].([]interface{})
+			if !ok {
+				break
+			}
+			allowed := make([]string, len(enum))
+			for j, v := range enum {
+				data, _ := json.Marshal(v)
+				allowed[j] = string(data)
+			}
+			name := c.Name
+			if name == "" {
+				name = parts[1]
+			}
+			value, _ := json.Marshal(e.InvalidValue)
+			errs[i].Message = fmt.Sprintf("row %s: %s isn't an allowed value for column %q, expected one of: %s", parts[0], value, name, strings.Join(allowed, ", "))
+			break
+		}
+	}
+}
+
+// AnnotateColumnStats adds column metadata to a list of column stats, as
+// produced by the stats package. Stats of object rows are matched to columns by
+// their "key", stats of array rows by position
+func AnnotateColumnStats(stats []map[string]interface{}, schema map[string]interface{}) {
+	for _, c := range Columns(schema) {
+		st := columnStat(stats, c)
+		if st == nil {
+			continue
+		}
+		for _, key := range ColumnMetadataKeys {
+			if v, ok := c.Schema[key]; ok {
+				st[key] = v
+			}
+		}
+	}
+}
+
+// columnStat finds the stats of a column, nil if stats don't include it
+func columnStat(stats []map[string]interface{}, c Column) map[string]interface{} {
+	if i, ok := statKey(stats, c.Name); ok {
+		return stats[i]
+	} else if c.Index < len(stats) {
+		if _, keyed := stats[c.Index]["key"]; !keyed {
+			return stats[c.Index]
+		}
+	}
+	return nil
+}
+
+// statKey finds the index of a keyed stat
+func statKey(stats []map[string]interface{}, key string) (int, bool) {
+	for i, st := range stats {
+		if k, ok := st["key"].(string); ok && k == key {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,169 @@
+package base
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+func columnsSchema(cols ...map[string]interface{}) map[string]interface{} {
+	items := make([]interface{}, len(cols))
+	for i, c := range cols {
+		items[i] = c
+	}
+	return map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"type": "array", "items": items},
+	}
+}
+
+func TestColumns(t *testing.T) {
+	sch := columnsSchema(
+		map[string]interface{}{"title": "city", "type": "string"},
+		map[string]interface{}{"title": "pop", "type": "integer"},
+	)
+	names := []string{}
+	for _, c := range Columns(sch) {
+		names = append(names, c.Name)
+	}
+	if diff := cmp.Diff([]string{"city", "pop"}, names); diff != "" {
+		t.Errorf("array column names mismatch (-want +got):\n%s", diff)
+	}
+
+	objSch := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{"type": "object", "properties": map[string]interface{}{
+			"pop":  map[string]interface{}{"type": "integer"},
+			"city": map[string]interface{}{"type": "string"},
+		}},
+	}
+	names = []string{}
+	for _, c := range Columns(objSch) {
+		names = append(names, c.Name)
+	}
+	if diff := cmp.Diff([]string{"city", "pop"}, names); diff != "" {
+		t.Errorf("object column names mismatch (-want +got):\n%s", diff)
+	}
+
+	if cols := Columns(map[string]interface{}{"type": "object"}); len(cols) != 0 {
+		t.Errorf("expected non-tabular schema to have no columns, got: %d", len(cols))
+	}
+}
+
+func TestMergeColumnMetadata(t *testing.T) {
+	prev := columnsSchema(
+		map[string]interface{}{"title": "city", "type": "string", "description": "city name"},
+		map[string]interface{}{"title": "pop", "type": "integer", "unit": "people", "description": "population"},
+	)
+	sch := columnsSchema(
+		map[string]interface{}{"title": "pop", "type": "integer"},
+		map[string]interface{}{"title": "city", "type": "string", "description": "new description"},
+		map[string]interface{}{"title": "area", "type": "number"},
+	)
+	MergeColumnMetadata(sch, prev)
+
+	expect := columnsSchema(
+		map[string]interface{}{"title": "pop", "type": "integer", "unit": "people", "description": "population"},
+		map[string]interface{}{"title": "city", "type": "string", "description": "new description"},
+		map[string]interface{}{"title": "area", "type": "number"},
+	)
+	if diff := cmp.Diff(expect, sch); diff != "" {
+		t.Errorf("merged schema mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSetColumnMetadata(t *testing.T) {
+	sch := columnsSchema(
+		map[string]interface{}{"title": "city", "type": "string", "description": "city name"},
+		map[string]interface{}{"title": "size", "type": "string"},
+	)
+
+	got, err := SetColumnMetadata(sch, "size", map[string]interface{}{
+		"description": "relative size",
+		"enum":        []interface{}{"big", "small"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := columnsSchema(
+		map[string]interface{}{"title": "city", "type": "string", "description": "city name"},
+		map[string]interface{}{"title": "size", "type": "string", "description": "relative size", "enum": []interface{}{"big", "small"}},
+	)
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	if _, ok := Columns(sch)[1].Schema["enum"]; ok {
+		t.Error("expected input schema to be left unchanged")
+	}
+
+	got, err = SetColumnMetadata(sch, "city", map[string]interface{}{"description": nil, "title": "town"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"title": "town", "type": "string"}, Columns(got)[0].Schema); diff != "" {
+		t.Errorf("column mismatch (-want +got):\n%s", diff)
+	}
+
+	bad := []struct {
+		column  string
+		changes map[string]interface{}
+	}{
+		{"city", nil},
+		{"missing", map[string]interface{}{"description": "nope"}},
+		{"city", map[string]interface{}{"type": "integer"}},
+		{"city", map[string]interface{}{"enum": []interface{}{}}},
+		{"city", map[string]interface{}{"enum": "big"}},
+		{"city", map[string]interface{}{"references": map[string]interface{}{"dataset": "me/cities"}}},
+	}
+	for i, c := range bad {
+		if _, err := SetColumnMetadata(sch, c.column, c.changes); err == nil {
+			t.Errorf("case %d: expected error, got nil", i)
+		}
+	}
+}
+
+func TestAnnotateColumnStats(t *testing.T) {
+	sch := columnsSchema(
+		map[string]interface{}{"title": "city", "type": "string", "description": "city name"},
+		map[string]interface{}{"title": "pop", "type": "integer", "unit": "people"},
+	)
+	stats := []map[string]interface{}{{"type": "string"}, {"type": "integer"}}
+	AnnotateColumnStats(stats, sch)
+	expect := []map[string]interface{}{
+		{"type": "string", "title": "city", "description": "city name"},
+		{"type": "integer", "title": "pop", "unit": "people"},
+	}
+	if diff := cmp.Diff(expect, stats); diff != "" {
+		t.Errorf("stats mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestValidateEnumColumn(t *testing.T) {
+	ctx := context.Background()
+	st := &dataset.Structure{
+		Format: "json",
+		Schema: columnsSchema(
+			map[string]interface{}{"title": "city", "type": "string", "unit": "name"},
+			map[string]interface{}{"title": "size", "type": "string", "enum": []interface{}{"big", "small"}},
+		),
+	}
+	body := qfs.NewMemfileBytes("body.json", []byte(`[["toronto","big"],["new york","huge"]]`))
+	errs, err := Validate(ctx, nil, body, st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 validation error, got: %v", errs)
+	}
+	expect := `row 1: "huge" isn't an allowed value for column "size", expected one of: "big", "small"`
+	if errs[0].Message != expect {
+		t.Errorf("message mismatch.\nwant: %s\ngot:  %s", expect, errs[0].Message)
+	}
+	if !strings.HasPrefix(errs[0].PropertyPath, "/1/1") {
+		t.Errorf("expected property path to be kept, got: %q", errs[0].PropertyPath)
+	}
+}
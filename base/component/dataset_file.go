@@ -0,0 +1,89 @@
+package component
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/qri-io/dataset"
+	"gopkg.in/yaml.v2"
+)
+
+// DatasetFilename is the name of the file that holds every structured
+// component of a dataset when components are kept in a single file
+const DatasetFilename = "dataset.yaml"
+
+// DatasetFileSections lists components that are written as top-level
+// sections of a single dataset file, in the order they're written. Body, readme
+// & transform components aren't structured data, and keep their own files
+func DatasetFileSections() []string {
+	return []string{"commit", "meta", "structure"}
+}
+
+// WriteDatasetFile writes the structured components of a component collection
+// as sections of a single dataset.yaml in dirPath, replacing any existing
+// dataset.yaml. Sections are written in a fixed order, and keys within a
+// section are sorted, so rewriting unchanged components produces an identical
+// file
+func WriteDatasetFile(comp Component, dirPath string) (targetFile string, err error) {
+	sections := yaml.MapSlice{}
+	for _, name := range DatasetFileSections() {
+		sub := comp.Base().GetSubcomponent(name)
+		if sub == nil {
+			continue
+		}
+		if err = sub.LoadAndFill(nil); err != nil {
+			return "", err
+		}
+		if st, ok := sub.(*StructureComponent); ok && (st.Value == nil || st.Value.IsEmpty()) {
+			continue
+		}
+		data, err := sub.StructuredData()
+		if err != nil {
+			return "", err
+		}
+		sections = append(sections, yaml.MapItem{Key: name, Value: data})
+	}
+
+	data, err := yaml.Marshal(sections)
+	if err != nil {
+		return "", err
+	}
+	targetFile = filepath.Join(dirPath, DatasetFilename)
+	if err = ioutil.WriteFile(targetFile, data, os.ModePerm); err != nil {
+		return "", err
+	}
+	return targetFile, nil
+}
+
+// ReadDatasetFileSections reads the sections of a dataset file in dirPath
+// into a component collection. A missing dataset file reads as an empty
+// collection
+func ReadDatasetFileSections(dirPath string) (Component, error) {
+	ds := &dataset.Dataset{}
+	comp := ConvertDatasetToComponents(ds, nil)
+
+	dsFile := filepath.Join(dirPath, DatasetFilename)
+	fi, err := os.Stat(dsFile)
+	if os.IsNotExist(err) {
+		return comp, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	container := &FilesysComponent{}
+	container.SetSubcomponent("dataset", BaseComponent{
+		ModTime:    fi.ModTime(),
+		SourceFile: dsFile,
+		Format:     "yaml",
+	})
+	if err = ExpandListedComponents(container, nil); err != nil {
+		return nil, err
+	}
+	for _, name := range DatasetFileSections() {
+		if sub := container.GetSubcomponent(name); sub != nil {
+			comp.Base().Subcomponents[name] = sub
+		}
+	}
+	return comp, nil
+}
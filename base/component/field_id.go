@@ -2,7 +2,35 @@ package component
 
 import (
 	"regexp"
+	"strings"
 )
 
 // IsDatasetField can be used to check if a string is a dataset field identifier
 var IsDatasetField = regexp.MustCompile("(?i)^(commit|cm|structure|st|body|bd|meta|md|readme|rm|viz|vz|transform|tf|rendered|rd|stats)($|\\.)")
+
+// fieldAbbreviations maps the short identifiers of dataset fields to their
+// full names
+var fieldAbbreviations = map[string]string{
+	"cm": "commit",
+	"st": "structure",
+	"bd": "body",
+	"md": "meta",
+	"rm": "readme",
+	"vz": "viz",
+	"tf": "transform",
+	"rd": "rendered",
+}
+
+// ExpandFieldAbbrev replaces a short field identifier at the start of a
+// selector with the field's full name, eg: "rm.script" becomes "readme.script".
+// Other selectors are returned as-is
+func ExpandFieldAbbrev(sel string) string {
+	head, rest := sel, ""
+	if i := strings.Index(sel, "."); i >= 0 {
+		head, rest = sel[:i], sel[i:]
+	}
+	if name, ok := fieldAbbreviations[strings.ToLower(head)]; ok {
+		return name + rest
+	}
+	return sel
+}
@@ -0,0 +1,24 @@
+package component
+
+import "testing"
+
+func TestExpandFieldAbbrev(t *testing.T) {
+	cases := []struct {
+		sel, expect string
+	}{
+		{"", ""},
+		{"rm", "readme"},
+		{"rm.script", "readme.script"},
+		{"RM.script", "readme.script"},
+		{"md.title", "meta.title"},
+		{"rd", "rendered"},
+		{"readme.script", "readme.script"},
+		{"stats", "stats"},
+		{"meta.rm", "meta.rm"},
+	}
+	for _, c := range cases {
+		if got := ExpandFieldAbbrev(c.sel); got != c.expect {
+			t.Errorf("ExpandFieldAbbrev(%q) mismatch. expected: %q, got: %q", c.sel, c.expect, got)
+		}
+	}
+}
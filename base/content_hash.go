@@ -0,0 +1,86 @@
+package base
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/multiformats/go-multihash"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
+)
+
+// ContentHash hashes the data-bearing components of a dataset: body,
+// structure & meta. Commit details, component paths, and structure fields
+// derived from the body are left out, so two versions holding the same data
+// hash the same no matter when or by whom they were committed. The hash is a
+// base58-encoded sha2-256 multihash
+func ContentHash(ctx context.Context, fs qfs.Filesystem, ds *dataset.Dataset) (string, error) {
+	if ds == nil {
+		return "", fmt.Errorf("can't hash a nil dataset")
+	}
+
+	h := sha256.New()
+
+	var st *dataset.Structure
+	if ds.Structure != nil {
+		st = &dataset.Structure{}
+		st.Assign(ds.Structure)
+		st.Path = ""
+		// body hashing covers values derived from the body
+		st.Checksum = ""
+		st.Depth = 0
+		st.Entries = 0
+		st.ErrCount = 0
+		st.Length = 0
+	}
+	if err := writeHashSection(h, "structure", st); err != nil {
+		return "", err
+	}
+
+	var md *dataset.Meta
+	if ds.Meta != nil {
+		md = &dataset.Meta{}
+		md.Assign(ds.Meta)
+		md.Path = ""
+	}
+	if err := writeHashSection(h, "meta", md); err != nil {
+		return "", err
+	}
+
+	io.WriteString(h, "body\n")
+	if dsfs.IsExternalBodyPath(ds.BodyPath) && ds.Structure != nil {
+		// external bodies are too big to stream, they're covered by the checksum
+		// recorded when they were saved
+		io.WriteString(h, ds.Structure.Checksum)
+	} else if ds.BodyPath != "" {
+		body, err := fs.Get(ctx, ds.BodyPath)
+		if err != nil {
+			return "", fmt.Errorf("getting body: %s", err)
+		}
+		defer body.Close()
+		if _, err := io.Copy(h, body); err != nil {
+			return "", fmt.Errorf("reading body: %s", err)
+		}
+	}
+
+	mh, err := multihash.Encode(h.Sum(nil), multihash.SHA2_256)
+	if err != nil {
+		return "", err
+	}
+	return multihash.Multihash(mh).B58String(), nil
+}
+
+// writeHashSection writes a labelled, length-prefixed JSON encoding of a
+// component to a hash, so components can't run into one another
+func writeHashSection(w io.Writer, label string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding %s: %s", label, err)
+	}
+	_, err = fmt.Fprintf(w, "%s %d\n%s\n", label, len(data), data)
+	return err
+}
@@ -0,0 +1,44 @@
+package base
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qri-io/qri/base/dsfs"
+)
+
+func TestContentHash(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	hash := func(path string) string {
+		ds, err := dsfs.LoadDataset(ctx, r.Store(), path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := ContentHash(ctx, r.Filesystem(), ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	v1 := addCitiesDataset(t, r)
+	title := v1.Dataset.Meta.Title
+	v2 := updateCitiesDataset(t, r, "a different title")
+	v3 := updateCitiesDataset(t, r, title)
+
+	if v1.Path == v3.Path {
+		t.Fatal("expected versions with different commits to have different paths")
+	}
+	if hash(v1.Path) != hash(v3.Path) {
+		t.Error("expected versions with the same data to have the same content hash")
+	}
+	if hash(v1.Path) == hash(v2.Path) {
+		t.Error("expected versions with different meta to have different content hashes")
+	}
+
+	if _, err := ContentHash(ctx, r.Filesystem(), nil); err == nil {
+		t.Error("expected hashing a nil dataset to error")
+	}
+}
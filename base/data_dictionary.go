@@ -0,0 +1,180 @@
+package base
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
+)
+
+// DataDictionaryMetaKey is the meta field data dictionaries are stored in
+const DataDictionaryMetaKey = "dataDictionary"
+
+// maxExampleRows caps the number of body entries read looking for example
+// values, columns that are mostly null may end up with fewer examples
+const maxExampleRows = 1000
+
+// DataDictionary documents the columns of a dataset body, combining the
+// structure schema, column stats & meta into one human-readable document
+type DataDictionary struct {
+	// Ref is the dataset version the dictionary describes
+	Ref         string             `json:"ref,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Columns     []DictionaryColumn `json:"columns"`
+}
+
+// DictionaryColumn describes a single column in a data dictionary
+type DictionaryColumn struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Unit        string `json:"unit,omitempty"`
+	// Examples are distinct, non-null values from the first rows of the body
+	Examples []interface{} `json:"examples,omitempty"`
+	// Count is the number of non-null values, Nulls the number of nulls
+	Count int `json:"count"`
+	Nulls int `json:"nulls"`
+	// NullRate is the fraction of values in the column that are null
+	NullRate float64 `json:"nullRate"`
+}
+
+// NewDataDictionary creates a data dictionary from a dataset & a list of
+// column stats, as produced by the stats package. The dataset's body file,
+// if open, is read for up to numExamples example values per column
+func NewDataDictionary(ds *dataset.Dataset, stats []map[string]interface{}, numExamples int) (*DataDictionary, error) {
+	if ds.Structure == nil || ds.Structure.Schema == nil {
+		return nil, fmt.Errorf("dataset has no schema")
+	}
+	cols := Columns(ds.Structure.Schema)
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("dataset body has no columns to describe")
+	}
+
+	dd := &DataDictionary{Columns: make([]DictionaryColumn, len(cols))}
+	if ds.Meta != nil {
+		dd.Title = ds.Meta.Title
+		dd.Description = ds.Meta.Description
+	}
+
+	for i, c := range cols {
+		dc := DictionaryColumn{Name: c.Name}
+		dc.Description, _ = c.Schema["description"].(string)
+		dc.Unit, _ = c.Schema["unit"].(string)
+		dc.Type = schemaType(c.Schema)
+
+		if st := columnStat(stats, c); st != nil {
+			if dc.Type == "" {
+				dc.Type, _ = st["type"].(string)
+			}
+			dc.Count = statInt(st["count"])
+			dc.Nulls = statInt(st["nulls"])
+			if total := dc.Count + dc.Nulls; total > 0 {
+				dc.NullRate = float64(dc.Nulls) / float64(total)
+			}
+		}
+		dd.Columns[i] = dc
+	}
+
+	if numExamples > 0 && ds.BodyFile() != nil {
+		if err := addDictionaryExamples(dd, cols, ds.Structure, ds.BodyFile(), numExamples); err != nil {
+			return nil, err
+		}
+	}
+	return dd, nil
+}
+
+// addDictionaryExamples reads body entries until each column has n examples
+func addDictionaryExamples(dd *DataDictionary, cols []Column, st *dataset.Structure, file qfs.File, n int) error {
+	file, err := dsfs.DecompressBody(file, nil)
+	if err != nil {
+		return err
+	}
+	rr, err := dsio.NewEntryReader(st, file)
+	if err != nil {
+		return fmt.Errorf("error allocating data reader: %s", err)
+	}
+
+	for row := 0; row < maxExampleRows; row++ {
+		ent, err := rr.ReadEntry()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("error reading entry %d: %s", row, err)
+		}
+
+		full := true
+		for i, c := range cols {
+			dc := &dd.Columns[i]
+			if len(dc.Examples) >= n {
+				continue
+			}
+			if v, ok := columnValue(ent.Value, c); ok && v != nil && !containsValue(dc.Examples, v) {
+				dc.Examples = append(dc.Examples, v)
+			}
+			full = full && len(dc.Examples) >= n
+		}
+		if full {
+			return nil
+		}
+	}
+	return nil
+}
+
+// columnValue gets the value of a column from a row
+func columnValue(row interface{}, c Column) (interface{}, bool) {
+	switch r := row.(type) {
+	case []interface{}:
+		if c.Index < len(r) {
+			return r[c.Index], true
+		}
+	case map[string]interface{}:
+		v, ok := r[c.Name]
+		return v, ok
+	}
+	return nil, false
+}
+
+func containsValue(vals []interface{}, v interface{}) bool {
+	for _, val := range vals {
+		if reflect.DeepEqual(val, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaType describes the type of a column schema. Columns that allow
+// multiple types list them separated by "|"
+func schemaType(sch map[string]interface{}) string {
+	switch t := sch["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		str := ""
+		for i, v := range t {
+			if i > 0 {
+				str += "|"
+			}
+			str += fmt.Sprintf("%v", v)
+		}
+		return str
+	}
+	return ""
+}
+
+// statInt reads a count from stats, which may have been decoded from JSON
+func statInt(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case float64:
+		return int(n)
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package base
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+func TestNewDataDictionary(t *testing.T) {
+	ds := &dataset.Dataset{
+		Meta: &dataset.Meta{Title: "cities", Description: "big cities"},
+		Structure: &dataset.Structure{
+			Format: "json",
+			Schema: columnsSchema(
+				map[string]interface{}{"title": "city", "type": "string", "description": "city name"},
+				map[string]interface{}{"title": "pop", "type": []interface{}{"integer", "null"}, "unit": "people"},
+				map[string]interface{}{"title": "in_usa"},
+			),
+		},
+	}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[["toronto",null,false],["toronto",40000000,false],["chicago",300000,true],["new york",null,true]]`)))
+	stats := []map[string]interface{}{
+		{"type": "string", "count": 4},
+		{"type": "numeric", "count": float64(2), "nulls": float64(2)},
+		{"type": "boolean", "count": 4},
+	}
+
+	dd, err := NewDataDictionary(ds, stats, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := &DataDictionary{
+		Title:       "cities",
+		Description: "big cities",
+		Columns: []DictionaryColumn{
+			{Name: "city", Type: "string", Description: "city name", Examples: []interface{}{"toronto", "chicago"}, Count: 4},
+			{Name: "pop", Type: "integer|null", Unit: "people", Examples: []interface{}{int64(40000000), int64(300000)}, Count: 2, Nulls: 2, NullRate: 0.5},
+			{Name: "in_usa", Type: "boolean", Examples: []interface{}{false, true}, Count: 4},
+		},
+	}
+	if diff := cmp.Diff(expect, dd); diff != "" {
+		t.Errorf("data dictionary mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := NewDataDictionary(&dataset.Dataset{}, nil, 0); err == nil {
+		t.Errorf("expected a dataset without a schema to error")
+	}
+}
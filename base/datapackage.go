@@ -0,0 +1,567 @@
+package base
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base/fill"
+)
+
+// DataPackageFilename is the name of a Frictionless Data Package descriptor
+const DataPackageFilename = "datapackage.json"
+
+// WarnUnmappedFields is the code of a warning that fields of a data package
+// have no equivalent in a dataset, and were dropped
+const WarnUnmappedFields = "unmapped_fields"
+
+// DataPackage is a Frictionless Data Package descriptor, the contents of a
+// datapackage.json file. Only the parts of the spec qri maps to dataset
+// components are modeled. see https://specs.frictionlessdata.io/data-package
+type DataPackage struct {
+	Name         string                   `json:"name,omitempty"`
+	ID           string                   `json:"id,omitempty"`
+	Title        string                   `json:"title,omitempty"`
+	Description  string                   `json:"description,omitempty"`
+	Homepage     string                   `json:"homepage,omitempty"`
+	Version      string                   `json:"version,omitempty"`
+	Keywords     []string                 `json:"keywords,omitempty"`
+	Licenses     []DataPackageLicense     `json:"licenses,omitempty"`
+	Sources      []DataPackageSource      `json:"sources,omitempty"`
+	Contributors []DataPackageContributor `json:"contributors,omitempty"`
+	Resources    []DataResource           `json:"resources"`
+	// Extra holds package properties that aren't part of the spec. Meta fields
+	// without a data package equivalent are exported as extra properties
+	Extra map[string]interface{} `json:"-"`
+}
+
+// DataPackageLicense is a license of a data package
+type DataPackageLicense struct {
+	Name  string `json:"name,omitempty"`
+	Path  string `json:"path,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+// DataPackageSource is a source of the data in a data package
+type DataPackageSource struct {
+	Title string `json:"title,omitempty"`
+	Path  string `json:"path,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// DataPackageContributor is a person or organization that contributed to a
+// data package. ID isn't part of the spec, it keeps the id of a meta
+// contributor
+type DataPackageContributor struct {
+	ID    string `json:"id,omitempty"`
+	Title string `json:"title,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// DataResource is a data file of a data package
+type DataResource struct {
+	Name        string       `json:"name"`
+	Path        string       `json:"path,omitempty"`
+	Format      string       `json:"format,omitempty"`
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Schema      *TableSchema `json:"schema,omitempty"`
+	Dialect     *CSVDialect  `json:"dialect,omitempty"`
+	// JSONSchema isn't part of the spec, it keeps the schema of bodies that
+	// aren't tables, which can't be described by a table schema
+	JSONSchema map[string]interface{} `json:"jsonSchema,omitempty"`
+}
+
+// TableSchema describes the columns of a tabular data resource
+type TableSchema struct {
+	Fields []TableField `json:"fields"`
+}
+
+// TableField is a column of a table schema
+type TableField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// CSVDialect describes how a CSV data resource is formatted
+type CSVDialect struct {
+	Delimiter string `json:"delimiter,omitempty"`
+	Header    bool   `json:"header"`
+}
+
+// MarshalJSON writes extra properties alongside the package's spec properties
+func (pkg DataPackage) MarshalJSON() ([]byte, error) {
+	type plain DataPackage
+	data, err := json.Marshal(plain(pkg))
+	if err != nil || len(pkg.Extra) == 0 {
+		return data, err
+	}
+	fields := map[string]interface{}{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for k, v := range pkg.Extra {
+		if _, ok := fields[k]; !ok {
+			fields[k] = v
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// dataPackageMetaKeys are the keys of meta fields that map to data package
+// properties
+var dataPackageMetaKeys = map[string]bool{
+	"qri":          true,
+	"path":         true,
+	"title":        true,
+	"description":  true,
+	"homeURL":      true,
+	"identifier":   true,
+	"version":      true,
+	"keywords":     true,
+	"license":      true,
+	"citations":    true,
+	"contributors": true,
+}
+
+// DataPackageFromDataset describes a dataset as a data package with a single
+// resource. The resource path points to a body file named for the dataset,
+// see WriteDataPackage
+func DataPackageFromDataset(ds *dataset.Dataset) (*DataPackage, error) {
+	if ds.Structure == nil || ds.Structure.Format == "" {
+		return nil, fmt.Errorf("dataset has no body to package")
+	}
+	name := strings.ToLower(ds.Name)
+	if name == "" {
+		name = "body"
+	}
+	pkg := &DataPackage{Name: name}
+
+	if md := ds.Meta; md != nil {
+		pkg.ID = md.Identifier
+		pkg.Title = md.Title
+		pkg.Description = md.Description
+		pkg.Homepage = md.HomeURL
+		pkg.Version = md.Version
+		pkg.Keywords = md.Keywords
+		if md.License != nil {
+			pkg.Licenses = []DataPackageLicense{{Name: md.License.Type, Path: md.License.URL}}
+		}
+		for _, c := range md.Citations {
+			if c != nil {
+				pkg.Sources = append(pkg.Sources, DataPackageSource{Title: c.Name, Path: c.URL, Email: c.Email})
+			}
+		}
+		for _, u := range md.Contributors {
+			if u != nil {
+				pkg.Contributors = append(pkg.Contributors, DataPackageContributor{ID: u.ID, Title: u.Fullname, Email: u.Email})
+			}
+		}
+
+		data, err := json.Marshal(md)
+		if err != nil {
+			return nil, err
+		}
+		fields := map[string]interface{}{}
+		if err = json.Unmarshal(data, &fields); err != nil {
+			return nil, err
+		}
+		for k, v := range fields {
+			if dataPackageMetaKeys[k] {
+				continue
+			}
+			if pkg.Extra == nil {
+				pkg.Extra = map[string]interface{}{}
+			}
+			pkg.Extra[k] = v
+		}
+	}
+
+	st := ds.Structure
+	res := DataResource{
+		Name:   name,
+		Path:   path.Join("data", fmt.Sprintf("%s.%s", name, st.Format)),
+		Format: st.Format,
+	}
+	if fields, ok := tableFields(st.Schema); ok {
+		res.Schema = &TableSchema{Fields: fields}
+	} else if st.Schema != nil {
+		res.JSONSchema = st.Schema
+	}
+	if st.DataFormat() == dataset.CSVDataFormat {
+		res.Dialect = &CSVDialect{}
+		if hr, ok := st.FormatConfig["headerRow"].(bool); ok {
+			res.Dialect.Header = hr
+		}
+		res.Dialect.Delimiter = separatorString(st.FormatConfig["separator"])
+	}
+	pkg.Resources = []DataResource{res}
+	return pkg, nil
+}
+
+// tableFields describes the columns of a tabular schema as table schema
+// fields, reporting false for schemas that aren't tabular
+func tableFields(schema map[string]interface{}) ([]TableField, bool) {
+	if schema == nil || schema["type"] != "array" {
+		return nil, false
+	}
+	items, ok := schema["items"].(map[string]interface{})
+	if !ok || items["type"] != "array" {
+		return nil, false
+	}
+	cols, ok := items["items"].([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	fields := make([]TableField, len(cols))
+	for i, c := range cols {
+		col, ok := c.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		f := TableField{}
+		f.Name, _ = col["title"].(string)
+		f.Description, _ = col["description"].(string)
+		switch t := col["type"].(type) {
+		case string:
+			f.Type = t
+		case []interface{}:
+			// columns of more than one type are nullable or any type
+			var types []string
+			for _, v := range t {
+				if s, ok := v.(string); ok && s != "null" {
+					types = append(types, s)
+				}
+			}
+			if len(types) == 1 {
+				f.Type = types[0]
+			} else {
+				f.Type = "any"
+			}
+		}
+		if f.Type == "null" {
+			f.Type = "any"
+		}
+		fields[i] = f
+	}
+	return fields, true
+}
+
+// separatorString reads a csv separator from a format config, which may be a
+// string or a rune decoded as a number
+func separatorString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case rune:
+		return string(s)
+	case float64:
+		return string(rune(s))
+	case int:
+		return string(rune(s))
+	}
+	return ""
+}
+
+// WriteDataPackage writes a dataset as a zip archive holding a data package
+// descriptor, the dataset body as the package's only resource and the readme,
+// if any, as README.md. The dataset body & readme files must be open
+func WriteDataPackage(ds *dataset.Dataset, w io.Writer) error {
+	pkg, err := DataPackageFromDataset(ds)
+	if err != nil {
+		return err
+	}
+	if ds.BodyFile() == nil {
+		return fmt.Errorf("dataset body isn't open")
+	}
+
+	zw := zip.NewWriter(w)
+	desc, err := zw.Create(DataPackageFilename)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err = desc.Write(data); err != nil {
+		return err
+	}
+
+	body, err := zw.Create(pkg.Resources[0].Path)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(body, ds.BodyFile()); err != nil {
+		return err
+	}
+
+	if ds.Readme != nil && ds.Readme.ScriptFile() != nil {
+		readme, err := zw.Create("README.md")
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(readme, ds.Readme.ScriptFile()); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// dataPackageProps are the spec properties of a data package, mapped or not.
+// Properties outside the spec are kept as meta fields
+var dataPackageProps = map[string]bool{
+	"name":         true,
+	"id":           true,
+	"title":        true,
+	"description":  true,
+	"homepage":     true,
+	"version":      true,
+	"keywords":     true,
+	"licenses":     true,
+	"sources":      true,
+	"contributors": true,
+	"resources":    true,
+	"profile":      true,
+	"image":        true,
+	"created":      true,
+}
+
+// DatasetFromDataPackage reads the components of a dataset from a data package
+// descriptor. Packages with more than one resource must name the resource to
+// read. The body path is the resource path as written, callers resolve paths
+// relative to the descriptor. Package fields without a dataset equivalent are
+// dropped, returning warnings naming them
+func DatasetFromDataPackage(r io.Reader, resource string) (*dataset.Dataset, []Warning, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	fields := map[string]interface{}{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, nil, fmt.Errorf("invalid data package: %s", err)
+	}
+	pkg := &DataPackage{}
+	if err = json.Unmarshal(data, pkg); err != nil {
+		return nil, nil, fmt.Errorf("invalid data package: %s", err)
+	}
+
+	res, resFields, err := pickDataResource(pkg, fields, resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u := &unmapped{}
+	ds := &dataset.Dataset{Name: pkg.Name}
+	md := &dataset.Meta{
+		Identifier:  pkg.ID,
+		Title:       pkg.Title,
+		Description: pkg.Description,
+		HomeURL:     pkg.Homepage,
+		Version:     pkg.Version,
+		Keywords:    pkg.Keywords,
+	}
+	for i, l := range pkg.Licenses {
+		if i > 0 {
+			u.add("meta", fmt.Sprintf("licenses[%d]", i))
+			continue
+		}
+		md.License = &dataset.License{Type: l.Name, URL: l.Path}
+		if l.Title != "" {
+			u.add("meta", "licenses[0].title")
+		}
+	}
+	for _, s := range pkg.Sources {
+		md.Citations = append(md.Citations, &dataset.Citation{Name: s.Title, URL: s.Path, Email: s.Email})
+	}
+	for _, c := range pkg.Contributors {
+		md.Contributors = append(md.Contributors, &dataset.User{ID: c.ID, Fullname: c.Title, Email: c.Email})
+	}
+	u.addUnknown("meta", "sources", fields["sources"], DataPackageSource{})
+	u.addUnknown("meta", "contributors", fields["contributors"], DataPackageContributor{})
+	for k, v := range fields {
+		if !dataPackageProps[k] {
+			if err := md.Set(k, v); err != nil {
+				return nil, nil, fmt.Errorf("data package property %q: %s", k, err)
+			}
+		} else if k == "profile" || k == "image" || k == "created" {
+			u.add("meta", k)
+		}
+	}
+	if !md.IsEmpty() || len(md.Meta()) > 0 {
+		ds.Meta = md
+	}
+
+	st := &dataset.Structure{Format: strings.ToLower(res.Format)}
+	if st.Format == "" {
+		st.Format = strings.TrimPrefix(strings.ToLower(path.Ext(res.Path)), ".")
+	}
+	if res.Schema != nil {
+		st.Schema = tableSchema(res.Schema)
+		for i, f := range res.Schema.Fields {
+			if t := jsonSchemaType(f.Type); t != f.Type && f.Type != "any" {
+				u.add("structure", fmt.Sprintf("schema.fields[%d].type (%s read as %s)", i, f.Type, t))
+			}
+		}
+		if sch, ok := resFields["schema"].(map[string]interface{}); ok {
+			u.addKeys("structure", "schema", sch, TableSchema{})
+			u.addUnknown("structure", "schema.fields", sch["fields"], TableField{})
+		}
+	} else if res.JSONSchema != nil {
+		st.Schema = res.JSONSchema
+	}
+	if st.DataFormat() == dataset.CSVDataFormat {
+		// data package dialects default to a header row
+		st.FormatConfig = map[string]interface{}{"headerRow": true}
+		if dialect, ok := resFields["dialect"].(map[string]interface{}); ok {
+			if res.Dialect != nil {
+				if _, ok := dialect["header"]; ok {
+					st.FormatConfig["headerRow"] = res.Dialect.Header
+				}
+				if res.Dialect.Delimiter != "" && res.Dialect.Delimiter != "," {
+					st.FormatConfig["separator"] = res.Dialect.Delimiter
+				}
+			}
+			u.addKeys("structure", "dialect", dialect, CSVDialect{})
+		}
+	}
+	ds.Structure = st
+
+	if inline, ok := resFields["data"]; ok {
+		ds.Body = inline
+		if st.Format == "" {
+			st.Format = "json"
+		}
+	} else if res.Path != "" {
+		ds.BodyPath = res.Path
+	} else {
+		return nil, nil, fmt.Errorf("data package resource %q has no path or data", res.Name)
+	}
+	delete(resFields, "data")
+	u.addKeys("body", "resources."+res.Name, resFields, DataResource{})
+
+	return ds, u.warnings(), nil
+}
+
+// pickDataResource chooses the resource of a data package to read, returning
+// the resource & its raw fields
+func pickDataResource(pkg *DataPackage, fields map[string]interface{}, name string) (DataResource, map[string]interface{}, error) {
+	raw, _ := fields["resources"].([]interface{})
+	if len(pkg.Resources) == 0 || len(raw) != len(pkg.Resources) {
+		return DataResource{}, nil, fmt.Errorf("data package has no resources")
+	}
+	if name == "" && len(pkg.Resources) > 1 {
+		return DataResource{}, nil, fmt.Errorf("data package has %d resources, choose one of: %s", len(pkg.Resources), strings.Join(resourceNames(pkg), ", "))
+	}
+	for i, res := range pkg.Resources {
+		if name == "" || res.Name == name {
+			resFields, _ := raw[i].(map[string]interface{})
+			if resFields == nil {
+				resFields = map[string]interface{}{}
+			}
+			return res, resFields, nil
+		}
+	}
+	return DataResource{}, nil, fmt.Errorf("data package has no resource named %q, choose one of: %s", name, strings.Join(resourceNames(pkg), ", "))
+}
+
+func resourceNames(pkg *DataPackage) []string {
+	names := make([]string, len(pkg.Resources))
+	for i, res := range pkg.Resources {
+		names[i] = res.Name
+	}
+	return names
+}
+
+// tableSchema converts a table schema to the json schema of a tabular body
+func tableSchema(ts *TableSchema) map[string]interface{} {
+	cols := make([]interface{}, len(ts.Fields))
+	for i, f := range ts.Fields {
+		col := map[string]interface{}{"title": f.Name}
+		if t := jsonSchemaType(f.Type); t != "" {
+			col["type"] = t
+		}
+		if f.Description != "" {
+			col["description"] = f.Description
+		}
+		cols[i] = col
+	}
+	return map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": cols,
+		},
+	}
+}
+
+// jsonSchemaType maps a table schema field type to a json schema type. Types
+// of dates, times & geographic values are read as strings, "any" has no type
+func jsonSchemaType(t string) string {
+	switch t {
+	case "string", "integer", "number", "boolean", "object", "array":
+		return t
+	case "", "any":
+		return ""
+	default:
+		return "string"
+	}
+}
+
+// unmapped collects data package fields that have no dataset equivalent, by
+// component
+type unmapped struct {
+	fields map[string][]string
+}
+
+func (u *unmapped) add(component, field string) {
+	if u.fields == nil {
+		u.fields = map[string][]string{}
+	}
+	u.fields[component] = append(u.fields[component], field)
+}
+
+// addKeys adds the keys of fields that don't match a field of the struct v
+func (u *unmapped) addKeys(component, prefix string, fields map[string]interface{}, v interface{}) {
+	for _, k := range fill.UnknownFields(fields, v) {
+		u.add(component, prefix+"."+k)
+	}
+}
+
+// addUnknown adds unknown keys of each object in a list of objects
+func (u *unmapped) addUnknown(component, prefix string, list interface{}, v interface{}) {
+	objs, _ := list.([]interface{})
+	for i, o := range objs {
+		if fields, ok := o.(map[string]interface{}); ok {
+			u.addKeys(component, fmt.Sprintf("%s[%d]", prefix, i), fields, v)
+		}
+	}
+}
+
+func (u *unmapped) warnings() []Warning {
+	var components []string
+	for c := range u.fields {
+		components = append(components, c)
+	}
+	sort.Strings(components)
+	warnings := make([]Warning, 0, len(components))
+	for _, c := range components {
+		warnings = append(warnings, Warning{
+			Code:      WarnUnmappedFields,
+			Message:   fmt.Sprintf("data package fields have no dataset equivalent and were dropped: %s", strings.Join(u.fields[c], ", ")),
+			Component: c,
+		})
+	}
+	if len(warnings) == 0 {
+		return nil
+	}
+	return warnings
+}
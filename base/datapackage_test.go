@@ -0,0 +1,105 @@
+package base
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestDatasetFromDataPackage(t *testing.T) {
+	pkg := `{
+  "name": "cities",
+  "title": "World Cities",
+  "profile": "tabular-data-package",
+  "licenses": [{ "name": "ODC-PDDL-1.0", "path": "http://opendatacommons.org/licenses/pddl/" }],
+  "contributors": [{ "title": "Joe Bloggs", "email": "joe@example.com", "role": "author" }],
+  "theme": ["geography"],
+  "resources": [
+    {
+      "name": "cities",
+      "path": "data/cities.csv",
+      "mediatype": "text/csv",
+      "dialect": { "delimiter": ";", "header": true, "quoteChar": "'" },
+      "schema": {
+        "fields": [
+          { "name": "city", "type": "string", "description": "name of the city" },
+          { "name": "founded", "type": "date", "format": "%Y" },
+          { "name": "population", "type": "integer" }
+        ],
+        "primaryKey": "city"
+      }
+    },
+    { "name": "countries", "data": [["france"], ["spain"]] }
+  ]
+}`
+
+	if _, _, err := DatasetFromDataPackage(strings.NewReader(pkg), ""); err == nil || !strings.Contains(err.Error(), "cities, countries") {
+		t.Errorf("expected error listing resources of a package with many, got: %v", err)
+	}
+	if _, _, err := DatasetFromDataPackage(strings.NewReader(pkg), "towns"); err == nil || !strings.Contains(err.Error(), "cities, countries") {
+		t.Errorf("expected error listing resources when resource isn't found, got: %v", err)
+	}
+
+	ds, warnings, err := DatasetFromDataPackage(strings.NewReader(pkg), "cities")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Name != "cities" || ds.BodyPath != "data/cities.csv" {
+		t.Errorf("expected name & body path of resource, got: %q, %q", ds.Name, ds.BodyPath)
+	}
+	if ds.Meta.Title != "World Cities" || ds.Meta.License.Type != "ODC-PDDL-1.0" || ds.Meta.Contributors[0].Fullname != "Joe Bloggs" {
+		t.Errorf("meta mismatch: %#v", ds.Meta)
+	}
+	if diff := cmp.Diff([]string{"geography"}, ds.Meta.Theme); diff != "" {
+		t.Errorf("expected properties outside the spec to be kept as meta (-want +got):\n%s", diff)
+	}
+
+	expectSt := &dataset.Structure{
+		Format:       "csv",
+		FormatConfig: map[string]interface{}{"headerRow": true, "separator": ";"},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "city", "type": "string", "description": "name of the city"},
+					map[string]interface{}{"title": "founded", "type": "string"},
+					map[string]interface{}{"title": "population", "type": "integer"},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expectSt, ds.Structure, cmp.AllowUnexported(dataset.Structure{})); diff != "" {
+		t.Errorf("structure mismatch (-want +got):\n%s", diff)
+	}
+
+	got := map[string]string{}
+	for _, w := range warnings {
+		if w.Code != WarnUnmappedFields {
+			t.Errorf("unexpected warning code: %s", w.Code)
+		}
+		got[w.Component] = w.Message
+	}
+	expect := map[string][]string{
+		"meta":      {"profile", "contributors[0].role"},
+		"structure": {"schema.primaryKey", "schema.fields[1].format", "schema.fields[1].type (date read as string)", "dialect.quoteChar"},
+		"body":      {"resources.cities.mediatype"},
+	}
+	for component, fields := range expect {
+		for _, f := range fields {
+			if !strings.Contains(got[component], f) {
+				t.Errorf("expected %s warning to name %q, got: %q", component, f, got[component])
+			}
+		}
+	}
+
+	ds, _, err = DatasetFromDataPackage(strings.NewReader(pkg), "countries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Structure.Format != "json" || ds.Body == nil {
+		t.Errorf("expected inline resource data to be read as a json body, got format: %q body: %v", ds.Structure.Format, ds.Body)
+	}
+}
@@ -10,18 +10,39 @@ import (
 	"github.com/qri-io/qfs/cafs"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
 	reporef "github.com/qri-io/qri/repo/ref"
 )
 
 // OpenDataset prepares a dataset for use, checking each component
 // for populated Path or Byte suffixed fields, consuming those fields to
-// set File handlers that are ready for reading
+// set File handlers that are ready for reading. External bodies are only
+// opened if the context allows it, see dsfs.AllowExternalBodies
 func OpenDataset(ctx context.Context, fsys qfs.Filesystem, ds *dataset.Dataset) (err error) {
-	if ds.BodyFile() == nil {
+	if ds.BodyFile() == nil && dsfs.ExternalBodyURI(ds) != "" {
+		if dsfs.ExternalBodiesAllowed(ctx) {
+			var body qfs.File
+			if body, err = dsfs.LoadExternalBody(ctx, ds); err != nil {
+				return
+			}
+			ds.SetBodyFile(body)
+		}
+	} else if ds.BodyFile() == nil {
 		if err = ds.OpenBodyFile(ctx, fsys); err != nil {
 			log.Debug(err)
 			return
 		}
+		// unsaved bodies are given as-is, structure.compression applies once
+		// they're stored
+		st := ds.Structure
+		if ds.Path == "" {
+			st = nil
+		}
+		var body qfs.File
+		if body, err = dsfs.DecompressBody(ds.BodyFile(), st); err != nil {
+			return
+		}
+		ds.SetBodyFile(body)
 	}
 	if ds.Transform != nil && ds.Transform.ScriptFile() == nil {
 		if err = ds.Transform.OpenScriptFile(ctx, fsys); err != nil {
@@ -95,8 +116,9 @@ func CloseDataset(ds *dataset.Dataset) (err error) {
 	return
 }
 
-// ListDatasets lists datasets from a repo
-func ListDatasets(ctx context.Context, r repo.Repo, term string, limit, offset int, RPC, publishedOnly, showVersions bool) (res []reporef.DatasetRef, err error) {
+// ListDatasets lists datasets from a repo. If profileIDs is non-empty only
+// datasets authored by one of the given profiles are listed
+func ListDatasets(ctx context.Context, r repo.Repo, term string, profileIDs []profile.ID, limit, offset int, RPC, publishedOnly, showVersions bool) (res []reporef.DatasetRef, err error) {
 	store := r.Store()
 	num, err := r.RefCount()
 	if err != nil {
@@ -120,6 +142,22 @@ func ListDatasets(ctx context.Context, r repo.Repo, term string, limit, offset i
 		res = matched[:i]
 	}
 
+	if len(profileIDs) > 0 {
+		ids := make(map[profile.ID]bool, len(profileIDs))
+		for _, id := range profileIDs {
+			ids[id] = true
+		}
+		matched := make([]reporef.DatasetRef, len(res))
+		i := 0
+		for _, ref := range res {
+			if ids[ref.ProfileID] {
+				matched[i] = ref
+				i++
+			}
+		}
+		res = matched[:i]
+	}
+
 	if publishedOnly {
 		pub := make([]reporef.DatasetRef, len(res))
 		i := 0
@@ -177,27 +215,89 @@ func ListDatasets(ctx context.Context, r repo.Repo, term string, limit, offset i
 	return
 }
 
+// ListDatasetsByKeyword lists datasets with a latest version that carries
+// keyword in its meta keywords. Keywords match ignoring case & surrounding
+// whitespace. Matching refs include their latest version, datasets that
+// aren't stored locally are skipped
+func ListDatasetsByKeyword(ctx context.Context, r repo.Repo, keyword string) ([]reporef.DatasetRef, error) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return nil, fmt.Errorf("keyword is required")
+	}
+	refs, err := RawDatasetRefList(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []reporef.DatasetRef{}
+	for _, ref := range refs {
+		if ref.Path == "" {
+			continue
+		}
+		ds, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path)
+		if err != nil {
+			log.Debugf("loading %s: %s", ref, err)
+			continue
+		}
+		if ds.Meta == nil || !hasKeyword(ds.Meta.Keywords, keyword) {
+			continue
+		}
+		if err := repo.CanonicalizeProfile(r, &ref); err != nil {
+			return nil, err
+		}
+		ds.Peername = ref.Peername
+		ds.Name = ref.Name
+		ref.Dataset = ds
+		res = append(res, ref)
+	}
+	return res, nil
+}
+
+func hasKeyword(keywords []string, keyword string) bool {
+	for _, kw := range keywords {
+		if strings.EqualFold(strings.TrimSpace(kw), keyword) {
+			return true
+		}
+	}
+	return false
+}
+
 // RawDatasetRefs converts the dataset refs to a string
 func RawDatasetRefs(ctx context.Context, r repo.Repo) (string, error) {
-	num, err := r.RefCount()
+	refs, err := RawDatasetRefList(ctx, r)
 	if err != nil {
 		return "", err
 	}
+	return FormatRawDatasetRefs(refs), nil
+}
+
+// RawDatasetRefList lists every reference record in the repo's refstore as
+// stored, including FSIPath & published flags
+func RawDatasetRefList(ctx context.Context, r repo.Repo) ([]reporef.DatasetRef, error) {
+	num, err := r.RefCount()
+	if err != nil {
+		return nil, err
+	}
 	res, err := r.References(0, num)
 	if err != nil {
 		log.Debug(err.Error())
-		return "", fmt.Errorf("error getting dataset list: %s", err.Error())
+		return nil, fmt.Errorf("error getting dataset list: %s", err.Error())
 	}
+	return res, nil
+}
 
+// FormatRawDatasetRefs renders reference records as an indexed,
+// human-readable list
+func FormatRawDatasetRefs(refs []reporef.DatasetRef) string {
 	// Calculate the largest index, and get its length
-	width := len(fmt.Sprintf("%d", num-1))
+	width := len(fmt.Sprintf("%d", len(refs)-1))
 	// Padding for each row to stringify
 	padding := strings.Repeat(" ", width)
 	// A printf template for stringifying indexes, such that they all have the same size
 	numTemplate := fmt.Sprintf("%%%dd", width)
 
 	builder := strings.Builder{}
-	for n, ref := range res {
+	for n, ref := range refs {
 		datasetNum := fmt.Sprintf(numTemplate, n)
 		fmt.Fprintf(&builder, "%s Peername:  %s\n", datasetNum, ref.Peername)
 		fmt.Fprintf(&builder, "%s ProfileID: %s\n", padding, ref.ProfileID)
@@ -206,7 +306,7 @@ func RawDatasetRefs(ctx context.Context, r repo.Repo) (string, error) {
 		fmt.Fprintf(&builder, "%s FSIPath:   %s\n", padding, ref.FSIPath)
 		fmt.Fprintf(&builder, "%s Published: %v\n", padding, ref.Published)
 	}
-	return builder.String(), nil
+	return builder.String()
 }
 
 // FetchDataset grabs a dataset from a remote source
@@ -232,6 +332,9 @@ func FetchDataset(ctx context.Context, r repo.Repo, ref *reporef.DatasetRef, pin
 	if pin {
 		if err = PinDataset(ctx, r, *ref); err != nil {
 			log.Debug(err.Error())
+			if _, ok := err.(*repo.QuotaExceededError); ok {
+				return err
+			}
 			return fmt.Errorf("error pinning root key: %s", err.Error())
 		}
 	}
@@ -292,12 +395,32 @@ func ReadDataset(ctx context.Context, r repo.Repo, ref *reporef.DatasetRef) (err
 	return cafs.ErrNotFound
 }
 
-// PinDataset marks a dataset for retention in a store
+// PinDataset marks a dataset for retention in a store. Pinning is refused with
+// a *repo.QuotaExceededError if the version's body would take the repo past
+// its quota
 func PinDataset(ctx context.Context, r repo.Repo, ref reporef.DatasetRef) error {
-	if pinner, ok := r.Store().(cafs.Pinner); ok {
-		return pinner.Pin(ctx, ref.Path, true)
+	pinner, ok := r.Store().(cafs.Pinner)
+	if !ok {
+		return repo.ErrNotPinner
 	}
-	return repo.ErrNotPinner
+
+	var size int64
+	if u := DiskUsageOf(r); u != nil {
+		if ds, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path); err == nil {
+			size = versionBodyBytes(ctx, r.Store(), ds)
+		} else {
+			log.Debugf("loading %s for disk usage: %s", ref.Path, err)
+		}
+		if err := u.CheckQuota(size); err != nil {
+			return err
+		}
+	}
+
+	if err := pinner.Pin(ctx, ref.Path, true); err != nil {
+		return err
+	}
+	accountBytes(r, size)
+	return nil
 }
 
 // UnpinDataset unmarks a dataset for retention in a store
@@ -37,7 +37,7 @@ func PrepareDatasetSave(ctx context.Context, r repo.Repo, peername, name string)
 	// Determine if the save is creating a new dataset or updating an existing dataset by
 	// seeing if the name can canonicalize to a repo that we know about
 	lookup := &reporef.DatasetRef{Name: name, Peername: peername}
-	if err = repo.CanonicalizeDatasetRef(r, lookup); err == repo.ErrNotFound || lookup.Path == "" {
+	if err = repo.CanonicalizeDatasetRef(r, lookup); repo.IsNotFound(err) || lookup.Path == "" {
 		return &dataset.Dataset{}, &dataset.Dataset{}, "", nil
 	}
 
@@ -46,7 +46,8 @@ func PrepareDatasetSave(ctx context.Context, r repo.Repo, peername, name string)
 	if prev, err = dsfs.LoadDataset(ctx, r.Store(), prevPath); err != nil {
 		return
 	}
-	if prev.BodyPath != "" {
+	// external bodies are opened fresh by the save that needs them
+	if prev.BodyPath != "" && dsfs.ExternalBodyURI(prev) == "" {
 		var body qfs.File
 		body, err = dsfs.LoadBody(ctx, r.Store(), prev)
 		if err != nil {
@@ -75,8 +76,11 @@ func MaybeInferName(ds *dataset.Dataset) bool {
 	return false
 }
 
-// InferValues populates any missing fields that must exist to create a snapshot
-func InferValues(pro *profile.Profile, ds *dataset.Dataset) error {
+// InferValues populates any missing fields that must exist to create a snapshot,
+// returning warnings describing inference decisions users should know about
+func InferValues(pro *profile.Profile, ds *dataset.Dataset) ([]Warning, error) {
+	var warnings []Warning
+
 	// infer commit values
 	if ds.Commit == nil {
 		ds.Commit = &dataset.Commit{}
@@ -92,26 +96,40 @@ func InferValues(pro *profile.Profile, ds *dataset.Dataset) error {
 		// use a TeeReader that writes to a buffer to preserve data
 		buf := &bytes.Buffer{}
 		tr := io.TeeReader(body, buf)
-		var df dataset.DataFormat
 
-		df, err := detect.ExtensionDataFormat(body.FileName())
+		peek := make([]byte, sniffLen)
+		n, err := io.ReadFull(tr, peek)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("reading body: %s", err)
+		}
+		peek = peek[:n]
+
+		df, sniffed, err := bodyDataFormat(ds.Structure, body.FileName(), peek)
 		if err != nil {
-			log.Debug(err.Error())
-			err = fmt.Errorf("invalid data format: %s", err.Error())
-			return err
+			return nil, err
+		}
+		if sniffed {
+			warnings = append(warnings, Warning{
+				Code:      WarnSniffedFormat,
+				Message:   fmt.Sprintf("couldn't determine the format of %q from its name, detected %s from the contents", body.FileName(), df),
+				Component: "structure",
+			})
 		}
 
-		guessedStructure, _, err := detect.FromReader(df, tr)
+		guessedStructure, _, err := detect.FromReader(df, io.MultiReader(bytes.NewReader(peek), tr))
 		if err != nil {
 			log.Debug(err.Error())
 			err = fmt.Errorf("determining dataset structure: %s", err.Error())
-			return err
+			return nil, err
 		}
 
 		// attach the structure, schema, and formatConfig, as appropriate
 		if ds.Structure == nil {
 			ds.Structure = guessedStructure
 		}
+		if ds.Structure.Format == "" {
+			ds.Structure.Format = guessedStructure.Format
+		}
 		if ds.Structure.Schema == nil {
 			ds.Structure.Schema = guessedStructure.Schema
 		}
@@ -129,7 +147,28 @@ func InferValues(pro *profile.Profile, ds *dataset.Dataset) error {
 		ds.Transform = nil
 	}
 
-	return nil
+	return warnings, nil
+}
+
+// bodyDataFormat determines the format of a body, preferring an explicitly set
+// structure format, then the body filename extension. If neither is
+// conclusive the leading bytes of the body are sniffed
+func bodyDataFormat(st *dataset.Structure, filename string, peek []byte) (df dataset.DataFormat, sniffed bool, err error) {
+	if st != nil && st.Format != "" {
+		df, err = dataset.ParseDataFormatString(st.Format)
+		return df, false, err
+	}
+
+	if df, err = detect.ExtensionDataFormat(filename); err == nil {
+		return df, false, nil
+	}
+	log.Debugf("detecting format from extension: %s. sniffing body contents", err)
+
+	if df, err = SniffDataFormat(peek); err != nil {
+		log.Debug(err.Error())
+		return df, false, fmt.Errorf("invalid data format: %s", err.Error())
+	}
+	return df, true, nil
 }
 
 // ValidateDataset checks that a dataset is semantically valid
@@ -63,7 +63,7 @@ func TestInferValues(t *testing.T) {
 		t.Fatal(err)
 	}
 	ds := &dataset.Dataset{}
-	if err = InferValues(pro, ds); err != nil {
+	if _, err = InferValues(pro, ds); err != nil {
 		t.Error(err)
 	}
 	expectAuthorID := `9tmwSYB7dPRUXaEwJRNgzb6NbwPYNXrYyeahyHPAUqrTYd3Z6bVS9z1mCDsRmvb`
@@ -98,7 +98,7 @@ func TestInferValuesStructure(t *testing.T) {
 	ds.SetBodyFile(qfs.NewMemfileBytes("animals.csv",
 		[]byte("Animal,Sound,Weight\ncat,meow,1.4\ndog,bark,3.7\n")))
 
-	if err = InferValues(pro, ds); err != nil {
+	if _, err = InferValues(pro, ds); err != nil {
 		t.Error(err)
 	}
 
@@ -132,7 +132,7 @@ func TestInferValuesSchema(t *testing.T) {
 	}
 	ds.SetBodyFile(qfs.NewMemfileBytes("animals.csv",
 		[]byte("Animal,Sound,Weight\ncat,meow,1.4\ndog,bark,3.7\n")))
-	if err = InferValues(pro, ds); err != nil {
+	if _, err = InferValues(pro, ds); err != nil {
 		t.Error(err)
 	}
 
@@ -177,7 +177,7 @@ func TestInferValuesDontOverwriteSchema(t *testing.T) {
 	}
 	ds.SetBodyFile(qfs.NewMemfileBytes("animals.csv",
 		[]byte("Animal,Sound,Weight\ncat,meow,1.4\ndog,bark,3.7\n")))
-	if err = InferValues(pro, ds); err != nil {
+	if _, err = InferValues(pro, ds); err != nil {
 		t.Error(err)
 	}
 
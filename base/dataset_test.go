@@ -6,10 +6,13 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dstest"
+	"github.com/qri-io/qfs"
 	"github.com/qri-io/qfs/cafs"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
 	reporef "github.com/qri-io/qri/repo/ref"
 	repotest "github.com/qri-io/qri/repo/test"
 )
@@ -20,7 +23,7 @@ func TestListDatasets(t *testing.T) {
 	ref := addCitiesDataset(t, r)
 
 	// Limit to one
-	res, err := ListDatasets(ctx, r, "", 1, 0, false, false, false)
+	res, err := ListDatasets(ctx, r, "", nil, 1, 0, false, false, false)
 	if err != nil {
 		t.Error(err.Error())
 	}
@@ -29,7 +32,7 @@ func TestListDatasets(t *testing.T) {
 	}
 
 	// Limit to published datasets
-	res, err = ListDatasets(ctx, r, "", 1, 0, false, true, false)
+	res, err = ListDatasets(ctx, r, "", nil, 1, 0, false, true, false)
 	if err != nil {
 		t.Error(err.Error())
 	}
@@ -43,7 +46,7 @@ func TestListDatasets(t *testing.T) {
 	}
 
 	// Limit to published datasets, after publishing cities
-	res, err = ListDatasets(ctx, r, "", 1, 0, false, true, false)
+	res, err = ListDatasets(ctx, r, "", nil, 1, 0, false, true, false)
 	if err != nil {
 		t.Error(err.Error())
 	}
@@ -53,7 +56,7 @@ func TestListDatasets(t *testing.T) {
 	}
 
 	// Limit to datasets with "city" in their name
-	res, err = ListDatasets(ctx, r, "city", 1, 0, false, false, false)
+	res, err = ListDatasets(ctx, r, "city", nil, 1, 0, false, false, false)
 	if err != nil {
 		t.Error(err.Error())
 	}
@@ -62,13 +65,30 @@ func TestListDatasets(t *testing.T) {
 	}
 
 	// Limit to datasets with "cit" in their name
-	res, err = ListDatasets(ctx, r, "cit", 1, 0, false, false, false)
+	res, err = ListDatasets(ctx, r, "cit", nil, 1, 0, false, false, false)
 	if err != nil {
 		t.Error(err.Error())
 	}
 	if len(res) != 1 {
 		t.Error("expected one dataset with \"cit\" in their name")
 	}
+
+	// Limit to datasets authored by a set of profiles
+	res, err = ListDatasets(ctx, r, "", []profile.ID{"other", ref.ProfileID}, 1, 0, false, false, false)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	if len(res) != 1 {
+		t.Error("expected one dataset authored by the listed profiles")
+	}
+
+	res, err = ListDatasets(ctx, r, "", []profile.ID{"other"}, 1, 0, false, false, false)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	if len(res) != 0 {
+		t.Error("expected no datasets authored by an unknown profile")
+	}
 }
 
 func TestFetchDataset(t *testing.T) {
@@ -132,6 +152,58 @@ func TestDatasetPinning(t *testing.T) {
 	}
 }
 
+func TestListDatasetsByKeyword(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	save := func(name string, keywords ...string) {
+		t.Helper()
+		ds := &dataset.Dataset{
+			Peername:  "me",
+			Name:      name,
+			Meta:      &dataset.Meta{Keywords: keywords},
+			Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte("[1]")))
+		if _, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("rainfall", "climate", "Weather")
+	save("budget", "finance")
+	save("emissions", " Climate ")
+	addCitiesDataset(t, r)
+
+	names := func(keyword string) []string {
+		t.Helper()
+		refs, err := ListDatasetsByKeyword(ctx, r, keyword)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res []string
+		for _, ref := range refs {
+			if ref.Dataset == nil || ref.Dataset.Meta == nil {
+				t.Errorf("%s: expected matches to include their latest version", ref)
+			}
+			res = append(res, ref.Name)
+		}
+		return res
+	}
+
+	if diff := cmp.Diff([]string{"emissions", "rainfall"}, names("climate")); diff != "" {
+		t.Errorf("climate mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"rainfall"}, names("weather")); diff != "" {
+		t.Errorf("weather mismatch (-want +got):\n%s", diff)
+	}
+	if got := names("sports"); len(got) != 0 {
+		t.Errorf("expected no matches, got: %v", got)
+	}
+	if _, err := ListDatasetsByKeyword(ctx, r, " "); err == nil {
+		t.Error("expected an empty keyword to error")
+	}
+}
+
 func TestRawDatasetRefs(t *testing.T) {
 	// to keep hashes consistent, artificially specify the timestamp by overriding
 	// the dsfs.Timestamp func
@@ -147,10 +219,25 @@ func TestRawDatasetRefs(t *testing.T) {
 	r := newTestRepo(t)
 	addCitiesDataset(t, r)
 
-	actual, err := RawDatasetRefs(ctx, r)
+	refs, err := RawDatasetRefList(ctx, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pro, err := r.Profile()
 	if err != nil {
 		t.Fatal(err)
 	}
+	expectRefs := []reporef.DatasetRef{{
+		Peername:  "peer",
+		ProfileID: pro.ID,
+		Name:      "cities",
+		Path:      "/map/QmbU34XVYPGeEGjJ93rBm4Nac2g4hBYFouDnu9p9psccDB",
+	}}
+	if diff := cmp.Diff(expectRefs, refs); diff != "" {
+		t.Errorf("refs mismatch (-want +got):\n%s", diff)
+	}
+
+	actual := FormatRawDatasetRefs(refs)
 	expect := `0 Peername:  peer
   ProfileID: 9tmwSYB7dPRUXaEwJRNgzb6NbwPYNXrYyeahyHPAUqrTYd3Z6bVS9z1mCDsRmvb
   Name:      cities
@@ -0,0 +1,213 @@
+package base
+
+import (
+	"context"
+	"sort"
+
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// BlockInfo describes a block of a stored version
+type BlockInfo struct {
+	ID   string `json:"id"`
+	Size uint64 `json:"size"`
+	// Component names the dataset component the block belongs to, eg: "body",
+	// blocks of the dataset document itself are "dataset"
+	Component string `json:"component"`
+	// Root is true for the first block of a component
+	Root bool `json:"root,omitempty"`
+}
+
+// VersionBlocksFunc lists every block of a stored version
+type VersionBlocksFunc func(ctx context.Context, path string) ([]BlockInfo, error)
+
+// SharedBlock is a block referenced more than once across stored versions
+type SharedBlock struct {
+	ID   string `json:"id"`
+	Size uint64 `json:"size"`
+	// References counts the versions that include the block
+	References int `json:"references"`
+	// Saved is the bytes deduplicating the block saves
+	Saved      uint64   `json:"saved"`
+	Components []string `json:"components"`
+	Datasets   []string `json:"datasets"`
+}
+
+// SharedBody is a body stored once but used by more than one dataset
+type SharedBody struct {
+	ID       string   `json:"id"`
+	Size     uint64   `json:"size"`
+	Datasets []string `json:"datasets"`
+}
+
+// DedupReport compares the storage used by stored dataset versions with the
+// space they'd take up if no blocks were shared
+type DedupReport struct {
+	// Versions is the number of stored versions measured
+	Versions int `json:"versions"`
+	// Skipped counts versions that couldn't be measured
+	Skipped int `json:"skipped"`
+	// Blocks is the number of distinct blocks
+	Blocks int `json:"blocks"`
+	// LogicalSize is the total size of every version counted separately
+	LogicalSize uint64 `json:"logicalSize"`
+	// StoredSize is the total size of distinct blocks
+	StoredSize uint64 `json:"storedSize"`
+	// ComponentSizes is the stored size of blocks by component. Blocks shared
+	// between components are counted against each
+	ComponentSizes map[string]uint64 `json:"componentSizes"`
+	// TopShared lists the blocks saving the most space, most first
+	TopShared []SharedBlock `json:"topShared"`
+	// SharedBodies lists bodies used by more than one dataset
+	SharedBodies []SharedBody `json:"sharedBodies"`
+}
+
+// Saved is the space deduplication saves
+func (r DedupReport) Saved() uint64 {
+	return r.LogicalSize - r.StoredSize
+}
+
+// blockTally accumulates the use of a block across versions
+type blockTally struct {
+	size       uint64
+	refs       int
+	bodyRoot   bool
+	components map[string]bool
+	datasets   map[string]bool
+}
+
+// ReportDedup walks the stored versions of every dataset in a repo, grouping
+// blocks by ID to report how much space shared blocks save. Versions are
+// measured one at a time, so only per-block tallies are held in memory. top
+// caps the number of shared blocks listed. ReportDedup stops with ctx's error
+// when ctx is cancelled
+func ReportDedup(ctx context.Context, r repo.Repo, blocks VersionBlocksFunc, top int) (*DedupReport, error) {
+	num, err := r.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.References(0, num)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DedupReport{ComponentSizes: map[string]uint64{}}
+	tallies := map[string]*blockTally{}
+	seen := map[string]bool{}
+
+	for _, ref := range refs {
+		if ref.Path == "" {
+			continue
+		}
+		alias := ref.AliasString()
+		versions, err := DatasetLog(ctx, r, reporef.DatasetRef{Peername: ref.Peername, Name: ref.Name}, -1, 0, false)
+		if err != nil {
+			log.Debugf("dedup: listing versions of %s: %s", alias, err)
+			report.Skipped++
+			continue
+		}
+
+		for _, v := range versions {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			// forks can share versions, each is only counted once
+			if v.Path == "" || seen[v.Path] {
+				continue
+			}
+			seen[v.Path] = true
+			if has, err := r.Store().Has(ctx, v.Path); err != nil || !has {
+				continue
+			}
+
+			bs, err := blocks(ctx, v.Path)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				log.Debugf("dedup: listing blocks of %s: %s", v.Path, err)
+				report.Skipped++
+				continue
+			}
+			report.Versions++
+			tallyBlocks(tallies, alias, bs, report)
+		}
+	}
+
+	shared := []SharedBlock{}
+	for id, t := range tallies {
+		report.Blocks++
+		report.StoredSize += t.size
+		for c := range t.components {
+			report.ComponentSizes[c] += t.size
+		}
+		if t.refs > 1 {
+			shared = append(shared, SharedBlock{
+				ID:         id,
+				Size:       t.size,
+				References: t.refs,
+				Saved:      t.size * uint64(t.refs-1),
+				Components: setKeys(t.components),
+				Datasets:   setKeys(t.datasets),
+			})
+		}
+		if t.bodyRoot && len(t.datasets) > 1 {
+			report.SharedBodies = append(report.SharedBodies, SharedBody{
+				ID:       id,
+				Size:     t.size,
+				Datasets: setKeys(t.datasets),
+			})
+		}
+	}
+
+	sort.Slice(shared, func(i, j int) bool {
+		if shared[i].Saved == shared[j].Saved {
+			return shared[i].ID < shared[j].ID
+		}
+		return shared[i].Saved > shared[j].Saved
+	})
+	if top >= 0 && len(shared) > top {
+		shared = shared[:top]
+	}
+	report.TopShared = shared
+	sort.Slice(report.SharedBodies, func(i, j int) bool {
+		return report.SharedBodies[i].ID < report.SharedBodies[j].ID
+	})
+	return report, nil
+}
+
+// tallyBlocks adds the blocks of one version to the running tallies. Blocks
+// repeated within a version are only counted once
+func tallyBlocks(tallies map[string]*blockTally, alias string, bs []BlockInfo, report *DedupReport) {
+	counted := map[string]bool{}
+	for _, b := range bs {
+		t, ok := tallies[b.ID]
+		if !ok {
+			t = &blockTally{size: b.Size, components: map[string]bool{}, datasets: map[string]bool{}}
+			tallies[b.ID] = t
+		}
+		if b.Component != "" {
+			t.components[b.Component] = true
+		}
+		if b.Component == "body" && b.Root {
+			t.bodyRoot = true
+		}
+		if counted[b.ID] {
+			continue
+		}
+		counted[b.ID] = true
+		t.refs++
+		t.datasets[alias] = true
+		report.LogicalSize += b.Size
+	}
+}
+
+func setKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
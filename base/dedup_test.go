@@ -0,0 +1,57 @@
+package base
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReportDedup(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	v1 := addCitiesDataset(t, r)
+	updateCitiesDataset(t, r, "second")
+	flourinated := addFlourinatedCompoundsDataset(t, r)
+
+	// every version shares a body with cities v1, & has a dataset block of its
+	// own
+	blocks := func(ctx context.Context, path string) ([]BlockInfo, error) {
+		return []BlockInfo{
+			{ID: path, Size: 10, Component: "dataset"},
+			{ID: "sharedBody", Size: 100, Component: "body", Root: true},
+		}, nil
+	}
+
+	report, err := ReportDedup(ctx, r, blocks, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Versions != 3 || report.Blocks != 4 {
+		t.Errorf("expected 3 versions & 4 blocks, got: %d & %d", report.Versions, report.Blocks)
+	}
+	if report.LogicalSize != 330 || report.StoredSize != 130 || report.Saved() != 200 {
+		t.Errorf("expected 330 logical, 130 stored & 200 saved bytes, got: %d, %d & %d", report.LogicalSize, report.StoredSize, report.Saved())
+	}
+
+	datasets := []string{v1.AliasString(), flourinated.AliasString()}
+	expectShared := []SharedBlock{
+		{ID: "sharedBody", Size: 100, References: 3, Saved: 200, Components: []string{"body"}, Datasets: datasets},
+	}
+	if diff := cmp.Diff(expectShared, report.TopShared); diff != "" {
+		t.Errorf("shared blocks mismatch (-want +got):\n%s", diff)
+	}
+	expectBodies := []SharedBody{{ID: "sharedBody", Size: 100, Datasets: datasets}}
+	if diff := cmp.Diff(expectBodies, report.SharedBodies); diff != "" {
+		t.Errorf("shared bodies mismatch (-want +got):\n%s", diff)
+	}
+	if report.ComponentSizes["dataset"] != 30 || report.ComponentSizes["body"] != 100 {
+		t.Errorf("unexpected component sizes: %v", report.ComponentSizes)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := ReportDedup(cancelled, r, blocks, 1); err != context.Canceled {
+		t.Errorf("expected a cancelled report to return context.Canceled, got: %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package base
+
+import (
+	"context"
+	"io/ioutil"
+
+	"github.com/multiformats/go-multihash"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/repo"
+)
+
+// DiskUsageOf returns the disk usage account of a repo, or nil if the repo
+// doesn't keep one
+func DiskUsageOf(r repo.Repo) *repo.DiskUsage {
+	if u, ok := r.(repo.DiskUsager); ok {
+		return u.DiskUsage()
+	}
+	return nil
+}
+
+// RecalculateDiskUsage measures the bytes a repo stores, replacing the repo's
+// running account with the measurement. With a pin store the measurement is
+// the total size of the distinct blocks of every pinned path. Without one,
+// the history of each reference is walked, counting each distinct body once
+func RecalculateDiskUsage(ctx context.Context, r repo.Repo, ps PinStore) (int64, error) {
+	u := DiskUsageOf(r)
+	if u == nil {
+		return 0, nil
+	}
+
+	var (
+		total int64
+		err   error
+	)
+	if ps.Pinned != nil && ps.BlockSizes != nil {
+		total, err = pinnedBlockBytes(ctx, ps)
+	} else {
+		total, err = historyBodyBytes(ctx, r)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return total, u.Reset(total)
+}
+
+// pinnedBlockBytes sums the size of distinct blocks held by every pinned path
+func pinnedBlockBytes(ctx context.Context, ps PinStore) (int64, error) {
+	pinned, err := ps.Pinned(ctx)
+	if err != nil {
+		return 0, err
+	}
+	blocks := map[string]uint64{}
+	for path := range pinned {
+		sizes, err := ps.BlockSizes(ctx, path)
+		if err != nil {
+			return 0, err
+		}
+		for id, size := range sizes {
+			blocks[id] = size
+		}
+	}
+	var total int64
+	for _, size := range blocks {
+		total += int64(size)
+	}
+	return total, nil
+}
+
+// historyBodyBytes sums the length of distinct bodies in the history of every
+// reference in a repo. History that can't be loaded isn't counted
+func historyBodyBytes(ctx context.Context, r repo.Repo) (int64, error) {
+	num, err := r.RefCount()
+	if err != nil {
+		return 0, err
+	}
+	refs, err := r.References(0, num)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	bodies := map[string]bool{}
+	versions := map[string]bool{}
+	for _, ref := range refs {
+		for path := ref.Path; path != "" && !versions[path]; {
+			versions[path] = true
+			ds, err := dsfs.LoadDataset(ctx, r.Store(), path)
+			if err != nil {
+				log.Debugf("loading %s for disk usage: %s", path, err)
+				break
+			}
+			if ds.BodyPath != "" && !bodies[ds.BodyPath] && ds.Structure != nil {
+				bodies[ds.BodyPath] = true
+				total += int64(ds.Structure.Length)
+			}
+			path = ds.PreviousPath
+		}
+	}
+	return total, nil
+}
+
+// accountBytes records bytes added to or freed from a repo. The account is an
+// estimate corrected by recalculating, so failing to record is only logged
+func accountBytes(r repo.Repo, delta int64) {
+	if u := DiskUsageOf(r); u != nil {
+		if err := u.Add(delta); err != nil {
+			log.Errorf("recording disk usage: %s", err)
+		}
+	}
+}
+
+// checkBodyQuota errors if saving the body of ds would exceed a repo's quota.
+// Measuring a body reads it into memory, replacing the body file of ds with
+// the buffered bytes. Bodies matching the previous version's checksum add
+// nothing, as do external bodies, which aren't stored
+func checkBodyQuota(r repo.Repo, ds, dsPrev *dataset.Dataset, external bool) error {
+	u := DiskUsageOf(r)
+	if u == nil || !u.HasQuota() {
+		return nil
+	}
+	bf := ds.BodyFile()
+	if bf == nil || external {
+		return u.CheckQuota(0)
+	}
+
+	data, err := ioutil.ReadAll(bf)
+	if err != nil {
+		return err
+	}
+	bf.Close()
+	ds.SetBodyFile(qfs.NewMemfileBytes(bf.FileName(), data))
+
+	size := int64(len(data))
+	if dsPrev != nil && dsPrev.Structure != nil && dsPrev.Structure.Checksum != "" {
+		if mh, err := multihash.Sum(data, multihash.SHA2_256, -1); err == nil && mh.B58String() == dsPrev.Structure.Checksum {
+			size = 0
+		}
+	}
+	return u.CheckQuota(size)
+}
+
+// savedBodyBytes estimates the bytes a saved version added to a store: the
+// length of its body, unless the body is unchanged or isn't stored
+func savedBodyBytes(ds, dsPrev *dataset.Dataset) int64 {
+	if ds.Structure == nil || dsfs.IsExternalBodyPath(ds.BodyPath) {
+		return 0
+	}
+	if dsPrev != nil && dsPrev.Structure != nil && dsPrev.Structure.Checksum == ds.Structure.Checksum {
+		return 0
+	}
+	return int64(ds.Structure.Length)
+}
+
+// versionBodyBytes gives the length of a version's body, loading the
+// version's structure if need be. Versions that can't be measured count as
+// zero bytes
+func versionBodyBytes(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset) int64 {
+	if ds.Structure != nil && ds.Structure.Path != "" && ds.Structure.IsEmpty() {
+		if err := dsfs.DerefDatasetStructure(ctx, store, ds); err != nil {
+			log.Debugf("loading structure of %s for disk usage: %s", ds.Path, err)
+			return 0
+		}
+	}
+	if ds.Structure == nil || dsfs.ExternalBodyURI(ds) != "" {
+		return 0
+	}
+	return int64(ds.Structure.Length)
+}
+
+// accountRemovedVersions subtracts the bodies of removed versions from a
+// repo's disk usage. Each body is counted once, and not at all if head, the
+// version left as the dataset's head, shares it
+func accountRemovedVersions(ctx context.Context, r repo.Repo, removed []*dataset.Dataset, head *dataset.Dataset) {
+	if DiskUsageOf(r) == nil || len(removed) == 0 {
+		return
+	}
+	seen := map[string]bool{}
+	if head != nil && head != removed[len(removed)-1] && head.BodyPath != "" {
+		seen[head.BodyPath] = true
+	}
+	var freed int64
+	for _, ds := range removed {
+		if ds.BodyPath == "" || seen[ds.BodyPath] {
+			continue
+		}
+		seen[ds.BodyPath] = true
+		freed += versionBodyBytes(ctx, r.Store(), ds)
+	}
+	accountBytes(r, -freed)
+}
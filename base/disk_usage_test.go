@@ -0,0 +1,58 @@
+package base
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestDiskUsageAccounting(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	u := DiskUsageOf(r)
+
+	ref := addCitiesDataset(t, r)
+	length := int64(ref.Dataset.Structure.Length)
+	if u.Bytes() != length {
+		t.Errorf("expected saving to add body length. expected: %d, got: %d", length, u.Bytes())
+	}
+
+	u.SetQuota(length+1, 0)
+	err := PinDataset(ctx, r, ref)
+	if qe, ok := err.(*repo.QuotaExceededError); !ok || qe.Usage != length || qe.Size != length {
+		t.Errorf("expected pinning past the quota to error with usage & size, got: %v", err)
+	}
+	if u.Bytes() != length {
+		t.Errorf("expected refused pin to leave usage unchanged, got: %d", u.Bytes())
+	}
+	u.SetQuota(0, 0)
+
+	// saving without the previous version counts an unchanged body twice,
+	// recalculating corrects the drift
+	update := updateCitiesDataset(t, r, "a new title")
+	if expect := 2 * length; u.Bytes() != expect {
+		t.Errorf("expected body to be counted again. expected: %d, got: %d", expect, u.Bytes())
+	}
+	if _, err := RecalculateDiskUsage(ctx, r, PinStore{}); err != nil {
+		t.Fatal(err)
+	}
+	if u.Bytes() != length {
+		t.Errorf("recalculated usage mismatch. expected: %d, got: %d", length, u.Bytes())
+	}
+
+	// versions sharing a body are freed once
+	if _, err := RemoveNVersionsFromStore(ctx, r, reporef.ConvertToDsref(update), 1); err != nil {
+		t.Fatal(err)
+	}
+	if u.Bytes() != length {
+		t.Errorf("expected removing a version sharing the head's body to free nothing. expected: %d, got: %d", length, u.Bytes())
+	}
+	if _, err := RemoveNVersionsFromStore(ctx, r, reporef.ConvertToDsref(ref), -1); err != nil {
+		t.Fatal(err)
+	}
+	if u.Bytes() != 0 {
+		t.Errorf("expected removing the last version to free its body, got: %d", u.Bytes())
+	}
+}
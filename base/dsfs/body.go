@@ -1,14 +1,114 @@
 package dsfs
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"fmt"
+	"io"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qfs"
 	"github.com/qri-io/qfs/cafs"
 )
 
-// LoadBody loads the data this dataset points to from the store
+// CompressionGzip is the structure.compression value for bodies stored
+// gzip-compressed
+const CompressionGzip = "gzip"
+
+// gzipMagic is the header every gzip stream starts with
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// LoadBody loads the data this dataset points to from the store. Compressed
+// bodies are decompressed, external bodies are streamed from their URI if the
+// context allows it. see AllowExternalBodies
 func LoadBody(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset) (qfs.File, error) {
-	return store.Get(ctx, ds.BodyPath)
+	if ExternalBodyURI(ds) != "" {
+		return LoadExternalBody(ctx, ds)
+	}
+	f, err := store.Get(ctx, ds.BodyPath)
+	if err != nil {
+		return nil, err
+	}
+	return DecompressBody(f, ds.Structure)
+}
+
+// ValidateCompression checks a structure uses a supported body compression
+func ValidateCompression(st *dataset.Structure) error {
+	if st == nil || st.Compression == "" || st.Compression == CompressionGzip {
+		return nil
+	}
+	return fmt.Errorf("unsupported body compression %q, only %q is supported", st.Compression, CompressionGzip)
+}
+
+// CompressBody compresses body data as structure.compression specifies
+func CompressBody(st *dataset.Structure, data []byte) ([]byte, error) {
+	if err := ValidateCompression(st); err != nil {
+		return nil, err
+	}
+	if st == nil || st.Compression == "" {
+		return data, nil
+	}
+
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecompressBody wraps a body file, decompressing it as st.Compression
+// declares. Without a declared compression, like for bodies given to a save
+// or read from a working directory, the body is checked for a gzip header &
+// decompressed if it has one. Files DecompressBody returns are already
+// decompressed, and pass through unchanged
+func DecompressBody(f qfs.File, st *dataset.Structure) (qfs.File, error) {
+	if f == nil {
+		return nil, nil
+	}
+	if _, ok := f.(readerFile); ok {
+		return f, nil
+	}
+	if st != nil && st.Compression != "" {
+		if err := ValidateCompression(st); err != nil {
+			return nil, err
+		}
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing %s body: %s", st.Compression, err)
+		}
+		return readerFile{File: f, r: zr}, nil
+	}
+
+	br := bufio.NewReader(f)
+	header, err := br.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !bytes.Equal(header, gzipMagic) {
+		return readerFile{File: f, r: br}, nil
+	}
+
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing body: %s", err)
+	}
+	return readerFile{File: f, r: zr}, nil
+}
+
+// readerFile is a decompressed body file, it reads a file through another
+// reader, keeping the file's name & path
+type readerFile struct {
+	qfs.File
+	r io.Reader
+}
+
+// Read implements the io.Reader interface
+func (f readerFile) Read(p []byte) (int, error) {
+	return f.r.Read(p)
 }
@@ -5,6 +5,9 @@ import (
 	"context"
 	"io/ioutil"
 	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
 )
 
 func TestLoadBody(t *testing.T) {
@@ -43,3 +46,77 @@ func TestLoadBody(t *testing.T) {
 		t.Errorf("byte mismatch. expected: %s, got: %s", string(eq), string(data))
 	}
 }
+
+func TestCompressBody(t *testing.T) {
+	body := []byte("city,pop\ntoronto,40000000\n")
+
+	data, err := CompressBody(&dataset.Structure{Compression: CompressionGzip}, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(data, body) {
+		t.Fatal("expected body to be compressed")
+	}
+	f, err := DecompressBody(qfs.NewMemfileBytes("body.csv", data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, got) {
+		t.Errorf("decompressed body mismatch. want: %q got: %q", body, got)
+	}
+	if f.FileName() != "body.csv" {
+		t.Errorf("expected file name to be kept, got: %q", f.FileName())
+	}
+
+	// uncompressed bodies pass through
+	for _, raw := range [][]byte{body, []byte("a"), {}} {
+		f, err = DecompressBody(qfs.NewMemfileBytes("body.csv", raw), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ = ioutil.ReadAll(f); !bytes.Equal(raw, got) {
+			t.Errorf("passthrough mismatch. want: %q got: %q", raw, got)
+		}
+	}
+
+	// declared compression is decompressed without sniffing
+	gz := &dataset.Structure{Compression: CompressionGzip}
+	if f, err = DecompressBody(qfs.NewMemfileBytes("body.csv", data), gz); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = ioutil.ReadAll(f); !bytes.Equal(body, got) {
+		t.Errorf("declared gzip mismatch. want: %q got: %q", body, got)
+	}
+	if _, err = DecompressBody(qfs.NewMemfileBytes("body.csv", body), gz); err == nil {
+		t.Error("expected a body declared gzip that isn't gzipped to error")
+	}
+	if _, err = DecompressBody(qfs.NewMemfileBytes("body.csv", data), &dataset.Structure{Compression: "tar"}); err == nil {
+		t.Error("expected an unsupported declared compression to error")
+	}
+
+	// a gzipped body that decompresses to gzipped bytes is decompressed once
+	twice, err := CompressBody(gz, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, err = DecompressBody(qfs.NewMemfileBytes("body.csv", twice), gz); err != nil {
+		t.Fatal(err)
+	}
+	if f, err = DecompressBody(f, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = ioutil.ReadAll(f); !bytes.Equal(data, got) {
+		t.Errorf("expected a decompressed body to pass through. want: %q got: %q", data, got)
+	}
+
+	if data, err = CompressBody(&dataset.Structure{}, body); err != nil || !bytes.Equal(data, body) {
+		t.Errorf("expected no compression to leave the body as-is, got: %q, %v", data, err)
+	}
+	if _, err = CompressBody(&dataset.Structure{Compression: "tar"}, body); err == nil {
+		t.Error("expected unsupported compression to error")
+	}
+}
@@ -3,14 +3,19 @@ package dsfs
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 
+	humanize "github.com/dustin/go-humanize"
 	crypto "github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/multiformats/go-multihash"
 	"github.com/qri-io/dataset"
@@ -25,6 +30,10 @@ import (
 	"github.com/qri-io/qri/base/toqtype"
 )
 
+// ErrNoChanges is wrapped by errors for versions that don't change anything
+// from the previous version
+var ErrNoChanges = fmt.Errorf("no changes")
+
 // LoadDataset reads a dataset from a cafs and dereferences structure, transform, and commitMsg if they exist,
 // returning a fully-hydrated dataset
 func LoadDataset(ctx context.Context, store cafs.Filestore, path string) (*dataset.Dataset, error) {
@@ -221,7 +230,33 @@ func CreateDataset(ctx context.Context, store cafs.Filestore, ds, dsPrev *datase
 			return
 		}
 	}
-	err = prepareDataset(store, ds, dsPrev, pk, force, shouldRender)
+	// compare readmes by their text, an unchanged readme isn't a change
+	if dsPrev != nil && dsPrev.Readme != nil && ds.Readme != nil {
+		if err = inlineReadmeScript(ctx, store, ds.Readme); err != nil {
+			log.Debug(err.Error())
+			return
+		}
+		if err = inlineReadmeScript(ctx, store, dsPrev.Readme); err != nil {
+			log.Debug(err.Error())
+			return
+		}
+	}
+	commitTime := Timestamp()
+	if t, ok := ctx.Value(commitTimestampKey{}).(time.Time); ok {
+		commitTime = t.UTC()
+	}
+	externalBody := ExternalBodyFromContext(ctx)
+	if externalBody != "" {
+		if err = ValidateExternalBody(externalBody); err != nil {
+			return
+		}
+	}
+	vizTemplates := VizTemplatesFromContext(ctx)
+	if len(vizTemplates) > 0 && ds.Viz == nil {
+		err = fmt.Errorf("viz templates need a viz component")
+		return
+	}
+	err = prepareDataset(store, ds, dsPrev, pk, force, vizTemplatesChanged(ctx), shouldRender, commitTime, externalBody != "")
 	if err != nil {
 		log.Debug(err.Error())
 		return
@@ -241,9 +276,18 @@ var Timestamp = func() time.Time {
 	return time.Now().UTC()
 }
 
+// commitTimestampKey is the context key for a commit timestamp override
+type commitTimestampKey struct{}
+
+// WithCommitTimestamp returns a context that makes CreateDataset commit at t
+// instead of the current time, for recreating history recorded elsewhere
+func WithCommitTimestamp(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, commitTimestampKey{}, t)
+}
+
 // prepareDataset modifies a dataset in preparation for adding to a dsfs
 // it returns a new data file for use in WriteDataset
-func prepareDataset(store cafs.Filestore, ds, dsPrev *dataset.Dataset, privKey crypto.PrivKey, force, shouldRender bool) error {
+func prepareDataset(store cafs.Filestore, ds, dsPrev *dataset.Dataset, privKey crypto.PrivKey, force, vizTemplatesChanged, shouldRender bool, commitTime time.Time, external bool) error {
 	var (
 		err error
 		// lock for parallel edits to ds pointer
@@ -265,6 +309,15 @@ func prepareDataset(store cafs.Filestore, ds, dsPrev *dataset.Dataset, privKey c
 	if bf == nil {
 		bf = bfPrev
 	}
+	if err = ValidateCompression(ds.Structure); err != nil {
+		return err
+	}
+	// external bodies are read once to derive structure values, but aren't
+	// buffered or stored
+	bodyBuf := &buf
+	if external {
+		bodyBuf = nil
+	}
 
 	errR, errW := io.Pipe()
 	entryR, entryW := io.Pipe()
@@ -275,7 +328,7 @@ func prepareDataset(store cafs.Filestore, ds, dsPrev *dataset.Dataset, privKey c
 
 	go setErrCount(ds, qfs.NewMemfileReader(bf.FileName(), errR), &mu, done, valChan)
 	go setDepthAndEntryCount(ds, qfs.NewMemfileReader(bf.FileName(), entryR), &mu, done)
-	go setChecksumAndLength(ds, qfs.NewMemfileReader(bf.FileName(), hashR), &buf, &mu, done)
+	go setChecksumAndLength(ds, qfs.NewMemfileReader(bf.FileName(), hashR), bodyBuf, &mu, done)
 
 	go func() {
 		// pipes must be manually closed to trigger EOF
@@ -310,10 +363,15 @@ func prepareDataset(store cafs.Filestore, ds, dsPrev *dataset.Dataset, privKey c
 		return fmt.Errorf("strict mode: dataset body did not validate against its schema")
 	}
 
-	if err = generateCommit(dsPrev, ds, privKey, force); err != nil {
+	if err = generateCommit(dsPrev, ds, privKey, force, vizTemplatesChanged, commitTime); err != nil {
 		return err
 	}
 
+	if external {
+		// there's no stored body to render a viz from or compress
+		ds.SetBodyFile(nil)
+		return nil
+	}
 	ds.SetBodyFile(qfs.NewMemfileBytes("body."+ds.Structure.Format, buf.Bytes()))
 
 	if shouldRender && ds.Viz != nil && ds.Viz.ScriptFile() != nil {
@@ -326,15 +384,29 @@ func prepareDataset(store cafs.Filestore, ds, dsPrev *dataset.Dataset, privKey c
 		ds.Viz.SetRenderedFile(renderedFile)
 	}
 
+	// structure length & checksum describe the uncompressed body, compression
+	// only changes how it's stored
+	if ds.Structure.Compression != "" {
+		data, err := CompressBody(ds.Structure, buf.Bytes())
+		if err != nil {
+			return err
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body."+ds.Structure.Format, data))
+	}
+
 	return nil
 }
 
 // generateCommit creates the commit title, message, timestamp, etc
-func generateCommit(prev, ds *dataset.Dataset, privKey crypto.PrivKey, force bool) error {
-	shortTitle, longMessage, err := generateCommitDescriptions(prev, ds, force)
+func generateCommit(prev, ds *dataset.Dataset, privKey crypto.PrivKey, force, vizTemplatesChanged bool, commitTime time.Time) error {
+	shortTitle, longMessage, err := generateCommitDescriptions(prev, ds, force && !vizTemplatesChanged)
+	if vizTemplatesChanged && errors.Is(err, ErrNoChanges) {
+		// viz templates aren't part of the dataset document, a diff can't see them
+		shortTitle, longMessage, err = "updated viz templates", "updated viz templates", nil
+	}
 	if err != nil {
 		log.Debug(fmt.Errorf("error saving: %s", err))
-		return fmt.Errorf("error saving: %s", err)
+		return fmt.Errorf("error saving: %w", err)
 	}
 
 	if ds.Commit.Title == "" {
@@ -344,7 +416,7 @@ func generateCommit(prev, ds *dataset.Dataset, privKey crypto.PrivKey, force boo
 		ds.Commit.Message = longMessage
 	}
 
-	ds.Commit.Timestamp = Timestamp()
+	ds.Commit.Timestamp = commitTime
 	sb, _ := ds.SignableBytes()
 	signedBytes, err := privKey.Sign(sb)
 	if err != nil {
@@ -449,12 +521,19 @@ func getDepth(x interface{}) (depth int) {
 func setChecksumAndLength(ds *dataset.Dataset, data qfs.File, buf *bytes.Buffer, mu *sync.Mutex, done chan error) {
 	defer data.Close()
 
-	if _, err := io.Copy(buf, data); err != nil {
+	// a nil buf hashes the body without keeping it
+	h := sha256.New()
+	w := io.Writer(h)
+	if buf != nil {
+		w = io.MultiWriter(h, buf)
+	}
+	length, err := io.Copy(w, data)
+	if err != nil {
 		done <- err
 		return
 	}
 
-	shasum, err := multihash.Sum(buf.Bytes(), multihash.SHA2_256, -1)
+	shasum, err := multihash.Encode(h.Sum(nil), multihash.SHA2_256)
 	if err != nil {
 		log.Debug(err.Error())
 		done <- fmt.Errorf("error calculating hash: %s", err.Error())
@@ -462,8 +541,8 @@ func setChecksumAndLength(ds *dataset.Dataset, data qfs.File, buf *bytes.Buffer,
 	}
 
 	mu.Lock()
-	ds.Structure.Checksum = shasum.B58String()
-	ds.Structure.Length = len(buf.Bytes())
+	ds.Structure.Checksum = multihash.Multihash(shasum).B58String()
+	ds.Structure.Length = int(length)
 	mu.Unlock()
 
 	done <- nil
@@ -475,9 +554,16 @@ func generateCommitDescriptions(prev, ds *dataset.Dataset, force bool) (short, l
 		return "created dataset", "created dataset", nil
 	}
 
+	// summarize changes one component at a time when the body has changed,
+	// falling back to a field-level description if that fails
+	summary, err := summarizeChanges(prev, ds)
+	if err != nil {
+		log.Debugf("summarizing changes: %s", err)
+		summary = ""
+	}
+
 	// TODO(dlong): Inline body if it is a reasonable size, in order to get information about
 	// how the body has changed.
-	// TODO(dlong): Also should ignore derived fields, like structure.{checksum,entries,length}.
 
 	var prevData map[string]interface{}
 	prevData, err = toqtype.StructToMap(prev)
@@ -491,6 +577,13 @@ func generateCommitDescriptions(prev, ds *dataset.Dataset, force bool) (short, l
 		return "", "", err
 	}
 
+	if summary != "" {
+		// the summary describes body changes, leave derived fields & the body
+		// out of the detailed message
+		dropDerivedFields(prevData)
+		dropDerivedFields(nextData)
+	}
+
 	stat := deepdiff.Stats{}
 	diff, err := deepdiff.Diff(prevData, nextData, deepdiff.OptionSetStats(&stat))
 	if err != nil {
@@ -498,16 +591,109 @@ func generateCommitDescriptions(prev, ds *dataset.Dataset, force bool) (short, l
 	}
 
 	shortTitle, longMessage := friendly.DiffDescriptions(diff, &stat)
+	if summary != "" {
+		if longMessage == "" {
+			return summary, summary, nil
+		}
+		return summary, fmt.Sprintf("%s\n\n%s", summary, longMessage), nil
+	}
 	if shortTitle == "" {
 		if force {
 			return "forced update", "forced update", nil
 		}
-		return "", "", fmt.Errorf("no changes")
+		return "", "", ErrNoChanges
 	}
 
 	return shortTitle, longMessage, nil
 }
 
+// summaryComponents are the components summarizeChanges describes, in order
+var summaryComponents = []string{"meta", "structure", "readme", "viz", "transform"}
+
+// derivedStructureFields are structure fields calculated from the body
+var derivedStructureFields = []string{"checksum", "depth", "entries", "errCount", "length"}
+
+// summarizeChanges describes the changes between two versions by component,
+// eg: "updated meta and added 1,204 body rows". Body changes are read from
+// structure checksums & entry counts, summarizeChanges returns an empty
+// string when the body hasn't changed
+func summarizeChanges(prev, ds *dataset.Dataset) (string, error) {
+	if prev.Structure == nil || ds.Structure == nil || prev.Structure.Checksum == "" || ds.Structure.Checksum == "" {
+		return "", nil
+	}
+	if prev.Structure.Checksum == ds.Structure.Checksum {
+		return "", nil
+	}
+
+	prevData, err := toqtype.StructToMap(prev)
+	if err != nil {
+		return "", err
+	}
+	nextData, err := toqtype.StructToMap(ds)
+	if err != nil {
+		return "", err
+	}
+	dropDerivedFields(prevData)
+	dropDerivedFields(nextData)
+
+	changes := []string{}
+	for _, name := range summaryComponents {
+		before, hadBefore := prevData[name]
+		after, hasAfter := nextData[name]
+		switch {
+		case !hadBefore && hasAfter:
+			changes = append(changes, "added "+name)
+		case hadBefore && !hasAfter:
+			changes = append(changes, "removed "+name)
+		case hadBefore && hasAfter && !reflect.DeepEqual(before, after):
+			changes = append(changes, "updated "+name)
+		}
+	}
+
+	switch rows := ds.Structure.Entries - prev.Structure.Entries; {
+	case rows > 0:
+		changes = append(changes, fmt.Sprintf("added %s body %s", humanize.Comma(int64(rows)), pluralRows(rows)))
+	case rows < 0:
+		changes = append(changes, fmt.Sprintf("removed %s body %s", humanize.Comma(int64(-rows)), pluralRows(-rows)))
+	default:
+		changes = append(changes, "updated body")
+	}
+
+	return joinChanges(changes), nil
+}
+
+// dropDerivedFields removes the body & fields calculated from it from a
+// dataset map, along with component paths
+func dropDerivedFields(data map[string]interface{}) {
+	delete(data, "body")
+	delete(data, "bodyPath")
+	for _, name := range summaryComponents {
+		if comp, ok := data[name].(map[string]interface{}); ok {
+			delete(comp, "path")
+		}
+	}
+	if st, ok := data["structure"].(map[string]interface{}); ok {
+		for _, f := range derivedStructureFields {
+			delete(st, f)
+		}
+	}
+}
+
+func pluralRows(n int) string {
+	if n == 1 {
+		return "row"
+	}
+	return "rows"
+}
+
+// joinChanges lists changes as "a", "a and b" or "a, b and c"
+func joinChanges(changes []string) string {
+	if len(changes) == 1 {
+		return changes[0]
+	}
+	return fmt.Sprintf("%s and %s", strings.Join(changes[:len(changes)-1], ", "), changes[len(changes)-1])
+}
+
 // WriteDataset writes a dataset to a cafs, replacing subcomponents of a dataset with path references
 // during the write process. Directory structure is according to PackageFile naming conventions.
 // This method is currently exported, but 99% of use cases should use CreateDataset instead of this
@@ -519,6 +705,8 @@ func WriteDataset(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset
 	}
 	name := ds.Name // preserve name for body file
 	bodyFile := ds.BodyFile()
+	externalBody := ExternalBodyFromContext(ctx)
+	vizTemplates := VizTemplatesFromContext(ctx)
 	fileTasks := 0
 	addedDataset := false
 	adder, err := store.NewAdder(pin, true)
@@ -545,7 +733,7 @@ func WriteDataset(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset
 			fileTasks++
 			adder.AddFile(ctx, vsFile)
 		} else {
-			vizdata, err := json.Marshal(ds.Viz)
+			vizdata, err := marshalViz(ds.Viz, vizTemplates)
 			if err != nil {
 				return "", fmt.Errorf("error marshalling dataset viz to json: %s", err.Error())
 			}
@@ -640,14 +828,23 @@ func WriteDataset(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset
 		adder.AddFile(ctx, stf)
 	}
 
-	fileTasks++
-	adder.AddFile(ctx, bodyFile)
+	if externalBody != "" {
+		// external bodies stay where they are, the version records the URI
+		ds.BodyPath = externalBody
+		bodyFile = nil
+	} else {
+		fileTasks++
+		adder.AddFile(ctx, bodyFile)
+	}
 
 	var path string
 	done := make(chan error, 0)
 	go func() {
 		for ao := range adder.Added() {
 			path = ao.Path
+			if bodyFile != nil && ao.Name == bodyFile.FileName() {
+				ds.BodyPath = ao.Path
+			}
 			switch ao.Name {
 			case PackageFileStructure.String():
 				ds.Structure = dataset.NewStructureRef(ao.Path)
@@ -659,9 +856,6 @@ func WriteDataset(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset
 				ds.Commit = dataset.NewCommitRef(ao.Path)
 			case PackageFileViz.String():
 				ds.Viz = dataset.NewVizRef(ao.Path)
-			case bodyFile.FileName():
-				ds.BodyPath = ao.Path
-				// ds.SetBodyFile(qfs.NewMemfileBytes(bodyFile.FileName(), bodyBytesBuf.Bytes()))
 			case transformScriptFilename:
 				ds.Transform.ScriptPath = ao.Path
 				tfdata, err := json.Marshal(ds.Transform)
@@ -680,7 +874,7 @@ func WriteDataset(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset
 				adder.AddFile(ctx, vsFile)
 			case vizScriptFilename:
 				ds.Viz.ScriptPath = ao.Path
-				vizdata, err := json.Marshal(ds.Viz)
+				vizdata, err := marshalViz(ds.Viz, vizTemplates)
 				if err != nil {
 					done <- err
 					return
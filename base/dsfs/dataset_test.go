@@ -277,6 +277,68 @@ func TestCreateDataset(t *testing.T) {
 	// case: previous dataset isn't valid
 }
 
+func TestCreateDatasetReadmeChanges(t *testing.T) {
+	ctx := context.Background()
+	store := cafs.NewMapstore()
+	privKey, err := crypto.UnmarshalPrivateKey(testPk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// newVersion creates the next version of a dataset with a readme, the way
+	// saves do: the previous readme's fields are kept & the script replaced
+	newVersion := func(prev *dataset.Dataset, readme string) *dataset.Dataset {
+		ds := &dataset.Dataset{
+			Commit:    &dataset.Commit{},
+			Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray},
+			Readme:    &dataset.Readme{},
+		}
+		if prev != nil {
+			ds.PreviousPath = prev.Path
+			ds.Readme.ScriptPath = prev.Readme.ScriptPath
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte("[1,2,3]")))
+		ds.Readme.SetScriptFile(qfs.NewMemfileBytes("readme.md", []byte(readme)))
+		return ds
+	}
+
+	path, err := CreateDataset(ctx, store, newVersion(nil, "# hello"), nil, privKey, false, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev, err := LoadDataset(ctx, store, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = CreateDataset(ctx, store, newVersion(prev, "# hello"), prev, privKey, false, false, true)
+	if err == nil || err.Error() != "error saving: no changes" {
+		t.Errorf("expected an unchanged readme to be no changes, got: %v", err)
+	}
+
+	if prev, err = LoadDataset(ctx, store, path); err != nil {
+		t.Fatal(err)
+	}
+	path, err = CreateDataset(ctx, store, newVersion(prev, "# hello world"), prev, privKey, false, false, true)
+	if err != nil {
+		t.Fatalf("expected a changed readme to save, got: %s", err)
+	}
+	ds, err := LoadDataset(ctx, store, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Commit.Title != "readme updated scriptBytes" {
+		t.Errorf("commit title mismatch. expected: %q, got: %q", "readme updated scriptBytes", ds.Commit.Title)
+	}
+	script, err := fileBytes(store.Get(ctx, ds.Readme.ScriptPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(script) != "# hello world" {
+		t.Errorf("readme script mismatch. expected: %q, got: %q", "# hello world", string(script))
+	}
+}
+
 func TestWriteDataset(t *testing.T) {
 	ctx := context.Background()
 	store := cafs.NewMapstore()
@@ -662,6 +724,41 @@ hen,twenty-nine,30`),
 			"updated meta and body",
 			"meta:\n\tupdated description\n\tadded homeURL\n\tupdated title\nbody:\n\tchanged by 16%",
 		},
+		{
+			"body rows added",
+			&dataset.Dataset{Structure: &dataset.Structure{Format: "csv", Checksum: "QmA", Entries: 10, Length: 100}},
+			&dataset.Dataset{Structure: &dataset.Structure{Format: "csv", Checksum: "QmB", Entries: 1214, Length: 12140}},
+			false,
+			"added 1,204 body rows",
+			"added 1,204 body rows",
+		},
+		{
+			"meta updated and body row removed",
+			&dataset.Dataset{
+				Meta:      &dataset.Meta{Title: "new dataset"},
+				Structure: &dataset.Structure{Format: "csv", Checksum: "QmA", Entries: 10},
+			},
+			&dataset.Dataset{
+				Meta:      &dataset.Meta{Title: "changes to dataset"},
+				Structure: &dataset.Structure{Format: "csv", Checksum: "QmB", Entries: 9},
+			},
+			false,
+			"updated meta and removed 1 body row",
+			"updated meta and removed 1 body row\n\nmeta:\n\tupdated title",
+		},
+		{
+			"readme added, structure & body updated",
+			&dataset.Dataset{
+				Structure: &dataset.Structure{Format: "csv", Checksum: "QmA", Entries: 10, ErrCount: 2},
+			},
+			&dataset.Dataset{
+				Structure: &dataset.Structure{Format: "csv", Checksum: "QmB", Entries: 10, FormatConfig: map[string]interface{}{"headerRow": true}},
+				Readme:    &dataset.Readme{Format: "md", ScriptBytes: []byte("# hello")},
+			},
+			false,
+			"updated structure, added readme and updated body",
+			"updated structure, added readme and updated body\n\nstructure:\n\tadded formatConfig\nreadme added",
+		},
 	}
 
 	for _, c := range goodCases {
@@ -0,0 +1,141 @@
+package dsutil
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ChecksumsFilename is the name of the checksum manifest in a zip archive
+const ChecksumsFilename = "checksums.json"
+
+// ErrNoChecksums indicates a zip archive doesn't have a checksum manifest.
+// Archives exported by older versions of qri don't have one
+var ErrNoChecksums = fmt.Errorf("zip archive has no %s, it may have been exported by an older version of qri", ChecksumsFilename)
+
+// ExportChecksums is the checksum manifest of a zip archive, listing the
+// SHA-256 hash of every other file in the archive
+type ExportChecksums struct {
+	// Path is the version path of the exported dataset
+	Path string `json:"path"`
+	// Files maps filenames to hex-encoded SHA-256 hashes
+	Files map[string]string `json:"files"`
+}
+
+// ErrChecksumMismatch lists files in a zip archive that don't match the
+// checksum manifest: files that were changed, removed or added
+type ErrChecksumMismatch struct {
+	Files []string
+}
+
+// Error implements the error interface
+func (e *ErrChecksumMismatch) Error() string {
+	return fmt.Sprintf("zip archive doesn't match %s, mismatched files: %s", ChecksumsFilename, strings.Join(e.Files, ", "))
+}
+
+// ChecksumZipWriter writes a zip archive that ends with a checksum manifest
+// of the files before it
+type ChecksumZipWriter struct {
+	zw     *zip.Writer
+	hashes map[string]hash.Hash
+}
+
+// NewChecksumZipWriter creates a ChecksumZipWriter writing to w
+func NewChecksumZipWriter(w io.Writer) *ChecksumZipWriter {
+	return &ChecksumZipWriter{zw: zip.NewWriter(w), hashes: map[string]hash.Hash{}}
+}
+
+// Create adds a file to the archive, returning a writer that hashes the file
+// contents as they're written
+func (cw *ChecksumZipWriter) Create(name string) (io.Writer, error) {
+	w, err := cw.zw.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	cw.hashes[name] = h
+	return io.MultiWriter(w, h), nil
+}
+
+// Close writes the checksum manifest & closes the archive. path is the
+// version path of the archived dataset
+func (cw *ChecksumZipWriter) Close(path string) error {
+	sums := ExportChecksums{Path: path, Files: map[string]string{}}
+	for name, h := range cw.hashes {
+		sums.Files[name] = hex.EncodeToString(h.Sum(nil))
+	}
+	data, err := json.MarshalIndent(sums, "", "  ")
+	if err != nil {
+		return err
+	}
+	w, err := cw.zw.Create(ChecksumsFilename)
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(data); err != nil {
+		return err
+	}
+	return cw.zw.Close()
+}
+
+// VerifyZipArchive checks the files in a zip archive match its checksum
+// manifest, returning the manifest. It returns ErrNoChecksums if the archive
+// doesn't have a manifest, and an *ErrChecksumMismatch if files don't match
+func VerifyZipArchive(r io.ReaderAt, size int64) (*ExportChecksums, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := unzipGetContents(zr)
+	if err != nil {
+		return nil, err
+	}
+	return verifyChecksums(contents)
+}
+
+// verifyChecksums checks unzipped file contents against the checksum manifest
+// included with them
+func verifyChecksums(contents map[string][]byte) (*ExportChecksums, error) {
+	data, ok := contents[ChecksumsFilename]
+	if !ok {
+		return nil, ErrNoChecksums
+	}
+	sums := &ExportChecksums{}
+	if err := json.Unmarshal(data, sums); err != nil {
+		return nil, fmt.Errorf("reading %s: %s", ChecksumsFilename, err)
+	}
+
+	var mismatched []string
+	for name, sum := range sums.Files {
+		data, ok := contents[name]
+		if !ok {
+			mismatched = append(mismatched, name)
+			continue
+		}
+		got := sha256.Sum256(data)
+		if hex.EncodeToString(got[:]) != strings.ToLower(sum) {
+			mismatched = append(mismatched, name)
+		}
+	}
+	for name := range contents {
+		// directory entries don't have contents to check
+		if strings.HasSuffix(name, "/") || name == ChecksumsFilename {
+			continue
+		}
+		if _, ok := sums.Files[name]; !ok {
+			mismatched = append(mismatched, name)
+		}
+	}
+
+	if len(mismatched) > 0 {
+		sort.Strings(mismatched)
+		return nil, &ErrChecksumMismatch{Files: mismatched}
+	}
+	return sums, nil
+}
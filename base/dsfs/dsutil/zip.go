@@ -17,9 +17,11 @@ import (
 	"github.com/qri-io/qri/base/dsfs"
 )
 
-// WriteZipArchive generates a zip archive of a dataset and writes it to w
+// WriteZipArchive generates a zip archive of a dataset and writes it to w.
+// The archive ends with a checksum manifest of the files before it, see
+// VerifyZipArchive
 func WriteZipArchive(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset, format string, ref string, w io.Writer) error {
-	zw := zip.NewWriter(w)
+	zw := NewChecksumZipWriter(w)
 
 	// Dataset header, contains meta, structure, and commit
 	dsf, err := zw.Create(fmt.Sprintf("dataset.%s", format))
@@ -115,6 +117,22 @@ func WriteZipArchive(ctx context.Context, store cafs.Filestore, ds *dataset.Data
 		}
 	}
 
+	// Readme
+	if ds.Readme != nil && ds.Readme.ScriptPath != "" {
+		script, err := store.Get(ctx, ds.Readme.ScriptPath)
+		if err != nil {
+			return err
+		}
+		target, err := zw.Create("readme.md")
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(target, script)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Body
 	datadst, err := zw.Create(fmt.Sprintf("body.%s", ds.Structure.Format))
 	if err != nil {
@@ -132,7 +150,7 @@ func WriteZipArchive(ctx context.Context, store cafs.Filestore, ds *dataset.Data
 		log.Debug(err.Error())
 		return err
 	}
-	return zw.Close()
+	return zw.Close(ds.Path)
 }
 
 // UnzipDatasetBytes is a convenince wrapper for UnzipDataset
@@ -140,24 +158,41 @@ func UnzipDatasetBytes(zipData []byte, ds *dataset.Dataset) error {
 	return UnzipDataset(bytes.NewReader(zipData), int64(len(zipData)), ds)
 }
 
-// UnzipDataset reads a zip file from a filename and returns a full dataset with components
+// UnzipDataset reads a zip file from a filename and returns a full dataset with components.
+// Archives with a checksum manifest are verified, returning an
+// *ErrChecksumMismatch if files don't match
 func UnzipDataset(r io.ReaderAt, size int64, ds *dataset.Dataset) error {
+	_, err := UnzipVerifiedDataset(r, size, ds)
+	return err
+}
+
+// UnzipVerifiedDataset is UnzipDataset, also returning the checksum manifest
+// the archive was verified against. The manifest is nil for archives that
+// don't have one
+func UnzipVerifiedDataset(r io.ReaderAt, size int64, ds *dataset.Dataset) (*ExportChecksums, error) {
 	zr, err := zip.NewReader(r, size)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	contents, err := unzipGetContents(zr)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	sums, err := verifyChecksums(contents)
+	if err == ErrNoChecksums {
+		sums = nil
+	} else if err != nil {
+		return nil, err
 	}
 
 	fileData, ok := contents["dataset.json"]
 	if !ok {
-		return fmt.Errorf("no dataset.json found in the provided zip")
+		return nil, fmt.Errorf("no dataset.json found in the provided zip")
 	}
 	if err = json.Unmarshal(fileData, ds); err != nil {
-		return err
+		return nil, err
 	}
 
 	// TODO - do a smarter iteration for body format
@@ -190,23 +225,31 @@ func UnzipDataset(r io.ReaderAt, size int64, ds *dataset.Dataset) error {
 		ds.Viz.ScriptPath = ""
 	}
 
+	if readmeScriptData, ok := contents["readme.md"]; ok {
+		if ds.Readme == nil {
+			ds.Readme = &dataset.Readme{}
+		}
+		ds.Readme.ScriptBytes = readmeScriptData
+		ds.Readme.ScriptPath = ""
+	}
+
 	// Get ref to existing dataset
 	if refText, ok := contents["ref.txt"]; ok {
 		refStr := string(refText)
 		atPos := strings.Index(refStr, "@")
 		if atPos == -1 {
-			return fmt.Errorf("invalid dataset ref: no '@' found")
+			return nil, fmt.Errorf("invalid dataset ref: no '@' found")
 		}
 		// Get name and peername
 		datasetName := refStr[:atPos]
 		sepPos := strings.Index(datasetName, "/")
 		if sepPos == -1 {
-			return fmt.Errorf("invalid dataset name: no '/' found")
+			return nil, fmt.Errorf("invalid dataset name: no '/' found")
 		}
 		ds.Peername = datasetName[:sepPos]
 		ds.Name = datasetName[sepPos+1:]
 	}
-	return nil
+	return sums, nil
 }
 
 // UnzipGetContents is a generic zip-unpack to a map of filename: contents
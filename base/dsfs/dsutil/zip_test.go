@@ -11,6 +11,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
 	"github.com/qri-io/qri/base/dsfs"
 )
 
@@ -101,6 +102,42 @@ func TestWriteZipArchiveFullDataset(t *testing.T) {
 	}
 }
 
+func TestZipArchiveReadme(t *testing.T) {
+	ctx := context.Background()
+	store, names, err := testStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, err := dsfs.LoadDataset(ctx, store, names["movies"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	scriptPath, err := store.Put(ctx, qfs.NewMemfileBytes("readme.md", []byte("# movies")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds.Readme = &dataset.Readme{ScriptPath: scriptPath}
+
+	buf := &bytes.Buffer{}
+	if err = WriteZipArchive(ctx, store, ds, "json", "peer/ref@a/ipfs/b", buf); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &dataset.Dataset{}
+	if err := UnzipDatasetBytes(buf.Bytes(), got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Readme == nil {
+		t.Fatal("expected unzipped dataset to have a readme")
+	}
+	if string(got.Readme.ScriptBytes) != "# movies" {
+		t.Errorf("readme script mismatch. expected: %q, got: %q", "# movies", string(got.Readme.ScriptBytes))
+	}
+	if got.Readme.ScriptPath != "" {
+		t.Errorf("expected unzipped readme script path to be empty, got: %q", got.Readme.ScriptPath)
+	}
+}
+
 func TestUnzipDatasetBytes(t *testing.T) {
 	path := zipTestdataFile("exported.zip")
 	zipBytes, err := ioutil.ReadFile(path)
@@ -144,8 +181,9 @@ func TestUnzipGetContents(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	expectLen := 6
+	expectLen := 7
 	// files include:
+	// checksums.json
 	// dataset.json
 	// body.csv
 	// index.html
@@ -156,3 +194,92 @@ func TestUnzipGetContents(t *testing.T) {
 		t.Errorf("contents length mismatch. expected: %d, got: %d", expectLen, len(res))
 	}
 }
+
+func TestVerifyZipArchive(t *testing.T) {
+	ctx := context.Background()
+	store, names, err := testStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, err := dsfs.LoadDataset(ctx, store, names["movies"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err = WriteZipArchive(ctx, store, ds, "json", "peer/ref@a/ipfs/b", buf); err != nil {
+		t.Fatal(err)
+	}
+	sums, err := VerifyZipArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sums.Path != ds.Path {
+		t.Errorf("checksum path mismatch. expected: %q, got: %q", ds.Path, sums.Path)
+	}
+	for _, name := range []string{"dataset.json", "ref.txt", "body.csv"} {
+		if sums.Files[name] == "" {
+			t.Errorf("expected a checksum for %s", name)
+		}
+	}
+
+	// rewrite the archive, changing one file, dropping another & adding a third
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := &bytes.Buffer{}
+	zw := zip.NewWriter(tampered)
+	for _, f := range zr.File {
+		if f.Name == "ref.txt" {
+			continue
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		if f.Name == "body.csv" {
+			data = append(data, []byte("evil,1\n")...)
+		}
+		w.Write(data)
+	}
+	w, _ := zw.Create("extra.txt")
+	w.Write([]byte("surprise"))
+	zw.Close()
+
+	_, err = VerifyZipArchive(bytes.NewReader(tampered.Bytes()), int64(tampered.Len()))
+	mismatch, ok := err.(*ErrChecksumMismatch)
+	if !ok {
+		t.Fatalf("expected a checksum mismatch error, got: %v", err)
+	}
+	if diff := cmp.Diff([]string{"body.csv", "extra.txt", "ref.txt"}, mismatch.Files); diff != "" {
+		t.Errorf("mismatched files (-want +got):\n%s", diff)
+	}
+	if err := UnzipDatasetBytes(tampered.Bytes(), &dataset.Dataset{}); err == nil {
+		t.Error("expected unzipping a tampered archive to error")
+	}
+
+	// archives without checksums unzip, but can't be verified
+	plain := &bytes.Buffer{}
+	zw = zip.NewWriter(plain)
+	w, _ = zw.Create("dataset.json")
+	w.Write([]byte(`{"meta":{"title":"old"}}`))
+	zw.Close()
+	if _, err := VerifyZipArchive(bytes.NewReader(plain.Bytes()), int64(plain.Len())); err != ErrNoChecksums {
+		t.Errorf("expected ErrNoChecksums, got: %v", err)
+	}
+	got := &dataset.Dataset{}
+	sums, err = UnzipVerifiedDataset(bytes.NewReader(plain.Bytes()), int64(plain.Len()), got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sums != nil || got.Meta == nil || got.Meta.Title != "old" {
+		t.Errorf("expected unverified archive to unzip without checksums, got: %#v %#v", sums, got)
+	}
+}
@@ -0,0 +1,322 @@
+package dsfs
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/multiformats/go-multihash"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+// ErrExternalBodyNotAllowed is the error reading an external body without
+// opting in with AllowExternalBodies
+var ErrExternalBodyNotAllowed = errors.New("reading external bodies isn't allowed")
+
+// MaxExternalBodySize is the largest external body in bytes a save reads.
+// Reading past the limit fails with an error, 0 means no limit. Bodies of
+// saved versions are streamed without a limit, they're checked against their
+// checksum instead
+var MaxExternalBodySize int64 = 1 << 30
+
+// externalBodyIdleTimeout is how long a read of an external body over HTTP can
+// wait for data before the request is abandoned. There's no limit on how long
+// reading a whole body takes, the caller's context cancels long reads
+var externalBodyIdleTimeout = time.Minute
+
+// externalBodyClient fetches external bodies over HTTP. Only connecting &
+// waiting for response headers are bounded, bodies can take as long as they
+// need to stream
+var externalBodyClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+// ExternalBodyOpener opens the body at an external URI for reading
+type ExternalBodyOpener func(ctx context.Context, uri *url.URL) (io.ReadCloser, error)
+
+var (
+	externalBodyLk      sync.Mutex
+	externalBodyOpeners = map[string]ExternalBodyOpener{
+		"http":  openHTTPBody,
+		"https": openHTTPBody,
+		"s3":    openS3Body,
+	}
+)
+
+// RegisterExternalBodyScheme sets how external bodies with a URI scheme are
+// opened, replacing any existing opener for the scheme
+func RegisterExternalBodyScheme(scheme string, open ExternalBodyOpener) {
+	externalBodyLk.Lock()
+	defer externalBodyLk.Unlock()
+	externalBodyOpeners[strings.ToLower(scheme)] = open
+}
+
+// externalBodyKey is the context key for the external body URI of a version
+// being written
+type externalBodyKey struct{}
+
+// WithExternalBody returns a context that makes CreateDataset write a version
+// with the body at an external URI. The body file is read once to derive
+// structure values, but isn't stored. The version records the URI as its
+// body path
+func WithExternalBody(ctx context.Context, uri string) context.Context {
+	return context.WithValue(ctx, externalBodyKey{}, uri)
+}
+
+// ExternalBodyFromContext returns the external body URI a context sets with
+// WithExternalBody, if any
+func ExternalBodyFromContext(ctx context.Context) string {
+	uri, _ := ctx.Value(externalBodyKey{}).(string)
+	return uri
+}
+
+// allowExternalBodiesKey is the context key for opting in to reading external
+// bodies
+type allowExternalBodiesKey struct{}
+
+// AllowExternalBodies returns a context that lets LoadExternalBody stream
+// bodies from their URI. External bodies are never read without it, a version
+// pulled from a peer can point its body at any URI
+func AllowExternalBodies(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allowExternalBodiesKey{}, true)
+}
+
+// ExternalBodiesAllowed reports whether a context opts in to reading external
+// bodies
+func ExternalBodiesAllowed(ctx context.Context) bool {
+	ok, _ := ctx.Value(allowExternalBodiesKey{}).(bool)
+	return ok
+}
+
+// ExternalBodyURI returns the external URI a saved version reads its body
+// from, or an empty string if the body is stored with the dataset. Unsaved
+// changes don't have an external body, a URI body path on changes is a body to
+// fetch & store
+func ExternalBodyURI(ds *dataset.Dataset) string {
+	if ds == nil || ds.Path == "" || !IsExternalBodyPath(ds.BodyPath) {
+		return ""
+	}
+	return ds.BodyPath
+}
+
+// IsExternalBodyPath reports whether a body path is an external URI with a
+// registered scheme rather than a path in a store
+func IsExternalBodyPath(p string) bool {
+	u, err := url.Parse(p)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	externalBodyLk.Lock()
+	defer externalBodyLk.Unlock()
+	_, ok := externalBodyOpeners[strings.ToLower(u.Scheme)]
+	return ok
+}
+
+// ValidateExternalBody checks an external body URI has a supported scheme
+func ValidateExternalBody(uri string) error {
+	_, _, err := externalBodyOpener(uri)
+	return err
+}
+
+func externalBodyOpener(uri string) (*url.URL, ExternalBodyOpener, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid external body URI: %s", err)
+	}
+	externalBodyLk.Lock()
+	open, ok := externalBodyOpeners[strings.ToLower(u.Scheme)]
+	externalBodyLk.Unlock()
+	if !ok || u.Host == "" {
+		return nil, nil, fmt.Errorf("unsupported external body URI %q, scheme must be one of: http, https, s3", uri)
+	}
+	return u, open, nil
+}
+
+// OpenExternalBody opens the body at an external URI. Gzipped bodies are
+// decompressed. Reading more than limit bytes before decompression fails
+// with an error, a limit of 0 or less reads the whole body. The body isn't
+// checked against a checksum, use LoadExternalBody to read the body of a saved
+// version
+func OpenExternalBody(ctx context.Context, uri string, limit int64) (qfs.File, error) {
+	u, open, err := externalBodyOpener(uri)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := open(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("opening external body: %s", err)
+	}
+	if limit > 0 {
+		rc = &limitedBody{ReadCloser: rc, uri: u.String(), limit: limit, remain: limit}
+	}
+	return DecompressBody(qfs.NewMemfileReader(path.Base(u.Path), rc), nil)
+}
+
+// LoadExternalBody streams the external body of a saved version, checking
+// it against the structure checksum & length as it's read. A body that's
+// changed since it was saved fails with an error at the end of the stream.
+// The context must opt in with AllowExternalBodies
+func LoadExternalBody(ctx context.Context, ds *dataset.Dataset) (qfs.File, error) {
+	uri := ExternalBodyURI(ds)
+	if !ExternalBodiesAllowed(ctx) {
+		return nil, fmt.Errorf("%w, body is at %s", ErrExternalBodyNotAllowed, uri)
+	}
+	f, err := OpenExternalBody(ctx, uri, 0)
+	if err != nil {
+		return nil, err
+	}
+	if ds.Structure == nil || ds.Structure.Checksum == "" {
+		return f, nil
+	}
+	return &verifiedBody{
+		File:     f,
+		h:        sha256.New(),
+		checksum: ds.Structure.Checksum,
+		length:   ds.Structure.Length,
+	}, nil
+}
+
+// verifiedBody hashes a body as it's read, erroring instead of returning
+// io.EOF if the body doesn't match the checksum it was saved with
+type verifiedBody struct {
+	qfs.File
+	h        hash.Hash
+	n        int
+	checksum string
+	length   int
+}
+
+// Read implements the io.Reader interface
+func (f *verifiedBody) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	f.h.Write(p[:n])
+	f.n += n
+	if err == io.EOF {
+		if verr := f.verify(); verr != nil {
+			return n, verr
+		}
+	}
+	return n, err
+}
+
+func (f *verifiedBody) verify() error {
+	mh, err := multihash.Encode(f.h.Sum(nil), multihash.SHA2_256)
+	if err != nil {
+		return err
+	}
+	if sum := multihash.Multihash(mh).B58String(); sum != f.checksum || f.n != f.length {
+		return fmt.Errorf("external body doesn't match the version it was saved with. expected checksum %s (%d bytes), got %s (%d bytes)", f.checksum, f.length, sum, f.n)
+	}
+	return nil
+}
+
+func openHTTPBody(ctx context.Context, u *url.URL) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	res, err := externalBodyClient.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("invalid status code fetching %s: %d", u, res.StatusCode)
+	}
+	return newIdleBody(res.Body, u.String(), externalBodyIdleTimeout, cancel), nil
+}
+
+// idleBody cancels a response when a read waits longer than timeout for
+// data, so a stalled connection can't block a reader forever
+type idleBody struct {
+	io.ReadCloser
+	uri     string
+	timeout time.Duration
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	stalled int32
+}
+
+func newIdleBody(rc io.ReadCloser, uri string, timeout time.Duration, cancel context.CancelFunc) *idleBody {
+	b := &idleBody{ReadCloser: rc, uri: uri, timeout: timeout, cancel: cancel}
+	b.timer = time.AfterFunc(timeout, func() {
+		atomic.StoreInt32(&b.stalled, 1)
+		cancel()
+	})
+	b.timer.Stop()
+	return b
+}
+
+// Read implements the io.Reader interface. Only time spent waiting in Read
+// counts towards the timeout
+func (b *idleBody) Read(p []byte) (int, error) {
+	b.timer.Reset(b.timeout)
+	n, err := b.ReadCloser.Read(p)
+	b.timer.Stop()
+	if err != nil && atomic.LoadInt32(&b.stalled) == 1 {
+		return n, fmt.Errorf("external body %s stalled, no data for %s", b.uri, b.timeout)
+	}
+	return n, err
+}
+
+// Close implements the io.Closer interface
+func (b *idleBody) Close() error {
+	b.timer.Stop()
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// limitedBody errors once more than limit bytes are read
+type limitedBody struct {
+	io.ReadCloser
+	uri    string
+	limit  int64
+	remain int64
+}
+
+// Read implements the io.Reader interface
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remain < 0 {
+		return 0, fmt.Errorf("external body %s is larger than the %d byte limit", b.uri, b.limit)
+	}
+	// read one byte past the limit to tell a body of exactly the limit apart
+	// from a larger one
+	if int64(len(p)) > b.remain+1 {
+		p = p[:b.remain+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remain -= int64(n)
+	if b.remain < 0 {
+		return n, fmt.Errorf("external body %s is larger than the %d byte limit", b.uri, b.limit)
+	}
+	return n, err
+}
+
+// openS3Body reads an s3://bucket/key URI from the bucket's HTTPS endpoint,
+// objects must be publicly readable
+func openS3Body(ctx context.Context, u *url.URL) (io.ReadCloser, error) {
+	return openHTTPBody(ctx, &url.URL{
+		Scheme: "https",
+		Host:   u.Host + ".s3.amazonaws.com",
+		Path:   u.Path,
+	})
+}
@@ -0,0 +1,59 @@
+package dsfs
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsExternalBodyPath(t *testing.T) {
+	cases := []struct {
+		path   string
+		expect bool
+	}{
+		{"https://example.com/body.csv", true},
+		{"HTTP://example.com/body.csv", true},
+		{"s3://bucket/body.csv", true},
+		{"/ipfs/QmeSYBYPm5Rb5Pdu3WS8ps6ZmnBgtLpbZHXGpCjSQzTfGT", false},
+		{"/map/QmeSYBYPm5Rb5Pdu3WS8ps6ZmnBgtLpbZHXGpCjSQzTfGT", false},
+		{"ftp://example.com/body.csv", false},
+		{"file:///tmp/body.csv", false},
+		{"/tmp/?q=https://example.com", false},
+		{"https://", false},
+	}
+	for _, c := range cases {
+		if got := IsExternalBodyPath(c.path); got != c.expect {
+			t.Errorf("%q: expected %t, got %t", c.path, c.expect, got)
+		}
+	}
+}
+
+func TestOpenExternalBodyIdleTimeout(t *testing.T) {
+	defer func(d time.Duration) { externalBodyIdleTimeout = d }(externalBodyIdleTimeout)
+	externalBodyIdleTimeout = 50 * time.Millisecond
+
+	done := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("a,b\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer s.Close()
+	defer close(done)
+
+	f, err := OpenExternalBody(context.Background(), s.URL+"/body.csv", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := ioutil.ReadAll(f); err == nil || !strings.Contains(err.Error(), "stalled") {
+		t.Errorf("expected a stalled body read to fail, got: %v", err)
+	}
+}
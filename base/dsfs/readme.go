@@ -3,6 +3,7 @@ package dsfs
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qfs"
@@ -36,3 +37,33 @@ func LoadReadmeScript(ctx context.Context, store cafs.Filestore, dspath string)
 
 	return store.Get(ctx, ds.Readme.ScriptPath)
 }
+
+// inlineReadmeScript reads a readme's script into ScriptBytes, so versions
+// can be compared by readme text instead of how the script was provided. An
+// open script file is replaced with an in-memory copy, leaving it to be written
+func inlineReadmeScript(ctx context.Context, store cafs.Filestore, rm *dataset.Readme) error {
+	if rm == nil || rm.ScriptBytes != nil {
+		return nil
+	}
+
+	if f := rm.ScriptFile(); f != nil {
+		data, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("reading readme script: %s", err)
+		}
+		rm.ScriptBytes = data
+		rm.SetScriptFile(qfs.NewMemfileBytes(f.FileName(), data))
+		return nil
+	}
+
+	if rm.ScriptPath == "" {
+		return nil
+	}
+	data, err := fileBytes(store.Get(ctx, rm.ScriptPath))
+	if err != nil {
+		return fmt.Errorf("loading readme script: %s", err)
+	}
+	rm.ScriptBytes = data
+	return nil
+}
@@ -0,0 +1,88 @@
+package dsfs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs/cafs"
+)
+
+// VizTemplates is a keyed collection of named viz scripts, by template name.
+// dataset.Viz holds a single script, so a version stores its named templates
+// in viz.json alongside the viz component. Peers that don't know about named
+// templates read the viz component as usual
+type VizTemplates map[string]string
+
+// vizTemplatesKey is the context key for the viz templates of a version
+type vizTemplatesKey struct{}
+
+// vizTemplatesValue holds the viz templates of a version & the version
+// before it
+type vizTemplatesValue struct {
+	templates, prev VizTemplates
+}
+
+// WithVizTemplates returns a context that makes CreateDataset store templates
+// with the version's viz component. prev are the templates of the previous
+// version, so a version that only changes templates isn't empty. A version
+// written without templates doesn't have any
+func WithVizTemplates(ctx context.Context, templates, prev VizTemplates) context.Context {
+	return context.WithValue(ctx, vizTemplatesKey{}, vizTemplatesValue{templates: templates, prev: prev})
+}
+
+// VizTemplatesFromContext returns the templates set with WithVizTemplates, if
+// any
+func VizTemplatesFromContext(ctx context.Context) VizTemplates {
+	v, _ := ctx.Value(vizTemplatesKey{}).(vizTemplatesValue)
+	return v.templates
+}
+
+// vizTemplatesChanged reports whether the templates set with WithVizTemplates
+// differ from the previous version's
+func vizTemplatesChanged(ctx context.Context) bool {
+	v, _ := ctx.Value(vizTemplatesKey{}).(vizTemplatesValue)
+	if len(v.templates) == 0 && len(v.prev) == 0 {
+		return false
+	}
+	return !reflect.DeepEqual(v.templates, v.prev)
+}
+
+// LoadVizTemplates reads the named viz templates of the version at path,
+// returning nil if it has none
+func LoadVizTemplates(ctx context.Context, store cafs.Filestore, path string) (VizTemplates, error) {
+	ds, err := LoadDatasetRefs(ctx, store, path)
+	if err != nil {
+		return nil, err
+	}
+	if ds.Viz == nil || ds.Viz.Path == "" {
+		return nil, nil
+	}
+	data, err := fileBytes(store.Get(ctx, ds.Viz.Path))
+	if err != nil {
+		return nil, fmt.Errorf("error loading viz file: %s", err.Error())
+	}
+	doc := struct {
+		Templates VizTemplates `json:"templates"`
+	}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error unmarshaling viz templates: %s", err.Error())
+	}
+	return doc.Templates, nil
+}
+
+// marshalViz encodes a viz component as viz.json, adding any named templates
+func marshalViz(vz *dataset.Viz, templates VizTemplates) ([]byte, error) {
+	data, err := json.Marshal(vz)
+	if err != nil || len(templates) == 0 {
+		return data, err
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	doc["templates"] = templates
+	return json.Marshal(doc)
+}
@@ -0,0 +1,308 @@
+package base
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/detect"
+	"github.com/qri-io/qfs"
+)
+
+// EncodingFormatConfigKey is the structure.formatConfig option that sets the
+// character encoding of a body, skipping detection. Bodies are always stored
+// as UTF-8, so the option is dropped once a body is converted
+const EncodingFormatConfigKey = "encoding"
+
+// Character encodings a body can be converted from
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingISO88591    = "iso-8859-1"
+	EncodingWindows1252 = "windows-1252"
+)
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// ErrBodyEncoding is returned when a body can't be decoded as text
+type ErrBodyEncoding struct {
+	// Encoding is the encoding the body was decoded as, empty if the encoding
+	// couldn't be detected
+	Encoding string
+	// Offset is the position of the first undecodable byte
+	Offset int
+}
+
+// Error implements the error interface
+func (e *ErrBodyEncoding) Error() string {
+	msg := "can't detect the character encoding of the body"
+	if e.Encoding != "" {
+		msg = fmt.Sprintf("body isn't valid %s", e.Encoding)
+	}
+	return fmt.Sprintf("%s, undecodable byte at offset %d. specify the encoding with structure.formatConfig.%s, one of: %s", msg, e.Offset, EncodingFormatConfigKey, strings.Join(encodingNames, ", "))
+}
+
+// encodingNames lists supported encodings in the order they're suggested
+var encodingNames = []string{EncodingUTF8, EncodingUTF16LE, EncodingUTF16BE, EncodingISO88591, EncodingWindows1252}
+
+// ParseEncoding normalizes the name of a character encoding
+func ParseEncoding(name string) (string, error) {
+	switch strings.Replace(strings.ToLower(strings.TrimSpace(name)), "_", "-", -1) {
+	case "utf-8", "utf8":
+		return EncodingUTF8, nil
+	case "utf-16le", "utf16le", "utf-16", "utf16":
+		return EncodingUTF16LE, nil
+	case "utf-16be", "utf16be":
+		return EncodingUTF16BE, nil
+	case "iso-8859-1", "latin1", "latin-1":
+		return EncodingISO88591, nil
+	case "windows-1252", "cp1252":
+		return EncodingWindows1252, nil
+	}
+	return "", fmt.Errorf("unsupported character encoding %q, options are: %s", name, strings.Join(encodingNames, ", "))
+}
+
+// NormalizeBodyEncoding converts a text body to UTF-8. The source encoding is
+// read from the structure.formatConfig encoding option if set, otherwise it's
+// detected from a byte order mark, or by looking at the body bytes. It returns
+// the converted body & the encoding it was converted from, which is empty
+// when the body is already UTF-8. Binary body formats are returned unchanged
+func NormalizeBodyEncoding(body qfs.File, st *dataset.Structure) (qfs.File, string, error) {
+	var forced string
+	if st != nil && st.FormatConfig != nil {
+		if v, ok := st.FormatConfig[EncodingFormatConfigKey]; ok {
+			s, ok := v.(string)
+			if !ok {
+				return nil, "", fmt.Errorf("invalid %s value: %v", EncodingFormatConfigKey, v)
+			}
+			var err error
+			if forced, err = ParseEncoding(s); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading body: %s", err)
+	}
+	filename := body.FileName()
+	unchanged := qfs.NewMemfileBytes(filename, data)
+
+	if forced == "" && !isTextBody(st, filename, data) {
+		return unchanged, "", nil
+	}
+
+	enc, bomLen := forced, 0
+	if enc == "" {
+		enc, bomLen = detectEncoding(data)
+	} else {
+		bomLen = encodingBOMLen(enc, data)
+	}
+
+	text, err := decodeText(enc, data[bomLen:])
+	if err != nil {
+		if e, ok := err.(*ErrBodyEncoding); ok {
+			e.Offset += bomLen
+			if forced == "" && (enc == EncodingISO88591 || enc == EncodingWindows1252) {
+				// single-byte encodings are a guess, don't claim to know
+				e.Encoding = ""
+			}
+		}
+		return nil, "", err
+	}
+	if enc == EncodingUTF8 {
+		if bomLen == 0 {
+			return unchanged, "", nil
+		}
+		// dropping a byte order mark isn't an encoding change worth reporting
+		return qfs.NewMemfileBytes(filename, text), "", nil
+	}
+	return qfs.NewMemfileBytes(filename, text), enc, nil
+}
+
+// isTextBody reports whether a body is in a text format that can be converted.
+// CBOR & XLSX are binary formats with their own string encodings
+func isTextBody(st *dataset.Structure, filename string, data []byte) bool {
+	var (
+		df  dataset.DataFormat
+		err error
+	)
+	if st != nil && st.Format != "" {
+		df, err = dataset.ParseDataFormatString(st.Format)
+	} else if df, err = detect.ExtensionDataFormat(filename); err != nil {
+		peek := data
+		if len(peek) > sniffLen {
+			peek = peek[:sniffLen]
+		}
+		df, err = SniffDataFormat(peek)
+	}
+	if err != nil {
+		// bodies of unknown formats error later, after they're converted to text
+		return true
+	}
+	return df == dataset.CSVDataFormat || df == dataset.JSONDataFormat
+}
+
+// detectEncoding guesses the character encoding of data, returning the
+// length of any byte order mark
+func detectEncoding(data []byte) (string, int) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return EncodingUTF8, len(bomUTF8)
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return EncodingUTF16LE, len(bomUTF16LE)
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return EncodingUTF16BE, len(bomUTF16BE)
+	}
+
+	if enc := sniffUTF16(data); enc != "" {
+		return enc, 0
+	}
+	if utf8.Valid(data) {
+		return EncodingUTF8, 0
+	}
+
+	// single-byte encodings. windows-1252 prints characters where iso-8859-1
+	// has rarely-used control codes, so their presence tips the scale
+	for _, b := range data {
+		if b >= 0x80 && b <= 0x9f {
+			return EncodingWindows1252, 0
+		}
+	}
+	return EncodingISO88591, 0
+}
+
+// sniffUTF16 checks for UTF-16 without a byte order mark, by looking for text
+// where most ASCII characters have a zero high byte
+func sniffUTF16(data []byte) string {
+	peek := data
+	if len(peek) > sniffLen {
+		peek = peek[:sniffLen]
+	}
+	if len(peek) < 2 {
+		return ""
+	}
+	var evenZeros, oddZeros int
+	pairs := len(peek) / 2
+	for i := 0; i+1 < len(peek); i += 2 {
+		if peek[i] == 0 {
+			evenZeros++
+		}
+		if peek[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	switch {
+	case oddZeros*2 > pairs && evenZeros*10 < pairs:
+		return EncodingUTF16LE
+	case evenZeros*2 > pairs && oddZeros*10 < pairs:
+		return EncodingUTF16BE
+	}
+	return ""
+}
+
+// encodingBOMLen returns the length of a byte order mark that matches enc at
+// the start of data
+func encodingBOMLen(enc string, data []byte) int {
+	var bom []byte
+	switch enc {
+	case EncodingUTF8:
+		bom = bomUTF8
+	case EncodingUTF16LE:
+		bom = bomUTF16LE
+	case EncodingUTF16BE:
+		bom = bomUTF16BE
+	}
+	if bom != nil && bytes.HasPrefix(data, bom) {
+		return len(bom)
+	}
+	return 0
+}
+
+// decodeText converts data in the given encoding to UTF-8
+func decodeText(enc string, data []byte) ([]byte, error) {
+	switch enc {
+	case EncodingUTF8:
+		for i := 0; i < len(data); {
+			r, size := utf8.DecodeRune(data[i:])
+			if r == utf8.RuneError && size == 1 {
+				return nil, &ErrBodyEncoding{Encoding: enc, Offset: i}
+			}
+			i += size
+		}
+		return data, nil
+	case EncodingUTF16LE, EncodingUTF16BE:
+		return decodeUTF16(enc, data)
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(data)+len(data)/8))
+	for i, b := range data {
+		if isBinaryControl(b) {
+			return nil, &ErrBodyEncoding{Encoding: enc, Offset: i}
+		}
+		r := rune(b)
+		if enc == EncodingWindows1252 && b >= 0x80 && b <= 0x9f {
+			if r = windows1252[b-0x80]; r == utf8.RuneError {
+				return nil, &ErrBodyEncoding{Encoding: enc, Offset: i}
+			}
+		}
+		buf.WriteRune(r)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeUTF16 converts UTF-16 data to UTF-8, erroring on a truncated code
+// unit or an unpaired surrogate
+func decodeUTF16(enc string, data []byte) ([]byte, error) {
+	if len(data)%2 != 0 {
+		return nil, &ErrBodyEncoding{Encoding: enc, Offset: len(data) - 1}
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if enc == EncodingUTF16LE {
+			units[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+		} else {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		}
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(units)))
+	for i := 0; i < len(units); i++ {
+		u := rune(units[i])
+		if utf16.IsSurrogate(u) {
+			if i+1 < len(units) {
+				if r := utf16.DecodeRune(u, rune(units[i+1])); r != utf8.RuneError {
+					buf.WriteRune(r)
+					i++
+					continue
+				}
+			}
+			return nil, &ErrBodyEncoding{Encoding: enc, Offset: 2 * i}
+		}
+		buf.WriteRune(u)
+	}
+	return buf.Bytes(), nil
+}
+
+// isBinaryControl reports whether b is a control code that doesn't belong in
+// text, which is a sign a body isn't in a single-byte encoding at all
+func isBinaryControl(b byte) bool {
+	return b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f'
+}
+
+// windows1252 maps bytes 0x80-0x9f of windows-1252 to unicode. The remaining
+// bytes are the same as iso-8859-1. Unassigned bytes map to utf8.RuneError
+var windows1252 = [32]rune{
+	'€', utf8.RuneError, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', utf8.RuneError, 'Ž', utf8.RuneError,
+	utf8.RuneError, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', utf8.RuneError, 'ž', 'Ÿ',
+}
@@ -0,0 +1,89 @@
+package base
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+func TestNormalizeBodyEncoding(t *testing.T) {
+	utf16le := func(s string) []byte {
+		var b []byte
+		for _, r := range s {
+			b = append(b, byte(r), byte(r>>8))
+		}
+		return b
+	}
+
+	cases := []struct {
+		description string
+		filename    string
+		st          *dataset.Structure
+		input       []byte
+		expect      string
+		expectEnc   string
+	}{
+		{"utf-8", "body.csv", nil, []byte("city,pop\nMünchen,1500000\n"), "city,pop\nMünchen,1500000\n", ""},
+		{"utf-8 byte order mark", "body.csv", nil, []byte("\xef\xbb\xbfcity,pop\n"), "city,pop\n", ""},
+		{"latin-1", "body.csv", nil, []byte("city,pop\nM\xfcnchen,1500000\n"), "city,pop\nMünchen,1500000\n", EncodingISO88591},
+		{"windows-1252", "body.csv", nil, []byte("item,price\n\x93a\x94,\x80 5\n"), "item,price\n“a”,€ 5\n", EncodingWindows1252},
+		{"utf-16le byte order mark", "body.csv", nil, append([]byte{0xff, 0xfe}, utf16le("a,b\nü,2\n")...), "a,b\nü,2\n", EncodingUTF16LE},
+		{"utf-16le without byte order mark", "body.json", nil, utf16le(`["a","b"]`), `["a","b"]`, EncodingUTF16LE},
+		{"utf-16be byte order mark", "body.csv", nil, []byte{0xfe, 0xff, 0, 'a', 0, ',', 0, 'b'}, "a,b", EncodingUTF16BE},
+		{"forced encoding", "body.csv", &dataset.Structure{Format: "csv", FormatConfig: map[string]interface{}{"encoding": "latin1"}}, []byte("a\n\x80\n"), "a\n\u0080\n", EncodingISO88591},
+		{"binary formats are unchanged", "body.cbor", nil, []byte{0x81, 0xff, 0x00}, "\x81\xff\x00", ""},
+	}
+
+	for _, c := range cases {
+		f, enc, err := NormalizeBodyEncoding(qfs.NewMemfileBytes(c.filename, c.input), c.st)
+		if err != nil {
+			t.Errorf("case %q unexpected error: %s", c.description, err)
+			continue
+		}
+		if enc != c.expectEnc {
+			t.Errorf("case %q encoding mismatch. want: %q got: %q", c.description, c.expectEnc, enc)
+		}
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != c.expect {
+			t.Errorf("case %q body mismatch. want: %q got: %q", c.description, c.expect, string(data))
+		}
+		if f.FileName() != c.filename {
+			t.Errorf("case %q expected filename to be kept, got: %q", c.description, f.FileName())
+		}
+	}
+
+	bad := []struct {
+		description string
+		st          *dataset.Structure
+		input       []byte
+		offset      int
+		encoding    string
+	}{
+		{"undetectable", nil, []byte("a,b\n\xff\x01\n"), 5, ""},
+		{"windows-1252 unassigned", nil, []byte("a,\x81\x80"), 2, ""},
+		{"forced utf-8", &dataset.Structure{FormatConfig: map[string]interface{}{"encoding": "utf-8"}}, []byte("a,b\n\xfc\n"), 4, EncodingUTF8},
+		{"truncated utf-16", nil, []byte{0xff, 0xfe, 'a', 0, 'b'}, 4, EncodingUTF16LE},
+		{"unpaired surrogate", &dataset.Structure{FormatConfig: map[string]interface{}{"encoding": "utf-16le"}}, []byte{'a', 0, 0x00, 0xd8, 'b', 0}, 2, EncodingUTF16LE},
+	}
+	for _, c := range bad {
+		_, _, err := NormalizeBodyEncoding(qfs.NewMemfileBytes("body.csv", c.input), c.st)
+		e, ok := err.(*ErrBodyEncoding)
+		if !ok {
+			t.Errorf("case %q expected an encoding error, got: %v", c.description, err)
+			continue
+		}
+		if e.Offset != c.offset || e.Encoding != c.encoding {
+			t.Errorf("case %q error mismatch. want offset: %d encoding: %q got offset: %d encoding: %q", c.description, c.offset, c.encoding, e.Offset, e.Encoding)
+		}
+	}
+
+	st := &dataset.Structure{FormatConfig: map[string]interface{}{"encoding": "ebcdic"}}
+	if _, _, err := NormalizeBodyEncoding(qfs.NewMemfileBytes("body.csv", []byte("a")), st); err == nil {
+		t.Error("expected unsupported encoding to error")
+	}
+}
@@ -0,0 +1,80 @@
+package base
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base/dsfs"
+)
+
+// MetaPatch creates a patch that sets meta fields of ds to the values in
+// changes, keyed by meta field name as it's written in JSON. A nil value
+// removes a field. Fields that already hold their new value are left out,
+// if nothing changes MetaPatch errors
+func MetaPatch(ds *dataset.Dataset, changes map[string]interface{}) (*Patch, error) {
+	var cur interface{}
+	if ds.Meta != nil {
+		md := *ds.Meta
+		md.DropDerivedValues()
+		cur = &md
+	}
+	return fieldPatch("meta", cur, changes)
+}
+
+// StructurePatch creates a patch that sets structure fields of ds, the same
+// way MetaPatch does for meta
+func StructurePatch(ds *dataset.Dataset, changes map[string]interface{}) (*Patch, error) {
+	var cur interface{}
+	if ds.Structure != nil {
+		st := *ds.Structure
+		st.DropDerivedValues()
+		cur = &st
+	}
+	return fieldPatch("structure", cur, changes)
+}
+
+// fieldPatch creates a patch of changes to top-level fields of a component
+func fieldPatch(component string, comp interface{}, changes map[string]interface{}) (*Patch, error) {
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("no %s fields to update", component)
+	}
+
+	cur := map[string]interface{}{}
+	if comp != nil {
+		v, err := normalizePatchValue(comp)
+		if err != nil {
+			return nil, err
+		}
+		if m, ok := v.(map[string]interface{}); ok {
+			cur = m
+		}
+	}
+
+	keys := make([]string, 0, len(changes))
+	for k := range changes {
+		switch k {
+		case "", "path", "qri":
+			return nil, fmt.Errorf("can't update %s field %q", component, k)
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	p := &Patch{Changes: []*PatchChange{}}
+	for _, k := range keys {
+		to, err := normalizePatchValue(changes[k])
+		if err != nil {
+			return nil, fmt.Errorf("%s field %q: %s", component, k, err)
+		}
+		if reflect.DeepEqual(cur[k], to) {
+			continue
+		}
+		p.Changes = append(p.Changes, &PatchChange{Component: component, Key: k, From: cur[k], To: to})
+	}
+	if len(p.Changes) == 0 {
+		return nil, fmt.Errorf("%w to %s, fields already have these values", dsfs.ErrNoChanges, component)
+	}
+	return p, nil
+}
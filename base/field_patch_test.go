@@ -0,0 +1,59 @@
+package base
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestMetaPatch(t *testing.T) {
+	ds := &dataset.Dataset{
+		Meta: &dataset.Meta{
+			Title:       "cities",
+			Description: "big cities",
+			Keywords:    []string{"population"},
+		},
+	}
+
+	patch, err := MetaPatch(ds, map[string]interface{}{
+		"description": "the largest cities",
+		"keywords":    []string{"population", "cities"},
+		"title":       "cities",
+		"license":     nil,
+		"version":     nil,
+		"theme":       []string{"places"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []*PatchChange{
+		{Component: "meta", Key: "description", From: "big cities", To: "the largest cities"},
+		{Component: "meta", Key: "keywords", From: []interface{}{"population"}, To: []interface{}{"population", "cities"}},
+		{Component: "meta", Key: "theme", To: []interface{}{"places"}},
+	}
+	if diff := cmp.Diff(expect, patch.Changes); diff != "" {
+		t.Errorf("patch changes mismatch (-want +got):\n%s", diff)
+	}
+
+	bad := []map[string]interface{}{
+		nil,
+		{"title": "cities"},
+		{"path": "/map/QmFoo"},
+		{"qri": "md:0"},
+	}
+	for i, changes := range bad {
+		if _, err := MetaPatch(ds, changes); err == nil {
+			t.Errorf("case %d: expected error, got nil", i)
+		}
+	}
+
+	// datasets without meta get one
+	patch, err = MetaPatch(&dataset.Dataset{}, map[string]interface{}{"title": "new"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*PatchChange{{Component: "meta", Key: "title", To: "new"}}, patch.Changes); diff != "" {
+		t.Errorf("patch changes mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -8,6 +8,7 @@ package fill
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 )
@@ -64,18 +65,8 @@ func putFieldsToTargetStruct(fields map[string]interface{}, target reflect.Value
 	usedKeys := make(map[string]bool)
 
 	for i := 0; i < target.NumField(); i++ {
-		// Lowercase the key in order to make matching case-insensitive.
 		fieldName := target.Type().Field(i).Name
-		lowerName := strings.ToLower(fieldName)
-		fieldTag := target.Type().Field(i).Tag
-		if fieldTag != "" && fieldTag.Get("json") != "" {
-			jsonName := fieldTag.Get("json")
-			pos := strings.Index(jsonName, ",")
-			if pos != -1 {
-				jsonName = jsonName[:pos]
-			}
-			lowerName = strings.ToLower(jsonName)
-		}
+		lowerName := fieldKey(target.Type().Field(i))
 
 		val, ok := fields[caseMap[lowerName]]
 		if !ok {
@@ -112,6 +103,47 @@ func putFieldsToTargetStruct(fields map[string]interface{}, target reflect.Value
 	}
 }
 
+// UnknownFields returns the keys of fields that don't match a field of the
+// output struct, sorted. Structs that implement ArbitrarySetter store unknown
+// keys, so have no unknown fields
+func UnknownFields(fields map[string]interface{}, output interface{}) []string {
+	target := reflect.ValueOf(output)
+	if target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+	if target.Kind() != reflect.Struct || getArbitrarySetter(target) != nil {
+		return nil
+	}
+
+	known := make(map[string]bool, target.NumField())
+	for i := 0; i < target.NumField(); i++ {
+		known[fieldKey(target.Type().Field(i))] = true
+	}
+	var unknown []string
+	for k := range fields {
+		if !known[strings.ToLower(k)] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// fieldKey is the lowercase name a struct field is matched by, its json tag
+// name if it has one. Lowercasing makes matching case-insensitive. Tags with
+// only options (eg: `json:",omitempty"`) keep the field name, same as
+// encoding/json
+func fieldKey(field reflect.StructField) string {
+	jsonName := field.Tag.Get("json")
+	if pos := strings.Index(jsonName, ","); pos != -1 {
+		jsonName = jsonName[:pos]
+	}
+	if jsonName != "" {
+		return strings.ToLower(jsonName)
+	}
+	return strings.ToLower(field.Name)
+}
+
 // putValueToPlace stores the val at the place, recusively if necessary
 func putValueToPlace(val interface{}, place reflect.Value, collector *ErrorCollector) {
 	switch place.Kind() {
@@ -146,6 +178,24 @@ func putValueToPlace(val interface{}, place reflect.Value, collector *ErrorColle
 			// If map is nil, nothing more to do.
 			return
 		}
+		if elemType := place.Type().Elem(); elemType.Kind() == reflect.Struct || elemType.Kind() == reflect.Ptr {
+			// Maps of structs are filled one value at a time.
+			component := toStringMap(val)
+			if component == nil {
+				collector.Add(&FieldError{Want: "map", Got: reflect.TypeOf(val).Name(), Val: val})
+				return
+			}
+			create := reflect.MakeMapWithSize(place.Type(), len(component))
+			for k, v := range component {
+				elem := reflect.Indirect(reflect.New(elemType))
+				collector.PushField(k)
+				putValueToPlace(v, elem, collector)
+				collector.PopField()
+				create.SetMapIndex(reflect.ValueOf(k).Convert(place.Type().Key()), elem)
+			}
+			place.Set(create)
+			return
+		}
 		ms, ok := val.(map[string]interface{})
 		if ok {
 			// Special case map[string]string, convert values to strings.
@@ -207,6 +207,25 @@ func TestStructUnknownFields(t *testing.T) {
 	}
 }
 
+func TestUnknownFields(t *testing.T) {
+	data := map[string]interface{}{
+		"name":    "test_name",
+		"Qri":     "qri:0",
+		"Unknown": "value",
+		"extra":   1,
+	}
+
+	got := UnknownFields(data, &dataset.Dataset{})
+	expect := []string{"Unknown", "extra"}
+	if !reflect.DeepEqual(expect, got) {
+		t.Errorf("expected: %v, got: %v", expect, got)
+	}
+
+	if got := UnknownFields(data, &Collection{}); len(got) != 0 {
+		t.Errorf("expected no unknown fields for an ArbitrarySetter, got: %v", got)
+	}
+}
+
 func TestStructYaml(t *testing.T) {
 	yamlData := `name: test_name
 profileID: test_profile_id
@@ -519,6 +538,47 @@ func TestFillMapStringToString(t *testing.T) {
 	}
 }
 
+// Pet is a struct that is stored in a map by name
+type Pet struct {
+	Sound string
+	Legs  int
+}
+
+// Household has maps of structs and pointers to structs
+type Household struct {
+	Pets   map[string]Pet
+	Strays map[string]*Pet
+}
+
+func TestFillMapStringToStruct(t *testing.T) {
+	jsonData := `{
+  "Pets": {
+    "cat": {"Sound": "meow", "Legs": 4}
+  },
+  "Strays": {
+    "eel": {"Sound": "zap"}
+  }
+}`
+	data := make(map[string]interface{})
+	err := json.Unmarshal([]byte(jsonData), &data)
+	if err != nil {
+		panic(err)
+	}
+
+	var h Household
+	err = Struct(data, &h)
+	if err != nil {
+		panic(err)
+	}
+
+	if h.Pets["cat"].Sound != "meow" || h.Pets["cat"].Legs != 4 {
+		t.Errorf("expected: Pets[\"cat\"] to be filled, got: %v", h.Pets["cat"])
+	}
+	if h.Strays["eel"] == nil || h.Strays["eel"].Sound != "zap" {
+		t.Errorf("expected: Strays[\"eel\"] to be filled, got: %v", h.Strays["eel"])
+	}
+}
+
 func TestStringSlice(t *testing.T) {
 	jsonData := `{
   "List": ["a","b","c"]
@@ -777,6 +837,18 @@ func TestFillAttrName(t *testing.T) {
 	}
 }
 
+func TestFillTagOptionsOnly(t *testing.T) {
+	var c struct {
+		Name string `json:",omitempty"`
+	}
+	if err := Struct(map[string]interface{}{"name": "meow"}, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "meow" {
+		t.Errorf("expected a tag without a name to match the field name, got: %q", c.Name)
+	}
+}
+
 func TestFillSubSection(t *testing.T) {
 	jsonData := `{
   "Sub": {
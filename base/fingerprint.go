@@ -0,0 +1,165 @@
+package base
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	crypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// Fingerprint identifies a dataset version by its root path & the blocks it's
+// made of, signed by the version's author. Fingerprints are small enough to
+// publish out-of-band, letting anyone holding a copy of the version confirm
+// it's authentic & complete
+type Fingerprint struct {
+	// Path is the root of the version
+	Path string `json:"path"`
+	// BlocksHash is the hex-encoded sha256 hash of the version's block IDs,
+	// one per line in DAG order
+	BlocksHash string `json:"blocksHash"`
+	BlockCount int    `json:"blockCount"`
+	// AuthorID is the profile ID of the signer
+	AuthorID string `json:"authorID"`
+	// Signature is the base64-encoded signature of SignableBytes
+	Signature string `json:"signature,omitempty"`
+}
+
+// SignableBytes returns the bytes of a fingerprint that are signed
+func (fp *Fingerprint) SignableBytes() []byte {
+	return []byte(fmt.Sprintf("%s\n%s\n%d\n%s", fp.Path, fp.BlocksHash, fp.BlockCount, fp.AuthorID))
+}
+
+// BlockListFunc lists the IDs of the blocks that make up a version
+type BlockListFunc func(ctx context.Context, path string) ([]string, error)
+
+// ComponentBlocks lists a version's root & component paths, the blocks of a
+// version in stores that don't expose a DAG
+func ComponentBlocks(store cafs.Filestore) BlockListFunc {
+	return func(ctx context.Context, path string) ([]string, error) {
+		ds, err := dsfs.LoadDatasetRefs(ctx, store, path)
+		if err != nil {
+			return nil, err
+		}
+		return append([]string{path}, componentPaths(ds)...), nil
+	}
+}
+
+// NewFingerprint creates a fingerprint of the version at path from the list
+// of its blocks, signed with pk
+func NewFingerprint(path string, blocks []string, authorID profile.ID, pk crypto.PrivKey) (*Fingerprint, error) {
+	fp := &Fingerprint{
+		Path:       path,
+		BlocksHash: hashBlocks(blocks),
+		BlockCount: len(blocks),
+		AuthorID:   authorID.String(),
+	}
+	sig, err := pk.Sign(fp.SignableBytes())
+	if err != nil {
+		return nil, fmt.Errorf("signing fingerprint: %s", err)
+	}
+	fp.Signature = base64.StdEncoding.EncodeToString(sig)
+	return fp, nil
+}
+
+func hashBlocks(blocks []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(blocks, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// FingerprintVerification reports whether a local copy of a version matches
+// a fingerprint
+type FingerprintVerification struct {
+	Path string `json:"path"`
+	// Verified is true when every check passed: the copy is authentic &
+	// complete
+	Verified bool `json:"verified"`
+	// RootMatches is true when the local version has the fingerprint's root
+	RootMatches bool `json:"rootMatches"`
+	// BlocksMatch is true when the local version is made of exactly the
+	// fingerprinted blocks
+	BlocksMatch bool `json:"blocksMatch"`
+	// MissingBlocks lists blocks of the local version that aren't stored
+	MissingBlocks []string `json:"missingBlocks,omitempty"`
+	// Signature is the outcome of checking the fingerprint signature against
+	// the key of its author
+	Signature SignatureStatus `json:"signature"`
+	Author    string          `json:"author,omitempty"`
+	// Reasons explain each failed check
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// VerifyFingerprint checks the local version at path against fp. ownerID is
+// the profile ID of the dataset's author, which must have signed fp
+func VerifyFingerprint(ctx context.Context, fp *Fingerprint, path string, ownerID profile.ID, blocks BlockListFunc, missingBlocks MissingBlocksFunc, authorKey AuthorKeyFunc) (*FingerprintVerification, error) {
+	v := &FingerprintVerification{Path: path, Author: fp.AuthorID}
+	fail := func(format string, args ...interface{}) {
+		v.Reasons = append(v.Reasons, fmt.Sprintf(format, args...))
+	}
+
+	v.Signature = verifyFingerprintSignature(ctx, fp, authorKey, fail)
+	if ownerID != "" && fp.AuthorID != ownerID.String() {
+		fail("fingerprint is signed by %s, not the dataset's author %s", fp.AuthorID, ownerID)
+	}
+
+	v.RootMatches = fp.Path == path
+	if !v.RootMatches {
+		fail("local version %s doesn't match fingerprinted version %s", path, fp.Path)
+	}
+
+	missing, err := missingBlocks(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("checking for missing blocks: %w", err)
+	}
+	v.MissingBlocks = missing
+	if len(missing) > 0 {
+		fail("%d blocks of the local version aren't stored", len(missing))
+	} else {
+		ids, err := blocks(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("listing blocks: %w", err)
+		}
+		v.BlocksMatch = len(ids) == fp.BlockCount && hashBlocks(ids) == fp.BlocksHash
+		if !v.BlocksMatch {
+			fail("local blocks don't match the fingerprint")
+		}
+	}
+
+	v.Verified = len(v.Reasons) == 0
+	return v, nil
+}
+
+// verifyFingerprintSignature checks the signature of a fingerprint against
+// the key of the author it names, calling fail to explain failed checks
+func verifyFingerprintSignature(ctx context.Context, fp *Fingerprint, authorKey AuthorKeyFunc, fail func(string, ...interface{})) SignatureStatus {
+	if fp.Signature == "" {
+		fail("fingerprint isn't signed")
+		return SignatureInvalid
+	}
+	id, err := profile.IDB58Decode(fp.AuthorID)
+	if err != nil {
+		fail("invalid author ID: %s", err)
+		return SignatureUnknownKey
+	}
+	pub, err := authorKey(ctx, id)
+	if err != nil {
+		fail("public key of author is unknown: %s", err)
+		return SignatureUnknownKey
+	}
+	sig, err := base64.StdEncoding.DecodeString(fp.Signature)
+	if err != nil {
+		fail("decoding fingerprint signature: %s", err)
+		return SignatureInvalid
+	}
+	if ok, err := pub.Verify(fp.SignableBytes(), sig); err != nil || !ok {
+		fail("fingerprint signature doesn't match the author's key")
+		return SignatureInvalid
+	}
+	return SignatureVerified
+}
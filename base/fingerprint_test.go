@@ -0,0 +1,59 @@
+package base
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-core/crypto"
+	peer "github.com/libp2p/go-libp2p-core/peer"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestVerifyFingerprint(t *testing.T) {
+	ctx := context.Background()
+	pk, pub, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := peer.IDFromPrivateKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	author := profile.IDFromPeerID(pid)
+	authorKey := func(ctx context.Context, id profile.ID) (crypto.PubKey, error) {
+		return pub, nil
+	}
+
+	stored := []string{"/map/root", "/map/body", "/map/structure"}
+	blocks := func(ctx context.Context, path string) ([]string, error) { return stored, nil }
+	noneMissing := func(ctx context.Context, path string) ([]string, error) { return nil, nil }
+	bodyMissing := func(ctx context.Context, path string) ([]string, error) { return []string{"/map/body"}, nil }
+
+	fp, err := NewFingerprint("/map/root", stored, author, pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := VerifyFingerprint(ctx, fp, "/map/root", author, blocks, noneMissing, authorKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Verified || !v.RootMatches || !v.BlocksMatch || v.Signature != SignatureVerified {
+		t.Errorf("expected fingerprint to verify, got: %#v", v)
+	}
+
+	if v, err = VerifyFingerprint(ctx, fp, "/map/root", author, blocks, bodyMissing, authorKey); err != nil {
+		t.Fatal(err)
+	}
+	if v.Verified || len(v.MissingBlocks) != 1 {
+		t.Errorf("expected an incomplete copy to fail, got: %#v", v)
+	}
+
+	if v, err = VerifyFingerprint(ctx, fp, "/map/root", profile.ID("other"), blocks, noneMissing, authorKey); err != nil {
+		t.Fatal(err)
+	}
+	if v.Verified || v.Signature != SignatureVerified {
+		t.Errorf("expected a fingerprint signed by someone other than the dataset's author to fail, got: %#v", v)
+	}
+}
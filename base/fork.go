@@ -0,0 +1,169 @@
+package base
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// ForkDataset creates a new dataset in the repo owner's namespace whose first
+// version copies a source version. The source body is read from its body path
+// & written again, the store is content-addressed so the fork's body resolves
+// to the same path & shares its blocks. The source version & author are
+// recorded as provenance in the first commit of the fork and in the logbook.
+// source must have a Path. Versions that aren't stored locally are fetched if
+// the store supports fetching
+func ForkDataset(ctx context.Context, r repo.Repo, str ioes.IOStreams, source reporef.DatasetRef, name string) (ref reporef.DatasetRef, err error) {
+	if source.Path == "" {
+		return ref, fmt.Errorf("a version path is required to fork a dataset")
+	}
+	if !dsref.IsValidName(name) {
+		return ref, dsref.ErrDescribeValidName
+	}
+
+	pro, err := r.Profile()
+	if err != nil {
+		return ref, err
+	}
+	existing := reporef.DatasetRef{Peername: pro.Peername, Name: name}
+	if err = repo.CanonicalizeDatasetRef(r, &existing); err == nil || err == repo.ErrNoHistory {
+		return ref, fmt.Errorf("dataset '%s/%s' %w", pro.Peername, name, repo.ErrAlreadyExists)
+	} else if !repo.IsNotFound(err) {
+		return ref, err
+	}
+
+	src, err := dsfs.LoadDataset(ctx, r.Store(), source.Path)
+	if err != nil {
+		if _, ok := r.Store().(cafs.Fetcher); !ok {
+			return ref, fmt.Errorf("loading source version: %s", err)
+		}
+		if err = FetchDataset(ctx, r, &source, true, true); err != nil {
+			return ref, err
+		}
+		src = source.Dataset
+	}
+
+	// fall back to the source commit for the original author
+	if source.ProfileID == "" && src.Commit != nil && src.Commit.Author != nil {
+		if id, err := profile.IDB58Decode(src.Commit.Author.ID); err == nil {
+			source.ProfileID = id
+		}
+	}
+
+	ds, err := forkedDataset(ctx, r.Store(), src)
+	if err != nil {
+		return ref, err
+	}
+	ds.Peername = pro.Peername
+	ds.Name = name
+	provenance := reporef.ConvertToDsref(source)
+	ds.Commit = &dataset.Commit{
+		Title:   fmt.Sprintf("forked from %s", provenance.Alias()),
+		Message: fmt.Sprintf("forked from %s", provenance),
+	}
+
+	// a fork replaces any trashed dataset with the same name
+	if err = PurgeTrashed(ctx, r, reporef.DatasetRef{Peername: pro.Peername, Name: name}); err != nil {
+		return ref, err
+	}
+
+	book := r.Logbook()
+	forkRef := dsref.Ref{Username: pro.Peername, Name: name}
+	// a log may outlive its reference, only initialize a name if needed
+	if _, err = book.DatasetRef(ctx, forkRef); err != nil {
+		if err = book.WriteDatasetInit(ctx, name); err != nil && err != logbook.ErrNoLogbook {
+			return ref, err
+		}
+	}
+	if err = book.WriteDatasetFork(ctx, forkRef, provenance); err != nil && err != logbook.ErrNoLogbook {
+		return ref, err
+	}
+
+	if ref, err = CreateDataset(ctx, r, str, ds, nil, false, true, false, true); err != nil {
+		// close the dataset log so the name can be used again
+		if book != nil {
+			if delErr := book.WriteDatasetDelete(ctx, forkRef); delErr != nil {
+				log.Debugf("removing log of failed fork: %s", delErr)
+			}
+		}
+		return ref, err
+	}
+	return ref, nil
+}
+
+// ForkOrigin returns the version a dataset was forked from as a reference
+// string, or an empty string if the dataset isn't a fork
+func ForkOrigin(ctx context.Context, r repo.Repo, ref dsref.Ref) string {
+	src, err := r.Logbook().ForkSource(ctx, ref)
+	if err != nil || src.Path == "" {
+		return ""
+	}
+	return src.String()
+}
+
+// forkedDataset copies the components of a stored version into a new
+// dataset, opening the body & scripts from the store. The body path isn't
+// copied, it's set when the opened body is written
+func forkedDataset(ctx context.Context, store cafs.Filestore, src *dataset.Dataset) (*dataset.Dataset, error) {
+	ds := &dataset.Dataset{
+		Meta: src.Meta,
+	}
+	if ds.Meta != nil {
+		ds.Meta.DropDerivedValues()
+	}
+	if src.Structure != nil {
+		ds.Structure = src.Structure
+		ds.Structure.DropDerivedValues()
+	}
+
+	body, err := dsfs.LoadBody(ctx, store, src)
+	if err != nil {
+		return nil, fmt.Errorf("loading source body: %s", err)
+	}
+	ds.SetBodyFile(body)
+
+	if src.Readme != nil {
+		ds.Readme = src.Readme
+		ds.Readme.DropDerivedValues()
+		if ds.Readme.ScriptPath != "" {
+			f, err := store.Get(ctx, ds.Readme.ScriptPath)
+			if err != nil {
+				return nil, fmt.Errorf("loading source readme: %s", err)
+			}
+			ds.Readme.SetScriptFile(f)
+		}
+	}
+	if src.Transform != nil {
+		ds.Transform = src.Transform
+		ds.Transform.DropDerivedValues()
+		if ds.Transform.ScriptPath != "" {
+			f, err := store.Get(ctx, ds.Transform.ScriptPath)
+			if err != nil {
+				return nil, fmt.Errorf("loading source transform: %s", err)
+			}
+			ds.Transform.SetScriptFile(f)
+		}
+	}
+	if src.Viz != nil {
+		ds.Viz = src.Viz
+		ds.Viz.DropDerivedValues()
+		if ds.Viz.ScriptPath != "" {
+			f, err := store.Get(ctx, ds.Viz.ScriptPath)
+			if err != nil {
+				return nil, fmt.Errorf("loading source viz: %s", err)
+			}
+			ds.Viz.SetScriptFile(f)
+		}
+	}
+
+	return ds, nil
+}
@@ -0,0 +1,57 @@
+package base
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qri-io/qri/base/dsfs"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestForkDataset(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	cities := addCitiesDataset(t, r)
+
+	ref, err := ForkDataset(ctx, r, devNull, cities, "cities_fork")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Name != "cities_fork" || ref.Path == cities.Path {
+		t.Errorf("expected a new version named cities_fork, got: %s", ref)
+	}
+
+	src, err := dsfs.LoadDataset(ctx, r.Store(), cities.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fork, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fork.BodyPath != src.BodyPath {
+		t.Errorf("expected fork to share the source body. want: %s got: %s", src.BodyPath, fork.BodyPath)
+	}
+	if fork.PreviousPath != "" {
+		t.Errorf("expected fork to start a new history, got previous path: %s", fork.PreviousPath)
+	}
+	expectTitle := "forked from peer/cities"
+	if fork.Commit.Title != expectTitle {
+		t.Errorf("commit title mismatch. want: %q got: %q", expectTitle, fork.Commit.Title)
+	}
+
+	expectOrigin := reporef.ConvertToDsref(cities).String()
+	if got := ForkOrigin(ctx, r, reporef.ConvertToDsref(ref)); got != expectOrigin {
+		t.Errorf("fork origin mismatch. want: %q got: %q", expectOrigin, got)
+	}
+	if got := ForkOrigin(ctx, r, reporef.ConvertToDsref(cities)); got != "" {
+		t.Errorf("expected source to have no fork origin, got: %q", got)
+	}
+
+	if _, err := ForkDataset(ctx, r, devNull, cities, "cities_fork"); err == nil {
+		t.Error("expected forking to an existing name to error")
+	}
+	if _, err := ForkDataset(ctx, r, devNull, cities, "Not A Name"); err == nil {
+		t.Error("expected forking to an invalid name to error")
+	}
+}
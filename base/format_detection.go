@@ -0,0 +1,221 @@
+package base
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/detect"
+	"github.com/qri-io/dataset/vals"
+)
+
+// detectLen is the number of leading bytes of a body DetectBodyFormat reads.
+// It's larger than sniffLen to see enough lines to trust a delimiter
+const detectLen = 64 * 1024
+
+// delimiters are the CSV field separators DetectBodyFormat looks for, in order
+// of preference when more than one fits
+var delimiters = []byte{',', '\t', ';', '|'}
+
+// FormatDetection describes the format of a body file, detected from its name
+// & leading bytes
+type FormatDetection struct {
+	// Format is the detected data format: csv, json, cbor or xlsx
+	Format string `json:"format"`
+	// Delimiter separates fields of a CSV body, empty for other formats
+	Delimiter string `json:"delimiter,omitempty"`
+	// Encoding is the character encoding of a text body, empty for binary
+	// formats
+	Encoding string `json:"encoding,omitempty"`
+	// HeaderRow is true when the first row of a CSV body looks like column
+	// names
+	HeaderRow bool `json:"headerRow,omitempty"`
+	// Confidence is a score between 0 & 1 of how sure detection is. Formats
+	// named by a file extension and confirmed by the contents score 1, while
+	// guesses like single-byte encodings & delimiters seen on one line score
+	// lower
+	Confidence float64 `json:"confidence"`
+}
+
+// DetectBodyFormat reads the leading bytes of a body to work out how it's
+// encoded, without saving anything. The filename extension is trusted when
+// the contents don't contradict it, bodies without a known extension are
+// sniffed
+func DetectBodyFormat(filename string, r io.Reader) (*FormatDetection, error) {
+	data := make([]byte, detectLen)
+	n, err := io.ReadFull(r, data)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("reading body: %s", err)
+	}
+	data = data[:n]
+	truncated := n == detectLen
+
+	res := &FormatDetection{Confidence: 1}
+	extDf, extErr := detect.ExtensionDataFormat(filename)
+	if extErr == nil && (extDf == dataset.CBORDataFormat || extDf == dataset.XLSXDataFormat) {
+		res.Format = extDf.String()
+		return res, nil
+	}
+
+	enc, bomLen := detectEncoding(data)
+	if extErr != nil && bomLen == 0 && enc != EncodingUTF8 {
+		// the only binary format that can be sniffed is CBOR
+		if df, err := SniffDataFormat(data); err == nil && df == dataset.CBORDataFormat {
+			res.Format = df.String()
+			res.Confidence = 0.8
+			return res, nil
+		}
+	}
+	text, err := decodeText(enc, trimPartialChar(enc, data[bomLen:], truncated))
+	if err != nil {
+		return nil, err
+	}
+	res.Encoding = enc
+	switch {
+	case enc == EncodingISO88591 || enc == EncodingWindows1252:
+		res.Confidence *= 0.7
+	case bomLen == 0 && (enc == EncodingUTF16LE || enc == EncodingUTF16BE):
+		res.Confidence *= 0.9
+	}
+
+	peek := text
+	if len(peek) > sniffLen {
+		peek = peek[:sniffLen]
+	}
+	sniffed, sniffErr := SniffDataFormat(peek)
+
+	delim, lines := sniffDelimiter(text, truncated || len(text) > len(peek))
+	switch {
+	case extErr == nil:
+		res.Format = extDf.String()
+		if extDf == dataset.CSVDataFormat && delim == 0 || extDf != dataset.CSVDataFormat && sniffed != extDf {
+			// the contents don't look like the extension claims
+			res.Confidence *= 0.6
+		}
+	case sniffErr == nil && sniffed != dataset.CSVDataFormat:
+		res.Format = sniffed.String()
+		res.Confidence *= 0.8
+	case delim != 0:
+		res.Format = dataset.CSVDataFormat.String()
+		res.Confidence *= 0.8
+	default:
+		if sniffErr == nil {
+			sniffErr = ErrUnknownBodyFormat
+		}
+		return nil, sniffErr
+	}
+
+	if res.Format == dataset.CSVDataFormat.String() {
+		if delim == 0 {
+			delim = ','
+		} else if lines < 2 {
+			res.Confidence *= 0.8
+		}
+		res.Delimiter = string(delim)
+		res.HeaderRow = looksLikeHeaderRow(text, delim)
+	}
+	return res, nil
+}
+
+// trimPartialChar drops a trailing partial character a fixed-length read may
+// have cut off
+func trimPartialChar(enc string, data []byte, truncated bool) []byte {
+	if !truncated {
+		return data
+	}
+	switch enc {
+	case EncodingUTF8:
+		return truncateRunes(data)
+	case EncodingUTF16LE, EncodingUTF16BE:
+		data = data[:len(data)-len(data)%2]
+		// a high surrogate needs the low surrogate that was cut off
+		if n := len(data); n >= 2 {
+			hi := data[n-2]
+			if enc == EncodingUTF16LE {
+				hi = data[n-1]
+			}
+			if hi >= 0xd8 && hi <= 0xdb {
+				data = data[:n-2]
+			}
+		}
+	}
+	return data
+}
+
+// sniffDelimiter finds the field separator that splits every complete line of
+// text into the same number of fields, returning the separator & the number of
+// lines it was seen on. Separators inside quoted fields aren't counted. It
+// returns a zero delimiter if none fit
+func sniffDelimiter(text []byte, truncated bool) (byte, int) {
+	text = bytes.TrimSpace(text)
+	if len(text) == 0 || text[0] == '[' || text[0] == '{' {
+		return 0, 0
+	}
+	lines := bytes.Split(text, []byte("\n"))
+	if truncated && len(lines) > 1 {
+		lines = lines[:len(lines)-1]
+	}
+
+	for _, delim := range delimiters {
+		count, seen := -1, 0
+		for _, line := range lines {
+			line = bytes.TrimSuffix(line, []byte("\r"))
+			if len(line) == 0 {
+				continue
+			}
+			n := countUnquoted(line, delim)
+			if count == -1 {
+				count = n
+			} else if n != count && !bytes.Contains(line, []byte(`"`)) {
+				count = 0
+				break
+			}
+			seen++
+		}
+		if count > 0 {
+			return delim, seen
+		}
+	}
+	return 0, 0
+}
+
+// countUnquoted counts occurrences of delim in a line that aren't inside a
+// double-quoted field
+func countUnquoted(line []byte, delim byte) int {
+	n, quoted := 0, false
+	for _, b := range line {
+		switch {
+		case b == '"':
+			quoted = !quoted
+		case b == delim && !quoted:
+			n++
+		}
+	}
+	return n
+}
+
+// looksLikeHeaderRow guesses if the first line of delimited text holds column
+// names: every field must be non-empty, and none can be a number or boolean
+func looksLikeHeaderRow(text []byte, delim byte) bool {
+	first := bytes.TrimSpace(text)
+	if i := bytes.IndexByte(first, '\n'); i >= 0 {
+		first = first[:i]
+	}
+	first = bytes.TrimSuffix(first, []byte("\r"))
+	if len(first) == 0 {
+		return false
+	}
+	for _, field := range strings.Split(string(first), string(delim)) {
+		field = strings.Trim(strings.TrimSpace(field), `"`)
+		if field == "" {
+			return false
+		}
+		switch vals.ParseType([]byte(field)) {
+		case vals.TypeInteger, vals.TypeNumber, vals.TypeBoolean:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package base
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDetectBodyFormat(t *testing.T) {
+	cases := []struct {
+		filename, data string
+		expect         FormatDetection
+		err            string
+	}{
+		{"body.csv", "name,count\nfoo,1\nbar,2\n", FormatDetection{Format: "csv", Delimiter: ",", Encoding: "utf-8", HeaderRow: true, Confidence: 1}, ""},
+		{"body.csv", "1,2\n3,4\n", FormatDetection{Format: "csv", Delimiter: ",", Encoding: "utf-8", Confidence: 1}, ""},
+		{"body.csv", "name;note\nfoo;\"a; b\"\n", FormatDetection{Format: "csv", Delimiter: ";", Encoding: "utf-8", HeaderRow: true, Confidence: 1}, ""},
+		{"body.txt", "name\tcount\nfoo\t1\n", FormatDetection{Format: "csv", Delimiter: "\t", Encoding: "utf-8", HeaderRow: true, Confidence: 0.8}, ""},
+		{"body", "a|b\n", FormatDetection{Format: "csv", Delimiter: "|", Encoding: "utf-8", HeaderRow: true, Confidence: 0.8 * 0.8}, ""},
+		{"body.csv", "caf\xe9,1\nna\xefve,2\n", FormatDetection{Format: "csv", Delimiter: ",", Encoding: "iso-8859-1", Confidence: 0.7}, ""},
+		{"body.json", `[{"a":1}]`, FormatDetection{Format: "json", Encoding: "utf-8", Confidence: 1}, ""},
+		{"body.json", "a,b\n1,2\n", FormatDetection{Format: "json", Encoding: "utf-8", Confidence: 0.6}, ""},
+		{"body", "\xef\xbb\xbf[1,2]", FormatDetection{Format: "json", Encoding: "utf-8", Confidence: 0.8}, ""},
+		{"body", "\x82\x01\x02", FormatDetection{Format: "cbor", Confidence: 0.8}, ""},
+		{"body.xlsx", "PK\x03\x04", FormatDetection{Format: "xlsx", Confidence: 1}, ""},
+		{"body", "just some words", FormatDetection{}, "cannot determine body format, please specify structure.format"},
+	}
+
+	for i, c := range cases {
+		got, err := DetectBodyFormat(c.filename, strings.NewReader(c.data))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: %q, got: %v", i, c.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		confidence := got.Confidence
+		got.Confidence = c.expect.Confidence
+		if *got != c.expect || math.Abs(confidence-c.expect.Confidence) > 1e-9 {
+			got.Confidence = confidence
+			t.Errorf("case %d result mismatch.\nexpected: %#v\ngot:      %#v", i, c.expect, *got)
+		}
+	}
+}
+
+func TestDetectBodyFormatUTF16(t *testing.T) {
+	data := []byte{0xff, 0xfe}
+	for _, b := range []byte("a,b\n1,2\n") {
+		data = append(data, b, 0)
+	}
+	got, err := DetectBodyFormat("body.csv", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Encoding != EncodingUTF16LE || got.Delimiter != "," || got.Confidence != 1 {
+		t.Errorf("expected utf-16le csv, got: %#v", got)
+	}
+}
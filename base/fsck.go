@@ -0,0 +1,433 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// RefProblem names a kind of inconsistency found while checking a repo
+type RefProblem string
+
+const (
+	// ProblemDanglingRef is a reference whose head version can't be loaded
+	ProblemDanglingRef = RefProblem("dangling ref")
+	// ProblemMissingBlocks is a reference with version blocks missing from the
+	// store
+	ProblemMissingBlocks = RefProblem("missing blocks")
+	// ProblemMissingLog is a reference with no corresponding logbook entry
+	ProblemMissingLog = RefProblem("missing log")
+	// ProblemMissingFSIDir is a reference linked to a working directory that
+	// no longer exists
+	ProblemMissingFSIDir = RefProblem("missing fsi dir")
+	// ProblemOrphanedLog is a logbook entry with no corresponding reference
+	ProblemOrphanedLog = RefProblem("orphaned log")
+	// ProblemLogMismatch is a reference whose head version isn't the head
+	// version of its logbook entry
+	ProblemLogMismatch = RefProblem("log mismatch")
+)
+
+// RefCheck is the result of checking a single reference
+type RefCheck struct {
+	Ref     string `json:"ref"`
+	Path    string `json:"path,omitempty"`
+	FSIPath string `json:"fsiPath,omitempty"`
+	// LogPath is the head version according to the logbook, set when it
+	// doesn't match Path
+	LogPath       string       `json:"logPath,omitempty"`
+	Problems      []RefProblem `json:"problems,omitempty"`
+	MissingBlocks []string     `json:"missingBlocks,omitempty"`
+	// Error describes why a head version couldn't be loaded
+	Error string `json:"error,omitempty"`
+}
+
+// OK returns true if no problems were found
+func (rc RefCheck) OK() bool {
+	return len(rc.Problems) == 0
+}
+
+// RepoCheck is a report describing the consistency of a repo
+type RepoCheck struct {
+	// OK is the number of references without problems
+	OK int `json:"ok"`
+	// Counts tallies problems by kind
+	Counts map[RefProblem]int `json:"counts"`
+	// Refs details each checked reference
+	Refs []RefCheck `json:"refs"`
+	// OrphanedLogs lists aliases of logbook entries with no reference
+	OrphanedLogs []string `json:"orphanedLogs,omitempty"`
+}
+
+// Problems returns only the reference checks that found problems
+func (c RepoCheck) Problems() []RefCheck {
+	var res []RefCheck
+	for _, rc := range c.Refs {
+		if !rc.OK() {
+			res = append(res, rc)
+		}
+	}
+	return res
+}
+
+// MissingBlocksFunc lists blocks of a version that aren't present locally
+type MissingBlocksFunc func(ctx context.Context, path string) ([]string, error)
+
+// MissingComponentBlocks checks a version for missing blocks by confirming
+// the store has the dataset & each of its components
+func MissingComponentBlocks(store cafs.Filestore) MissingBlocksFunc {
+	return func(ctx context.Context, path string) ([]string, error) {
+		ds, err := dsfs.LoadDatasetRefs(ctx, store, path)
+		if err != nil {
+			return nil, err
+		}
+
+		var missing []string
+		for _, p := range componentPaths(ds) {
+			has, err := store.Has(ctx, p)
+			if err != nil {
+				return nil, err
+			}
+			if !has {
+				missing = append(missing, p)
+			}
+		}
+		return missing, nil
+	}
+}
+
+func componentPaths(ds *dataset.Dataset) []string {
+	bodyPath := ds.BodyPath
+	if dsfs.IsExternalBodyPath(bodyPath) {
+		// external bodies aren't kept in the store
+		bodyPath = ""
+	}
+	paths := []string{
+		bodyPath,
+		metaPath(ds.Meta),
+		structurePath(ds.Structure),
+		readmePath(ds.Readme),
+		transformPath(ds.Transform),
+		vizPath(ds.Viz),
+	}
+	if ds.Commit != nil {
+		paths = append(paths, ds.Commit.Path)
+	}
+
+	res := paths[:0]
+	for _, p := range paths {
+		if p != "" {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// CheckRepo walks every reference in a repo, checking that head versions
+// load, references have logbook entries that agree on the head version,
+// linked directories exist, and that logbook entries have references. If missingBlocks is non-nil it's used to
+// check each head version for missing blocks
+func CheckRepo(ctx context.Context, r repo.Repo, missingBlocks MissingBlocksFunc) (*RepoCheck, error) {
+	num, err := r.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.References(0, num)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &RepoCheck{
+		Counts: map[RefProblem]int{},
+		Refs:   make([]RefCheck, 0, len(refs)),
+	}
+	book := r.Logbook()
+	known := map[string]bool{}
+	if trash := TrashOf(r); trash != nil {
+		// trashed datasets keep their logs until the trash is emptied
+		for _, e := range trash.List() {
+			known[e.Ref.AliasString()] = true
+		}
+	}
+
+	for _, ref := range refs {
+		rc := RefCheck{
+			Ref:     ref.AliasString(),
+			Path:    ref.Path,
+			FSIPath: ref.FSIPath,
+		}
+		known[rc.Ref] = true
+
+		if ref.Path != "" {
+			if _, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path); err != nil {
+				rc.Problems = append(rc.Problems, ProblemDanglingRef)
+				rc.Error = err.Error()
+			} else if missingBlocks != nil {
+				missing, err := missingBlocks(ctx, ref.Path)
+				if err != nil {
+					rc.Problems = append(rc.Problems, ProblemMissingBlocks)
+					rc.Error = err.Error()
+				} else if len(missing) > 0 {
+					rc.Problems = append(rc.Problems, ProblemMissingBlocks)
+					rc.MissingBlocks = missing
+				}
+			}
+		}
+
+		if book != nil {
+			dr := reporef.ConvertToDsref(ref)
+			if _, err := book.DatasetRef(ctx, dr); err != nil {
+				rc.Problems = append(rc.Problems, ProblemMissingLog)
+			} else if versions, err := book.Versions(ctx, dr, 0, 1); err == nil && len(versions) > 0 && versions[0].Path != ref.Path {
+				rc.Problems = append(rc.Problems, ProblemLogMismatch)
+				rc.LogPath = versions[0].Path
+			}
+		}
+
+		if ref.FSIPath != "" {
+			if fi, err := os.Stat(ref.FSIPath); err != nil || !fi.IsDir() {
+				rc.Problems = append(rc.Problems, ProblemMissingFSIDir)
+			}
+		}
+
+		for _, p := range rc.Problems {
+			check.Counts[p]++
+		}
+		if rc.OK() {
+			check.OK++
+		}
+		check.Refs = append(check.Refs, rc)
+	}
+
+	if book != nil {
+		logs, err := book.ListAllLogs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, author := range logs {
+			for _, ds := range author.Logs {
+				if ds.Removed() {
+					continue
+				}
+				alias := fmt.Sprintf("%s/%s", author.Name(), ds.Name())
+				if !known[alias] {
+					check.OrphanedLogs = append(check.OrphanedLogs, alias)
+				}
+			}
+		}
+		sort.Strings(check.OrphanedLogs)
+		check.Counts[ProblemOrphanedLog] = len(check.OrphanedLogs)
+	}
+
+	return check, nil
+}
+
+// RepairRepo applies safe fixes for the problems in a check:
+//   - links to missing working directories are cleared
+//   - dangling references are pointed at the newest logged version that loads,
+//     newer logged versions are marked deleted
+//   - missing logs are rebuilt from dataset history
+//   - logs missing the newest versions of a reference have them added, and
+//     references behind their log are moved up to the log's head version
+//
+// Logs are only written for datasets the repo's profile owns. References
+// listed in drop are removed if the check found problems with them. RepairRepo
+// returns the aliases of references it changed
+func RepairRepo(ctx context.Context, r repo.Repo, check *RepoCheck, drop []string) ([]string, error) {
+	dropSet := map[string]bool{}
+	for _, d := range drop {
+		dropSet[d] = true
+	}
+	pro, err := r.Profile()
+	if err != nil {
+		return nil, err
+	}
+
+	var repaired []string
+	for _, rc := range check.Problems() {
+		ref, err := repo.ParseDatasetRef(rc.Ref)
+		if err != nil {
+			return repaired, err
+		}
+		if ref, err = r.GetRef(ref); err != nil {
+			return repaired, fmt.Errorf("getting reference %s: %s", rc.Ref, err)
+		}
+
+		if dropSet[rc.Ref] {
+			if err := r.DeleteRef(ref); err != nil {
+				return repaired, fmt.Errorf("removing reference %s: %s", rc.Ref, err)
+			}
+			repaired = append(repaired, rc.Ref)
+			continue
+		}
+
+		owned := ref.Peername == pro.Peername
+		changed := false
+		switch {
+		case hasProblem(rc, ProblemDanglingRef):
+			if changed, err = rewindDanglingRef(ctx, r, &ref, owned); err != nil {
+				return repaired, fmt.Errorf("rewinding reference %s: %s", rc.Ref, err)
+			}
+		case hasProblem(rc, ProblemLogMismatch):
+			if changed, err = reconcileLog(ctx, r, &ref, rc.LogPath, owned); err != nil {
+				return repaired, fmt.Errorf("reconciling log of %s: %s", rc.Ref, err)
+			}
+		case hasProblem(rc, ProblemMissingLog) && owned && ref.Path != "":
+			if changed, err = rebuildLog(ctx, r, ref); err != nil {
+				return repaired, fmt.Errorf("rebuilding log of %s: %s", rc.Ref, err)
+			}
+		}
+
+		// references with no history are only a link, clearing it would leave
+		// nothing to reference, leave those for the user to drop
+		if hasProblem(rc, ProblemMissingFSIDir) && ref.Path != "" {
+			ref.FSIPath = ""
+			if err := r.PutRef(ref); err != nil {
+				return repaired, fmt.Errorf("unlinking reference %s: %s", rc.Ref, err)
+			}
+			changed = true
+		}
+		if changed {
+			repaired = append(repaired, rc.Ref)
+		}
+	}
+	return repaired, nil
+}
+
+// RepairableInPlace reports whether RepairRepo can attempt to fix every
+// problem with a reference without removing it
+func RepairableInPlace(rc RefCheck) bool {
+	for _, p := range rc.Problems {
+		switch p {
+		case ProblemMissingFSIDir:
+			if rc.Path == "" {
+				return false
+			}
+		case ProblemDanglingRef, ProblemMissingLog:
+			// dangling references are rewound with their log
+			if hasProblem(rc, ProblemDanglingRef) && hasProblem(rc, ProblemMissingLog) {
+				return false
+			}
+		case ProblemLogMismatch:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// rewindDanglingRef points a reference whose head version can't be loaded at
+// the newest logged version that can, marking newer versions deleted in the log
+func rewindDanglingRef(ctx context.Context, r repo.Repo, ref *reporef.DatasetRef, owned bool) (bool, error) {
+	book := r.Logbook()
+	if book == nil {
+		return false, nil
+	}
+	dr := reporef.ConvertToDsref(*ref)
+	versions, err := book.Versions(ctx, dr, 0, -1)
+	if err != nil {
+		return false, nil
+	}
+
+	for i, v := range versions {
+		if v.Path == "" {
+			continue
+		}
+		if _, err := dsfs.LoadDataset(ctx, r.Store(), v.Path); err != nil {
+			continue
+		}
+		if i > 0 {
+			if !owned {
+				return false, nil
+			}
+			if err := book.WriteVersionDelete(ctx, dr, i); err != nil {
+				return false, err
+			}
+		}
+		ref.Path = v.Path
+		return true, r.PutRef(*ref)
+	}
+	return false, nil
+}
+
+// reconcileLog fixes a reference & its log disagreeing on the head version
+// when one is ahead of the other. Diverged histories are left for the user
+func reconcileLog(ctx context.Context, r repo.Repo, ref *reporef.DatasetRef, logPath string, owned bool) (bool, error) {
+	if ref.Path != "" {
+		if newer, ok := historySince(ctx, r, ref.Path, logPath); ok {
+			// the log is missing the newest versions
+			if !owned {
+				return false, nil
+			}
+			for i := len(newer) - 1; i >= 0; i-- {
+				ds := newer[i]
+				ds.Peername = ref.Peername
+				ds.Name = ref.Name
+				ds.ProfileID = ref.ProfileID.String()
+				if err := r.Logbook().WriteVersionSave(ctx, ds); err != nil {
+					return false, err
+				}
+			}
+			return true, nil
+		}
+	}
+
+	if logPath != "" {
+		if _, ok := historySince(ctx, r, logPath, ref.Path); ok {
+			// the reference is missing the newest versions
+			ref.Path = logPath
+			return true, r.PutRef(*ref)
+		}
+	}
+	return false, nil
+}
+
+// rebuildLog constructs a log from the history of a reference that has none
+func rebuildLog(ctx context.Context, r repo.Repo, ref reporef.DatasetRef) (bool, error) {
+	history, ok := historySince(ctx, r, ref.Path, "")
+	if !ok || r.Logbook() == nil {
+		return false, nil
+	}
+	// logs are constructed oldest version first
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	if err := r.Logbook().ConstructDatasetLog(ctx, reporef.ConvertToDsref(ref), history); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// historySince loads versions from head back to stop, newest first & not
+// including stop. An empty stop loads all history. ok is false if stop isn't
+// an ancestor of head or a version can't be loaded
+func historySince(ctx context.Context, r repo.Repo, head, stop string) (history []*dataset.Dataset, ok bool) {
+	for path := head; path != stop; {
+		if path == "" {
+			return nil, false
+		}
+		ds, err := dsfs.LoadDataset(ctx, r.Store(), path)
+		if err != nil {
+			return nil, false
+		}
+		ds.Path = path
+		history = append(history, ds)
+		path = ds.PreviousPath
+	}
+	return history, true
+}
+
+func hasProblem(rc RefCheck, p RefProblem) bool {
+	for _, rp := range rc.Problems {
+		if rp == p {
+			return true
+		}
+	}
+	return false
+}
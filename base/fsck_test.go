@@ -0,0 +1,208 @@
+package base
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestCheckRepo(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	cities := addCitiesDataset(t, r)
+
+	check, err := CheckRepo(ctx, r, MissingComponentBlocks(r.Store()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.OK != 1 || len(check.Problems()) != 0 || len(check.OrphanedLogs) != 0 {
+		t.Fatalf("expected a healthy repo, got: %#v", check)
+	}
+
+	// missing blocks: remove the body of a saved version
+	flourinated := addFlourinatedCompoundsDataset(t, r)
+	ds, err := dsfs.LoadDataset(ctx, r.Store(), flourinated.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Store().Delete(ctx, ds.BodyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// dangling ref with no log: a reference to a version that isn't stored
+	dangling := reporef.DatasetRef{ProfileID: cities.ProfileID, Peername: cities.Peername, Name: "dangling", Path: "/map/QmDanglingPath"}
+	if err := r.PutRef(dangling); err != nil {
+		t.Fatal(err)
+	}
+
+	// missing fsi dir: link cities to a directory that doesn't exist
+	dir, err := ioutil.TempDir("", "check_repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.RemoveAll(dir)
+	linked := cities
+	linked.FSIPath = dir
+	if err := r.PutRef(linked); err != nil {
+		t.Fatal(err)
+	}
+
+	// orphaned log: a logbook entry without a reference
+	if err := r.Logbook().WriteDatasetInit(ctx, "orphan"); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err = CheckRepo(ctx, r, MissingComponentBlocks(r.Store()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectCounts := map[RefProblem]int{
+		ProblemDanglingRef:   1,
+		ProblemMissingLog:    1,
+		ProblemMissingBlocks: 1,
+		ProblemMissingFSIDir: 1,
+		ProblemOrphanedLog:   1,
+	}
+	if diff := cmp.Diff(expectCounts, check.Counts); diff != "" {
+		t.Errorf("problem counts mismatch (-want +got):\n%s", diff)
+	}
+	if check.OK != 0 {
+		t.Errorf("expected no healthy refs, got: %d", check.OK)
+	}
+	if diff := cmp.Diff([]string{"peer/orphan"}, check.OrphanedLogs); diff != "" {
+		t.Errorf("orphaned logs mismatch (-want +got):\n%s", diff)
+	}
+
+	problems := map[string][]RefProblem{}
+	for _, rc := range check.Problems() {
+		problems[rc.Ref] = rc.Problems
+	}
+	expectProblems := map[string][]RefProblem{
+		"peer/cities":   {ProblemMissingFSIDir},
+		"peer/dangling": {ProblemDanglingRef, ProblemMissingLog},
+		"peer/flourinated_compounds_in_fast_food_packaging": {ProblemMissingBlocks},
+	}
+	if diff := cmp.Diff(expectProblems, problems); diff != "" {
+		t.Errorf("ref problems mismatch (-want +got):\n%s", diff)
+	}
+
+	repaired, err := RepairRepo(ctx, r, check, []string{"peer/dangling"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"peer/cities", "peer/dangling"}, repaired); diff != "" {
+		t.Errorf("repaired refs mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err := r.GetRef(reporef.DatasetRef{Peername: "peer", Name: "cities"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.FSIPath != "" || got.Path != cities.Path {
+		t.Errorf("expected repair to only clear FSIPath, got: %s", got)
+	}
+	if _, err := r.GetRef(dangling); err == nil {
+		t.Error("expected dropped reference to be removed")
+	}
+}
+
+func TestRepairRepoLogs(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t).(*repo.MemRepo)
+	book := r.Logbook()
+	addCitiesDataset(t, r)
+	v2 := updateCitiesDataset(t, r, "second")
+
+	// save without a logbook, leaving the cities log a version behind and
+	// flourinated compounds without a log
+	r.RemoveLogbook()
+	v3 := updateCitiesDataset(t, r, "third")
+	flourinated := addFlourinatedCompoundsDataset(t, r)
+	r.SetLogbook(book)
+
+	check, err := CheckRepo(ctx, r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCounts := map[RefProblem]int{
+		ProblemLogMismatch: 1,
+		ProblemMissingLog:  1,
+		ProblemOrphanedLog: 0,
+	}
+	if diff := cmp.Diff(expectCounts, check.Counts); diff != "" {
+		t.Errorf("problem counts mismatch (-want +got):\n%s", diff)
+	}
+	for _, rc := range check.Problems() {
+		if !RepairableInPlace(rc) {
+			t.Errorf("expected %s to be repairable in place", rc.Ref)
+		}
+	}
+
+	repaired, err := RepairRepo(ctx, r, check, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repaired) != 2 {
+		t.Errorf("expected 2 repaired refs, got: %v", repaired)
+	}
+	expectLogHead(t, r, v3)
+	expectLogHead(t, r, flourinated)
+
+	// a reference behind its log is moved up to the log's head
+	behind := v3
+	behind.Path = v2.Path
+	if err := r.PutRef(behind); err != nil {
+		t.Fatal(err)
+	}
+	expectRepairedHead(t, r, v3.Path)
+
+	// a dangling reference is rewound to the newest version that loads
+	if err := r.Store().Delete(ctx, v3.Path); err != nil {
+		t.Fatal(err)
+	}
+	expectRepairedHead(t, r, v2.Path)
+	expectLogHead(t, r, v2)
+}
+
+func expectLogHead(t *testing.T, r repo.Repo, ref reporef.DatasetRef) {
+	t.Helper()
+	versions, err := r.Logbook().Versions(context.Background(), reporef.ConvertToDsref(ref), 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) == 0 || versions[0].Path != ref.Path {
+		t.Errorf("expected %s log head to be %s, got: %v", ref.AliasString(), ref.Path, versions)
+	}
+}
+
+func expectRepairedHead(t *testing.T, r repo.Repo, path string) {
+	t.Helper()
+	ctx := context.Background()
+	check, err := CheckRepo(ctx, r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RepairRepo(ctx, r, check, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.GetRef(reporef.DatasetRef{Peername: "peer", Name: "cities"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != path {
+		t.Errorf("expected cities head %s, got: %s", path, got.Path)
+	}
+	if check, err = CheckRepo(ctx, r, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(check.Problems()) != 0 {
+		t.Errorf("expected no problems after repair, got: %v", check.Problems())
+	}
+}
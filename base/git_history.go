@@ -0,0 +1,81 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// GitCommit is a commit in the history of a file in a git repository
+type GitCommit struct {
+	Hash       string
+	AuthorDate time.Time
+	Subject    string
+	Body       string
+}
+
+const (
+	// separators for fields & records of git log output, ASCII unit & record
+	// separators are vanishingly unlikely to appear in commit messages
+	gitFieldSep  = "\x1f"
+	gitRecordSep = "\x1e"
+)
+
+// GitFileHistory lists the commits that added or modified a file in a local
+// git repository, oldest first. Commits that delete the file are left out.
+// Reading history shells out to the git command line tool
+func GitFileHistory(ctx context.Context, repoPath, filePath string) ([]GitCommit, error) {
+	format := strings.Join([]string{"%H", "%aI", "%s", "%b"}, gitFieldSep) + gitRecordSep
+	out, err := runGit(ctx, repoPath, "log", "--reverse", "--diff-filter=AM", "--format="+format, "--", filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []GitCommit
+	for _, rec := range strings.Split(string(out), gitRecordSep) {
+		rec = strings.TrimSpace(rec)
+		if rec == "" {
+			continue
+		}
+		fields := strings.SplitN(rec, gitFieldSep, 4)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected git log output: %q", rec)
+		}
+		date, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("parsing author date of commit %s: %s", fields[0], err)
+		}
+		commits = append(commits, GitCommit{
+			Hash:       fields[0],
+			AuthorDate: date.UTC(),
+			Subject:    fields[2],
+			Body:       strings.TrimSpace(fields[3]),
+		})
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("%s has no history in git repository %s", filePath, repoPath)
+	}
+	return commits, nil
+}
+
+// GitFileAt reads the contents of a file at a commit in a local git repository
+func GitFileAt(ctx context.Context, repoPath, hash, filePath string) ([]byte, error) {
+	return runGit(ctx, repoPath, "show", fmt.Sprintf("%s:%s", hash, filePath))
+}
+
+func runGit(ctx context.Context, repoPath string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repoPath}, args...)...)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %s", args[0], err)
+	}
+	return out, nil
+}
@@ -0,0 +1,183 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// LabelVersion attaches a label to the version of a dataset ref.Path points
+// to. A label names at most one version of a dataset, labelling a version
+// that already has the label is a no-op
+func LabelVersion(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, label string) (info dsref.VersionInfo, err error) {
+	if err = dsref.ValidateLabel(label); err != nil {
+		return info, err
+	}
+	book := r.Logbook()
+	if book == nil {
+		return info, logbook.ErrNoLogbook
+	}
+	dsr := reporef.ConvertToDsref(ref)
+	history, err := book.Versions(ctx, dsr, 0, -1)
+	if err != nil {
+		return info, err
+	}
+
+	idx := -1
+	for i, v := range history {
+		if v.Path == ref.Path {
+			idx = i
+		}
+		if hasLabel(v, label) {
+			if v.Path == ref.Path {
+				return v, nil
+			}
+			return info, fmt.Errorf("label %q already names version %s of %s, remove it first", label, v.Path, ref.AliasString())
+		}
+	}
+	if idx < 0 {
+		return info, fmt.Errorf("version %s isn't in the history of %s", ref.Path, ref.AliasString())
+	}
+
+	if err = book.WriteVersionLabel(ctx, dsr, ref.Path, label); err != nil {
+		return info, err
+	}
+	info = history[idx]
+	info.Labels = append(info.Labels, label)
+	return info, nil
+}
+
+// UnlabelVersion detaches a label from the version of a dataset it names
+func UnlabelVersion(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, label string) (info dsref.VersionInfo, err error) {
+	book := r.Logbook()
+	if book == nil {
+		return info, logbook.ErrNoLogbook
+	}
+	dsr := reporef.ConvertToDsref(ref)
+	history, err := book.Versions(ctx, dsr, 0, -1)
+	if err != nil {
+		return info, err
+	}
+
+	i, err := labelIndex(ref, history, label)
+	if err != nil {
+		return info, err
+	}
+	if err = book.WriteVersionUnlabel(ctx, dsr, label); err != nil {
+		return info, err
+	}
+	info = history[i]
+	info.Labels = removeLabel(info.Labels, label)
+	return info, nil
+}
+
+// ResolveRevs sets the generation of absolute revisions, which select a
+// version by label or date, by finding the version they select in the history
+// of a dataset. Generations count from the latest version, which is 1
+func ResolveRevs(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, revs []*dsref.Rev) error {
+	var history []dsref.VersionInfo
+	for _, rev := range revs {
+		if !rev.Absolute() {
+			continue
+		}
+		if history == nil {
+			var err error
+			if history, err = DatasetLog(ctx, r, ref, -1, 0, true); err != nil {
+				return err
+			}
+		}
+		i, err := revIndex(ref, history, rev)
+		if err != nil {
+			return err
+		}
+		rev.Gen = i + 1
+	}
+	return nil
+}
+
+// RevVersion returns the single dataset version a revision selects
+func RevVersion(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, rev *dsref.Rev) (dsref.VersionInfo, error) {
+	if rev.Field != "ds" {
+		return dsref.VersionInfo{}, fmt.Errorf("revision must select a whole dataset version, not a component")
+	}
+	if !rev.Absolute() && rev.Gen < 1 {
+		return dsref.VersionInfo{}, fmt.Errorf("revision must select a single version")
+	}
+
+	history, err := DatasetLog(ctx, r, ref, -1, 0, true)
+	if err != nil {
+		return dsref.VersionInfo{}, err
+	}
+	if rev.Absolute() {
+		i, err := revIndex(ref, history, rev)
+		if err != nil {
+			return dsref.VersionInfo{}, err
+		}
+		return history[i], nil
+	}
+	if rev.Gen > len(history) {
+		return dsref.VersionInfo{}, fmt.Errorf("revision %d is out of range, %s has %d versions", rev.Gen, ref.AliasString(), len(history))
+	}
+	return history[rev.Gen-1], nil
+}
+
+// revIndex finds the position of the version an absolute revision selects in
+// a newest-first history
+func revIndex(ref reporef.DatasetRef, history []dsref.VersionInfo, rev *dsref.Rev) (int, error) {
+	if rev.Label != "" {
+		return labelIndex(ref, history, rev.Label)
+	}
+
+	if len(history) == 0 {
+		return -1, repo.ErrNoHistory
+	}
+	for i, v := range history {
+		if !v.CommitTime.After(rev.Time) {
+			return i, nil
+		}
+	}
+	first := history[len(history)-1].CommitTime
+	return -1, fmt.Errorf("date %s is out of range, the first version of %s was committed %s", rev.Time.Format(time.RFC3339), ref.AliasString(), first.Format(time.RFC3339))
+}
+
+func labelIndex(ref reporef.DatasetRef, history []dsref.VersionInfo, label string) (int, error) {
+	idx, matches := -1, 0
+	for i, v := range history {
+		if hasLabel(v, label) {
+			if idx < 0 {
+				idx = i
+			}
+			matches++
+		}
+	}
+	if matches == 0 {
+		return -1, fmt.Errorf("no version of %s is labelled %q", ref.AliasString(), label)
+	}
+	if matches > 1 {
+		return -1, fmt.Errorf("label %q is ambiguous, it names %d versions of %s", label, matches, ref.AliasString())
+	}
+	return idx, nil
+}
+
+func hasLabel(v dsref.VersionInfo, label string) bool {
+	for _, l := range v.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+func removeLabel(labels []string, label string) []string {
+	for i, l := range labels {
+		if l == label {
+			return append(labels[:i:i], labels[i+1:]...)
+		}
+	}
+	return labels
+}
@@ -0,0 +1,121 @@
+package base
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/dsref"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestLabelVersionAndResolveRevs(t *testing.T) {
+	prev := dsfs.Timestamp
+	defer func() { dsfs.Timestamp = prev }()
+	day := 0
+	dsfs.Timestamp = func() time.Time {
+		day++
+		return time.Date(2019, 6, day, 12, 0, 0, 0, time.UTC)
+	}
+
+	ctx := context.Background()
+	r := newTestRepo(t)
+	v1 := addCitiesDataset(t, r)
+	v2 := updateCitiesDataset(t, r, "second")
+	v3 := updateCitiesDataset(t, r, "third")
+	head := reporef.DatasetRef{Peername: v3.Peername, ProfileID: v3.ProfileID, Name: v3.Name, Path: v3.Path}
+
+	labelAt := func(path string) reporef.DatasetRef {
+		ref := head
+		ref.Path = path
+		return ref
+	}
+
+	info, err := LabelVersion(ctx, r, labelAt(v2.Path), "release-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Path != v2.Path || len(info.Labels) != 1 || info.Labels[0] != "release-1" {
+		t.Errorf("unexpected labelled version: %v", info)
+	}
+	if _, err := LabelVersion(ctx, r, labelAt(v2.Path), "release-1"); err != nil {
+		t.Errorf("expected relabelling the same version to succeed, got: %s", err)
+	}
+	if _, err := LabelVersion(ctx, r, labelAt(v1.Path), "release-1"); err == nil {
+		t.Error("expected labelling a second version with the same label to error")
+	}
+	if _, err := LabelVersion(ctx, r, labelAt(v1.Path), "body"); err == nil {
+		t.Error("expected a label named after a component to error")
+	}
+	if _, err := LabelVersion(ctx, r, labelAt("/map/QmNotAVersion"), "missing"); err == nil {
+		t.Error("expected labelling a version outside the history to error")
+	}
+
+	history, err := DatasetLog(ctx, r, head, -1, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history[1].Labels) != 1 || history[1].Labels[0] != "release-1" {
+		t.Errorf("expected history to show labels, got: %v", history[1].Labels)
+	}
+
+	cases := []struct {
+		rev string
+		gen int
+		err string
+	}{
+		{"release-1", 2, ""},
+		{"2019-06-01", 3, ""},
+		{"2019-06-02", 2, ""},
+		{"2019-06-02T11:00:00Z", 3, ""},
+		{"2019-06-03T12:00:00Z", 1, ""},
+		{"2019-06-03T11:59:00Z", 2, ""},
+		{"2020-01-01", 1, ""},
+		{"2019-05-31", 0, "out of range"},
+		{"unknown", 0, "no version"},
+	}
+
+	for _, c := range cases {
+		rev, err := dsref.ParseRev(c.rev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ResolveRevs(ctx, r, head, []*dsref.Rev{rev})
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("%s: expected error containing %q, got: %v", c.rev, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.rev, err)
+			continue
+		}
+		if rev.Gen != c.gen {
+			t.Errorf("%s: expected generation %d, got: %d", c.rev, c.gen, rev.Gen)
+		}
+	}
+
+	got, err := RevVersion(ctx, r, head, &dsref.Rev{Field: "ds", Label: "release-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != v2.Path {
+		t.Errorf("expected label to select %s, got: %s", v2.Path, got.Path)
+	}
+	if _, err := RevVersion(ctx, r, head, &dsref.Rev{Field: "ds", Gen: 4}); err == nil {
+		t.Error("expected a generation past the first version to error")
+	}
+
+	if _, err := UnlabelVersion(ctx, r, head, "release-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UnlabelVersion(ctx, r, head, "release-1"); err == nil {
+		t.Error("expected removing a label that isn't attached to error")
+	}
+	if _, err := LabelVersion(ctx, r, labelAt(v1.Path), "release-1"); err != nil {
+		t.Errorf("expected a removed label to be reusable, got: %s", err)
+	}
+}
@@ -0,0 +1,277 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
+)
+
+// MergeConflict is a value both sides of a merge changed, differently. Key is
+// the meta field, body row key or body object key in conflict, empty when the
+// whole component conflicts. A nil side removed the value
+type MergeConflict struct {
+	Component string      `json:"component"`
+	Key       string      `json:"key,omitempty"`
+	Base      interface{} `json:"base,omitempty"`
+	Left      interface{} `json:"left,omitempty"`
+	Right     interface{} `json:"right,omitempty"`
+}
+
+// Address returns a dotted address for the value in conflict
+func (c *MergeConflict) Address() string {
+	if c.Key == "" {
+		return c.Component
+	}
+	return fmt.Sprintf("%s.%s", c.Component, c.Key)
+}
+
+// ErrMergeConflict is returned when two versions of a dataset can't be merged
+// without choosing between their changes
+type ErrMergeConflict struct {
+	Conflicts []*MergeConflict
+}
+
+// Error implements the error interface
+func (e *ErrMergeConflict) Error() string {
+	addrs := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		addrs[i] = c.Address()
+	}
+	return fmt.Sprintf("merge conflicts at: %s", strings.Join(addrs, ", "))
+}
+
+// CommonAncestor finds the most recent version in the history of the version
+// at path a that's also in the history of the version at path b
+func CommonAncestor(ctx context.Context, store cafs.Filestore, a, b string) (string, error) {
+	history := map[string]bool{}
+	for path := a; path != ""; {
+		history[path] = true
+		ds, err := dsfs.LoadDataset(ctx, store, path)
+		if err != nil {
+			return "", fmt.Errorf("loading %s: %s", path, err)
+		}
+		path = ds.PreviousPath
+	}
+	for path := b; path != ""; {
+		if history[path] {
+			return path, nil
+		}
+		ds, err := dsfs.LoadDataset(ctx, store, path)
+		if err != nil {
+			return "", fmt.Errorf("loading %s: %s", path, err)
+		}
+		path = ds.PreviousPath
+	}
+	return "", fmt.Errorf("versions have no common ancestor")
+}
+
+// MergeDatasets performs a three-way merge of the changes made in two
+// versions of a dataset since their common ancestor, returning a dataset
+// that's ready to be saved as the next version of left. Meta merges by field &
+// object bodies by key. Array bodies merge by row when bodyKey names a column
+// identifying rows, otherwise the whole body merges as one value, as do other
+// components. When both sides change a value differently MergeDatasets
+// returns an *ErrMergeConflict listing every conflict
+func MergeDatasets(ctx context.Context, store cafs.Filestore, ancestor, left, right *dataset.Dataset, bodyKey string) (*dataset.Dataset, error) {
+	bv, err := patchValues(ctx, store, ancestor)
+	if err != nil {
+		return nil, err
+	}
+	lv, err := patchValues(ctx, store, left)
+	if err != nil {
+		return nil, err
+	}
+	rv, err := patchValues(ctx, store, right)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []*MergeConflict
+	merged := map[string]interface{}{}
+	for _, name := range patchComponents {
+		var (
+			v    interface{}
+			errs []*MergeConflict
+		)
+		switch {
+		case name == "meta":
+			v, errs = mergeFields(name, bv[name], lv[name], rv[name])
+		case name == "body" && bodyKey != "":
+			if v, errs, err = mergeRows(bodyKey, ancestor.Structure, left.Structure, right.Structure, bv[name], lv[name], rv[name]); err != nil {
+				return nil, err
+			}
+		case name == "body":
+			if _, isObject := lv[name].(map[string]interface{}); isObject {
+				v, errs = mergeFields(name, bv[name], lv[name], rv[name])
+				break
+			}
+			fallthrough
+		default:
+			var ok bool
+			if v, ok = mergeValue(bv[name], lv[name], rv[name]); !ok {
+				errs = []*MergeConflict{{Component: name, Base: bv[name], Left: lv[name], Right: rv[name]}}
+			}
+		}
+		conflicts = append(conflicts, errs...)
+		merged[name] = v
+	}
+	if len(conflicts) > 0 {
+		return nil, &ErrMergeConflict{Conflicts: conflicts}
+	}
+
+	res := &dataset.Dataset{
+		Peername: left.Peername,
+		Name:     left.Name,
+	}
+	for _, name := range patchComponents[:5] {
+		if err := assignComponent(res, name, merged[name]); err != nil {
+			return nil, err
+		}
+	}
+	if res.Structure == nil {
+		return nil, fmt.Errorf("merged dataset has no structure")
+	}
+	data, err := encodeBody(res.Structure, merged["body"])
+	if err != nil {
+		return nil, err
+	}
+	res.SetBodyFile(qfs.NewMemfileBytes(fmt.Sprintf("body.%s", res.Structure.Format), data))
+	return res, nil
+}
+
+// mergeValue merges a single value, reporting false if both sides changed it
+// differently
+func mergeValue(base, left, right interface{}) (interface{}, bool) {
+	switch {
+	case reflect.DeepEqual(left, right), reflect.DeepEqual(right, base):
+		return left, true
+	case reflect.DeepEqual(left, base):
+		return right, true
+	}
+	return nil, false
+}
+
+// mergeFields merges objects field by field
+func mergeFields(component string, base, left, right interface{}) (interface{}, []*MergeConflict) {
+	bm, _ := base.(map[string]interface{})
+	lm, _ := left.(map[string]interface{})
+	rm, _ := right.(map[string]interface{})
+	if lm == nil && rm == nil {
+		if v, ok := mergeValue(base, left, right); ok {
+			return v, nil
+		}
+		return nil, []*MergeConflict{{Component: component, Base: base, Left: left, Right: right}}
+	}
+
+	keys := map[string]bool{}
+	for _, m := range []map[string]interface{}{bm, lm, rm} {
+		for k := range m {
+			keys[k] = true
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var conflicts []*MergeConflict
+	res := map[string]interface{}{}
+	for _, k := range sorted {
+		v, ok := mergeValue(bm[k], lm[k], rm[k])
+		if !ok {
+			conflicts = append(conflicts, &MergeConflict{Component: component, Key: k, Base: bm[k], Left: lm[k], Right: rm[k]})
+			continue
+		}
+		if v != nil {
+			res[k] = v
+		}
+	}
+	if len(res) == 0 && lm == nil {
+		return nil, conflicts
+	}
+	return res, conflicts
+}
+
+// keyedRows indexes the rows of an array body by the value of a column
+type keyedRows struct {
+	keys   []string
+	rows   map[string]interface{}
+	labels map[string]string
+}
+
+func indexRows(key string, st *dataset.Structure, body interface{}) (*keyedRows, error) {
+	kr := &keyedRows{rows: map[string]interface{}{}, labels: map[string]string{}}
+	if body == nil {
+		return kr, nil
+	}
+	rows, ok := body.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("can't merge rows by %q, body isn't an array", key)
+	}
+	for i, row := range rows {
+		val, ok := entryField(st, dsio.Entry{Index: i, Value: row}, key)
+		if !ok || val == nil {
+			return nil, fmt.Errorf("row %d has no %q value to merge by", i, key)
+		}
+		k := referenceKey(val)
+		if _, dup := kr.rows[k]; dup {
+			return nil, fmt.Errorf("rows can't be merged by %q, row %d duplicates value %v", key, i, val)
+		}
+		kr.keys = append(kr.keys, k)
+		kr.rows[k] = row
+		kr.labels[k] = fmt.Sprint(val)
+	}
+	return kr, nil
+}
+
+// mergeRows merges array bodies row by row, matching rows by the value of a
+// key column. Rows keep the order of left, followed by rows only right added
+func mergeRows(key string, bst, lst, rst *dataset.Structure, base, left, right interface{}) (interface{}, []*MergeConflict, error) {
+	b, err := indexRows(key, bst, base)
+	if err != nil {
+		return nil, nil, err
+	}
+	l, err := indexRows(key, lst, left)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := indexRows(key, rst, right)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var conflicts []*MergeConflict
+	res := []interface{}{}
+	add := func(k string) {
+		v, ok := mergeValue(b.rows[k], l.rows[k], r.rows[k])
+		if !ok {
+			label := l.labels[k]
+			if label == "" {
+				label = r.labels[k]
+			}
+			conflicts = append(conflicts, &MergeConflict{Component: "body", Key: label, Base: b.rows[k], Left: l.rows[k], Right: r.rows[k]})
+			return
+		}
+		if v != nil {
+			res = append(res, v)
+		}
+	}
+	for _, k := range l.keys {
+		add(k)
+	}
+	for _, k := range r.keys {
+		if _, inLeft := l.rows[k]; !inLeft {
+			add(k)
+		}
+	}
+	return res, conflicts, nil
+}
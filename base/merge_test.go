@@ -0,0 +1,94 @@
+package base
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
+)
+
+func TestMergeDatasets(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	save := func(name string, md *dataset.Meta, body string) *dataset.Dataset {
+		ds := &dataset.Dataset{
+			Peername: "me",
+			Name:     name,
+			Meta:     md,
+			Structure: &dataset.Structure{
+				Format: "json",
+				Schema: columnsSchema(
+					map[string]interface{}{"title": "id", "type": "string"},
+					map[string]interface{}{"title": "n", "type": "integer"},
+				),
+			},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(body)))
+		ref, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded.Peername = ref.Peername
+		loaded.Name = ref.Name
+		return loaded
+	}
+
+	ancestor := save("ancestor", &dataset.Meta{Title: "counts", Description: "some counts"}, `[["a",1],["b",2],["c",3]]`)
+	left := save("left", &dataset.Meta{Title: "letter counts", Description: "some counts"}, `[["a",1],["b",20],["c",3],["d",4]]`)
+	right := save("right", &dataset.Meta{Title: "counts", Description: "counts of letters"}, `[["a",1],["b",2],["e",5]]`)
+
+	merged, err := MergeDatasets(ctx, r.Store(), ancestor, left, right, "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Meta.Title != "letter counts" || merged.Meta.Description != "counts of letters" {
+		t.Errorf("expected meta fields from both sides, got title: %q description: %q", merged.Meta.Title, merged.Meta.Description)
+	}
+	merged.Commit = &dataset.Commit{Title: "merge"}
+	ref, _, err := SaveDataset(ctx, r, devNull, merged, nil, nil, SaveDatasetSwitches{Replace: true, Pin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ReadBody(ref.Dataset, dataset.JSONDataFormat, nil, nil, 0, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(`[["a",1],["b",20],["d",4],["e",5]]`, string(body)); diff != "" {
+		t.Errorf("merged body mismatch (-want +got):\n%s", diff)
+	}
+
+	// without a key, bodies changed on both sides conflict as a whole
+	_, err = MergeDatasets(ctx, r.Store(), ancestor, left, right, "")
+	conflict, ok := err.(*ErrMergeConflict)
+	if !ok {
+		t.Fatalf("expected *ErrMergeConflict, got: %v", err)
+	}
+	if len(conflict.Conflicts) != 1 || conflict.Conflicts[0].Address() != "body" {
+		t.Errorf("expected a single body conflict, got: %s", err)
+	}
+
+	diverged := save("diverged", &dataset.Meta{Title: "tallies", Description: "some counts"}, `[["a",1],["b",7],["c",3]]`)
+	_, err = MergeDatasets(ctx, r.Store(), ancestor, left, diverged, "id")
+	if conflict, ok = err.(*ErrMergeConflict); !ok {
+		t.Fatalf("expected *ErrMergeConflict, got: %v", err)
+	}
+	addrs := []string{}
+	for _, c := range conflict.Conflicts {
+		addrs = append(addrs, c.Address())
+	}
+	if diff := cmp.Diff([]string{"meta.title", "body.b"}, addrs); diff != "" {
+		t.Errorf("conflicts mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err = MergeDatasets(ctx, r.Store(), ancestor, left, right, "missing"); err == nil {
+		t.Error("expected merging by a missing column to error")
+	}
+}
@@ -0,0 +1,99 @@
+// Package parquet reads & writes flat (non-nested) Apache Parquet files,
+// converting between parquet columns & the json-schema column types qri
+// structures use. Both directions work one row group at a time, so bodies
+// larger than memory can pass through
+package parquet
+
+import "fmt"
+
+// magic opens & closes every parquet file
+const magic = "PAR1"
+
+// physical types
+const (
+	typeBoolean           = 0
+	typeInt32             = 1
+	typeInt64             = 2
+	typeInt96             = 3
+	typeFloat             = 4
+	typeDouble            = 5
+	typeByteArray         = 6
+	typeFixedLenByteArray = 7
+)
+
+// repetition types
+const (
+	repRequired = 0
+	repOptional = 1
+	repRepeated = 2
+)
+
+// converted types, the logical type annotations of parquet format version 1
+const (
+	convUTF8            = 0
+	convMap             = 1
+	convMapKeyValue     = 2
+	convList            = 3
+	convEnum            = 4
+	convDecimal         = 5
+	convDate            = 6
+	convTimeMillis      = 7
+	convTimeMicros      = 8
+	convTimestampMillis = 9
+	convTimestampMicros = 10
+	convJSON            = 19
+	convBSON            = 20
+)
+
+// encodings
+const (
+	encPlain           = 0
+	encPlainDictionary = 2
+	encRLE             = 3
+	encRLEDictionary   = 8
+)
+
+// compression codecs
+const (
+	codecUncompressed = 0
+	codecSnappy       = 1
+	codecGzip         = 2
+)
+
+var codecNames = map[int64]string{
+	0: "UNCOMPRESSED",
+	1: "SNAPPY",
+	2: "GZIP",
+	3: "LZO",
+	4: "BROTLI",
+	5: "LZ4",
+	6: "ZSTD",
+	7: "LZ4_RAW",
+}
+
+// page types
+const (
+	pageData       = 0
+	pageIndex      = 1
+	pageDictionary = 2
+	pageDataV2     = 3
+)
+
+// Column describes a column of a flat parquet file with a json-schema type:
+// "string", "integer", "number" or "boolean"
+type Column struct {
+	Name string
+	Type string
+}
+
+// ErrNested is returned when a parquet file has columns that can't be
+// represented as a single value of a row
+type ErrNested struct {
+	Column string
+	Reason string
+}
+
+// Error implements the error interface
+func (e ErrNested) Error() string {
+	return fmt.Sprintf("column %q: %s, only flat parquet columns are supported", e.Column, e.Reason)
+}
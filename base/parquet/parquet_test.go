@@ -0,0 +1,202 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteRead(t *testing.T) {
+	cols := []Column{
+		{Name: "city", Type: "string"},
+		{Name: "pop", Type: "integer"},
+		{Name: "avg_age", Type: "number"},
+		{Name: "in_usa", Type: "boolean"},
+		{Name: "tags", Type: "array"},
+	}
+	rows := [][]interface{}{
+		{"toronto", int64(40000000), 55.5, false, []interface{}{"a"}},
+		{"new york", float64(8500000), int64(44), true, nil},
+		{"chicago", int64(300000), 44.4, true, []interface{}{}},
+		{nil, nil, nil, nil, nil},
+		{"raleigh", 250000, 50.65, true, []interface{}{"b", "c"}},
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.RowGroupSize = 2
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.NumRows() != 5 || r.NumRowGroups() != 3 {
+		t.Errorf("expected 5 rows in 3 row groups, got %d rows in %d groups", r.NumRows(), r.NumRowGroups())
+	}
+	expectCols := []Column{
+		{Name: "city", Type: "string"},
+		{Name: "pop", Type: "integer"},
+		{Name: "avg_age", Type: "number"},
+		{Name: "in_usa", Type: "boolean"},
+		{Name: "tags", Type: "string"},
+	}
+	if diff := cmp.Diff(expectCols, r.Columns()); diff != "" {
+		t.Errorf("columns mismatch (-want +got):\n%s", diff)
+	}
+
+	var got [][]interface{}
+	for i := 0; i < r.NumRowGroups(); i++ {
+		group, err := r.ReadRowGroup(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, group...)
+	}
+	expect := [][]interface{}{
+		{"toronto", int64(40000000), 55.5, false, `["a"]`},
+		{"new york", int64(8500000), float64(44), true, nil},
+		{"chicago", int64(300000), 44.4, true, `[]`},
+		{nil, nil, nil, nil, nil},
+		{"raleigh", int64(250000), 50.65, true, `["b","c"]`},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteRowError(t *testing.T) {
+	w, err := NewWriter(&bytes.Buffer{}, []Column{{Name: "pop", Type: "integer"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.WriteRow([]interface{}{"lots"})
+	if err == nil || !strings.Contains(err.Error(), `column "pop"`) {
+		t.Errorf("expected error naming column, got: %v", err)
+	}
+}
+
+func TestReadNested(t *testing.T) {
+	cases := []struct {
+		schema func(e *tencoder)
+		err    string
+	}{
+		{func(e *tencoder) {
+			e.beginStruct(0)
+			e.i32(3, repRepeated)
+			e.binary(4, []byte("tags"))
+			e.i32(6, convList)
+			e.i32(5, 1)
+			e.endStruct()
+		}, `column "tags": list type`},
+		{func(e *tencoder) {
+			e.beginStruct(0)
+			e.i32(1, typeInt64)
+			e.i32(3, repRepeated)
+			e.binary(4, []byte("scores"))
+			e.endStruct()
+		}, `column "scores": repeated field`},
+	}
+
+	for i, c := range cases {
+		e := newTEncoder()
+		e.i32(1, 1)
+		e.beginList(2, tStruct, 2)
+		e.beginStruct(0)
+		e.binary(4, []byte("schema"))
+		e.i32(5, 1)
+		e.endStruct()
+		c.schema(e)
+		e.i64(3, 0)
+		e.buf = append(e.buf, 0)
+
+		file := append([]byte(magic), e.buf...)
+		file = append(file, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(file[len(file)-4:], uint32(len(e.buf)))
+		file = append(file, magic...)
+
+		_, err := NewReader(bytes.NewReader(file), int64(len(file)))
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("case %d: expected error %q, got: %v", i, c.err, err)
+		}
+	}
+}
+
+func TestDecodeHybrid(t *testing.T) {
+	// a run of four 3s, then one bit-packed group of 0..7 at bit width 3
+	data := []byte{4 << 1, 3, 1<<1 | 1, 0x88, 0xc6, 0xfa}
+	got, err := decodeHybrid(data, 3, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []uint32{3, 3, 3, 3, 0, 1, 2, 3, 4, 5, 6, 7}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("values mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadCorrupt(t *testing.T) {
+	cols := []Column{
+		{Name: "city", Type: "string"},
+		{Name: "pop", Type: "integer"},
+		{Name: "avg_age", Type: "number"},
+		{Name: "in_usa", Type: "boolean"},
+	}
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.RowGroupSize = 3
+	for i := 0; i < 10; i++ {
+		if err := w.WriteRow([]interface{}{"toronto", int64(i), 55.5, i%2 == 0}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.WriteRow([]interface{}{nil, nil, nil, nil}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	valid := buf.Bytes()
+
+	// corrupt files must error, never panic
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		data := append([]byte(nil), valid...)
+		for j := 0; j < 1+rng.Intn(4); j++ {
+			data[rng.Intn(len(data))] = byte(rng.Intn(256))
+		}
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					t.Fatalf("mutation %d panicked: %v", i, p)
+				}
+			}()
+			r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				return
+			}
+			for g := 0; g < r.NumRowGroups(); g++ {
+				if _, err := r.ReadRowGroup(g); err != nil {
+					return
+				}
+			}
+		}()
+	}
+}
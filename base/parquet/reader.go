@@ -0,0 +1,607 @@
+package parquet
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang/snappy"
+)
+
+// julianUnixEpoch is the julian day of 1970-01-01, used by INT96 timestamps
+const julianUnixEpoch = 2440588
+
+// maxPageSize caps the uncompressed size of a page. Writers default to pages
+// of about a megabyte, larger sizes come from corrupt headers
+const maxPageSize = 1 << 28
+
+// Reader reads a flat parquet file one row group at a time
+type Reader struct {
+	r       io.ReaderAt
+	size    int64
+	cols    []readColumn
+	groups  []tstruct
+	numRows int64
+}
+
+type readColumn struct {
+	Column
+	physical int64
+	optional bool
+	typeLen  int
+	convert  func(v interface{}) interface{}
+}
+
+// NewReader reads the footer of a parquet file of size bytes. It errors if
+// the file has columns that can't be read as flat values, naming the column
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < 12 {
+		return nil, fmt.Errorf("not a parquet file: too short")
+	}
+	tail := make([]byte, 8)
+	if _, err := r.ReadAt(tail, size-8); err != nil {
+		return nil, err
+	}
+	if string(tail[4:]) != magic {
+		return nil, fmt.Errorf("not a parquet file: missing magic number")
+	}
+	footerLen := int64(binary.LittleEndian.Uint32(tail))
+	if footerLen > size-12 {
+		return nil, fmt.Errorf("invalid parquet footer length: %d", footerLen)
+	}
+
+	d := &tdecoder{r: bufio.NewReader(io.NewSectionReader(r, size-8-footerLen, footerLen))}
+	meta, err := d.readStruct()
+	if err != nil {
+		return nil, fmt.Errorf("reading parquet metadata: %s", err)
+	}
+
+	pr := &Reader{r: r, size: size, numRows: meta.i64(3)}
+	if pr.cols, err = readSchema(meta.list(2)); err != nil {
+		return nil, err
+	}
+	for _, g := range meta.list(4) {
+		group, _ := g.(tstruct)
+		if len(group.list(1)) != len(pr.cols) {
+			return nil, fmt.Errorf("parquet row group has %d columns, expected %d", len(group.list(1)), len(pr.cols))
+		}
+		pr.groups = append(pr.groups, group)
+	}
+	return pr, nil
+}
+
+// Columns lists the columns of the file
+func (r *Reader) Columns() []Column {
+	cols := make([]Column, len(r.cols))
+	for i, c := range r.cols {
+		cols[i] = c.Column
+	}
+	return cols
+}
+
+// NumRows is the total number of rows in the file
+func (r *Reader) NumRows() int64 {
+	return r.numRows
+}
+
+// NumRowGroups is the number of row groups in the file
+func (r *Reader) NumRowGroups() int {
+	return len(r.groups)
+}
+
+// ReadRowGroup reads the rows of row group i. Null values are nil
+func (r *Reader) ReadRowGroup(i int) ([][]interface{}, error) {
+	if i < 0 || i >= len(r.groups) {
+		return nil, fmt.Errorf("row group %d out of range", i)
+	}
+	group := r.groups[i]
+	numRows := group.i64(3)
+	if numRows < 0 || numRows > r.numRows {
+		return nil, fmt.Errorf("invalid row group size: %d", numRows)
+	}
+
+	// sizes in metadata can't be trusted, rows are allocated once a column has
+	// decoded the expected number of values
+	var rows [][]interface{}
+	for j, cc := range group.list(1) {
+		chunk, _ := cc.(tstruct)
+		vals, err := r.readChunk(r.cols[j], chunk)
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %s", r.cols[j].Name, err)
+		}
+		if int64(len(vals)) != numRows {
+			return nil, fmt.Errorf("column %q: has %d values, expected %d", r.cols[j].Name, len(vals), numRows)
+		}
+		if rows == nil {
+			rows = make([][]interface{}, len(vals))
+			for k := range rows {
+				rows[k] = make([]interface{}, len(r.cols))
+			}
+		}
+		for k, v := range vals {
+			if v != nil {
+				rows[k][j] = r.cols[j].convert(v)
+			}
+		}
+	}
+	return rows, nil
+}
+
+// readSchema maps parquet schema elements to columns. The first element is the
+// root of the schema tree, the rest must be its flat children
+func readSchema(elems []interface{}) ([]readColumn, error) {
+	if len(elems) < 2 {
+		return nil, fmt.Errorf("parquet file has no columns")
+	}
+	var cols []readColumn
+	for _, e := range elems[1:] {
+		el, _ := e.(tstruct)
+		name := el.str(4)
+		if el.i64(5) > 0 || !el.has(1) {
+			reason := "nested group"
+			switch conv, lt := el.i64(6), el.strct(10); {
+			case el.has(6) && conv == convList, lt.has(3):
+				reason = "list type"
+			case el.has(6) && (conv == convMap || conv == convMapKeyValue), lt.has(2):
+				reason = "map type"
+			}
+			return nil, ErrNested{Column: name, Reason: reason}
+		}
+		if el.i64(3) == repRepeated {
+			return nil, ErrNested{Column: name, Reason: "repeated field"}
+		}
+		c, err := readColumnType(el)
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %s", name, err)
+		}
+		c.Name = name
+		c.optional = el.i64(3) == repOptional
+		cols = append(cols, c)
+	}
+	return cols, nil
+}
+
+// readColumnType picks the json-schema type of a column & how to convert its
+// physical values. Dates & times become strings, decimals become numbers
+func readColumnType(el tstruct) (readColumn, error) {
+	c := readColumn{physical: el.i64(1), typeLen: int(el.i64(2))}
+	conv := int64(-1)
+	if el.has(6) {
+		conv = el.i64(6)
+	}
+	logical := el.strct(10)
+	scale := int(el.i64(7))
+	if dec := logical.strct(5); dec != nil {
+		conv = convDecimal
+		scale = int(dec.i64(1))
+	}
+
+	identity := func(v interface{}) interface{} { return v }
+	switch c.physical {
+	case typeBoolean:
+		c.Type, c.convert = "boolean", identity
+	case typeInt32, typeInt64:
+		c.Type, c.convert = "integer", identity
+		switch {
+		case conv == convDecimal:
+			c.Type, c.convert = "number", func(v interface{}) interface{} {
+				return float64(v.(int64)) / math.Pow10(scale)
+			}
+		case conv == convDate || logical.has(6):
+			c.Type, c.convert = "string", func(v interface{}) interface{} {
+				return time.Unix(v.(int64)*86400, 0).UTC().Format("2006-01-02")
+			}
+		case conv == convTimestampMillis:
+			c.Type, c.convert = "string", timestamp(time.Millisecond)
+		case conv == convTimestampMicros:
+			c.Type, c.convert = "string", timestamp(time.Microsecond)
+		case logical.has(8):
+			unit := time.Millisecond
+			switch u := logical.strct(8).strct(2); {
+			case u.has(2):
+				unit = time.Microsecond
+			case u.has(3):
+				unit = time.Nanosecond
+			}
+			c.Type, c.convert = "string", timestamp(unit)
+		}
+	case typeInt96:
+		c.Type, c.convert = "string", func(v interface{}) interface{} {
+			b := v.([]byte)
+			nanos := int64(binary.LittleEndian.Uint64(b[:8]))
+			days := int64(binary.LittleEndian.Uint32(b[8:])) - julianUnixEpoch
+			return time.Unix(days*86400, nanos).UTC().Format(time.RFC3339Nano)
+		}
+	case typeFloat, typeDouble:
+		c.Type, c.convert = "number", identity
+	case typeByteArray, typeFixedLenByteArray:
+		if c.physical == typeFixedLenByteArray && c.typeLen <= 0 {
+			return c, fmt.Errorf("fixed length byte array has no length")
+		}
+		c.Type, c.convert = "string", func(v interface{}) interface{} {
+			b := v.([]byte)
+			if utf8.Valid(b) {
+				return string(b)
+			}
+			return base64.StdEncoding.EncodeToString(b)
+		}
+		if conv == convDecimal {
+			c.Type, c.convert = "number", func(v interface{}) interface{} {
+				f, _ := new(big.Float).SetInt(signedBigInt(v.([]byte))).Float64()
+				return f / math.Pow10(scale)
+			}
+		}
+	default:
+		return c, fmt.Errorf("unknown parquet type: %d", c.physical)
+	}
+	return c, nil
+}
+
+func timestamp(unit time.Duration) func(v interface{}) interface{} {
+	return func(v interface{}) interface{} {
+		return time.Unix(0, v.(int64)*int64(unit)).UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// signedBigInt decodes a big-endian two's complement integer
+func signedBigInt(b []byte) *big.Int {
+	i := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		i.Sub(i, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	return i
+}
+
+// readChunk reads all values of a column chunk, reading only the chunk's
+// bytes from the file
+func (r *Reader) readChunk(col readColumn, chunk tstruct) ([]interface{}, error) {
+	if chunk.has(1) {
+		return nil, fmt.Errorf("column chunks in external files aren't supported")
+	}
+	meta := chunk.strct(3)
+	codec := meta.i64(4)
+	if _, ok := codecNames[codec]; !ok || codec > codecGzip {
+		name := codecNames[codec]
+		if name == "" {
+			name = fmt.Sprintf("%d", codec)
+		}
+		return nil, fmt.Errorf("unsupported compression codec: %s", name)
+	}
+
+	start := meta.i64(9)
+	if meta.has(11) && meta.i64(11) > 0 && meta.i64(11) < start {
+		start = meta.i64(11)
+	}
+	length := meta.i64(7)
+	if start < 0 || length < 0 || length > r.size-start {
+		return nil, fmt.Errorf("invalid column chunk: %d bytes at offset %d", length, start)
+	}
+	buf := make([]byte, length)
+	if _, err := r.r.ReadAt(buf, start); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	numValues := meta.i64(5)
+	if numValues < 0 {
+		return nil, fmt.Errorf("invalid number of values: %d", numValues)
+	}
+	vals := make([]interface{}, 0, capHint(numValues, len(buf)))
+	var dict []interface{}
+	br := bytes.NewReader(buf)
+	for int64(len(vals)) < numValues {
+		header, err := (&tdecoder{r: br}).readStruct()
+		if err != nil {
+			return nil, fmt.Errorf("reading page header: %s", err)
+		}
+		size := header.i64(3)
+		if size < 0 || size > int64(br.Len()) {
+			return nil, fmt.Errorf("invalid page size: %d", size)
+		}
+		data := make([]byte, size)
+		br.Read(data)
+		usize := header.i64(2)
+
+		switch header.i64(1) {
+		case pageDictionary:
+			if data, err = decompress(codec, data, usize); err != nil {
+				return nil, err
+			}
+			dh := header.strct(7)
+			if dict, _, err = decodePlain(col, data, int(dh.i64(1))); err != nil {
+				return nil, err
+			}
+		case pageData:
+			if data, err = decompress(codec, data, usize); err != nil {
+				return nil, err
+			}
+			dh := header.strct(5)
+			n := int(dh.i64(1))
+			if n < 0 {
+				return nil, fmt.Errorf("invalid number of page values: %d", n)
+			}
+			defs := data
+			if col.optional {
+				if len(data) < 4 {
+					return nil, fmt.Errorf("page too short")
+				}
+				l := int(binary.LittleEndian.Uint32(data))
+				if l > len(data)-4 {
+					return nil, fmt.Errorf("invalid definition levels length: %d", l)
+				}
+				defs, data = data[4:4+l], data[4+l:]
+			}
+			if vals, err = appendPage(vals, col, dh.i64(2), defs, data, n, dict); err != nil {
+				return nil, err
+			}
+		case pageDataV2:
+			dh := header.strct(8)
+			n := int(dh.i64(1))
+			if n < 0 {
+				return nil, fmt.Errorf("invalid number of page values: %d", n)
+			}
+			defLen, repLen := dh.i64(5), dh.i64(6)
+			if defLen < 0 || repLen < 0 || defLen+repLen > int64(len(data)) {
+				return nil, fmt.Errorf("invalid level lengths")
+			}
+			defs := data[repLen : repLen+defLen]
+			data = data[repLen+defLen:]
+			if compressed, ok := dh.bool(7); !ok || compressed {
+				if data, err = decompress(codec, data, usize); err != nil {
+					return nil, err
+				}
+			}
+			if vals, err = appendPage(vals, col, dh.i64(4), defs, data, n, dict); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return vals, nil
+}
+
+// appendPage decodes n values of a data page, nil where definition levels
+// mark a null
+func appendPage(vals []interface{}, col readColumn, encoding int64, defs, data []byte, n int, dict []interface{}) ([]interface{}, error) {
+	present := n
+	var levels []uint32
+	if col.optional {
+		var err error
+		if levels, err = decodeHybrid(defs, 1, n); err != nil {
+			return nil, fmt.Errorf("decoding definition levels: %s", err)
+		}
+		present = 0
+		for _, l := range levels {
+			present += int(l)
+		}
+	}
+
+	var values []interface{}
+	switch encoding {
+	case encPlain:
+		var err error
+		if values, _, err = decodePlain(col, data, present); err != nil {
+			return nil, err
+		}
+	case encPlainDictionary, encRLEDictionary:
+		if len(data) < 1 {
+			return nil, fmt.Errorf("page too short")
+		}
+		idx, err := decodeHybrid(data[1:], int(data[0]), present)
+		if err != nil {
+			return nil, fmt.Errorf("decoding dictionary indices: %s", err)
+		}
+		values = make([]interface{}, len(idx))
+		for i, j := range idx {
+			if int(j) >= len(dict) {
+				return nil, fmt.Errorf("dictionary index %d out of range", j)
+			}
+			values[i] = dict[j]
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %d", encoding)
+	}
+
+	if !col.optional {
+		return append(vals, values...), nil
+	}
+	for _, l := range levels {
+		if l == 0 {
+			vals = append(vals, nil)
+			continue
+		}
+		vals = append(vals, values[0])
+		values = values[1:]
+	}
+	return vals, nil
+}
+
+// decodePlain decodes n PLAIN encoded values
+func decodePlain(col readColumn, data []byte, n int) ([]interface{}, []byte, error) {
+	short := fmt.Errorf("page too short for %d values", n)
+	// every plain value takes at least a bit
+	if n < 0 || n > len(data)*8 {
+		return nil, nil, short
+	}
+	vals := make([]interface{}, n)
+	fixed := func(size int) ([]byte, error) {
+		if size < 0 || len(data) < size {
+			return nil, short
+		}
+		b := data[:size]
+		data = data[size:]
+		return b, nil
+	}
+
+	if col.physical == typeBoolean {
+		if len(data) < (n+7)/8 {
+			return nil, nil, short
+		}
+		for i := range vals {
+			vals[i] = data[i/8]>>uint(i%8)&1 == 1
+		}
+		return vals, data[(n+7)/8:], nil
+	}
+
+	for i := range vals {
+		switch col.physical {
+		case typeInt32:
+			b, err := fixed(4)
+			if err != nil {
+				return nil, nil, err
+			}
+			vals[i] = int64(int32(binary.LittleEndian.Uint32(b)))
+		case typeInt64:
+			b, err := fixed(8)
+			if err != nil {
+				return nil, nil, err
+			}
+			vals[i] = int64(binary.LittleEndian.Uint64(b))
+		case typeInt96:
+			b, err := fixed(12)
+			if err != nil {
+				return nil, nil, err
+			}
+			vals[i] = b
+		case typeFloat:
+			b, err := fixed(4)
+			if err != nil {
+				return nil, nil, err
+			}
+			vals[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		case typeDouble:
+			b, err := fixed(8)
+			if err != nil {
+				return nil, nil, err
+			}
+			vals[i] = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		case typeByteArray:
+			l, err := fixed(4)
+			if err != nil {
+				return nil, nil, err
+			}
+			b, err := fixed(int(binary.LittleEndian.Uint32(l)))
+			if err != nil {
+				return nil, nil, err
+			}
+			vals[i] = b
+		case typeFixedLenByteArray:
+			b, err := fixed(col.typeLen)
+			if err != nil {
+				return nil, nil, err
+			}
+			vals[i] = b
+		}
+	}
+	return vals, data, nil
+}
+
+// decodeHybrid decodes n values of the RLE/bit-packing hybrid encoding
+func decodeHybrid(data []byte, width, n int) ([]uint32, error) {
+	if width > 32 {
+		return nil, fmt.Errorf("invalid bit width: %d", width)
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("invalid number of values: %d", n)
+	}
+	out := make([]uint32, 0, capHint(int64(n), len(data)))
+	byteWidth := (width + 7) / 8
+	for len(out) < n {
+		h, k := binary.Uvarint(data)
+		if k <= 0 {
+			return nil, fmt.Errorf("unexpected end of data")
+		}
+		data = data[k:]
+
+		if h&1 == 0 {
+			if len(data) < byteWidth {
+				return nil, fmt.Errorf("unexpected end of data")
+			}
+			var v uint32
+			for i := 0; i < byteWidth; i++ {
+				v |= uint32(data[i]) << uint(8*i)
+			}
+			data = data[byteWidth:]
+			for i := uint64(0); i < h>>1 && len(out) < n; i++ {
+				out = append(out, v)
+			}
+			continue
+		}
+
+		// each group of 8 values takes width bytes, groups past the end of the
+		// data can't be read
+		groups := h >> 1
+		if groups > uint64(len(data)) {
+			groups = uint64(len(data))
+		}
+		count := int(groups) * 8
+		size := int(groups) * width
+		if len(data) < size {
+			// the last bit-packed run may be cut short
+			size = len(data)
+			count = size * 8 / width
+		}
+		for i := 0; i < count && len(out) < n; i++ {
+			var v uint32
+			for b := 0; b < width; b++ {
+				pos := i*width + b
+				if data[pos/8]>>uint(pos%8)&1 == 1 {
+					v |= 1 << uint(b)
+				}
+			}
+			out = append(out, v)
+		}
+		data = data[size:]
+	}
+	return out, nil
+}
+
+// decompress a page, erroring if it's larger than the uncompressed size
+// declared in the page header
+func decompress(codec int64, data []byte, size int64) ([]byte, error) {
+	if size < 0 || size > maxPageSize {
+		return nil, fmt.Errorf("invalid uncompressed page size: %d", size)
+	}
+	switch codec {
+	case codecSnappy:
+		n, err := snappy.DecodedLen(data)
+		if err != nil {
+			return nil, err
+		}
+		if int64(n) > size {
+			return nil, fmt.Errorf("page decompresses to %d bytes, expected %d", n, size)
+		}
+		return snappy.Decode(nil, data)
+	case codecGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		out, err := ioutil.ReadAll(io.LimitReader(r, size+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(out)) > size {
+			return nil, fmt.Errorf("page decompresses to more than %d bytes", size)
+		}
+		return out, nil
+	}
+	return data, nil
+}
+
+// capHint bounds a preallocation taken from file metadata. n is the declared
+// number of values, the hint never exceeds eight values per byte of data
+func capHint(n int64, dataLen int) int {
+	if max := int64(dataLen) * 8; n > max {
+		return int(max)
+	}
+	return int(n)
+}
@@ -0,0 +1,296 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// parquet metadata is serialized with the thrift compact protocol. Only the
+// subset parquet uses is implemented: structs, lists, integers, doubles,
+// booleans & binary
+const (
+	tBoolTrue  = 1
+	tBoolFalse = 2
+	tByte      = 3
+	tI16       = 4
+	tI32       = 5
+	tI64       = 6
+	tDouble    = 7
+	tBinary    = 8
+	tList      = 9
+	tSet       = 10
+	tMap       = 11
+	tStruct    = 12
+)
+
+// tstruct is a decoded thrift struct, values keyed by field id. values are
+// int64, float64, bool, []byte, []interface{} or tstruct
+type tstruct map[int16]interface{}
+
+func (s tstruct) i64(id int16) int64 {
+	v, _ := s[id].(int64)
+	return v
+}
+
+func (s tstruct) has(id int16) bool {
+	_, ok := s[id]
+	return ok
+}
+
+func (s tstruct) str(id int16) string {
+	v, _ := s[id].([]byte)
+	return string(v)
+}
+
+func (s tstruct) bool(id int16) (val, ok bool) {
+	val, ok = s[id].(bool)
+	return val, ok
+}
+
+func (s tstruct) strct(id int16) tstruct {
+	v, _ := s[id].(tstruct)
+	return v
+}
+
+func (s tstruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+// tencoder writes thrift compact protocol
+type tencoder struct {
+	buf  []byte
+	last []int16
+}
+
+func newTEncoder() *tencoder {
+	return &tencoder{last: []int16{0}}
+}
+
+func (e *tencoder) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	e.buf = append(e.buf, b[:n]...)
+}
+
+func (e *tencoder) varint(v int64) {
+	e.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (e *tencoder) fieldHeader(id int16, typ byte) {
+	last := e.last[len(e.last)-1]
+	if delta := id - last; delta > 0 && delta <= 15 {
+		e.buf = append(e.buf, byte(delta)<<4|typ)
+	} else {
+		e.buf = append(e.buf, typ)
+		e.varint(int64(id))
+	}
+	e.last[len(e.last)-1] = id
+}
+
+func (e *tencoder) i32(id int16, v int32) {
+	e.fieldHeader(id, tI32)
+	e.varint(int64(v))
+}
+
+func (e *tencoder) i64(id int16, v int64) {
+	e.fieldHeader(id, tI64)
+	e.varint(v)
+}
+
+func (e *tencoder) bool(id int16, v bool) {
+	if v {
+		e.fieldHeader(id, tBoolTrue)
+	} else {
+		e.fieldHeader(id, tBoolFalse)
+	}
+}
+
+func (e *tencoder) binary(id int16, v []byte) {
+	e.fieldHeader(id, tBinary)
+	e.bytes(v)
+}
+
+func (e *tencoder) bytes(v []byte) {
+	e.uvarint(uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// beginStruct starts a struct field, or a struct list element when id is zero
+func (e *tencoder) beginStruct(id int16) {
+	if id != 0 {
+		e.fieldHeader(id, tStruct)
+	}
+	e.last = append(e.last, 0)
+}
+
+func (e *tencoder) endStruct() {
+	e.buf = append(e.buf, 0)
+	e.last = e.last[:len(e.last)-1]
+}
+
+func (e *tencoder) beginList(id int16, elemType byte, size int) {
+	e.fieldHeader(id, tList)
+	if size < 15 {
+		e.buf = append(e.buf, byte(size)<<4|elemType)
+	} else {
+		e.buf = append(e.buf, 0xf0|elemType)
+		e.uvarint(uint64(size))
+	}
+}
+
+// maxThriftDepth caps struct & list nesting. parquet metadata nests a few
+// levels, deeper nesting comes from corrupt files
+const maxThriftDepth = 64
+
+// tdecoder reads thrift compact protocol. Sizes are read from the input, so
+// nothing is allocated up front based on them
+type tdecoder struct {
+	r     io.ByteReader
+	depth int
+}
+
+func (d *tdecoder) uvarint() (uint64, error) {
+	return binary.ReadUvarint(d.r)
+}
+
+func (d *tdecoder) varint() (int64, error) {
+	u, err := d.uvarint()
+	return int64(u>>1) ^ -int64(u&1), err
+}
+
+func (d *tdecoder) bytes() ([]byte, error) {
+	n, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > math.MaxInt32 {
+		return nil, fmt.Errorf("invalid thrift binary length: %d", n)
+	}
+	b := make([]byte, 0, minUint64(n, 4096))
+	for i := uint64(0); i < n; i++ {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, c)
+	}
+	return b, nil
+}
+
+func (d *tdecoder) readStruct() (tstruct, error) {
+	if d.depth++; d.depth > maxThriftDepth {
+		return nil, fmt.Errorf("thrift struct nested too deeply")
+	}
+	defer func() { d.depth-- }()
+	s := tstruct{}
+	var last int16
+	for {
+		h, err := d.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if h == 0 {
+			return s, nil
+		}
+		typ := h & 0x0f
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			v, err := d.varint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		last = id
+
+		switch typ {
+		case tBoolTrue:
+			s[id] = true
+		case tBoolFalse:
+			s[id] = false
+		default:
+			if s[id], err = d.readValue(typ); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (d *tdecoder) readValue(typ byte) (interface{}, error) {
+	switch typ {
+	case tBoolTrue, tBoolFalse:
+		b, err := d.r.ReadByte()
+		return b == tBoolTrue, err
+	case tByte:
+		b, err := d.r.ReadByte()
+		return int64(int8(b)), err
+	case tI16, tI32, tI64:
+		return d.varint()
+	case tDouble:
+		var b [8]byte
+		for i := range b {
+			var err error
+			if b[i], err = d.r.ReadByte(); err != nil {
+				return nil, err
+			}
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b[:])), nil
+	case tBinary:
+		return d.bytes()
+	case tList, tSet:
+		h, err := d.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(h >> 4)
+		if size == 15 {
+			if size, err = d.uvarint(); err != nil {
+				return nil, err
+			}
+		}
+		if d.depth++; d.depth > maxThriftDepth {
+			return nil, fmt.Errorf("thrift list nested too deeply")
+		}
+		defer func() { d.depth-- }()
+		list := make([]interface{}, 0, minUint64(size, 1024))
+		for i := uint64(0); i < size; i++ {
+			v, err := d.readValue(h & 0x0f)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case tMap:
+		size, err := d.uvarint()
+		if err != nil || size == 0 {
+			return nil, err
+		}
+		kv, err := d.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < size; i++ {
+			if _, err := d.readValue(kv >> 4); err != nil {
+				return nil, err
+			}
+			if _, err := d.readValue(kv & 0x0f); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case tStruct:
+		return d.readStruct()
+	}
+	return nil, fmt.Errorf("unknown thrift type: %d", typ)
+}
+
+func minUint64(a, b uint64) int {
+	if a < b {
+		return int(a)
+	}
+	return int(b)
+}
@@ -0,0 +1,347 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/golang/snappy"
+)
+
+// DefaultRowGroupSize is the number of rows a Writer buffers before writing a
+// row group
+const DefaultRowGroupSize = 10000
+
+// Writer writes rows to a parquet file one row group at a time. Every column
+// is optional, so rows may contain nil values. Values are PLAIN encoded &
+// snappy compressed
+type Writer struct {
+	// RowGroupSize is the number of rows per row group, DefaultRowGroupSize if
+	// zero
+	RowGroupSize int
+
+	w       io.Writer
+	cols    []writeColumn
+	offset  int64
+	rows    int
+	numRows int64
+	groups  [][]byte
+}
+
+type writeColumn struct {
+	Column
+	physical int32
+	conv     int32
+	defs     []uint32
+	values   []byte
+	bools    []bool
+}
+
+// NewWriter creates a parquet Writer that writes columns to w, writing the
+// file header immediately
+func NewWriter(w io.Writer, cols []Column) (*Writer, error) {
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("parquet files must have at least one column")
+	}
+
+	pw := &Writer{w: w}
+	for _, c := range cols {
+		wc := writeColumn{Column: c, conv: -1}
+		switch c.Type {
+		case "boolean":
+			wc.physical = typeBoolean
+		case "integer":
+			wc.physical = typeInt64
+		case "number":
+			wc.physical = typeDouble
+		case "object", "array":
+			wc.physical = typeByteArray
+			wc.conv = convJSON
+		default:
+			wc.physical = typeByteArray
+			wc.conv = convUTF8
+		}
+		pw.cols = append(pw.cols, wc)
+	}
+
+	if err := pw.write([]byte(magic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	return err
+}
+
+// WriteRow adds a row to the current row group, flushing the group once it's
+// full. Rows must have one value per column
+func (w *Writer) WriteRow(row []interface{}) error {
+	if len(row) != len(w.cols) {
+		return fmt.Errorf("row %d has %d values, expected %d", w.numRows, len(row), len(w.cols))
+	}
+	for i, v := range row {
+		if err := w.cols[i].add(v); err != nil {
+			return fmt.Errorf("row %d, column %q: %s", w.numRows, w.cols[i].Name, err)
+		}
+	}
+	w.rows++
+	w.numRows++
+
+	size := w.RowGroupSize
+	if size <= 0 {
+		size = DefaultRowGroupSize
+	}
+	if w.rows >= size {
+		return w.flush()
+	}
+	return nil
+}
+
+// Close flushes buffered rows & writes the file footer. Close doesn't close
+// the underlying writer
+func (w *Writer) Close() error {
+	if w.rows > 0 || len(w.groups) == 0 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+
+	e := newTEncoder()
+	e.i32(1, 1)
+	e.beginList(2, tStruct, len(w.cols)+1)
+	e.beginStruct(0)
+	e.binary(4, []byte("schema"))
+	e.i32(5, int32(len(w.cols)))
+	e.endStruct()
+	for _, c := range w.cols {
+		e.beginStruct(0)
+		e.i32(1, c.physical)
+		e.i32(3, repOptional)
+		e.binary(4, []byte(c.Name))
+		if c.conv >= 0 {
+			e.i32(6, c.conv)
+		}
+		e.endStruct()
+	}
+	e.i64(3, w.numRows)
+	e.beginList(4, tStruct, len(w.groups))
+	for _, g := range w.groups {
+		e.buf = append(e.buf, g...)
+	}
+	e.binary(6, []byte("qri"))
+	e.buf = append(e.buf, 0)
+
+	footer := make([]byte, 4)
+	binary.LittleEndian.PutUint32(footer, uint32(len(e.buf)))
+	if err := w.write(e.buf); err != nil {
+		return err
+	}
+	if err := w.write(footer); err != nil {
+		return err
+	}
+	return w.write([]byte(magic))
+}
+
+// flush writes buffered rows as a row group, keeping only the row group's
+// metadata for the footer
+func (w *Writer) flush() error {
+	e := newTEncoder()
+	e.beginList(1, tStruct, len(w.cols))
+
+	var total int64
+	for i := range w.cols {
+		c := &w.cols[i]
+		start := w.offset
+		page := c.page()
+
+		header := newTEncoder()
+		header.i32(1, pageData)
+		header.i32(2, int32(len(page.uncompressed)))
+		header.i32(3, int32(len(page.compressed)))
+		header.beginStruct(5)
+		header.i32(1, int32(len(c.defs)))
+		header.i32(2, encPlain)
+		header.i32(3, encRLE)
+		header.i32(4, encRLE)
+		header.endStruct()
+		header.buf = append(header.buf, 0)
+
+		if err := w.write(header.buf); err != nil {
+			return err
+		}
+		if err := w.write(page.compressed); err != nil {
+			return err
+		}
+		uncompressed := int64(len(header.buf) + len(page.uncompressed))
+		total += uncompressed
+
+		e.beginStruct(0)
+		e.i64(2, start)
+		e.beginStruct(3)
+		e.i32(1, c.physical)
+		e.beginList(2, tI32, 2)
+		e.varint(encPlain)
+		e.varint(encRLE)
+		e.beginList(3, tBinary, 1)
+		e.bytes([]byte(c.Name))
+		e.i32(4, codecSnappy)
+		e.i64(5, int64(len(c.defs)))
+		e.i64(6, uncompressed)
+		e.i64(7, w.offset-start)
+		e.i64(9, start)
+		e.endStruct()
+		e.endStruct()
+
+		c.defs = c.defs[:0]
+		c.values = c.values[:0]
+		c.bools = c.bools[:0]
+	}
+
+	e.i64(2, total)
+	e.i64(3, int64(w.rows))
+	e.buf = append(e.buf, 0)
+	w.groups = append(w.groups, e.buf)
+	w.rows = 0
+	return nil
+}
+
+type page struct {
+	uncompressed, compressed []byte
+}
+
+// page encodes buffered values as a data page: definition levels followed by
+// non-null values
+func (c *writeColumn) page() page {
+	levels := encodeRLE(c.defs)
+	data := make([]byte, 4, 4+len(levels)+len(c.values))
+	binary.LittleEndian.PutUint32(data, uint32(len(levels)))
+	data = append(data, levels...)
+	if c.physical == typeBoolean {
+		data = append(data, packBools(c.bools)...)
+	} else {
+		data = append(data, c.values...)
+	}
+	return page{uncompressed: data, compressed: snappy.Encode(nil, data)}
+}
+
+func (c *writeColumn) add(v interface{}) error {
+	if v == nil {
+		c.defs = append(c.defs, 0)
+		return nil
+	}
+
+	switch c.physical {
+	case typeBoolean:
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("can't write %T value as boolean", v)
+		}
+		c.bools = append(c.bools, b)
+	case typeInt64:
+		i, err := toInt64(v)
+		if err != nil {
+			return err
+		}
+		c.values = appendUint64(c.values, uint64(i))
+	case typeDouble:
+		f, err := toFloat64(v)
+		if err != nil {
+			return err
+		}
+		c.values = appendUint64(c.values, math.Float64bits(f))
+	default:
+		var b []byte
+		if s, ok := v.(string); ok && c.conv == convUTF8 {
+			b = []byte(s)
+		} else {
+			var err error
+			if b, err = json.Marshal(v); err != nil {
+				return err
+			}
+		}
+		c.values = appendUint32(c.values, uint32(len(b)))
+		c.values = append(c.values, b...)
+	}
+	c.defs = append(c.defs, 1)
+	return nil
+}
+
+func toInt64(v interface{}) (int64, error) {
+	switch x := v.(type) {
+	case int:
+		return int64(x), nil
+	case int32:
+		return int64(x), nil
+	case int64:
+		return x, nil
+	case float64:
+		if x == math.Trunc(x) {
+			return int64(x), nil
+		}
+	case json.Number:
+		return x.Int64()
+	}
+	return 0, fmt.Errorf("can't write %T value %v as integer", v, v)
+}
+
+func toFloat64(v interface{}) (float64, error) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), nil
+	case int32:
+		return float64(x), nil
+	case int64:
+		return float64(x), nil
+	case float32:
+		return float64(x), nil
+	case float64:
+		return x, nil
+	case json.Number:
+		return x.Float64()
+	}
+	return 0, fmt.Errorf("can't write %T value %v as number", v, v)
+}
+
+// encodeRLE encodes levels of bit width 1 as runs of the RLE/bit-packing
+// hybrid encoding
+func encodeRLE(levels []uint32) []byte {
+	var buf []byte
+	for i := 0; i < len(levels); {
+		j := i + 1
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		var h [binary.MaxVarintLen64]byte
+		buf = append(buf, h[:binary.PutUvarint(h[:], uint64(j-i)<<1)]...)
+		buf = append(buf, byte(levels[i]))
+		i = j
+	}
+	return buf
+}
+
+func packBools(bools []bool) []byte {
+	buf := make([]byte, (len(bools)+7)/8)
+	for i, b := range bools {
+		if b {
+			buf[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return buf
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	return append(buf, b[:]...)
+}
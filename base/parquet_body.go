@@ -0,0 +1,258 @@
+package base
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/base/parquet"
+)
+
+// ParquetFormat names the Apache Parquet format. Parquet isn't a format bodies
+// are stored in: parquet bodies are converted when saved, and bodies can be
+// encoded as parquet when read or exported
+const ParquetFormat = "parquet"
+
+// IsParquetBody checks if a body filename has the parquet file extension
+func IsParquetBody(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), "."+ParquetFormat)
+}
+
+// ParquetColumns maps the columns of a tabular schema to parquet columns.
+// Columns with more than one type are written as strings
+func ParquetColumns(schema map[string]interface{}) ([]parquet.Column, error) {
+	cols := Columns(schema)
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("only tabular bodies with schema columns can be written as %s", ParquetFormat)
+	}
+	pcols := make([]parquet.Column, len(cols))
+	for i, c := range cols {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("field_%d", i+1)
+		}
+		pcols[i] = parquet.Column{Name: name, Type: columnType(c.Schema)}
+	}
+	return pcols, nil
+}
+
+// columnType gives the one non-null type of a column schema, if it has one
+func columnType(sch map[string]interface{}) string {
+	switch t := sch["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		typ := ""
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				if typ != "" {
+					return ""
+				}
+				typ = s
+			}
+		}
+		return typ
+	}
+	return ""
+}
+
+// ParquetEntryWriter writes entries of a tabular body as rows of a parquet
+// file. Rows are flushed in row groups as they're written
+type ParquetEntryWriter struct {
+	st    *dataset.Structure
+	cols  []Column
+	pw    *parquet.Writer
+	index int
+}
+
+// NewParquetEntryWriter creates a ParquetEntryWriter for the columns of a
+// structure schema
+func NewParquetEntryWriter(st *dataset.Structure, w io.Writer) (*ParquetEntryWriter, error) {
+	pcols, err := ParquetColumns(st.Schema)
+	if err != nil {
+		return nil, err
+	}
+	pw, err := parquet.NewWriter(w, pcols)
+	if err != nil {
+		return nil, err
+	}
+	return &ParquetEntryWriter{st: st, cols: Columns(st.Schema), pw: pw}, nil
+}
+
+// Structure gives the structure being written
+func (w *ParquetEntryWriter) Structure() *dataset.Structure {
+	return w.st
+}
+
+// WriteEntry writes an entry as a row. Array rows are read by position,
+// object rows by property key
+func (w *ParquetEntryWriter) WriteEntry(ent dsio.Entry) error {
+	row := make([]interface{}, len(w.cols))
+	switch v := ent.Value.(type) {
+	case []interface{}:
+		for i := range row {
+			if i < len(v) {
+				row[i] = v[i]
+			}
+		}
+	case map[string]interface{}:
+		for i, c := range w.cols {
+			row[i] = v[c.Name]
+		}
+	default:
+		return fmt.Errorf("entry %d: can't write %T as a %s row", w.index, ent.Value, ParquetFormat)
+	}
+	w.index++
+	return w.pw.WriteRow(row)
+}
+
+// Close writes the parquet footer. It doesn't close the underlying writer
+func (w *ParquetEntryWriter) Close() error {
+	return w.pw.Close()
+}
+
+// ReadParquetBody reads a page of a dataset body encoded as parquet. Only
+// columns are written if any are given
+func ReadParquetBody(ds *dataset.Dataset, columns []string, limit, offset int, all bool) ([]byte, error) {
+	if ds == nil {
+		return nil, fmt.Errorf("can't load body from a nil dataset")
+	}
+	file := ds.BodyFile()
+	if file == nil {
+		return nil, fmt.Errorf("no body file to read")
+	}
+	if ds.Structure == nil {
+		return nil, fmt.Errorf("dataset has no structure")
+	}
+	out := &dataset.Structure{Format: ParquetFormat, Schema: ds.Structure.Schema}
+	return ConvertBodyFile(file, ds.Structure, out, columns, limit, offset, all)
+}
+
+// WriteParquetBody writes the entire body of a dataset to w as parquet
+func WriteParquetBody(ds *dataset.Dataset, w io.Writer) error {
+	file := ds.BodyFile()
+	if file == nil {
+		return fmt.Errorf("no body file to read")
+	}
+	if ds.Structure == nil {
+		return fmt.Errorf("dataset has no structure")
+	}
+	file, err := dsfs.DecompressBody(file, nil)
+	if err != nil {
+		return err
+	}
+
+	pw, err := NewParquetEntryWriter(ds.Structure, w)
+	if err != nil {
+		return err
+	}
+	rr, err := dsio.NewEntryReader(ds.Structure, file)
+	if err != nil {
+		return fmt.Errorf("error allocating data reader: %s", err)
+	}
+	if err = dsio.Copy(rr, pw); err != nil {
+		return err
+	}
+	return pw.Close()
+}
+
+// ParquetBodyFile converts a parquet body to the format of st, or json if st
+// is nil. The returned structure has the target format & a schema read from
+// the parquet columns. Parquet needs random access to read, so the body is
+// spooled to a temp file, then converted one row group at a time as the
+// returned file is read
+func ParquetBodyFile(file qfs.File, st *dataset.Structure) (qfs.File, *dataset.Structure, error) {
+	tmp, err := ioutil.TempFile("", "qri_parquet_body")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	size, err := io.Copy(tmp, file)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	pr, err := parquet.NewReader(tmp, size)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("reading %s body: %s", ParquetFormat, err)
+	}
+
+	items := make([]interface{}, 0, len(pr.Columns()))
+	for _, c := range pr.Columns() {
+		items = append(items, map[string]interface{}{"title": c.Name, "type": c.Type})
+	}
+	out := &dataset.Structure{
+		Format: dataset.JSONDataFormat.String(),
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":  "array",
+				"items": items,
+			},
+		},
+	}
+	if st != nil && st.Format != "" && st.Format != ParquetFormat {
+		out.Format = st.Format
+		out.FormatConfig = st.FormatConfig
+		if out.DataFormat() == dataset.CSVDataFormat && out.FormatConfig == nil {
+			out.FormatConfig = map[string]interface{}{"headerRow": true}
+		}
+	}
+
+	if out.DataFormat() == dataset.UnknownDataFormat {
+		cleanup()
+		return nil, nil, fmt.Errorf("can't convert a %s body to %q", ParquetFormat, out.Format)
+	}
+
+	// writers may write as they're created, which blocks until the pipe is read
+	r, w := io.Pipe()
+	go func() {
+		defer cleanup()
+		// a panic here would take down the process, bodies come from users
+		defer func() {
+			if p := recover(); p != nil {
+				w.CloseWithError(fmt.Errorf("reading %s body: %v", ParquetFormat, p))
+			}
+		}()
+		ew, err := dsio.NewEntryWriter(out, w)
+		if err != nil {
+			w.CloseWithError(err)
+			return
+		}
+		index := 0
+		for i := 0; i < pr.NumRowGroups(); i++ {
+			rows, err := pr.ReadRowGroup(i)
+			if err != nil {
+				w.CloseWithError(err)
+				return
+			}
+			for _, row := range rows {
+				if err := ew.WriteEntry(dsio.Entry{Index: index, Value: row}); err != nil {
+					w.CloseWithError(err)
+					return
+				}
+				index++
+			}
+		}
+		if err := ew.Close(); err != nil {
+			w.CloseWithError(err)
+			return
+		}
+		w.Close()
+	}()
+
+	name := strings.TrimSuffix(filepath.Base(file.FileName()), filepath.Ext(file.FileName()))
+	return qfs.NewMemfileReader(fmt.Sprintf("%s.%s", name, out.Format), r), out, nil
+}
@@ -0,0 +1,75 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+func TestParquetBody(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	ref := addCitiesDataset(t, r)
+
+	ds, err := ReadDatasetPath(ctx, r, ref.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = OpenDataset(ctx, r.Filesystem(), ds); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ReadParquetBody(ds, []string{"city", "pop"}, 2, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, st, err := ParquetBodyFile(qfs.NewMemfileBytes("cities.parquet", data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.FileName() != "cities.json" || st.Format != "json" {
+		t.Errorf("expected parquet to convert to json, got filename: %q format: %q", f.FileName(), st.Format)
+	}
+	cols := Columns(st.Schema)
+	if len(cols) != 2 || cols[0].Name != "city" || cols[1].Schema["type"] != "integer" {
+		t.Errorf("expected schema of projected columns, got: %v", st.Schema)
+	}
+	got, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := `[["new york",8500000],["chicago",300000]]`; string(got) != expect {
+		t.Errorf("body mismatch. expected: %s, got: %s", expect, string(got))
+	}
+
+	if ds, err = ReadDatasetPath(ctx, r, ref.String()); err != nil {
+		t.Fatal(err)
+	}
+	if err = OpenDataset(ctx, r.Filesystem(), ds); err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err = WriteParquetBody(ds, buf); err != nil {
+		t.Fatal(err)
+	}
+	f, st, err = ParquetBodyFile(qfs.NewMemfileBytes("cities.parquet", buf.Bytes()), &dataset.Structure{Format: "csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "city,pop,avg_age,in_usa\ntoronto,40000000,55.5,false\nnew york,8500000,44.4,true\nchicago,300000,44.4,true\nchatham,35000,65.25,true\nraleigh,250000,50.65,true\n"
+	if string(got) != expect || st.Format != "csv" {
+		t.Errorf("csv body mismatch. expected: %q, got: %q", expect, string(got))
+	}
+
+	if _, _, err := ParquetBodyFile(qfs.NewMemfileBytes("cities.parquet", []byte("city,pop\n")), nil); err == nil {
+		t.Error("expected reading a file that isn't parquet to error")
+	}
+}
@@ -0,0 +1,487 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
+)
+
+// patchComponents lists the components a patch can change, in the order
+// changes are written
+var patchComponents = []string{"meta", "structure", "readme", "transform", "viz", "body"}
+
+// Patch is a portable description of the changes between two versions of a
+// dataset. A patch records both the original & changed value of everything
+// it touches, so it can be checked against, and applied to, any other dataset
+type Patch struct {
+	// From & To are the paths of the versions the patch was created from
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	// Changes lists each change, ordered by component
+	Changes []*PatchChange `json:"changes"`
+}
+
+// PatchChange is a single change to a dataset component. Changes to objects
+// are broken down by top-level field, and body changes by entry when rows are
+// edited in place. An empty Key replaces the whole component. A nil From
+// adds a value, a nil To removes it
+type PatchChange struct {
+	Component string      `json:"component"`
+	Key       string      `json:"key,omitempty"`
+	From      interface{} `json:"from,omitempty"`
+	To        interface{} `json:"to,omitempty"`
+}
+
+// Address returns a dotted address for the value a change modifies
+func (c *PatchChange) Address() string {
+	if c.Key == "" {
+		return c.Component
+	}
+	return fmt.Sprintf("%s.%s", c.Component, c.Key)
+}
+
+// PatchConflict is a change that couldn't be applied because the target
+// dataset holds neither the original nor the changed value
+type PatchConflict struct {
+	Change  *PatchChange `json:"change"`
+	Current interface{}  `json:"current,omitempty"`
+}
+
+// ErrPatchConflict is returned when a patch doesn't apply cleanly
+type ErrPatchConflict struct {
+	Conflicts []*PatchConflict
+}
+
+// Error implements the error interface
+func (e *ErrPatchConflict) Error() string {
+	addrs := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		addrs[i] = c.Change.Address()
+	}
+	return fmt.Sprintf("patch conflicts with the target dataset at: %s", strings.Join(addrs, ", "))
+}
+
+// DiffPatch creates a patch describing the changes from dataset a to dataset
+// b. Derived values & commits aren't included in the patch
+func DiffPatch(ctx context.Context, store cafs.Filestore, a, b *dataset.Dataset) (*Patch, error) {
+	ac, err := patchValues(ctx, store, a)
+	if err != nil {
+		return nil, err
+	}
+	bc, err := patchValues(ctx, store, b)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Patch{From: a.Path, To: b.Path, Changes: []*PatchChange{}}
+	for _, name := range patchComponents {
+		p.Changes = append(p.Changes, componentChanges(name, ac[name], bc[name])...)
+	}
+	return p, nil
+}
+
+// componentChanges breaks the difference between two component values into
+// a list of changes
+func componentChanges(name string, a, b interface{}) []*PatchChange {
+	if reflect.DeepEqual(a, b) {
+		return nil
+	}
+
+	var changes []*PatchChange
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := map[string]bool{}
+		for k := range av {
+			keys[k] = true
+		}
+		for k := range bv {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		for _, k := range sorted {
+			if !reflect.DeepEqual(av[k], bv[k]) {
+				changes = append(changes, &PatchChange{Component: name, Key: k, From: av[k], To: bv[k]})
+			}
+		}
+		return changes
+	case []interface{}:
+		// only edit entries in place when no rows have been added or removed,
+		// otherwise indexes don't line up & the whole body is replaced
+		bv, ok := b.([]interface{})
+		if !ok || name != "body" || len(av) != len(bv) {
+			break
+		}
+		for i := range av {
+			if !reflect.DeepEqual(av[i], bv[i]) {
+				changes = append(changes, &PatchChange{Component: name, Key: strconv.Itoa(i), From: av[i], To: bv[i]})
+			}
+		}
+		return changes
+	}
+
+	return []*PatchChange{{Component: name, From: a, To: b}}
+}
+
+// ApplyPatch checks a patch against a dataset, returning a new dataset with
+// the patch applied that's ready to be saved as the next version of ds.
+// Changes that have already been made to ds are skipped. If ds has diverged
+// from the values a patch expects, ApplyPatch returns an *ErrPatchConflict
+// listing each conflict & makes no changes. As with any save, the returned
+// dataset only has a transform if the patch changes it
+func ApplyPatch(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset, patch *Patch) (*dataset.Dataset, error) {
+	values, err := patchValues(ctx, store, ds)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := []*PatchConflict{}
+	changed := map[string]bool{}
+	for _, c := range patch.Changes {
+		cur, err := patchValue(values, c)
+		if err != nil {
+			return nil, err
+		}
+		if reflect.DeepEqual(cur, c.To) {
+			continue
+		}
+		if !reflect.DeepEqual(cur, c.From) {
+			conflicts = append(conflicts, &PatchConflict{Change: c, Current: cur})
+			continue
+		}
+		if err := setPatchValue(values, c); err != nil {
+			return nil, err
+		}
+		changed[c.Component] = true
+	}
+	if len(conflicts) > 0 {
+		return nil, &ErrPatchConflict{Conflicts: conflicts}
+	}
+	if len(changed) == 0 {
+		return nil, fmt.Errorf("%w to apply, the patch has already been applied", dsfs.ErrNoChanges)
+	}
+
+	res := &dataset.Dataset{
+		Peername: ds.Peername,
+		Name:     ds.Name,
+		BodyPath: ds.BodyPath,
+	}
+	for _, name := range patchComponents[:5] {
+		if name == "transform" && !changed[name] {
+			continue
+		}
+		if err := assignComponent(res, name, values[name]); err != nil {
+			return nil, err
+		}
+	}
+	if res.Structure == nil {
+		return nil, fmt.Errorf("patched dataset has no structure")
+	}
+
+	if changed["body"] {
+		data, err := encodeBody(res.Structure, values["body"])
+		if err != nil {
+			return nil, err
+		}
+		res.BodyPath = ""
+		res.SetBodyFile(qfs.NewMemfileBytes(fmt.Sprintf("body.%s", res.Structure.Format), data))
+	} else if ds.BodyPath != "" {
+		body, err := dsfs.LoadBody(ctx, store, ds)
+		if err != nil {
+			return nil, err
+		}
+		res.SetBodyFile(body)
+	}
+
+	return res, nil
+}
+
+// patchValue gets the current value a change applies to
+func patchValue(values map[string]interface{}, c *PatchChange) (interface{}, error) {
+	v := values[c.Component]
+	if c.Key == "" {
+		return v, nil
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return t[c.Key], nil
+	case []interface{}:
+		i, err := strconv.Atoi(c.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entry index for %s: %s", c.Address(), err)
+		}
+		if i < 0 || i >= len(t) {
+			return nil, nil
+		}
+		return t[i], nil
+	case nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("can't address %s, %s isn't an object or array", c.Address(), c.Component)
+}
+
+// setPatchValue assigns the changed value of a change
+func setPatchValue(values map[string]interface{}, c *PatchChange) error {
+	if c.Key == "" {
+		values[c.Component] = c.To
+		return nil
+	}
+
+	switch t := values[c.Component].(type) {
+	case map[string]interface{}:
+		if c.To == nil {
+			delete(t, c.Key)
+		} else {
+			t[c.Key] = c.To
+		}
+	case []interface{}:
+		i, err := strconv.Atoi(c.Key)
+		if err != nil || i < 0 || i >= len(t) {
+			return fmt.Errorf("invalid entry index for %s", c.Address())
+		}
+		t[i] = c.To
+	case nil:
+		if c.To != nil {
+			values[c.Component] = map[string]interface{}{c.Key: c.To}
+		}
+	}
+	return nil
+}
+
+// patchValues converts the components of a dataset into generic values,
+// dropping derived values & inlining scripts so patches don't depend on the
+// store they were created from
+func patchValues(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset) (map[string]interface{}, error) {
+	var (
+		comps = map[string]interface{}{}
+		err   error
+	)
+
+	if ds.Meta != nil {
+		md := *ds.Meta
+		md.DropDerivedValues()
+		if comps["meta"], err = toPatchValue(&md, nil); err != nil {
+			return nil, err
+		}
+	}
+	if ds.Structure != nil {
+		st := *ds.Structure
+		st.DropDerivedValues()
+		if comps["structure"], err = toPatchValue(&st, nil); err != nil {
+			return nil, err
+		}
+	}
+	if ds.Readme != nil {
+		rm := *ds.Readme
+		rm.DropDerivedValues()
+		rm.RenderedPath = ""
+		script, err := readScript(ctx, store, rm.ScriptBytes, rm.ScriptPath)
+		if err != nil {
+			return nil, err
+		}
+		rm.ScriptBytes, rm.ScriptPath = nil, ""
+		if comps["readme"], err = toPatchValue(&rm, script); err != nil {
+			return nil, err
+		}
+	}
+	if ds.Transform != nil {
+		tf := *ds.Transform
+		tf.DropDerivedValues()
+		script, err := readScript(ctx, store, tf.ScriptBytes, tf.ScriptPath)
+		if err != nil {
+			return nil, err
+		}
+		tf.ScriptBytes, tf.ScriptPath = nil, ""
+		if comps["transform"], err = toPatchValue(&tf, script); err != nil {
+			return nil, err
+		}
+	}
+	if ds.Viz != nil {
+		vz := *ds.Viz
+		vz.DropDerivedValues()
+		vz.RenderedPath = ""
+		script, err := readScript(ctx, store, vz.ScriptBytes, vz.ScriptPath)
+		if err != nil {
+			return nil, err
+		}
+		vz.ScriptBytes, vz.ScriptPath = nil, ""
+		if comps["viz"], err = toPatchValue(&vz, script); err != nil {
+			return nil, err
+		}
+	}
+
+	if ds.BodyPath != "" && ds.Structure != nil {
+		f, err := dsfs.LoadBody(ctx, store, ds)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		rdr, err := dsio.NewEntryReader(ds.Structure, f)
+		if err != nil {
+			return nil, err
+		}
+		body, err := ReadEntries(rdr)
+		if err != nil {
+			return nil, err
+		}
+		if comps["body"], err = normalizePatchValue(body); err != nil {
+			return nil, err
+		}
+	}
+
+	return comps, nil
+}
+
+// scripts are stored as a plain string field so patches stay readable
+const patchScriptKey = "script"
+
+// toPatchValue converts a component to a generic value, adding script
+// contents if provided
+func toPatchValue(comp interface{}, script []byte) (interface{}, error) {
+	v, err := normalizePatchValue(comp)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := v.(map[string]interface{}); ok && script != nil {
+		m[patchScriptKey] = string(script)
+	}
+	return v, nil
+}
+
+// normalizePatchValue round-trips a value through JSON so values compare the
+// same no matter where they came from
+func normalizePatchValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var res interface{}
+	err = json.Unmarshal(data, &res)
+	return res, err
+}
+
+// assignComponent sets a dataset component from a generic value
+func assignComponent(ds *dataset.Dataset, name string, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+
+	var script []byte
+	if m, ok := v.(map[string]interface{}); ok {
+		if s, ok := m[patchScriptKey].(string); ok {
+			script = []byte(s)
+			cp := map[string]interface{}{}
+			for k, val := range m {
+				if k != patchScriptKey {
+					cp[k] = val
+				}
+			}
+			v = cp
+		}
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case "meta":
+		ds.Meta = &dataset.Meta{}
+		err = json.Unmarshal(data, ds.Meta)
+	case "structure":
+		ds.Structure = &dataset.Structure{}
+		err = json.Unmarshal(data, ds.Structure)
+	case "readme":
+		ds.Readme = &dataset.Readme{}
+		if err = json.Unmarshal(data, ds.Readme); err == nil {
+			ds.Readme.ScriptBytes = script
+		}
+	case "transform":
+		ds.Transform = &dataset.Transform{}
+		if err = json.Unmarshal(data, ds.Transform); err == nil {
+			ds.Transform.ScriptBytes = script
+		}
+	case "viz":
+		ds.Viz = &dataset.Viz{}
+		if err = json.Unmarshal(data, ds.Viz); err == nil {
+			ds.Viz.ScriptBytes = script
+		}
+	default:
+		return fmt.Errorf("unknown component %q", name)
+	}
+	if err != nil {
+		return fmt.Errorf("patched %s is invalid: %s", name, err)
+	}
+	return nil
+}
+
+// readScript returns script bytes, reading them from the store if they
+// haven't been inlined
+func readScript(ctx context.Context, store cafs.Filestore, data []byte, path string) ([]byte, error) {
+	if data != nil || path == "" {
+		return data, nil
+	}
+	f, err := store.Get(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("loading script %s: %s", path, err)
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+// encodeBody writes generic body data in the format of a structure
+func encodeBody(st *dataset.Structure, body interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w, err := dsio.NewEntryWriter(st, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := body.(type) {
+	case []interface{}:
+		for i, v := range t {
+			if err := w.WriteEntry(dsio.Entry{Index: i, Value: v}); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := w.WriteEntry(dsio.Entry{Key: k, Value: t[k]}); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("patched body must be an array or object")
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
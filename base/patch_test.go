@@ -0,0 +1,112 @@
+package base
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestDiffApplyPatch(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	save := func(name, title, body string) *dataset.Dataset {
+		ds := &dataset.Dataset{
+			Peername:  "me",
+			Name:      name,
+			Meta:      &dataset.Meta{Title: title},
+			Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(body)))
+		ref, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded.Peername = ref.Peername
+		loaded.Name = ref.Name
+		return loaded
+	}
+
+	a := save("cherry", "cherry", `[["a",1],["b",2],["c",3]]`)
+	b := save("cherry", "cherry picked", `[["a",1],["b",20],["c",3]]`)
+
+	patch, err := DiffPatch(ctx, r.Store(), a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []*PatchChange{
+		{Component: "meta", Key: "title", From: "cherry", To: "cherry picked"},
+		{Component: "body", Key: "1", From: []interface{}{"b", float64(2)}, To: []interface{}{"b", float64(20)}},
+	}
+	if diff := cmp.Diff(expect, patch.Changes); diff != "" {
+		t.Errorf("patch changes mismatch (-want +got):\n%s", diff)
+	}
+	if patch.From != a.Path || patch.To != b.Path {
+		t.Errorf("expected patch to record version paths, got from: %q to: %q", patch.From, patch.To)
+	}
+
+	// apply to a dataset that shares the original values, but has other rows
+	target := save("orchard", "cherry", `[["a",1],["b",2],["c",3],["d",4]]`)
+	patched, err := ApplyPatch(ctx, r.Store(), target, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patched.Commit = &dataset.Commit{Title: "apply patch"}
+	ref, _, err := SaveDataset(ctx, r, devNull, patched, nil, nil, SaveDatasetSwitches{Replace: true, Pin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Dataset.Meta.Title != "cherry picked" {
+		t.Errorf("expected patched title, got: %q", ref.Dataset.Meta.Title)
+	}
+	body, err := ReadBody(ref.Dataset, dataset.JSONDataFormat, nil, nil, 0, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(`[["a",1],["b",20],["c",3],["d",4]]`, string(body)); diff != "" {
+		t.Errorf("patched body mismatch (-want +got):\n%s", diff)
+	}
+
+	// applying again is a no-op
+	saved, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ApplyPatch(ctx, r.Store(), saved, patch); err == nil {
+		t.Error("expected reapplying a patch to error")
+	}
+
+	// a diverged target reports conflicts
+	diverged := save("diverged", "something else", `[["a",1],["b",5],["c",3]]`)
+	_, err = ApplyPatch(ctx, r.Store(), diverged, patch)
+	conflict, ok := err.(*ErrPatchConflict)
+	if !ok {
+		t.Fatalf("expected *ErrPatchConflict, got: %v", err)
+	}
+	addrs := []string{}
+	for _, c := range conflict.Conflicts {
+		addrs = append(addrs, c.Change.Address())
+	}
+	if diff := cmp.Diff([]string{"meta.title", "body.1"}, addrs); diff != "" {
+		t.Errorf("conflicts mismatch (-want +got):\n%s", diff)
+	}
+
+	// the diverged dataset is untouched
+	ref = reporef.DatasetRef{Peername: "me", Name: "diverged"}
+	if err := repo.CanonicalizeDatasetRef(r, &ref); err != nil {
+		t.Fatal(err)
+	}
+	if ref.Path != diverged.Path {
+		t.Errorf("expected conflicted dataset to be unchanged")
+	}
+}
@@ -0,0 +1,215 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// PinStore lists & measures pinned versions in a store
+type PinStore struct {
+	// Pinned lists every path pinned in the store
+	Pinned func(ctx context.Context) (map[string]bool, error)
+	// BlockSizes maps each block of a version to its size in bytes
+	BlockSizes func(ctx context.Context, path string) (map[string]uint64, error)
+}
+
+// VersionPin describes whether a version of a dataset is pinned & how much
+// space it takes up
+type VersionPin struct {
+	Path       string    `json:"path"`
+	CommitTime time.Time `json:"commitTime"`
+	Pinned     bool      `json:"pinned"`
+	// Protected versions can't be unpinned. The head version is always
+	// protected, as are versions checked out to a working directory
+	Protected bool `json:"protected,omitempty"`
+	// Size is the total size of the version's blocks in bytes
+	Size uint64 `json:"size"`
+	// Reclaimable estimates the bytes unpinning this version would free: the
+	// size of blocks no other pinned version of the dataset shares. Blocks
+	// shared with other datasets are counted, so this can overestimate
+	Reclaimable uint64 `json:"reclaimable"`
+}
+
+// PinPoliciesOf returns the pin policies of a repo, or nil if the repo doesn't
+// keep any
+func PinPoliciesOf(r repo.Repo) *repo.PinPolicies {
+	if p, ok := r.(repo.PinPolicier); ok {
+		return p.PinPolicies()
+	}
+	return nil
+}
+
+// ListVersionPins lists the versions of a dataset, newest first, with the pin
+// status & size of each. protected lists paths that must stay pinned besides
+// the head
+func ListVersionPins(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, ps PinStore, protected []string) ([]VersionPin, error) {
+	pins, blocks, err := versionPins(ctx, r, ref, ps, protected)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range pins {
+		if p.Pinned {
+			pins[i].Reclaimable = reclaimable(pins, blocks, map[string]bool{p.Path: true})
+		}
+	}
+	return pins, nil
+}
+
+// UnpinVersions unpins versions of a dataset, returning an estimate of the
+// bytes freed. Paths must be versions of the dataset that aren't protected,
+// nothing is unpinned if any path can't be. Versions that aren't pinned are
+// skipped
+func UnpinVersions(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, paths []string, ps PinStore, protected []string) (reclaimed uint64, err error) {
+	pins, blocks, err := versionPins(ctx, r, ref, ps, protected)
+	if err != nil {
+		return 0, err
+	}
+
+	unpin := map[string]bool{}
+	for _, path := range paths {
+		p, ok := findVersionPin(pins, path)
+		if !ok {
+			return 0, fmt.Errorf("%s isn't a version of %s", path, ref.AliasString())
+		}
+		if p.Protected {
+			return 0, fmt.Errorf("version %s can't be unpinned, it's the head version or checked out to a working directory", path)
+		}
+		if p.Pinned {
+			unpin[path] = true
+		}
+	}
+	return unpinVersions(ctx, r, pins, blocks, unpin)
+}
+
+// EnforcePinPolicy unpins all but the newest keep versions of a dataset,
+// returning the unpinned paths & an estimate of the bytes freed. Protected
+// versions are never unpinned. A keep of less than one unpins nothing
+func EnforcePinPolicy(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, keep int, ps PinStore, protected []string) (unpinned []string, reclaimed uint64, err error) {
+	if keep < 1 {
+		return nil, 0, nil
+	}
+	pins, blocks, err := versionPins(ctx, r, ref, ps, protected)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	unpin := map[string]bool{}
+	for i, p := range pins {
+		if i >= keep && p.Pinned && !p.Protected {
+			unpin[p.Path] = true
+			unpinned = append(unpinned, p.Path)
+		}
+	}
+	if reclaimed, err = unpinVersions(ctx, r, pins, blocks, unpin); err != nil {
+		return nil, 0, err
+	}
+	return unpinned, reclaimed, nil
+}
+
+// versionPins lists versions of a dataset with their pin status & size, plus
+// the blocks of each version keyed by path
+func versionPins(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, ps PinStore, protected []string) ([]VersionPin, map[string]map[string]uint64, error) {
+	if ps.Pinned == nil || ps.BlockSizes == nil {
+		return nil, nil, repo.ErrNotPinner
+	}
+	if err := repo.CanonicalizeDatasetRef(r, &ref); err != nil {
+		return nil, nil, err
+	}
+	versions, err := DatasetLog(ctx, r, ref, -1, 0, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	pinned, err := ps.Pinned(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pins := make([]VersionPin, 0, len(versions))
+	blocks := map[string]map[string]uint64{}
+	for _, v := range versions {
+		if v.Path == "" {
+			continue
+		}
+		p := VersionPin{
+			Path:       v.Path,
+			CommitTime: v.CommitTime,
+			Pinned:     pinned[v.Path],
+			Protected:  v.Path == ref.Path || containsPath(protected, v.Path),
+		}
+		// blocks of unpinned versions may already be garbage collected
+		if sizes, err := ps.BlockSizes(ctx, v.Path); err == nil {
+			blocks[v.Path] = sizes
+			for _, s := range sizes {
+				p.Size += s
+			}
+		} else if p.Pinned {
+			return nil, nil, fmt.Errorf("measuring version %s: %s", v.Path, err)
+		}
+		pins = append(pins, p)
+	}
+	return pins, blocks, nil
+}
+
+// unpinVersions unpins a set of versions, returning the bytes they free
+func unpinVersions(ctx context.Context, r repo.Repo, pins []VersionPin, blocks map[string]map[string]uint64, unpin map[string]bool) (uint64, error) {
+	reclaimed := reclaimable(pins, blocks, unpin)
+	for _, p := range pins {
+		if !unpin[p.Path] {
+			continue
+		}
+		if err := UnpinDataset(ctx, r, reporef.DatasetRef{Path: p.Path}); err != nil {
+			return 0, fmt.Errorf("unpinning %s: %s", p.Path, err)
+		}
+	}
+	accountBytes(r, -int64(reclaimed))
+	return reclaimed, nil
+}
+
+// reclaimable sums the size of blocks held by a set of pinned versions that
+// no pinned version outside the set holds
+func reclaimable(pins []VersionPin, blocks map[string]map[string]uint64, set map[string]bool) uint64 {
+	kept := map[string]bool{}
+	for _, p := range pins {
+		if p.Pinned && !set[p.Path] {
+			for id := range blocks[p.Path] {
+				kept[id] = true
+			}
+		}
+	}
+
+	freed := map[string]uint64{}
+	for path := range set {
+		for id, size := range blocks[path] {
+			if !kept[id] {
+				freed[id] = size
+			}
+		}
+	}
+	var total uint64
+	for _, size := range freed {
+		total += size
+	}
+	return total
+}
+
+func findVersionPin(pins []VersionPin, path string) (VersionPin, bool) {
+	for _, p := range pins {
+		if p.Path == path {
+			return p, true
+		}
+	}
+	return VersionPin{}, false
+}
+
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
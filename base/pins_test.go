@@ -0,0 +1,70 @@
+package base
+
+import (
+	"context"
+	"testing"
+
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// fakePinStore pins every version in pinned. Versions share a 100 byte block
+// & each has a 10 byte block of its own
+func fakePinStore(pinned ...string) PinStore {
+	return PinStore{
+		Pinned: func(ctx context.Context) (map[string]bool, error) {
+			m := map[string]bool{}
+			for _, p := range pinned {
+				m[p] = true
+			}
+			return m, nil
+		},
+		BlockSizes: func(ctx context.Context, path string) (map[string]uint64, error) {
+			return map[string]uint64{"shared": 100, path: 10}, nil
+		},
+	}
+}
+
+func TestVersionPins(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	v1 := addCitiesDataset(t, r)
+	v2 := updateCitiesDataset(t, r, "second")
+	v3 := updateCitiesDataset(t, r, "third")
+	ref := reporef.DatasetRef{Peername: v3.Peername, Name: v3.Name}
+	ps := fakePinStore(v1.Path, v2.Path, v3.Path)
+
+	pins, err := ListVersionPins(ctx, r, ref, ps, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pins) != 3 {
+		t.Fatalf("expected 3 versions, got: %d", len(pins))
+	}
+	if pins[0].Path != v3.Path || !pins[0].Protected {
+		t.Errorf("expected head version first & protected, got: %+v", pins[0])
+	}
+	if pins[1].Size != 110 || pins[1].Reclaimable != 10 {
+		t.Errorf("expected size 110 & 10 reclaimable bytes, got: %d & %d", pins[1].Size, pins[1].Reclaimable)
+	}
+
+	if _, err := UnpinVersions(ctx, r, ref, []string{v3.Path}, ps, nil); err == nil {
+		t.Error("expected unpinning the head version to error")
+	}
+	if _, err := UnpinVersions(ctx, r, ref, []string{v1.Path}, ps, []string{v1.Path}); err == nil {
+		t.Error("expected unpinning a protected version to error")
+	}
+	if _, err := UnpinVersions(ctx, r, ref, []string{"/map/QmNotAVersion"}, ps, nil); err == nil {
+		t.Error("expected unpinning a path that isn't a version to error")
+	}
+
+	unpinned, reclaimed, err := EnforcePinPolicy(ctx, r, ref, 1, ps, []string{v2.Path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unpinned) != 1 || unpinned[0] != v1.Path {
+		t.Errorf("expected only %s to be unpinned, got: %v", v1.Path, unpinned)
+	}
+	if reclaimed != 10 {
+		t.Errorf("expected 10 reclaimed bytes, got: %d", reclaimed)
+	}
+}
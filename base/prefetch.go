@@ -0,0 +1,150 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// ErrNoPrefetches indicates a repo doesn't keep a record of prefetched
+// versions
+var ErrNoPrefetches = fmt.Errorf("repo doesn't keep a record of prefetched versions")
+
+// PrefetchesOf returns the prefetch record of a repo, or nil if the repo
+// doesn't keep one
+func PrefetchesOf(r repo.Repo) *repo.Prefetches {
+	if p, ok := r.(repo.Prefetcher); ok {
+		return p.Prefetches()
+	}
+	return nil
+}
+
+// PinPrefetched pins a version pulled from a remote without adding its
+// dataset to the repo, recording the version under the dataset's peername &
+// name so it shows up in pin management. The version's body is added to the
+// repo's disk usage the first time it's recorded
+func PinPrefetched(ctx context.Context, r repo.Repo, ref reporef.DatasetRef) error {
+	prefetches := PrefetchesOf(r)
+	if prefetches == nil {
+		return ErrNoPrefetches
+	}
+	if ref.Peername == "" || ref.Name == "" || ref.Path == "" {
+		return fmt.Errorf("prefetched versions need a peername, name & path")
+	}
+
+	e := repo.PrefetchEntry{Ref: reporef.DatasetRef{Peername: ref.Peername, Name: ref.Name, ProfileID: ref.ProfileID, Path: ref.Path}, Fetched: time.Now()}
+	if _, err := prefetches.Get(ref.Path); err == repo.ErrNotFound {
+		if err := PinDataset(ctx, r, ref); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	return prefetches.Put(e)
+}
+
+// ListPrefetchPins lists the prefetched versions of a dataset, most recently
+// fetched first, with the pin status & size of each
+func ListPrefetchPins(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, ps PinStore) ([]VersionPin, error) {
+	pins, blocks, err := prefetchPins(ctx, r, ref, ps, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range pins {
+		if p.Pinned {
+			pins[i].Reclaimable = reclaimable(pins, blocks, map[string]bool{p.Path: true})
+		}
+	}
+	return pins, nil
+}
+
+// UnpinPrefetched unpins prefetched versions of a dataset & drops them from
+// the prefetch record, returning an estimate of the bytes freed. Nothing is
+// unpinned if any path isn't a prefetched version of the dataset or is
+// protected
+func UnpinPrefetched(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, paths []string, ps PinStore, protected []string) (reclaimed uint64, err error) {
+	pins, blocks, err := prefetchPins(ctx, r, ref, ps, protected)
+	if err != nil {
+		return 0, err
+	}
+
+	unpin := map[string]bool{}
+	for _, path := range paths {
+		p, ok := findVersionPin(pins, path)
+		if !ok {
+			return 0, fmt.Errorf("%s isn't a prefetched version of %s", path, ref.AliasString())
+		}
+		if p.Protected {
+			return 0, fmt.Errorf("version %s can't be unpinned, it's the head version or checked out to a working directory", path)
+		}
+		if p.Pinned {
+			unpin[path] = true
+		}
+	}
+	if reclaimed, err = unpinVersions(ctx, r, pins, blocks, unpin); err != nil {
+		return 0, err
+	}
+
+	prefetches := PrefetchesOf(r)
+	for _, path := range paths {
+		if err := prefetches.Delete(path); err != nil && err != repo.ErrNotFound {
+			return reclaimed, err
+		}
+	}
+	return reclaimed, nil
+}
+
+// IsPrefetched reports whether path is a prefetched version of a dataset
+func IsPrefetched(r repo.Repo, ref reporef.DatasetRef, path string) bool {
+	prefetches := PrefetchesOf(r)
+	if prefetches == nil {
+		return false
+	}
+	e, err := prefetches.Get(path)
+	return err == nil && e.Ref.Peername == ref.Peername && e.Ref.Name == ref.Name
+}
+
+// prefetchPins lists the prefetched versions of a dataset with their pin
+// status & size, plus the blocks of each version keyed by path
+func prefetchPins(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, ps PinStore, protected []string) ([]VersionPin, map[string]map[string]uint64, error) {
+	if ps.Pinned == nil || ps.BlockSizes == nil {
+		return nil, nil, repo.ErrNotPinner
+	}
+	prefetches := PrefetchesOf(r)
+	if prefetches == nil {
+		return nil, nil, ErrNoPrefetches
+	}
+	pinned, err := ps.Pinned(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries := prefetches.List(ref)
+	pins := make([]VersionPin, 0, len(entries))
+	blocks := map[string]map[string]uint64{}
+	for _, e := range entries {
+		p := VersionPin{
+			Path:       e.Ref.Path,
+			CommitTime: e.Fetched,
+			Pinned:     pinned[e.Ref.Path],
+			Protected:  containsPath(protected, e.Ref.Path),
+		}
+		if ds, err := dsfs.LoadDataset(ctx, r.Store(), e.Ref.Path); err == nil && ds.Commit != nil {
+			p.CommitTime = ds.Commit.Timestamp
+		}
+		if sizes, err := ps.BlockSizes(ctx, e.Ref.Path); err == nil {
+			blocks[e.Ref.Path] = sizes
+			for _, s := range sizes {
+				p.Size += s
+			}
+		} else if p.Pinned {
+			return nil, nil, fmt.Errorf("measuring version %s: %s", e.Ref.Path, err)
+		}
+		pins = append(pins, p)
+	}
+	return pins, blocks, nil
+}
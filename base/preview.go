@@ -27,17 +27,26 @@ func CreatePreview(ctx context.Context, r repo.Repo, ref dsref.Ref) (ds *dataset
 		return nil, err
 	}
 
-	if err = ds.OpenBodyFile(ctx, r.Store()); err != nil {
+	body, err := dsfs.LoadBody(ctx, r.Store(), ds)
+	if err != nil {
 		log.Errorf("CreatePreview opening body file: %s", err.Error())
 		return nil, err
 	}
+	ds.SetBodyFile(body)
+
+	if ds.Readme != nil && ds.Readme.ScriptPath != "" {
+		if err = ds.Readme.InlineScriptFile(ctx, r.Filesystem()); err != nil {
+			log.Errorf("CreatePreview inlining readme: %s", err.Error())
+			return nil, err
+		}
+	}
 
 	st := &dataset.Structure{
 		Format: "json",
 		Schema: ds.Structure.Schema,
 	}
 
-	data, err := ConvertBodyFile(ds.BodyFile(), ds.Structure, st, MaxNumDatasetRowsInPreview, 0, false)
+	data, err := ConvertBodyFile(ds.BodyFile(), ds.Structure, st, nil, MaxNumDatasetRowsInPreview, 0, false)
 	if err != nil {
 		log.Errorf("CreatePreview converting body file: %s", err.Error())
 		return nil, err
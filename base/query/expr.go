@@ -0,0 +1,625 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// expr is a node of a parsed expression
+type expr interface {
+	String() string
+	eval(e *env) (interface{}, error)
+}
+
+// env holds the values expressions are evaluated against: a row of all
+// columns in scope, and the results of aggregates when rows are grouped
+type env struct {
+	row  []interface{}
+	aggs []interface{}
+}
+
+type literal struct {
+	val interface{}
+}
+
+func (l *literal) String() string {
+	switch v := l.val.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.Replace(v, "'", "''", -1) + "'"
+	}
+	return fmt.Sprintf("%v", l.val)
+}
+
+func (l *literal) eval(*env) (interface{}, error) { return l.val, nil }
+
+// column references a column by name, resolved to a position in the row
+type column struct {
+	table string
+	name  string
+	idx   int
+}
+
+func (c *column) String() string {
+	if c.table != "" {
+		return c.table + "." + c.name
+	}
+	return c.name
+}
+
+func (c *column) eval(e *env) (interface{}, error) {
+	if c.idx < 0 || c.idx >= len(e.row) {
+		return nil, fmt.Errorf("column %q isn't available here", c.String())
+	}
+	return e.row[c.idx], nil
+}
+
+type unary struct {
+	op string
+	x  expr
+}
+
+func (u *unary) String() string {
+	if u.op == "NOT" {
+		return "NOT " + u.x.String()
+	}
+	return u.op + u.x.String()
+}
+
+func (u *unary) eval(e *env) (interface{}, error) {
+	v, err := u.x.eval(e)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	if u.op == "NOT" {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("NOT needs a boolean, got %s", describe(v))
+		}
+		return !b, nil
+	}
+	switch n := v.(type) {
+	case int64:
+		return -n, nil
+	case float64:
+		return -n, nil
+	}
+	return nil, fmt.Errorf("can't negate %s", describe(v))
+}
+
+type binary struct {
+	op   string
+	l, r expr
+}
+
+func (b *binary) String() string {
+	return b.l.String() + " " + b.op + " " + b.r.String()
+}
+
+func (b *binary) eval(e *env) (interface{}, error) {
+	l, err := b.l.eval(e)
+	if err != nil {
+		return nil, err
+	}
+
+	// AND & OR follow three-valued logic, short-circuiting where the result
+	// is known
+	switch b.op {
+	case "AND", "OR":
+		lb, lok := l.(bool)
+		if l != nil && !lok {
+			return nil, fmt.Errorf("%s needs booleans, got %s", b.op, describe(l))
+		}
+		if lok && lb == (b.op == "OR") {
+			return lb, nil
+		}
+		r, err := b.r.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		rb, rok := r.(bool)
+		if r != nil && !rok {
+			return nil, fmt.Errorf("%s needs booleans, got %s", b.op, describe(r))
+		}
+		if rok && rb == (b.op == "OR") {
+			return rb, nil
+		}
+		if l == nil || r == nil {
+			return nil, nil
+		}
+		return rb, nil
+	}
+
+	r, err := b.r.eval(e)
+	if err != nil || l == nil || r == nil {
+		return nil, err
+	}
+	switch b.op {
+	case "=":
+		return compare(l, r) == 0, nil
+	case "!=":
+		return compare(l, r) != 0, nil
+	case "<":
+		return compare(l, r) < 0, nil
+	case "<=":
+		return compare(l, r) <= 0, nil
+	case ">":
+		return compare(l, r) > 0, nil
+	case ">=":
+		return compare(l, r) >= 0, nil
+	}
+	return arithmetic(b.op, l, r)
+}
+
+func arithmetic(op string, l, r interface{}) (interface{}, error) {
+	li, lInt := l.(int64)
+	ri, rInt := r.(int64)
+	if lInt && rInt && op != "/" {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		case "%":
+			if ri == 0 {
+				return nil, nil
+			}
+			return li % ri, nil
+		}
+	}
+
+	lf, lok := toFloat(l)
+	rf, rok := toFloat(r)
+	if !lok || !rok {
+		return nil, fmt.Errorf("can't apply %s to %s and %s", op, describe(l), describe(r))
+	}
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, nil
+		}
+		return lf / rf, nil
+	case "%":
+		if rf == 0 {
+			return nil, nil
+		}
+		return math.Mod(lf, rf), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", op)
+}
+
+type isNull struct {
+	x   expr
+	not bool
+}
+
+func (n *isNull) String() string {
+	if n.not {
+		return n.x.String() + " IS NOT NULL"
+	}
+	return n.x.String() + " IS NULL"
+}
+
+func (n *isNull) eval(e *env) (interface{}, error) {
+	v, err := n.x.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	return (v == nil) != n.not, nil
+}
+
+type inList struct {
+	x    expr
+	list []expr
+	not  bool
+}
+
+func (in *inList) String() string {
+	items := make([]string, len(in.list))
+	for i, e := range in.list {
+		items[i] = e.String()
+	}
+	op := " IN "
+	if in.not {
+		op = " NOT IN "
+	}
+	return in.x.String() + op + "(" + strings.Join(items, ", ") + ")"
+}
+
+func (in *inList) eval(e *env) (interface{}, error) {
+	v, err := in.x.eval(e)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	for _, item := range in.list {
+		iv, err := item.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		if iv != nil && compare(v, iv) == 0 {
+			return !in.not, nil
+		}
+	}
+	return in.not, nil
+}
+
+type like struct {
+	x, pattern expr
+	not        bool
+
+	src string
+	re  *regexp.Regexp
+}
+
+func (l *like) String() string {
+	op := " LIKE "
+	if l.not {
+		op = " NOT LIKE "
+	}
+	return l.x.String() + op + l.pattern.String()
+}
+
+func (l *like) eval(e *env) (interface{}, error) {
+	v, err := l.x.eval(e)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	pv, err := l.pattern.eval(e)
+	if err != nil || pv == nil {
+		return nil, err
+	}
+	s, ok := v.(string)
+	pattern, pok := pv.(string)
+	if !ok || !pok {
+		return nil, fmt.Errorf("LIKE needs strings, got %s and %s", describe(v), describe(pv))
+	}
+	if l.re == nil || l.src != pattern {
+		var b strings.Builder
+		b.WriteString("^(?s)")
+		for _, r := range pattern {
+			switch r {
+			case '%':
+				b.WriteString(".*")
+			case '_':
+				b.WriteString(".")
+			default:
+				b.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		b.WriteString("$")
+		if l.re, err = regexp.Compile(b.String()); err != nil {
+			return nil, err
+		}
+		l.src = pattern
+	}
+	return l.re.MatchString(s) != l.not, nil
+}
+
+type between struct {
+	x, lo, hi expr
+	not       bool
+}
+
+func (b *between) String() string {
+	op := " BETWEEN "
+	if b.not {
+		op = " NOT BETWEEN "
+	}
+	return b.x.String() + op + b.lo.String() + " AND " + b.hi.String()
+}
+
+func (b *between) eval(e *env) (interface{}, error) {
+	v, err := b.x.eval(e)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	lo, err := b.lo.eval(e)
+	if err != nil || lo == nil {
+		return nil, err
+	}
+	hi, err := b.hi.eval(e)
+	if err != nil || hi == nil {
+		return nil, err
+	}
+	return (compare(v, lo) >= 0 && compare(v, hi) <= 0) != b.not, nil
+}
+
+// call is a function call. Aggregate calls are computed over a group of rows,
+// agg is the position of their result in env.aggs
+type call struct {
+	name     string
+	args     []expr
+	star     bool
+	distinct bool
+	agg      int
+}
+
+func (c *call) String() string {
+	if c.star {
+		return strings.ToLower(c.name) + "(*)"
+	}
+	args := make([]string, len(c.args))
+	for i, a := range c.args {
+		args[i] = a.String()
+	}
+	pre := ""
+	if c.distinct {
+		pre = "distinct "
+	}
+	return strings.ToLower(c.name) + "(" + pre + strings.Join(args, ", ") + ")"
+}
+
+func (c *call) eval(e *env) (interface{}, error) {
+	if aggregates[c.name] {
+		if c.agg < 0 || c.agg >= len(e.aggs) {
+			return nil, fmt.Errorf("aggregate %s can't be used here", c.String())
+		}
+		return e.aggs[c.agg], nil
+	}
+	args := make([]interface{}, len(c.args))
+	for i, a := range c.args {
+		var err error
+		if args[i], err = a.eval(e); err != nil {
+			return nil, err
+		}
+	}
+	fn := functions[c.name]
+	if fn.arity >= 0 && len(args) != fn.arity {
+		return nil, fmt.Errorf("%s takes %d argument(s), got %d", c.name, fn.arity, len(args))
+	}
+	return fn.fn(args)
+}
+
+var aggregates = map[string]bool{
+	"COUNT": true,
+	"SUM":   true,
+	"AVG":   true,
+	"MIN":   true,
+	"MAX":   true,
+}
+
+type function struct {
+	// arity is the number of arguments, -1 for any number
+	arity int
+	fn    func(args []interface{}) (interface{}, error)
+}
+
+var functions = map[string]function{
+	"LOWER": {1, stringFunc(strings.ToLower)},
+	"UPPER": {1, stringFunc(strings.ToUpper)},
+	"LENGTH": {1, func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("LENGTH needs a string, got %s", describe(args[0]))
+		}
+		return int64(len([]rune(s))), nil
+	}},
+	"ABS": {1, func(args []interface{}) (interface{}, error) {
+		switch n := args[0].(type) {
+		case nil:
+			return nil, nil
+		case int64:
+			if n < 0 {
+				return -n, nil
+			}
+			return n, nil
+		case float64:
+			return math.Abs(n), nil
+		}
+		return nil, fmt.Errorf("ABS needs a number, got %s", describe(args[0]))
+	}},
+	"ROUND": {-1, func(args []interface{}) (interface{}, error) {
+		if len(args) < 1 || len(args) > 2 {
+			return nil, fmt.Errorf("ROUND takes 1 or 2 arguments, got %d", len(args))
+		}
+		if args[0] == nil {
+			return nil, nil
+		}
+		f, ok := toFloat(args[0])
+		if !ok {
+			return nil, fmt.Errorf("ROUND needs a number, got %s", describe(args[0]))
+		}
+		places := int64(0)
+		if len(args) == 2 {
+			if places, ok = args[1].(int64); !ok {
+				return nil, fmt.Errorf("ROUND needs an integer number of places, got %s", describe(args[1]))
+			}
+		}
+		p := math.Pow10(int(places))
+		return math.Round(f*p) / p, nil
+	}},
+	"COALESCE": {-1, func(args []interface{}) (interface{}, error) {
+		for _, a := range args {
+			if a != nil {
+				return a, nil
+			}
+		}
+		return nil, nil
+	}},
+}
+
+func stringFunc(fn func(string) string) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("needs a string, got %s", describe(args[0]))
+		}
+		return fn(s), nil
+	}
+}
+
+// normalize converts values read from a body to the types expressions work
+// with: int64, float64, string, bool, or nil
+func normalize(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case uint64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i
+		}
+		f, _ := n.Float64()
+		return f
+	}
+	return v
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// compare orders two non-null values. Numbers compare numerically, strings
+// holding numbers compare with numbers as numbers. Values of other mixed
+// types order by type: booleans, numbers, strings, then anything else
+func compare(a, b interface{}) int {
+	af, aNum := toFloat(a)
+	bf, bNum := toFloat(b)
+	if aNum != bNum {
+		if s, ok := a.(string); ok {
+			af, aNum = parseNumber(s)
+		} else if s, ok := b.(string); ok {
+			bf, bNum = parseNumber(s)
+		}
+	}
+	if aNum && bNum {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	}
+
+	as, aStr := a.(string)
+	bs, bStr := b.(string)
+	if aStr && bStr {
+		return strings.Compare(as, bs)
+	}
+	ab, aBool := a.(bool)
+	bb, bBool := b.(bool)
+	if aBool && bBool {
+		switch {
+		case ab == bb:
+			return 0
+		case !ab:
+			return -1
+		}
+		return 1
+	}
+	if ra, rb := typeRank(a), typeRank(b); ra != rb {
+		if ra < rb {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(fmt.Sprintf("%v", a), fmt.Sprintf("%v", b))
+}
+
+func parseNumber(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f, err == nil
+}
+
+func typeRank(v interface{}) int {
+	switch v.(type) {
+	case bool:
+		return 0
+	case int64, float64:
+		return 1
+	case string:
+		return 2
+	}
+	return 3
+}
+
+// orderValues sorts nulls first
+func orderValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return compare(a, b)
+}
+
+// key encodes values for grouping & hashing. Numbers of equal value have
+// equal keys whatever their type
+func key(vals ...interface{}) string {
+	var b strings.Builder
+	for _, v := range vals {
+		switch x := v.(type) {
+		case nil:
+			b.WriteString("n|")
+		case int64:
+			fmt.Fprintf(&b, "f%s|", strconv.FormatFloat(float64(x), 'g', -1, 64))
+		case float64:
+			fmt.Fprintf(&b, "f%s|", strconv.FormatFloat(x, 'g', -1, 64))
+		case string:
+			fmt.Fprintf(&b, "s%d:%s|", len(x), x)
+		case bool:
+			fmt.Fprintf(&b, "b%t|", x)
+		default:
+			data, _ := json.Marshal(x)
+			fmt.Fprintf(&b, "j%d:%s|", len(data), data)
+		}
+	}
+	return b.String()
+}
+
+func describe(v interface{}) string {
+	switch v.(type) {
+	case int64, float64:
+		return fmt.Sprintf("number %v", v)
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// truthy reports if a condition holds, NULL doesn't
+func truthy(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return b, nil
+	}
+	return false, fmt.Errorf("condition must be a boolean, got %s", describe(v))
+}
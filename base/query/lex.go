@@ -0,0 +1,156 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tEOF tokenKind = iota
+	tIdent
+	tKeyword
+	tString
+	tNumber
+	tOp
+	tRef
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tEOF:
+		return "end of query"
+	case tString:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+var keywords = map[string]bool{
+	"SELECT": true, "DISTINCT": true, "FROM": true, "AS": true, "WHERE": true,
+	"GROUP": true, "BY": true, "HAVING": true, "ORDER": true, "ASC": true,
+	"DESC": true, "LIMIT": true, "OFFSET": true, "JOIN": true, "INNER": true,
+	"LEFT": true, "OUTER": true, "ON": true, "AND": true, "OR": true,
+	"NOT": true, "IS": true, "NULL": true, "IN": true, "LIKE": true,
+	"BETWEEN": true, "TRUE": true, "FALSE": true,
+}
+
+// lex splits a query into tokens. Table names following FROM & JOIN are
+// dataset references like me/sales@/ipfs/Qm..., read as a single token
+func lex(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '-' && strings.HasPrefix(src[i:], "--"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		}
+
+		start := i
+		if n := len(toks); n > 0 && toks[n-1].kind == tKeyword && (toks[n-1].text == "FROM" || toks[n-1].text == "JOIN") && c != '"' && c != '`' {
+			for i < len(src) && !strings.ContainsRune(" \t\r\n,();", rune(src[i])) {
+				i++
+			}
+			toks = append(toks, token{kind: tRef, text: src[start:i], pos: start})
+			continue
+		}
+
+		switch {
+		case isIdentStart(c):
+			for i < len(src) && isIdentChar(src[i]) {
+				i++
+			}
+			word := src[start:i]
+			if upper := strings.ToUpper(word); keywords[upper] {
+				toks = append(toks, token{kind: tKeyword, text: upper, pos: start})
+			} else {
+				toks = append(toks, token{kind: tIdent, text: word, pos: start})
+			}
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+					i++
+				}
+			}
+			toks = append(toks, token{kind: tNumber, text: src[start:i], pos: start})
+		case c == '\'':
+			s, n, err := readQuoted(src[i:], '\'')
+			if err != nil {
+				return nil, fmt.Errorf("%s at position %d", err, start)
+			}
+			i += n
+			toks = append(toks, token{kind: tString, text: s, pos: start})
+		case c == '"' || c == '`':
+			s, n, err := readQuoted(src[i:], c)
+			if err != nil {
+				return nil, fmt.Errorf("%s at position %d", err, start)
+			}
+			i += n
+			kind := tIdent
+			if n := len(toks); n > 0 && toks[n-1].kind == tKeyword && (toks[n-1].text == "FROM" || toks[n-1].text == "JOIN") {
+				kind = tRef
+			}
+			toks = append(toks, token{kind: kind, text: s, pos: start})
+		default:
+			op := string(c)
+			if i+1 < len(src) {
+				switch two := src[i : i+2]; two {
+				case "!=", "<>", "<=", ">=":
+					op = two
+				}
+			}
+			if !strings.Contains("=!<>+-*/%(),.;", op[:1]) || op == "!" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, start)
+			}
+			i += len(op)
+			toks = append(toks, token{kind: tOp, text: op, pos: start})
+		}
+	}
+	return append(toks, token{kind: tEOF, pos: len(src)}), nil
+}
+
+// readQuoted reads a quoted string, where a doubled quote escapes the quote
+func readQuoted(src string, q byte) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(src); i++ {
+		if src[i] == q {
+			if i+1 < len(src) && src[i+1] == q {
+				b.WriteByte(q)
+				i++
+				continue
+			}
+			return b.String(), i + 1, nil
+		}
+		b.WriteByte(src[i])
+	}
+	return "", 0, fmt.Errorf("unterminated quote")
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
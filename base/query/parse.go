@@ -0,0 +1,548 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Statement is a parsed SELECT statement of the restricted SQL dialect
+// queries are written in:
+//
+//	SELECT [DISTINCT] * | expr [[AS] name], ...
+//	FROM ref [[AS] alias]
+//	[[INNER | LEFT [OUTER]] JOIN ref [[AS] alias] ON expr]
+//	[WHERE expr]
+//	[GROUP BY expr, ...] [HAVING expr]
+//	[ORDER BY expr [ASC | DESC], ...]
+//	[LIMIT n [OFFSET n]]
+//
+// Table names are dataset references. Expressions support comparisons,
+// arithmetic, AND, OR, NOT, IS [NOT] NULL, [NOT] IN, [NOT] LIKE,
+// [NOT] BETWEEN, the aggregates COUNT, SUM, AVG, MIN & MAX and the scalar
+// functions LOWER, UPPER, LENGTH, ABS, ROUND & COALESCE
+type Statement struct {
+	distinct bool
+	items    []selectItem
+	from     tableRef
+	join     *join
+	where    expr
+	groupBy  []expr
+	having   expr
+	orderBy  []orderItem
+	limit    int
+	offset   int
+}
+
+type tableRef struct {
+	ref   string
+	alias string
+}
+
+type join struct {
+	table tableRef
+	left  bool
+	on    expr
+}
+
+type selectItem struct {
+	expr  expr
+	alias string
+	star  bool
+}
+
+type orderItem struct {
+	expr expr
+	desc bool
+}
+
+// Tables lists the dataset references a statement reads, in the order
+// they're named
+func (s *Statement) Tables() []string {
+	refs := []string{s.from.ref}
+	if s.join != nil {
+		refs = append(refs, s.join.table.ref)
+	}
+	return refs
+}
+
+// Parse parses a query
+func Parse(sql string) (*Statement, error) {
+	toks, err := lex(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	stmt, err := p.statement()
+	if err != nil {
+		return nil, fmt.Errorf("parsing query: %s", err)
+	}
+	return stmt, nil
+}
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tEOF {
+		p.i++
+	}
+	return t
+}
+
+// keyword consumes the next token if it's one of the given keywords
+func (p *parser) keyword(kws ...string) bool {
+	t := p.peek()
+	if t.kind != tKeyword {
+		return false
+	}
+	for _, kw := range kws {
+		if t.text == kw {
+			p.i++
+			return true
+		}
+	}
+	return false
+}
+
+// op consumes the next token if it's the given operator
+func (p *parser) op(op string) bool {
+	if t := p.peek(); t.kind == tOp && t.text == op {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) unexpected(expected string) error {
+	t := p.peek()
+	return fmt.Errorf("expected %s, got %s at position %d", expected, t, t.pos)
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if !p.keyword(kw) {
+		return p.unexpected(kw)
+	}
+	return nil
+}
+
+func (p *parser) statement() (*Statement, error) {
+	s := &Statement{limit: -1}
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	s.distinct = p.keyword("DISTINCT")
+
+	for {
+		if p.op("*") {
+			s.items = append(s.items, selectItem{star: true})
+		} else {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			item := selectItem{expr: e}
+			if p.keyword("AS") {
+				if item.alias, err = p.ident(); err != nil {
+					return nil, err
+				}
+			} else if t := p.peek(); t.kind == tIdent {
+				item.alias = p.next().text
+			}
+			s.items = append(s.items, item)
+		}
+		if !p.op(",") {
+			break
+		}
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	var err error
+	if s.from, err = p.tableRef(); err != nil {
+		return nil, err
+	}
+
+	if p.keyword("INNER", "LEFT", "JOIN") {
+		j := &join{}
+		switch p.toks[p.i-1].text {
+		case "LEFT":
+			j.left = true
+			p.keyword("OUTER")
+			if err := p.expectKeyword("JOIN"); err != nil {
+				return nil, err
+			}
+		case "INNER":
+			if err := p.expectKeyword("JOIN"); err != nil {
+				return nil, err
+			}
+		}
+		if j.table, err = p.tableRef(); err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("ON"); err != nil {
+			return nil, err
+		}
+		if j.on, err = p.expr(); err != nil {
+			return nil, err
+		}
+		s.join = j
+	}
+
+	if p.keyword("WHERE") {
+		if s.where, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.keyword("GROUP") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		if s.groupBy, err = p.exprList(); err != nil {
+			return nil, err
+		}
+	}
+	if p.keyword("HAVING") {
+		if s.having, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.keyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			item := orderItem{expr: e}
+			if p.keyword("DESC") {
+				item.desc = true
+			} else {
+				p.keyword("ASC")
+			}
+			s.orderBy = append(s.orderBy, item)
+			if !p.op(",") {
+				break
+			}
+		}
+	}
+	if p.keyword("LIMIT") {
+		if s.limit, err = p.count(); err != nil {
+			return nil, err
+		}
+		if p.keyword("OFFSET") {
+			if s.offset, err = p.count(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	p.op(";")
+	if p.peek().kind != tEOF {
+		return nil, p.unexpected("end of query")
+	}
+	return s, nil
+}
+
+func (p *parser) ident() (string, error) {
+	if t := p.peek(); t.kind == tIdent {
+		return p.next().text, nil
+	}
+	return "", p.unexpected("a name")
+}
+
+func (p *parser) count() (int, error) {
+	t := p.peek()
+	if t.kind == tNumber {
+		if n, err := strconv.Atoi(t.text); err == nil && n >= 0 {
+			p.next()
+			return n, nil
+		}
+	}
+	return 0, p.unexpected("a non-negative integer")
+}
+
+func (p *parser) tableRef() (tableRef, error) {
+	t := p.peek()
+	if t.kind != tRef || t.text == "" {
+		return tableRef{}, p.unexpected("a dataset reference")
+	}
+	p.next()
+	tr := tableRef{ref: t.text}
+	if p.keyword("AS") {
+		var err error
+		if tr.alias, err = p.ident(); err != nil {
+			return tr, err
+		}
+	} else if p.peek().kind == tIdent {
+		tr.alias = p.next().text
+	}
+	return tr, nil
+}
+
+func (p *parser) exprList() ([]expr, error) {
+	var list []expr
+	for {
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+		if !p.op(",") {
+			return list, nil
+		}
+	}
+}
+
+func (p *parser) expr() (expr, error) {
+	l, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		r, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l = &binary{op: "OR", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) and() (expr, error) {
+	l, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		r, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		l = &binary{op: "AND", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) not() (expr, error) {
+	if p.keyword("NOT") {
+		x, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{op: "NOT", x: x}, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (expr, error) {
+	l, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind == tOp {
+		switch t.text {
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			p.next()
+			r, err := p.additive()
+			if err != nil {
+				return nil, err
+			}
+			op := t.text
+			if op == "<>" {
+				op = "!="
+			}
+			return &binary{op: op, l: l, r: r}, nil
+		}
+	}
+
+	if p.keyword("IS") {
+		not := p.keyword("NOT")
+		if err := p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return &isNull{x: l, not: not}, nil
+	}
+
+	not := p.keyword("NOT")
+	switch {
+	case p.keyword("IN"):
+		if !p.op("(") {
+			return nil, p.unexpected(`"("`)
+		}
+		list, err := p.exprList()
+		if err != nil {
+			return nil, err
+		}
+		if !p.op(")") {
+			return nil, p.unexpected(`")"`)
+		}
+		return &inList{x: l, list: list, not: not}, nil
+	case p.keyword("LIKE"):
+		pattern, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		return &like{x: l, pattern: pattern, not: not}, nil
+	case p.keyword("BETWEEN"):
+		lo, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		hi, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		return &between{x: l, lo: lo, hi: hi, not: not}, nil
+	case not:
+		return nil, p.unexpected("IN, LIKE or BETWEEN")
+	}
+	return l, nil
+}
+
+func (p *parser) additive() (expr, error) {
+	l, err := p.multiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tOp || (t.text != "+" && t.text != "-") {
+			return l, nil
+		}
+		p.next()
+		r, err := p.multiplicative()
+		if err != nil {
+			return nil, err
+		}
+		l = &binary{op: t.text, l: l, r: r}
+	}
+}
+
+func (p *parser) multiplicative() (expr, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tOp || (t.text != "*" && t.text != "/" && t.text != "%") {
+			return l, nil
+		}
+		p.next()
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = &binary{op: t.text, l: l, r: r}
+	}
+}
+
+func (p *parser) unary() (expr, error) {
+	if p.op("-") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{op: "-", x: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (expr, error) {
+	t := p.peek()
+	switch t.kind {
+	case tNumber:
+		p.next()
+		if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return &literal{val: i}, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s at position %d", t, t.pos)
+		}
+		return &literal{val: f}, nil
+	case tString:
+		p.next()
+		return &literal{val: t.text}, nil
+	case tKeyword:
+		switch t.text {
+		case "TRUE":
+			p.next()
+			return &literal{val: true}, nil
+		case "FALSE":
+			p.next()
+			return &literal{val: false}, nil
+		case "NULL":
+			p.next()
+			return &literal{val: nil}, nil
+		}
+	case tOp:
+		if t.text == "(" {
+			p.next()
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.op(")") {
+				return nil, p.unexpected(`")"`)
+			}
+			return e, nil
+		}
+	case tIdent:
+		p.next()
+		if p.op("(") {
+			return p.call(t)
+		}
+		if p.op(".") {
+			name, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			return &column{table: t.text, name: name, idx: -1}, nil
+		}
+		return &column{name: t.text, idx: -1}, nil
+	}
+	return nil, p.unexpected("an expression")
+}
+
+func (p *parser) call(name token) (expr, error) {
+	c := &call{name: strings.ToUpper(name.text), agg: -1}
+	if _, ok := functions[c.name]; !ok && !aggregates[c.name] {
+		return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos)
+	}
+	if p.op("*") {
+		if c.name != "COUNT" {
+			return nil, fmt.Errorf("only COUNT accepts *, at position %d", name.pos)
+		}
+		c.star = true
+	} else if !(p.peek().kind == tOp && p.peek().text == ")") {
+		if aggregates[c.name] {
+			c.distinct = p.keyword("DISTINCT")
+		}
+		var err error
+		if c.args, err = p.exprList(); err != nil {
+			return nil, err
+		}
+	}
+	if !p.op(")") {
+		return nil, p.unexpected(`")"`)
+	}
+	if aggregates[c.name] && !c.star && len(c.args) != 1 {
+		return nil, fmt.Errorf("%s takes one argument, at position %d", c.name, name.pos)
+	}
+	return c, nil
+}
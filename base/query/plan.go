@@ -0,0 +1,812 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/qri-io/dataset/dsio"
+)
+
+// DefaultMaxJoinRows is the number of rows the right side of a join may have
+// when Options.MaxJoinRows isn't set. The right side of a join is held in
+// memory while the left side streams past it
+const DefaultMaxJoinRows = 100000
+
+// ErrJoinTooLarge means the right side of a join has more rows than a query
+// may hold in memory
+var ErrJoinTooLarge = fmt.Errorf("join too large")
+
+// ErrUnknownTable means a statement names a table it wasn't given
+var ErrUnknownTable = fmt.Errorf("unknown table")
+
+// Table is a tabular body a query reads from
+type Table struct {
+	// Columns names the columns of each row. Array rows are read by position,
+	// object rows by key
+	Columns []string
+	// Types gives the json schema type of each column, if known
+	Types []string
+	// Open starts a read of the table body
+	Open func() (dsio.EntryReader, error)
+}
+
+// Options configures running a query
+type Options struct {
+	// MaxRows caps the number of result rows, 0 for no cap. Queries with
+	// more results are truncated
+	MaxRows int
+	// MaxJoinRows caps the rows of the right side of a join, defaults to
+	// DefaultMaxJoinRows
+	MaxJoinRows int
+}
+
+// Plan is a statement ready to run against a set of tables
+type Plan struct {
+	stmt   *Statement
+	opts   Options
+	tables []Table
+	// offsets holds the position of each table's first column in the
+	// combined row a query evaluates expressions against
+	offsets []int
+	width   int
+
+	out     []output
+	aggs    []*call
+	grouped bool
+	order   []sortKey
+
+	// leftKey & rightKey are set when a join is an equality of a left & a
+	// right column, and can be run as a hash join
+	leftKey, rightKey *column
+}
+
+type output struct {
+	name string
+	typ  string
+	expr expr
+}
+
+// sortKey is an ORDER BY term. It sorts either by an output column or by an
+// expression evaluated against the source row
+type sortKey struct {
+	out  int
+	expr expr
+	desc bool
+}
+
+// NewPlan resolves the tables & columns of a statement. tables is keyed by
+// the references the statement names, as returned by Statement.Tables
+func NewPlan(stmt *Statement, tables map[string]Table, opts Options) (*Plan, error) {
+	if opts.MaxJoinRows <= 0 {
+		opts.MaxJoinRows = DefaultMaxJoinRows
+	}
+	p := &Plan{stmt: stmt, opts: opts}
+
+	refs := []tableRef{stmt.from}
+	if stmt.join != nil {
+		refs = append(refs, stmt.join.table)
+	}
+	for _, ref := range refs {
+		t, ok := tables[ref.ref]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTable, ref.ref)
+		}
+		p.tables = append(p.tables, t)
+		p.offsets = append(p.offsets, p.width)
+		p.width += len(t.Columns)
+	}
+	if len(refs) == 2 && tableName(refs[0]) == tableName(refs[1]) {
+		return nil, fmt.Errorf("both tables are named %q, give one an alias", tableName(refs[0]))
+	}
+
+	res := &resolver{plan: p, refs: refs}
+	if stmt.join != nil {
+		if err := res.resolve(stmt.join.on, "JOIN"); err != nil {
+			return nil, err
+		}
+		p.planHashJoin()
+	}
+	if stmt.where != nil {
+		if err := res.resolve(stmt.where, "WHERE"); err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range stmt.groupBy {
+		if err := res.resolve(e, "GROUP BY"); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, item := range stmt.items {
+		if item.star {
+			for i, ref := range refs {
+				for j, name := range p.tables[i].Columns {
+					col := &column{table: tableName(ref), name: name, idx: p.offsets[i] + j}
+					p.out = append(p.out, output{name: name, typ: p.columnType(col.idx), expr: col})
+				}
+			}
+			continue
+		}
+		if err := res.resolve(item.expr, ""); err != nil {
+			return nil, err
+		}
+		name := item.alias
+		if name == "" {
+			if c, ok := item.expr.(*column); ok {
+				name = c.name
+			} else {
+				name = item.expr.String()
+			}
+		}
+		p.out = append(p.out, output{name: name, typ: p.exprType(item.expr), expr: item.expr})
+	}
+
+	if stmt.having != nil {
+		if err := res.resolve(stmt.having, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, o := range stmt.orderBy {
+		key := sortKey{out: -1, expr: o.expr, desc: o.desc}
+		switch e := o.expr.(type) {
+		case *literal:
+			if n, ok := e.val.(int64); ok {
+				if n < 1 || int(n) > len(p.out) {
+					return nil, fmt.Errorf("ORDER BY position %d is out of range", n)
+				}
+				key.out = int(n) - 1
+			}
+		case *column:
+			if e.table == "" {
+				for i, out := range p.out {
+					if strings.EqualFold(out.name, e.name) {
+						key.out = i
+						break
+					}
+				}
+			}
+		}
+		if key.out < 0 {
+			if err := res.resolve(o.expr, ""); err != nil {
+				return nil, err
+			}
+		}
+		p.order = append(p.order, key)
+	}
+
+	p.aggs = res.aggs
+	p.grouped = len(stmt.groupBy) > 0 || len(p.aggs) > 0
+	if stmt.having != nil && !p.grouped {
+		return nil, fmt.Errorf("HAVING needs GROUP BY or an aggregate")
+	}
+	return p, nil
+}
+
+// Columns names the columns of the results
+func (p *Plan) Columns() []string {
+	names := make([]string, len(p.out))
+	for i, o := range p.out {
+		names[i] = o.name
+	}
+	return names
+}
+
+// Types gives the json schema type of each result column, empty where it
+// can't be known before the query runs
+func (p *Plan) Types() []string {
+	types := make([]string, len(p.out))
+	for i, o := range p.out {
+		types[i] = o.typ
+	}
+	return types
+}
+
+// tableName is the name columns of a table are qualified with
+func tableName(ref tableRef) string {
+	if ref.alias != "" {
+		return ref.alias
+	}
+	name := ref.ref
+	if i := strings.IndexAny(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+type resolver struct {
+	plan *Plan
+	refs []tableRef
+	aggs []*call
+}
+
+// resolve binds columns to positions in the combined row & numbers the
+// aggregates of an expression. clause names where aggregates aren't allowed
+func (r *resolver) resolve(e expr, clause string) error {
+	return walk(e, func(e expr) error {
+		switch x := e.(type) {
+		case *column:
+			return r.column(x)
+		case *call:
+			if !aggregates[x.name] {
+				return nil
+			}
+			if clause != "" {
+				return fmt.Errorf("aggregate %s isn't allowed in %s", x.String(), clause)
+			}
+			for _, a := range x.args {
+				if err := walk(a, func(e expr) error {
+					if c, ok := e.(*call); ok && aggregates[c.name] {
+						return fmt.Errorf("aggregate %s can't be nested in %s", c.String(), x.String())
+					}
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+			if x.agg < 0 {
+				x.agg = len(r.aggs)
+				r.aggs = append(r.aggs, x)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *resolver) column(c *column) error {
+	if c.idx >= 0 {
+		return nil
+	}
+	found := -1
+	for i, ref := range r.refs {
+		if c.table != "" && c.table != tableName(ref) && c.table != ref.ref {
+			continue
+		}
+		for j, name := range r.plan.tables[i].Columns {
+			if name != c.name {
+				continue
+			}
+			if found >= 0 {
+				return fmt.Errorf("column %q is ambiguous, qualify it with a table name", c.String())
+			}
+			found = r.plan.offsets[i] + j
+		}
+	}
+	if found < 0 {
+		return fmt.Errorf("unknown column %q", c.String())
+	}
+	c.idx = found
+	return nil
+}
+
+// walk calls fn for e & each expression it contains
+func walk(e expr, fn func(expr) error) error {
+	if err := fn(e); err != nil {
+		return err
+	}
+	var children []expr
+	switch x := e.(type) {
+	case *unary:
+		children = []expr{x.x}
+	case *binary:
+		children = []expr{x.l, x.r}
+	case *isNull:
+		children = []expr{x.x}
+	case *inList:
+		children = append([]expr{x.x}, x.list...)
+	case *like:
+		children = []expr{x.x, x.pattern}
+	case *between:
+		children = []expr{x.x, x.lo, x.hi}
+	case *call:
+		children = x.args
+	}
+	for _, c := range children {
+		if err := walk(c, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Plan) planHashJoin() {
+	b, ok := p.stmt.join.on.(*binary)
+	if !ok || b.op != "=" {
+		return
+	}
+	l, lok := b.l.(*column)
+	r, rok := b.r.(*column)
+	if !lok || !rok {
+		return
+	}
+	split := p.offsets[1]
+	switch {
+	case l.idx < split && r.idx >= split:
+		p.leftKey, p.rightKey = l, r
+	case r.idx < split && l.idx >= split:
+		p.leftKey, p.rightKey = r, l
+	}
+}
+
+func (p *Plan) columnType(idx int) string {
+	for i := len(p.offsets) - 1; i >= 0; i-- {
+		if idx >= p.offsets[i] {
+			if types := p.tables[i].Types; idx-p.offsets[i] < len(types) {
+				return types[idx-p.offsets[i]]
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// exprType gives the json schema type an expression evaluates to, if it can
+// be known before running
+func (p *Plan) exprType(e expr) string {
+	switch x := e.(type) {
+	case *literal:
+		switch x.val.(type) {
+		case int64:
+			return "integer"
+		case float64:
+			return "number"
+		case string:
+			return "string"
+		case bool:
+			return "boolean"
+		}
+	case *column:
+		return p.columnType(x.idx)
+	case *unary:
+		if x.op == "NOT" {
+			return "boolean"
+		}
+		return p.exprType(x.x)
+	case *binary:
+		switch x.op {
+		case "+", "-", "*", "%":
+			if p.exprType(x.l) == "integer" && p.exprType(x.r) == "integer" {
+				return "integer"
+			}
+			return "number"
+		case "/":
+			return "number"
+		}
+		return "boolean"
+	case *isNull, *inList, *like, *between:
+		return "boolean"
+	case *call:
+		switch x.name {
+		case "COUNT", "LENGTH":
+			return "integer"
+		case "AVG", "ROUND":
+			return "number"
+		case "LOWER", "UPPER":
+			return "string"
+		case "SUM":
+			if p.exprType(x.args[0]) == "integer" {
+				return "integer"
+			}
+			return "number"
+		case "MIN", "MAX", "ABS", "COALESCE":
+			if len(x.args) > 0 {
+				return p.exprType(x.args[0])
+			}
+		}
+	}
+	return ""
+}
+
+// errStop ends a scan early once a query has all the rows it needs
+var errStop = fmt.Errorf("stop")
+
+// Run executes the plan, calling emit with each result row. Rows are
+// streamed as the tables are read unless the query groups or orders them.
+// truncated reports if results were cut off by Options.MaxRows. Run stops
+// with the context's error when it's cancelled
+func (p *Plan) Run(ctx context.Context, emit func(row []interface{}) error) (truncated bool, err error) {
+	var (
+		skipped, emitted int
+		seen             map[string]bool
+	)
+	if p.stmt.distinct {
+		seen = map[string]bool{}
+	}
+
+	// send applies DISTINCT, OFFSET, LIMIT & the row cap to projected rows
+	send := func(row []interface{}) error {
+		if seen != nil {
+			k := key(row...)
+			if seen[k] {
+				return nil
+			}
+			seen[k] = true
+		}
+		if skipped < p.stmt.offset {
+			skipped++
+			return nil
+		}
+		if p.stmt.limit >= 0 && emitted >= p.stmt.limit {
+			return errStop
+		}
+		if p.opts.MaxRows > 0 && emitted >= p.opts.MaxRows {
+			truncated = true
+			return errStop
+		}
+		emitted++
+		return emit(row)
+	}
+
+	var sorted []sortedRow
+	// result projects a row, either sending it on or holding it for sorting
+	result := func(e *env) error {
+		row := make([]interface{}, len(p.out))
+		for i, o := range p.out {
+			v, err := o.expr.eval(e)
+			if err != nil {
+				return err
+			}
+			row[i] = v
+		}
+		if len(p.order) == 0 {
+			return send(row)
+		}
+		keys := make([]interface{}, len(p.order))
+		for i, k := range p.order {
+			if k.out >= 0 {
+				keys[i] = row[k.out]
+				continue
+			}
+			v, err := k.expr.eval(e)
+			if err != nil {
+				return err
+			}
+			keys[i] = v
+		}
+		sorted = append(sorted, sortedRow{row: row, keys: keys})
+		return nil
+	}
+
+	var groups *groupSet
+	if p.grouped {
+		groups = &groupSet{index: map[string]*group{}}
+	}
+
+	err = p.scan(ctx, func(row []interface{}) error {
+		e := &env{row: row}
+		if p.stmt.where != nil {
+			v, err := p.stmt.where.eval(e)
+			if err != nil {
+				return err
+			}
+			if ok, err := truthy(v); err != nil || !ok {
+				return err
+			}
+		}
+		if groups != nil {
+			return p.accumulate(groups, e)
+		}
+		return result(e)
+	})
+	if err == errStop {
+		return truncated, nil
+	} else if err != nil {
+		return truncated, err
+	}
+
+	if groups != nil {
+		// an aggregate without GROUP BY summarizes all rows, even none
+		if len(p.stmt.groupBy) == 0 && len(groups.list) == 0 {
+			groups.list = append(groups.list, p.newGroup(make([]interface{}, p.width)))
+		}
+		for _, g := range groups.list {
+			e := &env{row: g.row, aggs: make([]interface{}, len(g.accs))}
+			for i, acc := range g.accs {
+				e.aggs[i] = acc.result()
+			}
+			if p.stmt.having != nil {
+				v, err := p.stmt.having.eval(e)
+				if err != nil {
+					return truncated, err
+				}
+				if ok, err := truthy(v); err != nil {
+					return truncated, err
+				} else if !ok {
+					continue
+				}
+			}
+			if err := result(e); err == errStop {
+				return truncated, nil
+			} else if err != nil {
+				return truncated, err
+			}
+		}
+	}
+
+	if len(p.order) > 0 {
+		sort.SliceStable(sorted, func(i, j int) bool {
+			for k, o := range p.order {
+				c := orderValues(sorted[i].keys[k], sorted[j].keys[k])
+				if c == 0 {
+					continue
+				}
+				if o.desc {
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+		for i, r := range sorted {
+			if i%1024 == 0 && ctx.Err() != nil {
+				return truncated, ctx.Err()
+			}
+			if err := send(r.row); err == errStop {
+				break
+			} else if err != nil {
+				return truncated, err
+			}
+		}
+	}
+	return truncated, nil
+}
+
+type sortedRow struct {
+	row  []interface{}
+	keys []interface{}
+}
+
+// scan reads the combined rows of the query's tables, joining them if the
+// statement has a join
+func (p *Plan) scan(ctx context.Context, fn func(row []interface{}) error) error {
+	if p.stmt.join == nil {
+		return p.read(ctx, 0, fn)
+	}
+
+	var (
+		right []([]interface{})
+		index map[string][]([]interface{})
+	)
+	if p.rightKey != nil {
+		index = map[string][]([]interface{}){}
+	}
+	err := p.read(ctx, 1, func(row []interface{}) error {
+		if len(right) >= p.opts.MaxJoinRows {
+			return fmt.Errorf("%w: the joined table %q has more than %d rows", ErrJoinTooLarge, p.stmt.join.table.ref, p.opts.MaxJoinRows)
+		}
+		right = append(right, row)
+		if index != nil {
+			v := row[p.rightKey.idx]
+			if v != nil {
+				k := key(v)
+				index[k] = append(index[k], row)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	on := p.stmt.join.on
+	return p.read(ctx, 0, func(left []interface{}) error {
+		candidates := right
+		if index != nil {
+			candidates = nil
+			if v := left[p.leftKey.idx]; v != nil {
+				candidates = index[key(v)]
+			}
+		}
+		matched := false
+		for i, r := range candidates {
+			if i%1024 == 1023 && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			row := make([]interface{}, p.width)
+			copy(row, left)
+			copy(row[p.offsets[1]:], r[p.offsets[1]:])
+			v, err := on.eval(&env{row: row})
+			if err != nil {
+				return err
+			}
+			if ok, err := truthy(v); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+			matched = true
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		if !matched && p.stmt.join.left {
+			row := make([]interface{}, p.width)
+			copy(row, left)
+			return fn(row)
+		}
+		return nil
+	})
+}
+
+// read streams the rows of table t, each placed at the table's offset in a
+// combined row
+func (p *Plan) read(ctx context.Context, t int, fn func(row []interface{}) error) error {
+	table := p.tables[t]
+	r, err := table.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	cols := table.Columns
+	offset := p.offsets[t]
+	for i := 0; ; i++ {
+		if i%1024 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		ent, err := r.ReadEntry()
+		if err != nil {
+			if err.Error() == "EOF" {
+				return nil
+			}
+			return err
+		}
+		row := make([]interface{}, p.width)
+		switch v := ent.Value.(type) {
+		case []interface{}:
+			for j := range cols {
+				if j < len(v) {
+					row[offset+j] = normalize(v[j])
+				}
+			}
+		case map[string]interface{}:
+			for j, name := range cols {
+				row[offset+j] = normalize(v[name])
+			}
+		default:
+			return fmt.Errorf("row %d of %q isn't an array or object", i, p.tableRef(t))
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Plan) tableRef(t int) string {
+	if t == 0 {
+		return p.stmt.from.ref
+	}
+	return p.stmt.join.table.ref
+}
+
+type group struct {
+	row  []interface{}
+	accs []*accumulator
+}
+
+type groupSet struct {
+	index map[string]*group
+	list  []*group
+}
+
+func (p *Plan) newGroup(row []interface{}) *group {
+	g := &group{row: row, accs: make([]*accumulator, len(p.aggs))}
+	for i, c := range p.aggs {
+		g.accs[i] = &accumulator{fn: c}
+		if c.distinct {
+			g.accs[i].seen = map[string]bool{}
+		}
+	}
+	return g
+}
+
+// accumulate adds a row to its group. Columns outside aggregates that aren't
+// grouped on take their value from the first row of a group
+func (p *Plan) accumulate(groups *groupSet, e *env) error {
+	vals := make([]interface{}, len(p.stmt.groupBy))
+	for i, g := range p.stmt.groupBy {
+		v, err := g.eval(e)
+		if err != nil {
+			return err
+		}
+		vals[i] = v
+	}
+	k := key(vals...)
+	g, ok := groups.index[k]
+	if !ok {
+		g = p.newGroup(e.row)
+		groups.index[k] = g
+		groups.list = append(groups.list, g)
+	}
+	for _, acc := range g.accs {
+		if err := acc.add(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// accumulator computes an aggregate over the rows of a group
+type accumulator struct {
+	fn   *call
+	seen map[string]bool
+
+	count    int64
+	sumInt   int64
+	sumFloat float64
+	floats   bool
+	best     interface{}
+}
+
+func (a *accumulator) add(e *env) error {
+	if a.fn.star {
+		a.count++
+		return nil
+	}
+	v, err := a.fn.args[0].eval(e)
+	if err != nil || v == nil {
+		return err
+	}
+	if a.seen != nil {
+		k := key(v)
+		if a.seen[k] {
+			return nil
+		}
+		a.seen[k] = true
+	}
+	a.count++
+
+	switch a.fn.name {
+	case "SUM", "AVG":
+		switch n := v.(type) {
+		case int64:
+			a.sumInt += n
+		case float64:
+			a.floats = true
+			a.sumFloat += n
+		default:
+			s, _ := v.(string)
+			f, ok := parseNumber(s)
+			if !ok {
+				return fmt.Errorf("%s needs numbers, got %s", a.fn.name, describe(v))
+			}
+			a.floats = true
+			a.sumFloat += f
+		}
+	case "MIN":
+		if a.best == nil || compare(v, a.best) < 0 {
+			a.best = v
+		}
+	case "MAX":
+		if a.best == nil || compare(v, a.best) > 0 {
+			a.best = v
+		}
+	}
+	return nil
+}
+
+func (a *accumulator) result() interface{} {
+	switch a.fn.name {
+	case "COUNT":
+		return a.count
+	case "SUM":
+		if a.count == 0 {
+			return nil
+		}
+		if !a.floats {
+			return a.sumInt
+		}
+		return a.sumFloat + float64(a.sumInt)
+	case "AVG":
+		if a.count == 0 {
+			return nil
+		}
+		return (a.sumFloat + float64(a.sumInt)) / float64(a.count)
+	}
+	return a.best
+}
@@ -0,0 +1,223 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+type rowReader struct {
+	rows []interface{}
+	i    int
+}
+
+func (r *rowReader) Structure() *dataset.Structure { return nil }
+
+func (r *rowReader) ReadEntry() (dsio.Entry, error) {
+	if r.i >= len(r.rows) {
+		return dsio.Entry{}, io.EOF
+	}
+	r.i++
+	return dsio.Entry{Index: r.i - 1, Value: r.rows[r.i-1]}, nil
+}
+
+func (r *rowReader) Close() error { return nil }
+
+func table(cols, types []string, rows ...interface{}) Table {
+	return Table{
+		Columns: cols,
+		Types:   types,
+		Open:    func() (dsio.EntryReader, error) { return &rowReader{rows: rows}, nil },
+	}
+}
+
+func testTables() map[string]Table {
+	return map[string]Table{
+		"me/sales": table(
+			[]string{"id", "region", "amount"},
+			[]string{"integer", "string", "number"},
+			[]interface{}{1, "north", 10.5},
+			[]interface{}{2, "south", 20.0},
+			[]interface{}{3, "north", 4.5},
+			[]interface{}{4, "east", nil},
+			map[string]interface{}{"id": 5, "region": "south", "amount": 5},
+		),
+		"me/regions": table(
+			[]string{"region", "manager"},
+			[]string{"string", "string"},
+			[]interface{}{"north", "ana"},
+			[]interface{}{"south", "bo"},
+			[]interface{}{"west", "cy"},
+		),
+	}
+}
+
+func run(t *testing.T, sql string, opts Options) (*Plan, [][]interface{}, bool, error) {
+	t.Helper()
+	stmt, err := Parse(sql)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	p, err := NewPlan(stmt, testTables(), opts)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	var rows [][]interface{}
+	truncated, err := p.Run(context.Background(), func(row []interface{}) error {
+		rows = append(rows, row)
+		return nil
+	})
+	return p, rows, truncated, err
+}
+
+func TestQuery(t *testing.T) {
+	cases := []struct {
+		sql  string
+		cols []string
+		rows string
+	}{
+		{"SELECT * FROM me/sales WHERE id <= 2", []string{"id", "region", "amount"},
+			`[[1,"north",10.5],[2,"south",20]]`},
+		{"select id, amount * 2 as double from me/sales where amount > 5 order by double desc", []string{"id", "double"},
+			`[[2,40],[1,21]]`},
+		{"SELECT region, count(*), sum(amount) total FROM me/sales GROUP BY region ORDER BY region", []string{"region", "count(*)", "total"},
+			`[["east",1,null],["north",2,15],["south",2,25]]`},
+		{"SELECT region, avg(amount) FROM me/sales GROUP BY region HAVING count(*) > 1 ORDER BY 2", []string{"region", "avg(amount)"},
+			`[["north",7.5],["south",12.5]]`},
+		{"SELECT count(*), min(region), max(amount) FROM me/sales", []string{"count(*)", "min(region)", "max(amount)"},
+			`[[5,"east",20]]`},
+		{"SELECT count(*) FROM me/sales WHERE id > 100", []string{"count(*)"},
+			`[[0]]`},
+		{"SELECT DISTINCT region FROM me/sales ORDER BY region LIMIT 2 OFFSET 1", []string{"region"},
+			`[["north"],["south"]]`},
+		{"SELECT id FROM me/sales WHERE amount IS NULL OR region IN ('nowhere')", []string{"id"},
+			`[[4]]`},
+		{"SELECT id FROM me/sales WHERE region LIKE 'n%' AND amount BETWEEN 1 AND 5", []string{"id"},
+			`[[3]]`},
+		{"SELECT upper(region) r, length(region) FROM me/sales WHERE NOT id != 1", []string{"r", "length(region)"},
+			`[["NORTH",5]]`},
+		{"SELECT s.id, r.manager FROM me/sales s JOIN me/regions r ON s.region = r.region ORDER BY s.id", []string{"id", "manager"},
+			`[[1,"ana"],[2,"bo"],[3,"ana"],[5,"bo"]]`},
+		{"SELECT sales.id, manager FROM me/sales LEFT JOIN me/regions ON sales.region = regions.region AND manager != 'bo' ORDER BY sales.id", []string{"id", "manager"},
+			`[[1,"ana"],[2,null],[3,"ana"],[4,null],[5,null]]`},
+		{"SELECT r.manager, sum(s.amount) FROM me/sales s JOIN me/regions r ON r.region = s.region GROUP BY r.manager ORDER BY r.manager", []string{"manager", "sum(s.amount)"},
+			`[["ana",15],["bo",25]]`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.sql, func(t *testing.T) {
+			p, rows, _, err := run(t, c.sql, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.cols, p.Columns()); diff != "" {
+				t.Errorf("columns mismatch (-want +got):\n%s", diff)
+			}
+			data, err := json.Marshal(rows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.rows != string(data) {
+				t.Errorf("rows mismatch.\nwant: %s\ngot:  %s", c.rows, data)
+			}
+		})
+	}
+}
+
+func TestQueryTypes(t *testing.T) {
+	_, _, _, err := run(t, "SELECT id, region, count(*), avg(amount), id > 1, manager FROM me/sales JOIN me/regions ON sales.region = regions.region GROUP BY id, region", Options{})
+	if err == nil {
+		t.Fatal("expected an ambiguous column to error")
+	}
+	p, _, _, err := run(t, "SELECT id, sales.region, count(*), avg(amount), id > 1, coalesce(manager, 'none') FROM me/sales JOIN me/regions ON sales.region = regions.region GROUP BY id", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"integer", "string", "integer", "number", "boolean", "string"}
+	if diff := cmp.Diff(expect, p.Types()); diff != "" {
+		t.Errorf("types mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestQueryLimits(t *testing.T) {
+	_, rows, truncated, err := run(t, "SELECT id FROM me/sales", Options{MaxRows: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || !truncated {
+		t.Errorf("expected 2 truncated rows, got %d rows, truncated: %t", len(rows), truncated)
+	}
+
+	_, rows, truncated, err = run(t, "SELECT id FROM me/sales LIMIT 2", Options{MaxRows: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || truncated {
+		t.Errorf("expected 2 rows the query limited itself to, got %d rows, truncated: %t", len(rows), truncated)
+	}
+
+	_, _, _, err = run(t, "SELECT * FROM me/sales JOIN me/regions ON sales.region = regions.region", Options{MaxJoinRows: 2})
+	if !errors.Is(err, ErrJoinTooLarge) {
+		t.Errorf("expected a join past the size cap to fail with ErrJoinTooLarge, got: %v", err)
+	}
+
+	stmt, err := Parse("SELECT * FROM me/sales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewPlan(stmt, testTables(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Run(ctx, func([]interface{}) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled query to stop, got: %v", err)
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	cases := []struct {
+		sql, err string
+	}{
+		{"SELECT", `parsing query: expected an expression, got end of query at position 6`},
+		{"SELECT id FROM", `parsing query: expected a dataset reference, got end of query at position 14`},
+		{"SELECT id FROM me/sales WHERE 'open", `unterminated quote at position 30`},
+		{"SELECT nope(id) FROM me/sales", `parsing query: unknown function "nope" at position 7`},
+		{"SELECT id FROM me/sales LIMIT -1", `parsing query: expected a non-negative integer, got "-" at position 30`},
+		{"SELECT id FROM me/missing", `unknown table: me/missing`},
+		{"SELECT nope FROM me/sales", `unknown column "nope"`},
+		{"SELECT region FROM me/sales JOIN me/regions ON sales.region = regions.region", `column "region" is ambiguous, qualify it with a table name`},
+		{"SELECT id FROM me/sales WHERE count(*) > 1", `aggregate count(*) isn't allowed in WHERE`},
+		{"SELECT sum(count(id)) FROM me/sales", `aggregate count(id) can't be nested in sum(count(id))`},
+		{"SELECT id FROM me/sales ORDER BY 3", `ORDER BY position 3 is out of range`},
+		{"SELECT id FROM me/sales WHERE region", `condition must be a boolean, got string "north"`},
+	}
+	for _, c := range cases {
+		_, _, _, err := run(t, c.sql, Options{})
+		if err == nil {
+			t.Errorf("%q: expected error, got nil", c.sql)
+			continue
+		}
+		if c.err != err.Error() {
+			t.Errorf("%q: error mismatch.\nwant: %s\ngot:  %s", c.sql, c.err, err)
+		}
+	}
+}
+
+func TestStatementTables(t *testing.T) {
+	stmt, err := Parse(`SELECT * FROM me/sales@/ipfs/QmFoo JOIN "peer/regions" r ON 1 = 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(stmt.Tables()); got != "[me/sales@/ipfs/QmFoo peer/regions]" {
+		t.Errorf("tables mismatch, got: %s", got)
+	}
+}
@@ -33,11 +33,18 @@ func SetPublishStatus(r repo.Repo, ref *reporef.DatasetRef, published bool) erro
 }
 
 // ToDatasetRef parses the dataset ref and returns it, allowing datasets with no history only
-// if FSI is enabled.
+// if FSI is enabled. References that end in a date, like me/dataset@2023-06-01, resolve to the
+// version committed at or before that date.
 func ToDatasetRef(path string, r repo.Repo, allowFSI bool) (*reporef.DatasetRef, error) {
 	if path == "" {
 		return nil, repo.ErrEmptyRef
 	}
+	if refstr, t, ok := dsref.ParseTimeRef(path); ok {
+		if allowFSI {
+			return nil, fmt.Errorf("cannot select a version of a working directory by date")
+		}
+		return ResolveAtTime(context.TODO(), r, refstr, t)
+	}
 	ref, err := repo.ParseDatasetRef(path)
 	if err != nil {
 		return nil, fmt.Errorf("'%s' is not a valid dataset reference", path)
@@ -51,6 +58,29 @@ func ToDatasetRef(path string, r repo.Repo, allowFSI bool) (*reporef.DatasetRef,
 	return &ref, nil
 }
 
+// ResolveAtTime resolves a reference to the latest version of a dataset
+// committed at or before t, searching the dataset's commit history
+func ResolveAtTime(ctx context.Context, r repo.Repo, refstr string, t time.Time) (*reporef.DatasetRef, error) {
+	ref, err := repo.ParseDatasetRef(refstr)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a valid dataset reference", refstr)
+	}
+	if ref.Path != "" {
+		return nil, fmt.Errorf("can't select a version by date from a reference to a specific version")
+	}
+	if err = repo.CanonicalizeDatasetRef(r, &ref); err != nil {
+		return nil, err
+	}
+	v, err := RevVersion(ctx, r, ref, &dsref.Rev{Field: "ds", Time: t})
+	if err != nil {
+		return nil, err
+	}
+	ref.Path = v.Path
+	// the working directory holds the latest version, not the one selected
+	ref.FSIPath = ""
+	return &ref, nil
+}
+
 // ReplaceRefIfMoreRecent replaces the given ref in the ref store, if
 // it is more recent then the ref currently in the refstore
 func ReplaceRefIfMoreRecent(r repo.Repo, prev, curr *reporef.DatasetRef) error {
@@ -134,8 +164,8 @@ func ModifyDatasetRef(ctx context.Context, r repo.Repo, curr, next dsref.Ref) (*
 		err := repo.CanonicalizeDatasetRef(r, &nextRef)
 		if err == nil {
 			// successful canonicalization on rename is an error
-			return nil, fmt.Errorf("dataset '%s/%s' already exists", nextRef.Peername, nextRef.Name)
-		} else if err != repo.ErrNotFound {
+			return nil, fmt.Errorf("dataset '%s/%s' %w", nextRef.Peername, nextRef.Name, repo.ErrAlreadyExists)
+		} else if !repo.IsNotFound(err) {
 			log.Debug(err.Error())
 			return nil, fmt.Errorf("error with new reference: %s", err.Error())
 		}
@@ -1,10 +1,13 @@
 package base
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/profile"
 	reporef "github.com/qri-io/qri/repo/ref"
@@ -234,3 +237,58 @@ func TestReplaceRefIfMoreRecent(t *testing.T) {
 		}
 	}
 }
+
+func TestResolveAtTime(t *testing.T) {
+	prev := dsfs.Timestamp
+	defer func() { dsfs.Timestamp = prev }()
+	day := 0
+	dsfs.Timestamp = func() time.Time {
+		day++
+		return time.Date(2019, 6, day, 12, 0, 0, 0, time.UTC)
+	}
+
+	ctx := context.Background()
+	r := newTestRepo(t)
+	v1 := addCitiesDataset(t, r)
+	v2 := updateCitiesDataset(t, r, "second")
+	v3 := updateCitiesDataset(t, r, "third")
+
+	cases := []struct {
+		t    time.Time
+		path string
+		err  string
+	}{
+		{time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC), v1.Path, ""},
+		{time.Date(2019, 6, 2, 18, 0, 0, 0, time.UTC), v2.Path, ""},
+		{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), v3.Path, ""},
+		{time.Date(2019, 5, 31, 0, 0, 0, 0, time.UTC), "", "out of range"},
+	}
+	for _, c := range cases {
+		ref, err := ResolveAtTime(ctx, r, "me/cities", c.t)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("%s: expected error containing %q, got: %v", c.t, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.t, err)
+			continue
+		}
+		if ref.Path != c.path {
+			t.Errorf("%s: expected path %s, got: %s", c.t, c.path, ref.Path)
+		}
+	}
+
+	if _, err := ResolveAtTime(ctx, r, "me/cities@"+v2.Path, time.Now()); err == nil {
+		t.Error("expected selecting a date from a versioned reference to error")
+	}
+
+	ref, err := ToDatasetRef("me/cities@2019-06-02", r, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Path != v2.Path {
+		t.Errorf("expected dated reference to resolve to %s, got: %s", v2.Path, ref.Path)
+	}
+}
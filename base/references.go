@@ -0,0 +1,190 @@
+package base
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/jsonschema"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/repo"
+)
+
+func init() {
+	// "references" declares a column is a foreign key. Like "unit" it only
+	// describes the column, ValidateReferences does the checking
+	jsonschema.RegisterValidator("references", newReferencesKeyword)
+}
+
+// referencesKeyword is the "references" schema keyword
+type referencesKeyword map[string]interface{}
+
+func newReferencesKeyword() jsonschema.Validator {
+	return new(referencesKeyword)
+}
+
+// Validate implements the jsonschema.Validator interface
+func (referencesKeyword) Validate(propPath string, data interface{}, errs *[]jsonschema.ValError) {}
+
+// Reference is a foreign key: every value of a column must appear in the key
+// column of another dataset. References are declared in a column schema:
+//
+//	{"title": "customer_id", "references": {"dataset": "me/customers", "key": "id"}}
+type Reference struct {
+	// Column is the referencing column
+	Column string `json:"column"`
+	// Dataset is a reference to the dataset holding the key column. Without a
+	// version path the latest version is used
+	Dataset string `json:"dataset"`
+	// Key is the title or property name of the referenced column
+	Key string `json:"key"`
+}
+
+// String formats a reference for display
+func (ref Reference) String() string {
+	return fmt.Sprintf("%s -> %s.%s", ref.Column, ref.Dataset, ref.Key)
+}
+
+// SchemaReferences lists the references declared by columns of a body schema,
+// in column order
+func SchemaReferences(schema map[string]interface{}) ([]Reference, error) {
+	var refs []Reference
+	for _, c := range Columns(schema) {
+		v, ok := c.Schema["references"]
+		if !ok {
+			continue
+		}
+		if c.Name == "" {
+			return nil, fmt.Errorf("column %d declares a reference but has no title", c.Index)
+		}
+		dsref, key, err := parseReference(v)
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %s", c.Name, err)
+		}
+		refs = append(refs, Reference{Column: c.Name, Dataset: dsref, Key: key})
+	}
+	return refs, nil
+}
+
+// parseReference reads the value of a "references" keyword
+func parseReference(v interface{}) (dsref, key string, err error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return "", "", fmt.Errorf("references must be an object with dataset & key fields")
+	}
+	dsref, _ = m["dataset"].(string)
+	key, _ = m["key"].(string)
+	if dsref == "" || key == "" {
+		return "", "", fmt.Errorf("references requires both a dataset & a key")
+	}
+	if _, err = repo.ParseDatasetRef(dsref); err != nil {
+		return "", "", fmt.Errorf("'%s' is not a valid dataset reference", dsref)
+	}
+	return dsref, key, nil
+}
+
+// ValidateReferences checks referential integrity of a dataset body, loading
+// every referenced dataset & confirming each referencing value appears in its
+// key column. Null & missing values aren't checked. Failures are returned as a
+// RuleViolations error, the body file is replaced with an unread copy
+func ValidateReferences(ctx context.Context, r repo.Repo, ds *dataset.Dataset) error {
+	if ds.Structure == nil {
+		return nil
+	}
+	refs, err := SchemaReferences(ds.Structure.Schema)
+	if err != nil {
+		return err
+	}
+
+	rules := make([]ValidationRule, 0, len(refs))
+	for _, ref := range refs {
+		keys, err := referencedKeys(ctx, r, ref)
+		if err != nil {
+			return fmt.Errorf("column %q references %s: %s", ref.Column, ref.Dataset, err)
+		}
+		rules = append(rules, &referenceRule{ref: ref, keys: keys})
+	}
+	return ValidateRules(ctx, ds, rules)
+}
+
+// referencedKeys loads the set of values in the key column of a referenced
+// dataset
+func referencedKeys(ctx context.Context, r repo.Repo, ref Reference) (map[string]bool, error) {
+	dsr, err := repo.ParseDatasetRef(ref.Dataset)
+	if err != nil {
+		return nil, err
+	}
+	if err = repo.CanonicalizeDatasetRef(r, &dsr); err != nil {
+		return nil, err
+	}
+	ds, err := dsfs.LoadDataset(ctx, r.Store(), dsr.Path)
+	if err != nil {
+		return nil, fmt.Errorf("loading dataset: %s", err)
+	}
+	if ds.Structure == nil {
+		return nil, fmt.Errorf("dataset has no structure")
+	}
+	if _, ok := column(ds.Structure.Schema, ref.Key); !ok {
+		return nil, fmt.Errorf("column %q not found", ref.Key)
+	}
+
+	body, err := dsfs.LoadBody(ctx, r.Store(), ds)
+	if err != nil {
+		return nil, fmt.Errorf("loading body: %s", err)
+	}
+	defer body.Close()
+	rdr, err := dsio.NewEntryReader(ds.Structure, body)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]bool{}
+	for {
+		ent, err := rdr.ReadEntry()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			return nil, fmt.Errorf("reading body: %s", err)
+		}
+		if val, ok := entryField(ds.Structure, ent, ref.Key); ok && val != nil {
+			keys[referenceKey(val)] = true
+		}
+	}
+	return keys, nil
+}
+
+// referenceKey normalizes a value for comparison across body formats, which
+// decode numbers as different types
+func referenceKey(v interface{}) string {
+	if f, ok := toFloat(v); ok {
+		v = f
+	}
+	return fmt.Sprintf("%#v", v)
+}
+
+// referenceRule requires every value of a column appear in a set of keys
+type referenceRule struct {
+	ref  Reference
+	keys map[string]bool
+}
+
+// Name implements ValidationRule
+func (r *referenceRule) Name() string { return fmt.Sprintf("references(%s)", r.ref) }
+
+// CheckEntry implements ValidationRule
+func (r *referenceRule) CheckEntry(st *dataset.Structure, row int, ent dsio.Entry) []RuleViolation {
+	val, ok := entryField(st, ent, r.ref.Column)
+	if !ok || val == nil || r.keys[referenceKey(val)] {
+		return nil
+	}
+	return []RuleViolation{{
+		Rule:    r.Name(),
+		Row:     row,
+		Message: fmt.Sprintf("value %v not found in %s column %q", val, r.ref.Dataset, r.ref.Key),
+	}}
+}
+
+// Finish implements ValidationRule
+func (r *referenceRule) Finish() []RuleViolation { return nil }
@@ -0,0 +1,105 @@
+package base
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+func TestValidateReferences(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	addCitiesDataset(t, r)
+
+	body := `[
+  {"id": 1, "city": "toronto"},
+  {"id": 2, "city": "springfield"},
+  {"id": 3, "city": null},
+  {"id": 4, "city": "chicago"}
+]`
+	ds := &dataset.Dataset{
+		Structure: &dataset.Structure{
+			Format: "json",
+			Schema: map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id": map[string]interface{}{"type": "integer"},
+						"city": map[string]interface{}{
+							"type":       []interface{}{"string", "null"},
+							"references": map[string]interface{}{"dataset": "me/cities", "key": "city"},
+						},
+					},
+				},
+			},
+		},
+	}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(body)))
+
+	err := ValidateReferences(ctx, r, ds)
+	violations, ok := err.(RuleViolations)
+	if !ok {
+		t.Fatalf("expected RuleViolations error, got: %v", err)
+	}
+	if len(violations) != 1 {
+		t.Fatalf("expected 1 violation, got: %v", violations)
+	}
+	expect := RuleViolation{
+		Rule:    "references(city -> me/cities.city)",
+		Row:     1,
+		Message: `value springfield not found in me/cities column "city"`,
+	}
+	if violations[0] != expect {
+		t.Errorf("violation mismatch. expected: %v, got: %v", expect, violations[0])
+	}
+
+	data, err := ioutil.ReadAll(ds.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != body {
+		t.Errorf("body wasn't restored after validation. got: %q", string(data))
+	}
+
+	// the schema must still be usable for JSON schema validation
+	if _, err := ds.Structure.JSONSchema(); err != nil {
+		t.Errorf("parsing schema with references: %s", err)
+	}
+
+	bad := []map[string]interface{}{
+		{"dataset": "me/not_a_dataset", "key": "city"},
+		{"dataset": "me/cities", "key": "not_a_column"},
+	}
+	for i, ref := range bad {
+		ds.Structure.Schema["items"].(map[string]interface{})["properties"].(map[string]interface{})["city"].(map[string]interface{})["references"] = ref
+		if err := ValidateReferences(ctx, r, ds); err == nil {
+			t.Errorf("case %d: expected error, got nil", i)
+		} else if _, ok := err.(RuleViolations); ok {
+			t.Errorf("case %d: expected error loading reference, got violations: %s", i, err)
+		}
+	}
+}
+
+func TestSchemaReferences(t *testing.T) {
+	sch := columnsSchema(
+		map[string]interface{}{"title": "order", "type": "integer"},
+		map[string]interface{}{"title": "customer", "type": "integer", "references": map[string]interface{}{"dataset": "me/customers", "key": "id"}},
+	)
+	refs, err := SchemaReferences(sch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Reference{{Column: "customer", Dataset: "me/customers", Key: "id"}}
+	if len(refs) != 1 || refs[0] != expect[0] {
+		t.Errorf("references mismatch. expected: %v, got: %v", expect, refs)
+	}
+
+	sch = columnsSchema(map[string]interface{}{"title": "customer", "references": "me/customers"})
+	if _, err := SchemaReferences(sch); err == nil {
+		t.Error("expected error for malformed reference, got nil")
+	}
+}
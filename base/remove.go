@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/logbook"
@@ -90,6 +91,7 @@ func RemoveNVersionsFromStore(ctx context.Context, r repo.Repo, curr dsref.Ref,
 	defer timeoutCancel()
 
 	i := n
+	var removed []*dataset.Dataset
 
 	for i != 0 {
 		// Decrement our counter. If counter was -1, this loop will continue forever, until a
@@ -105,6 +107,7 @@ func RemoveNVersionsFromStore(ctx context.Context, r repo.Repo, curr dsref.Ref,
 		if err = UnpinDataset(ctx, r, datasetRef); err != nil && !strings.Contains(err.Error(), "not pinned") {
 			return curr, err
 		}
+		removed = append(removed, ds)
 		// if no previous path, break
 		if ds.PreviousPath == "" {
 			break
@@ -134,6 +137,8 @@ func RemoveNVersionsFromStore(ctx context.Context, r repo.Repo, curr dsref.Ref,
 		ds = next
 	}
 
+	accountRemovedVersions(ctx, r, removed, ds)
+
 	err = r.Logbook().WriteVersionDelete(ctx, curr, n)
 	if err == logbook.ErrNoLogbook {
 		err = nil
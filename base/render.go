@@ -2,7 +2,10 @@ package base
 
 import (
 	"context"
+	"fmt"
+	"html/template"
 	"io/ioutil"
+	"sort"
 	"strings"
 
 	"github.com/qri-io/dataset"
@@ -43,10 +46,37 @@ func init() {
   {{ if ds.structure }}
   <p class="stat"><label>data format:</label>{{ ds.structure.format }}</p>
   <p class="stat"><label>entry count:</label>{{ ds.structure.entries }}</p>
-  <p class="stat"><label>errors:</label>{{ ds.structure.errCount }}</p>
+  <p class="stat"><label>errors:</label>{{ ds.structure.errCount }}</p>{{ template "columns" . }}
   {{ end }}
 </section>`
 
+	columns := `{{ with ds.structure.schema }}{{ with .items }}
+  {{- $described := false }}
+  {{- if isType .items "array" }}{{ range .items }}{{ if or .description .unit .enum }}{{ $described = true }}{{ end }}{{ end }}
+  {{- else if isType .properties "object" }}{{ range .properties }}{{ if or .description .unit .enum }}{{ $described = true }}{{ end }}{{ end }}{{ end }}
+  {{- if $described }}
+  <style>
+    .columns { width: 100%; text-align: left; border-collapse: collapse; }
+    .columns td, .columns th { padding: 4px 8px 4px 0; vertical-align: top; }
+  </style>
+  {{- if isType .items "array" }}
+  <table class="columns">
+    <tr><th>column</th><th>type</th><th>description</th></tr>
+    {{ range .items }}<tr><td>{{ .title }}{{ if .unit }} <small>({{ .unit }})</small>{{ end }}</td>{{ template "columnDetails" . }}</tr>
+    {{ end }}
+  </table>
+  {{- else }}
+  <table class="columns">
+    <tr><th>column</th><th>type</th><th>description</th></tr>
+    {{ range $key, $col := .properties }}<tr><td>{{ $key }}{{ if $col.unit }} <small>({{ $col.unit }})</small>{{ end }}</td>{{ template "columnDetails" $col }}</tr>
+    {{ end }}
+  </table>
+  {{- end }}
+  {{- end }}
+{{- end }}{{ end }}`
+
+	columnDetails := `<td>{{ .type }}</td><td>{{ .description }}{{ if .enum }} <small>one of: {{ range $i, $v := .enum }}{{ if $i }}, {{ end }}{{ $v }}{{ end }}</small>{{ end }}</td>`
+
 	citation := `<footer>
   <div class="content">
     <p class="stat"><label>commit title:</label>{{ ds.commit.title }}</p>
@@ -59,10 +89,12 @@ func init() {
 </footer>`
 
 	dsviz.PredefinedHTMLTemplates = map[string]string{
-		"stylesheet": stylesheet,
-		"header":     header,
-		"summary":    summary,
-		"citation":   citation,
+		"stylesheet":    stylesheet,
+		"header":        header,
+		"summary":       summary,
+		"columns":       columns,
+		"columnDetails": columnDetails,
+		"citation":      citation,
 	}
 }
 
@@ -84,7 +116,9 @@ func MaybeAddDefaultViz(ds *dataset.Dataset) {
 // Render uses go's html/template package to generate html documents from an
 // input dataset. It's API has been adjusted to use lowerCamelCase instead of
 // UpperCamelCase naming conventions
-func Render(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, tmplData []byte) ([]byte, error) {
+// A non-empty vizName renders one of the version's named viz templates
+// instead of its viz script, see dsfs.VizTemplates
+func Render(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, tmplData []byte, vizName string) ([]byte, error) {
 	/*
 		outline: html viz
 			HTML template gives users a number of helper template functions, along
@@ -119,6 +153,9 @@ func Render(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, tmplData [
 					"title" info about a dataset
 				{{ block "summary" . }}{{ end }}
 					html block of basic dataset info
+				{{ block "columns" . }}{{ end }}
+					table of body columns with their descriptions, units & allowed
+					values. included in summary when any column is described
 				{{ block "citation" . }}{{ end }}
 					html citation block, uses styles defined in stylesheet
 
@@ -143,9 +180,23 @@ func Render(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, tmplData [
 	// TODO(dlong): Deprecated, this should be removed.
 	MaybeAddDefaultViz(ds)
 
+	if tmplData != nil && vizName != "" {
+		return nil, fmt.Errorf("can't render both a template & a named viz template")
+	}
 	if tmplData != nil {
 		ds.Viz.SetScriptFile(qfs.NewMemfileBytes(tmplName, tmplData))
 	}
+	if vizName != "" {
+		templates, err := dsfs.LoadVizTemplates(ctx, store, ref.Path)
+		if err != nil {
+			return nil, err
+		}
+		script, err := lookupVizTemplate(templates, vizName)
+		if err != nil {
+			return nil, err
+		}
+		ds.Viz.SetScriptFile(qfs.NewMemfileBytes(vizName, []byte(script)))
+	}
 
 	data, err := dsviz.Render(ds)
 	if err != nil {
@@ -155,6 +206,74 @@ func Render(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, tmplData [
 	return ioutil.ReadAll(data)
 }
 
+// lookupVizTemplate gets a named viz template, listing the available
+// templates if there's no template by that name
+func lookupVizTemplate(templates dsfs.VizTemplates, name string) (string, error) {
+	if script, ok := templates[name]; ok {
+		return script, nil
+	}
+	if len(templates) == 0 {
+		return "", fmt.Errorf("viz template %q not found, the dataset doesn't have any", name)
+	}
+	return "", fmt.Errorf("viz template %q not found, available templates: %s", name, strings.Join(VizTemplateNames(templates), ", "))
+}
+
+// VizTemplateNames lists the names of viz templates in sorted order
+func VizTemplateNames(templates dsfs.VizTemplates) []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MergeVizTemplates applies changes to a collection of viz templates,
+// returning a new collection. An empty script removes a template
+func MergeVizTemplates(prev dsfs.VizTemplates, changes map[string]string) dsfs.VizTemplates {
+	merged := dsfs.VizTemplates{}
+	for name, script := range prev {
+		merged[name] = script
+	}
+	for name, script := range changes {
+		if script == "" {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = script
+	}
+	return merged
+}
+
+// ValidateVizTemplates checks viz templates have names & parse as html
+// templates
+func ValidateVizTemplates(templates map[string]string) error {
+	for _, name := range VizTemplateNames(templates) {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("viz templates need a name")
+		}
+		script := templates[name]
+		if script == "" {
+			continue
+		}
+		if _, err := template.New(name).Funcs(vizStubFuncs).Parse(script); err != nil {
+			return fmt.Errorf("viz template %q: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// vizStubFuncs stand in for the functions dsviz provides templates, parsing
+// only checks a function exists
+var vizStubFuncs = template.FuncMap{
+	"ds":             func() interface{} { return nil },
+	"bodyEntries":    func(offset, limit int) interface{} { return nil },
+	"allBodyEntries": func() interface{} { return nil },
+	"filesize":       func(n float64) string { return "" },
+	"isType":         func(in interface{}, eq string) bool { return false },
+	"title":          func() string { return "" },
+}
+
 // DefaultTemplate is the template that render will fall back to should no
 // template be available
 var DefaultTemplate = `<!DOCTYPE html>
@@ -16,6 +16,10 @@ func RenderReadme(ctx context.Context, file qfs.File) (string, error) {
 		return "", err
 	}
 	unsafe := blackfriday.Run(data)
-	htmlBytes := bluemonday.UGCPolicy().SanitizeBytes(unsafe)
-	return string(htmlBytes), nil
+	return string(sanitizeHTML(unsafe)), nil
+}
+
+// sanitizeHTML strips scripts & other unsafe markup from user-provided HTML
+func sanitizeHTML(unsafe []byte) []byte {
+	return bluemonday.UGCPolicy().SanitizeBytes(unsafe)
 }
@@ -2,7 +2,14 @@ package base
 
 import (
 	"context"
+	"io/ioutil"
+	"strings"
 	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsviz"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
 )
 
 func TestRender(t *testing.T) {
@@ -11,9 +18,118 @@ func TestRender(t *testing.T) {
 	r := newTestRepo(t)
 	ref := addCitiesDataset(t, r)
 
-	_, err := Render(ctx, r, ref, nil)
+	_, err := Render(ctx, r, ref, nil, "")
 	if err != nil {
 		t.Error(err.Error())
 	}
 
 }
+
+func TestDefaultVizColumns(t *testing.T) {
+	cases := []struct {
+		description string
+		items       map[string]interface{}
+		body        string
+	}{
+		{"array rows", map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "city", "type": "string", "description": "name of the city"},
+				map[string]interface{}{"title": "area", "type": "number", "unit": "km²"},
+				map[string]interface{}{"title": "size", "type": "string", "enum": []interface{}{"big", "small"}},
+			},
+		}, `[["toronto",630.2,"big"]]`},
+		{"object rows", map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"city": map[string]interface{}{"type": "string", "description": "name of the city"},
+				"area": map[string]interface{}{"type": "number", "unit": "km²"},
+				"size": map[string]interface{}{"type": "string", "enum": []interface{}{"big", "small"}},
+			},
+		}, `[{"city":"toronto","area":630.2,"size":"big"}]`},
+	}
+
+	for _, c := range cases {
+		ds := &dataset.Dataset{
+			Peername: "me",
+			Name:     "cities",
+			Structure: &dataset.Structure{
+				Format: "json",
+				Schema: map[string]interface{}{"type": "array", "items": c.items},
+			},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(c.body)))
+		MaybeAddDefaultViz(ds)
+
+		f, err := dsviz.Render(ds)
+		if err != nil {
+			t.Fatalf("case %q: %s", c.description, err)
+		}
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expect := range []string{"<td>city", "name of the city", "(km²)", "one of: big, small"} {
+			if !strings.Contains(string(data), expect) {
+				t.Errorf("case %q: expected default viz to contain %q", c.description, expect)
+			}
+		}
+	}
+
+	// columns without descriptions, units or enumerations aren't listed
+	ds := &dataset.Dataset{
+		Peername: "me",
+		Name:     "cities",
+		Structure: &dataset.Structure{
+			Format: "json",
+			Schema: map[string]interface{}{"type": "array", "items": map[string]interface{}{
+				"type":  "array",
+				"items": []interface{}{map[string]interface{}{"title": "city", "type": "string"}},
+			}},
+		},
+	}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[["toronto"]]`)))
+	MaybeAddDefaultViz(ds)
+	f, err := dsviz.Render(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `class="columns"`) {
+		t.Error("expected undescribed columns to be left out of the default viz")
+	}
+}
+
+func TestVizTemplates(t *testing.T) {
+	prev := dsfs.VizTemplates{"map": "<div>map</div>", "table": "<table></table>"}
+	merged := MergeVizTemplates(prev, map[string]string{"map": "", "title": "<h1>{{ title }}</h1>"})
+	if names := strings.Join(VizTemplateNames(merged), ","); names != "table,title" {
+		t.Errorf("expected merged templates [table title], got: %s", names)
+	}
+	if len(prev) != 2 {
+		t.Error("expected merging not to modify the previous templates")
+	}
+
+	if script, err := lookupVizTemplate(merged, "title"); err != nil || script != "<h1>{{ title }}</h1>" {
+		t.Errorf("expected title template, got: %q, %v", script, err)
+	}
+	if _, err := lookupVizTemplate(merged, "map"); err == nil || !strings.Contains(err.Error(), "available templates: table, title") {
+		t.Errorf("expected a missing template error to list available templates, got: %v", err)
+	}
+	if _, err := lookupVizTemplate(nil, "map"); err == nil {
+		t.Error("expected looking up a template without any templates to error")
+	}
+
+	if err := ValidateVizTemplates(map[string]string{"table": "{{ range allBodyEntries }}{{ . }}{{ end }}", "gone": ""}); err != nil {
+		t.Errorf("expected valid templates, got: %s", err)
+	}
+	if err := ValidateVizTemplates(map[string]string{"bad": "{{ .Unclosed"}); err == nil {
+		t.Error("expected an unparsable template to error")
+	}
+	if err := ValidateVizTemplates(map[string]string{" ": "<div></div>"}); err == nil {
+		t.Error("expected a template without a name to error")
+	}
+}
@@ -25,6 +25,10 @@ func Recall(ctx context.Context, r repo.Repo, str string, ref reporef.DatasetRef
 		return nil, err
 	}
 
+	if err := ResolveRevs(ctx, r, ref, revs); err != nil {
+		return nil, err
+	}
+
 	res, err := LoadRevs(ctx, r, ref, revs)
 	if err != nil {
 		return nil, err
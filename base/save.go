@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/ioes"
@@ -27,10 +28,50 @@ type SaveDatasetSwitches struct {
 	Force               bool
 	ShouldRender        bool
 	NewName             bool
+
+	// ValidationRules are checked against the body before committing, any
+	// rule violation fails the save
+	ValidationRules []ValidationRule
+	// EnforceReferences fails the save if a column declaring a reference has
+	// values missing from the referenced dataset. see ValidateReferences
+	EnforceReferences bool
+	// CommitTimestamp overrides the commit timestamp of the saved version,
+	// which defaults to the current time
+	CommitTimestamp time.Time
+	// MaxBodySize is the largest body in bytes the save accepts, after
+	// decompression. Larger bodies fail with ErrBodyTooLarge. 0 means no limit
+	MaxBodySize int64
+	// RejectBreakingSchema fails the save with ErrBreakingSchemaChange if the
+	// body schema changes in a way that isn't backward compatible with the
+	// previous version. Force saves anyway. see CompareSchemas
+	RejectBreakingSchema bool
+	// ExternalBody is a URI to read the body from instead of storing it, see
+	// dsfs.WithExternalBody. Saves without a body keep the previous version's
+	// external body, unless Replace is set
+	ExternalBody string
+	// VizTemplates are named viz scripts to store with the version, see
+	// dsfs.VizTemplates. They're merged over the previous version's templates,
+	// an empty script removes a template. Replace drops previous templates
+	VizTemplates map[string]string
+	// PreSaveHooks are run in order before a version is written, after changes
+	// are applied to the previous version & validated. An error from any hook
+	// vetoes the save
+	PreSaveHooks []PreSaveHook
+	// PostSaveHooks are run in order with the reference of a saved version.
+	// Dry runs don't call them
+	PostSaveHooks []PostSaveHook
 }
 
-// SaveDataset initializes a dataset from a dataset pointer and data file
-func SaveDataset(ctx context.Context, r repo.Repo, str ioes.IOStreams, changes *dataset.Dataset, secrets map[string]string, scriptOut io.Writer, sw SaveDatasetSwitches) (ref reporef.DatasetRef, err error) {
+// PreSaveHook inspects a dataset about to be saved. The dataset is the
+// complete version that will be written, returning an error stops the save
+type PreSaveHook func(ctx context.Context, ds *dataset.Dataset) error
+
+// PostSaveHook is called with the reference of a newly saved version
+type PostSaveHook func(ctx context.Context, ref reporef.DatasetRef)
+
+// SaveDataset initializes a dataset from a dataset pointer and data file,
+// returning warnings about problems that didn't stop the save
+func SaveDataset(ctx context.Context, r repo.Repo, str ioes.IOStreams, changes *dataset.Dataset, secrets map[string]string, scriptOut io.Writer, sw SaveDatasetSwitches) (ref reporef.DatasetRef, warnings []Warning, err error) {
 	var (
 		prevPath string
 		pro      *profile.Profile
@@ -44,6 +85,11 @@ func SaveDataset(ctx context.Context, r repo.Repo, str ioes.IOStreams, changes *
 		changes.Peername = "me"
 	}
 
+	// saving through an alias adds a version to the dataset the alias names
+	if target, ok := repo.AliasTarget(r, changes.Peername, changes.Name); ok {
+		changes.Name = target
+	}
+
 	isInferredName := MaybeInferName(changes)
 
 	prev, mutable, prevPath, err := PrepareDatasetSave(ctx, r, changes.Peername, changes.Name)
@@ -65,12 +111,12 @@ func SaveDataset(ctx context.Context, r repo.Repo, str ioes.IOStreams, changes *
 			// Name was explicitly given, with the --new flag, but the name is already in use.
 			// This is an error.
 			// TODO(dlong): Add a test for this case.
-			return ref, fmt.Errorf("dataset name has a previous version, cannot make new dataset")
+			return ref, nil, fmt.Errorf("dataset name has a previous version, cannot make new dataset")
 		} else if isInferredName {
 			// Name was inferred, and has previous version. Unclear if the user meant to create
 			// a brand new dataset or if they wanted to add a new version to the existing dataset.
 			// Raise an error recommending one of these course of actions.
-			return ref, fmt.Errorf("inferred dataset name already exists. To add a new commit to this dataset, run save again with the dataset reference. To create a new dataset, use --new flag")
+			return ref, nil, fmt.Errorf("inferred dataset name %w. To add a new commit to this dataset, run save again with the dataset reference. To create a new dataset, use --new flag", repo.ErrAlreadyExists)
 		}
 	}
 
@@ -78,6 +124,20 @@ func SaveDataset(ctx context.Context, r repo.Repo, str ioes.IOStreams, changes *
 		return
 	}
 
+	if err = ValidateVizTemplates(sw.VizTemplates); err != nil {
+		return
+	}
+	var prevTemplates dsfs.VizTemplates
+	if prevPath != "" {
+		if prevTemplates, err = dsfs.LoadVizTemplates(ctx, r.Store(), prevPath); err != nil {
+			return
+		}
+	}
+	templates := MergeVizTemplates(prevTemplates, sw.VizTemplates)
+	if sw.Replace {
+		templates = MergeVizTemplates(nil, sw.VizTemplates)
+	}
+
 	if sw.DryRun {
 		str.PrintErr("🏃🏽‍♀️ dry run\n")
 
@@ -113,13 +173,111 @@ func SaveDataset(ctx context.Context, r repo.Repo, str ioes.IOStreams, changes *
 		return
 	}
 
+	// readers of the body can swallow the error of a read past the size limit,
+	// report it whatever error the save ends with
+	var limit *BodySizeLimit
+	defer func() {
+		if err != nil && limit != nil && limit.Exceeded() {
+			err = limit.Err()
+		}
+	}()
+
+	externalBody := sw.ExternalBody
+	if externalBody == "" && !sw.Replace {
+		externalBody = dsfs.ExternalBodyURI(prev)
+	}
+	if externalBody != "" {
+		if err = dsfs.ValidateExternalBody(externalBody); err != nil {
+			return
+		}
+		if changes.BodyFile() != nil {
+			err = fmt.Errorf("can't save a body for a dataset with an external body, the body is read from %s", externalBody)
+			return
+		}
+		// an external body is read from its URI on every save, picking up any
+		// changes to the data it holds. It's streamed as-is, without encoding
+		// conversion
+		var body qfs.File
+		if body, err = dsfs.OpenExternalBody(ctx, externalBody, dsfs.MaxExternalBodySize); err != nil {
+			return
+		}
+		if sw.MaxBodySize > 0 {
+			body, limit = limitBodyFile(body, sw.MaxBodySize)
+		}
+		changes.SetBodyFile(body)
+	} else if changes.BodyFile() != nil {
+		// bodies can be given gzipped, compression is applied when a version is
+		// stored, as structure.compression specifies
+		var body qfs.File
+		if body, err = dsfs.DecompressBody(changes.BodyFile(), nil); err != nil {
+			return
+		}
+		if sw.MaxBodySize > 0 {
+			body, limit = limitBodyFile(body, sw.MaxBodySize)
+		}
+		changes.SetBodyFile(body)
+
+		// parquet bodies are converted to the format the dataset is stored in,
+		// json for new datasets, with a schema from the parquet columns
+		if IsParquetBody(body.FileName()) {
+			target := changes.Structure
+			if (target == nil || target.Format == "") && !sw.Replace {
+				target = prev.Structure
+			}
+			var st *dataset.Structure
+			if body, st, err = ParquetBodyFile(body, target); err != nil {
+				return
+			}
+			changes.SetBodyFile(body)
+			if changes.Structure == nil {
+				changes.Structure = &dataset.Structure{}
+			}
+			changes.Structure.Format = st.Format
+			changes.Structure.FormatConfig = st.FormatConfig
+			if changes.Structure.Schema == nil {
+				changes.Structure.Schema = st.Schema
+			}
+			warnings = append(warnings, Warning{
+				Code:      WarnConvertedFormat,
+				Message:   fmt.Sprintf("converted body from %s to %s", ParquetFormat, st.Format),
+				Component: "body",
+			})
+		}
+
+		// bodies are stored as UTF-8, convert text in any other encoding before
+		// it's read for structure inference
+		st := changes.Structure
+		if st == nil && !sw.Replace {
+			st = prev.Structure
+		}
+		var (
+			f   qfs.File
+			enc string
+		)
+		if f, enc, err = NormalizeBodyEncoding(changes.BodyFile(), st); err != nil {
+			return
+		}
+		changes.SetBodyFile(f)
+		if enc != "" {
+			warnings = append(warnings, Warning{
+				Code:      WarnConvertedEncoding,
+				Message:   fmt.Sprintf("converted body from %s to UTF-8", enc),
+				Component: "body",
+			})
+		}
+	}
+
 	if changes.BodyFile() != nil && prev.Structure != nil && changes.Structure != nil && prev.Structure.Format != changes.Structure.Format {
 		if sw.ConvertFormatToPrev {
-			var f qfs.File
-			f, err = ConvertBodyFormat(changes.BodyFile(), changes.Structure, prev.Structure)
+			var (
+				f     qfs.File
+				warns []Warning
+			)
+			f, warns, err = ConvertBodyFormat(changes.BodyFile(), changes.Structure, prev.Structure)
 			if err != nil {
 				return
 			}
+			warnings = append(warnings, warns...)
 			// Set the new format on the change structure.
 			changes.Structure.Format = prev.Structure.Format
 			changes.SetBodyFile(f)
@@ -135,11 +293,46 @@ func SaveDataset(ctx context.Context, r repo.Repo, str ioes.IOStreams, changes *
 		mutable.Assign(changes)
 		changes = mutable
 	}
+	if changes.Structure != nil && changes.Structure.FormatConfig != nil {
+		delete(changes.Structure.FormatConfig, EncodingFormatConfigKey)
+		if len(changes.Structure.FormatConfig) == 0 {
+			changes.Structure.FormatConfig = nil
+		}
+	}
 
 	// infer missing values
-	if err = InferValues(pro, changes); err != nil {
+	inferSchema := changes.Structure == nil || changes.Structure.Schema == nil
+	warns, err := InferValues(pro, changes)
+	if err != nil {
 		return
 	}
+	warnings = append(warnings, warns...)
+	if inferSchema && changes.Structure != nil && prev.Structure != nil {
+		// an inferred schema knows nothing of column metadata, keep what the
+		// previous version described
+		MergeColumnMetadata(changes.Structure.Schema, prev.Structure.Schema)
+	}
+	if sw.RejectBreakingSchema && !sw.Force && changes.Structure != nil && prev.Structure != nil {
+		if err = CompareSchemas(prev.Structure.Schema, changes.Structure.Schema).Err(); err != nil {
+			return
+		}
+	}
+
+	if err = ValidateRules(ctx, changes, sw.ValidationRules); err != nil {
+		return
+	}
+	if sw.EnforceReferences {
+		if err = ValidateReferences(ctx, r, changes); err != nil {
+			return
+		}
+	}
+
+	for _, hook := range sw.PreSaveHooks {
+		if err = hook(ctx, changes); err != nil {
+			err = fmt.Errorf("pre-save hook: %w", err)
+			return
+		}
+	}
 
 	// TODO(dlong): Remove this, stop generating a default viz.
 	// add a default viz if one is needed
@@ -150,7 +343,42 @@ func SaveDataset(ctx context.Context, r repo.Repo, str ioes.IOStreams, changes *
 	// let's make history, if it exists
 	changes.PreviousPath = prevPath
 
-	return CreateDataset(ctx, r, str, changes, prev, sw.DryRun, sw.Pin, sw.Force, sw.ShouldRender)
+	if prevPath == "" && !sw.DryRun {
+		// a new dataset replaces any trashed dataset with the same name
+		if err = PurgeTrashed(ctx, r, reporef.DatasetRef{Peername: changes.Peername, Name: changes.Name}); err != nil {
+			return
+		}
+	}
+
+	if !sw.CommitTimestamp.IsZero() {
+		ctx = dsfs.WithCommitTimestamp(ctx, sw.CommitTimestamp)
+	}
+	if externalBody != "" {
+		// saving with an external body opts in to reading it
+		ctx = dsfs.AllowExternalBodies(dsfs.WithExternalBody(ctx, externalBody))
+	}
+	if len(templates) > 0 || len(prevTemplates) > 0 {
+		if len(templates) > 0 && changes.Viz == nil {
+			changes.Viz = &dataset.Viz{Format: "html"}
+		}
+		ctx = dsfs.WithVizTemplates(ctx, templates, prevTemplates)
+	}
+	if ref, err = CreateDataset(ctx, r, str, changes, prev, sw.DryRun, sw.Pin, sw.Force, sw.ShouldRender); err != nil {
+		return
+	}
+	if ref.Dataset != nil && ref.Dataset.Structure != nil && ref.Dataset.Structure.ErrCount > 0 {
+		warnings = append(warnings, Warning{
+			Code:      WarnValidation,
+			Message:   fmt.Sprintf("this dataset has %d validation errors", ref.Dataset.Structure.ErrCount),
+			Component: "body",
+		})
+	}
+	if !sw.DryRun {
+		for _, hook := range sw.PostSaveHooks {
+			hook(ctx, ref)
+		}
+	}
+	return
 }
 
 // CreateDataset uses dsfs to add a dataset to a repo's store, updating all
@@ -173,10 +401,18 @@ func CreateDataset(ctx context.Context, r repo.Repo, streams ioes.IOStreams, ds,
 		return
 	}
 
+	if err = checkBodyQuota(r, ds, dsPrev, dsfs.ExternalBodyFromContext(ctx) != ""); err != nil {
+		log.Debugf("checkBodyQuota: %s", err)
+		return
+	}
+
 	if path, err = dsfs.CreateDataset(ctx, r.Store(), ds, dsPrev, r.PrivateKey(), pin, force, shouldRender); err != nil {
 		log.Debugf("dsfs.CreateDataset: %s", err)
 		return
 	}
+	if pin {
+		accountBytes(r, savedBodyBytes(ds, dsPrev))
+	}
 	if ds.PreviousPath != "" && ds.PreviousPath != "/" {
 		prev := reporef.DatasetRef{
 			ProfileID: pro.ID,
@@ -226,7 +462,7 @@ func CreateDataset(ctx context.Context, r repo.Repo, streams ioes.IOStreams, ds,
 	// references to files in a store that won't exist after this function call
 	// TODO (b5): this should be replaced with a call to OpenDataset with a qfs that
 	// knows about the store
-	if resBody, err = r.Store().Get(ctx, ref.Dataset.BodyPath); err != nil {
+	if resBody, err = dsfs.LoadBody(ctx, r.Store(), ref.Dataset); err != nil {
 		log.Error("error getting from store:", err.Error())
 	}
 	ref.Dataset.SetBodyFile(resBody)
@@ -241,7 +477,7 @@ func GenerateAvailableName(r repo.Repo, peername, prefix string) string {
 		tryName := fmt.Sprintf("%s_%d", prefix, counter)
 		lookup := &reporef.DatasetRef{Name: tryName, Peername: peername}
 		err := repo.CanonicalizeDatasetRef(r, lookup)
-		if err == repo.ErrNotFound {
+		if repo.IsNotFound(err) {
 			return tryName
 		}
 	}
@@ -1,15 +1,24 @@
 package base
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/ioes"
 	"github.com/qri-io/qfs"
 	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
 )
 
 func TestSaveDataset(t *testing.T) {
@@ -26,7 +35,7 @@ func TestSaveDataset(t *testing.T) {
 	}
 	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte("[]")))
 
-	ref, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{DryRun: true, ShouldRender: true})
+	ref, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{DryRun: true, ShouldRender: true})
 	if err != nil {
 		t.Errorf("dry run error: %s", err.Error())
 	}
@@ -49,7 +58,7 @@ func TestSaveDataset(t *testing.T) {
 	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte("[]")))
 
 	// test save
-	ref, err = SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true, ShouldRender: true})
+	ref, _, err = SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true, ShouldRender: true})
 	if err != nil {
 		t.Error(err)
 	}
@@ -78,7 +87,7 @@ func TestSaveDataset(t *testing.T) {
 	ds.Transform.OpenScriptFile(ctx, nil)
 
 	// dryrun should work
-	ref, err = SaveDataset(ctx, r, devNull, ds, secrets, nil, SaveDatasetSwitches{DryRun: true, ShouldRender: true})
+	ref, _, err = SaveDataset(ctx, r, devNull, ds, secrets, nil, SaveDatasetSwitches{DryRun: true, ShouldRender: true})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -104,7 +113,7 @@ func TestSaveDataset(t *testing.T) {
 	ds.Transform.OpenScriptFile(ctx, nil)
 
 	// test save with transform
-	ref, err = SaveDataset(ctx, r, devNull, ds, secrets, nil, SaveDatasetSwitches{Pin: true, ShouldRender: true})
+	ref, _, err = SaveDataset(ctx, r, devNull, ds, secrets, nil, SaveDatasetSwitches{Pin: true, ShouldRender: true})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -123,7 +132,7 @@ func TestSaveDataset(t *testing.T) {
 		},
 	}
 
-	ref, err = SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true, ShouldRender: true})
+	ref, _, err = SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true, ShouldRender: true})
 	if err != nil {
 		t.Error(err)
 	}
@@ -151,7 +160,7 @@ func TestSaveDataset(t *testing.T) {
 		t.Error(err)
 	}
 
-	ref, err = SaveDataset(ctx, r, devNull, ds, secrets, nil, SaveDatasetSwitches{Pin: true, ShouldRender: true})
+	ref, _, err = SaveDataset(ctx, r, devNull, ds, secrets, nil, SaveDatasetSwitches{Pin: true, ShouldRender: true})
 	if err != nil {
 		t.Error(err)
 	}
@@ -173,7 +182,7 @@ func TestSaveDatasetWithoutStructureOrBody(t *testing.T) {
 		},
 	}
 
-	_, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{ShouldRender: true})
+	_, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{ShouldRender: true})
 	expect := "creating a new dataset requires a structure or a body"
 	if err == nil || err.Error() != expect {
 		t.Errorf("expected error, but got %s", err.Error())
@@ -195,7 +204,7 @@ func TestSaveDatasetReplace(t *testing.T) {
 	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte("[]")))
 
 	// test save
-	_, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true})
+	_, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true})
 	if err != nil {
 		t.Error(err)
 	}
@@ -207,7 +216,7 @@ func TestSaveDatasetReplace(t *testing.T) {
 	}
 	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`{"foo":"bar"}`)))
 
-	ref, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Replace: true, Pin: true})
+	ref, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Replace: true, Pin: true})
 	if err != nil {
 		t.Error(err)
 	}
@@ -221,6 +230,353 @@ func TestSaveDatasetReplace(t *testing.T) {
 	}
 }
 
+func TestSaveDatasetHooks(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	errMissingMeta := errors.New("meta title is required")
+	var (
+		checked []string
+		saved   []reporef.DatasetRef
+	)
+	sw := SaveDatasetSwitches{
+		Pin: true,
+		PreSaveHooks: []PreSaveHook{
+			func(ctx context.Context, ds *dataset.Dataset) error {
+				checked = append(checked, ds.Name)
+				if ds.Meta == nil || ds.Meta.Title == "" {
+					return errMissingMeta
+				}
+				return nil
+			},
+		},
+		PostSaveHooks: []PostSaveHook{
+			func(ctx context.Context, ref reporef.DatasetRef) {
+				saved = append(saved, ref)
+			},
+		},
+	}
+	newDs := func(meta *dataset.Meta) *dataset.Dataset {
+		ds := &dataset.Dataset{
+			Peername:  "me",
+			Name:      "hooked",
+			Meta:      meta,
+			Structure: &dataset.Structure{Format: "json", Schema: map[string]interface{}{"type": "array"}},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte("[1,2]")))
+		return ds
+	}
+
+	_, _, err := SaveDataset(ctx, r, devNull, newDs(nil), nil, nil, sw)
+	if !errors.Is(err, errMissingMeta) {
+		t.Errorf("expected a pre-save hook to veto the save, got: %v", err)
+	}
+	if len(saved) != 0 {
+		t.Errorf("expected a vetoed save not to call post-save hooks")
+	}
+	if _, err := r.GetRef(reporef.DatasetRef{Peername: "peer", Name: "hooked"}); err == nil {
+		t.Error("expected a vetoed save not to create a reference")
+	}
+
+	ref, _, err := SaveDataset(ctx, r, devNull, newDs(&dataset.Meta{Title: "hooked"}), nil, nil, sw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].Path != ref.Path {
+		t.Errorf("expected post-save hook to get the saved ref %s, got: %v", ref.Path, saved)
+	}
+
+	// changes are applied to the previous version before pre-save hooks run
+	ds := &dataset.Dataset{Peername: "me", Name: "hooked"}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte("[1,2,3]")))
+	if _, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, sw); err != nil {
+		t.Errorf("expected the previous meta to satisfy the hook, got: %v", err)
+	}
+
+	sw.DryRun = true
+	if _, _, err := SaveDataset(ctx, r, devNull, newDs(&dataset.Meta{Title: "dry"}), nil, nil, sw); err != nil {
+		t.Fatal(err)
+	}
+	if len(checked) != 4 || len(saved) != 2 {
+		t.Errorf("expected dry runs to only call pre-save hooks. pre: %d post: %d", len(checked), len(saved))
+	}
+}
+
+func TestSaveDatasetBodyEncoding(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	ds := &dataset.Dataset{
+		Peername: "me",
+		Name:     "latin_cities",
+		Structure: &dataset.Structure{
+			Format:       "csv",
+			FormatConfig: map[string]interface{}{"headerRow": true, "encoding": "latin1"},
+		},
+	}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.csv", []byte("city,pop\nM\xfcnchen,1500000\n")))
+
+	ref, warnings, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectWarning := Warning{Code: WarnConvertedEncoding, Message: "converted body from iso-8859-1 to UTF-8", Component: "body"}
+	if len(warnings) != 1 || warnings[0] != expectWarning {
+		t.Errorf("expected a conversion warning, got: %v", warnings)
+	}
+	body, err := ReadBody(ref.Dataset, dataset.CSVDataFormat, nil, nil, 0, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "city,pop\nMünchen,1500000\n"; string(body) != expect {
+		t.Errorf("body mismatch. want: %q got: %q", expect, string(body))
+	}
+	if _, ok := ref.Dataset.Structure.FormatConfig["encoding"]; ok {
+		t.Error("expected encoding option to be dropped from the stored structure")
+	}
+
+	ds = &dataset.Dataset{Peername: "me", Name: "latin_cities"}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.csv", []byte("city,pop\n\x00\x01\xff,1\n")))
+	_, _, err = SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true})
+	if _, ok := err.(*ErrBodyEncoding); !ok {
+		t.Errorf("expected saving an undecodable body to return an encoding error, got: %v", err)
+	}
+}
+
+func TestSaveDatasetCompression(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	body := []byte("city,pop\ntoronto,40000000\nnew york,8500000\n")
+	ds := &dataset.Dataset{
+		Peername: "me",
+		Name:     "gzip_cities",
+		Structure: &dataset.Structure{
+			Format:       "csv",
+			Compression:  "gzip",
+			FormatConfig: map[string]interface{}{"headerRow": true},
+		},
+	}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.csv", body))
+
+	ref, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Dataset.Structure.Length != len(body) {
+		t.Errorf("expected length to be the uncompressed size %d, got: %d", len(body), ref.Dataset.Structure.Length)
+	}
+
+	stored, err := r.Store().Get(ctx, ref.Dataset.BodyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		t.Errorf("expected body to be stored gzipped")
+	}
+
+	loaded, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = OpenDataset(ctx, r.Filesystem(), loaded); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadBody(loaded, dataset.CSVDataFormat, nil, nil, 0, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(body) {
+		t.Errorf("body mismatch. want: %q got: %q", body, got)
+	}
+
+	stored, err = r.Store().Get(ctx, ref.Dataset.BodyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted, _, err := ConvertBodyFormat(stored, loaded.Structure, &dataset.Structure{Format: "json", Schema: loaded.Structure.Schema})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ = ioutil.ReadAll(converted); !strings.Contains(string(data), "toronto") {
+		t.Errorf("expected converted body to be decompressed, got: %q", data)
+	}
+
+	ds = &dataset.Dataset{Peername: "me", Name: "zip_cities", Structure: &dataset.Structure{Format: "csv", Compression: "zip"}}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.csv", body))
+	if _, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true}); err == nil {
+		t.Error("expected an unsupported compression to error")
+	}
+}
+
+func TestSaveDatasetExternalBody(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	body := "city,pop\ntoronto,40000000\nnew york,8500000\n"
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer s.Close()
+
+	uri := s.URL + "/cities.csv"
+	ds := &dataset.Dataset{
+		Peername: "me",
+		Name:     "external_cities",
+		Structure: &dataset.Structure{
+			Format:       "csv",
+			FormatConfig: map[string]interface{}{"headerRow": true},
+		},
+	}
+	ref, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true, ExternalBody: uri})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Dataset.BodyPath != uri {
+		t.Errorf("expected body path to be the external URI, got: %q", ref.Dataset.BodyPath)
+	}
+	if st := ref.Dataset.Structure; st.Checksum == "" || st.Length != len(body) || st.Entries != 2 {
+		t.Errorf("expected structure values derived from the external body, got checksum: %q length: %d entries: %d", st.Checksum, st.Length, st.Entries)
+	}
+
+	loaded, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.Structure.FormatConfig["externalBody"]; ok {
+		t.Error("expected the external body URI to be kept out of format config")
+	}
+
+	// external bodies are only read on opt-in
+	if err = OpenDataset(ctx, r.Filesystem(), loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.BodyFile() != nil {
+		t.Error("expected an external body not to be opened without opting in")
+	}
+	if _, err := dsfs.LoadBody(ctx, r.Store(), loaded); !errors.Is(err, dsfs.ErrExternalBodyNotAllowed) {
+		t.Errorf("expected loading an external body without opting in to fail with ErrExternalBodyNotAllowed, got: %v", err)
+	}
+
+	ctx = dsfs.AllowExternalBodies(ctx)
+	if err = OpenDataset(ctx, r.Filesystem(), loaded); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadBody(loaded, dataset.CSVDataFormat, nil, nil, 0, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != body {
+		t.Errorf("body mismatch. want: %q got: %q", body, got)
+	}
+
+	// a changed external body no longer matches the saved version
+	body = "city,pop\ntoronto,1\n"
+	f, err := dsfs.LoadBody(ctx, r.Store(), loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadAll(f); err == nil || !strings.Contains(err.Error(), "doesn't match") {
+		t.Errorf("expected a checksum mismatch error, got: %v", err)
+	}
+
+	// saving again picks up the change
+	next, _, err := SaveDataset(ctx, r, devNull, &dataset.Dataset{Peername: "me", Name: "external_cities"}, nil, nil, SaveDatasetSwitches{Pin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Dataset.Structure.Entries != 1 || next.Dataset.BodyPath != uri {
+		t.Errorf("expected new version to read the changed external body, got entries: %d, body path: %q", next.Dataset.Structure.Entries, next.Dataset.BodyPath)
+	}
+
+	ds = &dataset.Dataset{Peername: "me", Name: "external_cities", Structure: &dataset.Structure{Format: "csv"}}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.csv", []byte(body)))
+	if _, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true}); err == nil {
+		t.Error("expected saving a body for a dataset with an external body to error")
+	}
+
+	ds = &dataset.Dataset{Peername: "me", Name: "ftp_cities", Structure: &dataset.Structure{Format: "csv"}}
+	if _, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true, ExternalBody: "ftp://example.com/cities.csv"}); err == nil {
+		t.Error("expected an unsupported external body scheme to error")
+	}
+
+	defer func(max int64) { dsfs.MaxExternalBodySize = max }(dsfs.MaxExternalBodySize)
+	dsfs.MaxExternalBodySize = 4
+	ds = &dataset.Dataset{Peername: "me", Name: "big_cities", Structure: &dataset.Structure{Format: "csv"}}
+	if _, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true, ExternalBody: uri}); err == nil {
+		t.Error("expected an external body larger than MaxExternalBodySize to error")
+	}
+
+	// the size cap only applies when saving, saved versions stream in full
+	loaded, err = dsfs.LoadDataset(ctx, r.Store(), next.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, err = dsfs.LoadBody(ctx, r.Store(), loaded); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = ioutil.ReadAll(f); err != nil {
+		t.Fatalf("expected a saved external body to load past MaxExternalBodySize, got: %s", err)
+	}
+	if string(got) != body {
+		t.Errorf("body mismatch. want: %q got: %q", body, got)
+	}
+}
+
+func TestSaveDatasetVizTemplates(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	ds := &dataset.Dataset{Peername: "me", Name: "viz_cities", Structure: &dataset.Structure{Format: "csv"}}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.csv", []byte("city,pop\ntoronto,40000000\n")))
+	templates := map[string]string{"map": "<div>map</div>", "table": "<table></table>"}
+	ref, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true, VizTemplates: templates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := dsfs.LoadVizTemplates(ctx, r.Store(), ref.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(dsfs.VizTemplates(templates), got); diff != "" {
+		t.Errorf("saved viz templates mismatch (-want +got):\n%s", diff)
+	}
+	// templates don't change how the viz component reads
+	loaded, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Viz == nil || loaded.Viz.Format != "html" {
+		t.Errorf("expected an html viz component, got: %#v", loaded.Viz)
+	}
+
+	// templates carry over to new versions, changing only templates is a change
+	ref, _, err = SaveDataset(ctx, r, devNull, &dataset.Dataset{Peername: "me", Name: "viz_cities"}, nil, nil, SaveDatasetSwitches{Pin: true, VizTemplates: map[string]string{"map": ""}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Dataset.Commit.Title != "updated viz templates" {
+		t.Errorf("expected commit title %q, got: %q", "updated viz templates", ref.Dataset.Commit.Title)
+	}
+	if got, err = dsfs.LoadVizTemplates(ctx, r.Store(), ref.Path); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(dsfs.VizTemplates{"table": "<table></table>"}, got); diff != "" {
+		t.Errorf("viz templates mismatch after removing a template (-want +got):\n%s", diff)
+	}
+
+	if _, _, err = SaveDataset(ctx, r, devNull, &dataset.Dataset{Peername: "me", Name: "viz_cities"}, nil, nil, SaveDatasetSwitches{Pin: true}); !errors.Is(err, dsfs.ErrNoChanges) {
+		t.Errorf("expected saving unchanged templates to be no changes, got: %v", err)
+	}
+	if _, _, err = SaveDataset(ctx, r, devNull, &dataset.Dataset{Peername: "me", Name: "viz_cities"}, nil, nil, SaveDatasetSwitches{Pin: true, VizTemplates: map[string]string{"bad": "{{ .Unclosed"}}); err == nil {
+		t.Error("expected an unparsable viz template to error")
+	}
+}
+
 func TestCreateDataset(t *testing.T) {
 	ctx := context.Background()
 	streams := ioes.NewDiscardIOStreams()
@@ -0,0 +1,437 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrBreakingSchemaChange means a save changes the body schema in a way
+// downstream consumers of the dataset may not handle
+var ErrBreakingSchemaChange = fmt.Errorf("breaking schema change")
+
+// SchemaChangeKind classifies a change between two versions of a schema
+type SchemaChangeKind string
+
+const (
+	// SchemaColumnAdded is a new optional column: a column appended to array
+	// rows, or an object row property that isn't required
+	SchemaColumnAdded SchemaChangeKind = "column_added"
+	// SchemaRequiredColumnAdded is a new property object rows must have
+	SchemaRequiredColumnAdded SchemaChangeKind = "required_column_added"
+	// SchemaColumnRemoved is a column the newer schema no longer has
+	SchemaColumnRemoved SchemaChangeKind = "column_removed"
+	// SchemaColumnMoved is a column of array rows at a new position
+	SchemaColumnMoved SchemaChangeKind = "column_moved"
+	// SchemaTypeWidened is a column that accepts more types than it did, like
+	// "integer" to "number"
+	SchemaTypeWidened SchemaChangeKind = "type_widened"
+	// SchemaTypeNarrowed is a column that accepts fewer types than it did
+	SchemaTypeNarrowed SchemaChangeKind = "type_narrowed"
+	// SchemaTypeChanged is a column with unrelated types, like "string" to
+	// "integer"
+	SchemaTypeChanged SchemaChangeKind = "type_changed"
+	// SchemaConstraintLoosened is a validation keyword that accepts more
+	// values than it did, or was dropped
+	SchemaConstraintLoosened SchemaChangeKind = "constraint_loosened"
+	// SchemaConstraintTightened is a validation keyword that accepts fewer
+	// values than it did, or was added
+	SchemaConstraintTightened SchemaChangeKind = "constraint_tightened"
+	// SchemaMetadataChanged is a change to column description or unit
+	SchemaMetadataChanged SchemaChangeKind = "metadata_changed"
+	// SchemaShapeChanged is a change to the type of the body or its rows
+	SchemaShapeChanged SchemaChangeKind = "shape_changed"
+)
+
+// SchemaChange is a single difference between two schemas
+type SchemaChange struct {
+	Kind SchemaChangeKind `json:"kind"`
+	// Column names the changed column, empty for shape changes
+	Column string `json:"column,omitempty"`
+	// Keyword is the schema keyword a type or constraint change is to
+	Keyword string `json:"keyword,omitempty"`
+	// From & To are the values before & after the change
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+	// Breaking is true if consumers of the older schema may not handle the
+	// change
+	Breaking bool `json:"breaking"`
+}
+
+// String describes the change in a sentence fragment
+func (c SchemaChange) String() string {
+	col := fmt.Sprintf("column %q", c.Column)
+	switch c.Kind {
+	case SchemaColumnAdded:
+		return col + " added"
+	case SchemaRequiredColumnAdded:
+		return fmt.Sprintf("required %s added", col)
+	case SchemaColumnRemoved:
+		return col + " removed"
+	case SchemaColumnMoved:
+		return fmt.Sprintf("%s moved from position %v to %v", col, c.From, c.To)
+	case SchemaTypeWidened:
+		return fmt.Sprintf("%s type widened from %s to %s", col, describeSchemaValue(c.From), describeSchemaValue(c.To))
+	case SchemaTypeNarrowed:
+		return fmt.Sprintf("%s type narrowed from %s to %s", col, describeSchemaValue(c.From), describeSchemaValue(c.To))
+	case SchemaTypeChanged:
+		return fmt.Sprintf("%s type changed from %s to %s", col, describeSchemaValue(c.From), describeSchemaValue(c.To))
+	case SchemaConstraintLoosened:
+		return fmt.Sprintf("%s %s loosened from %s to %s", col, c.Keyword, describeSchemaValue(c.From), describeSchemaValue(c.To))
+	case SchemaConstraintTightened:
+		return fmt.Sprintf("%s %s tightened from %s to %s", col, c.Keyword, describeSchemaValue(c.From), describeSchemaValue(c.To))
+	case SchemaMetadataChanged:
+		return fmt.Sprintf("%s %s changed", col, c.Keyword)
+	case SchemaShapeChanged:
+		return fmt.Sprintf("%s changed from %s to %s", c.Keyword, describeSchemaValue(c.From), describeSchemaValue(c.To))
+	}
+	return string(c.Kind)
+}
+
+// SchemaCompatibility reports the changes between two schemas, & whether
+// the newer one is backward compatible with the older
+type SchemaCompatibility struct {
+	// Compatible is false if any change is breaking
+	Compatible bool           `json:"compatible"`
+	Changes    []SchemaChange `json:"changes,omitempty"`
+}
+
+// Breaking lists the breaking changes
+func (sc *SchemaCompatibility) Breaking() []SchemaChange {
+	var breaking []SchemaChange
+	for _, c := range sc.Changes {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+	return breaking
+}
+
+// Err returns an ErrBreakingSchemaChange listing breaking changes, nil if
+// the schemas are compatible
+func (sc *SchemaCompatibility) Err() error {
+	breaking := sc.Breaking()
+	if len(breaking) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(breaking))
+	for i, c := range breaking {
+		msgs[i] = c.String()
+	}
+	return fmt.Errorf("%w: %s", ErrBreakingSchemaChange, strings.Join(msgs, ", "))
+}
+
+// CompareSchemas classifies the changes from schema a to a newer schema b.
+// Columns are matched by name. Columns of array rows are positional, so
+// inserting a column moves the ones after it, breaking consumers that read
+// by position. Only tabular schemas are compared column by column, other
+// changes to schemas that don't describe rows aren't reported. A missing
+// older schema is compatible with anything
+func CompareSchemas(a, b map[string]interface{}) *SchemaCompatibility {
+	sc := &SchemaCompatibility{Compatible: true}
+	if a == nil || b == nil {
+		return sc
+	}
+
+	if change, ok := compareShape("type", a["type"], b["type"]); ok {
+		sc.add(change)
+		return sc
+	}
+	aItems, _ := a["items"].(map[string]interface{})
+	bItems, _ := b["items"].(map[string]interface{})
+	if change, ok := compareShape("row type", rowType(aItems), rowType(bItems)); ok {
+		sc.add(change)
+		return sc
+	}
+
+	positional := rowType(aItems) == "array"
+	aCols, bCols := Columns(a), Columns(b)
+	aRequired, bRequired := requiredSet(aItems), requiredSet(bItems)
+
+	matched := map[string]bool{}
+	for _, ac := range aCols {
+		name := columnKey(ac)
+		bc, ok := findColumn(bCols, name)
+		if !ok {
+			sc.add(SchemaChange{Kind: SchemaColumnRemoved, Column: name, Breaking: true})
+			continue
+		}
+		matched[name] = true
+		if positional && ac.Index != bc.Index {
+			sc.add(SchemaChange{Kind: SchemaColumnMoved, Column: name, From: ac.Index, To: bc.Index, Breaking: true})
+		}
+		if !positional && aRequired[name] != bRequired[name] {
+			kind := SchemaConstraintTightened
+			if aRequired[name] {
+				kind = SchemaConstraintLoosened
+			}
+			sc.add(SchemaChange{Kind: kind, Column: name, Keyword: "required", From: aRequired[name], To: bRequired[name], Breaking: kind == SchemaConstraintTightened})
+		}
+		for _, change := range compareColumn(name, ac.Schema, bc.Schema) {
+			sc.add(change)
+		}
+	}
+
+	for _, bc := range bCols {
+		name := columnKey(bc)
+		if matched[name] {
+			continue
+		}
+		if bRequired[name] {
+			sc.add(SchemaChange{Kind: SchemaRequiredColumnAdded, Column: name, Breaking: true})
+		} else {
+			sc.add(SchemaChange{Kind: SchemaColumnAdded, Column: name})
+		}
+	}
+	return sc
+}
+
+func (sc *SchemaCompatibility) add(c SchemaChange) {
+	sc.Changes = append(sc.Changes, c)
+	if c.Breaking {
+		sc.Compatible = false
+	}
+}
+
+func compareShape(keyword string, a, b interface{}) (SchemaChange, bool) {
+	if reflect.DeepEqual(a, b) {
+		return SchemaChange{}, false
+	}
+	return SchemaChange{Kind: SchemaShapeChanged, Keyword: keyword, From: a, To: b, Breaking: true}, true
+}
+
+// rowType is the type of body rows, "array" or "object"
+func rowType(items map[string]interface{}) string {
+	if items == nil {
+		return ""
+	}
+	if _, ok := items["items"].([]interface{}); ok {
+		return "array"
+	}
+	if _, ok := items["properties"].(map[string]interface{}); ok {
+		return "object"
+	}
+	t, _ := items["type"].(string)
+	return t
+}
+
+func requiredSet(items map[string]interface{}) map[string]bool {
+	set := map[string]bool{}
+	list, _ := items["required"].([]interface{})
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			set[s] = true
+		}
+	}
+	if strs, ok := items["required"].([]string); ok {
+		for _, s := range strs {
+			set[s] = true
+		}
+	}
+	return set
+}
+
+// columnKey names a column, untitled columns are named by position
+func columnKey(c Column) string {
+	if c.Name == "" {
+		return fmt.Sprintf("#%d", c.Index)
+	}
+	return c.Name
+}
+
+func findColumn(cols []Column, name string) (Column, bool) {
+	for _, c := range cols {
+		if columnKey(c) == name {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+const (
+	// lowerBound keywords tighten as they rise
+	lowerBound = iota
+	// upperBound keywords tighten as they fall
+	upperBound
+	// restriction keywords tighten when added or changed
+	restriction
+)
+
+// constraintKeywords are the validation keywords compared between columns
+var constraintKeywords = []struct {
+	name string
+	kind int
+}{
+	{"minimum", lowerBound},
+	{"exclusiveMinimum", lowerBound},
+	{"minLength", lowerBound},
+	{"minItems", lowerBound},
+	{"maximum", upperBound},
+	{"exclusiveMaximum", upperBound},
+	{"maxLength", upperBound},
+	{"maxItems", upperBound},
+	{"pattern", restriction},
+	{"format", restriction},
+	{"multipleOf", restriction},
+	{"const", restriction},
+}
+
+// compareColumn classifies changes between two versions of a column schema
+func compareColumn(name string, a, b map[string]interface{}) []SchemaChange {
+	var changes []SchemaChange
+	if kind, changed := compareTypes(a["type"], b["type"]); changed {
+		changes = append(changes, SchemaChange{Kind: kind, Column: name, Keyword: "type", From: a["type"], To: b["type"], Breaking: kind != SchemaTypeWidened})
+	}
+
+	for _, kw := range constraintKeywords {
+		av, aok := a[kw.name]
+		bv, bok := b[kw.name]
+		if !aok && !bok || reflect.DeepEqual(av, bv) {
+			continue
+		}
+		tightened := true
+		switch {
+		case !bok:
+			tightened = false
+		case !aok:
+			tightened = true
+		case kw.kind != restriction:
+			af, aNum := toFloat(av)
+			bf, bNum := toFloat(bv)
+			if aNum && bNum {
+				tightened = (kw.kind == lowerBound && bf > af) || (kw.kind == upperBound && bf < af)
+			}
+		}
+		changes = append(changes, constraintChange(name, kw.name, av, bv, tightened))
+	}
+
+	if av, bv := a["enum"], b["enum"]; av != nil || bv != nil {
+		if tightened, changed := compareEnums(av, bv); changed {
+			changes = append(changes, constraintChange(name, "enum", av, bv, tightened))
+		}
+	}
+
+	for _, kw := range []string{"description", "unit"} {
+		if !reflect.DeepEqual(a[kw], b[kw]) {
+			changes = append(changes, SchemaChange{Kind: SchemaMetadataChanged, Column: name, Keyword: kw, From: a[kw], To: b[kw]})
+		}
+	}
+	return changes
+}
+
+func constraintChange(name, keyword string, from, to interface{}, tightened bool) SchemaChange {
+	kind := SchemaConstraintLoosened
+	if tightened {
+		kind = SchemaConstraintTightened
+	}
+	return SchemaChange{Kind: kind, Column: name, Keyword: keyword, From: from, To: to, Breaking: tightened}
+}
+
+// compareTypes compares "type" keyword values. A missing type accepts any
+// value, & "number" includes "integer"
+func compareTypes(a, b interface{}) (kind SchemaChangeKind, changed bool) {
+	as, bs := typeSet(a), typeSet(b)
+	aInB, bInA := typesCovered(as, bs), typesCovered(bs, as)
+	switch {
+	case aInB && bInA:
+		return "", false
+	case aInB:
+		return SchemaTypeWidened, true
+	case bInA:
+		return SchemaTypeNarrowed, true
+	}
+	return SchemaTypeChanged, true
+}
+
+// typeSet lists the types a "type" keyword accepts, nil for any type
+func typeSet(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []interface{}:
+		set := make([]string, 0, len(t))
+		for _, s := range t {
+			if str, ok := s.(string); ok {
+				set = append(set, str)
+			}
+		}
+		return set
+	}
+	return nil
+}
+
+// typesCovered checks every type in a is accepted by b
+func typesCovered(a, b []string) bool {
+	if b == nil {
+		return true
+	}
+	if a == nil {
+		return false
+	}
+	for _, t := range a {
+		found := false
+		for _, bt := range b {
+			if t == bt || (t == "integer" && bt == "number") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// compareEnums compares "enum" keyword values. Dropping values tightens an
+// enum, adding values loosens it
+func compareEnums(a, b interface{}) (tightened, changed bool) {
+	as, aok := enumSet(a)
+	bs, bok := enumSet(b)
+	switch {
+	case !aok && !bok:
+		return false, false
+	case !bok:
+		return false, true
+	case !aok:
+		return true, true
+	}
+	for v := range as {
+		if !bs[v] {
+			return true, true
+		}
+	}
+	return false, len(as) != len(bs)
+}
+
+func enumSet(v interface{}) (map[string]bool, bool) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	set := make(map[string]bool, len(list))
+	for _, el := range list {
+		data, _ := json.Marshal(el)
+		set[string(data)] = true
+	}
+	return set, true
+}
+
+func describeSchemaValue(v interface{}) string {
+	if v == nil {
+		return "none"
+	}
+	if list, ok := v.([]interface{}); ok {
+		strs := make([]string, len(list))
+		for i, el := range list {
+			strs[i] = fmt.Sprint(el)
+		}
+		sort.Strings(strs)
+		return "[" + strings.Join(strs, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,160 @@
+package base
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func objectRowsSchema(required []interface{}, props map[string]interface{}) map[string]interface{} {
+	items := map[string]interface{}{"type": "object", "properties": props}
+	if required != nil {
+		items["required"] = required
+	}
+	return map[string]interface{}{"type": "array", "items": items}
+}
+
+func TestCompareSchemas(t *testing.T) {
+	col := func(title string, kv ...interface{}) map[string]interface{} {
+		c := map[string]interface{}{"title": title}
+		for i := 0; i < len(kv); i += 2 {
+			c[kv[i].(string)] = kv[i+1]
+		}
+		return c
+	}
+	city := col("city", "type", "string")
+	pop := col("pop", "type", "integer")
+
+	cases := []struct {
+		description string
+		a, b        map[string]interface{}
+		changes     []string
+		compatible  bool
+	}{
+		{"no changes",
+			columnsSchema(city, pop), columnsSchema(city, pop),
+			nil, true},
+		{"no previous schema",
+			nil, columnsSchema(city),
+			nil, true},
+		{"column appended",
+			columnsSchema(city), columnsSchema(city, pop),
+			[]string{"column_added pop"}, true},
+		{"column inserted moves the ones after it",
+			columnsSchema(city, pop), columnsSchema(city, col("state", "type", "string"), pop),
+			[]string{"column_moved pop", "column_added state"}, false},
+		{"column removed",
+			columnsSchema(city, pop), columnsSchema(city),
+			[]string{"column_removed pop"}, false},
+		{"column renamed",
+			columnsSchema(city, pop), columnsSchema(city, col("population", "type", "integer")),
+			[]string{"column_removed pop", "column_added population"}, false},
+		{"integer widened to number",
+			columnsSchema(pop), columnsSchema(col("pop", "type", "number")),
+			[]string{"type_widened pop type"}, true},
+		{"nullable type widened",
+			columnsSchema(pop), columnsSchema(col("pop", "type", []interface{}{"integer", "null"})),
+			[]string{"type_widened pop type"}, true},
+		{"type dropped accepts anything",
+			columnsSchema(pop), columnsSchema(col("pop")),
+			[]string{"type_widened pop type"}, true},
+		{"number narrowed to integer",
+			columnsSchema(col("pop", "type", "number")), columnsSchema(pop),
+			[]string{"type_narrowed pop type"}, false},
+		{"type changed",
+			columnsSchema(pop), columnsSchema(col("pop", "type", "string")),
+			[]string{"type_changed pop type"}, false},
+		{"minimum added",
+			columnsSchema(pop), columnsSchema(col("pop", "type", "integer", "minimum", 0)),
+			[]string{"constraint_tightened pop minimum"}, false},
+		{"minimum raised",
+			columnsSchema(col("pop", "minimum", 0)), columnsSchema(col("pop", "minimum", 10)),
+			[]string{"constraint_tightened pop minimum"}, false},
+		{"minimum lowered",
+			columnsSchema(col("pop", "minimum", 10)), columnsSchema(col("pop", "minimum", 0)),
+			[]string{"constraint_loosened pop minimum"}, true},
+		{"maxLength lowered",
+			columnsSchema(col("city", "maxLength", 20.0)), columnsSchema(col("city", "maxLength", 10.0)),
+			[]string{"constraint_tightened city maxLength"}, false},
+		{"maximum removed",
+			columnsSchema(col("pop", "maximum", 100)), columnsSchema(col("pop")),
+			[]string{"constraint_loosened pop maximum"}, true},
+		{"pattern changed",
+			columnsSchema(col("city", "pattern", "^[a-z]+$")), columnsSchema(col("city", "pattern", "^[A-Z]+$")),
+			[]string{"constraint_tightened city pattern"}, false},
+		{"enum value added",
+			columnsSchema(col("size", "enum", []interface{}{"s", "m"})), columnsSchema(col("size", "enum", []interface{}{"s", "m", "l"})),
+			[]string{"constraint_loosened size enum"}, true},
+		{"enum value dropped",
+			columnsSchema(col("size", "enum", []interface{}{"s", "m"})), columnsSchema(col("size", "enum", []interface{}{"s"})),
+			[]string{"constraint_tightened size enum"}, false},
+		{"enum reordered",
+			columnsSchema(col("size", "enum", []interface{}{"s", "m"})), columnsSchema(col("size", "enum", []interface{}{"m", "s"})),
+			nil, true},
+		{"description changed",
+			columnsSchema(col("pop", "description", "people")), columnsSchema(col("pop", "description", "residents")),
+			[]string{"metadata_changed pop description"}, true},
+		{"optional property added",
+			objectRowsSchema(nil, map[string]interface{}{"city": city}),
+			objectRowsSchema(nil, map[string]interface{}{"city": city, "pop": pop}),
+			[]string{"column_added pop"}, true},
+		{"required property added",
+			objectRowsSchema(nil, map[string]interface{}{"city": city}),
+			objectRowsSchema([]interface{}{"pop"}, map[string]interface{}{"city": city, "pop": pop}),
+			[]string{"required_column_added pop"}, false},
+		{"property made required",
+			objectRowsSchema(nil, map[string]interface{}{"city": city}),
+			objectRowsSchema([]interface{}{"city"}, map[string]interface{}{"city": city}),
+			[]string{"constraint_tightened city required"}, false},
+		{"property made optional",
+			objectRowsSchema([]interface{}{"city"}, map[string]interface{}{"city": city}),
+			objectRowsSchema(nil, map[string]interface{}{"city": city}),
+			[]string{"constraint_loosened city required"}, true},
+		{"array rows to object rows",
+			columnsSchema(city), objectRowsSchema(nil, map[string]interface{}{"city": city}),
+			[]string{"shape_changed  row type"}, false},
+		{"body type changed",
+			columnsSchema(city), map[string]interface{}{"type": "object"},
+			[]string{"shape_changed  type"}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			sc := CompareSchemas(c.a, c.b)
+			var got []string
+			for _, ch := range sc.Changes {
+				s := string(ch.Kind) + " " + ch.Column
+				if ch.Keyword != "" {
+					s += " " + ch.Keyword
+				}
+				got = append(got, s)
+			}
+			if diff := cmp.Diff(c.changes, got); diff != "" {
+				t.Errorf("changes mismatch (-want +got):\n%s", diff)
+			}
+			if c.compatible != sc.Compatible {
+				t.Errorf("expected compatible to be %t", c.compatible)
+			}
+			if err := sc.Err(); c.compatible != (err == nil) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSchemaCompatibilityErr(t *testing.T) {
+	a := columnsSchema(
+		map[string]interface{}{"title": "city", "type": "string"},
+		map[string]interface{}{"title": "pop", "type": "number"},
+	)
+	b := columnsSchema(map[string]interface{}{"title": "pop", "type": "integer"})
+	err := CompareSchemas(a, b).Err()
+	if !errors.Is(err, ErrBreakingSchemaChange) {
+		t.Fatalf("expected ErrBreakingSchemaChange, got: %v", err)
+	}
+	expect := `breaking schema change: column "city" removed, column "pop" moved from position 1 to 0, column "pop" type narrowed from number to integer`
+	if err.Error() != expect {
+		t.Errorf("error mismatch.\nwant: %s\ngot:  %s", expect, err)
+	}
+}
@@ -0,0 +1,214 @@
+package base
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	crypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// AuthorKeyFunc finds the public key of a dataset author
+type AuthorKeyFunc func(ctx context.Context, id profile.ID) (crypto.PubKey, error)
+
+// VerifyCommitSignature checks a dataset commit was signed by the holder of
+// the private key matching pub
+func VerifyCommitSignature(ds *dataset.Dataset, pub crypto.PubKey) error {
+	if ds.Commit == nil || ds.Commit.Signature == "" {
+		return fmt.Errorf("commit isn't signed")
+	}
+	sig, err := base64.StdEncoding.DecodeString(ds.Commit.Signature)
+	if err != nil {
+		return fmt.Errorf("decoding commit signature: %s", err)
+	}
+	sb, err := ds.SignableBytes()
+	if err != nil {
+		return err
+	}
+	ok, err := pub.Verify(sb, sig)
+	if err != nil {
+		return fmt.Errorf("verifying commit signature: %s", err)
+	}
+	if !ok {
+		return fmt.Errorf("commit signature doesn't match the author's key")
+	}
+	return nil
+}
+
+// ErrInvalidSignature is the error kind of a version with a commit signature
+// that doesn't verify against its author's key
+var ErrInvalidSignature = errors.New("invalid commit signature")
+
+// SignatureStatus is the outcome of verifying a commit signature
+type SignatureStatus string
+
+const (
+	// SignatureVerified means the commit was signed by the author's key
+	SignatureVerified = SignatureStatus("verified")
+	// SignatureUnknownKey means the author's public key isn't known, so the
+	// signature couldn't be checked
+	SignatureUnknownKey = SignatureStatus("unknown_key")
+	// SignatureInvalid means the commit is unsigned, or its signature doesn't
+	// match the author's key
+	SignatureInvalid = SignatureStatus("invalid")
+)
+
+// SignatureVerification reports the commit signature check of one version
+type SignatureVerification struct {
+	Path   string          `json:"path,omitempty"`
+	Status SignatureStatus `json:"status"`
+	// Author is the profile ID the commit claims to be signed by
+	Author string `json:"author,omitempty"`
+	// Reason explains a status other than verified
+	Reason string `json:"reason,omitempty"`
+}
+
+// Err returns an ErrInvalidSignature error for an invalid signature, nil
+// otherwise
+func (v *SignatureVerification) Err() error {
+	if v == nil || v.Status != SignatureInvalid {
+		return nil
+	}
+	return fmt.Errorf("%w: version %s: %s", ErrInvalidSignature, v.Path, v.Reason)
+}
+
+// VerifySignature checks the commit of a loaded version against the public
+// key authorKey finds for the commit's author. The author is the commit
+// author, or the dataset's profile ID when the commit doesn't record one
+func VerifySignature(ctx context.Context, ds *dataset.Dataset, authorKey AuthorKeyFunc) *SignatureVerification {
+	v := &SignatureVerification{Path: ds.Path}
+	if ds.Commit == nil || ds.Commit.Signature == "" {
+		v.Status, v.Reason = SignatureInvalid, "commit isn't signed"
+		return v
+	}
+
+	v.Author = ds.ProfileID
+	if ds.Commit.Author != nil && ds.Commit.Author.ID != "" {
+		v.Author = ds.Commit.Author.ID
+	}
+	if v.Author == "" {
+		v.Status, v.Reason = SignatureUnknownKey, "commit doesn't record an author"
+		return v
+	}
+	id, err := profile.IDB58Decode(v.Author)
+	if err != nil {
+		v.Status, v.Reason = SignatureUnknownKey, fmt.Sprintf("invalid author ID: %s", err)
+		return v
+	}
+	pub, err := authorKey(ctx, id)
+	if err != nil {
+		v.Status, v.Reason = SignatureUnknownKey, fmt.Sprintf("public key of author is unknown: %s", err)
+		return v
+	}
+
+	if err := VerifyCommitSignature(ds, pub); err != nil {
+		v.Status, v.Reason = SignatureInvalid, err.Error()
+		return v
+	}
+	v.Status = SignatureVerified
+	return v
+}
+
+// AddVersionByPath registers a dataset version known only by its path in the
+// repo. The author is read from the version's commit, and the commit
+// signature must verify against the key authorKey returns for that author.
+// Versions record no name, so name is required unless the version carries one.
+// Versions that aren't stored locally are fetched if the store supports
+// fetching
+func AddVersionByPath(ctx context.Context, r repo.Repo, path, name string, authorKey AuthorKeyFunc) (ref reporef.DatasetRef, err error) {
+	ref = reporef.DatasetRef{Path: path}
+	ds, err := dsfs.LoadDataset(ctx, r.Store(), path)
+	if err != nil {
+		if _, ok := r.Store().(cafs.Fetcher); !ok {
+			return ref, fmt.Errorf("loading %s: %s", path, err)
+		}
+		if err = FetchDataset(ctx, r, &ref, false, true); err != nil {
+			return ref, err
+		}
+		ds = ref.Dataset
+	}
+
+	if ds.Commit == nil || ds.Commit.Author == nil || ds.Commit.Author.ID == "" {
+		return ref, fmt.Errorf("cannot add %s: commit doesn't record an author", path)
+	}
+	authorID, err := profile.IDB58Decode(ds.Commit.Author.ID)
+	if err != nil {
+		return ref, fmt.Errorf("cannot add %s: invalid author ID %q: %s", path, ds.Commit.Author.ID, err)
+	}
+
+	pub, err := authorKey(ctx, authorID)
+	if err != nil {
+		return ref, fmt.Errorf("cannot add %s: unable to verify the commit signature, public key of author %s is unknown: %s", path, authorID, err)
+	}
+	if err = VerifyCommitSignature(ds, pub); err != nil {
+		return ref, fmt.Errorf("cannot add %s: %s. the dataset may have been tampered with, or doesn't belong to the author it claims", path, err)
+	}
+
+	peername, err := authorPeername(r, authorID, ds.Peername)
+	if err != nil {
+		return ref, fmt.Errorf("cannot add %s: %s", path, err)
+	}
+
+	if name == "" {
+		name = ds.Name
+	}
+	if name == "" {
+		return ref, fmt.Errorf("cannot add %s: the version doesn't record a dataset name, please provide one", path)
+	}
+	if !dsref.IsValidName(name) {
+		return ref, dsref.ErrDescribeValidName
+	}
+
+	ref = reporef.DatasetRef{
+		ProfileID: authorID,
+		Peername:  peername,
+		Name:      name,
+		Path:      path,
+	}
+
+	existing, err := r.GetRef(reporef.DatasetRef{Peername: peername, Name: name})
+	if err == nil {
+		if existing.Path == path {
+			ref.Dataset = ds
+			return ref, nil
+		}
+		return ref, fmt.Errorf("dataset %s/%s %w, please choose a different local name", peername, name, repo.ErrAlreadyExists)
+	} else if !repo.IsNotFound(err) {
+		return ref, err
+	}
+
+	if err = PinDataset(ctx, r, ref); err != nil {
+		if _, ok := err.(*repo.QuotaExceededError); ok {
+			return ref, err
+		}
+		return ref, fmt.Errorf("pinning %s: %s", path, err)
+	}
+	if err = r.PutRef(ref); err != nil {
+		return ref, err
+	}
+	ref.Dataset = ds
+	return ref, nil
+}
+
+// authorPeername finds the peername of a dataset author, preferring known
+// profiles over the peername a version claims
+func authorPeername(r repo.Repo, id profile.ID, claimed string) (string, error) {
+	if pro, err := r.Profile(); err == nil && pro.ID == id {
+		return pro.Peername, nil
+	}
+	if pro, err := r.Profiles().GetProfile(id); err == nil && pro.Peername != "" {
+		return pro.Peername, nil
+	}
+	if claimed != "" && claimed != "me" {
+		return claimed, nil
+	}
+	return "", fmt.Errorf("peername of author %s is unknown", id)
+}
@@ -0,0 +1,144 @@
+package base
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestAddVersionByPath(t *testing.T) {
+	ctx := context.Background()
+	pro := &profile.Profile{
+		Peername: "peer",
+		ID:       profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt"),
+		PrivKey:  privKey,
+	}
+	store := cafs.NewMapstore()
+	fs := qfs.NewMux(map[string]qfs.Filesystem{"local": store, "cafs": store})
+	r, err := repo.NewMemRepo(pro, store, fs, profile.NewMemStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds := &dataset.Dataset{
+		Peername:  "me",
+		Name:      "signed",
+		Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray},
+	}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[1,2,3]`)))
+	saved, _, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorKey := func(ctx context.Context, id profile.ID) (crypto.PubKey, error) {
+		return privKey.GetPublic(), nil
+	}
+
+	if _, err := AddVersionByPath(ctx, r, saved.Path, "", authorKey); err == nil {
+		t.Error("expected adding without a name to error")
+	}
+	if _, err := AddVersionByPath(ctx, r, saved.Path, "signed", authorKey); err != nil {
+		t.Errorf("expected re-adding the same version to succeed, got: %s", err)
+	}
+
+	ref, err := AddVersionByPath(ctx, r, saved.Path, "signed_copy", authorKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Peername != "peer" || ref.ProfileID != pro.ID || ref.Path != saved.Path {
+		t.Errorf("unexpected ref: %s", ref)
+	}
+	if _, err := r.GetRef(ref); err != nil {
+		t.Errorf("expected ref to be registered: %s", err)
+	}
+
+	// a different version can't take an existing name
+	other := addCitiesDataset(t, r)
+	if _, err := AddVersionByPath(ctx, r, saved.Path, other.Name, authorKey); err == nil {
+		t.Error("expected adding under a name that's taken to error")
+	}
+
+	_, wrongKey, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = AddVersionByPath(ctx, r, saved.Path, "imposter", func(ctx context.Context, id profile.ID) (crypto.PubKey, error) {
+		return wrongKey, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("expected signature verification to fail, got: %v", err)
+	}
+	if _, err := r.GetRef(reporef.DatasetRef{Peername: "peer", Name: "imposter"}); err == nil {
+		t.Error("expected unverified version not to be registered")
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	ref := addCitiesDataset(t, r)
+	ds, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// stored versions don't record a profile ID, callers set it from the ref
+	ds.ProfileID = "QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt"
+
+	_, wrongKey, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := func(key crypto.PubKey, err error) AuthorKeyFunc {
+		return func(ctx context.Context, id profile.ID) (crypto.PubKey, error) {
+			return key, err
+		}
+	}
+
+	cases := []struct {
+		description string
+		mutate      func(ds *dataset.Dataset)
+		authorKey   AuthorKeyFunc
+		status      SignatureStatus
+	}{
+		{"verified", nil, keys(privKey.GetPublic(), nil), SignatureVerified},
+		{"unknown key", nil, keys(nil, fmt.Errorf("no key found")), SignatureUnknownKey},
+		{"no author", func(ds *dataset.Dataset) { ds.ProfileID = "" }, keys(privKey.GetPublic(), nil), SignatureUnknownKey},
+		{"wrong key", nil, keys(wrongKey, nil), SignatureInvalid},
+		{"tampered", func(ds *dataset.Dataset) { ds.Structure.Checksum = "QmTampered" }, keys(privKey.GetPublic(), nil), SignatureInvalid},
+		{"unsigned", func(ds *dataset.Dataset) { ds.Commit.Signature = "" }, keys(privKey.GetPublic(), nil), SignatureInvalid},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			cpy := &dataset.Dataset{}
+			cpy.Assign(ds)
+			cpy.Commit = &dataset.Commit{}
+			cpy.Commit.Assign(ds.Commit)
+			cpy.Structure = &dataset.Structure{}
+			cpy.Structure.Assign(ds.Structure)
+			if c.mutate != nil {
+				c.mutate(cpy)
+			}
+
+			v := VerifySignature(ctx, cpy, c.authorKey)
+			if v.Status != c.status {
+				t.Errorf("expected status %q, got %q: %s", c.status, v.Status, v.Reason)
+			}
+			if err := v.Err(); (c.status == SignatureInvalid) != errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("expected only invalid signatures to error, got: %v", err)
+			}
+		})
+	}
+}
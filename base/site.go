@@ -0,0 +1,266 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// DefaultSiteSampleSize is the number of body entries ExportSite embeds when
+// no sample size is given
+const DefaultSiteSampleSize = 1000
+
+// ExportSite writes a dataset version as a static HTML site to dir. The site
+// has a page for the rendered viz, the dataset meta, the readme & a sample of
+// the body, plus the body sample as JSON. Styles & data are inlined so pages
+// work offline from any static file server, assets a viz template links to
+// are left as-is. sampleSize limits embedded body entries, -1 embeds the whole
+// body. ExportSite returns the paths of written files, relative to dir
+func ExportSite(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, dir string, sampleSize int) ([]string, error) {
+	if sampleSize == 0 {
+		sampleSize = DefaultSiteSampleSize
+	}
+	if entries, err := ioutil.ReadDir(dir); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("already exists: %q isn't an empty directory", dir)
+	}
+
+	viz, err := Render(ctx, r, ref, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("rendering viz: %s", err)
+	}
+
+	ds, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path)
+	if err != nil {
+		return nil, err
+	}
+	ds.Peername = ref.Peername
+	ds.Name = ref.Name
+	if err = OpenDataset(ctx, r.Filesystem(), ds); err != nil {
+		return nil, err
+	}
+	defer CloseDataset(ds)
+
+	// viz & readme HTML come from dataset authors, they're sanitized the
+	// same way readmes are rendered
+	page := sitePage{Dataset: ds, Ref: ref.AliasString(), Viz: string(sanitizeHTML(viz))}
+
+	var sample []byte
+	if ds.BodyFile() != nil {
+		if sample, err = ReadBody(ds, dataset.JSONDataFormat, nil, nil, sampleSize, 0, sampleSize < 0); err != nil {
+			return nil, fmt.Errorf("reading body: %s", err)
+		}
+		var body interface{}
+		if err = json.Unmarshal(sample, &body); err != nil {
+			return nil, err
+		}
+		page.Body = siteBody(body, ds.Structure)
+		page.Sampled = ds.Structure != nil && ds.Structure.Entries > len(page.Body.Rows)
+	}
+
+	if ds.Readme != nil && ds.Readme.ScriptFile() != nil {
+		readme, err := RenderReadme(ctx, ds.Readme.ScriptFile())
+		if err != nil {
+			return nil, fmt.Errorf("rendering readme: %s", err)
+		}
+		page.Readme = template.HTML(sanitizeHTML([]byte(readme)))
+	}
+
+	files := map[string][]byte{}
+	for _, name := range []string{"index.html", "meta.html", "body.html", "readme.html"} {
+		if name == "readme.html" && page.Readme == "" {
+			continue
+		}
+		page.Page = name
+		buf := &bytes.Buffer{}
+		if err = siteTemplate.ExecuteTemplate(buf, name, page); err != nil {
+			return nil, err
+		}
+		files[name] = buf.Bytes()
+	}
+	if sample != nil {
+		files["body.json"] = sample
+	}
+
+	if err = os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	written := make([]string, 0, len(files))
+	for _, name := range []string{"index.html", "meta.html", "body.html", "readme.html", "body.json"} {
+		data, ok := files[name]
+		if !ok {
+			continue
+		}
+		if err = ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return written, err
+		}
+		written = append(written, name)
+	}
+	return written, nil
+}
+
+// sitePage is the data site page templates execute with
+type sitePage struct {
+	Dataset *dataset.Dataset
+	Ref     string
+	// Page is the file name of the page being rendered
+	Page    string
+	Viz     string
+	Readme  template.HTML
+	Body    siteTable
+	Sampled bool
+}
+
+// siteTable is a body laid out as rows of cells
+type siteTable struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// siteBody lays out body entries as a table. Rows of objects are split into
+// their properties, rows of arrays into their items. Any other entry is a
+// single cell
+func siteBody(body interface{}, st *dataset.Structure) siteTable {
+	t := siteTable{}
+	if st != nil {
+		for _, c := range Columns(st.Schema) {
+			t.Columns = append(t.Columns, c.Name)
+		}
+	}
+
+	switch b := body.(type) {
+	case []interface{}:
+		for _, row := range b {
+			t.Rows = append(t.Rows, siteRow(row, t.Columns))
+		}
+	case map[string]interface{}:
+		keys := sortedKeys(b)
+		t.Columns = []string{"key", "value"}
+		for _, k := range keys {
+			t.Rows = append(t.Rows, []string{k, siteCell(b[k])})
+		}
+	}
+	return t
+}
+
+func siteRow(row interface{}, cols []string) []string {
+	switch r := row.(type) {
+	case []interface{}:
+		cells := make([]string, len(r))
+		for i, v := range r {
+			cells[i] = siteCell(v)
+		}
+		return cells
+	case map[string]interface{}:
+		if len(cols) == 0 {
+			cols = sortedKeys(r)
+		}
+		cells := make([]string, len(cols))
+		for i, c := range cols {
+			cells[i] = siteCell(r[c])
+		}
+		return cells
+	}
+	return []string{siteCell(row)}
+}
+
+func siteCell(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var siteTemplate = template.Must(template.New("site").Parse(`
+{{ define "layout" }}<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>{{ .Ref }}</title>
+  <style>
+    body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #303030; }
+    nav { background: #0c2a48; padding: 0.8em 2em; }
+    nav a { color: #fff; margin-right: 1.5em; text-decoration: none; }
+    nav a.active { border-bottom: 2px solid #fff; }
+    main { padding: 1em 2em; }
+    table { border-collapse: collapse; font-size: 0.9em; }
+    th, td { border: 1px solid #ddd; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+    th { background: #f4f4f4; }
+    dt { font-weight: bold; margin-top: 0.8em; }
+    iframe { border: none; width: 100%; height: 90vh; }
+    .note { color: #888; }
+  </style>
+</head>
+<body>
+  <nav>
+    <a href="index.html"{{ if eq .Page "index.html" }} class="active"{{ end }}>{{ .Ref }}</a>
+    <a href="meta.html"{{ if eq .Page "meta.html" }} class="active"{{ end }}>meta</a>
+    <a href="body.html"{{ if eq .Page "body.html" }} class="active"{{ end }}>body</a>
+    {{ if .Readme }}<a href="readme.html"{{ if eq .Page "readme.html" }} class="active"{{ end }}>readme</a>{{ end }}
+  </nav>
+  <main>{{ template "content" . }}</main>
+</body>
+</html>{{ end }}
+
+{{ define "index.html" }}{{ template "layout" . }}{{ end }}
+{{ define "meta.html" }}{{ template "layout" . }}{{ end }}
+{{ define "body.html" }}{{ template "layout" . }}{{ end }}
+{{ define "readme.html" }}{{ template "layout" . }}{{ end }}
+
+{{ define "content" }}
+{{ if eq .Page "index.html" }}<iframe srcdoc="{{ .Viz }}"></iframe>{{ end }}
+{{ if eq .Page "meta.html" }}{{ template "meta" . }}{{ end }}
+{{ if eq .Page "body.html" }}{{ template "body" . }}{{ end }}
+{{ if eq .Page "readme.html" }}{{ .Readme }}{{ end }}
+{{ end }}
+
+{{ define "meta" }}{{ with .Dataset.Meta }}
+<h1>{{ if .Title }}{{ .Title }}{{ else }}{{ $.Ref }}{{ end }}</h1>
+{{ if .Description }}<p>{{ .Description }}</p>{{ end }}
+<dl>
+  {{ if .Keywords }}<dt>keywords</dt><dd>{{ range $i, $k := .Keywords }}{{ if $i }}, {{ end }}{{ $k }}{{ end }}</dd>{{ end }}
+  {{ if .Theme }}<dt>theme</dt><dd>{{ range $i, $k := .Theme }}{{ if $i }}, {{ end }}{{ $k }}{{ end }}</dd>{{ end }}
+  {{ if .License }}<dt>license</dt><dd>{{ if .License.URL }}<a href="{{ .License.URL }}">{{ .License.Type }}</a>{{ else }}{{ .License.Type }}{{ end }}</dd>{{ end }}
+  {{ if .Contributors }}<dt>contributors</dt>{{ range .Contributors }}<dd>{{ .Name }}{{ if .Email }} &lt;{{ .Email }}&gt;{{ end }}</dd>{{ end }}{{ end }}
+  {{ if .Citations }}<dt>citations</dt>{{ range .Citations }}<dd>{{ if .URL }}<a href="{{ .URL }}">{{ if .Name }}{{ .Name }}{{ else }}{{ .URL }}{{ end }}</a>{{ else }}{{ .Name }}{{ end }}</dd>{{ end }}{{ end }}
+  {{ if .HomeURL }}<dt>home</dt><dd><a href="{{ .HomeURL }}">{{ .HomeURL }}</a></dd>{{ end }}
+  {{ if .DownloadURL }}<dt>download</dt><dd><a href="{{ .DownloadURL }}">{{ .DownloadURL }}</a></dd>{{ end }}
+  {{ if .AccrualPeriodicity }}<dt>updated</dt><dd>{{ .AccrualPeriodicity }}</dd>{{ end }}
+</dl>
+{{ else }}<h1>{{ .Ref }}</h1><p class="note">this dataset has no meta</p>{{ end }}
+{{ with .Dataset.Commit }}<p class="note">{{ .Title }}, committed {{ .Timestamp.Format "Jan 2, 2006" }}</p>{{ end }}
+{{ end }}
+
+{{ define "body" }}
+{{ if .Body.Rows }}
+<p class="note">{{ if .Sampled }}showing the first {{ len .Body.Rows }} of {{ .Dataset.Structure.Entries }} entries{{ else }}{{ len .Body.Rows }} entries{{ end }}, <a href="body.json">download as JSON</a></p>
+<table>
+  {{ if .Body.Columns }}<thead><tr>{{ range .Body.Columns }}<th>{{ . }}</th>{{ end }}</tr></thead>{{ end }}
+  <tbody>{{ range .Body.Rows }}<tr>{{ range . }}<td>{{ . }}</td>{{ end }}</tr>{{ end }}</tbody>
+</table>
+{{ else }}<p class="note">this dataset has no body</p>{{ end }}
+{{ end }}
+`))
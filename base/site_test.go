@@ -0,0 +1,102 @@
+package base
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dstest"
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qfs"
+	repotest "github.com/qri-io/qri/repo/test"
+)
+
+func TestExportSite(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	ref := addCitiesDataset(t, r)
+
+	tmp, err := ioutil.TempDir("", "export_site")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	dir := filepath.Join(tmp, "site")
+
+	written, err := ExportSite(ctx, r, ref, dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "index.html,meta.html,body.html,body.json"
+	if got := strings.Join(written, ","); got != expect {
+		t.Errorf("written files mismatch. want: %s got: %s", expect, got)
+	}
+
+	read := func(name string) string {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	if index := read("index.html"); !strings.Contains(index, "<iframe srcdoc=") || !strings.Contains(index, `href="meta.html"`) {
+		t.Errorf("expected index to embed the rendered viz & link pages, got:\n%s", index)
+	}
+	if meta := read("meta.html"); !strings.Contains(meta, "<h1>") {
+		t.Errorf("expected meta page to have a heading, got:\n%s", meta)
+	}
+	body := read("body.html")
+	if !strings.Contains(body, "showing the first 2 of 5 entries") || strings.Count(body, "<tr>") != 3 {
+		t.Errorf("expected body page to show a 2 entry sample with a header, got:\n%s", body)
+	}
+	if !strings.Contains(body, "<th>city</th>") {
+		t.Errorf("expected body table to title columns, got:\n%s", body)
+	}
+
+	if _, err := ExportSite(ctx, r, ref, dir, 2); err == nil {
+		t.Error("expected exporting to a directory that isn't empty to error")
+	}
+}
+
+func TestExportSiteSanitizesHTML(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	tc, err := dstest.NewTestCaseFromDir(repotest.TestdataPath("cities"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := tc.Input
+	ds.Viz = &dataset.Viz{Format: "html"}
+	ds.Viz.SetScriptFile(qfs.NewMemfileBytes("viz.html", []byte(`<h1>cities</h1><script>alert("viz")</script>`)))
+	ds.Readme = &dataset.Readme{Format: "md"}
+	ds.Readme.SetScriptFile(qfs.NewMemfileBytes("readme.md", []byte("# cities\n\n<script>alert(\"readme\")</script>")))
+	ref, err := CreateDataset(ctx, r, ioes.NewDiscardIOStreams(), ds, nil, false, true, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmp, err := ioutil.TempDir("", "export_site_sanitize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	dir := filepath.Join(tmp, "site")
+	if _, err := ExportSite(ctx, r, ref, dir, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"index.html", "readme.html"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page := string(data); strings.Contains(page, "alert") {
+			t.Errorf("expected %s to strip scripts, got:\n%s", name, page)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package base
+
+import (
+	"fmt"
+
+	"github.com/qri-io/dataset"
+)
+
+const (
+	// valueBytes is the memory a decoded value takes beyond its contents: an
+	// interface{} header plus the boxed value or string header
+	valueBytes = 32
+	// arrayEntryBytes is the memory of the slice header of a decoded array row
+	arrayEntryBytes = 24
+	// objectFieldBytes is the memory a field of a decoded object row takes in
+	// its map, beyond the key & value
+	objectFieldBytes = 48
+	// bytesPerValue guesses the number of values in a body with no schema
+	// columns, assuming a value & its separators take this many bytes
+	bytesPerValue = 8
+	// xlsxInflation is how many times larger xlsx cell data is than the
+	// compressed workbook file
+	xlsxInflation = 4
+)
+
+// SizeEstimate estimates the memory reading an entire dataset body takes
+type SizeEstimate struct {
+	// BodyBytes is the length of the body file in bytes
+	BodyBytes int `json:"bodyBytes"`
+	// Entries is the number of top-level entries in the body, zero if unknown
+	Entries int `json:"entries"`
+	// DecodedBytes estimates the memory the decoded body takes, in bytes. It's
+	// a rough guess from structure, meant for deciding whether to stream
+	DecodedBytes int64 `json:"decodedBytes"`
+}
+
+// EstimateSize estimates the memory needed to decode a body from its
+// structure alone, without reading the body
+func EstimateSize(st *dataset.Structure) (*SizeEstimate, error) {
+	if st == nil {
+		return nil, fmt.Errorf("dataset has no structure")
+	}
+	est := &SizeEstimate{BodyBytes: st.Length, Entries: st.Entries}
+
+	// values hold at most the contents of the body file, less once separators
+	// & quotes are dropped
+	content := int64(st.Length)
+	if st.DataFormat() == dataset.XLSXDataFormat {
+		content *= xlsxInflation
+	}
+
+	cols := Columns(st.Schema)
+	entries := int64(st.Entries)
+	var values int64
+	if len(cols) > 0 && entries > 0 {
+		values = entries * int64(len(cols))
+	} else {
+		values = content / bytesPerValue
+	}
+
+	est.DecodedBytes = content + values*valueBytes
+	if items, ok := st.Schema["items"].(map[string]interface{}); ok && items["type"] == "object" {
+		est.DecodedBytes += values * objectFieldBytes
+	} else {
+		est.DecodedBytes += entries * arrayEntryBytes
+	}
+	return est, nil
+}
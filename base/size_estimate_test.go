@@ -0,0 +1,50 @@
+package base
+
+import (
+	"testing"
+
+	"github.com/qri-io/dataset"
+)
+
+func TestEstimateSize(t *testing.T) {
+	if _, err := EstimateSize(nil); err == nil {
+		t.Error("expected estimating a dataset without structure to error")
+	}
+
+	tabular := &dataset.Structure{
+		Format:  "csv",
+		Length:  1000,
+		Entries: 10,
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "a"},
+					map[string]interface{}{"title": "b"},
+				},
+			},
+		},
+	}
+	est, err := EstimateSize(tabular)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.BodyBytes != 1000 || est.Entries != 10 {
+		t.Errorf("expected body length & entries from structure, got: %d, %d", est.BodyBytes, est.Entries)
+	}
+	if expect := int64(1000 + 20*valueBytes + 10*arrayEntryBytes); est.DecodedBytes != expect {
+		t.Errorf("decoded bytes mismatch. expected: %d, got: %d", expect, est.DecodedBytes)
+	}
+
+	xlsx := &dataset.Structure{Format: "xlsx", Length: 1000, Entries: 10, Schema: tabular.Schema}
+	if xest, _ := EstimateSize(xlsx); xest.DecodedBytes <= est.DecodedBytes {
+		t.Errorf("expected compressed xlsx bodies to decode larger than csv, got: %d <= %d", xest.DecodedBytes, est.DecodedBytes)
+	}
+
+	// bodies without schema columns guess values from length
+	untyped := &dataset.Structure{Format: "json", Length: 800, Schema: dataset.BaseSchemaArray}
+	if uest, _ := EstimateSize(untyped); uest.DecodedBytes != int64(800+100*valueBytes) {
+		t.Errorf("untyped decoded bytes mismatch. expected: %d, got: %d", 800+100*valueBytes, uest.DecodedBytes)
+	}
+}
@@ -0,0 +1,620 @@
+package base
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	crypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/identity"
+	"github.com/qri-io/qri/logbook"
+	"github.com/qri-io/qri/logbook/oplog"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// SnapshotFormatVersion is the version of the archive format SnapshotRepo
+// writes. RestoreRepo rejects archives of other versions
+const SnapshotFormatVersion = 1
+
+const (
+	snapshotManifestFile = "manifest.json"
+	snapshotLogsDir      = "logbook/"
+	snapshotVersionsDir  = "versions/"
+	snapshotDatasetFile  = "dataset.json"
+	snapshotBodyDir      = "body/"
+)
+
+// files of a version other than the dataset document & body, by the name
+// they're archived under
+const (
+	snapshotTransformScript = "transform_script"
+	snapshotVizScript       = "viz_script"
+	snapshotVizRendered     = "viz_rendered"
+	snapshotReadmeScript    = "readme_script"
+	snapshotReadmeRendered  = "readme_rendered"
+)
+
+// SnapshotManifest is the first entry of a repo snapshot, describing what
+// the archive holds
+type SnapshotManifest struct {
+	FormatVersion int       `json:"formatVersion"`
+	Created       time.Time `json:"created"`
+	// Profile is the profile of the snapshotted repo. Private keys aren't
+	// included, back up the config file to keep them
+	Profile *config.ProfilePod `json:"profile"`
+	// AuthorPubKey is the base64-encoded public key logbook entries are
+	// signed with
+	AuthorPubKey string `json:"authorPubKey"`
+	// Datasets lists references of the repo with their stored versions
+	Datasets []SnapshotDataset `json:"datasets"`
+}
+
+// SnapshotDataset is a reference in a snapshot
+type SnapshotDataset struct {
+	Peername  string `json:"peername"`
+	ProfileID string `json:"profileID"`
+	Name      string `json:"name"`
+	Published bool   `json:"published,omitempty"`
+	// Path is the head version
+	Path string `json:"path"`
+	// Versions lists the paths of every stored version, oldest first.
+	// Versions that aren't stored locally are left out
+	Versions []string `json:"versions"`
+}
+
+// SnapshotRepo writes an archive of a repo's datasets, every stored version
+// with its components & body, the logbook, and the repo profile. The archive
+// is a tar stream that RestoreRepo reads back. Bodies are spooled through
+// temp files one at a time, so snapshots of large repos aren't buffered in
+// memory
+func SnapshotRepo(ctx context.Context, r repo.Repo, w io.Writer) error {
+	pro, err := r.Profile()
+	if err != nil {
+		return err
+	}
+	pod, err := pro.Encode()
+	if err != nil {
+		return err
+	}
+	mf := SnapshotManifest{
+		FormatVersion: SnapshotFormatVersion,
+		Created:       time.Now().UTC(),
+		Profile:       pod,
+	}
+
+	book := r.Logbook()
+	if book != nil {
+		data, err := crypto.MarshalPublicKey(book.AuthorPubKey())
+		if err != nil {
+			return err
+		}
+		mf.AuthorPubKey = base64.StdEncoding.EncodeToString(data)
+	}
+
+	count, err := r.RefCount()
+	if err != nil {
+		return err
+	}
+	refs, err := r.References(0, count)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if ref.Path == "" {
+			// references that only link a working directory have no versions
+			continue
+		}
+		sd := SnapshotDataset{
+			Peername:  ref.Peername,
+			ProfileID: ref.ProfileID.String(),
+			Name:      ref.Name,
+			Published: ref.Published,
+			Path:      ref.Path,
+		}
+		if sd.Versions, err = storedVersions(ctx, r, ref.Path); err != nil {
+			return err
+		}
+		mf.Datasets = append(mf.Datasets, sd)
+	}
+
+	tw := tar.NewWriter(w)
+	mfdata, err := json.Marshal(mf)
+	if err != nil {
+		return err
+	}
+	if err = WriteTarBytes(tw, snapshotManifestFile, mfdata); err != nil {
+		return err
+	}
+
+	if book != nil {
+		logs, err := book.ListAllLogs(ctx)
+		if err != nil {
+			return err
+		}
+		for i, l := range logs {
+			data, err := book.LogBytes(l)
+			if err != nil {
+				return err
+			}
+			if err = WriteTarBytes(tw, fmt.Sprintf("%s%d", snapshotLogsDir, i), data); err != nil {
+				return err
+			}
+		}
+	}
+
+	written := map[string]bool{}
+	for _, sd := range mf.Datasets {
+		for _, p := range sd.Versions {
+			if written[p] {
+				continue
+			}
+			written[p] = true
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := snapshotVersion(ctx, r, tw, p); err != nil {
+				return fmt.Errorf("snapshotting %s/%s version %s: %w", sd.Peername, sd.Name, p, err)
+			}
+		}
+	}
+
+	return tw.Close()
+}
+
+// storedVersions walks the history of a version, returning the paths of
+// versions that are stored locally, oldest first
+func storedVersions(ctx context.Context, r repo.Repo, head string) ([]string, error) {
+	var versions []string
+	seen := map[string]bool{}
+	for p := head; p != "" && !seen[p]; {
+		seen[p] = true
+		ds, err := dsfs.LoadDataset(ctx, r.Store(), p)
+		if err != nil {
+			if p == head {
+				return nil, fmt.Errorf("loading %s: %w", p, err)
+			}
+			// earlier versions may not have been fetched from a peer
+			break
+		}
+		versions = append(versions, p)
+		p = ds.PreviousPath
+	}
+	for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+		versions[i], versions[j] = versions[j], versions[i]
+	}
+	return versions, nil
+}
+
+func snapshotVersionDir(p string) string {
+	return snapshotVersionsDir + strings.TrimPrefix(p, "/") + "/"
+}
+
+// snapshotVersion archives a stored version. Files are archived as they're
+// stored, keeping compressed bodies compressed
+func snapshotVersion(ctx context.Context, r repo.Repo, tw *tar.Writer, p string) error {
+	ds, err := dsfs.LoadDataset(ctx, r.Store(), p)
+	if err != nil {
+		return err
+	}
+	dir := snapshotVersionDir(p)
+
+	data, err := json.Marshal(ds)
+	if err != nil {
+		return err
+	}
+	if err = WriteTarBytes(tw, dir+snapshotDatasetFile, data); err != nil {
+		return err
+	}
+
+	if ds.BodyPath != "" && dsfs.ExternalBodyURI(ds) == "" {
+		f, err := r.Store().Get(ctx, ds.BodyPath)
+		if err != nil {
+			return fmt.Errorf("getting body: %w", err)
+		}
+		name := path.Base(f.FileName())
+		if name == "" || name == "." || name == "/" {
+			name = path.Base(ds.BodyPath)
+		}
+		err = WriteTarFile(tw, dir+snapshotBodyDir+name, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	files := map[string]string{}
+	if ds.Transform != nil {
+		files[snapshotTransformScript] = ds.Transform.ScriptPath
+	}
+	if ds.Viz != nil {
+		files[snapshotVizScript] = ds.Viz.ScriptPath
+		files[snapshotVizRendered] = ds.Viz.RenderedPath
+	}
+	if ds.Readme != nil {
+		files[snapshotReadmeScript] = ds.Readme.ScriptPath
+		files[snapshotReadmeRendered] = ds.Readme.RenderedPath
+	}
+	for _, name := range []string{snapshotTransformScript, snapshotVizScript, snapshotVizRendered, snapshotReadmeScript, snapshotReadmeRendered} {
+		fp := files[name]
+		if fp == "" {
+			continue
+		}
+		f, err := r.Store().Get(ctx, fp)
+		if err != nil {
+			return fmt.Errorf("getting %s: %w", name, err)
+		}
+		err = WriteTarFile(tw, dir+name, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTarBytes archives a byte slice as a tar entry. Entries have a fixed
+// modification time so archives of the same content are identical
+func WriteTarBytes(tw *tar.Writer, name string, data []byte) error {
+	return WriteTarEntry(tw, name, int64(len(data)), bytes.NewReader(data))
+}
+
+// WriteTarFile archives a file of unknown size, spooling it to a temp file
+// to find its size before writing
+func WriteTarFile(tw *tar.Writer, name string, r io.Reader) error {
+	tmp, err := ioutil.TempFile("", "qri_snapshot")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return WriteTarEntry(tw, name, size, tmp)
+}
+
+// WriteTarEntry archives size bytes read from r as a tar entry
+func WriteTarEntry(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: size, ModTime: time.Unix(0, 0)}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := io.Copy(tw, r)
+	return err
+}
+
+// RestoreReport describes a restored snapshot
+type RestoreReport struct {
+	Datasets int `json:"datasets"`
+	Versions int `json:"versions"`
+	Logs     int `json:"logs"`
+	// ProfileRestored is true when the snapshot profile was applied to the
+	// repo. Profiles are only restored onto repos with the same profile ID
+	ProfileRestored bool `json:"profileRestored"`
+	// Moved maps versions that were stored at a different path than they
+	// had when snapshotted, which happens when the stores of the two repos
+	// hash differently
+	Moved map[string]string `json:"moved,omitempty"`
+	// Warnings lists problems that didn't stop the restore
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// RestoreRepo writes the contents of an archive created by SnapshotRepo
+// into a repo, storing & pinning every version, merging the logbook and
+// adding references. Existing references with the same names are replaced
+func RestoreRepo(ctx context.Context, r repo.Repo, rd io.Reader) (*RestoreReport, error) {
+	tr := tar.NewReader(rd)
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot: %w", err)
+	}
+	if hdr.Name != snapshotManifestFile {
+		return nil, fmt.Errorf("invalid snapshot: expected %s first, found %s", snapshotManifestFile, hdr.Name)
+	}
+	mf := SnapshotManifest{}
+	if err := json.NewDecoder(tr).Decode(&mf); err != nil {
+		return nil, fmt.Errorf("invalid snapshot manifest: %w", err)
+	}
+	if mf.FormatVersion != SnapshotFormatVersion {
+		return nil, fmt.Errorf("unsupported snapshot format version %d, expected %d", mf.FormatVersion, SnapshotFormatVersion)
+	}
+
+	report := &RestoreReport{Moved: map[string]string{}}
+	if err := restoreProfile(r, mf.Profile, report); err != nil {
+		return nil, err
+	}
+
+	var author identity.Author
+	if mf.AuthorPubKey != "" {
+		data, err := base64.StdEncoding.DecodeString(mf.AuthorPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot author key: %w", err)
+		}
+		pub, err := crypto.UnmarshalPublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot author key: %w", err)
+		}
+		id, err := identity.KeyIDFromPub(pub)
+		if err != nil {
+			return nil, err
+		}
+		author = identity.NewAuthor(id, pub)
+	}
+
+	var pending *pendingVersion
+	flush := func() error {
+		if pending == nil {
+			return nil
+		}
+		defer pending.close()
+		p, err := pending.write(ctx, r, report.Moved)
+		if err != nil {
+			return fmt.Errorf("restoring version %s: %w", pending.path, err)
+		}
+		if p != pending.path {
+			report.Moved[pending.path] = p
+		}
+		report.Versions++
+		pending = nil
+		return nil
+	}
+	defer func() {
+		if pending != nil {
+			pending.close()
+		}
+	}()
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading snapshot: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case strings.HasPrefix(hdr.Name, snapshotLogsDir):
+			if err := restoreLog(ctx, r, author, tr, report); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(hdr.Name, snapshotVersionsDir):
+			rel := strings.TrimPrefix(hdr.Name, snapshotVersionsDir)
+			if strings.HasSuffix(rel, "/"+snapshotDatasetFile) {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+				p := "/" + strings.TrimSuffix(rel, "/"+snapshotDatasetFile)
+				ds := &dataset.Dataset{}
+				if err := json.NewDecoder(tr).Decode(ds); err != nil {
+					return nil, fmt.Errorf("invalid snapshot version %s: %w", p, err)
+				}
+				pending = &pendingVersion{path: p, ds: ds, files: map[string][]byte{}}
+				continue
+			}
+			if pending == nil || !strings.HasPrefix(hdr.Name, snapshotVersionDir(pending.path)) {
+				return nil, fmt.Errorf("invalid snapshot: unexpected file %s", hdr.Name)
+			}
+			name := strings.TrimPrefix(hdr.Name, snapshotVersionDir(pending.path))
+			if strings.HasPrefix(name, snapshotBodyDir) {
+				if err := pending.spoolBody(strings.TrimPrefix(name, snapshotBodyDir), tr); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			data, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			pending.files[name] = data
+		default:
+			report.Warnings = append(report.Warnings, fmt.Sprintf("skipped unknown snapshot file %s", hdr.Name))
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	pro, err := r.Profile()
+	if err != nil {
+		return nil, err
+	}
+	for _, sd := range mf.Datasets {
+		pid := pro.ID
+		if sd.ProfileID != pro.ID.String() {
+			if pid, err = profile.IDB58Decode(sd.ProfileID); err != nil {
+				return nil, fmt.Errorf("invalid profile ID for %s/%s: %w", sd.Peername, sd.Name, err)
+			}
+		}
+		ref := reporef.DatasetRef{
+			Peername:  sd.Peername,
+			ProfileID: pid,
+			Name:      sd.Name,
+			Path:      sd.Path,
+			Published: sd.Published,
+		}
+		if moved, ok := report.Moved[ref.Path]; ok {
+			ref.Path = moved
+		}
+		if err := r.PutRef(ref); err != nil {
+			return nil, err
+		}
+		report.Datasets++
+	}
+
+	if len(report.Moved) == 0 {
+		report.Moved = nil
+	}
+	return report, nil
+}
+
+// restoreProfile applies the details of a snapshot profile to a repo when
+// both belong to the same profile ID
+func restoreProfile(r repo.Repo, pod *config.ProfilePod, report *RestoreReport) error {
+	if pod == nil {
+		return nil
+	}
+	current, err := r.Profile()
+	if err != nil {
+		return err
+	}
+	if current.ID.String() != pod.ID {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("snapshot belongs to profile %s (%s), not this repo's profile %s, datasets are restored as that profile's", pod.Peername, pod.ID, current.ID))
+		return nil
+	}
+	pro := *current
+	pro.Peername = pod.Peername
+	pro.Name = pod.Name
+	pro.Email = pod.Email
+	pro.Description = pod.Description
+	pro.HomeURL = pod.HomeURL
+	pro.Color = pod.Color
+	pro.Twitter = pod.Twitter
+	pro.Photo = pod.Photo
+	pro.Thumb = pod.Thumb
+	pro.Poster = pod.Poster
+	if err := r.SetProfile(&pro); err != nil {
+		return err
+	}
+	report.ProfileRestored = true
+	return nil
+}
+
+func restoreLog(ctx context.Context, r repo.Repo, author identity.Author, rd io.Reader, report *RestoreReport) error {
+	book := r.Logbook()
+	if book == nil || author == nil {
+		report.Warnings = append(report.Warnings, "skipped logbook, the repo or snapshot has no logbook")
+		return nil
+	}
+	data, err := ioutil.ReadAll(rd)
+	if err != nil {
+		return err
+	}
+	lg, err := oplog.FromFlatbufferBytes(data)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot log: %w", err)
+	}
+	if lg.Model() == logbook.AuthorModel && lg.Name() == book.AuthorName() && lg.ID() != book.AuthorID() {
+		// the repo was re-initialized under the same name, graft the dataset
+		// logs of the snapshot onto this repo's author log, so they keep
+		// resolving by name
+		if err := lg.Verify(author.AuthorPubKey()); err != nil {
+			return fmt.Errorf("verifying log: %w", err)
+		}
+		local, err := book.Log(ctx, book.AuthorID())
+		if err != nil {
+			return err
+		}
+		grafted := local.DeepCopy()
+		for _, l := range lg.Logs {
+			grafted.AddChild(l)
+		}
+		// LogBytes signs the log with this repo's key
+		if _, err := book.LogBytes(grafted); err != nil {
+			return err
+		}
+		lg, author = grafted, book.Author()
+	}
+	if err := book.MergeLog(ctx, author, lg); err != nil {
+		return fmt.Errorf("merging log: %w", err)
+	}
+	report.Logs++
+	return nil
+}
+
+// pendingVersion collects the files of an archived version until all have
+// been read
+type pendingVersion struct {
+	path     string
+	ds       *dataset.Dataset
+	files    map[string][]byte
+	bodyName string
+	body     *os.File
+}
+
+func (v *pendingVersion) spoolBody(name string, rd io.Reader) error {
+	tmp, err := ioutil.TempFile("", "qri_restore")
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(tmp, rd); err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	v.bodyName, v.body = name, tmp
+	return nil
+}
+
+func (v *pendingVersion) close() {
+	if v.body != nil {
+		v.body.Close()
+		os.Remove(v.body.Name())
+		v.body = nil
+	}
+}
+
+// write stores the version, linking it to the restored path of its previous
+// version
+func (v *pendingVersion) write(ctx context.Context, r repo.Repo, moved map[string]string) (string, error) {
+	ds := v.ds
+	if p, ok := moved[ds.PreviousPath]; ok {
+		ds.PreviousPath = p
+	}
+	if v.body != nil {
+		ds.SetBodyFile(qfs.NewMemfileReader(v.bodyName, v.body))
+	}
+	script := func(name string) qfs.File {
+		if data, ok := v.files[name]; ok {
+			return qfs.NewMemfileReader(name, bytes.NewReader(data))
+		}
+		return nil
+	}
+	if ds.Transform != nil {
+		if f := script(snapshotTransformScript); f != nil {
+			ds.Transform.SetScriptFile(f)
+		}
+	}
+	if ds.Viz != nil {
+		if f := script(snapshotVizScript); f != nil {
+			ds.Viz.SetScriptFile(f)
+		}
+		if f := script(snapshotVizRendered); f != nil {
+			ds.Viz.SetRenderedFile(f)
+		}
+	}
+	if ds.Readme != nil {
+		if f := script(snapshotReadmeScript); f != nil {
+			ds.Readme.SetScriptFile(f)
+		}
+		if f := script(snapshotReadmeRendered); f != nil {
+			ds.Readme.SetRenderedFile(f)
+		}
+	}
+	return dsfs.WriteDataset(ctx, r.Store(), ds, true)
+}
@@ -0,0 +1,77 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/qri-io/qri/base/dsfs"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestSnapshotRestoreRepo(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	addCitiesDataset(t, r)
+	head := updateCitiesDataset(t, r, "second version")
+
+	buf := &bytes.Buffer{}
+	if err := SnapshotRepo(ctx, r, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := newTestRepo(t)
+	report, err := RestoreRepo(ctx, fresh, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Datasets != 1 || report.Versions != 2 || !report.ProfileRestored {
+		t.Errorf("unexpected restore report: %#v", report)
+	}
+	if len(report.Moved) != 0 {
+		t.Errorf("expected versions to restore at the paths they were stored at, got: %v", report.Moved)
+	}
+
+	got, err := fresh.GetRef(reporef.DatasetRef{Peername: head.Peername, Name: head.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != head.Path {
+		t.Errorf("head mismatch. expected: %s, got: %s", head.Path, got.Path)
+	}
+
+	ds, err := dsfs.LoadDataset(ctx, fresh.Store(), got.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Meta.Title != "second version" {
+		t.Errorf("expected restored meta title, got: %q", ds.Meta.Title)
+	}
+	if _, err := dsfs.LoadDataset(ctx, fresh.Store(), ds.PreviousPath); err != nil {
+		t.Errorf("expected previous version to be restored: %s", err)
+	}
+	if err := OpenDataset(ctx, fresh.Filesystem(), ds); err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(ds.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(body) == 0 {
+		t.Error("expected restored body to have data")
+	}
+
+	if fresh.Logbook() != nil {
+		if report.Logs == 0 {
+			t.Error("expected logs to be restored")
+		}
+		if _, err := fresh.Logbook().Versions(ctx, reporef.ConvertToDsref(got), 0, 10); err != nil {
+			t.Errorf("expected restored dataset to have a log: %s", err)
+		}
+	}
+
+	if _, err := RestoreRepo(ctx, fresh, bytes.NewReader([]byte("not a snapshot"))); err == nil {
+		t.Error("expected restoring invalid data to fail")
+	}
+}
@@ -0,0 +1,108 @@
+package base
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/qri-io/dataset"
+)
+
+// sniffLen is the number of bytes SniffDataFormat needs to make a decision
+const sniffLen = 4096
+
+// ErrUnknownBodyFormat indicates a body format couldn't be determined from
+// either a file extension or the body contents
+var ErrUnknownBodyFormat = fmt.Errorf("cannot determine body format, please specify structure.format")
+
+// SniffDataFormat guesses the data format of a body by looking at it's
+// leading bytes. It can tell JSON from CSV, and reports newline-delimited
+// JSON as unsupported instead of mistaking it for either
+func SniffDataFormat(peek []byte) (dataset.DataFormat, error) {
+	peek = bytes.TrimPrefix(peek, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimSpace(peek)
+	if len(trimmed) == 0 {
+		return dataset.UnknownDataFormat, ErrUnknownBodyFormat
+	}
+
+	// CBOR is a binary format, the only one we support that isn't valid text.
+	// arrays & maps are the only valid top level types, which start with major
+	// type 4 (0x80-0x9f) or 5 (0xa0-0xbf)
+	if !utf8.Valid(truncateRunes(peek)) {
+		if b := peek[0]; b >= 0x80 && b <= 0xbf {
+			return dataset.CBORDataFormat, nil
+		}
+		return dataset.UnknownDataFormat, ErrUnknownBodyFormat
+	}
+
+	switch trimmed[0] {
+	case '[':
+		return dataset.JSONDataFormat, nil
+	case '{':
+		if isJSONLines(trimmed) {
+			return dataset.UnknownDataFormat, fmt.Errorf("newline-delimited JSON bodies are not supported, please convert to a JSON array")
+		}
+		return dataset.JSONDataFormat, nil
+	}
+
+	if looksLikeCSV(trimmed, len(peek) >= sniffLen) {
+		return dataset.CSVDataFormat, nil
+	}
+	return dataset.UnknownDataFormat, ErrUnknownBodyFormat
+}
+
+// truncateRunes drops a trailing partial utf8 sequence that may have been cut
+// by a fixed-length peek
+func truncateRunes(p []byte) []byte {
+	for i := 0; i < utf8.UTFMax && len(p) > 0; i++ {
+		if r, _ := utf8.DecodeLastRune(p); r != utf8.RuneError {
+			break
+		}
+		p = p[:len(p)-1]
+	}
+	return p
+}
+
+// isJSONLines checks if the first two lines of data are each a complete JSON
+// object
+func isJSONLines(data []byte) bool {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	complete := 0
+	for sc.Scan() && complete < 2 {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] != '{' || !json.Valid(line) {
+			return false
+		}
+		complete++
+	}
+	return complete == 2
+}
+
+// looksLikeCSV checks that the complete lines of data have the same, non-zero
+// number of commas. A single line with at least one comma counts
+func looksLikeCSV(data []byte, truncated bool) bool {
+	lines := bytes.Split(data, []byte("\n"))
+	// the last line of a truncated peek may be partial, skip it if we have others
+	if truncated && len(lines) > 1 {
+		lines = lines[:len(lines)-1]
+	}
+	commas := -1
+	for _, line := range lines {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if len(line) == 0 {
+			continue
+		}
+		n := bytes.Count(line, []byte(","))
+		if commas == -1 {
+			commas = n
+		} else if n != commas && !bytes.Contains(line, []byte(`"`)) {
+			return false
+		}
+	}
+	return commas > 0
+}
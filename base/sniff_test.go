@@ -0,0 +1,74 @@
+package base
+
+import (
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+func TestSniffDataFormat(t *testing.T) {
+	cases := []struct {
+		data   string
+		expect dataset.DataFormat
+		err    string
+	}{
+		{"", dataset.UnknownDataFormat, "cannot determine body format, please specify structure.format"},
+		{"just some words", dataset.UnknownDataFormat, "cannot determine body format, please specify structure.format"},
+		{`[["a",1],["b",2]]`, dataset.JSONDataFormat, ""},
+		{"\n  {\"a\": 1,\n \"b\": 2}", dataset.JSONDataFormat, ""},
+		{"{\"a\":1}\n{\"a\":2}\n", dataset.UnknownDataFormat, "newline-delimited JSON bodies are not supported, please convert to a JSON array"},
+		{"a,b,c\n1,2,3\n4,5,6", dataset.CSVDataFormat, ""},
+		{"a,b,c\r\n1,2,3\r\n", dataset.CSVDataFormat, ""},
+		{"name,note\nfoo,\"a, b\"\n", dataset.CSVDataFormat, ""},
+		{"a,b\n1,2,3,4\n", dataset.UnknownDataFormat, "cannot determine body format, please specify structure.format"},
+		{"\x82\x01\x02", dataset.CBORDataFormat, ""},
+	}
+
+	for i, c := range cases {
+		got, err := SniffDataFormat([]byte(c.data))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: %q, got: %v", i, c.err, err)
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case %d format mismatch. expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
+
+func TestInferValuesSniffsFormat(t *testing.T) {
+	r := newTestRepo(t)
+	pro, err := r.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds := &dataset.Dataset{}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body", []byte("city,pop\ntoronto,40000000\n")))
+	warnings, err := InferValues(pro, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Structure.Format != "csv" {
+		t.Errorf("expected sniffed format to be csv, got: %q", ds.Structure.Format)
+	}
+	if len(warnings) != 1 || warnings[0].Code != WarnSniffedFormat {
+		t.Errorf("expected a sniffed format warning, got: %v", warnings)
+	}
+
+	ds = &dataset.Dataset{}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.csv", []byte("city,pop\ntoronto,40000000\n")))
+	if warnings, err = InferValues(pro, ds); err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings when the extension gives the format, got: %v", warnings)
+	}
+
+	ds = &dataset.Dataset{}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.txt", []byte("no idea")))
+	if _, err = InferValues(pro, ds); err == nil {
+		t.Error("expected undetectable body format to error")
+	}
+}
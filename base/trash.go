@@ -0,0 +1,145 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qri-io/qri/logbook/oplog"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// ErrNoTrash indicates a repo doesn't keep removed datasets in a trash
+var ErrNoTrash = fmt.Errorf("repo doesn't keep a trash")
+
+// TrashOf returns the trash of a repo, or nil if the repo doesn't keep one
+func TrashOf(r repo.Repo) *repo.Trash {
+	if t, ok := r.(repo.Trasher); ok {
+		return t.Trash()
+	}
+	return nil
+}
+
+// CheckTrashable errors if a dataset can't be moved to the trash because the
+// trash already holds a dataset with the same name
+func CheckTrashable(r repo.Repo, ref reporef.DatasetRef) error {
+	trash := TrashOf(r)
+	if trash == nil {
+		return ErrNoTrash
+	}
+	if _, err := trash.Get(ref); err == nil {
+		return fmt.Errorf("a dataset named %s is %w in the trash, empty the trash or remove it permanently", ref.AliasString(), repo.ErrAlreadyExists)
+	}
+	return nil
+}
+
+// TrashDataset moves a dataset reference into the trash. Trashed datasets
+// aren't listed, but their versions stay pinned & their logbook is untouched
+// until the trash is emptied, so they can be restored. ref should be
+// canonicalized, the trash records its working directory & published flag
+func TrashDataset(ctx context.Context, r repo.Repo, ref reporef.DatasetRef) (repo.TrashEntry, error) {
+	trash := TrashOf(r)
+	if trash == nil {
+		return repo.TrashEntry{}, ErrNoTrash
+	}
+
+	e := repo.TrashEntry{Ref: ref, Removed: time.Now()}
+	e.Ref.Dataset = nil
+	if err := trash.Put(e); err == repo.ErrAlreadyExists {
+		return e, CheckTrashable(r, ref)
+	} else if err != nil {
+		return e, err
+	}
+	if err := r.DeleteRef(ref); err != nil {
+		if delErr := trash.Delete(ref); delErr != nil {
+			log.Debugf("TrashDataset: rolling back trash entry: %s", delErr)
+		}
+		return e, err
+	}
+	return e, nil
+}
+
+// RestoreTrashed brings a dataset back from the trash, returning the entry
+// it was restored from. The restored reference keeps its published flag, but
+// isn't linked to a working directory, callers are responsible for relinking
+func RestoreTrashed(ctx context.Context, r repo.Repo, ref reporef.DatasetRef) (repo.TrashEntry, error) {
+	trash := TrashOf(r)
+	if trash == nil {
+		return repo.TrashEntry{}, ErrNoTrash
+	}
+
+	e, err := trash.Get(ref)
+	if err == repo.ErrNotFound {
+		return e, fmt.Errorf("%s isn't in the trash", ref.AliasString())
+	} else if err != nil {
+		return e, err
+	}
+
+	if _, err := r.GetRef(reporef.DatasetRef{Peername: e.Ref.Peername, Name: e.Ref.Name}); err == nil {
+		return e, fmt.Errorf("a dataset named %s %w, rename it before restoring", e.Ref.AliasString(), repo.ErrAlreadyExists)
+	}
+
+	restored := e.Ref
+	restored.FSIPath = ""
+	if err := r.PutRef(restored); err != nil {
+		return e, err
+	}
+	return e, trash.Delete(restored)
+}
+
+// EmptyTrash permanently deletes datasets moved to the trash before cutoff,
+// unpinning their versions & writing their deletion to the logbook
+func EmptyTrash(ctx context.Context, r repo.Repo, cutoff time.Time) (deleted []repo.TrashEntry, err error) {
+	trash := TrashOf(r)
+	if trash == nil {
+		return nil, ErrNoTrash
+	}
+
+	for _, e := range trash.List() {
+		if !e.Removed.Before(cutoff) {
+			continue
+		}
+		if err := deleteTrashed(ctx, r, trash, e); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, e)
+	}
+	return deleted, nil
+}
+
+// PurgeTrashed permanently deletes a trashed dataset that shares a name with
+// ref, freeing the name for a new dataset. It's not an error if the trash
+// doesn't hold a dataset with that name
+func PurgeTrashed(ctx context.Context, r repo.Repo, ref reporef.DatasetRef) error {
+	trash := TrashOf(r)
+	if trash == nil {
+		return nil
+	}
+	if err := repo.CanonicalizeProfile(r, &ref); err != nil {
+		return err
+	}
+	e, err := trash.Get(ref)
+	if err == repo.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return deleteTrashed(ctx, r, trash, e)
+}
+
+func deleteTrashed(ctx context.Context, r repo.Repo, trash *repo.Trash, e repo.TrashEntry) error {
+	ref := reporef.ConvertToDsref(e.Ref)
+	if ref.Path != "" {
+		if _, err := RemoveNVersionsFromStore(ctx, r, ref, -1); err != nil {
+			log.Debugf("deleting versions of trashed %s: %s", ref, err)
+		}
+	}
+	if book := r.Logbook(); book != nil {
+		// datasets added from peers don't have a log to write to
+		if err := book.WriteDatasetDelete(ctx, ref); err != nil && err != oplog.ErrNotFound {
+			return err
+		}
+	}
+	return trash.Delete(e.Ref)
+}
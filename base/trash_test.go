@@ -0,0 +1,115 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestTrashDataset(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	ref := addCitiesDataset(t, r)
+	ref.Published = true
+	ref.Dataset = nil
+
+	if _, err := TrashDataset(ctx, r, ref); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.GetRef(reporef.DatasetRef{Peername: ref.Peername, Name: ref.Name}); err != repo.ErrNotFound {
+		t.Errorf("expected trashed dataset reference to be removed, got: %v", err)
+	}
+	if _, err := r.Store().Get(ctx, ref.Path); err != nil {
+		t.Errorf("expected trashed dataset to stay in the store, got: %s", err)
+	}
+	check, err := CheckRepo(ctx, r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(check.OrphanedLogs) != 0 {
+		t.Errorf("expected trashed dataset's log not to be orphaned, got: %v", check.OrphanedLogs)
+	}
+
+	// only one dataset of a name can be in the trash
+	if err := r.PutRef(ref); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TrashDataset(ctx, r, ref); !errors.Is(err, repo.ErrAlreadyExists) {
+		t.Errorf("expected trashing a name that's in the trash to fail, got: %v", err)
+	}
+	if err := r.DeleteRef(ref); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := RestoreTrashed(ctx, r, reporef.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Ref.Path != ref.Path {
+		t.Errorf("expected restored path %s, got: %s", ref.Path, restored.Ref.Path)
+	}
+	got, err := r.GetRef(reporef.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Published {
+		t.Error("expected restored dataset to keep its published flag")
+	}
+	if _, err := RestoreTrashed(ctx, r, ref); err == nil {
+		t.Error("expected restoring a dataset that isn't in the trash to error")
+	}
+
+	// only datasets removed before the cutoff are deleted
+	if _, err := TrashDataset(ctx, r, ref); err != nil {
+		t.Fatal(err)
+	}
+	deleted, err := EmptyTrash(ctx, r, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 0 {
+		t.Errorf("expected no deleted datasets, got: %d", len(deleted))
+	}
+	deleted, err = EmptyTrash(ctx, r, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 {
+		t.Errorf("expected 1 deleted dataset, got: %d", len(deleted))
+	}
+	if len(TrashOf(r).List()) != 0 {
+		t.Error("expected trash to be empty")
+	}
+}
+
+func TestPurgeTrashed(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	ref := addCitiesDataset(t, r)
+	ref.Dataset = nil
+
+	if _, err := TrashDataset(ctx, r, ref); err != nil {
+		t.Fatal(err)
+	}
+	if err := PurgeTrashed(ctx, r, reporef.DatasetRef{Peername: "me", Name: ref.Name}); err != nil {
+		t.Fatal(err)
+	}
+	if len(TrashOf(r).List()) != 0 {
+		t.Error("expected purged dataset to be removed from the trash")
+	}
+	if err := PurgeTrashed(ctx, r, reporef.DatasetRef{Peername: "me", Name: "not_trashed"}); err != nil {
+		t.Errorf("expected purging a name that isn't trashed to succeed, got: %s", err)
+	}
+
+	// the name can be used by a new dataset
+	ref = addCitiesDataset(t, r)
+	if history, err := DatasetLog(ctx, r, ref, -1, 0, false); err != nil {
+		t.Fatal(err)
+	} else if len(history) != 1 {
+		t.Errorf("expected new dataset to start a new history, got %d versions", len(history))
+	}
+}
@@ -4,13 +4,89 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"runtime"
+	"strings"
+	"sync"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/jsonschema"
 	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
 )
 
+// SchemaDraft07 is the JSON Schema draft validation implements
+const SchemaDraft07 = "draft-07"
+
+// supportedSchemaDrafts are the drafts validation follows to spec. draft-07
+// only adds keywords to draft-06, so draft-06 schemas validate as specified
+var supportedSchemaDrafts = map[string]bool{
+	"draft-06":    true,
+	SchemaDraft07: true,
+}
+
+// schemaDraftURIs maps published JSON Schema drafts to their meta-schema URIs
+var schemaDraftURIs = map[string]string{
+	"draft-04": "json-schema.org/draft-04/schema",
+	"draft-06": "json-schema.org/draft-06/schema",
+	"draft-07": "json-schema.org/draft-07/schema",
+	"2019-09":  "json-schema.org/draft/2019-09/schema",
+	"2020-12":  "json-schema.org/draft/2020-12/schema",
+}
+
+// ParseSchemaDraft normalizes a JSON Schema draft given as a name like
+// "draft-07", "7", or "2020-12", or as a meta-schema URI
+func ParseSchemaDraft(s string) (string, error) {
+	d := strings.ToLower(strings.TrimSpace(s))
+	d = strings.TrimPrefix(strings.TrimPrefix(d, "https://"), "http://")
+	d = strings.TrimSuffix(strings.TrimSuffix(d, "#"), "/")
+	for draft, uri := range schemaDraftURIs {
+		if d == uri {
+			return draft, nil
+		}
+	}
+	d = strings.TrimPrefix(strings.TrimPrefix(d, "draft"), "-")
+	if len(d) == 1 {
+		d = "0" + d
+	}
+	if len(d) == 2 {
+		d = "draft-" + d
+	}
+	if _, ok := schemaDraftURIs[d]; ok {
+		return d, nil
+	}
+	return "", fmt.Errorf("unknown JSON Schema draft %q", s)
+}
+
+// CheckSchemaDraft confirms a structure's schema can be validated as a given
+// draft. Validation implements draft-07, which validates draft-06 schemas as
+// specified. Other drafts error rather than risk results that differ from what
+// the draft specifies. A schema declaring a different draft with "$schema" is
+// also an error
+func CheckSchemaDraft(st *dataset.Structure, draft string) error {
+	draft, err := ParseSchemaDraft(draft)
+	if err != nil {
+		return err
+	}
+	if !supportedSchemaDrafts[draft] {
+		return fmt.Errorf("JSON Schema %s isn't supported, schemas can only be validated as draft-06 or %s", draft, SchemaDraft07)
+	}
+	if st == nil || st.Schema == nil {
+		return nil
+	}
+	if uri, ok := st.Schema["$schema"].(string); ok && uri != "" {
+		declared, err := ParseSchemaDraft(uri)
+		if err != nil {
+			return fmt.Errorf("schema declares an unknown draft: %s", uri)
+		}
+		if declared != draft {
+			return fmt.Errorf("schema declares JSON Schema %s, not %s", declared, draft)
+		}
+	}
+	return nil
+}
+
 // Validate checks a dataset body for errors based on the structure's schema
 func Validate(ctx context.Context, r repo.Repo, body qfs.File, st *dataset.Structure) ([]jsonschema.ValError, error) {
 	if body == nil {
@@ -26,7 +102,7 @@ func Validate(ctx context.Context, r repo.Repo, body qfs.File, st *dataset.Struc
 			Format: "json",
 			Schema: st.Schema,
 		}
-		file, err := ConvertBodyFormat(body, st, &convert)
+		file, _, err := ConvertBodyFormat(body, st, &convert)
 		if err != nil {
 			return nil, err
 		}
@@ -42,5 +118,100 @@ func Validate(ctx context.Context, r repo.Repo, body qfs.File, st *dataset.Struc
 	if err != nil {
 		return nil, err
 	}
-	return jsch.ValidateBytes(data)
+	errs, err := jsch.ValidateBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	describeEnumErrors(st.Schema, errs)
+	return errs, nil
+}
+
+// NamespaceValidation is the result of validating the head version of a
+// single dataset against its own schema
+type NamespaceValidation struct {
+	Ref    string                `json:"ref"`
+	Path   string                `json:"path,omitempty"`
+	Errors []jsonschema.ValError `json:"errors,omitempty"`
+	// Error describes why a dataset couldn't be validated
+	Error string `json:"error,omitempty"`
+}
+
+// Valid returns true if the dataset was validated without finding errors
+func (nv NamespaceValidation) Valid() bool {
+	return nv.Error == "" && len(nv.Errors) == 0
+}
+
+// ValidateNamespace validates the head version of every dataset a peer has in
+// the repo against its own schema. Datasets are validated in parallel, up to
+// parallelism at a time, defaulting to the number of CPUs. A dataset that
+// can't be validated records an error in its result instead of stopping the
+// run. Datasets without history are skipped. Results are in reference order
+func ValidateNamespace(ctx context.Context, r repo.Repo, peername string, parallelism int) ([]NamespaceValidation, error) {
+	num, err := r.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.References(0, num)
+	if err != nil {
+		return nil, err
+	}
+
+	var owned []reporef.DatasetRef
+	for _, ref := range refs {
+		if ref.Peername == peername && ref.Path != "" {
+			owned = append(owned, ref)
+		}
+	}
+
+	if parallelism <= 0 {
+		parallelism = runtime.NumCPU()
+	}
+
+	res := make([]NamespaceValidation, len(owned))
+	idxs := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idxs {
+				res[i] = validateHead(ctx, r, owned[i])
+			}
+		}()
+	}
+	for i := range owned {
+		idxs <- i
+	}
+	close(idxs)
+	wg.Wait()
+
+	return res, nil
+}
+
+func validateHead(ctx context.Context, r repo.Repo, ref reporef.DatasetRef) NamespaceValidation {
+	nv := NamespaceValidation{
+		Ref:  ref.AliasString(),
+		Path: ref.Path,
+	}
+
+	ds, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path)
+	if err != nil {
+		nv.Error = fmt.Sprintf("loading dataset: %s", err)
+		return nv
+	}
+	if ds.Structure == nil {
+		nv.Error = "dataset has no structure"
+		return nv
+	}
+	body, err := dsfs.LoadBody(ctx, r.Store(), ds)
+	if err != nil {
+		nv.Error = fmt.Sprintf("loading body: %s", err)
+		return nv
+	}
+	defer body.Close()
+
+	if nv.Errors, err = Validate(ctx, r, body, ds.Structure); err != nil {
+		nv.Error = err.Error()
+	}
+	return nv
 }
@@ -0,0 +1,545 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/starlib/util"
+	"go.starlark.net/starlark"
+)
+
+// RuleViolation is a single failure of a custom validation rule
+type RuleViolation struct {
+	// Rule is the name of the rule that failed
+	Rule string `json:"rule"`
+	// Row is the zero-indexed body entry that caused the failure, -1 if the
+	// failure can't be attributed to a single entry
+	Row int `json:"row"`
+	// Message describes the failure
+	Message string `json:"message"`
+}
+
+// String formats a violation for display
+func (v RuleViolation) String() string {
+	if v.Row < 0 {
+		return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+	}
+	return fmt.Sprintf("%s: row %d: %s", v.Rule, v.Row, v.Message)
+}
+
+// RuleViolations is an error returned when one or more custom validation
+// rules fail
+type RuleViolations []RuleViolation
+
+// Error implements the error interface
+func (rv RuleViolations) Error() string {
+	strs := make([]string, len(rv))
+	for i, v := range rv {
+		strs[i] = v.String()
+	}
+	return fmt.Sprintf("%d validation rule violation(s):\n%s", len(rv), strings.Join(strs, "\n"))
+}
+
+// ValidationRule checks a dataset body for invariants JSON schema can't
+// express, like uniqueness across rows or comparisons between fields. Rules
+// are stateful: a rule is created for each validation, fed every body entry in
+// order, and finally asked for any violations that span rows
+type ValidationRule interface {
+	// Name identifies the rule in violation reports
+	Name() string
+	// CheckEntry is called once per body entry, row is the entry index
+	CheckEntry(st *dataset.Structure, row int, ent dsio.Entry) []RuleViolation
+	// Finish is called after the last entry has been checked
+	Finish() []RuleViolation
+}
+
+// RuleFactory creates a validation rule from configuration values
+type RuleFactory func(cfg map[string]interface{}) (ValidationRule, error)
+
+var (
+	rulesLk      sync.Mutex
+	ruleRegistry = map[string]RuleFactory{
+		"unique":  newUniqueRule,
+		"compare": newCompareRule,
+	}
+)
+
+// RegisterValidationRule makes a kind of rule available to rules files. It's
+// the extension point for Go implementations of validation rules
+func RegisterValidationRule(kind string, f RuleFactory) {
+	rulesLk.Lock()
+	defer rulesLk.Unlock()
+	ruleRegistry[kind] = f
+}
+
+// LoadValidationRules reads rules from a file. Files ending in .star are
+// starlark scripts, where every global function with a "check_" prefix is a
+// rule. JSON & YAML files contain a list of rule configurations, each with a
+// "rule" key naming a registered kind of rule:
+//   - rule: unique
+//     field: id
+//   - rule: compare
+//     field: end_date
+//     op: ">"
+//     other: start_date
+func LoadValidationRules(path string) ([]ValidationRule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading validation rules: %s", err)
+	}
+	return ParseValidationRules(filepath.Base(path), data)
+}
+
+// ParseValidationRules creates rules from the contents of a rules file, the
+// file type is picked by filename extension. see LoadValidationRules for
+// supported formats
+func ParseValidationRules(filename string, data []byte) ([]ValidationRule, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".star":
+		return loadStarlarkRules(filepath.Base(filename), data)
+	case ".json", ".yaml", ".yml":
+		var cfgs []map[string]interface{}
+		if err := yaml.Unmarshal(data, &cfgs); err != nil {
+			return nil, fmt.Errorf("parsing validation rules: %s", err)
+		}
+		return ValidationRulesFromConfig(cfgs)
+	default:
+		return nil, fmt.Errorf("unsupported validation rules file type: %q", filepath.Ext(filename))
+	}
+}
+
+// ValidationRulesFromConfig creates rules from a list of configurations
+func ValidationRulesFromConfig(cfgs []map[string]interface{}) ([]ValidationRule, error) {
+	rulesLk.Lock()
+	defer rulesLk.Unlock()
+
+	rules := make([]ValidationRule, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		kind, _ := cfg["rule"].(string)
+		f, ok := ruleRegistry[kind]
+		if !ok {
+			return nil, fmt.Errorf("validation rule %d: unknown rule %q", i, kind)
+		}
+		rule, err := f(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("validation rule %d: %s", i, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ValidateRules runs a set of rules against a dataset body, returning a
+// RuleViolations error if any rule fails. The body file is read completely &
+// replaced with an unread copy
+func ValidateRules(ctx context.Context, ds *dataset.Dataset, rules []ValidationRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	body := ds.BodyFile()
+	if body == nil {
+		return nil
+	}
+	if ds.Structure == nil {
+		return fmt.Errorf("validating rules: dataset is missing structure")
+	}
+
+	buf := &bytes.Buffer{}
+	defer func() {
+		ds.SetBodyFile(qfs.NewMemfileReader(body.FileName(), io.MultiReader(buf, body)))
+	}()
+
+	rdr, err := dsio.NewEntryReader(ds.Structure, io.TeeReader(body, buf))
+	if err != nil {
+		return fmt.Errorf("validating rules: %s", err)
+	}
+
+	var violations RuleViolations
+	for row := 0; ; row++ {
+		ent, err := rdr.ReadEntry()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			return fmt.Errorf("validating rules: reading row %d: %s", row, err)
+		}
+		for _, rule := range rules {
+			violations = append(violations, rule.CheckEntry(ds.Structure, row, ent)...)
+		}
+	}
+	for _, rule := range rules {
+		violations = append(violations, rule.Finish()...)
+	}
+
+	if len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
+
+// entryField gets a named field from an entry. object entries are looked up by
+// key, array entries by the title of the matching schema column
+func entryField(st *dataset.Structure, ent dsio.Entry, field string) (interface{}, bool) {
+	switch v := ent.Value.(type) {
+	case map[string]interface{}:
+		val, ok := v[field]
+		return val, ok
+	case []interface{}:
+		for i, title := range columnTitles(st) {
+			if title == field && i < len(v) {
+				return v[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// columnTitles lists the titles of tabular schema columns, in order
+func columnTitles(st *dataset.Structure) []string {
+	if st == nil || st.Schema == nil {
+		return nil
+	}
+	items, ok := st.Schema["items"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	cols, ok := items["items"].([]interface{})
+	if !ok {
+		return nil
+	}
+	titles := make([]string, len(cols))
+	for i, col := range cols {
+		if m, ok := col.(map[string]interface{}); ok {
+			titles[i], _ = m["title"].(string)
+		}
+	}
+	return titles
+}
+
+// uniqueRule requires every value of a field be distinct
+type uniqueRule struct {
+	field string
+	seen  map[string]int
+}
+
+func newUniqueRule(cfg map[string]interface{}) (ValidationRule, error) {
+	field, _ := cfg["field"].(string)
+	if field == "" {
+		return nil, fmt.Errorf("unique rule requires a field")
+	}
+	return &uniqueRule{field: field, seen: map[string]int{}}, nil
+}
+
+// Name implements ValidationRule
+func (r *uniqueRule) Name() string { return fmt.Sprintf("unique(%s)", r.field) }
+
+// CheckEntry implements ValidationRule
+func (r *uniqueRule) CheckEntry(st *dataset.Structure, row int, ent dsio.Entry) []RuleViolation {
+	val, ok := entryField(st, ent, r.field)
+	if !ok || val == nil {
+		return nil
+	}
+	key := fmt.Sprintf("%#v", val)
+	if first, ok := r.seen[key]; ok {
+		return []RuleViolation{{
+			Rule:    r.Name(),
+			Row:     row,
+			Message: fmt.Sprintf("value %v duplicates row %d", val, first),
+		}}
+	}
+	r.seen[key] = row
+	return nil
+}
+
+// Finish implements ValidationRule
+func (r *uniqueRule) Finish() []RuleViolation { return nil }
+
+// compareRule requires one field relate to another field in the same entry.
+// numbers are compared numerically, everything else as strings, which works for
+// ISO-8601 dates
+type compareRule struct {
+	field, op, other string
+}
+
+func newCompareRule(cfg map[string]interface{}) (ValidationRule, error) {
+	r := &compareRule{}
+	r.field, _ = cfg["field"].(string)
+	r.op, _ = cfg["op"].(string)
+	r.other, _ = cfg["other"].(string)
+	if r.field == "" || r.other == "" {
+		return nil, fmt.Errorf("compare rule requires field and other")
+	}
+	switch r.op {
+	case "<", "<=", ">", ">=", "==", "!=":
+	default:
+		return nil, fmt.Errorf("compare rule: invalid op %q", r.op)
+	}
+	return r, nil
+}
+
+// Name implements ValidationRule
+func (r *compareRule) Name() string { return fmt.Sprintf("compare(%s %s %s)", r.field, r.op, r.other) }
+
+// CheckEntry implements ValidationRule
+func (r *compareRule) CheckEntry(st *dataset.Structure, row int, ent dsio.Entry) []RuleViolation {
+	a, aok := entryField(st, ent, r.field)
+	b, bok := entryField(st, ent, r.other)
+	if !aok || !bok || a == nil || b == nil {
+		return nil
+	}
+
+	var cmp int
+	af, aNum := toFloat(a)
+	bf, bNum := toFloat(b)
+	if aNum && bNum {
+		switch {
+		case af < bf:
+			cmp = -1
+		case af > bf:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+
+	ok := map[string]bool{
+		"<":  cmp < 0,
+		"<=": cmp <= 0,
+		">":  cmp > 0,
+		">=": cmp >= 0,
+		"==": cmp == 0,
+		"!=": cmp != 0,
+	}[r.op]
+	if ok {
+		return nil
+	}
+	return []RuleViolation{{
+		Rule:    r.Name(),
+		Row:     row,
+		Message: fmt.Sprintf("expected %s (%v) %s %s (%v)", r.field, a, r.op, r.other, b),
+	}}
+}
+
+// Finish implements ValidationRule
+func (r *compareRule) Finish() []RuleViolation { return nil }
+
+func toFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case float32:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+// starlarkRule wraps a starlark "check_" function. Starlark rules see the
+// entire body at once as a list of rows, and return a list of violations. Each
+// violation is either a message string, or a (row, message) tuple:
+//
+//	def check_positive_population(rows):
+//	  return [(i, "population must be positive") for i, r in enumerate(rows) if r[1] <= 0]
+type starlarkRule struct {
+	name string
+	fn   *starlark.Function
+	rows []interface{}
+}
+
+// MaxRuleSteps bounds the loop iterations a starlark rules file can run, both
+// while loading & in each check_ function. The starlark interpreter can't
+// count execution steps, so rules get a range builtin that counts iterations
+// against this budget. Starlark has no while loops or recursion, so loops are
+// bounded by range or by the data being checked
+var MaxRuleSteps = 10000000
+
+// stepBudgetKey is the thread local holding a rule's *stepBudget
+const stepBudgetKey = "stepBudget"
+
+// stepBudget counts loop iterations on a starlark thread
+type stepBudget struct {
+	steps, max int
+	over       bool
+}
+
+// step counts an iteration, reporting false once the budget has run out
+func (b *stepBudget) step() bool {
+	if b.steps >= b.max {
+		b.over = true
+		return false
+	}
+	b.steps++
+	return true
+}
+
+// exceeded reports whether a step was refused
+func (b *stepBudget) exceeded() bool { return b.over }
+
+// ruleThread creates a starlark thread with a fresh step budget
+func ruleThread(name string) (*starlark.Thread, *stepBudget) {
+	thread := &starlark.Thread{Name: name}
+	budget := &stepBudget{max: MaxRuleSteps}
+	thread.SetLocal(stepBudgetKey, budget)
+	return thread, budget
+}
+
+// ruleBuiltins are predeclared for rules files, shadowing universal builtins
+var ruleBuiltins = starlark.StringDict{
+	"range": starlark.NewBuiltin("range", countedRange),
+}
+
+// countedRange wraps the range builtin, iterating the result counts against
+// the thread's step budget
+func countedRange(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	v, err := starlark.Call(thread, starlark.Universe["range"], args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	budget, ok := thread.Local(stepBudgetKey).(*stepBudget)
+	seq, isSeq := v.(starlark.Indexable)
+	if !ok || !isSeq {
+		return v, nil
+	}
+	return budgetedRange{Indexable: seq, budget: budget}, nil
+}
+
+// budgetedRange is a range value that counts iteration steps
+type budgetedRange struct {
+	starlark.Indexable
+	budget *stepBudget
+}
+
+// Iterate implements starlark.Iterable
+func (r budgetedRange) Iterate() starlark.Iterator {
+	return &budgetedIterator{Iterator: r.Indexable.(starlark.Iterable).Iterate(), budget: r.budget}
+}
+
+// budgetedIterator stops iterating when the step budget runs out
+type budgetedIterator struct {
+	starlark.Iterator
+	budget *stepBudget
+}
+
+// Next implements starlark.Iterator
+func (it *budgetedIterator) Next(p *starlark.Value) bool {
+	return it.budget.step() && it.Iterator.Next(p)
+}
+
+func loadStarlarkRules(filename string, script []byte) ([]ValidationRule, error) {
+	thread, budget := ruleThread("validation rules")
+	globals, err := starlark.ExecFile(thread, filename, script, ruleBuiltins)
+	if err == nil && budget.exceeded() {
+		err = fmt.Errorf("%s: exceeded %d steps", filename, budget.max)
+	}
+	if err != nil {
+		if evalErr, ok := err.(*starlark.EvalError); ok {
+			return nil, fmt.Errorf(evalErr.Backtrace())
+		}
+		return nil, err
+	}
+
+	names := make([]string, 0, len(globals))
+	for name, val := range globals {
+		if _, ok := val.(*starlark.Function); ok && strings.HasPrefix(name, "check_") {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%s: no check_ functions defined", filename)
+	}
+	sort.Strings(names)
+
+	rules := make([]ValidationRule, len(names))
+	for i, name := range names {
+		rules[i] = &starlarkRule{name: name, fn: globals[name].(*starlark.Function)}
+	}
+	return rules, nil
+}
+
+// Name implements ValidationRule
+func (r *starlarkRule) Name() string { return r.name }
+
+// CheckEntry implements ValidationRule
+func (r *starlarkRule) CheckEntry(st *dataset.Structure, row int, ent dsio.Entry) []RuleViolation {
+	r.rows = append(r.rows, ent.Value)
+	return nil
+}
+
+// Finish implements ValidationRule
+func (r *starlarkRule) Finish() []RuleViolation {
+	fail := func(msg string) []RuleViolation {
+		return []RuleViolation{{Rule: r.name, Row: -1, Message: msg}}
+	}
+
+	rows, err := util.Marshal(r.rows)
+	if err != nil {
+		return fail(fmt.Sprintf("converting body: %s", err))
+	}
+	thread, budget := ruleThread(r.name)
+	res, err := starlark.Call(thread, r.fn, starlark.Tuple{rows}, nil)
+	if err != nil {
+		return fail(err.Error())
+	}
+	if budget.exceeded() {
+		return fail(fmt.Sprintf("exceeded %d steps", budget.max))
+	}
+	if res == starlark.None {
+		return nil
+	}
+
+	iter, ok := res.(starlark.Iterable)
+	if !ok {
+		return fail(fmt.Sprintf("expected a list of violations, got %s", res.Type()))
+	}
+	var violations []RuleViolation
+	it := iter.Iterate()
+	defer it.Done()
+	var x starlark.Value
+	for it.Next(&x) {
+		v := RuleViolation{Rule: r.name, Row: -1}
+		switch val := x.(type) {
+		case starlark.String:
+			v.Message = string(val)
+		case starlark.Tuple:
+			if len(val) != 2 {
+				return fail("violation tuples must be (row, message)")
+			}
+			row, err := starlark.AsInt32(val[0])
+			if err != nil {
+				return fail(fmt.Sprintf("violation row: %s", err))
+			}
+			v.Row = row
+			msg, _ := starlark.AsString(val[1])
+			v.Message = msg
+		default:
+			return fail(fmt.Sprintf("unexpected violation type %s", x.Type()))
+		}
+		violations = append(violations, v)
+	}
+	return violations
+}
+
+// assert rules satisfy the interface at compile time
+var (
+	_ ValidationRule = (*uniqueRule)(nil)
+	_ ValidationRule = (*compareRule)(nil)
+	_ ValidationRule = (*starlarkRule)(nil)
+
+	_ starlark.Sequence = budgetedRange{}
+)
@@ -0,0 +1,176 @@
+package base
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+func TestValidateRules(t *testing.T) {
+	ctx := context.Background()
+	body := `id,start,end
+1,2019-01-01,2019-02-01
+2,2019-03-01,2019-01-01
+1,2019-04-01,2019-05-01
+`
+	st := &dataset.Structure{
+		Format:       "csv",
+		FormatConfig: map[string]interface{}{"headerRow": true},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "id", "type": "integer"},
+					map[string]interface{}{"title": "start", "type": "string"},
+					map[string]interface{}{"title": "end", "type": "string"},
+				},
+			},
+		},
+	}
+
+	rules, err := ValidationRulesFromConfig([]map[string]interface{}{
+		{"rule": "unique", "field": "id"},
+		{"rule": "compare", "field": "end", "op": ">", "other": "start"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds := &dataset.Dataset{Structure: st}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.csv", []byte(body)))
+
+	err = ValidateRules(ctx, ds, rules)
+	violations, ok := err.(RuleViolations)
+	if !ok {
+		t.Fatalf("expected RuleViolations error, got: %v", err)
+	}
+	expect := RuleViolations{
+		{Rule: "compare(end > start)", Row: 1, Message: "expected end (2019-01-01) > start (2019-03-01)"},
+		{Rule: "unique(id)", Row: 2, Message: "value 1 duplicates row 0"},
+	}
+	if len(violations) != len(expect) {
+		t.Fatalf("violation count mismatch. expected: %v, got: %v", expect, violations)
+	}
+	for i, v := range violations {
+		if v != expect[i] {
+			t.Errorf("violation %d mismatch. expected: %v, got: %v", i, expect[i], v)
+		}
+	}
+
+	// body must be left readable
+	data, err := ioutil.ReadAll(ds.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != body {
+		t.Errorf("body wasn't restored after validation. got: %q", string(data))
+	}
+}
+
+func TestValidationRulesFromConfigErrors(t *testing.T) {
+	bad := []map[string]interface{}{
+		{"rule": "nope"},
+		{"rule": "unique"},
+		{"rule": "compare", "field": "a", "other": "b", "op": "~"},
+	}
+	for i, cfg := range bad {
+		if _, err := ValidationRulesFromConfig([]map[string]interface{}{cfg}); err == nil {
+			t.Errorf("case %d: expected error, got nil", i)
+		}
+	}
+}
+
+func TestLoadValidationRulesStarlark(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validation_rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	script := `
+def check_positive(rows):
+  return [(i, "amount must be positive") for i, r in enumerate(rows) if r["amount"] <= 0]
+
+def check_not_empty(rows):
+  if len(rows) == 0:
+    return ["body is empty"]
+`
+	path := filepath.Join(dir, "rules.star")
+	if err := ioutil.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := LoadValidationRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(rules))
+	}
+
+	ds := &dataset.Dataset{Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[{"amount":1},{"amount":-2},{"amount":3}]`)))
+
+	violations, ok := ValidateRules(context.Background(), ds, rules).(RuleViolations)
+	if !ok || len(violations) != 1 {
+		t.Fatalf("expected one violation, got: %v", violations)
+	}
+	if expect := (RuleViolation{Rule: "check_positive", Row: 1, Message: "amount must be positive"}); violations[0] != expect {
+		t.Errorf("violation mismatch. expected: %v, got: %v", expect, violations[0])
+	}
+}
+
+func TestStarlarkRuleSteps(t *testing.T) {
+	prev := MaxRuleSteps
+	MaxRuleSteps = 1000
+	defer func() { MaxRuleSteps = prev }()
+
+	if _, err := ParseValidationRules("rules.star", []byte(`
+def spin():
+  for i in range(1000000):
+    pass
+spin()
+
+def check_nothing(rows):
+  pass
+`)); err == nil {
+		t.Error("expected loading a rules file that exceeds the step limit to error")
+	}
+
+	rules, err := ParseValidationRules("rules.star", []byte(`
+def check_spin(rows):
+  n = 0
+  for r in rows:
+    for i in range(1000000):
+      n += 1
+  return []
+
+def check_small(rows):
+  return [(i, "odd") for i in range(len(rows)) if i % 2]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := &dataset.Dataset{Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[1,2,3]`)))
+
+	violations, ok := ValidateRules(context.Background(), ds, rules).(RuleViolations)
+	if !ok || len(violations) != 2 {
+		t.Fatalf("expected two violations, got: %v", violations)
+	}
+	expect := RuleViolations{
+		{Rule: "check_small", Row: 1, Message: "odd"},
+		{Rule: "check_spin", Row: -1, Message: "exceeded 1000 steps"},
+	}
+	for i, v := range violations {
+		if v != expect[i] {
+			t.Errorf("violation %d mismatch. expected: %v, got: %v", i, expect[i], v)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
 	"github.com/qri-io/qri/base/dsfs"
 )
 
@@ -31,3 +33,96 @@ func TestValidate(t *testing.T) {
 		t.Errorf("expected 0 errors. got: %d", len(errs))
 	}
 }
+
+func TestValidateNamespace(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	addCitiesDataset(t, r)
+	addFlourinatedCompoundsDataset(t, r)
+
+	ds := &dataset.Dataset{
+		Peername: "peer",
+		Name:     "invalid",
+		Structure: &dataset.Structure{
+			Format: "json",
+			Schema: map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "number"},
+			},
+		},
+	}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[1,"two",3,"four"]`)))
+	_, warnings, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectWarning := Warning{Code: WarnValidation, Message: "this dataset has 2 validation errors", Component: "body"}
+	if len(warnings) != 1 || warnings[0] != expectWarning {
+		t.Errorf("expected a validation warning, got: %v", warnings)
+	}
+
+	res, err := ValidateNamespace(ctx, r, "peer", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 3 {
+		t.Fatalf("expected 3 results, got: %d", len(res))
+	}
+
+	invalid := 0
+	for _, nv := range res {
+		if nv.Error != "" {
+			t.Errorf("%s: unexpected error: %s", nv.Ref, nv.Error)
+		}
+		if !nv.Valid() {
+			invalid++
+			if nv.Ref != "peer/invalid" {
+				t.Errorf("expected only peer/invalid to be invalid, got: %s", nv.Ref)
+			}
+			if len(nv.Errors) != 2 {
+				t.Errorf("expected 2 validation errors, got: %d", len(nv.Errors))
+			}
+		}
+	}
+	if invalid != 1 {
+		t.Errorf("expected 1 invalid dataset, got: %d", invalid)
+	}
+
+	if res, err = ValidateNamespace(ctx, r, "nobody", 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 0 {
+		t.Errorf("expected no results for an unknown peer, got: %d", len(res))
+	}
+}
+
+func TestCheckSchemaDraft(t *testing.T) {
+	for _, s := range []string{"draft-07", "7", "draft7", "http://json-schema.org/draft-07/schema#"} {
+		if d, err := ParseSchemaDraft(s); err != nil || d != SchemaDraft07 {
+			t.Errorf("parsing %q: expected %s, got: %q, %v", s, SchemaDraft07, d, err)
+		}
+	}
+	if d, err := ParseSchemaDraft("https://json-schema.org/draft/2020-12/schema"); err != nil || d != "2020-12" {
+		t.Errorf("expected 2020-12, got: %q, %v", d, err)
+	}
+	if _, err := ParseSchemaDraft("draft-99"); err == nil {
+		t.Error("expected an unknown draft to error")
+	}
+
+	st := &dataset.Structure{Schema: map[string]interface{}{"type": "array"}}
+	if err := CheckSchemaDraft(st, "draft-07"); err != nil {
+		t.Errorf("expected a schema without $schema to check as draft-07, got: %s", err)
+	}
+	if err := CheckSchemaDraft(st, "draft-06"); err != nil {
+		t.Errorf("expected a schema without $schema to check as draft-06, got: %s", err)
+	}
+	for _, d := range []string{"draft-04", "2019-09", "2020-12", "draft-99"} {
+		if err := CheckSchemaDraft(st, d); err == nil {
+			t.Errorf("expected draft %s to error", d)
+		}
+	}
+	st.Schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	if err := CheckSchemaDraft(st, "draft-07"); err == nil {
+		t.Error("expected a schema declaring a different draft to error")
+	}
+}
@@ -0,0 +1,305 @@
+package base
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
+)
+
+// VersionDelta summarizes the changes between two versions of a dataset
+type VersionDelta struct {
+	// number of body entries present in the newer version but not the older
+	RowsAdded int `json:"rowsAdded"`
+	// number of body entries present in the older version but not the newer
+	RowsRemoved int `json:"rowsRemoved"`
+	// names of components that differ between versions, like "meta" or "body"
+	Components []string `json:"components,omitempty"`
+}
+
+// maxCachedDeltas caps the number of summaries held by the delta cache
+const maxCachedDeltas = 1000
+
+// deltaCache holds summaries computed by CachedVersionDelta
+var deltaCache = &versionDeltaCache{size: maxCachedDeltas}
+
+// CachedVersionDelta returns a VersionDelta between two dataset versions,
+// computing it only if it hasn't been calculated before
+func CachedVersionDelta(ctx context.Context, store cafs.Filestore, prevPath, path string) (*VersionDelta, error) {
+	key := prevPath + ":" + path
+	if delta, ok := deltaCache.get(key); ok {
+		return delta, nil
+	}
+
+	delta, err := DatasetVersionDelta(ctx, store, prevPath, path)
+	if err != nil {
+		return nil, err
+	}
+	deltaCache.put(key, delta)
+	return delta, nil
+}
+
+// versionDeltaCache holds summaries between versions, keyed by
+// "prevPath:path". Versions are immutable, so deltas never go stale. The
+// least recently used delta is dropped when the cache holds size deltas.
+// Cached deltas are shared & must not be modified
+type versionDeltaCache struct {
+	lk      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type versionDeltaEntry struct {
+	key   string
+	delta *VersionDelta
+}
+
+// get finds a delta, marking it as most recently used
+func (c *versionDeltaCache) get(key string) (*VersionDelta, bool) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*versionDeltaEntry).delta, true
+}
+
+// put adds a delta, dropping the least recently used delta if the cache is
+// full
+func (c *versionDeltaCache) put(key string, delta *VersionDelta) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]*list.Element{}
+		c.order = list.New()
+	}
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*versionDeltaEntry).delta = delta
+		c.order.MoveToFront(el)
+		return
+	}
+	for c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*versionDeltaEntry).key)
+	}
+	c.entries[key] = c.order.PushFront(&versionDeltaEntry{key: key, delta: delta})
+}
+
+// DatasetVersionDelta compares two dataset versions. Body entries are
+// compared as a multiset of hashes, so reordered rows aren't counted as
+// changes. An empty prevPath counts every entry in path as added
+func DatasetVersionDelta(ctx context.Context, store cafs.Filestore, prevPath, path string) (*VersionDelta, error) {
+	delta := &VersionDelta{}
+
+	next, err := dsfs.LoadDatasetRefs(ctx, store, path)
+	if err != nil {
+		return nil, err
+	}
+	prev := &dataset.Dataset{}
+	if prevPath != "" {
+		if prev, err = dsfs.LoadDatasetRefs(ctx, store, prevPath); err != nil {
+			return nil, err
+		}
+	}
+	delta.Components = changedComponents(prev, next)
+
+	seen := map[uint64]int{}
+	if prevPath != "" {
+		err = eachBodyEntryHash(ctx, store, prevPath, func(h uint64) {
+			seen[h]++
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = eachBodyEntryHash(ctx, store, path, func(h uint64) {
+		if seen[h] > 0 {
+			seen[h]--
+			return
+		}
+		delta.RowsAdded++
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range seen {
+		delta.RowsRemoved += n
+	}
+
+	return delta, nil
+}
+
+// VersionSummary is a lightweight description of how a version changed from
+// the version before it, computed from components without reading bodies
+type VersionSummary struct {
+	// Unavailable is true when the version or the version before it isn't
+	// stored locally, in which case no changes are listed
+	Unavailable bool `json:"unavailable,omitempty"`
+	// names of components that differ from the previous version
+	Components []string `json:"components,omitempty"`
+	// change in body size in bytes
+	BodySizeDelta int `json:"bodySizeDelta"`
+}
+
+// SummarizeVersion describes changes between the version at path & its
+// previous version. Versions that aren't stored locally are summarized as
+// unavailable unless fetchMissing is true, which may fetch blocks from the
+// network
+func SummarizeVersion(ctx context.Context, store cafs.Filestore, path string, fetchMissing bool) (*VersionSummary, error) {
+	available := func(p string) (bool, error) {
+		if fetchMissing {
+			return true, nil
+		}
+		return store.Has(ctx, p)
+	}
+
+	if ok, err := available(path); err != nil || !ok {
+		return &VersionSummary{Unavailable: true}, err
+	}
+	next, err := dsfs.LoadDataset(ctx, store, path)
+	if err != nil {
+		return nil, err
+	}
+	prev := &dataset.Dataset{}
+	if next.PreviousPath != "" {
+		if ok, err := available(next.PreviousPath); err != nil || !ok {
+			return &VersionSummary{Unavailable: true}, err
+		}
+		if prev, err = dsfs.LoadDataset(ctx, store, next.PreviousPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return &VersionSummary{
+		Components:    changedComponents(prev, next),
+		BodySizeDelta: bodySize(next) - bodySize(prev),
+	}, nil
+}
+
+func bodySize(ds *dataset.Dataset) int {
+	if ds.Structure == nil {
+		return 0
+	}
+	return ds.Structure.Length
+}
+
+// changedComponents lists components that differ between two unreferenced
+// datasets, comparing content addresses when present
+func changedComponents(prev, next *dataset.Dataset) (changed []string) {
+	pairs := []struct {
+		name string
+		a, b interface{}
+		ap   string
+		bp   string
+	}{
+		{"meta", prev.Meta, next.Meta, metaPath(prev.Meta), metaPath(next.Meta)},
+		{"structure", prev.Structure, next.Structure, structurePath(prev.Structure), structurePath(next.Structure)},
+		{"readme", prev.Readme, next.Readme, readmePath(prev.Readme), readmePath(next.Readme)},
+		{"transform", prev.Transform, next.Transform, transformPath(prev.Transform), transformPath(next.Transform)},
+		{"viz", prev.Viz, next.Viz, vizPath(prev.Viz), vizPath(next.Viz)},
+		{"body", nil, nil, prev.BodyPath, next.BodyPath},
+	}
+
+	for _, p := range pairs {
+		if p.ap != "" || p.bp != "" {
+			if p.ap != p.bp {
+				changed = append(changed, p.name)
+			}
+			continue
+		}
+		a, _ := json.Marshal(p.a)
+		b, _ := json.Marshal(p.b)
+		if string(a) != string(b) {
+			changed = append(changed, p.name)
+		}
+	}
+	return changed
+}
+
+func metaPath(m *dataset.Meta) string {
+	if m == nil {
+		return ""
+	}
+	return m.Path
+}
+
+func structurePath(st *dataset.Structure) string {
+	if st == nil {
+		return ""
+	}
+	return st.Path
+}
+
+func readmePath(rm *dataset.Readme) string {
+	if rm == nil {
+		return ""
+	}
+	return rm.Path
+}
+
+func transformPath(tf *dataset.Transform) string {
+	if tf == nil {
+		return ""
+	}
+	return tf.Path
+}
+
+func vizPath(vz *dataset.Viz) string {
+	if vz == nil {
+		return ""
+	}
+	return vz.Path
+}
+
+// eachBodyEntryHash calls fn with a hash of each entry in a version's body.
+// object bodies include the entry key in the hash
+func eachBodyEntryHash(ctx context.Context, store cafs.Filestore, path string, fn func(h uint64)) error {
+	ds, err := dsfs.LoadDataset(ctx, store, path)
+	if err != nil {
+		return err
+	}
+	if ds.BodyPath == "" || ds.Structure == nil {
+		return nil
+	}
+	body, err := dsfs.LoadBody(ctx, store, ds)
+	if err != nil {
+		return fmt.Errorf("loading body: %s", err)
+	}
+	defer body.Close()
+
+	rdr, err := dsio.NewEntryReader(ds.Structure, body)
+	if err != nil {
+		return fmt.Errorf("reading body: %s", err)
+	}
+
+	h := fnv.New64a()
+	for {
+		ent, err := rdr.ReadEntry()
+		if err != nil {
+			if err.Error() == "EOF" {
+				return nil
+			}
+			return fmt.Errorf("reading body: %s", err)
+		}
+		data, err := json.Marshal(ent.Value)
+		if err != nil {
+			return err
+		}
+		h.Reset()
+		h.Write([]byte(ent.Key))
+		h.Write([]byte{0})
+		h.Write(data)
+		fn(h.Sum64())
+	}
+}
@@ -0,0 +1,138 @@
+package base
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+func TestDatasetVersionDelta(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	save := func(prevPath, title, body string) string {
+		ds := &dataset.Dataset{
+			Name:         "delta_test",
+			Peername:     "peer",
+			PreviousPath: prevPath,
+			Commit:       &dataset.Commit{Title: "commit"},
+			Meta:         &dataset.Meta{Title: title},
+			Structure: &dataset.Structure{
+				Format: "json",
+				Schema: dataset.BaseSchemaArray,
+			},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(body)))
+		ref, err := CreateDataset(ctx, r, devNull, ds, nil, false, true, false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ref.Path
+	}
+
+	first := save("", "delta", `[1,2,3,3]`)
+	second := save(first, "delta", `[3,2,4,5,3]`)
+	third := save(second, "new title", `[3,2,4,5,3]`)
+
+	cases := []struct {
+		description    string
+		prevPath, path string
+		expect         *VersionDelta
+	}{
+		{"first version", "", first, &VersionDelta{RowsAdded: 4, Components: []string{"meta", "structure", "body"}}},
+		{"body change", first, second, &VersionDelta{RowsAdded: 2, RowsRemoved: 1, Components: []string{"structure", "body"}}},
+		{"meta change", second, third, &VersionDelta{Components: []string{"meta"}}},
+	}
+
+	for _, c := range cases {
+		got, err := CachedVersionDelta(ctx, r.Store(), c.prevPath, c.path)
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", c.description, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case %s: result mismatch (-want +got):\n%s", c.description, diff)
+		}
+	}
+}
+
+func TestSummarizeVersion(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	save := func(prevPath, title, body string) string {
+		ds := &dataset.Dataset{
+			Name:         "summary_test",
+			Peername:     "peer",
+			PreviousPath: prevPath,
+			Commit:       &dataset.Commit{Title: "commit"},
+			Meta:         &dataset.Meta{Title: title},
+			Structure: &dataset.Structure{
+				Format: "json",
+				Schema: dataset.BaseSchemaArray,
+			},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(body)))
+		ref, err := CreateDataset(ctx, r, devNull, ds, nil, false, true, false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ref.Path
+	}
+
+	first := save("", "summary", `[1,2,3]`)
+	second := save(first, "summary", `[1,2,3,4]`)
+	third := save(second, "new title", `[1,2,3,4]`)
+
+	cases := []struct {
+		description string
+		path        string
+		expect      *VersionSummary
+	}{
+		{"first version", first, &VersionSummary{Components: []string{"meta", "structure", "body"}, BodySizeDelta: 7}},
+		{"body change", second, &VersionSummary{Components: []string{"structure", "body"}, BodySizeDelta: 2}},
+		{"meta change", third, &VersionSummary{Components: []string{"meta"}}},
+		{"missing version", "/map/QmNotStoredLocally", &VersionSummary{Unavailable: true}},
+	}
+
+	for _, c := range cases {
+		got, err := SummarizeVersion(ctx, r.Store(), c.path, false)
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", c.description, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case %s: result mismatch (-want +got):\n%s", c.description, diff)
+		}
+	}
+}
+
+func TestVersionDeltaCache(t *testing.T) {
+	c := &versionDeltaCache{size: 2}
+
+	if _, ok := c.get("a"); ok {
+		t.Errorf("expected empty cache to miss")
+	}
+	a := &VersionDelta{RowsAdded: 1}
+	c.put("a", a)
+	if d, ok := c.get("a"); !ok || d != a {
+		t.Errorf("expected cached delta")
+	}
+	c.put("b", &VersionDelta{RowsAdded: 2})
+
+	// "a" was used more recently than "b", adding "c" drops "b"
+	c.get("a")
+	c.put("c", &VersionDelta{RowsAdded: 3})
+	if _, ok := c.get("b"); ok {
+		t.Errorf("expected least recently used delta to be dropped")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Errorf("expected recently used delta to be kept")
+	}
+	if _, ok := c.get("c"); !ok {
+		t.Errorf("expected newest delta to be kept")
+	}
+}
@@ -0,0 +1,58 @@
+package base
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// WarnSniffedFormat is the code of a warning that a body's data format was
+	// detected from its contents
+	WarnSniffedFormat = "sniffed_format"
+	// WarnConvertedFormat is the code of a warning that a body was rewritten in
+	// a different data format
+	WarnConvertedFormat = "converted_format"
+	// WarnConvertedEncoding is the code of a warning that a body was converted
+	// to UTF-8 from another text encoding
+	WarnConvertedEncoding = "converted_encoding"
+	// WarnUnknownFields is the code of a warning that fields of a dataset file
+	// weren't recognized, and were dropped
+	WarnUnknownFields = "unknown_fields"
+	// WarnValidation is the code of a warning that a body doesn't match its
+	// schema
+	WarnValidation = "validation"
+	// WarnUnverifiedArchive is the code of a warning that an imported zip
+	// archive doesn't have a checksum manifest to verify its contents against
+	WarnUnverifiedArchive = "unverified_archive"
+)
+
+// Warning is a problem encountered working with a dataset that didn't stop the
+// work from being done. Warnings are reported to users instead of failing
+type Warning struct {
+	// Code identifies the kind of warning
+	Code string `json:"code"`
+	// Message describes the warning
+	Message string `json:"message"`
+	// Component is the name of the dataset component the warning concerns, if
+	// any. eg: "body", "structure"
+	Component string `json:"component,omitempty"`
+	// Row & Column locate a warning about a body in the body, when known.
+	// both are zero-indexed
+	Row    *int `json:"row,omitempty"`
+	Column *int `json:"column,omitempty"`
+}
+
+// String describes a warning, including its location if known
+func (w Warning) String() string {
+	var loc []string
+	if w.Row != nil {
+		loc = append(loc, fmt.Sprintf("row %d", *w.Row))
+	}
+	if w.Column != nil {
+		loc = append(loc, fmt.Sprintf("column %d", *w.Column))
+	}
+	if len(loc) == 0 {
+		return w.Message
+	}
+	return fmt.Sprintf("%s (%s)", w.Message, strings.Join(loc, ", "))
+}
@@ -0,0 +1,21 @@
+package base
+
+import "testing"
+
+func TestWarningString(t *testing.T) {
+	row, col := 2, 5
+	cases := []struct {
+		w      Warning
+		expect string
+	}{
+		{Warning{Message: "converted body from csv to json"}, "converted body from csv to json"},
+		{Warning{Message: "bad value", Row: &row}, "bad value (row 2)"},
+		{Warning{Message: "bad value", Row: &row, Column: &col}, "bad value (row 2, column 5)"},
+	}
+
+	for i, c := range cases {
+		if got := c.w.String(); got != c.expect {
+			t.Errorf("case %d string mismatch. expected: %q, got: %q", i, c.expect, got)
+		}
+	}
+}
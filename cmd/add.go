@@ -22,9 +22,17 @@ func NewAddCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
 Add retrieves a dataset owned by another peer and adds it to your repo. 
 The dataset reference of the dataset will remain the same, including 
 the name of the peer that originally added the dataset. You must have 
-` + "`qri connect`" + ` running in another terminal to use this command.`,
+` + "`qri connect`" + ` running in another terminal to use this command.
+
+A dataset version can also be added by its path alone. The author is read
+from the version's commit, and the add is refused if the commit signature
+doesn't verify against the author's key. Versions don't record a dataset
+name, so one must be given with the --name flag.`,
 		Example: `  add a dataset named their_data, owned by other_peer:
-  $ qri add other_peer/their_data`,
+  $ qri add other_peer/their_data
+
+  add a dataset version by path, naming it their_data:
+  $ qri add /ipfs/QmZfwmhbcgSDGqGaoMMYx8jxBGauZw75zPjnZAyfwPso7M --name their_data`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := o.Complete(f); err != nil {
 				return err
@@ -35,6 +43,7 @@ the name of the peer that originally added the dataset. You must have
 
 	cmd.Flags().StringVar(&o.LinkDir, "link", "", "path to directory to link dataset to")
 	cmd.Flags().BoolVar(&o.LogsOnly, "logs-only", false, "only fetch logs, skipping HEAD data")
+	cmd.Flags().StringVar(&o.LocalName, "name", "", "name to add a dataset version path under")
 
 	return cmd
 }
@@ -44,6 +53,7 @@ type AddOptions struct {
 	ioes.IOStreams
 	LinkDir         string
 	LogsOnly        bool
+	LocalName       string
 	DatasetRequests *lib.DatasetRequests
 }
 
@@ -66,12 +76,16 @@ func (o *AddOptions) Run(args []string) error {
 	if len(args) > 1 && o.LinkDir != "" {
 		return fmt.Errorf("link flag can only be used with a single reference")
 	}
+	if len(args) > 1 && o.LocalName != "" {
+		return fmt.Errorf("name flag can only be used with a single reference")
+	}
 
 	for _, arg := range args {
 		p := &lib.AddParams{
-			Ref:      arg,
-			LinkDir:  o.LinkDir,
-			LogsOnly: o.LogsOnly,
+			Ref:       arg,
+			LinkDir:   o.LinkDir,
+			LogsOnly:  o.LogsOnly,
+			LocalName: o.LocalName,
 		}
 
 		res := reporef.DatasetRef{}
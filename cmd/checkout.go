@@ -31,6 +31,9 @@ func NewCheckoutCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
 		},
 	}
 
+	cmd.Flags().StringVar(&o.Layout, "layout", "", "how to arrange dataset files, \"files\" (default) or \"single-file\" to keep components in one dataset.yaml")
+	cmd.Flags().StringVar(&o.BodyRange, "body-range", "", "only check out a range of body rows, like 1000-2000. saving splices edited rows back into the whole body")
+
 	return cmd
 }
 
@@ -38,7 +41,9 @@ func NewCheckoutCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
 type CheckoutOptions struct {
 	ioes.IOStreams
 
-	Refs *RefSelect
+	Refs      *RefSelect
+	Layout    string
+	BodyRange string
 
 	FSIMethods *lib.FSIMethods
 }
@@ -77,7 +82,7 @@ func (o *CheckoutOptions) Run() (err error) {
 	}
 
 	var res string
-	err = o.FSIMethods.Checkout(&lib.CheckoutParams{Dir: folderName, Ref: ref}, &res)
+	err = o.FSIMethods.Checkout(&lib.CheckoutParams{Dir: folderName, Ref: ref, Layout: o.Layout, BodyRange: o.BodyRange}, &res)
 	if err != nil {
 		return err
 	}
@@ -33,6 +33,9 @@ which ports on qri serves on; etc.
 Configuration is stored as a .yaml file kept at $QRI_PATH, or provided at CLI 
 runtime via command a line argument.
 
+Named environments in the configuration override parts of it, select one with
+the --env flag or the QRI_ENV environment variable.
+
 For details on each config field checkout: 
 https://github.com/qri-io/qri/blob/master/config/readme.md`,
 		Example: `  # get your profile information
@@ -42,7 +45,10 @@ https://github.com/qri-io/qri/blob/master/config/readme.md`,
   $ qri config set api.port 4444
 
   # disable rpc connections:
-  $ qri config set rpc.enabled false`,
+  $ qri config set rpc.enabled false
+
+  # get the api port of the "dev" environment
+  $ qri config get api.port --env dev`,
 	}
 
 	get := &cobra.Command{
@@ -182,8 +188,12 @@ func (o *ConfigOptions) Set(args []string) (err error) {
 		"profile.thumb":  true,
 	}
 
-	profile := o.inst.Config().Profile
+	// the instance config is shared, change a copy
+	cfg := o.inst.Config().Copy()
+	profile := cfg.Profile
 	profileChanged := false
+	// photos are set after the config is saved, setting them saves a new config
+	var photos []string
 
 	for i := 0; i < len(args)-1; i = i + 2 {
 		path := strings.ToLower(args[i])
@@ -194,9 +204,7 @@ func (o *ConfigOptions) Set(args []string) (err error) {
 		}
 
 		if photoPaths[path] {
-			if err = setPhotoPath(o.ProfileMethods, path, args[i+1]); err != nil {
-				return err
-			}
+			photos = append(photos, path, value)
 		} else if strings.HasPrefix(path, profilePrefix) {
 			field := strings.ToLower(path[len(profilePrefix):])
 			if err = profile.SetField(field, args[i+1]); err != nil {
@@ -205,15 +213,20 @@ func (o *ConfigOptions) Set(args []string) (err error) {
 			profileChanged = true
 		} else {
 			// TODO (b5): I think this'll resule in configuration not getting set. should investigate
-			if err = o.inst.Config().Set(path, value); err != nil {
+			if err = cfg.Set(path, value); err != nil {
 				return err
 			}
 		}
 	}
 	var ok bool
-	if err = o.ConfigMethods.SetConfig(o.inst.Config(), &ok); err != nil {
+	if err = o.ConfigMethods.SetConfig(cfg, &ok); err != nil {
 		return err
 	}
+	for i := 0; i < len(photos); i = i + 2 {
+		if err = setPhotoPath(o.ProfileMethods, photos[i], photos[i+1]); err != nil {
+			return err
+		}
+	}
 	if profileChanged {
 		var res config.ProfilePod
 		if err = o.ProfileMethods.SaveProfile(profile, &res); err != nil {
@@ -90,6 +90,9 @@ func (o *ConnectOptions) Complete(f Factory, args []string) (err error) {
 		return fmt.Errorf("Cannot serve without a node (`qri connect` already running?)")
 	}
 
+	// a running server can't answer prompts, remotes it contacts must have
+	// keys configured in RemoteKeys or trusted earlier
+	setNoPrompt(true)
 	o.inst = f.Instance()
 	return
 }
@@ -47,6 +47,9 @@ func NewDAGCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
 	missing := &cobra.Command{
 		Use:   "missing",
 		Short: "list blocks not present in this repo for a given manifest",
+		Long: `Missing lists blocks a manifest file lists that this repo doesn't have.
+With --remote, missing instead lists blocks the manifest file lists that a
+remote's manifest file doesn't, the blocks a push to that remote sends.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := o.Complete(f, args); err != nil {
 				return err
@@ -63,6 +66,7 @@ func NewDAGCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
 	missing.Flags().BoolVar(&o.Pretty, "pretty", false, "print output without indentation, only applies to json format")
 	missing.Flags().BoolVar(&o.Hex, "hex", false, "hex-encode output")
 	missing.Flags().StringVar(&o.File, "file", "", "manifest file")
+	missing.Flags().StringVar(&o.RemoteFile, "remote", "", "manifest file of blocks a remote has")
 
 	manifest.AddCommand(get, missing)
 
@@ -96,6 +100,7 @@ type DAGOptions struct {
 	Pretty     bool
 	Hex        bool
 	File       string
+	RemoteFile string
 	Label      string
 
 	DatasetRequests *lib.DatasetRequests
@@ -153,32 +158,22 @@ func (o *DAGOptions) Missing() error {
 		return fmt.Errorf("manifest file is required")
 	}
 
-	in := &dag.Manifest{}
-	data, err := ioutil.ReadFile(o.File)
-	if err != nil {
-		return err
-	}
-
-	switch strings.ToLower(filepath.Ext(o.File)) {
-	case ".yaml":
-		err = yaml.Unmarshal(data, in)
-	case ".json":
-		err = json.Unmarshal(data, in)
-	case ".cbor":
-		// TODO - detect hex input?
-		// data, err = hex.DecodeString(string(data))
-		// if err != nil {
-		// 	return err
-		// }
-		in, err = dag.UnmarshalCBORManifest(data)
-	}
-
+	in, err := readManifestFile(o.File)
 	if err != nil {
 		return err
 	}
 
 	mf := &dag.Manifest{}
-	if err = o.DatasetRequests.ManifestMissing(in, mf); err != nil {
+	if o.RemoteFile != "" {
+		remote, err := readManifestFile(o.RemoteFile)
+		if err != nil {
+			return err
+		}
+		p := &lib.ManifestMissingRemoteParams{Local: in, Remote: remote}
+		if err = o.DatasetRequests.ManifestMissingRemote(p, mf); err != nil {
+			return err
+		}
+	} else if err = o.DatasetRequests.ManifestMissing(in, mf); err != nil {
 		return err
 	}
 
@@ -206,6 +201,30 @@ func (o *DAGOptions) Missing() error {
 	return err
 }
 
+// readManifestFile reads a manifest from a yaml, json or cbor file
+func readManifestFile(path string) (mf *dag.Manifest, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mf = &dag.Manifest{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml":
+		err = yaml.Unmarshal(data, mf)
+	case ".json":
+		err = json.Unmarshal(data, mf)
+	case ".cbor":
+		// TODO - detect hex input?
+		// data, err = hex.DecodeString(string(data))
+		// if err != nil {
+		// 	return err
+		// }
+		mf, err = dag.UnmarshalCBORManifest(data)
+	}
+	return mf, err
+}
+
 // Info executes the dag info command
 func (o *DAGOptions) Info() (err error) {
 	info := &dag.Info{}
@@ -29,7 +29,10 @@ new dataset, use --blank.`,
   qri export me/annual_pop
 
   # export to a specific directory
-  qri export -o ~/new_directory me/annual_pop`,
+  qri export -o ~/new_directory me/annual_pop
+
+  # export as a static website, viewable in any browser
+  qri export --site -o annual_pop_site me/annual_pop`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -43,8 +46,10 @@ new dataset, use --blank.`,
 
 	cmd.Flags().BoolVarP(&o.Blank, "blank", "", false, "export a blank dataset YAML file, overrides all other flags except output")
 	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "path to write to, default is current directory")
-	cmd.Flags().StringVarP(&o.Format, "format", "f", "", "format for the exported dataset, such as native, json, xlsx. default: json")
+	cmd.Flags().StringVarP(&o.Format, "format", "f", "", "format for the exported dataset, such as native, json, xlsx, parquet, datapackage. default: json")
 	cmd.Flags().BoolVarP(&o.Zipped, "zip", "z", false, "export as a zip file")
+	cmd.Flags().BoolVarP(&o.Site, "site", "", false, "export as a static HTML site directory")
+	cmd.Flags().IntVarP(&o.SampleSize, "sample-size", "", 0, "number of body entries a site includes, -1 includes the entire body. default: 1000")
 
 	return cmd
 }
@@ -59,6 +64,9 @@ type ExportOptions struct {
 	Format string
 	Zipped bool
 
+	Site       bool
+	SampleSize int
+
 	UsingRPC       bool
 	ExportRequests *lib.ExportRequests
 }
@@ -96,6 +104,20 @@ func (o *ExportOptions) Run() error {
 		return fmt.Errorf("'%s' already exists", path)
 	}
 
+	if o.Site {
+		p := &lib.ExportSiteParams{
+			Ref:        o.Refs.Ref(),
+			Dir:        path,
+			SampleSize: o.SampleSize,
+		}
+		var dirWritten string
+		if err := o.ExportRequests.ExportSite(p, &dirWritten); err != nil {
+			return err
+		}
+		fmt.Fprintf(o.Out, "dataset site exported to \"%s\"\n", dirWritten)
+		return nil
+	}
+
 	p := &lib.ExportParams{
 		Ref:    o.Refs.Ref(),
 		Output: path,
@@ -37,6 +37,8 @@ type Factory interface {
 	SearchMethods() (*lib.SearchMethods, error)
 	RenderRequests() (*lib.RenderRequests, error)
 	FSIMethods() (*lib.FSIMethods, error)
+	RepoMethods() (*lib.RepoMethods, error)
+	TrashMethods() (*lib.TrashMethods, error)
 }
 
 // PathFactory is a function that returns paths to qri & ipfs repos
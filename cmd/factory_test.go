@@ -191,6 +191,16 @@ func (t TestFactory) FSIMethods() (*lib.FSIMethods, error) {
 	return lib.NewFSIMethods(t.inst), nil
 }
 
+// RepoMethods generates a lib.RepoMethods from internal state
+func (t TestFactory) RepoMethods() (*lib.RepoMethods, error) {
+	return lib.NewRepoMethods(t.inst), nil
+}
+
+// TrashMethods generates a lib.TrashMethods from internal state
+func (t TestFactory) TrashMethods() (*lib.TrashMethods, error) {
+	return lib.NewTrashMethods(t.inst), nil
+}
+
 // SearchMethods generates a lib.SearchMethods from internal state
 func (t TestFactory) SearchMethods() (*lib.SearchMethods, error) {
 	return lib.NewSearchMethods(t.inst), nil
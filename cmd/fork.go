@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qri/lib"
+	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/spf13/cobra"
+)
+
+// NewForkCommand creates a new `qri fork` cobra command for branching a
+// dataset into a new name
+func NewForkCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
+	o := &ForkOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "fork SOURCE NEW_NAME",
+		Short: "Copy a dataset to a new name, keeping track of where it came from",
+		Long: `
+Fork creates a new dataset in your namespace from a version of an existing
+dataset. The source can be one of your datasets or a dataset you've added
+from a peer. The latest version is forked unless the source reference
+includes a version path.
+
+The new dataset starts with a single version that records the source
+version and its author. Forks share stored data with their source, so
+forking doesn't use extra space until you make changes.`,
+		Example: `  # fork a dataset to experiment with:
+  $ qri fork me/annual_pop annual_pop_experiment
+
+  # fork a specific version of a peer's dataset:
+  $ qri fork b5/world_bank_population@/ipfs/QmFoo... my_population`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	return cmd
+}
+
+// ForkOptions encapsulates state for the fork command
+type ForkOptions struct {
+	ioes.IOStreams
+
+	Source string
+	Name   string
+
+	DatasetRequests *lib.DatasetRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *ForkOptions) Complete(f Factory, args []string) (err error) {
+	o.Source = args[0]
+	o.Name = args[1]
+	o.DatasetRequests, err = f.DatasetRequests()
+	return
+}
+
+// Run executes the fork command
+func (o *ForkOptions) Run() error {
+	p := &lib.ForkParams{
+		Source: o.Source,
+		Name:   o.Name,
+	}
+	res := reporef.DatasetRef{}
+	if err := o.DatasetRequests.Fork(p, &res); err != nil {
+		return err
+	}
+
+	printSuccess(o.Out, "forked %s to %s", o.Source, res.AliasString())
+	return nil
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/lib"
+	"github.com/spf13/cobra"
+)
+
+// NewFsckCommand creates a new `qri fsck` command for checking repo integrity
+func NewFsckCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
+	o := &FsckOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "fsck",
+		Short: "Check the integrity of your qri repo",
+		Long: `
+Fsck walks every dataset reference in your repo, checking that:
+  * the latest version of each dataset can be loaded
+  * each dataset has a history in your logbook that agrees on the latest
+    version
+  * linked working directories still exist
+  * every dataset history in your logbook has a reference
+
+Use the ` + "`--blocks`" + ` flag to also confirm every block of each dataset's
+latest version is stored locally. This can be slow for large repos.
+
+Use the ` + "`--dedup`" + ` flag to also report how much space is saved by
+dataset versions sharing blocks, and which datasets share a body.
+
+Use the ` + "`--usage`" + ` flag to measure how much space the repo uses, correcting
+the running total qri keeps to enforce the repo size limits set in config.
+
+Use the ` + "`--repair`" + ` flag to apply safe fixes. Links to missing working
+directories are removed, missing logbook histories are rebuilt, references
+and logbook histories where one is behind the other are brought up to date,
+and references to versions that can't be loaded are rewound to the latest
+version in the logbook that can. You'll be asked to confirm removing each
+reference with other problems.`,
+		Example: `  # check the repo for problems:
+  $ qri fsck
+
+  # check for missing blocks & fix what can be fixed:
+  $ qri fsck --blocks --repair
+
+  # see how much space shared blocks save:
+  $ qri fsck --dedup`,
+		Annotations: map[string]string{
+			"group": "other",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().BoolVar(&o.VerifyBlocks, "blocks", false, "confirm all blocks of each version are stored")
+	cmd.Flags().BoolVar(&o.Repair, "repair", false, "apply safe fixes for problems found")
+	cmd.Flags().BoolVar(&o.Dedup, "dedup", false, "report space saved by shared blocks")
+	cmd.Flags().BoolVar(&o.Usage, "usage", false, "measure & report disk usage")
+	cmd.Flags().StringVarP(&o.Format, "format", "f", "", "set output format [json]")
+
+	return cmd
+}
+
+// FsckOptions encapsulates state for the fsck command
+type FsckOptions struct {
+	ioes.IOStreams
+
+	VerifyBlocks bool
+	Repair       bool
+	Dedup        bool
+	Usage        bool
+	Format       string
+
+	RepoMethods *lib.RepoMethods
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *FsckOptions) Complete(f Factory, args []string) (err error) {
+	if o.Format != "" && o.Format != "json" {
+		return fmt.Errorf("%s is not an accepted format, options are 'json'", o.Format)
+	}
+	o.RepoMethods, err = f.RepoMethods()
+	return err
+}
+
+// Run executes the fsck command
+func (o *FsckOptions) Run() error {
+	p := &lib.CheckRepoParams{VerifyBlocks: o.VerifyBlocks}
+	res := &lib.CheckRepoResult{}
+	if err := o.RepoMethods.Check(p, res); err != nil {
+		return err
+	}
+
+	if o.Repair {
+		// references with problems that can't be fixed in place need the user's
+		// go-ahead before they're removed
+		for _, rc := range res.Problems() {
+			if base.RepairableInPlace(rc) {
+				continue
+			}
+			if confirm(o.ErrOut, o.In, fmt.Sprintf("remove reference %s (%s)?", rc.Ref, problemsString(rc.Problems)), false) {
+				p.Drop = append(p.Drop, rc.Ref)
+			}
+		}
+		p.Repair = true
+		res = &lib.CheckRepoResult{}
+		if err := o.RepoMethods.Check(p, res); err != nil {
+			return err
+		}
+	}
+
+	var dedup *base.DedupReport
+	if o.Dedup {
+		dedup = &base.DedupReport{}
+		if err := o.RepoMethods.Dedup(&lib.DedupParams{}, dedup); err != nil {
+			return err
+		}
+	}
+
+	var usage *lib.DiskUsageResult
+	if o.Usage {
+		usage = &lib.DiskUsageResult{}
+		if err := o.RepoMethods.RecalculateDiskUsage(&lib.DiskUsageParams{}, usage); err != nil {
+			return err
+		}
+	}
+
+	if o.Format == "json" {
+		data, err := json.MarshalIndent(struct {
+			*lib.CheckRepoResult
+			Dedup     *base.DedupReport    `json:"dedup,omitempty"`
+			DiskUsage *lib.DiskUsageResult `json:"diskUsage,omitempty"`
+		}{res, dedup, usage}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(o.Out, string(data))
+		return nil
+	}
+
+	for _, rc := range res.Problems() {
+		fmt.Fprintf(o.Out, "%s: %s\n", rc.Ref, problemsString(rc.Problems))
+		for _, b := range rc.MissingBlocks {
+			fmt.Fprintf(o.Out, "    missing block %s\n", b)
+		}
+		if rc.LogPath != "" {
+			fmt.Fprintf(o.Out, "    logbook head is %s, reference head is %s\n", rc.LogPath, rc.Path)
+		}
+		if rc.Error != "" {
+			fmt.Fprintf(o.Out, "    %s\n", rc.Error)
+		}
+	}
+	for _, alias := range res.OrphanedLogs {
+		fmt.Fprintf(o.Out, "%s: orphaned log\n", alias)
+	}
+	for _, alias := range res.Repaired {
+		printSuccess(o.Out, "repaired %s", alias)
+	}
+
+	problems := len(res.Problems()) + len(res.OrphanedLogs)
+	if problems == 0 {
+		printSuccess(o.Out, "✔ checked %d references, no problems found", res.OK)
+	} else {
+		printWarning(o.Out, "checked %d references, %d ok, %d problems found", len(res.Refs), res.OK, problems)
+	}
+	if dedup != nil {
+		printDedupReport(o.Out, dedup)
+	}
+	if usage != nil {
+		printDiskUsage(o.Out, usage)
+	}
+	return nil
+}
+
+func printDiskUsage(w io.Writer, u *lib.DiskUsageResult) {
+	fmt.Fprintf(w, "\ndisk usage:    %s\n", humanize.Bytes(uint64(u.Bytes)))
+	if u.MaxSize > 0 {
+		fmt.Fprintf(w, "repo limit:    %s\n", humanize.Bytes(uint64(u.MaxSize)))
+	}
+	if u.MaxVersionSize > 0 {
+		fmt.Fprintf(w, "version limit: %s\n", humanize.Bytes(uint64(u.MaxVersionSize)))
+	}
+}
+
+func printDedupReport(w io.Writer, r *base.DedupReport) {
+	fmt.Fprintf(w, "\n%d versions, %d distinct blocks\n", r.Versions, r.Blocks)
+	fmt.Fprintf(w, "stored size:  %s\n", humanize.Bytes(r.StoredSize))
+	fmt.Fprintf(w, "logical size: %s\n", humanize.Bytes(r.LogicalSize))
+	fmt.Fprintf(w, "saved:        %s\n", humanize.Bytes(r.Saved()))
+	if r.Skipped > 0 {
+		fmt.Fprintf(w, "%d versions couldn't be measured\n", r.Skipped)
+	}
+	if len(r.TopShared) > 0 {
+		fmt.Fprintln(w, "\nmost shared blocks:")
+		for _, b := range r.TopShared {
+			fmt.Fprintf(w, "  %s  %s x%d (%s) %s\n", b.ID, humanize.Bytes(b.Size), b.References, strings.Join(b.Components, ", "), strings.Join(b.Datasets, ", "))
+		}
+	}
+	if len(r.SharedBodies) > 0 {
+		fmt.Fprintln(w, "\ndatasets sharing a body:")
+		for _, b := range r.SharedBodies {
+			fmt.Fprintf(w, "  %s  %s: %s\n", b.ID, humanize.Bytes(b.Size), strings.Join(b.Datasets, ", "))
+		}
+	}
+}
+
+func problemsString(problems []base.RefProblem) string {
+	strs := make([]string, len(problems))
+	for i, p := range problems {
+		strs[i] = string(p)
+	}
+	return strings.Join(strs, ", ")
+}
@@ -19,9 +19,10 @@ func NewGetCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
 		Use:   "get",
 		Short: "Get elements of qri datasets",
 		Long: `Get the qri dataset (except for the body). You can also get portions of 
-the dataset: meta, structure, viz, transform, and commit. To narrow down
-further to specific fields in each section, use dot notation. The get 
-command prints to the console in yaml format, by default.
+the dataset: meta, structure, readme, viz, transform, and commit. To narrow
+down further to specific fields in each section, use dot notation. Sections
+can be abbreviated: cm, md, st, rm, vz, tf. The get command prints to the
+console in yaml format, by default.
 
 You can get pertinent information on multiple datasets at the same time
 by supplying more than one dataset reference.
@@ -37,8 +38,20 @@ dataset and its fields.`,
   # print the dataset body size to the console
   qri get structure.length me/annual_pop
 
+  # print the markdown text of the readme
+  qri get rm.script me/annual_pop
+
   # print the dataset body size for two different datasets
-  qri get structure.length me/annual_pop me/annual_gdp`,
+  qri get structure.length me/annual_pop me/annual_gdp
+
+  # print the meta of the version labelled "release-1"
+  qri get meta me/annual_pop --rev release-1
+
+  # print the body as it was on June 1st, 2019
+  qri get body me/annual_pop --rev 2019-06-01
+
+  # print only the country and population columns of the body as csv
+  qri get body me/annual_pop --columns country,population -f csv`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -59,6 +72,10 @@ dataset and its fields.`,
 	cmd.Flags().IntVar(&o.PageSize, "page-size", -1, "for body, limit how many entries to get per page")
 	cmd.Flags().IntVar(&o.Page, "page", -1, "for body, page at which to get entries")
 	cmd.Flags().BoolVarP(&o.All, "all", "a", true, "for body, whether to get all entries")
+	cmd.Flags().StringSliceVar(&o.Columns, "columns", nil, "for body, columns to get, comma separated")
+	cmd.Flags().StringVar(&o.Revision, "rev", "", "version to get, a number of versions back from the latest, an ISO date, or a label")
+	cmd.Flags().BoolVar(&o.StrictSignatures, "strict-signatures", false, "fail if the version's commit signature is invalid")
+	cmd.Flags().BoolVar(&o.ExternalBody, "external-body", false, "for body, read a body stored at an external url")
 
 	return cmd
 }
@@ -70,14 +87,19 @@ type GetOptions struct {
 	Refs     *RefSelect
 	Selector string
 	Format   string
+	Revision string
 
 	Page     int
 	PageSize int
 	All      bool
+	Columns  []string
 
 	Pretty    bool
 	HasPretty bool
 
+	StrictSignatures bool
+	ExternalBody     bool
+
 	DatasetRequests *lib.DatasetRequests
 }
 
@@ -89,7 +111,7 @@ func (o *GetOptions) Complete(f Factory, args []string) (err error) {
 
 	if len(args) > 0 {
 		if component.IsDatasetField.MatchString(args[0]) {
-			o.Selector = args[0]
+			o.Selector = component.ExpandFieldAbbrev(args[0])
 			args = args[1:]
 		}
 	}
@@ -139,17 +161,25 @@ func (o *GetOptions) Run() (err error) {
 	p := lib.GetParams{
 		Path:         o.Refs.Ref(),
 		Selector:     o.Selector,
-		UseFSI:       o.Refs.IsLinked(),
+		UseFSI:       o.Refs.IsLinked() && o.Revision == "",
 		Format:       o.Format,
 		FormatConfig: fc,
+		Revision:     o.Revision,
 		Offset:       page.Offset(),
 		Limit:        page.Limit(),
 		All:          o.All,
+		Columns:      o.Columns,
+
+		StrictSignatures: o.StrictSignatures,
+		ExternalBody:     o.ExternalBody,
 	}
 	res := lib.GetResult{}
 	if err = o.DatasetRequests.Get(&p, &res); err != nil {
 		return err
 	}
+	if res.Signature.Err() != nil {
+		printWarning(o.ErrOut, "WARNING: the commit signature of this version is invalid, it may have been tampered with: %s", res.Signature.Reason)
+	}
 
 	buf := bytes.NewBuffer(res.Bytes)
 	buf.Write([]byte{'\n'})
@@ -34,6 +34,8 @@ func NewInitCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
 	cmd.Flags().StringVar(&o.Name, "name", "", "name of the dataset")
 	cmd.Flags().StringVar(&o.Format, "format", "", "format of dataset")
 	cmd.Flags().StringVar(&o.SourceBodyPath, "source-body-path", "", "path to the body file")
+	cmd.Flags().StringVar(&o.Template, "template", "", "name of a dataset template to start from")
+	cmd.Flags().StringVar(&o.Layout, "layout", "", "how to arrange dataset files, \"files\" (default) or \"single-file\" to keep components in one dataset.yaml")
 
 	return cmd
 }
@@ -46,6 +48,8 @@ type InitOptions struct {
 	Format         string
 	SourceBodyPath string
 	Mkdir          string
+	Layout         string
+	Template       string
 
 	DatasetRequests *lib.DatasetRequests
 	FSIMethods      *lib.FSIMethods
@@ -90,7 +94,8 @@ func (o *InitOptions) Run() (err error) {
 		o.Format = ext
 	}
 
-	if o.Format == "" {
+	// Templates with a structure supply a format
+	if o.Format == "" && o.Template == "" {
 		o.Format = inputText(o.ErrOut, o.In, "Format of dataset, csv or json", "csv")
 	}
 
@@ -100,6 +105,8 @@ func (o *InitOptions) Run() (err error) {
 		Format:         o.Format,
 		Name:           o.Name,
 		SourceBodyPath: o.SourceBodyPath,
+		Layout:         o.Layout,
+		Template:       o.Template,
 	}
 	var name string
 	if err = o.FSIMethods.InitDataset(p, &name); err != nil {
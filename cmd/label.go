@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/lib"
+	"github.com/spf13/cobra"
+)
+
+// NewLabelCommand creates a new `qri label` cobra command for naming dataset
+// versions
+func NewLabelCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
+	o := &LabelOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "label [DATASET] LABEL",
+		Short: "Name a version of a dataset",
+		Long: `
+Label attaches a name to a version of one of your datasets. Labels show up in
+` + "`qri log`" + `, and can be used anywhere a revision is accepted, like
+` + "`qri get --rev`" + ` or ` + "`qri remove --revisions`" + `.
+
+The latest version is labelled unless the dataset reference includes a version
+path. A label names one version of a dataset at a time, to move a label remove
+it first. Labels must start with a letter, and can't share a name with a
+dataset component like "body" or "meta".`,
+		Example: `  # label the latest version of a dataset:
+  $ qri label me/annual_pop release-1
+
+  # label an earlier version:
+  $ qri label me/annual_pop@/ipfs/QmFoo... draft
+
+  # remove a label:
+  $ qri label me/annual_pop draft --remove`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().BoolVar(&o.Remove, "remove", false, "remove the label instead of attaching it")
+
+	return cmd
+}
+
+// LabelOptions encapsulates state for the label command
+type LabelOptions struct {
+	ioes.IOStreams
+
+	Refs   *RefSelect
+	Label  string
+	Remove bool
+
+	LogRequests *lib.LogRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *LabelOptions) Complete(f Factory, args []string) (err error) {
+	o.Label = args[len(args)-1]
+	if o.Refs, err = GetCurrentRefSelect(f, args[:len(args)-1], 1, nil); err != nil {
+		return err
+	}
+	o.LogRequests, err = f.LogRequests()
+	return
+}
+
+// Run executes the label command
+func (o *LabelOptions) Run() error {
+	printRefSelect(o.ErrOut, o.Refs)
+
+	p := &lib.LabelParams{
+		Ref:    o.Refs.Ref(),
+		Label:  o.Label,
+		Remove: o.Remove,
+	}
+	res := dsref.VersionInfo{}
+	if err := o.LogRequests.Label(p, &res); err != nil {
+		return err
+	}
+
+	if o.Remove {
+		printSuccess(o.Out, "removed label %q from %s", o.Label, res.Path)
+	} else {
+		printSuccess(o.Out, "labelled %s %q", res.Path, o.Label)
+	}
+	return nil
+}
@@ -58,8 +58,12 @@ must have ` + "`qri connect`" + ` running in a separate terminal window.`,
 	cmd.Flags().BoolVarP(&o.Published, "published", "p", false, "list only published datasets")
 	cmd.Flags().BoolVarP(&o.ShowNumVersions, "num-versions", "n", false, "show number of versions")
 	cmd.Flags().StringVar(&o.Peername, "peer", "", "peer whose datasets to list")
+	cmd.Flags().StringSliceVar(&o.ProfileIDs, "profile-id", nil, "only list datasets authored by these profile IDs")
 	cmd.Flags().BoolVarP(&o.Raw, "raw", "r", false, "to show raw references")
 	cmd.Flags().BoolVarP(&o.UseDscache, "use-dscache", "", false, "build and use dscache to list")
+	cmd.Flags().BoolVarP(&o.SyncStatus, "sync-status", "", false, "compare published datasets with a remote")
+	cmd.Flags().StringVar(&o.RemoteName, "remote", "", "remote to compare with for --sync-status, defaults to the registry")
+	cmd.Flags().BoolVar(&o.Trashed, "trashed", false, "include removed datasets that are still in the trash")
 
 	return cmd
 }
@@ -73,10 +77,14 @@ type ListOptions struct {
 	Page            int
 	Term            string
 	Peername        string
+	ProfileIDs      []string
 	Published       bool
 	ShowNumVersions bool
 	Raw             bool
 	UseDscache      bool
+	SyncStatus      bool
+	RemoteName      string
+	Trashed         bool
 
 	DatasetRequests *lib.DatasetRequests
 }
@@ -111,12 +119,16 @@ func (o *ListOptions) Run() (err error) {
 	p := &lib.ListParams{
 		Term:            o.Term,
 		Peername:        o.Peername,
+		ProfileIDs:      o.ProfileIDs,
 		Limit:           page.Limit(),
 		Offset:          page.Offset(),
 		Published:       o.Published,
 		ShowNumVersions: o.ShowNumVersions,
 		EnsureFSIExists: true,
 		UseDscache:      o.UseDscache,
+		WithSyncStatus:  o.SyncStatus,
+		RemoteName:      o.RemoteName,
+		ShowTrashed:     o.Trashed,
 	}
 	if err = o.DatasetRequests.List(p, &infos); err != nil {
 		return err
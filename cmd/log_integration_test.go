@@ -74,16 +74,12 @@ func TestLogAndDeletes(t *testing.T) {
 		t.Fatal(err)
 	}
 	output = run.GetCommandOutput()
-	expect = `1   Commit:  /ipfs/QmafMDc2BZQfg1fsnpBmsK9J1jciPgnMfWdgPiNTjjW84j
+	expect = `1   Commit:  /ipfs/QmZkUj8Mr3NVgQQNePaaysNUmc7gECSK1Eo73R7hnCwDYQ
     Date:    Sun Dec 31 20:02:01 EST 2000
     Storage: local
     Size:    137 B
 
-    structure updated 3 fields
-    structure:
-    	updated checksum
-    	updated entries
-    	updated length
+    added 2 body rows
 
 2   Commit:  /ipfs/QmeDCtiNtcomDT6tm1kBCFD16pyHsZW5dRZGSo7Uwk4QKg
     Date:    Sun Dec 31 20:01:01 EST 2000
@@ -222,6 +222,10 @@ func (o *PeersOptions) List() (err error) {
 		if err := o.PeerRequests.ConnectedIPFSPeers(&limit, &res); err != nil {
 			return err
 		}
+		status := &lib.P2PStatus{}
+		if err := o.PeerRequests.Status(nil, status); err == nil && status.PrivateNetwork {
+			printInfo(o.Out, "private network mode active")
+		}
 
 		items = make([]fmt.Stringer, len(res))
 		for i, p := range res {
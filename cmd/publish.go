@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/qri-io/ioes"
 	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/lib"
@@ -24,11 +27,20 @@ to a published dataset will be immediately visible to connected peers.
   # publish a few datasets
   $ qri publish me/dataset me/other_dataset
 
+  # publish every one of your datasets with a name starting with "sales_"
+  $ qri publish "me/sales_*"
+
   # unpublish a dataset
   $ qri publish --unpublish me/dataset
 
   # publish a few dataset on p2p only
-  $ qri publish --no-registry me/dataset_2`,
+  $ qri publish --no-registry me/dataset_2
+
+  # queue a dataset to publish in the background, retrying while offline
+  $ qri publish --queue me/dataset
+
+  # publish a dataset to two remotes
+  $ qri publish --remote registry --remote internal me/dataset`,
 		Annotations: map[string]string{
 			"group": "network",
 		},
@@ -43,7 +55,8 @@ to a published dataset will be immediately visible to connected peers.
 	cmd.Flags().BoolVarP(&o.Unpublish, "unpublish", "", false, "unpublish a dataset")
 	cmd.Flags().BoolVarP(&o.NoRegistry, "no-registry", "", false, "don't publish to registry")
 	cmd.Flags().BoolVarP(&o.NoPin, "no-pin", "", false, "don't pin dataset to registry")
-	cmd.Flags().StringVarP(&o.RemoteName, "remote", "", "", "name of remote to publish to")
+	cmd.Flags().BoolVarP(&o.Queue, "queue", "", false, "publish in the background, retrying until the remote is reachable")
+	cmd.Flags().StringSliceVar(&o.RemoteNames, "remote", nil, "name of remote to publish to, repeat to publish to more than one")
 
 	return cmd
 }
@@ -52,11 +65,12 @@ to a published dataset will be immediately visible to connected peers.
 type PublishOptions struct {
 	ioes.IOStreams
 
-	Refs       *RefSelect
-	Unpublish  bool
-	NoRegistry bool
-	NoPin      bool
-	RemoteName string
+	Refs        *RefSelect
+	Unpublish   bool
+	NoRegistry  bool
+	NoPin       bool
+	Queue       bool
+	RemoteNames []string
 
 	DatasetRequests *lib.DatasetRequests
 	RemoteMethods   *lib.RemoteMethods
@@ -69,7 +83,7 @@ func (o *PublishOptions) Complete(f Factory, args []string) (err error) {
 		return err
 	}
 
-	if o.Refs, err = GetCurrentRefSelect(f, args, 1, nil); err != nil {
+	if o.Refs, err = GetCurrentRefSelect(f, args, 2, nil); err != nil {
 		return err
 	}
 
@@ -81,9 +95,26 @@ func (o *PublishOptions) Complete(f Factory, args []string) (err error) {
 func (o *PublishOptions) Run() error {
 	printRefSelect(o.ErrOut, o.Refs)
 
+	if len(o.RemoteNames) > 1 {
+		if o.Unpublish {
+			return fmt.Errorf("can only unpublish from one remote at a time")
+		}
+		if o.Queue {
+			return fmt.Errorf("can only queue publishing to one remote at a time")
+		}
+		return o.publishToRemotes()
+	}
+
+	if refs := o.Refs.RefList(); len(refs) > 1 || strings.Contains(o.Refs.Ref(), "*") {
+		return o.publishMany(refs)
+	}
+
 	p := lib.PublicationParams{
-		Ref:        o.Refs.Ref(),
-		RemoteName: o.RemoteName,
+		Ref:   o.Refs.Ref(),
+		Queue: o.Queue,
+	}
+	if len(o.RemoteNames) == 1 {
+		p.RemoteName = o.RemoteNames[0]
 	}
 	var res dsref.Ref
 	if o.Unpublish {
@@ -95,7 +126,78 @@ func (o *PublishOptions) Run() error {
 		if err := o.RemoteMethods.Publish(&p, &res); err != nil {
 			return err
 		}
+		if o.Queue {
+			printInfo(o.Out, "queued dataset %s for publishing", res)
+			return nil
+		}
 		printInfo(o.Out, "published dataset %s", res)
 	}
 	return nil
 }
+
+// publishToRemotes publishes to every remote, reporting each outcome
+func (o *PublishOptions) publishToRemotes() error {
+	p := lib.PublicationParams{
+		Ref:         o.Refs.Ref(),
+		RemoteNames: o.RemoteNames,
+	}
+	var res []lib.PublishResult
+	if err := o.RemoteMethods.PublishToRemotes(&p, &res); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range res {
+		switch {
+		case r.Skipped:
+			printInfo(o.Out, "%s already has the latest version", r.RemoteName)
+		case r.Error != "":
+			failed++
+			printErr(o.ErrOut, fmt.Errorf("publishing to %s: %s", r.RemoteName, r.Error))
+		default:
+			printSuccess(o.Out, "published dataset %s to %s", o.Refs.Ref(), r.RemoteName)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("publishing failed for %d of %d remotes, publish again to retry", failed, len(res))
+	}
+	return nil
+}
+
+// publishMany changes the publish status of a list of datasets & patterns,
+// reporting each outcome
+func (o *PublishOptions) publishMany(refs []string) error {
+	p := lib.BulkPublicationParams{
+		Refs:      refs,
+		Unpublish: o.Unpublish,
+		Queue:     o.Queue,
+	}
+	if len(o.RemoteNames) == 1 {
+		p.RemoteName = o.RemoteNames[0]
+	}
+	var res []lib.BulkPublicationResult
+	if err := o.RemoteMethods.PublishMany(&p, &res); err != nil {
+		return err
+	}
+
+	verb := "published"
+	switch {
+	case o.Unpublish:
+		verb = "unpublished"
+	case o.Queue:
+		verb = "queued for publishing"
+	}
+	failed := 0
+	for _, r := range res {
+		if r.Error != "" {
+			failed++
+			printErr(o.ErrOut, fmt.Errorf("%s: %s", r.Ref, r.Error))
+			continue
+		}
+		printSuccess(o.Out, "%s dataset %s", verb, r.Ref)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d datasets failed", failed, len(res))
+	}
+	return nil
+}
@@ -39,6 +39,7 @@ https://github.com/qri-io/qri/issues`,
 	cmd.PersistentFlags().StringVar(&opt.RepoPath, "repo", qriPath, "provide a path to load qri from")
 	cmd.PersistentFlags().StringVar(&opt.IpfsPath, "ipfs-path", ipfsPath, "override IPFS path location")
 	cmd.PersistentFlags().BoolVarP(&opt.LogAll, "log-all", "", false, "log all activity")
+	cmd.PersistentFlags().StringVar(&opt.Env, "env", os.Getenv("QRI_ENV"), "config environment to apply, defaults to $QRI_ENV")
 
 	cmd.AddCommand(
 		NewAddCommand(opt, ioStreams),
@@ -49,9 +50,12 @@ https://github.com/qri-io/qri/issues`,
 		NewDiffCommand(opt, ioStreams),
 		NewExportCommand(opt, ioStreams),
 		NewFetchCommand(opt, ioStreams),
+		NewForkCommand(opt, ioStreams),
 		NewFSICommand(opt, ioStreams),
+		NewFsckCommand(opt, ioStreams),
 		NewGetCommand(opt, ioStreams),
 		NewInitCommand(opt, ioStreams),
+		NewLabelCommand(opt, ioStreams),
 		NewListCommand(opt, ioStreams),
 		NewLogCommand(opt, ioStreams),
 		NewLogbookCommand(opt, ioStreams),
@@ -67,6 +71,7 @@ https://github.com/qri-io/qri/issues`,
 		NewSetupCommand(opt, ioStreams),
 		NewStatsCommand(opt, ioStreams),
 		NewStatusCommand(opt, ioStreams),
+		NewTrashCommand(opt, ioStreams),
 		NewUseCommand(opt, ioStreams),
 		NewUpdateCommand(opt, ioStreams),
 		NewValidateCommand(opt, ioStreams),
@@ -102,6 +107,8 @@ type QriOptions struct {
 	ConfigPath string
 	// Whether to log all activity by enabling logging for all packages
 	LogAll bool
+	// Env names the config environment to apply
+	Env string
 
 	inst        *lib.Instance
 	initialized sync.Once
@@ -125,7 +132,9 @@ func (o *QriOptions) Init() (err error) {
 			lib.OptIOStreams(o.IOStreams), // transfer iostreams to instance
 			lib.OptSetIPFSPath(o.IpfsPath),
 			lib.OptCheckConfigMigrations(""),
+			lib.OptEnvironment(o.Env),
 			lib.OptSetLogAll(o.LogAll),
+			lib.OptTrustPrompt(o.trustRemoteKey),
 		}
 		o.inst, err = lib.NewInstance(o.ctx, o.RepoPath, opts...)
 		log.Debugf("running cmd %q", os.Args)
@@ -134,6 +143,17 @@ func (o *QriOptions) Init() (err error) {
 	return
 }
 
+// trustRemoteKey asks whether to trust the key a remote signs responses with
+// the first time the remote is contacted. Without prompts nothing can confirm
+// the key, so it isn't trusted
+func (o *QriOptions) trustRemoteKey(remoteAddr, keyID string) bool {
+	if o.NoPrompt || noPrompt {
+		return false
+	}
+	msg := fmt.Sprintf("remote %s signs responses with key %s, which hasn't been seen before. trust it?", remoteAddr, keyID)
+	return confirm(o.ErrOut, o.In, msg, false)
+}
+
 // Instance returns the instance this options is using
 func (o *QriOptions) Instance() *lib.Instance {
 	if err := o.Init(); err != nil {
@@ -210,7 +230,7 @@ func (o *QriOptions) LogRequests() (*lib.LogRequests, error) {
 	if err := o.Init(); err != nil {
 		return nil, err
 	}
-	return lib.NewLogRequests(o.inst.Node(), o.inst.RPC()), nil
+	return lib.NewLogRequestsInstance(o.inst), nil
 }
 
 // ExportRequests generates a lib.ExportRequests from internal state
@@ -271,3 +291,21 @@ func (o *QriOptions) FSIMethods() (m *lib.FSIMethods, err error) {
 
 	return lib.NewFSIMethods(o.inst), nil
 }
+
+// RepoMethods generates a lib.RepoMethods from internal state
+func (o *QriOptions) RepoMethods() (m *lib.RepoMethods, err error) {
+	if err = o.Init(); err != nil {
+		return
+	}
+
+	return lib.NewRepoMethods(o.inst), nil
+}
+
+// TrashMethods generates a lib.TrashMethods from internal state
+func (o *QriOptions) TrashMethods() (m *lib.TrashMethods, err error) {
+	if err = o.Init(); err != nil {
+		return
+	}
+
+	return lib.NewTrashMethods(o.inst), nil
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/qri-io/ioes"
@@ -28,10 +29,23 @@ be sooner.
 Keep in mind that by default your IPFS repo is capped at 10GB in size, if you
 adjust this cap using IPFS, qri will respect it.
 
+Removing all versions of a dataset moves it to the trash, where it can be
+restored with ` + "`qri trash restore`" + ` until the trash is emptied. Use the
+` + "`--permanent`" + ` flag to skip the trash.
+
 In the future we’ll add a flag that’ll force immediate removal of a dataset from
 both qri & IPFS. Promise.`,
 		Example: `  remove a dataset named annual_pop:
-  $ qri remove me/annual_pop --all`,
+  $ qri remove me/annual_pop --all
+
+  remove the latest two versions of annual_pop:
+  $ qri remove me/annual_pop --revisions 2
+
+  remove all versions of annual_pop that came after the version labelled "release-1":
+  $ qri remove me/annual_pop --revisions release-1
+
+  remove all versions of annual_pop saved after June 1st, 2019:
+  $ qri remove me/annual_pop --revisions 2019-06-01`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -43,10 +57,11 @@ both qri & IPFS. Promise.`,
 		},
 	}
 
-	cmd.Flags().StringVarP(&o.RevisionsText, "revisions", "r", "", "revisions to delete")
+	cmd.Flags().StringVarP(&o.RevisionsText, "revisions", "r", "", "revisions to delete, a number of versions, or a date or label to remove newer versions than")
 	cmd.Flags().BoolVarP(&o.All, "all", "a", false, "synonym for --revisions=all")
 	cmd.Flags().BoolVar(&o.KeepFiles, "keep-files", false, "don't modify files in working directory")
 	cmd.Flags().BoolVarP(&o.Force, "force", "f", false, "remove files even if dirty")
+	cmd.Flags().BoolVar(&o.Permanent, "permanent", false, "delete immediately instead of moving to the trash when removing all versions")
 
 	return cmd
 }
@@ -62,6 +77,7 @@ type RemoveOptions struct {
 	All           bool
 	KeepFiles     bool
 	Force         bool
+	Permanent     bool
 
 	DatasetRequests *lib.DatasetRequests
 }
@@ -112,12 +128,18 @@ func (o *RemoveOptions) Run() (err error) {
 		Revision:  o.Revision,
 		KeepFiles: o.KeepFiles,
 		Force:     o.Force,
+		Permanent: o.Permanent,
 	}
 
 	res := lib.RemoveResponse{}
 	if err = o.DatasetRequests.Remove(&params, &res); err != nil {
-		if err == repo.ErrNotFound {
-			return lib.NewError(err, fmt.Sprintf("could not find dataset '%s'", o.Refs.Ref()))
+		if repo.IsNotFound(err) {
+			msg := fmt.Sprintf("could not find dataset '%s'", o.Refs.Ref())
+			var e *repo.ErrRefNotFound
+			if errors.As(err, &e) && e.DidYouMean() != "" {
+				msg = fmt.Sprintf("%s. %s", msg, e.DidYouMean())
+			}
+			return lib.NewError(err, msg)
 		}
 		if err == lib.ErrCantRemoveDirectoryDirty {
 			printErr(o.ErrOut, err)
@@ -129,6 +151,9 @@ func (o *RemoveOptions) Run() (err error) {
 
 	if res.NumDeleted == dsref.AllGenerations {
 		printSuccess(o.Out, "removed entire dataset '%s'", res.Ref)
+		if res.Trashed {
+			printInfo(o.ErrOut, "moved to the trash, use `qri trash restore` to bring it back")
+		}
 	} else if res.NumDeleted != 0 {
 		printSuccess(o.Out, "removed %d revisions of dataset '%s'", res.NumDeleted, res.Ref)
 	} else if res.Message != "" {
@@ -25,12 +25,18 @@ Use the ` + "`--output`" + ` flag to save the rendered html to a file.
 Use the ` + "`--viz`" + ` flag to render the viz. Default is to use readme.
 
 Use the ` + "`--template`" + ` flag to use a custom template. If no template is
-provided, Qri will render the dataset with a default template.`,
+provided, Qri will render the dataset with a default template.
+
+Use the ` + "`--name`" + ` flag with ` + "`--viz`" + ` to render one of the dataset's named viz
+templates, added with ` + "`qri save --viz-template`" + `.`,
 		Example: `  render the readme of a dataset called me/schools:
   $ qri render -o=schools.html me/schools
 
   render a dataset with a custom template:
-  $ qri render --viz --template=template.html me/schools`,
+  $ qri render --viz --template=template.html me/schools
+
+  render the dataset's "map" viz template:
+  $ qri render --viz --name=map me/schools`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -45,6 +51,7 @@ provided, Qri will render the dataset with a default template.`,
 	cmd.Flags().StringVarP(&o.Template, "template", "t", "", "path to template file")
 	cmd.Flags().BoolVarP(&o.UseViz, "viz", "v", false, "whether to use the viz component")
 	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "path to write output file")
+	cmd.Flags().StringVar(&o.VizName, "name", "", "name of a viz template to render")
 
 	return cmd
 }
@@ -56,6 +63,7 @@ type RenderOptions struct {
 	Refs     *RefSelect
 	Template string
 	UseViz   bool
+	VizName  string
 	Output   string
 
 	RenderRequests *lib.RenderRequests
@@ -77,6 +85,9 @@ func (o *RenderOptions) Run() error {
 	if o.Template != "" && !o.UseViz {
 		return fmt.Errorf("can not specify both --template without --viz flag")
 	}
+	if o.VizName != "" && !o.UseViz {
+		return fmt.Errorf("can not specify --name without --viz flag")
+	}
 
 	if o.UseViz {
 		return o.RunVizRender()
@@ -98,6 +109,7 @@ func (o *RenderOptions) RunVizRender() (err error) {
 	p := &lib.RenderParams{
 		Ref:       o.Refs.Ref(),
 		Template:  template,
+		VizName:   o.VizName,
 		OutFormat: "html",
 	}
 
@@ -3,12 +3,13 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"strings"
 
 	"github.com/qri-io/ioes"
 	"github.com/qri-io/qfs"
 	"github.com/qri-io/qri/lib"
 	"github.com/qri-io/qri/repo"
-	reporef "github.com/qri-io/qri/repo/ref"
 	"github.com/spf13/cobra"
 )
 
@@ -43,7 +44,10 @@ commit message and title to the save.`,
   qri save --file /path/to/dataset.yaml me/annual_pop
   
   # re-execute a dataset that has a transform:
-  qri save me/tf_dataset`,
+  qri save me/tf_dataset
+
+  # add a named viz template, render it with qri render --viz --name=map:
+  qri save --viz-template map=/path/to/map.html me/annual_pop`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -58,11 +62,18 @@ commit message and title to the save.`,
 		},
 	}
 
-	cmd.Flags().StringSliceVarP(&o.FilePaths, "file", "f", nil, "dataset or component file (yaml or json)")
+	cmd.Flags().StringSliceVarP(&o.FilePaths, "file", "f", nil, "dataset or component file or url (yaml, json, toml or datapackage.json)")
 	cmd.Flags().StringVarP(&o.Title, "title", "t", "", "title of commit message for save")
 	cmd.Flags().StringVarP(&o.Message, "message", "m", "", "commit message for save")
 	cmd.Flags().StringVarP(&o.BodyPath, "body", "", "", "path to file or url of data to add as dataset contents")
+	cmd.Flags().StringArrayVar(&o.VizTemplates, "viz-template", nil, "named viz template to store as name=path, an empty path removes the template")
+	cmd.Flags().StringVar(&o.ExternalBody, "external-body", "", "url of a body to read on every save & read instead of storing it (http, https or s3)")
 	cmd.Flags().StringVarP(&o.Recall, "recall", "", "", "restore revisions from dataset history")
+	cmd.Flags().StringVar(&o.Resource, "resource", "", "name of the resource to save from a datapackage.json file with more than one")
+	cmd.Flags().StringVar(&o.Template, "template", "", "name of a dataset template to start from, other inputs override the template")
+	cmd.Flags().StringVar(&o.ValidationRules, "rules", "", "path to a file of validation rules (.star, .json, or .yaml) the body must pass")
+	cmd.Flags().BoolVar(&o.EnforceReferences, "enforce-references", false, "fail if column values are missing from datasets their schema references")
+	cmd.Flags().BoolVar(&o.RejectBreakingSchema, "reject-breaking-schema", false, "fail if the schema changes in a way that isn't backward compatible, unless --force is set")
 	// cmd.Flags().BoolVarP(&o.ShowValidation, "show-validation", "s", false, "display a list of validation errors upon adding")
 	cmd.Flags().StringSliceVar(&o.Secrets, "secrets", nil, "transform secrets as comma separated key,value,key,value,... sequence")
 	cmd.Flags().BoolVarP(&o.Publish, "publish", "p", false, "publish this dataset to the registry")
@@ -86,6 +97,15 @@ type SaveOptions struct {
 	BodyPath  string
 	Recall    string
 
+	ExternalBody string
+	Template     string
+	Resource     string
+	VizTemplates []string
+
+	ValidationRules      string
+	EnforceReferences    bool
+	RejectBreakingSchema bool
+
 	Title   string
 	Message string
 
@@ -133,6 +153,10 @@ func (o *SaveOptions) Complete(f Factory, args []string) (err error) {
 		return fmt.Errorf("body file: %s", err)
 	}
 
+	if err := qfs.AbsPath(&o.ValidationRules); err != nil {
+		return fmt.Errorf("validation rules file: %s", err)
+	}
+
 	return nil
 }
 
@@ -154,25 +178,37 @@ func (o *SaveOptions) Run() (err error) {
 		return lib.NewError(lib.ErrBadArgs, "error parsing dataset reference '"+o.Refs.Ref()+"'")
 	}
 
+	vizTemplates, err := readVizTemplates(o.VizTemplates)
+	if err != nil {
+		return err
+	}
+
 	p := &lib.SaveParams{
-		Ref:      ref.AliasString(),
-		BodyPath: o.BodyPath,
-		Title:    o.Title,
-		Message:  o.Message,
-
-		ReadFSI:             o.UsingFSI,
-		WriteFSI:            o.UsingFSI,
-		FilePaths:           o.FilePaths,
-		Private:             false,
-		Publish:             o.Publish,
-		DryRun:              o.DryRun,
-		Recall:              o.Recall,
-		ConvertFormatToPrev: o.KeepFormat,
-		Force:               o.Force,
-		ReturnBody:          o.DryRun,
-		ShouldRender:        !o.NoRender,
-		NewName:             o.NewName,
-		UseDscache:          o.UseDscache,
+		Ref:          ref.AliasString(),
+		VizTemplates: vizTemplates,
+		BodyPath:     o.BodyPath,
+		ExternalBody: o.ExternalBody,
+		Title:        o.Title,
+		Message:      o.Message,
+
+		ReadFSI:              o.UsingFSI,
+		WriteFSI:             o.UsingFSI,
+		FilePaths:            o.FilePaths,
+		Private:              false,
+		Publish:              o.Publish,
+		DryRun:               o.DryRun,
+		Recall:               o.Recall,
+		Template:             o.Template,
+		Resource:             o.Resource,
+		ValidationRules:      o.ValidationRules,
+		EnforceReferences:    o.EnforceReferences,
+		RejectBreakingSchema: o.RejectBreakingSchema,
+		ConvertFormatToPrev:  o.KeepFormat,
+		Force:                o.Force,
+		ReturnBody:           o.DryRun,
+		ShouldRender:         !o.NoRender,
+		NewName:              o.NewName,
+		UseDscache:           o.UseDscache,
 	}
 
 	if o.Secrets != nil {
@@ -192,15 +228,15 @@ continue?`, true) {
 		}
 	}
 
-	res := &reporef.DatasetRef{}
+	res := &lib.SaveResult{}
 	if err = o.DatasetRequests.Save(p, res); err != nil {
 		return err
 	}
 
 	o.StopSpinner()
 	printSuccess(o.ErrOut, "dataset saved: %s", res)
-	if res.Dataset.Structure != nil && res.Dataset.Structure.ErrCount > 0 {
-		printWarning(o.ErrOut, fmt.Sprintf("this dataset has %d validation errors", res.Dataset.Structure.ErrCount))
+	for _, w := range res.Warnings {
+		printWarning(o.ErrOut, "%s", w)
 	}
 
 	if o.DryRun {
@@ -213,3 +249,29 @@ continue?`, true) {
 
 	return nil
 }
+
+// readVizTemplates reads named viz templates given as name=path pairs. An
+// empty path gives an empty script, removing the template
+func readVizTemplates(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	templates := map[string]string{}
+	for _, pair := range pairs {
+		i := strings.Index(pair, "=")
+		if i < 1 {
+			return nil, lib.NewError(lib.ErrBadArgs, fmt.Sprintf("viz template %q must be given as name=path", pair))
+		}
+		name, path := pair[:i], pair[i+1:]
+		if path == "" {
+			templates[name] = ""
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading viz template %q: %s", name, err)
+		}
+		templates[name] = string(data)
+	}
+	return templates, nil
+}
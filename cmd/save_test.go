@@ -405,12 +405,9 @@ func TestSaveDscacheExistingDataset(t *testing.T) {
      bodySize      = 137
      bodyRows      = 4
      commitTime    = 978310921
-     commitTitle   = structure updated 3 fields
-     commitMessage = structure:
-	updated checksum
-	updated entries
-	updated length
-     headRef       = /ipfs/QmbKb9dHzcFsngjDUUUZ12XsREuh37Zm7yJ4f3t5BkCdQv
+     commitTitle   = added 2 body rows
+     commitMessage = added 2 body rows
+     headRef       = /ipfs/QmdJZJUB89S2fmaY2wdU4k9ivpiTVxbCTG8eL9EGVVw9Ad
 `
 	if diff := cmp.Diff(expect, actual); diff != "" {
 		t.Errorf("result mismatch (-want +got):%s\n", diff)
@@ -117,6 +117,15 @@ func (vis versionInfoStringer) String() string {
 
 	v := dsref.VersionInfo(vis)
 	sr := v.SimpleRef()
+	if vis.Trashed {
+		// trashed datasets aren't loaded, only show where they came from
+		fmt.Fprintf(w, "%s %s", path(sr.Alias()), path("(trashed)"))
+		if vis.Path != "" {
+			fmt.Fprintf(w, "\n%s", path(vis.Path))
+		}
+		fmt.Fprintf(w, "\n\n")
+		return w.String()
+	}
 	fmt.Fprintf(w, "%s", title(sr.Alias()))
 
 	if vis.MetaTitle != "" {
@@ -130,6 +139,12 @@ func (vis versionInfoStringer) String() string {
 	if vis.Foreign {
 		fmt.Fprintf(w, "\n%s", warn("foreign"))
 	}
+	if vis.ForkOf != "" {
+		fmt.Fprintf(w, "\nforked from: %s", path(vis.ForkOf))
+	}
+	if vis.SyncStatus != "" {
+		fmt.Fprintf(w, "\nsync status: %s", vis.SyncStatus)
+	}
 	fmt.Fprintf(w, "\n%s", humanize.Bytes(uint64(vis.BodySize)))
 	if vis.BodyRows == 1 {
 		fmt.Fprintf(w, ", %d entry", vis.BodyRows)
@@ -292,9 +307,15 @@ func (s dslogItemStringer) String() string {
 		storage = faint("remote")
 	}
 
-	msg := fmt.Sprintf("%s%s\n%s%s\n%s%s\n%s%s\n\n%s\n",
+	labels := ""
+	if len(s.Labels) > 0 {
+		labels = " " + color.New(color.FgGreen).Sprintf("(%s)", strings.Join(s.Labels, ", "))
+	}
+
+	msg := fmt.Sprintf("%s%s%s\n%s%s\n%s%s\n%s%s\n\n%s\n",
 		faint("Commit:  "),
 		yellow(s.Path),
+		labels,
 		faint("Date:    "),
 		s.CommitTime.In(StringerLocation).Format(time.UnixDate),
 		faint("Storage: "),
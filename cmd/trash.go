@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/spf13/cobra"
+)
+
+// NewTrashCommand creates a new `qri trash` command for managing removed
+// datasets
+func NewTrashCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
+	o := &TrashOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "List, restore, or permanently delete removed datasets",
+		Long: `
+Removing every version of a dataset moves it to the trash. Trashed datasets
+aren't listed, but their data stays on your computer until the trash is
+emptied, so they can be restored. Datasets are permanently deleted once
+they've been in the trash longer than the retention period set by the
+repo.trashretentionhours config value, which defaults to 7 days.
+
+Use ` + "`qri remove --all --permanent`" + ` to skip the trash.`,
+		Example: `  # show datasets in the trash:
+  $ qri trash list
+
+  # bring back a removed dataset:
+  $ qri trash restore me/annual_pop
+
+  # permanently delete everything in the trash:
+  $ qri trash empty --all`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "show datasets in the trash",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.List()
+		},
+	}
+
+	restore := &cobra.Command{
+		Use:   "restore DATASET",
+		Short: "bring back a dataset from the trash",
+		Long: `restore brings back a dataset removed to the trash. Restored datasets keep
+their published state, and are relinked to their working directory if it
+still exists.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Restore()
+		},
+	}
+
+	empty := &cobra.Command{
+		Use:   "empty",
+		Short: "permanently delete datasets in the trash",
+		Long: `empty permanently deletes datasets that have been in the trash longer than
+the retention period. Use --all to delete everything in the trash.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Empty()
+		},
+	}
+	empty.Flags().BoolVar(&o.All, "all", false, "delete everything in the trash, regardless of when it was removed")
+
+	cmd.AddCommand(list, restore, empty)
+	return cmd
+}
+
+// TrashOptions encapsulates state for the trash command
+type TrashOptions struct {
+	ioes.IOStreams
+
+	Ref string
+	All bool
+
+	TrashMethods *lib.TrashMethods
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *TrashOptions) Complete(f Factory, args []string) (err error) {
+	if len(args) > 0 {
+		o.Ref = args[0]
+	}
+	o.TrashMethods, err = f.TrashMethods()
+	return err
+}
+
+// List shows the contents of the trash
+func (o *TrashOptions) List() error {
+	res := []repo.TrashEntry{}
+	if err := o.TrashMethods.List(&lib.TrashListParams{}, &res); err != nil {
+		return err
+	}
+	if len(res) == 0 {
+		printInfo(o.Out, "the trash is empty")
+		return nil
+	}
+	for _, e := range res {
+		fmt.Fprintf(o.Out, "%s\tremoved %s\n", e.Ref.AliasString(), e.Removed.In(StringerLocation).Format("2006-01-02 15:04:05"))
+	}
+	return nil
+}
+
+// Restore brings back a dataset from the trash
+func (o *TrashOptions) Restore() error {
+	res := reporef.DatasetRef{}
+	if err := o.TrashMethods.Restore(&lib.TrashRestoreParams{Ref: o.Ref}, &res); err != nil {
+		return err
+	}
+	printSuccess(o.Out, "restored %s", res.AliasString())
+	if res.FSIPath != "" {
+		printInfo(o.Out, "linked to %s", res.FSIPath)
+	}
+	return nil
+}
+
+// Empty permanently deletes datasets in the trash
+func (o *TrashOptions) Empty() error {
+	res := []repo.TrashEntry{}
+	if err := o.TrashMethods.Empty(&lib.TrashEmptyParams{All: o.All}, &res); err != nil {
+		return err
+	}
+	for _, e := range res {
+		printInfo(o.Out, "deleted %s", e.Ref.AliasString())
+	}
+	printSuccess(o.Out, "permanently deleted %d datasets", len(res))
+	return nil
+}
@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	util "github.com/qri-io/apiutil"
 	"github.com/qri-io/ioes"
@@ -57,6 +59,10 @@ responsible for executing your scheduled updates is currently active.
 	time:
 	$ qri update schedule --file dataset.yaml b5/my_dataset R/P1D
 	qri scheduled b5/my_dataset, next update: 2019-05-08 20:15:13.191602 +0000 UTC
+
+	schedule a nightly update, retrying failures 3 times & calling a webhook
+	if the update still fails:
+	$ qri update schedule --retries 3 --notify https://example.com/hook b5/my_dataset R/P1D
 	`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := o.Complete(f, args); err != nil {
@@ -78,6 +84,9 @@ responsible for executing your scheduled updates is currently active.
 	scheduleCmd.Flags().BoolVar(&o.Force, "force", false, "force a new commit, even if no changes are detected")
 	scheduleCmd.Flags().BoolVarP(&o.KeepFormat, "keep-format", "k", false, "convert incoming data to stored data format")
 	scheduleCmd.Flags().StringVar(&o.RepoPath, "use-repo", "", "experiment. run update on behalf of another repo")
+	scheduleCmd.Flags().IntVar(&o.Retries, "retries", 0, "number of times to retry a failed update")
+	scheduleCmd.Flags().DurationVar(&o.RetryBackoff, "retry-backoff", time.Minute, "wait before the first retry, doubling with each retry after")
+	scheduleCmd.Flags().StringVar(&o.NotifyURL, "notify", "", "webhook url to POST to when an update fails after all retries")
 
 	unscheduleCmd := &cobra.Command{
 		Use:   "unschedule",
@@ -172,6 +181,9 @@ but assumes you want to recall the most recent transform in the dataset.
   ✅ transform complete
   dataset saved: b5/my_dataset@MSN9/ipfs/2BntM
 
+  preview an update without saving it
+  $ qri update run --dry-run b5/my_dataset
+
 	`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := o.Complete(f, args); err != nil {
@@ -186,7 +198,7 @@ but assumes you want to recall the most recent transform in the dataset.
 	runCmd.Flags().StringVarP(&o.Recall, "recall", "", "", "restore revisions from dataset history, only 'tf' applies when updating")
 	runCmd.Flags().StringSliceVar(&o.Secrets, "secrets", nil, "transform secrets as comma separated key,value,key,value,... sequence")
 	runCmd.Flags().BoolVarP(&o.Publish, "publish", "p", false, "publish successful update to the registry")
-	runCmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "simulate updating a dataset")
+	runCmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "run the update without saving, printing the resulting dataset")
 	runCmd.Flags().BoolVarP(&o.NoRender, "no-render", "n", false, "don't store a rendered version of the the vizualization ")
 	runCmd.Flags().StringSliceVarP(&o.FilePaths, "file", "f", nil, "dataset or component file (yaml or json)")
 	runCmd.Flags().StringVarP(&o.BodyPath, "body", "", "", "path to file or url of data to add as dataset contents")
@@ -274,6 +286,10 @@ type UpdateOptions struct {
 	KeepFormat bool
 	Secrets    []string
 
+	Retries      int
+	RetryBackoff time.Duration
+	NotifyURL    string
+
 	Daemonize bool
 	Page      int
 	PageSize  int
@@ -306,6 +322,13 @@ func (o *UpdateOptions) Schedule(args []string) (err error) {
 		SaveParams: o.saveParams(),
 		RepoPath:   o.RepoPath,
 	}
+	if o.Retries != 0 || o.NotifyURL != "" {
+		p.FailurePolicy = &lib.FailurePolicy{
+			Retries:   o.Retries,
+			Backoff:   o.RetryBackoff,
+			NotifyURL: o.NotifyURL,
+		}
+	}
 	if len(args) > 1 {
 		p.Periodicity = args[1]
 	}
@@ -450,8 +473,9 @@ func (o *UpdateOptions) RunUpdate(args []string) (err error) {
 
 	var (
 		name = args[0]
-		job  = &lib.Job{
-			Name: name,
+		p    = &lib.RunParams{
+			Job:    lib.Job{Name: name},
+			DryRun: o.DryRun,
 		}
 	)
 
@@ -459,10 +483,20 @@ func (o *UpdateOptions) RunUpdate(args []string) (err error) {
 	defer o.StopSpinner()
 
 	res := &reporef.DatasetRef{}
-	if err := o.updateMethods.Run(job, res); err != nil {
+	if err := o.updateMethods.Run(p, res); err != nil {
 		return err
 	}
 
+	if o.DryRun {
+		o.StopSpinner()
+		data, err := json.MarshalIndent(res.Dataset, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(o.Out, string(data))
+		return nil
+	}
+
 	printSuccess(o.Out, "updated dataset %s", res.AliasString())
 	return nil
 }
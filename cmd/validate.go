@@ -51,7 +51,12 @@ You can get the current schema of a dataset by running the ` + "`qri get structu
 command.
 
 Note: --body and --schema or --structure flags will override the dataset
-if these flags are provided.`,
+if these flags are provided.
+
+Schemas are validated as JSON Schema draft-07, which also validates draft-06
+schemas as specified. Use --draft to require a draft, validate will error
+instead of checking a schema as a draft it doesn't implement, or a schema
+that declares a different draft.`,
 		Example: `  # show errors in an existing dataset:
   qri validate b5/comics
 
@@ -59,7 +64,16 @@ if these flags are provided.`,
   qri validate --body new_data.csv me/annual_pop
 
   # validate data against a new schema
-  qri validate --body data.csv --schema schema.json`,
+  qri validate --body data.csv --schema schema.json
+
+  # show errors in every dataset you have
+  qri validate --all
+
+  # fail if the schema declares a draft other than draft-07
+  qri validate --draft draft-07 me/annual_pop
+
+  # check values of columns that reference other datasets exist in them
+  qri validate --references me/orders`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := o.Complete(f, args); err != nil {
 				return err
@@ -73,6 +87,9 @@ if these flags are provided.`,
 	cmd.Flags().StringVarP(&o.BodyFilepath, "body", "b", "", "body file to validate")
 	cmd.Flags().StringVarP(&o.SchemaFilepath, "schema", "", "", "json schema file to use for validation")
 	cmd.Flags().StringVarP(&o.StructureFilepath, "structure", "", "", "json structure file to use for validation")
+	cmd.Flags().BoolVarP(&o.All, "all", "a", false, "validate every dataset in your namespace")
+	cmd.Flags().StringVar(&o.SchemaDraft, "draft", "", "JSON Schema draft to validate against")
+	cmd.Flags().BoolVar(&o.References, "references", false, "check referential integrity instead of the schema")
 
 	return cmd
 }
@@ -85,7 +102,10 @@ type ValidateOptions struct {
 	BodyFilepath      string
 	SchemaFilepath    string
 	StructureFilepath string
+	SchemaDraft       string
 	URL               string
+	All               bool
+	References        bool
 
 	DatasetRequests *lib.DatasetRequests
 }
@@ -96,6 +116,16 @@ func (o *ValidateOptions) Complete(f Factory, args []string) (err error) {
 		return
 	}
 
+	if o.All {
+		if len(args) > 0 || o.BodyFilepath != "" || o.SchemaFilepath != "" || o.StructureFilepath != "" {
+			return fmt.Errorf("--all cannot be combined with a dataset reference or file flags")
+		}
+		return nil
+	}
+	if o.References && (o.BodyFilepath != "" || o.SchemaFilepath != "" || o.StructureFilepath != "") {
+		return fmt.Errorf("--references cannot be combined with file flags")
+	}
+
 	o.Refs, err = GetCurrentRefSelect(f, args, 1, nil)
 	if err == repo.ErrEmptyRef {
 		// It is not an error to call validate without a dataset reference. Might be
@@ -108,6 +138,13 @@ func (o *ValidateOptions) Complete(f Factory, args []string) (err error) {
 
 // Run executes the run command
 func (o *ValidateOptions) Run() (err error) {
+	if o.All {
+		return o.validateAll()
+	}
+	if o.References {
+		return o.validateReferences()
+	}
+
 	printRefSelect(o.ErrOut, o.Refs)
 
 	o.StartSpinner()
@@ -122,6 +159,7 @@ func (o *ValidateOptions) Run() (err error) {
 		SchemaFilename:    o.SchemaFilepath,
 		StructureFilename: o.StructureFilepath,
 		UseFSI:            o.Refs.IsLinked(),
+		SchemaDraft:       o.SchemaDraft,
 	}
 
 	res := []jsonschema.ValError{}
@@ -141,3 +179,61 @@ func (o *ValidateOptions) Run() (err error) {
 	}
 	return nil
 }
+
+func (o *ValidateOptions) validateReferences() error {
+	printRefSelect(o.ErrOut, o.Refs)
+
+	o.StartSpinner()
+	defer o.StopSpinner()
+
+	ref := o.Refs.Ref()
+	res := []lib.RuleViolation{}
+	if err := o.DatasetRequests.ValidateReferences(&ref, &res); err != nil {
+		return err
+	}
+
+	o.StopSpinner()
+
+	if len(res) == 0 {
+		printSuccess(o.Out, "✔ All references are intact")
+		return nil
+	}
+	for i, v := range res {
+		fmt.Fprintf(o.Out, "%d: %s\n", i, v)
+	}
+	return nil
+}
+
+func (o *ValidateOptions) validateAll() error {
+	o.StartSpinner()
+	defer o.StopSpinner()
+
+	res := []lib.NamespaceValidationResult{}
+	if err := o.DatasetRequests.ValidateNamespace(&lib.ValidateNamespaceParams{}, &res); err != nil {
+		return err
+	}
+
+	o.StopSpinner()
+
+	failed := 0
+	for _, nv := range res {
+		if nv.Valid() {
+			continue
+		}
+		failed++
+		if nv.Error != "" {
+			printWarning(o.Out, "%s: %s", nv.Ref, nv.Error)
+			continue
+		}
+		printWarning(o.Out, "%s: %d errors", nv.Ref, len(nv.Errors))
+		for i, err := range nv.Errors {
+			fmt.Fprintf(o.Out, "  %d: %s\n", i, err.Error())
+		}
+	}
+
+	if failed == 0 {
+		printSuccess(o.Out, "✔ All %d datasets are valid", len(res))
+		return nil
+	}
+	return fmt.Errorf("%d of %d datasets have errors", failed, len(res))
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"reflect"
 	"time"
@@ -44,6 +45,79 @@ type API struct {
 	AllowedOrigins []string `json:"allowedorigins"`
 	// whether to allow requests from addresses other than localhost
 	ServeRemoteTraffic bool `json:"serveremotetraffic"`
+	// WatchActions run when files in a linked dataset directory change
+	WatchActions []WatchAction `json:"watchactions,omitempty"`
+	// AutoPublish saves & publishes linked datasets when their working
+	// directory changes
+	AutoPublish []AutoPublishRule `json:"autopublish,omitempty"`
+	// AccessTokens grant access to the websocket. When set, every websocket
+	// connection must present one of these tokens
+	AccessTokens []APIAccessToken `json:"accesstokens,omitempty"`
+	// MaxBodySize is the largest dataset body in bytes the save endpoint
+	// accepts, default 0 means no limit
+	MaxBodySize int64 `json:"maxbodysize,omitempty"`
+	// Debug serves the /debug routes, which dump repo state for bug reports.
+	// Without it, debug routes require a write scope access token
+	Debug bool `json:"debug,omitempty"`
+}
+
+const (
+	// APIScopeRead grants access to status events
+	APIScopeRead = "read"
+	// APIScopeWrite grants access to status events & the progress of saves &
+	// publishes
+	APIScopeWrite = "write"
+)
+
+// APIAccessToken is a secret token & the scope of access it grants
+type APIAccessToken struct {
+	// Token is the secret clients present
+	Token string `json:"token"`
+	// Scope is one of APIScopeRead or APIScopeWrite
+	Scope string `json:"scope"`
+}
+
+// TokenScope finds the scope an access token grants, returning false if the
+// token isn't one of AccessTokens
+func (a API) TokenScope(token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	for _, t := range a.AccessTokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+			return t.Scope, true
+		}
+	}
+	return "", false
+}
+
+// WatchAction configures a command or URL to notify when files in the linked
+// working directory of a dataset change
+type WatchAction struct {
+	// Ref is the dataset reference to watch, eg: me/dataset
+	Ref string `json:"ref"`
+	// Command is run by the system shell from within the linked directory
+	Command string `json:"command,omitempty"`
+	// URL is sent a POST request with the filesystem event as a JSON body
+	URL string `json:"url,omitempty"`
+	// DebounceMs is the number of milliseconds to wait for changes to settle
+	// before running the action
+	DebounceMs int `json:"debouncems,omitempty"`
+}
+
+// AutoPublishRule maps a linked dataset to the remote it's published to when
+// files in its working directory change. One of Ref or Dir is required
+type AutoPublishRule struct {
+	// Ref is the dataset reference to publish, eg: me/dataset
+	Ref string `json:"ref,omitempty"`
+	// Dir is the linked working directory of the dataset to publish
+	Dir string `json:"dir,omitempty"`
+	// Remote is the name of the remote to publish to, defaulting to the
+	// registry
+	Remote string `json:"remote,omitempty"`
+	// DebounceMs is the number of milliseconds to wait for changes to settle
+	// before saving
+	DebounceMs int `json:"debouncems,omitempty"`
 }
 
 // Validate validates all fields of api returning all errors found.
@@ -89,6 +163,92 @@ func (a API) Validate() error {
         "items": {
           "type": "string"
         }
+      },
+      "watchactions": {
+        "description": "Commands or URLs to run when files in a linked dataset directory change",
+        "type": "array",
+        "items": {
+          "type": "object",
+          "required": ["ref"],
+          "properties": {
+            "ref": {
+              "description": "Reference to the dataset to watch",
+              "type": "string"
+            },
+            "command": {
+              "description": "Shell command to run from within the linked directory",
+              "type": "string"
+            },
+            "url": {
+              "description": "URL to POST filesystem events to",
+              "type": "string"
+            },
+            "debouncems": {
+              "description": "Milliseconds to wait for changes to settle before running",
+              "type": "integer",
+              "minimum": 0
+            }
+          }
+        }
+      },
+      "autopublish": {
+        "description": "Linked datasets to save & publish when their working directory changes",
+        "type": "array",
+        "items": {
+          "type": "object",
+          "anyOf": [
+            { "required": ["ref"] },
+            { "required": ["dir"] }
+          ],
+          "properties": {
+            "ref": {
+              "description": "Reference to the dataset to publish",
+              "type": "string"
+            },
+            "dir": {
+              "description": "Linked working directory of the dataset to publish",
+              "type": "string"
+            },
+            "remote": {
+              "description": "Name of the remote to publish to, defaults to the registry",
+              "type": "string"
+            },
+            "debouncems": {
+              "description": "Milliseconds to wait for changes to settle before saving",
+              "type": "integer",
+              "minimum": 0
+            }
+          }
+        }
+      },
+      "maxbodysize": {
+        "description": "Largest dataset body in bytes the save endpoint accepts, 0 for no limit",
+        "type": "integer",
+        "minimum": 0
+      },
+      "debug": {
+        "description": "When true, serves routes that dump repo state for bug reports",
+        "type": "boolean"
+      },
+      "accesstokens": {
+        "description": "Tokens granting access to the websocket",
+        "type": "array",
+        "items": {
+          "type": "object",
+          "required": ["token", "scope"],
+          "properties": {
+            "token": {
+              "description": "Secret token clients present",
+              "type": "string",
+              "minLength": 1
+            },
+            "scope": {
+              "description": "Scope of access the token grants",
+              "type": "string",
+              "enum": ["read", "write"]
+            }
+          }
+        }
       }
     }
   }`)
@@ -113,18 +273,35 @@ func DefaultAPI() *API {
 // Copy returns a deep copy of an API struct
 func (a *API) Copy() *API {
 	res := &API{
-		Enabled:            a.Enabled,
-		Port:               a.Port,
-		ReadOnly:           a.ReadOnly,
-		URLRoot:            a.URLRoot,
-		TLS:                a.TLS,
-		DisconnectAfter:    a.DisconnectAfter,
-		ProxyForceHTTPS:    a.ProxyForceHTTPS,
-		ServeRemoteTraffic: a.ServeRemoteTraffic,
+		Enabled:               a.Enabled,
+		Port:                  a.Port,
+		ReadOnly:              a.ReadOnly,
+		RemoteMode:            a.RemoteMode,
+		RemoteAcceptSizeMax:   a.RemoteAcceptSizeMax,
+		RemoteAcceptTimeoutMs: a.RemoteAcceptTimeoutMs,
+		URLRoot:               a.URLRoot,
+		TLS:                   a.TLS,
+		DisconnectAfter:       a.DisconnectAfter,
+		ProxyForceHTTPS:       a.ProxyForceHTTPS,
+		ServeRemoteTraffic:    a.ServeRemoteTraffic,
+		MaxBodySize:           a.MaxBodySize,
+		Debug:                 a.Debug,
 	}
 	if a.AllowedOrigins != nil {
 		res.AllowedOrigins = make([]string, len(a.AllowedOrigins))
 		reflect.Copy(reflect.ValueOf(res.AllowedOrigins), reflect.ValueOf(a.AllowedOrigins))
 	}
+	if a.WatchActions != nil {
+		res.WatchActions = make([]WatchAction, len(a.WatchActions))
+		copy(res.WatchActions, a.WatchActions)
+	}
+	if a.AutoPublish != nil {
+		res.AutoPublish = make([]AutoPublishRule, len(a.AutoPublish))
+		copy(res.AutoPublish, a.AutoPublish)
+	}
+	if a.AccessTokens != nil {
+		res.AccessTokens = make([]APIAccessToken, len(a.AccessTokens))
+		copy(res.AccessTokens, a.AccessTokens)
+	}
 	return res
 }
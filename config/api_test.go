@@ -25,6 +25,26 @@ func TestAPICopy(t *testing.T) {
 			ProxyForceHTTPS:    true,
 			ServeRemoteTraffic: true,
 		}},
+		{"watch actions", &API{
+			WatchActions: []WatchAction{
+				{Ref: "me/dataset", Command: "qri save", DebounceMs: 500},
+			},
+		}},
+		{"auto publish", &API{
+			AutoPublish: []AutoPublishRule{
+				{Dir: "/path/to/dataset", Remote: "origin", DebounceMs: 500},
+			},
+		}},
+		{"access tokens", &API{
+			AccessTokens: []APIAccessToken{{Token: "secret", Scope: APIScopeRead}},
+		}},
+		{"max body size", &API{MaxBodySize: 1 << 20}},
+		{"debug", &API{Debug: true}},
+		{"deprecated remote fields", &API{
+			RemoteMode:            true,
+			RemoteAcceptSizeMax:   1 << 20,
+			RemoteAcceptTimeoutMs: 500,
+		}},
 	}
 	for i, c := range cases {
 		cpy := c.api.Copy()
@@ -39,5 +59,97 @@ func TestAPICopy(t *testing.T) {
 				continue
 			}
 		}
+		if cpy.WatchActions != nil {
+			cpy.WatchActions[0].Ref = ""
+			if reflect.DeepEqual(cpy, c.api) {
+				t.Errorf("API Copy test case %d '%s', editing one api struct's watch actions should not affect the other", i, c.description)
+				continue
+			}
+		}
+		if cpy.AutoPublish != nil {
+			cpy.AutoPublish[0].Dir = ""
+			if reflect.DeepEqual(cpy, c.api) {
+				t.Errorf("API Copy test case %d '%s', editing one api struct's auto publish rules should not affect the other", i, c.description)
+				continue
+			}
+		}
+		if cpy.AccessTokens != nil {
+			cpy.AccessTokens[0].Scope = APIScopeWrite
+			if reflect.DeepEqual(cpy, c.api) {
+				t.Errorf("API Copy test case %d '%s', editing one api struct's access tokens should not affect the other", i, c.description)
+				continue
+			}
+		}
+	}
+}
+
+func TestAPIValidateWatchActions(t *testing.T) {
+	api := DefaultAPI()
+	api.WatchActions = []WatchAction{{Ref: "me/dataset", URL: "http://localhost:8080/hook", DebounceMs: 100}}
+	if err := api.Validate(); err != nil {
+		t.Errorf("unexpected error validating watch actions: %s", err)
+	}
+
+	api.WatchActions = []WatchAction{{Ref: "me/dataset", DebounceMs: -1}}
+	if err := api.Validate(); err == nil {
+		t.Error("expected negative debounce to fail validation")
+	}
+}
+
+func TestAPIValidateAutoPublish(t *testing.T) {
+	api := DefaultAPI()
+	api.AutoPublish = []AutoPublishRule{{Ref: "me/dataset", Remote: "origin", DebounceMs: 100}}
+	if err := api.Validate(); err != nil {
+		t.Errorf("unexpected error validating auto publish rules: %s", err)
+	}
+
+	api.AutoPublish = []AutoPublishRule{{Remote: "origin"}}
+	if err := api.Validate(); err == nil {
+		t.Error("expected a rule without a ref or dir to fail validation")
+	}
+}
+
+func TestAPIAccessTokens(t *testing.T) {
+	api := DefaultAPI()
+	api.AccessTokens = []APIAccessToken{
+		{Token: "reader", Scope: APIScopeRead},
+		{Token: "writer", Scope: APIScopeWrite},
+	}
+	if err := api.Validate(); err != nil {
+		t.Errorf("unexpected error validating access tokens: %s", err)
+	}
+
+	cases := []struct {
+		token, scope string
+		ok           bool
+	}{
+		{"reader", APIScopeRead, true},
+		{"writer", APIScopeWrite, true},
+		{"", "", false},
+		{"nope", "", false},
+	}
+	for _, c := range cases {
+		scope, ok := api.TokenScope(c.token)
+		if scope != c.scope || ok != c.ok {
+			t.Errorf("token %q: expected (%q, %t), got (%q, %t)", c.token, c.scope, c.ok, scope, ok)
+		}
+	}
+
+	api.AccessTokens = []APIAccessToken{{Token: "admin", Scope: "admin"}}
+	if err := api.Validate(); err == nil {
+		t.Error("expected an unknown scope to fail validation")
+	}
+}
+
+func TestAPIValidateMaxBodySize(t *testing.T) {
+	api := DefaultAPI()
+	api.MaxBodySize = 1 << 20
+	if err := api.Validate(); err != nil {
+		t.Errorf("unexpected error validating max body size: %s", err)
+	}
+
+	api.MaxBodySize = -1
+	if err := api.Validate(); err == nil {
+		t.Error("expected negative max body size to fail validation")
 	}
 }
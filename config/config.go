@@ -20,6 +20,8 @@ const CurrentConfigRevision = 1
 // Config encapsulates all configuration details for qri
 type Config struct {
 	path string
+	// name of the environment applied to this config, if any
+	env string
 
 	Revision int
 	Profile  *ProfilePod
@@ -29,9 +31,10 @@ type Config struct {
 	Update   *Update
 	Stats    *Stats
 
-	Registry *Registry
-	Remotes  *Remotes
-	Remote   *Remote
+	Registry   *Registry
+	Remotes    *Remotes
+	RemoteKeys *RemoteKeys
+	Remote     *Remote
 
 	CLI     *CLI
 	API     *API
@@ -40,6 +43,13 @@ type Config struct {
 	Logging *Logging
 
 	Render *Render
+
+	// Templates are named dataset scaffolds new datasets can start from
+	Templates *Templates `json:",omitempty"`
+
+	// Environments are named sets of overrides to this configuration, each a
+	// partial config. Use WithEnvironment to apply one
+	Environments map[string]interface{} `json:",omitempty"`
 }
 
 // NOTE: The configuration returned by DefaultConfig is insufficient, as is, to run a functional
@@ -80,6 +90,9 @@ func DefaultConfig() *Config {
 // running. we should move this elsewhere
 func (cfg Config) SummaryString() (summary string) {
 	summary = "\n"
+	if cfg.env != "" {
+		summary += fmt.Sprintf("environment:\t%s\n", cfg.env)
+	}
 	if cfg.Profile != nil {
 		summary += fmt.Sprintf("peername:\t%s\nprofileID:\t%s\n", cfg.Profile.Peername, cfg.Profile.ID)
 	}
@@ -125,8 +138,14 @@ func (cfg Config) Path() string {
 	return cfg.path
 }
 
-// WriteToFile encodes a configration to YAML and writes it to path
+// WriteToFile encodes a configration to YAML and writes it to path. Configs
+// with an environment applied can't be written, the environment's overrides
+// would replace base values
 func (cfg Config) WriteToFile(path string) error {
+	if cfg.env != "" {
+		return fmt.Errorf("can't write config with the %q environment applied, edit the config without an environment selected", cfg.env)
+	}
+
 	// Never serialize the address mapping to the configuration file.
 	prev := cfg.Profile.PeerIDs
 	cfg.Profile.NetworkAddrs = nil
@@ -221,6 +240,7 @@ func (cfg Config) Validate() error {
 		cfg.Update,
 		cfg.Logging,
 		cfg.Stats,
+		cfg.Templates,
 	}
 	for _, val := range validators {
 		// we need to check here because we're potentially calling methods on nil
@@ -242,10 +262,14 @@ func (cfg Config) Validate() error {
 func (cfg *Config) Copy() *Config {
 	res := &Config{
 		Revision: cfg.Revision,
+		env:      cfg.env,
 	}
 	if cfg.path != "" {
 		res.path = cfg.path
 	}
+	if cfg.Environments != nil {
+		res.Environments = copyFields(cfg.Environments)
+	}
 	if cfg.Profile != nil {
 		res.Profile = cfg.Profile.Copy()
 	}
@@ -282,6 +306,9 @@ func (cfg *Config) Copy() *Config {
 	if cfg.Remotes != nil {
 		res.Remotes = cfg.Remotes.Copy()
 	}
+	if cfg.RemoteKeys != nil {
+		res.RemoteKeys = cfg.RemoteKeys.Copy()
+	}
 	if cfg.Logging != nil {
 		res.Logging = cfg.Logging.Copy()
 	}
@@ -291,6 +318,9 @@ func (cfg *Config) Copy() *Config {
 	if cfg.Stats != nil {
 		res.Stats = cfg.Stats.Copy()
 	}
+	if cfg.Templates != nil {
+		res.Templates = cfg.Templates.Copy()
+	}
 
 	return res
 }
@@ -301,6 +331,7 @@ func (cfg *Config) WithoutPrivateValues() *Config {
 
 	res.Profile.PrivKey = ""
 	res.P2P.PrivKey = ""
+	res.P2P.SwarmKey = ""
 
 	return res
 }
@@ -312,6 +343,7 @@ func (cfg *Config) WithPrivateValues(p *Config) *Config {
 
 	res.Profile.PrivKey = p.Profile.PrivKey
 	res.P2P.PrivKey = p.P2P.PrivKey
+	res.P2P.SwarmKey = p.P2P.SwarmKey
 
 	return res
 }
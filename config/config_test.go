@@ -224,3 +224,76 @@ func TestConfigCopy(t *testing.T) {
 		}
 	}
 }
+
+// fillFields sets every exported field of v to a non-zero value, so copies
+// that drop a field fail to round trip
+func fillFields(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		fillFields(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				fillFields(v.Field(i))
+			}
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fillFields(v.Index(0))
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		key := reflect.New(v.Type().Key()).Elem()
+		val := reflect.New(v.Type().Elem()).Elem()
+		fillFields(key)
+		fillFields(val)
+		v.SetMapIndex(key, val)
+	case reflect.Interface:
+		if val := reflect.ValueOf("value"); val.Type().AssignableTo(v.Type()) {
+			v.Set(val)
+		}
+	case reflect.String:
+		v.SetString("value")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1)
+	}
+}
+
+func TestCopyAllFields(t *testing.T) {
+	cases := []interface{}{
+		&Config{},
+		&API{},
+		&CLI{},
+		&Logging{},
+		&P2P{},
+		&ProfilePod{},
+		&Registry{},
+		&Remote{},
+		&Remotes{},
+		&RemoteKeys{},
+		&Render{},
+		&Repo{},
+		&RPC{},
+		&Stats{},
+		&Store{},
+		&Templates{},
+		&Update{},
+		&Webapp{},
+	}
+	for _, c := range cases {
+		v := reflect.ValueOf(c)
+		fillFields(v)
+		cpy := v.MethodByName("Copy").Call(nil)[0].Interface()
+		if !reflect.DeepEqual(cpy, c) {
+			t.Errorf("%T Copy doesn't round trip: \ncopy: %#v, \noriginal: %#v", c, cpy, c)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/qri-io/qri/base/fill"
+)
+
+// Env gives the name of the environment applied to a config, empty if the
+// config is the base configuration
+func (cfg Config) Env() string {
+	return cfg.env
+}
+
+// EnvironmentNames lists the environments defined by a config in sorted order
+func (cfg Config) EnvironmentNames() []string {
+	names := make([]string, 0, len(cfg.Environments))
+	for name := range cfg.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithEnvironment returns a copy of the config with the overrides of the named
+// environment merged in. Overrides are partial configurations, any value they
+// set replaces the base value at the same path, leaving the rest of the base
+// config in place. An empty name returns a copy of the config as-is
+func (cfg *Config) WithEnvironment(name string) (*Config, error) {
+	if name == "" {
+		return cfg.Copy(), nil
+	}
+	if cfg.env != "" {
+		return nil, fmt.Errorf("config already has the %q environment applied", cfg.env)
+	}
+
+	overrides, ok := lookupKey(cfg.Environments, name)
+	if !ok {
+		if len(cfg.Environments) == 0 {
+			return nil, fmt.Errorf("unknown config environment %q, config doesn't define any environments", name)
+		}
+		return nil, fmt.Errorf("unknown config environment %q, must be one of: %s", name, strings.Join(cfg.EnvironmentNames(), ", "))
+	}
+	overrideFields, ok := overrides.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("config environment %q must be a map of config values", name)
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	mergeFields(fields, overrideFields)
+
+	res := &Config{}
+	if err := fill.Struct(fields, res); err != nil {
+		return nil, fmt.Errorf("applying config environment %q: %s", name, err)
+	}
+	// environments can't redefine environments
+	res.Environments = copyFields(cfg.Environments)
+	res.path = cfg.path
+	res.env = name
+	return res, nil
+}
+
+// mergeFields recursively writes override values into base. Keys are matched
+// without regard to case, the same way fill matches keys to config fields
+func mergeFields(base, overrides map[string]interface{}) {
+	for key, val := range overrides {
+		if strings.EqualFold(key, "environments") {
+			continue
+		}
+		baseKey := key
+		for k := range base {
+			if strings.EqualFold(k, key) {
+				baseKey = k
+				break
+			}
+		}
+
+		baseMap, baseIsMap := base[baseKey].(map[string]interface{})
+		overrideMap, overrideIsMap := val.(map[string]interface{})
+		if baseIsMap && overrideIsMap {
+			mergeFields(baseMap, overrideMap)
+			continue
+		}
+		base[baseKey] = val
+	}
+}
+
+// lookupKey gets a value from a map, matching the key without regard to case
+// if there's no exact match
+func lookupKey(m map[string]interface{}, key string) (interface{}, bool) {
+	if val, ok := m[key]; ok {
+		return val, true
+	}
+	for k, val := range m {
+		if strings.EqualFold(k, key) {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+// copyFields deep copies a map of config values
+func copyFields(fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		return nil
+	}
+	res := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		res[k] = copyValue(v)
+	}
+	return res
+}
+
+func copyValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		return copyFields(x)
+	case []interface{}:
+		res := make([]interface{}, len(x))
+		for i, el := range x {
+			res[i] = copyValue(el)
+		}
+		return res
+	default:
+		return v
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithEnvironment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestWithEnvironment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	base := DefaultConfigForTesting()
+	base.Environments = map[string]interface{}{
+		"dev": map[string]interface{}{
+			"API":      map[string]interface{}{"port": 3503},
+			"registry": map[string]interface{}{"location": "http://localhost:2500"},
+		},
+		"prod": map[string]interface{}{},
+	}
+	path := filepath.Join(dir, "config.yaml")
+	if err := base.WriteToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := ReadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dev, err := cfg.WithEnvironment("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dev.Env() != "dev" {
+		t.Errorf("environment name mismatch. expected: %q, got: %q", "dev", dev.Env())
+	}
+	if dev.API.Port != 3503 {
+		t.Errorf("expected environment to override api port. expected: %d, got: %d", 3503, dev.API.Port)
+	}
+	if dev.Registry.Location != "http://localhost:2500" {
+		t.Errorf("expected environment to override registry location, got: %q", dev.Registry.Location)
+	}
+	if dev.API.Enabled != cfg.API.Enabled || len(dev.API.AllowedOrigins) != len(cfg.API.AllowedOrigins) {
+		t.Errorf("expected environment to keep api values it doesn't set")
+	}
+	if dev.Profile.Peername != cfg.Profile.Peername || dev.Path() != path {
+		t.Errorf("expected environment to keep values outside of its overrides")
+	}
+	if cfg.API.Port == 3503 || cfg.Env() != "" {
+		t.Errorf("expected applying an environment to leave the base config unchanged")
+	}
+	if err := dev.Validate(); err != nil {
+		t.Errorf("expected config with environment applied to be valid, got: %s", err)
+	}
+
+	if _, err := dev.WithEnvironment("prod"); err == nil {
+		t.Errorf("expected applying a second environment to error")
+	}
+	if err := dev.WriteToFile(path); err == nil {
+		t.Errorf("expected writing a config with an environment applied to error")
+	}
+
+	expect := `unknown config environment "staging", must be one of: dev, prod`
+	if _, err := cfg.WithEnvironment("staging"); err == nil || err.Error() != expect {
+		t.Errorf("error mismatch. expected: %q, got: %v", expect, err)
+	}
+	if _, err := DefaultConfigForTesting().WithEnvironment("dev"); err == nil {
+		t.Errorf("expected applying an environment to a config without environments to error")
+	}
+}
@@ -45,7 +45,10 @@ func ZeroToOne(cfg *config.Config) error {
 			}
 		}
 
-		cfg.P2P.QriBootstrapAddrs = append(cfg.P2P.QriBootstrapAddrs, adds...)
+		// public bootstrap addresses are unreachable from a private network
+		if !cfg.P2P.PrivateNetwork() {
+			cfg.P2P.QriBootstrapAddrs = append(cfg.P2P.QriBootstrapAddrs, adds...)
+		}
 	}
 
 	cfg.Revision = 1
@@ -2,11 +2,15 @@ package config
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"reflect"
+	"strings"
 
 	crypto "github.com/libp2p/go-libp2p-core/crypto"
 	peer "github.com/libp2p/go-libp2p-core/peer"
+	ipnet "github.com/libp2p/go-libp2p-core/pnet"
+	pnet "github.com/libp2p/go-libp2p-pnet"
 	ma "github.com/multiformats/go-multiaddr"
 
 	"github.com/qri-io/jsonschema"
@@ -44,9 +48,16 @@ type P2P struct {
 	// any data that is verifiably posted by the same peer
 	ProfileReplication string `json:"profilereplication"`
 
-	// list of addresses to bootsrap qri peers on
+	// BootstrapAddrs lists addresses to bootstrap IPFS connections from. When
+	// not empty, these replace the IPFS repo's default bootstrap peers
 	BootstrapAddrs []string `json:"bootstrapaddrs"`
 
+	// SwarmKey is a pre-shared key that restricts this node to a private
+	// network of nodes configured with the same key. Connections from nodes
+	// outside the network are refused. Keys are either the contents of an
+	// IPFS swarm.key file, or 64 hexadecimal characters
+	SwarmKey string `json:"swarmkey,omitempty"`
+
 	// Enable AutoNAT service. unless you're hosting a server, leave this as false
 	AutoNAT bool `json:"autoNAT"`
 }
@@ -91,6 +102,36 @@ func (cfg *P2P) DecodePrivateKey() (crypto.PrivKey, error) {
 	return crypto.UnmarshalPrivateKey(data)
 }
 
+// swarmKeyHeader prefixes a base16 encoded v1 pre-shared key in the IPFS
+// swarm.key file format
+const swarmKeyHeader = "/key/swarm/psk/1.0.0/\n/base16/\n"
+
+// PrivateNetwork returns true if this node is configured to only connect to
+// a private network
+func (cfg *P2P) PrivateNetwork() bool {
+	return strings.TrimSpace(cfg.SwarmKey) != ""
+}
+
+// DecodeSwarmKey creates a private network protector from the configured
+// swarm key. DecodeSwarmKey returns a nil protector if no key is configured
+func (cfg *P2P) DecodeSwarmKey() (ipnet.Protector, error) {
+	key := strings.TrimSpace(cfg.SwarmKey)
+	if key == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(key, "/key/") {
+		if data, err := hex.DecodeString(key); err != nil || len(data) != 32 {
+			return nil, fmt.Errorf("invalid swarm key: expected 64 hexadecimal characters or the contents of a swarm.key file")
+		}
+		key = swarmKeyHeader + key
+	}
+	prot, err := pnet.NewProtector(strings.NewReader(key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid swarm key: %s", err)
+	}
+	return prot, nil
+}
+
 // DecodePeerID takes P2P.ID (a string), and decodes it into a peer.ID
 func (cfg *P2P) DecodePeerID() (peer.ID, error) {
 	if string(cfg.PeerID) == "" {
@@ -157,7 +198,7 @@ func (cfg P2P) Validate() error {
         ]
       },
       "bootstrapaddrs": {
-        "description": "List of addresses to bootstrap qri peers on",
+        "description": "List of addresses to bootstrap IPFS connections from, replacing the default IPFS bootstrap peers",
         "anyOf": [
           {"type": "array"},
           {"type": "null"}
@@ -165,10 +206,18 @@ func (cfg P2P) Validate() error {
         "items": {
           "type": "string"
         }
+      },
+      "swarmkey": {
+        "description": "Pre-shared key restricting connections to a private network",
+        "type": "string"
       }
     }
   }`)
-	return validate(schema, &cfg)
+	if err := validate(schema, &cfg); err != nil {
+		return err
+	}
+	_, err := cfg.DecodeSwarmKey()
+	return err
 }
 
 // Copy returns a deep copy of a p2p struct
@@ -181,6 +230,13 @@ func (cfg *P2P) Copy() *P2P {
 		Port:               cfg.Port,
 		ProfileReplication: cfg.ProfileReplication,
 		HTTPGatewayAddr:    cfg.HTTPGatewayAddr,
+		SwarmKey:           cfg.SwarmKey,
+		AutoNAT:            cfg.AutoNAT,
+	}
+
+	if cfg.Addrs != nil {
+		res.Addrs = make([]ma.Multiaddr, len(cfg.Addrs))
+		copy(res.Addrs, cfg.Addrs)
 	}
 
 	if cfg.QriBootstrapAddrs != nil {
@@ -31,11 +31,49 @@ func TestP2PDecodePrivateKey(t *testing.T) {
 	}
 }
 
+func TestP2PDecodeSwarmKey(t *testing.T) {
+	hexKey := "4f3c1a9e0b7d2c58e6a1f09b3d7c4e2a5b8f1d0c9e6a3b7f2d4c8e1a0b5f9d3c"
+	cases := []struct {
+		key     string
+		private bool
+		err     string
+	}{
+		{"", false, ""},
+		{hexKey, true, ""},
+		{"/key/swarm/psk/1.0.0/\n/base16/\n" + hexKey + "\n", true, ""},
+		{"abc123", false, "invalid swarm key: expected 64 hexadecimal characters or the contents of a swarm.key file"},
+		{hexKey[:62] + "zz", false, "invalid swarm key: expected 64 hexadecimal characters or the contents of a swarm.key file"},
+		{"/key/swarm/psk/1.0.0/\n/base32/\n" + hexKey, false, "invalid swarm key: malformed private network key: unknown encoding: /base32/"},
+	}
+	for i, c := range cases {
+		p := &P2P{SwarmKey: c.key}
+		prot, err := p.DecodeSwarmKey()
+		if c.err != "" {
+			if err == nil || err.Error() != c.err {
+				t.Errorf("case %d error mismatch. expected: %q, got: %v", i, c.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err)
+			continue
+		}
+		if (prot != nil) != c.private || p.PrivateNetwork() != c.private {
+			t.Errorf("case %d expected private network to be %t", i, c.private)
+		}
+	}
+}
+
 func TestP2PValidate(t *testing.T) {
 	err := DefaultP2PForTesting().Validate()
 	if err != nil {
 		t.Errorf("error validating default p2p: %s", err)
 	}
+
+	p := DefaultP2PForTesting()
+	p.SwarmKey = "not a key"
+	if err := p.Validate(); err == nil {
+		t.Errorf("expected a malformed swarm key to fail validation")
+	}
 }
 
 func TestP2PCopy(t *testing.T) {
@@ -43,6 +81,11 @@ func TestP2PCopy(t *testing.T) {
 		p2p *P2P
 	}{
 		{DefaultP2PForTesting()},
+		{&P2P{
+			QriBootstrapAddrs: []string{"/ip4/10.0.0.1/tcp/4001/ipfs/QmdpGkbqDYRPCcwLYnEm8oYGz2G9aUZn9WwPjqvqw3XUAc"},
+			BootstrapAddrs:    []string{"/ip4/10.0.0.2/tcp/4001/ipfs/QmTRqTLbKndFC2rp6VzpyApxHCLrFV35setF1DQZaRWPVf"},
+			SwarmKey:          "4f3c1a9e0b7d2c58e6a1f09b3d7c4e2a5b8f1d0c9e6a3b7f2d4c8e1a0b5f9d3c",
+		}},
 	}
 	for i, c := range cases {
 		cpy := c.p2p.Copy()
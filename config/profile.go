@@ -212,11 +212,16 @@ func (p *ProfilePod) Copy() *ProfilePod {
 		Photo:       p.Photo,
 		Poster:      p.Poster,
 		Twitter:     p.Twitter,
+		Online:      p.Online,
 	}
 	if p.PeerIDs != nil {
 		res.PeerIDs = make([]string, len(p.PeerIDs))
 		copy(res.PeerIDs, p.PeerIDs)
 	}
+	if p.NetworkAddrs != nil {
+		res.NetworkAddrs = make([]string, len(p.NetworkAddrs))
+		copy(res.NetworkAddrs, p.NetworkAddrs)
+	}
 
 	return res
 }
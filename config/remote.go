@@ -19,6 +19,20 @@ type Remote struct {
 	RequireAllBlocks bool `json:"requireallblocks"`
 	// allow clients to request unpins for their own pushes
 	AllowRemoves bool `json:"allowremoves"`
+	// RejectDatasetRemoves refuses requests to remove datasets from the
+	// remote, answering with a read_only error
+	RejectDatasetRemoves bool `json:"rejectdatasetremoves,omitempty"`
+	// push sessions that receive no data for longer than this are expired,
+	// in milliseconds. zero uses a default of five minutes
+	SessionIdleTimeoutMs time.Duration `json:"sessionidletimeoutms,omitempty"`
+	// AccessLogPath is the file dataset access events are appended to, relative
+	// paths are resolved against the repo path. Empty records access in memory
+	AccessLogPath string `json:"accesslogpath,omitempty"`
+	// AccessLogMaxBytes is the size the access log grows to before it's
+	// rotated. zero uses a default of 10Mb
+	AccessLogMaxBytes int64 `json:"accesslogmaxbytes,omitempty"`
+	// AccessLogOmitProfileIDs records every dataset access as anonymous
+	AccessLogOmitProfileIDs bool `json:"accesslogomitprofileids,omitempty"`
 }
 
 // Validate validates all fields of render returning all errors found.
@@ -50,6 +64,12 @@ func (cfg *Remote) Copy() *Remote {
 		AcceptTimeoutMs:  cfg.AcceptTimeoutMs,
 		RequireAllBlocks: cfg.RequireAllBlocks,
 		AllowRemoves:     cfg.AllowRemoves,
+
+		RejectDatasetRemoves:    cfg.RejectDatasetRemoves,
+		SessionIdleTimeoutMs:    cfg.SessionIdleTimeoutMs,
+		AccessLogPath:           cfg.AccessLogPath,
+		AccessLogMaxBytes:       cfg.AccessLogMaxBytes,
+		AccessLogOmitProfileIDs: cfg.AccessLogOmitProfileIDs,
 	}
 
 	return res
@@ -18,6 +18,8 @@ func TestRemoteCopy(t *testing.T) {
 		remote *Remote
 	}{
 		{&Remote{}},
+		{&Remote{AcceptSizeMax: 10000, SessionIdleTimeoutMs: 30000}},
+		{&Remote{AccessLogPath: "remote_access.log", AccessLogMaxBytes: 1024, AccessLogOmitProfileIDs: true}},
 	}
 	for i, c := range cases {
 		cpy := c.remote.Copy()
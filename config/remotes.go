@@ -31,3 +31,34 @@ func (r *Remotes) Copy() *Remotes {
 	}
 	return (*Remotes)(&c)
 }
+
+// RemoteKey configures how responses from a remote are verified
+type RemoteKey struct {
+	// PubKey is the base64-encoded public key the remote signs responses with
+	PubKey string `json:"pubkey,omitempty"`
+	// Insecure accepts unsigned responses, for remotes that don't sign them
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// RemoteKeys pins the keys remotes sign responses with, keyed by remote
+// name. The registry is keyed by "registry" unless a remote has that name
+type RemoteKeys map[string]*RemoteKey
+
+// Get retrieves the key configuration of a remote by name
+func (r *RemoteKeys) Get(name string) (*RemoteKey, bool) {
+	k, ok := (*r)[name]
+	return k, ok && k != nil
+}
+
+// Copy creates a copy of a RemoteKeys struct
+func (r *RemoteKeys) Copy() *RemoteKeys {
+	c := make(map[string]*RemoteKey)
+	for name, k := range *r {
+		if k != nil {
+			cp := *k
+			k = &cp
+		}
+		c[name] = k
+	}
+	return (*RemoteKeys)(&c)
+}
@@ -11,6 +11,25 @@ type Repo struct {
 	Middleware []string `json:"middleware"`
 	Type       string   `json:"type"`
 	Path       string   `json:"path,omitempty"`
+	// TrashRetentionHours is how long removed datasets stay in the trash
+	// before they're permanently deleted. zero uses the default retention
+	// period, a negative value disables the trash
+	TrashRetentionHours int `json:"trashretentionhours,omitempty"`
+	// MaxSize limits the total bytes of dataset versions the repo stores,
+	// zero means no limit
+	MaxSize int64 `json:"maxsize,omitempty"`
+	// MaxVersionSize limits the bytes a single dataset version can add to the
+	// repo, zero means no limit
+	MaxVersionSize int64 `json:"maxversionsize,omitempty"`
+	// UpdateCheckMinutes is how often datasets added from other peers are
+	// checked for new versions, zero disables background checks
+	UpdateCheckMinutes int `json:"updatecheckminutes,omitempty"`
+	// UpdateCheckRemote names the remote update checks ask for the latest
+	// version of a dataset, defaulting to the registry
+	UpdateCheckRemote string `json:"updatecheckremote,omitempty"`
+	// UpdateCheckWebhook is a URL sent a POST request describing each dataset
+	// an update check finds new versions of
+	UpdateCheckWebhook string `json:"updatecheckwebhook,omitempty"`
 }
 
 // DefaultRepo creates & returns a new default repo configuration
@@ -44,6 +63,33 @@ func (cfg Repo) Validate() error {
           "fs",
           "mem"
         ]
+      },
+      "trashretentionhours": {
+        "description": "Hours removed datasets stay in the trash, zero for the default, negative to disable the trash",
+        "type": "integer"
+      },
+      "maxsize": {
+        "description": "Maximum bytes of dataset versions the repo stores, zero for no limit",
+        "type": "integer",
+        "minimum": 0
+      },
+      "maxversionsize": {
+        "description": "Maximum bytes a single dataset version can add to the repo, zero for no limit",
+        "type": "integer",
+        "minimum": 0
+      },
+      "updatecheckminutes": {
+        "description": "Minutes between checks for new versions of datasets added from peers, zero disables checks",
+        "type": "integer",
+        "minimum": 0
+      },
+      "updatecheckremote": {
+        "description": "Name of the remote update checks ask for new versions, the registry when empty",
+        "type": "string"
+      },
+      "updatecheckwebhook": {
+        "description": "URL sent a POST request for each dataset an update check finds new versions of",
+        "type": "string"
       }
     }
   }`)
@@ -53,7 +99,14 @@ func (cfg Repo) Validate() error {
 // Copy returns a deep copy of the Repo struct
 func (cfg *Repo) Copy() *Repo {
 	res := &Repo{
-		Type: cfg.Type,
+		Type:                cfg.Type,
+		Path:                cfg.Path,
+		TrashRetentionHours: cfg.TrashRetentionHours,
+		MaxSize:             cfg.MaxSize,
+		MaxVersionSize:      cfg.MaxVersionSize,
+		UpdateCheckMinutes:  cfg.UpdateCheckMinutes,
+		UpdateCheckRemote:   cfg.UpdateCheckRemote,
+		UpdateCheckWebhook:  cfg.UpdateCheckWebhook,
 	}
 	if cfg.Middleware != nil {
 		res.Middleware = make([]string, len(cfg.Middleware))
@@ -10,6 +10,18 @@ func TestRepoValidate(t *testing.T) {
 	if err != nil {
 		t.Errorf("error validating default repo: %s", err)
 	}
+
+	r := DefaultRepo()
+	r.MaxSize = -1
+	if err := r.Validate(); err == nil {
+		t.Errorf("expected a negative max size to be invalid")
+	}
+
+	r = DefaultRepo()
+	r.UpdateCheckMinutes = -1
+	if err := r.Validate(); err == nil {
+		t.Errorf("expected a negative update check interval to be invalid")
+	}
 }
 
 func TestRepoCopy(t *testing.T) {
@@ -17,6 +29,13 @@ func TestRepoCopy(t *testing.T) {
 	// actually copies over correctly (ie, deeply)
 	r := DefaultRepo()
 	r.Middleware = []string{"firstMiddleware"}
+	r.Path = "/path/to/repo"
+	r.TrashRetentionHours = 48
+	r.MaxSize = 1 << 30
+	r.MaxVersionSize = 1 << 20
+	r.UpdateCheckMinutes = 60
+	r.UpdateCheckRemote = "registry"
+	r.UpdateCheckWebhook = "https://example.com/updates"
 
 	cases := []struct {
 		repo *Repo
@@ -7,6 +7,14 @@ type Store struct {
 	Type    string                 `json:"type"`
 	Options map[string]interface{} `json:"options,omitempty"`
 	Path    string                 `json:"path,omitempty"`
+	// MaxConcurrentOps caps the number of store reads & writes that can run at
+	// once. Zero means no limit
+	MaxConcurrentOps int `json:"maxConcurrentOps,omitempty"`
+	// MaxUploadBps & MaxDownloadBps cap the bytes per second sent & received
+	// syncing datasets with peers & remotes. Limits are shared by all
+	// transfers. Zero means no limit
+	MaxUploadBps   int64 `json:"maxUploadBps,omitempty"`
+	MaxDownloadBps int64 `json:"maxDownloadBps,omitempty"`
 }
 
 // DefaultStore returns a new default Store configuration
@@ -36,6 +44,21 @@ func (cfg Store) Validate() error {
 					"ipfs_http",
 					"map"
         ]
+      },
+      "maxConcurrentOps": {
+        "description": "Maximum number of concurrent store operations, 0 for no limit",
+        "type": "integer",
+        "minimum": 0
+      },
+      "maxUploadBps": {
+        "description": "Maximum bytes per second sent syncing datasets, 0 for no limit",
+        "type": "integer",
+        "minimum": 0
+      },
+      "maxDownloadBps": {
+        "description": "Maximum bytes per second received syncing datasets, 0 for no limit",
+        "type": "integer",
+        "minimum": 0
       }
     }
   }`)
@@ -45,8 +68,17 @@ func (cfg Store) Validate() error {
 // Copy returns a deep copy of the Store struct
 func (cfg *Store) Copy() *Store {
 	res := &Store{
-		Type:    cfg.Type,
-		Options: cfg.Options,
+		Type:             cfg.Type,
+		Path:             cfg.Path,
+		MaxConcurrentOps: cfg.MaxConcurrentOps,
+		MaxUploadBps:     cfg.MaxUploadBps,
+		MaxDownloadBps:   cfg.MaxDownloadBps,
+	}
+	if cfg.Options != nil {
+		res.Options = make(map[string]interface{}, len(cfg.Options))
+		for key, val := range cfg.Options {
+			res.Options[key] = val
+		}
 	}
 
 	return res
@@ -10,6 +10,27 @@ func TestStoreValidate(t *testing.T) {
 	if err != nil {
 		t.Errorf("error validating default store: %s", err)
 	}
+
+	limited := DefaultStore()
+	limited.MaxConcurrentOps = 16
+	if err := limited.Validate(); err != nil {
+		t.Errorf("error validating store with an operation limit: %s", err)
+	}
+	limited.MaxConcurrentOps = -1
+	if err := limited.Validate(); err == nil {
+		t.Error("expected negative operation limit to be invalid")
+	}
+
+	throttled := DefaultStore()
+	throttled.MaxUploadBps = 1024
+	throttled.MaxDownloadBps = 2048
+	if err := throttled.Validate(); err != nil {
+		t.Errorf("error validating store with bandwidth limits: %s", err)
+	}
+	throttled.MaxDownloadBps = -1
+	if err := throttled.Validate(); err == nil {
+		t.Error("expected negative bandwidth limit to be invalid")
+	}
 }
 
 func TestStoreCopy(t *testing.T) {
@@ -17,6 +38,8 @@ func TestStoreCopy(t *testing.T) {
 		store *Store
 	}{
 		{DefaultStore()},
+		{&Store{Type: "map", MaxConcurrentOps: 4}},
+		{&Store{Type: "ipfs", MaxUploadBps: 1024, MaxDownloadBps: 2048}},
 	}
 	for i, c := range cases {
 		cpy := c.store.Copy()
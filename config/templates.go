@@ -0,0 +1,79 @@
+package config
+
+import (
+	"github.com/qri-io/jsonschema"
+)
+
+// Template is a named scaffold new datasets can start from, holding the
+// components a house style expects every dataset to have
+type Template struct {
+	// Description says what the template is for
+	Description string `json:"description,omitempty"`
+	// Meta is a skeleton of meta fields
+	Meta map[string]interface{} `json:"meta,omitempty"`
+	// Structure is a structure, commonly a format & a schema of standard
+	// columns
+	Structure map[string]interface{} `json:"structure,omitempty"`
+	// Transform is the path to a starter transform script
+	Transform string `json:"transform,omitempty"`
+}
+
+// Templates are dataset templates, keyed by name
+type Templates map[string]*Template
+
+// Get retrieves a template by name
+func (t *Templates) Get(name string) (*Template, bool) {
+	tmpl, ok := (*t)[name]
+	return tmpl, ok && tmpl != nil
+}
+
+// Validate validates all templates, returning the first error found
+func (t Templates) Validate() error {
+	schema := jsonschema.Must(`{
+    "$schema": "http://json-schema.org/draft-06/schema#",
+    "title": "Templates",
+    "description": "Named dataset templates",
+    "type": "object",
+    "additionalProperties": {
+      "type": "object",
+      "properties": {
+        "description": {
+          "description": "What the template is for",
+          "type": "string"
+        },
+        "meta": {
+          "description": "Skeleton of meta fields new datasets start with",
+          "type": "object"
+        },
+        "structure": {
+          "description": "Structure new datasets start with",
+          "type": "object"
+        },
+        "transform": {
+          "description": "Path to a starter transform script",
+          "type": "string"
+        }
+      }
+    }
+  }`)
+	return validate(schema, &t)
+}
+
+// Copy creates a deep copy of a Templates struct
+func (t *Templates) Copy() *Templates {
+	c := make(map[string]*Template)
+	for name, tmpl := range *t {
+		if tmpl != nil {
+			cp := *tmpl
+			if tmpl.Meta != nil {
+				cp.Meta = copyFields(tmpl.Meta)
+			}
+			if tmpl.Structure != nil {
+				cp.Structure = copyFields(tmpl.Structure)
+			}
+			tmpl = &cp
+		}
+		c[name] = tmpl
+	}
+	return (*Templates)(&c)
+}
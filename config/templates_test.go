@@ -0,0 +1,50 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplatesValidate(t *testing.T) {
+	tmpls := Templates{
+		"survey": &Template{
+			Description: "survey responses",
+			Meta:        map[string]interface{}{"license": map[string]interface{}{"type": "CC-BY-4.0"}},
+			Structure:   map[string]interface{}{"format": "csv"},
+			Transform:   "/path/to/transform.star",
+		},
+	}
+	if err := tmpls.Validate(); err != nil {
+		t.Errorf("error validating templates: %s", err)
+	}
+	if err := (Templates{}).Validate(); err != nil {
+		t.Errorf("error validating empty templates: %s", err)
+	}
+}
+
+func TestTemplatesCopy(t *testing.T) {
+	tmpls := &Templates{
+		"survey": &Template{
+			Description: "survey responses",
+			Meta:        map[string]interface{}{"keywords": []interface{}{"survey"}},
+			Structure:   map[string]interface{}{"format": "csv"},
+		},
+	}
+	cpy := tmpls.Copy()
+	if !reflect.DeepEqual(cpy, tmpls) {
+		t.Fatalf("copy mismatch.\ncopy: %v\noriginal: %v", cpy, tmpls)
+	}
+
+	(*cpy)["survey"].Meta["keywords"].([]interface{})[0] = "poll"
+	(*cpy)["survey"].Structure["format"] = "json"
+	if reflect.DeepEqual(cpy, tmpls) {
+		t.Errorf("editing a copy should not affect the original: %v", (*tmpls)["survey"])
+	}
+
+	if _, ok := cpy.Get("survey"); !ok {
+		t.Error("expected to get a template by name")
+	}
+	if _, ok := cpy.Get("missing"); ok {
+		t.Error("expected getting a missing template to fail")
+	}
+}
@@ -40,6 +40,11 @@ var encoded = []EncodedPeerInfo{
 
 var peerInfo []PeerInfo
 
+// NumTestPeers is the number of predefined test peers
+func NumTestPeers() int {
+	return len(encoded)
+}
+
 // GetTestPeerInfo gets PeerInfo for constructing a peer for testing.
 func GetTestPeerInfo(i int) *PeerInfo {
 	if peerInfo == nil {
@@ -3,8 +3,13 @@ package dsref
 
 import (
 	"fmt"
+	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/qri-io/dataset"
 )
 
 // Rev names a field of a dataset at a snapshot relative to the latest version
@@ -19,6 +24,17 @@ type Rev struct {
 	Field string
 	// the nth-generational ancestor of a history
 	Gen int
+	// Time selects the newest version committed at or before a moment
+	Time time.Time
+	// Label selects the version a label is attached to
+	Label string
+}
+
+// Absolute reports whether a revision names a specific version by date or
+// label instead of counting back from the latest version. Absolute revisions
+// must be resolved against a history to find their generation
+func (r Rev) Absolute() bool {
+	return r.Label != "" || !r.Time.IsZero()
 }
 
 // AllGenerations represents all the generations of a dataset's history
@@ -52,9 +68,134 @@ func ParseRev(rev string) (*Rev, error) {
 	if ok {
 		return &Rev{Gen: 1, Field: field}, nil
 	}
+	// Check for date.
+	if t, ok := parseRevTime(rev); ok {
+		return &Rev{Field: "ds", Time: t}, nil
+	}
+	// Check for a field selector, which would otherwise read as a label
+	if err := fieldSelectorError(rev); err != nil {
+		return nil, err
+	}
+	// Check for label.
+	if labelRegex.MatchString(rev) {
+		return &Rev{Field: "ds", Label: rev}, nil
+	}
 	return nil, fmt.Errorf("unrecognized revision field: %s", rev)
 }
 
+// parseRevTime reads an ISO 8601 date or timestamp. A date without a time of
+// day selects through the end of that day, in UTC
+func parseRevTime(rev string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, rev); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05", rev); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02", rev); err == nil {
+		return t.Add(24*time.Hour - time.Nanosecond), true
+	}
+	return time.Time{}, false
+}
+
+// ParseTimeRef splits a reference that selects a version by date, like
+// "me/dataset@2023-06-01", into the dataset reference and the date. ok is
+// false for references that don't end in an ISO 8601 date or timestamp
+func ParseTimeRef(text string) (ref string, t time.Time, ok bool) {
+	i := strings.LastIndex(text, "@")
+	if i < 0 {
+		return text, t, false
+	}
+	if t, ok = parseRevTime(text[i+1:]); !ok {
+		return text, t, false
+	}
+	return text[:i], t, true
+}
+
+// labelRegex matches label names. labels must start with a letter so they
+// can't be confused with generation counts or dates
+var labelRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_.-]*$`)
+
+// ErrDescribeValidLabel is an error describing valid version labels
+var ErrDescribeValidLabel = fmt.Errorf("label must start with a letter, and only contain letters, numbers, dashes, dots, and underscore")
+
+// ValidateLabel checks a label can name a version. labels can't share a name
+// with a revision field, or revisions that use them would be ambiguous
+func ValidateLabel(label string) error {
+	if !labelRegex.MatchString(label) {
+		return ErrDescribeValidLabel
+	}
+	if _, ok := fieldMap[label]; ok || label == "all" {
+		return fmt.Errorf("label %q is ambiguous, it's also the name of a revision field", label)
+	}
+	if i := strings.Index(label, "."); i >= 0 {
+		if _, ok := fieldMap[label[:i]]; ok {
+			return fmt.Errorf("label %q is ambiguous, it reads as a field of %s", label, label[:i])
+		}
+	}
+	return nil
+}
+
+// componentTypes are the types of components with fields, by revision field
+var componentTypes = map[string]reflect.Type{
+	"ds": reflect.TypeOf(dataset.Dataset{}),
+	"md": reflect.TypeOf(dataset.Meta{}),
+	"vz": reflect.TypeOf(dataset.Viz{}),
+	"tf": reflect.TypeOf(dataset.Transform{}),
+	"st": reflect.TypeOf(dataset.Structure{}),
+	"rm": reflect.TypeOf(dataset.Readme{}),
+}
+
+// fieldSelectorError describes why a revision that selects a field within a
+// component, like "meta.title", is invalid. Revisions select whole
+// components, and a mistyped field name mustn't be taken for a label. It
+// returns nil for revisions that don't start with a component name
+func fieldSelectorError(rev string) error {
+	i := strings.Index(rev, ".")
+	if i < 0 {
+		return nil
+	}
+	component := rev[:i]
+	field, ok := fieldMap[component]
+	if !ok {
+		return nil
+	}
+	name := rev[i+1:]
+	if j := strings.Index(name, "."); j >= 0 {
+		name = name[:j]
+	}
+	t, ok := componentTypes[field]
+	if !ok {
+		return fmt.Errorf("invalid revision %q, %s doesn't have fields", rev, component)
+	}
+	if !hasJSONField(t, name) {
+		return fmt.Errorf("invalid revision %q, %s doesn't have a field named %q", rev, component, name)
+	}
+	return fmt.Errorf("invalid revision %q, revisions select whole components like %q, not fields", rev, component)
+}
+
+// hasJSONField reports whether a struct type encodes a field to JSON with a
+// name, ignoring case
+func hasJSONField(t reflect.Type, name string) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		if strings.EqualFold(tag, name) {
+			return true
+		}
+	}
+	return false
+}
+
 // NewAllRevisions returns a Rev struct that represents all revisions.
 func NewAllRevisions() Rev {
 	return Rev{Field: "ds", Gen: AllGenerations}
@@ -3,6 +3,7 @@ package dsref
 import (
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestParseRevs(t *testing.T) {
@@ -12,15 +13,26 @@ func TestParseRevs(t *testing.T) {
 		err string
 	}{
 		{"", []*Rev{}, "unrecognized revision field: "},
-		{"body", []*Rev{&Rev{"bd", 1}}, ""},
-		{"md", []*Rev{&Rev{"md", 1}}, ""},
-		{"ds", []*Rev{&Rev{"ds", 1}}, ""},
-		{"rd", []*Rev{&Rev{"rd", 1}}, ""},
-		{"rm", []*Rev{&Rev{"rm", 1}}, ""},
-		{"1", []*Rev{&Rev{"ds", 1}}, ""},
-		{"2", []*Rev{&Rev{"ds", 2}}, ""},
-		{"3", []*Rev{&Rev{"ds", 3}}, ""},
-		{"all", []*Rev{&Rev{"ds", AllGenerations}}, ""},
+		{"body", []*Rev{&Rev{Field: "bd", Gen: 1}}, ""},
+		{"md", []*Rev{&Rev{Field: "md", Gen: 1}}, ""},
+		{"ds", []*Rev{&Rev{Field: "ds", Gen: 1}}, ""},
+		{"rd", []*Rev{&Rev{Field: "rd", Gen: 1}}, ""},
+		{"rm", []*Rev{&Rev{Field: "rm", Gen: 1}}, ""},
+		{"1", []*Rev{&Rev{Field: "ds", Gen: 1}}, ""},
+		{"2", []*Rev{&Rev{Field: "ds", Gen: 2}}, ""},
+		{"3", []*Rev{&Rev{Field: "ds", Gen: 3}}, ""},
+		{"all", []*Rev{&Rev{Field: "ds", Gen: AllGenerations}}, ""},
+		{"2019-06-01", []*Rev{&Rev{Field: "ds", Time: time.Date(2019, 6, 1, 23, 59, 59, 999999999, time.UTC)}}, ""},
+		{"2019-06-01T12:30:00Z", []*Rev{&Rev{Field: "ds", Time: time.Date(2019, 6, 1, 12, 30, 0, 0, time.UTC)}}, ""},
+		{"release-1", []*Rev{&Rev{Field: "ds", Label: "release-1"}}, ""},
+		{"md,release-1", []*Rev{&Rev{Field: "md", Gen: 1}, &Rev{Field: "ds", Label: "release-1"}}, ""},
+		{"2019-13-01", nil, "unrecognized revision field: 2019-13-01"},
+		{"not a label", nil, "unrecognized revision field: not a label"},
+		{"v1.2", []*Rev{&Rev{Field: "ds", Label: "v1.2"}}, ""},
+		{"meta.titel", nil, `invalid revision "meta.titel", meta doesn't have a field named "titel"`},
+		{"md.title", nil, `invalid revision "md.title", revisions select whole components like "md", not fields`},
+		{"structure.schema.type", nil, `invalid revision "structure.schema.type", revisions select whole components like "structure", not fields`},
+		{"body.rows", nil, `invalid revision "body.rows", body doesn't have fields`},
 	}
 
 	for i, c := range cases {
@@ -47,5 +59,48 @@ func EnsureRevEqual(a, b *Rev) error {
 	if a.Gen != b.Gen {
 		return fmt.Errorf("Gen: %d != %d", a.Gen, b.Gen)
 	}
+	if !a.Time.Equal(b.Time) {
+		return fmt.Errorf("Time: %s != %s", a.Time, b.Time)
+	}
+	if a.Label != b.Label {
+		return fmt.Errorf("Label: %s != %s", a.Label, b.Label)
+	}
 	return nil
 }
+
+func TestValidateLabel(t *testing.T) {
+	good := []string{"release-1", "v1.2", "Q3_final"}
+	for _, l := range good {
+		if err := ValidateLabel(l); err != nil {
+			t.Errorf("expected %q to be valid, got: %s", l, err)
+		}
+	}
+	bad := []string{"", "1st", "2019-06-01", "has space", "body", "md", "all", "meta.v1"}
+	for _, l := range bad {
+		if err := ValidateLabel(l); err == nil {
+			t.Errorf("expected %q to be invalid", l)
+		}
+	}
+}
+
+func TestParseTimeRef(t *testing.T) {
+	cases := []struct {
+		in  string
+		ref string
+		t   time.Time
+		ok  bool
+	}{
+		{"me/ds@2023-06-01", "me/ds", time.Date(2023, 6, 1, 23, 59, 59, 999999999, time.UTC), true},
+		{"me/ds@2023-06-01T10:00:00Z", "me/ds", time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC), true},
+		{"me/ds", "me/ds", time.Time{}, false},
+		{"me/ds@/ipfs/QmFoo", "me/ds@/ipfs/QmFoo", time.Time{}, false},
+		{"me/ds@yesterday", "me/ds@yesterday", time.Time{}, false},
+	}
+
+	for _, c := range cases {
+		ref, got, ok := ParseTimeRef(c.in)
+		if ref != c.ref || ok != c.ok || !got.Equal(c.t) {
+			t.Errorf("%s: expected (%q, %s, %t), got (%q, %s, %t)", c.in, c.ref, c.t, c.ok, ref, got, ok)
+		}
+	}
+}
@@ -75,6 +75,30 @@ type VersionInfo struct {
 	NumVersions int `json:"numVersions,omitempty"`
 	// FSIPath is this dataset's link to the local filesystem if one exists
 	FSIPath string `json:"fsiPath,omitempty"`
+	// ForkOf is the version this dataset was forked from, if any. ForkOf is
+	// read from the logbook when listing, and isn't stored in dscache
+	ForkOf string `json:"forkOf,omitempty"`
+	// Labels attached to this version. Labels are read from the logbook, and
+	// aren't stored in dscache
+	Labels []string `json:"labels,omitempty"`
+	// Trashed is true for removed datasets waiting in the trash. Trashed is
+	// read from the repo trash, and isn't stored in dscache
+	Trashed bool `json:"trashed,omitempty"`
+	// AliasOf is the name of the dataset an alias listing resolves to, empty
+	// for datasets. AliasOf is read from the repo aliases, and isn't stored
+	// in dscache
+	AliasOf string `json:"aliasOf,omitempty"`
+	// SyncStatus describes how a published dataset's history compares with a
+	// remote's, one of "in-sync", "ahead", "behind", "diverged" or "unknown".
+	// SyncStatus is only set when requested while listing
+	SyncStatus string `json:"syncStatus,omitempty"`
+	// UpdatesAvailable is true for a dataset added from another peer when the
+	// latest update check found newer versions. UpdatesAvailable is read from
+	// the instance's update checks, and isn't stored in dscache
+	UpdatesAvailable bool `json:"updatesAvailable,omitempty"`
+	// Remote is the address of the remote this version was read from. Remote
+	// is only set on history fetched from a remote, and isn't stored in dscache
+	Remote string `json:"remote,omitempty"`
 }
 
 // SimpleRef returns a simple dsref.Ref
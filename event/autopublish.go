@@ -0,0 +1,26 @@
+package event
+
+var (
+	// ETAutoPublishEvent type for when a watched dataset is saved & published
+	// automatically, or fails to be
+	ETAutoPublishEvent = Topic("autopublish:event")
+)
+
+const (
+	// AutoPublishPublished means changes were saved & published
+	AutoPublishPublished = "published"
+	// AutoPublishFailed means saving or publishing changes failed. Changes
+	// are tried again the next time the working directory changes
+	AutoPublishFailed = "failed"
+)
+
+// AutoPublishEvent describes an attempt to save & publish a dataset after its
+// working directory changed
+type AutoPublishEvent struct {
+	Action   string
+	Username string
+	Dsname   string
+	Path     string
+	Remote   string
+	Error    string
+}
@@ -0,0 +1,28 @@
+package event
+
+var (
+	// ETPublishQueueEvent type for when a queued publish changes state
+	ETPublishQueueEvent = Topic("publish:queueEvent")
+)
+
+const (
+	// PublishQueueQueued means a dataset was queued for publishing
+	PublishQueueQueued = "queued"
+	// PublishQueueRetrying means an attempt failed, and will be retried
+	PublishQueueRetrying = "retrying"
+	// PublishQueuePublished means a queued dataset was published
+	PublishQueuePublished = "published"
+	// PublishQueueFailed means a queued publish gave up retrying
+	PublishQueueFailed = "failed"
+)
+
+// PublishQueueEvent describes a change to a queued publish
+type PublishQueueEvent struct {
+	Action   string
+	Username string
+	Dsname   string
+	Path     string
+	Remote   string
+	Attempts int
+	Error    string
+}
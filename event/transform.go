@@ -0,0 +1,35 @@
+package event
+
+var (
+	// ETTransformEvent type for when a transform script run starts, prints
+	// output or finishes
+	ETTransformEvent = Topic("transform:event")
+)
+
+const (
+	// TransformStarted means a transform script began running
+	TransformStarted = "started"
+	// TransformPrint means a transform script printed output
+	TransformPrint = "print"
+	// TransformFinished means a transform script ran & the dataset was saved
+	TransformFinished = "finished"
+	// TransformFailed means a transform script, or saving its results, failed
+	TransformFailed = "failed"
+)
+
+// TransformEvent describes progress of a transform script run during a save.
+// Events for one run share a RunID when the caller provides one. The bus
+// doesn't guarantee delivery order, Seq numbers the events of a run in the
+// order they happened
+type TransformEvent struct {
+	Action   string
+	RunID    string
+	Seq      int
+	Username string
+	Dsname   string
+	// Msg is printed output for print events, without a trailing newline
+	Msg string
+	// Path is the saved version for finished events
+	Path  string
+	Error string
+}
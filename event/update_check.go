@@ -0,0 +1,19 @@
+package event
+
+var (
+	// ETUpdateAvailableEvent type for when an update check finds a dataset
+	// added from another peer has new versions
+	ETUpdateAvailableEvent = Topic("updates:available")
+)
+
+// UpdateAvailableEvent describes a dataset with versions newer than the one
+// in the local repo
+type UpdateAvailableEvent struct {
+	Username string
+	Dsname   string
+	// LocalHead is the latest version in the local repo, RemoteHead the latest
+	// version the remote knows of
+	LocalHead  string
+	RemoteHead string
+	Remote     string
+}
@@ -12,7 +12,7 @@ import (
 )
 
 // GetBody is an FSI version of base.ReadBody
-func GetBody(dirPath string, format dataset.DataFormat, fcfg dataset.FormatConfig, offset, limit int, all bool) ([]byte, error) {
+func GetBody(dirPath string, format dataset.DataFormat, fcfg dataset.FormatConfig, columns []string, offset, limit int, all bool) ([]byte, error) {
 
 	components, err := component.ListDirectoryComponents(dirPath)
 	if err != nil {
@@ -65,19 +65,11 @@ func GetBody(dirPath string, format dataset.DataFormat, fcfg dataset.FormatConfi
 
 	file := qfs.NewMemfileReader(filepath.Base(bodyComponent.Base().SourceFile), f)
 
-	st := &dataset.Structure{}
-	assign := &dataset.Structure{
-		Format: format.String(),
-		Schema: schema,
-	}
-	if fcfg != nil {
-		assign.FormatConfig = fcfg.Map()
-	}
-	st.Assign(structure, assign)
 	// If there is no schema on the dataset, but one was detected, assign it as well.
-	if structure.Schema == nil && assign.Schema != nil {
-		structure.Schema = assign.Schema
+	if structure.Schema == nil && schema != nil {
+		structure.Schema = schema
 	}
 
-	return base.ConvertBodyFile(file, structure, st, limit, offset, all)
+	st := base.ReadStructure(structure, format, fcfg)
+	return base.ConvertBodyFile(file, structure, st, columns, limit, offset, all)
 }
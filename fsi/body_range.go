@@ -0,0 +1,172 @@
+package fsi
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/component"
+	"github.com/qri-io/qri/base/dsfs"
+)
+
+// bodyRangePrefix starts the line of a link file that records a body range
+const bodyRangePrefix = "bodyrange: "
+
+// BodyRange is a range of rows of an array body, from Start up to but not
+// including End. A directory checked out with a body range holds only those
+// rows in its body file. Saving splices the edited rows back into the body of
+// the previous version. An empty range checks out no rows, rows added to it
+// are inserted at Start
+type BodyRange struct {
+	Start int
+	End   int
+}
+
+// ParseBodyRange parses a body range of the form "start-end"
+func ParseBodyRange(s string) (*BodyRange, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid body range %q, expected the form start-end", s)
+	}
+	start, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid body range start %q", parts[0])
+	}
+	end, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid body range end %q", parts[1])
+	}
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("invalid body range %q, end can't be less than start", s)
+	}
+	return &BodyRange{Start: start, End: end}, nil
+}
+
+// String formats a body range as "start-end"
+func (r *BodyRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// slice returns the rows of a body within the range
+func (r *BodyRange) slice(rows []interface{}) []interface{} {
+	start, end := r.Start, r.End
+	if start > len(rows) {
+		start = len(rows)
+	}
+	if end > len(rows) {
+		end = len(rows)
+	}
+	return rows[start:end]
+}
+
+// GetBodyRange returns the body range recorded in the link file of a
+// directory, nil if the directory holds the whole body
+func GetBodyRange(dir string) *BodyRange {
+	l, _ := readLinkFile(dir)
+	return l.BodyRange
+}
+
+// SetBodyRange records the body range of a linked directory. A nil range
+// checks out the whole body
+func SetBodyRange(dir string, rng *BodyRange) error {
+	l, ok := readLinkFile(dir)
+	if !ok {
+		return fmt.Errorf("not a linked directory")
+	}
+	l.BodyRange = rng
+	_, err := writeLinkFile(dir, l)
+	return err
+}
+
+// sliceBodyComponent replaces the value of a body component with the rows
+// within a range
+func sliceBodyComponent(comp component.Component, rng *BodyRange) error {
+	bc, ok := comp.(*component.BodyComponent)
+	if !ok {
+		return fmt.Errorf("could not get body")
+	}
+	if err := bc.LoadAndFill(nil); err != nil {
+		return err
+	}
+	rows, ok := bc.Value.([]interface{})
+	if !ok {
+		return fmt.Errorf("body ranges can only be used with array bodies")
+	}
+	bc.Value = rng.slice(rows)
+	return nil
+}
+
+// SpliceBodyRange replaces the rows of a directory's body range in the body
+// of the version at prevPath with the rows of ds, which must be read from the
+// directory. It returns the range covering the edited rows, which may have
+// grown or shrunk, for callers to record with SetBodyRange once the spliced
+// body is saved. Directories without a body range are left as-is, returning a
+// nil range
+func (fsi *FSI) SpliceBodyRange(ctx context.Context, dir, prevPath string, ds *dataset.Dataset) (*BodyRange, error) {
+	rng := GetBodyRange(dir)
+	if rng == nil {
+		return nil, nil
+	}
+	if ds.BodyPath == "" {
+		return nil, fmt.Errorf("body file of a dataset checked out with body range %s can't be removed", rng)
+	}
+
+	prev, err := dsfs.LoadDataset(ctx, fsi.repo.Store(), prevPath)
+	if err != nil {
+		return nil, err
+	}
+	if err = base.OpenDataset(ctx, fsi.repo.Filesystem(), prev); err != nil {
+		return nil, err
+	}
+	prevBody := &component.BodyComponent{BodyFile: prev.BodyFile(), Structure: prev.Structure}
+	if err = prevBody.LoadAndFill(nil); err != nil {
+		return nil, err
+	}
+	prevRows, ok := prevBody.Value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("body ranges can only be used with array bodies")
+	}
+
+	edited := &component.BodyComponent{
+		BaseComponent: component.BaseComponent{
+			SourceFile: ds.BodyPath,
+			Format:     strings.TrimPrefix(filepath.Ext(ds.BodyPath), "."),
+		},
+	}
+	if err = edited.LoadAndFill(nil); err != nil {
+		return nil, err
+	}
+	editedRows, ok := edited.Value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("body ranges can only be used with array bodies")
+	}
+
+	start, end := rng.Start, rng.End
+	if start > len(prevRows) {
+		start = len(prevRows)
+	}
+	if end > len(prevRows) {
+		end = len(prevRows)
+	}
+	rows := make([]interface{}, 0, len(prevRows)-(end-start)+len(editedRows))
+	rows = append(rows, prevRows[:start]...)
+	rows = append(rows, editedRows...)
+	rows = append(rows, prevRows[end:]...)
+
+	st := ds.Structure
+	if st == nil {
+		st = prev.Structure
+	}
+	data, err := component.SerializeBody(rows, st)
+	if err != nil {
+		return nil, err
+	}
+	ds.SetBodyFile(qfs.NewMemfileBytes(fmt.Sprintf("body.%s", st.Format), data))
+
+	return &BodyRange{Start: rng.Start, End: rng.Start + len(editedRows)}, nil
+}
@@ -0,0 +1,143 @@
+package fsi
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/component"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/repo"
+)
+
+func TestParseBodyRange(t *testing.T) {
+	good := map[string]BodyRange{
+		"1000-2000": {Start: 1000, End: 2000},
+		"0-1":       {Start: 0, End: 1},
+		"5-5":       {Start: 5, End: 5},
+	}
+	for s, expect := range good {
+		rng, err := ParseBodyRange(s)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", s, err)
+			continue
+		}
+		if *rng != expect {
+			t.Errorf("%q: expected %v, got %v", s, expect, *rng)
+		}
+		if rng.String() != s {
+			t.Errorf("%q: expected range to format as itself, got %q", s, rng)
+		}
+	}
+
+	for _, s := range []string{"", "10", "a-b", "20-10", "-1-5"} {
+		if _, err := ParseBodyRange(s); err == nil {
+			t.Errorf("%q: expected error, got nil", s)
+		}
+	}
+}
+
+func TestBodyRange(t *testing.T) {
+	ctx := context.Background()
+	paths := NewTmpPaths()
+	defer paths.Close()
+
+	fsi := NewFSI(paths.testRepo, nil)
+	ref, err := repo.ParseDatasetRef("me/cities")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = repo.CanonicalizeDatasetRef(paths.testRepo, &ref); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := dsfs.LoadDataset(ctx, paths.testRepo.Store(), ref.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = base.OpenDataset(ctx, paths.testRepo.Filesystem(), ds); err != nil {
+		t.Fatal(err)
+	}
+	full := &component.BodyComponent{BodyFile: ds.BodyFile(), Structure: ds.Structure}
+	if err = full.LoadAndFill(nil); err != nil {
+		t.Fatal(err)
+	}
+	rows := full.Value.([]interface{})
+	ds.SetBodyFile(nil)
+	ds.Body = rows
+
+	dir := paths.firstDir
+	if _, _, err = fsi.CreateLink(dir, "me/cities"); err != nil {
+		t.Fatal(err)
+	}
+	if err = SetBodyRange(dir, &BodyRange{Start: 1, End: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err = WriteComponents(ds, dir, paths.testRepo.Filesystem()); err != nil {
+		t.Fatal(err)
+	}
+
+	bodyFile := filepath.Join(dir, "body."+ds.Structure.Format)
+	working := &component.BodyComponent{BaseComponent: component.BaseComponent{SourceFile: bodyFile, Format: ds.Structure.Format}}
+	if err = working.LoadAndFill(nil); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(rows[1:3], working.Value); diff != "" {
+		t.Errorf("checked out rows mismatch (-want +got):\n%s", diff)
+	}
+
+	changes, err := fsi.Status(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ch := range changes {
+		if ch.Component == "body" && ch.Type != STUnmodified {
+			t.Errorf("expected checked out rows to be unmodified, got: %s", ch.Type)
+		}
+	}
+
+	// replace the two checked out rows with a single edited row
+	edited := &dataset.Dataset{Structure: ds.Structure}
+	data, err := component.SerializeBody([]interface{}{rows[0]}, ds.Structure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(bodyFile, data, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	edited.BodyPath = bodyFile
+
+	rng, err := fsi.SpliceBodyRange(ctx, dir, ref.Path, edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *rng != (BodyRange{Start: 1, End: 2}) {
+		t.Errorf("expected range to shrink to the edited rows, got: %s", rng)
+	}
+	spliced := &component.BodyComponent{BodyFile: edited.BodyFile(), Structure: ds.Structure}
+	if err = spliced.LoadAndFill(nil); err != nil {
+		t.Fatal(err)
+	}
+	expect := append([]interface{}{rows[0], rows[0]}, rows[3:]...)
+	if diff := cmp.Diff(expect, spliced.Value); diff != "" {
+		t.Errorf("spliced body mismatch (-want +got):\n%s", diff)
+	}
+
+	if err = os.Remove(bodyFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fsi.SpliceBodyRange(ctx, dir, ref.Path, &dataset.Dataset{}); err == nil {
+		t.Error("expected removing the body of a ranged checkout to error")
+	}
+
+	if err = SetBodyRange(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	if rng, err = fsi.SpliceBodyRange(ctx, dir, ref.Path, edited); rng != nil || err != nil {
+		t.Errorf("expected directories without a range to be left as-is, got: %v, %v", rng, err)
+	}
+}
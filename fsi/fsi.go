@@ -13,7 +13,6 @@ package fsi
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -33,6 +32,9 @@ var (
 	// ErrNotLinkedToFilesystem is the err implementers should return when we
 	// are expecting the dataset to have a file system link, but fsiPath is empty
 	ErrNoLink = fmt.Errorf("dataset is not linked to the filesystem")
+	// ErrAlreadyLinked is wrapped by errors for datasets or directories that
+	// are already linked
+	ErrAlreadyLinked = fmt.Errorf("already linked")
 )
 
 // QriRefFilename is the name of the file that links a folder to a dataset.
@@ -44,11 +46,8 @@ const QriRefFilename = ".qri-ref"
 // GetLinkedFilesysRef returns whether a directory is linked to a
 // dataset in your repo, and the reference to that dataset.
 func GetLinkedFilesysRef(dir string) (string, bool) {
-	data, err := ioutil.ReadFile(filepath.Join(dir, QriRefFilename))
-	if err == nil {
-		return strings.TrimSpace(string(data)), true
-	}
-	return "", false
+	l, ok := readLinkFile(dir)
+	return l.Ref, ok
 }
 
 // RepoPath returns the standard path to an FSI file for a given file-system
@@ -107,7 +106,7 @@ func (fsi *FSI) CreateLink(dirPath, refStr string) (alias string, rollback func(
 		return "", rollback, err
 	}
 	err = repo.CanonicalizeDatasetRef(fsi.repo, &ref)
-	if err != nil && err != repo.ErrNotFound && err != repo.ErrNoHistory {
+	if err != nil && !repo.IsNotFound(err) && err != repo.ErrNoHistory {
 		return ref.String(), rollback, err
 	}
 
@@ -116,7 +115,7 @@ func (fsi *FSI) CreateLink(dirPath, refStr string) (alias string, rollback func(
 			// There is already a link for this dataset, see if that link still exists.
 			targetPath := filepath.Join(stored.FSIPath, QriRefFilename)
 			if _, err := os.Stat(targetPath); err == nil {
-				return "", rollback, fmt.Errorf("'%s' is already linked to %s", ref.AliasString(), stored.FSIPath)
+				return "", rollback, fmt.Errorf("'%s' is %w to %s", ref.AliasString(), ErrAlreadyLinked, stored.FSIPath)
 			}
 		}
 	}
@@ -136,7 +135,7 @@ func (fsi *FSI) CreateLink(dirPath, refStr string) (alias string, rollback func(
 	}
 
 	linkFile := ""
-	if linkFile, err = writeLinkFile(dirPath, ref.AliasString()); err != nil {
+	if linkFile, err = writeLinkFile(dirPath, link{Ref: ref.AliasString(), Layout: LayoutFiles}); err != nil {
 		return "", removeRefFunc, err
 	}
 	// If future steps fail, remove the link file we just wrote to
@@ -192,7 +191,9 @@ func (fsi *FSI) ModifyLinkReference(dirPath, refStr string) error {
 	}
 
 	log.Debugf("fsi.ModifyLinkReference: modify linkfile at %q, ref=%q", dirPath, ref)
-	if _, err = writeLinkFile(dirPath, ref.AliasString()); err != nil {
+	l, _ := readLinkFile(dirPath)
+	l.Ref = ref.AliasString()
+	if _, err = writeLinkFile(dirPath, l); err != nil {
 		return err
 	}
 	return nil
@@ -243,8 +244,17 @@ func (fsi *FSI) getRepoRef(refStr string) (ref reporef.DatasetRef, err error) {
 	return fsi.repo.GetRef(ref)
 }
 
-func writeLinkFile(dir, linkstr string) (string, error) {
+func writeLinkFile(dir string, l link) (string, error) {
 	linkFile := filepath.Join(dir, QriRefFilename)
+	linkstr := l.Ref
+	if l.Layout != LayoutFiles && l.Layout != "" {
+		// only record non-default layouts, keeping link files readable by
+		// versions of qri that don't know about layouts
+		linkstr = fmt.Sprintf("%s\n%s%s", linkstr, layoutPrefix, l.Layout)
+	}
+	if l.BodyRange != nil {
+		linkstr = fmt.Sprintf("%s\n%s%s", linkstr, bodyRangePrefix, l.BodyRange)
+	}
 	return linkFile, base.WriteHiddenFile(linkFile, linkstr)
 }
 
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/base/component"
 	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/logbook"
@@ -22,6 +23,15 @@ type InitParams struct {
 	Format         string
 	Mkdir          string
 	SourceBodyPath string
+	// Layout is how components are arranged in the working directory, defaults
+	// to LayoutFiles
+	Layout string
+	// Template names a dataset template. fsi doesn't read templates, callers
+	// resolve Template into Scaffold
+	Template string
+	// Scaffold is a dataset to start from. Any meta, structure & transform it
+	// has are written in place of the defaults
+	Scaffold *dataset.Dataset
 }
 
 func concatFunc(f1, f2 func()) func() {
@@ -55,6 +65,10 @@ func (fsi *FSI) InitDataset(p InitParams) (name string, err error) {
 	if p.Dir == "" {
 		return "", fmt.Errorf("directory is required to initialize a dataset")
 	}
+	layout, err := ParseLayout(p.Layout)
+	if err != nil {
+		return "", err
+	}
 
 	if fi, err := os.Stat(p.Dir); err != nil {
 		return "", err
@@ -101,7 +115,11 @@ func (fsi *FSI) InitDataset(p InitParams) (name string, err error) {
 	// Make sure a dataset with this name does not exist in your repo.
 	if err = repo.CanonicalizeDatasetRef(fsi.repo, ref); err == nil {
 		// TODO(dlong): Tell user to use `checkout` if the dataset already exists in their repo?
-		return "", fmt.Errorf("a dataset with the name %s already exists in your repo", ref)
+		return "", fmt.Errorf("a dataset with the name %s %w in your repo", ref, repo.ErrAlreadyExists)
+	}
+	// a new dataset replaces any trashed dataset with the same name
+	if err = base.PurgeTrashed(context.TODO(), fsi.repo, *ref); err != nil {
+		return "", err
 	}
 
 	// Derive format from --source-body-path if provided.
@@ -112,6 +130,11 @@ func (fsi *FSI) InitDataset(p InitParams) (name string, err error) {
 		}
 	}
 
+	// Derive format from the scaffold structure if provided.
+	if p.Format == "" && p.Scaffold != nil && p.Scaffold.Structure != nil {
+		p.Format = p.Scaffold.Structure.Format
+	}
+
 	// Validate dataset format
 	if p.Format != "csv" && p.Format != "json" {
 		return "", fmt.Errorf("invalid format \"%s\", only \"csv\" and \"json\" accepted", p.Format)
@@ -124,6 +147,11 @@ func (fsi *FSI) InitDataset(p InitParams) (name string, err error) {
 	}
 	// If future steps fail, rollback the link creation.
 	rollback = concatFunc(undo, rollback)
+	if layout != LayoutFiles {
+		if err = SetLinkLayout(targetPath, layout); err != nil {
+			return "", err
+		}
+	}
 
 	// Construct the dataset to write to the working directory
 	initDs := &dataset.Dataset{}
@@ -131,6 +159,23 @@ func (fsi *FSI) InitDataset(p InitParams) (name string, err error) {
 	// Add an empty meta.
 	initDs.Meta = &dataset.Meta{Title: ""}
 
+	// Start from the scaffold's components. A scaffold structure with a schema
+	// describes the body, which starts empty instead of with example rows
+	var scaffoldSchema map[string]interface{}
+	if sc := p.Scaffold; sc != nil {
+		if sc.Meta != nil {
+			initDs.Meta = sc.Meta
+		}
+		if sc.Structure != nil && p.SourceBodyPath == "" {
+			initDs.Structure = sc.Structure
+			initDs.Structure.Format = p.Format
+			scaffoldSchema = sc.Structure.Schema
+		}
+		if sc.Transform != nil {
+			initDs.Transform = sc.Transform
+		}
+	}
+
 	// Add body file.
 	var bodySchema map[string]interface{}
 	if p.SourceBodyPath != "" {
@@ -147,6 +192,12 @@ func (fsi *FSI) InitDataset(p InitParams) (name string, err error) {
 			return "", err
 		}
 		initDs.Structure = entries.Structure()
+	} else if scaffoldSchema != nil {
+		if scaffoldSchema["type"] == "object" {
+			initDs.Body = map[string]interface{}{}
+		} else {
+			initDs.Body = []interface{}{}
+		}
 	} else if p.Format == "csv" {
 		initDs.Body = []interface{}{
 			[]interface{}{"one", "two", 3},
@@ -172,6 +223,27 @@ func (fsi *FSI) InitDataset(p InitParams) (name string, err error) {
 	// Write components of the dataset to the working directory.
 	container := component.ConvertDatasetToComponents(initDs, fsi.repo.Filesystem())
 	PrepareToWrite(container)
+	// If future steps fail, rollback the components that have been written
+	rollbackWrite := func(wroteFile string) {
+		rollback = concatFunc(func() {
+			if wroteFile != "" {
+				log.Debugf("removing file %q during rollback", wroteFile)
+				if err := os.Remove(wroteFile); err != nil {
+					log.Debugf("error while removing file %q: %s", wroteFile, err)
+				}
+			}
+		}, rollback)
+	}
+	if layout == LayoutSingleFile {
+		wroteFile, err := component.WriteDatasetFile(container, targetPath)
+		if err != nil {
+			return "", err
+		}
+		rollbackWrite(wroteFile)
+		for _, compName := range component.DatasetFileSections() {
+			container.Base().RemoveSubcomponent(compName)
+		}
+	}
 	for _, compName := range component.AllSubcomponentNames() {
 		aComp := container.Base().GetSubcomponent(compName)
 		if aComp != nil {
@@ -179,15 +251,7 @@ func (fsi *FSI) InitDataset(p InitParams) (name string, err error) {
 			if err != nil {
 				return "", err
 			}
-			// If future steps fail, rollback the components that have been written
-			rollback = concatFunc(func() {
-				if wroteFile != "" {
-					log.Debugf("removing file %q during rollback", wroteFile)
-					if err := os.Remove(wroteFile); err != nil {
-						log.Debugf("error while removing file %q: %s", wroteFile, err)
-					}
-				}
-			}, rollback)
+			rollbackWrite(wroteFile)
 		}
 	}
 
@@ -206,12 +270,15 @@ func (fsi *FSI) InitDataset(p InitParams) (name string, err error) {
 
 func canInitDir(dir string) error {
 	if _, err := os.Stat(filepath.Join(dir, QriRefFilename)); !os.IsNotExist(err) {
-		return fmt.Errorf("working directory is already linked, .qri-ref exists")
+		return fmt.Errorf("working directory is %w, .qri-ref exists", ErrAlreadyLinked)
 	}
 	if _, err := os.Stat(filepath.Join(dir, "meta.json")); !os.IsNotExist(err) {
 		// TODO(dlong): Instead, import the meta.json file for the new dataset
 		return fmt.Errorf("cannot initialize new dataset, meta.json exists")
 	}
+	if _, err := os.Stat(filepath.Join(dir, component.DatasetFilename)); !os.IsNotExist(err) {
+		return fmt.Errorf("cannot initialize new dataset, %s exists", component.DatasetFilename)
+	}
 	if _, err := os.Stat(filepath.Join(dir, "structure.json")); !os.IsNotExist(err) {
 		// TODO(dlong): Instead, import the structure.json file for the new dataset
 		return fmt.Errorf("cannot initialize new dataset, schema.json exists")
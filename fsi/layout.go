@@ -0,0 +1,140 @@
+package fsi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/qri-io/qri/base/component"
+)
+
+// Layout is how the components of a linked dataset are arranged as files in
+// its working directory
+type Layout string
+
+const (
+	// LayoutFiles gives each component its own file, like meta.json and
+	// structure.json. LayoutFiles is the default layout
+	LayoutFiles Layout = "files"
+	// LayoutSingleFile keeps commit, meta & structure as sections of a single
+	// dataset.yaml. Body, readme & transform keep their own files
+	LayoutSingleFile Layout = "single-file"
+)
+
+// layoutPrefix starts the line of a link file that records a layout
+const layoutPrefix = "layout: "
+
+// link is the contents of a link file
+type link struct {
+	Ref    string
+	Layout Layout
+	// BodyRange is the rows of the body checked out to the directory, nil for
+	// the whole body
+	BodyRange *BodyRange
+}
+
+// ParseLayout checks a layout name, an empty string is the default layout
+func ParseLayout(s string) (Layout, error) {
+	switch Layout(s) {
+	case "", LayoutFiles:
+		return LayoutFiles, nil
+	case LayoutSingleFile:
+		return LayoutSingleFile, nil
+	}
+	return "", fmt.Errorf("unknown layout %q, options are %q and %q", s, LayoutFiles, LayoutSingleFile)
+}
+
+// GetLinkLayout returns the layout recorded in the link file of a directory,
+// defaulting to LayoutFiles
+func GetLinkLayout(dir string) Layout {
+	l, _ := readLinkFile(dir)
+	return l.Layout
+}
+
+// SetLinkLayout records the layout of a linked directory in its link file
+func SetLinkLayout(dir string, layout Layout) error {
+	l, ok := readLinkFile(dir)
+	if !ok {
+		return fmt.Errorf("not a linked directory")
+	}
+	l.Layout = layout
+	_, err := writeLinkFile(dir, l)
+	return err
+}
+
+// readLinkFile reads a link file. The first line of a link file is the linked
+// dataset reference, later lines hold link settings
+func readLinkFile(dir string) (l link, ok bool) {
+	l.Layout = LayoutFiles
+	data, err := ioutil.ReadFile(filepath.Join(dir, QriRefFilename))
+	if err != nil {
+		return l, false
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, layoutPrefix):
+			if layout, err := ParseLayout(strings.TrimPrefix(line, layoutPrefix)); err == nil {
+				l.Layout = layout
+			}
+		case strings.HasPrefix(line, bodyRangePrefix):
+			if rng, err := ParseBodyRange(strings.TrimPrefix(line, bodyRangePrefix)); err == nil {
+				l.BodyRange = rng
+			}
+		}
+	}
+	l.Ref = strings.TrimSpace(lines[0])
+	return l, true
+}
+
+// checkLayout marks components that are in the wrong place for the layout of
+// a linked directory as conflicts. In a single file layout, a component file
+// like meta.json conflicts with the meta section of dataset.yaml, even if
+// dataset.yaml doesn't have a meta section
+func checkLayout(dir string, comps component.Component) {
+	if GetLinkLayout(dir) != LayoutSingleFile {
+		return
+	}
+	for _, name := range component.DatasetFileSections() {
+		sub := comps.Base().GetSubcomponent(name)
+		if sub == nil || sub.Base().ProblemKind != "" {
+			continue
+		}
+		filename := filepath.Base(sub.Base().SourceFile)
+		if filename == component.DatasetFilename {
+			continue
+		}
+		files := []string{filename, component.DatasetFilename}
+		sort.Strings(files)
+		sub.Base().ProblemKind = "conflict"
+		sub.Base().ProblemMessage = strings.Join(files, " ")
+	}
+}
+
+// writeDatasetFileSection replaces one section of the dataset.yaml in a
+// directory with the named component from comp, keeping other sections. If
+// comp doesn't have the component, the section is removed
+func writeDatasetFileSection(comp component.Component, name, dirPath string) (string, error) {
+	sections, err := component.ReadDatasetFileSections(dirPath)
+	if err != nil {
+		return "", err
+	}
+	if sub := comp.Base().GetSubcomponent(name); sub != nil {
+		sections.Base().Subcomponents[name] = sub
+	} else {
+		sections.Base().RemoveSubcomponent(name)
+	}
+	return component.WriteDatasetFile(sections, dirPath)
+}
+
+func isDatasetFileSection(name string) bool {
+	for _, s := range component.DatasetFileSections() {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+package fsi
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/component"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/repo"
+)
+
+func TestSetLinkLayout(t *testing.T) {
+	paths := NewTmpPaths()
+	defer paths.Close()
+
+	fsi := NewFSI(paths.testRepo, nil)
+	if _, _, err := fsi.CreateLink(paths.firstDir, "me/test_ds"); err != nil {
+		t.Fatal(err)
+	}
+	if layout := GetLinkLayout(paths.firstDir); layout != LayoutFiles {
+		t.Errorf("expected default layout %q, got: %q", LayoutFiles, layout)
+	}
+	if err := SetLinkLayout(paths.firstDir, LayoutSingleFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsi.ModifyLinkReference(paths.firstDir, "me/test_ds"); err != nil {
+		t.Fatal(err)
+	}
+
+	ref, ok := GetLinkedFilesysRef(paths.firstDir)
+	if !ok || ref != "peer/test_ds" {
+		t.Errorf("expected link to peer/test_ds, got: %q", ref)
+	}
+	if layout := GetLinkLayout(paths.firstDir); layout != LayoutSingleFile {
+		t.Errorf("expected layout %q to be kept, got: %q", LayoutSingleFile, layout)
+	}
+
+	if err := SetLinkLayout(paths.secondDir, LayoutSingleFile); err == nil {
+		t.Error("expected setting the layout of an unlinked directory to error")
+	}
+	if _, err := ParseLayout("spreadsheet"); err == nil {
+		t.Error("expected an unknown layout to error")
+	}
+}
+
+func TestSingleFileLayout(t *testing.T) {
+	ctx := context.Background()
+	paths := NewTmpPaths()
+	defer paths.Close()
+
+	fsi := NewFSI(paths.testRepo, nil)
+	ref, err := repo.ParseDatasetRef("me/cities")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = repo.CanonicalizeDatasetRef(paths.testRepo, &ref); err != nil {
+		t.Fatal(err)
+	}
+	// body files can only be read once, load a fresh dataset for each write
+	load := func() *dataset.Dataset {
+		ds, err := dsfs.LoadDataset(ctx, paths.testRepo.Store(), ref.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = base.OpenDataset(ctx, paths.testRepo.Filesystem(), ds); err != nil {
+			t.Fatal(err)
+		}
+		return ds
+	}
+	ds := load()
+
+	dir := paths.firstDir
+	if _, _, err = fsi.CreateLink(dir, "me/cities"); err != nil {
+		t.Fatal(err)
+	}
+	if err = SetLinkLayout(dir, LayoutSingleFile); err != nil {
+		t.Fatal(err)
+	}
+	if err = WriteComponents(ds, dir, paths.testRepo.Filesystem()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"meta.json", "structure.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("expected %s not to be written", name)
+		}
+	}
+	dsFile := filepath.Join(dir, component.DatasetFilename)
+	written, err := ioutil.ReadFile(dsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(written), "meta:\n") || !strings.Contains(string(written), "\nstructure:\n") {
+		t.Errorf("expected meta & structure sections, got:\n%s", written)
+	}
+
+	// rewriting unchanged components doesn't change the file
+	if err = WriteComponents(load(), dir, paths.testRepo.Filesystem()); err != nil {
+		t.Fatal(err)
+	}
+	if rewritten, _ := ioutil.ReadFile(dsFile); string(rewritten) != string(written) {
+		t.Errorf("expected rewriting to be stable, got:\n%s\nthen:\n%s", written, rewritten)
+	}
+
+	changes, err := fsi.Status(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ch := range changes {
+		if ch.Type != STUnmodified {
+			t.Errorf("expected %s to be unmodified, got: %s", ch.Component, ch.Type)
+		}
+		if (ch.Component == "meta" || ch.Component == "structure") && filepath.Base(ch.SourceFile) != component.DatasetFilename {
+			t.Errorf("expected %s to be read from %s, got: %s", ch.Component, component.DatasetFilename, ch.SourceFile)
+		}
+	}
+	if _, err := ReadDir(dir); err != nil {
+		t.Errorf("expected to read the working directory, got: %s", err)
+	}
+
+	// editing one section only changes that component
+	edited := strings.Replace(string(written), ds.Meta.Title, "a new title", 1)
+	if err = ioutil.WriteFile(dsFile, []byte(edited), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	changes, err = fsi.Status(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := map[string]string{}
+	for _, ch := range changes {
+		status[ch.Component] = ch.Type
+	}
+	if status["meta"] != STChange || status["structure"] != STUnmodified {
+		t.Errorf("expected only meta to be modified, got: %v", status)
+	}
+
+	// restoring a section rewrites it in place
+	comps := component.ConvertDatasetToComponents(ds, paths.testRepo.Filesystem())
+	comps.DropDerivedValues()
+	if _, err = WriteComponent(comps, "meta", dir); err != nil {
+		t.Fatal(err)
+	}
+	if restored, _ := ioutil.ReadFile(dsFile); string(restored) != string(written) {
+		t.Errorf("expected restored file to match the original, got:\n%s", restored)
+	}
+
+	// component files can't be mixed with dataset.yaml
+	if err = ioutil.WriteFile(filepath.Join(dir, "meta.json"), []byte(`{"title":"stray"}`), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	changes, err = fsi.Status(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(fmt.Sprintf("%s", changes), "meta conflict") {
+		t.Errorf("expected a meta conflict, got: %v", changes)
+	}
+	if _, err := ReadDir(dir); err == nil {
+		t.Error("expected reading a mixed layout to error")
+	}
+}
+
+func TestInitDatasetSingleFileLayout(t *testing.T) {
+	paths := NewTmpPaths()
+	defer paths.Close()
+
+	fsi := NewFSI(paths.testRepo, nil)
+	// test repos can share a logbook, keep the dataset name unique
+	name := "single_file_" + filepath.Base(paths.firstDir)
+	p := InitParams{Dir: paths.firstDir, Name: name, Format: "csv", Mkdir: "single_file", Layout: string(LayoutSingleFile)}
+	if _, err := fsi.InitDataset(p); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(paths.firstDir, "single_file")
+	if layout := GetLinkLayout(dir); layout != LayoutSingleFile {
+		t.Errorf("expected link to record layout %q, got: %q", LayoutSingleFile, layout)
+	}
+	for _, name := range []string{component.DatasetFilename, "body.csv"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to be written, got: %s", name, err)
+		}
+	}
+	for _, name := range []string{"meta.json", "structure.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("expected %s not to be written", name)
+		}
+	}
+	ds, err := ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Meta == nil || ds.Structure == nil || ds.Structure.Format != "csv" {
+		t.Errorf("expected meta & a csv structure to be read from %s, got: %v", component.DatasetFilename, ds)
+	}
+
+	p.Layout = "spreadsheet"
+	p.Name = "bad_layout_" + filepath.Base(paths.firstDir)
+	p.Mkdir = "bad_layout"
+	if _, err := fsi.InitDataset(p); err == nil {
+		t.Error("expected an unknown layout to error")
+	}
+}
@@ -20,6 +20,7 @@ func ReadDir(dir string) (*dataset.Dataset, error) {
 	if err != nil {
 		return nil, err
 	}
+	checkLayout(dir, components)
 	problems := GetProblems(components)
 	if problems != "" {
 		return nil, fmt.Errorf(problems)
@@ -53,7 +54,9 @@ func GetProblems(comp component.Component) string {
 	return problems
 }
 
-// WriteComponents writes components of the dataset to the given path, as individual files.
+// WriteComponents writes components of the dataset to the given path, as individual files,
+// or as sections of a single dataset.yaml if the directory is linked with a single file layout.
+// Directories checked out with a body range only get the rows of the body within the range.
 func WriteComponents(ds *dataset.Dataset, dirPath string, resolver qfs.Filesystem) error {
 	// TODO(dlong): In the future, use ListDirectoryComponents(dirPath) to figure out what
 	// files exist, project this component.Component onto those files. This will handle
@@ -63,6 +66,23 @@ func WriteComponents(ds *dataset.Dataset, dirPath string, resolver qfs.Filesyste
 	comp.Base().RemoveSubcomponent("commit")
 	comp.DropDerivedValues()
 
+	if rng := GetBodyRange(dirPath); rng != nil {
+		if body := comp.Base().GetSubcomponent("body"); body != nil {
+			if err := sliceBodyComponent(body, rng); err != nil {
+				return err
+			}
+		}
+	}
+
+	if GetLinkLayout(dirPath) == LayoutSingleFile {
+		if _, err := component.WriteDatasetFile(comp, dirPath); err != nil {
+			return err
+		}
+		for _, name := range component.DatasetFileSections() {
+			comp.Base().RemoveSubcomponent(name)
+		}
+	}
+
 	for _, compName := range component.AllSubcomponentNames() {
 		aComp := comp.Base().GetSubcomponent(compName)
 		if aComp != nil {
@@ -75,15 +95,27 @@ func WriteComponents(ds *dataset.Dataset, dirPath string, resolver qfs.Filesyste
 
 // WriteComponent writes the component with the given name to the directory
 func WriteComponent(comp component.Component, name string, dirPath string) (string, error) {
+	if isDatasetFileSection(name) && GetLinkLayout(dirPath) == LayoutSingleFile {
+		return writeDatasetFileSection(comp, name, dirPath)
+	}
 	aComp := comp.Base().GetSubcomponent(name)
 	if aComp == nil {
 		return "", nil
 	}
+	if rng := GetBodyRange(dirPath); rng != nil && name == "body" {
+		if err := sliceBodyComponent(aComp, rng); err != nil {
+			return "", err
+		}
+	}
 	return aComp.WriteTo(dirPath)
 }
 
 // DeleteComponent deletes the component with the given name from the directory
 func DeleteComponent(comp component.Component, name string, dirPath string) error {
+	if isDatasetFileSection(name) && GetLinkLayout(dirPath) == LayoutSingleFile {
+		_, err := writeDatasetFileSection(component.ConvertDatasetToComponents(&dataset.Dataset{}, nil), name, dirPath)
+		return err
+	}
 	aComp := comp.Base().GetSubcomponent(name)
 	if aComp == nil {
 		return nil
@@ -101,11 +101,20 @@ func (fsi *FSI) Status(ctx context.Context, dir string) (changes []StatusItem, e
 	if err != nil {
 		return nil, err
 	}
+	checkLayout(dir, working)
 
 	// TODO: If in the future we cache mtimes and previous status, we can more lazily read only
 	// some components.
 
 	prevComps := component.ConvertDatasetToComponents(stored, fsi.repo.Filesystem())
+	// directories checked out with a body range compare against those rows
+	if rng := GetBodyRange(dir); rng != nil {
+		if body := prevComps.Base().GetSubcomponent("body"); body != nil {
+			if err = sliceBodyComponent(body, rng); err != nil {
+				return nil, err
+			}
+		}
+	}
 	nextComps := working
 	return fsi.CalculateStateTransition(ctx, prevComps, nextComps)
 }
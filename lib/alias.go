@@ -0,0 +1,80 @@
+package lib
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// AliasParams configures adding & removing dataset name aliases
+type AliasParams struct {
+	// Name is the alias, a dataset name in your namespace
+	Name string
+	// Ref is the dataset the alias resolves to, only used when adding
+	Ref string
+}
+
+// AddAlias creates a local alternate name for a dataset in your namespace.
+// Aliases resolve wherever a dataset reference is accepted, but results always
+// use the dataset's name. Aliases can't shadow an existing dataset name, and
+// can't be published
+func (r *DatasetRequests) AddAlias(p *AliasParams, res *dsref.VersionInfo) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.AddAlias", p, res)
+	}
+	defer r.inst.lockWrites()()
+
+	if p.Ref == "" {
+		return fmt.Errorf("a dataset reference is required to add an alias")
+	}
+	ref, err := repo.ParseDatasetRef(p.Ref)
+	if err != nil {
+		return err
+	}
+	if ref.Path != "" {
+		return fmt.Errorf("aliases name a dataset, not a version")
+	}
+
+	a, err := base.AddAlias(r.node.Repo, p.Name, ref)
+	if err != nil {
+		return err
+	}
+	*res = r.aliasInfo(a)
+	return nil
+}
+
+// RemoveAlias deletes a dataset name alias, the dataset it resolves to is
+// untouched
+func (r *DatasetRequests) RemoveAlias(p *AliasParams, res *bool) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.RemoveAlias", p, res)
+	}
+	defer r.inst.lockWrites()()
+
+	if err = base.RemoveAlias(r.node.Repo, p.Name); err != nil {
+		return err
+	}
+	*res = true
+	return nil
+}
+
+// aliasInfo describes an alias as a list entry, with the alias name &
+// the head version of the dataset it resolves to
+func (r *DatasetRequests) aliasInfo(a repo.Alias) dsref.VersionInfo {
+	info := dsref.VersionInfo{Name: a.Name, AliasOf: a.Target}
+	pro, err := r.node.Repo.Profile()
+	if err != nil {
+		return info
+	}
+	info.Username = pro.Peername
+	info.ProfileID = pro.ID.String()
+	if ref, err := r.node.Repo.GetRef(reporef.DatasetRef{Peername: pro.Peername, Name: a.Target}); err == nil {
+		info.Path = ref.Path
+	}
+	return info
+}
@@ -0,0 +1,104 @@
+package lib
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsAlias(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	inst := NewInstanceFromConfigAndNode(config.DefaultConfigForTesting(), node)
+	dsr := NewDatasetRequestsInstance(inst)
+
+	info := dsref.VersionInfo{}
+	if err := dsr.AddAlias(&AliasParams{Name: "films", Ref: "me/movies"}, &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.AliasOf != "movies" || info.Path == "" {
+		t.Errorf("expected alias of movies at head, got: %#v", info)
+	}
+	if err := dsr.AddAlias(&AliasParams{Name: "cities", Ref: "me/movies"}, &info); !errors.Is(err, repo.ErrAlreadyExists) {
+		t.Errorf("expected alias shadowing a dataset to fail, got: %v", err)
+	}
+
+	got := &GetResult{}
+	if err := dsr.Get(&GetParams{Path: "me/films"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Ref.Name != "movies" {
+		t.Errorf("expected get through an alias to use the dataset name, got: %q", got.Ref.Name)
+	}
+
+	saved := &SaveResult{}
+	if err := dsr.Save(&SaveParams{Ref: "me/films", Dataset: &dataset.Dataset{Meta: &dataset.Meta{Title: "films"}}}, saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Name != "movies" {
+		t.Errorf("expected save through an alias to use the dataset name, got: %q", saved.Name)
+	}
+
+	infos := []dsref.VersionInfo{}
+	if err := dsr.List(&ListParams{Term: "films", Limit: 10}, &infos); err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Name != "films" || infos[0].AliasOf != "movies" {
+		t.Errorf("expected list to mark the alias, got: %#v", infos)
+	}
+
+	pub := &dsref.Ref{}
+	if err := NewRemoteMethods(inst).Publish(&PublicationParams{Ref: "me/films"}, pub); !errors.Is(err, repo.ErrAliasNotPublishable) {
+		t.Errorf("expected publishing an alias to fail, got: %v", err)
+	}
+
+	renamed := dsref.VersionInfo{}
+	p := &RenameParams{
+		Current:     dsref.Ref{Username: "me", Name: "films"},
+		Next:        dsref.Ref{Username: "me", Name: "cinema"},
+		CreateAlias: true,
+	}
+	if err := dsr.Rename(p, &renamed); err != nil {
+		t.Fatal(err)
+	}
+	if renamed.Name != "cinema" {
+		t.Errorf("expected rename to cinema, got: %q", renamed.Name)
+	}
+	for _, name := range []string{"films", "movies"} {
+		if target, ok := repo.AliasTarget(mr, "me", name); !ok || target != "cinema" {
+			t.Errorf("expected %s to alias cinema, got: %q %t", name, target, ok)
+		}
+	}
+
+	// renaming back to an alias takes the name back
+	p = &RenameParams{
+		Current: dsref.Ref{Username: "me", Name: "cinema"},
+		Next:    dsref.Ref{Username: "me", Name: "movies"},
+	}
+	if err := dsr.Rename(p, &renamed); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := repo.AliasTarget(mr, "me", "movies"); ok {
+		t.Error("expected renaming to an alias to remove the alias")
+	}
+
+	removed := false
+	if err := dsr.RemoveAlias(&AliasParams{Name: "films"}, &removed); err != nil {
+		t.Fatal(err)
+	}
+	if err := dsr.RemoveAlias(&AliasParams{Name: "films"}, &removed); err == nil {
+		t.Error("expected removing a missing alias to fail")
+	}
+}
@@ -0,0 +1,162 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/event"
+	"github.com/qri-io/qri/fsi"
+	"github.com/qri-io/qri/logbook"
+	"github.com/qri-io/qri/remote"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/qri-io/qri/watchfs"
+)
+
+// AutoPublishActions resolves the instance's auto publish rules to watch
+// actions that save a linked dataset when files in its working directory
+// change, then publish the new version. Rules that can't be resolved are
+// skipped
+func (inst *Instance) AutoPublishActions() []watchfs.Action {
+	cfg := inst.Config()
+	if cfg == nil || cfg.API == nil {
+		return nil
+	}
+
+	var actions []watchfs.Action
+	for _, rule := range cfg.API.AutoPublish {
+		ref, err := inst.autoPublishRef(rule)
+		if err != nil {
+			log.Errorf("auto publish %s: %s", autoPublishRuleName(rule), err)
+			continue
+		}
+		if _, err := remote.Address(cfg, rule.Remote); err != nil {
+			log.Errorf("auto publish %s: %s", autoPublishRuleName(rule), err)
+			continue
+		}
+		remoteName := rule.Remote
+		actions = append(actions, watchfs.Action{
+			Username: ref.Peername,
+			Dsname:   ref.Name,
+			Debounce: time.Duration(rule.DebounceMs) * time.Millisecond,
+			Func: func(e watchfs.FilesysEvent) error {
+				return inst.autoPublish(inst.ctx, ref, remoteName)
+			},
+		})
+	}
+	return actions
+}
+
+// autoPublishRef resolves the dataset an auto publish rule applies to
+func (inst *Instance) autoPublishRef(rule config.AutoPublishRule) (reporef.DatasetRef, error) {
+	refstr := rule.Ref
+	if refstr == "" {
+		linked, ok := fsi.GetLinkedFilesysRef(rule.Dir)
+		if !ok {
+			return reporef.DatasetRef{}, fmt.Errorf("%q is not a linked working directory", rule.Dir)
+		}
+		refstr = linked
+	}
+	ref, err := repo.ParseDatasetRef(refstr)
+	if err != nil {
+		return ref, err
+	}
+	ref.Path = ""
+	if err = repo.CanonicalizeDatasetRef(inst.Repo(), &ref); err != nil && err != repo.ErrNoHistory {
+		return ref, err
+	}
+	return ref, nil
+}
+
+func autoPublishRuleName(rule config.AutoPublishRule) string {
+	if rule.Ref != "" {
+		return rule.Ref
+	}
+	return rule.Dir
+}
+
+// autoPublish saves changes to the working directory of a dataset & publishes
+// the latest version to a remote, reporting the outcome on the event bus.
+// Versions the logbook records the remote as holding aren't published again
+func (inst *Instance) autoPublish(ctx context.Context, ref reporef.DatasetRef, remoteName string) error {
+	inst.autopubLk.Lock()
+	defer inst.autopubLk.Unlock()
+
+	e := event.AutoPublishEvent{
+		Username: ref.Peername,
+		Dsname:   ref.Name,
+		Remote:   remoteName,
+	}
+	path, err := inst.saveAndPublish(ctx, ref, remoteName)
+	if err != nil {
+		e.Action = event.AutoPublishFailed
+		e.Error = err.Error()
+		inst.autoPublishEvent(e)
+		return err
+	}
+	if path != "" {
+		e.Action = event.AutoPublishPublished
+		e.Path = path
+		inst.autoPublishEvent(e)
+	}
+	return nil
+}
+
+// saveAndPublish returns the path of the published version, or an empty
+// string if there was nothing to publish
+func (inst *Instance) saveAndPublish(ctx context.Context, ref reporef.DatasetRef, remoteName string) (string, error) {
+	addr, err := remote.Address(inst.Config(), remoteName)
+	if err != nil {
+		return "", err
+	}
+	if inst.RemoteClient() == nil {
+		return "", fmt.Errorf("instance can't publish, no remote client")
+	}
+
+	if err = repo.CanonicalizeDatasetRef(inst.Repo(), &ref); err != nil && err != repo.ErrNoHistory {
+		return "", err
+	}
+	if ref.FSIPath == "" {
+		return "", fsi.ErrNoLink
+	}
+
+	err = inst.fsi.IsWorkingDirectoryClean(ctx, ref.FSIPath)
+	if err == fsi.ErrWorkingDirectoryDirty {
+		saved := &SaveResult{}
+		p := &SaveParams{
+			Ref:      ref.AliasString(),
+			ReadFSI:  true,
+			WriteFSI: true,
+		}
+		if err = NewDatasetRequestsInstance(inst).Save(p, saved); err != nil {
+			return "", fmt.Errorf("saving: %s", err)
+		}
+		ref.Path = saved.Path
+	} else if err != nil {
+		return "", err
+	}
+	if ref.Path == "" {
+		return "", nil
+	}
+
+	published, err := inst.Repo().Logbook().PublishedDestinations(ctx, reporef.ConvertToDsref(ref))
+	if err != nil && err != logbook.ErrNoLogbook {
+		log.Debugf("reading publish destinations: %s", err)
+	}
+	if containsString(published, addr) {
+		return "", nil
+	}
+	if err = inst.attemptPublish(ctx, ref, addr); err != nil {
+		return "", fmt.Errorf("publishing: %s", err)
+	}
+	return ref.Path, nil
+}
+
+func (inst *Instance) autoPublishEvent(e event.AutoPublishEvent) {
+	if inst.bus == nil {
+		return
+	}
+	inst.bus.Publish(event.ETAutoPublishEvent, e)
+}
@@ -0,0 +1,485 @@
+package lib
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	blocks "github.com/ipfs/go-block-format"
+	cid "github.com/ipfs/go-cid"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	ipfs "github.com/qri-io/qfs/cafs/ipfs"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/car"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo/buildrepo"
+	fsrepo "github.com/qri-io/qri/repo/fs"
+	"github.com/qri-io/qri/repo/gen"
+)
+
+// BackupFormatVersion is the version of the backup archive format Backup
+// writes & RestoreBackup reads
+const BackupFormatVersion = 1
+
+// names of entries in a backup archive. files of the repo directory are stored
+// under backupRepoDir
+const (
+	backupManifestFile = "manifest.json"
+	backupConfigFile   = "config.yaml"
+	backupRepoDir      = "repo/"
+	backupBlocksFile   = "blocks.car"
+)
+
+// BackupParams configures a repo backup
+type BackupParams struct {
+	// RedactPrivateKeys leaves private keys out of the backed up config.
+	// Restoring a redacted backup requires the profile private key
+	RedactPrivateKeys bool
+}
+
+// BackupManifest describes a backup archive, it's the first entry of every
+// archive
+type BackupManifest struct {
+	FormatVersion int       `json:"formatVersion"`
+	Created       time.Time `json:"created"`
+	Peername      string    `json:"peername"`
+	ProfileID     string    `json:"profileID"`
+	// RedactedKeys is true when the config has no private keys
+	RedactedKeys bool `json:"redactedKeys,omitempty"`
+	// Files lists the files of the repo directory in the archive, relative to
+	// the repo directory
+	Files []string `json:"files"`
+	// Pins & DirectPins list the recursively & directly pinned roots of the
+	// block archive
+	Pins       []string `json:"pins"`
+	DirectPins []string `json:"directPins,omitempty"`
+	Blocks     int      `json:"blocks"`
+}
+
+// Backup writes an archive of the entire repo to w: the config, every file of
+// the repo directory (refs, logbook, working directory links & caches), and
+// each pinned block as a CAR. Writes to the repo wait until the backup
+// finishes so the archive is consistent. Backups require an IPFS store
+func (m *RepoMethods) Backup(p *BackupParams, w io.Writer) (*BackupManifest, error) {
+	if m.inst.rpc != nil {
+		return nil, ErrSnapshotOverRPC
+	}
+	ctx := context.TODO()
+
+	m.inst.writeLk.Lock()
+	defer m.inst.writeLk.Unlock()
+
+	node := m.inst.Node()
+	capi, err := node.IPFSCoreAPI()
+	if err != nil {
+		return nil, fmt.Errorf("backups require an IPFS store")
+	}
+	ipfsNode, err := node.IPFS()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := m.inst.Config()
+	if p.RedactPrivateKeys {
+		cfg = cfg.WithoutPrivateValues()
+	}
+	cfgData, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("encoding config: %s", err)
+	}
+
+	man := &BackupManifest{
+		FormatVersion: BackupFormatVersion,
+		Created:       time.Now().UTC(),
+		Peername:      cfg.Profile.Peername,
+		ProfileID:     cfg.Profile.ID,
+		RedactedKeys:  p.RedactPrivateKeys,
+	}
+
+	if man.Files, err = backupRepoFiles(m.inst.repoPath, cfg.Store.Path); err != nil {
+		return nil, fmt.Errorf("listing repo files: %s", err)
+	}
+
+	recursive, err := capi.Pin().Ls(ctx, options.Pin.Type.Recursive())
+	if err != nil {
+		return nil, fmt.Errorf("listing pins: %s", err)
+	}
+	direct, err := capi.Pin().Ls(ctx, options.Pin.Type.Direct())
+	if err != nil {
+		return nil, fmt.Errorf("listing pins: %s", err)
+	}
+	for _, pin := range recursive {
+		man.Pins = append(man.Pins, pin.Path().Cid().String())
+	}
+	for _, pin := range direct {
+		man.DirectPins = append(man.DirectPins, pin.Path().Cid().String())
+	}
+	sort.Strings(man.Pins)
+	sort.Strings(man.DirectPins)
+
+	// the tar header of the block archive needs its size, spool it first
+	blocksFile, err := ioutil.TempFile("", "qri_backup_blocks")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(blocksFile.Name())
+	defer blocksFile.Close()
+
+	if man.Blocks, err = writeBackupBlocks(ctx, node, ipfsNode.Blockstore.Get, man, blocksFile); err != nil {
+		return nil, err
+	}
+
+	tw := tar.NewWriter(w)
+	manData, err := json.MarshalIndent(man, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := base.WriteTarBytes(tw, backupManifestFile, manData); err != nil {
+		return nil, err
+	}
+	if err := base.WriteTarBytes(tw, backupConfigFile, cfgData); err != nil {
+		return nil, err
+	}
+	for _, name := range man.Files {
+		if err := writeBackupRepoFile(tw, m.inst.repoPath, name); err != nil {
+			return nil, err
+		}
+	}
+
+	size, err := blocksFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := blocksFile.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := base.WriteTarEntry(tw, backupBlocksFile, size, blocksFile); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	return man, nil
+}
+
+// backupRepoFiles lists the files of a repo directory to back up, skipping
+// the lockfile, the config, which is backed up separately, and the IPFS
+// store, which is backed up as blocks
+func backupRepoFiles(repoPath, storePath string) ([]string, error) {
+	skip := map[string]bool{
+		strings.TrimPrefix(fsrepo.Filepath(fsrepo.FileLockfile), "/"): true,
+		backupConfigFile: true,
+	}
+
+	var files []string
+	err := filepath.Walk(repoPath, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if storePath != "" && filepath.Clean(path) == filepath.Clean(storePath) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		name, err := filepath.Rel(repoPath, path)
+		if err != nil {
+			return err
+		}
+		if !skip[name] {
+			files = append(files, name)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// writeBackupBlocks writes every block of each pinned root as a CAR to w,
+// returning the number of blocks written
+func writeBackupBlocks(ctx context.Context, node *p2p.QriNode, get func(cid.Cid) (blocks.Block, error), man *BackupManifest, w io.Writer) (int, error) {
+	var roots []cid.Cid
+	for _, id := range append(append([]string{}, man.Pins...), man.DirectPins...) {
+		c, err := cid.Decode(id)
+		if err != nil {
+			return 0, err
+		}
+		roots = append(roots, c)
+	}
+
+	cw, err := car.NewWriter(w, roots)
+	if err != nil {
+		return 0, err
+	}
+
+	written := map[string]bool{}
+	put := func(id string) error {
+		if written[id] {
+			return nil
+		}
+		c, err := cid.Decode(id)
+		if err != nil {
+			return err
+		}
+		blk, err := get(c)
+		if err != nil {
+			return fmt.Errorf("reading block %s: %s", id, err)
+		}
+		written[id] = true
+		return cw.Put(c, blk.RawData())
+	}
+
+	for _, id := range man.Pins {
+		mf, err := node.NewManifest(ctx, "/ipfs/"+id)
+		if err != nil {
+			return 0, fmt.Errorf("listing blocks of %s: %s", id, err)
+		}
+		for _, blockID := range mf.Nodes {
+			if err := put(blockID); err != nil {
+				return 0, err
+			}
+		}
+	}
+	for _, id := range man.DirectPins {
+		if err := put(id); err != nil {
+			return 0, err
+		}
+	}
+	return len(written), nil
+}
+
+// writeBackupRepoFile archives a file of the repo directory
+func writeBackupRepoFile(tw *tar.Writer, repoPath, name string) error {
+	f, err := os.Open(filepath.Join(repoPath, name))
+	if err != nil {
+		return fmt.Errorf("reading repo file %s: %s", name, err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("reading repo file %s: %s", name, err)
+	}
+	return base.WriteTarEntry(tw, backupRepoDir+filepath.ToSlash(name), fi.Size(), f)
+}
+
+// RestoreBackupParams configures rebuilding a repo from a backup
+type RestoreBackupParams struct {
+	// QriRepoPath & IPFSFsPath are the directories to restore the qri repo &
+	// blocks into. Both must be empty or not exist
+	QriRepoPath string
+	IPFSFsPath  string
+	// PrivKey is the base64-encoded profile private key, required when the
+	// backup config has none
+	PrivKey string
+	// Generator creates the IPFS repo blocks are restored into
+	Generator gen.CryptoGenerator
+}
+
+// RestoreBackup rebuilds a repo from an archive written by Backup, verifying
+// the hash of every block. Restoring doesn't need an instance, it writes a
+// repo that can be opened with NewInstance
+func RestoreBackup(ctx context.Context, r io.Reader, p RestoreBackupParams) (*BackupManifest, error) {
+	if p.QriRepoPath == "" || p.IPFSFsPath == "" {
+		return nil, fmt.Errorf("qri repo & IPFS paths are required to restore a backup")
+	}
+	for _, dir := range []string{p.QriRepoPath, p.IPFSFsPath} {
+		if err := requireEmptyDir(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	tr := tar.NewReader(r)
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("reading backup: %s", err)
+	}
+	if hdr.Name != backupManifestFile {
+		return nil, fmt.Errorf("backup is missing a manifest")
+	}
+	man := &BackupManifest{}
+	if err := json.NewDecoder(tr).Decode(man); err != nil {
+		return nil, fmt.Errorf("reading backup manifest: %s", err)
+	}
+	if man.FormatVersion != BackupFormatVersion {
+		return nil, fmt.Errorf("unsupported backup format version %d", man.FormatVersion)
+	}
+
+	restoredBlocks := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading backup: %s", err)
+		}
+
+		switch {
+		case hdr.Name == backupConfigFile:
+			if err := restoreConfig(tr, p); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(hdr.Name, backupRepoDir):
+			if err := restoreRepoFile(p.QriRepoPath, strings.TrimPrefix(hdr.Name, backupRepoDir), tr); err != nil {
+				return nil, err
+			}
+		case hdr.Name == backupBlocksFile:
+			if err := restoreBlocks(ctx, tr, man, p); err != nil {
+				return nil, err
+			}
+			restoredBlocks = true
+		default:
+			return nil, fmt.Errorf("unexpected backup entry %q", hdr.Name)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(p.QriRepoPath, backupConfigFile)); err != nil {
+		return nil, fmt.Errorf("backup is missing a config")
+	}
+	if !restoredBlocks {
+		return nil, fmt.Errorf("backup is missing blocks")
+	}
+	return man, nil
+}
+
+func requireEmptyDir(dir string) error {
+	infos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return os.MkdirAll(dir, os.ModePerm)
+	} else if err != nil {
+		return err
+	}
+	if len(infos) > 0 {
+		return fmt.Errorf("can't restore into %s, directory isn't empty", dir)
+	}
+	return nil
+}
+
+// restoreConfig writes the backed up config to the restored repo, pointing
+// it at the restored IPFS repo
+func restoreConfig(r io.Reader, p RestoreBackupParams) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(p.QriRepoPath, backupConfigFile)
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	cfg, err := config.ReadFromFile(path)
+	if err != nil {
+		return fmt.Errorf("reading backup config: %s", err)
+	}
+
+	if cfg.Profile.PrivKey == "" {
+		if p.PrivKey == "" {
+			return fmt.Errorf("backup config has no private key, a private key is required to restore it")
+		}
+		cfg.Profile.PrivKey = p.PrivKey
+		cfg.P2P.PrivKey = p.PrivKey
+	}
+	cfg.Store.Path = p.IPFSFsPath
+	if cfg.Repo != nil && cfg.Repo.Path != "" {
+		cfg.Repo.Path = p.QriRepoPath
+	}
+	return cfg.WriteToFile(path)
+}
+
+func restoreRepoFile(repoPath, name string, r io.Reader) error {
+	name = filepath.FromSlash(name)
+	if filepath.IsAbs(name) || name != filepath.Clean(name) || strings.HasPrefix(name, "..") {
+		return fmt.Errorf("invalid repo file name in backup: %q", name)
+	}
+	path := filepath.Join(repoPath, name)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// restoreBlocks creates an IPFS repo holding the blocks of a CAR, pinning the
+// roots listed in the backup manifest
+func restoreBlocks(ctx context.Context, r io.Reader, man *BackupManifest, p RestoreBackupParams) error {
+	if p.Generator == nil {
+		return fmt.Errorf("a crypto generator is required to restore blocks")
+	}
+	if err := buildrepo.LoadIPFSPluginsOnce(p.IPFSFsPath); err != nil {
+		return err
+	}
+	if err := initIPFS(p.IPFSFsPath, nil, p.Generator); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	fst, err := ipfs.NewFilestore(func(c *ipfs.StoreCfg) {
+		c.Ctx = ctx
+		c.FsRepoPath = p.IPFSFsPath
+	})
+	if err != nil {
+		return err
+	}
+	ipfsNode := fst.Node()
+	// release the IPFS repo before returning so the restored repo can be opened
+	defer func() {
+		ipfsNode.Close()
+		ipfsNode.Repo.Close()
+	}()
+
+	cr, err := car.NewReader(r)
+	if err != nil {
+		return err
+	}
+	count := 0
+	for {
+		c, data, err := cr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("restoring blocks: %s", err)
+		}
+		blk, err := blocks.NewBlockWithCid(data, c)
+		if err != nil {
+			return err
+		}
+		if err := ipfsNode.Blockstore.Put(blk); err != nil {
+			return fmt.Errorf("storing block %s: %s", c, err)
+		}
+		count++
+	}
+	if count != man.Blocks {
+		return fmt.Errorf("backup lists %d blocks, archive has %d", man.Blocks, count)
+	}
+
+	for _, id := range man.Pins {
+		if err := fst.Pin(ctx, id, true); err != nil {
+			return fmt.Errorf("pinning %s: %s", id, err)
+		}
+	}
+	for _, id := range man.DirectPins {
+		if err := fst.Pin(ctx, id, false); err != nil {
+			return fmt.Errorf("pinning %s: %s", id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/dsref"
+	repotest "github.com/qri-io/qri/repo/test"
+)
+
+func TestBackupRestore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, err := repotest.NewTempRepo("backup_peer", "lib_backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Delete()
+
+	inst, err := NewInstance(ctx, r.QriPath, OptSetIPFSPath(r.IPFSPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dsm := NewDatasetRequestsInstance(inst)
+	for _, body := range []string{"a,b\n1,2\n", "a,b\n1,2\n3,4\n"} {
+		res := &SaveResult{}
+		p := &SaveParams{
+			Ref: "me/backed_up",
+			Dataset: &dataset.Dataset{
+				Meta:      &dataset.Meta{Title: "backed up"},
+				BodyPath:  "body.csv",
+				BodyBytes: []byte(body),
+			},
+		}
+		if err := dsm.Save(p, res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	privKey := inst.Config().Profile.PrivKey
+
+	buf := &bytes.Buffer{}
+	man, err := NewRepoMethods(inst).Backup(&BackupParams{RedactPrivateKeys: true}, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if man.Blocks == 0 || len(man.Pins) == 0 {
+		t.Errorf("expected backup to include pinned blocks, got: %#v", man)
+	}
+	inst.Teardown()
+
+	dir := filepath.Join(r.RootPath, "restored")
+	p := RestoreBackupParams{
+		QriRepoPath: filepath.Join(dir, "qri"),
+		IPFSFsPath:  filepath.Join(dir, "ipfs"),
+		Generator:   r.TestCrypto,
+	}
+	if _, err := RestoreBackup(context.Background(), bytes.NewReader(buf.Bytes()), p); err == nil {
+		t.Error("expected restoring a redacted backup without a private key to fail")
+	}
+	os.RemoveAll(dir)
+
+	p.PrivKey = privKey
+	if _, err := RestoreBackup(context.Background(), bytes.NewReader(buf.Bytes()), p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RestoreBackup(context.Background(), bytes.NewReader(buf.Bytes()), p); err == nil {
+		t.Error("expected restoring into a directory that isn't empty to fail")
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	restored, err := NewInstance(ctx, p.QriRepoPath, OptSetIPFSPath(p.IPFSFsPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := &CheckRepoResult{}
+	if err := NewRepoMethods(restored).Check(&CheckRepoParams{VerifyBlocks: true}, res); err != nil {
+		t.Fatal(err)
+	}
+	if problems := res.Problems(); len(problems) > 0 || len(res.OrphanedLogs) > 0 || res.OK != 1 {
+		t.Errorf("expected restored repo to check clean, got: %#v", res.RepoCheck)
+	}
+
+	items := []dsref.VersionInfo{}
+	if err := NewLogRequestsInstance(restored).Log(&LogParams{Ref: "me/backed_up"}, &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Errorf("expected restored history to have 2 versions, got: %d", len(items))
+	}
+}
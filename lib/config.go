@@ -38,7 +38,7 @@ func (m *ConfigMethods) GetConfig(p *GetConfigParams, res *[]byte) (err error) {
 	}
 
 	var (
-		cfg    = m.inst.cfg
+		cfg    = m.inst.Config()
 		encode interface{}
 	)
 
@@ -2,7 +2,10 @@ package lib
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/qri-io/qri/config"
@@ -97,3 +100,50 @@ func TestSetConfig(t *testing.T) {
 		t.Errorf("response mismatch. got %s", string(res))
 	}
 }
+
+func TestConfigConcurrentAccess(t *testing.T) {
+	cfg := config.DefaultConfigForTesting()
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := p2p.NewQriNode(mr, cfg.P2P)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inst := NewInstanceFromConfigAndNode(cfg, node)
+	cm := NewConfigMethods(inst)
+	pm := NewProfileMethods(inst)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+		go func(i int) {
+			defer wg.Done()
+			res := config.ProfilePod{}
+			if err := pm.SaveProfile(&config.ProfilePod{Name: fmt.Sprintf("name_%d", i)}, &res); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			res := []byte{}
+			if err := cm.GetConfig(&GetConfigParams{Format: "json"}, &res); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			var ok bool
+			if err := cm.SetConfig(inst.Config().Copy(), &ok); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if name := inst.Config().Profile.Name; !strings.HasPrefix(name, "name_") {
+		t.Errorf("expected a saved profile name, got: %q", name)
+	}
+}
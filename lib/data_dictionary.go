@@ -0,0 +1,97 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/dsfs"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// DefaultDataDictionaryExamples is the number of example values listed for
+// each column of a data dictionary if no number is given
+const DefaultDataDictionaryExamples = 3
+
+// DataDictionary is an alias for base.DataDictionary, a document describing
+// the name, type, description, example values & null rate of each column
+type DataDictionary = base.DataDictionary
+
+// DataDictionaryParams defines parameters for the DataDictionary method
+type DataDictionaryParams struct {
+	// Ref is the dataset to describe
+	Ref string
+	// Examples is the number of example values to list per column, defaults to
+	// DefaultDataDictionaryExamples
+	Examples int
+	// Save stores the dictionary in the dataset's meta component, saving a new
+	// version if the stored dictionary changes
+	Save bool
+}
+
+// DataDictionary combines the structure schema, column stats & meta of the
+// latest version of a dataset into a data dictionary
+func (r *DatasetRequests) DataDictionary(p *DataDictionaryParams, res *DataDictionary) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.DataDictionary", p, res)
+	}
+	ctx := context.TODO()
+
+	ref, head, err := r.loadHead(ctx, p.Ref)
+	if err != nil {
+		return err
+	}
+
+	statsRes := &StatsResponse{}
+	if err = r.Stats(&StatsParams{Ref: ref.String()}, statsRes); err != nil {
+		return err
+	}
+	colStats := []map[string]interface{}{}
+	if err = json.Unmarshal(statsRes.StatsBytes, &colStats); err != nil {
+		// stats that aren't a list of columns don't describe any columns
+		colStats = nil
+	}
+
+	if err = base.OpenDataset(ctx, r.node.Repo.Filesystem(), head); err != nil {
+		return err
+	}
+	examples := p.Examples
+	if examples == 0 {
+		examples = DefaultDataDictionaryExamples
+	}
+	dd, err := base.NewDataDictionary(head, colStats, examples)
+	if err != nil {
+		return err
+	}
+
+	if p.Save {
+		if ref, err = r.saveDataDictionary(ctx, ref, head, dd); err != nil {
+			return err
+		}
+	}
+	dd.Ref = ref.String()
+	*res = *dd
+	return nil
+}
+
+// saveDataDictionary stores a dictionary in the meta component of head,
+// returning the ref of the new version. Storing an unchanged dictionary
+// doesn't create a version, & returns the ref unchanged
+func (r *DatasetRequests) saveDataDictionary(ctx context.Context, ref reporef.DatasetRef, head *dataset.Dataset, dd *DataDictionary) (reporef.DatasetRef, error) {
+	patch, err := base.MetaPatch(head, map[string]interface{}{base.DataDictionaryMetaKey: dd})
+	if errors.Is(err, dsfs.ErrNoChanges) {
+		return ref, nil
+	} else if err != nil {
+		return ref, err
+	}
+
+	saved := reporef.DatasetRef{}
+	commit := &dataset.Commit{Title: "updated data dictionary"}
+	if err := r.saveHeadPatch(ctx, ref, head, patch, commit, &saved); err != nil {
+		return ref, err
+	}
+	return saved, nil
+}
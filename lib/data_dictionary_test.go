@@ -0,0 +1,65 @@
+package lib
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsDataDictionary(t *testing.T) {
+	ctx := context.Background()
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	inst := NewInstanceFromConfigAndNode(config.DefaultConfigForTesting(), node)
+	req := NewDatasetRequestsInstance(inst)
+
+	res := &DataDictionary{}
+	if err := req.DataDictionary(&DataDictionaryParams{Ref: "me/cities", Examples: 2}, res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Columns) != 4 {
+		t.Fatalf("expected 4 columns, got: %#v", res.Columns)
+	}
+	pop := res.Columns[1]
+	if pop.Name != "pop" || pop.Type != "integer" || pop.Count != 5 || pop.NullRate != 0 || len(pop.Examples) != 2 {
+		t.Errorf("unexpected pop column: %#v", pop)
+	}
+	prevRef := res.Ref
+
+	p := &DataDictionaryParams{Ref: "me/cities", Save: true}
+	if err := req.DataDictionary(p, res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Ref == prevRef {
+		t.Fatalf("expected saving a dictionary to create a new version, ref is still %s", res.Ref)
+	}
+	_, head, err := req.loadHead(ctx, "me/cities")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Meta == nil || head.Meta.Meta()[base.DataDictionaryMetaKey] == nil {
+		t.Errorf("expected the saved version to store the dictionary in meta, got: %#v", head.Meta)
+	}
+
+	savedRef := res.Ref
+	if err := req.DataDictionary(p, res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Ref != savedRef {
+		t.Errorf("expected saving an unchanged dictionary to keep version %s, got: %s", savedRef, res.Ref)
+	}
+
+	if err := req.DataDictionary(&DataDictionaryParams{Ref: "me/not_a_dataset"}, res); err == nil {
+		t.Errorf("expected a missing dataset to error")
+	}
+}
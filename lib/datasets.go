@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/ghodss/yaml"
 	"github.com/qri-io/dag"
@@ -19,15 +20,18 @@ import (
 	"github.com/qri-io/qfs"
 	"github.com/qri-io/qfs/localfs"
 	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/component"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/base/fill"
 	"github.com/qri-io/qri/dscache/build"
 	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/fsi"
 	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/remote"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/profile"
 	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/qri-io/qri/stats"
 )
 
 // DatasetRequests encapsulates business logic for working with Datasets on Qri
@@ -64,7 +68,8 @@ func NewDatasetRequestsInstance(inst *Instance) *DatasetRequests {
 }
 
 // List gets the reflist for either the local repo or a peer
-func (r *DatasetRequests) List(p *ListParams, res *[]dsref.VersionInfo) error {
+func (r *DatasetRequests) List(p *ListParams, res *[]dsref.VersionInfo) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.cli != nil {
 		p.RPC = true
 		return r.cli.Call("DatasetRequests.List", p, res)
@@ -95,6 +100,15 @@ func (r *DatasetRequests) List(p *ListParams, res *[]dsref.VersionInfo) error {
 		return err
 	}
 
+	var profileIDs []profile.ID
+	for _, idStr := range p.ProfileIDs {
+		id, err := profile.IDB58Decode(idStr)
+		if err != nil {
+			return fmt.Errorf("invalid profile ID %q: %s", idStr, err)
+		}
+		profileIDs = append(profileIDs, id)
+	}
+
 	var refs []reporef.DatasetRef
 	if p.UseDscache {
 		c := r.node.Repo.Dscache()
@@ -125,6 +139,22 @@ func (r *DatasetRequests) List(p *ListParams, res *[]dsref.VersionInfo) error {
 			}
 			refs = matched[:count]
 		}
+		// Filter references so that only those by a listed author are returned
+		if len(profileIDs) > 0 {
+			ids := make(map[profile.ID]bool, len(profileIDs))
+			for _, id := range profileIDs {
+				ids[id] = true
+			}
+			matched := make([]reporef.DatasetRef, len(refs))
+			count := 0
+			for _, ref := range refs {
+				if ids[ref.ProfileID] {
+					matched[count] = ref
+					count++
+				}
+			}
+			refs = matched[:count]
+		}
 		// Filter references by skipping to the correct offset
 		if p.Offset > len(refs) {
 			refs = []reporef.DatasetRef{}
@@ -136,8 +166,8 @@ func (r *DatasetRequests) List(p *ListParams, res *[]dsref.VersionInfo) error {
 			refs = refs[:p.Limit]
 		}
 		// TODO(dlong): Filtered by p.Published flag
-	} else if ref.Peername == "" || pro.Peername == ref.Peername {
-		refs, err = base.ListDatasets(ctx, r.node.Repo, p.Term, p.Limit, p.Offset, p.RPC, p.Published, p.ShowNumVersions)
+	} else if ref.Peername == "" || pro.Peername == ref.Peername || len(profileIDs) > 0 {
+		refs, err = base.ListDatasets(ctx, r.node.Repo, p.Term, profileIDs, p.Limit, p.Offset, p.RPC, p.Published, p.ShowNumVersions)
 	} else {
 
 		refs, err = r.inst.RemoteClient().ListDatasets(ctx, ref, p.Term, p.Offset, p.Limit)
@@ -173,8 +203,72 @@ func (r *DatasetRequests) List(p *ListParams, res *[]dsref.VersionInfo) error {
 	// Convert old style DatasetRef list to VersionInfo list.
 	// TODO(dlong): Remove this and convert lower-level functions to return []VersionInfo.
 	infos := make([]dsref.VersionInfo, len(refs))
-	for i, r := range refs {
-		infos[i] = reporef.ConvertToVersionInfo(&r)
+	for i, ref := range refs {
+		infos[i] = reporef.ConvertToVersionInfo(&ref)
+		if ref.Peername == pro.Peername {
+			infos[i].ForkOf = base.ForkOrigin(ctx, r.node.Repo, reporef.ConvertToDsref(ref))
+		} else if r.inst != nil {
+			if c, ok := r.inst.updates.get(ref.AliasString()); ok {
+				infos[i].UpdatesAvailable = c.UpdatesAvailable
+			}
+		}
+	}
+
+	if trash := base.TrashOf(r.node.Repo); p.ShowTrashed && trash != nil && (ref.Peername == "" || pro.Peername == ref.Peername) {
+		for _, e := range trash.List() {
+			if p.Term != "" && !strings.Contains(e.Ref.Name, p.Term) {
+				continue
+			}
+			info := reporef.ConvertToVersionInfo(&e.Ref)
+			info.Trashed = true
+			infos = append(infos, info)
+		}
+	}
+
+	if aliases := base.AliasesOf(r.node.Repo); aliases != nil && !p.Published && len(profileIDs) == 0 && (ref.Peername == "" || pro.Peername == ref.Peername) {
+		for _, a := range aliases.List() {
+			if p.Term != "" && !strings.Contains(a.Name, p.Term) {
+				continue
+			}
+			infos = append(infos, r.aliasInfo(a))
+		}
+	}
+
+	if p.WithSyncStatus {
+		if r.inst == nil {
+			return fmt.Errorf("listing sync status requires a remote client")
+		}
+		book := r.node.Repo.Logbook()
+		addr, err := remote.Address(r.inst.Config(), p.RemoteName)
+		var (
+			published []reporef.DatasetRef
+			statuses  []remote.SyncStatus
+		)
+		if err == nil {
+			published = publishedRefs(ctx, book, pro.Peername, refs, addr)
+			statuses, err = syncStatuses(ctx, r.inst, published, addr)
+		} else {
+			// without an address there's no telling which remote datasets are
+			// published to, every published dataset is unknown
+			published = publishedRefs(ctx, book, pro.Peername, refs, "")
+		}
+		states := make(map[string]remote.SyncState, len(published))
+		if err != nil {
+			// a remote that can't be used leaves statuses unknown, listing
+			// still succeeds
+			log.Debugf("comparing with remote %q: %s", p.RemoteName, err)
+			for _, ref := range published {
+				states[reporef.ConvertToDsref(ref).Alias()] = remote.SyncStateUnknown
+			}
+		}
+		for _, s := range statuses {
+			states[s.Ref] = s.State
+		}
+		for i := range infos {
+			if state, ok := states[infos[i].Alias()]; ok {
+				infos[i].SyncStatus = string(state)
+			}
+		}
 	}
 	*res = infos
 
@@ -183,6 +277,7 @@ func (r *DatasetRequests) List(p *ListParams, res *[]dsref.VersionInfo) error {
 
 // ListRawRefs gets the list of raw references as string
 func (r *DatasetRequests) ListRawRefs(p *ListParams, text *string) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.ListRawRefs", p, text)
 	}
@@ -199,20 +294,58 @@ func (r *DatasetRequests) ListRawRefs(p *ListParams, text *string) (err error) {
 	return err
 }
 
+// ListByTag lists datasets with a latest version tagged with a keyword in
+// its meta keywords, ignoring case. Matching refs include their latest version
+func (r *DatasetRequests) ListByTag(tag *string, res *[]reporef.DatasetRef) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.ListByTag", tag, res)
+	}
+	ctx := context.TODO()
+
+	*res, err = base.ListDatasetsByKeyword(ctx, r.node.Repo, *tag)
+	return err
+}
+
 // GetParams defines parameters for looking up the body of a dataset
 type GetParams struct {
 	// Path to get, this will often be a dataset reference like me/dataset
 	Path string
 
 	// read from a filesystem link instead of stored version
-	UseFSI       bool
+	UseFSI bool
+	// Format to encode results in. Bodies read without a format fall back
+	// to the dataset's preferred output format, then the stored body format,
+	// then json. see GetResult.FormatSource
 	Format       string
 	FormatConfig dataset.FormatConfig
 
 	Selector string
+	// Revision selects an earlier version to get, either a generation count
+	// from the latest version, an ISO date, or a version label
+	Revision string
 
 	Limit, Offset int
 	All           bool
+
+	// Columns projects body rows to the named columns, in the given order.
+	// Projection applies before paging & sampling
+	Columns []string
+
+	// Sample reads a sample of the body in place of a page, either
+	// base.SampleHead or base.SampleRandom. SampleSize is the number of
+	// entries to sample. Random samples taken with the same SampleSeed match
+	Sample     string
+	SampleSize int
+	SampleSeed int64
+
+	// StrictSignatures fails with ErrInvalidSignature if the version's commit
+	// signature is invalid. Otherwise invalid signatures are reported in
+	// GetResult.Signature & logged as a warning
+	StrictSignatures bool
+	// ExternalBody allows reading a body stored at an external URI. It's off
+	// by default, a version pulled from a peer can point its body at any URI
+	ExternalBody bool
 }
 
 // GetResult combines data with it's hashed path
@@ -220,6 +353,70 @@ type GetResult struct {
 	Ref     *reporef.DatasetRef `json:"ref"`
 	Dataset *dataset.Dataset    `json:"data"`
 	Bytes   []byte              `json:"bytes"`
+	// ForkOf is the version the dataset was forked from, if any
+	ForkOf string `json:"forkOf,omitempty"`
+	// Total is the number of entries in a sampled body, -1 if unknown
+	Total int `json:"total,omitempty"`
+	// Format is the format Bytes are encoded in. FormatSource says which
+	// fallback chose it when GetParams.Format is empty
+	Format       string `json:"format,omitempty"`
+	FormatSource string `json:"formatSource,omitempty"`
+	// Signature is the commit signature check of the version, nil when
+	// reading a working directory
+	Signature *SignatureVerification `json:"signature,omitempty"`
+	// VizTemplates are the version's named viz scripts, any of which can be
+	// rendered with RenderParams.VizName. nil when reading a working directory
+	VizTemplates dsfs.VizTemplates `json:"vizTemplates,omitempty"`
+}
+
+const (
+	// FormatSourceParam means GetParams.Format set the format
+	FormatSourceParam = "param"
+	// FormatSourceDataset means the format is the dataset's preferred output
+	// format, declared with the base.OutputFormatKey meta field
+	FormatSourceDataset = "dataset"
+	// FormatSourceStored means the body is read in the format it's stored in
+	FormatSourceStored = "stored"
+	// FormatSourceDefault means no other source set a format
+	FormatSourceDefault = "default"
+)
+
+// bodyFormat picks the format to read a body in. An explicit format comes
+// first, then the dataset's preferred format, then the stored body format,
+// and only then JSON
+func bodyFormat(p *GetParams, ds *dataset.Dataset) (dataset.DataFormat, string, error) {
+	if p.Format != "" {
+		df, err := dataset.ParseDataFormatString(p.Format)
+		return df, FormatSourceParam, err
+	}
+	if df := base.OutputFormat(ds); df != dataset.UnknownDataFormat {
+		return df, FormatSourceDataset, nil
+	}
+	if ds.Structure != nil {
+		if df, err := dataset.ParseDataFormatString(ds.Structure.Format); err == nil && df != dataset.UnknownDataFormat {
+			return df, FormatSourceStored, nil
+		}
+	}
+	// working directories without a structure store bodies in the format
+	// their file extension names
+	if df, err := dataset.ParseDataFormatString(filepath.Ext(ds.BodyPath)); err == nil && df != dataset.UnknownDataFormat {
+		return df, FormatSourceStored, nil
+	}
+	return dataset.JSONDataFormat, FormatSourceDefault, nil
+}
+
+// componentFormat picks the format to encode a dataset head or component in.
+// An explicit format comes first, then the dataset's preferred format if it's
+// json. Components can't be encoded in tabular formats, so the stored body
+// format doesn't apply, and the default stays yaml
+func componentFormat(p *GetParams, ds *dataset.Dataset) (string, string) {
+	if p.Format != "" {
+		return p.Format, FormatSourceParam
+	}
+	if base.OutputFormat(ds) == dataset.JSONDataFormat {
+		return "json", FormatSourceDataset
+	}
+	return "yaml", FormatSourceDefault
 }
 
 // Get retrieves datasets and components for a given reference. If p.Ref is provided, it is
@@ -228,12 +425,19 @@ type GetResult struct {
 // Using p.Selector will control what components are returned in res.Bytes. The default,
 // a blank selector, will also fill the entire dataset at res.Data. If the selector is "body"
 // then res.Bytes is loaded with the body. If the selector is "stats", then res.Bytes is loaded
-// with the generated stats.
+// with the generated stats. Selectors can start with a short component name, eg: "rm.script"
+// selects the same thing as "readme.script". Setting p.Sample with the "body" selector loads a
+// sample of the body instead of a page
 func (r *DatasetRequests) Get(p *GetParams, res *GetResult) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Get", p, res)
 	}
 	ctx := context.TODO()
+	if p.ExternalBody {
+		ctx = dsfs.AllowExternalBodies(ctx)
+	}
+	p.Selector = component.ExpandFieldAbbrev(p.Selector)
 
 	ref, err := base.ToDatasetRef(p.Path, r.node.Repo, p.UseFSI)
 	if err != nil {
@@ -241,6 +445,22 @@ func (r *DatasetRequests) Get(p *GetParams, res *GetResult) (err error) {
 		return err
 	}
 
+	if p.Revision != "" {
+		if p.UseFSI {
+			return fmt.Errorf("cannot select a revision of a working directory")
+		}
+		rev, err := dsref.ParseRev(p.Revision)
+		if err != nil {
+			return err
+		}
+		v, err := base.RevVersion(ctx, r.node.Repo, *ref, rev)
+		if err != nil {
+			log.Debugf("Get dataset, base.RevVersion %q failed, error: %s", p.Revision, err)
+			return err
+		}
+		ref.Path = v.Path
+	}
+
 	var ds *dataset.Dataset
 	if p.UseFSI {
 		if ref.FSIPath == "" {
@@ -259,35 +479,83 @@ func (r *DatasetRequests) Get(p *GetParams, res *GetResult) (err error) {
 		}
 	}
 
+	if !p.UseFSI {
+		res.Signature = verifyVersion(ctx, r.node, *ref, ds)
+		if p.StrictSignatures {
+			if err = res.Signature.Err(); err != nil {
+				return err
+			}
+		}
+	}
+
 	ds.Name = ref.Name
 	ds.Peername = ref.Peername
 	res.Ref = ref
 	res.Dataset = ds
+	res.ForkOf = base.ForkOrigin(ctx, r.node.Repo, reporef.ConvertToDsref(*ref))
 
 	if err = base.OpenDataset(ctx, r.node.Repo.Filesystem(), ds); err != nil {
 		log.Debugf("Get dataset, base.OpenDataset failed, error: %s", err)
 		return err
 	}
+	if p.Selector == "body" && ds.BodyFile() == nil {
+		if uri := dsfs.ExternalBodyURI(ds); uri != "" {
+			return fmt.Errorf("%w, body is at %s. set ExternalBody to read it", dsfs.ErrExternalBodyNotAllowed, uri)
+		}
+	}
 
-	if p.Selector == "body" {
+	if p.Selector == "body" && p.Sample != "" {
+		if p.UseFSI {
+			return fmt.Errorf("sampling a working directory body isn't supported")
+		}
+		df, source, err := bodyFormat(p, ds)
+		if err != nil {
+			return err
+		}
+		res.Format, res.FormatSource = df.String(), source
+		file := ds.BodyFile()
+		if file == nil {
+			return fmt.Errorf("no body file to read")
+		}
+		st := base.ReadStructure(ds.Structure, df, p.FormatConfig)
+		sample, err := base.SampleBody(file, ds.Structure, st, p.Columns, p.Sample, p.SampleSize, p.SampleSeed)
+		if err != nil {
+			log.Debugf("Get dataset, base.SampleBody %q failed, error: %s", ds, err)
+			return err
+		}
+		res.Bytes = sample.Data
+		res.Total = sample.Total
+		return nil
+	} else if p.Selector == "body" {
 		// `qri get body` loads the body
 		if !p.All && (p.Limit < 0 || p.Offset < 0) {
 			return fmt.Errorf("invalid limit / offset settings")
 		}
-		df, err := dataset.ParseDataFormatString(p.Format)
+		if strings.EqualFold(p.Format, base.ParquetFormat) {
+			if p.UseFSI {
+				return fmt.Errorf("reading a working directory body as %s isn't supported", base.ParquetFormat)
+			}
+			res.Format, res.FormatSource = base.ParquetFormat, FormatSourceParam
+			if res.Bytes, err = base.ReadParquetBody(ds, p.Columns, p.Limit, p.Offset, p.All); err != nil {
+				log.Debugf("Get dataset, base.ReadParquetBody %q failed, error: %s", ds, err)
+			}
+			return err
+		}
+		df, source, err := bodyFormat(p, ds)
 		if err != nil {
 			log.Debugf("Get dataset, ParseDataFormatString %q failed, error: %s", p.Format, err)
 			return err
 		}
+		res.Format, res.FormatSource = df.String(), source
 
 		var bufData []byte
 		if p.UseFSI {
-			if bufData, err = fsi.GetBody(ref.FSIPath, df, p.FormatConfig, p.Offset, p.Limit, p.All); err != nil {
+			if bufData, err = fsi.GetBody(ref.FSIPath, df, p.FormatConfig, p.Columns, p.Offset, p.Limit, p.All); err != nil {
 				log.Debugf("Get dataset, fsi.GetBody %q failed, error: %s", ref.FSIPath, err)
 				return err
 			}
 		} else {
-			if bufData, err = base.ReadBody(ds, df, p.FormatConfig, p.Limit, p.Offset, p.All); err != nil {
+			if bufData, err = base.ReadBody(ds, df, p.FormatConfig, p.Columns, p.Limit, p.Offset, p.All); err != nil {
 				log.Debugf("Get dataset, base.ReadBody %q failed, error: %s", ds, err)
 				return err
 			}
@@ -323,6 +591,12 @@ func (r *DatasetRequests) Get(p *GetParams, res *GetResult) (err error) {
 		res.Bytes = statsRes.StatsBytes
 		return
 	} else {
+		if !p.UseFSI && (p.Selector == "" || p.Selector == "viz") {
+			if res.VizTemplates, err = dsfs.LoadVizTemplates(ctx, r.node.Repo.Store(), ref.Path); err != nil {
+				log.Debugf("Get dataset, dsfs.LoadVizTemplates %q failed, error: %s", ref, err)
+				return fmt.Errorf("loading viz templates: %s", err)
+			}
+		}
 		var value interface{}
 		if p.Selector == "" {
 			// `qri get` without a selector loads only the dataset head
@@ -334,7 +608,9 @@ func (r *DatasetRequests) Get(p *GetParams, res *GetResult) (err error) {
 				return err
 			}
 		}
-		switch p.Format {
+		format, source := componentFormat(p, ds)
+		res.Format, res.FormatSource = format, source
+		switch format {
 		case "json":
 			// Pretty defaults to true for the dataset head, unless explicitly set in the config.
 			pretty := true
@@ -349,10 +625,10 @@ func (r *DatasetRequests) Get(p *GetParams, res *GetResult) (err error) {
 			} else {
 				res.Bytes, err = json.Marshal(value)
 			}
-		case "yaml", "":
+		case "yaml":
 			res.Bytes, err = yaml.Marshal(value)
 		default:
-			return fmt.Errorf("unknown format: \"%s\"", p.Format)
+			return fmt.Errorf("unknown format: \"%s\"", format)
 		}
 		return err
 	}
@@ -372,13 +648,45 @@ type SaveParams struct {
 	Message string
 	// path to body data
 	BodyPath string
+	// ExternalBody is a URI to read the body from on every save & read instead
+	// of storing it, see base.SaveDatasetSwitches.ExternalBody
+	ExternalBody string
+	// VizTemplates are named viz scripts to add to the dataset, by name. An
+	// empty script removes a template, see base.SaveDatasetSwitches.VizTemplates
+	VizTemplates map[string]string
 	// absolute path or URL to the list of dataset files or components to load
 	FilePaths []string
+	// name of the resource to read from a data package file with more than one
+	// resource
+	Resource string
+	// name of a dataset template from config to start the dataset from. all
+	// other params override components the template provides
+	Template string
+	// path to a file of custom validation rules to check the body against
+	// before committing. see base.LoadValidationRules for supported formats
+	ValidationRules string
+	// ValidationRulesData is the contents of a validation rules file. When set
+	// ValidationRules only names the file to pick its type, and isn't read
+	ValidationRulesData []byte
+	// EnforceReferences fails the save if values of a column declaring a
+	// reference to another dataset's key column are missing from that dataset
+	EnforceReferences bool
 	// secrets for transform execution
 	Secrets map[string]string
 	// optional writer to have transform script record standard output to
 	// note: this won't work over RPC, only on local calls
 	ScriptOutput io.Writer
+	// TransformRunID is included in the transform events published to the
+	// event bus while a transform runs, letting listeners follow output of
+	// one save as it's printed
+	TransformRunID string
+	// MaxBodySize is the largest body in bytes the save accepts, larger bodies
+	// fail with ErrBodyTooLarge. 0 means no limit
+	MaxBodySize int64
+	// RejectBreakingSchema fails the save with ErrBreakingSchemaChange if the
+	// body schema changes in a way that isn't backward compatible with the
+	// previous version, unless Force is set
+	RejectBreakingSchema bool
 
 	// load FSI-linked dataset before saving. anything provided in the Dataset
 	// field and any param field will override the FSI dataset
@@ -395,7 +703,8 @@ type SaveParams struct {
 	Private bool
 	// if true, set saved dataset to published
 	Publish bool
-	// run without saving, returning results
+	// run without saving, returning results. Dry runs still generate a commit
+	// title & message describing what would change
 	DryRun bool
 	// if true, res.Dataset.Body will be a fs.file of the body
 	ReturnBody bool
@@ -421,6 +730,10 @@ func (p *SaveParams) AbsolutizePaths() error {
 	}
 
 	for i := range p.FilePaths {
+		// URLs are fetched as given
+		if qfs.PathKind(p.FilePaths[i]) == "http" {
+			continue
+		}
 		if err := qfs.AbsPath(&p.FilePaths[i]); err != nil {
 			return err
 		}
@@ -432,13 +745,71 @@ func (p *SaveParams) AbsolutizePaths() error {
 	return nil
 }
 
+// Warning is a problem encountered working with a dataset that didn't stop
+// the work from being done
+type Warning = base.Warning
+
+// SaveResult is the outcome of a save, a reference to the saved dataset and
+// any warnings encountered saving it. Fields match reporef.DatasetRef, which
+// earlier releases send & expect as the result of a save over RPC, so results
+// decode on either side
+type SaveResult struct {
+	Peername  string           `json:"peername,omitempty"`
+	ProfileID profile.ID       `json:"profileID,omitempty"`
+	Name      string           `json:"name,omitempty"`
+	Path      string           `json:"path,omitempty"`
+	FSIPath   string           `json:"fsiPath,omitempty"`
+	Dataset   *dataset.Dataset `json:"dataset,omitempty"`
+	Published bool             `json:"published"`
+	Foreign   bool             `json:"foreign,omitempty"`
+	// Warnings are problems encountered saving the dataset that didn't stop it
+	// from being saved. Earlier releases send warnings as strings, so results
+	// with warnings only decode over RPC when both sides are on this release
+	Warnings []Warning `json:"warnings,omitempty"`
+}
+
+func newSaveResult(ref reporef.DatasetRef, warnings []Warning) SaveResult {
+	return SaveResult{
+		Peername:  ref.Peername,
+		ProfileID: ref.ProfileID,
+		Name:      ref.Name,
+		Path:      ref.Path,
+		FSIPath:   ref.FSIPath,
+		Dataset:   ref.Dataset,
+		Published: ref.Published,
+		Foreign:   ref.Foreign,
+		Warnings:  warnings,
+	}
+}
+
+// Ref returns the reference to the saved dataset
+func (r SaveResult) Ref() reporef.DatasetRef {
+	return reporef.DatasetRef{
+		Peername:  r.Peername,
+		ProfileID: r.ProfileID,
+		Name:      r.Name,
+		Path:      r.Path,
+		FSIPath:   r.FSIPath,
+		Dataset:   r.Dataset,
+		Published: r.Published,
+		Foreign:   r.Foreign,
+	}
+}
+
+// String implements the Stringer interface for SaveResult
+func (r SaveResult) String() string {
+	return r.Ref().String()
+}
+
 // Save adds a history entry, updating a dataset
 // TODO - need to make sure users aren't forking by referencing commits other than tip
-func (r *DatasetRequests) Save(p *SaveParams, res *reporef.DatasetRef) (err error) {
+func (r *DatasetRequests) Save(p *SaveParams, res *SaveResult) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Save", p, res)
 	}
 	ctx := context.TODO()
+	defer r.inst.lockWrites()()
 
 	if p.Private {
 		return fmt.Errorf("option to make dataset private not yet implemented, refer to https://github.com/qri-io/qri/issues/291 for updates")
@@ -454,6 +825,9 @@ func (r *DatasetRequests) Save(p *SaveParams, res *reporef.DatasetRef) (err erro
 	}
 
 	ds := &dataset.Dataset{}
+	// rows of the body checked out to a working directory after saving, for
+	// directories checked out with a body range
+	var bodyRange *fsi.BodyRange
 
 	if p.ReadFSI {
 		err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref)
@@ -468,6 +842,11 @@ func (r *DatasetRequests) Save(p *SaveParams, res *reporef.DatasetRef) (err erro
 		if err != nil {
 			return
 		}
+		if ref.Path != "" && p.BodyPath == "" {
+			if bodyRange, err = r.inst.fsi.SpliceBodyRange(ctx, ref.FSIPath, ref.Path, ds); err != nil {
+				return err
+			}
+		}
 	}
 
 	// add param-supplied changes
@@ -503,20 +882,36 @@ func (r *DatasetRequests) Save(p *SaveParams, res *reporef.DatasetRef) (err erro
 		ds = recall
 	}
 
+	var warnings []Warning
 	if len(p.FilePaths) > 0 {
 		// TODO (b5): handle this with a qfs.Filesystem
-		dsf, err := ReadDatasetFiles(p.FilePaths...)
+		dsf, warns, err := readDatasetFiles(p.Resource, p.FilePaths)
 		if err != nil {
 			return err
 		}
+		warnings = warns
 		dsf.Assign(ds)
 		ds = dsf
 	}
 
+	if p.Template != "" {
+		tmpl, err := datasetTemplate(r.inst, p.Template)
+		if err != nil {
+			return err
+		}
+		tmpl.Assign(ds)
+		ds = tmpl
+	}
+
 	if p.BodyPath == "" && ds.Name == "" {
 		return fmt.Errorf("name or bodypath is required")
 	}
+	if p.ExternalBody != "" && ds.BodyPath != "" {
+		return fmt.Errorf("can't save both a body path and an external body")
+	}
 	if !p.Force &&
+		p.ExternalBody == "" &&
+		len(p.VizTemplates) == 0 &&
 		ds.BodyPath == "" &&
 		ds.Body == nil &&
 		ds.BodyBytes == nil &&
@@ -525,7 +920,7 @@ func (r *DatasetRequests) Save(p *SaveParams, res *reporef.DatasetRef) (err erro
 		ds.Readme == nil &&
 		ds.Viz == nil &&
 		ds.Transform == nil {
-		return fmt.Errorf("no changes to save")
+		return fmt.Errorf("%w to save", ErrNoChanges)
 	}
 
 	if err = base.OpenDataset(ctx, r.node.Repo.Filesystem(), ds); err != nil {
@@ -543,28 +938,64 @@ func (r *DatasetRequests) Save(p *SaveParams, res *reporef.DatasetRef) (err erro
 	fsiPath := ref.FSIPath
 
 	switches := base.SaveDatasetSwitches{
-		Replace:             p.Replace,
-		DryRun:              p.DryRun,
-		Pin:                 true,
-		ConvertFormatToPrev: p.ConvertFormatToPrev,
-		Force:               p.Force,
-		ShouldRender:        p.ShouldRender,
-		NewName:             p.NewName,
-	}
-	ref, err = base.SaveDataset(ctx, r.node.Repo, r.node.LocalStreams, ds, p.Secrets, p.ScriptOutput, switches)
+		Replace:              p.Replace,
+		DryRun:               p.DryRun,
+		Pin:                  true,
+		ConvertFormatToPrev:  p.ConvertFormatToPrev,
+		Force:                p.Force,
+		ShouldRender:         p.ShouldRender,
+		NewName:              p.NewName,
+		EnforceReferences:    p.EnforceReferences,
+		MaxBodySize:          p.MaxBodySize,
+		RejectBreakingSchema: p.RejectBreakingSchema,
+		ExternalBody:         p.ExternalBody,
+		VizTemplates:         p.VizTemplates,
+	}
+	if p.ValidationRulesData != nil {
+		if switches.ValidationRules, err = base.ParseValidationRules(p.ValidationRules, p.ValidationRulesData); err != nil {
+			return err
+		}
+	} else if p.ValidationRules != "" {
+		if switches.ValidationRules, err = base.LoadValidationRules(p.ValidationRules); err != nil {
+			return err
+		}
+	}
+	scriptOut := p.ScriptOutput
+	var tfEvents *transformEventWriter
+	if ds.Transform != nil && r.inst != nil && r.inst.bus != nil {
+		tfEvents = newTransformEventWriter(r.inst.bus, p.TransformRunID, ds.Peername, ds.Name)
+		if scriptOut != nil {
+			scriptOut = io.MultiWriter(scriptOut, tfEvents)
+		} else {
+			scriptOut = tfEvents
+		}
+		tfEvents.started()
+	}
+	ref, warns, err := base.SaveDataset(ctx, r.node.Repo, r.node.LocalStreams, ds, p.Secrets, scriptOut, r.inst.withSaveHooks(switches))
+	if tfEvents != nil {
+		tfEvents.finish(ref.Path, err)
+	}
 	if err != nil {
 		log.Debugf("create ds error: %s\n", err.Error())
 		return err
 	}
+	warnings = append(warnings, warns...)
 
 	// TODO (b5) - this should be integrated into base.SaveDataset
-	if fsiPath != "" {
+	if fsiPath != "" && !p.DryRun {
 		ref.FSIPath = fsiPath
 		if err = r.node.Repo.PutRef(ref); err != nil {
 			return err
 		}
 	}
 
+	if !p.DryRun && supersedeQueuedPublish(r.node.Repo, ref) {
+		r.inst.kickPublishQueue()
+	}
+	if !p.DryRun {
+		enforcePinPolicy(ctx, r.node, ref)
+	}
+
 	if p.ReturnBody {
 		if err = base.InlineJSONBody(ref.Dataset); err != nil {
 			return err
@@ -585,7 +1016,13 @@ func (r *DatasetRequests) Save(p *SaveParams, res *reporef.DatasetRef) (err erro
 		}
 	}
 
-	*res = ref
+	*res = newSaveResult(ref, warnings)
+
+	if bodyRange != nil && !p.DryRun {
+		if err = fsi.SetBodyRange(fsiPath, bodyRange); err != nil {
+			return err
+		}
+	}
 
 	if p.WriteFSI {
 		// Need to pass filesystem here so that we can read the README component and write it
@@ -595,6 +1032,148 @@ func (r *DatasetRequests) Save(p *SaveParams, res *reporef.DatasetRef) (err erro
 	return nil
 }
 
+// UpdateMetaParams defines parameters for UpdateMeta
+type UpdateMetaParams struct {
+	// Ref is the dataset to update
+	Ref string
+	// Changes maps meta field names to new values, a nil value removes a field
+	Changes map[string]interface{}
+	// Title & Message set commit details for the new version. Title defaults
+	// to listing the updated fields
+	Title, Message string
+}
+
+// UpdateMeta saves a new version of a dataset that changes only the given
+// meta fields of the latest version, without needing the rest of the dataset
+func (r *DatasetRequests) UpdateMeta(p *UpdateMetaParams, res *reporef.DatasetRef) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.UpdateMeta", p, res)
+	}
+	ctx := context.TODO()
+
+	ref, head, err := r.loadHead(ctx, p.Ref)
+	if err != nil {
+		return err
+	}
+	patch, err := base.MetaPatch(head, p.Changes)
+	if err != nil {
+		return err
+	}
+
+	commit := &dataset.Commit{Title: p.Title, Message: p.Message}
+	if commit.Title == "" {
+		fields := make([]string, len(patch.Changes))
+		for i, c := range patch.Changes {
+			fields[i] = c.Key
+		}
+		commit.Title = fmt.Sprintf("updated metadata: %s", strings.Join(fields, ", "))
+	}
+	return r.saveHeadPatch(ctx, ref, head, patch, commit, res)
+}
+
+// UpdateColumnParams defines parameters for UpdateColumn
+type UpdateColumnParams struct {
+	// Ref is the dataset to update
+	Ref string
+	// Column is the name of the column to update
+	Column string
+	// Changes maps column schema keywords to new values, a nil value removes a
+	// keyword. Keys must be one of base.ColumnMetadataKeys
+	Changes map[string]interface{}
+	// Title & Message set commit details for the new version. Title defaults
+	// to naming the updated column
+	Title, Message string
+}
+
+// UpdateColumn saves a new version of a dataset that changes the metadata of
+// a single column: its title, description, unit, enumeration of allowed
+// values, or reference to a key column of another dataset
+func (r *DatasetRequests) UpdateColumn(p *UpdateColumnParams, res *reporef.DatasetRef) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.UpdateColumn", p, res)
+	}
+	ctx := context.TODO()
+
+	ref, head, err := r.loadHead(ctx, p.Ref)
+	if err != nil {
+		return err
+	}
+	if head.Structure == nil || head.Structure.Schema == nil {
+		return fmt.Errorf("dataset has no schema")
+	}
+	schema, err := base.SetColumnMetadata(head.Structure.Schema, p.Column, p.Changes)
+	if err != nil {
+		return err
+	}
+	patch, err := base.StructurePatch(head, map[string]interface{}{"schema": schema})
+	if err != nil {
+		return err
+	}
+
+	commit := &dataset.Commit{Title: p.Title, Message: p.Message}
+	if commit.Title == "" {
+		commit.Title = fmt.Sprintf("updated column metadata: %s", p.Column)
+	}
+	return r.saveHeadPatch(ctx, ref, head, patch, commit, res)
+}
+
+// loadHead loads the latest version of a dataset
+func (r *DatasetRequests) loadHead(ctx context.Context, refstr string) (reporef.DatasetRef, *dataset.Dataset, error) {
+	ref, err := repo.ParseDatasetRef(refstr)
+	if err != nil {
+		return ref, nil, err
+	}
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
+		if err == repo.ErrNoHistory {
+			return ref, nil, fmt.Errorf("dataset has no versions, nothing to update")
+		}
+		return ref, nil, err
+	}
+	head, err := dsfs.LoadDataset(ctx, r.node.Repo.Store(), ref.Path)
+	if err != nil {
+		return ref, nil, err
+	}
+	head.Peername = ref.Peername
+	head.Name = ref.Name
+	return ref, head, nil
+}
+
+// saveHeadPatch applies a patch to the latest version of a dataset & saves
+// the result as a new version, keeping any working directory link
+func (r *DatasetRequests) saveHeadPatch(ctx context.Context, ref reporef.DatasetRef, head *dataset.Dataset, patch *base.Patch, commit *dataset.Commit, res *reporef.DatasetRef) error {
+	defer r.inst.lockWrites()()
+
+	ds, err := base.ApplyPatch(ctx, r.node.Repo.Store(), head, patch)
+	if err != nil {
+		return err
+	}
+	ds.Commit = commit
+	if err = base.OpenDataset(ctx, r.node.Repo.Filesystem(), ds); err != nil {
+		return err
+	}
+
+	switches := base.SaveDatasetSwitches{
+		Replace:      true,
+		Pin:          true,
+		ShouldRender: true,
+	}
+	saved, _, err := base.SaveDataset(ctx, r.node.Repo, r.node.LocalStreams, ds, nil, nil, r.inst.withSaveHooks(switches))
+	if err != nil {
+		return err
+	}
+
+	if ref.FSIPath != "" {
+		saved.FSIPath = ref.FSIPath
+		if err = r.node.Repo.PutRef(saved); err != nil {
+			return err
+		}
+	}
+	*res = saved
+	return nil
+}
+
 // SetPublishStatusParams encapsulates parameters for setting the publication status of a dataset
 type SetPublishStatusParams struct {
 	Ref           string
@@ -605,6 +1184,7 @@ type SetPublishStatusParams struct {
 
 // SetPublishStatus updates the publicity of a reference in the peer's namespace
 func (r *DatasetRequests) SetPublishStatus(p *SetPublishStatusParams, publishedRef *reporef.DatasetRef) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.SetPublishStatus", p, publishedRef)
 	}
@@ -613,6 +1193,11 @@ func (r *DatasetRequests) SetPublishStatus(p *SetPublishStatusParams, publishedR
 	if err != nil {
 		return err
 	}
+	if p.PublishStatus {
+		if err = base.RejectAlias(r.node.Repo, ref); err != nil {
+			return err
+		}
+	}
 	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
 		return err
 	}
@@ -629,25 +1214,59 @@ func (r *DatasetRequests) SetPublishStatus(p *SetPublishStatusParams, publishedR
 // RenameParams defines parameters for Dataset renaming
 type RenameParams struct {
 	Current, Next dsref.Ref
+	// CreateAlias keeps the current name working as an alias of the new name
+	CreateAlias bool
 }
 
 // Rename changes a user's given name for a dataset
 func (r *DatasetRequests) Rename(p *RenameParams, res *dsref.VersionInfo) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Rename", p, res)
 	}
 	ctx := context.TODO()
+	defer r.inst.lockWrites()()
 
 	if p.Current.IsEmpty() {
 		return fmt.Errorf("current name is required to rename a dataset")
 	}
 
+	// Resolve aliases to the dataset name. Renaming a dataset to one of its own
+	// aliases takes the name back from the alias
+	aliases := base.AliasesOf(r.node.Repo)
+	if target, ok := repo.AliasTarget(r.node.Repo, p.Current.Username, p.Current.Name); ok {
+		p.Current.Name = target
+	}
+	if target, ok := repo.AliasTarget(r.node.Repo, p.Next.Username, p.Next.Name); ok && target == p.Current.Name {
+		if err = aliases.Delete(p.Next.Name); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				if putErr := aliases.Put(p.Next.Name, target); putErr != nil {
+					log.Debugf("Rename: restoring alias %q: %s", p.Next.Name, putErr)
+				}
+			}
+		}()
+	}
+
 	// Update the reference stored in the repo
 	info, err := base.ModifyDatasetRef(ctx, r.node.Repo, p.Current, p.Next)
 	if err != nil {
 		return err
 	}
 
+	if aliases != nil && info.Name != p.Current.Name {
+		if err = aliases.Retarget(p.Current.Name, info.Name); err != nil {
+			return err
+		}
+		if p.CreateAlias {
+			if err = aliases.Put(p.Current.Name, info.Name); err != nil {
+				return err
+			}
+		}
+	}
+
 	// If the dataset is linked to a working directory, update the ref
 	if info.FSIPath != "" {
 		if err = r.inst.fsi.ModifyLinkReference(info.FSIPath, info.Alias()); err != nil {
@@ -675,12 +1294,61 @@ func (r *DatasetRequests) Rename(p *RenameParams, res *dsref.VersionInfo) (err e
 	return nil
 }
 
+// ForkParams defines parameters for forking a dataset
+type ForkParams struct {
+	// Source is a reference to the dataset to fork, forks the latest version
+	// unless the reference includes a path
+	Source string
+	// Name is the name of the new dataset in your namespace
+	Name string
+}
+
+// Fork creates a new dataset from a version of an existing dataset, recording
+// the source version as the new dataset's origin. The source body is written
+// again, which the content-addressed store resolves to the blocks the source
+// already stores
+func (r *DatasetRequests) Fork(p *ForkParams, res *reporef.DatasetRef) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Fork", p, res)
+	}
+	ctx := context.TODO()
+	defer r.inst.lockWrites()()
+
+	if p.Name == "" {
+		return fmt.Errorf("name is required to fork a dataset")
+	}
+
+	source, err := repo.ParseDatasetRef(p.Source)
+	if err != nil {
+		return err
+	}
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &source); err != nil {
+		if err == repo.ErrNoHistory {
+			return fmt.Errorf("dataset has no versions, nothing to fork")
+		}
+		// versions of datasets we don't have a reference to can be forked by path
+		if !repo.IsNotFound(err) || source.Path == "" {
+			return err
+		}
+	}
+
+	ref, err := base.ForkDataset(ctx, r.node.Repo, r.node.LocalStreams, source, p.Name)
+	if err != nil {
+		return err
+	}
+	*res = ref
+	return nil
+}
+
 // RemoveParams defines parameters for remove command
 type RemoveParams struct {
 	Ref       string
 	Revision  dsref.Rev
 	KeepFiles bool
 	Force     bool
+	// Permanent skips the trash when removing all versions of a dataset
+	Permanent bool
 }
 
 // RemoveResponse gives the results of a remove
@@ -689,21 +1357,26 @@ type RemoveResponse struct {
 	NumDeleted int
 	Message    string
 	Unlinked   bool
+	// Trashed is true if the dataset was moved to the trash, instead of being
+	// permanently deleted
+	Trashed bool
 }
 
 // ErrCantRemoveDirectoryDirty is returned when a directory is dirty so the files cant' be removed
 var ErrCantRemoveDirectoryDirty = fmt.Errorf("cannot remove files while working directory is dirty")
 
 // Remove a dataset entirely or remove a certain number of revisions
-func (r *DatasetRequests) Remove(p *RemoveParams, res *RemoveResponse) error {
+func (r *DatasetRequests) Remove(p *RemoveParams, res *RemoveResponse) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Remove", p, res)
 	}
 	ctx := context.TODO()
+	defer r.inst.lockWrites()()
 
 	log.Debugf("Remove dataset ref %q, revisions %v", p.Ref, p.Revision)
 
-	if p.Revision.Gen == 0 {
+	if p.Revision.Gen == 0 && !p.Revision.Absolute() {
 		return fmt.Errorf("invalid number of revisions to delete: 0")
 	}
 
@@ -730,6 +1403,18 @@ func (r *DatasetRequests) Remove(p *RemoveParams, res *RemoveResponse) error {
 	}
 	res.Ref = ref.String()
 
+	if p.Revision.Absolute() {
+		// remove the versions that come after the version a label or date selects
+		sel := p.Revision
+		if err := base.ResolveRevs(ctx, r.node.Repo, ref, []*dsref.Rev{&sel}); err != nil {
+			return err
+		}
+		if sel.Gen == 1 {
+			return fmt.Errorf("revision selects the latest version of %s, there are no newer versions to remove", ref.AliasString())
+		}
+		p.Revision.Gen = sel.Gen - 1
+	}
+
 	if ref.FSIPath != "" {
 		// Dataset is linked in a working directory.
 		if !(p.KeepFiles || p.Force) {
@@ -776,6 +1461,14 @@ func (r *DatasetRequests) Remove(p *RemoveParams, res *RemoveResponse) error {
 	}
 
 	if p.Revision.Gen == dsref.AllGenerations {
+		toTrash := !p.Permanent && ref.Path != "" && r.inst.trashRetention() >= 0 && base.TrashOf(r.inst.Repo()) != nil
+		if toTrash {
+			// check before unlinking, a dataset that can't be trashed is left as-is
+			if err := base.CheckTrashable(r.inst.Repo(), ref); err != nil {
+				return err
+			}
+		}
+
 		// removing all revisions of a dataset must unlink it
 		if ref.FSIPath != "" {
 			if err := r.inst.fsi.Unlink(ref.FSIPath, ref.AliasString()); err == nil {
@@ -785,9 +1478,19 @@ func (r *DatasetRequests) Remove(p *RemoveParams, res *RemoveResponse) error {
 			}
 		}
 
-		didRemove, _ := base.RemoveEntireDataset(ctx, r.inst.Repo(), reporef.ConvertToDsref(ref), history)
+		if toTrash {
+			// keep versions pinned until the trash is emptied, so the dataset can be restored
+			if _, err := base.TrashDataset(ctx, r.inst.Repo(), ref); err != nil {
+				log.Debugf("Remove, base.TrashDataset failed, error: %s", err)
+				return err
+			}
+			res.Trashed = true
+			res.Message = "trash"
+		} else {
+			didRemove, _ := base.RemoveEntireDataset(ctx, r.inst.Repo(), reporef.ConvertToDsref(ref), history)
+			res.Message = didRemove
+		}
 		res.NumDeleted = dsref.AllGenerations
-		res.Message = didRemove
 
 		if ref.FSIPath != "" && !p.KeepFiles {
 			// Remove all files
@@ -861,10 +1564,14 @@ type AddParams struct {
 	LinkDir    string
 	RemoteAddr string // remote to attempt to pull from
 	LogsOnly   bool   // only fetch logbook data
+	// LocalName is the name to add a dataset under when Ref is a bare version
+	// path like /ipfs/Qm...
+	LocalName string
 }
 
 // Add adds an existing dataset to a peer's repository
 func (r *DatasetRequests) Add(p *AddParams, res *reporef.DatasetRef) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if err = qfs.AbsPath(&p.LinkDir); err != nil {
 		return
 	}
@@ -873,16 +1580,34 @@ func (r *DatasetRequests) Add(p *AddParams, res *reporef.DatasetRef) (err error)
 		return r.cli.Call("DatasetRequests.Add", p, res)
 	}
 	ctx := context.TODO()
+	defer r.inst.lockWrites()()
+
+	if p.RemoteAddr == "" && r.inst != nil {
+		if cfg := r.inst.Config(); cfg.Registry != nil {
+			p.RemoteAddr = cfg.Registry.Location
+		}
+	}
+
+	// dataset names can't start with a slash, Ref is a bare version path like
+	// /ipfs/Qm...
+	if strings.HasPrefix(p.Ref, "/") {
+		if p.LogsOnly {
+			return fmt.Errorf("cannot fetch only logs for a version path, logs are named by dataset")
+		}
+		ref, err := base.AddVersionByPath(ctx, r.node.Repo, p.Ref, p.LocalName, nodeAuthorKey(r.node))
+		if err != nil {
+			return err
+		}
+		r.pullVersionLogs(ctx, ref, p.RemoteAddr)
+		*res = ref
+		return r.checkoutAdded(ref, p.LinkDir)
+	}
 
 	ref, err := repo.ParseDatasetRef(p.Ref)
 	if err != nil {
 		return err
 	}
 
-	if p.RemoteAddr == "" && r.inst != nil && r.inst.cfg.Registry != nil {
-		p.RemoteAddr = r.inst.cfg.Registry.Location
-	}
-
 	mergeLogsError := r.inst.RemoteClient().CloneLogs(ctx, reporef.ConvertToDsref(ref), p.RemoteAddr)
 	if p.LogsOnly {
 		return mergeLogsError
@@ -893,20 +1618,61 @@ func (r *DatasetRequests) Add(p *AddParams, res *reporef.DatasetRef) (err error)
 	}
 
 	*res = ref
+	return r.checkoutAdded(ref, p.LinkDir)
+}
 
-	if p.LinkDir != "" {
-		checkoutp := &CheckoutParams{
-			Ref: ref.String(),
-			Dir: p.LinkDir,
-		}
-		m := NewFSIMethods(r.inst)
-		checkoutRes := ""
-		if err = m.Checkout(checkoutp, &checkoutRes); err != nil {
-			return err
-		}
+// checkoutAdded links an added dataset to dir, if dir is provided
+func (r *DatasetRequests) checkoutAdded(ref reporef.DatasetRef, dir string) error {
+	if dir == "" {
+		return nil
+	}
+	checkoutp := &CheckoutParams{
+		Ref: ref.String(),
+		Dir: dir,
 	}
+	m := NewFSIMethods(r.inst)
+	checkoutRes := ""
+	return m.Checkout(checkoutp, &checkoutRes)
+}
 
-	return nil
+// pullVersionLogs attempts to clone logs for a dataset added by version path.
+// Logs are looked up by the name the version records, which is the name the
+// remote knows the dataset by. A remote's logs are only cloned if they include
+// the added version, and failures are tolerated, a version added by path may
+// have no logs at all
+func (r *DatasetRequests) pullVersionLogs(ctx context.Context, ref reporef.DatasetRef, remoteAddr string) {
+	if r.inst == nil || r.inst.RemoteClient() == nil || remoteAddr == "" {
+		return
+	}
+	if ref.Dataset == nil || ref.Dataset.Name == "" {
+		log.Debugf("version %s doesn't record a dataset name, skipping logs", ref.Path)
+		return
+	}
+	remoteRef := dsref.Ref{
+		Username:  ref.Peername,
+		ProfileID: ref.ProfileID.String(),
+		Name:      ref.Dataset.Name,
+	}
+	// logs are named by dataset, a version added under another name can't
+	// take on the remote's history
+	if remoteRef.Name != ref.Name {
+		log.Debugf("version %s was added as %s, not %s, skipping logs", ref.Path, ref.Name, remoteRef.Name)
+		return
+	}
+	versions, err := remote.FetchVersions(ctx, r.inst.RemoteClient(), remoteRef, remoteAddr)
+	if err != nil {
+		log.Debugf("fetching logs for %s: %s", remoteRef, err)
+		return
+	}
+	for _, v := range versions {
+		if v.Path == ref.Path {
+			if err := r.inst.RemoteClient().CloneLogs(ctx, remoteRef, remoteAddr); err != nil {
+				log.Debugf("cloning logs for %s: %s", remoteRef, err)
+			}
+			return
+		}
+	}
+	log.Debugf("logs for %s don't include version %s, skipping", remoteRef.Alias(), ref.Path)
 }
 
 // ValidateDatasetParams defines parameters for dataset
@@ -918,10 +1684,15 @@ type ValidateDatasetParams struct {
 	SchemaFilename    string
 	StructureFilename string
 	UseFSI            bool
+	// SchemaDraft is the JSON Schema draft to validate against, eg: "draft-07".
+	// Drafts validation doesn't implement are an error. Empty validates
+	// without checking the draft
+	SchemaDraft string
 }
 
 // Validate gives a dataset of errors and issues for a given dataset
 func (r *DatasetRequests) Validate(p *ValidateDatasetParams, errors *[]jsonschema.ValError) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Validate", p, errors)
 	}
@@ -956,8 +1727,8 @@ func (r *DatasetRequests) Validate(p *ValidateDatasetParams, errors *[]jsonschem
 	}
 	err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref)
 	if err != nil && err != repo.ErrEmptyRef {
-		if err == repo.ErrNotFound {
-			return fmt.Errorf("cannot find dataset: %s", ref)
+		if repo.IsNotFound(err) {
+			return fmt.Errorf("cannot find dataset: %s%s", ref, didYouMean(err))
 		}
 		return err
 	}
@@ -1038,12 +1809,337 @@ func (r *DatasetRequests) Validate(p *ValidateDatasetParams, errors *[]jsonschem
 		}
 	}
 
+	if p.SchemaDraft != "" {
+		if err = base.CheckSchemaDraft(st, p.SchemaDraft); err != nil {
+			return NewError(ErrBadArgs, err.Error())
+		}
+	}
+
 	*errors, err = base.Validate(ctx, r.node.Repo, body, st)
 	return
 }
 
+// ValidateNamespaceParams defines parameters for validating every dataset in
+// a namespace
+type ValidateNamespaceParams struct {
+	// Peername of the namespace to validate, defaults to your own
+	Peername string
+	// Parallelism caps how many datasets are validated at once, defaults to
+	// the number of CPUs
+	Parallelism int
+}
+
+// NamespaceValidationResult is the result of validating a single dataset
+type NamespaceValidationResult = base.NamespaceValidation
+
+// ValidateNamespace checks the head version of every dataset in a namespace
+// against its own schema. Datasets that can't be validated record an error in
+// their result instead of failing the whole call
+func (r *DatasetRequests) ValidateNamespace(p *ValidateNamespaceParams, res *[]NamespaceValidationResult) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.ValidateNamespace", p, res)
+	}
+	ctx := context.TODO()
+
+	peername := p.Peername
+	if peername == "" || peername == "me" {
+		pro, err := r.node.Repo.Profile()
+		if err != nil {
+			return err
+		}
+		peername = pro.Peername
+	}
+
+	results, err := base.ValidateNamespace(ctx, r.node.Repo, peername, p.Parallelism)
+	if err != nil {
+		return err
+	}
+	*res = results
+	return nil
+}
+
+// RuleViolation is a single failure of a validation rule or reference
+type RuleViolation = base.RuleViolation
+
+// ValidateReferences checks the referential integrity of a dataset, loading
+// each dataset its columns reference & listing values missing from the
+// referenced key columns. A dataset with intact references has no violations
+func (r *DatasetRequests) ValidateReferences(refstr *string, res *[]RuleViolation) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.ValidateReferences", refstr, res)
+	}
+	ctx := context.TODO()
+
+	ref, err := repo.ParseDatasetRef(*refstr)
+	if err != nil {
+		return invalidRefError(*refstr)
+	}
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
+		return err
+	}
+	ds, err := dsfs.LoadDataset(ctx, r.node.Repo.Store(), ref.Path)
+	if err != nil {
+		return fmt.Errorf("loading dataset: %s", err)
+	}
+	if ds.Structure == nil {
+		return fmt.Errorf("%s has no structure", ref.AliasString())
+	}
+	refs, err := base.SchemaReferences(ds.Structure.Schema)
+	if err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		return fmt.Errorf("%s doesn't declare any references", ref.AliasString())
+	}
+	body, err := dsfs.LoadBody(ctx, r.node.Repo.Store(), ds)
+	if err != nil {
+		return fmt.Errorf("loading body: %s", err)
+	}
+	defer body.Close()
+	ds.SetBodyFile(body)
+
+	err = base.ValidateReferences(ctx, r.node.Repo, ds)
+	if violations, ok := err.(base.RuleViolations); ok {
+		*res = violations
+		return nil
+	} else if err != nil {
+		return err
+	}
+	*res = []RuleViolation{}
+	return nil
+}
+
+// SchemaResult is the JSON schema of a dataset
+type SchemaResult struct {
+	// Ref is the dataset the schema belongs to
+	Ref string `json:"ref"`
+	// Schema is kept encoded so it passes over RPC intact
+	Schema json.RawMessage `json:"schema"`
+}
+
+// GetSchema reads the schema of a dataset without loading the body. Datasets
+// linked to a working directory read the schema from the working directory,
+// unless refstr names a version path
+func (r *DatasetRequests) GetSchema(refstr *string, res *SchemaResult) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.GetSchema", refstr, res)
+	}
+	ctx := context.TODO()
+
+	ref, err := repo.ParseDatasetRef(*refstr)
+	if err != nil {
+		return invalidRefError(*refstr)
+	}
+	versionPath := ref.Path
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil && err != repo.ErrNoHistory {
+		return err
+	}
+
+	var ds *dataset.Dataset
+	if ref.FSIPath != "" && versionPath == "" {
+		if ds, err = fsi.ReadDir(ref.FSIPath); err != nil {
+			return fmt.Errorf("loading linked dataset: %s", err)
+		}
+	} else {
+		if err != nil {
+			return err
+		}
+		if ds, err = dsfs.LoadDataset(ctx, r.node.Repo.Store(), ref.Path); err != nil {
+			return fmt.Errorf("loading dataset: %s", err)
+		}
+	}
+
+	if ds.Structure == nil || ds.Structure.Schema == nil {
+		return fmt.Errorf("%s has no schema", ref.AliasString())
+	}
+	data, err := json.Marshal(ds.Structure.Schema)
+	if err != nil {
+		return err
+	}
+
+	res.Ref = ref.String()
+	res.Schema = data
+	return nil
+}
+
+// ContentHash hashes the body, structure & meta of a dataset version, leaving
+// out commit details. Versions holding the same data have the same content
+// hash, even if they were committed separately or under different names
+func (r *DatasetRequests) ContentHash(refstr *string, res *string) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.ContentHash", refstr, res)
+	}
+	ctx := context.TODO()
+
+	ref, err := repo.ParseDatasetRef(*refstr)
+	if err != nil {
+		return invalidRefError(*refstr)
+	}
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
+		return err
+	}
+
+	ds, err := dsfs.LoadDataset(ctx, r.node.Repo.Store(), ref.Path)
+	if err != nil {
+		return fmt.Errorf("loading dataset: %s", err)
+	}
+
+	*res, err = base.ContentHash(ctx, r.node.Repo.Filesystem(), ds)
+	return err
+}
+
+// RowCount gets the number of entries in the body of a dataset version
+// without reading the body. Counts are stored in the structure when a version
+// is saved, versions saved without a count have their body read on the first
+// request, caching the count for later ones
+func (r *DatasetRequests) RowCount(refstr *string, res *int) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.RowCount", refstr, res)
+	}
+	ctx := context.TODO()
+
+	ref, err := repo.ParseDatasetRef(*refstr)
+	if err != nil {
+		return invalidRefError(*refstr)
+	}
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
+		return err
+	}
+
+	ds, err := dsfs.LoadDataset(ctx, r.node.Repo.Store(), ref.Path)
+	if err != nil {
+		return fmt.Errorf("loading dataset: %s", err)
+	}
+	if ds.Structure != nil && ds.Structure.Entries > 0 {
+		*res = ds.Structure.Entries
+		return nil
+	}
+
+	var cache *rowCountCache
+	if r.inst != nil {
+		cache = &r.inst.rowCounts
+	}
+	if n, ok := cache.get(ref.Path); ok {
+		*res = n
+		return nil
+	}
+
+	if err = base.OpenDataset(ctx, r.node.Repo.Filesystem(), ds); err != nil {
+		return err
+	}
+	n, err := base.CountEntries(ds)
+	if err != nil {
+		return err
+	}
+	cache.put(ref.Path, n)
+	*res = n
+	return nil
+}
+
+// rowCountCache holds counted body entries, keyed by version path. Versions
+// never change, so counts never go stale. The zero value is ready to use, a
+// nil cache doesn't hold anything
+type rowCountCache struct {
+	lk     sync.Mutex
+	counts map[string]int
+}
+
+func (c *rowCountCache) get(path string) (int, bool) {
+	if c == nil {
+		return 0, false
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	n, ok := c.counts[path]
+	return n, ok
+}
+
+func (c *rowCountCache) put(path string, n int) {
+	if c == nil {
+		return
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	if c.counts == nil {
+		c.counts = map[string]int{}
+	}
+	c.counts[path] = n
+}
+
+// FormatDetection describes the detected format, delimiter, encoding &
+// header presence of a body file
+type FormatDetection = base.FormatDetection
+
+// SizeEstimate estimates the memory reading an entire dataset body takes
+type SizeEstimate = base.SizeEstimate
+
+// EstimateSize estimates the memory getting the entire body of a dataset
+// version takes, reading only the version's structure. Callers can use it to
+// decide whether to stream a body instead of getting it all at once
+func (r *DatasetRequests) EstimateSize(refstr *string, res *SizeEstimate) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.EstimateSize", refstr, res)
+	}
+	ctx := context.TODO()
+
+	ref, err := repo.ParseDatasetRef(*refstr)
+	if err != nil {
+		return invalidRefError(*refstr)
+	}
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
+		return err
+	}
+
+	ds, err := dsfs.LoadDataset(ctx, r.node.Repo.Store(), ref.Path)
+	if err != nil {
+		return fmt.Errorf("loading dataset: %s", err)
+	}
+	est, err := base.EstimateSize(ds.Structure)
+	if err != nil {
+		return err
+	}
+	*res = *est
+	return nil
+}
+
+// DetectFormat sniffs the format of a body file without saving it, so the
+// structure of a new dataset can be filled in for a user to confirm
+func (r *DatasetRequests) DetectFormat(path *string, res *FormatDetection) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.DetectFormat", path, res)
+	}
+	if *path == "" {
+		return fmt.Errorf("path to a body file is required")
+	}
+
+	abs := *path
+	if err = qfs.AbsPath(&abs); err != nil {
+		return err
+	}
+	f, err := os.Open(abs)
+	if err != nil {
+		return fmt.Errorf("opening body file: %s", err)
+	}
+	defer f.Close()
+
+	det, err := base.DetectBodyFormat(abs, f)
+	if err != nil {
+		return err
+	}
+	*res = *det
+	return nil
+}
+
 // Manifest generates a manifest for a dataset path
 func (r *DatasetRequests) Manifest(refstr *string, m *dag.Manifest) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Manifest", refstr, m)
 	}
@@ -1068,6 +2164,7 @@ func (r *DatasetRequests) Manifest(refstr *string, m *dag.Manifest) (err error)
 
 // ManifestMissing generates a manifest of blocks that are not present on this repo for a given manifest
 func (r *DatasetRequests) ManifestMissing(a, b *dag.Manifest) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Manifest", a, b)
 	}
@@ -1082,6 +2179,30 @@ func (r *DatasetRequests) ManifestMissing(a, b *dag.Manifest) (err error) {
 	return
 }
 
+// ManifestMissingRemoteParams defines parameters for the
+// ManifestMissingRemote method
+type ManifestMissingRemoteParams struct {
+	// Local is the manifest of the version being sent
+	Local *dag.Manifest
+	// Remote is a manifest of blocks the receiving remote already has
+	Remote *dag.Manifest
+}
+
+// ManifestMissingRemote generates a manifest of blocks in a local manifest
+// that are not present in a remote's manifest, the blocks a push needs to send
+func (r *DatasetRequests) ManifestMissingRemote(p *ManifestMissingRemoteParams, res *dag.Manifest) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.ManifestMissingRemote", p, res)
+	}
+	if p.Local == nil || p.Remote == nil {
+		return fmt.Errorf("local and remote manifests are required")
+	}
+
+	*res = *p2p.MissingFromManifest(p.Local, p.Remote)
+	return nil
+}
+
 // DAGInfoParams defines parameters for the DAGInfo method
 type DAGInfoParams struct {
 	RefStr, Label string
@@ -1089,6 +2210,7 @@ type DAGInfoParams struct {
 
 // DAGInfo generates a dag.Info for a dataset path. If a label is given, DAGInfo will generate a sub-dag.Info at that label.
 func (r *DatasetRequests) DAGInfo(s *DAGInfoParams, i *dag.Info) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.DAGInfo", s, i)
 	}
@@ -1118,40 +2240,77 @@ type StatsParams struct {
 	// if we get a Dataset from the params, then we do not have to
 	// attempt to open a dataset from the reference
 	Dataset *dataset.Dataset
+	// number of buckets to use in numeric histograms, defaults to
+	// stats.DefaultHistogramBins when zero
+	HistogramBins int
+	// read the body from a linked working directory instead of the stored
+	// version, using the working structure if one exists
+	UseFSI bool
 }
 
 // StatsResponse defines the response for a Stats request
 type StatsResponse struct {
 	StatsBytes []byte
+	// Uncommitted is true when stats describe a working directory body that
+	// may differ from the latest version
+	Uncommitted bool
 }
 
-// Stats generates stats for a dataset
+// Stats generates stats for a dataset. Bodies that fail to parse return a
+// *stats.ParseError, missing bodies return stats.ErrNoBody
 func (r *DatasetRequests) Stats(p *StatsParams, res *StatsResponse) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Stats", p, res)
 	}
 	ctx := context.TODO()
 	if p.Dataset == nil {
 		ref := &reporef.DatasetRef{}
-		ref, err = base.ToDatasetRef(p.Ref, r.node.Repo, false)
+		ref, err = base.ToDatasetRef(p.Ref, r.node.Repo, p.UseFSI)
 		if err != nil {
 			return err
 		}
-		p.Dataset, err = dsfs.LoadDataset(ctx, r.node.Repo.Store(), ref.Path)
-		if err != nil {
-			return fmt.Errorf("loading dataset: %s", err)
-		}
 
-		if err = base.OpenDataset(ctx, r.node.Repo.Filesystem(), p.Dataset); err != nil {
-			return
+		if p.UseFSI {
+			if ref.FSIPath == "" {
+				return fsi.ErrNoLink
+			}
+			if p.Dataset, err = fsi.ReadDir(ref.FSIPath); err != nil {
+				return fmt.Errorf("loading linked dataset: %s", err)
+			}
+			// working directory stats must never be cached against a version path
+			p.Dataset.Path = ""
+			res.Uncommitted = true
+			if p.Dataset.BodyPath == "" {
+				return stats.ErrNoBody
+			}
+			if err = p.Dataset.OpenBodyFile(ctx, r.node.Repo.Filesystem()); err != nil {
+				return err
+			}
+		} else {
+			p.Dataset, err = dsfs.LoadDataset(ctx, r.node.Repo.Store(), ref.Path)
+			if err != nil {
+				return fmt.Errorf("loading dataset: %s", err)
+			}
+
+			if err = base.OpenDataset(ctx, r.node.Repo.Filesystem(), p.Dataset); err != nil {
+				return
+			}
 		}
 	}
+	if p.Dataset.BodyFile() == nil {
+		return stats.ErrNoBody
+	}
 	if p.Dataset.Structure == nil || p.Dataset.Structure.IsEmpty() {
 		p.Dataset.Structure = &dataset.Structure{}
+	}
+	if p.Dataset.Structure.Format == "" {
 		p.Dataset.Structure.Format = filepath.Ext(p.Dataset.BodyFile().FileName())
+	}
+	if p.Dataset.Structure.Schema == nil {
 		p.Dataset.Structure.Schema, _, err = detect.Schema(p.Dataset.Structure, p.Dataset.BodyFile())
 		if err != nil {
-			return err
+			return &stats.ParseError{Row: 0, Err: err}
 		}
 		// TODO (ramfox): this feels gross, but since we consume the reader when
 		// detecting the schema, we need to open up the file again, since we don't
@@ -1160,10 +2319,29 @@ func (r *DatasetRequests) Stats(p *StatsParams, res *StatsResponse) (err error)
 			return err
 		}
 	}
-	reader, err := r.inst.stats.JSON(ctx, p.Dataset)
+	reader, err := r.inst.stats.JSON(ctx, p.Dataset, stats.OptHistogramBins(p.HistogramBins))
 	if err != nil {
 		return err
 	}
-	res.StatsBytes, err = ioutil.ReadAll(reader)
-	return err
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	res.StatsBytes = annotateStats(data, p.Dataset.Structure.Schema)
+	return nil
+}
+
+// annotateStats adds column metadata from a schema to stats JSON. Stats that
+// aren't a list of columns are returned unchanged
+func annotateStats(data []byte, schema map[string]interface{}) []byte {
+	cols := []map[string]interface{}{}
+	if err := json.Unmarshal(data, &cols); err != nil {
+		return data
+	}
+	base.AnnotateColumnStats(cols, schema)
+	annotated, err := json.Marshal(cols)
+	if err != nil {
+		return data
+	}
+	return annotated
 }
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -17,17 +18,22 @@ import (
 
 	"github.com/ghodss/yaml"
 	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dag"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/dataset/dstest"
 	"github.com/qri-io/jsonschema"
+	"github.com/qri-io/qfs"
 	"github.com/qri-io/qfs/cafs"
 	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook"
+	"github.com/qri-io/qri/logbook/oplog"
 	"github.com/qri-io/qri/p2p"
 	p2ptest "github.com/qri-io/qri/p2p/test"
+	"github.com/qri-io/qri/remote"
 	"github.com/qri-io/qri/repo"
 	reporef "github.com/qri-io/qri/repo/ref"
 	testrepo "github.com/qri-io/qri/repo/test"
@@ -91,7 +97,7 @@ func TestDatasetRequestsSave(t *testing.T) {
 	}
 
 	for i, c := range good {
-		got := &reporef.DatasetRef{}
+		got := &SaveResult{}
 		err := req.Save(&c.params, got)
 		if err != nil {
 			t.Errorf("case %d: '%s' unexpected error: %s", i, c.description, err.Error())
@@ -126,7 +132,7 @@ func TestDatasetRequestsSave(t *testing.T) {
 	}
 
 	for i, c := range bad {
-		got := &reporef.DatasetRef{}
+		got := &SaveResult{}
 		err := req.Save(&c.params, got)
 		if err == nil {
 			t.Errorf("case %d: '%s' returned no error", i, c.description)
@@ -153,7 +159,7 @@ func TestDatasetRequestsForceSave(t *testing.T) {
 	ref := addCitiesDataset(t, node)
 	r := NewDatasetRequests(node, nil)
 
-	res := &reporef.DatasetRef{}
+	res := &SaveResult{}
 	if err := r.Save(&SaveParams{Ref: ref.AliasString()}, res); err == nil {
 		t.Error("expected empty save without force flag to error")
 	}
@@ -166,6 +172,162 @@ func TestDatasetRequestsForceSave(t *testing.T) {
 	}
 }
 
+func TestDatasetRequestsSaveWarnings(t *testing.T) {
+	node := newTestQriNode(t)
+	r := NewDatasetRequests(node, nil)
+
+	dir, err := ioutil.TempDir("", "TestDatasetRequestsSaveWarnings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	commitPath := filepath.Join(dir, "commit.json")
+	if err := ioutil.WriteFile(commitPath, []byte(`{"qri":"cm:0","title":"initial","colour":"blue"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// no extension, the body format is sniffed
+	bodyPath := filepath.Join(dir, "body")
+	if err := ioutil.WriteFile(bodyPath, []byte("[1,\"two\",3]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	res := &SaveResult{}
+	p := &SaveParams{
+		Ref:       "me/warnings",
+		BodyPath:  bodyPath,
+		FilePaths: []string{commitPath},
+	}
+	if err := r.Save(p, res); err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{base.WarnUnknownFields, base.WarnSniffedFormat}
+	if diff := cmp.Diff(expect, warningCodes(res.Warnings)); diff != "" {
+		t.Errorf("warning codes mismatch (-want +got):\n%s", diff)
+	}
+	if res.Path == "" {
+		t.Errorf("expected saved reference to be returned alongside warnings")
+	}
+
+	p = &SaveParams{
+		Ref:      "me/warnings",
+		BodyPath: bodyPath,
+		Dataset: &dataset.Dataset{
+			Structure: &dataset.Structure{
+				Format: "json",
+				Schema: map[string]interface{}{
+					"type":  "array",
+					"items": map[string]interface{}{"type": "number"},
+				},
+			},
+		},
+	}
+	if err := r.Save(p, res); err != nil {
+		t.Fatal(err)
+	}
+	expect = []string{base.WarnValidation}
+	if diff := cmp.Diff(expect, warningCodes(res.Warnings)); diff != "" {
+		t.Errorf("warning codes mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDatasetRequestsSaveReadme(t *testing.T) {
+	ctx := context.Background()
+	node := newTestQriNode(t)
+	r := NewDatasetRequests(node, nil)
+
+	res := &SaveResult{}
+	p := &SaveParams{
+		Ref:     "me/with_readme",
+		Dataset: &dataset.Dataset{Meta: &dataset.Meta{Title: "with readme"}, BodyBytes: []byte("[1,2,3]")},
+	}
+	if err := r.Save(p, res); err != nil {
+		t.Fatal(err)
+	}
+
+	readme := func(text string) *SaveParams {
+		return &SaveParams{
+			Ref:     "me/with_readme",
+			Dataset: &dataset.Dataset{Readme: &dataset.Readme{ScriptBytes: []byte(text)}},
+		}
+	}
+	// a readme alone is a change
+	if err := r.Save(readme("# hello"), res); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Save(readme("# hello"), res); err == nil || err.Error() != "error saving: no changes" {
+		t.Errorf("expected saving an unchanged readme to error with no changes, got: %v", err)
+	}
+
+	got := &GetResult{}
+	if err := r.Get(&GetParams{Path: "me/with_readme", Selector: "rm.script"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if string(got.Bytes) != "# hello" {
+		t.Errorf("readme script mismatch. expected: %q, got: %q", "# hello", string(got.Bytes))
+	}
+
+	ref := reporef.ConvertToDsref(res.Ref())
+	preview, err := base.CreatePreview(ctx, node.Repo, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Readme == nil || string(preview.Readme.ScriptBytes) != "# hello" {
+		t.Errorf("expected preview to inline the readme script, got: %v", preview.Readme)
+	}
+}
+
+func warningCodes(warnings []Warning) []string {
+	codes := make([]string, len(warnings))
+	for i, w := range warnings {
+		codes[i] = w.Code
+	}
+	return codes
+}
+
+func TestDatasetRequestsSaveDryRunSummary(t *testing.T) {
+	node := newTestQriNode(t)
+	ref := addCitiesDataset(t, node)
+	r := NewDatasetRequests(node, nil)
+
+	dir, err := ioutil.TempDir("", "TestDatasetRequestsSaveDryRunSummary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bodyPath := filepath.Join(dir, "body.csv")
+	body := `city,pop,avg_age,in_usa
+toronto,40000000,55.5,false
+new york,8500000,44.4,true
+chicago,300000,44.4,true
+chatham,35000,65.25,true
+raleigh,250000,50.65,true
+sarnia,550000,55.65,false
+`
+	if err := ioutil.WriteFile(bodyPath, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	res := &SaveResult{}
+	if err := r.Save(&SaveParams{Ref: ref.AliasString(), BodyPath: bodyPath, DryRun: true}, res); err != nil {
+		t.Fatal(err)
+	}
+	expect := "added 1 body row"
+	if res.Dataset.Commit.Title != expect {
+		t.Errorf("commit title mismatch. expected: %q, got: %q", expect, res.Dataset.Commit.Title)
+	}
+	if !strings.HasPrefix(res.Dataset.Commit.Message, expect) {
+		t.Errorf("expected commit message to start with the summary, got: %q", res.Dataset.Commit.Message)
+	}
+
+	if err := r.Save(&SaveParams{Ref: ref.AliasString(), BodyPath: bodyPath, Title: "my title", DryRun: true}, res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Dataset.Commit.Title != "my title" {
+		t.Errorf("expected user supplied title to be kept, got: %q", res.Dataset.Commit.Title)
+	}
+}
+
 func TestDatasetRequestsSaveRecall(t *testing.T) {
 	node := newTestQriNode(t)
 	ref := addNowTransformDataset(t, node)
@@ -178,7 +340,7 @@ func TestDatasetRequestsSaveRecall(t *testing.T) {
 		os.RemoveAll(metaTwoPath)
 	}()
 
-	res := &reporef.DatasetRef{}
+	res := &SaveResult{}
 	err := r.Save(&SaveParams{
 		Ref:        ref.AliasString(),
 		FilePaths:  []string{metaOnePath},
@@ -218,7 +380,7 @@ func TestDatasetRequestsSaveZip(t *testing.T) {
 	}
 	req := NewDatasetRequests(node, nil)
 
-	res := reporef.DatasetRef{}
+	res := SaveResult{}
 	// TODO (b5): import.zip has a ref.txt file that specifies test_user/test_repo as the dataset name,
 	// save now requires a string reference. we need to pick a behaviour here & write a test that enforces it
 	err = req.Save(&SaveParams{Ref: "me/huh", FilePaths: []string{"testdata/import.zip"}}, &res)
@@ -287,6 +449,9 @@ func TestDatasetRequestsList(t *testing.T) {
 		{"list datasets - limit 2 offset 5", &ListParams{OrderBy: "", Limit: 2, Offset: 5}, []dsref.VersionInfo{}, ""},
 		{"list datasets - order by timestamp", &ListParams{OrderBy: "timestamp", Limit: 30, Offset: 0}, []dsref.VersionInfo{cities, counter, craigslist, movies, sitemap}, ""},
 		{"list datasets - peername 'me'", &ListParams{Peername: "me", OrderBy: "timestamp", Limit: 30, Offset: 0}, []dsref.VersionInfo{cities, counter, craigslist, movies, sitemap}, ""},
+		{"list datasets - profile IDs", &ListParams{ProfileIDs: []string{"QmSoLV4Bbm51jM9C4gDYZQ9Cy3U6aXMJDAbzgu2fzaDs64", cities.ProfileID}, Limit: 30}, []dsref.VersionInfo{cities, counter, craigslist, movies, sitemap}, ""},
+		{"list datasets - unknown profile ID", &ListParams{ProfileIDs: []string{"QmSoLV4Bbm51jM9C4gDYZQ9Cy3U6aXMJDAbzgu2fzaDs64"}, Limit: 30}, []dsref.VersionInfo{}, ""},
+		{"list datasets - invalid profile ID", &ListParams{ProfileIDs: []string{"not_an_id"}}, nil, `invalid profile ID "not_an_id": input isn't valid multihash`},
 		// TODO: re-enable {&ListParams{OrderBy: "name", Limit: 30, Offset: 0}, []*dsref.VersionInfo{cities, counter, movies}, ""},
 	}
 
@@ -382,6 +547,45 @@ func TestDatasetRequestsListP2p(t *testing.T) {
 	wg.Wait()
 }
 
+func TestDatasetRequestsGetFormatFallbacks(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+	req := NewDatasetRequestsInstance(tr.Instance)
+
+	get := func(format, selector string) *GetResult {
+		res := &GetResult{}
+		if err := req.Get(&GetParams{Path: "me/movies", Format: format, Selector: selector, All: true}, res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	check := func(description string, res *GetResult, format, source string) {
+		if res.Format != format || res.FormatSource != source {
+			t.Errorf("%s: expected format %q from %q, got: %q from %q", description, format, source, res.Format, res.FormatSource)
+		}
+	}
+
+	check("body without a preference", get("", "body"), "csv", FormatSourceStored)
+	check("head without a preference", get("", ""), "yaml", FormatSourceDefault)
+	check("explicit body format", get("json", "body"), "json", FormatSourceParam)
+
+	md := &dataset.Meta{Title: "movies"}
+	if err := md.Set(base.OutputFormatKey, "json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := req.Save(&SaveParams{Ref: "me/movies", Dataset: &dataset.Dataset{Meta: md}}, &SaveResult{}); err != nil {
+		t.Fatal(err)
+	}
+
+	res := get("", "body")
+	check("body with a preference", res, "json", FormatSourceDataset)
+	if !json.Valid(res.Bytes) {
+		t.Errorf("expected preferred format body to be json, got: %q", string(res.Bytes[:20]))
+	}
+	check("head with a preference", get("", ""), "json", FormatSourceDataset)
+	check("explicit format overrides preference", get("csv", "body"), "csv", FormatSourceParam)
+}
+
 func TestDatasetRequestsGet(t *testing.T) {
 	ctx := context.Background()
 	mr, err := testrepo.NewTestRepo()
@@ -677,6 +881,72 @@ func TestDatasetRequestsRename(t *testing.T) {
 	}
 }
 
+func TestDatasetRequestsFork(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	bad := []struct {
+		p   *ForkParams
+		err string
+	}{
+		{&ForkParams{Source: "peer/movies"}, "name is required to fork a dataset"},
+		{&ForkParams{Source: "peer/movies", Name: "cities"}, "dataset 'peer/cities' already exists"},
+		{&ForkParams{Source: "peer/not_a_dataset", Name: "fork"}, "repo: not found"},
+	}
+
+	req := NewDatasetRequests(node, nil)
+	for i, c := range bad {
+		err := req.Fork(c.p, &reporef.DatasetRef{})
+		if err == nil {
+			t.Errorf("case %d didn't error. expected: %s", i, c.err)
+			continue
+		}
+		if c.err != err.Error() {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+		}
+	}
+
+	source := reporef.DatasetRef{Peername: "peer", Name: "movies"}
+	if err := repo.CanonicalizeDatasetRef(mr, &source); err != nil {
+		t.Fatal(err)
+	}
+
+	res := reporef.DatasetRef{}
+	if err := req.Fork(&ForkParams{Source: "peer/movies", Name: "movies_fork"}, &res); err != nil {
+		t.Fatal(err)
+	}
+	expectOrigin := reporef.ConvertToDsref(source).String()
+
+	got := &GetResult{}
+	if err := req.Get(&GetParams{Path: "peer/movies_fork"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ForkOf != expectOrigin {
+		t.Errorf("get fork origin mismatch. expected: %q, got: %q", expectOrigin, got.ForkOf)
+	}
+
+	infos := []dsref.VersionInfo{}
+	if err := req.List(&ListParams{Limit: 100}, &infos); err != nil {
+		t.Fatal(err)
+	}
+	origins := map[string]string{}
+	for _, info := range infos {
+		origins[info.Name] = info.ForkOf
+	}
+	if origins["movies_fork"] != expectOrigin {
+		t.Errorf("list fork origin mismatch. expected: %q, got: %q", expectOrigin, origins["movies_fork"])
+	}
+	if origins["movies"] != "" {
+		t.Errorf("expected source to have no fork origin, got: %q", origins["movies"])
+	}
+}
+
 func TestDatasetRequestsRemove(t *testing.T) {
 	mr, err := testrepo.NewTestRepo()
 	if err != nil {
@@ -726,7 +996,7 @@ func TestDatasetRequestsRemove(t *testing.T) {
 	}
 
 	// add a commit to craigslist
-	saveRes := &reporef.DatasetRef{}
+	saveRes := &SaveResult{}
 	if err := req.Save(&SaveParams{Ref: "peer/craigslist", Dataset: &dataset.Dataset{Meta: &dataset.Meta{Title: "oh word"}}}, saveRes); err != nil {
 		t.Fatal(err)
 	}
@@ -830,6 +1100,105 @@ func TestDatasetRequestsAdd(t *testing.T) {
 	}
 }
 
+func TestDatasetRequestsAddByPath(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req := NewDatasetRequests(node, nil)
+
+	saved := &SaveResult{}
+	err = req.Save(&SaveParams{
+		Ref:      "me/by_path",
+		BodyPath: "testdata/cities_2/body.csv",
+	}, saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := req.Add(&AddParams{Ref: saved.Path}, &reporef.DatasetRef{}); err == nil {
+		t.Error("expected adding a version path without a name to error")
+	}
+
+	got := &reporef.DatasetRef{}
+	if err := req.Add(&AddParams{Ref: saved.Path, LocalName: "by_path_copy"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.AliasString() != "peer/by_path_copy" || got.Path != saved.Path {
+		t.Errorf("unexpected added ref: %s", got)
+	}
+
+	ref := reporef.DatasetRef{Peername: "peer", Name: "by_path_copy"}
+	if err := repo.CanonicalizeDatasetRef(mr, &ref); err != nil {
+		t.Fatal(err)
+	}
+	if ref.Path != saved.Path {
+		t.Errorf("expected added ref path %q, got: %q", saved.Path, ref.Path)
+	}
+}
+
+// logsClient is a remote client that serves logs from a logbook
+type logsClient struct {
+	remote.Client
+	book    *logbook.Book
+	fetched []dsref.Ref
+	cloned  []dsref.Ref
+}
+
+func (c *logsClient) FetchLogs(ctx context.Context, ref dsref.Ref, remoteAddr string) (*oplog.Log, error) {
+	c.fetched = append(c.fetched, ref)
+	return c.book.UserDatasetRef(ctx, ref)
+}
+
+func (c *logsClient) CloneLogs(ctx context.Context, ref dsref.Ref, remoteAddr string) error {
+	c.cloned = append(c.cloned, ref)
+	return nil
+}
+
+func TestPullVersionLogs(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	mr := tr.Instance.Repo()
+	head, err := mr.GetRef(reporef.DatasetRef{Peername: "peer", Name: "movies"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := &logsClient{book: mr.Logbook()}
+	tr.Instance.remoteClient = cli
+	req := NewDatasetRequestsInstance(tr.Instance)
+
+	// logs are looked up by the name the version records
+	added := reporef.DatasetRef{Peername: "peer", Name: "movies", Path: head.Path, Dataset: &dataset.Dataset{Name: "movies"}}
+	req.pullVersionLogs(ctx, added, "https://remote.example.com")
+	if len(cli.fetched) != 1 || cli.fetched[0].Alias() != "peer/movies" {
+		t.Errorf("expected logs to be fetched for peer/movies, got: %v", cli.fetched)
+	}
+	if len(cli.cloned) != 1 || cli.cloned[0].Alias() != "peer/movies" {
+		t.Errorf("expected logs including the version to be cloned, got: %v", cli.cloned)
+	}
+
+	cli.fetched, cli.cloned = nil, nil
+	added.Path = "/map/QmNotInHistory"
+	req.pullVersionLogs(ctx, added, "https://remote.example.com")
+	if len(cli.cloned) != 0 {
+		t.Errorf("expected logs without the version not to be cloned, got: %v", cli.cloned)
+	}
+
+	// versions added under another name don't take on the remote's logs
+	cli.fetched, cli.cloned = nil, nil
+	renamed := reporef.DatasetRef{Peername: "peer", Name: "movies_copy", Path: head.Path, Dataset: &dataset.Dataset{Name: "movies"}}
+	req.pullVersionLogs(ctx, renamed, "https://remote.example.com")
+	if len(cli.fetched) != 0 || len(cli.cloned) != 0 {
+		t.Errorf("expected a renamed version to skip logs, fetched: %v cloned: %v", cli.fetched, cli.cloned)
+	}
+}
+
 func TestDatasetRequestsAddP2P(t *testing.T) {
 	t.Skip("TODO (b5)")
 	// Matches what is used to generate the test peers.
@@ -942,6 +1311,10 @@ Pirates of the Caribbean: At World's End ,foo
 		{ValidateDatasetParams{Ref: "me/movies", BodyFilename: bodyFilename}, 1, ""},
 		{ValidateDatasetParams{Ref: "me/movies", SchemaFilename: schemaFilename}, 5, ""},
 		{ValidateDatasetParams{SchemaFilename: schemaFilename, BodyFilename: bodyFilename}, 1, ""},
+		{ValidateDatasetParams{Ref: "me/movies", SchemaDraft: "draft-07"}, 4, ""},
+		{ValidateDatasetParams{Ref: "me/movies", SchemaDraft: "draft-06"}, 4, ""},
+		{ValidateDatasetParams{Ref: "me/movies", SchemaDraft: "2020-12"}, 0, "bad arguments provided"},
+		{ValidateDatasetParams{Ref: "me/movies", SchemaDraft: "draft-99"}, 0, "bad arguments provided"},
 	}
 
 	mr, err := testrepo.NewTestRepo()
@@ -970,6 +1343,38 @@ Pirates of the Caribbean: At World's End ,foo
 	}
 }
 
+func TestDatasetRequestsValidateNamespace(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req := NewDatasetRequests(node, nil)
+
+	got := []NamespaceValidationResult{}
+	if err := req.ValidateNamespace(&ValidateNamespaceParams{Peername: "me"}, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	refs, err := mr.References(0, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(refs) {
+		t.Errorf("result count mismatch. expected: %d, got: %d", len(refs), len(got))
+	}
+	for _, res := range got {
+		if res.Error != "" {
+			t.Errorf("%s: unexpected error: %s", res.Ref, res.Error)
+		}
+		if res.Ref == "peer/movies" && len(res.Errors) != 4 {
+			t.Errorf("expected peer/movies to have 4 validation errors, got: %d", len(res.Errors))
+		}
+	}
+}
 func TestDatasetRequestsStats(t *testing.T) {
 	mr, err := testrepo.NewTestRepo()
 	if err != nil {
@@ -1006,8 +1411,8 @@ func TestDatasetRequestsStats(t *testing.T) {
 		ref         string
 		expected    []byte
 	}{
-		{"csv: me/cities", "me/cities", []byte(`[{"count":5,"maxLength":8,"minLength":7,"type":"string","unique":5},{"count":5,"histogram":{"bins":[35000,4031500.1,8028000.2,12024500.3,16021000.4,20017500.5,24014000.6,28010500.7,32007000.8,36003500.9,40000001],"frequencies":[3,0,1,0,0,0,0,0,0,1]},"max":40000000,"mean":9817000,"median":300000,"min":35000,"type":"numeric"},{"count":5,"histogram":{"bins":[44.4,46.585,48.769999999999996,50.955,53.14,55.325,57.51,59.695,61.879999999999995,64.065,66.25],"frequencies":[2,0,1,0,0,1,0,0,0,1]},"max":65.25,"mean":52.04,"median":50.65,"min":44.4,"type":"numeric"},{"count":5,"falseCount":1,"trueCount":4,"type":"boolean"}]`)},
-		{"json: me/sitemap", "me/sitemap", []byte(`[{"count":10,"histogram":{"bins":[24515,26071.5,27628,29184.5,30741,32297.5,33854,35410.5,36967,38523.5,40080],"frequencies":[4,0,3,1,0,0,1,0,0,1]},"key":"contentLength","max":40079,"mean":28825.8,"median":28059,"min":24515,"type":"numeric"},{"count":10,"frequencies":{"text/html; charset=utf-8":10},"key":"contentSniff","maxLength":24,"minLength":24,"type":"string"},{"count":10,"frequencies":{"text/html; charset=utf-8":10},"key":"contentType","maxLength":24,"minLength":24,"type":"string"},{"count":10,"histogram":{"bins":[74291866,475020463.6,875749061.2,1276477658.8000002,1677206256.4,2077934854,2478663451.6000004,2879392049.2000003,3280120646.8,3680849244.4,4081577842],"frequencies":[2,0,0,0,0,0,0,0,0,8]},"key":"duration","max":4081577841,"mean":3276899953.4,"median":4077230086,"min":74291866,"type":"numeric"},{"count":10,"key":"hash","maxLength":68,"minLength":68,"type":"string","unique":10},{"key":"links","type":"array","values":[{"count":10,"maxLength":58,"minLength":14,"unique":10},{"count":10,"maxLength":115,"minLength":19,"unique":10},{"count":10,"maxLength":68,"minLength":22,"unique":10},{"count":10,"maxLength":115,"minLength":14,"unique":10},{"count":9,"maxLength":70,"minLength":15,"unique":9},{"count":9,"maxLength":115,"minLength":37,"unique":9},{"count":9,"maxLength":52,"minLength":15,"unique":9},{"count":9,"maxLength":75,"minLength":19,"unique":9},{"count":9,"maxLength":66,"minLength":15,"unique":9},{"count":7,"maxLength":75,"minLength":19,"unique":7},{"count":7,"maxLength":66,"minLength":22,"unique":7},{"count":6,"maxLength":43,"minLength":19,"unique":6},{"count":6,"maxLength":77,"minLength":14,"unique":6},{"count":6,"maxLength":77,"minLength":21,"unique":6},{"count":4,"maxLength":43,"minLength":14,"unique":4},{"count":3,"maxLength":32,"minLength":21,"unique":3},{"count":3,"maxLength":42,"minLength":19,"unique":3},{"count":3,"maxLength":66,"minLength":32,"unique":3},{"count":3,"maxLength":46,"minLength":19,"unique":3},{"count":2,"maxLength":66,"minLength":22,"unique":2},{"count":2,"maxLength":32,"minLength":23,"unique":2},{"count":2,"maxLength":33,"minLength":22,"unique":2},{"count":2,"maxLength":32,"minLength":27,"unique":2},{"count":1,"maxLength":33,"minLength":33,"unique":1},{"count":1,"maxLength":27,"minLength":27,"unique":1}]},{"count":1,"key":"redirectTo","maxLength":18,"minLength":18,"type":"string","unique":1},{"count":11,"histogram":{"bins":[200,210.2,220.4,230.6,240.8,251,261.2,271.4,281.6,291.8,302],"frequencies":[10,0,0,0,0,0,0,0,0,1]},"key":"status","max":301,"mean":209.1818181818182,"median":200,"min":200,"type":"numeric"},{"count":11,"key":"timestamp","maxLength":35,"minLength":35,"type":"string","unique":11},{"count":10,"key":"title","maxLength":88,"minLength":53,"type":"string","unique":10},{"count":11,"key":"url","maxLength":78,"minLength":18,"type":"string","unique":11}]`)},
+		{"csv: me/cities", "me/cities", []byte(`[{"count":5,"distinct":5,"maxLength":8,"minLength":7,"title":"city","type":"string","unique":5},{"count":5,"distinct":5,"histogram":{"bins":[35000,4031500.1,8028000.2,12024500.3,16021000.4,20017500.5,24014000.6,28010500.7,32007000.8,36003500.9,40000001],"frequencies":[3,0,1,0,0,0,0,0,0,1]},"max":40000000,"mean":9817000,"median":300000,"min":35000,"title":"pop","type":"numeric"},{"count":5,"distinct":4,"histogram":{"bins":[44.4,46.585,48.769999999999996,50.955,53.14,55.325,57.51,59.695,61.879999999999995,64.065,66.25],"frequencies":[2,0,1,0,0,1,0,0,0,1]},"max":65.25,"mean":52.04,"median":50.65,"min":44.4,"title":"avg_age","type":"numeric"},{"count":5,"falseCount":1,"title":"in_usa","trueCount":4,"type":"boolean"}]`)},
+		{"json: me/sitemap", "me/sitemap", []byte(`[{"count":10,"distinct":10,"histogram":{"bins":[24515,26071.5,27628,29184.5,30741,32297.5,33854,35410.5,36967,38523.5,40080],"frequencies":[4,0,3,1,0,0,1,0,0,1]},"key":"contentLength","max":40079,"mean":28825.8,"median":28059,"min":24515,"type":"numeric"},{"count":10,"distinct":1,"frequencies":{"text/html; charset=utf-8":10},"key":"contentSniff","maxLength":24,"minLength":24,"type":"string"},{"count":10,"distinct":1,"frequencies":{"text/html; charset=utf-8":10},"key":"contentType","maxLength":24,"minLength":24,"type":"string"},{"count":10,"distinct":10,"histogram":{"bins":[74291866,475020463.6,875749061.2,1276477658.8000002,1677206256.4,2077934854,2478663451.6000004,2879392049.2000003,3280120646.8,3680849244.4,4081577842],"frequencies":[2,0,0,0,0,0,0,0,0,8]},"key":"duration","max":4081577841,"mean":3276899953.4,"median":4077230086,"min":74291866,"type":"numeric"},{"count":10,"distinct":10,"key":"hash","maxLength":68,"minLength":68,"type":"string","unique":10},{"key":"links","type":"array","values":[{"count":10,"distinct":10,"maxLength":58,"minLength":14,"unique":10},{"count":10,"distinct":10,"maxLength":115,"minLength":19,"unique":10},{"count":10,"distinct":10,"maxLength":68,"minLength":22,"unique":10},{"count":10,"distinct":10,"maxLength":115,"minLength":14,"unique":10},{"count":9,"distinct":9,"maxLength":70,"minLength":15,"unique":9},{"count":9,"distinct":9,"maxLength":115,"minLength":37,"unique":9},{"count":9,"distinct":9,"maxLength":52,"minLength":15,"unique":9},{"count":9,"distinct":9,"maxLength":75,"minLength":19,"unique":9},{"count":9,"distinct":9,"maxLength":66,"minLength":15,"unique":9},{"count":7,"distinct":7,"maxLength":75,"minLength":19,"unique":7},{"count":7,"distinct":7,"maxLength":66,"minLength":22,"unique":7},{"count":6,"distinct":6,"maxLength":43,"minLength":19,"unique":6},{"count":6,"distinct":6,"maxLength":77,"minLength":14,"unique":6},{"count":6,"distinct":6,"maxLength":77,"minLength":21,"unique":6},{"count":4,"distinct":4,"maxLength":43,"minLength":14,"unique":4},{"count":3,"distinct":3,"maxLength":32,"minLength":21,"unique":3},{"count":3,"distinct":3,"maxLength":42,"minLength":19,"unique":3},{"count":3,"distinct":3,"maxLength":66,"minLength":32,"unique":3},{"count":3,"distinct":3,"maxLength":46,"minLength":19,"unique":3},{"count":2,"distinct":2,"maxLength":66,"minLength":22,"unique":2},{"count":2,"distinct":2,"maxLength":32,"minLength":23,"unique":2},{"count":2,"distinct":2,"maxLength":33,"minLength":22,"unique":2},{"count":2,"distinct":2,"maxLength":32,"minLength":27,"unique":2},{"count":1,"distinct":1,"maxLength":33,"minLength":33,"unique":1},{"count":1,"distinct":1,"maxLength":27,"minLength":27,"unique":1}]},{"count":1,"distinct":1,"key":"redirectTo","maxLength":18,"minLength":18,"type":"string","unique":1},{"count":11,"distinct":2,"histogram":{"bins":[200,210.2,220.4,230.6,240.8,251,261.2,271.4,281.6,291.8,302],"frequencies":[10,0,0,0,0,0,0,0,0,1]},"key":"status","max":301,"mean":209.1818181818182,"median":200,"min":200,"type":"numeric"},{"count":11,"distinct":11,"key":"timestamp","maxLength":35,"minLength":35,"type":"string","unique":11},{"count":10,"distinct":10,"key":"title","maxLength":88,"minLength":53,"type":"string","unique":10},{"count":11,"distinct":11,"key":"url","maxLength":78,"minLength":18,"type":"string","unique":11}]`)},
 	}
 	for i, c := range goodCases {
 		res := &StatsResponse{}
@@ -1022,6 +1427,157 @@ func TestDatasetRequestsStats(t *testing.T) {
 	}
 }
 
+func TestDatasetRequestsRowCount(t *testing.T) {
+	ctx := context.Background()
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	inst := NewInstanceFromConfigAndNode(config.DefaultConfigForTesting(), node)
+	req := NewDatasetRequestsInstance(inst)
+
+	ref := "me/cities"
+	count := 0
+	if err := req.RowCount(&ref, &count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 5 {
+		t.Errorf("stored row count mismatch. expected: %d, got: %d", 5, count)
+	}
+
+	// versions saved without a count have their body counted once
+	legacy := &dataset.Dataset{
+		Commit:    &dataset.Commit{Title: "legacy"},
+		Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray},
+	}
+	legacy.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[1,2,3]`)))
+	path, err := dsfs.WriteDataset(ctx, mr.Store(), legacy, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pro, err := mr.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mr.PutRef(reporef.DatasetRef{Peername: pro.Peername, ProfileID: pro.ID, Name: "legacy", Path: path}); err != nil {
+		t.Fatal(err)
+	}
+	ref = "me/legacy"
+	if err := req.RowCount(&ref, &count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("counted row count mismatch. expected: %d, got: %d", 3, count)
+	}
+	if cached, ok := inst.rowCounts.get(path); !ok || cached != 3 {
+		t.Errorf("expected counted rows to be cached, got: %d, %t", cached, ok)
+	}
+
+	ref = "me/dataset_does_not_exist"
+	if err := req.RowCount(&ref, &count); err == nil {
+		t.Error("expected row count of a missing dataset to error")
+	}
+}
+
+func TestDatasetRequestsEstimateSize(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req := NewDatasetRequestsInstance(NewInstanceFromConfigAndNode(config.DefaultConfigForTesting(), node))
+
+	ref := "me/cities"
+	est := SizeEstimate{}
+	if err := req.EstimateSize(&ref, &est); err != nil {
+		t.Fatal(err)
+	}
+	if est.Entries != 5 || est.BodyBytes == 0 {
+		t.Errorf("expected estimate to report stored entries & length, got: %#v", est)
+	}
+	if est.DecodedBytes <= int64(est.BodyBytes) {
+		t.Errorf("expected decoded size to exceed body length, got: %d <= %d", est.DecodedBytes, est.BodyBytes)
+	}
+
+	ref = "me/dataset_does_not_exist"
+	if err := req.EstimateSize(&ref, &est); err == nil {
+		t.Error("expected estimating a missing dataset to error")
+	}
+}
+
+func TestDatasetRequestsGetSchema(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	inst := NewInstanceFromConfigAndNode(config.DefaultConfigForTesting(), node)
+	req := NewDatasetRequestsInstance(inst)
+
+	for _, ref := range []string{"", "me/dataset_does_not_exist"} {
+		if err := req.GetSchema(&ref, &SchemaResult{}); err == nil {
+			t.Errorf("expected getting the schema of %q to error", ref)
+		}
+	}
+
+	ref := "me/cities"
+	res := SchemaResult{}
+	if err := req.GetSchema(&ref, &res); err != nil {
+		t.Fatal(err)
+	}
+	stored := res.Schema
+	if !strings.Contains(string(stored), `"title":"city"`) {
+		t.Errorf("expected stored schema to describe cities columns, got: %s", stored)
+	}
+
+	// linked datasets read the schema from the working directory
+	dir, err := ioutil.TempDir("", "QriTestDatasetRequestsGetSchema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var out string
+	fsim := NewFSIMethods(inst)
+	if err := fsim.Checkout(&CheckoutParams{Dir: filepath.Join(dir, "cities"), Ref: ref}, &out); err != nil {
+		t.Fatal(err)
+	}
+	stPath := filepath.Join(dir, "cities", "structure.json")
+	data, err := ioutil.ReadFile(stPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = bytes.Replace(data, []byte(`"city"`), []byte(`"town"`), 1)
+	if err := ioutil.WriteFile(stPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := req.GetSchema(&ref, &res); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(res.Schema), `"title":"town"`) {
+		t.Errorf("expected schema from the working directory, got: %s", res.Schema)
+	}
+
+	// a version path selects the stored version
+	versioned := res.Ref
+	if err := req.GetSchema(&versioned, &res); err != nil {
+		t.Fatal(err)
+	}
+	if string(res.Schema) != string(stored) {
+		t.Errorf("expected a version path to read the stored schema, got: %s", res.Schema)
+	}
+}
+
 // Convert the interface value into an array, or panic if not possible
 func mustBeArray(i interface{}, err error) []interface{} {
 	if err != nil {
@@ -1030,6 +1586,57 @@ func mustBeArray(i interface{}, err error) []interface{} {
 	return i.([]interface{})
 }
 
+func TestDatasetRequestsManifestMissingRemote(t *testing.T) {
+	req := NewDatasetRequests(nil, nil)
+
+	p := &ManifestMissingRemoteParams{
+		Local:  &dag.Manifest{Links: [][2]int{{0, 1}, {0, 2}}, Nodes: []string{"root", "a", "b"}},
+		Remote: &dag.Manifest{Links: [][2]int{{0, 1}}, Nodes: []string{"old_root", "a"}},
+	}
+	got := dag.Manifest{}
+	if err := req.ManifestMissingRemote(p, &got); err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"root", "b"}
+	if diff := cmp.Diff(expect, got.Nodes); diff != "" {
+		t.Errorf("missing blocks mismatch. (-want +got):\n%s", diff)
+	}
+
+	if err := req.ManifestMissingRemote(&ManifestMissingRemoteParams{Local: p.Local}, &got); err == nil {
+		t.Error("expected a missing remote manifest to error")
+	}
+}
+
+func TestDatasetRequestsListByTag(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+	req := NewDatasetRequestsInstance(tr.Instance)
+
+	bodyPath := tr.writeFile(t, "body.json", "[1,2,3]")
+	for name, keywords := range map[string][]string{
+		"tagged_rainfall": {"Climate", "weather"},
+		"tagged_budget":   {"finance"},
+	} {
+		p := &SaveParams{
+			Ref:      "me/" + name,
+			BodyPath: bodyPath,
+			Dataset:  &dataset.Dataset{Meta: &dataset.Meta{Keywords: keywords}},
+		}
+		if err := req.Save(p, &SaveResult{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tag := "climate"
+	res := []reporef.DatasetRef{}
+	if err := req.ListByTag(&tag, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Name != "tagged_rainfall" {
+		t.Errorf("expected only tagged_rainfall to be tagged %q, got: %v", tag, res)
+	}
+}
+
 func TestListRawRefs(t *testing.T) {
 	// TODO(dlong): Put a TestRunner instance here
 
@@ -1092,3 +1699,295 @@ func TestListRawRefs(t *testing.T) {
 		t.Errorf("result mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestDatasetRequestsContentHash(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req := NewDatasetRequestsInstance(NewInstanceFromConfigAndNode(config.DefaultConfigForTesting(), node))
+
+	for _, ref := range []string{"", "me/dataset_does_not_exist"} {
+		var res string
+		if err := req.ContentHash(&ref, &res); err == nil {
+			t.Errorf("expected hashing %q to error", ref)
+		}
+	}
+
+	hashes := map[string]string{}
+	for _, ref := range []string{"me/cities", "me/movies"} {
+		var res string
+		if err := req.ContentHash(&ref, &res); err != nil {
+			t.Fatal(err)
+		}
+		if res == "" {
+			t.Errorf("expected a content hash for %s", ref)
+		}
+		hashes[ref] = res
+	}
+	if hashes["me/cities"] == hashes["me/movies"] {
+		t.Error("expected datasets with different data to have different content hashes")
+	}
+}
+
+func TestDatasetRequestsDetectFormat(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "detect_format")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	path := filepath.Join(tmp, "mystery.txt")
+	if err := ioutil.WriteFile(path, []byte("city;pop\ntoronto;40000000\nnew york;8500000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	req := NewDatasetRequests(newTestQriNode(t), nil)
+	res := FormatDetection{}
+	if err := req.DetectFormat(&path, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Format != "csv" || res.Delimiter != ";" || res.Encoding != "utf-8" || !res.HeaderRow {
+		t.Errorf("unexpected detection: %#v", res)
+	}
+
+	missing := filepath.Join(tmp, "missing.csv")
+	if err := req.DetectFormat(&missing, &res); err == nil {
+		t.Error("expected detecting a missing file to error")
+	}
+}
+
+func TestDatasetRequestsUpdateMeta(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req := NewDatasetRequestsInstance(NewInstanceFromConfigAndNode(config.DefaultConfigForTesting(), node))
+
+	prev, err := repo.ParseDatasetRef("me/cities")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CanonicalizeDatasetRef(mr, &prev); err != nil {
+		t.Fatal(err)
+	}
+
+	res := reporef.DatasetRef{}
+	p := &UpdateMetaParams{
+		Ref: "me/cities",
+		Changes: map[string]interface{}{
+			"description": "updated description",
+			"keywords":    []string{"cities", "population"},
+		},
+	}
+	if err := req.UpdateMeta(p, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Path == prev.Path {
+		t.Error("expected a new version")
+	}
+	ds := res.Dataset
+	if ds.Meta.Description != "updated description" {
+		t.Errorf("description mismatch. got: %q", ds.Meta.Description)
+	}
+	if diff := cmp.Diff([]string{"cities", "population"}, ds.Meta.Keywords); diff != "" {
+		t.Errorf("keywords mismatch (-want +got):\n%s", diff)
+	}
+	if ds.Meta.Title == "" {
+		t.Error("expected unchanged title to be kept")
+	}
+	if ds.Structure == nil || ds.BodyPath == "" {
+		t.Error("expected structure & body to be kept")
+	}
+	if expect := "updated metadata: description, keywords"; ds.Commit.Title != expect {
+		t.Errorf("commit title mismatch. want: %q got: %q", expect, ds.Commit.Title)
+	}
+
+	// updating to the same values is an error
+	if err := req.UpdateMeta(p, &res); err == nil {
+		t.Error("expected updating to current values to error")
+	}
+	if err := req.UpdateMeta(&UpdateMetaParams{Ref: "me/not_a_dataset", Changes: p.Changes}, &res); err == nil {
+		t.Error("expected updating a missing dataset to error")
+	}
+}
+
+func TestDatasetRequestsUpdateColumn(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	inst := NewInstanceFromConfigAndNode(config.DefaultConfigForTesting(), node)
+	req := NewDatasetRequestsInstance(inst)
+
+	res := reporef.DatasetRef{}
+	p := &UpdateColumnParams{
+		Ref:    "me/cities",
+		Column: "pop",
+		Changes: map[string]interface{}{
+			"description": "number of residents",
+			"unit":        "people",
+		},
+	}
+	if err := req.UpdateColumn(p, &res); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "updated column metadata: pop"; res.Dataset.Commit.Title != expect {
+		t.Errorf("commit title mismatch. want: %q got: %q", expect, res.Dataset.Commit.Title)
+	}
+	expect := map[string]interface{}{
+		"title":       "pop",
+		"type":        "integer",
+		"description": "number of residents",
+		"unit":        "people",
+	}
+	checkPop := func(ds *dataset.Dataset) {
+		t.Helper()
+		if ds.Structure == nil {
+			t.Fatal("expected dataset to have a structure")
+		}
+		cols := base.Columns(ds.Structure.Schema)
+		if len(cols) < 2 {
+			t.Fatalf("expected cities columns, got: %v", cols)
+		}
+		if diff := cmp.Diff(expect, cols[1].Schema); diff != "" {
+			t.Errorf("pop column mismatch (-want +got):\n%s", diff)
+		}
+	}
+	checkPop(res.Dataset)
+
+	if err := req.UpdateColumn(&UpdateColumnParams{Ref: "me/cities", Column: "population", Changes: p.Changes}, &res); err == nil {
+		t.Error("expected updating a missing column to error")
+	}
+	if err := req.UpdateColumn(&UpdateColumnParams{Ref: "me/cities", Column: "pop", Changes: map[string]interface{}{"type": "string"}}, &res); err == nil {
+		t.Error("expected updating a non-metadata keyword to error")
+	}
+
+	// stats carry column metadata
+	sres := &StatsResponse{}
+	if err := req.Stats(&StatsParams{Ref: "me/cities"}, sres); err != nil {
+		t.Fatal(err)
+	}
+	stats := []map[string]interface{}{}
+	if err := json.Unmarshal(sres.StatsBytes, &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats[1]["description"] != "number of residents" || stats[1]["unit"] != "people" {
+		t.Errorf("expected pop stats to be annotated, got: %v", stats[1])
+	}
+
+	// column metadata survives a save, checkout & save with a changed body
+	dir, err := ioutil.TempDir("", "TestDatasetRequestsUpdateColumn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd := filepath.Join(dir, "cities")
+	var out string
+	if err := NewFSIMethods(inst).Checkout(&CheckoutParams{Dir: wd, Ref: "me/cities"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadFile(filepath.Join(wd, "body.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body = append(body, []byte("halifax,400000,41.5,false\n")...)
+	if err := ioutil.WriteFile(filepath.Join(wd, "body.csv"), body, 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved := SaveResult{}
+	if err := req.Save(&SaveParams{Ref: "me/cities", ReadFSI: true, WriteFSI: true}, &saved); err != nil {
+		t.Fatal(err)
+	}
+	checkPop(saved.Dataset)
+
+	// an inferred schema keeps the metadata of the previous version
+	bodyPath := filepath.Join(dir, "body.csv")
+	if err := ioutil.WriteFile(bodyPath, body, 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved = SaveResult{}
+	ds := &dataset.Dataset{Structure: &dataset.Structure{Format: "csv", FormatConfig: map[string]interface{}{"headerRow": true}}}
+	if err := req.Save(&SaveParams{Ref: "me/cities", Dataset: ds, BodyPath: bodyPath, Force: true}, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if cols := base.Columns(saved.Dataset.Structure.Schema); len(cols) < 2 || cols[1].Schema["unit"] != "people" {
+		t.Errorf("expected inferred schema to keep the pop unit, got: %v", cols)
+	}
+}
+
+func TestDatasetRequestsValidateReferences(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	r := NewDatasetRequests(node, nil)
+
+	orders := func(body string) *SaveParams {
+		return &SaveParams{
+			Ref: "me/orders",
+			Dataset: &dataset.Dataset{
+				Meta: &dataset.Meta{Title: "orders"},
+				Structure: &dataset.Structure{
+					Format:       "csv",
+					FormatConfig: map[string]interface{}{"headerRow": true},
+					Schema: map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "array",
+							"items": []interface{}{
+								map[string]interface{}{"title": "id", "type": "integer"},
+								map[string]interface{}{
+									"title":      "city",
+									"type":       "string",
+									"references": map[string]interface{}{"dataset": "me/cities", "key": "city"},
+								},
+							},
+						},
+					},
+				},
+				BodyBytes: []byte(body),
+			},
+		}
+	}
+
+	res := &SaveResult{}
+	p := orders("id,city\n1,toronto\n2,springfield\n")
+	p.EnforceReferences = true
+	if err := r.Save(p, res); err == nil || !strings.Contains(err.Error(), "springfield not found") {
+		t.Errorf("expected enforcing references to fail the save, got: %v", err)
+	}
+	if err := r.Save(orders("id,city\n1,toronto\n2,springfield\n"), res); err != nil {
+		t.Fatal(err)
+	}
+
+	ref := "me/orders"
+	got := []RuleViolation{}
+	if err := r.ValidateReferences(&ref, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Row != 1 {
+		t.Errorf("expected one violation in row 1, got: %v", got)
+	}
+
+	ref = "me/cities"
+	if err := r.ValidateReferences(&ref, &got); err == nil {
+		t.Error("expected validating a dataset without references to error")
+	}
+}
@@ -0,0 +1,217 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/logbook"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// redactedValue replaces secret config values in a debug dump
+const redactedValue = "[redacted]"
+
+// safeConfigFields are the config fields whose string values are included
+// in a debug dump, as lowercased dotted paths. "[]" matches the elements of
+// a list & "*" any key of a map. Every other non-empty string is redacted, so
+// fields added to the config are kept out of dumps until they're listed here.
+// Webhook URLs, commands & remote addresses aren't listed because they often
+// embed tokens
+var safeConfigFields = map[string]bool{
+	"profile.id":       true,
+	"profile.peername": true,
+	"profile.type":     true,
+	"profile.created":  true,
+	"profile.updated":  true,
+
+	"repo.type":              true,
+	"repo.path":              true,
+	"repo.middleware.[]":     true,
+	"repo.updatecheckremote": true,
+
+	"store.type": true,
+	"store.path": true,
+
+	"p2p.peerid":               true,
+	"p2p.pubkey":               true,
+	"p2p.addrs.[]":             true,
+	"p2p.qribootstrapaddrs.[]": true,
+	"p2p.bootstrapaddrs.[]":    true,
+	"p2p.httpgatewayaddr":      true,
+	"p2p.profilereplication":   true,
+
+	"update.type":    true,
+	"update.address": true,
+
+	"stats.cache.type": true,
+	"stats.cache.path": true,
+
+	"registry.location":    true,
+	"remotekeys.*.pubkey":  true,
+	"remote.accesslogpath": true,
+
+	"api.urlroot":               true,
+	"api.allowedorigins.[]":     true,
+	"api.watchactions.[].ref":   true,
+	"api.autopublish.[].ref":    true,
+	"api.autopublish.[].dir":    true,
+	"api.autopublish.[].remote": true,
+	"api.accesstokens.[].scope": true,
+
+	"webapp.entrypointupdateaddress": true,
+	"webapp.entrypointhash":          true,
+
+	"logging.levels.*": true,
+
+	"render.templateupdateaddress": true,
+	"render.defaulttemplatehash":   true,
+}
+
+// DebugDump is a snapshot of repo state for attaching to bug reports. It
+// never includes private keys or other secrets
+type DebugDump struct {
+	Created time.Time `json:"created"`
+	// Refs are the refstore's records as stored
+	Refs []reporef.DatasetRef `json:"refs"`
+	// Logs summarizes the logbook history of each ref
+	Logs []LogSummary `json:"logs"`
+	// NumLogs counts every log in the logbook, including logs without a ref
+	NumLogs int `json:"numLogs"`
+	// Config is the instance configuration with secrets redacted
+	Config map[string]interface{} `json:"config"`
+}
+
+// LogSummary describes the logbook history of a dataset reference
+type LogSummary struct {
+	Ref   string `json:"ref"`
+	LogID string `json:"logID,omitempty"`
+	// Ops counts the operations in the dataset's branch log
+	Ops int `json:"ops"`
+	// Versions counts the versions the log records, Head is the latest
+	Versions int    `json:"versions"`
+	Head     string `json:"head,omitempty"`
+	// Error describes why a log couldn't be read
+	Error string `json:"error,omitempty"`
+}
+
+// DebugDump collects the refstore, a summary of the logbook & the redacted
+// config into one document for bug reports
+func (m *RepoMethods) DebugDump(in *bool, res *DebugDump) error {
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("RepoMethods.DebugDump", in, res)
+	}
+	ctx := context.TODO()
+	r := m.inst.Repo()
+
+	refs, err := base.RawDatasetRefList(ctx, r)
+	if err != nil {
+		return err
+	}
+	cfg, err := redactedConfig(m.inst.Config())
+	if err != nil {
+		return err
+	}
+
+	dump := DebugDump{
+		Created: time.Now(),
+		Refs:    refs,
+		Logs:    []LogSummary{},
+		Config:  cfg,
+	}
+	if book := r.Logbook(); book != nil {
+		logs, err := book.ListAllLogs(ctx)
+		if err != nil {
+			return err
+		}
+		dump.NumLogs = len(logs)
+		for _, ref := range refs {
+			dump.Logs = append(dump.Logs, summarizeLog(ctx, book, ref))
+		}
+	}
+
+	*res = dump
+	return nil
+}
+
+// summarizeLog reads the branch log of a ref. Logs in a repo that needs a
+// bug report may be malformed, so a log that can't be interpreted is reported
+// as an error instead of failing the dump
+func summarizeLog(ctx context.Context, book *logbook.Book, ref reporef.DatasetRef) (sum LogSummary) {
+	sum.Ref = ref.AliasString()
+	defer func() {
+		if r := recover(); r != nil {
+			sum.Error = fmt.Sprintf("interpreting log: %v", r)
+		}
+	}()
+	dsr := reporef.ConvertToDsref(ref)
+	l, err := book.BranchRef(ctx, dsr)
+	if err != nil {
+		sum.Error = err.Error()
+		return sum
+	}
+	sum.LogID = l.ID()
+	sum.Ops = len(l.Ops)
+	versions := logbook.Versions(l, dsr, 0, -1)
+	sum.Versions = len(versions)
+	if len(versions) > 0 {
+		sum.Head = versions[0].Path
+	}
+	return sum
+}
+
+// redactedConfig encodes a config as generic JSON, removing private keys &
+// redacting every string value that isn't in safeConfigFields
+func redactedConfig(cfg *config.Config) (map[string]interface{}, error) {
+	data, err := json.Marshal(cfg.WithoutPrivateValues())
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]interface{}{}
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	for key, val := range res {
+		res[key] = redactUnsafe(strings.ToLower(key), val)
+	}
+	return res, nil
+}
+
+// redactUnsafe replaces the string values of v that safeConfigFields doesn't
+// list. Numbers & booleans can't carry secrets & are kept
+func redactUnsafe(path string, v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for key, val := range x {
+			field := path + "." + strings.ToLower(key)
+			if !safeConfigFields[field] && hasSafeConfigField(path+".*") {
+				field = path + ".*"
+			}
+			x[key] = redactUnsafe(field, val)
+		}
+	case []interface{}:
+		for i, val := range x {
+			x[i] = redactUnsafe(path+".[]", val)
+		}
+	case string:
+		if x != "" && !safeConfigFields[path] {
+			return redactedValue
+		}
+	}
+	return v
+}
+
+// hasSafeConfigField reports whether safeConfigFields lists prefix or a
+// field below it
+func hasSafeConfigField(prefix string) bool {
+	for field := range safeConfigFields {
+		if field == prefix || strings.HasPrefix(field, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package lib
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestRepoMethodsDebugDump(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfigForTesting()
+	cfg.API.AccessTokens = []config.APIAccessToken{{Token: "api-secret", Scope: config.APIScopeWrite}}
+	cfg.Repo.UpdateCheckWebhook = "https://hooks.example.com/webhook-secret"
+	cfg.API.WatchActions = []config.WatchAction{
+		{Ref: "me/cities", URL: "https://hooks.example.com/watch-url-secret"},
+		{Ref: "me/cities", Command: "notify --token watch-command-secret"},
+	}
+	cfg.P2P.SwarmKey = "4f3c1a9e0b7d2c58e6a1f09b3d7c4e2a5b8f1d0c9e6a3b7f2d4c8e1a0b5f9d3c"
+	cfg.Environments = map[string]interface{}{
+		"staging": map[string]interface{}{
+			"profile": map[string]interface{}{"privkey": "environment-secret"},
+		},
+	}
+	inst := NewInstanceFromConfigAndNode(cfg, node)
+
+	res := &DebugDump{}
+	if err := NewRepoMethods(inst).DebugDump(nil, res); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := mr.RefCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Refs) != count || len(res.Logs) != count {
+		t.Errorf("expected %d refs & log summaries, got %d refs & %d logs", count, len(res.Refs), len(res.Logs))
+	}
+	for _, l := range res.Logs {
+		if l.Error == "" && (l.Versions == 0 || l.Head == "") {
+			t.Errorf("%s: expected a summary of the log's versions, got: %#v", l.Ref, l)
+		}
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := []string{
+		cfg.Profile.PrivKey,
+		cfg.P2P.PrivKey,
+		cfg.P2P.SwarmKey,
+		"api-secret",
+		"webhook-secret",
+		"environment-secret",
+		"watch-url-secret",
+		"watch-command-secret",
+	}
+	for _, secret := range secrets {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("dump includes secret %q", secret)
+		}
+	}
+
+	// fields that are safe to dump are kept
+	if peername := res.Config["Profile"].(map[string]interface{})["peername"]; peername != cfg.Profile.Peername {
+		t.Errorf("expected dump to include the profile peername, got: %v", peername)
+	}
+	action := res.Config["API"].(map[string]interface{})["watchactions"].([]interface{})[0].(map[string]interface{})
+	if action["ref"] != "me/cities" || action["url"] != redactedValue {
+		t.Errorf("expected watch action ref to be kept & url redacted, got: %v", action)
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/qri-io/dataset"
 	"github.com/qri-io/deepdiff"
 	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/base/component"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/fsi"
 	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
 )
 
 // Delta is an alias for deepdiff.Delta, abstracting the deepdiff implementation
@@ -43,10 +47,14 @@ type DiffResponse struct {
 	Diff []*Delta    `json:"diff,omitempty"`
 	A    interface{} `json:"b,omitempty"`
 	B    interface{} `json:"a,omitempty"`
+	// Schema classifies body schema changes when both sides are versions
+	// with a schema
+	Schema *SchemaCompatibility `json:"schema,omitempty"`
 }
 
 // Diff computes the diff of two datasets
 func (r *DatasetRequests) Diff(p *DiffParams, res *DiffResponse) (err error) {
+	defer func() { err = wrapErr(err) }()
 	// absolutize any local paths before a possible trip over RPC to another local process
 	if !dsref.IsRefString(p.LeftPath) {
 		if err = qfs.AbsPath(&p.LeftPath); err != nil {
@@ -123,9 +131,13 @@ func (r *DatasetRequests) Diff(p *DiffParams, res *DiffResponse) (err error) {
 		}
 	}
 	leftComp := component.ConvertDatasetToComponents(ds, r.inst.node.Repo.Filesystem())
+	leftSt := ds.Structure
 
 	// Right side of diff
-	var rightComp component.Component
+	var (
+		rightComp component.Component
+		rightSt   *dataset.Structure
+	)
 	if p.WorkingDir != "" {
 		// Working directory, read dataset from the current files.
 		rightComp, err = component.ListDirectoryComponents(p.WorkingDir)
@@ -138,10 +150,11 @@ func (r *DatasetRequests) Diff(p *DiffParams, res *DiffResponse) (err error) {
 		}
 		// TODO(dlong): Hack! This is what fills the value. StucturedData assumes this has been
 		// called. Should cleanup component's API so that this isn't necessary.
-		_, err = component.ToDataset(rightComp)
+		wds, err := component.ToDataset(rightComp)
 		if err != nil {
 			return err
 		}
+		rightSt = wds.Structure
 
 	} else {
 		ref, err := repo.ParseDatasetRef(p.RightPath)
@@ -157,6 +170,10 @@ func (r *DatasetRequests) Diff(p *DiffParams, res *DiffResponse) (err error) {
 			return err
 		}
 		rightComp = component.ConvertDatasetToComponents(ds, r.inst.node.Repo.Filesystem())
+		rightSt = ds.Structure
+	}
+	if leftSt != nil && leftSt.Schema != nil && rightSt != nil && rightSt.Schema != nil {
+		res.Schema = base.CompareSchemas(leftSt.Schema, rightSt.Schema)
 	}
 
 	// If in an FSI linked working directory, drop derived values, since the user is not
@@ -238,3 +255,300 @@ func (r *DatasetRequests) Diff(p *DiffParams, res *DiffResponse) (err error) {
 	res.Diff, err = deepdiff.Diff(leftData, rightData, deepdiff.OptionSetStats(res.Stat))
 	return err
 }
+
+// SchemaCompatibility is an alias for base.SchemaCompatibility, a report of
+// body schema changes between versions
+type SchemaCompatibility = base.SchemaCompatibility
+
+// SchemaCompatParams defines parameters for comparing the body schemas of two
+// versions with SchemaCompat
+type SchemaCompatParams struct {
+	// LeftPath is the older version, RightPath the newer. Without a RightPath
+	// LeftPath is compared to its working directory if WorkingDir is set, and
+	// its previous version otherwise
+	LeftPath, RightPath string
+	// WorkingDir is a linked working directory to compare LeftPath to
+	WorkingDir string
+}
+
+// SchemaCompat classifies the changes between the body schemas of two
+// versions of a dataset, reporting whether the newer schema is backward
+// compatible with the older one
+func (r *DatasetRequests) SchemaCompat(p *SchemaCompatParams, res *SchemaCompatibility) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if p.WorkingDir != "" {
+		if err = qfs.AbsPath(&p.WorkingDir); err != nil {
+			return err
+		}
+	}
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.SchemaCompat", p, res)
+	}
+	ctx := context.TODO()
+
+	if p.LeftPath == "" {
+		return fmt.Errorf("a dataset reference is required")
+	}
+	right, err := r.loadPatchVersion(ctx, p.LeftPath)
+	if err != nil {
+		return err
+	}
+	left := right
+	switch {
+	case p.RightPath != "":
+		if right, err = r.loadPatchVersion(ctx, p.RightPath); err != nil {
+			return err
+		}
+	case p.WorkingDir != "":
+		if right, err = fsi.ReadDir(p.WorkingDir); err != nil {
+			return err
+		}
+	default:
+		if left.PreviousPath == "" {
+			return fmt.Errorf("dataset has only one version, nothing to compare against")
+		}
+		if left, err = dsfs.LoadDataset(ctx, r.inst.node.Repo.Store(), left.PreviousPath); err != nil {
+			return err
+		}
+	}
+
+	*res = *base.CompareSchemas(schemaOf(left), schemaOf(right))
+	return nil
+}
+
+func schemaOf(ds *dataset.Dataset) map[string]interface{} {
+	if ds == nil || ds.Structure == nil {
+		return nil
+	}
+	return ds.Structure.Schema
+}
+
+// Patch is an alias for base.Patch, a portable set of changes between two
+// versions of a dataset
+type Patch = base.Patch
+
+// DiffPatchParams defines parameters for creating a patch with DiffPatch
+type DiffPatchParams struct {
+	// References to the versions to create a patch between
+	LeftPath, RightPath string
+}
+
+// DiffPatch creates a patch of the changes from one dataset version to
+// another, suitable for applying to a different dataset with ApplyPatch
+func (r *DatasetRequests) DiffPatch(p *DiffPatchParams, res *Patch) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.DiffPatch", p, res)
+	}
+	ctx := context.TODO()
+
+	a, err := r.loadPatchVersion(ctx, p.LeftPath)
+	if err != nil {
+		return err
+	}
+	b, err := r.loadPatchVersion(ctx, p.RightPath)
+	if err != nil {
+		return err
+	}
+
+	patch, err := base.DiffPatch(ctx, r.inst.node.Repo.Store(), a, b)
+	if err != nil {
+		return err
+	}
+	*res = *patch
+	return nil
+}
+
+// ApplyPatchParams defines parameters for applying a patch with ApplyPatch
+type ApplyPatchParams struct {
+	// Ref is the dataset to apply the patch to
+	Ref   string
+	Patch *Patch
+	// Title & Message set commit details for the new version
+	Title, Message string
+}
+
+// ApplyPatch saves a new version of a dataset with the changes in a patch.
+// If the dataset no longer holds the values the patch changes from, no
+// version is saved & ApplyPatch returns a *base.ErrPatchConflict describing
+// each conflict
+func (r *DatasetRequests) ApplyPatch(p *ApplyPatchParams, res *reporef.DatasetRef) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.ApplyPatch", p, res)
+	}
+	ctx := context.TODO()
+	defer r.inst.lockWrites()()
+
+	if p.Patch == nil {
+		return fmt.Errorf("patch is required")
+	}
+
+	ref, err := repo.ParseDatasetRef(p.Ref)
+	if err != nil {
+		return err
+	}
+	if err = repo.CanonicalizeDatasetRef(r.inst.node.Repo, &ref); err != nil {
+		if err == repo.ErrNoHistory {
+			return fmt.Errorf("dataset has no versions, nothing to apply a patch to")
+		}
+		return err
+	}
+	target, err := dsfs.LoadDataset(ctx, r.inst.node.Repo.Store(), ref.Path)
+	if err != nil {
+		return err
+	}
+	target.Peername = ref.Peername
+	target.Name = ref.Name
+
+	ds, err := base.ApplyPatch(ctx, r.inst.node.Repo.Store(), target, p.Patch)
+	if err != nil {
+		return err
+	}
+	ds.Commit = &dataset.Commit{Title: p.Title, Message: p.Message}
+	if ds.Commit.Message == "" && p.Patch.From != "" {
+		ds.Commit.Message = fmt.Sprintf("applied changes from %s to %s", p.Patch.From, p.Patch.To)
+	}
+	if err = base.OpenDataset(ctx, r.inst.node.Repo.Filesystem(), ds); err != nil {
+		return err
+	}
+
+	switches := base.SaveDatasetSwitches{
+		Replace:      true,
+		Pin:          true,
+		ShouldRender: true,
+	}
+	saved, _, err := base.SaveDataset(ctx, r.inst.node.Repo, r.inst.node.LocalStreams, ds, nil, nil, r.inst.withSaveHooks(switches))
+	if err != nil {
+		return err
+	}
+	*res = saved
+	return nil
+}
+
+// loadPatchVersion loads the dataset version a reference points to
+func (r *DatasetRequests) loadPatchVersion(ctx context.Context, refstr string) (*dataset.Dataset, error) {
+	ref, err := repo.ParseDatasetRef(refstr)
+	if err != nil {
+		return nil, err
+	}
+	if err = repo.CanonicalizeDatasetRef(r.inst.node.Repo, &ref); err != nil {
+		if err == repo.ErrNoHistory {
+			return nil, fmt.Errorf("dataset %s has no versions, nothing to diff", refstr)
+		}
+		return nil, err
+	}
+	return dsfs.LoadDataset(ctx, r.inst.node.Repo.Store(), ref.Path)
+}
+
+// MergeConflict is a value both sides of a merge changed differently
+type MergeConflict = base.MergeConflict
+
+// MergeParams defines parameters for merging two versions of a dataset
+type MergeParams struct {
+	// Ref is the dataset the merge is saved to
+	Ref string
+	// LeftPath is the version changes are merged into, defaulting to the
+	// latest version of Ref
+	LeftPath string
+	// RightPath is the version with changes to merge
+	RightPath string
+	// BasePath is the common ancestor of both versions. It's found by walking
+	// the history of each version when empty
+	BasePath string
+	// Key is a body column that identifies rows, for merging array bodies row
+	// by row. Without a key a body changed on both sides conflicts
+	Key string
+	// Title & Message set commit details for the merge version
+	Title, Message string
+}
+
+// MergeResult is the outcome of a merge, either a new version or a list of
+// conflicts
+type MergeResult struct {
+	// BasePath is the common ancestor the merge was made from
+	BasePath string
+	// Ref is the saved merge version, empty if there are conflicts
+	Ref *reporef.DatasetRef
+	// Conflicts lists values both sides changed differently, nothing is saved
+	// if there are any
+	Conflicts []*MergeConflict
+}
+
+// Merge performs a three-way merge of two divergent versions of a dataset,
+// combining the changes each made since their common ancestor into a new
+// version of Ref. Meta merges by field & bodies by row when Key names a column
+// identifying rows. If both sides change a value differently nothing is saved,
+// & the conflicts are listed in the result
+func (r *DatasetRequests) Merge(p *MergeParams, res *MergeResult) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Merge", p, res)
+	}
+	ctx := context.TODO()
+	defer r.inst.lockWrites()()
+	store := r.node.Repo.Store()
+
+	if p.RightPath == "" {
+		return fmt.Errorf("a version to merge is required")
+	}
+	ref, head, err := r.loadHead(ctx, p.Ref)
+	if err != nil {
+		return err
+	}
+	left := head
+	if p.LeftPath != "" {
+		if left, err = r.loadPatchVersion(ctx, p.LeftPath); err != nil {
+			return err
+		}
+	}
+	right, err := r.loadPatchVersion(ctx, p.RightPath)
+	if err != nil {
+		return err
+	}
+
+	var ancestor *dataset.Dataset
+	if p.BasePath != "" {
+		ancestor, err = r.loadPatchVersion(ctx, p.BasePath)
+	} else {
+		var basePath string
+		if basePath, err = base.CommonAncestor(ctx, store, left.Path, right.Path); err != nil {
+			return err
+		}
+		ancestor, err = dsfs.LoadDataset(ctx, store, basePath)
+	}
+	if err != nil {
+		return err
+	}
+	left.Peername, left.Name = ref.Peername, ref.Name
+
+	ds, err := base.MergeDatasets(ctx, store, ancestor, left, right, p.Key)
+	if conflict, ok := err.(*base.ErrMergeConflict); ok {
+		*res = MergeResult{BasePath: ancestor.Path, Conflicts: conflict.Conflicts}
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	ds.Commit = &dataset.Commit{Title: p.Title, Message: p.Message}
+	if ds.Commit.Message == "" {
+		ds.Commit.Message = fmt.Sprintf("merged %s into %s", right.Path, left.Path)
+	}
+	switches := base.SaveDatasetSwitches{
+		Replace:      true,
+		Pin:          true,
+		ShouldRender: true,
+	}
+	saved, _, err := base.SaveDataset(ctx, r.node.Repo, r.node.LocalStreams, ds, nil, nil, r.inst.withSaveHooks(switches))
+	if err != nil {
+		return err
+	}
+	if ref.FSIPath != "" {
+		saved.FSIPath = ref.FSIPath
+		if err = r.node.Repo.PutRef(saved); err != nil {
+			return err
+		}
+	}
+	*res = MergeResult{BasePath: ancestor.Path, Ref: &saved}
+	return nil
+}
@@ -2,9 +2,14 @@ package lib
 
 import (
 	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/repo"
 	reporef "github.com/qri-io/qri/repo/ref"
 )
 
@@ -19,7 +24,7 @@ func TestDatasetRequestsDiff(t *testing.T) {
 	djsOnePath := tr.writeFile(t, "djs_1.json", `{ "dj dj booth": { "rating": 1, "uses_soundcloud": true } }`)
 	djsTwoPath := tr.writeFile(t, "djs_2.json", `{ "DJ dj booth": { "rating": 1, "uses_soundcloud": true } }`)
 
-	dsRef1 := reporef.DatasetRef{}
+	dsRef1 := SaveResult{}
 	initParams := &SaveParams{
 		Ref:      "me/jobs_ranked_by_automation_prob",
 		BodyPath: jobsOnePath,
@@ -28,7 +33,7 @@ func TestDatasetRequestsDiff(t *testing.T) {
 		t.Fatalf("couldn't save: %s", err.Error())
 	}
 
-	dsRef2 := reporef.DatasetRef{}
+	dsRef2 := SaveResult{}
 	initParams = &SaveParams{
 		Ref:      "me/jobs_ranked_by_automation_prob",
 		BodyPath: jobsTwoPath,
@@ -47,13 +52,13 @@ func TestDatasetRequestsDiff(t *testing.T) {
 		{"two fully qualified references",
 			dsRef1.String(), dsRef2.String(),
 			"",
-			&DiffStat{Left: 41, Right: 42, LeftWeight: 2548, RightWeight: 2770, Inserts: 0, Updates: 8, Deletes: 0, Moves: 0},
+			&DiffStat{Left: 41, Right: 42, LeftWeight: 2548, RightWeight: 2632, Inserts: 0, Updates: 8, Deletes: 0, Moves: 0},
 			8,
 		},
 		{"fill left path from history",
-			dsRef2.AliasString(), dsRef2.AliasString(),
+			dsRef2.Ref().AliasString(), dsRef2.Ref().AliasString(),
 			"",
-			&DiffStat{Left: 41, Right: 42, LeftWeight: 2548, RightWeight: 2770, Inserts: 0, Updates: 8, Deletes: 0, Moves: 0},
+			&DiffStat{Left: 41, Right: 42, LeftWeight: 2548, RightWeight: 2632, Inserts: 0, Updates: 8, Deletes: 0, Moves: 0},
 			8,
 		},
 		{"two local file paths",
@@ -109,6 +114,136 @@ func TestDatasetRequestsDiff(t *testing.T) {
 	}
 }
 
+func TestDatasetRequestsDiffApplyPatch(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	save := func(ref, body, readme string) reporef.DatasetRef {
+		res := SaveResult{}
+		p := &SaveParams{
+			Ref:      ref,
+			BodyPath: tr.writeFile(t, "body.csv", body),
+			Dataset:  &dataset.Dataset{Readme: &dataset.Readme{ScriptBytes: []byte(readme)}},
+		}
+		if err := req.Save(p, &res); err != nil {
+			t.Fatalf("saving %s: %s", ref, err)
+		}
+		return res.Ref()
+	}
+
+	v1 := save("me/upstream", "name,count\nfoo,1\nbar,2\n", "# upstream")
+	v2 := save("me/upstream", "name,count\nfoo,1\nbar,3\n", "# upstream, fixed")
+	fork := save("me/fork", "name,count\nfoo,5\nbar,2\n", "# upstream")
+
+	patch := &Patch{}
+	if err := req.DiffPatch(&DiffPatchParams{LeftPath: v1.String(), RightPath: v2.String()}, patch); err != nil {
+		t.Fatal(err)
+	}
+	addrs := []string{}
+	for _, c := range patch.Changes {
+		addrs = append(addrs, c.Address())
+	}
+	if diff := cmp.Diff([]string{"readme.script", "body.1"}, addrs); diff != "" {
+		t.Errorf("patch changes mismatch (-want +got):\n%s", diff)
+	}
+
+	res := reporef.DatasetRef{}
+	if err := req.ApplyPatch(&ApplyPatchParams{Ref: "me/fork", Patch: patch}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Path == fork.Path {
+		t.Fatal("expected applying a patch to create a new version")
+	}
+
+	got := &GetResult{}
+	if err := req.Get(&GetParams{Path: "me/fork", Selector: "body", Format: "csv", All: true}, got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("name,count\nfoo,5\nbar,3\n", string(got.Bytes)); diff != "" {
+		t.Errorf("patched body mismatch (-want +got):\n%s", diff)
+	}
+
+	// the fork now matches the patch's changed values, diverge it & re-apply
+	// the reverse to get a conflict
+	save("me/fork", "name,count\nfoo,1\nbar,4\n", "# upstream, fixed")
+	reverse := &Patch{}
+	if err := req.DiffPatch(&DiffPatchParams{LeftPath: v2.String(), RightPath: v1.String()}, reverse); err != nil {
+		t.Fatal(err)
+	}
+	err := req.ApplyPatch(&ApplyPatchParams{Ref: "me/fork", Patch: reverse}, &res)
+	if _, ok := err.(*base.ErrPatchConflict); !ok {
+		t.Errorf("expected a patch conflict error, got: %v", err)
+	}
+}
+
+func TestDatasetRequestsMerge(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	r := tr.Instance.node.Repo
+	save := func(body string) reporef.DatasetRef {
+		res := SaveResult{}
+		p := &SaveParams{Ref: "me/shared", BodyPath: tr.writeFile(t, "body.csv", body)}
+		if err := req.Save(p, &res); err != nil {
+			t.Fatal(err)
+		}
+		return res.Ref()
+	}
+	// diverge saves a version on top of from, as another peer editing the same
+	// history would, leaving head as the latest version
+	diverge := func(from, head reporef.DatasetRef, body string) reporef.DatasetRef {
+		if err := r.PutRef(from); err != nil {
+			t.Fatal(err)
+		}
+		ref := save(body)
+		if err := r.PutRef(head); err != nil {
+			t.Fatal(err)
+		}
+		return ref
+	}
+
+	ancestor := save("name,count\nfoo,1\nbar,2\n")
+	left := save("name,count\nfoo,10\nbar,2\n")
+	right := diverge(ancestor, left, "name,count\nfoo,1\nbar,2\nbaz,3\n")
+
+	res := MergeResult{}
+	if err := req.Merge(&MergeParams{Ref: "me/shared", RightPath: right.String(), Key: "name"}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.BasePath != ancestor.Path {
+		t.Errorf("expected common ancestor %q, got: %q", ancestor.Path, res.BasePath)
+	}
+	if len(res.Conflicts) != 0 || res.Ref == nil {
+		t.Fatalf("expected a clean merge, got conflicts: %v", res.Conflicts)
+	}
+	got := &GetResult{}
+	if err := req.Get(&GetParams{Path: "me/shared", Selector: "body", Format: "csv", All: true}, got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("name,count\nfoo,10\nbar,2\nbaz,3\n", string(got.Bytes)); diff != "" {
+		t.Errorf("merged body mismatch (-want +got):\n%s", diff)
+	}
+
+	merged := *res.Ref
+	conflicting := diverge(ancestor, merged, "name,count\nfoo,5\nbar,2\n")
+	res = MergeResult{}
+	if err := req.Merge(&MergeParams{Ref: "me/shared", RightPath: conflicting.String(), Key: "name"}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Conflicts) != 1 || res.Conflicts[0].Address() != "body.foo" || res.Ref != nil {
+		t.Errorf("expected a conflict on row foo, got: %v", res.Conflicts)
+	}
+	head := reporef.DatasetRef{Peername: "me", Name: "shared"}
+	if err := repo.CanonicalizeDatasetRef(r, &head); err != nil {
+		t.Fatal(err)
+	}
+	if head.Path != merged.Path {
+		t.Error("expected a conflicted merge to leave the dataset unchanged")
+	}
+}
+
 const jobsByAutomationData1 = `
 rank,probability_of_automation,soc_code,job_title
 702,"0.99","41-9041","Telemarketers"
@@ -176,3 +311,66 @@ rank,probability_of_automation,industry_code,job_name
 674,"0.98","53-3031","Driver/Sales Workers"
 673,"0.98","27-4013","Radio Operators"
 `
+
+func TestDatasetRequestsSchemaCompat(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	save := func(body string, reject, force bool) error {
+		header := strings.Split(strings.SplitN(body, "\n", 2)[0], ",")
+		cols := make([]interface{}, len(header))
+		for i, title := range header {
+			cols[i] = map[string]interface{}{"title": title, "type": "string"}
+		}
+		p := &SaveParams{
+			Ref:      "me/schema_compat",
+			BodyPath: tr.writeFile(t, "body.csv", body),
+			Dataset: &dataset.Dataset{Structure: &dataset.Structure{
+				Format:       "csv",
+				FormatConfig: map[string]interface{}{"headerRow": true},
+				Schema: map[string]interface{}{
+					"type":  "array",
+					"items": map[string]interface{}{"type": "array", "items": cols},
+				},
+			}},
+			RejectBreakingSchema: reject,
+			Force:                force,
+		}
+		return req.Save(p, &SaveResult{})
+	}
+
+	if err := save("city,pop\ntoronto,100\n", true, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := save("city,pop,state\ntoronto,100,on\n", true, false); err != nil {
+		t.Fatalf("expected appending a column to be compatible, got: %s", err)
+	}
+	res := SchemaCompatibility{}
+	if err := req.SchemaCompat(&SchemaCompatParams{LeftPath: "me/schema_compat"}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.Compatible || len(res.Changes) != 1 || res.Changes[0].Kind != base.SchemaColumnAdded {
+		t.Errorf("expected one compatible column_added change, got: %#v", res)
+	}
+
+	err := save("city,state\ntoronto,on\n", true, false)
+	if !errors.Is(err, ErrBreakingSchemaChange) {
+		t.Fatalf("expected removing a column to fail with ErrBreakingSchemaChange, got: %v", err)
+	}
+	expect := `breaking schema change: column "pop" removed, column "state" moved from position 2 to 1`
+	if err.Error() != expect {
+		t.Errorf("error mismatch.\nwant: %s\ngot:  %s", expect, err)
+	}
+	if err := save("city,state\ntoronto,on\n", true, true); err != nil {
+		t.Fatalf("expected a forced save to allow a breaking change, got: %s", err)
+	}
+
+	diff := &DiffResponse{}
+	if err := req.Diff(&DiffParams{LeftPath: "me/schema_compat", RightPath: "me/schema_compat", IsLeftAsPrevious: true}, diff); err != nil {
+		t.Fatal(err)
+	}
+	if diff.Schema == nil || diff.Schema.Compatible {
+		t.Errorf("expected diff to report a breaking schema change, got: %#v", diff.Schema)
+	}
+}
@@ -0,0 +1,71 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/repo"
+)
+
+// DiskUsageParams configures reporting a repo's disk usage
+type DiskUsageParams struct{}
+
+// DiskUsageResult describes how many bytes a repo stores & its quotas
+type DiskUsageResult struct {
+	Bytes int64 `json:"bytes"`
+	// MaxSize & MaxVersionSize are the repo's quotas, zero means no limit
+	MaxSize        int64 `json:"maxSize,omitempty"`
+	MaxVersionSize int64 `json:"maxVersionSize,omitempty"`
+	// Recalculated is the last time usage was measured. Usage is a running
+	// estimate between measurements, zero if usage has never been measured
+	Recalculated time.Time `json:"recalculated"`
+}
+
+// DiskUsage reports the running account of bytes the repo stores
+func (m *RepoMethods) DiskUsage(p *DiskUsageParams, res *DiskUsageResult) error {
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("RepoMethods.DiskUsage", p, res)
+	}
+	u := base.DiskUsageOf(m.inst.Repo())
+	if u == nil {
+		return fmt.Errorf("repo doesn't keep disk usage")
+	}
+	*res = diskUsageResult(u)
+	return nil
+}
+
+// RecalculateDiskUsage measures the bytes the repo stores, correcting the
+// running account. Pinned blocks are measured when the repo's store can list
+// pins, otherwise the bodies of each dataset's history are
+func (m *RepoMethods) RecalculateDiskUsage(p *DiskUsageParams, res *DiskUsageResult) error {
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("RepoMethods.RecalculateDiskUsage", p, res)
+	}
+	ctx := context.TODO()
+
+	u := base.DiskUsageOf(m.inst.Repo())
+	if u == nil {
+		return fmt.Errorf("repo doesn't keep disk usage")
+	}
+	ps, err := nodePinStore(m.inst.Node())
+	if err != nil && err != repo.ErrNotPinner {
+		return err
+	}
+	if _, err := base.RecalculateDiskUsage(ctx, m.inst.Repo(), ps); err != nil {
+		return err
+	}
+	*res = diskUsageResult(u)
+	return nil
+}
+
+func diskUsageResult(u *repo.DiskUsage) DiskUsageResult {
+	maxSize, maxVersionSize := u.Quota()
+	return DiskUsageResult{
+		Bytes:          u.Bytes(),
+		MaxSize:        maxSize,
+		MaxVersionSize: maxVersionSize,
+		Recalculated:   u.Recalculated(),
+	}
+}
@@ -0,0 +1,94 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestRepoMethodsDiskUsage(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst := NewInstanceFromConfigAndNode(config.DefaultConfigForTesting(), node)
+	m := NewRepoMethods(inst)
+	req := NewDatasetRequestsInstance(inst)
+
+	// test repos are built without accounting, recalculating measures them
+	res := &DiskUsageResult{}
+	if err := m.RecalculateDiskUsage(&DiskUsageParams{}, res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Bytes == 0 || res.Recalculated.IsZero() {
+		t.Fatalf("expected recalculating to measure test datasets, got: %#v", res)
+	}
+	measured := res.Bytes
+
+	body := []byte(`[1,2,3,4,5]`)
+	save := func(body []byte) error {
+		return req.Save(&SaveParams{
+			Ref:     "me/usage",
+			Dataset: &dataset.Dataset{Meta: &dataset.Meta{Title: string(body)}, BodyBytes: body},
+		}, &SaveResult{})
+	}
+	if err := save(body); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DiskUsage(&DiskUsageParams{}, res); err != nil {
+		t.Fatal(err)
+	}
+	if expect := measured + int64(len(body)); res.Bytes != expect {
+		t.Errorf("expected saving to add body length. expected: %d, got: %d", expect, res.Bytes)
+	}
+
+	// an unchanged body adds nothing, even with a quota it would break
+	base.DiskUsageOf(mr).SetQuota(res.Bytes+5, 0)
+	if err := req.Save(&SaveParams{
+		Ref:     "me/usage",
+		Dataset: &dataset.Dataset{Meta: &dataset.Meta{Title: "same body"}, BodyBytes: body},
+	}, &SaveResult{}); err != nil {
+		t.Errorf("expected saving an unchanged body within quota, got: %s", err)
+	}
+
+	err = save([]byte(`[1,2,3,4,5,6,7,8,9]`))
+	qe, ok := err.(*repo.QuotaExceededError)
+	if !ok {
+		t.Fatalf("expected a QuotaExceededError, got: %v", err)
+	}
+	if qe.Quota != repo.QuotaRepoSize || qe.Usage != measured+int64(len(body)) || qe.Limit != measured+int64(len(body))+5 {
+		t.Errorf("unexpected quota error: %#v", qe)
+	}
+
+	base.DiskUsageOf(mr).SetQuota(0, 8)
+	if err := save([]byte(`[1,2,3]`)); err != nil {
+		t.Errorf("expected version within size limit to save, got: %s", err)
+	}
+	err = save([]byte(`[1,2,3,4]`))
+	if qe, ok := err.(*repo.QuotaExceededError); !ok || qe.Quota != repo.QuotaVersionSize {
+		t.Errorf("expected version size quota to be exceeded, got: %v", err)
+	}
+
+	// recalculating replaces a drifted estimate
+	if err := base.DiskUsageOf(mr).Add(1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RecalculateDiskUsage(&DiskUsageParams{}, res); err != nil {
+		t.Fatal(err)
+	}
+	if expect := measured + int64(len(body)) + 7; res.Bytes != expect {
+		t.Errorf("recalculated usage mismatch. expected: %d, got: %d", expect, res.Bytes)
+	}
+	if res.MaxVersionSize != 8 {
+		t.Errorf("expected usage to report quota, got: %d", res.MaxVersionSize)
+	}
+}
@@ -1,6 +1,18 @@
 package lib
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/fsi"
+	"github.com/qri-io/qri/remote"
+	"github.com/qri-io/qri/repo"
+)
 
 // Error wraps an error and satisfies the error interface
 // It couples more developer focused errors with more
@@ -31,3 +43,123 @@ func NewError(err error, msg string) Error {
 
 // ErrBadArgs is an error for when a user provides bad arguments
 var ErrBadArgs = errors.New("bad arguments provided")
+
+// Kinds of errors lib methods return. DatasetRequests, FSIMethods &
+// RemoteMethods return errors that match one of these kinds with errors.Is
+// where a failure has one, keeping the message of the underlying error.
+// Kinds don't survive RPC, errors from a connected instance are plain
+var (
+	// ErrInvalidRef means a dataset reference is empty or can't be parsed
+	ErrInvalidRef = errors.New("invalid dataset reference")
+	// ErrNotFound means a dataset, version or block doesn't exist
+	ErrNotFound = repo.ErrNotFound
+	// ErrNoChanges means saving would create a version identical to the
+	// previous one
+	ErrNoChanges = dsfs.ErrNoChanges
+	// ErrNoHistory means a dataset has no saved versions
+	ErrNoHistory = repo.ErrNoHistory
+	// ErrNotLinked means a dataset isn't linked to a working directory
+	ErrNotLinked = fsi.ErrNoLink
+	// ErrAlreadyExists means a dataset name or working directory is taken
+	ErrAlreadyExists = repo.ErrAlreadyExists
+	// ErrUnauthorizedRemote means a remote refused a request, or a response
+	// couldn't be verified as coming from the remote
+	ErrUnauthorizedRemote = errors.New("unauthorized remote")
+	// ErrRemoteUnreachable means a remote couldn't be contacted
+	ErrRemoteUnreachable = errors.New("remote unreachable")
+	// ErrBodyTooLarge means a body is larger than a save accepts
+	ErrBodyTooLarge = base.ErrBodyTooLarge
+	// ErrForbidden means a dataset can't be read by the caller, like the
+	// unpublished dataset of a peer
+	ErrForbidden = errors.New("forbidden")
+	// ErrBreakingSchemaChange means a save changes the body schema in a way
+	// that isn't backward compatible
+	ErrBreakingSchemaChange = base.ErrBreakingSchemaChange
+	// ErrInvalidSignature means a version's commit signature doesn't verify
+	// against its author's key
+	ErrInvalidSignature = base.ErrInvalidSignature
+)
+
+var errKinds = []error{
+	ErrInvalidRef,
+	ErrNotFound,
+	ErrNoChanges,
+	ErrNoHistory,
+	ErrNotLinked,
+	ErrAlreadyExists,
+	ErrUnauthorizedRemote,
+	ErrRemoteUnreachable,
+	ErrBodyTooLarge,
+	ErrForbidden,
+	ErrBreakingSchemaChange,
+	ErrInvalidSignature,
+}
+
+// kindError classes an error as one of the kinds lib methods return without
+// changing its message
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e kindError) Error() string        { return e.err.Error() }
+func (e kindError) Unwrap() error        { return e.err }
+func (e kindError) Is(target error) bool { return target == e.kind }
+
+// wrapErr classes err as a kind of error if it isn't already one. Errors
+// that don't have a kind are returned unchanged
+func wrapErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, kind := range errKinds {
+		if errors.Is(err, kind) {
+			return err
+		}
+	}
+
+	if errors.Is(err, repo.ErrEmptyRef) || errors.Is(err, repo.ErrMalformedRef) || errors.Is(err, dsref.ErrParseError) {
+		return kindError{kind: ErrInvalidRef, err: err}
+	}
+	if errors.Is(err, cafs.ErrNotFound) {
+		return kindError{kind: ErrNotFound, err: err}
+	}
+	if errors.Is(err, fsi.ErrAlreadyLinked) {
+		return kindError{kind: ErrAlreadyExists, err: err}
+	}
+
+	// remote errors are matched by code
+	switch remote.ErrorCodeOf(err) {
+	case remote.ErrCodeNotFound:
+		return kindError{kind: ErrNotFound, err: err}
+	case remote.ErrCodeNotAuthorized, remote.ErrCodeUnverified:
+		return kindError{kind: ErrUnauthorizedRemote, err: err}
+	}
+	// match concrete network errors, filesystem errors also satisfy net.Error
+	var (
+		operr  *net.OpError
+		dnserr *net.DNSError
+	)
+	if errors.As(err, &operr) || errors.As(err, &dnserr) {
+		return kindError{kind: ErrRemoteUnreachable, err: err}
+	}
+	return err
+}
+
+// invalidRefError is returned when a reference string can't be parsed
+func invalidRefError(ref string) error {
+	return kindError{kind: ErrInvalidRef, err: fmt.Errorf("'%s' is not a valid dataset reference", ref)}
+}
+
+// didYouMean gives a suffix suggesting similarly-named references when err is
+// a reference that couldn't be resolved, or the empty string if there are no
+// suggestions
+func didYouMean(err error) string {
+	var e *repo.ErrRefNotFound
+	if errors.As(err, &e) {
+		if hint := e.DidYouMean(); hint != "" {
+			return ". " + hint
+		}
+	}
+	return ""
+}
@@ -1,8 +1,18 @@
 package lib
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"os"
 	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/fsi"
+	"github.com/qri-io/qri/remote"
+	"github.com/qri-io/qri/repo"
 )
 
 func TestError(t *testing.T) {
@@ -16,3 +26,106 @@ func TestError(t *testing.T) {
 		t.Errorf("error in Error struct function `Error()`: expected: %s, got: %s", "testing error", e.Error())
 	}
 }
+
+func TestDidYouMean(t *testing.T) {
+	if got := didYouMean(fmt.Errorf("oh no")); got != "" {
+		t.Errorf("expected no suggestion for plain errors, got: %q", got)
+	}
+	err := &repo.ErrRefNotFound{Ref: "me/wbp", Suggestions: []string{"peer/world_bank_population"}}
+	expect := ". did you mean peer/world_bank_population?"
+	if got := didYouMean(err); got != expect {
+		t.Errorf("suggestion mismatch. expected: %q, got: %q", expect, got)
+	}
+}
+
+func TestMethodErrorKinds(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	dsm := NewDatasetRequestsInstance(tr.Instance)
+	fsim := NewFSIMethods(tr.Instance)
+
+	badRef := "@"
+	cases := []struct {
+		description string
+		call        func() error
+		kind        error
+	}{
+		{"get an empty reference", func() error {
+			return dsm.Get(&GetParams{Path: ""}, &GetResult{})
+		}, ErrInvalidRef},
+		{"hash a malformed reference", func() error {
+			var hash string
+			return dsm.ContentHash(&badRef, &hash)
+		}, ErrInvalidRef},
+		{"get a missing dataset", func() error {
+			return dsm.Get(&GetParams{Path: "me/not_a_dataset"}, &GetResult{})
+		}, ErrNotFound},
+		{"save without changes", func() error {
+			if err := dsm.Save(&SaveParams{Ref: "me/cities", Dataset: &dataset.Dataset{Meta: &dataset.Meta{Title: "cities"}}}, &SaveResult{}); err != nil {
+				return err
+			}
+			return dsm.Save(&SaveParams{Ref: "me/cities", Dataset: &dataset.Dataset{Meta: &dataset.Meta{Title: "cities"}}}, &SaveResult{})
+		}, ErrNoChanges},
+		{"rename to a taken name", func() error {
+			return dsm.Rename(&RenameParams{Current: dsref.Ref{Username: "peer", Name: "cities"}, Next: dsref.Ref{Username: "peer", Name: "movies"}}, &dsref.VersionInfo{})
+		}, ErrAlreadyExists},
+		{"write to an unlinked dataset", func() error {
+			var res []StatusItem
+			return fsim.Write(&FSIWriteParams{Ref: "peer/movies", Ds: &dataset.Dataset{}}, &res)
+		}, ErrNotLinked},
+		{"checkout into an existing directory", func() error {
+			var out string
+			return fsim.Checkout(&CheckoutParams{Dir: tr.Dir, Ref: "me/movies"}, &out)
+		}, ErrAlreadyExists},
+	}
+
+	for _, c := range cases {
+		err := c.call()
+		if !errors.Is(err, c.kind) {
+			t.Errorf("case %q expected error of kind %q, got: %v", c.description, c.kind, err)
+		}
+	}
+}
+
+func TestWrapErr(t *testing.T) {
+	cases := []struct {
+		err  error
+		kind error
+	}{
+		{repo.ErrEmptyRef, ErrInvalidRef},
+		{dsref.ErrParseError, ErrInvalidRef},
+		{cafs.ErrNotFound, ErrNotFound},
+		{&repo.ErrRefNotFound{Ref: "me/wbp"}, ErrNotFound},
+		{fsi.ErrAlreadyLinked, ErrAlreadyExists},
+		{remote.NewError(remote.ErrCodeNotFound, "no dataset"), ErrNotFound},
+		{remote.NewError(remote.ErrCodeNotAuthorized, "go away"), ErrUnauthorizedRemote},
+		{remote.NewError(remote.ErrCodeUnverified, "bad signature"), ErrUnauthorizedRemote},
+		{&net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}, ErrRemoteUnreachable},
+	}
+	for _, c := range cases {
+		got := wrapErr(c.err)
+		if !errors.Is(got, c.kind) {
+			t.Errorf("expected %q to be of kind %q", c.err, c.kind)
+		}
+		if got.Error() != c.err.Error() {
+			t.Errorf("wrapping changed message. expected: %q, got: %q", c.err, got)
+		}
+	}
+
+	notRemote := &os.PathError{Op: "mkdir", Path: "", Err: fmt.Errorf("no such file or directory")}
+	if got := wrapErr(notRemote); errors.Is(got, ErrRemoteUnreachable) {
+		t.Errorf("expected filesystem errors not to be remote errors")
+	}
+
+	plain := fmt.Errorf("oh no")
+	if got := wrapErr(plain); got != plain {
+		t.Errorf("expected errors without a kind to be unchanged, got: %v", got)
+	}
+	if got := wrapErr(repo.ErrNoHistory); got != repo.ErrNoHistory {
+		t.Errorf("expected errors that are a kind to be unchanged, got: %v", got)
+	}
+	if code := remote.ErrorCodeOf(wrapErr(remote.NewError(remote.ErrCodeNotFound, "no"))); code != remote.ErrCodeNotFound {
+		t.Errorf("expected wrapped remote errors to keep their code, got: %q", code)
+	}
+}
@@ -1,11 +1,11 @@
 package lib
 
 import (
-	"archive/zip"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/rpc"
 	"os"
 	"path"
@@ -73,7 +73,7 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 	}
 	ref, err := repo.ParseDatasetRef(p.Ref)
 	if err != nil {
-		return fmt.Errorf("'%s' is not a valid dataset reference", p.Ref)
+		return invalidRefError(p.Ref)
 	}
 	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
 		return err
@@ -96,9 +96,15 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 		}
 	}
 
+	// data packages are written as zip archives
+	fileExt := format
+	if format == "datapackage" {
+		fileExt = "zip"
+	}
+
 	if p.Output == "" || isDirectory(p.Output) {
 		// If output is blank or a directory, derive filename from repo name and commit timestamp.
-		baseName, err := GenerateFilename(ds, format)
+		baseName, err := GenerateFilename(ds, fileExt)
 		if err != nil {
 			return err
 		}
@@ -114,9 +120,10 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 		// from file extension.
 		if p.Format == "" && !p.Zipped {
 			format = ext
+			fileExt = ext
 		}
 		// Make sure the format doesn't contradict the file extension.
-		if ext != format {
+		if ext != fileExt {
 			return fmt.Errorf("file extension doesn't match format %s <> %s", ext, format)
 		}
 		*fileWritten = p.Output
@@ -132,7 +139,7 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 	}
 
 	// If output is a format wrapped in a zip file, fixup the output name.
-	if p.Zipped && format != "zip" {
+	if p.Zipped && fileExt != "zip" {
 		outputPath = replaceExt(outputPath, ".zip")
 		*fileWritten = replaceExt(*fileWritten, ".zip")
 	}
@@ -151,8 +158,8 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 	}
 
 	// If outputting a wrapped zip file, create the zip wrapper.
-	if p.Zipped && format != "zip" {
-		zipWriter := zip.NewWriter(writer)
+	if p.Zipped && fileExt != "zip" {
+		zipWriter := dsutil.NewChecksumZipWriter(writer)
 
 		writer, err = zipWriter.Create(fmt.Sprintf("dataset.%s", format))
 		if err != nil {
@@ -160,10 +167,19 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 		}
 
 		defer func() {
-			zipWriter.Close()
+			zipWriter.Close(ds.Path)
 		}()
 	}
 
+	// data packages copy the body as-is, without reading entries
+	if format == "datapackage" {
+		return base.WriteDataPackage(ds, writer)
+	}
+	// parquet is written a row group at a time, it isn't an entry writer format
+	if format == base.ParquetFormat {
+		return base.WriteParquetBody(ds, writer)
+	}
+
 	// Create entry reader.
 	reader, err := dsio.NewEntryReader(ds.Structure, ds.BodyFile())
 	if err != nil {
@@ -181,6 +197,10 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 		}
 		ds.Body = bodyEntries
 
+		if err := inlineReadme(ds); err != nil {
+			return err
+		}
+
 		ds.Structure = &dataset.Structure{
 			Format:   "json",
 			Schema:   ds.Structure.Schema,
@@ -203,6 +223,10 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 		}
 		ds.Body = bodyEntries
 
+		if err := inlineReadme(ds); err != nil {
+			return err
+		}
+
 		ds.Structure = &dataset.Structure{
 			Format:   "yaml",
 			Schema:   ds.Structure.Schema,
@@ -253,6 +277,111 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 	}
 }
 
+// inlineReadme replaces the store path of a readme script with the script's
+// text, readmes are exported as text
+func inlineReadme(ds *dataset.Dataset) (err error) {
+	if ds.Readme == nil || ds.Readme.ScriptFile() == nil {
+		return nil
+	}
+	if ds.Readme.ScriptBytes, err = ioutil.ReadAll(ds.Readme.ScriptFile()); err != nil {
+		return err
+	}
+	ds.Readme.ScriptPath = ""
+	return nil
+}
+
+// ExportChecksums is the checksum manifest included in zip exports
+type ExportChecksums = dsutil.ExportChecksums
+
+// VerifyExport checks the files in a zip export match the checksums it was
+// exported with, without importing it. Mismatches are returned as a
+// *dsutil.ErrChecksumMismatch listing the files that don't match
+func (r *ExportRequests) VerifyExport(path *string, res *ExportChecksums) (err error) {
+	if *path == "" {
+		return fmt.Errorf("path to a zip export is required")
+	}
+	abs := *path
+	if err = qfs.AbsPath(&abs); err != nil {
+		return err
+	}
+
+	if r.cli != nil {
+		return r.cli.Call("ExportRequests.VerifyExport", &abs, res)
+	}
+
+	f, err := os.Open(abs)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	sums, err := dsutil.VerifyZipArchive(f, fi.Size())
+	if err != nil {
+		return err
+	}
+	*res = *sums
+	return nil
+}
+
+// ExportSiteParams defines parameters for the ExportSite method
+type ExportSiteParams struct {
+	Ref string
+	// Dir is the directory to write the site to, it must be empty or not exist.
+	// defaults to a directory named after the dataset
+	Dir       string
+	TargetDir string
+	// SampleSize limits the number of body entries the site embeds, defaults to
+	// base.DefaultSiteSampleSize. -1 embeds the entire body
+	SampleSize int
+}
+
+// ExportSite writes a dataset as a self-contained static HTML site, suitable
+// for sharing with people who don't use qri. dirWritten is set to the
+// directory the site was written to
+func (r *ExportRequests) ExportSite(p *ExportSiteParams, dirWritten *string) (err error) {
+	if p.TargetDir == "" {
+		p.TargetDir = "."
+		if err = qfs.AbsPath(&p.TargetDir); err != nil {
+			return err
+		}
+	}
+
+	if r.cli != nil {
+		return r.cli.Call("ExportRequests.ExportSite", p, dirWritten)
+	}
+	ctx := context.TODO()
+
+	if p.Ref == "" {
+		return repo.ErrEmptyRef
+	}
+	ref, err := repo.ParseDatasetRef(p.Ref)
+	if err != nil {
+		return invalidRefError(p.Ref)
+	}
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
+		return err
+	}
+
+	dir := p.Dir
+	if dir == "" {
+		dir = fmt.Sprintf("%s-%s", ref.Peername, ref.Name)
+	}
+	outputPath := dir
+	if !path.IsAbs(dir) {
+		outputPath = path.Join(p.TargetDir, dir)
+	}
+
+	if _, err = base.ExportSite(ctx, r.node.Repo, ref, outputPath, p.SampleSize); err != nil {
+		return err
+	}
+	*dirWritten = dir
+	return nil
+}
+
 func isDirectory(path string) bool {
 	st, err := os.Stat(path)
 	if err != nil {
@@ -1,8 +1,12 @@
 package lib
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -10,8 +14,12 @@ import (
 	"time"
 
 	"github.com/ghodss/yaml"
+	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/base/dsfs/dsutil"
+	"github.com/qri-io/qri/base/parquet"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/p2p"
 	testrepo "github.com/qri-io/qri/repo/test"
@@ -113,6 +121,152 @@ func TestExport(t *testing.T) {
 	}
 }
 
+func TestExportReadme(t *testing.T) {
+	node := newTestQriNode(t)
+	res := &SaveResult{}
+	p := &SaveParams{
+		Ref: "me/with_readme",
+		Dataset: &dataset.Dataset{
+			Readme:    &dataset.Readme{ScriptBytes: []byte("# hello")},
+			BodyBytes: []byte("[1,2,3]"),
+		},
+	}
+	if err := NewDatasetRequests(node, nil).Save(p, res); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpDir, err := ioutil.TempDir("", "export_readme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	req := NewExportRequests(node, nil)
+	for _, format := range []string{"json", "yaml"} {
+		var fileWritten string
+		if err := req.Export(&ExportParams{Ref: "me/with_readme", Format: format, TargetDir: tmpDir}, &fileWritten); err != nil {
+			t.Fatalf("exporting %s: %s", format, err)
+		}
+		ds := &dataset.Dataset{}
+		if err := readDataset(filepath.Join(tmpDir, fileWritten), ds); err != nil {
+			t.Fatal(err)
+		}
+		if ds.Readme == nil || string(ds.Readme.ScriptBytes) != "# hello" {
+			t.Errorf("%s export: expected readme script to be inlined, got: %v", format, ds.Readme)
+		} else if ds.Readme.ScriptPath != "" {
+			t.Errorf("%s export: expected no readme script path, got: %q", format, ds.Readme.ScriptPath)
+		}
+	}
+}
+
+func TestExportSite(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req := NewExportRequests(node, nil)
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "export_site")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var dirWritten string
+	if err := req.ExportSite(&ExportSiteParams{TargetDir: tmpDir}, &dirWritten); err == nil {
+		t.Error("expected exporting without a reference to error")
+	}
+
+	p := &ExportSiteParams{Ref: "peer/movies", TargetDir: tmpDir, SampleSize: 10}
+	if err := req.ExportSite(p, &dirWritten); err != nil {
+		t.Fatal(err)
+	}
+	if dirWritten != "peer-movies" {
+		t.Errorf("expected site to default to a directory named for the dataset, got: %q", dirWritten)
+	}
+	for _, name := range []string{"index.html", "meta.html", "body.html", "body.json"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, dirWritten, name)); err != nil {
+			t.Errorf("expected site to include %s: %s", name, err)
+		}
+	}
+}
+
+func TestVerifyExport(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req := NewExportRequests(node, nil)
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "verify_export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var fileWritten string
+	if err := req.Export(&ExportParams{Ref: "peer/movies", TargetDir: tmpDir, Format: "zip"}, &fileWritten); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(tmpDir, fileWritten)
+
+	// verifying doesn't need a node
+	sums := ExportChecksums{}
+	if err := NewExportRequests(nil, nil).VerifyExport(&path, &sums); err != nil {
+		t.Fatal(err)
+	}
+	if sums.Path == "" || sums.Files["body.csv"] == "" {
+		t.Errorf("expected checksums to include the version path & body, got: %#v", sums)
+	}
+
+	// rewrite the archive with a tampered body
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := filepath.Join(tmpDir, "tampered.zip")
+	f, err := os.Create(tampered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, file := range zr.File {
+		w, err := zw.Create(file.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(w, rc)
+		rc.Close()
+		if file.Name == "body.csv" {
+			w.Write([]byte("extra,row\n"))
+		}
+	}
+	zr.Close()
+	zw.Close()
+	f.Close()
+
+	err = req.VerifyExport(&tampered, &sums)
+	mismatch, ok := err.(*dsutil.ErrChecksumMismatch)
+	if !ok {
+		t.Fatalf("expected a checksum mismatch, got: %v", err)
+	}
+	if len(mismatch.Files) != 1 || mismatch.Files[0] != "body.csv" {
+		t.Errorf("expected body.csv to mismatch, got: %v", mismatch.Files)
+	}
+}
+
 func readDataset(path string, ds *dataset.Dataset) error {
 	file, err := os.Open(path)
 	if err != nil {
@@ -237,3 +391,183 @@ func TestGenerateFilename(t *testing.T) {
 		}
 	}
 }
+
+func TestExportDataPackageRoundTrip(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	dsPath := tr.writeFile(t, "dataset.json", `{
+  "meta": {
+    "title": "Founding Cities",
+    "description": "cities & when they were founded",
+    "keywords": ["cities", "history"],
+    "license": { "type": "CC-BY-4.0", "url": "https://creativecommons.org/licenses/by/4.0/" },
+    "citations": [{ "name": "wikipedia", "url": "https://wikipedia.org" }],
+    "contributors": [{ "id": "QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt", "name": "Ada", "email": "ada@example.com" }],
+    "theme": ["geography"],
+    "accrualPeriodicity": "R/P1Y",
+    "collection": "history"
+  },
+  "structure": {
+    "format": "csv",
+    "formatConfig": { "headerRow": true },
+    "schema": {
+      "type": "array",
+      "items": {
+        "type": "array",
+        "items": [
+          { "title": "city", "type": "string", "description": "name of the city" },
+          { "title": "founded", "type": "integer" }
+        ]
+      }
+    }
+  }
+}`)
+	readmePath := tr.writeFile(t, "readme.md", "# Founding Cities\n")
+	bodyPath := tr.writeFile(t, "body.csv", "city,founded\nrome,-753\nparis,-250\n")
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	saved := SaveResult{}
+	if err := req.Save(&SaveParams{Ref: "me/cities", FilePaths: []string{dsPath}, BodyPath: bodyPath}, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if err := req.Save(&SaveParams{Ref: "me/cities", FilePaths: []string{readmePath}}, &saved); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := NewExportRequests(tr.Instance.node, nil)
+	var written string
+	if err := exp.Export(&ExportParams{Ref: "me/cities", Format: "datapackage", TargetDir: tr.Dir}, &written); err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Ext(written) != ".zip" {
+		t.Errorf("expected data package to be written as a zip archive, got: %s", written)
+	}
+
+	pkgDir := filepath.Join(tr.Dir, "package")
+	zr, err := zip.OpenReader(filepath.Join(tr.Dir, written))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dest := filepath.Join(pkgDir, f.Name)
+		if err = os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		tr.MustWriteFile(t, dest, string(data))
+	}
+	zr.Close()
+
+	imported := SaveResult{}
+	p := &SaveParams{Ref: "me/cities_copy", FilePaths: []string{filepath.Join(pkgDir, "datapackage.json")}}
+	if err := req.Save(p, &imported); err != nil {
+		t.Fatal(err)
+	}
+	if len(imported.Warnings) != 0 {
+		t.Errorf("expected exported package to map without warnings, got: %v", imported.Warnings)
+	}
+
+	load := func(path string) (*dataset.Dataset, string, string) {
+		ds, err := dsfs.LoadDataset(ctx, tr.Instance.Repo().Store(), path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = base.OpenDataset(ctx, tr.Instance.Repo().Filesystem(), ds); err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(ds.BodyFile())
+		if err != nil {
+			t.Fatal(err)
+		}
+		readme, err := ioutil.ReadAll(ds.Readme.ScriptFile())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ds.Meta.DropDerivedValues()
+		return ds, string(body), string(readme)
+	}
+	orig, origBody, origReadme := load(saved.Path)
+	got, gotBody, gotReadme := load(imported.Path)
+
+	if diff := cmp.Diff(orig.Meta, got.Meta, cmp.AllowUnexported(dataset.Meta{})); diff != "" {
+		t.Errorf("meta mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(orig.Structure.Schema, got.Structure.Schema); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(orig.Structure.FormatConfig, got.Structure.FormatConfig); diff != "" {
+		t.Errorf("format config mismatch (-want +got):\n%s", diff)
+	}
+	if origBody != gotBody {
+		t.Errorf("body mismatch. want: %q, got: %q", origBody, gotBody)
+	}
+	if origReadme != gotReadme {
+		t.Errorf("readme mismatch. want: %q, got: %q", origReadme, gotReadme)
+	}
+}
+
+func TestExportParquetRoundTrip(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	bodyPath := tr.writeFile(t, "body.csv", "city,founded\nrome,-753\nparis,-250\n")
+	req := NewDatasetRequestsInstance(tr.Instance)
+	saved := SaveResult{}
+	if err := req.Save(&SaveParams{Ref: "me/founding", BodyPath: bodyPath}, &saved); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := NewExportRequests(tr.Instance.node, nil)
+	var written string
+	if err := exp.Export(&ExportParams{Ref: "me/founding", Format: "parquet", TargetDir: tr.Dir}, &written); err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Ext(written) != ".parquet" {
+		t.Errorf("expected a parquet file, got: %s", written)
+	}
+
+	saved = SaveResult{}
+	if err := req.Save(&SaveParams{Ref: "me/parquet_founding", BodyPath: filepath.Join(tr.Dir, written)}, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Warnings) == 0 || saved.Warnings[0].Code != base.WarnConvertedFormat {
+		t.Errorf("expected a converted format warning, got: %v", saved.Warnings)
+	}
+
+	got := GetResult{}
+	if err := req.Get(&GetParams{Path: "me/parquet_founding", Selector: "body", Format: "json", All: true}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if expect := `[["rome",-753],["paris",-250]]`; string(got.Bytes) != expect {
+		t.Errorf("body mismatch. expected: %s, got: %s", expect, string(got.Bytes))
+	}
+
+	got = GetResult{}
+	if err := req.Get(&GetParams{Path: "me/parquet_founding", Selector: "body", Format: "parquet", Limit: 1, Offset: 1}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Format != "parquet" {
+		t.Errorf("expected parquet format, got: %q", got.Format)
+	}
+	pr, err := parquet.NewReader(bytes.NewReader(got.Bytes), int64(len(got.Bytes)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := pr.ReadRowGroup(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([][]interface{}{{"paris", int64(-250)}}, rows); diff != "" {
+		t.Errorf("parquet rows mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -1,16 +1,24 @@
 package lib
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/base/dsfs/dsutil"
 	"github.com/qri-io/qri/base/fill"
 	"gopkg.in/yaml.v2"
@@ -19,132 +27,351 @@ import (
 // ReadDatasetFiles reads zero or more files, each representing a dataset or component of a
 // dataset, and deserializes them, merging the results into a single dataset object. It is an
 // error to provide any combination of files whose contents overlap (modify the same component).
-func ReadDatasetFiles(pathList ...string) (*dataset.Dataset, error) {
+// Dataset files can be json, yaml or toml. A yaml file with multiple documents is read as a
+// sequence of patches, each document a dataset or component applied over the ones before it.
+// Unknown top-level keys are dropped, returning a warning naming them. A file
+// named datapackage.json is read as a Frictionless Data Package
+func ReadDatasetFiles(pathList ...string) (*dataset.Dataset, []Warning, error) {
+	return readDatasetFiles("", pathList)
+}
+
+// readDatasetFiles is ReadDatasetFiles, reading the named resource of any data
+// package
+func readDatasetFiles(resource string, pathList []string) (*dataset.Dataset, []Warning, error) {
 	// If there's only a single file provided, read it and return the dataset.
 	if len(pathList) == 1 {
-		ds, _, err := readSingleFile(pathList[0])
-		return ds, err
+		ds, _, warnings, err := readSingleFile(pathList[0], resource)
+		return ds, warnings, err
 	}
 
 	// If there's multiple files provided, read each one and merge them. Any exclusive
 	// component is an error, any component showing up multiple times is an error.
 	foundKinds := make(map[string]bool)
 	ds := dataset.Dataset{}
+	var warnings []Warning
 	for _, p := range pathList {
-		component, kind, err := readSingleFile(p)
+		component, kinds, warns, err := readSingleFile(p, resource)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		if kind == "zip" || kind == "ds" {
-			return nil, fmt.Errorf("conflict, cannot save a full dataset with other components")
-		}
-		if _, ok := foundKinds[kind]; ok {
-			return nil, fmt.Errorf("conflict, multiple components of kind \"%s\"", kind)
+		for _, kind := range kinds {
+			if kind == "zip" || kind == "ds" {
+				return nil, nil, fmt.Errorf("conflict, cannot save a full dataset with other components")
+			}
+			if _, ok := foundKinds[kind]; ok {
+				return nil, nil, fmt.Errorf("conflict, multiple components of kind \"%s\"", kind)
+			}
+			foundKinds[kind] = true
 		}
-		foundKinds[kind] = true
 
+		warnings = append(warnings, warns...)
 		ds.Assign(component)
 	}
 
-	return &ds, nil
+	return &ds, warnings, nil
 }
 
+var (
+	// datasetFileFetchTimeout bounds fetching a dataset file from a URL
+	datasetFileFetchTimeout = 30 * time.Second
+	// datasetFileMaxSize is the largest dataset file fetched from a URL
+	datasetFileMaxSize int64 = 25 << 20
+)
+
 // readSingleFile reads a single file, either a full dataset or component, and returns it as
-// a dataset and a string specifying the kind of component that was created
-func readSingleFile(path string) (*dataset.Dataset, string, error) {
-	ds := dataset.Dataset{}
+// a dataset, the kinds of component that were created, and warnings about unknown keys
+func readSingleFile(path, resource string) (*dataset.Dataset, []string, []Warning, error) {
 	switch qfs.PathKind(path) {
 	case "http":
-		// currently the only supported type of file url is a zip archive
-		resp, err := http.Get(path)
-		if err != nil {
-			return nil, "", err
-		}
-		data, err := ioutil.ReadAll(resp.Body)
+		ext, data, err := fetchDatasetFile(path)
 		if err != nil {
-			return nil, "", err
+			return nil, nil, nil, err
 		}
-		resp.Body.Close()
-		err = dsutil.UnzipDatasetBytes(data, &ds)
-		return &ds, "zip", nil
+		return readDatasetFile(path, ext, resource, bytes.NewReader(data))
 
 	case "ipfs":
-		return nil, "", fmt.Errorf("reading dataset files from IPFS currently unsupported")
+		return nil, nil, nil, fmt.Errorf("reading dataset files from IPFS currently unsupported")
 
 	case "local":
 		f, err := os.Open(path)
 		if err != nil {
-			return nil, "", err
+			return nil, nil, nil, err
 		}
+		return readDatasetFile(path, strings.ToLower(filepath.Ext(path)), resource, f)
 
-		fileExt := strings.ToLower(filepath.Ext(path))
-		switch fileExt {
-		case ".yaml", ".yml":
-			data, err := ioutil.ReadAll(f)
-			if err != nil {
-				return nil, "", err
-			}
+	default:
+		return nil, nil, nil, fmt.Errorf("error, unknown path kind: \"%s\"", qfs.PathKind(path))
+	}
+}
 
-			fields := make(map[string]interface{})
-			if err = yaml.Unmarshal(data, fields); err != nil {
-				return nil, "", err
-			}
+// readDatasetFile deserializes a dataset or component from a file read from
+// path, choosing how to read it from the file extension ext. resource names
+// the resource to read from a data package
+func readDatasetFile(path, ext, resource string, f io.Reader) (*dataset.Dataset, []string, []Warning, error) {
+	if strings.EqualFold(filepath.Base(path), base.DataPackageFilename) {
+		return readDataPackage(path, resource, f)
+	}
+
+	ds := dataset.Dataset{}
+	switch ext {
+	case ".yaml", ".yml":
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			return nil, nil, nil, err
+		}
 
-			kind, err := fillDatasetOrComponent(fields, path, &ds)
-			return &ds, kind, err
+		docs, err := yamlDocuments(data)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: %s", path, err)
+		}
+		if len(docs) > 1 {
+			return fillComponentPatches(path, docs)
+		}
 
-		case ".json":
-			fields := make(map[string]interface{})
-			if err = json.NewDecoder(f).Decode(&fields); err != nil {
-				if strings.HasPrefix(err.Error(), "json: cannot unmarshal array") {
-					err = fmt.Errorf("json has top-level type \"array\", cannot be a dataset file")
-				}
-				return nil, "", err
-			}
-			kind, err := fillDatasetOrComponent(fields, path, &ds)
-			return &ds, kind, err
+		fields := make(map[string]interface{})
+		if len(docs) == 1 {
+			fields = docs[0].fields
+		}
+		kind, unknown, err := fillDatasetOrComponent(fields, path, &ds)
+		return &ds, []string{kind}, unknownKeyWarnings(path, kind, unknown), err
 
-		case ".zip":
-			data, err := ioutil.ReadAll(f)
-			if err != nil {
-				return nil, "", err
+	case ".json":
+		fields := make(map[string]interface{})
+		if err := json.NewDecoder(f).Decode(&fields); err != nil {
+			if strings.HasPrefix(err.Error(), "json: cannot unmarshal array") {
+				err = fmt.Errorf("json has top-level type \"array\", cannot be a dataset file")
 			}
-			err = dsutil.UnzipDatasetBytes(data, &ds)
-			return &ds, "zip", err
-
-		case ".star":
-			// starlark files are assumed to be a transform script with no additional
-			// tranform component details:
-			ds.Transform = &dataset.Transform{ScriptPath: path}
-			ds.Transform.SetScriptFile(qfs.NewMemfileReader("transform.star", f))
-			return &ds, "tf", nil
-
-		case ".html":
-			// html files are assumped to be a viz script with no additional viz
-			// component details
-			// TODO(dlong): Deprecate viz, assume "html" is a readme
-			ds.Viz = &dataset.Viz{ScriptPath: path}
-			ds.Viz.Format = "html"
-			ds.Viz.SetScriptFile(qfs.NewMemfileReader("viz.html", f))
-			return &ds, "vz", nil
-
-		case ".md":
-			// md files are assumped to be a readme file
-			ds.Readme = &dataset.Readme{ScriptPath: path}
-			ds.Readme.Format = "md"
-			ds.Readme.SetScriptFile(qfs.NewMemfileReader("readme.md", f))
-			return &ds, "rm", nil
-
-		default:
-			return nil, "", fmt.Errorf("error, unrecognized file extension: \"%s\"", fileExt)
+			return nil, nil, nil, err
 		}
+		kind, unknown, err := fillDatasetOrComponent(fields, path, &ds)
+		return &ds, []string{kind}, unknownKeyWarnings(path, kind, unknown), err
+
+	case ".toml":
+		fields := make(map[string]interface{})
+		if _, err := toml.NewDecoder(f).Decode(&fields); err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: %s", path, err)
+		}
+		kind, unknown, err := fillDatasetOrComponent(tomlFields(fields), path, &ds)
+		return &ds, []string{kind}, unknownKeyWarnings(path, kind, unknown), err
+
+	case ".zip":
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		sums, err := dsutil.UnzipVerifiedDataset(bytes.NewReader(data), int64(len(data)), &ds)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		var warnings []Warning
+		if sums == nil {
+			warnings = append(warnings, Warning{
+				Code:    base.WarnUnverifiedArchive,
+				Message: fmt.Sprintf("%s: %s, contents can't be verified", path, dsutil.ErrNoChecksums),
+			})
+		}
+		return &ds, []string{"zip"}, warnings, nil
+
+	case ".star":
+		// starlark files are assumed to be a transform script with no additional
+		// tranform component details:
+		ds.Transform = &dataset.Transform{ScriptPath: path}
+		ds.Transform.SetScriptFile(qfs.NewMemfileReader("transform.star", f))
+		return &ds, []string{"tf"}, nil, nil
+
+	case ".html":
+		// html files are assumped to be a viz script with no additional viz
+		// component details
+		// TODO(dlong): Deprecate viz, assume "html" is a readme
+		ds.Viz = &dataset.Viz{ScriptPath: path}
+		ds.Viz.Format = "html"
+		ds.Viz.SetScriptFile(qfs.NewMemfileReader("viz.html", f))
+		return &ds, []string{"vz"}, nil, nil
+
+	case ".md":
+		// md files are assumped to be a readme file
+		ds.Readme = &dataset.Readme{ScriptPath: path}
+		ds.Readme.Format = "md"
+		ds.Readme.SetScriptFile(qfs.NewMemfileReader("readme.md", f))
+		return &ds, []string{"rm"}, nil, nil
+
 	default:
-		return nil, "", fmt.Errorf("error, unknown path kind: \"%s\"", qfs.PathKind(path))
+		return nil, nil, nil, fmt.Errorf("error, unrecognized file extension: \"%s\"", ext)
+	}
+}
+
+// readDataPackage reads a dataset from a data package descriptor. A README.md
+// beside a local descriptor is read as the dataset readme
+func readDataPackage(path, resource string, f io.Reader) (*dataset.Dataset, []string, []Warning, error) {
+	ds, warnings, err := base.DatasetFromDataPackage(f, resource)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: %w", path, err)
 	}
+	absDatasetPaths(path, ds)
+	kinds := []string{"md", "st"}
+
+	if qfs.PathKind(path) == "local" {
+		readmePath := filepath.Join(filepath.Dir(path), "README.md")
+		if data, err := ioutil.ReadFile(readmePath); err == nil {
+			ds.Readme = &dataset.Readme{ScriptPath: readmePath, Format: "md"}
+			ds.Readme.SetScriptFile(qfs.NewMemfileBytes("readme.md", data))
+			kinds = append(kinds, "rm")
+		}
+	}
+	return ds, kinds, warnings, nil
+}
+
+// datasetFileContentTypes maps media types to the file extension of the
+// dataset files they hold
+var datasetFileContentTypes = map[string]string{
+	"application/json":   ".json",
+	"application/yaml":   ".yaml",
+	"application/x-yaml": ".yaml",
+	"text/yaml":          ".yaml",
+	"text/x-yaml":        ".yaml",
+	"application/toml":   ".toml",
+	"application/zip":    ".zip",
+	"text/markdown":      ".md",
+	"text/html":          ".html",
 }
 
-func fillDatasetOrComponent(fields map[string]interface{}, path string, ds *dataset.Dataset) (string, error) {
+// fetchDatasetFile fetches a dataset file from a URL, returning the file
+// extension it should be read as & the file's contents. The extension is
+// taken from the URL path, falling back to the response Content-Type.
+// Redirects aren't followed
+func fetchDatasetFile(urlstr string) (string, []byte, error) {
+	u, err := url.Parse(urlstr)
+	if err != nil {
+		return "", nil, err
+	}
+
+	client := &http.Client{
+		Timeout: datasetFileFetchTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	res, err := client.Get(urlstr)
+	if err != nil {
+		return "", nil, fmt.Errorf("fetching %s: %s", urlstr, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 && res.StatusCode < 400 {
+		return "", nil, fmt.Errorf("fetching %s: redirected to %q, use the final URL instead", urlstr, res.Header.Get("Location"))
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("fetching %s: unexpected status %s", urlstr, res.Status)
+	}
+	if res.ContentLength > datasetFileMaxSize {
+		return "", nil, fmt.Errorf("fetching %s: file is larger than the %d byte limit", urlstr, datasetFileMaxSize)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, datasetFileMaxSize+1))
+	if err != nil {
+		return "", nil, fmt.Errorf("fetching %s: %s", urlstr, err)
+	}
+	if int64(len(data)) > datasetFileMaxSize {
+		return "", nil, fmt.Errorf("fetching %s: file is larger than the %d byte limit", urlstr, datasetFileMaxSize)
+	}
+
+	ext := strings.ToLower(path.Ext(u.Path))
+	switch ext {
+	case ".yaml", ".yml", ".json", ".toml", ".zip", ".star", ".html", ".md":
+		return ext, data, nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if ext, ok := datasetFileContentTypes[mediaType]; ok {
+		return ext, data, nil
+	}
+	return "", nil, fmt.Errorf("fetching %s: can't tell what kind of file it is from the URL or content type %q", urlstr, res.Header.Get("Content-Type"))
+}
+
+// yamlDocument is a single document of a yaml file
+type yamlDocument struct {
+	// num is the position of the document in the file, starting at 1
+	num int
+	// line is the line the document starts on
+	line   int
+	fields map[string]interface{}
+}
+
+// yamlDocuments splits yaml data into documents, skipping empty documents
+func yamlDocuments(data []byte) ([]yamlDocument, error) {
+	lines := yamlDocumentLines(data)
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	var docs []yamlDocument
+	for num := 1; ; num++ {
+		fields := make(map[string]interface{})
+		if err := dec.Decode(&fields); err == io.EOF {
+			return docs, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		doc := yamlDocument{num: num, line: 1, fields: fields}
+		if num <= len(lines) {
+			doc.line = lines[num-1]
+		}
+		docs = append(docs, doc)
+	}
+}
+
+// yamlDocumentLines finds the line each document of yaml data starts on
+func yamlDocumentLines(data []byte) []int {
+	lines := strings.Split(string(data), "\n")
+	starts := []int{}
+	for i, l := range lines {
+		if strings.HasPrefix(l, "---") {
+			starts = append(starts, i+2)
+		}
+	}
+	// a leading document doesn't need a separator
+	for _, l := range lines {
+		t := strings.TrimSpace(l)
+		if t == "" || strings.HasPrefix(t, "#") || strings.HasPrefix(t, "%") {
+			continue
+		}
+		if !strings.HasPrefix(l, "---") {
+			starts = append([]int{1}, starts...)
+		}
+		break
+	}
+	return starts
+}
+
+// fillComponentPatches applies each document of a multi-document yaml file in
+// order, later documents overriding the fields they set
+func fillComponentPatches(path string, docs []yamlDocument) (*dataset.Dataset, []string, []Warning, error) {
+	ds := &dataset.Dataset{}
+	var (
+		kinds    []string
+		warnings []Warning
+	)
+	seen := map[string]bool{}
+	for _, doc := range docs {
+		source := fmt.Sprintf("%s: document %d (line %d)", path, doc.num, doc.line)
+		patch := &dataset.Dataset{}
+		kind, unknown, err := fillDatasetOrComponent(doc.fields, path, patch)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: %s", source, err)
+		}
+		warnings = append(warnings, unknownKeyWarnings(source, kind, unknown)...)
+		if !seen[kind] {
+			seen[kind] = true
+			kinds = append(kinds, kind)
+		}
+		ds.Assign(patch)
+	}
+	return ds, kinds, warnings, nil
+}
+
+// fillDatasetOrComponent fills a dataset, or the component named by the "qri" field, returning
+// the kind of component filled and any unknown top-level keys, which are dropped
+func fillDatasetOrComponent(fields map[string]interface{}, path string, ds *dataset.Dataset) (string, []string, error) {
 	var target interface{}
 	target = ds
 	kind := "ds"
@@ -170,27 +397,110 @@ func fillDatasetOrComponent(fields map[string]interface{}, path string, ds *data
 		}
 	}
 
+	unknown := fill.UnknownFields(fields, target)
+	for _, k := range unknown {
+		delete(fields, k)
+	}
 	if err := fill.Struct(fields, target); err != nil {
-		return "", err
+		return "", nil, err
 	}
 	absDatasetPaths(path, ds)
-	return kind, nil
+	return kind, unknown, nil
+}
+
+// componentKindNames maps the kinds of component fillDatasetOrComponent fills
+// to component names
+var componentKindNames = map[string]string{
+	"rm": "readme",
+	"md": "meta",
+	"cm": "commit",
+	"st": "structure",
+}
+
+func unknownKeyWarnings(source, kind string, unknown []string) []Warning {
+	if len(unknown) == 0 {
+		return nil
+	}
+	keys := make([]string, len(unknown))
+	for i, k := range unknown {
+		keys[i] = fmt.Sprintf("%q", k)
+	}
+	return []Warning{{
+		Code:      base.WarnUnknownFields,
+		Message:   fmt.Sprintf("%s: ignored unknown keys %s", source, strings.Join(keys, ", ")),
+		Component: componentKindNames[kind],
+	}}
+}
+
+// tomlFields converts decoded toml values to the types fill expects: tables
+// become maps, integers ints, and dates & times strings
+func tomlFields(fields map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		res[k] = tomlValue(v)
+	}
+	return res
+}
+
+func tomlValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		return tomlFields(x)
+	case []map[string]interface{}:
+		res := make([]interface{}, len(x))
+		for i, m := range x {
+			res[i] = tomlFields(m)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(x))
+		for i, e := range x {
+			res[i] = tomlValue(e)
+		}
+		return res
+	case int64:
+		return int(x)
+	case time.Time:
+		return x.Format(time.RFC3339)
+	case fmt.Stringer:
+		// local dates & times
+		return x.String()
+	default:
+		return v
+	}
 }
 
 // absDatasetPaths converts any relative filepath references in a Dataset to
 // their absolute counterpart
 func absDatasetPaths(path string, dsp *dataset.Dataset) {
-	base := filepath.Dir(path)
+	abs := func(p string) string {
+		return filepath.Join(filepath.Dir(path), p)
+	}
+	if qfs.PathKind(path) == "http" {
+		// paths within a file fetched from a URL are relative to the URL
+		base, err := url.Parse(path)
+		if err != nil {
+			return
+		}
+		abs = func(p string) string {
+			rel, err := url.Parse(filepath.ToSlash(p))
+			if err != nil {
+				return p
+			}
+			return base.ResolveReference(rel).String()
+		}
+	}
+
 	if dsp.BodyPath != "" && qfs.PathKind(dsp.BodyPath) == "local" && !filepath.IsAbs(dsp.BodyPath) {
-		dsp.BodyPath = filepath.Join(base, dsp.BodyPath)
+		dsp.BodyPath = abs(dsp.BodyPath)
 	}
 	if dsp.Transform != nil && qfs.PathKind(dsp.Transform.ScriptPath) == "local" && !filepath.IsAbs(dsp.Transform.ScriptPath) {
-		dsp.Transform.ScriptPath = filepath.Join(base, dsp.Transform.ScriptPath)
+		dsp.Transform.ScriptPath = abs(dsp.Transform.ScriptPath)
 	}
 	if dsp.Viz != nil && qfs.PathKind(dsp.Viz.ScriptPath) == "local" && !filepath.IsAbs(dsp.Viz.ScriptPath) {
-		dsp.Viz.ScriptPath = filepath.Join(base, dsp.Viz.ScriptPath)
+		dsp.Viz.ScriptPath = abs(dsp.Viz.ScriptPath)
 	}
 	if dsp.Readme != nil && qfs.PathKind(dsp.Readme.ScriptPath) == "local" && !filepath.IsAbs(dsp.Readme.ScriptPath) {
-		dsp.Readme.ScriptPath = filepath.Join(base, dsp.Readme.ScriptPath)
+		dsp.Readme.ScriptPath = abs(dsp.Readme.ScriptPath)
 	}
 }
@@ -1,9 +1,17 @@
 package lib
 
 import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base"
 )
 
 func TestReadDatasetFiles(t *testing.T) {
@@ -117,10 +125,53 @@ func TestReadDatasetFiles(t *testing.T) {
 				},
 			},
 		},
+		{"dataset.toml file",
+			[]string{
+				"testdata/component_files/dataset.toml",
+			},
+			&dataset.Dataset{
+				Meta: &dataset.Meta{
+					Title:    "build a dataset with component files",
+					Keywords: []string{"toml"},
+				},
+				Structure: &dataset.Structure{
+					Format: "json",
+					Depth:  2,
+					Schema: map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "array",
+							"items": []interface{}{
+								map[string]interface{}{"type": "string", "name": "field_1"},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		{"multi-document yaml patches",
+			[]string{
+				"testdata/component_files/patches.yaml",
+			},
+			&dataset.Dataset{
+				Meta: &dataset.Meta{
+					Qri:         "md",
+					Title:       "build a dataset with component files",
+					Description: "kept from the first document",
+					Keywords:    []string{"yaml"},
+				},
+				Structure: &dataset.Structure{
+					Qri:    "st",
+					Format: "json",
+					Schema: map[string]interface{}{"type": "array"},
+				},
+			},
+		},
 	}
 
 	for i, c := range cases {
-		got, err := ReadDatasetFiles(c.paths...)
+		got, _, err := ReadDatasetFiles(c.paths...)
 		if err != nil {
 			t.Errorf("case %d %s unexpected error: %s", i, c.description, err.Error())
 			continue
@@ -131,3 +182,152 @@ func TestReadDatasetFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestReadDatasetFilesWarnings(t *testing.T) {
+	if _, _, err := ReadDatasetFiles("testdata/component_files/dataset.toml", "testdata/component_files/commit.yaml"); err == nil {
+		t.Error("expected a full dataset with other components to error")
+	}
+
+	_, warnings, err := ReadDatasetFiles("testdata/component_files/dataset.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Warning{{
+		Code:    base.WarnUnknownFields,
+		Message: `testdata/component_files/dataset.toml: ignored unknown keys "colour"`,
+	}}
+	if diff := cmp.Diff(expect, warnings); diff != "" {
+		t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+	}
+
+	_, warnings, err = ReadDatasetFiles("testdata/component_files/patches.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = []Warning{{
+		Code:    base.WarnUnknownFields,
+		Message: `testdata/component_files/patches.yaml: document 4 (line 14): ignored unknown keys "colour"`,
+	}}
+	if diff := cmp.Diff(expect, warnings); diff != "" {
+		t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+	}
+
+	f, err := ioutil.TempFile("", "*-commit.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(`{"qri":"cm:0","title":"cities","colour":"blue"}`)
+	f.Close()
+
+	_, warnings, err = ReadDatasetFiles(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = []Warning{{
+		Code:      base.WarnUnknownFields,
+		Message:   f.Name() + `: ignored unknown keys "colour"`,
+		Component: "commit",
+	}}
+	if diff := cmp.Diff(expect, warnings); diff != "" {
+		t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadDatasetFilesErrors(t *testing.T) {
+	cases := []struct {
+		path string
+		err  string
+	}{
+		{"testdata/component_files/bad.toml", "line 3"},
+		{"testdata/component_files/bad_syntax.yaml", "line 5"},
+		{"testdata/component_files/bad_patches.yaml", "document 3 (line 7)"},
+	}
+
+	for _, c := range cases {
+		_, _, err := ReadDatasetFiles(c.path)
+		if err == nil {
+			t.Errorf("%s: expected error, got nil", c.path)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: expected error to mention %q, got: %s", c.path, c.err, err)
+		}
+	}
+}
+
+func TestReadDatasetFilesURL(t *testing.T) {
+	meta, err := ioutil.ReadFile("testdata/component_files/meta.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	structure, err := ioutil.ReadFile("testdata/component_files/structure.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/raw/meta.json":
+			w.Write(meta)
+		case "/raw/structure":
+			w.Header().Set("Content-Type", "application/x-yaml; charset=utf-8")
+			w.Write(structure)
+		case "/raw/dataset.json":
+			w.Write([]byte(`{"bodyPath":"body.csv"}`))
+		case "/raw/unknown":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte(`{}`))
+		case "/moved/meta.json":
+			http.Redirect(w, r, "/raw/meta.json", http.StatusFound)
+		case "/large.json":
+			w.Write(bytes.Repeat([]byte(" "), 100))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	// component kind is inferred from the URL path or content type
+	got, _, err := ReadDatasetFiles(s.URL+"/raw/meta.json", s.URL+"/raw/structure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Meta == nil || got.Meta.Title != "build a dataset with component files" {
+		t.Errorf("expected meta to be read from URL, got: %v", got.Meta)
+	}
+	if got.Structure == nil || got.Structure.Format != "json" {
+		t.Errorf("expected structure to be read from URL, got: %v", got.Structure)
+	}
+
+	// relative paths are relative to the URL
+	got, _, err = ReadDatasetFiles(s.URL + "/raw/dataset.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := s.URL + "/raw/body.csv"; got.BodyPath != expect {
+		t.Errorf("expected body path %q, got: %q", expect, got.BodyPath)
+	}
+
+	prevMax := datasetFileMaxSize
+	datasetFileMaxSize = 10
+	defer func() { datasetFileMaxSize = prevMax }()
+
+	bad := []struct {
+		path, err string
+	}{
+		{"/raw/missing.json", "404"},
+		{"/moved/meta.json", "redirected"},
+		{"/raw/unknown", "can't tell what kind of file"},
+		{"/large.json", "byte limit"},
+	}
+	for _, c := range bad {
+		_, _, err := ReadDatasetFiles(s.URL + c.path)
+		if err == nil {
+			t.Errorf("%s: expected error, got nil", c.path)
+			continue
+		}
+		if !strings.Contains(err.Error(), s.URL+c.path) || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: expected error naming the URL & mentioning %q, got: %s", c.path, c.err, err)
+		}
+	}
+}
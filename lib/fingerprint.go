@@ -0,0 +1,113 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+)
+
+// Fingerprint is an alias for base.Fingerprint, a signed summary of the root
+// & blocks of a dataset version that can be shared out-of-band
+type Fingerprint = base.Fingerprint
+
+// VerifyResult is an alias for base.FingerprintVerification, the outcome of
+// checking a local version against a fingerprint
+type VerifyResult = base.FingerprintVerification
+
+// Fingerprint creates a fingerprint of a version of one of your datasets,
+// signed with your private key
+func (r *DatasetRequests) Fingerprint(refstr *string, res *Fingerprint) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Fingerprint", refstr, res)
+	}
+	ctx := context.TODO()
+
+	ref, err := repo.ParseDatasetRef(*refstr)
+	if err != nil {
+		return err
+	}
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
+		return err
+	}
+	pro, err := r.node.Repo.Profile()
+	if err != nil {
+		return err
+	}
+	if ref.ProfileID != pro.ID {
+		return fmt.Errorf("can only fingerprint versions of your own datasets")
+	}
+
+	blocks, err := nodeBlockList(r.node)(ctx, ref.Path)
+	if err != nil {
+		return err
+	}
+	fp, err := base.NewFingerprint(ref.Path, blocks, pro.ID, r.node.Repo.PrivateKey())
+	if err != nil {
+		return err
+	}
+	*res = *fp
+	return nil
+}
+
+// VerifyFingerprintParams defines parameters for the VerifyFingerprint
+// method
+type VerifyFingerprintParams struct {
+	// Ref is the local dataset version to check, the latest version if the
+	// reference has no path
+	Ref string
+	// Fingerprint is the signed fingerprint to check against
+	Fingerprint *Fingerprint
+}
+
+// VerifyFingerprint checks a local copy of a dataset version against a
+// fingerprint received out-of-band, confirming the copy has the fingerprinted
+// root & every fingerprinted block, and that the fingerprint was signed by the
+// dataset's author. Failed checks are reported, not returned as errors
+func (r *DatasetRequests) VerifyFingerprint(p *VerifyFingerprintParams, res *VerifyResult) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.VerifyFingerprint", p, res)
+	}
+	ctx := context.TODO()
+
+	if p.Fingerprint == nil {
+		return fmt.Errorf("fingerprint is required")
+	}
+	ref, err := repo.ParseDatasetRef(p.Ref)
+	if err != nil {
+		return err
+	}
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
+		return err
+	}
+
+	v, err := base.VerifyFingerprint(ctx, p.Fingerprint, ref.Path, ref.ProfileID, nodeBlockList(r.node), nodeMissingBlocks(r.node), nodeAuthorKey(r.node))
+	if err != nil {
+		return err
+	}
+	if !v.Verified {
+		log.Warningf("%s: version %s doesn't match fingerprint: %v", ref.AliasString(), ref.Path, v.Reasons)
+	}
+	*res = *v
+	return nil
+}
+
+// nodeBlockList lists the blocks in a version's DAG when an IPFS node is
+// available, falling back to the version's components
+func nodeBlockList(node *p2p.QriNode) base.BlockListFunc {
+	if _, err := node.IPFSCoreAPI(); err != nil {
+		return base.ComponentBlocks(node.Repo.Store())
+	}
+
+	return func(ctx context.Context, path string) ([]string, error) {
+		mf, err := node.NewManifest(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return mf.Nodes, nil
+	}
+}
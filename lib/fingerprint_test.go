@@ -0,0 +1,50 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/qri-io/qri/base"
+)
+
+func TestDatasetRequestsVerifyFingerprint(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+	req := NewDatasetRequestsInstance(tr.Instance)
+
+	ref := "peer/movies"
+	fp := Fingerprint{}
+	if err := req.Fingerprint(&ref, &fp); err != nil {
+		t.Fatal(err)
+	}
+	if fp.Signature == "" || fp.BlockCount == 0 {
+		t.Fatalf("expected a signed fingerprint listing blocks, got: %#v", fp)
+	}
+
+	res := VerifyResult{}
+	if err := req.VerifyFingerprint(&VerifyFingerprintParams{Ref: ref, Fingerprint: &fp}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.Verified || res.Signature != base.SignatureVerified {
+		t.Errorf("expected fingerprint to verify, got: %#v", res)
+	}
+
+	if err := req.VerifyFingerprint(&VerifyFingerprintParams{Ref: "peer/cities", Fingerprint: &fp}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Verified || res.RootMatches || res.BlocksMatch {
+		t.Errorf("expected a different dataset not to match, got: %#v", res)
+	}
+
+	tampered := fp
+	tampered.BlocksHash = "0000"
+	if err := req.VerifyFingerprint(&VerifyFingerprintParams{Ref: ref, Fingerprint: &tampered}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Verified || res.Signature != base.SignatureInvalid || res.BlocksMatch {
+		t.Errorf("expected a tampered fingerprint to fail, got: %#v", res)
+	}
+
+	if err := req.VerifyFingerprint(&VerifyFingerprintParams{Ref: ref}, &res); err == nil {
+		t.Error("expected verifying without a fingerprint to error")
+	}
+}
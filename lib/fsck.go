@@ -0,0 +1,201 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qri-io/dag"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/p2p"
+)
+
+// RepoMethods encapsulates business logic for maintaining a qri repo
+type RepoMethods struct {
+	inst *Instance
+}
+
+// NewRepoMethods creates RepoMethods from a qri Instance
+func NewRepoMethods(inst *Instance) *RepoMethods {
+	return &RepoMethods{inst: inst}
+}
+
+// CoreRequestsName implements the Methods interface
+func (m RepoMethods) CoreRequestsName() string { return "repo" }
+
+// CheckRepoParams configures a repo integrity check
+type CheckRepoParams struct {
+	// VerifyBlocks confirms every block of each head version is stored locally
+	VerifyBlocks bool
+	// Repair applies safe fixes after checking
+	Repair bool
+	// Drop lists aliases of references to remove if problems are found with
+	// them. Drop is only used when Repair is true
+	Drop []string
+}
+
+// CheckRepoResult is the report produced by a repo integrity check
+type CheckRepoResult struct {
+	base.RepoCheck
+	// Repaired lists aliases of references changed by a repair
+	Repaired []string `json:"repaired,omitempty"`
+}
+
+// Check walks every reference in the repo, reporting dangling references,
+// missing blocks, references without logs or that disagree with their log on
+// the head version, logs without references, and links to working directories
+// that no longer exist
+func (m *RepoMethods) Check(p *CheckRepoParams, res *CheckRepoResult) error {
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("RepoMethods.Check", p, res)
+	}
+	ctx := context.TODO()
+	r := m.inst.Repo()
+
+	var missing base.MissingBlocksFunc
+	if p.VerifyBlocks {
+		missing = nodeMissingBlocks(m.inst.Node())
+	}
+
+	check, err := base.CheckRepo(ctx, r, missing)
+	if err != nil {
+		return err
+	}
+
+	res.RepoCheck = *check
+	if p.Repair {
+		if res.Repaired, err = base.RepairRepo(ctx, r, check, p.Drop); err != nil {
+			return fmt.Errorf("repairing repo: %s", err)
+		}
+	}
+	return nil
+}
+
+// nodeMissingBlocks checks full version manifests when an IPFS node is
+// available, falling back to confirming each component is stored
+func nodeMissingBlocks(node *p2p.QriNode) base.MissingBlocksFunc {
+	if _, err := node.IPFSCoreAPI(); err != nil {
+		return base.MissingComponentBlocks(node.Repo.Store())
+	}
+
+	return func(ctx context.Context, path string) ([]string, error) {
+		mf, err := node.NewManifest(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		mis, err := node.MissingManifest(ctx, mf)
+		if err != nil {
+			return nil, err
+		}
+		return mis.Nodes, nil
+	}
+}
+
+// DefaultDedupTop is the number of shared blocks a deduplication report lists
+// when no number is given
+const DefaultDedupTop = 10
+
+// DedupParams configures a deduplication report
+type DedupParams struct {
+	// Top caps the number of shared blocks listed, zero uses DefaultDedupTop
+	Top int
+	// Timeout cancels the report if it runs longer, zero means no limit
+	Timeout time.Duration
+}
+
+// Dedup walks every stored version, reporting how much space blocks shared
+// between versions & datasets save, the blocks saving the most, and bodies
+// used by more than one dataset. Dedup requires an IPFS store
+func (m *RepoMethods) Dedup(p *DedupParams, res *base.DedupReport) error {
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("RepoMethods.Dedup", p, res)
+	}
+	ctx := m.inst.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+
+	node := m.inst.Node()
+	if _, err := node.IPFSCoreAPI(); err != nil {
+		return fmt.Errorf("deduplication reports require an IPFS store")
+	}
+	top := p.Top
+	if top == 0 {
+		top = DefaultDedupTop
+	}
+
+	report, err := base.ReportDedup(ctx, m.inst.Repo(), func(ctx context.Context, path string) ([]base.BlockInfo, error) {
+		info, err := node.NewDAGInfo(ctx, path, "")
+		if err != nil {
+			return nil, err
+		}
+		return dagBlocks(info), nil
+	}, top)
+	if err != nil {
+		return err
+	}
+	*res = *report
+	return nil
+}
+
+// dagLabelComponents maps dag info labels to the components they label
+var dagLabelComponents = map[string]string{
+	"bd": "body",
+	"cm": "commit",
+	"md": "meta",
+	"rd": "rendered",
+	"st": "structure",
+	"tf": "transform",
+	"vz": "viz",
+}
+
+// dagBlocks lists the blocks of a version, attributing blocks under a labelled
+// sub-DAG to the labelled component
+func dagBlocks(info *dag.Info) []base.BlockInfo {
+	if info.Manifest == nil {
+		return nil
+	}
+	nodes := info.Manifest.Nodes
+	index := make(map[string]int, len(nodes))
+	for i, id := range nodes {
+		index[id] = i
+	}
+	size := func(i int) uint64 {
+		if i < len(info.Sizes) {
+			return info.Sizes[i]
+		}
+		return 0
+	}
+
+	var blocks []base.BlockInfo
+	labelled := make([]bool, len(nodes))
+	for label, root := range info.Labels {
+		sub, err := info.InfoAtIndex(root)
+		if err != nil || sub.Manifest == nil {
+			continue
+		}
+		component := dagLabelComponents[label]
+		if component == "" {
+			component = label
+		}
+		for _, id := range sub.Manifest.Nodes {
+			i, ok := index[id]
+			if !ok {
+				continue
+			}
+			labelled[i] = true
+			blocks = append(blocks, base.BlockInfo{ID: id, Size: size(i), Component: component, Root: i == root})
+		}
+	}
+	for i, id := range nodes {
+		if !labelled[i] {
+			blocks = append(blocks, base.BlockInfo{ID: id, Size: size(i), Component: "dataset"})
+		}
+	}
+	return blocks
+}
@@ -0,0 +1,82 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dag"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	reporef "github.com/qri-io/qri/repo/ref"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestRepoMethodsCheck(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst := NewInstanceFromConfigAndNode(config.DefaultConfigForTesting(), node)
+	m := NewRepoMethods(inst)
+
+	pro, err := mr.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dangling := reporef.DatasetRef{ProfileID: pro.ID, Peername: pro.Peername, Name: "dangling", Path: "/map/QmDanglingPath"}
+	if err := mr.PutRef(dangling); err != nil {
+		t.Fatal(err)
+	}
+
+	res := &CheckRepoResult{}
+	if err := m.Check(&CheckRepoParams{VerifyBlocks: true}, res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Counts[base.ProblemDanglingRef] != 1 {
+		t.Errorf("expected one dangling ref, got: %d", res.Counts[base.ProblemDanglingRef])
+	}
+	if res.Counts[base.ProblemMissingBlocks] != 0 {
+		t.Errorf("expected no missing blocks, got: %d", res.Counts[base.ProblemMissingBlocks])
+	}
+
+	res = &CheckRepoResult{}
+	if err := m.Check(&CheckRepoParams{Repair: true, Drop: []string{dangling.AliasString()}}, res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Repaired) != 1 || res.Repaired[0] != dangling.AliasString() {
+		t.Errorf("expected dangling ref to be repaired, got: %v", res.Repaired)
+	}
+	if _, err := mr.GetRef(dangling); err == nil {
+		t.Error("expected dropped reference to be removed")
+	}
+}
+
+func TestDagBlocks(t *testing.T) {
+	info := &dag.Info{
+		Manifest: &dag.Manifest{
+			Nodes: []string{"root", "meta", "body", "bodyChunk"},
+			Links: [][2]int{{0, 1}, {0, 2}, {2, 3}},
+		},
+		Labels: map[string]int{"bd": 2},
+		Sizes:  []uint64{10, 20, 30, 40},
+	}
+
+	got := map[string]base.BlockInfo{}
+	for _, b := range dagBlocks(info) {
+		got[b.ID] = b
+	}
+	expect := map[string]base.BlockInfo{
+		"root":      {ID: "root", Size: 10, Component: "dataset"},
+		"meta":      {ID: "meta", Size: 20, Component: "dataset"},
+		"body":      {ID: "body", Size: 30, Component: "body", Root: true},
+		"bodyChunk": {ID: "bodyChunk", Size: 40, Component: "body"},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("blocks mismatch (-want +got):\n%s", diff)
+	}
+}
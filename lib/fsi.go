@@ -30,6 +30,7 @@ func (m FSIMethods) CoreRequestsName() string { return "fsi" }
 
 // LinkedRefs lists all fsi links
 func (m *FSIMethods) LinkedRefs(p *ListParams, res *[]reporef.DatasetRef) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.LinkedRefs", p, res)
 	}
@@ -47,6 +48,7 @@ type LinkParams struct {
 
 // CreateLink creates a connection between a working drirectory and a dataset history
 func (m *FSIMethods) CreateLink(p *LinkParams, res *string) (err error) {
+	defer func() { err = wrapErr(err) }()
 	// absolutize path name
 	path, err := filepath.Abs(p.Dir)
 	if err != nil {
@@ -65,6 +67,7 @@ func (m *FSIMethods) CreateLink(p *LinkParams, res *string) (err error) {
 
 // Unlink rmeoves a connection between a working drirectory and a dataset history
 func (m *FSIMethods) Unlink(p *LinkParams, res *string) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.Unlink", p, res)
 	}
@@ -78,6 +81,7 @@ type StatusItem = fsi.StatusItem
 // Status checks for any modifications or errors in a linked directory against its previous
 // version in the repo. Must only be called if FSI is enabled for this dataset.
 func (m *FSIMethods) Status(dir *string, res *[]StatusItem) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.Status", dir, res)
 	}
@@ -91,6 +95,7 @@ func (m *FSIMethods) Status(dir *string, res *[]StatusItem) (err error) {
 // the status of its current working directory. It is an error to call this for a reference that
 // is not linked.
 func (m *FSIMethods) StatusForAlias(alias *string, res *[]StatusItem) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.AliasStatus", alias, res)
 	}
@@ -107,6 +112,7 @@ func (m *FSIMethods) StatusForAlias(alias *string, res *[]StatusItem) (err error
 // StatusAtVersion gets changes that happened at a particular version in the history of the given
 // dataset reference. Not used for FSI.
 func (m *FSIMethods) StatusAtVersion(ref *string, res *[]StatusItem) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.StoredStatus", ref, res)
 	}
@@ -120,10 +126,17 @@ func (m *FSIMethods) StatusAtVersion(ref *string, res *[]StatusItem) (err error)
 type CheckoutParams struct {
 	Dir string
 	Ref string
+	// Layout is how components are arranged in the working directory, defaults
+	// to fsi.LayoutFiles
+	Layout string
+	// BodyRange checks out only a range of body rows, of the form "start-end".
+	// Saving splices edits to those rows back into the whole body
+	BodyRange string
 }
 
 // Checkout method writes a dataset to a directory as individual files.
 func (m *FSIMethods) Checkout(p *CheckoutParams, out *string) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.Checkout", p, out)
 	}
@@ -136,7 +149,7 @@ func (m *FSIMethods) Checkout(p *CheckoutParams, out *string) (err error) {
 
 	// If directory exists, error.
 	if _, err = os.Stat(p.Dir); !os.IsNotExist(err) {
-		return fmt.Errorf("directory with name \"%s\" already exists", p.Dir)
+		return fmt.Errorf("directory with name \"%s\" %w", p.Dir, ErrAlreadyExists)
 	}
 
 	// Handle the ref to checkout.
@@ -146,12 +159,23 @@ func (m *FSIMethods) Checkout(p *CheckoutParams, out *string) (err error) {
 	}
 	*ref, err = repo.ParseDatasetRef(p.Ref)
 	if err != nil {
-		return fmt.Errorf("'%s' is not a valid dataset reference", p.Ref)
+		return invalidRefError(p.Ref)
 	}
 	if err = repo.CanonicalizeDatasetRef(m.inst.repo, ref); err != nil {
 		return
 	}
 
+	layout, err := fsi.ParseLayout(p.Layout)
+	if err != nil {
+		return err
+	}
+	var bodyRange *fsi.BodyRange
+	if p.BodyRange != "" {
+		if bodyRange, err = fsi.ParseBodyRange(p.BodyRange); err != nil {
+			return err
+		}
+	}
+
 	log.Debugf("Checkout for ref %q", ref)
 
 	// Load dataset that is being checked out.
@@ -180,6 +204,16 @@ func (m *FSIMethods) Checkout(p *CheckoutParams, out *string) (err error) {
 		log.Debugf("Checkout, fsi.CreateLink failed, error: %s", ref)
 		return err
 	}
+	if layout != fsi.LayoutFiles {
+		if err = fsi.SetLinkLayout(p.Dir, layout); err != nil {
+			return err
+		}
+	}
+	if bodyRange != nil {
+		if err = fsi.SetBodyRange(p.Dir, bodyRange); err != nil {
+			return err
+		}
+	}
 	log.Debugf("Checkout created link for %q <-> %q", p.Dir, p.Ref)
 
 	// Write components of the dataset to the working directory.
@@ -201,6 +235,7 @@ type FSIWriteParams struct {
 
 // Write mutates a linked dataset on the filesystem
 func (m *FSIMethods) Write(p *FSIWriteParams, res *[]StatusItem) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.Write", p, res)
 	}
@@ -214,7 +249,7 @@ func (m *FSIMethods) Write(p *FSIWriteParams, res *[]StatusItem) (err error) {
 	}
 	ref, err := repo.ParseDatasetRef(p.Ref)
 	if err != nil {
-		return fmt.Errorf("'%s' is not a valid dataset reference", p.Ref)
+		return invalidRefError(p.Ref)
 	}
 	err = repo.CanonicalizeDatasetRef(m.inst.node.Repo, &ref)
 	if err != nil && err != repo.ErrNoHistory {
@@ -244,6 +279,7 @@ type RestoreParams struct {
 
 // Restore method restores a component or all of the component files of a dataset from the repo
 func (m *FSIMethods) Restore(p *RestoreParams, out *string) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.Restore", p, out)
 	}
@@ -254,7 +290,7 @@ func (m *FSIMethods) Restore(p *RestoreParams, out *string) (err error) {
 	}
 	ref, err := repo.ParseDatasetRef(p.Ref)
 	if err != nil {
-		return fmt.Errorf("'%s' is not a valid dataset reference", p.Ref)
+		return invalidRefError(p.Ref)
 	}
 	err = repo.CanonicalizeDatasetRef(m.inst.node.Repo, &ref)
 	if err != nil && err != repo.ErrNoHistory {
@@ -310,7 +346,8 @@ func (m *FSIMethods) Restore(p *RestoreParams, out *string) (err error) {
 }
 
 // FSIDatasetForRef reads an fsi-linked dataset for a given reference string
-func (m *FSIMethods) FSIDatasetForRef(refStr *string, res *reporef.DatasetRef) error {
+func (m *FSIMethods) FSIDatasetForRef(refStr *string, res *reporef.DatasetRef) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.FSIDatasetForRef", refStr, res)
 	}
@@ -355,7 +392,8 @@ type FSIBodyParams struct {
 }
 
 // FSIDatasetBody grabs the body of a dataset
-func (m *FSIMethods) FSIDatasetBody(p *FSIBodyParams, res *[]byte) error {
+func (m *FSIMethods) FSIDatasetBody(p *FSIBodyParams, res *[]byte) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.FSIDatasetBody", p, res)
 	}
@@ -374,7 +412,7 @@ func (m *FSIMethods) FSIDatasetBody(p *FSIBodyParams, res *[]byte) error {
 		return err
 	}
 
-	*res, err = fsi.GetBody(ref.FSIPath, df, p.FormatConfig, p.Offset, p.Limit, p.All)
+	*res, err = fsi.GetBody(ref.FSIPath, df, p.FormatConfig, nil, p.Offset, p.Limit, p.All)
 	return err
 }
 
@@ -383,11 +421,19 @@ type InitFSIDatasetParams = fsi.InitParams
 
 // InitDataset creates a new dataset and FSI link
 func (m *FSIMethods) InitDataset(p *InitFSIDatasetParams, name *string) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.InitDataset", p, name)
 	}
 
-	*name, err = m.inst.fsi.InitDataset(*p)
+	params := *p
+	if params.Template != "" {
+		if params.Scaffold, err = datasetTemplate(m.inst, params.Template); err != nil {
+			return err
+		}
+	}
+
+	*name, err = m.inst.fsi.InitDataset(params)
 	return err
 }
 
@@ -398,7 +444,8 @@ type EnsureParams struct {
 }
 
 // EnsureRef will modify the directory path in the repo for the given reference
-func (m *FSIMethods) EnsureRef(p *EnsureParams, out *bool) error {
+func (m *FSIMethods) EnsureRef(p *EnsureParams, out *bool) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.EnsureRef", p, out)
 	}
@@ -0,0 +1,128 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// ImportGitParams configures importing the git history of a file as versions
+// of a dataset
+type ImportGitParams struct {
+	// RepoPath is the path to a local git clone
+	RepoPath string
+	// FilePath is the file to import, relative to the root of the git repository
+	FilePath string
+	// Ref is the dataset to save versions to, it's created if it doesn't exist
+	Ref string
+	// After resumes an import after the git commit with this hash, see
+	// ImportGitResult.Cursor
+	After string
+}
+
+// ImportGitResult is the outcome of importing git history
+type ImportGitResult struct {
+	Ref *reporef.DatasetRef `json:"ref,omitempty"`
+	// Imported counts git commits saved as versions
+	Imported int `json:"imported"`
+	// Skipped counts git commits that didn't change the file's data
+	Skipped int `json:"skipped"`
+	// Cursor is the hash of the last git commit imported or skipped. An import
+	// that fails can be continued by passing the cursor as ImportGitParams.After
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// ImportGit saves a version of a dataset for each git commit that changed a
+// file, oldest first. Versions keep the git commit message & author date.
+// Commits that don't change the file's data are skipped
+func (r *DatasetRequests) ImportGit(p *ImportGitParams, res *ImportGitResult) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.ImportGit", p, res)
+	}
+	ctx := context.TODO()
+	defer r.inst.lockWrites()()
+
+	if p.RepoPath == "" || p.FilePath == "" {
+		return fmt.Errorf("a git repository path and a file path are required")
+	}
+	ref, err := repo.ParseDatasetRef(p.Ref)
+	if err != nil {
+		return err
+	}
+	if ref.Name == "" {
+		return fmt.Errorf("a dataset name to import to is required")
+	}
+	ext := strings.TrimPrefix(filepath.Ext(p.FilePath), ".")
+	if _, err := dataset.ParseDataFormatString(ext); err != nil {
+		return fmt.Errorf("can't import %s: %s", p.FilePath, err)
+	}
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil && !repo.IsNotFound(err) && err != repo.ErrNoHistory {
+		return err
+	}
+	fsiPath := ref.FSIPath
+
+	commits, err := base.GitFileHistory(ctx, p.RepoPath, p.FilePath)
+	if err != nil {
+		return err
+	}
+	if p.After != "" {
+		i := 0
+		for i < len(commits) && !strings.HasPrefix(commits[i].Hash, p.After) {
+			i++
+		}
+		if i == len(commits) {
+			return fmt.Errorf("commit %s isn't in the history of %s", p.After, p.FilePath)
+		}
+		commits = commits[i+1:]
+	}
+
+	*res = ImportGitResult{Cursor: p.After}
+	for _, c := range commits {
+		data, err := base.GitFileAt(ctx, p.RepoPath, c.Hash, p.FilePath)
+		if err != nil {
+			return err
+		}
+		ds := &dataset.Dataset{
+			Peername: ref.Peername,
+			Name:     ref.Name,
+			Commit: &dataset.Commit{
+				Title:   c.Subject,
+				Message: c.Body,
+			},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body."+ext, data))
+
+		switches := base.SaveDatasetSwitches{
+			Pin:             true,
+			CommitTimestamp: c.AuthorDate,
+		}
+		saved, _, err := base.SaveDataset(ctx, r.node.Repo, r.node.LocalStreams, ds, nil, nil, r.inst.withSaveHooks(switches))
+		if errors.Is(err, dsfs.ErrNoChanges) {
+			res.Skipped++
+			res.Cursor = c.Hash
+			continue
+		} else if err != nil {
+			return fmt.Errorf("importing commit %s: %w", c.Hash, err)
+		}
+		if fsiPath != "" {
+			saved.FSIPath = fsiPath
+			if err = r.node.Repo.PutRef(saved); err != nil {
+				return err
+			}
+		}
+		res.Ref = &saved
+		res.Imported++
+		res.Cursor = c.Hash
+	}
+	return nil
+}
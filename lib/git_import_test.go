@@ -0,0 +1,98 @@
+package lib
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/dsfs"
+)
+
+func TestDatasetRequestsImportGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	gitDir := filepath.Join(tr.Dir, "snapshots")
+	if err := os.Mkdir(gitDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	git := func(date string, args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", gitDir, "-c", "user.name=test", "-c", "user.email=test@qri.io"}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s\n%s", args, err, out)
+		}
+	}
+	commit := func(date, msg, body string) {
+		tr.MustWriteFile(t, filepath.Join(gitDir, "counts.csv"), body)
+		tr.MustWriteFile(t, filepath.Join(gitDir, "notes.txt"), msg)
+		git(date, "add", "-A")
+		git(date, "commit", "-m", msg)
+	}
+	git("2017-01-01T00:00:00Z", "init")
+	commit("2017-01-01T00:00:00Z", "first snapshot", "name,count\nfoo,1\nbar,2\n")
+	commit("2018-01-01T00:00:00Z", "second snapshot", "name,count\nfoo,3\nbar,2\n")
+	commit("2020-01-01T00:00:00Z", "third snapshot", "name,count\nfoo,3\nbar,4\n")
+
+	history, err := base.GitFileHistory(context.Background(), gitDir, "counts.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("expected 3 commits in file history, got: %d", len(history))
+	}
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	p := &ImportGitParams{RepoPath: gitDir, FilePath: "counts.csv", Ref: "me/counts", After: history[0].Hash[:8]}
+	res := ImportGitResult{}
+	if err := req.ImportGit(p, &res); err != nil {
+		t.Fatal(err)
+	}
+	// resuming after the first commit creates the dataset from the second
+	if res.Imported != 2 || res.Skipped != 0 || res.Cursor != history[2].Hash {
+		t.Errorf("result mismatch. got imported: %d skipped: %d cursor: %s", res.Imported, res.Skipped, res.Cursor)
+	}
+
+	ds, err := dsfs.LoadDataset(context.Background(), tr.Instance.Repo().Store(), res.Ref.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Commit.Title != "third snapshot" {
+		t.Errorf("expected git commit message to be kept, got: %q", ds.Commit.Title)
+	}
+	if expect := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC); !ds.Commit.Timestamp.Equal(expect) {
+		t.Errorf("expected commit timestamp %s, got: %s", expect, ds.Commit.Timestamp)
+	}
+	prev, err := dsfs.LoadDataset(context.Background(), tr.Instance.Repo().Store(), ds.PreviousPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev.Commit.Title != "second snapshot" || prev.PreviousPath != "" {
+		t.Errorf("expected previous version to be the first imported, got: %q, previous path: %q", prev.Commit.Title, prev.PreviousPath)
+	}
+
+	// re-importing a snapshot identical to the latest version skips it
+	p.After = history[1].Hash
+	if err := req.ImportGit(p, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Imported != 0 || res.Skipped != 1 || res.Cursor != history[2].Hash {
+		t.Errorf("result mismatch. got imported: %d skipped: %d cursor: %s", res.Imported, res.Skipped, res.Cursor)
+	}
+
+	p.After = "0000000"
+	if err := req.ImportGit(p, &res); err == nil {
+		t.Error("expected resuming from a commit outside the file's history to error")
+	}
+	p.After, p.FilePath = "", "notes.txt"
+	if err := req.ImportGit(p, &res); err == nil {
+		t.Error("expected importing an unsupported file format to error")
+	}
+}
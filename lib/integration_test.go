@@ -2,18 +2,29 @@ package lib
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"io/ioutil"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
+	crypto "github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/event"
 	"github.com/qri-io/qri/registry"
 	"github.com/qri-io/qri/registry/regserver"
 	"github.com/qri-io/qri/remote"
+	"github.com/qri-io/qri/repo"
 	reporef "github.com/qri-io/qri/repo/ref"
 	repotest "github.com/qri-io/qri/repo/test"
+	"github.com/qri-io/qri/watchfs"
 )
 
 func TestTwoActorRegistryIntegration(t *testing.T) {
@@ -84,6 +95,431 @@ func TestTwoActorRegistryIntegration(t *testing.T) {
 	// TODO (b5) - assert hinshun has world bank dataset blocks
 }
 
+func TestPrefetchIntegration(t *testing.T) {
+	tr := NewNetworkIntegrationTestRunner(t, "integration_prefetch")
+	defer tr.Cleanup()
+
+	nasim := tr.InitNasim(t)
+	ref := InitWorldBankDataset(t, nasim)
+	PublishToRegistry(t, nasim, ref.AliasString())
+
+	hinshun := tr.InitHinshun(t)
+	ctx := context.Background()
+	if has, _ := hinshun.Repo().Store().Has(ctx, ref.Path); has {
+		t.Fatal("expected dataset not to be in the store before prefetching")
+	}
+
+	res := dsref.Ref{}
+	if err := NewRemoteMethods(hinshun).Prefetch(&PrefetchParams{Ref: ref.AliasString()}, &res); err != nil {
+		t.Fatalf("prefetching: %s", err)
+	}
+	if res.Path != ref.Path {
+		t.Errorf("prefetched path mismatch. expected: %q, got: %q", ref.Path, res.Path)
+	}
+
+	if has, err := hinshun.Repo().Store().Has(ctx, ref.Path); err != nil || !has {
+		t.Errorf("expected prefetched dataset to be in the store. has: %t, err: %v", has, err)
+	}
+	if _, err := dsfs.LoadDataset(ctx, hinshun.Repo().Store(), ref.Path); err != nil {
+		t.Errorf("loading prefetched dataset: %s", err)
+	}
+
+	// prefetching isn't adding, the dataset isn't in the repo
+	if _, err := hinshun.Repo().GetRef(reporef.DatasetRef{Peername: ref.Peername, Name: ref.Name}); err != repo.ErrNotFound {
+		t.Errorf("expected prefetched dataset not to be added to the repo, got: %v", err)
+	}
+
+	// prefetched versions are recorded for pin management
+	pins := PinsResult{}
+	if err := NewRepoMethods(hinshun).Pins(&PinsParams{Ref: ref.AliasString()}, &pins); err != nil {
+		t.Fatalf("listing pins: %s", err)
+	}
+	if len(pins.Versions) != 0 || len(pins.Prefetched) != 1 || pins.Prefetched[0].Path != ref.Path || !pins.Prefetched[0].Pinned {
+		t.Errorf("expected a single pinned prefetched version, got: %#v", pins)
+	}
+
+	unpinned := UnpinResult{}
+	if err := NewRepoMethods(hinshun).Unpin(&UnpinParams{Ref: ref.AliasString(), Paths: []string{ref.Path}}, &unpinned); err != nil {
+		t.Fatalf("unpinning prefetched version: %s", err)
+	}
+	if err := NewRepoMethods(hinshun).Pins(&PinsParams{Ref: ref.AliasString()}, &pins); err == nil {
+		t.Errorf("expected unpinning the only prefetched version to drop the dataset from pin management, got: %#v", pins)
+	}
+
+	// a bare version path doesn't say which dataset the pins belong to
+	err := NewRemoteMethods(hinshun).Prefetch(&PrefetchParams{Ref: ref.Path}, &res)
+	if err == nil || err.Error() != ErrBadArgs.Error() {
+		t.Errorf("expected prefetching a bare path to be bad arguments, got: %v", err)
+	}
+}
+
+func TestSyncStatusIntegration(t *testing.T) {
+	tr := NewNetworkIntegrationTestRunner(t, "integration_sync_status")
+	defer tr.Cleanup()
+
+	nasim := tr.InitNasim(t)
+	ref := InitWorldBankDataset(t, nasim)
+	PublishToRegistry(t, nasim, ref.AliasString())
+
+	status := SyncStatus(t, nasim, "")
+	if len(status) != 1 {
+		t.Fatalf("expected status of 1 published dataset, got: %d", len(status))
+	}
+	if status[0].State != remote.SyncStateInSync {
+		t.Errorf("expected published dataset to be in sync, got: %s %s", status[0].State, status[0].Error)
+	}
+
+	// - nasim commits a new version without publishing it
+	ref = Commit2WorldBank(t, nasim)
+	status = SyncStatus(t, nasim, ref.AliasString())
+	if status[0].State != remote.SyncStateAhead || status[0].Ahead != 1 {
+		t.Errorf("expected dataset to be ahead by 1, got: %s by %d %s", status[0].State, status[0].Ahead, status[0].Error)
+	}
+	if status[0].LocalHead != ref.Path {
+		t.Errorf("expected local head %q, got: %q", ref.Path, status[0].LocalHead)
+	}
+
+	infos := []dsref.VersionInfo{}
+	if err := NewDatasetRequestsInstance(nasim).List(&ListParams{Limit: 10, WithSyncStatus: true}, &infos); err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].SyncStatus != string(remote.SyncStateAhead) {
+		t.Errorf("expected listing to include an ahead sync status, got: %v", infos)
+	}
+
+	// - a remote that isn't configured leaves listed statuses unknown
+	infos = []dsref.VersionInfo{}
+	if err := NewDatasetRequestsInstance(nasim).List(&ListParams{Limit: 10, WithSyncStatus: true, RemoteName: "not_a_remote"}, &infos); err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].SyncStatus != string(remote.SyncStateUnknown) {
+		t.Errorf("expected listing with an unknown remote to include an unknown sync status, got: %v", infos)
+	}
+
+	// - datasets are only compared with remotes they're published to
+	nasim.Config().Remotes = &config.Remotes{"other": "http://other.example.com"}
+	status = []remote.SyncStatus{}
+	if err := NewRemoteMethods(nasim).SyncStatus(&SyncStatusParams{RemoteName: "other"}, &status); err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 0 {
+		t.Errorf("expected no statuses for a remote nothing is published to, got: %v", status)
+	}
+	infos = []dsref.VersionInfo{}
+	if err := NewDatasetRequestsInstance(nasim).List(&ListParams{Limit: 10, WithSyncStatus: true, RemoteName: "other"}, &infos); err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].SyncStatus != "" {
+		t.Errorf("expected listing with a remote nothing is published to to leave sync status empty, got: %v", infos)
+	}
+
+	// - an unreachable remote degrades to unknown
+	tr.RegistryHTTPServer.Close()
+	status = SyncStatus(t, nasim, "")
+	if status[0].State != remote.SyncStateUnknown || status[0].Error == "" {
+		t.Errorf("expected unreachable remote to report an unknown state with an error, got: %s", status[0].State)
+	}
+}
+
+func TestRemoteLogIntegration(t *testing.T) {
+	tr := NewNetworkIntegrationTestRunner(t, "integration_remote_log")
+	defer tr.Cleanup()
+
+	nasim := tr.InitNasim(t)
+	ref := InitWorldBankDataset(t, nasim)
+	PublishToRegistry(t, nasim, ref.AliasString())
+
+	hinshun := tr.InitHinshun(t)
+	logs := NewLogRequestsInstance(hinshun)
+	versions := []dsref.VersionInfo{}
+	p := &LogParams{Ref: ref.AliasString(), ListParams: ListParams{Limit: 10}}
+	if err := logs.Log(p, &versions); err == nil {
+		t.Error("expected log of a dataset that isn't local to error without fetching remotes")
+	}
+
+	p.FetchRemote = true
+	if err := logs.Log(p, &versions); err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 {
+		t.Fatalf("expected 1 remote version, got: %d", len(versions))
+	}
+	if versions[0].Path != ref.Path {
+		t.Errorf("version path mismatch. want: %q got: %q", ref.Path, versions[0].Path)
+	}
+	if !versions[0].Foreign || versions[0].Remote != tr.RegistryHTTPServer.URL {
+		t.Errorf("expected remote version to be marked as remote-sourced, got: %#v", versions[0])
+	}
+
+	// fetching history doesn't add the dataset
+	if _, err := hinshun.Repo().GetRef(reporef.DatasetRef{Peername: ref.Peername, Name: ref.Name}); err == nil {
+		t.Error("expected fetching a remote log to leave the local repo unchanged")
+	}
+}
+
+func TestPublishToRemotesIntegration(t *testing.T) {
+	tr := NewNetworkIntegrationTestRunner(t, "integration_publish_to_remotes")
+	defer tr.Cleanup()
+
+	nasim := tr.InitNasim(t)
+	ref := InitWorldBankDataset(t, nasim)
+
+	remotes := config.Remotes{
+		"registry": tr.RegistryHTTPServer.URL,
+		"offline":  "http://127.0.0.1:1",
+	}
+	nasim.Config().Remotes = &remotes
+
+	p := &PublicationParams{
+		Ref:         ref.AliasString(),
+		RemoteNames: []string{"registry", "offline"},
+		Timeout:     time.Second * 5,
+	}
+	res := []PublishResult{}
+	if err := NewRemoteMethods(nasim).PublishToRemotes(p, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("expected a result for each remote, got: %d", len(res))
+	}
+	if res[0].Error != "" || res[0].Skipped {
+		t.Errorf("expected publishing to the registry to succeed, got: %#v", res[0])
+	}
+	if res[1].Error == "" {
+		t.Errorf("expected publishing to an offline remote to fail")
+	}
+
+	pub, err := nasim.Repo().GetRef(reporef.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Published {
+		t.Error("expected partial success to mark the dataset published")
+	}
+	dests, err := nasim.Repo().Logbook().PublishedDestinations(tr.Ctx, reporef.ConvertToDsref(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dests) != 1 || dests[0] != tr.RegistryHTTPServer.URL {
+		t.Errorf("expected the registry to be recorded as a destination, got: %v", dests)
+	}
+
+	refstr := ref.AliasString()
+	recs := []PublishRecord{}
+	if err := NewRemoteMethods(nasim).PublishedTo(&refstr, &recs); err != nil {
+		t.Fatal(err)
+	}
+	expect := []PublishRecord{{Destination: tr.RegistryHTTPServer.URL, Revisions: 1}}
+	if diff := cmp.Diff(expect, recs); diff != "" {
+		t.Errorf("published to mismatch (-want +got):\n%s", diff)
+	}
+
+	// publishing again only retries the remote that failed
+	if err := NewRemoteMethods(nasim).PublishToRemotes(p, &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res[0].Skipped {
+		t.Errorf("expected the registry to be skipped, got: %#v", res[0])
+	}
+	if res[1].Skipped || res[1].Error == "" {
+		t.Errorf("expected the offline remote to be retried, got: %#v", res[1])
+	}
+}
+
+func TestPublishManyIntegration(t *testing.T) {
+	tr := NewNetworkIntegrationTestRunner(t, "integration_publish_many")
+	defer tr.Cleanup()
+
+	nasim := tr.InitNasim(t)
+	ref := InitWorldBankDataset(t, nasim)
+	rm := NewRemoteMethods(nasim)
+
+	p := &BulkPublicationParams{
+		Refs: []string{"me/world_*", "me/no_match_*", "hinshun/world_*", "me/missing"},
+	}
+	res := []BulkPublicationResult{}
+	if err := rm.PublishMany(p, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 4 {
+		t.Fatalf("expected a result for each ref, got: %#v", res)
+	}
+	if res[0].Ref != ref.AliasString() || res[0].Error != "" {
+		t.Errorf("expected pattern to publish %s, got: %#v", ref.AliasString(), res[0])
+	}
+	for _, r := range res[1:] {
+		if r.Error == "" {
+			t.Errorf("expected %q to fail", r.Ref)
+		}
+	}
+	if res[2].Ref != "hinshun/world_*" {
+		t.Errorf("expected a pattern in another namespace to be reported unexpanded, got: %q", res[2].Ref)
+	}
+
+	p.Unpublish = true
+	p.Refs = []string{ref.AliasString()}
+	if err := rm.PublishMany(p, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Error != "" {
+		t.Errorf("expected unpublishing to succeed, got: %#v", res)
+	}
+	unpub, err := nasim.Repo().GetRef(reporef.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unpub.Published {
+		t.Error("expected dataset to be unpublished")
+	}
+}
+
+func TestPublishQueueIntegration(t *testing.T) {
+	tr := NewNetworkIntegrationTestRunner(t, "integration_publish_queue")
+	defer tr.Cleanup()
+
+	nasim := tr.InitNasim(t)
+	ref := InitWorldBankDataset(t, nasim)
+	remotes := config.Remotes{"offline": "http://127.0.0.1:1"}
+	nasim.Config().Remotes = &remotes
+	rm := NewRemoteMethods(nasim)
+
+	queue := func() []repo.PublishQueueItem {
+		items := []repo.PublishQueueItem{}
+		if err := rm.PublishQueue(&PublishQueueParams{}, &items); err != nil {
+			t.Fatal(err)
+		}
+		return items
+	}
+
+	// queue a publish to an unreachable remote
+	res := dsref.Ref{}
+	if err := rm.Publish(&PublicationParams{Ref: ref.AliasString(), RemoteName: "offline", Queue: true}, &res); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	nasim.processPublishQueue(tr.Ctx, now)
+	items := queue()
+	if len(items) != 1 {
+		t.Fatalf("expected 1 queued item, got: %d", len(items))
+	}
+	if items[0].Status != repo.PublishQueued || items[0].Attempts < 1 || items[0].LastError == "" || !items[0].NextAttempt.After(now) {
+		t.Errorf("expected a failed attempt to back off, got: %#v", items[0])
+	}
+
+	// the queue is persisted by the repo
+	persisted, err := repo.NewPublishQueue(filepath.Join(nasim.RepoPath(), "publish_queue.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items := persisted.List(); len(items) != 1 || items[0].Address != "http://127.0.0.1:1" {
+		t.Errorf("expected queue to persist, got: %v", items)
+	}
+
+	// saving a new version supersedes the queued version
+	v2 := Commit2WorldBank(t, nasim)
+	items = queue()
+	if items[0].Ref.Path != v2.Path {
+		t.Errorf("expected save to supersede the queued version, got: %#v", items[0])
+	}
+
+	// items that keep failing give up after the max age
+	nasim.processPublishQueue(tr.Ctx, now.Add(PublishQueueMaxAge*2))
+	if items = queue(); items[0].Status != repo.PublishFailed {
+		t.Errorf("expected an item past the max age to fail, got: %#v", items[0])
+	}
+
+	// queued publishes to a reachable remote are published & dequeued
+	if err := rm.Publish(&PublicationParams{Ref: ref.AliasString(), Queue: true}, &res); err != nil {
+		t.Fatal(err)
+	}
+	nasim.processPublishQueue(tr.Ctx, time.Now())
+	for _, item := range queue() {
+		if item.Address == tr.RegistryHTTPServer.URL {
+			t.Errorf("expected registry publish to be dequeued, got: %#v", item)
+		}
+	}
+	pub, err := nasim.Repo().GetRef(reporef.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Published {
+		t.Error("expected queued publish to mark the dataset published")
+	}
+}
+
+func TestAutoPublishIntegration(t *testing.T) {
+	tr := NewNetworkIntegrationTestRunner(t, "integration_auto_publish")
+	defer tr.Cleanup()
+
+	nasim := tr.InitNasim(t)
+	ref := InitWorldBankDataset(t, nasim)
+	remotes := config.Remotes{
+		"registry": tr.RegistryHTTPServer.URL,
+		"offline":  "http://127.0.0.1:1",
+	}
+	nasim.Config().Remotes = &remotes
+
+	tmp, err := ioutil.TempDir("", "integration_auto_publish")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	dir := filepath.Join(tmp, "world_bank_population")
+	out := ""
+	if err := NewFSIMethods(nasim).Checkout(&CheckoutParams{Dir: dir, Ref: ref.AliasString()}, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	nasim.Config().API.AutoPublish = []config.AutoPublishRule{
+		{Dir: dir, Remote: "registry"},
+		{Dir: filepath.Join(tmp, "not_linked")},
+	}
+	actions := nasim.AutoPublishActions()
+	if len(actions) != 1 || actions[0].Username != ref.Peername || actions[0].Dsname != ref.Name {
+		t.Fatalf("expected only the linked directory to resolve to an action, got: %v", actions)
+	}
+
+	events := nasim.Bus().Subscribe(event.ETAutoPublishEvent)
+	defer nasim.Bus().Unsubscribe(events)
+	next := func() event.AutoPublishEvent {
+		select {
+		case e := <-events:
+			return e.Payload.(event.AutoPublishEvent)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for auto publish event")
+		}
+		return event.AutoPublishEvent{}
+	}
+
+	// changes to the working directory are saved & published
+	body := []byte("a,b,c,true,2\nd,e,f,false,3\ng,h,i,true,4\n")
+	if err := ioutil.WriteFile(filepath.Join(dir, "body.csv"), body, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := actions[0].Func(watchfs.FilesysEvent{}); err != nil {
+		t.Fatal(err)
+	}
+	e := next()
+	if e.Action != event.AutoPublishPublished || e.Path == "" || e.Path == ref.Path {
+		t.Errorf("expected a new version to be published, got: %#v", e)
+	}
+	dests, err := nasim.Repo().Logbook().PublishedDestinations(tr.Ctx, dsref.Ref{Username: ref.Peername, Name: ref.Name, Path: e.Path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dests) != 1 || dests[0] != tr.RegistryHTTPServer.URL {
+		t.Errorf("expected the registry to be recorded as a destination, got: %v", dests)
+	}
+
+	// failures are reported on the bus instead of stopping the daemon
+	if err := nasim.autoPublish(tr.Ctx, reporef.DatasetRef{Peername: ref.Peername, Name: ref.Name}, "offline"); err == nil {
+		t.Error("expected publishing to an offline remote to fail")
+	}
+	if e := next(); e.Action != event.AutoPublishFailed || e.Error == "" {
+		t.Errorf("expected a failed event with an error, got: %#v", e)
+	}
+}
+
 type NetworkIntegrationTestRunner struct {
 	Ctx                                  context.Context
 	prefix                               string
@@ -124,6 +560,7 @@ func (tr *NetworkIntegrationTestRunner) InitNasim(t *testing.T) *Instance {
 
 	cfg := r.GetConfig()
 	cfg.Registry.Location = tr.RegistryHTTPServer.URL
+	cfg.RemoteKeys = tr.registryKeys(t)
 	r.WriteConfigFile()
 	tr.nasimRepo = &r
 
@@ -143,6 +580,7 @@ func (tr *NetworkIntegrationTestRunner) InitHinshun(t *testing.T) *Instance {
 	tr.hinshunRepo = &r
 	cfg := tr.hinshunRepo.GetConfig()
 	cfg.Registry.Location = tr.RegistryHTTPServer.URL
+	cfg.RemoteKeys = tr.registryKeys(t)
 	tr.hinshunRepo.WriteConfigFile()
 
 	if tr.Hinshun, err = NewInstance(tr.Ctx, tr.hinshunRepo.QriPath); err != nil {
@@ -196,6 +634,18 @@ func (tr *NetworkIntegrationTestRunner) InitRegistry(t *testing.T) {
 	_, tr.RegistryHTTPServer = regserver.NewMockServerRegistry(tr.Registry)
 }
 
+// registryKeys configures clients to verify registry responses with the
+// registry's key
+func (tr *NetworkIntegrationTestRunner) registryKeys(t *testing.T) *config.RemoteKeys {
+	data, err := crypto.MarshalPublicKey(tr.RegistryInst.Repo().PrivateKey().GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &config.RemoteKeys{
+		"registry": {PubKey: base64.StdEncoding.EncodeToString(data)},
+	}
+}
+
 func AssertLogsEqual(a, b *Instance, ref *reporef.DatasetRef) error {
 	r := reporef.ConvertToDsref(*ref)
 
@@ -221,7 +671,7 @@ func AssertLogsEqual(a, b *Instance, ref *reporef.DatasetRef) error {
 }
 
 func InitWorldBankDataset(t *testing.T, inst *Instance) *reporef.DatasetRef {
-	res := &reporef.DatasetRef{}
+	res := &SaveResult{}
 	err := NewDatasetRequestsInstance(inst).Save(&SaveParams{
 		Publish: true,
 		Ref:     "me/world_bank_population",
@@ -243,11 +693,12 @@ d,e,f,false,3`),
 		log.Fatalf("saving dataset version: %s", err)
 	}
 
-	return res
+	ref := res.Ref()
+	return &ref
 }
 
 func Commit2WorldBank(t *testing.T, inst *Instance) *reporef.DatasetRef {
-	res := &reporef.DatasetRef{}
+	res := &SaveResult{}
 	err := NewDatasetRequestsInstance(inst).Save(&SaveParams{
 		Publish: true,
 		Ref:     "me/world_bank_population",
@@ -266,7 +717,8 @@ g,g,i,true,4`),
 		log.Fatalf("saving dataset version: %s", err)
 	}
 
-	return res
+	ref := res.Ref()
+	return &ref
 }
 
 func PublishToRegistry(t *testing.T, inst *Instance, refstr string) *dsref.Ref {
@@ -282,6 +734,14 @@ func PublishToRegistry(t *testing.T, inst *Instance, refstr string) *dsref.Ref {
 	return &res
 }
 
+func SyncStatus(t *testing.T, inst *Instance, refstr string) []remote.SyncStatus {
+	res := []remote.SyncStatus{}
+	if err := NewRemoteMethods(inst).SyncStatus(&SyncStatusParams{Ref: refstr}, &res); err != nil {
+		t.Fatalf("getting sync status: %s", err)
+	}
+	return res
+}
+
 func SearchFor(t *testing.T, inst *Instance, term string) []SearchResult {
 	results := []SearchResult{}
 	if err := NewSearchMethods(inst).Search(&SearchParams{QueryString: term}, &results); err != nil {
@@ -7,11 +7,14 @@ import (
 	"context"
 	"encoding/gob"
 	"fmt"
+	"io"
 	"net"
 	"net/rpc"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	golog "github.com/ipfs/go-log"
 	homedir "github.com/mitchellh/go-homedir"
@@ -65,7 +68,7 @@ func Receivers(inst *Instance) []Methods {
 		NewDatasetRequestsInstance(inst),
 		NewRegistryClientMethods(inst),
 		NewRemoteMethods(inst),
-		NewLogRequests(node, nil),
+		NewLogRequestsInstance(inst),
 		NewExportRequests(node, nil),
 		NewPeerRequests(node, nil),
 		NewProfileMethods(inst),
@@ -74,6 +77,8 @@ func Receivers(inst *Instance) []Methods {
 		NewRenderRequests(r, nil),
 		NewUpdateMethods(inst),
 		NewFSIMethods(inst),
+		NewRepoMethods(inst),
+		NewTrashMethods(inst),
 	}
 }
 
@@ -104,10 +109,13 @@ type InstanceOptions struct {
 	statsCache *stats.Cache
 	logbook    *logbook.Book
 	logAll     bool
+	noNetwork  bool
 
 	remoteMockClient bool
 	// use OptRemoteOptions to set this
 	remoteOptsFunc func(*remote.Options)
+	// use OptTrustPrompt to set this
+	trustPrompt func(remoteAddr, keyID string) bool
 }
 
 // InstanceContextKey is used by context to set keys for constucting a lib.Instance
@@ -191,6 +199,21 @@ func OptCheckConfigMigrations(cfgPath string) Option {
 	}
 }
 
+// OptEnvironment applies the named environment's overrides to the instance
+// configuration. An empty name leaves the configuration as-is
+func OptEnvironment(name string) Option {
+	return func(o *InstanceOptions) (err error) {
+		if name == "" {
+			return nil
+		}
+		if o.Cfg == nil {
+			return fmt.Errorf("no config to apply environment %q to", name)
+		}
+		o.Cfg, err = o.Cfg.WithEnvironment(name)
+		return err
+	}
+}
+
 // OptSetLogAll sets the logAll value so that debug level logging is enabled for all qri packages
 func OptSetLogAll(logAll bool) Option {
 	return func(o *InstanceOptions) error {
@@ -209,6 +232,16 @@ func OptRemoteOptions(fn func(opt *remote.Options)) Option {
 	}
 }
 
+// OptTrustPrompt sets the function asked whether to trust the key a remote
+// signs responses with the first time the remote is contacted. Without a
+// prompt the first key a remote signs with is trusted & logged
+func OptTrustPrompt(fn func(remoteAddr, keyID string) bool) Option {
+	return func(o *InstanceOptions) error {
+		o.trustPrompt = fn
+		return nil
+	}
+}
+
 // OptQriNode configures bring-your-own qri node
 func OptQriNode(node *p2p.QriNode) Option {
 	return func(o *InstanceOptions) error {
@@ -226,6 +259,33 @@ func OptQriNode(node *p2p.QriNode) Option {
 	}
 }
 
+// OptStore overrides the configured content-addressed store, eg: to keep
+// datasets in memory with cafs.NewMapstore()
+func OptStore(store cafs.Filestore) Option {
+	return func(o *InstanceOptions) error {
+		o.store = store
+		return nil
+	}
+}
+
+// OptFilesystem overrides the configured filesystem
+func OptFilesystem(fs qfs.Filesystem) Option {
+	return func(o *InstanceOptions) error {
+		o.qfs = fs
+		return nil
+	}
+}
+
+// OptNoNetwork keeps an instance from connecting to anything. p2p, RPC, the
+// API, the remote, the registry client and the update service are all
+// disabled, regardless of configuration
+func OptNoNetwork() Option {
+	return func(o *InstanceOptions) error {
+		o.noNetwork = true
+		return nil
+	}
+}
+
 // OptRegistryClient overrides any configured registry client
 func OptRegistryClient(cli *regclient.Client) Option {
 	return func(o *InstanceOptions) error {
@@ -253,17 +313,21 @@ func OptLogbook(bk *logbook.Book) Option {
 // NewInstance creates a new Qri Instance, if no Option funcs are provided,
 // New uses a default set of Option funcs. Any Option functions passed to this
 // function must check whether their fields are nil or not.
+//
+// An empty repoPath creates an in-process instance that never reads from or
+// writes to disk. In-process instances must be given a configuration with
+// OptConfig that uses an in-memory repo and update service, and either an
+// in-memory store or one given with OptStore. Without OptFilesystem the store
+// is used as the filesystem
 func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Instance, err error) {
-	if repoPath == "" {
-		return nil, fmt.Errorf("repo path is required")
-	}
-
 	o := &InstanceOptions{}
 
 	// attempt to load a base configuration from repoPath
-	if o.Cfg, err = loadRepoConfig(repoPath); err != nil {
-		log.Error("loading config: %s", err)
-		return
+	if repoPath != "" {
+		if o.Cfg, err = loadRepoConfig(repoPath); err != nil {
+			log.Error("loading config: %s", err)
+			return
+		}
 	}
 
 	if len(opts) == 0 {
@@ -281,7 +345,9 @@ func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Ins
 	}
 
 	cfg := o.Cfg
-	if cfg == nil {
+	if cfg == nil && repoPath == "" {
+		return nil, fmt.Errorf("repo path is required")
+	} else if cfg == nil {
 		// If at this point we don't have a configuration pointer one couldn't be
 		// loaded from repoPath, and a configuration wasn't provided through Options,
 		// so qri needs to be set up
@@ -291,6 +357,15 @@ func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Ins
 		return
 	}
 
+	if repoPath == "" {
+		if err = checkInProcessConfig(cfg, o); err != nil {
+			return nil, err
+		}
+	}
+	if o.noNetwork {
+		cfg = disableNetwork(cfg)
+	}
+
 	ctx, teardown := context.WithCancel(ctx)
 	inst := &Instance{
 		ctx:      ctx,
@@ -298,11 +373,12 @@ func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Ins
 		repoPath: repoPath,
 		cfg:      cfg,
 
-		node:     o.node,
-		streams:  o.Streams,
-		registry: o.regclient,
-		logbook:  o.logbook,
-		bus:      event.NewBus(ctx),
+		node:        o.node,
+		streams:     o.Streams,
+		registry:    o.regclient,
+		logbook:     o.logbook,
+		bus:         event.NewBus(ctx),
+		trustPrompt: o.trustPrompt,
 	}
 	qri = inst
 
@@ -323,7 +399,7 @@ func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Ins
 		log.Debugf("--log-all set: turning on logging for all activity")
 	}
 
-	if inst.cron, err = newCron(cfg, inst.repoPath); err != nil {
+	if inst.cron, err = newCron(cfg, inst.repoPath, !o.noNetwork); err != nil {
 		log.Error("initializing cron:", err.Error())
 		return nil, fmt.Errorf("newCron: %s", err)
 	}
@@ -335,8 +411,14 @@ func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Ins
 		if err == nil {
 			// we have a connection
 			log.Debugf("using RPC address %s", addr)
-			inst.rpc = rpc.NewClient(conn)
-			return qri, err
+			hs, rwc, err := ClientRPCHandshake(conn)
+			if err != nil {
+				conn.Close()
+				return nil, err
+			}
+			inst.rpcHandshake = hs
+			inst.rpc = rpc.NewClient(rwc)
+			return qri, nil
 		}
 	}
 
@@ -351,6 +433,9 @@ func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Ins
 
 	if o.qfs != nil {
 		inst.qfs = o.qfs
+	} else if inst.qfs == nil && inst.repoPath == "" {
+		// in-process instances keep everything in the store
+		inst.qfs = inst.store
 	} else if inst.qfs == nil {
 		if inst.qfs, err = buildrepo.NewFilesystem(cfg, inst.store); err != nil {
 			log.Error("intializing filesystem:", err.Error())
@@ -372,7 +457,7 @@ func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Ins
 		}
 	}
 
-	if inst.registry == nil {
+	if inst.registry == nil && !o.noNetwork {
 		inst.registry = newRegClient(ctx, cfg)
 	}
 
@@ -383,6 +468,15 @@ func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Ins
 			log.Error("intializing repo:", err.Error())
 			return nil, fmt.Errorf("newRepo: %s", err)
 		}
+		// close the repo this instance built when the instance is torn down
+		if c, ok := inst.repo.(io.Closer); ok {
+			go func() {
+				<-ctx.Done()
+				if err := c.Close(); err != nil {
+					log.Debugf("closing repo: %s", err)
+				}
+			}()
+		}
 	}
 
 	if o.statsCache != nil {
@@ -392,12 +486,25 @@ func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Ins
 	}
 
 	if inst.repo != nil {
-		// Try to make the repo a hidden directory, but it's okay if we can't. Ignore the error.
-		// TODO (b5) - this can't happen in the buildrepo package due to import cycles. if SetFileHidden
-		// were somewhere else we could move it there
-		_ = base.SetFileHidden(inst.repoPath)
+		if inst.repoPath != "" {
+			// Try to make the repo a hidden directory, but it's okay if we can't. Ignore the error.
+			// TODO (b5) - this can't happen in the buildrepo package due to import cycles. if SetFileHidden
+			// were somewhere else we could move it there
+			_ = base.SetFileHidden(inst.repoPath)
+		}
 
 		inst.fsi = fsi.NewFSI(inst.repo, inst.bus)
+
+		if u := base.DiskUsageOf(inst.repo); u != nil && cfg.Repo != nil {
+			u.SetQuota(cfg.Repo.MaxSize, cfg.Repo.MaxVersionSize)
+		}
+		if base.TrashOf(inst.repo) != nil {
+			go inst.sweepTrash(ctx, trashSweepInterval)
+		}
+		if publishQueueOf(inst.repo) != nil {
+			inst.pubqKick = make(chan struct{}, 1)
+			go inst.runPublishQueue(ctx, publishQueueInterval)
+		}
 	}
 
 	if inst.node == nil {
@@ -406,6 +513,9 @@ func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Ins
 			return
 		}
 	}
+	if inst.node != nil && cfg.Store != nil {
+		inst.node.SetBandwidthLimits(cfg.Store.MaxUploadBps, cfg.Store.MaxDownloadBps)
+	}
 
 	// Check if this is coming from a test, which is requesting a MockRemoteClient.
 	key := InstanceContextKey("RemoteClient")
@@ -417,8 +527,8 @@ func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Ins
 	} else if inst.node != nil {
 		inst.node.LocalStreams = o.Streams
 
-		if _, e := inst.node.IPFSCoreAPI(); e == nil {
-			if inst.remoteClient, err = remote.NewClient(inst.node); err != nil {
+		if _, e := inst.node.IPFSCoreAPI(); e == nil && !o.noNetwork {
+			if inst.remoteClient, err = remote.NewClient(inst.node, inst.remoteClientOptions); err != nil {
 				log.Error("initializing remote client:", err.Error())
 				return
 			}
@@ -429,16 +539,59 @@ func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Ins
 				o.remoteOptsFunc = func(*remote.Options) {}
 			}
 
-			if inst.remote, err = remote.NewRemote(inst.node, cfg.Remote, o.remoteOptsFunc); err != nil {
+			remoteCfg := cfg.Remote
+			if path := remoteCfg.AccessLogPath; path != "" && !filepath.IsAbs(path) && inst.repoPath != "" {
+				remoteCfg = remoteCfg.Copy()
+				remoteCfg.AccessLogPath = filepath.Join(inst.repoPath, path)
+			}
+			if inst.remote, err = remote.NewRemote(inst.node, remoteCfg, o.remoteOptsFunc); err != nil {
 				log.Error("intializing remote:", err.Error())
 				return
 			}
+			go inst.remote.RunSessionJanitor(ctx)
 		}
 	}
 
+	if cfg.Repo != nil && cfg.Repo.UpdateCheckMinutes > 0 && inst.remoteClient != nil {
+		go inst.runUpdateChecks(ctx, time.Duration(cfg.Repo.UpdateCheckMinutes)*time.Minute)
+	}
+
 	return
 }
 
+// checkInProcessConfig errors if an instance without a repo path would need
+// to touch disk
+func checkInProcessConfig(cfg *config.Config, o *InstanceOptions) error {
+	if o.repo == nil && cfg.Repo != nil && cfg.Repo.Type != "mem" {
+		return fmt.Errorf("instances without a repo path require a mem repo, got: %q", cfg.Repo.Type)
+	}
+	if o.store == nil && cfg.Store != nil && cfg.Store.Type != "map" {
+		return fmt.Errorf("instances without a repo path require a map store, got: %q", cfg.Store.Type)
+	}
+	if cfg.Update != nil && cfg.Update.Type != "mem" {
+		return fmt.Errorf("instances without a repo path require a mem update service, got: %q", cfg.Update.Type)
+	}
+	return nil
+}
+
+// disableNetwork returns a copy of cfg with every network service turned off
+func disableNetwork(cfg *config.Config) *config.Config {
+	cfg = cfg.Copy()
+	if cfg.P2P != nil {
+		cfg.P2P.Enabled = false
+	}
+	if cfg.RPC != nil {
+		cfg.RPC.Enabled = false
+	}
+	if cfg.API != nil {
+		cfg.API.Enabled = false
+	}
+	if cfg.Remote != nil {
+		cfg.Remote.Enabled = false
+	}
+	return cfg
+}
+
 // TODO (b5): this is a repo layout assertion, move to repo package?
 func loadRepoConfig(repoPath string) (*config.Config, error) {
 	path := filepath.Join(repoPath, "config.yaml")
@@ -477,6 +630,10 @@ func newLogbook(fs qfs.Filesystem, cfg *config.Config, repoPath string) (book *l
 }
 
 func newDscache(ctx context.Context, fs qfs.Filesystem, book *logbook.Book, cfg *config.Config, repoPath string) (*dscache.Dscache, error) {
+	if repoPath == "" {
+		// dscaches without a filename are kept in memory
+		return dscache.NewDscache(ctx, fs, book, ""), nil
+	}
 	dscachePath := filepath.Join(repoPath, "dscache.qfb")
 	return dscache.NewDscache(ctx, fs, book, dscachePath), nil
 }
@@ -511,7 +668,8 @@ func newStats(repoPath string, cfg *config.Config) *stats.Stats {
 	// The stats cache default location is repoPath/stats
 	// can be overridden in the config: cfg.Stats.Path
 	path := filepath.Join(repoPath, "stats")
-	if cfg.Stats == nil {
+	if cfg.Stats == nil || repoPath == "" {
+		// without a repo path stats aren't cached
 		return stats.New(nil)
 	}
 	if cfg.Stats.Cache.Path != "" {
@@ -531,25 +689,28 @@ func newStats(repoPath string, cfg *config.Config) *stats.Stats {
 	}
 }
 
-func newCron(cfg *config.Config, repoPath string) (cron.Scheduler, error) {
+// newCron connects to a running update service if dial is true and one is
+// running, and creates a scheduler otherwise
+func newCron(cfg *config.Config, repoPath string, dial bool) (cron.Scheduler, error) {
 	updateCfg := cfg.Update
 	if updateCfg == nil {
 		updateCfg = config.DefaultUpdate()
 	}
 
-	cli := cron.HTTPClient{Addr: updateCfg.Address}
-	if err := cli.Ping(); err == nil {
-		return cli, nil
-	}
-
-	path, err := update.Path(repoPath)
-	if err != nil {
-		return nil, err
+	if dial {
+		cli := cron.HTTPClient{Addr: updateCfg.Address}
+		if err := cli.Ping(); err == nil {
+			return cli, nil
+		}
 	}
 
 	var jobStore, logStore cron.JobStore
 	switch updateCfg.Type {
 	case "fs":
+		path, err := update.Path(repoPath)
+		if err != nil {
+			return nil, err
+		}
 		jobStore = cron.NewFlatbufferJobStore(filepath.Join(path, "jobs.qfb"))
 		logStore = cron.NewFlatbufferJobStore(filepath.Join(path, "logs.qfb"))
 	case "mem":
@@ -578,7 +739,7 @@ func NewInstanceFromConfigAndNode(cfg *config.Config, node *p2p.QriNode) *Instan
 	}
 
 	var err error
-	inst.remoteClient, err = remote.NewClient(node)
+	inst.remoteClient, err = remote.NewClient(node, inst.remoteClientOptions)
 	if err != nil {
 		panic(err)
 	}
@@ -604,7 +765,11 @@ type Instance struct {
 	teardown context.CancelFunc
 
 	repoPath string
-	cfg      *config.Config
+	// cfg is never changed in place once set, updates swap in a new config.
+	// cfgLk guards the swap, cfgUpdateLk serializes read-modify-write updates
+	cfg         *config.Config
+	cfgLk       sync.RWMutex
+	cfgUpdateLk sync.Mutex
 
 	streams ioes.IOStreams
 	repo    repo.Repo
@@ -622,9 +787,29 @@ type Instance struct {
 	dscache      *dscache.Dscache
 	bus          event.Bus
 
+	// pubqKick wakes the publish queue worker, pubqLk keeps queue attempts
+	// from overlapping
+	pubqKick chan struct{}
+	pubqLk   sync.Mutex
+	// autopubLk keeps automatic saves of working directories from overlapping
+	autopubLk sync.Mutex
+	// rowCounts caches body entries counted for versions saved without a count
+	rowCounts rowCountCache
+	// updates holds the latest checks for new versions of foreign datasets
+	updates updateChecks
+	// saveHooks are run around every save
+	saveHooks saveHooks
+	// trustPrompt asks whether to trust the key a remote signs responses with
+	trustPrompt func(remoteAddr, keyID string) bool
+	// writeLk is held for reading by every change to the repo & for writing by
+	// backups, which need the repo to sit still
+	writeLk sync.RWMutex
+
 	Watcher *watchfs.FilesysWatcher
 
 	rpc *rpc.Client
+	// rpcHandshake is the version handshake made when dialing rpc
+	rpcHandshake *RPCHandshake
 }
 
 // Connect takes an instance online
@@ -639,11 +824,13 @@ func (inst *Instance) Connect(ctx context.Context) (err error) {
 	// old instance, we run into issues where the online instance can't "see"
 	// the additions. We fix that by re-initializing the client with the new
 	// instance
-	if inst.remoteClient, err = remote.NewClient(inst.node); err != nil {
+	if inst.remoteClient, err = remote.NewClient(inst.node, inst.remoteClientOptions); err != nil {
 		log.Debugf("initializing remote client: %s", err.Error())
 		return
 	}
 
+	// being online is a good time to retry queued publishes
+	inst.kickPublishQueue()
 	return nil
 }
 
@@ -652,8 +839,14 @@ func (inst *Instance) Context() context.Context {
 	return inst.ctx
 }
 
-// Config provides methods for manipulating Qri configuration
+// Config returns the current configuration. The returned config is shared &
+// must not be modified, change a copy & pass it to ChangeConfig instead
 func (inst *Instance) Config() *config.Config {
+	if inst == nil {
+		return nil
+	}
+	inst.cfgLk.RLock()
+	defer inst.cfgLk.RUnlock()
 	return inst.cfg
 }
 
@@ -669,6 +862,9 @@ func (inst *Instance) Bus() event.Bus {
 
 // ChangeConfig implements the ConfigSetter interface
 func (inst *Instance) ChangeConfig(cfg *config.Config) (err error) {
+	inst.cfgLk.Lock()
+	defer inst.cfgLk.Unlock()
+
 	cfg = cfg.WithPrivateValues(inst.cfg)
 
 	if path := inst.cfg.Path(); path != "" {
@@ -681,6 +877,19 @@ func (inst *Instance) ChangeConfig(cfg *config.Config) (err error) {
 	return nil
 }
 
+// modifyConfig applies fn to a copy of the current config & saves the result.
+// Concurrent modifications run one at a time so none of them are lost
+func (inst *Instance) modifyConfig(fn func(cfg *config.Config) error) error {
+	inst.cfgUpdateLk.Lock()
+	defer inst.cfgUpdateLk.Unlock()
+
+	cfg := inst.Config().Copy()
+	if err := fn(cfg); err != nil {
+		return err
+	}
+	return inst.ChangeConfig(cfg)
+}
+
 // Node accesses the instance qri node if one exists
 func (inst *Instance) Node() *p2p.QriNode {
 	if inst == nil {
@@ -718,6 +927,15 @@ func (inst *Instance) RPC() *rpc.Client {
 	return inst.rpc
 }
 
+// RPCHandshake is the version handshake made with the RPC server, nil if the
+// instance isn't operating over RPC
+func (inst *Instance) RPCHandshake() *RPCHandshake {
+	if inst == nil {
+		return nil
+	}
+	return inst.rpcHandshake
+}
+
 // Remote accesses the remote subsystem if one exists
 func (inst *Instance) Remote() *remote.Remote {
 	if inst == nil {
@@ -734,6 +952,16 @@ func (inst *Instance) RemoteClient() remote.Client {
 	return inst.remoteClient
 }
 
+// lockWrites blocks until no backup is running, keeping backups from starting
+// until the returned unlock func is called
+func (inst *Instance) lockWrites() (unlock func()) {
+	if inst == nil {
+		return func() {}
+	}
+	inst.writeLk.RLock()
+	return inst.writeLk.RUnlock
+}
+
 // Teardown destroys the instance, releasing reserved resources
 func (inst *Instance) Teardown() {
 	inst.teardown()
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	crypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dstest"
 	"github.com/qri-io/ioes"
 	"github.com/qri-io/qfs"
@@ -87,6 +88,141 @@ func TestNewInstance(t *testing.T) {
 	}
 }
 
+func ExampleNewInstance_inProcess() {
+	cfg := config.DefaultConfigForTesting()
+	cfg.Repo.Type = "mem"
+	cfg.Update.Type = "mem"
+
+	// an empty repo path keeps the instance in-process: nothing is read from
+	// or written to disk
+	inst, err := NewInstance(context.Background(), "",
+		OptConfig(cfg),
+		OptStore(cafs.NewMapstore()),
+		OptIOStreams(ioes.NewDiscardIOStreams()),
+		OptNoNetwork(),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	dsm := NewDatasetRequestsInstance(inst)
+	saved := &SaveResult{}
+	err = dsm.Save(&SaveParams{
+		Ref: "me/greetings",
+		Dataset: &dataset.Dataset{
+			Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray},
+			BodyBytes: []byte(`["hello","world"]`),
+		},
+	}, saved)
+	if err != nil {
+		panic(err)
+	}
+
+	got := &GetResult{}
+	if err := dsm.Get(&GetParams{Path: "me/greetings", Selector: "body", Format: "json", All: true}, got); err != nil {
+		panic(err)
+	}
+	fmt.Println(string(got.Bytes))
+	// Output: ["hello","world"]
+}
+
+func TestInProcessInstance(t *testing.T) {
+	// run from an empty directory to check nothing is written relative to it
+	dir, err := ioutil.TempDir("", "TestInProcessInstance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	cfg := config.DefaultConfigForTesting()
+	cfg.Repo.Type = "mem"
+	cfg.RPC.Enabled = true
+	if _, err := NewInstance(context.Background(), "", OptConfig(cfg), OptNoNetwork()); err == nil {
+		t.Error("expected an in-process instance with an fs update service to error")
+	}
+	cfg.Update.Type = "mem"
+
+	inst, err := NewInstance(context.Background(), "",
+		OptConfig(cfg),
+		OptStore(cafs.NewMapstore()),
+		OptIOStreams(ioes.NewDiscardIOStreams()),
+		OptNoNetwork(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inst.rpc != nil || inst.registry != nil || inst.remoteClient != nil {
+		t.Error("expected in-process instance without network to have no rpc, registry or remote client")
+	}
+	if inst.Config().RPC.Enabled || !cfg.RPC.Enabled {
+		t.Error("expected disabling network to apply to a copy of the given config")
+	}
+
+	dsm := NewDatasetRequestsInstance(inst)
+	saved := &SaveResult{}
+	err = dsm.Save(&SaveParams{
+		Ref: "me/greetings",
+		Dataset: &dataset.Dataset{
+			Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray},
+			BodyBytes: []byte(`["hello"]`),
+		},
+	}, saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []LogEntry{}
+	if err := NewLogRequestsInstance(inst).Logbook(&RefListParams{Ref: "me/greetings", Limit: -1}, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Error("expected save to be recorded in the logbook")
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected in-process instance not to write to disk, found %d files", len(files))
+	}
+}
+
+func TestInstanceEnvironment(t *testing.T) {
+	cfg := config.DefaultConfigForTesting()
+	cfg.Repo.Type = "mem"
+	cfg.Update.Type = "mem"
+	cfg.Environments = map[string]interface{}{
+		"dev": map[string]interface{}{"api": map[string]interface{}{"port": 3503}},
+	}
+
+	inst, err := NewInstance(context.Background(), "",
+		OptConfig(cfg),
+		OptEnvironment("dev"),
+		OptStore(cafs.NewMapstore()),
+		OptIOStreams(ioes.NewDiscardIOStreams()),
+		OptNoNetwork(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inst.Config().API.Port != 3503 || inst.Config().Env() != "dev" {
+		t.Errorf("expected instance config to have the dev environment applied, got port: %d", inst.Config().API.Port)
+	}
+
+	if _, err := NewInstance(context.Background(), "", OptConfig(cfg), OptEnvironment("prod")); err == nil {
+		t.Error("expected an unknown environment to error")
+	}
+}
+
 func TestNewDefaultInstance(t *testing.T) {
 	prevIPFSEnvLocation := os.Getenv("IPFS_PATH")
 	prevDefaultIPFSLocation := defaultIPFSLocation
@@ -150,7 +286,7 @@ func TestReceivers(t *testing.T) {
 	inst := &Instance{node: node, cfg: cfg}
 
 	reqs := Receivers(inst)
-	expect := 12
+	expect := 14
 	if len(reqs) != expect {
 		t.Errorf("unexpected number of receivers returned. expected: %d. got: %d\nhave you added/removed a receiver?", expect, len(reqs))
 		return
@@ -169,7 +305,7 @@ func addCitiesDataset(t *testing.T, node *p2p.QriNode) reporef.DatasetRef {
 	ds.Name = tc.Name
 	ds.BodyBytes = tc.Body
 
-	ref, err := base.SaveDataset(ctx, node.Repo, devNull, ds, nil, nil, base.SaveDatasetSwitches{Pin: true, ShouldRender: true})
+	ref, _, err := base.SaveDataset(ctx, node.Repo, devNull, ds, nil, nil, base.SaveDatasetSwitches{Pin: true, ShouldRender: true})
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -186,7 +322,7 @@ func addNowTransformDataset(t *testing.T, node *p2p.QriNode) reporef.DatasetRef
 	ds.Name = tc.Name
 	ds.Transform.ScriptPath = "testdata/now_tf/transform.star"
 
-	ref, err := base.SaveDataset(ctx, node.Repo, devNull, ds, nil, nil, base.SaveDatasetSwitches{Pin: true, ShouldRender: true})
+	ref, _, err := base.SaveDataset(ctx, node.Repo, devNull, ds, nil, nil, base.SaveDatasetSwitches{Pin: true, ShouldRender: true})
 	if err != nil {
 		t.Fatal(err.Error())
 	}
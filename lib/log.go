@@ -6,9 +6,11 @@ import (
 	"net/rpc"
 
 	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/remote"
 	"github.com/qri-io/qri/repo"
 	reporef "github.com/qri-io/qri/repo/ref"
 )
@@ -19,6 +21,7 @@ import (
 type LogRequests struct {
 	node *p2p.QriNode
 	cli  *rpc.Client
+	inst *Instance
 }
 
 // CoreRequestsName implements the Requets interface
@@ -36,11 +39,24 @@ func NewLogRequests(node *p2p.QriNode, cli *rpc.Client) *LogRequests {
 	}
 }
 
+// NewLogRequestsInstance creates a LogRequests pointer from an instance.
+// Only LogRequests created from an instance can fetch history from remotes
+func NewLogRequestsInstance(inst *Instance) *LogRequests {
+	return &LogRequests{
+		node: inst.Node(),
+		cli:  inst.RPC(),
+		inst: inst,
+	}
+}
+
 // LogParams defines parameters for the Log method
 type LogParams struct {
 	ListParams
 	// Reference to data to fetch history for
 	Ref string
+	// FetchRemote fetches history from the remote named by RemoteName when
+	// the dataset isn't in the local repo. The dataset isn't added
+	FetchRemote bool
 }
 
 // Log returns the history of changes for a given dataset
@@ -55,7 +71,7 @@ func (r *LogRequests) Log(params *LogParams, res *[]dsref.VersionInfo) (err erro
 	}
 	ref, err := repo.ParseDatasetRef(params.Ref)
 	if err != nil {
-		return fmt.Errorf("'%s' is not a valid dataset reference", params.Ref)
+		return invalidRefError(params.Ref)
 	}
 	// we only canonicalize the profile here, full dataset canonicalization
 	// currently relies on repo's refstore, and the logbook may be a superset
@@ -74,9 +90,259 @@ func (r *LogRequests) Log(params *LogParams, res *[]dsref.VersionInfo) (err erro
 	}
 
 	*res, err = base.DatasetLog(ctx, r.node.Repo, ref, params.Limit, params.Offset, true)
+	if params.FetchRemote && (err == repo.ErrNoHistory || repo.IsNotFound(err)) {
+		return r.remoteLog(ctx, ref, params, res)
+	}
 	return
 }
 
+// remoteLog fetches dataset history from a remote, marking each version with
+// the remote it came from
+func (r *LogRequests) remoteLog(ctx context.Context, ref reporef.DatasetRef, params *LogParams, res *[]dsref.VersionInfo) error {
+	if r.inst == nil || r.inst.RemoteClient() == nil {
+		return remote.ErrNoRemoteClient
+	}
+	addr, err := remote.Address(r.inst.Config(), params.RemoteName)
+	if err != nil {
+		return err
+	}
+
+	versions, err := r.inst.RemoteClient().FetchLog(ctx, reporef.ConvertToDsref(ref), addr, params.Offset, params.Limit)
+	if err != nil {
+		return err
+	}
+	for i := range versions {
+		versions[i].Foreign = true
+		versions[i].Remote = addr
+	}
+	*res = versions
+	return nil
+}
+
+// LogPageParams defines parameters for the LogPage method
+type LogPageParams struct {
+	LogParams
+	// Summaries describes the changes each version in the page made
+	Summaries bool
+	// FetchMissing summarizes versions that aren't stored locally by fetching
+	// them from the network. Otherwise they're summarized as unavailable
+	FetchMissing bool
+}
+
+// LogItem is a dataset version in a page of history
+type LogItem struct {
+	dsref.VersionInfo
+	// Summary of changes from the previous version, only set when requested
+	Summary *base.VersionSummary `json:"summary,omitempty"`
+}
+
+// LogPage is a page of the history of a dataset, newest first
+type LogPage struct {
+	Versions []LogItem `json:"versions"`
+	Offset   int       `json:"offset"`
+	Limit    int       `json:"limit"`
+	// Total is the number of versions in the history, -1 if unknown
+	Total int `json:"total"`
+}
+
+// LogPage returns a page of the history of a dataset with the total number of
+// versions, optionally summarizing changes made by each version in the page
+func (r *LogRequests) LogPage(p *LogPageParams, res *LogPage) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("LogRequests.LogPage", p, res)
+	}
+	ctx := context.TODO()
+
+	versions := []dsref.VersionInfo{}
+	if err = r.Log(&p.LogParams, &versions); err != nil {
+		return err
+	}
+
+	page := LogPage{
+		Versions: make([]LogItem, len(versions)),
+		Offset:   p.Offset,
+		Limit:    p.Limit,
+		Total:    r.versionCount(ctx, p.Ref, versions),
+	}
+	for i, v := range versions {
+		page.Versions[i] = LogItem{VersionInfo: v}
+		if !p.Summaries || v.Path == "" {
+			continue
+		}
+		// summaries are computed per page so long histories stay cheap
+		if page.Versions[i].Summary, err = base.SummarizeVersion(ctx, r.node.Repo.Store(), v.Path, p.FetchMissing); err != nil {
+			log.Debugf("LogPage: summarizing %q: %s", v.Path, err)
+			page.Versions[i].Summary = &base.VersionSummary{Unavailable: true}
+		}
+	}
+
+	*res = page
+	return nil
+}
+
+// versionCount returns the number of versions in the history of a dataset,
+// or -1 if the logbook doesn't know
+func (r *LogRequests) versionCount(ctx context.Context, refstr string, page []dsref.VersionInfo) int {
+	if len(page) > 0 && page[0].Foreign {
+		return -1
+	}
+	book := r.node.Repo.Logbook()
+	if book == nil {
+		return -1
+	}
+	ref, err := repo.ParseDatasetRef(refstr)
+	if err != nil {
+		return -1
+	}
+	if err = repo.CanonicalizeProfile(r.node.Repo, &ref); err != nil {
+		return -1
+	}
+	all, err := book.Versions(ctx, reporef.ConvertToDsref(ref), 0, -1)
+	if err != nil {
+		return -1
+	}
+	return len(all)
+}
+
+// HistoryParams defines parameters for the History method
+type HistoryParams struct {
+	ListParams
+	// Reference to data to fetch history for
+	Ref string
+	// FetchRemote fetches history from a remote when the dataset isn't in the
+	// local repo, see LogParams
+	FetchRemote bool
+	// StrictSignatures fails with ErrInvalidSignature if any version has an
+	// invalid commit signature. Otherwise invalid signatures are reported in
+	// HistoryEntry.Signature & logged as a warning
+	StrictSignatures bool
+}
+
+// HistoryEntry is a version of a dataset with details about how it changed
+// from the version before it
+type HistoryEntry struct {
+	dsref.VersionInfo
+	// number of blocks that make up the version, zero if it couldn't be
+	// determined
+	BlockCount int `json:"blockCount,omitempty"`
+	// summary of changes from the previous version. nil for the first version
+	// and for versions that aren't stored locally
+	Delta *base.VersionDelta `json:"delta,omitempty"`
+	// Signature is the commit signature check of the version, nil for
+	// versions that aren't stored locally
+	Signature *SignatureVerification `json:"signature,omitempty"`
+}
+
+// History returns the versions of a dataset, newest first, annotated with
+// body sizes and a summary of changes between adjacent versions
+func (r *LogRequests) History(params *HistoryParams, res *[]HistoryEntry) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("LogRequests.History", params, res)
+	}
+	ctx := context.TODO()
+
+	versions := []dsref.VersionInfo{}
+	if err = r.Log(&LogParams{ListParams: params.ListParams, Ref: params.Ref, FetchRemote: params.FetchRemote}, &versions); err != nil {
+		return err
+	}
+
+	// versions are verified against the keys of the dataset's owner
+	ref, err := repo.ParseDatasetRef(params.Ref)
+	if err != nil {
+		return invalidRefError(params.Ref)
+	}
+	if err = repo.CanonicalizeProfile(r.node.Repo, &ref); err != nil {
+		return err
+	}
+
+	store := r.node.Repo.Store()
+	entries := make([]HistoryEntry, len(versions))
+	for i, v := range versions {
+		entries[i] = HistoryEntry{VersionInfo: v}
+		if v.Path == "" || v.Foreign {
+			continue
+		}
+		if local, err := store.Has(ctx, v.Path); err != nil || !local {
+			continue
+		}
+
+		ds, err := dsfs.LoadDatasetRefs(ctx, store, v.Path)
+		if err != nil {
+			return err
+		}
+		if ds.Structure != nil && ds.Structure.Length > 0 {
+			entries[i].BodySize = ds.Structure.Length
+		}
+		if full, err := dsfs.LoadDataset(ctx, store, v.Path); err == nil {
+			entries[i].Signature = verifyVersion(ctx, r.node, ref, full)
+			if params.StrictSignatures {
+				if err := entries[i].Signature.Err(); err != nil {
+					return err
+				}
+			}
+		}
+		if info, err := r.node.NewDAGInfo(ctx, v.Path, ""); err == nil && info.Manifest != nil {
+			entries[i].BlockCount = len(info.Manifest.Nodes)
+		}
+
+		if ds.PreviousPath == "" {
+			continue
+		}
+		if entries[i].Delta, err = base.CachedVersionDelta(ctx, store, ds.PreviousPath, v.Path); err != nil {
+			log.Debugf("History: calculating delta for %q: %s", v.Path, err)
+		}
+	}
+
+	*res = entries
+	return nil
+}
+
+// LabelParams defines parameters for the Label method
+type LabelParams struct {
+	// Ref is the dataset version to label. A reference without a path labels
+	// the latest version
+	Ref   string
+	Label string
+	// Remove detaches the label from whichever version it names instead
+	Remove bool
+}
+
+// Label attaches a label to a version of a dataset, or removes one. Labelled
+// versions can be selected in revisions by label name. Only versions of your
+// own datasets can be labelled
+func (r *LogRequests) Label(p *LabelParams, res *dsref.VersionInfo) error {
+	if r.cli != nil {
+		return r.cli.Call("LogRequests.Label", p, res)
+	}
+	ctx := context.TODO()
+	defer r.inst.lockWrites()()
+
+	if p.Label == "" {
+		return fmt.Errorf("label is required")
+	}
+	ref, err := repo.ParseDatasetRef(p.Ref)
+	if err != nil {
+		return invalidRefError(p.Ref)
+	}
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
+		return err
+	}
+	pro, err := r.node.Repo.Profile()
+	if err != nil {
+		return err
+	}
+	if ref.ProfileID != pro.ID {
+		return fmt.Errorf("can only label versions of your own datasets")
+	}
+
+	if p.Remove {
+		*res, err = base.UnlabelVersion(ctx, r.node.Repo, ref, p.Label)
+	} else {
+		*res, err = base.LabelVersion(ctx, r.node.Repo, ref, p.Label)
+	}
+	return err
+}
+
 // RefListParams encapsulates parameters for requests to a single reference
 // that will produce a paginated result
 type RefListParams struct {
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -82,6 +83,65 @@ func TestHistoryRequestsLog(t *testing.T) {
 	}
 }
 
+func TestHistoryRequestsLabel(t *testing.T) {
+	mr, refs, err := testrepo.NewTestRepoWithHistory()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	req := NewLogRequests(node, nil)
+	labelled := refs[1]
+
+	info := dsref.VersionInfo{}
+	if err := req.Label(&LabelParams{Ref: labelled.String(), Label: "release-1"}, &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Path != labelled.Path {
+		t.Errorf("expected %s to be labelled, got: %s", labelled.Path, info.Path)
+	}
+	if err := req.Label(&LabelParams{Ref: refs[0].String(), Label: "release-1"}, &info); err == nil {
+		t.Error("expected labelling a second version with the same label to error")
+	}
+	if err := req.Label(&LabelParams{Ref: refs[0].String()}, &info); err == nil {
+		t.Error("expected labelling without a label to error")
+	}
+
+	versions := []dsref.VersionInfo{}
+	if err := req.Log(&LogParams{Ref: refs[0].AliasString()}, &versions); err != nil {
+		t.Fatal(err)
+	}
+	if len(versions[1].Labels) != 1 || versions[1].Labels[0] != "release-1" {
+		t.Errorf("expected log to show the label, got: %v", versions[1].Labels)
+	}
+
+	dsr := NewDatasetRequests(node, nil)
+	got := GetResult{}
+	if err := dsr.Get(&GetParams{Path: refs[0].AliasString(), Revision: "release-1"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Ref.Path != labelled.Path {
+		t.Errorf("expected get by label to load %s, got: %s", labelled.Path, got.Ref.Path)
+	}
+	if err := dsr.Get(&GetParams{Path: refs[0].AliasString(), Revision: "3"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Ref.Path != refs[2].Path {
+		t.Errorf("expected get by generation to load %s, got: %s", refs[2].Path, got.Ref.Path)
+	}
+
+	if err := req.Label(&LabelParams{Ref: refs[0].AliasString(), Label: "release-1", Remove: true}, &info); err != nil {
+		t.Fatal(err)
+	}
+	if err := dsr.Get(&GetParams{Path: refs[0].AliasString(), Revision: "release-1"}, &got); err == nil {
+		t.Error("expected get by a removed label to error")
+	}
+}
+
 func TestHistoryRequestsLogEntries(t *testing.T) {
 	mr, refs, err := testrepo.NewTestRepoWithHistory()
 	if err != nil {
@@ -126,3 +186,81 @@ func TestHistoryRequestsLogEntries(t *testing.T) {
 		t.Errorf("result mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestHistoryRequestsHistory(t *testing.T) {
+	mr, refs, err := testrepo.NewTestRepoWithHistory()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req := NewLogRequests(node, nil)
+
+	if err := req.History(&HistoryParams{}, &[]HistoryEntry{}); err == nil {
+		t.Errorf("expected empty reference to error")
+	}
+
+	got := []HistoryEntry{}
+	p := &HistoryParams{Ref: refs[0].String(), ListParams: ListParams{Limit: 2}}
+	if err := req.History(p, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(got))
+	}
+	for i, ent := range got {
+		if ent.BodySize == 0 {
+			t.Errorf("entry %d: expected body size to be set", i)
+		}
+		if ent.Delta == nil {
+			t.Errorf("entry %d: expected delta to be set", i)
+		}
+	}
+}
+
+func TestHistoryRequestsLogPage(t *testing.T) {
+	ctx := context.Background()
+	mr, refs, err := testrepo.NewTestRepoWithHistory()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req := NewLogRequests(node, nil)
+
+	got := LogPage{}
+	p := &LogPageParams{LogParams: LogParams{Ref: refs[0].AliasString(), ListParams: ListParams{Offset: 1, Limit: 2}}}
+	if err := req.LogPage(p, &got); err != nil {
+		t.Fatal(err)
+	}
+	all, err := mr.Logbook().Versions(ctx, reporef.ConvertToDsref(refs[0]), 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Total != len(all) || got.Offset != 1 || got.Limit != 2 {
+		t.Errorf("expected total %d, offset 1 & limit 2. got: %d, %d, %d", len(all), got.Total, got.Offset, got.Limit)
+	}
+	if len(got.Versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(got.Versions))
+	}
+	if got.Versions[0].Path != refs[1].Path {
+		t.Errorf("expected page to start at %s, got: %s", refs[1].Path, got.Versions[0].Path)
+	}
+	if got.Versions[0].Summary != nil {
+		t.Errorf("expected summaries to be omitted unless requested")
+	}
+
+	p.Summaries = true
+	if err := req.LogPage(p, &got); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range got.Versions {
+		if v.Summary == nil || v.Summary.Unavailable || len(v.Summary.Components) == 0 {
+			t.Errorf("version %d: expected a summary of changed components, got: %#v", i, v.Summary)
+		}
+	}
+}
@@ -17,11 +17,14 @@ const DefaultPageSize = 100
 // TODO - rename this to PageParams.
 type ListParams struct {
 	ProfileID profile.ID
-	Term      string
-	Peername  string
-	OrderBy   string
-	Limit     int
-	Offset    int
+	// ProfileIDs limits listing to locally stored datasets authored by any of
+	// the given base58-encoded profile IDs
+	ProfileIDs []string
+	Term       string
+	Peername   string
+	OrderBy    string
+	Limit      int
+	Offset     int
 	// RPC is a horrible hack while we work to replace the net/rpc package
 	// TODO - remove this
 	RPC bool
@@ -33,6 +36,16 @@ type ListParams struct {
 	EnsureFSIExists bool
 	// UseDscache controls whether to build a dscache to use to list the references
 	UseDscache bool
+	// WithSyncStatus compares the history of each published dataset with a
+	// remote, filling in the SyncStatus of listed datasets
+	WithSyncStatus bool
+	// RemoteName is the remote to compare with when WithSyncStatus is set, or
+	// to fetch history from when a log request fetches remotes. Defaults to
+	// the registry
+	RemoteName string
+	// ShowTrashed adds removed datasets that are still in the trash to local
+	// listings, marked as trashed
+	ShowTrashed bool
 }
 
 // NewListParams creates a ListParams from page & pagesize, pages are 1-indexed
@@ -197,6 +197,139 @@ func (d *PeerRequests) DisconnectFromPeer(p *PeerConnectionParamsPod, res *bool)
 	return nil
 }
 
+// P2PStatus describes this node's connection to the p2p network
+type P2PStatus struct {
+	Online bool
+	// PrivateNetwork is true when this node only connects to peers that share
+	// its swarm key
+	PrivateNetwork bool
+	// Connections counts open peer connections
+	Connections int
+}
+
+// Status reports whether this node is online, connected to a private network
+// & how many peers it's connected to
+func (d *PeerRequests) Status(in *bool, res *P2PStatus) error {
+	if d.cli != nil {
+		return d.cli.Call("PeerRequests.Status", in, res)
+	}
+	if d.qriNode == nil {
+		return fmt.Errorf("error: no p2p node")
+	}
+
+	status := P2PStatus{
+		Online:         d.qriNode.Online,
+		PrivateNetwork: d.qriNode.PrivateNetwork(),
+	}
+	if d.qriNode.Online {
+		status.Connections = len(d.qriNode.Host().Network().Conns())
+	}
+	*res = status
+	return nil
+}
+
+// PeerConnection describes an open connection to a peer
+type PeerConnection struct {
+	// PeerID is the base58-encoded network ID of the peer
+	PeerID string
+	// Multiaddr is the network address of the connected peer
+	Multiaddr string
+	// Profile is the qri profile of the peer, nil if the peer doesn't speak
+	// the qri protocol
+	Profile *config.ProfilePod
+}
+
+// peerIDConnectionParams interprets s as a network peer ID, with or without
+// an /ipfs/ prefix, or a multiaddr
+func peerIDConnectionParams(s string) (*PeerConnectionParamsPod, error) {
+	if s == "" {
+		return nil, fmt.Errorf("peer ID is required")
+	}
+	if strings.HasPrefix(s, "/ipfs/") {
+		return &PeerConnectionParamsPod{NetworkID: s}, nil
+	}
+	if strings.HasPrefix(s, "/") {
+		return &PeerConnectionParamsPod{Multiaddr: s}, nil
+	}
+	return &PeerConnectionParamsPod{NetworkID: "/ipfs/" + s}, nil
+}
+
+// onlineNode returns the node of peer requests, erroring if it isn't
+// connected to the p2p network
+func (d *PeerRequests) onlineNode() (*p2p.QriNode, error) {
+	if d.qriNode == nil || !d.qriNode.Online {
+		return nil, fmt.Errorf("error: not connected, run `qri connect` in another window")
+	}
+	return d.qriNode, nil
+}
+
+// Connect opens a connection to a peer, where peerID is a network peer ID or
+// a multiaddr ending in one. res is set to the profile of the peer
+func (d *PeerRequests) Connect(peerID *string, res *config.ProfilePod) error {
+	if d.cli != nil {
+		return d.cli.Call("PeerRequests.Connect", peerID, res)
+	}
+	if _, err := d.onlineNode(); err != nil {
+		return err
+	}
+
+	p, err := peerIDConnectionParams(*peerID)
+	if err != nil {
+		return err
+	}
+	return d.ConnectToPeer(p, res)
+}
+
+// Disconnect closes all connections to a peer, where peerID is a network
+// peer ID or a multiaddr ending in one
+func (d *PeerRequests) Disconnect(peerID *string, res *bool) error {
+	if d.cli != nil {
+		return d.cli.Call("PeerRequests.Disconnect", peerID, res)
+	}
+	if _, err := d.onlineNode(); err != nil {
+		return err
+	}
+
+	p, err := peerIDConnectionParams(*peerID)
+	if err != nil {
+		return err
+	}
+	return d.DisconnectFromPeer(p, res)
+}
+
+// Connections lists open peer connections, returning no more than limit
+// connections if limit is greater than zero
+func (d *PeerRequests) Connections(limit *int, res *[]PeerConnection) error {
+	if d.cli != nil {
+		return d.cli.Call("PeerRequests.Connections", limit, res)
+	}
+	node, err := d.onlineNode()
+	if err != nil {
+		return err
+	}
+
+	profiles := node.Repo.Profiles()
+	conns := node.Host().Network().Conns()
+	build := make([]PeerConnection, 0, len(conns))
+	for _, conn := range conns {
+		if *limit > 0 && len(build) >= *limit {
+			break
+		}
+		pc := PeerConnection{
+			PeerID:    conn.RemotePeer().Pretty(),
+			Multiaddr: conn.RemoteMultiaddr().String(),
+		}
+		if pro, err := profiles.PeerProfile(conn.RemotePeer()); err == nil {
+			if pc.Profile, err = pro.Encode(); err == nil {
+				pc.Profile.Online = true
+			}
+		}
+		build = append(build, pc)
+	}
+	*res = build
+	return nil
+}
+
 // PeerInfoParams defines parameters for the Info method
 type PeerInfoParams struct {
 	Peername  string
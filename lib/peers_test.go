@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -172,6 +173,107 @@ func TestGetReferences(t *testing.T) {
 	}
 }
 
+func TestPeerRequestsConnect(t *testing.T) {
+	factory := p2ptest.NewTestNodeFactory(p2p.NewTestableQriNode)
+	node := newTestOnlineQriNode(t, factory, "a")
+	other := newTestOnlineQriNode(t, factory, "b")
+	req := NewPeerRequests(node, nil)
+
+	limit := 0
+	conns := []PeerConnection{}
+	if err := req.Connections(&limit, &conns); err != nil {
+		t.Fatal(err)
+	}
+	if len(conns) != 0 {
+		t.Fatalf("expected no connections, got: %d", len(conns))
+	}
+
+	otherID := other.ID.Pretty()
+	addr := fmt.Sprintf("%s/ipfs/%s", other.Host().Addrs()[0], otherID)
+	pro := config.ProfilePod{}
+	if err := req.Connect(&addr, &pro); err != nil {
+		t.Fatal(err)
+	}
+	otherPro, err := other.Repo.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pro.ID != otherPro.ID.String() {
+		t.Errorf("connected profile mismatch. expected: %q, got: %q", otherPro.ID, pro.ID)
+	}
+
+	if err := req.Connections(&limit, &conns); err != nil {
+		t.Fatal(err)
+	}
+	if len(conns) != 1 {
+		t.Fatalf("expected 1 connection, got: %d", len(conns))
+	}
+	if conns[0].PeerID != otherID {
+		t.Errorf("connection peer ID mismatch. expected: %s, got: %s", otherID, conns[0].PeerID)
+	}
+	if conns[0].Profile == nil {
+		t.Errorf("expected qri peer connection to include a profile")
+	}
+
+	disconnected := false
+	if err := req.Disconnect(&otherID, &disconnected); err != nil {
+		t.Fatal(err)
+	}
+	if !disconnected {
+		t.Errorf("expected disconnect to succeed")
+	}
+	if err := req.Connections(&limit, &conns); err != nil {
+		t.Fatal(err)
+	}
+	if len(conns) != 0 {
+		t.Errorf("expected no connections after disconnect, got: %d", len(conns))
+	}
+}
+
+func TestPeerRequestsConnectOffline(t *testing.T) {
+	req := NewPeerRequests(newTestQriNode(t), nil)
+	peerID := "QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt"
+	expect := "error: not connected, run `qri connect` in another window"
+
+	pro := config.ProfilePod{}
+	if err := req.Connect(&peerID, &pro); err == nil || err.Error() != expect {
+		t.Errorf("connect error mismatch. expected: %q, got: %v", expect, err)
+	}
+	disconnected := false
+	if err := req.Disconnect(&peerID, &disconnected); err == nil || err.Error() != expect {
+		t.Errorf("disconnect error mismatch. expected: %q, got: %v", expect, err)
+	}
+	limit := 0
+	conns := []PeerConnection{}
+	if err := req.Connections(&limit, &conns); err == nil || err.Error() != expect {
+		t.Errorf("connections error mismatch. expected: %q, got: %v", expect, err)
+	}
+}
+
+func TestPeerIDConnectionParams(t *testing.T) {
+	cases := []struct {
+		in     string
+		expect PeerConnectionParamsPod
+		err    string
+	}{
+		{"", PeerConnectionParamsPod{}, "peer ID is required"},
+		{"QmFoo", PeerConnectionParamsPod{NetworkID: "/ipfs/QmFoo"}, ""},
+		{"/ipfs/QmFoo", PeerConnectionParamsPod{NetworkID: "/ipfs/QmFoo"}, ""},
+		{"/ip4/127.0.0.1/tcp/4001/ipfs/QmFoo", PeerConnectionParamsPod{Multiaddr: "/ip4/127.0.0.1/tcp/4001/ipfs/QmFoo"}, ""},
+	}
+
+	for i, c := range cases {
+		got, err := peerIDConnectionParams(c.in)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: %q, got: %v", i, c.err, err)
+			continue
+		}
+		if got != nil && *got != c.expect {
+			t.Errorf("case %d params mismatch. expected: %v, got: %v", i, c.expect, *got)
+		}
+	}
+}
+
 func TestPeerConnectionsParamsPod(t *testing.T) {
 	if p := NewPeerConnectionParamsPod("peername"); p.Peername != "peername" {
 		t.Error("expected Peername to be set")
@@ -232,6 +334,26 @@ func newTestQriNode(t *testing.T) *p2p.QriNode {
 	return node
 }
 
+// newTestOnlineQriNode creates a node that's connected to the p2p network
+func newTestOnlineQriNode(t *testing.T, f *p2ptest.TestNodeFactory, peername string) *p2p.QriNode {
+	info := f.NextInfo()
+	ms := cafs.NewMapstore()
+	pro := &profile.Profile{
+		ID:       profile.IDFromPeerID(info.PeerID),
+		Peername: peername,
+		PrivKey:  info.PrivKey,
+	}
+	r, err := repo.NewMemRepo(pro, ms, newTestFS(ms), profile.NewMemStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := p2ptest.NewAvailableTestNode(r, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n.(*p2p.QriNode)
+}
+
 func newTestFS(cafsys cafs.Filestore) qfs.Filesystem {
 	return qfs.NewMux(map[string]qfs.Filesystem{
 		"local": localfs.NewFS(),
@@ -0,0 +1,266 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/fsi"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// PinsParams configures listing the pinned versions of a dataset
+type PinsParams struct {
+	Ref string
+}
+
+// PinsResult lists the versions of a dataset with their pin status
+type PinsResult struct {
+	// KeepPinned is the number of newest versions the dataset's pin policy
+	// keeps pinned, zero if the dataset has no policy
+	KeepPinned int               `json:"keepPinned"`
+	Versions   []base.VersionPin `json:"versions"`
+	// Prefetched lists versions pinned by prefetching, most recently fetched
+	// first. Pin policies don't apply to prefetched versions
+	Prefetched []base.VersionPin `json:"prefetched,omitempty"`
+}
+
+// Pins lists the versions of a dataset, newest first, with whether each is
+// pinned, its size, and an estimate of the bytes unpinning it would free.
+// Datasets that were only prefetched list just their prefetched versions
+func (m *RepoMethods) Pins(p *PinsParams, res *PinsResult) error {
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("RepoMethods.Pins", p, res)
+	}
+	ctx := context.TODO()
+
+	ref, ps, err := m.pinRef(p.Ref)
+	if err != nil && !m.onlyPrefetched(ref, err) {
+		return err
+	}
+
+	*res = PinsResult{}
+	if err == nil {
+		if res.Versions, err = base.ListVersionPins(ctx, m.inst.Repo(), ref, ps, checkedOutPaths(ref)); err != nil {
+			return err
+		}
+		if policies := base.PinPoliciesOf(m.inst.Repo()); policies != nil {
+			res.KeepPinned = policies.KeepPinned(ref)
+		}
+	}
+	if base.PrefetchesOf(m.inst.Repo()) != nil {
+		if res.Prefetched, err = base.ListPrefetchPins(ctx, m.inst.Repo(), ref, ps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnpinParams configures unpinning versions of a dataset
+type UnpinParams struct {
+	Ref string
+	// Paths lists the versions to unpin
+	Paths []string
+}
+
+// UnpinResult lists unpinned versions
+type UnpinResult struct {
+	Unpinned []string `json:"unpinned"`
+	// Reclaimed estimates the bytes freed by unpinning, blocks are deleted
+	// the next time the store is garbage collected
+	Reclaimed uint64 `json:"reclaimed"`
+}
+
+// Unpin unpins versions of a dataset. The head version & versions checked out
+// to a working directory can't be unpinned. Unpinning a prefetched version
+// drops it from the prefetch record
+func (m *RepoMethods) Unpin(p *UnpinParams, res *UnpinResult) error {
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("RepoMethods.Unpin", p, res)
+	}
+	ctx := context.TODO()
+	defer m.inst.lockWrites()()
+
+	if len(p.Paths) == 0 {
+		return fmt.Errorf("at least one version path is required")
+	}
+	ref, ps, refErr := m.pinRef(p.Ref)
+	if refErr != nil && !m.onlyPrefetched(ref, refErr) {
+		return refErr
+	}
+
+	var versions, prefetched []string
+	for _, path := range p.Paths {
+		if base.IsPrefetched(m.inst.Repo(), ref, path) {
+			prefetched = append(prefetched, path)
+		} else {
+			versions = append(versions, path)
+		}
+	}
+
+	protected := checkedOutPaths(ref)
+	if ref.Path != "" {
+		protected = append(protected, ref.Path)
+	}
+	var reclaimed uint64
+	if len(versions) > 0 {
+		if refErr != nil {
+			return refErr
+		}
+		n, err := base.UnpinVersions(ctx, m.inst.Repo(), ref, versions, ps, protected)
+		if err != nil {
+			return err
+		}
+		reclaimed += n
+	}
+	if len(prefetched) > 0 {
+		n, err := base.UnpinPrefetched(ctx, m.inst.Repo(), ref, prefetched, ps, protected)
+		if err != nil {
+			return err
+		}
+		reclaimed += n
+	}
+	*res = UnpinResult{Unpinned: p.Paths, Reclaimed: reclaimed}
+	return nil
+}
+
+// PinPolicyParams sets a dataset's pin policy
+type PinPolicyParams struct {
+	Ref string
+	// KeepPinned is the number of newest versions to keep pinned, zero removes
+	// the policy, keeping every version pinned from then on
+	KeepPinned int
+}
+
+// SetPinPolicy sets how many of the newest versions of a dataset stay pinned.
+// The policy is applied straight away, and again after each save
+func (m *RepoMethods) SetPinPolicy(p *PinPolicyParams, res *UnpinResult) error {
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("RepoMethods.SetPinPolicy", p, res)
+	}
+	ctx := context.TODO()
+	defer m.inst.lockWrites()()
+
+	if p.KeepPinned < 0 {
+		return fmt.Errorf("versions to keep pinned can't be negative")
+	}
+	policies := base.PinPoliciesOf(m.inst.Repo())
+	if policies == nil {
+		return fmt.Errorf("repo doesn't keep pin policies")
+	}
+	ref, ps, err := m.pinRef(p.Ref)
+	if err != nil {
+		return err
+	}
+	if err = policies.SetKeepPinned(ref, p.KeepPinned); err != nil {
+		return err
+	}
+
+	unpinned, reclaimed, err := base.EnforcePinPolicy(ctx, m.inst.Repo(), ref, p.KeepPinned, ps, checkedOutPaths(ref))
+	if err != nil {
+		return err
+	}
+	*res = UnpinResult{Unpinned: unpinned, Reclaimed: reclaimed}
+	return nil
+}
+
+// pinRef resolves a dataset reference for pin management
+func (m *RepoMethods) pinRef(refstr string) (reporef.DatasetRef, base.PinStore, error) {
+	ps, err := nodePinStore(m.inst.Node())
+	if err != nil {
+		return reporef.DatasetRef{}, ps, err
+	}
+	ref, err := repo.ParseDatasetRef(refstr)
+	if err != nil {
+		return ref, ps, invalidRefError(refstr)
+	}
+	if ref.Path != "" {
+		return ref, ps, fmt.Errorf("pins are managed per dataset, use a reference without a version path")
+	}
+	if err = repo.CanonicalizeDatasetRef(m.inst.Repo(), &ref); err != nil {
+		return ref, ps, err
+	}
+	return ref, ps, nil
+}
+
+// onlyPrefetched reports whether err resolving ref for pin management is
+// because the dataset isn't in the repo, but has prefetched versions
+func (m *RepoMethods) onlyPrefetched(ref reporef.DatasetRef, err error) bool {
+	prefetches := base.PrefetchesOf(m.inst.Repo())
+	return repo.IsNotFound(err) && prefetches != nil && len(prefetches.List(ref)) > 0
+}
+
+// enforcePinPolicy applies a dataset's pin policy after a save. Failing to
+// apply the policy doesn't fail the save
+func enforcePinPolicy(ctx context.Context, node *p2p.QriNode, ref reporef.DatasetRef) {
+	policies := base.PinPoliciesOf(node.Repo)
+	if policies == nil {
+		return
+	}
+	keep := policies.KeepPinned(ref)
+	if keep == 0 {
+		return
+	}
+	ps, err := nodePinStore(node)
+	if err != nil {
+		log.Debugf("applying pin policy of %s: %s", ref.AliasString(), err)
+		return
+	}
+	if _, _, err := base.EnforcePinPolicy(ctx, node.Repo, ref, keep, ps, checkedOutPaths(ref)); err != nil {
+		log.Errorf("applying pin policy of %s: %s", ref.AliasString(), err)
+	}
+}
+
+// nodePinStore lists & measures pins with a node's IPFS API
+func nodePinStore(node *p2p.QriNode) (base.PinStore, error) {
+	capi, err := node.IPFSCoreAPI()
+	if err != nil {
+		return base.PinStore{}, repo.ErrNotPinner
+	}
+
+	return base.PinStore{
+		Pinned: func(ctx context.Context) (map[string]bool, error) {
+			pins, err := capi.Pin().Ls(ctx, options.Pin.Type.Recursive())
+			if err != nil {
+				return nil, err
+			}
+			pinned := make(map[string]bool, len(pins))
+			for _, p := range pins {
+				pinned["/ipfs/"+p.Path().Cid().String()] = true
+			}
+			return pinned, nil
+		},
+		BlockSizes: func(ctx context.Context, path string) (map[string]uint64, error) {
+			info, err := node.NewDAGInfo(ctx, path, "")
+			if err != nil {
+				return nil, err
+			}
+			sizes := make(map[string]uint64, len(info.Sizes))
+			for i, id := range info.Manifest.Nodes {
+				if i < len(info.Sizes) {
+					sizes[id] = info.Sizes[i]
+				}
+			}
+			return sizes, nil
+		},
+	}, nil
+}
+
+// checkedOutPaths lists versions of a dataset checked out to a working
+// directory, which must stay pinned
+func checkedOutPaths(ref reporef.DatasetRef) []string {
+	if ref.FSIPath == "" {
+		return nil
+	}
+	linked, ok := fsi.GetLinkedFilesysRef(ref.FSIPath)
+	if !ok {
+		return nil
+	}
+	if lref, err := repo.ParseDatasetRef(linked); err == nil && lref.Path != "" {
+		return []string{lref.Path}
+	}
+	return nil
+}
@@ -53,7 +53,7 @@ func (m *ProfileMethods) GetProfile(in *bool, res *config.ProfilePod) (err error
 		return err
 	}
 
-	cfg := m.inst.cfg
+	cfg := m.inst.Config()
 	// TODO (b5) - this isn't the right way to check if you're online
 	if cfg != nil && cfg.P2P != nil {
 		pro.Online = cfg.P2P.Enabled
@@ -106,56 +106,56 @@ func (m *ProfileMethods) SaveProfile(p *config.ProfilePod, res *config.ProfilePo
 		return fmt.Errorf("profile required for update")
 	}
 
-	cfg := m.inst.cfg
 	r := m.inst.repo
-
-	if p.Peername != cfg.Profile.Peername && p.Peername != "" {
-		if reg := m.inst.registry; reg != nil {
-			current, err := profile.NewProfile(cfg.Profile)
-			if err != nil {
-				return err
+	return m.inst.modifyConfig(func(cfg *config.Config) error {
+		if p.Peername != cfg.Profile.Peername && p.Peername != "" {
+			if reg := m.inst.registry; reg != nil {
+				current, err := profile.NewProfile(cfg.Profile)
+				if err != nil {
+					return err
+				}
+
+				if _, err := reg.PutProfile(&registry.Profile{Username: p.Peername}, current.PrivKey); err != nil {
+					return err
+				}
 			}
 
-			if _, err := reg.PutProfile(&registry.Profile{Username: p.Peername}, current.PrivKey); err != nil {
-				return err
-			}
+			cfg.Set("profile.peername", p.Peername)
 		}
 
-		cfg.Set("profile.peername", p.Peername)
-	}
-
-	cfg.Set("profile.name", p.Name)
-	cfg.Set("profile.email", p.Email)
-	cfg.Set("profile.description", p.Description)
-	cfg.Set("profile.homeurl", p.HomeURL)
-	cfg.Set("profile.twitter", p.Twitter)
+		cfg.Set("profile.name", p.Name)
+		cfg.Set("profile.email", p.Email)
+		cfg.Set("profile.description", p.Description)
+		cfg.Set("profile.homeurl", p.HomeURL)
+		cfg.Set("profile.twitter", p.Twitter)
 
-	if p.Color != "" {
-		cfg.Set("profile.color", p.Color)
-	}
-	// TODO (b5) - strange bug:
-	if cfg.Profile.Type == "" {
-		cfg.Profile.Type = "peer"
-	}
+		if p.Color != "" {
+			cfg.Set("profile.color", p.Color)
+		}
+		// TODO (b5) - strange bug:
+		if cfg.Profile.Type == "" {
+			cfg.Profile.Type = "peer"
+		}
 
-	pro, err := profile.NewProfile(cfg.Profile)
-	if err != nil {
-		return err
-	}
-	if err := r.SetProfile(pro); err != nil {
-		return err
-	}
+		pro, err := profile.NewProfile(cfg.Profile)
+		if err != nil {
+			return err
+		}
+		if err := r.SetProfile(pro); err != nil {
+			return err
+		}
 
-	// Copy the global config, except without the private key.
-	*res = *cfg.Profile
-	res.PrivKey = ""
+		// Copy the global config, except without the private key.
+		*res = *cfg.Profile
+		res.PrivKey = ""
 
-	// TODO (b5) - we should have a betteer way of determining onlineness
-	if cfg.P2P != nil {
-		res.Online = cfg.P2P.Enabled
-	}
+		// TODO (b5) - we should have a betteer way of determining onlineness
+		if cfg.P2P != nil {
+			res.Online = cfg.P2P.Enabled
+		}
 
-	return m.inst.ChangeConfig(cfg)
+		return nil
+	})
 }
 
 // ProfilePhoto fetches the byte slice of a given user's profile photo
@@ -231,31 +231,32 @@ func (m *ProfileMethods) SetProfilePhoto(p *FileParams, res *config.ProfilePod)
 
 	res.Photo = path
 	res.Thumb = path
-	cfg := m.inst.cfg.Copy()
-	cfg.Set("profile.photo", path)
-	// TODO - resize photo for thumb
-	cfg.Set("profile.thumb", path)
+	return m.inst.modifyConfig(func(cfg *config.Config) error {
+		cfg.Set("profile.photo", path)
+		// TODO - resize photo for thumb
+		cfg.Set("profile.thumb", path)
 
-	pro, err := profile.NewProfile(cfg.Profile)
-	if err != nil {
-		return err
-	}
-	if err := r.SetProfile(pro); err != nil {
-		return err
-	}
+		pro, err := profile.NewProfile(cfg.Profile)
+		if err != nil {
+			return err
+		}
+		if err := r.SetProfile(pro); err != nil {
+			return err
+		}
 
-	newPro, err := r.Profile()
-	if err != nil {
-		return fmt.Errorf("error getting newly set profile: %s", err)
-	}
-	pp, err := newPro.Encode()
-	if err != nil {
-		return fmt.Errorf("error encoding new profile: %s", err)
-	}
+		newPro, err := r.Profile()
+		if err != nil {
+			return fmt.Errorf("error getting newly set profile: %s", err)
+		}
+		pp, err := newPro.Encode()
+		if err != nil {
+			return fmt.Errorf("error encoding new profile: %s", err)
+		}
 
-	*res = *pp
+		*res = *pp
 
-	return m.inst.ChangeConfig(cfg)
+		return nil
+	})
 }
 
 // PosterPhoto fetches the byte slice of a given user's poster photo
@@ -324,27 +325,28 @@ func (m *ProfileMethods) SetPosterPhoto(p *FileParams, res *config.ProfilePod) e
 	}
 
 	res.Poster = path
-	cfg := m.inst.cfg.Copy()
-	cfg.Set("profile.poster", path)
+	return m.inst.modifyConfig(func(cfg *config.Config) error {
+		cfg.Set("profile.poster", path)
 
-	pro, err := profile.NewProfile(cfg.Profile)
-	if err != nil {
-		return err
-	}
-	if err := r.SetProfile(pro); err != nil {
-		return err
-	}
+		pro, err := profile.NewProfile(cfg.Profile)
+		if err != nil {
+			return err
+		}
+		if err := r.SetProfile(pro); err != nil {
+			return err
+		}
 
-	newPro, err := r.Profile()
-	if err != nil {
-		return fmt.Errorf("error getting newly set profile: %s", err)
-	}
-	pp, err := newPro.Encode()
-	if err != nil {
-		return fmt.Errorf("error encoding new profile: %s", err)
-	}
+		newPro, err := r.Profile()
+		if err != nil {
+			return fmt.Errorf("error getting newly set profile: %s", err)
+		}
+		pp, err := newPro.Encode()
+		if err != nil {
+			return fmt.Errorf("error encoding new profile: %s", err)
+		}
 
-	*res = *pp
+		*res = *pp
 
-	return m.inst.ChangeConfig(cfg)
+		return nil
+	})
 }
@@ -131,6 +131,12 @@ func TestSaveProfile(t *testing.T) {
 		log.Fatal(err)
 	}
 
+	// Saving changes a copy of the config, leaving the original untouched
+	if cfg.Profile.Name == pro.Name {
+		t.Errorf("expected saving a profile not to modify the config in place")
+	}
+	cfg = inst.Config()
+
 	// Saving adds a private key. Verify that it used to not exist, then copy the key.
 	if got.PrivKey != "" {
 		log.Errorf("Returned Profile should not have private key: %v", got.PrivKey)
@@ -213,6 +219,8 @@ func TestProfileRequestsSetPeername(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// pro is shared with other tests through testPeerProfile
+	defer func(name string) { pro.Peername = name }(pro.Peername)
 	pro.Peername = "keyboard_cat"
 	pp, err := pro.Encode()
 	if err != nil {
@@ -0,0 +1,230 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/event"
+	"github.com/qri-io/qri/logbook"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+const (
+	// publishQueueInterval is how often an instance checks the publish queue
+	// for items that are due to be attempted
+	publishQueueInterval = 30 * time.Second
+	// publishRetryBackoff is the wait after the first failed attempt, each
+	// following failure doubles it, up to publishRetryMaxBackoff
+	publishRetryBackoff    = 30 * time.Second
+	publishRetryMaxBackoff = time.Hour
+	// PublishQueueMaxAge is how long a dataset stays queued before a publish
+	// that keeps failing is marked failed
+	PublishQueueMaxAge = 24 * time.Hour
+)
+
+// publishQueueOf returns the publish queue of a repo, or nil if the repo
+// doesn't keep one
+func publishQueueOf(r repo.Repo) *repo.PublishQueue {
+	if q, ok := r.(repo.PublishQueuer); ok {
+		return q.PublishQueue()
+	}
+	return nil
+}
+
+// PublishQueueParams configures listing the publish queue
+type PublishQueueParams struct{}
+
+// PublishQueue lists datasets waiting to be published in the background,
+// oldest first. Items that gave up retrying stay listed with a failed status
+// until the dataset is published again
+func (r *RemoteMethods) PublishQueue(p *PublishQueueParams, res *[]repo.PublishQueueItem) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.inst.rpc != nil {
+		return r.inst.rpc.Call("RemoteMethods.PublishQueue", p, res)
+	}
+
+	q := publishQueueOf(r.inst.Repo())
+	if q == nil {
+		return fmt.Errorf("repo doesn't keep a publish queue")
+	}
+	*res = q.List()
+	return nil
+}
+
+// enqueuePublish queues a dataset to be published to a remote in the
+// background, replacing any queued or failed item for the same remote
+func (inst *Instance) enqueuePublish(ref reporef.DatasetRef, remoteName, addr string) error {
+	q := publishQueueOf(inst.Repo())
+	if q == nil {
+		return fmt.Errorf("repo doesn't keep a publish queue")
+	}
+
+	ref.Dataset = nil
+	item := repo.PublishQueueItem{
+		Ref:        ref,
+		RemoteName: remoteName,
+		Address:    addr,
+		Status:     repo.PublishQueued,
+		Queued:     time.Now(),
+	}
+	if err := q.Put(item); err != nil {
+		return err
+	}
+	inst.publishQueueEvent(event.PublishQueueQueued, item)
+	inst.kickPublishQueue()
+	return nil
+}
+
+// supersedeQueuedPublish points queued publishes of a dataset at a newly
+// saved version, retrying straight away instead of waiting out a backoff
+func supersedeQueuedPublish(r repo.Repo, ref reporef.DatasetRef) (superseded bool) {
+	q := publishQueueOf(r)
+	if q == nil {
+		return false
+	}
+	for _, item := range q.List() {
+		if item.Status != repo.PublishQueued || item.Ref.Peername != ref.Peername || item.Ref.Name != ref.Name {
+			continue
+		}
+		item.Ref.Path = ref.Path
+		item.Attempts = 0
+		item.NextAttempt = time.Time{}
+		if err := q.Put(item); err != nil {
+			log.Errorf("superseding queued publish of %s: %s", ref.AliasString(), err)
+			continue
+		}
+		superseded = true
+	}
+	return superseded
+}
+
+// kickPublishQueue asks the publish queue worker to check for due items now
+func (inst *Instance) kickPublishQueue() {
+	if inst == nil || inst.pubqKick == nil {
+		return
+	}
+	select {
+	case inst.pubqKick <- struct{}{}:
+	default:
+	}
+}
+
+// runPublishQueue attempts due publish queue items every interval, or when
+// kicked, until ctx is cancelled. The queue is persisted by the repo as it
+// changes, so items left when an instance shuts down are picked up by the
+// next instance to run
+func (inst *Instance) runPublishQueue(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		inst.processPublishQueue(ctx, time.Now())
+		select {
+		case <-t.C:
+		case <-inst.pubqKick:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// processPublishQueue attempts every queued item that's due at now
+func (inst *Instance) processPublishQueue(ctx context.Context, now time.Time) {
+	inst.pubqLk.Lock()
+	defer inst.pubqLk.Unlock()
+
+	q := publishQueueOf(inst.Repo())
+	if q == nil || inst.RemoteClient() == nil {
+		return
+	}
+
+	for _, item := range q.List() {
+		if item.Status != repo.PublishQueued || now.Before(item.NextAttempt) {
+			continue
+		}
+		if now.Sub(item.Queued) > PublishQueueMaxAge {
+			item.Status = repo.PublishFailed
+			if err := q.Put(item); err != nil {
+				log.Errorf("updating publish queue: %s", err)
+			}
+			inst.publishQueueEvent(event.PublishQueueFailed, item)
+			continue
+		}
+
+		// always publish the latest version, even if the queue hasn't seen a save
+		ref := reporef.DatasetRef{Peername: item.Ref.Peername, Name: item.Ref.Name}
+		if err := repo.CanonicalizeDatasetRef(inst.Repo(), &ref); err != nil {
+			log.Debugf("dropping queued publish of %s: %s", item.Ref.AliasString(), err)
+			if err := q.Delete(item.Ref, item.Address); err != nil {
+				log.Errorf("updating publish queue: %s", err)
+			}
+			continue
+		}
+		item.Ref.Path = ref.Path
+
+		item.Attempts++
+		item.LastAttempt = now
+		if err := inst.attemptPublish(ctx, ref, item.Address); err != nil {
+			item.LastError = err.Error()
+			item.NextAttempt = now.Add(publishBackoff(item.Attempts))
+			if err := q.Put(item); err != nil {
+				log.Errorf("updating publish queue: %s", err)
+			}
+			inst.publishQueueEvent(event.PublishQueueRetrying, item)
+			continue
+		}
+
+		if err := q.Delete(item.Ref, item.Address); err != nil {
+			log.Errorf("updating publish queue: %s", err)
+		}
+		item.LastError = ""
+		inst.publishQueueEvent(event.PublishQueuePublished, item)
+	}
+}
+
+// attemptPublish publishes a dataset to a remote, recording the remote as a
+// publish destination & marking the dataset published on success
+func (inst *Instance) attemptPublish(ctx context.Context, ref reporef.DatasetRef, addr string) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultPublishTimeout)
+	defer cancel()
+	if err := NewRemoteMethods(inst).publishTo(ctx, ref, addr); err != nil {
+		return err
+	}
+
+	err := inst.Repo().Logbook().WritePublish(ctx, reporef.ConvertToDsref(ref), 1, addr)
+	if err != nil && err != logbook.ErrNoLogbook {
+		log.Errorf("recording publish of %s: %s", ref.AliasString(), err)
+	}
+	ref.Published = true
+	return base.SetPublishStatus(inst.Repo(), &ref, ref.Published)
+}
+
+// publishBackoff is how long to wait before retrying a publish that has
+// failed a number of times
+func publishBackoff(attempts int) time.Duration {
+	d := publishRetryBackoff
+	for i := 1; i < attempts && d < publishRetryMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > publishRetryMaxBackoff {
+		d = publishRetryMaxBackoff
+	}
+	return d
+}
+
+func (inst *Instance) publishQueueEvent(action string, item repo.PublishQueueItem) {
+	if inst.bus == nil {
+		return
+	}
+	inst.bus.Publish(event.ETPublishQueueEvent, event.PublishQueueEvent{
+		Action:   action,
+		Username: item.Ref.Peername,
+		Dsname:   item.Ref.Name,
+		Path:     item.Ref.Path,
+		Remote:   item.Address,
+		Attempts: item.Attempts,
+		Error:    item.LastError,
+	})
+}
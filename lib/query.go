@@ -0,0 +1,244 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/base/query"
+	"github.com/qri-io/qri/repo"
+)
+
+const (
+	// DefaultQueryLimit is the number of rows a query returns when
+	// QueryParams.Limit isn't set
+	DefaultQueryLimit = 1000
+	// DefaultQueryTimeout is how long a query runs when QueryParams.Timeout
+	// isn't set
+	DefaultQueryTimeout = 30 * time.Second
+)
+
+// QueryParams defines parameters for querying dataset bodies
+type QueryParams struct {
+	// Query is a SELECT statement in the restricted SQL dialect described by
+	// query.Statement. Tables are dataset references, like:
+	//   SELECT city, pop FROM me/cities WHERE pop > 1000000
+	Query string
+	// Format to encode results in, defaults to json
+	Format string
+	// Limit caps the number of result rows, defaults to DefaultQueryLimit.
+	// Results past the limit are dropped & the result marked truncated
+	Limit int
+	// Timeout caps how long a query runs, defaults to DefaultQueryTimeout
+	Timeout time.Duration
+}
+
+// QueryResult is the result of a query
+type QueryResult struct {
+	// Columns names the columns of each result row
+	Columns []string `json:"columns"`
+	// Data is result rows encoded in Format
+	Data   []byte `json:"data"`
+	Format string `json:"format"`
+	// Truncated is true when results were cut off by QueryParams.Limit
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Query runs a SQL query over the bodies of datasets. Queries read local
+// datasets, and the published datasets of peers. Joins hold the body of the
+// joined dataset in memory, and fail once it passes query.DefaultMaxJoinRows
+// rows
+func (r *DatasetRequests) Query(p *QueryParams, res *QueryResult) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Query", p, res)
+	}
+
+	if strings.TrimSpace(p.Query) == "" {
+		return fmt.Errorf("query is required")
+	}
+	stmt, err := query.Parse(p.Query)
+	if err != nil {
+		return err
+	}
+
+	format := p.Format
+	if format == "" {
+		format = dataset.JSONDataFormat.String()
+	}
+	if !strings.EqualFold(format, base.ParquetFormat) {
+		if _, err := dataset.ParseDataFormatString(format); err != nil {
+			return err
+		}
+	}
+
+	limit := p.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultQueryTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), timeout)
+	defer cancel()
+
+	tables := map[string]query.Table{}
+	for _, refstr := range stmt.Tables() {
+		if _, ok := tables[refstr]; ok {
+			continue
+		}
+		if tables[refstr], err = r.queryTable(ctx, refstr); err != nil {
+			return err
+		}
+	}
+
+	plan, err := query.NewPlan(stmt, tables, query.Options{MaxRows: limit})
+	if err != nil {
+		return err
+	}
+
+	st := queryStructure(plan, format)
+	buf := &bytes.Buffer{}
+	var w dsio.EntryWriter
+	if strings.EqualFold(format, base.ParquetFormat) {
+		w, err = base.NewParquetEntryWriter(st, buf)
+	} else {
+		w, err = dsio.NewEntryWriter(st, buf)
+	}
+	if err != nil {
+		return err
+	}
+
+	i := 0
+	truncated, err := plan.Run(ctx, func(row []interface{}) error {
+		i++
+		return w.WriteEntry(dsio.Entry{Index: i - 1, Value: row})
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("query timed out after %s", timeout)
+	} else if err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	*res = QueryResult{
+		Columns:   plan.Columns(),
+		Data:      buf.Bytes(),
+		Format:    st.Format,
+		Truncated: truncated,
+	}
+	return nil
+}
+
+// queryTable resolves a reference named in a query to the body it reads.
+// Datasets of other peers can only be queried once they're published
+func (r *DatasetRequests) queryTable(ctx context.Context, refstr string) (query.Table, error) {
+	ref, err := repo.ParseDatasetRef(refstr)
+	if err != nil {
+		return query.Table{}, invalidRefError(refstr)
+	}
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
+		return query.Table{}, err
+	}
+	pro, err := r.node.Repo.Profile()
+	if err != nil {
+		return query.Table{}, err
+	}
+	if ref.ProfileID != pro.ID && !ref.Published {
+		return query.Table{}, fmt.Errorf("%w: %s isn't published", ErrForbidden, refstr)
+	}
+
+	ds, err := dsfs.LoadDataset(ctx, r.node.Repo.Store(), ref.Path)
+	if err != nil {
+		return query.Table{}, fmt.Errorf("loading dataset: %s", err)
+	}
+	if ds.Structure == nil {
+		return query.Table{}, fmt.Errorf("%s has no body to query", refstr)
+	}
+	cols := base.Columns(ds.Structure.Schema)
+	if len(cols) == 0 {
+		return query.Table{}, fmt.Errorf("%s isn't tabular, only bodies with schema columns can be queried", refstr)
+	}
+
+	t := query.Table{
+		Columns: make([]string, len(cols)),
+		Types:   make([]string, len(cols)),
+		Open: func() (dsio.EntryReader, error) {
+			ds, err := dsfs.LoadDataset(ctx, r.node.Repo.Store(), ref.Path)
+			if err != nil {
+				return nil, fmt.Errorf("loading dataset: %s", err)
+			}
+			if err = base.OpenDataset(ctx, r.node.Repo.Filesystem(), ds); err != nil {
+				return nil, err
+			}
+			body := ds.BodyFile()
+			if body == nil {
+				return nil, fmt.Errorf("%s has no body to query", refstr)
+			}
+			er, err := dsio.NewEntryReader(ds.Structure, body)
+			if err != nil {
+				body.Close()
+				return nil, err
+			}
+			return &bodyEntryReader{EntryReader: er, close: body.Close}, nil
+		},
+	}
+	for i, c := range cols {
+		t.Columns[i] = c.Name
+		if typ, ok := c.Schema["type"].(string); ok && typ != "null" {
+			t.Types[i] = typ
+		}
+	}
+	return t, nil
+}
+
+// bodyEntryReader closes the body file it reads once reading is done
+type bodyEntryReader struct {
+	dsio.EntryReader
+	close func() error
+}
+
+func (r *bodyEntryReader) Close() error {
+	err := r.EntryReader.Close()
+	if cerr := r.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// queryStructure describes the rows a query plan results in
+func queryStructure(plan *query.Plan, format string) *dataset.Structure {
+	names, types := plan.Columns(), plan.Types()
+	items := make([]interface{}, len(names))
+	for i, name := range names {
+		col := map[string]interface{}{"title": name}
+		if types[i] != "" {
+			col["type"] = types[i]
+		}
+		items[i] = col
+	}
+	st := &dataset.Structure{
+		Format: strings.ToLower(format),
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":  "array",
+				"items": items,
+			},
+		},
+	}
+	if st.Format == dataset.CSVDataFormat.String() {
+		st.FormatConfig = map[string]interface{}{"headerRow": true}
+	}
+	return st
+}
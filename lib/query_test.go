@@ -0,0 +1,83 @@
+package lib
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestDatasetRequestsQuery(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	saved := SaveResult{}
+	bodyPath := tr.writeFile(t, "founded.csv", "city,founded\ntoronto,1793\nnew york,1624\nchicago,1837\n")
+	if err := req.Save(&SaveParams{Ref: "me/founded", BodyPath: bodyPath}, &saved); err != nil {
+		t.Fatal(err)
+	}
+
+	res := QueryResult{}
+	q := "SELECT c.city, founded FROM me/cities c JOIN me/founded f ON c.city = f.city WHERE pop > 40000 ORDER BY founded"
+	if err := req.Query(&QueryParams{Query: q}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if expect := `[["new york",1624],["toronto",1793],["chicago",1837]]`; string(res.Data) != expect {
+		t.Errorf("data mismatch.\nwant: %s\ngot:  %s", expect, res.Data)
+	}
+
+	res = QueryResult{}
+	if err := req.Query(&QueryParams{Query: "SELECT city, pop FROM me/cities ORDER BY pop DESC", Format: "csv", Limit: 2}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "city,pop\ntoronto,40000000\nnew york,8500000\n"; string(res.Data) != expect {
+		t.Errorf("csv mismatch.\nwant: %q\ngot:  %q", expect, res.Data)
+	}
+	if !res.Truncated {
+		t.Error("expected results past the limit to be truncated")
+	}
+
+	bad := []struct {
+		params QueryParams
+		err    string
+	}{
+		{QueryParams{}, "query is required"},
+		{QueryParams{Query: "SELECT * FROM me/cities", Format: "nope"}, "invalid data format: `nope`"},
+		{QueryParams{Query: "SELECT nope FROM me/cities"}, `unknown column "nope"`},
+	}
+	for _, c := range bad {
+		err := req.Query(&c.params, &QueryResult{})
+		if err == nil || err.Error() != c.err {
+			t.Errorf("%q: error mismatch. expected: %s, got: %v", c.params.Query, c.err, err)
+		}
+	}
+
+	// datasets of other peers can't be queried until they're published
+	mr := tr.Instance.Repo()
+	cities, err := mr.GetRef(reporef.DatasetRef{Peername: "peer", Name: "cities"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := reporef.DatasetRef{
+		Peername:  "other",
+		ProfileID: profile.IDB58MustDecode("QmY1PxkV9t9RoBwtXHfue1Qf6iYob19nL6rDHuXxooAVZa"),
+		Name:      "cities",
+		Path:      cities.Path,
+	}
+	if err := mr.PutRef(other); err != nil {
+		t.Fatal(err)
+	}
+	err = req.Query(&QueryParams{Query: "SELECT * FROM other/cities"}, &QueryResult{})
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected querying an unpublished peer dataset to be forbidden, got: %v", err)
+	}
+	other.Published = true
+	if err := mr.PutRef(other); err != nil {
+		t.Fatal(err)
+	}
+	if err := req.Query(&QueryParams{Query: "SELECT count(*) FROM other/cities"}, &QueryResult{}); err != nil {
+		t.Errorf("expected a published peer dataset to be queryable, got: %v", err)
+	}
+}
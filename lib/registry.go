@@ -62,8 +62,7 @@ func (m RegistryClientMethods) ProveProfileKey(p *RegistryProfile, ok *bool) err
 	return m.updateConfig(pro)
 }
 
-func (m RegistryClientMethods) configChanges(pro *registry.Profile) *config.Config {
-	cfg := m.inst.cfg.Copy()
+func (m RegistryClientMethods) configChanges(cfg *config.Config, pro *registry.Profile) {
 	cfg.Profile.Peername = pro.Username
 	cfg.Profile.Created = pro.Created
 	cfg.Profile.Email = pro.Email
@@ -73,33 +72,30 @@ func (m RegistryClientMethods) configChanges(pro *registry.Profile) *config.Conf
 	cfg.Profile.Description = pro.Description
 	cfg.Profile.HomeURL = pro.HomeURL
 	cfg.Profile.Twitter = pro.Twitter
-
-	return cfg
 }
 
 func (m RegistryClientMethods) updateConfig(pro *registry.Profile) error {
 	ctx := context.TODO()
-	cfg := m.configChanges(pro)
-
-	// TODO (b5) - this should be automatically done by m.inst.ChangeConfig
-	repoPro, err := profile.NewProfile(cfg.Profile)
-	if err != nil {
-		return err
-	}
+	return m.inst.modifyConfig(func(cfg *config.Config) error {
+		prevPeername := cfg.Profile.Peername
+		m.configChanges(cfg, pro)
 
-	// TODO (b5) - this is the lowest level place I could find to monitor for
-	// profile name changes, not sure this makes the most sense to have this here.
-	// we should consider a separate track for any change that affects the peername,
-	// it should always be verified by any set registry before saving
-	if cfg.Profile.Peername != m.inst.cfg.Profile.Peername {
-		if err := base.ModifyRepoUsername(ctx, m.inst.Repo(), m.inst.logbook, m.inst.cfg.Profile.Peername, cfg.Profile.Peername); err != nil {
+		// TODO (b5) - this should be automatically done by m.inst.ChangeConfig
+		repoPro, err := profile.NewProfile(cfg.Profile)
+		if err != nil {
 			return err
 		}
-	}
 
-	if err := m.inst.Repo().SetProfile(repoPro); err != nil {
-		return err
-	}
+		// TODO (b5) - this is the lowest level place I could find to monitor for
+		// profile name changes, not sure this makes the most sense to have this here.
+		// we should consider a separate track for any change that affects the peername,
+		// it should always be verified by any set registry before saving
+		if cfg.Profile.Peername != prevPeername {
+			if err := base.ModifyRepoUsername(ctx, m.inst.Repo(), m.inst.logbook, prevPeername, cfg.Profile.Peername); err != nil {
+				return err
+			}
+		}
 
-	return m.inst.ChangeConfig(cfg)
+		return m.inst.Repo().SetProfile(repoPro)
+	})
 }
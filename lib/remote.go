@@ -3,9 +3,15 @@ package lib
 import (
 	"context"
 	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/remote"
@@ -38,7 +44,8 @@ type FetchParams struct {
 }
 
 // Fetch pulls a logbook from a remote
-func (r *RemoteMethods) Fetch(p *FetchParams, res *[]dsref.VersionInfo) error {
+func (r *RemoteMethods) Fetch(p *FetchParams, res *[]dsref.VersionInfo) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.inst.rpc != nil {
 		return r.inst.rpc.Call("RemoteMethods.Fetch", p, res)
 	}
@@ -48,7 +55,7 @@ func (r *RemoteMethods) Fetch(p *FetchParams, res *[]dsref.VersionInfo) error {
 		return err
 	}
 	if err = repo.CanonicalizeDatasetRef(r.inst.Repo(), &ref); err != nil {
-		if err == repo.ErrNotFound {
+		if repo.IsNotFound(err) {
 			err = nil
 		} else {
 			return err
@@ -103,10 +110,34 @@ type PublicationParams struct {
 	// All indicates all versions of a dataset and the dataset namespace should
 	// be either published or removed
 	All bool
+	// RemoteNames lists the remotes PublishToRemotes publishes to, by name or
+	// address
+	RemoteNames []string
+	// Timeout bounds publishing to each remote, defaults to
+	// DefaultPublishTimeout
+	Timeout time.Duration
+	// Queue publishes in the background, retrying until the remote is
+	// reachable. Publish returns once the dataset is queued
+	Queue bool
 }
 
-// Publish posts a dataset version to a remote
-func (r *RemoteMethods) Publish(p *PublicationParams, res *dsref.Ref) error {
+// DefaultPublishTimeout is how long PublishToRemotes waits on each remote
+const DefaultPublishTimeout = time.Minute
+
+// PublishResult reports the outcome of publishing to one remote
+type PublishResult struct {
+	RemoteName string
+	Address    string
+	// Skipped is true when the remote already has the latest version
+	Skipped bool
+	// Error is empty when publishing succeeded
+	Error string
+}
+
+// Publish posts a dataset version to a remote. With p.Queue set the dataset is
+// queued & published in the background instead
+func (r *RemoteMethods) Publish(p *PublicationParams, res *dsref.Ref) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.inst.rpc != nil {
 		return r.inst.rpc.Call("RemoteMethods.Publish", p, res)
 	}
@@ -118,6 +149,9 @@ func (r *RemoteMethods) Publish(p *PublicationParams, res *dsref.Ref) error {
 	if ref.Path != "" {
 		return fmt.Errorf("can only publish entire dataset, cannot use version %s", ref.Path)
 	}
+	if err = base.RejectAlias(r.inst.Repo(), ref); err != nil {
+		return err
+	}
 	if err = repo.CanonicalizeDatasetRef(r.inst.Repo(), &ref); err != nil {
 		return err
 	}
@@ -127,6 +161,14 @@ func (r *RemoteMethods) Publish(p *PublicationParams, res *dsref.Ref) error {
 		return err
 	}
 
+	if p.Queue {
+		if err = r.inst.enqueuePublish(ref, p.RemoteName, addr); err != nil {
+			return err
+		}
+		*res = reporef.ConvertToDsref(ref)
+		return nil
+	}
+
 	// TODO (b5) - need contexts yo
 	ctx := context.TODO()
 
@@ -141,6 +183,9 @@ func (r *RemoteMethods) Publish(p *PublicationParams, res *dsref.Ref) error {
 	if err = r.inst.RemoteClient().PushDataset(ctx, ref, addr); err != nil {
 		return err
 	}
+	if err = r.inst.Repo().Logbook().WritePublish(ctx, reporef.ConvertToDsref(ref), 1, addr); err != nil && err != logbook.ErrNoLogbook {
+		return err
+	}
 
 	ref.Published = true
 	if err = base.SetPublishStatus(r.inst.node.Repo, &ref, ref.Published); err != nil {
@@ -151,8 +196,164 @@ func (r *RemoteMethods) Publish(p *PublicationParams, res *dsref.Ref) error {
 	return nil
 }
 
-// Unpublish asks a remote to remove a dataset
-func (r *RemoteMethods) Unpublish(p *PublicationParams, res *dsref.Ref) error {
+// PublishToRemotes posts a dataset version to a number of remotes at once,
+// reporting the outcome for each. Remotes the logbook records as already
+// holding the latest version are skipped, so publishing again after a partial
+// failure only retries the remotes that failed. The dataset is marked
+// published if any remote succeeds
+func (r *RemoteMethods) PublishToRemotes(p *PublicationParams, res *[]PublishResult) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.inst.rpc != nil {
+		return r.inst.rpc.Call("RemoteMethods.PublishToRemotes", p, res)
+	}
+	if len(p.RemoteNames) == 0 {
+		return fmt.Errorf("at least one remote is required")
+	}
+
+	ref, err := repo.ParseDatasetRef(p.Ref)
+	if err != nil {
+		return err
+	}
+	if ref.Path != "" {
+		return fmt.Errorf("can only publish entire dataset, cannot use version %s", ref.Path)
+	}
+	if err = base.RejectAlias(r.inst.Repo(), ref); err != nil {
+		return err
+	}
+	if err = repo.CanonicalizeDatasetRef(r.inst.Repo(), &ref); err != nil {
+		return err
+	}
+
+	results := make([]PublishResult, len(p.RemoteNames))
+	for i, name := range p.RemoteNames {
+		results[i].RemoteName = name
+		if results[i].Address, err = remote.Address(r.inst.Config(), name); err != nil {
+			return err
+		}
+	}
+
+	// TODO (b5) - need contexts yo
+	ctx := context.TODO()
+	book := r.inst.Repo().Logbook()
+	dsr := reporef.ConvertToDsref(ref)
+	published, err := book.PublishedDestinations(ctx, dsr)
+	if err != nil && err != logbook.ErrNoLogbook {
+		log.Debugf("reading publish destinations: %s", err)
+	}
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultPublishTimeout
+	}
+
+	wg := sync.WaitGroup{}
+	for i := range results {
+		if containsString(published, results[i].Address) {
+			results[i].Skipped = true
+			continue
+		}
+		wg.Add(1)
+		go func(res *PublishResult) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			if err := r.publishTo(ctx, ref, res.Address); err != nil {
+				res.Error = err.Error()
+			}
+		}(&results[i])
+	}
+	wg.Wait()
+
+	var succeeded []string
+	for _, res := range results {
+		if !res.Skipped && res.Error == "" {
+			succeeded = append(succeeded, res.Address)
+		}
+	}
+	if len(succeeded) > 0 {
+		if err = book.WritePublish(ctx, dsr, 1, succeeded...); err != nil && err != logbook.ErrNoLogbook {
+			return err
+		}
+		ref.Published = true
+		if err = base.SetPublishStatus(r.inst.node.Repo, &ref, ref.Published); err != nil {
+			return err
+		}
+	}
+
+	*res = results
+	return nil
+}
+
+// publishTo pushes logs and dataset blocks to a remote, giving up when ctx is
+// done
+func (r *RemoteMethods) publishTo(ctx context.Context, ref reporef.DatasetRef, addr string) error {
+	errs := make(chan error, 1)
+	go func() {
+		if err := r.inst.RemoteClient().PushLogs(ctx, reporef.ConvertToDsref(ref), addr); err != nil {
+			log.Errorf("pushing logs to %s: %s", addr, err)
+		}
+		errs <- r.inst.RemoteClient().PushDataset(ctx, ref, addr)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("publishing to %s: %s", addr, ctx.Err())
+	}
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+// PublishRecord is an alias for logbook.PublishRecord, a destination a
+// dataset has been published to & how many revisions it holds
+type PublishRecord = logbook.PublishRecord
+
+// PublishedTo lists the remotes & registries a dataset has been published to,
+// with the number of revisions published to each, as recorded in the logbook
+func (r *RemoteMethods) PublishedTo(refstr *string, res *[]PublishRecord) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.inst.rpc != nil {
+		return r.inst.rpc.Call("RemoteMethods.PublishedTo", refstr, res)
+	}
+	ctx := context.TODO()
+
+	ref, err := repo.ParseDatasetRef(*refstr)
+	if err != nil {
+		return err
+	}
+	if ref.Path != "" {
+		return fmt.Errorf("can only list publications of an entire dataset, cannot use version %s", ref.Path)
+	}
+	if err = repo.CanonicalizeDatasetRef(r.inst.Repo(), &ref); err != nil {
+		return err
+	}
+
+	recs, err := r.inst.Repo().Logbook().PublishRecords(ctx, reporef.ConvertToDsref(ref))
+	if err == logbook.ErrNoLogbook {
+		recs = nil
+	} else if err != nil {
+		return err
+	}
+	if recs == nil {
+		recs = []PublishRecord{}
+	}
+	*res = recs
+	return nil
+}
+
+// Unpublish asks a remote to remove a dataset. A refusal from the remote has a
+// code classifying why the dataset wasn't removed, see remote.ErrorCodeOf.
+// Removing a dataset the remote doesn't have succeeds
+func (r *RemoteMethods) Unpublish(p *PublicationParams, res *dsref.Ref) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.inst.rpc != nil {
 		return r.inst.rpc.Call("RemoteMethods.Unpublish", p, res)
 	}
@@ -189,6 +390,16 @@ func (r *RemoteMethods) Unpublish(p *PublicationParams, res *dsref.Ref) error {
 	if err := r.inst.RemoteClient().RemoveDataset(ctx, ref, addr); err != nil {
 		return err
 	}
+	if err = writeUnpublish(ctx, r.inst.Repo().Logbook(), reporef.ConvertToDsref(ref), addr); err != nil && err != logbook.ErrNoLogbook {
+		return err
+	}
+
+	// a queued publish would undo unpublishing
+	if q := publishQueueOf(r.inst.Repo()); q != nil {
+		if err := q.Delete(ref, addr); err != nil && err != repo.ErrNotFound {
+			log.Errorf("dropping queued publish: %s", err)
+		}
+	}
 
 	ref.Published = false
 	if err = base.SetPublishStatus(r.inst.node.Repo, &ref, ref.Published); err != nil {
@@ -199,8 +410,166 @@ func (r *RemoteMethods) Unpublish(p *PublicationParams, res *dsref.Ref) error {
 	return nil
 }
 
+// writeUnpublish records removing every revision of a dataset published to
+// addr
+func writeUnpublish(ctx context.Context, book *logbook.Book, ref dsref.Ref, addr string) error {
+	records, err := book.PublishRecords(ctx, ref)
+	if err != nil {
+		return err
+	}
+	for _, rec := range records {
+		if rec.Destination == addr {
+			return book.WriteUnpublish(ctx, ref, rec.Revisions, addr)
+		}
+	}
+	return nil
+}
+
+// BulkPublicationParams encapsulates parameters for changing the publish
+// status of many datasets at once
+type BulkPublicationParams struct {
+	// Refs are dataset references or patterns. A pattern matches the names of
+	// your own datasets, using * as a wildcard: "me/sales_*"
+	Refs       []string
+	RemoteName string
+	// Unpublish removes datasets from the remote instead of publishing them
+	Unpublish bool
+	// Queue publishes in the background, see PublicationParams.Queue
+	Queue bool
+}
+
+// BulkPublicationResult reports the outcome of changing the publish status of
+// one dataset
+type BulkPublicationResult struct {
+	Ref string
+	// Error is empty when the status change succeeded
+	Error string
+}
+
+// PublishMany publishes or unpublishes a number of datasets, reporting the
+// outcome for each. Results are in the order refs are given, with patterns
+// expanded in place to matching datasets, sorted by name. A failure doesn't
+// stop the remaining datasets from being published
+func (r *RemoteMethods) PublishMany(p *BulkPublicationParams, res *[]BulkPublicationResult) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.inst.rpc != nil {
+		return r.inst.rpc.Call("RemoteMethods.PublishMany", p, res)
+	}
+	if len(p.Refs) == 0 {
+		return fmt.Errorf("at least one dataset reference is required")
+	}
+	if p.Unpublish && p.Queue {
+		return fmt.Errorf("can only queue publishing, not unpublishing")
+	}
+
+	results, err := r.expandPublishRefs(p.Refs)
+	if err != nil {
+		return err
+	}
+
+	for i := range results {
+		if results[i].Error != "" {
+			continue
+		}
+		pp := &PublicationParams{
+			Ref:        results[i].Ref,
+			RemoteName: p.RemoteName,
+			Queue:      p.Queue,
+		}
+		var ref dsref.Ref
+		if p.Unpublish {
+			err = r.Unpublish(pp, &ref)
+		} else {
+			err = r.Publish(pp, &ref)
+		}
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Ref = ref.Alias()
+	}
+
+	*res = results
+	return nil
+}
+
+// expandPublishRefs replaces patterns in a list of references with the
+// references of matching datasets. Patterns only match datasets in the
+// caller's own namespace, bad patterns & patterns that match nothing are
+// reported as errors in the result for that pattern
+func (r *RemoteMethods) expandPublishRefs(refs []string) ([]BulkPublicationResult, error) {
+	pro, err := r.inst.Repo().Profile()
+	if err != nil {
+		return nil, err
+	}
+	var own []reporef.DatasetRef
+
+	results := make([]BulkPublicationResult, 0, len(refs))
+	for _, refstr := range refs {
+		if !strings.Contains(refstr, "*") {
+			results = append(results, BulkPublicationResult{Ref: refstr})
+			continue
+		}
+
+		slash := strings.Index(refstr, "/")
+		if slash < 0 {
+			results = append(results, BulkPublicationResult{Ref: refstr, Error: "pattern must be in the form username/name_pattern"})
+			continue
+		}
+		peername, pattern := refstr[:slash], refstr[slash+1:]
+		if peername != "me" && peername != pro.Peername {
+			results = append(results, BulkPublicationResult{Ref: refstr, Error: fmt.Sprintf("patterns can only match your own datasets, %q is not your username", peername)})
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil || strings.Contains(pattern, "/") {
+			results = append(results, BulkPublicationResult{Ref: refstr, Error: fmt.Sprintf("invalid pattern %q", pattern)})
+			continue
+		}
+
+		if own == nil {
+			if own, err = ownRefs(r.inst.Repo(), pro.Peername); err != nil {
+				return nil, err
+			}
+		}
+		matched := 0
+		for _, ref := range own {
+			if ok, _ := path.Match(pattern, ref.Name); ok {
+				results = append(results, BulkPublicationResult{Ref: ref.AliasString()})
+				matched++
+			}
+		}
+		if matched == 0 {
+			results = append(results, BulkPublicationResult{Ref: refstr, Error: "no datasets match pattern"})
+		}
+	}
+	return results, nil
+}
+
+// ownRefs lists the references to datasets in a peername's namespace, sorted
+// by name
+func ownRefs(r repo.Repo, peername string) ([]reporef.DatasetRef, error) {
+	count, err := r.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.References(0, count)
+	if err != nil {
+		return nil, err
+	}
+
+	own := make([]reporef.DatasetRef, 0, len(refs))
+	for _, ref := range refs {
+		if ref.Peername == peername {
+			own = append(own, ref)
+		}
+	}
+	sort.Slice(own, func(i, j int) bool { return own[i].Name < own[j].Name })
+	return own, nil
+}
+
 // PullDataset fetches a dataset ref from a remote
-func (r *RemoteMethods) PullDataset(p *PublicationParams, res *bool) error {
+func (r *RemoteMethods) PullDataset(p *PublicationParams, res *bool) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.inst.rpc != nil {
 		return r.inst.rpc.Call("RemoteMethods.PullDataset", p, res)
 	}
@@ -217,9 +586,67 @@ func (r *RemoteMethods) PullDataset(p *PublicationParams, res *bool) error {
 	return err
 }
 
+// PrefetchParams provides arguments to Prefetch
+type PrefetchParams struct {
+	// Ref is the dataset to prefetch. References without a path are resolved
+	// to the latest version the remote knows of
+	Ref string
+	// RemoteName names the remote to pull from, defaults to the registry
+	RemoteName string
+}
+
+// Prefetch pulls the blocks of a dataset version from a remote into the local
+// store & pins them, so later reads of the version don't wait on the network.
+// Unlike Add, prefetching doesn't add the dataset to the repo: no reference
+// is stored & no logs are fetched. The version is recorded as prefetched
+// under the dataset's peername & name instead, which pin management lists &
+// unpins it by. res is set to the prefetched version
+func (r *RemoteMethods) Prefetch(p *PrefetchParams, res *dsref.Ref) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.inst.rpc != nil {
+		return r.inst.rpc.Call("RemoteMethods.Prefetch", p, res)
+	}
+	ctx := context.TODO()
+
+	if r.inst.RemoteClient() == nil {
+		return remote.ErrNoRemoteClient
+	}
+
+	ref, err := repo.ParseDatasetRef(p.Ref)
+	if err != nil {
+		return err
+	}
+	if ref.Peername == "" || ref.Name == "" {
+		return NewError(ErrBadArgs, "prefetching requires a dataset reference with a peername & name, like peer/dataset")
+	}
+	if base.PrefetchesOf(r.inst.Repo()) == nil {
+		return base.ErrNoPrefetches
+	}
+
+	addr, err := remote.Address(r.inst.Config(), p.RemoteName)
+	if err != nil {
+		return err
+	}
+
+	if err = r.inst.RemoteClient().PullDataset(ctx, &ref, addr); err != nil {
+		return err
+	}
+
+	if err = base.PinPrefetched(ctx, r.inst.Repo(), ref); err != nil && err != repo.ErrNotPinner {
+		if _, ok := err.(*repo.QuotaExceededError); ok {
+			return err
+		}
+		return fmt.Errorf("pinning prefetched dataset: %s", err)
+	}
+
+	*res = reporef.ConvertToDsref(ref)
+	return nil
+}
+
 // Feeds returns a listing of datasets from a number of feeds like featured and
 // popular. Each feed is keyed by string in the response
-func (r *RemoteMethods) Feeds(remoteName *string, res *map[string][]dsref.VersionInfo) error {
+func (r *RemoteMethods) Feeds(remoteName *string, res *map[string][]dsref.VersionInfo) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.inst.rpc != nil {
 		return r.inst.rpc.Call("RemoteMethods.Feeds", remoteName, res)
 	}
@@ -246,7 +673,8 @@ type PreviewParams struct {
 }
 
 // Preview requests a dataset preview from a remote
-func (r *RemoteMethods) Preview(p *PreviewParams, res *dataset.Dataset) error {
+func (r *RemoteMethods) Preview(p *PreviewParams, res *dataset.Dataset) (err error) {
+	defer func() { err = wrapErr(err) }()
 	if r.inst.rpc != nil {
 		return r.inst.rpc.Call("RemoteMethods.Preview", p, res)
 	}
@@ -270,3 +698,145 @@ func (r *RemoteMethods) Preview(p *PreviewParams, res *dataset.Dataset) error {
 	*res = *pre
 	return nil
 }
+
+// SyncStatusParams encapsulates parameters for comparing local dataset
+// histories with a remote
+type SyncStatusParams struct {
+	// Ref limits the comparison to a single dataset. When empty all of your
+	// published datasets are compared
+	Ref        string
+	RemoteName string
+}
+
+// SyncStatus reports whether local datasets are ahead of, behind, diverged
+// from, or in sync with a remote. Only logs are fetched from the remote, no
+// dataset blocks are transferred. Datasets that can't be compared report an
+// unknown state instead of failing the whole call
+func (r *RemoteMethods) SyncStatus(p *SyncStatusParams, res *[]remote.SyncStatus) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.inst.rpc != nil {
+		return r.inst.rpc.Call("RemoteMethods.SyncStatus", p, res)
+	}
+	ctx := context.TODO()
+
+	addr, err := remote.Address(r.inst.Config(), p.RemoteName)
+	if err != nil {
+		return err
+	}
+
+	var refs []reporef.DatasetRef
+	if p.Ref != "" {
+		ref, err := repo.ParseDatasetRef(p.Ref)
+		if err != nil {
+			return err
+		}
+		if err = repo.CanonicalizeDatasetRef(r.inst.Repo(), &ref); err != nil {
+			return err
+		}
+		refs = append(refs, ref)
+	} else {
+		pro, err := r.inst.Repo().Profile()
+		if err != nil {
+			return err
+		}
+		num, err := r.inst.Repo().RefCount()
+		if err != nil {
+			return err
+		}
+		all, err := r.inst.Repo().References(0, num)
+		if err != nil {
+			return err
+		}
+		refs = publishedRefs(ctx, r.inst.Repo().Logbook(), pro.Peername, all, addr)
+	}
+
+	statuses, err := syncStatuses(ctx, r.inst, refs, addr)
+	if err != nil {
+		return err
+	}
+	*res = statuses
+	return nil
+}
+
+// syncStatusParallelism caps how many datasets are compared with a remote at
+// once
+const syncStatusParallelism = 8
+
+// publishedRefs filters refs to the datasets of peername the logbook records
+// as published to the remote at addr. An empty addr matches datasets
+// published to any remote
+func publishedRefs(ctx context.Context, book *logbook.Book, peername string, refs []reporef.DatasetRef, addr string) []reporef.DatasetRef {
+	var published []reporef.DatasetRef
+	for _, ref := range refs {
+		if ref.Peername != peername {
+			continue
+		}
+		records, err := book.PublishRecords(ctx, reporef.ConvertToDsref(ref))
+		if err != nil {
+			log.Debugf("reading publish records of %s: %s", ref, err)
+			continue
+		}
+		for _, rec := range records {
+			if addr == "" || rec.Destination == addr {
+				published = append(published, ref)
+				break
+			}
+		}
+	}
+	return published
+}
+
+// syncStatuses compares the history of each ref with the remote at addr, up
+// to syncStatusParallelism refs at a time
+func syncStatuses(ctx context.Context, inst *Instance, refs []reporef.DatasetRef, addr string) ([]remote.SyncStatus, error) {
+	if inst.RemoteClient() == nil {
+		return nil, remote.ErrNoRemoteClient
+	}
+
+	res := make([]remote.SyncStatus, len(refs))
+	idxs := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < syncStatusParallelism && w < len(refs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idxs {
+				res[i] = remote.FetchSyncStatus(ctx, inst.RemoteClient(), inst.Repo().Logbook(), reporef.ConvertToDsref(refs[i]), addr)
+			}
+		}()
+	}
+	for i := range refs {
+		idxs <- i
+	}
+	close(idxs)
+	wg.Wait()
+
+	return res, nil
+}
+
+// remoteClientOptions configures the remote client to verify responses with
+// the keys of the RemoteKeys configuration, asking the instance trust prompt
+// about remotes that aren't configured
+func (inst *Instance) remoteClientOptions(o *remote.ClientOptions) {
+	o.TrustPrompt = inst.trustPrompt
+
+	cfg := inst.Config()
+	if cfg == nil || cfg.RemoteKeys == nil {
+		return
+	}
+	o.Keys = map[string]*config.RemoteKey{}
+	for name, k := range *cfg.RemoteKeys {
+		addr, found := "", false
+		if cfg.Remotes != nil {
+			addr, found = cfg.Remotes.Get(name)
+		}
+		if !found && name == "registry" && cfg.Registry != nil {
+			addr, found = cfg.Registry.Location, cfg.Registry.Location != ""
+		}
+		if !found {
+			log.Debugf("remote keys: no address for remote %q", name)
+			continue
+		}
+		o.Keys[addr] = k
+	}
+}
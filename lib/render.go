@@ -46,6 +46,9 @@ type RenderParams struct {
 	Dataset *dataset.Dataset
 	// Optional template override
 	Template []byte
+	// VizName renders one of the dataset's named viz templates instead of its
+	// viz script, see dsfs.VizTemplates
+	VizName string
 	// If true,
 	UseFSI bool
 	// Output format. defaults to "html"
@@ -80,13 +83,13 @@ func (r *RenderRequests) RenderViz(p *RenderParams, res *[]byte) (err error) {
 		return
 	}
 
-	if err = repo.CanonicalizeDatasetRef(r.repo, &ref); err == repo.ErrNotFound {
-		return fmt.Errorf("unknown dataset '%s'", ref.AliasString())
+	if err = repo.CanonicalizeDatasetRef(r.repo, &ref); repo.IsNotFound(err) {
+		return fmt.Errorf("unknown dataset '%s'%s", ref.AliasString(), didYouMean(err))
 	} else if err != nil {
 		return err
 	}
 
-	*res, err = base.Render(ctx, r.repo, ref, p.Template)
+	*res, err = base.Render(ctx, r.repo, ref, p.Template, p.VizName)
 	return err
 }
 
@@ -16,7 +16,6 @@ import (
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/p2p"
 	"github.com/qri-io/qri/repo"
-	reporef "github.com/qri-io/qri/repo/ref"
 	testrepo "github.com/qri-io/qri/repo/test"
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
@@ -74,6 +73,17 @@ func TestRenderRequestsRender(t *testing.T) {
 				Ref:      "me/movies",
 				Template: []byte("{{ .Meta.Title }}"),
 			}, []byte("example movie data"), ""},
+		{"template override & named template",
+			&RenderParams{
+				Ref:      "me/movies",
+				Template: []byte("{{ .Meta.Title }}"),
+				VizName:  "title",
+			}, nil, "can't render both a template & a named viz template"},
+		{"unknown named template",
+			&RenderParams{
+				Ref:     "me/movies",
+				VizName: "map",
+			}, nil, `viz template "map" not found, the dataset doesn't have any`},
 		{"override with invalid template",
 			&RenderParams{
 				Ref:      "me/movies",
@@ -164,7 +174,7 @@ func (r *renderTestRunner) Delete() {
 
 // Save saves a version of the dataset with a body
 func (r *renderTestRunner) Save(ref string, ds *dataset.Dataset, bodyPath string) {
-	dsRef := reporef.DatasetRef{}
+	dsRef := SaveResult{}
 	params := SaveParams{
 		Ref:      ref,
 		Dataset:  ds,
@@ -176,6 +186,40 @@ func (r *renderTestRunner) Save(ref string, ds *dataset.Dataset, bodyPath string
 	}
 }
 
+func TestRenderRequestsNamedVizTemplates(t *testing.T) {
+	runner := newRenderTestRunner(t, "render_named_viz")
+	defer runner.Delete()
+
+	res := SaveResult{}
+	err := runner.DatasetReqs.Save(&SaveParams{
+		Ref:          "me/movies",
+		VizTemplates: map[string]string{"title": "<h1>{{ .Meta.Title }}</h1>", "name": "{{ .Name }}"},
+	}, &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := GetResult{}
+	if err := runner.DatasetReqs.Get(&GetParams{Path: "me/movies"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.VizTemplates["title"] != "<h1>{{ .Meta.Title }}</h1>" || len(got.VizTemplates) != 2 {
+		t.Errorf("expected Get to include viz templates, got: %v", got.VizTemplates)
+	}
+
+	data := []byte{}
+	if err := runner.RenderReqs.RenderViz(&RenderParams{Ref: "me/movies", VizName: "title"}, &data); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "<h1>example movie data</h1>"; string(data) != expect {
+		t.Errorf("rendered template mismatch. expected: %q, got: %q", expect, string(data))
+	}
+	err = runner.RenderReqs.RenderViz(&RenderParams{Ref: "me/movies", VizName: "map"}, &data)
+	if expect := `viz template "map" not found, available templates: name, title`; err == nil || err.Error() != expect {
+		t.Errorf("error mismatch. expected: %q, got: %v", expect, err)
+	}
+}
+
 func TestRenderRequestsRenderViz(t *testing.T) {
 	runner := newRenderTestRunner(t, "render_viz")
 	defer runner.Delete()
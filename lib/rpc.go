@@ -0,0 +1,151 @@
+package lib
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/qri-io/qri/version"
+)
+
+const (
+	// RPCProtocolVersion numbers the shape of the params & results lib methods
+	// send over RPC. It must be bumped whenever a change would break decoding
+	// calls between releases. Protocol 2 sends save warnings as Warning
+	// values instead of strings
+	RPCProtocolVersion = 2
+	// MinRPCProtocolVersion is the oldest RPC protocol this release can talk to
+	MinRPCProtocolVersion = 2
+)
+
+// rpcHandshakeTimeout caps the time either side of an RPC connection waits
+// for the other to handshake. Releases that predate the handshake never
+// reply, so this is the time taken to fail on them
+var rpcHandshakeTimeout = time.Second * 2
+
+// RPCVersion describes the release and RPC protocol one side of an RPC
+// connection speaks
+type RPCVersion struct {
+	Version     string `json:"version"`
+	Protocol    int    `json:"protocol"`
+	MinProtocol int    `json:"minProtocol"`
+}
+
+// LocalRPCVersion is the RPCVersion of this release
+func LocalRPCVersion() RPCVersion {
+	return RPCVersion{
+		Version:     version.String,
+		Protocol:    RPCProtocolVersion,
+		MinProtocol: MinRPCProtocolVersion,
+	}
+}
+
+// compatible is true if each side speaks a protocol the other accepts
+func (v RPCVersion) compatible(other RPCVersion) bool {
+	return v.Protocol >= other.MinProtocol && other.Protocol >= v.MinProtocol
+}
+
+// RPCHandshake is the outcome of the version handshake that opens every RPC
+// connection
+type RPCHandshake struct {
+	Client     RPCVersion `json:"client"`
+	Server     RPCVersion `json:"server"`
+	Compatible bool       `json:"compatible"`
+}
+
+// Err describes an incompatible handshake, returning nil if the sides of the
+// connection are compatible
+func (h *RPCHandshake) Err() error {
+	if h.Compatible {
+		return nil
+	}
+	return fmt.Errorf("client v%s (rpc protocol %d) / server v%s (rpc protocol %d) aren't compatible, please restart qri connect", h.Client.Version, h.Client.Protocol, h.Server.Version, h.Server.Protocol)
+}
+
+// rpcConn is an RPC connection that reads through the buffer used to read
+// the handshake
+type rpcConn struct {
+	io.Reader
+	net.Conn
+}
+
+func (c rpcConn) Read(p []byte) (int, error) {
+	return c.Reader.Read(p)
+}
+
+// ClientRPCHandshake opens an RPC connection by sending this release's
+// version, and reading the server's reply. The returned connection must be
+// used for RPC calls in place of conn. Incompatible servers are an error
+func ClientRPCHandshake(conn net.Conn) (*RPCHandshake, io.ReadWriteCloser, error) {
+	local := LocalRPCVersion()
+	conn.SetDeadline(time.Now().Add(rpcHandshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	if err := writeHandshakeLine(conn, local); err != nil {
+		return nil, nil, fmt.Errorf("rpc handshake: %s", err)
+	}
+
+	r := bufio.NewReader(conn)
+	hs := &RPCHandshake{}
+	if err := readHandshakeLine(r, hs); err != nil {
+		return nil, nil, fmt.Errorf("rpc handshake: the running qri connect didn't reply with its version, it's likely from an older release. client v%s, please restart qri connect", local.Version)
+	}
+	// judge compatibility locally, the server may be newer than this client
+	hs.Client = local
+	hs.Compatible = local.compatible(hs.Server)
+	if err := hs.Err(); err != nil {
+		return hs, nil, err
+	}
+	return hs, rpcConn{Reader: r, Conn: conn}, nil
+}
+
+// ServerRPCHandshake answers the handshake of a client opening an RPC
+// connection. The returned connection must be used to serve RPC calls in
+// place of conn. Clients that are incompatible are sent the handshake before
+// ServerRPCHandshake returns an error
+func ServerRPCHandshake(conn net.Conn) (*RPCHandshake, io.ReadWriteCloser, error) {
+	conn.SetDeadline(time.Now().Add(rpcHandshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	r := bufio.NewReader(conn)
+	client := RPCVersion{}
+	if err := readHandshakeLine(r, &client); err != nil {
+		return nil, nil, fmt.Errorf("rpc handshake: reading client version: %s", err)
+	}
+
+	local := LocalRPCVersion()
+	hs := &RPCHandshake{
+		Client:     client,
+		Server:     local,
+		Compatible: local.compatible(client),
+	}
+	if err := writeHandshakeLine(conn, hs); err != nil {
+		return nil, nil, fmt.Errorf("rpc handshake: %s", err)
+	}
+	if err := hs.Err(); err != nil {
+		return hs, nil, err
+	}
+	return hs, rpcConn{Reader: r, Conn: conn}, nil
+}
+
+// handshakes are a single line of JSON, a format that's stable across
+// releases, unlike the gob encoding RPC calls use
+func writeHandshakeLine(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func readHandshakeLine(r *bufio.Reader, v interface{}) error {
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(line, v)
+}
@@ -0,0 +1,131 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base"
+)
+
+func TestRPCHandshake(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	type result struct {
+		hs  *RPCHandshake
+		err error
+	}
+	done := make(chan result)
+	go func() {
+		hs, rwc, err := ServerRPCHandshake(server)
+		if err == nil {
+			// calls after the handshake must read through to the connection
+			buf := make([]byte, 4)
+			if _, err = rwc.Read(buf); err == nil && string(buf) != "call" {
+				t.Errorf("expected server to read call after handshake, got: %q", string(buf))
+			}
+		}
+		done <- result{hs, err}
+	}()
+
+	hs, rwc, err := ClientRPCHandshake(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hs.Compatible {
+		t.Errorf("expected handshake to be compatible")
+	}
+	if hs.Server != LocalRPCVersion() {
+		t.Errorf("server version mismatch. expected: %v, got: %v", LocalRPCVersion(), hs.Server)
+	}
+	if _, err := rwc.Write([]byte("call")); err != nil {
+		t.Fatal(err)
+	}
+
+	res := <-done
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if res.hs.Client != LocalRPCVersion() {
+		t.Errorf("client version mismatch. expected: %v, got: %v", LocalRPCVersion(), res.hs.Client)
+	}
+}
+
+func TestRPCHandshakeMismatch(t *testing.T) {
+	prevTimeout := rpcHandshakeTimeout
+	rpcHandshakeTimeout = time.Millisecond * 100
+	defer func() { rpcHandshakeTimeout = prevTimeout }()
+
+	// a server from a future release that no longer speaks our protocol
+	client, server := net.Pipe()
+	go func() {
+		r := bufio.NewReader(server)
+		if _, err := r.ReadBytes('\n'); err != nil {
+			return
+		}
+		future := RPCVersion{Version: "99.0.0", Protocol: RPCProtocolVersion + 2, MinProtocol: RPCProtocolVersion + 1}
+		writeHandshakeLine(server, &RPCHandshake{Server: future})
+	}()
+	_, _, err := ClientRPCHandshake(client)
+	client.Close()
+	server.Close()
+	if err == nil {
+		t.Fatal("expected incompatible server to error")
+	}
+	expect := fmt.Sprintf("client v%s (rpc protocol %d) / server v99.0.0 (rpc protocol %d) aren't compatible, please restart qri connect", LocalRPCVersion().Version, RPCProtocolVersion, RPCProtocolVersion+2)
+	if err.Error() != expect {
+		t.Errorf("error mismatch.\nexpected: %s\ngot:      %s", expect, err)
+	}
+
+	// a server from a release before the handshake never replies
+	client, server = net.Pipe()
+	go func() {
+		r := bufio.NewReader(server)
+		r.ReadBytes('\n')
+	}()
+	_, _, err = ClientRPCHandshake(client)
+	client.Close()
+	server.Close()
+	if err == nil {
+		t.Fatal("expected silent server to error")
+	}
+	if !strings.Contains(err.Error(), "please restart qri connect") {
+		t.Errorf("expected error to ask for a restart, got: %s", err)
+	}
+}
+
+func TestSaveResultGob(t *testing.T) {
+	res := SaveResult{
+		Peername: "me",
+		Name:     "cities",
+		Path:     "/map/QmVersion",
+		Dataset:  &dataset.Dataset{Meta: &dataset.Meta{Title: "cities"}},
+		Warnings: []Warning{{Code: base.WarnSniffedFormat, Message: "body format was detected as csv", Component: "body"}},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(res); err != nil {
+		t.Fatal(err)
+	}
+	got := SaveResult{}
+	if err := gob.NewDecoder(buf).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Dataset == nil || got.Dataset.Meta == nil || got.Dataset.Meta.Title != "cities" {
+		t.Errorf("expected result to carry the dataset, got: %v", got.Dataset)
+	}
+	expect := res
+	expect.Dataset, got.Dataset = nil, nil
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,53 @@
+package lib
+
+import (
+	"sync"
+
+	"github.com/qri-io/qri/base"
+)
+
+// PreSaveHook is an alias for base.PreSaveHook, a check run before a version
+// is saved that vetoes the save by returning an error
+type PreSaveHook = base.PreSaveHook
+
+// PostSaveHook is an alias for base.PostSaveHook, called with the reference
+// of every newly saved version
+type PostSaveHook = base.PostSaveHook
+
+// saveHooks holds the hooks registered on an instance. The zero value is
+// ready to use
+type saveHooks struct {
+	lk   sync.Mutex
+	pre  []PreSaveHook
+	post []PostSaveHook
+}
+
+// RegisterPreSaveHook adds a hook run before every save made by the instance,
+// after changes are applied to the previous version & validated. Hooks run in
+// the order they're registered, the first to return an error stops the save
+func (inst *Instance) RegisterPreSaveHook(hook PreSaveHook) {
+	inst.saveHooks.lk.Lock()
+	defer inst.saveHooks.lk.Unlock()
+	inst.saveHooks.pre = append(inst.saveHooks.pre, hook)
+}
+
+// RegisterPostSaveHook adds a hook called with the reference of every version
+// the instance saves. Dry runs don't call post-save hooks
+func (inst *Instance) RegisterPostSaveHook(hook PostSaveHook) {
+	inst.saveHooks.lk.Lock()
+	defer inst.saveHooks.lk.Unlock()
+	inst.saveHooks.post = append(inst.saveHooks.post, hook)
+}
+
+// withSaveHooks adds registered hooks to save switches. Saves made without an
+// instance don't have hooks
+func (inst *Instance) withSaveHooks(sw base.SaveDatasetSwitches) base.SaveDatasetSwitches {
+	if inst == nil {
+		return sw
+	}
+	inst.saveHooks.lk.Lock()
+	defer inst.saveHooks.lk.Unlock()
+	sw.PreSaveHooks = append(append([]PreSaveHook{}, inst.saveHooks.pre...), sw.PreSaveHooks...)
+	sw.PostSaveHooks = append(append([]PostSaveHook{}, inst.saveHooks.post...), sw.PostSaveHooks...)
+	return sw
+}
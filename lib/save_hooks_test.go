@@ -0,0 +1,47 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestSaveHooks(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+	inst := tr.Instance
+
+	errNoDescription := errors.New("meta description is required")
+	var saved []reporef.DatasetRef
+	inst.RegisterPreSaveHook(func(ctx context.Context, ds *dataset.Dataset) error {
+		if ds.Meta == nil || ds.Meta.Description == "" {
+			return errNoDescription
+		}
+		return nil
+	})
+	inst.RegisterPostSaveHook(func(ctx context.Context, ref reporef.DatasetRef) {
+		saved = append(saved, ref)
+	})
+
+	req := NewDatasetRequestsInstance(inst)
+	bodyPath := tr.writeFile(t, "body.json", "[1,2,3]")
+	p := &SaveParams{Ref: "me/governed", BodyPath: bodyPath}
+	res := &SaveResult{}
+	if err := req.Save(p, res); !errors.Is(err, errNoDescription) {
+		t.Errorf("expected a pre-save hook to veto the save, got: %v", err)
+	}
+	if len(saved) != 0 {
+		t.Errorf("expected a vetoed save not to call post-save hooks, got: %v", saved)
+	}
+
+	p.Dataset = &dataset.Dataset{Meta: &dataset.Meta{Description: "governed data"}}
+	if err := req.Save(p, res); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].Path != res.Path || saved[0].Name != "governed" {
+		t.Errorf("expected post-save hook to get the saved ref %s, got: %v", res.Path, saved)
+	}
+}
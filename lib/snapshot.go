@@ -0,0 +1,33 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/qri-io/qri/base"
+)
+
+// ErrSnapshotOverRPC means a snapshot was requested of an instance connected
+// to another process. Snapshots stream through the repo directly, shut down
+// the running node or snapshot from its process
+var ErrSnapshotOverRPC = fmt.Errorf("repo snapshots can't be streamed over RPC")
+
+// SnapshotRepo writes an archive of the entire repo to w: every stored
+// version of each dataset with its components & body, the logbook, and the
+// profile. RestoreRepo reconstructs the repo from the archive on another
+// node. Private keys aren't included, back up the config alongside
+func (m *RepoMethods) SnapshotRepo(w io.Writer) error {
+	if m.inst.rpc != nil {
+		return ErrSnapshotOverRPC
+	}
+	return base.SnapshotRepo(context.TODO(), m.inst.Repo(), w)
+}
+
+// RestoreRepo reads an archive written by SnapshotRepo into the repo
+func (m *RepoMethods) RestoreRepo(r io.Reader) (*base.RestoreReport, error) {
+	if m.inst.rpc != nil {
+		return nil, ErrSnapshotOverRPC
+	}
+	return base.RestoreRepo(context.TODO(), m.inst.Repo(), r)
+}
@@ -0,0 +1,51 @@
+package lib
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestSnapshotRestoreRepo(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	bodyPath := tr.writeFile(t, "body.csv", "city,pop\ntoronto,40000000\n")
+	if err := req.Save(&SaveParams{Ref: "me/backed_up", BodyPath: bodyPath}, &SaveResult{}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := NewRepoMethods(tr.Instance).SnapshotRepo(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst := NewInstanceFromConfigAndNode(config.DefaultConfigForTesting(), node)
+	report, err := NewRepoMethods(inst).RestoreRepo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Datasets == 0 || report.Versions == 0 {
+		t.Errorf("expected datasets to be restored, got: %#v", report)
+	}
+
+	got := GetResult{}
+	if err := NewDatasetRequestsInstance(inst).Get(&GetParams{Path: "me/backed_up", Selector: "body", Format: "csv", All: true}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "city,pop\ntoronto,40000000\n"; string(got.Bytes) != expect {
+		t.Errorf("restored body mismatch. expected: %q, got: %q", expect, got.Bytes)
+	}
+}
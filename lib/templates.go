@@ -0,0 +1,143 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/config"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// TemplateInfo describes a dataset template
+type TemplateInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// ListTemplates lists the dataset templates set in config, sorted by name
+func (r *DatasetRequests) ListTemplates(in *bool, res *[]TemplateInfo) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.ListTemplates", in, res)
+	}
+
+	infos := []TemplateInfo{}
+	if cfg := r.inst.Config(); cfg != nil && cfg.Templates != nil {
+		for name, tmpl := range *cfg.Templates {
+			if tmpl == nil {
+				continue
+			}
+			infos = append(infos, TemplateInfo{Name: name, Description: tmpl.Description})
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	*res = infos
+	return nil
+}
+
+// NewFromTemplateParams encapsulates arguments to NewFromTemplate
+type NewFromTemplateParams struct {
+	// Template is the name of the dataset template to start from
+	Template string
+	// Ref is the reference to create the dataset at
+	Ref string
+}
+
+// NewFromTemplate creates a new dataset, saving a first version made from
+// a dataset template
+func (r *DatasetRequests) NewFromTemplate(p *NewFromTemplateParams, res *reporef.DatasetRef) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.NewFromTemplate", p, res)
+	}
+	if p.Template == "" {
+		return fmt.Errorf("template name is required")
+	}
+
+	ds, err := datasetTemplate(r.inst, p.Template)
+	if err != nil {
+		return err
+	}
+	// versions need a body, start with an empty one. templates without a
+	// structure start as an empty json array
+	if ds.Structure == nil {
+		ds.Structure = &dataset.Structure{
+			Format: dataset.JSONDataFormat.String(),
+			Schema: dataset.BaseSchemaArray,
+		}
+	}
+	ds.BodyBytes = emptyBody(ds.Structure)
+
+	save := &SaveParams{
+		Ref:     p.Ref,
+		Dataset: ds,
+		Title:   fmt.Sprintf("created from template %s", p.Template),
+		NewName: true,
+	}
+	saved := &SaveResult{}
+	if err = r.Save(save, saved); err != nil {
+		return err
+	}
+	*res = saved.Ref()
+	return nil
+}
+
+// datasetTemplate builds the dataset a named template describes, it's an
+// error to name a template that isn't set in config
+func datasetTemplate(inst *Instance, name string) (*dataset.Dataset, error) {
+	var tmpl *config.Template
+	if inst != nil {
+		if cfg := inst.Config(); cfg != nil && cfg.Templates != nil {
+			tmpl, _ = cfg.Templates.Get(name)
+		}
+	}
+	if tmpl == nil {
+		return nil, kindError{kind: ErrNotFound, err: fmt.Errorf("dataset template %q not found", name)}
+	}
+	return templateDataset(tmpl)
+}
+
+// templateDataset converts a config template to a dataset
+func templateDataset(tmpl *config.Template) (*dataset.Dataset, error) {
+	comps := map[string]interface{}{}
+	if tmpl.Meta != nil {
+		comps["meta"] = tmpl.Meta
+	}
+	if tmpl.Structure != nil {
+		comps["structure"] = tmpl.Structure
+	}
+	data, err := json.Marshal(comps)
+	if err != nil {
+		return nil, err
+	}
+	ds := &dataset.Dataset{}
+	if err = json.Unmarshal(data, ds); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+
+	if tmpl.Transform != "" {
+		script, err := ioutil.ReadFile(tmpl.Transform)
+		if err != nil {
+			return nil, fmt.Errorf("reading template transform: %w", err)
+		}
+		ds.Transform = &dataset.Transform{
+			Syntax:      "starlark",
+			ScriptBytes: script,
+		}
+	}
+	return ds, nil
+}
+
+// emptyBody gives the bytes of an empty body in a structure's format
+func emptyBody(st *dataset.Structure) []byte {
+	if st.Format != dataset.JSONDataFormat.String() {
+		return []byte{}
+	}
+	if st.Schema != nil && st.Schema["type"] == "object" {
+		return []byte("{}")
+	}
+	return []byte("[]")
+}
@@ -0,0 +1,148 @@
+package lib
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/fsi"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestDatasetTemplates(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	script := "def transform(ds, ctx):\n  return ds\n"
+	scriptPath := filepath.Join(tr.Dir, "transform.star")
+	if err := ioutil.WriteFile(scriptPath, []byte(script), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	tr.Instance.cfg.Templates = &config.Templates{
+		"survey": &config.Template{
+			Description: "survey responses",
+			Meta: map[string]interface{}{
+				"keywords": []interface{}{"survey"},
+				"license":  map[string]interface{}{"type": "CC-BY-4.0"},
+			},
+			Structure: map[string]interface{}{
+				"format":       "csv",
+				"formatConfig": map[string]interface{}{"headerRow": true},
+				"schema": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "array",
+						"items": []interface{}{
+							map[string]interface{}{"title": "respondent", "type": "string"},
+							map[string]interface{}{"title": "answer", "type": "string"},
+						},
+					},
+				},
+			},
+		},
+		"scratch": &config.Template{
+			Meta:      map[string]interface{}{"title": "scratch data"},
+			Transform: scriptPath,
+		},
+	}
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+
+	infos := []TemplateInfo{}
+	if err := req.ListTemplates(nil, &infos); err != nil {
+		t.Fatal(err)
+	}
+	expectInfos := []TemplateInfo{
+		{Name: "scratch"},
+		{Name: "survey", Description: "survey responses"},
+	}
+	if !reflect.DeepEqual(expectInfos, infos) {
+		t.Errorf("template list mismatch. expected: %v, got: %v", expectInfos, infos)
+	}
+
+	ref := reporef.DatasetRef{}
+	err := req.NewFromTemplate(&NewFromTemplateParams{Template: "missing", Ref: "me/missing"}, &ref)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a missing template to be a not found error, got: %v", err)
+	}
+
+	if err := req.NewFromTemplate(&NewFromTemplateParams{Template: "survey", Ref: "me/poll"}, &ref); err != nil {
+		t.Fatal(err)
+	}
+	ds := ref.Dataset
+	if ds.Meta == nil || ds.Meta.License == nil || ds.Meta.License.Type != "CC-BY-4.0" {
+		t.Errorf("expected new dataset to have the template license, got meta: %v", ds.Meta)
+	}
+	if ds.Structure == nil || ds.Structure.Format != "csv" {
+		t.Errorf("expected new dataset to have the template structure, got: %v", ds.Structure)
+	}
+
+	if err := req.NewFromTemplate(&NewFromTemplateParams{Template: "scratch", Ref: "me/scratch"}, &ref); err != nil {
+		t.Fatal(err)
+	}
+	if ref.Dataset.Structure == nil || ref.Dataset.Structure.Format != "json" {
+		t.Errorf("expected templates without a structure to default to json, got: %v", ref.Dataset.Structure)
+	}
+	if ref.Dataset.Transform == nil {
+		t.Errorf("expected new dataset to have the template transform")
+	}
+
+	// other save params override the template
+	res := &SaveResult{}
+	save := &SaveParams{
+		Ref:      "me/override",
+		Template: "survey",
+		Dataset:  &dataset.Dataset{Meta: &dataset.Meta{Title: "answers", Keywords: []string{"poll"}}},
+		BodyPath: filepath.Join(tr.Dir, "body.csv"),
+	}
+	if err := ioutil.WriteFile(save.BodyPath, []byte("respondent,answer\na,yes\n"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := req.Save(save, res); err != nil {
+		t.Fatal(err)
+	}
+	if md := res.Dataset.Meta; md == nil || md.Title != "answers" || md.Keywords[0] != "poll" || md.License == nil {
+		t.Errorf("expected saved meta to be the template meta with params applied, got: %v", md)
+	}
+
+	// the test repo logbook outlives the test, use a unique name
+	name := "survey_" + filepath.Base(tr.Dir)
+	fsim := NewFSIMethods(tr.Instance)
+	initp := &InitFSIDatasetParams{
+		Name:     name,
+		Dir:      tr.Dir,
+		Mkdir:    name,
+		Template: "survey",
+	}
+	var alias string
+	if err := fsim.InitDataset(initp, &alias); err != nil {
+		t.Fatal(err)
+	}
+	if initp.Scaffold != nil {
+		t.Errorf("expected init not to modify params")
+	}
+	linked, err := fsi.ReadDir(filepath.Join(tr.Dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if linked.Meta == nil || linked.Meta.License == nil || linked.Meta.License.Type != "CC-BY-4.0" {
+		t.Errorf("expected working directory meta to come from the template, got: %v", linked.Meta)
+	}
+	if linked.Structure == nil || linked.Structure.Format != "csv" {
+		t.Errorf("expected working directory structure to come from the template, got: %v", linked.Structure)
+	}
+	body, err := ioutil.ReadFile(filepath.Join(tr.Dir, name, "body.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), "one,two") {
+		t.Errorf("expected template body to start without example rows, got: %q", body)
+	}
+}
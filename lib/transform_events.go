@@ -0,0 +1,66 @@
+package lib
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/qri-io/qri/event"
+)
+
+// transformEventWriter publishes transform script output to the event bus
+type transformEventWriter struct {
+	bus  event.Publisher
+	base event.TransformEvent
+
+	lk  sync.Mutex
+	seq int
+}
+
+func newTransformEventWriter(bus event.Publisher, runID, username, dsname string) *transformEventWriter {
+	return &transformEventWriter{
+		bus: bus,
+		base: event.TransformEvent{
+			RunID:    runID,
+			Username: username,
+			Dsname:   dsname,
+		},
+	}
+}
+
+// Write implements io.Writer. Transform scripts write each print call in one
+// write, so each write is published as a print event
+func (w *transformEventWriter) Write(p []byte) (int, error) {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	w.publish(event.TransformPrint, strings.TrimSuffix(string(p), "\n"), "", "")
+	return len(p), nil
+}
+
+// started announces a transform is about to run
+func (w *transformEventWriter) started() {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	w.publish(event.TransformStarted, "", "", "")
+}
+
+// finish publishes the outcome of the run
+func (w *transformEventWriter) finish(path string, err error) {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	if err != nil {
+		w.publish(event.TransformFailed, "", "", err.Error())
+		return
+	}
+	w.publish(event.TransformFinished, "", path, "")
+}
+
+func (w *transformEventWriter) publish(action, msg, path, errMsg string) {
+	e := w.base
+	e.Seq = w.seq
+	w.seq++
+	e.Action = action
+	e.Msg = msg
+	e.Path = path
+	e.Error = errMsg
+	w.bus.Publish(event.ETTransformEvent, e)
+}
@@ -0,0 +1,98 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/event"
+)
+
+type recordingPublisher struct {
+	events []event.TransformEvent
+}
+
+func (p *recordingPublisher) Publish(t event.Topic, data interface{}) {
+	p.events = append(p.events, data.(event.TransformEvent))
+}
+
+func TestTransformEventWriter(t *testing.T) {
+	pub := &recordingPublisher{}
+	w := newTransformEventWriter(pub, "run_1", "peer", "tf")
+	w.started()
+	w.Write([]byte("hello"))
+	w.Write([]byte("running transform...\n"))
+	w.finish("", fmt.Errorf("oh noes"))
+
+	expect := []event.TransformEvent{
+		{Action: event.TransformStarted, Seq: 0},
+		{Action: event.TransformPrint, Seq: 1, Msg: "hello"},
+		{Action: event.TransformPrint, Seq: 2, Msg: "running transform..."},
+		{Action: event.TransformFailed, Seq: 3, Error: "oh noes"},
+	}
+	for i := range expect {
+		expect[i].RunID, expect[i].Username, expect[i].Dsname = "run_1", "peer", "tf"
+	}
+	if diff := cmp.Diff(expect, pub.events); diff != "" {
+		t.Errorf("events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSaveTransformEvents(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "save_transform_events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	script := filepath.Join(tmp, "transform.star")
+	data := []byte("def transform(ds, ctx):\n  print(\"one\")\n  print(\"two\")\n  ds.set_body([1, 2])\n")
+	if err := ioutil.WriteFile(script, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	inst := NewInstanceFromConfigAndNode(config.DefaultConfigForTesting(), newTestQriNode(t))
+	events := inst.Bus().Subscribe(event.ETTransformEvent)
+	defer inst.Bus().Unsubscribe(events)
+
+	res := &SaveResult{}
+	p := &SaveParams{Ref: "me/tf_events", FilePaths: []string{script}, TransformRunID: "run_1"}
+	if err := NewDatasetRequestsInstance(inst).Save(p, res); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []event.TransformEvent
+	for len(got) == 0 || got[len(got)-1].Action != event.TransformFinished {
+		select {
+		case e := <-events:
+			got = append(got, e.Payload.(event.TransformEvent))
+			sort.Slice(got, func(i, j int) bool { return got[i].Seq < got[j].Seq })
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for transform events, got: %v", got)
+		}
+	}
+
+	prints := []string{}
+	for _, e := range got {
+		if e.RunID != "run_1" || e.Dsname != "tf_events" {
+			t.Errorf("expected events to identify the run, got: %#v", e)
+		}
+		if e.Action == event.TransformPrint && (e.Msg == "one" || e.Msg == "two") {
+			prints = append(prints, e.Msg)
+		}
+	}
+	if got[0].Action != event.TransformStarted {
+		t.Errorf("expected the first event to be started, got: %#v", got[0])
+	}
+	if diff := cmp.Diff([]string{"one", "two"}, prints); diff != "" {
+		t.Errorf("printed output mismatch (-want +got):\n%s", diff)
+	}
+	if last := got[len(got)-1]; last.Path != res.Path {
+		t.Errorf("expected finished event to have the saved path %q, got: %q", res.Path, last.Path)
+	}
+}
@@ -0,0 +1,156 @@
+package lib
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// trashSweepInterval is how often an instance permanently deletes datasets
+// that have been in the trash longer than the retention period
+const trashSweepInterval = time.Hour
+
+// TrashMethods encapsulates business logic for datasets removed to the trash
+type TrashMethods struct {
+	inst *Instance
+}
+
+// NewTrashMethods creates TrashMethods from a qri Instance
+func NewTrashMethods(inst *Instance) *TrashMethods {
+	return &TrashMethods{inst: inst}
+}
+
+// CoreRequestsName implements the Methods interface
+func (m TrashMethods) CoreRequestsName() string { return "trash" }
+
+// TrashListParams configures listing the trash
+type TrashListParams struct{}
+
+// List shows datasets in the trash, most recently removed first
+func (m *TrashMethods) List(p *TrashListParams, res *[]repo.TrashEntry) error {
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("TrashMethods.List", p, res)
+	}
+
+	trash := base.TrashOf(m.inst.Repo())
+	if trash == nil {
+		return base.ErrNoTrash
+	}
+	*res = trash.List()
+	return nil
+}
+
+// TrashRestoreParams configures restoring a dataset from the trash
+type TrashRestoreParams struct {
+	Ref string
+}
+
+// Restore brings a dataset back from the trash, keeping its published flag.
+// Datasets that were linked to a working directory are relinked if the
+// directory still exists
+func (m *TrashMethods) Restore(p *TrashRestoreParams, res *reporef.DatasetRef) error {
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("TrashMethods.Restore", p, res)
+	}
+	ctx := context.TODO()
+	defer m.inst.lockWrites()()
+
+	ref, err := repo.ParseDatasetRef(p.Ref)
+	if err != nil {
+		return invalidRefError(p.Ref)
+	}
+	if err = repo.CanonicalizeProfile(m.inst.Repo(), &ref); err != nil {
+		return err
+	}
+
+	e, err := base.RestoreTrashed(ctx, m.inst.Repo(), ref)
+	if err != nil {
+		return err
+	}
+	*res = e.Ref
+
+	if dir := e.Ref.FSIPath; dir != "" && m.inst.fsi != nil {
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			if _, _, err := m.inst.fsi.CreateLink(dir, e.Ref.AliasString()); err != nil {
+				log.Debugf("Restore, relinking %s failed, error: %s", dir, err)
+				res.FSIPath = ""
+			}
+		} else {
+			res.FSIPath = ""
+		}
+	}
+	return nil
+}
+
+// TrashEmptyParams configures emptying the trash
+type TrashEmptyParams struct {
+	// All deletes everything in the trash, instead of only datasets removed
+	// before the retention period
+	All bool
+}
+
+// Empty permanently deletes datasets in the trash, unpinning their versions
+func (m *TrashMethods) Empty(p *TrashEmptyParams, res *[]repo.TrashEntry) error {
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("TrashMethods.Empty", p, res)
+	}
+	ctx := context.TODO()
+	defer m.inst.lockWrites()()
+
+	cutoff := time.Now()
+	if retention := m.inst.trashRetention(); !p.All && retention > 0 {
+		cutoff = cutoff.Add(-retention)
+	}
+
+	deleted, err := base.EmptyTrash(ctx, m.inst.Repo(), cutoff)
+	*res = deleted
+	return err
+}
+
+// trashRetention is how long removed datasets stay in the trash. A negative
+// retention disables the trash
+func (inst *Instance) trashRetention() time.Duration {
+	if inst == nil {
+		return repo.DefaultTrashRetention
+	}
+	cfg := inst.Config()
+	if cfg == nil || cfg.Repo == nil || cfg.Repo.TrashRetentionHours == 0 {
+		return repo.DefaultTrashRetention
+	}
+	return time.Duration(cfg.Repo.TrashRetentionHours) * time.Hour
+}
+
+// sweepTrash permanently deletes datasets that have been in the trash longer
+// than the retention period, checking every interval until ctx is cancelled
+func (inst *Instance) sweepTrash(ctx context.Context, interval time.Duration) {
+	sweep := func() {
+		retention := inst.trashRetention()
+		if retention < 0 {
+			return
+		}
+		defer inst.lockWrites()()
+		deleted, err := base.EmptyTrash(ctx, inst.Repo(), time.Now().Add(-retention))
+		if err != nil {
+			log.Errorf("sweeping trash: %s", err)
+		}
+		for _, e := range deleted {
+			log.Debugf("permanently deleted %s from the trash", e.Ref.AliasString())
+		}
+	}
+
+	sweep()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			sweep()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
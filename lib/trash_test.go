@@ -0,0 +1,119 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestTrashMethods(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	inst := NewInstanceFromConfigAndNode(config.DefaultConfigForTesting(), node)
+	dsr := NewDatasetRequestsInstance(inst)
+	m := NewTrashMethods(inst)
+	allRevs := dsref.Rev{Field: "ds", Gen: dsref.AllGenerations}
+
+	movies, err := mr.GetRef(reporef.DatasetRef{Peername: "peer", Name: "movies"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	movies.Published = true
+	if err := mr.PutRef(movies); err != nil {
+		t.Fatal(err)
+	}
+
+	res := RemoveResponse{}
+	if err := dsr.Remove(&RemoveParams{Ref: "me/movies", Revision: allRevs}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.Trashed {
+		t.Error("expected removing all versions to move the dataset to the trash")
+	}
+
+	infos := []dsref.VersionInfo{}
+	if err := dsr.List(&ListParams{Peername: "me", Limit: 100}, &infos); err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range infos {
+		if info.Name == "movies" {
+			t.Error("expected trashed dataset to be hidden from list by default")
+		}
+	}
+	infos = []dsref.VersionInfo{}
+	if err := dsr.List(&ListParams{Peername: "me", Limit: 100, ShowTrashed: true}, &infos); err != nil {
+		t.Fatal(err)
+	}
+	trashed := 0
+	for _, info := range infos {
+		if info.Trashed {
+			trashed++
+			if info.Name != "movies" {
+				t.Errorf("unexpected trashed dataset: %s", info.Name)
+			}
+		}
+	}
+	if trashed != 1 {
+		t.Errorf("expected 1 trashed dataset listed, got: %d", trashed)
+	}
+
+	entries := []repo.TrashEntry{}
+	if err := m.List(&TrashListParams{}, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 trash entry, got: %d", len(entries))
+	}
+
+	restored := reporef.DatasetRef{}
+	if err := m.Restore(&TrashRestoreParams{Ref: "me/movies"}, &restored); err != nil {
+		t.Fatal(err)
+	}
+	got, err := mr.GetRef(reporef.DatasetRef{Peername: "peer", Name: "movies"})
+	if err != nil {
+		t.Fatalf("expected restored dataset reference, got: %s", err)
+	}
+	if got.Path != movies.Path || !got.Published {
+		t.Errorf("expected restored reference to match the removed one, got: %v", got)
+	}
+
+	// permanent removal skips the trash
+	res = RemoveResponse{}
+	if err := dsr.Remove(&RemoveParams{Ref: "me/cities", Revision: allRevs, Permanent: true}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Trashed {
+		t.Error("expected permanent removal to skip the trash")
+	}
+
+	if err := dsr.Remove(&RemoveParams{Ref: "me/movies", Revision: allRevs}, &res); err != nil {
+		t.Fatal(err)
+	}
+	// recently trashed datasets are kept unless the whole trash is emptied
+	if err := m.Empty(&TrashEmptyParams{}, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected nothing to be deleted within the retention period, got: %d", len(entries))
+	}
+	if err := m.Empty(&TrashEmptyParams{All: true}, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected 1 deleted dataset, got: %d", len(entries))
+	}
+	if err := m.Restore(&TrashRestoreParams{Ref: "me/movies"}, &restored); err == nil {
+		t.Error("expected restoring an emptied dataset to error")
+	}
+}
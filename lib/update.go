@@ -46,8 +46,15 @@ type ScheduleParams struct {
 
 	// SaveParams only applies to dataset saves
 	SaveParams *SaveParams
+	// FailurePolicy configures retrying failed runs and notification of runs
+	// that still fail. optional
+	FailurePolicy *FailurePolicy
 }
 
+// FailurePolicy aliases cron.FailurePolicy, configuring how an update
+// recovers from failed runs
+type FailurePolicy = cron.FailurePolicy
+
 // Schedule creates a job and adds it to the scheduler
 func (m *UpdateMethods) Schedule(in *ScheduleParams, out *cron.Job) (err error) {
 	// this context is scoped to the scheduling request. currently not cancellable
@@ -77,6 +84,7 @@ func (m *UpdateMethods) Schedule(in *ScheduleParams, out *cron.Job) (err error)
 	if err != nil {
 		return err
 	}
+	job.FailurePolicy = in.FailurePolicy
 
 	if m.inst.cron == nil {
 		return fmt.Errorf("update service not available")
@@ -211,6 +219,41 @@ func (m *UpdateMethods) LogFile(logName *string, data *[]byte) error {
 	return err
 }
 
+// RunningUpdate aliases cron.RunningJob, describing an update that's
+// currently executing
+type RunningUpdate = cron.RunningJob
+
+// ListRunning lists updates that are currently executing, oldest first
+func (m *UpdateMethods) ListRunning(in *bool, res *[]RunningUpdate) error {
+	if m.inst.cron == nil {
+		return fmt.Errorf("update service not available")
+	}
+	running, err := m.inst.cron.ListRunning(context.Background())
+	if err != nil {
+		return err
+	}
+
+	*res = running
+	return nil
+}
+
+// CancelUpdate stops a running update by its RunningUpdate.ID. The update is
+// logged as cancelled
+func (m *UpdateMethods) CancelUpdate(id *string, cancelled *bool) error {
+	if m.inst.cron == nil {
+		return fmt.Errorf("update service not available")
+	}
+	if *id == "" {
+		return fmt.Errorf("id of the update to cancel is required")
+	}
+	if err := m.inst.cron.Cancel(context.Background(), *id); err != nil {
+		return err
+	}
+
+	*cancelled = true
+	return nil
+}
+
 // ServiceStatus describes the current state of a service
 type ServiceStatus struct {
 	Name       string
@@ -279,9 +322,19 @@ func (m *UpdateMethods) ServiceRestart(in, out *bool) error {
 	return fmt.Errorf("not finished")
 }
 
+// RunParams configures running an update immediately
+type RunParams struct {
+	Job
+	// DryRun runs a dataset update against an in-memory repo, returning the
+	// resulting dataset without saving it, publishing it or recording the run
+	DryRun bool
+}
+
 // Run advances a dataset to the latest known version from either a peer or by
-// re-running a transform in the peer's namespace
-func (m *UpdateMethods) Run(p *Job, res *reporef.DatasetRef) (err error) {
+// re-running a transform in the peer's namespace. Runs follow the failure
+// policy of the job, or the policy of the scheduled update with the same name
+// if the job doesn't have one
+func (m *UpdateMethods) Run(p *RunParams, res *reporef.DatasetRef) (err error) {
 	// Make all paths absolute. this must happen *before* any possible RPC call
 	if update.PossibleShellScript(p.Name) {
 		if err = qfs.AbsPath(&p.Name); err != nil {
@@ -292,7 +345,7 @@ func (m *UpdateMethods) Run(p *Job, res *reporef.DatasetRef) (err error) {
 		p.Type = cron.JTDataset
 	}
 
-	if err := absolutizeJobFilepaths(p); err != nil {
+	if err := absolutizeJobFilepaths(&p.Job); err != nil {
 		return err
 	}
 
@@ -301,6 +354,37 @@ func (m *UpdateMethods) Run(p *Job, res *reporef.DatasetRef) (err error) {
 	}
 	ctx := context.TODO()
 
+	if p.DryRun && p.Type != cron.JTDataset {
+		return fmt.Errorf("dry runs only apply to dataset updates")
+	}
+
+	policy := p.FailurePolicy
+	if policy == nil && !p.DryRun && m.inst.cron != nil {
+		if scheduled, err := m.inst.cron.Job(ctx, p.Name); err == nil {
+			policy = scheduled.FailurePolicy
+		}
+	}
+
+	err = policy.Run(ctx, func() error {
+		return m.runJob(ctx, p, res)
+	})
+	if err != nil {
+		failed := p.Job
+		failed.RunError = err.Error()
+		if nerr := policy.Notify(ctx, &failed); nerr != nil {
+			log.Errorf("notifying of failed update %s: %s", p.Name, nerr)
+		}
+		return err
+	}
+
+	if p.RunError == "" && !p.DryRun {
+		err = m.inst.Repo().Logbook().WriteCronJobRan(ctx, p.RunNumber, reporef.ConvertToDsref(*res))
+	}
+	return err
+}
+
+// runJob makes a single attempt at running an update
+func (m *UpdateMethods) runJob(ctx context.Context, p *RunParams, res *reporef.DatasetRef) error {
 	switch p.Type {
 	case cron.JTDataset:
 		params := &SaveParams{
@@ -325,25 +409,17 @@ func (m *UpdateMethods) Run(p *Job, res *reporef.DatasetRef) (err error) {
 				// Config: o.Config
 			}
 		}
+		if p.DryRun {
+			params.DryRun = true
+			params.Publish = false
+		}
 		*res = reporef.DatasetRef{}
-		err = m.runDatasetUpdate(ctx, params, res)
-
+		return m.runDatasetUpdate(ctx, params, res)
 	case cron.JTShellScript:
-		err = update.JobToCmd(m.inst.streams, p).Run()
-	case cron.JobType(""):
-		return fmt.Errorf("update requires a job type to run")
+		return update.JobToCmd(m.inst.streams, &p.Job).Run()
 	default:
 		return fmt.Errorf("unrecognized update type: %s", p.Type)
 	}
-
-	if err != nil {
-		return err
-	}
-
-	if p.RunError == "" {
-		err = m.inst.Repo().Logbook().WriteCronJobRan(ctx, p.RunNumber, reporef.ConvertToDsref(*res))
-	}
-	return err
 }
 
 func absolutizeJobFilepaths(j *Job) error {
@@ -366,8 +442,8 @@ func (m *UpdateMethods) runDatasetUpdate(ctx context.Context, p *SaveParams, res
 		return err
 	}
 
-	if err = repo.CanonicalizeDatasetRef(m.inst.node.Repo, &ref); err == repo.ErrNotFound {
-		return fmt.Errorf("unknown dataset '%s'. please add before updating", ref.AliasString())
+	if err = repo.CanonicalizeDatasetRef(m.inst.node.Repo, &ref); repo.IsNotFound(err) {
+		return fmt.Errorf("unknown dataset '%s'. please add before updating%s", ref.AliasString(), didYouMean(err))
 	} else if err != nil {
 		return err
 	}
@@ -383,6 +459,10 @@ func (m *UpdateMethods) runDatasetUpdate(ctx context.Context, p *SaveParams, res
 		p.Recall = "tf"
 	}
 
-	dsr := NewDatasetRequestsInstance(m.inst)
-	return dsr.Save(p, res)
+	saved := &SaveResult{}
+	if err = NewDatasetRequestsInstance(m.inst).Save(p, saved); err != nil {
+		return err
+	}
+	*res = saved.Ref()
+	return nil
 }
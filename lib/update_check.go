@@ -0,0 +1,316 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/event"
+	"github.com/qri-io/qri/remote"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// updateCheckSpacing is the least time between two update check requests to
+// the same remote
+var updateCheckSpacing = 250 * time.Millisecond
+
+// UpdateCheck is the outcome of checking a dataset added from another peer
+// for versions newer than the one in the local repo
+type UpdateCheck struct {
+	Ref    string `json:"ref"`
+	Remote string `json:"remote,omitempty"`
+	// LocalHead is the latest local version, RemoteHead the latest version the
+	// remote knows of
+	LocalHead  string `json:"localHead,omitempty"`
+	RemoteHead string `json:"remoteHead,omitempty"`
+	// UpdatesAvailable is true if the remote has a version the local history
+	// doesn't
+	UpdatesAvailable bool `json:"updatesAvailable"`
+	// Checked is when the remote last answered for this dataset
+	Checked time.Time `json:"checked,omitempty"`
+	// Error describes why the latest check failed, like an unreachable remote.
+	// A failed check keeps the result of the last one that succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// updateChecks holds the latest update check of each dataset added from
+// another peer, keyed by alias. The zero value is ready to use
+type updateChecks struct {
+	// runLk keeps check passes from overlapping
+	runLk sync.Mutex
+
+	lk     sync.Mutex
+	checks map[string]UpdateCheck
+	// unreachable remotes are only reported once, until they answer again
+	unreachable map[string]bool
+	// last is when each remote was last sent a request
+	last map[string]time.Time
+}
+
+func (u *updateChecks) get(alias string) (UpdateCheck, bool) {
+	u.lk.Lock()
+	defer u.lk.Unlock()
+	c, ok := u.checks[alias]
+	return c, ok
+}
+
+func (u *updateChecks) put(c UpdateCheck) {
+	u.lk.Lock()
+	defer u.lk.Unlock()
+	if u.checks == nil {
+		u.checks = map[string]UpdateCheck{}
+	}
+	u.checks[c.Ref] = c
+}
+
+func (u *updateChecks) list() []UpdateCheck {
+	u.lk.Lock()
+	defer u.lk.Unlock()
+	res := make([]UpdateCheck, 0, len(u.checks))
+	for _, c := range u.checks {
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Ref < res[j].Ref })
+	return res
+}
+
+// setReachable records whether a remote answered, returning true if that's a
+// change from what was recorded before
+func (u *updateChecks) setReachable(addr string, reachable bool) bool {
+	u.lk.Lock()
+	defer u.lk.Unlock()
+	if u.unreachable == nil {
+		u.unreachable = map[string]bool{}
+	}
+	changed := u.unreachable[addr] == reachable
+	u.unreachable[addr] = !reachable
+	return changed
+}
+
+// wait blocks until a request to a remote is allowed by updateCheckSpacing
+func (u *updateChecks) wait(ctx context.Context, addr string) error {
+	u.lk.Lock()
+	if u.last == nil {
+		u.last = map[string]time.Time{}
+	}
+	next := u.last[addr].Add(updateCheckSpacing)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	u.last[addr] = next
+	u.lk.Unlock()
+
+	select {
+	case <-time.After(time.Until(next)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CheckUpdatesParams configures checking datasets added from other peers for
+// new versions
+type CheckUpdatesParams struct {
+	// Refresh asks the remote now, instead of reporting the results of the
+	// latest background check
+	Refresh bool
+}
+
+// CheckUpdates reports which datasets added from other peers have versions
+// newer than the ones in the local repo. Results come from the latest check,
+// checks run in the background every Repo.UpdateCheckMinutes when configured.
+// Datasets are checked now if Refresh is set, or no check has run yet
+func (r *RemoteMethods) CheckUpdates(p *CheckUpdatesParams, res *[]UpdateCheck) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.inst.rpc != nil {
+		return r.inst.rpc.Call("RemoteMethods.CheckUpdates", p, res)
+	}
+	ctx := context.TODO()
+
+	if p.Refresh || len(r.inst.updates.list()) == 0 {
+		if err = r.inst.checkUpdates(ctx); err != nil {
+			return err
+		}
+	}
+	*res = r.inst.updates.list()
+	return nil
+}
+
+// runUpdateChecks checks datasets added from other peers for new versions
+// every interval, until ctx is cancelled
+func (inst *Instance) runUpdateChecks(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := inst.checkUpdates(ctx); err != nil {
+			log.Debugf("checking for updates: %s", err)
+		}
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkUpdates asks the update check remote for the head of every dataset in
+// the repo that belongs to another peer. Once the remote fails to answer, the
+// datasets left are skipped until the next check
+func (inst *Instance) checkUpdates(ctx context.Context) error {
+	inst.updates.runLk.Lock()
+	defer inst.updates.runLk.Unlock()
+
+	cli := inst.RemoteClient()
+	if cli == nil {
+		return remote.ErrNoRemoteClient
+	}
+	cfg := inst.Config()
+	remoteName := ""
+	if cfg.Repo != nil {
+		remoteName = cfg.Repo.UpdateCheckRemote
+	}
+	addr, err := remote.Address(cfg, remoteName)
+	if err != nil {
+		return err
+	}
+
+	refs, err := inst.foreignRefs()
+	if err != nil {
+		return err
+	}
+
+	unreachable := ""
+	for _, ref := range refs {
+		prev, _ := inst.updates.get(ref.AliasString())
+		c := prev
+		c.Ref = ref.AliasString()
+		c.Remote = addr
+		c.LocalHead = ref.Path
+
+		if unreachable != "" {
+			c.Error = unreachable
+			inst.updates.put(c)
+			continue
+		}
+		if err := inst.updates.wait(ctx, addr); err != nil {
+			return err
+		}
+
+		head := &reporef.DatasetRef{Peername: ref.Peername, Name: ref.Name}
+		if err := cli.ResolveHeadRef(ctx, head, addr); err != nil {
+			if code := remote.ErrorCodeOf(err); code != remote.ErrCodeNotFound && code != remote.ErrCodeNotAuthorized {
+				unreachable = fmt.Sprintf("remote unreachable: %s", err)
+				if inst.updates.setReachable(addr, false) {
+					log.Infof("update checks can't reach remote %s, skipping until the next check: %s", addr, err)
+				}
+			}
+			c.Error = err.Error()
+			if unreachable != "" {
+				c.Error = unreachable
+			}
+			inst.updates.put(c)
+			continue
+		}
+		if inst.updates.setReachable(addr, true) {
+			log.Infof("update checks reached remote %s again", addr)
+		}
+
+		c.RemoteHead = head.Path
+		c.UpdatesAvailable = inst.isUpdate(ctx, ref, head.Path)
+		c.Checked = time.Now()
+		c.Error = ""
+		inst.updates.put(c)
+
+		if c.UpdatesAvailable && (!prev.UpdatesAvailable || prev.RemoteHead != c.RemoteHead) {
+			inst.updateAvailableEvent(ctx, event.UpdateAvailableEvent{
+				Username:   ref.Peername,
+				Dsname:     ref.Name,
+				LocalHead:  c.LocalHead,
+				RemoteHead: c.RemoteHead,
+				Remote:     addr,
+			})
+		}
+	}
+	return nil
+}
+
+// foreignRefs lists the refs of datasets that belong to other peers
+func (inst *Instance) foreignRefs() ([]reporef.DatasetRef, error) {
+	r := inst.Repo()
+	pro, err := r.Profile()
+	if err != nil {
+		return nil, err
+	}
+	num, err := r.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	all, err := r.References(0, num)
+	if err != nil {
+		return nil, err
+	}
+	var refs []reporef.DatasetRef
+	for _, ref := range all {
+		if ref.Peername != pro.Peername && ref.Path != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs, nil
+}
+
+// isUpdate checks if a remote head is missing from the local history of a
+// dataset. Without a local logbook history only the local head is known
+func (inst *Instance) isUpdate(ctx context.Context, ref reporef.DatasetRef, remoteHead string) bool {
+	if remoteHead == "" || remoteHead == ref.Path {
+		return false
+	}
+	local := []dsref.VersionInfo{{Path: ref.Path}}
+	if book := inst.Repo().Logbook(); book != nil {
+		if versions, err := book.Versions(ctx, reporef.ConvertToDsref(ref), 0, -1); err == nil && len(versions) > 0 {
+			local = versions
+		}
+	}
+	return remote.CompareHead(local, remoteHead).State == remote.SyncStateUnknown
+}
+
+// updateAvailableEvent publishes an update to the event bus, & sends it to
+// the update check webhook when one is configured
+func (inst *Instance) updateAvailableEvent(ctx context.Context, e event.UpdateAvailableEvent) {
+	if inst.bus != nil {
+		inst.bus.Publish(event.ETUpdateAvailableEvent, e)
+	}
+	if cfg := inst.Config(); cfg.Repo != nil && cfg.Repo.UpdateCheckWebhook != "" {
+		if err := postUpdateWebhook(ctx, cfg.Repo.UpdateCheckWebhook, e); err != nil {
+			log.Errorf("sending update webhook: %s", err)
+		}
+	}
+}
+
+func postUpdateWebhook(ctx context.Context, url string, e event.UpdateAvailableEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%s responded with status %d", url, res.StatusCode)
+	}
+	return nil
+}
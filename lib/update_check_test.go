@@ -0,0 +1,158 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/event"
+	"github.com/qri-io/qri/remote"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// headClient is a remote client that only resolves dataset heads
+type headClient struct {
+	remote.Client
+	heads map[string]string
+	err   error
+	calls int
+}
+
+func (c *headClient) ResolveHeadRef(ctx context.Context, ref *reporef.DatasetRef, remoteAddr string) error {
+	c.calls++
+	if c.err != nil {
+		return c.err
+	}
+	path, ok := c.heads[ref.AliasString()]
+	if !ok {
+		return remote.NewError(remote.ErrCodeNotFound, "not found")
+	}
+	ref.Path = path
+	return nil
+}
+
+func TestCheckUpdates(t *testing.T) {
+	prevSpacing := updateCheckSpacing
+	updateCheckSpacing = 0
+	defer func() { updateCheckSpacing = prevSpacing }()
+
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+	inst := tr.Instance
+
+	var (
+		lk    sync.Mutex
+		hooks []event.UpdateAvailableEvent
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e := event.UpdateAvailableEvent{}
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Error(err)
+		}
+		lk.Lock()
+		hooks = append(hooks, e)
+		lk.Unlock()
+	}))
+	defer server.Close()
+
+	inst.cfg.Repo.UpdateCheckRemote = "origin"
+	inst.cfg.Repo.UpdateCheckWebhook = server.URL
+	inst.cfg.Remotes = &config.Remotes{"origin": "https://origin.example.com"}
+
+	mr := inst.Repo()
+	for _, name := range []string{"cities", "movies"} {
+		ref, err := mr.GetRef(reporef.DatasetRef{Peername: "peer", Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := mr.PutRef(reporef.DatasetRef{Peername: "other", ProfileID: ref.ProfileID, Name: name, Path: ref.Path}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	movies, err := mr.GetRef(reporef.DatasetRef{Peername: "other", Name: "movies"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := &headClient{heads: map[string]string{
+		"other/cities": "/map/QmNewerCities",
+		"other/movies": movies.Path,
+	}}
+	inst.remoteClient = cli
+	events := inst.Bus().Subscribe(event.ETUpdateAvailableEvent)
+
+	m := NewRemoteMethods(inst)
+	res := []UpdateCheck{}
+	if err := m.CheckUpdates(&CheckUpdatesParams{Refresh: true}, &res); err != nil {
+		t.Fatal(err)
+	}
+	available := map[string]bool{}
+	for _, c := range res {
+		if c.Error != "" {
+			t.Errorf("%s: unexpected error: %s", c.Ref, c.Error)
+		}
+		available[c.Ref] = c.UpdatesAvailable
+	}
+	if !available["other/cities"] || available["other/movies"] || len(available) != 2 {
+		t.Errorf("expected only other/cities to have updates, got: %v", available)
+	}
+
+	select {
+	case e := <-events:
+		if p := e.Payload.(event.UpdateAvailableEvent); p.Dsname != "cities" || p.RemoteHead != "/map/QmNewerCities" {
+			t.Errorf("event mismatch, got: %#v", p)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected an update available event")
+	}
+	lk.Lock()
+	if len(hooks) != 1 || hooks[0].Dsname != "cities" {
+		t.Errorf("expected one webhook for cities, got: %#v", hooks)
+	}
+	lk.Unlock()
+
+	infos := []dsref.VersionInfo{}
+	if err := NewDatasetRequestsInstance(inst).List(&ListParams{Limit: 100}, &infos); err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range infos {
+		if expect := info.Alias() == "other/cities"; info.UpdatesAvailable != expect {
+			t.Errorf("%s: expected updates available to be %t", info.Alias(), expect)
+		}
+	}
+
+	// a check that finds the same update doesn't notify again
+	if err := m.CheckUpdates(&CheckUpdatesParams{Refresh: true}, &res); err != nil {
+		t.Fatal(err)
+	}
+	lk.Lock()
+	if len(hooks) != 1 {
+		t.Errorf("expected an update to only be sent once, got %d webhooks", len(hooks))
+	}
+	lk.Unlock()
+
+	// an unreachable remote is asked once per check, keeping earlier results
+	cli.err = fmt.Errorf("connection refused")
+	cli.calls = 0
+	if err := m.CheckUpdates(&CheckUpdatesParams{Refresh: true}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if cli.calls != 1 {
+		t.Errorf("expected one request to an unreachable remote, got: %d", cli.calls)
+	}
+	for _, c := range res {
+		if !strings.HasPrefix(c.Error, "remote unreachable") {
+			t.Errorf("%s: expected an unreachable error, got: %q", c.Ref, c.Error)
+		}
+		if c.Ref == "other/cities" && !c.UpdatesAvailable {
+			t.Error("expected a failed check to keep the earlier result")
+		}
+	}
+}
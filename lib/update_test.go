@@ -2,13 +2,17 @@ package lib
 
 import (
 	"context"
+	"encoding/json"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/ioes"
+	"github.com/qri-io/iso8601"
 	"github.com/qri-io/qri/config"
 	reporef "github.com/qri-io/qri/repo/ref"
 	"github.com/qri-io/qri/update/cron"
@@ -106,6 +110,22 @@ func TestUpdateMethods(t *testing.T) {
 		t.Error(err)
 	}
 
+	in := false
+	running := []RunningUpdate{}
+	if err := m.ListRunning(&in, &running); err != nil {
+		t.Fatal(err)
+	}
+	if len(running) != 0 {
+		t.Errorf("expected no running updates once the service stops, got: %d", len(running))
+	}
+	id := ""
+	if err := m.CancelUpdate(&id, &fin); err == nil {
+		t.Error("expected cancelling without an id to error")
+	}
+	id = "1-not_running"
+	if err := m.CancelUpdate(&id, &fin); err != cron.ErrNotRunning {
+		t.Errorf("expected cancelling an update that isn't running to return ErrNotRunning, got: %v", err)
+	}
 }
 
 func TestUpdateServiceStart(t *testing.T) {
@@ -135,13 +155,13 @@ func TestDatasetMethodsRun(t *testing.T) {
 
 	m := NewUpdateMethods(inst)
 	res := &reporef.DatasetRef{}
-	if err := m.Run(&Job{Name: "me/bad_dataset", Type: cron.JTDataset}, res); err == nil {
+	if err := m.Run(&RunParams{Job: Job{Name: "me/bad_dataset", Type: cron.JTDataset}}, res); err == nil {
 		t.Error("expected update to nonexistent dataset to error")
 	}
 
 	ref := addNowTransformDataset(t, node)
 	res = &reporef.DatasetRef{}
-	if err := m.Run(&Job{Name: ref.AliasString(), Type: cron.JTDataset /* Recall: "tf", ReturnBody: true */}, res); err != nil {
+	if err := m.Run(&RunParams{Job: Job{Name: ref.AliasString(), Type: cron.JTDataset /* Recall: "tf", ReturnBody: true */}}, res); err != nil {
 		t.Errorf("update error: %s", err)
 	}
 
@@ -154,16 +174,91 @@ func TestDatasetMethodsRun(t *testing.T) {
 
 	dsm := NewDatasetRequests(inst.node, nil)
 	// run a manual save to lose the transform
+	saved := &SaveResult{}
 	err := dsm.Save(&SaveParams{
 		Ref:       res.AliasString(),
 		FilePaths: []string{metaPath},
-	}, res)
+	}, saved)
 	if err != nil {
 		t.Error(err)
 	}
+	*res = saved.Ref()
 
 	// update should grab the transform from 2 commits back
-	if err := m.Run(&Job{Name: res.AliasString(), Type: cron.JTDataset /* ReturnBody: true */}, res); err != nil {
+	if err := m.Run(&RunParams{Job: Job{Name: res.AliasString(), Type: cron.JTDataset /* ReturnBody: true */}}, res); err != nil {
 		t.Error(err)
 	}
 }
+
+func TestUpdateRunDryRun(t *testing.T) {
+	node := newTestQriNode(t)
+	inst := &Instance{node: node}
+	m := NewUpdateMethods(inst)
+
+	ref := addCitiesDataset(t, node)
+	res := &reporef.DatasetRef{}
+	job := Job{Name: ref.AliasString(), Options: &cron.DatasetOptions{Force: true}}
+	if err := m.Run(&RunParams{Job: job, DryRun: true}, res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Dataset == nil || res.Dataset.Commit == nil {
+		t.Fatal("expected dry run to return the resulting dataset")
+	}
+	if res.Path == ref.Path {
+		t.Errorf("expected dry run to produce a new version")
+	}
+
+	head, err := node.Repo.GetRef(reporef.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Path != ref.Path {
+		t.Errorf("expected dry run not to change the dataset head. expected: %s, got: %s", ref.Path, head.Path)
+	}
+
+	if err := m.Run(&RunParams{Job: Job{Name: "testdata/hello.sh"}, DryRun: true}, res); err == nil {
+		t.Error("expected a dry run of a shell script update to error")
+	}
+}
+
+func TestUpdateRunFailurePolicy(t *testing.T) {
+	node := newTestQriNode(t)
+	sched := cron.NewCron(&cron.MemJobStore{}, &cron.MemJobStore{}, nil)
+	inst := &Instance{node: node, cron: sched}
+	m := NewUpdateMethods(inst)
+
+	notified := []*Job{}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		job := &Job{}
+		if err := json.NewDecoder(r.Body).Decode(job); err != nil {
+			t.Error(err)
+		}
+		notified = append(notified, job)
+	}))
+	defer s.Close()
+
+	p, err := iso8601.ParseRepeatingInterval("R/P1D")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduled := &Job{
+		Name:          "me/bad_dataset",
+		Type:          cron.JTDataset,
+		Periodicity:   p,
+		FailurePolicy: &FailurePolicy{Retries: 1, Backoff: time.Millisecond, NotifyURL: s.URL},
+	}
+	if err := sched.Schedule(context.Background(), scheduled); err != nil {
+		t.Fatal(err)
+	}
+
+	res := &reporef.DatasetRef{}
+	if err := m.Run(&RunParams{Job: Job{Name: "me/bad_dataset"}}, res); err == nil {
+		t.Fatal("expected update to nonexistent dataset to error")
+	}
+	if len(notified) != 1 {
+		t.Fatalf("expected failed update to notify the scheduled update's webhook once, got: %d", len(notified))
+	}
+	if notified[0].Name != "me/bad_dataset" || notified[0].RunError == "" {
+		t.Errorf("expected notification to describe the failed update, got: %v", notified[0])
+	}
+}
@@ -0,0 +1,117 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+
+	crypto "github.com/libp2p/go-libp2p-core/crypto"
+	peer "github.com/libp2p/go-libp2p-core/peer"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// SignatureVerification is an alias for base.SignatureVerification, the
+// outcome of checking a version's commit signature against its author's key
+type SignatureVerification = base.SignatureVerification
+
+// VerifyParams configures a commit signature check
+type VerifyParams struct {
+	// Ref is the dataset version to verify, the latest version if the
+	// reference has no path
+	Ref string
+	// All verifies every locally stored version in the dataset's history
+	// instead of a single version
+	All bool
+}
+
+// Verify checks commit signatures against the public keys of their authors,
+// reporting each version as verified, signed by an unknown key, or invalid.
+// Invalid signatures are reported, not returned as errors
+func (r *DatasetRequests) Verify(p *VerifyParams, res *[]SignatureVerification) (err error) {
+	defer func() { err = wrapErr(err) }()
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Verify", p, res)
+	}
+	ctx := context.TODO()
+
+	ref, err := repo.ParseDatasetRef(p.Ref)
+	if err != nil {
+		return err
+	}
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
+		return err
+	}
+
+	paths := []string{ref.Path}
+	if p.All {
+		versions, err := base.DatasetLog(ctx, r.node.Repo, ref, -1, 0, false)
+		if err != nil {
+			return err
+		}
+		paths = paths[:0]
+		for _, v := range versions {
+			if v.Path != "" && !v.Foreign {
+				paths = append(paths, v.Path)
+			}
+		}
+	}
+
+	results := make([]SignatureVerification, 0, len(paths))
+	for _, path := range paths {
+		ds, err := dsfs.LoadDataset(ctx, r.node.Repo.Store(), path)
+		if err != nil {
+			return fmt.Errorf("loading %s: %w", path, err)
+		}
+		results = append(results, *verifyVersion(ctx, r.node, ref, ds))
+	}
+	*res = results
+	return nil
+}
+
+// verifyVersion checks the commit signature of a loaded version of ref,
+// logging a warning when it's invalid
+func verifyVersion(ctx context.Context, node *p2p.QriNode, ref reporef.DatasetRef, ds *dataset.Dataset) *SignatureVerification {
+	// stored versions don't record who they belong to, ref does. Verify a
+	// copy to leave the loaded version as stored
+	cpy := *ds
+	if cpy.ProfileID == "" && ref.ProfileID != "" {
+		cpy.ProfileID = ref.ProfileID.String()
+	}
+	v := base.VerifySignature(ctx, &cpy, nodeAuthorKey(node))
+	if v.Status == base.SignatureInvalid {
+		log.Warningf("%s: invalid commit signature on version %s: %s", ref.AliasString(), ds.Path, v.Reason)
+	}
+	return v
+}
+
+// nodeAuthorKey finds the public key of a dataset author from our own keys,
+// the author ID itself, or the keys of peers the profile store links to the
+// author
+func nodeAuthorKey(node *p2p.QriNode) base.AuthorKeyFunc {
+	return func(ctx context.Context, id profile.ID) (crypto.PubKey, error) {
+		if pro, err := node.Repo.Profile(); err == nil && pro.ID == id && node.Repo.PrivateKey() != nil {
+			return node.Repo.PrivateKey().GetPublic(), nil
+		}
+		if pub, err := peer.ID(id).ExtractPublicKey(); err == nil && pub != nil {
+			return pub, nil
+		}
+		if pids, err := node.Repo.Profiles().PeerIDs(id); err == nil {
+			for _, pid := range pids {
+				if pub, err := pid.ExtractPublicKey(); err == nil && pub != nil {
+					return pub, nil
+				}
+				if node.Host() != nil {
+					if pub := node.Keys().PubKey(pid); pub != nil {
+						return pub, nil
+					}
+				}
+			}
+		}
+		return nil, fmt.Errorf("no key found for profile")
+	}
+}
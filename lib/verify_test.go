@@ -0,0 +1,84 @@
+package lib
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/dsfs"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestDatasetRequestsVerify(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+	inst := tr.Instance
+	req := NewDatasetRequestsInstance(inst)
+
+	res := []SignatureVerification{}
+	if err := req.Verify(&VerifyParams{Ref: "peer/movies"}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Status != base.SignatureVerified {
+		t.Fatalf("expected the latest version to verify, got: %#v", res)
+	}
+
+	entries := []HistoryEntry{}
+	if err := NewLogRequestsInstance(inst).History(&HistoryParams{Ref: "peer/movies"}, &entries); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Signature == nil || e.Signature.Status != base.SignatureVerified {
+			t.Errorf("expected history version %s to verify, got: %#v", e.Path, e.Signature)
+		}
+	}
+
+	// a version signed with a key that isn't the author's
+	ctx := context.Background()
+	r := inst.Repo()
+	pro, err := r.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongKey, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := &dataset.Dataset{
+		Commit:    &dataset.Commit{Title: "forged"},
+		Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray},
+	}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte("[1,2,3]")))
+	path, err := dsfs.CreateDataset(ctx, r.Store(), ds, nil, wrongKey, true, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forgedRef := reporef.DatasetRef{Peername: pro.Peername, ProfileID: pro.ID, Name: "forged", Path: path}
+	if err := r.PutRef(forgedRef); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := req.Verify(&VerifyParams{Ref: "peer/forged"}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Status != base.SignatureInvalid {
+		t.Errorf("expected a forged signature to be invalid, got: %#v", res)
+	}
+
+	got := &GetResult{}
+	if err := req.Get(&GetParams{Path: "peer/forged"}, got); err != nil {
+		t.Fatalf("expected get to report an invalid signature without failing, got: %s", err)
+	}
+	if got.Signature == nil || got.Signature.Status != base.SignatureInvalid {
+		t.Errorf("expected get to report an invalid signature, got: %#v", got.Signature)
+	}
+	err = req.Get(&GetParams{Path: "peer/forged", StrictSignatures: true}, got)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected a strict get to fail with ErrInvalidSignature, got: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package logbook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook/oplog"
+)
+
+// CustomOp is an operation recording an event from outside of qri, like a
+// review or approval of a dataset. Custom operations are synced with the rest
+// of a dataset's history, but don't affect versions unless a handler for the
+// operation type is registered
+type CustomOp struct {
+	// Type is the user-defined kind of operation, eg: "approved"
+	Type string `json:"type"`
+	// Path is the latest version of the dataset when the op was written
+	Path      string                 `json:"path,omitempty"`
+	AuthorID  string                 `json:"authorID,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+	Payload   map[string]interface{} `json:"payload,omitempty"`
+}
+
+// CustomOpHandler applies a custom operation while reconstructing dataset
+// versions. versions are ordered oldest first, handlers return the versions
+// with the operation applied
+type CustomOpHandler func(versions []dsref.VersionInfo, op CustomOp) []dsref.VersionInfo
+
+var (
+	customOpHandlersLk sync.RWMutex
+	customOpHandlers   = map[string]CustomOpHandler{}
+)
+
+// RegisterCustomOpHandler sets the handler for custom operations of opType,
+// replacing any previously registered handler. Passing a nil handler
+// unregisters opType
+func RegisterCustomOpHandler(opType string, handler CustomOpHandler) {
+	customOpHandlersLk.Lock()
+	defer customOpHandlersLk.Unlock()
+	if handler == nil {
+		delete(customOpHandlers, opType)
+		return
+	}
+	customOpHandlers[opType] = handler
+}
+
+func customOpHandler(opType string) CustomOpHandler {
+	customOpHandlersLk.RLock()
+	defer customOpHandlersLk.RUnlock()
+	return customOpHandlers[opType]
+}
+
+// WriteCustomOp adds a custom operation of opType to a dataset log. payload
+// must be serializable as JSON
+func (book *Book) WriteCustomOp(ctx context.Context, ref dsref.Ref, opType string, payload map[string]interface{}) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	if opType == "" {
+		return fmt.Errorf("logbook: custom operation type is required")
+	}
+	log.Debugf("WriteCustomOp: %s, type: %s", ref, opType)
+
+	l, err := book.BranchRef(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	note := ""
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("logbook: encoding custom operation payload: %w", err)
+		}
+		note = string(data)
+	}
+
+	path := ""
+	if vs := Versions(l, ref, 0, 1); len(vs) > 0 {
+		path = vs[0].Path
+	}
+
+	l.Append(oplog.Op{
+		Type:      oplog.OpTypeInit,
+		Model:     CustomModel,
+		Ref:       path,
+		Name:      opType,
+		AuthorID:  book.AuthorID(),
+		Timestamp: NewTimestamp(),
+		Note:      note,
+	})
+
+	return book.save(ctx)
+}
+
+// CustomOps lists the custom operations of a dataset log, oldest first. An
+// empty opType lists operations of every type
+func (book *Book) CustomOps(ctx context.Context, ref dsref.Ref, opType string) ([]CustomOp, error) {
+	if book == nil {
+		return nil, ErrNoLogbook
+	}
+
+	l, err := book.BranchRef(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := []CustomOp{}
+	for _, op := range l.Ops {
+		if op.Model != CustomModel || (opType != "" && op.Name != opType) {
+			continue
+		}
+		cop, err := customOpFromOp(op)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, cop)
+	}
+	return ops, nil
+}
+
+func customOpFromOp(op oplog.Op) (CustomOp, error) {
+	cop := CustomOp{
+		Type:      op.Name,
+		Path:      op.Ref,
+		AuthorID:  op.AuthorID,
+		Timestamp: time.Unix(0, op.Timestamp),
+	}
+	if op.Note != "" {
+		if err := json.Unmarshal([]byte(op.Note), &cop.Payload); err != nil {
+			return cop, fmt.Errorf("logbook: decoding %q operation payload: %w", op.Name, err)
+		}
+	}
+	return cop, nil
+}
+
+// applyCustomOp runs the registered handler for a custom operation, if any
+func applyCustomOp(versions []dsref.VersionInfo, op oplog.Op) []dsref.VersionInfo {
+	handler := customOpHandler(op.Name)
+	if handler == nil {
+		return versions
+	}
+	cop, err := customOpFromOp(op)
+	if err != nil {
+		log.Errorf("skipping custom operation: %s", err)
+		return versions
+	}
+	return handler(versions, cop)
+}
@@ -56,6 +56,12 @@ const (
 	ACLModel
 	// CronJobModel is the enum for a cron-job model
 	CronJobModel
+	// ForkModel is the enum for a fork model
+	ForkModel
+	// LabelModel is the enum for a version label model
+	LabelModel
+	// CustomModel is the enum for operations recording external events
+	CustomModel
 )
 
 // DefaultBranchName is the default name all branch-level logbook data is read
@@ -80,6 +86,12 @@ func ModelString(m uint32) string {
 		return "acl"
 	case CronJobModel:
 		return "cronJob"
+	case ForkModel:
+		return "fork"
+	case LabelModel:
+		return "label"
+	case CustomModel:
+		return "custom"
 	default:
 		return ""
 	}
@@ -546,6 +558,122 @@ func (book *Book) WriteUnpublish(ctx context.Context, ref dsref.Ref, revisions i
 	return book.save(ctx)
 }
 
+// PublishedDestinations lists the destinations the latest version of a
+// dataset is published to, in the order PublishRecords lists them. Committing
+// a new version clears the list, an unpublish without destinations clears it
+// too
+func (book *Book) PublishedDestinations(ctx context.Context, ref dsref.Ref) ([]string, error) {
+	if book == nil {
+		return nil, ErrNoLogbook
+	}
+
+	l, err := book.BranchRef(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var dests []string
+	for _, s := range publishStates(l) {
+		if s.latest {
+			dests = append(dests, s.Destination)
+		}
+	}
+	return dests, nil
+}
+
+// PublishRecord is a destination a dataset has been published to, with the
+// number of revisions that were published there
+type PublishRecord struct {
+	Destination string `json:"destination"`
+	Revisions   int    `json:"revisions"`
+}
+
+// PublishRecords lists every destination a dataset has been published to in
+// the order they were first published to, with the number of revisions each
+// still holds. Unlike PublishedDestinations, committing a new version doesn't
+// clear the list. An unpublish takes its revisions off the destinations it
+// names, or off all of them when it names none
+func (book *Book) PublishRecords(ctx context.Context, ref dsref.Ref) ([]PublishRecord, error) {
+	if book == nil {
+		return nil, ErrNoLogbook
+	}
+
+	l, err := book.BranchRef(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var published []PublishRecord
+	for _, s := range publishStates(l) {
+		if s.Revisions > 0 {
+			published = append(published, s.PublishRecord)
+		}
+	}
+	return published, nil
+}
+
+// publishState is a publish record that notes if the destination holds the
+// latest version
+type publishState struct {
+	PublishRecord
+	latest bool
+}
+
+// publishStates replays the publication ops of a branch log, giving a state
+// for every destination ever published to in the order they were first
+// published to
+func publishStates(l *oplog.Log) []publishState {
+	var states []publishState
+	unpublish := func(i int, revisions int64) {
+		if states[i].Revisions -= int(revisions); states[i].Revisions < 0 {
+			states[i].Revisions = 0
+		}
+		states[i].latest = false
+	}
+	for _, op := range l.Ops {
+		switch op.Model {
+		case CommitModel:
+			for i := range states {
+				states[i].latest = false
+			}
+		case PublicationModel:
+			switch op.Type {
+			case oplog.OpTypeInit:
+				for _, d := range op.Relations {
+					i := publishStateIndex(states, d)
+					if i < 0 {
+						states = append(states, publishState{PublishRecord: PublishRecord{Destination: d}})
+						i = len(states) - 1
+					}
+					states[i].Revisions += int(op.Size)
+					states[i].latest = true
+				}
+			case oplog.OpTypeRemove:
+				if len(op.Relations) == 0 {
+					for i := range states {
+						unpublish(i, op.Size)
+					}
+				}
+				for _, d := range op.Relations {
+					if i := publishStateIndex(states, d); i >= 0 {
+						unpublish(i, op.Size)
+					}
+				}
+			}
+		}
+	}
+	return states
+}
+
+func publishStateIndex(states []publishState, dest string) int {
+	for i, s := range states {
+		if s.Destination == dest {
+			return i
+		}
+	}
+	return -1
+}
+
 // WriteCronJobRan adds an operation to a log marking the execution of a cronjob
 func (book *Book) WriteCronJobRan(ctx context.Context, number int64, ref dsref.Ref) error {
 	if book == nil {
@@ -568,6 +696,107 @@ func (book *Book) WriteCronJobRan(ctx context.Context, number int64, ref dsref.R
 	return book.save(ctx)
 }
 
+// WriteDatasetFork adds an operation to a log recording that a dataset was
+// forked from a version of another dataset. source should include the
+// original author's username, profileID, and the version path. Forks are
+// written after initializing a name, before the first version is saved
+func (book *Book) WriteDatasetFork(ctx context.Context, ref, source dsref.Ref) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteDatasetFork: %s, source: %s", ref, source)
+
+	l, err := book.BranchRef(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	l.Append(oplog.Op{
+		Type:      oplog.OpTypeInit,
+		Model:     ForkModel,
+		Ref:       source.Path,
+		Name:      source.Alias(),
+		AuthorID:  source.ProfileID,
+		Timestamp: NewTimestamp(),
+	})
+
+	return book.save(ctx)
+}
+
+// WriteVersionLabel adds an operation to a log attaching a label to the
+// version at path
+func (book *Book) WriteVersionLabel(ctx context.Context, ref dsref.Ref, path, label string) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteVersionLabel: %s, path: %s, label: %s", ref, path, label)
+
+	l, err := book.BranchRef(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	l.Append(oplog.Op{
+		Type:      oplog.OpTypeInit,
+		Model:     LabelModel,
+		Ref:       path,
+		Name:      label,
+		Timestamp: NewTimestamp(),
+	})
+
+	return book.save(ctx)
+}
+
+// WriteVersionUnlabel adds an operation to a log detaching a label from
+// whichever version it's attached to
+func (book *Book) WriteVersionUnlabel(ctx context.Context, ref dsref.Ref, label string) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteVersionUnlabel: %s, label: %s", ref, label)
+
+	l, err := book.BranchRef(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	l.Append(oplog.Op{
+		Type:      oplog.OpTypeRemove,
+		Model:     LabelModel,
+		Name:      label,
+		Timestamp: NewTimestamp(),
+	})
+
+	return book.save(ctx)
+}
+
+// ForkSource returns the dataset version a dataset was forked from. The
+// returned reference is empty if the dataset isn't a fork
+func (book *Book) ForkSource(ctx context.Context, ref dsref.Ref) (dsref.Ref, error) {
+	if book == nil {
+		return dsref.Ref{}, ErrNoLogbook
+	}
+
+	l, err := book.BranchRef(ctx, ref)
+	if err != nil {
+		return dsref.Ref{}, err
+	}
+
+	// the most recent fork wins
+	src := dsref.Ref{}
+	for _, op := range l.Ops {
+		if op.Model == ForkModel && op.Type == oplog.OpTypeInit {
+			src = dsref.Ref{ProfileID: op.AuthorID, Path: op.Ref}
+			if i := strings.Index(op.Name, "/"); i >= 0 {
+				src.Username, src.Name = op.Name[:i], op.Name[i+1:]
+			} else {
+				src.Name = op.Name
+			}
+		}
+	}
+	return src, nil
+}
+
 // Observe saves a function which listens for changes
 func (book *Book) Observe(listener func(*Action)) {
 	book.listener = listener
@@ -831,6 +1060,21 @@ func Versions(l *oplog.Log, ref dsref.Ref, offset, limit int) []dsref.VersionInf
 					refs[len(refs)-i].Published = false
 				}
 			}
+		case LabelModel:
+			switch op.Type {
+			case oplog.OpTypeInit:
+				for i := range refs {
+					if refs[i].Path == op.Ref {
+						refs[i].Labels = append(refs[i].Labels, op.Name)
+					}
+				}
+			case oplog.OpTypeRemove:
+				for i := range refs {
+					refs[i].Labels = removeLabel(refs[i].Labels, op.Name)
+				}
+			}
+		case CustomModel:
+			refs = applyCustomOp(refs, op)
 		}
 	}
 
@@ -853,6 +1097,15 @@ func Versions(l *oplog.Log, ref dsref.Ref, offset, limit int) []dsref.VersionInf
 	return refs
 }
 
+func removeLabel(labels []string, label string) []string {
+	for i, l := range labels {
+		if l == label {
+			return append(labels[:i:i], labels[i+1:]...)
+		}
+	}
+	return labels
+}
+
 // LogEntry is a simplified representation of a log operation
 type LogEntry struct {
 	Timestamp time.Time
@@ -897,17 +1150,24 @@ var actionStrings = map[uint32][3]string{
 	PublicationModel: [3]string{"publish", "", "unpublish"},
 	ACLModel:         [3]string{"update access", "update access", "remove all access"},
 	CronJobModel:     [3]string{"ran update", "", ""},
+	ForkModel:        [3]string{"fork", "", ""},
+	LabelModel:       [3]string{"label version", "", "remove label"},
+	CustomModel:      [3]string{"custom", "", ""},
 }
 
 func logEntryFromOp(author string, op oplog.Op) LogEntry {
+	action := actionStrings[op.Model][int(op.Type)-1]
 	note := op.Note
-	if note == "" && op.Name != "" {
+	if op.Model == CustomModel {
+		// custom ops are described by their type, noting the payload
+		action = op.Name
+	} else if note == "" && op.Name != "" {
 		note = op.Name
 	}
 	return LogEntry{
 		Timestamp: time.Unix(0, op.Timestamp),
 		Author:    author,
-		Action:    actionStrings[op.Model][int(op.Type)-1],
+		Action:    action,
 		Note:      note,
 	}
 }
@@ -214,6 +214,18 @@ func TestNilCallable(t *testing.T) {
 	if err = book.WriteDatasetDelete(ctx, dsref.Ref{}); err != ErrNoLogbook {
 		t.Errorf("expected '%s', got: %v", ErrNoLogbook, err)
 	}
+	if err = book.WriteDatasetFork(ctx, dsref.Ref{}, dsref.Ref{}); err != ErrNoLogbook {
+		t.Errorf("expected '%s', got: %v", ErrNoLogbook, err)
+	}
+	if err = book.WriteCustomOp(ctx, dsref.Ref{}, "", nil); err != ErrNoLogbook {
+		t.Errorf("expected '%s', got: %v", ErrNoLogbook, err)
+	}
+	if _, err = book.CustomOps(ctx, dsref.Ref{}, ""); err != ErrNoLogbook {
+		t.Errorf("expected '%s', got: %v", ErrNoLogbook, err)
+	}
+	if _, err = book.ForkSource(ctx, dsref.Ref{}); err != ErrNoLogbook {
+		t.Errorf("expected '%s', got: %v", ErrNoLogbook, err)
+	}
 	if err = book.WritePublish(ctx, dsref.Ref{}, 0); err != ErrNoLogbook {
 		t.Errorf("expected '%s', got: %v", ErrNoLogbook, err)
 	}
@@ -657,6 +669,46 @@ func TestRenameDataset(t *testing.T) {
 	}
 }
 
+func TestDatasetFork(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	tr.WriteWorldBankExample(t)
+	source := dsref.Ref{Username: "b5", ProfileID: "QmSourceProfileID", Name: "world_bank_population", Path: "QmHashOfSourceVersion"}
+
+	ref := dsref.Ref{Username: tr.Book.AuthorName(), Name: "population_fork"}
+	if err := tr.Book.WriteDatasetInit(tr.Ctx, ref.Name); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Book.WriteDatasetFork(tr.Ctx, ref, source); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tr.Book.ForkSource(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(source, got); diff != "" {
+		t.Errorf("fork source mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err = tr.Book.ForkSource(tr.Ctx, tr.WorldBankRef())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != "" {
+		t.Errorf("expected dataset that isn't a fork to have no source, got: %s", got)
+	}
+
+	entries, err := tr.Book.LogEntries(tr.Ctx, ref, 0, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].Action != "fork" || entries[1].Note != "b5/world_bank_population" {
+		t.Errorf("expected a fork log entry, got: %v", entries)
+	}
+}
+
 func TestVersions(t *testing.T) {
 	tr, cleanup := newTestRunner(t)
 	defer cleanup()
@@ -717,6 +769,224 @@ func TestVersions(t *testing.T) {
 	}
 }
 
+func TestVersionLabels(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	tr.WriteWorldBankExample(t)
+	tr.WriteMoreWorldBankCommits(t)
+	book := tr.Book
+	ref := tr.WorldBankRef()
+
+	if err := book.WriteVersionLabel(tr.Ctx, ref, "QmHashOfVersion4", "release-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.WriteVersionLabel(tr.Ctx, ref, "QmHashOfVersion4", "reviewed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.WriteVersionLabel(tr.Ctx, ref, "QmHashOfVersion3", "draft"); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.WriteVersionUnlabel(tr.Ctx, ref, "reviewed"); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := book.Versions(tr.Ctx, ref, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([][]string, len(versions))
+	for i, v := range versions {
+		got[i] = v.Labels
+	}
+	expect := [][]string{nil, {"release-1"}, {"draft"}}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("labels mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCustomOps(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	tr.WriteWorldBankExample(t)
+	tr.WriteMoreWorldBankCommits(t)
+	book := tr.Book
+	ref := tr.WorldBankRef()
+
+	if err := book.WriteCustomOp(tr.Ctx, ref, "", nil); err == nil {
+		t.Error("expected writing a custom op without a type to fail")
+	}
+	if err := book.WriteCustomOp(tr.Ctx, ref, "approved", map[string]interface{}{"by": "reviewer"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.WriteCustomOp(tr.Ctx, ref, "reviewed", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := book.CustomOps(tr.Ctx, ref, "approved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 1 {
+		t.Fatalf("expected 1 approved op, got: %d", len(ops))
+	}
+	if ops[0].Path != "QmHashOfVersion5" || ops[0].AuthorID != book.AuthorID() || ops[0].Payload["by"] != "reviewer" {
+		t.Errorf("unexpected custom op: %#v", ops[0])
+	}
+	if ops, err = book.CustomOps(tr.Ctx, ref, ""); err != nil || len(ops) != 2 {
+		t.Errorf("expected 2 custom ops of any type, got: %d, err: %v", len(ops), err)
+	}
+
+	// unhandled custom ops don't change versions
+	versions, err := book.Versions(tr.Ctx, ref, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if versions[0].Labels != nil {
+		t.Errorf("expected unhandled op to leave versions unchanged, got labels: %v", versions[0].Labels)
+	}
+
+	RegisterCustomOpHandler("approved", func(vs []dsref.VersionInfo, op CustomOp) []dsref.VersionInfo {
+		for i := range vs {
+			if vs[i].Path == op.Path {
+				vs[i].Labels = append(vs[i].Labels, "approved")
+			}
+		}
+		return vs
+	})
+	defer RegisterCustomOpHandler("approved", nil)
+
+	if versions, err = book.Versions(tr.Ctx, ref, 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"approved"}, versions[0].Labels); diff != "" {
+		t.Errorf("labels mismatch (-want +got):\n%s", diff)
+	}
+
+	entries, err := book.LogEntries(tr.Ctx, ref, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	approved := entries[len(entries)-2]
+	if approved.Action != "approved" || approved.Note != `{"by":"reviewer"}` {
+		t.Errorf("unexpected custom op log entry: %#v", approved)
+	}
+
+	// custom ops survive transfer to another logbook
+	l, err := book.UserDatasetRef(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Sign(book.pk); err != nil {
+		t.Fatal(err)
+	}
+	book2, err := NewJournal(testPrivKey2(t), "user2", qfs.NewMemFS(), "/mem/fs2_location")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := book2.MergeLog(tr.Ctx, book.Author(), l); err != nil {
+		t.Fatal(err)
+	}
+	if ops, err = book2.CustomOps(tr.Ctx, ref, "approved"); err != nil || len(ops) != 1 {
+		t.Errorf("expected merged log to include custom op, got: %d, err: %v", len(ops), err)
+	}
+}
+
+func TestPublishedDestinations(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	tr.WriteWorldBankExample(t)
+	book := tr.Book
+	ref := tr.WorldBankRef()
+
+	expectDests := func(expect []string) {
+		t.Helper()
+		got, err := book.PublishedDestinations(tr.Ctx, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(expect, got); diff != "" {
+			t.Errorf("destinations mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	// the example amends its latest version after publishing
+	expectDests(nil)
+
+	if err := book.WritePublish(tr.Ctx, ref, 1, "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.WritePublish(tr.Ctx, ref, 1, "b", "c"); err != nil {
+		t.Fatal(err)
+	}
+	expectDests([]string{"a", "b", "c"})
+
+	if err := book.WriteUnpublish(tr.Ctx, ref, 1, "a"); err != nil {
+		t.Fatal(err)
+	}
+	expectDests([]string{"b", "c"})
+
+	tr.WriteMoreWorldBankCommits(t)
+	expectDests(nil)
+
+	var nilBook *Book
+	if _, err := nilBook.PublishedDestinations(tr.Ctx, ref); err != ErrNoLogbook {
+		t.Errorf("expected nil book to return ErrNoLogbook, got: %v", err)
+	}
+}
+
+func TestPublishRecords(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	tr.WriteWorldBankExample(t)
+	book := tr.Book
+	ref := tr.WorldBankRef()
+
+	expectRecords := func(expect []PublishRecord) {
+		t.Helper()
+		got, err := book.PublishRecords(tr.Ctx, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(expect, got); diff != "" {
+			t.Errorf("records mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	// the example publishes & unpublishes its first version
+	expectRecords(nil)
+
+	if err := book.WritePublish(tr.Ctx, ref, 2, "registry", "backup"); err != nil {
+		t.Fatal(err)
+	}
+	tr.WriteMoreWorldBankCommits(t)
+	if err := book.WritePublish(tr.Ctx, ref, 1, "registry"); err != nil {
+		t.Fatal(err)
+	}
+	expectRecords([]PublishRecord{
+		{Destination: "registry", Revisions: 3},
+		{Destination: "backup", Revisions: 2},
+	})
+
+	if err := book.WriteUnpublish(tr.Ctx, ref, 2, "backup"); err != nil {
+		t.Fatal(err)
+	}
+	expectRecords([]PublishRecord{{Destination: "registry", Revisions: 3}})
+
+	if err := book.WriteUnpublish(tr.Ctx, ref, 1); err != nil {
+		t.Fatal(err)
+	}
+	expectRecords([]PublishRecord{{Destination: "registry", Revisions: 2}})
+
+	var nilBook *Book
+	if _, err := nilBook.PublishRecords(tr.Ctx, ref); err != ErrNoLogbook {
+		t.Errorf("expected nil book to return ErrNoLogbook, got: %v", err)
+	}
+}
+
 func TestConstructDatasetLog(t *testing.T) {
 	tr, cleanup := newTestRunner(t)
 	defer cleanup()
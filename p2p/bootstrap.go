@@ -41,12 +41,35 @@ func (n *QriNode) Bootstrap(boostrapAddrs []string, boostrapPeers chan pstore.Pe
 // BootstrapIPFS connects this node to standard ipfs nodes for file exchange
 func (n *QriNode) BootstrapIPFS() {
 	if node, err := n.ipfsNode(); err == nil {
-		if err := node.Bootstrap(bootstrap.DefaultBootstrapConfig); err != nil {
+		cfg, err := n.ipfsBootstrapConfig()
+		if err != nil {
+			log.Errorf("error parsing IPFS bootstrap addresses: %s", err.Error())
+			return
+		}
+		if err := node.Bootstrap(cfg); err != nil {
 			log.Errorf("IPFS bootsrap error: %s", err.Error())
 		}
 	}
 }
 
+// ipfsBootstrapConfig uses configured bootstrap addresses in place of the IPFS
+// repo's bootstrap peers. Nodes on a private network can't reach public peers,
+// and fall back to the qri bootstrap addresses
+func (n *QriNode) ipfsBootstrapConfig() (bootstrap.BootstrapConfig, error) {
+	addrs := n.cfg.BootstrapAddrs
+	if len(addrs) == 0 && n.PrivateNetwork() {
+		addrs = n.cfg.QriBootstrapAddrs
+	}
+	if len(addrs) == 0 {
+		return bootstrap.DefaultBootstrapConfig, nil
+	}
+	maddrs, err := ParseMultiaddrs(addrs)
+	if err != nil {
+		return bootstrap.BootstrapConfig{}, err
+	}
+	return bootstrap.BootstrapConfigWithPeers(toPeerInfos(maddrs)), nil
+}
+
 // ParseMultiaddrs turns a slice of strings into a slice of Multiaddrs
 func ParseMultiaddrs(addrs []string) (maddrs []ma.Multiaddr, err error) {
 	maddrs = make([]ma.Multiaddr, len(addrs))
@@ -10,7 +10,8 @@ import (
 	"github.com/qri-io/qri/base/dsfs"
 )
 
-// NewManifest generates a manifest for a given node
+// NewManifest generates a manifest for a given node. Manifests are cached by
+// root id, repeated calls for the same version don't walk the DAG again
 func (node *QriNode) NewManifest(ctx context.Context, path string) (*dag.Manifest, error) {
 	ng, err := newNodeGetter(node)
 	if err != nil {
@@ -21,12 +22,21 @@ func (node *QriNode) NewManifest(ctx context.Context, path string) (*dag.Manifes
 	if err != nil {
 		return nil, err
 	}
+	if m, ok := node.dags.manifest(id.String()); ok {
+		return m, nil
+	}
 
-	return dag.NewManifest(ctx, ng, id)
+	m, err := dag.NewManifest(ctx, ng, id)
+	if err != nil {
+		return nil, err
+	}
+	node.dags.putManifest(id.String(), m)
+	return m, nil
 }
 
 // MissingManifest returns a manifest describing blocks that are not in this
-// node for a given manifest
+// node for a given manifest. Missing manifests aren't cached, which blocks are
+// missing changes as blocks are fetched
 func (node *QriNode) MissingManifest(ctx context.Context, m *dag.Manifest) (missing *dag.Manifest, err error) {
 	ng, err := newNodeGetter(node)
 	if err != nil {
@@ -36,14 +46,51 @@ func (node *QriNode) MissingManifest(ctx context.Context, m *dag.Manifest) (miss
 	return dag.Missing(ctx, ng, m)
 }
 
+// MissingFromManifest returns a manifest describing blocks in a local
+// manifest that a remote manifest doesn't list, the blocks a push of local
+// needs to send to a remote holding remote. Blocks keep their order in local.
+// Neither manifest needs to be stored on this node
+func MissingFromManifest(local, remote *dag.Manifest) *dag.Manifest {
+	has := make(map[string]bool, len(remote.Nodes))
+	for _, id := range remote.Nodes {
+		has[id] = true
+	}
+
+	var nodes []string
+	for _, id := range local.Nodes {
+		if !has[id] {
+			nodes = append(nodes, id)
+			has[id] = true
+		}
+	}
+	return &dag.Manifest{Nodes: nodes}
+}
+
 // NewDAGInfo generates a DAGInfo for a given node. If a label is given, it will generate a sub-DAGInfo at thea label.
+// DAGInfo is cached by root id, repeated calls for the same version don't
+// walk the DAG again
 func (node *QriNode) NewDAGInfo(ctx context.Context, path, label string) (*dag.Info, error) {
 	ng, err := newNodeGetter(node)
 	if err != nil {
 		return nil, err
 	}
 
-	return newDAGInfo(ctx, node.Repo.Store(), ng, path, label)
+	id, err := cid.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := node.dags.info(id.String())
+	if !ok {
+		if info, err = newDAGInfo(ctx, node.Repo.Store(), ng, path, ""); err != nil {
+			return nil, err
+		}
+		node.dags.putInfo(id.String(), info)
+	}
+
+	if label != "" {
+		return info.InfoAtLabel(label)
+	}
+	return info, nil
 }
 
 // newDAGInfo generates a DAGInfo for a given node. If a label is provided,
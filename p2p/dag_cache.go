@@ -0,0 +1,105 @@
+package p2p
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/qri-io/dag"
+)
+
+// DefaultDAGCacheSize is the number of versions a node keeps generated
+// manifests & dag info for
+const DefaultDAGCacheSize = 256
+
+// dagCache holds manifests & dag info generated for versions, keyed by root
+// id. Versions are content-addressed & never change, so entries never go
+// stale. The least recently used version is dropped when the cache is full.
+// Cached values are shared & must not be modified. The zero value is ready to
+// use, holding DefaultDAGCacheSize versions
+type dagCache struct {
+	lk      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type dagCacheEntry struct {
+	id       string
+	manifest *dag.Manifest
+	// info is the full dag info of the version, with dataset component labels
+	info *dag.Info
+}
+
+// manifest gets the manifest of a version, which is also held by its dag info
+func (c *dagCache) manifest(id string) (*dag.Manifest, bool) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	ent := c.get(id)
+	if ent == nil {
+		return nil, false
+	}
+	if ent.manifest != nil {
+		return ent.manifest, true
+	}
+	if ent.info != nil && ent.info.Manifest != nil {
+		return ent.info.Manifest, true
+	}
+	return nil, false
+}
+
+// info gets the full dag info of a version
+func (c *dagCache) info(id string) (*dag.Info, bool) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	if ent := c.get(id); ent != nil && ent.info != nil {
+		return ent.info, true
+	}
+	return nil, false
+}
+
+func (c *dagCache) putManifest(id string, m *dag.Manifest) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	c.put(id).manifest = m
+}
+
+func (c *dagCache) putInfo(id string, info *dag.Info) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	c.put(id).info = info
+}
+
+// get finds an entry, marking it as most recently used. callers must hold the
+// lock
+func (c *dagCache) get(id string) *dagCacheEntry {
+	el, ok := c.entries[id]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*dagCacheEntry)
+}
+
+// put finds or adds an entry, dropping the least recently used entry if the
+// cache is full. callers must hold the lock
+func (c *dagCache) put(id string) *dagCacheEntry {
+	if ent := c.get(id); ent != nil {
+		return ent
+	}
+	if c.entries == nil {
+		c.entries = map[string]*list.Element{}
+		c.order = list.New()
+	}
+	size := c.size
+	if size <= 0 {
+		size = DefaultDAGCacheSize
+	}
+	for c.order.Len() >= size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*dagCacheEntry).id)
+	}
+	ent := &dagCacheEntry{id: id}
+	c.entries[id] = c.order.PushFront(ent)
+	return ent
+}
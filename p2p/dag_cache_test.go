@@ -0,0 +1,45 @@
+package p2p
+
+import (
+	"testing"
+
+	"github.com/qri-io/dag"
+)
+
+func TestDAGCache(t *testing.T) {
+	c := &dagCache{size: 2}
+
+	if _, ok := c.manifest("a"); ok {
+		t.Errorf("expected empty cache to miss")
+	}
+	a := &dag.Manifest{Nodes: []string{"a"}}
+	c.putManifest("a", a)
+	if m, ok := c.manifest("a"); !ok || m != a {
+		t.Errorf("expected cached manifest")
+	}
+	if _, ok := c.info("a"); ok {
+		t.Errorf("expected caching a manifest not to cache info")
+	}
+
+	b := &dag.Info{Manifest: &dag.Manifest{Nodes: []string{"b"}}}
+	c.putInfo("b", b)
+	if m, ok := c.manifest("b"); !ok || m != b.Manifest {
+		t.Errorf("expected manifest to be served from cached info")
+	}
+
+	// "a" was used more recently than "b", adding "c" drops "b"
+	c.manifest("a")
+	c.putManifest("c", &dag.Manifest{Nodes: []string{"c"}})
+	if _, ok := c.info("b"); ok {
+		t.Errorf("expected least recently used version to be dropped")
+	}
+	if _, ok := c.manifest("a"); !ok {
+		t.Errorf("expected recently used version to be kept")
+	}
+
+	var zero dagCache
+	zero.putManifest("a", a)
+	if m, ok := zero.manifest("a"); !ok || m != a {
+		t.Errorf("expected zero value cache to be usable")
+	}
+}
@@ -71,6 +71,29 @@ func TestMissingManifest(t *testing.T) {
 	}
 }
 
+func TestMissingFromManifest(t *testing.T) {
+	local := &dag.Manifest{
+		Links: [][2]int{{0, 1}, {0, 2}, {0, 3}},
+		Nodes: []string{"root", "a", "b", "c"},
+	}
+
+	cases := []struct {
+		description string
+		remote      *dag.Manifest
+		expect      *dag.Manifest
+	}{
+		{"remote has nothing", &dag.Manifest{}, &dag.Manifest{Nodes: []string{"root", "a", "b", "c"}}},
+		{"remote has some blocks", &dag.Manifest{Nodes: []string{"old_root", "a", "c"}}, &dag.Manifest{Nodes: []string{"root", "b"}}},
+		{"remote has every block", &dag.Manifest{Nodes: []string{"c", "b", "a", "root"}}, &dag.Manifest{}},
+	}
+	for _, c := range cases {
+		got := MissingFromManifest(local, c.remote)
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case %q result mismatch. (-want +got):\n%s", c.description, diff)
+		}
+	}
+}
+
 func TestNewDAGInfo(t *testing.T) {
 	tr, cleanup := newTestRunner(t)
 	defer cleanup()
@@ -111,4 +134,26 @@ func TestNewDAGInfo(t *testing.T) {
 	if diff := cmp.Diff(expect, di); diff != "" {
 		t.Errorf("result mismatch. (-want +got):\n%s", diff)
 	}
+	// dag info is generated once per version, manifests come from cached info
+	again, err := node.NewDAGInfo(tr.Ctx, ref.Path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != di {
+		t.Errorf("expected repeated dag info to be served from cache")
+	}
+	mf, err := node.NewManifest(tr.Ctx, ref.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mf != di.Manifest {
+		t.Errorf("expected manifest to be served from cached dag info")
+	}
+	bd, err := node.NewDAGInfo(tr.Ctx, ref.Path, "bd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bd.Manifest.Nodes) != 1 || bd.Manifest.Nodes[0] != expect.Manifest.Nodes[4] {
+		t.Errorf("expected labeled dag info of the body, got: %v", bd.Manifest)
+	}
 }
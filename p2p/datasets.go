@@ -72,7 +72,7 @@ func (n *QriNode) handleDatasetsList(ws *WrappedStream, msg Message) (hangup boo
 			dlp.Limit = listMax
 		}
 
-		refs, err := base.ListDatasets(context.TODO(), n.Repo, dlp.Term, dlp.Limit, dlp.Offset, false, true, false)
+		refs, err := base.ListDatasets(context.TODO(), n.Repo, dlp.Term, nil, dlp.Limit, dlp.Offset, false, true, false)
 		if err != nil {
 			log.Error(err)
 			return
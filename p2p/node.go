@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"sync"
@@ -16,6 +17,7 @@ import (
 	host "github.com/libp2p/go-libp2p-core/host"
 	net "github.com/libp2p/go-libp2p-core/network"
 	peer "github.com/libp2p/go-libp2p-core/peer"
+	ipnet "github.com/libp2p/go-libp2p-core/pnet"
 	pstore "github.com/libp2p/go-libp2p-peerstore"
 	pstoremem "github.com/libp2p/go-libp2p-peerstore/pstoremem"
 	discovery "github.com/libp2p/go-libp2p/p2p/discovery"
@@ -39,6 +41,9 @@ type QriNode struct {
 	privateKey crypto.PrivKey
 
 	cfg *config.P2P
+	// protector restricts connections to a private network, nil if this node
+	// connects to the public network
+	protector ipnet.Protector
 
 	// base context for this node
 	ctx context.Context
@@ -77,6 +82,13 @@ type QriNode struct {
 	// local feedback as opposed to p2p connections
 	LocalStreams ioes.IOStreams
 
+	// upload & download throttle bytes sent & received over qri streams and
+	// dsync. nil throttles don't limit. use SetBandwidthLimits to set
+	upload, download *Throttle
+
+	// dags caches manifests & dag info generated for versions
+	dags dagCache
+
 	// TODO - waiting on next IPFS release
 	// autoNAT service
 	// autonat *autonat.AutoNATService
@@ -100,14 +112,19 @@ func NewQriNode(r repo.Repo, p2pconf *config.P2P) (node *QriNode, err error) {
 	if err != nil {
 		return nil, fmt.Errorf("error decoding peer id: %s", err.Error())
 	}
+	protector, err := p2pconf.DecodeSwarmKey()
+	if err != nil {
+		return nil, err
+	}
 
 	node = &QriNode{
-		ID:       pid,
-		cfg:      p2pconf,
-		Repo:     r,
-		ctx:      context.Background(),
-		msgState: &sync.Map{},
-		msgChan:  make(chan Message),
+		ID:        pid,
+		cfg:       p2pconf,
+		protector: protector,
+		Repo:      r,
+		ctx:       context.Background(),
+		msgState:  &sync.Map{},
+		msgChan:   make(chan Message),
 		// Make sure we always have proper IOStreams, this can be set
 		// later
 		LocalStreams: ioes.NewDiscardIOStreams(),
@@ -143,7 +160,7 @@ func (n *QriNode) GoOnline() (err error) {
 	// If the underlying content-addressed-filestore is an ipfs
 	// node, it has built-in p2p, overlay the qri protocol
 	// on the ipfs node's p2p connections.
-	if ipfsfs, ok := n.Repo.Store().(*ipfs_filestore.Filestore); ok {
+	if ipfsfs, ok := repo.UnwrapStore(n.Repo.Store()).(*ipfs_filestore.Filestore); ok {
 		if !ipfsfs.Online() {
 			if err := ipfsfs.GoOnline(n.ctx); err != nil {
 				return err
@@ -151,6 +168,11 @@ func (n *QriNode) GoOnline() (err error) {
 		}
 
 		ipfsnode := ipfsfs.Node()
+		// the IPFS node's host is configured by the IPFS repo's swarm.key, refuse
+		// to overlay a private network on a host that doesn't share the key
+		if n.protector != nil && !bytes.Equal(ipfsnode.PNetFingerprint, n.protector.Fingerprint()) {
+			return fmt.Errorf("private network: IPFS repo swarm.key doesn't match the configured p2p swarm key")
+		}
 		if ipfsnode.PeerHost != nil {
 			n.host = ipfsnode.PeerHost
 		}
@@ -160,7 +182,7 @@ func (n *QriNode) GoOnline() (err error) {
 		}
 	} else if n.host == nil {
 		ps := pstoremem.NewPeerstore()
-		n.host, err = makeBasicHost(n.ctx, ps, n.cfg)
+		n.host, err = makeBasicHost(n.ctx, ps, n.cfg, n.protector)
 		if err != nil {
 			return fmt.Errorf("error creating host: %s", err.Error())
 		}
@@ -237,7 +259,7 @@ func (n *QriNode) echoMessages() {
 
 // ipfsNode returns the internal IPFS node
 func (n *QriNode) ipfsNode() (*core.IpfsNode, error) {
-	if ipfsfs, ok := n.Repo.Store().(*ipfs_filestore.Filestore); ok {
+	if ipfsfs, ok := repo.UnwrapStore(n.Repo.Store()).(*ipfs_filestore.Filestore); ok {
 		return ipfsfs.Node(), nil
 	}
 	return nil, fmt.Errorf("not using IPFS")
@@ -269,7 +291,7 @@ func (n *QriNode) IPFSCoreAPI() (coreiface.CoreAPI, error) {
 	if n == nil {
 		return nil, ErrNoQriNode
 	}
-	if apier, ok := n.Repo.Store().(ipfsApier); ok {
+	if apier, ok := repo.UnwrapStore(n.Repo.Store()).(ipfsApier); ok {
 		return apier.IPFSCoreAPI(), nil
 	}
 	return nil, fmt.Errorf("not using IPFS")
@@ -318,8 +340,21 @@ func (n *QriNode) Context() context.Context {
 // 	}
 // }
 
-// makeBasicHost creates a LibP2P host from a NodeCfg
-func makeBasicHost(ctx context.Context, ps pstore.Peerstore, p2pconf *config.P2P) (host.Host, error) {
+// PrivateNetwork returns true if this node only connects to peers that share
+// a pre-shared swarm key
+func (n *QriNode) PrivateNetwork() bool {
+	if n.protector != nil {
+		return true
+	}
+	if node, err := n.ipfsNode(); err == nil {
+		return len(node.PNetFingerprint) > 0
+	}
+	return false
+}
+
+// makeBasicHost creates a LibP2P host from a NodeCfg. A non-nil protector
+// limits the host to connections within a private network
+func makeBasicHost(ctx context.Context, ps pstore.Peerstore, p2pconf *config.P2P, protector ipnet.Protector) (host.Host, error) {
 	pk, err := p2pconf.DecodePrivateKey()
 	if err != nil {
 		return nil, err
@@ -351,6 +386,10 @@ func makeBasicHost(ctx context.Context, ps pstore.Peerstore, p2pconf *config.P2P
 	// So instead, we pass in the libp2p basic ConnManager:
 	opts = append(opts, libp2p.ConnectionManager(connmgr.NewConnManager(1000, 0, time.Millisecond)))
 
+	if protector != nil {
+		opts = append(opts, libp2p.PrivateNetwork(protector))
+	}
+
 	return libp2p.New(ctx, opts...)
 }
 
@@ -372,7 +411,7 @@ func (n *QriNode) SendMessage(ctx context.Context, msg Message, replies chan Mes
 		// tag this peer as supporting the qri protocol in the connection manager
 		n.host.ConnManager().TagPeer(peerID, qriSupportKey, qriSupportValue)
 
-		ws := WrapStream(s)
+		ws := n.wrapStream(s)
 		go n.handleStream(ws, replies)
 		if err := ws.sendMessage(msg); err != nil {
 			return err
@@ -385,7 +424,7 @@ func (n *QriNode) SendMessage(ctx context.Context, msg Message, replies chan Mes
 // QriStreamHandler is the handler we register with the multistream muxer
 func (n *QriNode) QriStreamHandler(s net.Stream) {
 	// defer s.Close()
-	n.handleStream(WrapStream(s), nil)
+	n.handleStream(n.wrapStream(s), nil)
 }
 
 // handleStream is a for loop which receives and handles messages
@@ -1,10 +1,14 @@
 package p2p
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	ma "github.com/multiformats/go-multiaddr"
 	"github.com/qri-io/qri/config"
 	cfgtest "github.com/qri-io/qri/config/test"
+	p2ptest "github.com/qri-io/qri/p2p/test"
 	"github.com/qri-io/qri/repo/profile"
 	"github.com/qri-io/qri/repo/test"
 )
@@ -34,3 +38,76 @@ func TestNewNode(t *testing.T) {
 		t.Errorf("online should equal true")
 	}
 }
+
+func TestPrivateNetwork(t *testing.T) {
+	ctx := context.Background()
+	swarmKey := "4f3c1a9e0b7d2c58e6a1f09b3d7c4e2a5b8f1d0c9e6a3b7f2d4c8e1a0b5f9d3c"
+	factory := p2ptest.NewTestNodeFactory(NewTestableQriNode)
+
+	newNode := func(i int, key string) *QriNode {
+		info := factory.NextInfo()
+		r, err := test.NewTestRepoFromProfileID(profile.IDFromPeerID(info.PeerID), i, -1)
+		if err != nil {
+			t.Fatalf("error creating test repo: %s", err.Error())
+		}
+		addr, _ := ma.NewMultiaddr("/ip4/127.0.0.1/tcp/0")
+		p2pconf := config.DefaultP2P()
+		p2pconf.Addrs = []ma.Multiaddr{addr}
+		p2pconf.QriBootstrapAddrs = []string{}
+		p2pconf.SwarmKey = key
+		node, err := factory.NewWithConf(r, p2pconf)
+		if err != nil {
+			t.Fatalf("error creating qri node: %s", err.Error())
+		}
+		if err := node.GoOnline(); err != nil {
+			t.Fatal(err)
+		}
+		return node.(*QriNode)
+	}
+
+	keyed := newNode(0, swarmKey)
+	keyedPeer := newNode(1, swarmKey)
+	unkeyed := newNode(2, "")
+
+	if !keyed.PrivateNetwork() {
+		t.Errorf("expected keyed node to report private network mode")
+	}
+	if unkeyed.PrivateNetwork() {
+		t.Errorf("expected unkeyed node not to report private network mode")
+	}
+
+	if err := keyed.Host().Connect(ctx, keyedPeer.SimplePeerInfo()); err != nil {
+		t.Errorf("expected nodes sharing a swarm key to connect, got: %s", err)
+	}
+
+	// a handshake across network boundaries stalls on unreadable data instead
+	// of erroring promptly, bound these attempts with a timeout
+	dialCtx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+	if err := keyed.Host().Connect(dialCtx, unkeyed.SimplePeerInfo()); err == nil {
+		t.Errorf("expected keyed node to fail connecting to an unkeyed node")
+	}
+	dialCtx, cancel = context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+	if err := unkeyed.Host().Connect(dialCtx, keyed.SimplePeerInfo()); err == nil {
+		t.Errorf("expected keyed node to refuse a connection from an unkeyed node")
+	}
+	if conns := keyed.Host().Network().ConnsToPeer(unkeyed.ID); len(conns) != 0 {
+		t.Errorf("expected no connections between keyed & unkeyed nodes, got %d", len(conns))
+	}
+}
+
+func TestNewNodeInvalidSwarmKey(t *testing.T) {
+	info := cfgtest.GetTestPeerInfo(0)
+	r, err := test.NewTestRepoFromProfileID(profile.IDFromPeerID(info.PeerID), 0, -1)
+	if err != nil {
+		t.Fatalf("error creating test repo: %s", err.Error())
+	}
+
+	p2pconf := config.DefaultP2PForTesting()
+	p2pconf.SwarmKey = "not-a-key"
+	expect := "invalid swarm key: expected 64 hexadecimal characters or the contents of a swarm.key file"
+	if _, err := NewQriNode(r, p2pconf); err == nil || err.Error() != expect {
+		t.Errorf("error mismatch. expected: %q, got: %v", expect, err)
+	}
+}
@@ -0,0 +1,182 @@
+package p2p
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// minThrottleBurst is the smallest number of bytes a throttle lets through at
+// once, keeping low limits from splitting reads into tiny pieces
+const minThrottleBurst = 32 * 1024
+
+// Throttle is a token bucket that caps the rate bytes move through it. A
+// single Throttle is shared by every transfer it limits, making the limit
+// global. A nil *Throttle doesn't limit anything
+type Throttle struct {
+	lk     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewThrottle creates a throttle that allows bytesPerSec bytes per second,
+// returning nil (no limit) if bytesPerSec isn't positive
+func NewThrottle(bytesPerSec int64) *Throttle {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	burst := float64(bytesPerSec)
+	if burst < minThrottleBurst {
+		burst = minThrottleBurst
+	}
+	return &Throttle{
+		rate:   float64(bytesPerSec),
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// BytesPerSec returns the rate the throttle allows, zero for no limit
+func (t *Throttle) BytesPerSec() int64 {
+	if t == nil {
+		return 0
+	}
+	return int64(t.rate)
+}
+
+// Wait blocks until n bytes are allowed through, or the context is cancelled
+func (t *Throttle) Wait(ctx context.Context, n int) error {
+	if t == nil {
+		return nil
+	}
+	for n > 0 {
+		chunk := n
+		if float64(chunk) > t.burst {
+			chunk = int(t.burst)
+		}
+		n -= chunk
+
+		if delay := t.reserve(chunk); delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+// reserve takes n tokens from the bucket, returning the time to wait for the
+// bucket to refill if it's overdrawn. Overdrawing makes later callers wait
+// their turn behind earlier ones
+func (t *Throttle) reserve(n int) time.Duration {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	now := time.Now()
+	t.tokens += now.Sub(t.last).Seconds() * t.rate
+	if t.tokens > t.burst {
+		t.tokens = t.burst
+	}
+	t.last = now
+
+	t.tokens -= float64(n)
+	if t.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-t.tokens / t.rate * float64(time.Second))
+}
+
+// Reader throttles reads from r
+func (t *Throttle) Reader(ctx context.Context, r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return throttledReader{ctx: ctx, r: r, t: t}
+}
+
+// Writer throttles writes to w
+func (t *Throttle) Writer(ctx context.Context, w io.Writer) io.Writer {
+	if t == nil {
+		return w
+	}
+	return throttledWriter{ctx: ctx, w: w, t: t}
+}
+
+type throttledReader struct {
+	ctx context.Context
+	r   io.Reader
+	t   *Throttle
+}
+
+func (tr throttledReader) Read(p []byte) (int, error) {
+	n, err := tr.r.Read(p)
+	if n > 0 {
+		if werr := tr.t.Wait(tr.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+type throttledWriter struct {
+	ctx context.Context
+	w   io.Writer
+	t   *Throttle
+}
+
+func (tw throttledWriter) Write(p []byte) (int, error) {
+	if err := tw.t.Wait(tw.ctx, len(p)); err != nil {
+		return 0, err
+	}
+	return tw.w.Write(p)
+}
+
+// SetBandwidthLimits caps the rate this node sends & receives qri protocol
+// messages and dsync blocks, in bytes per second. Zero means no limit
+func (n *QriNode) SetBandwidthLimits(uploadBps, downloadBps int64) {
+	n.upload = NewThrottle(uploadBps)
+	n.download = NewThrottle(downloadBps)
+}
+
+// ThrottledBlockAPI wraps a block API so blocks read from it count against
+// the node's upload limit, and blocks written to it against the download
+// limit. dsync reads blocks it sends and writes blocks it receives through
+// the block API, so wrapping it throttles dsync over every transport. The
+// block API is returned as-is if the node has no limits
+func (n *QriNode) ThrottledBlockAPI(bapi coreiface.BlockAPI) coreiface.BlockAPI {
+	if n.upload == nil && n.download == nil {
+		return bapi
+	}
+	return throttledBlockAPI{BlockAPI: bapi, upload: n.upload, download: n.download}
+}
+
+type throttledBlockAPI struct {
+	coreiface.BlockAPI
+	upload, download *Throttle
+}
+
+// Put writes a block, throttled by the download limit
+func (b throttledBlockAPI) Put(ctx context.Context, r io.Reader, opts ...options.BlockPutOption) (coreiface.BlockStat, error) {
+	return b.BlockAPI.Put(ctx, b.download.Reader(ctx, r), opts...)
+}
+
+// Get reads a block, throttled by the upload limit
+func (b throttledBlockAPI) Get(ctx context.Context, p path.Path) (io.Reader, error) {
+	r, err := b.BlockAPI.Get(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return b.upload.Reader(ctx, r), nil
+}
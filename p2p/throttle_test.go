@@ -0,0 +1,82 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestNilThrottle(t *testing.T) {
+	var th *Throttle
+	if th = NewThrottle(0); th != nil {
+		t.Fatalf("expected zero limit to create a nil throttle")
+	}
+	if err := th.Wait(context.Background(), 1<<30); err != nil {
+		t.Errorf("expected nil throttle to never wait, got: %s", err)
+	}
+	r := bytes.NewReader([]byte("data"))
+	if got := th.Reader(context.Background(), r); got != r {
+		t.Errorf("expected nil throttle to return reader as-is")
+	}
+	if th.BytesPerSec() != 0 {
+		t.Errorf("expected nil throttle to report no limit, got: %d", th.BytesPerSec())
+	}
+}
+
+func TestThrottleReader(t *testing.T) {
+	const bps = 256 * 1024
+	th := NewThrottle(bps)
+	data := make([]byte, bps+bps/2)
+
+	start := time.Now()
+	got, err := ioutil.ReadAll(th.Reader(context.Background(), bytes.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	elapsed := time.Since(start)
+	if len(got) != len(data) {
+		t.Errorf("byte count mismatch. expected: %d, got: %d", len(data), len(got))
+	}
+	// the first second of data is let through as a burst, the remainder must
+	// wait for the bucket to refill
+	if elapsed < time.Millisecond*400 {
+		t.Errorf("expected reading 1.5x the limit to take about half a second, took: %s", elapsed)
+	}
+}
+
+func TestThrottleWriter(t *testing.T) {
+	const bps = 256 * 1024
+	th := NewThrottle(bps)
+	buf := &bytes.Buffer{}
+	w := th.Writer(context.Background(), buf)
+
+	start := time.Now()
+	if _, err := w.Write(make([]byte, bps)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(make([]byte, bps/2)); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Millisecond*400 {
+		t.Errorf("expected writing 1.5x the limit to take about half a second, took: %s", elapsed)
+	}
+	if buf.Len() != bps+bps/2 {
+		t.Errorf("byte count mismatch. expected: %d, got: %d", bps+bps/2, buf.Len())
+	}
+}
+
+func TestThrottleWaitCancel(t *testing.T) {
+	th := NewThrottle(1024)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	// the burst is spent, the next wait takes far longer than the deadline
+	if err := th.Wait(ctx, minThrottleBurst); err != nil {
+		t.Fatal(err)
+	}
+	if err := th.Wait(ctx, minThrottleBurst); err != context.DeadlineExceeded {
+		t.Errorf("expected wait past the deadline to return %q, got: %v", context.DeadlineExceeded, err)
+	}
+}
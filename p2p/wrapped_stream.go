@@ -2,6 +2,7 @@ package p2p
 
 import (
 	"bufio"
+	"io"
 
 	net "github.com/libp2p/go-libp2p-core/network"
 	multicodec "github.com/multiformats/go-multicodec"
@@ -29,8 +30,18 @@ type WrappedStream struct {
 // incoming data works similarly with wrap.r.Read() for raw-reading and
 // wrap.dec.Decode() to decode.
 func WrapStream(s net.Stream) *WrappedStream {
-	reader := bufio.NewReader(s)
-	writer := bufio.NewWriter(s)
+	return wrapStream(s, s, s)
+}
+
+// wrapStream wraps a stream, with the stream's reads & writes throttled to
+// the node's bandwidth limits
+func (n *QriNode) wrapStream(s net.Stream) *WrappedStream {
+	return wrapStream(s, n.download.Reader(n.ctx, s), n.upload.Writer(n.ctx, s))
+}
+
+func wrapStream(s net.Stream, r io.Reader, w io.Writer) *WrappedStream {
+	reader := bufio.NewReader(r)
+	writer := bufio.NewWriter(w)
 	// This is where we pick our specific multicodec. In order to change the
 	// codec, we only need to change this place.
 	// See https://godoc.org/github.com/multiformats/go-multicodec/json
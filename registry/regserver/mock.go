@@ -98,7 +98,7 @@ type MockRepoSearch struct {
 // Search implements the registry.Searchable interface
 func (ss MockRepoSearch) Search(p registry.SearchParams) ([]*dataset.Dataset, error) {
 	ctx := context.Background()
-	refs, err := base.ListDatasets(ctx, ss.Repo, p.Q, 1000, 0, false, true, false)
+	refs, err := base.ListDatasets(ctx, ss.Repo, p.Q, nil, 1000, 0, false, true, false)
 	if err != nil {
 		return nil, err
 	}
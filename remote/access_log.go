@@ -0,0 +1,408 @@
+package remote
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/qri-io/apiutil"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// AccessOp names the kind of access a client made to a dataset
+type AccessOp string
+
+const (
+	// AccessResolve is a request to resolve a dataset reference
+	AccessResolve = AccessOp("resolve")
+	// AccessPull is a request to pull a dataset version
+	AccessPull = AccessOp("pull")
+	// AccessPreview is a request for a dataset preview
+	AccessPreview = AccessOp("preview")
+	// AccessLogFetch is a request for a dataset's version history
+	AccessLogFetch = AccessOp("log-fetch")
+)
+
+const (
+	// DefaultAccessLogMaxBytes is the size an access log file can grow to before
+	// it's rotated, if no size is configured
+	DefaultAccessLogMaxBytes = 10 * 1024 * 1024
+	// recentAccessEvents is the number of access events kept in memory for
+	// querying recent activity
+	recentAccessEvents = 1000
+)
+
+var (
+	accessCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "qri",
+		Subsystem: "remote",
+		Name:      "dataset_access_total",
+		Help:      "Number of dataset accesses served by this remote, by operation",
+	}, []string{"op"})
+	accessBytesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "qri",
+		Subsystem: "remote",
+		Name:      "dataset_access_bytes_total",
+		Help:      "Bytes of dataset data served by this remote, by operation",
+	}, []string{"op"})
+)
+
+func init() {
+	prometheus.MustRegister(accessCounter, accessBytesCounter)
+}
+
+// AccessEvent records a single client access to a dataset
+type AccessEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	// Ref is the alias of the accessed dataset, Path the version if known
+	Ref  string `json:"ref"`
+	Path string `json:"path,omitempty"`
+	// ProfileID identifies the client, empty for anonymous access
+	ProfileID string   `json:"profileID,omitempty"`
+	Op        AccessOp `json:"op"`
+	// Bytes is the amount of data served
+	Bytes int64 `json:"bytes"`
+}
+
+// DatasetAccess summarizes the accesses of a single dataset
+type DatasetAccess struct {
+	Ref    string           `json:"ref"`
+	Counts map[AccessOp]int `json:"counts"`
+	Bytes  int64            `json:"bytes"`
+	// Recent lists the latest accesses of the dataset, newest first
+	Recent []AccessEvent `json:"recent,omitempty"`
+}
+
+// AccessLog records dataset access events, appending them to a rotating file
+// & counting them in memory. On startup counts are rebuilt from the current &
+// most recently rotated file
+type AccessLog struct {
+	lk             sync.Mutex
+	path           string
+	maxBytes       int64
+	omitProfileIDs bool
+	file           *os.File
+	size           int64
+
+	// recent is a ring of the latest events, oldest first
+	recent []AccessEvent
+	counts map[string]*DatasetAccess
+}
+
+// NewAccessLog creates an access log from remote configuration. An empty
+// AccessLogPath keeps events in memory only
+func NewAccessLog(cfg *config.Remote) (*AccessLog, error) {
+	l := &AccessLog{
+		path:           cfg.AccessLogPath,
+		maxBytes:       cfg.AccessLogMaxBytes,
+		omitProfileIDs: cfg.AccessLogOmitProfileIDs,
+		counts:         map[string]*DatasetAccess{},
+	}
+	if l.maxBytes <= 0 {
+		l.maxBytes = DefaultAccessLogMaxBytes
+	}
+	if l.path == "" {
+		return l, nil
+	}
+
+	// replay the rotated file first, events are read oldest first
+	for _, path := range []string{l.rotatedPath(), l.path} {
+		if err := l.replay(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := l.openFile(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Record adds an access event to the log
+func (l *AccessLog) Record(e AccessEvent) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	if l.omitProfileIDs {
+		e.ProfileID = ""
+	}
+	accessCounter.WithLabelValues(string(e.Op)).Inc()
+	accessBytesCounter.WithLabelValues(string(e.Op)).Add(float64(e.Bytes))
+
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	l.add(e)
+	if l.file != nil {
+		if err := l.write(e); err != nil {
+			log.Errorf("writing access log: %s", err)
+		}
+	}
+}
+
+// Dataset summarizes the accesses of a dataset, listing up to limit of the
+// most recent events
+func (l *AccessLog) Dataset(ref string, limit int) DatasetAccess {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	da := DatasetAccess{Ref: ref, Counts: map[AccessOp]int{}}
+	if c, ok := l.counts[ref]; ok {
+		for op, n := range c.Counts {
+			da.Counts[op] = n
+		}
+		da.Bytes = c.Bytes
+	}
+	for i := len(l.recent) - 1; i >= 0 && len(da.Recent) < limit; i-- {
+		if l.recent[i].Ref == ref {
+			da.Recent = append(da.Recent, l.recent[i])
+		}
+	}
+	return da
+}
+
+// Datasets summarizes the accesses of every accessed dataset, ordered by
+// number of pulls, most pulled first
+func (l *AccessLog) Datasets() []DatasetAccess {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	res := make([]DatasetAccess, 0, len(l.counts))
+	for _, c := range l.counts {
+		da := DatasetAccess{Ref: c.Ref, Counts: map[AccessOp]int{}, Bytes: c.Bytes}
+		for op, n := range c.Counts {
+			da.Counts[op] = n
+		}
+		res = append(res, da)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Counts[AccessPull] == res[j].Counts[AccessPull] {
+			return res[i].Ref < res[j].Ref
+		}
+		return res[i].Counts[AccessPull] > res[j].Counts[AccessPull]
+	})
+	return res
+}
+
+// PullCount returns the number of times a dataset has been pulled
+func (l *AccessLog) PullCount(ref string) int {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	if c, ok := l.counts[ref]; ok {
+		return c.Counts[AccessPull]
+	}
+	return 0
+}
+
+// Close closes the log file
+func (l *AccessLog) Close() error {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// add counts an event, l.lk must be held
+func (l *AccessLog) add(e AccessEvent) {
+	c, ok := l.counts[e.Ref]
+	if !ok {
+		c = &DatasetAccess{Ref: e.Ref, Counts: map[AccessOp]int{}}
+		l.counts[e.Ref] = c
+	}
+	c.Counts[e.Op]++
+	c.Bytes += e.Bytes
+
+	l.recent = append(l.recent, e)
+	if len(l.recent) > recentAccessEvents {
+		l.recent = l.recent[len(l.recent)-recentAccessEvents:]
+	}
+}
+
+// write appends an event to the log file, rotating the file if it's grown
+// too large. l.lk must be held
+func (l *AccessLog) write(e AccessEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if l.size > 0 && l.size+int64(len(data)) > l.maxBytes {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	return err
+}
+
+// rotate replaces the previously rotated file with the current log file &
+// starts a new one
+func (l *AccessLog) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+	if err := os.Rename(l.path, l.rotatedPath()); err != nil {
+		return err
+	}
+	return l.openFile()
+}
+
+func (l *AccessLog) openFile() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("opening access log: %s", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file = f
+	l.size = fi.Size()
+	return nil
+}
+
+func (l *AccessLog) rotatedPath() string {
+	return l.path + ".1"
+}
+
+// replay counts the events of a log file. Lines that can't be read are
+// skipped, a partially written last line shouldn't prevent startup
+func (l *AccessLog) replay(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("reading access log: %s", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		e := AccessEvent{}
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			log.Debugf("skipping access log line: %s", err)
+			continue
+		}
+		l.add(e)
+	}
+	return sc.Err()
+}
+
+// accessLogged wraps a handler, recording successful GET requests as access
+// events of the dataset refFunc reads from the request
+func (r *Remote) accessLogged(op AccessOp, refFunc func(req *http.Request) reporef.DatasetRef, h http.HandlerFunc) http.HandlerFunc {
+	if r.accessLog == nil {
+		return h
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		cw := &countingResponseWriter{ResponseWriter: w}
+		h(cw, req)
+		if req.Method != "GET" || cw.status >= http.StatusBadRequest {
+			return
+		}
+		ref := refFunc(req)
+		if ref.AliasString() == "" {
+			return
+		}
+		r.recordAccess(op, requestProfileID(req), ref, cw.size)
+	}
+}
+
+// recordAccess adds an event to the remote's access log, if it has one
+func (r *Remote) recordAccess(op AccessOp, pid profile.ID, ref reporef.DatasetRef, bytes int64) {
+	if r.accessLog == nil {
+		return
+	}
+	e := AccessEvent{
+		Ref:   ref.AliasString(),
+		Path:  ref.Path,
+		Op:    op,
+		Bytes: bytes,
+	}
+	if pid != "" {
+		e.ProfileID = pid.String()
+	}
+	r.accessLog.Record(e)
+}
+
+// requestProfileID reads the profile ID clients send in a "pid" header,
+// returning an empty ID for anonymous requests
+func requestProfileID(req *http.Request) profile.ID {
+	return profile.IDB58DecodeOrEmpty(req.Header.Get("pid"))
+}
+
+// formValueRef reads a dataset reference from peername & name form values
+func formValueRef(req *http.Request) reporef.DatasetRef {
+	return reporef.DatasetRef{
+		Peername: req.FormValue("peername"),
+		Name:     req.FormValue("name"),
+	}
+}
+
+// countingResponseWriter records the status & number of body bytes written
+type countingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *countingResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *countingResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// AccessLog exposes this remote's access log, nil if the remote doesn't
+// record access
+func (r *Remote) AccessLog() *AccessLog {
+	if r == nil {
+		return nil
+	}
+	return r.accessLog
+}
+
+// AccessLogHTTPHandler serves a summary of dataset access. Requests naming a
+// dataset with peername & name params list recent events for that dataset,
+// other requests list access counts of every dataset, most pulled first
+func (r *Remote) AccessLogHTTPHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "GET" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.accessLog == nil {
+			apiutil.WriteErrResponse(w, http.StatusNotFound, fmt.Errorf("remote isn't recording dataset access"))
+			return
+		}
+
+		ref := formValueRef(req)
+		if ref.Peername == "" && ref.Name == "" {
+			apiutil.WriteResponse(w, r.accessLog.Datasets())
+			return
+		}
+		limit := 100
+		if i, err := apiutil.ReqParamInt("limit", req); err == nil && i > 0 {
+			limit = i
+		}
+		apiutil.WriteResponse(w, r.accessLog.Dataset(ref.AliasString(), limit))
+	}
+}
@@ -0,0 +1,142 @@
+package remote
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qri-io/qri/config"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestAccessLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remote_access_log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := &config.Remote{
+		AccessLogPath:     filepath.Join(dir, "access.log"),
+		AccessLogMaxBytes: 300,
+	}
+	l, err := NewAccessLog(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Record(AccessEvent{Ref: "a/cities", ProfileID: "QmProfile", Op: AccessPull, Bytes: 10})
+	l.Record(AccessEvent{Ref: "a/cities", Op: AccessResolve})
+	l.Record(AccessEvent{Ref: "a/cities", ProfileID: "QmProfile", Op: AccessPull, Bytes: 10})
+	l.Record(AccessEvent{Ref: "a/movies", Op: AccessPull, Bytes: 5})
+
+	if _, err := os.Stat(cfg.AccessLogPath + ".1"); err != nil {
+		t.Errorf("expected log to rotate: %s", err)
+	}
+
+	da := l.Dataset("a/cities", 2)
+	if da.Counts[AccessPull] != 2 || da.Counts[AccessResolve] != 1 || da.Bytes != 20 {
+		t.Errorf("unexpected cities access summary: %#v", da)
+	}
+	if len(da.Recent) != 2 || da.Recent[0].Op != AccessPull || da.Recent[1].Op != AccessResolve {
+		t.Errorf("expected the two most recent events, newest first. got: %#v", da.Recent)
+	}
+	if da.Recent[0].ProfileID != "QmProfile" {
+		t.Errorf("expected profileID to be recorded, got: %q", da.Recent[0].ProfileID)
+	}
+
+	all := l.Datasets()
+	if len(all) != 2 || all[0].Ref != "a/cities" || all[1].Ref != "a/movies" {
+		t.Errorf("expected datasets ordered by pulls, got: %#v", all)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// counts are rebuilt from both log files on restart
+	l, err = NewAccessLog(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if got := l.PullCount("a/cities"); got != 2 {
+		t.Errorf("expected replayed log to count 2 pulls, got: %d", got)
+	}
+	if got := l.PullCount("a/movies"); got != 1 {
+		t.Errorf("expected replayed log to count 1 pull, got: %d", got)
+	}
+
+	l, err = NewAccessLog(&config.Remote{AccessLogOmitProfileIDs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Record(AccessEvent{Ref: "a/cities", ProfileID: "QmProfile", Op: AccessPull})
+	if got := l.Dataset("a/cities", 1).Recent[0].ProfileID; got != "" {
+		t.Errorf("expected profileID to be omitted, got: %q", got)
+	}
+}
+
+func TestAccessLogHTTP(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	rem := tr.NodeARemote(t)
+	server := tr.RemoteTestServer(rem)
+	defer server.Close()
+
+	wbp := writeWorldBankPopulation(tr.Ctx, t, tr.NodeA.Repo)
+	publishRef(t, tr.NodeA.Repo, &wbp)
+	vvs := writeVideoViewStats(tr.Ctx, t, tr.NodeA.Repo)
+	publishRef(t, tr.NodeA.Repo, &vvs)
+
+	cli := tr.NodeBClient(t)
+	ref := &reporef.DatasetRef{Peername: wbp.Peername, Name: wbp.Name}
+	if err := cli.ResolveHeadRef(tr.Ctx, ref, server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.FetchLogs(tr.Ctx, reporef.ConvertToDsref(*ref), server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.PullDataset(tr.Ctx, &wbp, server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	da := rem.AccessLog().Dataset(wbp.AliasString(), 10)
+	for _, op := range []AccessOp{AccessResolve, AccessLogFetch, AccessPull} {
+		if da.Counts[op] != 1 {
+			t.Errorf("expected one %q access, got: %d", op, da.Counts[op])
+		}
+	}
+	if da.Bytes == 0 {
+		t.Errorf("expected served bytes to be recorded")
+	}
+
+	popular, err := rem.Feeds.Feed(tr.Ctx, "", "popular", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(popular) != 1 || popular[0].Name != wbp.Name {
+		t.Errorf("expected only pulled datasets in the popular feed, got: %#v", popular)
+	}
+
+	s := httptest.NewServer(rem.AccessLogHTTPHandler())
+	defer s.Close()
+	res, err := http.Get(s.URL + "?peername=" + wbp.Peername + "&name=" + wbp.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body := struct {
+		Data DatasetAccess
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Data.Counts[AccessPull] != 1 || len(body.Data.Recent) != 3 {
+		t.Errorf("unexpected access response: %#v", body.Data)
+	}
+}
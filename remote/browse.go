@@ -3,6 +3,7 @@ package remote
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/base"
@@ -32,9 +33,11 @@ type Feeds interface {
 	Feed(ctx context.Context, userID, name string, offset, limit int) ([]dsref.VersionInfo, error)
 }
 
-// RepoFeeds implements the feed interface with a Repo
+// RepoFeeds implements the feed interface with a Repo. Feeds include a
+// "popular" feed ordered by pull count if an AccessLog is set
 type RepoFeeds struct {
 	repo.Repo
+	AccessLog *AccessLog
 }
 
 // assert at compile time that RepoFeeds implements the Feeds interface
@@ -47,18 +50,36 @@ func (rf RepoFeeds) Feeds(ctx context.Context, userID string) (map[string][]dsre
 	if err != nil {
 		return nil, err
 	}
-	return map[string][]dsref.VersionInfo{
+	feeds := map[string][]dsref.VersionInfo{
 		"recent": recent,
-	}, nil
+	}
+	if rf.AccessLog != nil {
+		// popular is only listed once datasets have been pulled
+		popular, err := rf.Feed(ctx, userID, "popular", 0, 10)
+		if err != nil {
+			return nil, err
+		}
+		if len(popular) > 0 {
+			feeds["popular"] = popular
+		}
+	}
+	return feeds, nil
 }
 
 // Feed fetches a portion of an individual named feed
 func (rf RepoFeeds) Feed(ctx context.Context, userID, name string, offset, limit int) ([]dsref.VersionInfo, error) {
-	if name != "recent" {
+	var (
+		refs []reporef.DatasetRef
+		err  error
+	)
+	switch {
+	case name == "recent":
+		refs, err = base.ListDatasets(ctx, rf.Repo, "", nil, limit, offset, false, true, false)
+	case name == "popular" && rf.AccessLog != nil:
+		refs, err = rf.popular(ctx, offset, limit)
+	default:
 		return nil, fmt.Errorf("unknown feed name '%s'", name)
 	}
-
-	refs, err := base.ListDatasets(ctx, rf.Repo, "", limit, offset, false, true, false)
 	if err != nil {
 		return nil, err
 	}
@@ -72,6 +93,39 @@ func (rf RepoFeeds) Feed(ctx context.Context, userID, name string, offset, limit
 	return res, nil
 }
 
+// popular lists published datasets ordered by pull count, most pulled first.
+// Datasets that have never been pulled are left out
+func (rf RepoFeeds) popular(ctx context.Context, offset, limit int) ([]reporef.DatasetRef, error) {
+	num, err := rf.Repo.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := base.ListDatasets(ctx, rf.Repo, "", nil, num, 0, false, true, false)
+	if err != nil {
+		return nil, err
+	}
+	pulls := make(map[string]int, len(refs))
+	pulled := refs[:0]
+	for _, ref := range refs {
+		if n := rf.AccessLog.PullCount(ref.AliasString()); n > 0 {
+			pulls[ref.AliasString()] = n
+			pulled = append(pulled, ref)
+		}
+	}
+	sort.SliceStable(pulled, func(i, j int) bool {
+		return pulls[pulled[i].AliasString()] > pulls[pulled[j].AliasString()]
+	})
+
+	if offset >= len(pulled) {
+		return []reporef.DatasetRef{}, nil
+	}
+	pulled = pulled[offset:]
+	if limit >= 0 && limit < len(pulled) {
+		pulled = pulled[:limit]
+	}
+	return pulled, nil
+}
+
 // Previews is an interface for generating constant-size summaries of dataset
 // data
 type Previews interface {
@@ -24,6 +24,8 @@ type Client interface {
 	CloneLogs(ctx context.Context, ref dsref.Ref, remoteAddr string) error
 	RemoveLogs(ctx context.Context, ref dsref.Ref, remoteAddr string) error
 
+	FetchLog(ctx context.Context, ref dsref.Ref, remoteAddr string, offset, limit int) ([]dsref.VersionInfo, error)
+
 	Feeds(ctx context.Context, remoteAddr string) (map[string][]dsref.VersionInfo, error)
 	Preview(ctx context.Context, ref dsref.Ref, remoteAddr string) (*dataset.Dataset, error)
 }
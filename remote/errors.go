@@ -0,0 +1,114 @@
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// ErrorCode is a machine-readable class of error a remote responds with
+type ErrorCode string
+
+const (
+	// ErrCodeNotFound means the remote doesn't have the requested dataset
+	ErrCodeNotFound = ErrorCode("not_found")
+	// ErrCodeNotAuthorized means the remote refused the requesting peer
+	ErrCodeNotAuthorized = ErrorCode("not_authorized")
+	// ErrCodeReadOnly means the remote doesn't accept changes of the requested
+	// kind
+	ErrCodeReadOnly = ErrorCode("read_only")
+	// ErrCodeUnverified means a response couldn't be verified as coming from
+	// the remote, either because it isn't signed, the signature doesn't match,
+	// or the remote signed it with a key that isn't trusted. Clients raise
+	// ErrCodeUnverified errors, remotes never respond with them
+	ErrCodeUnverified = ErrorCode("unverified")
+	// ErrCodeCorrupt means a remote sent data that doesn't match the content
+	// address it was requested by. Like ErrCodeUnverified, only clients raise
+	// ErrCodeCorrupt errors
+	ErrCodeCorrupt = ErrorCode("corrupt")
+	// ErrCodeInternal covers all other failures
+	ErrCodeInternal = ErrorCode("internal")
+)
+
+// HTTPStatus returns the HTTP status code errors of a class are sent with
+func (c ErrorCode) HTTPStatus() int {
+	switch c {
+	case ErrCodeNotFound:
+		return http.StatusNotFound
+	case ErrCodeNotAuthorized:
+		return http.StatusForbidden
+	case ErrCodeReadOnly:
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is an error a remote responded with. Remote HTTP handlers send errors
+// as a JSON-encoded Error. The code is only readable from an *Error, errors
+// passed on as strings, like over RPC, are ErrCodeInternal
+type Error struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// NewError creates a remote error
+func NewError(code ErrorCode, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ErrorCodeOf returns the class of a remote error. Errors that don't wrap an
+// *Error are ErrCodeInternal
+func ErrorCodeOf(err error) ErrorCode {
+	if err == nil {
+		return ErrCodeInternal
+	}
+	var e *Error
+	if errors.As(err, &e) && e.Code != "" {
+		return e.Code
+	}
+	return ErrCodeInternal
+}
+
+// writeHTTPError sends an error as a JSON-encoded Error
+func writeHTTPError(w http.ResponseWriter, err error) {
+	e, ok := err.(*Error)
+	if !ok {
+		e = &Error{Code: ErrCodeInternal, Message: err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.Code.HTTPStatus())
+	json.NewEncoder(w).Encode(e)
+}
+
+// readHTTPError reads an error from a remote response. Remotes that predate
+// structured errors respond with plain text, which is classed by status code
+func readHTTPError(res *http.Response, action string) *Error {
+	data, _ := ioutil.ReadAll(res.Body)
+	e := &Error{}
+	if err := json.Unmarshal(data, e); err != nil || e.Code == "" {
+		e.Message = strings.TrimSpace(string(data))
+		switch res.StatusCode {
+		case http.StatusNotFound:
+			e.Code = ErrCodeNotFound
+		case http.StatusUnauthorized, http.StatusForbidden:
+			e.Code = ErrCodeNotAuthorized
+		case http.StatusConflict:
+			e.Code = ErrCodeReadOnly
+		default:
+			e.Code = ErrCodeInternal
+		}
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(res.StatusCode)
+	}
+	e.Message = fmt.Sprintf("%s: %s", action, e.Message)
+	return e
+}
@@ -0,0 +1,138 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/rpc"
+	"testing"
+
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestHTTPErrors(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		description string
+		handler     http.HandlerFunc
+		code        ErrorCode
+		message     string
+	}{
+		{"not found", func(w http.ResponseWriter, r *http.Request) {
+			writeHTTPError(w, NewError(ErrCodeNotFound, "dataset me/cities not found"))
+		}, ErrCodeNotFound, "dataset me/cities not found"},
+		{"not authorized", func(w http.ResponseWriter, r *http.Request) {
+			writeHTTPError(w, NewError(ErrCodeNotAuthorized, "peer isn't allowed"))
+		}, ErrCodeNotAuthorized, "peer isn't allowed"},
+		{"read only", func(w http.ResponseWriter, r *http.Request) {
+			writeHTTPError(w, NewError(ErrCodeReadOnly, "remote doesn't accept dataset removes"))
+		}, ErrCodeReadOnly, "remote doesn't accept dataset removes"},
+		{"internal", func(w http.ResponseWriter, r *http.Request) {
+			writeHTTPError(w, fmt.Errorf("disk full"))
+		}, ErrCodeInternal, "disk full"},
+		{"plain text", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("repo: not found"))
+		}, ErrCodeNotFound, "repo: not found"},
+		{"empty body", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}, ErrCodeNotAuthorized, "Forbidden"},
+	}
+
+	for _, c := range cases {
+		server := httptest.NewServer(c.handler)
+
+		err := removeDatasetHTTP(ctx, map[string]string{"peername": "me", "name": "cities"}, server.URL)
+		if code := ErrorCodeOf(err); code != c.code {
+			t.Errorf("case %q remove: code mismatch. want: %q got: %q", c.description, c.code, code)
+		}
+		if expect := NewError(c.code, "failed to remove dataset from remote: %s", c.message).Error(); err == nil || err.Error() != expect {
+			t.Errorf("case %q remove: error mismatch.\nwant: %s\ngot:  %v", c.description, expect, err)
+		}
+
+		err = (&PeerSyncClient{}).resolveHeadRefHTTP(ctx, &reporef.DatasetRef{Peername: "me", Name: "cities"}, server.URL)
+		if code := ErrorCodeOf(err); code != c.code {
+			t.Errorf("case %q resolve: code mismatch. want: %q got: %q", c.description, c.code, code)
+		}
+
+		server.Close()
+	}
+}
+
+func TestErrorCodeHTTPStatus(t *testing.T) {
+	cases := map[ErrorCode]int{
+		ErrCodeNotFound:      http.StatusNotFound,
+		ErrCodeNotAuthorized: http.StatusForbidden,
+		ErrCodeReadOnly:      http.StatusConflict,
+		ErrCodeInternal:      http.StatusInternalServerError,
+		ErrorCode("unknown"): http.StatusInternalServerError,
+	}
+	for code, status := range cases {
+		if got := code.HTTPStatus(); got != status {
+			t.Errorf("code %q status mismatch. want: %d got: %d", code, status, got)
+		}
+	}
+	if ErrorCodeOf(fmt.Errorf("oh noes")) != ErrCodeInternal {
+		t.Error("expected non-remote errors to be internal")
+	}
+}
+
+func TestErrorCodeOfWrapped(t *testing.T) {
+	for _, code := range []ErrorCode{ErrCodeNotFound, ErrCodeNotAuthorized, ErrCodeReadOnly, ErrCodeUnverified, ErrCodeCorrupt} {
+		err := fmt.Errorf("publishing: %w", NewError(code, "nope"))
+		if got := ErrorCodeOf(err); got != code {
+			t.Errorf("code mismatch. want: %q got: %q", code, got)
+		}
+	}
+	if msg := NewError(ErrCodeNotFound, "no dataset").Error(); msg != "no dataset" {
+		t.Errorf("expected the error string to be the message, got: %q", msg)
+	}
+	// errors passed on as strings, like over RPC, lose their code
+	if got := ErrorCodeOf(rpc.ServerError("no dataset [not_found]")); got != ErrCodeInternal {
+		t.Errorf("expected string errors to be internal, got: %q", got)
+	}
+}
+
+func TestRemoveDatasetErrors(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	rem, err := NewRemote(tr.NodeA, &config.Remote{Enabled: true, AcceptSizeMax: 10000, RejectDatasetRemoves: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := tr.RemoteTestServer(rem)
+	cli := tr.NodeBClient(t)
+	ref := reporef.DatasetRef{Peername: "me", Name: "cities"}
+	err = cli.RemoveDataset(tr.Ctx, ref, server.URL)
+	server.Close()
+	if ErrorCodeOf(err) != ErrCodeReadOnly {
+		t.Errorf("expected a remote that rejects removes to be read-only, got: %v", err)
+	}
+
+	removed := false
+	rem, err = NewRemote(tr.NodeA, &config.Remote{Enabled: true, AcceptSizeMax: 10000}, func(o *Options) {
+		o.DatasetRemoved = func(ctx context.Context, pid profile.ID, ref reporef.DatasetRef) error {
+			removed = true
+			return nil
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server = tr.RemoteTestServer(rem)
+	defer server.Close()
+	if err = cli.RemoveDataset(tr.Ctx, ref, server.URL); err != nil {
+		t.Errorf("expected removing a missing dataset to succeed, got: %v", err)
+	}
+	if !removed {
+		t.Error("expected removing a missing dataset to run the removed hook")
+	}
+	err = cli.ResolveHeadRef(tr.Ctx, &ref, server.URL)
+	if ErrorCodeOf(err) != ErrCodeNotFound {
+		t.Errorf("expected resolving a missing dataset to be not found, got: %v", err)
+	}
+}
@@ -105,6 +105,11 @@ func (c *MockClient) ResolveHeadRef(ctx context.Context, ref *reporef.DatasetRef
 	return ErrNotImplemented
 }
 
+// FetchLog is not implemented
+func (c *MockClient) FetchLog(ctx context.Context, ref dsref.Ref, remoteAddr string, offset, limit int) ([]dsref.VersionInfo, error) {
+	return nil, ErrNotImplemented
+}
+
 // Feeds is not implemented
 func (c *MockClient) Feeds(ctx context.Context, remoteAddr string) (map[string][]dsref.VersionInfo, error) {
 	return nil, ErrNotImplemented
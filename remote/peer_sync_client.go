@@ -7,17 +7,19 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	ipld "github.com/ipfs/go-ipld-format"
 	coreiface "github.com/ipfs/interface-go-ipfs-core"
 	crypto "github.com/libp2p/go-libp2p-core/crypto"
 	peer "github.com/libp2p/go-libp2p-core/peer"
 	"github.com/qri-io/dag/dsync"
 	"github.com/qri-io/dataset"
-	"github.com/qri-io/qfs/cafs"
 	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/logbook/logsync"
 	"github.com/qri-io/qri/logbook/oplog"
@@ -42,19 +44,40 @@ type PeerSyncClient struct {
 	logsync *logsync.Logsync
 	capi    coreiface.CoreAPI
 	node    *p2p.QriNode
+	// lng & bapi are the local node getter & block store dsync pulls into
+	lng  ipld.NodeGetter
+	bapi coreiface.BlockAPI
+
+	// keys, trustPrompt & trusted verify signed responses from remotes
+	keys        map[string]*config.RemoteKey
+	trustPrompt func(remoteAddr, keyID string) bool
+	trusted     *repo.RemoteKeys
 }
 
 // NewClient creates a remote client suitable for syncing peers
-func NewClient(node *p2p.QriNode) (c Client, err error) {
-	var ds *dsync.Dsync
+func NewClient(node *p2p.QriNode, opts ...func(o *ClientOptions)) (c Client, err error) {
+	o := &ClientOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	keys := map[string]*config.RemoteKey{}
+	for addr, k := range o.Keys {
+		keys[strings.TrimSuffix(addr, "/")] = k
+	}
+
+	var (
+		ds   *dsync.Dsync
+		lng  ipld.NodeGetter
+		bapi coreiface.BlockAPI
+	)
 	capi, capiErr := node.IPFSCoreAPI()
 	if capiErr == nil {
-		lng, err := dsync.NewLocalNodeGetter(capi)
-		if err != nil {
+		if lng, err = dsync.NewLocalNodeGetter(capi); err != nil {
 			return nil, err
 		}
+		bapi = node.ThrottledBlockAPI(capi.Block())
 
-		ds, err = dsync.New(lng, capi.Block(), func(dsyncConfig *dsync.Config) {
+		ds, err = dsync.New(lng, bapi, func(dsyncConfig *dsync.Config) {
 			if host := node.Host(); host != nil {
 				dsyncConfig.Libp2pHost = host
 			}
@@ -83,6 +106,12 @@ func NewClient(node *p2p.QriNode) (c Client, err error) {
 		logsync: ls,
 		capi:    capi,
 		node:    node,
+		lng:     lng,
+		bapi:    bapi,
+
+		keys:        keys,
+		trustPrompt: o.TrustPrompt,
+		trusted:     remoteKeysOf(node.Repo),
 	}, nil
 }
 
@@ -92,7 +121,7 @@ func (c *PeerSyncClient) FetchLogs(ctx context.Context, ref dsref.Ref, remoteAdd
 		return nil, ErrNoRemoteClient
 	}
 
-	if t := addressType(remoteAddr); t == "http" {
+	if t := addressType(remoteAddr); t == addrTypeHTTP {
 		remoteAddr = remoteAddr + "/remote/logsync"
 	}
 	log.Debugf("fetching logs for %s from %s", ref.Alias(), remoteAddr)
@@ -110,7 +139,7 @@ func (c *PeerSyncClient) CloneLogs(ctx context.Context, ref dsref.Ref, remoteAdd
 		return ErrNoRemoteClient
 	}
 
-	if t := addressType(remoteAddr); t == "http" {
+	if t := addressType(remoteAddr); t == addrTypeHTTP {
 		remoteAddr = remoteAddr + "/remote/logsync"
 	}
 	log.Debugf("cloning logs for %s from %s", ref.Alias(), remoteAddr)
@@ -130,7 +159,7 @@ func (c *PeerSyncClient) PushLogs(ctx context.Context, ref dsref.Ref, remoteAddr
 		return ErrNoRemoteClient
 	}
 
-	if t := addressType(remoteAddr); t == "http" {
+	if t := addressType(remoteAddr); t == addrTypeHTTP {
 		remoteAddr = remoteAddr + "/remote/logsync"
 	}
 	log.Debugf("pushing logs for %s from %s", ref.Alias(), remoteAddr)
@@ -148,7 +177,7 @@ func (c *PeerSyncClient) RemoveLogs(ctx context.Context, ref dsref.Ref, remoteAd
 		return ErrNoRemoteClient
 	}
 
-	if t := addressType(remoteAddr); t == "http" {
+	if t := addressType(remoteAddr); t == addrTypeHTTP {
 		remoteAddr = remoteAddr + "/remote/logsync"
 	}
 
@@ -161,7 +190,7 @@ func (c *PeerSyncClient) PushDataset(ctx context.Context, ref reporef.DatasetRef
 	if c == nil {
 		return ErrNoRemoteClient
 	}
-	if t := addressType(remoteAddr); t == "http" {
+	if t := addressType(remoteAddr); t == addrTypeHTTP {
 		remoteAddr = remoteAddr + "/remote/dsync"
 	}
 	log.Debugf("pushing dataset %s to %s", ref.Path, remoteAddr)
@@ -195,11 +224,16 @@ func (c *PeerSyncClient) PushDataset(ctx context.Context, ref reporef.DatasetRef
 	return push.Do(ctx)
 }
 
-// PullDataset fetches a dataset from a remote source
+// PullDataset fetches a dataset from a remote source. Every block the remote
+// sends is checked against its CID before it's stored, the pull fails with
+// an ErrCodeCorrupt error on the first block that doesn't match
 func (c *PeerSyncClient) PullDataset(ctx context.Context, ref *reporef.DatasetRef, remoteAddr string) error {
 	if c == nil {
 		return ErrNoRemoteClient
 	}
+	if c.ds == nil {
+		return fmt.Errorf("pulling datasets requires an IPFS repo")
+	}
 	log.Debugf("pulling dataset: %s from %s", ref.String(), remoteAddr)
 
 	if ref.Path == "" {
@@ -215,13 +249,20 @@ func (c *PeerSyncClient) PullDataset(ctx context.Context, ref *reporef.DatasetRe
 		return err
 	}
 
-	pull, err := c.ds.NewPull(ref.Path, remoteAddr+"/remote/dsync", params)
+	// dsync only checks a block's hash after storing it, pull from a remote
+	// that verifies blocks as they arrive instead
+	rem := &verifyingRemote{DagSyncable: &dsync.HTTPClient{URL: remoteAddr + "/remote/dsync"}}
+	pull, err := dsync.NewPull(ref.Path, c.lng, c.bapi, rem, params)
 	if err != nil {
 		log.Error("creating pull: ", err)
 		return err
 	}
 
-	return pull.Do(ctx)
+	if err = pull.Do(ctx); err != nil {
+		return err
+	}
+	log.Debugf("pulled %s, verified %d blocks", ref.Path, rem.Verified())
+	return nil
 }
 
 // RemoveDataset asks a remote to remove a dataset
@@ -237,7 +278,7 @@ func (c *PeerSyncClient) RemoveDataset(ctx context.Context, ref reporef.DatasetR
 	}
 
 	switch addressType(remoteAddr) {
-	case "http":
+	case addrTypeHTTP:
 		return removeDatasetHTTP(ctx, params, remoteAddr)
 	default:
 		return fmt.Errorf("dataset remove requests currently only work over HTTP")
@@ -252,14 +293,14 @@ func (c *PeerSyncClient) ResolveHeadRef(ctx context.Context, ref *reporef.Datase
 	}
 
 	switch addressType(remoteAddr) {
-	case "http":
-		return resolveHeadRefHTTP(ctx, ref, remoteAddr)
+	case addrTypeHTTP:
+		return c.resolveHeadRefHTTP(ctx, ref, remoteAddr)
 	default:
 		return fmt.Errorf("dataset name resolution currently only works over HTTP")
 	}
 }
 
-func resolveHeadRefHTTP(ctx context.Context, ref *reporef.DatasetRef, remoteAddr string) error {
+func (c *PeerSyncClient) resolveHeadRefHTTP(ctx context.Context, ref *reporef.DatasetRef, remoteAddr string) error {
 	u, err := url.Parse(remoteAddr)
 	if err != nil {
 		return err
@@ -284,13 +325,20 @@ func resolveHeadRefHTTP(ctx context.Context, ref *reporef.DatasetRef, remoteAddr
 	if err != nil {
 		return err
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		errMsg, _ := ioutil.ReadAll(res.Body)
-		return fmt.Errorf("resolving dataset ref from remote failed: %s", string(errMsg))
+		return readHTTPError(res, "resolving dataset ref from remote failed")
 	}
 
-	return json.NewDecoder(res.Body).Decode(ref)
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if err := c.verifyResponse(remoteAddr, res, data); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, ref)
 }
 
 func removeDatasetHTTP(ctx context.Context, params map[string]string, remoteAddr string) error {
@@ -321,22 +369,92 @@ func removeDatasetHTTP(ctx context.Context, params map[string]string, remoteAddr
 	}
 
 	if res.StatusCode != http.StatusOK {
-		if data, err := ioutil.ReadAll(res.Body); err == nil {
-			log.Error("HTTP server remove error response: ", string(data))
-		}
-		return fmt.Errorf("failed to remove dataset from remote")
+		return readHTTPError(res, "failed to remove dataset from remote")
 	}
 
 	return nil
 }
 
-// TODO (b5) - this should return an enumeration
+// FetchLog fetches a page of the version history of a published dataset
+// from a remote, newest first. The dataset isn't added to the local repo
+func (c *PeerSyncClient) FetchLog(ctx context.Context, ref dsref.Ref, remoteAddr string, offset, limit int) ([]dsref.VersionInfo, error) {
+	if c == nil {
+		return nil, ErrNoRemoteClient
+	}
+	if at := addressType(remoteAddr); at != addrTypeHTTP {
+		return nil, fmt.Errorf("fetching dataset logs is only supported over HTTP")
+	}
+
+	u, err := url.Parse(remoteAddr)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = "/remote/dataset/log"
+	q := u.Query()
+	q.Set("peername", ref.Username)
+	q.Set("name", ref.Name)
+	q.Set("offset", strconv.Itoa(offset))
+	q.Set("limit", strconv.Itoa(limit))
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.signHTTPRequest(req); err != nil {
+		return nil, err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such host") {
+			return nil, ErrRemoteNotFound
+		}
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, readHTTPError(res, "fetching dataset log from remote failed")
+	}
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.verifyResponse(remoteAddr, res, data); err != nil {
+		return nil, err
+	}
+	env := struct {
+		Data []dsref.VersionInfo
+	}{}
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+const (
+	// addrTypeP2P is a remote addressed by a base58 peerID
+	addrTypeP2P = "p2p"
+	// addrTypeHTTP is a remote addressed by URL
+	addrTypeHTTP = "http"
+)
+
+// SupportedProtocols lists the kinds of address a remote can be reached at
+func SupportedProtocols() []string {
+	return []string{addrTypeP2P, addrTypeHTTP}
+}
+
+// addressType returns the protocol used to reach a remote address, one of
+// SupportedProtocols or the empty string if the address isn't recognized
 func addressType(remoteAddr string) string {
 	// if a valid base58 peerID is passed, we're doing a p2p dsync
 	if _, err := peer.IDB58Decode(remoteAddr); err == nil {
-		return "p2p"
+		return addrTypeP2P
 	} else if strings.HasPrefix(remoteAddr, "http") {
-		return "http"
+		return addrTypeHTTP
 	}
 
 	return ""
@@ -437,8 +555,7 @@ func (c *PeerSyncClient) AddDataset(ctx context.Context, ref *reporef.DatasetRef
 				return
 			}
 			node.LocalStreams.PrintErr("🗼 fetched from registry\n")
-			if pinner, ok := node.Repo.Store().(cafs.Pinner); ok {
-				err := pinner.Pin(fetchCtx, ref.Path, true)
+			if err := base.PinDataset(fetchCtx, node.Repo, *ref); err != nil && err != repo.ErrNotPinner {
 				res.Error = err
 			}
 		}(refCopy)
@@ -526,7 +643,7 @@ func (c *PeerSyncClient) signHTTPRequest(req *http.Request) error {
 
 // Feeds fetches the first page of featured & recent feeds in one call
 func (c *PeerSyncClient) Feeds(ctx context.Context, remoteAddr string) (map[string][]dsref.VersionInfo, error) {
-	if at := addressType(remoteAddr); at != "http" {
+	if at := addressType(remoteAddr); at != addrTypeHTTP {
 		return nil, fmt.Errorf("feeds are only supported over HTTP")
 	}
 
@@ -547,6 +664,12 @@ func (c *PeerSyncClient) Feeds(ctx context.Context, remoteAddr string) (map[stri
 		}
 		return nil, err
 	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
 	// add response to an envelope
 	env := struct {
 		Data map[string][]dsref.VersionInfo
@@ -557,20 +680,23 @@ func (c *PeerSyncClient) Feeds(ctx context.Context, remoteAddr string) (map[stri
 		}
 	}{}
 
-	if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
+	if err := json.Unmarshal(data, &env); err != nil {
 		return nil, err
 	}
 
 	if res.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("error %d: %s", res.StatusCode, env.Meta.Error)
 	}
+	if err := c.verifyResponse(remoteAddr, res, data); err != nil {
+		return nil, err
+	}
 
 	return env.Data, nil
 }
 
 // Preview fetches a dataset preview from the registry
 func (c *PeerSyncClient) Preview(ctx context.Context, ref dsref.Ref, remoteAddr string) (*dataset.Dataset, error) {
-	if at := addressType(remoteAddr); at != "http" {
+	if at := addressType(remoteAddr); at != addrTypeHTTP {
 		return nil, fmt.Errorf("feeds are only supported over HTTP")
 	}
 
@@ -590,6 +716,12 @@ func (c *PeerSyncClient) Preview(ctx context.Context, ref dsref.Ref, remoteAddr
 		}
 		return nil, err
 	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
 	// add response to an envelope
 	env := struct {
 		Data *dataset.Dataset
@@ -600,13 +732,16 @@ func (c *PeerSyncClient) Preview(ctx context.Context, ref dsref.Ref, remoteAddr
 		}
 	}{}
 
-	if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
+	if err := json.Unmarshal(data, &env); err != nil {
 		return nil, err
 	}
 
 	if res.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("error %d: %s", res.StatusCode, env.Meta.Error)
 	}
+	if err := c.verifyResponse(remoteAddr, res, data); err != nil {
+		return nil, err
+	}
 
 	return env.Data, nil
 }
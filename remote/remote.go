@@ -42,7 +42,8 @@ type Options struct {
 	DatasetPushed Hook
 	// called when a client has unpublished a dataset version
 	DatasetRemovePreCheck Hook
-	// called after a dataset version has been removed
+	// called after a dataset version has been removed, including removes of
+	// datasets the remote doesn't have
 	DatasetRemoved Hook
 	// called before a version pull is permitted
 	DatasetPullPreCheck Hook
@@ -83,6 +84,10 @@ type Remote struct {
 	node    *p2p.QriNode
 	dsync   *dsync.Dsync
 	logsync *logsync.Logsync
+	// push sessions in progress
+	sessions *pushSessions
+	// accessLog records client access to datasets
+	accessLog *AccessLog
 
 	Feeds    Feeds
 	Previews Previews
@@ -90,6 +95,8 @@ type Remote struct {
 	acceptSizeMax int64
 	// TODO (b5) - dsync needs to use timeouts
 	acceptTimeoutMs time.Duration
+	// rejectDatasetRemoves is true for remotes that refuse dataset removes
+	rejectDatasetRemoves bool
 
 	datasetPushPreCheck   Hook
 	datasetPushFinalCheck Hook
@@ -112,8 +119,9 @@ func NewRemote(node *p2p.QriNode, cfg *config.Remote, opts ...func(o *Options))
 	r := &Remote{
 		node: node,
 
-		acceptSizeMax:   cfg.AcceptSizeMax,
-		acceptTimeoutMs: cfg.AcceptTimeoutMs,
+		acceptSizeMax:        cfg.AcceptSizeMax,
+		acceptTimeoutMs:      cfg.AcceptTimeoutMs,
+		rejectDatasetRemoves: cfg.RejectDatasetRemoves,
 
 		datasetPushPreCheck:   o.DatasetPushPreCheck,
 		datasetPushFinalCheck: o.DatasetPushFinalCheck,
@@ -127,10 +135,15 @@ func NewRemote(node *p2p.QriNode, cfg *config.Remote, opts ...func(o *Options))
 		PreviewPreCheck: o.PreviewPreCheck,
 	}
 
+	var err error
+	if r.accessLog, err = NewAccessLog(cfg); err != nil {
+		return nil, err
+	}
+
 	if o.Feeds != nil {
 		r.Feeds = o.Feeds
 	} else {
-		r.Feeds = RepoFeeds{node.Repo}
+		r.Feeds = RepoFeeds{Repo: node.Repo, AccessLog: r.accessLog}
 	}
 
 	if o.Previews != nil {
@@ -149,7 +162,10 @@ func NewRemote(node *p2p.QriNode, cfg *config.Remote, opts ...func(o *Options))
 		return nil, err
 	}
 
-	r.dsync, err = dsync.New(lng, capi.Block(), func(dsyncConfig *dsync.Config) {
+	bapi := node.ThrottledBlockAPI(capi.Block())
+	r.sessions = newPushSessions(bapi, !cfg.RequireAllBlocks, cfg.SessionIdleTimeoutMs*time.Millisecond)
+
+	r.dsync, err = dsync.New(lng, bapi, func(dsyncConfig *dsync.Config) {
 		if host := r.node.Host(); host != nil {
 			dsyncConfig.Libp2pHost = host
 		}
@@ -174,7 +190,7 @@ func NewRemote(node *p2p.QriNode, cfg *config.Remote, opts ...func(o *Options))
 			lso.PushFinalCheck = r.logHook(o.LogPushFinalCheck)
 			lso.Pushed = r.logHook(o.LogPushed)
 			lso.PullPreCheck = r.logHook(o.LogPullPreCheck)
-			lso.Pulled = r.logHook(o.LogPulled)
+			lso.Pulled = r.logHook(r.recordLogPull(o.LogPulled))
 			lso.RemovePreCheck = r.logHook(o.LogRemovePreCheck)
 			lso.Removed = r.logHook(o.LogRemoved)
 		})
@@ -201,8 +217,10 @@ func Address(cfg *config.Config, name string) (addr string, err error) {
 		return "", fmt.Errorf("no registry specifiied to use as default remote")
 	}
 
-	if dst, found := cfg.Remotes.Get(name); found {
-		return dst, nil
+	if cfg.Remotes != nil {
+		if dst, found := cfg.Remotes.Get(name); found {
+			return dst, nil
+		}
 	}
 
 	return "", fmt.Errorf(`remote name "%s" not found`, name)
@@ -226,37 +244,41 @@ func (r *Remote) ResolveHeadRef(ctx context.Context, peername, name string) (*re
 // the dataset ref from the refstore and add the (n + 1)th to the refstore
 // gen = -1 should indicate that we remove all the dataset versions
 func (r *Remote) RemoveDataset(ctx context.Context, params map[string]string) error {
+	if r.rejectDatasetRemoves {
+		return NewError(ErrCodeReadOnly, "remote doesn't accept dataset removes")
+	}
+
 	pid, ref, err := r.pidAndRefFromMeta(params)
 	if err != nil {
-		return err
+		return NewError(ErrCodeNotAuthorized, "invalid requester: %s", err)
 	}
 	log.Debugf("remove dataset %s", ref)
 
 	// run pre check hook
 	if r.datasetRemovePreCheck != nil {
 		if err = r.datasetRemovePreCheck(ctx, pid, ref); err != nil {
-			return err
+			return NewError(ErrCodeNotAuthorized, err.Error())
 		}
 	}
 
+	// removing a dataset the remote doesn't have succeeds, removes are
+	// idempotent. The completed hook runs either way
 	if err := repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
-		if err == repo.ErrNotFound {
-			err = nil
-		} else {
+		if !repo.IsNotFound(err) {
 			return err
 		}
-	}
-
-	// TODO(dlong): logbook is not being updated here
+	} else {
+		// TODO(dlong): logbook is not being updated here
 
-	// remove all the versions of this dataset from the store
-	if _, err := base.RemoveNVersionsFromStore(ctx, r.node.Repo, reporef.ConvertToDsref(ref), -1); err != nil {
-		return err
-	}
+		// remove all the versions of this dataset from the store
+		if _, err := base.RemoveNVersionsFromStore(ctx, r.node.Repo, reporef.ConvertToDsref(ref), -1); err != nil {
+			return err
+		}
 
-	// remove the dataset reference from the repo
-	if err := r.node.Repo.DeleteRef(ref); err != nil {
-		return err
+		// remove the dataset reference from the repo
+		if err := r.node.Repo.DeleteRef(ref); err != nil {
+			return err
+		}
 	}
 
 	// run completed hook
@@ -321,7 +343,7 @@ func (r *Remote) dsPushComplete(ctx context.Context, info dag.Info, meta map[str
 	}
 
 	if err := repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
-		if err == repo.ErrNotFound {
+		if repo.IsNotFound(err) {
 			err = nil
 		} else {
 			return err
@@ -370,9 +392,29 @@ func (r *Remote) dsGetDagInfo(ctx context.Context, into dag.Info, meta map[strin
 			return err
 		}
 	}
+
+	var size uint64
+	for _, s := range into.Sizes {
+		size += s
+	}
+	r.recordAccess(AccessPull, pid, ref, int64(size))
 	return nil
 }
 
+// recordLogPull wraps a log pulled hook, recording log pulls as log fetches
+func (r *Remote) recordLogPull(h Hook) Hook {
+	if r.accessLog == nil {
+		return h
+	}
+	return func(ctx context.Context, pid profile.ID, ref reporef.DatasetRef) error {
+		r.recordAccess(AccessLogFetch, pid, ref, 0)
+		if h != nil {
+			return h(ctx, pid, ref)
+		}
+		return nil
+	}
+}
+
 func (r *Remote) pidAndRefFromMeta(meta map[string]string) (profile.ID, reporef.DatasetRef, error) {
 	ref := reporef.DatasetRef{
 		Peername: meta["peername"],
@@ -426,6 +468,8 @@ func (r *Remote) AddDefaultRoutes(mux *http.ServeMux) {
 	mux.Handle("/remote/dsync", r.DsyncHTTPHandler())
 	mux.Handle("/remote/logsync", r.LogsyncHTTPHandler())
 	mux.Handle("/remote/refs", r.RefsHTTPHandler())
+	mux.Handle("/remote/dataset/log", r.LogHTTPHandler())
+	mux.Handle("/remote/metrics", r.MetricsHTTPHandler())
 
 	if fs := r.Feeds; fs != nil {
 		mux.Handle("/remote/feeds", r.FeedsHTTPHandler())
@@ -437,19 +481,9 @@ func (r *Remote) AddDefaultRoutes(mux *http.ServeMux) {
 	}
 }
 
-// DsyncHTTPHandler provides an http handler for dsync
-func (r *Remote) DsyncHTTPHandler() http.HandlerFunc {
-	return dsync.HTTPRemoteHandler(r.dsync)
-}
-
-// LogsyncHTTPHandler provides an http handler for synchronizing logs
-func (r *Remote) LogsyncHTTPHandler() http.HandlerFunc {
-	return logsync.HTTPHandler(r.logsync)
-}
-
-// FeedsHTTPHandler provides access to the home feed
+// FeedsHTTPHandler provides access to the home feed. Responses are signed
 func (r *Remote) FeedsHTTPHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, req *http.Request) {
+	return signResponses(r.node.Repo.PrivateKey(), func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
 		if r.FeedPreCheck != nil {
 			id, err := profile.IDB58Decode(req.Header.Get("pid"))
@@ -470,15 +504,16 @@ func (r *Remote) FeedsHTTPHandler() http.HandlerFunc {
 		}
 
 		apiutil.WriteResponse(w, feeds)
-	}
+	})
 }
 
 // max number of items in a page of feed data
 const feedPageSize = 30
 
-// FeedHTTPHandler gives access a feed VersionInfos constructed by a remote
+// FeedHTTPHandler gives access a feed VersionInfos constructed by a remote.
+// Responses are signed
 func (r *Remote) FeedHTTPHandler(prefix string) http.HandlerFunc {
-	return func(w http.ResponseWriter, req *http.Request) {
+	return signResponses(r.node.Repo.PrivateKey(), func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
 		if r.FeedPreCheck != nil {
 			id, err := profile.IDB58Decode(req.Header.Get("pid"))
@@ -499,12 +534,17 @@ func (r *Remote) FeedHTTPHandler(prefix string) http.HandlerFunc {
 		}
 
 		apiutil.WritePageResponse(w, refs, req, page)
-	}
+	})
 }
 
-// PreviewHTTPHandler handles dataset preview requests over HTTP
+// PreviewHTTPHandler handles dataset preview requests over HTTP. Responses
+// are signed
 func (r *Remote) PreviewHTTPHandler(prefix string) http.HandlerFunc {
-	return func(w http.ResponseWriter, req *http.Request) {
+	previewRef := func(req *http.Request) reporef.DatasetRef {
+		ref, _ := repo.ParseDatasetRef(strings.TrimPrefix(req.URL.Path, prefix))
+		return ref
+	}
+	return r.accessLogged(AccessPreview, previewRef, signResponses(r.node.Repo.PrivateKey(), func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
 		if r.PreviewPreCheck != nil {
 			id, err := profile.IDB58Decode(req.Header.Get("pid"))
@@ -525,7 +565,7 @@ func (r *Remote) PreviewHTTPHandler(prefix string) http.HandlerFunc {
 		}
 
 		apiutil.WriteResponse(w, preview)
-	}
+	}))
 }
 
 // ComponentHTTPHandler handles dataset component requests over HTTP
@@ -535,9 +575,59 @@ func (r *Remote) ComponentHTTPHandler(prefix string) http.HandlerFunc {
 	}
 }
 
-// RefsHTTPHandler handles requests for dataset references
+// LogHTTPHandler serves a page of the version history of a published
+// dataset, newest first. Datasets that aren't published can't be read.
+// Responses are signed
+func (r *Remote) LogHTTPHandler() http.HandlerFunc {
+	return r.accessLogged(AccessLogFetch, formValueRef, signResponses(r.node.Repo.PrivateKey(), func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "GET" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		ref := reporef.DatasetRef{
+			Peername: req.FormValue("peername"),
+			Name:     req.FormValue("name"),
+		}
+		if err := repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
+			if repo.IsNotFound(err) {
+				err = NewError(ErrCodeNotFound, "dataset %s not found", ref.AliasString())
+			}
+			writeHTTPError(w, err)
+			return
+		}
+		if !ref.Published {
+			writeHTTPError(w, NewError(ErrCodeNotAuthorized, "dataset %s isn't published", ref.AliasString()))
+			return
+		}
+
+		// offset & limit take precedence over page & pageSize
+		page := apiutil.PageFromRequest(req)
+		offset, limit := page.Offset(), page.Limit()
+		if i, err := apiutil.ReqParamInt("offset", req); err == nil && i >= 0 {
+			offset = i
+		}
+		if i, err := apiutil.ReqParamInt("limit", req); err == nil && i > 0 {
+			limit = i
+		}
+
+		versions, err := base.DatasetLog(req.Context(), r.node.Repo, ref, limit, offset, false)
+		if err == repo.ErrNoHistory {
+			// pages past the end of history are empty
+			versions, err = []dsref.VersionInfo{}, nil
+		}
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		apiutil.WriteResponse(w, versions)
+	}))
+}
+
+// RefsHTTPHandler handles requests for dataset references. Responses are
+// signed
 func (r *Remote) RefsHTTPHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, req *http.Request) {
+	return r.accessLogged(AccessResolve, formValueRef, signResponses(r.node.Repo.PrivateKey(), func(w http.ResponseWriter, req *http.Request) {
 		switch req.Method {
 		case "GET":
 			ref := &reporef.DatasetRef{
@@ -545,15 +635,16 @@ func (r *Remote) RefsHTTPHandler() http.HandlerFunc {
 				Name:     req.FormValue("name"),
 			}
 			if err := repo.CanonicalizeDatasetRef(r.node.Repo, ref); err != nil {
-				w.WriteHeader(http.StatusNotFound)
-				w.Write([]byte(err.Error()))
+				if repo.IsNotFound(err) {
+					err = NewError(ErrCodeNotFound, err.Error())
+				}
+				writeHTTPError(w, err)
 				return
 			}
 
 			res, err := json.Marshal(ref)
 			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				w.Write([]byte(err.Error()))
+				writeHTTPError(w, err)
 				return
 			}
 
@@ -567,8 +658,7 @@ func (r *Remote) RefsHTTPHandler() http.HandlerFunc {
 				params[key] = req.FormValue(key)
 			}
 			if err := r.RemoveDataset(req.Context(), params); err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				w.Write([]byte(err.Error()))
+				writeHTTPError(w, err)
 				return
 			}
 
@@ -577,5 +667,5 @@ func (r *Remote) RefsHTTPHandler() http.HandlerFunc {
 		default:
 			w.WriteHeader(http.StatusNotFound)
 		}
-	}
+	}))
 }
@@ -262,6 +262,49 @@ func newTestRunner(t *testing.T) (tr *testRunner, cleanup func()) {
 	return tr, cleanup
 }
 
+func TestFetchLogHTTP(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	server := tr.RemoteTestServer(tr.NodeARemote(t))
+	defer server.Close()
+	cli := tr.NodeBClient(t)
+
+	wbp := writeWorldBankPopulation(tr.Ctx, t, tr.NodeA.Repo)
+	vvs := writeVideoViewStats(tr.Ctx, t, tr.NodeA.Repo)
+	publishRef(t, tr.NodeA.Repo, &wbp)
+
+	versions, err := cli.FetchLog(tr.Ctx, reporef.ConvertToDsref(wbp), server.URL, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 {
+		t.Fatalf("expected 1 version, got: %d", len(versions))
+	}
+	if versions[0].Path != wbp.Path || versions[0].CommitTitle != "initial commit" {
+		t.Errorf("version mismatch. got: %#v", versions[0])
+	}
+	if versions, err = cli.FetchLog(tr.Ctx, reporef.ConvertToDsref(wbp), server.URL, 1, 10); err != nil || len(versions) != 0 {
+		t.Errorf("expected offset past the end of history to return no versions, got: %v, %v", versions, err)
+	}
+
+	if _, err = cli.FetchLog(tr.Ctx, reporef.ConvertToDsref(vvs), server.URL, 0, 10); ErrorCodeOf(err) != ErrCodeNotAuthorized {
+		t.Errorf("expected fetching the log of an unpublished dataset to be not authorized, got: %v", err)
+	}
+	missing := dsref.Ref{Username: wbp.Peername, Name: "not_a_dataset"}
+	if _, err = cli.FetchLog(tr.Ctx, missing, server.URL, 0, 10); ErrorCodeOf(err) != ErrCodeNotFound {
+		t.Errorf("expected fetching the log of a missing dataset to be not found, got: %v", err)
+	}
+
+	res, err := http.Get(server.URL + "/remote/dataset/log?peername=" + vvs.Peername + "&name=" + vvs.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("expected unpublished dataset log to 403, got: %d", res.StatusCode)
+	}
+}
+
 func (tr *testRunner) NodeARemote(t *testing.T, opts ...func(o *Options)) *Remote {
 	aCfg := &config.Remote{
 		Enabled:       true,
@@ -282,8 +325,12 @@ func (tr *testRunner) RemoteTestServer(rem *Remote) *httptest.Server {
 	return httptest.NewServer(mux)
 }
 
+// NodeBClient creates a client for node B that trusts any remote on first
+// contact
 func (tr *testRunner) NodeBClient(t *testing.T) Client {
-	cli, err := NewClient(tr.NodeB)
+	cli, err := NewClient(tr.NodeB, func(o *ClientOptions) {
+		o.TrustPrompt = func(string, string) bool { return true }
+	})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -357,3 +404,37 @@ func writeVideoViewStats(ctx context.Context, t *testing.T, r repo.Repo) reporef
 
 	return ref
 }
+
+func TestPullDatasetRejectsCorruptBlocks(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	mux := http.NewServeMux()
+	tr.NodeARemote(t).AddDefaultRoutes(mux)
+	// flip the last byte of every block the remote sends
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.FormValue("block") == "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, r)
+		data := rec.Body.Bytes()
+		if len(data) > 0 {
+			data[len(data)-1] ^= 0xff
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	ref := writeWorldBankPopulation(tr.Ctx, t, tr.NodeA.Repo)
+	cli := tr.NodeBClient(t)
+	err := cli.PullDataset(tr.Ctx, &ref, server.URL)
+	if ErrorCodeOf(err) != ErrCodeCorrupt {
+		t.Fatalf("expected pulling corrupt blocks to fail with ErrCodeCorrupt, got: %v", err)
+	}
+	if has, _ := tr.NodeB.Repo.Store().Has(tr.Ctx, ref.Path); has {
+		t.Error("expected a corrupt block not to be stored")
+	}
+}
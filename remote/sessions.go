@@ -0,0 +1,427 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+	"github.com/qri-io/apiutil"
+	"github.com/qri-io/dag"
+	"github.com/qri-io/dag/dsync"
+	"github.com/qri-io/qri/logbook/logsync"
+)
+
+// DefaultSessionIdleTimeout is how long a push session can go without
+// receiving data before it's expired, used when the remote configuration
+// doesn't set one
+const DefaultSessionIdleTimeout = time.Minute * 5
+
+const (
+	sessionKindDsync   = "dsync"
+	sessionKindLogsync = "logsync"
+)
+
+// pushSession tracks a client pushing data to this remote
+type pushSession struct {
+	id         string
+	kind       string
+	ref        string
+	started    time.Time
+	lastActive time.Time
+	// blocks the session is still waiting to receive
+	pending map[string]bool
+	// blocks the session has added to the store
+	received []string
+	// cancel ends the request a session is bound to, may be nil
+	cancel context.CancelFunc
+}
+
+// SessionInfo describes an active push session
+type SessionInfo struct {
+	ID             string  `json:"id"`
+	Kind           string  `json:"kind"`
+	Ref            string  `json:"ref,omitempty"`
+	AgeSeconds     float64 `json:"ageSeconds"`
+	IdleSeconds    float64 `json:"idleSeconds"`
+	BlocksReceived int     `json:"blocksReceived"`
+	BlocksPending  int     `json:"blocksPending"`
+}
+
+// SessionMetrics reports on the push sessions of a remote. Expired counts
+// sessions that sat idle too long, Aborted counts sessions dropped because the
+// client disconnected or the transfer failed
+type SessionMetrics struct {
+	Active   int           `json:"active"`
+	Expired  int           `json:"expired"`
+	Aborted  int           `json:"aborted"`
+	Sessions []SessionInfo `json:"sessions"`
+}
+
+// pushSessions tracks the push sessions of a remote, cleaning up after
+// sessions that don't finish
+type pushSessions struct {
+	lk sync.Mutex
+	// block API to remove partially pushed blocks from
+	bapi coreiface.BlockAPI
+	// removeBlocks is false when clients must push all blocks, in which case
+	// received blocks may have been in the store before the push
+	removeBlocks bool
+	maxIdle      time.Duration
+	sessions     map[string]*pushSession
+	nextID       int
+	expired      int
+	aborted      int
+}
+
+func newPushSessions(bapi coreiface.BlockAPI, removeBlocks bool, maxIdle time.Duration) *pushSessions {
+	if maxIdle <= 0 {
+		maxIdle = DefaultSessionIdleTimeout
+	}
+	return &pushSessions{
+		bapi:         bapi,
+		removeBlocks: removeBlocks,
+		maxIdle:      maxIdle,
+		sessions:     map[string]*pushSession{},
+	}
+}
+
+// open starts tracking a session. sessions without an id are assigned one
+func (ps *pushSessions) open(id, kind, ref string, pending []string, cancel context.CancelFunc) *pushSession {
+	ps.lk.Lock()
+	defer ps.lk.Unlock()
+
+	if id == "" {
+		ps.nextID++
+		id = fmt.Sprintf("%s-%d", kind, ps.nextID)
+	}
+	now := time.Now()
+	s := &pushSession{
+		id:         id,
+		kind:       kind,
+		ref:        ref,
+		started:    now,
+		lastActive: now,
+		pending:    map[string]bool{},
+		cancel:     cancel,
+	}
+	for _, hash := range pending {
+		s.pending[hash] = true
+	}
+	ps.sessions[id] = s
+	return s
+}
+
+// touch marks a session as active, reporting false if the session isn't
+// being tracked
+func (ps *pushSessions) touch(id string) bool {
+	ps.lk.Lock()
+	defer ps.lk.Unlock()
+	s, ok := ps.sessions[id]
+	if ok {
+		s.lastActive = time.Now()
+	}
+	return ok
+}
+
+// receivedBlock records a block a session added to the store, closing the
+// session & reporting true once all pending blocks have arrived
+func (ps *pushSessions) receivedBlock(id, hash string) bool {
+	ps.lk.Lock()
+	defer ps.lk.Unlock()
+	s, ok := ps.sessions[id]
+	if !ok {
+		return false
+	}
+	s.lastActive = time.Now()
+	if s.pending[hash] {
+		delete(s.pending, hash)
+		s.received = append(s.received, hash)
+	}
+	if len(s.pending) == 0 {
+		delete(ps.sessions, id)
+		return true
+	}
+	return false
+}
+
+// close stops tracking a session that finished
+func (ps *pushSessions) close(id string) {
+	ps.lk.Lock()
+	defer ps.lk.Unlock()
+	delete(ps.sessions, id)
+}
+
+// abort drops a session that can't finish, removing any blocks it received.
+// sessions are aborted when their requests end, so cleanup doesn't use a
+// request context
+func (ps *pushSessions) abort(id string, reason error) {
+	ps.lk.Lock()
+	s, ok := ps.sessions[id]
+	if !ok {
+		ps.lk.Unlock()
+		return
+	}
+	delete(ps.sessions, id)
+	ps.aborted++
+	blocks := ps.orphanedBlocks(s)
+	ps.lk.Unlock()
+
+	log.Infof("aborting %s session %s: %s", s.kind, s.id, reason)
+	ps.end(context.Background(), s, blocks)
+}
+
+// expire drops all sessions that have been idle longer than maxIdle,
+// returning the number of sessions expired
+func (ps *pushSessions) expire(ctx context.Context, now time.Time) int {
+	ps.lk.Lock()
+	var stale []*pushSession
+	for id, s := range ps.sessions {
+		if now.Sub(s.lastActive) > ps.maxIdle {
+			stale = append(stale, s)
+			delete(ps.sessions, id)
+		}
+	}
+	ps.expired += len(stale)
+	blocks := make([][]string, len(stale))
+	for i, s := range stale {
+		blocks[i] = ps.orphanedBlocks(s)
+	}
+	ps.lk.Unlock()
+
+	for i, s := range stale {
+		log.Infof("expiring %s session %s, idle for %s", s.kind, s.id, now.Sub(s.lastActive))
+		ps.end(ctx, s, blocks[i])
+	}
+	return len(stale)
+}
+
+// orphanedBlocks lists the blocks a dropped session received that no active
+// session is expecting. ps.lk must be held
+func (ps *pushSessions) orphanedBlocks(s *pushSession) []string {
+	if !ps.removeBlocks {
+		return nil
+	}
+	var blocks []string
+	for _, hash := range s.received {
+		shared := false
+		for _, other := range ps.sessions {
+			if other.pending[hash] || containsString(other.received, hash) {
+				shared = true
+				break
+			}
+		}
+		if !shared {
+			blocks = append(blocks, hash)
+		}
+	}
+	return blocks
+}
+
+// end cancels the request a dropped session is bound to & removes its blocks
+func (ps *pushSessions) end(ctx context.Context, s *pushSession, blocks []string) {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	for _, hash := range blocks {
+		if err := ps.bapi.Rm(ctx, path.New(hash)); err != nil {
+			log.Debugf("removing block %s of %s session %s: %s", hash, s.kind, s.id, err)
+		}
+	}
+}
+
+// metrics reports on the currently tracked sessions, oldest first
+func (ps *pushSessions) metrics(now time.Time) SessionMetrics {
+	ps.lk.Lock()
+	defer ps.lk.Unlock()
+
+	m := SessionMetrics{
+		Active:   len(ps.sessions),
+		Expired:  ps.expired,
+		Aborted:  ps.aborted,
+		Sessions: make([]SessionInfo, 0, len(ps.sessions)),
+	}
+	for _, s := range ps.sessions {
+		m.Sessions = append(m.Sessions, SessionInfo{
+			ID:             s.id,
+			Kind:           s.kind,
+			Ref:            s.ref,
+			AgeSeconds:     now.Sub(s.started).Seconds(),
+			IdleSeconds:    now.Sub(s.lastActive).Seconds(),
+			BlocksReceived: len(s.received),
+			BlocksPending:  len(s.pending),
+		})
+	}
+	sort.Slice(m.Sessions, func(i, j int) bool {
+		return m.Sessions[i].AgeSeconds > m.Sessions[j].AgeSeconds
+	})
+	return m
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+// RunSessionJanitor expires push sessions that have been idle longer than
+// the configured session idle timeout, checking periodically until the passed
+// context is cancelled
+func (r *Remote) RunSessionJanitor(ctx context.Context) {
+	t := time.NewTicker(r.sessions.maxIdle / 2)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			if n := r.sessions.expire(ctx, now); n > 0 {
+				log.Infof("expired %d idle push sessions", n)
+			}
+		}
+	}
+}
+
+// SessionMetrics reports on the push sessions of this remote
+func (r *Remote) SessionMetrics() SessionMetrics {
+	return r.sessions.metrics(time.Now())
+}
+
+// MetricsHTTPHandler serves counts & ages of active push sessions, along with
+// the number of sessions that have expired or been aborted
+func (r *Remote) MetricsHTTPHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "GET" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		apiutil.WriteResponse(w, map[string]interface{}{
+			"sessions": r.SessionMetrics(),
+		})
+	}
+}
+
+// DsyncHTTPHandler provides an http handler for dsync. Each push session is
+// tied to the requests of the client pushing it: a client that disconnects
+// mid-transfer aborts its session, removing any blocks it sent
+func (r *Remote) DsyncHTTPHandler() http.HandlerFunc {
+	handler := dsync.HTTPRemoteHandler(r.dsync)
+	return func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case "POST":
+			r.dsyncNewSession(w, req)
+		case "PUT":
+			r.dsyncReceiveBlock(w, req)
+		default:
+			handler(w, req)
+		}
+	}
+}
+
+func (r *Remote) dsyncNewSession(w http.ResponseWriter, req *http.Request) {
+	info := &dag.Info{}
+	if err := json.NewDecoder(req.Body).Decode(info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	req.Body.Close()
+
+	pinOnComplete := req.FormValue("pin") == "true"
+	meta := map[string]string{}
+	for key := range req.URL.Query() {
+		if key != "pin" {
+			meta[key] = req.URL.Query().Get(key)
+		}
+	}
+
+	sid, diff, err := r.dsync.NewReceiveSession(info, pinOnComplete, meta)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	// a client that's gone before the session starts will never send blocks
+	// TODO (b5) - dsync has no way to drop sessions, so sessions we stop
+	// tracking linger in the dsync session pool until dsync's own session TTL
+	// runs out. we refuse their blocks in the meantime
+	if err := req.Context().Err(); err != nil {
+		log.Debugf("client disconnected before dsync session %s started", sid)
+		return
+	}
+	if diff != nil && len(diff.Nodes) > 0 {
+		_, ref, _ := r.pidAndRefFromMeta(meta)
+		r.sessions.open(sid, sessionKindDsync, ref.String(), diff.Nodes, nil)
+	}
+
+	w.Header().Set("sid", sid)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+func (r *Remote) dsyncReceiveBlock(w http.ResponseWriter, req *http.Request) {
+	sid, hash := req.FormValue("sid"), req.FormValue("hash")
+	if !r.sessions.touch(sid) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(fmt.Sprintf("session %q not found", sid)))
+		return
+	}
+
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		r.sessions.abort(sid, fmt.Errorf("reading block %s: %s", hash, err))
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	res := r.dsync.ReceiveBlock(sid, hash, data)
+	switch res.Status {
+	case dsync.StatusErrored:
+		r.sessions.abort(sid, res.Err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(res.Err.Error()))
+		return
+	case dsync.StatusRetry:
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(res.Err.Error()))
+		return
+	}
+
+	if done := r.sessions.receivedBlock(sid, hash); !done {
+		if err := req.Context().Err(); err != nil {
+			// the client won't learn this block arrived, and won't send the rest
+			r.sessions.abort(sid, fmt.Errorf("client disconnected: %s", err))
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// LogsyncHTTPHandler provides an http handler for synchronizing logs. Pushed
+// logs are tracked as sessions bound to the pushing request, which the session
+// janitor cancels if the push runs past the session idle timeout
+func (r *Remote) LogsyncHTTPHandler() http.HandlerFunc {
+	handler := logsync.HTTPHandler(r.logsync)
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "PUT" {
+			handler(w, req)
+			return
+		}
+		ctx, cancel := context.WithCancel(req.Context())
+		defer cancel()
+		s := r.sessions.open("", sessionKindLogsync, "", nil, cancel)
+		defer r.sessions.close(s.id)
+		handler(w, req.WithContext(ctx))
+	}
+}
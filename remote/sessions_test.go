@@ -0,0 +1,132 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+)
+
+func TestPushSessions(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	ipfsn, err := tr.NodeA.IPFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	capi, err := tr.NodeA.IPFSCoreAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	putBlock := func(data string) string {
+		stat, err := capi.Block().Put(tr.Ctx, bytes.NewReader([]byte(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stat.Path().Cid().String()
+	}
+	hasBlock := func(hash string) bool {
+		id, err := cid.Decode(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		has, err := ipfsn.Blockstore.Has(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return has
+	}
+
+	ps := newPushSessions(capi.Block(), true, time.Minute)
+	a, b, shared := putBlock("a"), putBlock("b"), putBlock("shared")
+
+	ps.open("aborted", sessionKindDsync, "me/aborted", []string{a, shared, "pending"}, nil)
+	ps.open("sharing", sessionKindDsync, "me/sharing", []string{shared, "pending"}, nil)
+	ps.receivedBlock("aborted", a)
+	ps.receivedBlock("aborted", shared)
+	ps.abort("aborted", fmt.Errorf("client disconnected"))
+	if hasBlock(a) {
+		t.Error("expected aborting a session to remove the blocks it received")
+	}
+	if !hasBlock(shared) {
+		t.Error("expected blocks another session is waiting on to be kept")
+	}
+
+	if done := ps.receivedBlock("sharing", shared); done {
+		t.Error("expected session with pending blocks not to be done")
+	}
+	if done := ps.receivedBlock("sharing", "pending"); !done {
+		t.Error("expected session to be done once all blocks are received")
+	}
+
+	expired := false
+	ps.open("idle", sessionKindDsync, "me/idle", []string{b, "pending"}, nil)
+	ps.receivedBlock("idle", b)
+	ps.open("", sessionKindLogsync, "", nil, func() { expired = true })
+
+	m := ps.metrics(time.Now())
+	if m.Active != 2 || m.Aborted != 1 || m.Expired != 0 {
+		t.Errorf("metrics mismatch. got active: %d aborted: %d expired: %d", m.Active, m.Aborted, m.Expired)
+	}
+
+	if n := ps.expire(tr.Ctx, time.Now()); n != 0 {
+		t.Errorf("expected no sessions to expire before the idle timeout, got: %d", n)
+	}
+	if n := ps.expire(tr.Ctx, time.Now().Add(time.Minute*2)); n != 2 {
+		t.Errorf("expected 2 sessions to expire, got: %d", n)
+	}
+	if !expired {
+		t.Error("expected expiring a session to cancel its request")
+	}
+	if hasBlock(b) {
+		t.Error("expected expiring a session to remove the blocks it received")
+	}
+	if ps.touch("idle") {
+		t.Error("expected expired session to no longer be tracked")
+	}
+	if m = ps.metrics(time.Now()); m.Active != 0 || m.Expired != 2 {
+		t.Errorf("metrics mismatch. got active: %d expired: %d", m.Active, m.Expired)
+	}
+}
+
+func TestDsyncHTTPHandlerRefusesUnknownSessions(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	server := tr.RemoteTestServer(tr.NodeARemote(t))
+	defer server.Close()
+
+	req, err := http.NewRequest("PUT", server.URL+"/remote/dsync?sid=expired&hash=foo", bytes.NewReader([]byte("data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("expected block for an untracked session to 404, got: %d", res.StatusCode)
+	}
+
+	res, err = http.Get(server.URL + "/remote/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body := struct {
+		Data struct {
+			Sessions SessionMetrics `json:"sessions"`
+		} `json:"data"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Data.Sessions.Active != 0 {
+		t.Errorf("expected no active sessions, got: %d", body.Data.Sessions.Active)
+	}
+}
@@ -1,12 +1,15 @@
 package remote
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"net/http"
 	"time"
 
 	crypto "github.com/libp2p/go-libp2p-core/crypto"
-	"github.com/multiformats/go-multihash"
 	reporef "github.com/qri-io/qri/repo/ref"
 )
 
@@ -88,15 +91,89 @@ func signString(privKey crypto.PrivKey, str string) (b64Sig string, err error) {
 }
 
 func calcProfileID(privKey crypto.PrivKey) (string, error) {
-	pubkeybytes, err := privKey.GetPublic().Bytes()
+	return pubKeyID(privKey.GetPublic())
+}
+
+const (
+	// SignatureHeader carries the base64-encoded signature of a remote's
+	// response
+	SignatureHeader = "qri-signature"
+	// KeyIDHeader carries the profile ID of the key a response is signed with
+	KeyIDHeader = "qri-key-id"
+	// PubKeyHeader carries the base64-encoded public key a response is signed
+	// with
+	PubKeyHeader = "qri-pubkey"
+)
+
+// responseSigningString binds a response body to the request it answers, so
+// a signed response can't be replayed for a different request
+func responseSigningString(requestURI string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf("%s.%s", requestURI, hex.EncodeToString(sum[:]))
+}
+
+// signResponse adds signature headers for a response body
+func signResponse(privKey crypto.PrivKey, header http.Header, requestURI string, body []byte) error {
+	pubBytes, err := crypto.MarshalPublicKey(privKey.GetPublic())
 	if err != nil {
-		return "", fmt.Errorf("error getting pubkey bytes: %s", err.Error())
+		return err
 	}
-
-	mh, err := multihash.Sum(pubkeybytes, multihash.SHA2_256, 32)
+	keyID, err := calcProfileID(privKey)
+	if err != nil {
+		return err
+	}
+	b64Sig, err := signString(privKey, responseSigningString(requestURI, body))
 	if err != nil {
-		return "", fmt.Errorf("error summing pubkey: %s", err.Error())
+		return err
 	}
 
-	return mh.B58String(), nil
+	header.Set(SignatureHeader, b64Sig)
+	header.Set(KeyIDHeader, keyID)
+	header.Set(PubKeyHeader, base64.StdEncoding.EncodeToString(pubBytes))
+	return nil
+}
+
+// signedResponseWriter buffers a response so it can be signed before it's
+// sent
+type signedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *signedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *signedResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(p)
+}
+
+func (w *signedResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// signResponses wraps a handler, signing the responses it writes with
+// privKey. Responses are sent unsigned if privKey is nil
+func signResponses(privKey crypto.PrivKey, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		sw := &signedResponseWriter{header: w.Header()}
+		h(sw, req)
+
+		if privKey != nil {
+			if err := signResponse(privKey, w.Header(), req.URL.RequestURI(), sw.body.Bytes()); err != nil {
+				log.Errorf("signing response: %s", err)
+			}
+		}
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		w.WriteHeader(sw.status)
+		w.Write(sw.body.Bytes())
+	}
 }
@@ -0,0 +1,179 @@
+package remote
+
+import (
+	"context"
+
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook"
+	"github.com/qri-io/qri/logbook/oplog"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// SyncState describes how a local dataset history relates to the history a
+// remote has for the same dataset
+type SyncState string
+
+const (
+	// SyncStateInSync means local & remote heads match
+	SyncStateInSync = SyncState("in-sync")
+	// SyncStateAhead means the local history has versions the remote doesn't
+	SyncStateAhead = SyncState("ahead")
+	// SyncStateBehind means the remote history has versions the local doesn't
+	SyncStateBehind = SyncState("behind")
+	// SyncStateDiverged means both histories have versions the other doesn't
+	SyncStateDiverged = SyncState("diverged")
+	// SyncStateUnknown means histories couldn't be compared, usually because
+	// the remote couldn't be reached
+	SyncStateUnknown = SyncState("unknown")
+)
+
+// SyncStatus is the result of comparing a local dataset history with a remote
+type SyncStatus struct {
+	Ref   string    `json:"ref"`
+	State SyncState `json:"state"`
+	// LocalHead & RemoteHead are the latest version paths on each side
+	LocalHead  string `json:"localHead,omitempty"`
+	RemoteHead string `json:"remoteHead,omitempty"`
+	// Ahead is the number of local versions the remote doesn't have
+	Ahead int `json:"ahead,omitempty"`
+	// Behind is the number of remote versions the local doesn't have
+	Behind int `json:"behind,omitempty"`
+	// Error describes why the state is unknown
+	Error string `json:"error,omitempty"`
+}
+
+// CompareHistories determines the sync state of two version histories of the
+// same dataset. Both histories must be ordered newest-first, as returned by
+// logbook.Versions
+func CompareHistories(local, remote []dsref.VersionInfo) SyncStatus {
+	s := SyncStatus{}
+	if len(local) > 0 {
+		s.LocalHead = local[0].Path
+	}
+	if len(remote) > 0 {
+		s.RemoteHead = remote[0].Path
+	}
+
+	switch {
+	case s.LocalHead == s.RemoteHead:
+		s.State = SyncStateInSync
+	case s.RemoteHead == "":
+		s.State = SyncStateAhead
+		s.Ahead = len(local)
+	case s.LocalHead == "":
+		s.State = SyncStateBehind
+		s.Behind = len(remote)
+	default:
+		if i := versionIndex(local, s.RemoteHead); i >= 0 {
+			s.State = SyncStateAhead
+			s.Ahead = i
+		} else if i := versionIndex(remote, s.LocalHead); i >= 0 {
+			s.State = SyncStateBehind
+			s.Behind = i
+		} else {
+			s.State = SyncStateDiverged
+			s.Ahead, s.Behind = divergedCounts(local, remote)
+		}
+	}
+	return s
+}
+
+// CompareHead determines the sync state of a local version history with only
+// the head of a remote history. A remote head missing from the local history
+// could be behind or diverged, without the remote history the state is unknown
+func CompareHead(local []dsref.VersionInfo, remoteHead string) SyncStatus {
+	s := SyncStatus{RemoteHead: remoteHead}
+	if len(local) > 0 {
+		s.LocalHead = local[0].Path
+	}
+
+	if i := versionIndex(local, remoteHead); i == 0 {
+		s.State = SyncStateInSync
+	} else if i > 0 {
+		s.State = SyncStateAhead
+		s.Ahead = i
+	} else {
+		s.State = SyncStateUnknown
+		s.Error = "remote head isn't in local history, pull to compare"
+	}
+	return s
+}
+
+// FetchSyncStatus compares the local history of a dataset with the history a
+// remote has. Only logs are fetched from the remote, no dataset blocks are
+// transferred. When the remote won't send logs its head is resolved instead.
+// Failures are reported as an unknown state instead of an error
+func FetchSyncStatus(ctx context.Context, cli Client, book *logbook.Book, ref dsref.Ref, remoteAddr string) SyncStatus {
+	if book == nil {
+		return SyncStatus{Ref: ref.Alias(), State: SyncStateUnknown, Error: logbook.ErrNoLogbook.Error()}
+	}
+	local, err := book.Versions(ctx, ref, 0, -1)
+	if err != nil {
+		return SyncStatus{Ref: ref.Alias(), State: SyncStateUnknown, Error: err.Error()}
+	}
+
+	remote, err := FetchVersions(ctx, cli, ref, remoteAddr)
+	if err != nil {
+		// a remote that doesn't have a dataset hasn't seen any versions
+		if err.Error() != oplog.ErrNotFound.Error() {
+			// remotes that don't serve logs can still report their head
+			head := &reporef.DatasetRef{Peername: ref.Username, Name: ref.Name}
+			if herr := cli.ResolveHeadRef(ctx, head, remoteAddr); herr == nil && head.Path != "" {
+				s := CompareHead(local, head.Path)
+				s.Ref = ref.Alias()
+				return s
+			}
+			s := SyncStatus{Ref: ref.Alias(), State: SyncStateUnknown, Error: err.Error()}
+			if len(local) > 0 {
+				s.LocalHead = local[0].Path
+			}
+			return s
+		}
+	}
+
+	s := CompareHistories(local, remote)
+	s.Ref = ref.Alias()
+	return s
+}
+
+// FetchVersions fetches the logs a remote has for a dataset, returning the
+// versions they record, newest first
+func FetchVersions(ctx context.Context, cli Client, ref dsref.Ref, remoteAddr string) ([]dsref.VersionInfo, error) {
+	l, err := cli.FetchLogs(ctx, ref, remoteAddr)
+	if err != nil {
+		return nil, err
+	}
+	return logbook.Versions(branchLog(l), ref, 0, -1), nil
+}
+
+// branchLog descends a fetched user > dataset > branch log hierarchy to the
+// branch log that holds commit history
+func branchLog(l *oplog.Log) *oplog.Log {
+	if len(l.Logs) > 0 {
+		l = l.Logs[0]
+		if len(l.Logs) > 0 {
+			l = l.Logs[0]
+		}
+	}
+	return l
+}
+
+func versionIndex(versions []dsref.VersionInfo, path string) int {
+	for i, v := range versions {
+		if v.Path == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// divergedCounts returns the number of versions on each side that come after
+// the newest version both histories share
+func divergedCounts(local, remote []dsref.VersionInfo) (ahead, behind int) {
+	for i, v := range local {
+		if j := versionIndex(remote, v.Path); j >= 0 {
+			return i, j
+		}
+	}
+	return len(local), len(remote)
+}
@@ -0,0 +1,76 @@
+package remote
+
+import (
+	"testing"
+
+	"github.com/qri-io/qri/dsref"
+)
+
+func TestCompareHistories(t *testing.T) {
+	history := func(paths ...string) []dsref.VersionInfo {
+		vs := make([]dsref.VersionInfo, len(paths))
+		for i, p := range paths {
+			vs[i] = dsref.VersionInfo{Path: p}
+		}
+		return vs
+	}
+
+	cases := []struct {
+		description    string
+		local, remote  []dsref.VersionInfo
+		state          SyncState
+		ahead, behind  int
+		localHead, rem string
+	}{
+		{"both empty", nil, nil, SyncStateInSync, 0, 0, "", ""},
+		{"same head", history("c", "b", "a"), history("c", "b", "a"), SyncStateInSync, 0, 0, "c", "c"},
+		{"unpublished", history("b", "a"), nil, SyncStateAhead, 2, 0, "b", ""},
+		{"local ahead", history("c", "b", "a"), history("a"), SyncStateAhead, 2, 0, "c", "a"},
+		{"local behind", history("a"), history("c", "b", "a"), SyncStateBehind, 0, 2, "a", "c"},
+		{"not pulled", nil, history("b", "a"), SyncStateBehind, 0, 2, "", "b"},
+		{"diverged", history("d", "b", "a"), history("c", "b", "a"), SyncStateDiverged, 1, 1, "d", "c"},
+		{"unrelated", history("y", "x"), history("b", "a"), SyncStateDiverged, 2, 2, "y", "b"},
+	}
+
+	for _, c := range cases {
+		got := CompareHistories(c.local, c.remote)
+		if got.State != c.state {
+			t.Errorf("%s: state mismatch. expected: %q, got: %q", c.description, c.state, got.State)
+		}
+		if got.Ahead != c.ahead || got.Behind != c.behind {
+			t.Errorf("%s: count mismatch. expected ahead %d behind %d, got ahead %d behind %d", c.description, c.ahead, c.behind, got.Ahead, got.Behind)
+		}
+		if got.LocalHead != c.localHead || got.RemoteHead != c.rem {
+			t.Errorf("%s: head mismatch. expected %q %q, got %q %q", c.description, c.localHead, c.rem, got.LocalHead, got.RemoteHead)
+		}
+	}
+}
+
+func TestCompareHead(t *testing.T) {
+	local := []dsref.VersionInfo{{Path: "c"}, {Path: "b"}, {Path: "a"}}
+	cases := []struct {
+		description string
+		local       []dsref.VersionInfo
+		remoteHead  string
+		state       SyncState
+		ahead       int
+	}{
+		{"same head", local, "c", SyncStateInSync, 0},
+		{"local ahead", local, "a", SyncStateAhead, 2},
+		{"remote head unknown locally", local, "d", SyncStateUnknown, 0},
+		{"not pulled", nil, "d", SyncStateUnknown, 0},
+	}
+
+	for _, c := range cases {
+		got := CompareHead(c.local, c.remoteHead)
+		if got.State != c.state {
+			t.Errorf("%s: state mismatch. expected: %q, got: %q", c.description, c.state, got.State)
+		}
+		if got.Ahead != c.ahead {
+			t.Errorf("%s: ahead mismatch. expected: %d, got: %d", c.description, c.ahead, got.Ahead)
+		}
+		if got.RemoteHead != c.remoteHead {
+			t.Errorf("%s: remote head mismatch. expected: %q, got: %q", c.description, c.remoteHead, got.RemoteHead)
+		}
+	}
+}
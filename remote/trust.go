@@ -0,0 +1,121 @@
+package remote
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	crypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/multiformats/go-multihash"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/repo"
+)
+
+// ClientOptions configures a remote client
+type ClientOptions struct {
+	// Keys pins the keys remotes sign responses with, keyed by remote address
+	Keys map[string]*config.RemoteKey
+	// TrustPrompt asks whether to trust the key a remote signs responses with
+	// the first time the remote is contacted. Trusted keys are recorded in the
+	// repo. When TrustPrompt is nil keys are never trusted on first use, only
+	// keys pinned in Keys or already recorded in the repo are accepted
+	TrustPrompt func(remoteAddr, keyID string) bool
+}
+
+// verifyResponse checks the body of a response was signed by the remote at
+// remoteAddr, in answer to the request the response is for. Failures are
+// ErrCodeUnverified errors. Unsigned responses are only accepted from remotes
+// configured as insecure
+func (c *PeerSyncClient) verifyResponse(remoteAddr string, res *http.Response, body []byte) error {
+	addr := strings.TrimSuffix(remoteAddr, "/")
+	pinned := c.keys[addr]
+	if pinned != nil && pinned.Insecure {
+		return nil
+	}
+
+	b64Sig := res.Header.Get(SignatureHeader)
+	if b64Sig == "" {
+		return NewError(ErrCodeUnverified, "response from remote %s isn't signed. configure the remote as insecure to accept unsigned responses", addr)
+	}
+	b64Pub := res.Header.Get(PubKeyHeader)
+	pub, err := decodePubKey(b64Pub)
+	if err != nil {
+		return NewError(ErrCodeUnverified, "response from remote %s has an invalid key: %s", addr, err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(b64Sig)
+	if err != nil {
+		return NewError(ErrCodeUnverified, "response from remote %s has an invalid signature", addr)
+	}
+	if ok, err := pub.Verify([]byte(responseSigningString(res.Request.URL.RequestURI(), body)), sig); err != nil || !ok {
+		return NewError(ErrCodeUnverified, "response from remote %s has an invalid signature", addr)
+	}
+
+	keyID, err := pubKeyID(pub)
+	if err != nil {
+		return NewError(ErrCodeUnverified, "response from remote %s has an invalid key: %s", addr, err)
+	}
+
+	if pinned != nil && pinned.PubKey != "" {
+		if want, err := decodePubKey(pinned.PubKey); err != nil || !want.Equals(pub) {
+			return NewError(ErrCodeUnverified, "remote %s signed its response with key %s, which doesn't match the configured key", addr, keyID)
+		}
+		return nil
+	}
+
+	if b64Trusted, ok := c.trustedKey(addr); ok {
+		if trusted, err := decodePubKey(b64Trusted); err != nil || !trusted.Equals(pub) {
+			return NewError(ErrCodeUnverified, "remote %s signed its response with key %s, which doesn't match the key trusted when the remote was first contacted", addr, keyID)
+		}
+		return nil
+	}
+
+	if c.trustPrompt == nil || !c.trustPrompt(addr, keyID) {
+		return NewError(ErrCodeUnverified, "remote %s signs responses with untrusted key %s. add the key to the remote's RemoteKeys configuration to trust it", addr, keyID)
+	}
+	log.Infof("trusting key %s of remote %s on first contact", keyID, addr)
+	if c.trusted != nil {
+		if err := c.trusted.Trust(addr, b64Pub); err != nil {
+			log.Errorf("recording key of remote %s: %s", addr, err)
+		}
+	}
+	return nil
+}
+
+// trustedKey returns the key a remote was trusted with on first contact
+func (c *PeerSyncClient) trustedKey(addr string) (string, bool) {
+	if c.trusted == nil {
+		return "", false
+	}
+	return c.trusted.Get(addr)
+}
+
+// remoteKeysOf returns the trusted keys a repo records, or nil if the repo
+// doesn't record any
+func remoteKeysOf(r repo.Repo) *repo.RemoteKeys {
+	if k, ok := r.(repo.RemoteKeyer); ok {
+		return k.RemoteKeys()
+	}
+	return nil
+}
+
+func decodePubKey(b64 string) (crypto.PubKey, error) {
+	data, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.UnmarshalPublicKey(data)
+}
+
+// pubKeyID calculates the profile ID of a public key
+func pubKeyID(pub crypto.PubKey) (string, error) {
+	data, err := pub.Bytes()
+	if err != nil {
+		return "", fmt.Errorf("error getting pubkey bytes: %s", err.Error())
+	}
+	mh, err := multihash.Sum(data, multihash.SHA2_256, 32)
+	if err != nil {
+		return "", fmt.Errorf("error summing pubkey: %s", err.Error())
+	}
+	return mh.B58String(), nil
+}
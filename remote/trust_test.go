@@ -0,0 +1,126 @@
+package remote
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/config/test"
+	"github.com/qri-io/qri/repo"
+)
+
+func TestVerifyResponse(t *testing.T) {
+	pk0 := test.GetTestPeerInfo(0).PrivKey
+	pk1 := test.GetTestPeerInfo(1).PrivKey
+	pub0 := encodePubKey(t, pk0)
+	pub1 := encodePubKey(t, pk1)
+
+	respond := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":"/ipfs/QmFoo"}`))
+	}
+	signed := httptest.NewServer(signResponses(pk0, respond))
+	defer signed.Close()
+	unsigned := httptest.NewServer(http.HandlerFunc(respond))
+	defer unsigned.Close()
+	tampered := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signResponse(pk0, w.Header(), r.URL.RequestURI(), []byte(`{"data":"/ipfs/QmFoo"}`))
+		w.Write([]byte(`{"data":"/ipfs/QmEvil"}`))
+	}))
+	defer tampered.Close()
+
+	trustAll := func(string, string) bool { return true }
+	trustedBy := func(addr, pub string) *repo.RemoteKeys {
+		k := &repo.RemoteKeys{}
+		k.Trust(addr, pub)
+		return k
+	}
+
+	cases := []struct {
+		description string
+		server      *httptest.Server
+		client      *PeerSyncClient
+		verified    bool
+	}{
+		{"unsigned", unsigned, &PeerSyncClient{trustPrompt: trustAll}, false},
+		{"unsigned, configured key", unsigned, &PeerSyncClient{keys: map[string]*config.RemoteKey{unsigned.URL: {PubKey: pub0}}}, false},
+		{"unsigned, previously trusted key", unsigned, &PeerSyncClient{trusted: trustedBy(unsigned.URL, pub0)}, false},
+		{"unsigned, insecure remote", unsigned, &PeerSyncClient{keys: map[string]*config.RemoteKey{unsigned.URL: {Insecure: true}}}, true},
+		{"tampered", tampered, &PeerSyncClient{trustPrompt: trustAll}, false},
+		{"unknown key, no prompt", signed, &PeerSyncClient{}, false},
+		{"unknown key, refused", signed, &PeerSyncClient{trustPrompt: func(string, string) bool { return false }}, false},
+		{"unknown key, trusted on first use", signed, &PeerSyncClient{trustPrompt: trustAll}, true},
+		{"configured key", signed, &PeerSyncClient{keys: map[string]*config.RemoteKey{signed.URL: {PubKey: pub0}}}, true},
+		{"configured key mismatch", signed, &PeerSyncClient{keys: map[string]*config.RemoteKey{signed.URL: {PubKey: pub1}}, trustPrompt: trustAll}, false},
+		{"previously trusted key", signed, &PeerSyncClient{trusted: trustedBy(signed.URL, pub0)}, true},
+		{"previously trusted key mismatch", signed, &PeerSyncClient{trusted: trustedBy(signed.URL, pub1), trustPrompt: trustAll}, false},
+	}
+
+	for _, c := range cases {
+		res, err := http.Get(c.server.URL + "/remote/refs?peername=me&name=cities")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.client.verifyResponse(c.server.URL, res, body)
+		if c.verified && err != nil {
+			t.Errorf("case %q: unexpected error: %s", c.description, err)
+		} else if !c.verified && ErrorCodeOf(err) != ErrCodeUnverified {
+			t.Errorf("case %q: expected an %q error, got: %v", c.description, ErrCodeUnverified, err)
+		}
+	}
+
+	// keys trusted on first use are recorded
+	cli := &PeerSyncClient{trustPrompt: trustAll, trusted: &repo.RemoteKeys{}}
+	res, err := http.Get(signed.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err := cli.verifyResponse(signed.URL, res, body); err != nil {
+		t.Fatal(err)
+	}
+	if pub, _ := cli.trusted.Get(signed.URL); pub != pub0 {
+		t.Errorf("expected remote key to be recorded")
+	}
+
+	// without a prompt no key is trusted on first use
+	cli = &PeerSyncClient{trusted: &repo.RemoteKeys{}}
+	if err := cli.verifyResponse(signed.URL, res, body); ErrorCodeOf(err) != ErrCodeUnverified {
+		t.Errorf("expected an unknown key to be refused without a prompt, got: %v", err)
+	}
+	if _, ok := cli.trusted.Get(signed.URL); ok {
+		t.Errorf("expected remote key not to be recorded without a prompt")
+	}
+
+	// keys trusted on first use must match later responses
+	cli = &PeerSyncClient{trusted: trustedBy(signed.URL, pub0)}
+	other := httptest.NewServer(signResponses(pk1, respond))
+	defer other.Close()
+	res, err = http.Get(other.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err := cli.verifyResponse(signed.URL, res, body); ErrorCodeOf(err) != ErrCodeUnverified {
+		t.Errorf("expected a different key than the one pinned on first use to be refused, got: %v", err)
+	}
+}
+
+func encodePubKey(t *testing.T, pk crypto.PrivKey) string {
+	data, err := crypto.MarshalPublicKey(pk.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
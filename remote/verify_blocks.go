@@ -0,0 +1,58 @@
+package remote
+
+import (
+	"context"
+	"sync"
+
+	cid "github.com/ipfs/go-cid"
+	"github.com/qri-io/dag/dsync"
+)
+
+// verifyingRemote wraps a dsync remote, checking every block it sends hashes
+// to the CID the block was requested by. A block that doesn't match fails the
+// request, so it never reaches the local block store
+type verifyingRemote struct {
+	dsync.DagSyncable
+
+	lk       sync.Mutex
+	verified int
+}
+
+// GetBlock fetches a block from the remote, verifying its content
+func (r *verifyingRemote) GetBlock(ctx context.Context, id string) ([]byte, error) {
+	data, err := r.DagSyncable.GetBlock(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyBlock(id, data); err != nil {
+		return nil, err
+	}
+	r.lk.Lock()
+	r.verified++
+	r.lk.Unlock()
+	return data, nil
+}
+
+// Verified is the number of blocks that matched their CID
+func (r *verifyingRemote) Verified() int {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	return r.verified
+}
+
+// verifyBlock checks block data hashes to a CID, using the CID's own version,
+// codec & hash function
+func verifyBlock(id string, data []byte) error {
+	c, err := cid.Parse(id)
+	if err != nil {
+		return NewError(ErrCodeCorrupt, "remote sent a block with an invalid CID %q: %s", id, err)
+	}
+	sum, err := c.Prefix().Sum(data)
+	if err != nil {
+		return NewError(ErrCodeCorrupt, "hashing block %s: %s", id, err)
+	}
+	if !sum.Equals(c) {
+		return NewError(ErrCodeCorrupt, "remote sent corrupt block %s: content hashes to %s", id, sum)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package remote
+
+import (
+	"testing"
+
+	cid "github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+func TestVerifyBlock(t *testing.T) {
+	data := []byte("block data")
+	sum, err := cid.Prefix{Version: 1, Codec: cid.Raw, MhType: mh.SHA2_256, MhLength: -1}.Sum(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyBlock(sum.String(), data); err != nil {
+		t.Errorf("expected matching block to verify, got: %s", err)
+	}
+	if err := verifyBlock(sum.String(), []byte("other data")); ErrorCodeOf(err) != ErrCodeCorrupt {
+		t.Errorf("expected mismatched block to be corrupt, got: %v", err)
+	}
+	if err := verifyBlock("not a cid", data); ErrorCodeOf(err) != ErrCodeCorrupt {
+		t.Errorf("expected invalid CID to be corrupt, got: %v", err)
+	}
+}
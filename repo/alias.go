@@ -0,0 +1,139 @@
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+)
+
+// ErrAliasNotPublishable means a dataset was published or pushed by an alias.
+// Aliases only exist in the repo that created them
+var ErrAliasNotPublishable = fmt.Errorf("aliases are local to this repo & can't be published")
+
+// Aliaser is an opt-in interface for repos that keep alternate names for
+// datasets in the repo owner's namespace
+type Aliaser interface {
+	Aliases() *Aliases
+}
+
+// Alias is an alternate name for a dataset in the repo owner's namespace
+type Alias struct {
+	Name string `json:"name"`
+	// Target is the name of the dataset the alias resolves to
+	Target string `json:"target"`
+}
+
+// Aliases maps alternate names to the names of datasets in the repo owner's
+// namespace, letting old names keep working after a rename. Aliases never
+// point at other aliases
+type Aliases struct {
+	lk   sync.Mutex
+	path string
+	// names maps alias names to target dataset names
+	names map[string]string
+}
+
+// NewAliases creates aliases that persist to a JSON file at path. An empty
+// path keeps aliases in memory
+func NewAliases(path string) (*Aliases, error) {
+	a := &Aliases{path: path, names: map[string]string{}}
+	if path == "" {
+		return a, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return a, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &a.names); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Get returns the name of the dataset an alias resolves to
+func (a *Aliases) Get(name string) (target string, ok bool) {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+	target, ok = a.names[name]
+	return target, ok
+}
+
+// Put sets an alias, replacing any alias of the same name
+func (a *Aliases) Put(name, target string) error {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+	if a.names == nil {
+		a.names = map[string]string{}
+	}
+	a.names[name] = target
+	return a.save()
+}
+
+// Delete removes an alias, returning ErrNotFound if no alias has the name
+func (a *Aliases) Delete(name string) error {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+	if _, ok := a.names[name]; !ok {
+		return ErrNotFound
+	}
+	delete(a.names, name)
+	return a.save()
+}
+
+// Retarget points the aliases of a dataset at its new name after a rename. An
+// alias of the new name is removed, the dataset has taken the name back
+func (a *Aliases) Retarget(prev, next string) error {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+	delete(a.names, next)
+	for name, target := range a.names {
+		if target == prev {
+			a.names[name] = next
+		}
+	}
+	return a.save()
+}
+
+// List returns all aliases, sorted by name
+func (a *Aliases) List() []Alias {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+	aliases := make([]Alias, 0, len(a.names))
+	for name, target := range a.names {
+		aliases = append(aliases, Alias{Name: name, Target: target})
+	}
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].Name < aliases[j].Name })
+	return aliases
+}
+
+func (a *Aliases) save() error {
+	if a.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(a.names)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(a.path, data, os.ModePerm)
+}
+
+// AliasTarget returns the name of the dataset an alias in the repo owner's
+// namespace resolves to. peername "me" is the repo owner
+func AliasTarget(r Repo, peername, name string) (string, bool) {
+	ar, ok := r.(Aliaser)
+	if !ok || ar.Aliases() == nil || name == "" {
+		return "", false
+	}
+	if peername != "me" {
+		pro, err := r.Profile()
+		if err != nil || pro.Peername != peername {
+			return "", false
+		}
+	}
+	return ar.Aliases().Get(name)
+}
@@ -0,0 +1,99 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestAliases(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestAliases")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "aliases.json")
+
+	aliases, err := NewAliases(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := aliases.Put("old_name", "new_name"); err != nil {
+		t.Fatal(err)
+	}
+	if err := aliases.Put("older_name", "new_name"); err != nil {
+		t.Fatal(err)
+	}
+	if err := aliases.Delete("missing"); err != ErrNotFound {
+		t.Errorf("expected deleting a missing alias to be ErrNotFound, got: %v", err)
+	}
+
+	// renaming new_name to old_name removes the old_name alias & repoints the rest
+	if err := aliases.Retarget("new_name", "old_name"); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewAliases(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := reloaded.List()
+	if len(got) != 1 || got[0] != (Alias{Name: "older_name", Target: "old_name"}) {
+		t.Errorf("unexpected aliases after reload: %v", got)
+	}
+}
+
+func TestAliasTarget(t *testing.T) {
+	r, err := NewMemRepo(testPeerProfile, cafs.NewMapstore(), qfs.NewMemFS(), profile.NewMemStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Aliases().Put("old_name", "new_name"); err != nil {
+		t.Fatal(err)
+	}
+	pro, err := r.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, peername := range []string{"me", pro.Peername} {
+		if target, ok := AliasTarget(r, peername, "old_name"); !ok || target != "new_name" {
+			t.Errorf("expected %s/old_name to resolve to new_name, got: %q %t", peername, target, ok)
+		}
+	}
+	if _, ok := AliasTarget(r, "someone_else", "old_name"); ok {
+		t.Error("expected aliases to only resolve in the repo owner's namespace")
+	}
+}
+
+func TestCanonicalizeAlias(t *testing.T) {
+	r, err := NewMemRepo(testPeerProfile, cafs.NewMapstore(), qfs.NewMemFS(), profile.NewMemStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pro, err := r.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := reporef.DatasetRef{Peername: pro.Peername, ProfileID: pro.ID, Name: "new_name", Path: "/map/QmA"}
+	if err := r.PutRef(ds); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Aliases().Put("old_name", "new_name"); err != nil {
+		t.Fatal(err)
+	}
+
+	ref := reporef.DatasetRef{Peername: "me", Name: "old_name"}
+	if err := CanonicalizeDatasetRef(r, &ref); err != nil {
+		t.Fatal(err)
+	}
+	if ref.Name != "new_name" || ref.Path != ds.Path {
+		t.Errorf("expected alias to canonicalize to the dataset, got: %s", ref)
+	}
+}
@@ -93,7 +93,7 @@ func NewFilesystem(cfg *config.Config, store cafs.Filestore) (qfs.Filesystem, er
 		"cafs":  store,
 	}
 
-	if ipfss, ok := store.(*ipfs.Filestore); ok {
+	if ipfss, ok := repo.UnwrapStore(store).(*ipfs.Filestore); ok {
 		mux["ipfs"] = ipfss
 	}
 
@@ -105,6 +105,16 @@ func NewFilesystem(cfg *config.Config, store cafs.Filestore) (qfs.Filesystem, er
 // we're in the process of absorbing cafs.Filestore into qfs.Filesystem, use
 // a qfs.Filesystem instead
 func NewCAFSStore(ctx context.Context, cfg *config.Config) (store cafs.Filestore, err error) {
+	if store, err = newCAFSStore(ctx, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Store.MaxConcurrentOps > 0 {
+		store = repo.NewLimitedStore(store, cfg.Store.MaxConcurrentOps)
+	}
+	return store, nil
+}
+
+func newCAFSStore(ctx context.Context, cfg *config.Config) (store cafs.Filestore, err error) {
 	switch cfg.Store.Type {
 	case "ipfs":
 		path := cfg.Store.Path
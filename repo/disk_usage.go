@@ -0,0 +1,172 @@
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// DiskUsager is an opt-in interface for repos that keep an account of the
+// bytes their store holds
+type DiskUsager interface {
+	DiskUsage() *DiskUsage
+}
+
+// DiskUsage is a running account of the bytes a repo stores for datasets,
+// along with optional quotas on that usage. Accounting is updated as
+// datasets are saved, added, removed & unpinned. Updates are estimates that
+// drift from what the store actually holds, Reset reconciles the account
+// with a measurement of the store
+type DiskUsage struct {
+	lk   sync.Mutex
+	path string
+	rec  diskUsageRecord
+
+	maxSize        int64
+	maxVersionSize int64
+}
+
+type diskUsageRecord struct {
+	Bytes        int64     `json:"bytes"`
+	Recalculated time.Time `json:"recalculated,omitempty"`
+}
+
+// NewDiskUsage creates an account that persists to a JSON file at path. An
+// empty path keeps the account in memory
+func NewDiskUsage(path string) (*DiskUsage, error) {
+	u := &DiskUsage{path: path}
+	if path == "" {
+		return u, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return u, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &u.rec); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Bytes gives the number of bytes the repo is accounted to store
+func (u *DiskUsage) Bytes() int64 {
+	u.lk.Lock()
+	defer u.lk.Unlock()
+	return u.rec.Bytes
+}
+
+// Recalculated gives the last time the account was reconciled with a
+// measurement of the store, zero if it never has been
+func (u *DiskUsage) Recalculated() time.Time {
+	u.lk.Lock()
+	defer u.lk.Unlock()
+	return u.rec.Recalculated
+}
+
+// Add changes the account by delta bytes, negative deltas account for freed
+// bytes. The account never drops below zero
+func (u *DiskUsage) Add(delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	u.lk.Lock()
+	defer u.lk.Unlock()
+	u.rec.Bytes += delta
+	if u.rec.Bytes < 0 {
+		u.rec.Bytes = 0
+	}
+	return u.save()
+}
+
+// Reset replaces the account with a measured number of bytes
+func (u *DiskUsage) Reset(bytes int64) error {
+	u.lk.Lock()
+	defer u.lk.Unlock()
+	u.rec.Bytes = bytes
+	u.rec.Recalculated = time.Now()
+	return u.save()
+}
+
+// SetQuota limits the total bytes the repo stores & the bytes a single
+// dataset version adds. Zero means no limit. Quotas come from configuration
+// & aren't persisted with the account
+func (u *DiskUsage) SetQuota(maxSize, maxVersionSize int64) {
+	u.lk.Lock()
+	defer u.lk.Unlock()
+	u.maxSize = maxSize
+	u.maxVersionSize = maxVersionSize
+}
+
+// Quota gives the repo & version size limits, zero means no limit
+func (u *DiskUsage) Quota() (maxSize, maxVersionSize int64) {
+	u.lk.Lock()
+	defer u.lk.Unlock()
+	return u.maxSize, u.maxVersionSize
+}
+
+// HasQuota reports whether any limit is set
+func (u *DiskUsage) HasQuota() bool {
+	maxSize, maxVersionSize := u.Quota()
+	return maxSize > 0 || maxVersionSize > 0
+}
+
+// CheckQuota errors with a *QuotaExceededError if storing a version that
+// adds size bytes would break a quota
+func (u *DiskUsage) CheckQuota(size int64) error {
+	u.lk.Lock()
+	defer u.lk.Unlock()
+	if u.maxVersionSize > 0 && size > u.maxVersionSize {
+		return &QuotaExceededError{Quota: QuotaVersionSize, Usage: u.rec.Bytes, Size: size, Limit: u.maxVersionSize}
+	}
+	if u.maxSize > 0 && u.rec.Bytes+size > u.maxSize {
+		return &QuotaExceededError{Quota: QuotaRepoSize, Usage: u.rec.Bytes, Size: size, Limit: u.maxSize}
+	}
+	return nil
+}
+
+func (u *DiskUsage) save() error {
+	if u.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(u.rec)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(u.path, data, os.ModePerm)
+}
+
+const (
+	// QuotaRepoSize limits the total bytes a repo stores
+	QuotaRepoSize = "repo size"
+	// QuotaVersionSize limits the bytes a single dataset version adds
+	QuotaVersionSize = "version size"
+)
+
+// QuotaExceededError is returned when storing a dataset version would break
+// a quota
+type QuotaExceededError struct {
+	// Quota is the limit that would be broken, QuotaRepoSize or
+	// QuotaVersionSize
+	Quota string
+	// Usage is the number of bytes the repo is accounted to store
+	Usage int64
+	// Size is the number of bytes the version adds
+	Size int64
+	// Limit is the quota in bytes
+	Limit int64
+}
+
+// Error implements the error interface
+func (e *QuotaExceededError) Error() string {
+	if e.Quota == QuotaVersionSize {
+		return fmt.Sprintf("version is %s, over the %s version size limit", humanize.Bytes(uint64(e.Size)), humanize.Bytes(uint64(e.Limit)))
+	}
+	return fmt.Sprintf("adding %s would exceed the %s repo size limit, the repo is using %s", humanize.Bytes(uint64(e.Size)), humanize.Bytes(uint64(e.Limit)), humanize.Bytes(uint64(e.Usage)))
+}
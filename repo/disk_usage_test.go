@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskUsage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestDiskUsage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "disk_usage.json")
+
+	u, err := NewDiskUsage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Add(100); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Add(-30); err != nil {
+		t.Fatal(err)
+	}
+
+	// usage survives reloading
+	u, err = NewDiskUsage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Bytes() != 70 {
+		t.Errorf("expected 70 bytes, got: %d", u.Bytes())
+	}
+	if !u.Recalculated().IsZero() {
+		t.Errorf("expected usage that's never been recalculated to have a zero time")
+	}
+
+	// freeing more than is accounted for clamps to zero
+	if err := u.Add(-500); err != nil {
+		t.Fatal(err)
+	}
+	if u.Bytes() != 0 {
+		t.Errorf("expected usage to clamp to zero, got: %d", u.Bytes())
+	}
+	if err := u.Reset(900); err != nil {
+		t.Fatal(err)
+	}
+	if u.Bytes() != 900 || u.Recalculated().IsZero() {
+		t.Errorf("expected reset to record bytes & recalculation time")
+	}
+
+	if err := u.CheckQuota(1 << 30); err != nil {
+		t.Errorf("expected no quota to allow any size, got: %s", err)
+	}
+
+	u.SetQuota(1000, 200)
+	if err := u.CheckQuota(100); err != nil {
+		t.Errorf("expected size within quota to pass, got: %s", err)
+	}
+
+	err = u.CheckQuota(250)
+	qe, ok := err.(*QuotaExceededError)
+	if !ok {
+		t.Fatalf("expected a QuotaExceededError, got: %v", err)
+	}
+	if qe.Quota != QuotaVersionSize {
+		t.Errorf("expected version size quota to be exceeded, got: %q", qe.Quota)
+	}
+
+	err = u.CheckQuota(150)
+	qe, ok = err.(*QuotaExceededError)
+	if !ok {
+		t.Fatalf("expected a QuotaExceededError, got: %v", err)
+	}
+	if qe.Quota != QuotaRepoSize || qe.Usage != 900 || qe.Limit != 1000 {
+		t.Errorf("unexpected quota error: %#v", qe)
+	}
+	expect := "adding 150 B would exceed the 1.0 kB repo size limit, the repo is using 900 B"
+	if err.Error() != expect {
+		t.Errorf("error mismatch. expected: %q, got: %q", expect, err.Error())
+	}
+}
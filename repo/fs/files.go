@@ -61,6 +61,25 @@ const (
 	FileSelectedRefs
 	// FileChangeRequests is a file of change requests
 	FileChangeRequests
+	// FileRefsIndex is a leveldb directory indexing this repo's dataset
+	// references
+	FileRefsIndex
+	// FileTrash is a list of removed dataset references kept until the trash
+	// is emptied
+	FileTrash
+	// FilePublishQueue is a list of datasets waiting to be published
+	FilePublishQueue
+	// FilePinPolicies records how many versions of each dataset stay pinned
+	FilePinPolicies
+	// FileRemoteKeys records the keys remotes were trusted to sign responses
+	// with
+	FileRemoteKeys
+	// FileDiskUsage records the number of bytes the repo stores
+	FileDiskUsage
+	// FileAliases maps alternate names of datasets to the datasets they name
+	FileAliases
+	// FilePrefetches records dataset versions pinned by prefetching
+	FilePrefetches
 )
 
 var paths = map[File]string{
@@ -78,6 +97,14 @@ var paths = map[File]string{
 	FileSearchIndex:    "/index.bleve",
 	FileSelectedRefs:   "/selected_refs.json",
 	FileChangeRequests: "/change_requests.json",
+	FileRefsIndex:      "/refs",
+	FileTrash:          "/trash.json",
+	FilePublishQueue:   "/publish_queue.json",
+	FilePinPolicies:    "/pin_policies.json",
+	FileRemoteKeys:     "/remote_keys.json",
+	FileDiskUsage:      "/disk_usage.json",
+	FileAliases:        "/aliases.json",
+	FilePrefetches:     "/prefetches.json",
 }
 
 // Filepath gives the relative filepath to a repofiles
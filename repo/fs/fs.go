@@ -3,7 +3,9 @@ package fsrepo
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	golog "github.com/ipfs/go-log"
 	crypto "github.com/libp2p/go-libp2p-core/crypto"
@@ -14,6 +16,7 @@ import (
 	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
 )
 
 var log = golog.Logger("fsrepo")
@@ -30,11 +33,18 @@ type Repo struct {
 
 	profile *profile.Profile
 
-	store   cafs.Filestore
-	fsys    qfs.Filesystem
-	graph   map[string]*dsgraph.Node
-	logbook *logbook.Book
-	dscache *dscache.Dscache
+	store      cafs.Filestore
+	fsys       qfs.Filesystem
+	graph      map[string]*dsgraph.Node
+	logbook    *logbook.Book
+	dscache    *dscache.Dscache
+	trash      *repo.Trash
+	pubq       *repo.PublishQueue
+	pins       *repo.PinPolicies
+	prefetches *repo.Prefetches
+	rkeys      *repo.RemoteKeys
+	usage      *repo.DiskUsage
+	aliases    *repo.Aliases
 
 	profiles *ProfileStore
 }
@@ -42,7 +52,7 @@ type Repo struct {
 // NewRepo creates a new file-based repository
 //
 // Deprecated: use CreateRepo instead
-func NewRepo(store cafs.Filestore, fsys qfs.Filesystem, book *logbook.Book, cache *dscache.Dscache, pro *profile.Profile, base string) (repo.Repo, error) {
+func NewRepo(store cafs.Filestore, fsys qfs.Filesystem, book *logbook.Book, cache *dscache.Dscache, pro *profile.Profile, base string) (_ repo.Repo, err error) {
 	if err := os.MkdirAll(base, os.ModePerm); err != nil {
 		return nil, err
 	}
@@ -60,8 +70,6 @@ func NewRepo(store cafs.Filestore, fsys qfs.Filesystem, book *logbook.Book, cach
 		logbook:  book,
 		dscache:  cache,
 
-		Refstore: Refstore{basepath: bp, store: store, file: FileRefs},
-
 		profiles: NewProfileStore(bp),
 	}
 
@@ -69,6 +77,39 @@ func NewRepo(store cafs.Filestore, fsys qfs.Filesystem, book *logbook.Book, cach
 		return nil, err
 	}
 
+	refs, err := NewIndexedRefstore(base)
+	if err != nil {
+		return nil, err
+	}
+	r.Refstore = refs
+	defer func() {
+		if err != nil {
+			refs.Close()
+		}
+	}()
+
+	if r.trash, err = repo.NewTrash(bp.filepath(FileTrash)); err != nil {
+		return nil, err
+	}
+	if r.pubq, err = repo.NewPublishQueue(bp.filepath(FilePublishQueue)); err != nil {
+		return nil, err
+	}
+	if r.pins, err = repo.NewPinPolicies(bp.filepath(FilePinPolicies)); err != nil {
+		return nil, err
+	}
+	if r.prefetches, err = repo.NewPrefetches(bp.filepath(FilePrefetches)); err != nil {
+		return nil, err
+	}
+	if r.rkeys, err = repo.NewRemoteKeys(bp.filepath(FileRemoteKeys)); err != nil {
+		return nil, err
+	}
+	if r.usage, err = repo.NewDiskUsage(bp.filepath(FileDiskUsage)); err != nil {
+		return nil, err
+	}
+	if r.aliases, err = repo.NewAliases(bp.filepath(FileAliases)); err != nil {
+		return nil, err
+	}
+
 	// add our own profile to the store if it doesn't already exist.
 	if _, e := r.Profiles().GetProfile(pro.ID); e != nil {
 		if err := r.Profiles().PutProfile(pro); err != nil {
@@ -79,6 +120,65 @@ func NewRepo(store cafs.Filestore, fsys qfs.Filesystem, book *logbook.Book, cach
 	return r, nil
 }
 
+// Trash gives access to datasets that have been removed, but not yet deleted
+func (r *Repo) Trash() *repo.Trash {
+	return r.trash
+}
+
+// PublishQueue gives access to datasets waiting to be published
+func (r *Repo) PublishQueue() *repo.PublishQueue {
+	return r.pubq
+}
+
+// PinPolicies gives access to how many versions of each dataset stay pinned
+func (r *Repo) PinPolicies() *repo.PinPolicies {
+	return r.pins
+}
+
+// Prefetches gives access to dataset versions pinned by prefetching
+func (r *Repo) Prefetches() *repo.Prefetches {
+	return r.prefetches
+}
+
+// RemoteKeys gives access to the keys remotes were trusted to sign responses
+// with
+func (r *Repo) RemoteKeys() *repo.RemoteKeys {
+	return r.rkeys
+}
+
+// DiskUsage gives access to the account of bytes this repo stores
+func (r *Repo) DiskUsage() *repo.DiskUsage {
+	return r.usage
+}
+
+// Aliases gives access to alternate names of datasets in this repo
+func (r *Repo) Aliases() *repo.Aliases {
+	return r.aliases
+}
+
+// RefsFold returns references with a peername & name that match ignoring case,
+// implementing the repo.FoldRefstore interface
+func (r *Repo) RefsFold(peername, name string) ([]reporef.DatasetRef, error) {
+	if fr, ok := r.Refstore.(repo.FoldRefstore); ok {
+		return fr.RefsFold(peername, name)
+	}
+	count, err := r.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.References(0, count)
+	if err != nil {
+		return nil, err
+	}
+	var matched []reporef.DatasetRef
+	for _, ref := range refs {
+		if strings.EqualFold(ref.Peername, peername) && (name == "" || strings.EqualFold(ref.Name, name)) {
+			matched = append(matched, ref)
+		}
+	}
+	return matched, nil
+}
+
 // Path returns the path to the root of the repo directory
 func (r Repo) Path() string {
 	return string(r.basepath)
@@ -130,7 +230,19 @@ func (r *Repo) Profiles() profile.Store {
 	return r.profiles
 }
 
-// Destroy destroys this repository
+// Close releases the repo's handle on its reference index. A closed repo
+// can't read or write references
+func (r *Repo) Close() error {
+	if c, ok := r.Refstore.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Destroy closes & destroys this repository
 func (r *Repo) Destroy() error {
+	if err := r.Close(); err != nil {
+		log.Debugf("closing repo: %s", err)
+	}
 	return os.RemoveAll(string(r.basepath))
 }
@@ -0,0 +1,375 @@
+package fsrepo
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/syndtr/goleveldb/leveldb"
+	ldberrors "github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// IndexedRefstore is an implementation of the Refstore interface backed by an
+// embedded leveldb database. References are stored once, keyed by profileID
+// and name, with secondary indexes on peername & name, peername & name ignoring
+// case, and dataset path. Unlike
+// the flatfile Refstore, puts & lookups don't need to read every reference.
+// IndexedRefstore is safe for concurrent use
+type IndexedRefstore struct {
+	*refsDB
+	closeOnce sync.Once
+}
+
+// key prefixes. refs are stored once under refPrefix, other prefixes map to
+// a ref key
+const (
+	refPrefix      = "r/"
+	peernamePrefix = "n/"
+	foldPrefix     = "f/"
+	pathPrefix     = "p/"
+	migratedKey    = "m/migrated"
+	foldIndexedKey = "m/fold_indexed"
+)
+
+// NewIndexedRefstore opens the reference index within a repo directory,
+// creating it if necessary. The first time an index is created any existing
+// flatfile references are imported
+func NewIndexedRefstore(repoPath string) (*IndexedRefstore, error) {
+	db, err := openRefsDB(basepath(repoPath).filepath(FileRefsIndex))
+	if err != nil {
+		return nil, err
+	}
+	rs := &IndexedRefstore{refsDB: db}
+	if err := maybeImportFlatbufferRefs(repoPath, rs); err != nil {
+		rs.Close()
+		return nil, fmt.Errorf("importing references: %s", err)
+	}
+	if err := maybeIndexFold(rs); err != nil {
+		rs.Close()
+		return nil, fmt.Errorf("indexing references: %s", err)
+	}
+	return rs, nil
+}
+
+// Close releases the refstore's handle on the reference index. The index is
+// closed when every refstore sharing it has been closed. Closing more than
+// once is a no-op
+func (rs *IndexedRefstore) Close() (err error) {
+	rs.closeOnce.Do(func() {
+		err = rs.refsDB.release()
+	})
+	return err
+}
+
+// PutRef adds a reference to the store, replacing any existing references it
+// matches
+func (rs *IndexedRefstore) PutRef(r reporef.DatasetRef) error {
+	// remove dataset reference, refstores only store reference details
+	r.Dataset = nil
+
+	if r.ProfileID == "" {
+		return repo.ErrPeerIDRequired
+	} else if r.Name == "" {
+		return repo.ErrNameRequired
+	} else if r.Path == "" && r.FSIPath == "" {
+		return repo.ErrPathRequired
+	} else if r.Peername == "" {
+		return repo.ErrPeernameRequired
+	}
+
+	rs.lk.Lock()
+	defer rs.lk.Unlock()
+
+	batch := &leveldb.Batch{}
+	keys, err := rs.matchingKeys(r)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := rs.deleteRef(batch, key); err != nil {
+			return err
+		}
+	}
+
+	putRef(batch, r)
+	return rs.db.Write(batch, nil)
+}
+
+// GetRef completes a partially-known reference
+func (rs *IndexedRefstore) GetRef(get reporef.DatasetRef) (reporef.DatasetRef, error) {
+	rs.lk.RLock()
+	defer rs.lk.RUnlock()
+
+	keys, err := rs.matchingKeys(get)
+	if err != nil {
+		return reporef.DatasetRef{}, err
+	}
+	if len(keys) == 0 {
+		return reporef.DatasetRef{}, repo.ErrNotFound
+	}
+	return rs.getRef(keys[0])
+}
+
+// DeleteRef removes a reference from the store
+func (rs *IndexedRefstore) DeleteRef(del reporef.DatasetRef) error {
+	rs.lk.Lock()
+	defer rs.lk.Unlock()
+
+	keys, err := rs.matchingKeys(del)
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+
+	batch := &leveldb.Batch{}
+	if err := rs.deleteRef(batch, keys[0]); err != nil {
+		return err
+	}
+	return rs.db.Write(batch, nil)
+}
+
+// References gives a set of dataset references from the store, ordered by
+// peername and name. A negative limit returns all references past offset
+func (rs *IndexedRefstore) References(offset, limit int) ([]reporef.DatasetRef, error) {
+	rs.lk.RLock()
+	defer rs.lk.RUnlock()
+
+	refs := []reporef.DatasetRef{}
+	iter := rs.db.NewIterator(util.BytesPrefix([]byte(peernamePrefix)), nil)
+	defer iter.Release()
+	for i := 0; iter.Next(); i++ {
+		if i < offset {
+			continue
+		}
+		if limit >= 0 && len(refs) == limit {
+			break
+		}
+		ref, err := rs.getRef(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, iter.Error()
+}
+
+// RefsFold returns references with a peername & name that match ignoring case.
+// An empty name returns all references made by the peer
+func (rs *IndexedRefstore) RefsFold(peername, name string) ([]reporef.DatasetRef, error) {
+	rs.lk.RLock()
+	defer rs.lk.RUnlock()
+
+	prefix := foldPrefix + strings.ToLower(peername) + "/"
+	if name != "" {
+		prefix += strings.ToLower(name) + "/"
+	}
+	refs := []reporef.DatasetRef{}
+	iter := rs.db.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+	defer iter.Release()
+	for iter.Next() {
+		ref, err := rs.getRef(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, iter.Error()
+}
+
+// RefCount returns the number of references in the store
+func (rs *IndexedRefstore) RefCount() (int, error) {
+	rs.lk.RLock()
+	defer rs.lk.RUnlock()
+
+	count := 0
+	iter := rs.db.NewIterator(util.BytesPrefix([]byte(refPrefix)), nil)
+	defer iter.Release()
+	for iter.Next() {
+		count++
+	}
+	return count, iter.Error()
+}
+
+// matchingKeys finds the keys of stored references that match ref, following
+// the rules of reporef.DatasetRef.Match. callers must hold the lock
+func (rs *IndexedRefstore) matchingKeys(ref reporef.DatasetRef) (keys [][]byte, err error) {
+	add := func(key []byte) {
+		for _, k := range keys {
+			if string(k) == string(key) {
+				return
+			}
+		}
+		keys = append(keys, key)
+	}
+	lookup := func(indexKey []byte) error {
+		key, err := rs.db.Get(indexKey, nil)
+		if err == leveldb.ErrNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		add(key)
+		return nil
+	}
+
+	if ref.Path != "" {
+		if err = lookup(pathKey(ref.Path)); err != nil {
+			return nil, err
+		}
+	}
+	if ref.Name != "" {
+		if ref.ProfileID != "" {
+			key := refKey(ref)
+			if has, err := rs.db.Has(key, nil); err != nil {
+				return nil, err
+			} else if has {
+				add(key)
+			}
+		}
+		if ref.Peername != "" {
+			if err = lookup(peernameKey(ref)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return keys, nil
+}
+
+// getRef reads a stored reference. callers must hold the lock
+func (rs *IndexedRefstore) getRef(key []byte) (reporef.DatasetRef, error) {
+	data, err := rs.db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return reporef.DatasetRef{}, repo.ErrNotFound
+	} else if err != nil {
+		return reporef.DatasetRef{}, err
+	}
+	refs, err := repo.UnmarshalRefsFlatbuffer(data)
+	if err != nil {
+		return reporef.DatasetRef{}, err
+	}
+	if len(refs) != 1 {
+		return reporef.DatasetRef{}, fmt.Errorf("invalid stored reference %q", string(key))
+	}
+	return refs[0], nil
+}
+
+// deleteRef adds the removal of a stored reference and it's index entries to
+// a batch. callers must hold the lock
+func (rs *IndexedRefstore) deleteRef(batch *leveldb.Batch, key []byte) error {
+	ref, err := rs.getRef(key)
+	if err != nil {
+		return err
+	}
+	batch.Delete(key)
+	batch.Delete(peernameKey(ref))
+	batch.Delete(foldKey(ref))
+	if ref.Path != "" {
+		// paths aren't unique to a reference, only drop index entries that point
+		// to the reference being removed
+		if pk, err := rs.db.Get(pathKey(ref.Path), nil); err == nil && string(pk) == string(key) {
+			batch.Delete(pathKey(ref.Path))
+		}
+	}
+	return nil
+}
+
+// putRef adds a reference and it's index entries to a batch
+func putRef(batch *leveldb.Batch, ref reporef.DatasetRef) {
+	key := refKey(ref)
+	batch.Put(key, repo.FlatbufferBytes(repo.RefList{ref}))
+	batch.Put(peernameKey(ref), key)
+	batch.Put(foldKey(ref), key)
+	if ref.Path != "" {
+		batch.Put(pathKey(ref.Path), key)
+	}
+}
+
+func refKey(ref reporef.DatasetRef) []byte {
+	return []byte(refPrefix + ref.ProfileID.String() + "/" + ref.Name)
+}
+
+func peernameKey(ref reporef.DatasetRef) []byte {
+	return []byte(peernamePrefix + ref.Peername + "/" + ref.Name)
+}
+
+// foldKey indexes references by lowercased peername & name. The profileID
+// keeps names that differ only by case distinct
+func foldKey(ref reporef.DatasetRef) []byte {
+	return []byte(foldPrefix + strings.ToLower(ref.Peername) + "/" + strings.ToLower(ref.Name) + "/" + ref.ProfileID.String())
+}
+
+func pathKey(path string) []byte {
+	return []byte(pathPrefix + path)
+}
+
+// refsDB is a leveldb database shared by all refstores in a process that use
+// the same directory. leveldb only allows one open handle per directory
+type refsDB struct {
+	// lk serializes read-modify-write operations across refstores
+	lk   sync.RWMutex
+	db   *leveldb.DB
+	path string
+	dir  os.FileInfo
+	// refs counts the refstores using the database, guarded by openRefsDBsLk
+	refs   int
+	closed bool
+}
+
+var (
+	openRefsDBsLk sync.Mutex
+	openRefsDBs   = map[string]*refsDB{}
+)
+
+// openRefsDB opens or reuses a database at path. Databases whose directory
+// has been removed since they were opened are closed & recreated
+func openRefsDB(path string) (*refsDB, error) {
+	openRefsDBsLk.Lock()
+	defer openRefsDBsLk.Unlock()
+
+	if rdb, ok := openRefsDBs[path]; ok {
+		if fi, err := os.Stat(path); err == nil && os.SameFile(fi, rdb.dir) {
+			rdb.refs++
+			return rdb, nil
+		}
+		rdb.db.Close()
+		rdb.closed = true
+		delete(openRefsDBs, path)
+	}
+
+	db, err := leveldb.OpenFile(path, nil)
+	if ldberrors.IsCorrupted(err) {
+		log.Errorf("reference index is corrupted, attempting recovery: %s", err)
+		db, err = leveldb.RecoverFile(path, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening reference index: %s", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	rdb := &refsDB{db: db, path: path, dir: fi, refs: 1}
+	openRefsDBs[path] = rdb
+	return rdb, nil
+}
+
+// release drops a refstore's use of a database, closing it once no refstores
+// use it
+func (rdb *refsDB) release() error {
+	openRefsDBsLk.Lock()
+	defer openRefsDBsLk.Unlock()
+
+	if rdb.refs--; rdb.refs > 0 || rdb.closed {
+		return nil
+	}
+	if openRefsDBs[rdb.path] == rdb {
+		delete(openRefsDBs, rdb.path)
+	}
+	rdb.closed = true
+	return rdb.db.Close()
+}
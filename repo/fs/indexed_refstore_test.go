@@ -0,0 +1,249 @@
+package fsrepo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestIndexedRefstoreImportsFlatbufferRefs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qri_test_indexed_refstore_import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	proID := profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt")
+	refs := repo.RefList{
+		{ProfileID: proID, Peername: "peer", Name: "a", Path: "/map/a"},
+		{ProfileID: proID, Peername: "peer", Name: "b", Path: "/map/b"},
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, Filepath(FileRefs)), repo.FlatbufferBytes(refs), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	rs, err := NewIndexedRefstore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, err := rs.RefCount(); err != nil {
+		t.Fatal(err)
+	} else if count != 2 {
+		t.Errorf("ref count mismatch. expected: 2, got: %d", count)
+	}
+
+	got, err := rs.GetRef(reporef.DatasetRef{Path: "/map/b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "b" {
+		t.Errorf("expected path lookup to find ref 'b', got: %s", got)
+	}
+
+	// the import only happens once, changes to the flatbuffer file are ignored
+	if err := rs.DeleteRef(refs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if rs, err = NewIndexedRefstore(dir); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := rs.RefCount(); err != nil {
+		t.Fatal(err)
+	} else if count != 1 {
+		t.Errorf("ref count mismatch after reopening. expected: 1, got: %d", count)
+	}
+}
+
+func TestIndexedRefstoreConcurrentAccess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qri_test_indexed_refstore_concurrent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rs, err := NewIndexedRefstore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proID := profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt")
+	workers, perWorker := 8, 50
+
+	wg := sync.WaitGroup{}
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				ref := reporef.DatasetRef{
+					ProfileID: proID,
+					Peername:  "peer",
+					Name:      fmt.Sprintf("ds_%d_%d", w, i),
+					Path:      fmt.Sprintf("/map/%d/%d", w, i),
+				}
+				if err := rs.PutRef(ref); err != nil {
+					errs <- err
+					return
+				}
+				got, err := rs.GetRef(reporef.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+				if err != nil {
+					errs <- err
+					return
+				}
+				if got.Path != ref.Path {
+					errs <- fmt.Errorf("path mismatch for %s. expected: %s, got: %s", ref.Name, ref.Path, got.Path)
+					return
+				}
+				// remove every other ref
+				if i%2 == 0 {
+					if err := rs.DeleteRef(ref); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	expect := workers * perWorker / 2
+	if count, err := rs.RefCount(); err != nil {
+		t.Fatal(err)
+	} else if count != expect {
+		t.Errorf("ref count mismatch. expected: %d, got: %d", expect, count)
+	}
+	refs, err := rs.References(0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != expect {
+		t.Errorf("references length mismatch. expected: %d, got: %d", expect, len(refs))
+	}
+	if _, err := rs.GetRef(reporef.DatasetRef{Path: "/map/0/0"}); err != repo.ErrNotFound {
+		t.Errorf("expected deleted ref to be missing, got: %v", err)
+	}
+}
+
+func TestIndexedRefstoreRefsFold(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qri_test_indexed_refstore_fold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rs, err := NewIndexedRefstore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proID := profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt")
+	refs := []reporef.DatasetRef{
+		{ProfileID: proID, Peername: "peer", Name: "Cities", Path: "/map/a"},
+		{ProfileID: proID, Peername: "peer", Name: "movies", Path: "/map/b"},
+		{ProfileID: proID, Peername: "peer_two", Name: "cities", Path: "/map/c"},
+	}
+	for _, ref := range refs {
+		if err := rs.PutRef(ref); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := rs.RefsFold("PEER", "CITIES")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Path != "/map/a" {
+		t.Errorf("expected case-insensitive match for peer/Cities, got: %v", got)
+	}
+	if got, err = rs.RefsFold("peer", ""); err != nil {
+		t.Fatal(err)
+	} else if len(got) != 2 {
+		t.Errorf("expected 2 references by peer, got: %d", len(got))
+	}
+
+	if err := rs.DeleteRef(refs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = rs.RefsFold("peer", "cities"); err != nil {
+		t.Fatal(err)
+	} else if len(got) != 0 {
+		t.Errorf("expected deleted reference to be removed from the index, got: %v", got)
+	}
+
+	// indexes created before the case-insensitive index are indexed on open
+	if err := rs.db.Delete(foldKey(refs[1]), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.db.Delete([]byte(foldIndexedKey), nil); err != nil {
+		t.Fatal(err)
+	}
+	if rs, err = NewIndexedRefstore(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = rs.RefsFold("peer", "MOVIES"); err != nil {
+		t.Fatal(err)
+	} else if len(got) != 1 {
+		t.Errorf("expected reopened index to include movies, got: %v", got)
+	}
+}
+
+func TestIndexedRefstoreClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qri_test_indexed_refstore_close")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	proID := profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt")
+	ref := reporef.DatasetRef{ProfileID: proID, Peername: "peer", Name: "a", Path: "/map/a"}
+
+	a, err := NewIndexedRefstore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewIndexedRefstore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.PutRef(ref); err != nil {
+		t.Fatal(err)
+	}
+
+	// the index stays open while any refstore uses it
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Close(); err != nil {
+		t.Errorf("expected closing twice to be a no-op, got: %s", err)
+	}
+	if _, err := b.GetRef(reporef.DatasetRef{Path: ref.Path}); err != nil {
+		t.Errorf("expected open refstore to read after another closes, got: %s", err)
+	}
+
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.GetRef(reporef.DatasetRef{Path: ref.Path}); err == nil {
+		t.Error("expected reading from a closed index to error")
+	}
+
+	c, err := NewIndexedRefstore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.GetRef(reporef.DatasetRef{Path: ref.Path}); err != nil {
+		t.Errorf("expected a reopened index to read, got: %s", err)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 
 	"github.com/qri-io/qri/repo"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // maybeCreateFlatbufferRefsFile creates a flatbuffer from an existing ds_refs
@@ -27,3 +29,63 @@ func maybeCreateFlatbufferRefsFile(repoPath string) (migrated bool, err error) {
 	}
 	return false, nil
 }
+
+// maybeImportFlatbufferRefs copies references from the flatbuffer refs file
+// into an indexed refstore. The import only happens once, the flatbuffer file
+// is left in place but no longer written to
+func maybeImportFlatbufferRefs(repoPath string, rs *IndexedRefstore) error {
+	rs.lk.Lock()
+	defer rs.lk.Unlock()
+
+	if done, err := rs.db.Has([]byte(migratedKey), nil); err != nil || done {
+		return err
+	}
+
+	fbPath := filepath.Join(repoPath, Filepath(FileRefs))
+	data, err := ioutil.ReadFile(fbPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	batch := &leveldb.Batch{}
+	if len(data) > 0 {
+		refs, err := repo.UnmarshalRefsFlatbuffer(data)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			putRef(batch, ref)
+		}
+		log.Infof("imported %d references into reference index", len(refs))
+	}
+	batch.Put([]byte(migratedKey), []byte(fbPath))
+	return rs.db.Write(batch, nil)
+}
+
+// maybeIndexFold adds the case-insensitive index to reference indexes created
+// before it existed. Indexing only happens once
+func maybeIndexFold(rs *IndexedRefstore) error {
+	rs.lk.Lock()
+	defer rs.lk.Unlock()
+
+	if done, err := rs.db.Has([]byte(foldIndexedKey), nil); err != nil || done {
+		return err
+	}
+
+	batch := &leveldb.Batch{}
+	iter := rs.db.NewIterator(util.BytesPrefix([]byte(refPrefix)), nil)
+	for iter.Next() {
+		ref, err := rs.getRef(iter.Key())
+		if err != nil {
+			iter.Release()
+			return err
+		}
+		batch.Put(foldKey(ref), append([]byte(nil), iter.Key()...))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	batch.Put([]byte(foldIndexedKey), []byte{})
+	return rs.db.Write(batch, nil)
+}
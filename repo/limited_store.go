@@ -0,0 +1,175 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
+)
+
+// LimitedStore wraps a content-addressed store, bounding the number of
+// store operations that can run at once. Calls past the limit wait for a
+// running operation to finish. The limit covers each call, files returned by
+// Get are read after their slot is released
+type LimitedStore struct {
+	cafs.Filestore
+	sem      chan struct{}
+	inFlight int64
+}
+
+// compile-time assertions that LimitedStore keeps the opt-in interfaces all
+// stores in use implement
+var (
+	_ cafs.Pinner  = (*LimitedStore)(nil)
+	_ cafs.Fetcher = (*LimitedStore)(nil)
+)
+
+// NewLimitedStore allows at most maxOps concurrent operations on store
+func NewLimitedStore(store cafs.Filestore, maxOps int) *LimitedStore {
+	if maxOps < 1 {
+		maxOps = 1
+	}
+	return &LimitedStore{
+		Filestore: store,
+		sem:       make(chan struct{}, maxOps),
+	}
+}
+
+// UnwrapStore returns the store a LimitedStore wraps. Other stores are
+// returned as-is
+func UnwrapStore(store cafs.Filestore) cafs.Filestore {
+	for {
+		ls, ok := store.(*LimitedStore)
+		if !ok {
+			return store
+		}
+		store = ls.Filestore
+	}
+}
+
+// MaxOps returns the number of operations the store will run at once
+func (s *LimitedStore) MaxOps() int {
+	return cap(s.sem)
+}
+
+// InFlight returns the number of operations currently running
+func (s *LimitedStore) InFlight() int {
+	return int(atomic.LoadInt64(&s.inFlight))
+}
+
+// acquire waits for an operation slot, or for the context to be cancelled
+func (s *LimitedStore) acquire(ctx context.Context) error {
+	select {
+	case s.sem <- struct{}{}:
+		atomic.AddInt64(&s.inFlight, 1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *LimitedStore) release() {
+	atomic.AddInt64(&s.inFlight, -1)
+	<-s.sem
+}
+
+// Put places a file in the store
+func (s *LimitedStore) Put(ctx context.Context, file qfs.File) (string, error) {
+	if err := s.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer s.release()
+	return s.Filestore.Put(ctx, file)
+}
+
+// Get retrieves a file from the store
+func (s *LimitedStore) Get(ctx context.Context, path string) (qfs.File, error) {
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.release()
+	return s.Filestore.Get(ctx, path)
+}
+
+// Has checks if the store has a path
+func (s *LimitedStore) Has(ctx context.Context, path string) (bool, error) {
+	if err := s.acquire(ctx); err != nil {
+		return false, err
+	}
+	defer s.release()
+	return s.Filestore.Has(ctx, path)
+}
+
+// Delete removes a path from the store
+func (s *LimitedStore) Delete(ctx context.Context, path string) error {
+	if err := s.acquire(ctx); err != nil {
+		return err
+	}
+	defer s.release()
+	return s.Filestore.Delete(ctx, path)
+}
+
+// NewAdder creates an adder that bounds each file it adds
+func (s *LimitedStore) NewAdder(pin, wrap bool) (cafs.Adder, error) {
+	adder, err := s.Filestore.NewAdder(pin, wrap)
+	if err != nil {
+		return nil, err
+	}
+	return limitedAdder{Adder: adder, store: s}, nil
+}
+
+// Fetch gets a file from a source, if the wrapped store is a cafs.Fetcher
+func (s *LimitedStore) Fetch(ctx context.Context, source cafs.Source, key string) (qfs.File, error) {
+	fetcher, ok := s.Filestore.(cafs.Fetcher)
+	if !ok {
+		return nil, fmt.Errorf("store can't fetch")
+	}
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.release()
+	return fetcher.Fetch(ctx, source, key)
+}
+
+// Pin pins a path, if the wrapped store is a cafs.Pinner
+func (s *LimitedStore) Pin(ctx context.Context, key string, recursive bool) error {
+	pinner, ok := s.Filestore.(cafs.Pinner)
+	if !ok {
+		return fmt.Errorf("store doesn't support pinning")
+	}
+	if err := s.acquire(ctx); err != nil {
+		return err
+	}
+	defer s.release()
+	return pinner.Pin(ctx, key, recursive)
+}
+
+// Unpin unpins a path, if the wrapped store is a cafs.Pinner
+func (s *LimitedStore) Unpin(ctx context.Context, key string, recursive bool) error {
+	pinner, ok := s.Filestore.(cafs.Pinner)
+	if !ok {
+		return fmt.Errorf("store doesn't support pinning")
+	}
+	if err := s.acquire(ctx); err != nil {
+		return err
+	}
+	defer s.release()
+	return pinner.Unpin(ctx, key, recursive)
+}
+
+// limitedAdder takes a store operation slot for each added file
+type limitedAdder struct {
+	cafs.Adder
+	store *LimitedStore
+}
+
+// AddFile adds a file to the store
+func (a limitedAdder) AddFile(ctx context.Context, f qfs.File) error {
+	if err := a.store.acquire(ctx); err != nil {
+		return err
+	}
+	defer a.store.release()
+	return a.Adder.AddFile(ctx, f)
+}
@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
+)
+
+// slowStore holds each Get open until released, counting the most
+// concurrent calls it sees
+type slowStore struct {
+	cafs.Filestore
+	lk      sync.Mutex
+	running int
+	max     int
+	wait    chan struct{}
+}
+
+func (s *slowStore) Get(ctx context.Context, path string) (qfs.File, error) {
+	s.lk.Lock()
+	s.running++
+	if s.running > s.max {
+		s.max = s.running
+	}
+	s.lk.Unlock()
+
+	<-s.wait
+
+	s.lk.Lock()
+	s.running--
+	s.lk.Unlock()
+	return s.Filestore.Get(ctx, path)
+}
+
+func TestLimitedStore(t *testing.T) {
+	ctx := context.Background()
+	ms := cafs.NewMapstore()
+	path, err := ms.Put(ctx, qfs.NewMemfileBytes("hello.txt", []byte("hello")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slow := &slowStore{Filestore: ms, wait: make(chan struct{})}
+	store := NewLimitedStore(slow, 2)
+	if store.MaxOps() != 2 {
+		t.Errorf("expected max ops to be 2, got: %d", store.MaxOps())
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.Get(ctx, path); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	// wait for the limit to fill
+	for i := 0; store.InFlight() < 2 && i < 100; i++ {
+		time.Sleep(time.Millisecond * 5)
+	}
+	if store.InFlight() != 2 {
+		t.Errorf("expected 2 operations in flight, got: %d", store.InFlight())
+	}
+
+	// calls past the limit give up when their context is cancelled
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := store.Get(cctx, path); err != context.Canceled {
+		t.Errorf("expected a cancelled call to return context.Canceled, got: %v", err)
+	}
+
+	close(slow.wait)
+	wg.Wait()
+	if slow.max != 2 {
+		t.Errorf("expected at most 2 concurrent calls, got: %d", slow.max)
+	}
+	if store.InFlight() != 0 {
+		t.Errorf("expected no operations in flight, got: %d", store.InFlight())
+	}
+
+	if has, err := store.Has(ctx, path); err != nil || !has {
+		t.Errorf("expected store to have %s. has: %t err: %v", path, has, err)
+	}
+	if err := store.Pin(ctx, path, true); err == nil {
+		t.Error("expected pinning to error when the wrapped store can't pin")
+	}
+	if err := NewLimitedStore(ms, 1).Pin(ctx, path, true); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUnwrapStore(t *testing.T) {
+	ms := cafs.NewMapstore()
+	if UnwrapStore(ms) != ms {
+		t.Error("expected unwrapping an unwrapped store to return it")
+	}
+	if UnwrapStore(NewLimitedStore(NewLimitedStore(ms, 1), 1)) != ms {
+		t.Error("expected unwrapping to return the innermost store")
+	}
+}
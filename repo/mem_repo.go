@@ -22,6 +22,13 @@ type MemRepo struct {
 	refCache   *MemRefstore
 	logbook    *logbook.Book
 	dscache    *dscache.Dscache
+	trash      *Trash
+	pubq       *PublishQueue
+	pins       *PinPolicies
+	prefetches *Prefetches
+	rkeys      *RemoteKeys
+	usage      *DiskUsage
+	aliases    *Aliases
 
 	profile  *profile.Profile
 	profiles profile.Store
@@ -43,6 +50,13 @@ func NewMemRepo(p *profile.Profile, store cafs.Filestore, fsys qfs.Filesystem, p
 		refCache:    &MemRefstore{},
 		logbook:     book,
 		dscache:     dscache.NewDscache(ctx, fsys, book, ""),
+		trash:       &Trash{},
+		pubq:        &PublishQueue{},
+		pins:        &PinPolicies{},
+		prefetches:  &Prefetches{},
+		rkeys:       &RemoteKeys{},
+		usage:       &DiskUsage{},
+		aliases:     &Aliases{},
 		profile:     p,
 		profiles:    ps,
 	}, nil
@@ -68,6 +82,42 @@ func (r *MemRepo) Dscache() *dscache.Dscache {
 	return r.dscache
 }
 
+// Trash gives access to datasets that have been removed, but not yet deleted
+func (r *MemRepo) Trash() *Trash {
+	return r.trash
+}
+
+// PublishQueue gives access to datasets waiting to be published
+func (r *MemRepo) PublishQueue() *PublishQueue {
+	return r.pubq
+}
+
+// PinPolicies gives access to how many versions of each dataset stay pinned
+func (r *MemRepo) PinPolicies() *PinPolicies {
+	return r.pins
+}
+
+// Prefetches gives access to dataset versions pinned by prefetching
+func (r *MemRepo) Prefetches() *Prefetches {
+	return r.prefetches
+}
+
+// RemoteKeys gives access to the keys remotes were trusted to sign responses
+// with
+func (r *MemRepo) RemoteKeys() *RemoteKeys {
+	return r.rkeys
+}
+
+// DiskUsage gives access to the account of bytes this repo stores
+func (r *MemRepo) DiskUsage() *DiskUsage {
+	return r.usage
+}
+
+// Aliases gives access to alternate names of datasets in this repo
+func (r *MemRepo) Aliases() *Aliases {
+	return r.aliases
+}
+
 // RemoveLogbook drops a MemRepo's logbook pointer. MemRepo gets used in tests
 // a bunch, where logbook manipulation is helpful
 func (r *MemRepo) RemoveLogbook() {
@@ -108,7 +108,7 @@ func ParseDatasetRef(ref string) (reporef.DatasetRef, error) {
 	}
 
 	if dsr.ProfileID == "" && dsr.Peername == "" && dsr.Name == "" && dsr.Path == "" {
-		err = fmt.Errorf("malformed reporef.DatasetRef string: %s", ref)
+		err = fmt.Errorf("%w string: %s", ErrMalformedRef, ref)
 		return dsr, err
 	}
 
@@ -139,7 +139,7 @@ func parseIdentifiers(ids string) (profileID profile.ID, path string, err error)
 	toks := strings.Split(ids, "/")
 	switch len(toks) {
 	case 0:
-		err = fmt.Errorf("malformed reporef.DatasetRef identifier: %s", ids)
+		err = fmt.Errorf("%w identifier: %s", ErrMalformedRef, ids)
 	case 1:
 		if toks[0] != "" {
 			profileID, err = profile.IDB58Decode(toks[0])
@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// PinPolicier is an opt-in interface for repos that keep per-dataset pin
+// policies
+type PinPolicier interface {
+	PinPolicies() *PinPolicies
+}
+
+// PinPolicies records how many of the newest versions of each dataset are
+// kept pinned. Datasets without a policy keep every version pinned
+type PinPolicies struct {
+	lk   sync.Mutex
+	path string
+	// keep maps peername/name to the number of versions to keep pinned
+	keep map[string]int
+}
+
+// NewPinPolicies creates policies that persist to a JSON file at path. An
+// empty path keeps policies in memory
+func NewPinPolicies(path string) (*PinPolicies, error) {
+	p := &PinPolicies{path: path, keep: map[string]int{}}
+	if path == "" {
+		return p, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &p.keep); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// KeepPinned returns the number of versions of a dataset to keep pinned, zero
+// means the dataset has no policy
+func (p *PinPolicies) KeepPinned(ref reporef.DatasetRef) int {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	return p.keep[pinPolicyKey(ref)]
+}
+
+// SetKeepPinned sets the number of versions of a dataset to keep pinned. A
+// number less than one removes the dataset's policy
+func (p *PinPolicies) SetKeepPinned(ref reporef.DatasetRef, n int) error {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	if p.keep == nil {
+		p.keep = map[string]int{}
+	}
+	if n < 1 {
+		delete(p.keep, pinPolicyKey(ref))
+	} else {
+		p.keep[pinPolicyKey(ref)] = n
+	}
+	return p.save()
+}
+
+func pinPolicyKey(ref reporef.DatasetRef) string {
+	return ref.Peername + "/" + ref.Name
+}
+
+func (p *PinPolicies) save() error {
+	if p.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(p.keep)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p.path, data, os.ModePerm)
+}
@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestPinPolicies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestPinPolicies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "pin_policies.json")
+
+	p, err := NewPinPolicies(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := reporef.DatasetRef{Peername: "peer", Name: "a", Path: "/map/QmA"}
+	if n := p.KeepPinned(ref); n != 0 {
+		t.Errorf("expected no policy, got: %d", n)
+	}
+	if err := p.SetKeepPinned(ref, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// policies are per dataset & survive reloading
+	p, err = NewPinPolicies(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := p.KeepPinned(reporef.DatasetRef{Peername: "peer", Name: "a"}); n != 3 {
+		t.Errorf("expected to keep 3 versions pinned, got: %d", n)
+	}
+
+	if err := p.SetKeepPinned(ref, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := p.KeepPinned(ref); n != 0 {
+		t.Errorf("expected policy to be removed, got: %d", n)
+	}
+}
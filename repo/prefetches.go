@@ -0,0 +1,128 @@
+package repo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// PrefetchEntry is a dataset version pulled from a remote & pinned without
+// adding the dataset to the repo
+type PrefetchEntry struct {
+	// Ref is the prefetched version, with the peername & name it was
+	// prefetched by
+	Ref reporef.DatasetRef `json:"ref"`
+	// Fetched is when the version was prefetched
+	Fetched time.Time `json:"fetched"`
+}
+
+// Prefetcher is an opt-in interface for repos that keep a record of
+// prefetched dataset versions
+type Prefetcher interface {
+	Prefetches() *Prefetches
+}
+
+// Prefetches is a record of prefetched dataset versions, keyed by version
+// path. Prefetched versions stay pinned until they're unpinned through pin
+// management
+type Prefetches struct {
+	lk      sync.Mutex
+	path    string
+	entries []PrefetchEntry
+}
+
+// NewPrefetches creates a record that persists to a JSON file at path. An
+// empty path keeps the record in memory
+func NewPrefetches(path string) (*Prefetches, error) {
+	p := &Prefetches{path: path}
+	if path == "" {
+		return p, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &p.entries); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Put records a prefetched version, replacing any entry with the same path
+func (p *Prefetches) Put(e PrefetchEntry) error {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	if i := p.index(e.Ref.Path); i >= 0 {
+		p.entries[i] = e
+	} else {
+		p.entries = append(p.entries, e)
+	}
+	return p.save()
+}
+
+// Get finds the entry for a version path
+func (p *Prefetches) Get(path string) (PrefetchEntry, error) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	i := p.index(path)
+	if i < 0 {
+		return PrefetchEntry{}, ErrNotFound
+	}
+	return p.entries[i], nil
+}
+
+// List returns the entries of a dataset by peername & name, most recently
+// fetched first
+func (p *Prefetches) List(ref reporef.DatasetRef) []PrefetchEntry {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	entries := []PrefetchEntry{}
+	for _, e := range p.entries {
+		if e.Ref.Peername == ref.Peername && e.Ref.Name == ref.Name {
+			entries = append(entries, e)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Fetched.After(entries[j].Fetched)
+	})
+	return entries
+}
+
+// Delete drops the entry for a version path
+func (p *Prefetches) Delete(path string) error {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	i := p.index(path)
+	if i < 0 {
+		return ErrNotFound
+	}
+	p.entries = append(p.entries[:i], p.entries[i+1:]...)
+	return p.save()
+}
+
+func (p *Prefetches) index(path string) int {
+	for i, e := range p.entries {
+		if e.Ref.Path == path {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *Prefetches) save() error {
+	if p.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(p.entries)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p.path, data, os.ModePerm)
+}
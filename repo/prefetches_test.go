@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestPrefetches(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestPrefetches")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "prefetches.json")
+
+	p, err := NewPrefetches(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	older := PrefetchEntry{Ref: reporef.DatasetRef{Peername: "peer", Name: "a", Path: "/map/QmA"}, Fetched: now.Add(-time.Hour)}
+	newer := PrefetchEntry{Ref: reporef.DatasetRef{Peername: "peer", Name: "a", Path: "/map/QmB"}, Fetched: now}
+	other := PrefetchEntry{Ref: reporef.DatasetRef{Peername: "peer", Name: "b", Path: "/map/QmC"}, Fetched: now}
+	for _, e := range []PrefetchEntry{older, newer, other} {
+		if err := p.Put(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// entries survive reloading & are listed per dataset, newest first
+	p, err = NewPrefetches(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := p.List(reporef.DatasetRef{Peername: "peer", Name: "a"})
+	if len(entries) != 2 || entries[0].Ref.Path != "/map/QmB" || entries[1].Ref.Path != "/map/QmA" {
+		t.Errorf("unexpected entries: %#v", entries)
+	}
+
+	// putting a path again replaces its entry
+	if err := p.Put(PrefetchEntry{Ref: older.Ref, Fetched: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if entries := p.List(older.Ref); len(entries) != 2 || entries[0].Ref.Path != "/map/QmA" {
+		t.Errorf("expected refetching to replace the entry, got: %#v", entries)
+	}
+
+	if err := p.Delete("/map/QmA"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Get("/map/QmA"); err != ErrNotFound {
+		t.Errorf("expected deleted entry to be not found, got: %v", err)
+	}
+	if err := p.Delete("/map/QmA"); err != ErrNotFound {
+		t.Errorf("expected deleting a missing entry to be not found, got: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package repo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+const (
+	// PublishQueued is the status of a queued publish waiting to be attempted
+	PublishQueued = "queued"
+	// PublishFailed is the status of a queued publish that gave up retrying
+	PublishFailed = "failed"
+)
+
+// PublishQueueItem is a dataset waiting to be published to a remote
+type PublishQueueItem struct {
+	// Ref is the dataset to publish. Ref.Path is the version that was the
+	// latest when the item was queued or last superseded
+	Ref reporef.DatasetRef `json:"ref"`
+	// RemoteName is the name the remote was given as, Address is what it
+	// resolved to
+	RemoteName string `json:"remoteName,omitempty"`
+	Address    string `json:"address"`
+	Status     string `json:"status"`
+	// Queued is when the dataset was first queued for publishing
+	Queued      time.Time `json:"queued"`
+	Attempts    int       `json:"attempts"`
+	LastAttempt time.Time `json:"lastAttempt,omitempty"`
+	// NextAttempt is the earliest a queued item is retried
+	NextAttempt time.Time `json:"nextAttempt,omitempty"`
+	LastError   string    `json:"lastError,omitempty"`
+}
+
+// PublishQueuer is an opt-in interface for repos that keep a queue of
+// datasets waiting to be published
+type PublishQueuer interface {
+	PublishQueue() *PublishQueue
+}
+
+// PublishQueue is a list of datasets waiting to be published. Items are keyed
+// by peername, name & remote address, queueing a dataset that's already queued
+// for the same remote replaces the existing item
+type PublishQueue struct {
+	lk    sync.Mutex
+	path  string
+	items []PublishQueueItem
+}
+
+// NewPublishQueue creates a queue that persists to a JSON file at path. An
+// empty path keeps the queue in memory
+func NewPublishQueue(path string) (*PublishQueue, error) {
+	q := &PublishQueue{path: path}
+	if path == "" {
+		return q, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &q.items); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Put adds an item to the queue
+func (q *PublishQueue) Put(item PublishQueueItem) error {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+	if i := q.index(item.Ref, item.Address); i >= 0 {
+		q.items = append(q.items[:i], q.items[i+1:]...)
+	}
+	q.items = append(q.items, item)
+	return q.save()
+}
+
+// Get finds the queued item for a dataset & remote address
+func (q *PublishQueue) Get(ref reporef.DatasetRef, addr string) (PublishQueueItem, error) {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+	i := q.index(ref, addr)
+	if i < 0 {
+		return PublishQueueItem{}, ErrNotFound
+	}
+	return q.items[i], nil
+}
+
+// List returns all queued items, oldest first
+func (q *PublishQueue) List() []PublishQueueItem {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+	items := make([]PublishQueueItem, len(q.items))
+	copy(items, q.items)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Queued.Before(items[j].Queued)
+	})
+	return items
+}
+
+// Delete drops the queued item for a dataset & remote address
+func (q *PublishQueue) Delete(ref reporef.DatasetRef, addr string) error {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+	i := q.index(ref, addr)
+	if i < 0 {
+		return ErrNotFound
+	}
+	q.items = append(q.items[:i], q.items[i+1:]...)
+	return q.save()
+}
+
+func (q *PublishQueue) index(ref reporef.DatasetRef, addr string) int {
+	for i, item := range q.items {
+		if item.Ref.Peername == ref.Peername && item.Ref.Name == ref.Name && item.Address == addr {
+			return i
+		}
+	}
+	return -1
+}
+
+func (q *PublishQueue) save() error {
+	if q.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(q.items)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(q.path, data, os.ModePerm)
+}
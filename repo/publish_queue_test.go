@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestPublishQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestPublishQueue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "publish_queue.json")
+
+	q, err := NewPublishQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.List()) != 0 {
+		t.Fatal("expected a new queue to be empty")
+	}
+
+	queued := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ref := reporef.DatasetRef{Peername: "peer", Name: "a", Path: "/map/QmA"}
+	items := []PublishQueueItem{
+		{Ref: ref, Address: "https://one.example", Status: PublishQueued, Queued: queued.Add(time.Hour)},
+		{Ref: ref, Address: "https://two.example", Status: PublishQueued, Queued: queued},
+	}
+	for _, item := range items {
+		if err := q.Put(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// queueing the same dataset for the same remote replaces the item
+	items[0].Ref.Path = "/map/QmA2"
+	items[0].Attempts = 2
+	if err := q.Put(items[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewPublishQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := reloaded.List()
+	if len(list) != 2 {
+		t.Fatalf("expected 2 items, got: %d", len(list))
+	}
+	if list[0].Address != "https://two.example" || list[1].Address != "https://one.example" {
+		t.Errorf("expected oldest item first, got: %s, %s", list[0].Address, list[1].Address)
+	}
+	if list[1].Ref.Path != "/map/QmA2" || list[1].Attempts != 2 {
+		t.Errorf("items didn't round trip: %v", list)
+	}
+
+	if _, err := reloaded.Get(reporef.DatasetRef{Peername: "peer", Name: "a"}, "https://one.example"); err != nil {
+		t.Errorf("expected to get item by name & address, got: %s", err)
+	}
+	if err := reloaded.Delete(ref, "https://one.example"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reloaded.Get(ref, "https://one.example"); err != ErrNotFound {
+		t.Errorf("expected deleted item to be not found, got: %v", err)
+	}
+	if err := reloaded.Delete(ref, "https://one.example"); err != ErrNotFound {
+		t.Errorf("expected deleting a missing item to be not found, got: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// RemoteKeyer is an opt-in interface for repos that record the keys remotes
+// sign responses with
+type RemoteKeyer interface {
+	RemoteKeys() *RemoteKeys
+}
+
+// RemoteKeys records the public keys remotes were trusted to sign responses
+// with the first time they were contacted, keyed by remote address
+type RemoteKeys struct {
+	lk   sync.Mutex
+	path string
+	// keys maps remote addresses to base64-encoded public keys
+	keys map[string]string
+}
+
+// NewRemoteKeys creates a record of trusted remote keys that persists to a
+// JSON file at path. An empty path keeps keys in memory
+func NewRemoteKeys(path string) (*RemoteKeys, error) {
+	k := &RemoteKeys{path: path, keys: map[string]string{}}
+	if path == "" {
+		return k, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return k, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &k.keys); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Get returns the trusted public key of a remote, if one is recorded
+func (k *RemoteKeys) Get(addr string) (string, bool) {
+	k.lk.Lock()
+	defer k.lk.Unlock()
+	pub, ok := k.keys[addr]
+	return pub, ok
+}
+
+// Trust records the public key a remote signs responses with
+func (k *RemoteKeys) Trust(addr, pubKey string) error {
+	k.lk.Lock()
+	defer k.lk.Unlock()
+	if k.keys == nil {
+		k.keys = map[string]string{}
+	}
+	k.keys[addr] = pubKey
+	return k.save()
+}
+
+// Forget removes the trusted key of a remote, the next response from the
+// remote is treated as a first contact
+func (k *RemoteKeys) Forget(addr string) error {
+	k.lk.Lock()
+	defer k.lk.Unlock()
+	delete(k.keys, addr)
+	return k.save()
+}
+
+func (k *RemoteKeys) save() error {
+	if k.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(k.keys)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(k.path, data, os.ModePerm)
+}
@@ -0,0 +1,44 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoteKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestRemoteKeys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "remote_keys.json")
+
+	k, err := NewRemoteKeys(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := k.Get("http://remote.qri.io"); ok {
+		t.Error("expected no key for an unknown remote")
+	}
+	if err := k.Trust("http://remote.qri.io", "CAASpgIwggEiMA"); err != nil {
+		t.Fatal(err)
+	}
+
+	// trusted keys survive reloading
+	k, err = NewRemoteKeys(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub, _ := k.Get("http://remote.qri.io"); pub != "CAASpgIwggEiMA" {
+		t.Errorf("trusted key mismatch. expected: %q, got: %q", "CAASpgIwggEiMA", pub)
+	}
+
+	if err := k.Forget("http://remote.qri.io"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := k.Get("http://remote.qri.io"); ok {
+		t.Error("expected forgotten key to be removed")
+	}
+}
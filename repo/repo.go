@@ -36,6 +36,9 @@ var (
 	ErrPathRequired = fmt.Errorf("repo: path is required")
 	// ErrNameTaken is for when a name name is already taken
 	ErrNameTaken = fmt.Errorf("repo: name already in use")
+	// ErrAlreadyExists is wrapped by errors for datasets that can't be created
+	// because a dataset with the same name exists
+	ErrAlreadyExists = fmt.Errorf("already exists")
 	// ErrRepoEmpty is for when the repo has no datasets
 	ErrRepoEmpty = fmt.Errorf("repo: this repo contains no datasets")
 	// ErrNotPinner is for when the repo doesn't have the concept of pinning as a feature
@@ -44,6 +47,9 @@ var (
 	ErrNoRegistry = fmt.Errorf("no configured registry")
 	// ErrEmptyRef indicates that the given reference is empty
 	ErrEmptyRef = fmt.Errorf("repo: empty dataset reference")
+	// ErrMalformedRef is wrapped by errors for strings that can't be parsed as
+	// a dataset reference
+	ErrMalformedRef = fmt.Errorf("malformed reporef.DatasetRef")
 )
 
 // Repo is the interface for working with a qri repository qri repos are stored
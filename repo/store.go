@@ -2,6 +2,7 @@ package repo
 
 import (
 	"fmt"
+	"strings"
 
 	reporef "github.com/qri-io/qri/repo/ref"
 )
@@ -26,6 +27,14 @@ type Refstore interface {
 	RefCount() (int, error)
 }
 
+// FoldRefstore is an opt-in interface for refstores that index references
+// ignoring case, so lookups that ignore case don't read every reference
+type FoldRefstore interface {
+	// RefsFold returns references with a peername & name that match ignoring
+	// case. An empty name returns all references made by the peer
+	RefsFold(peername, name string) ([]reporef.DatasetRef, error)
+}
+
 // TODO(dlong): In the near future, switch to a new utility that resolves references to specific
 // versions by using logbook. A ref should resolve to a pair of (init-id, head-ref), where the
 // init-id is the stable unchanging identifier for a dataset (derived from logbook) and head-ref
@@ -45,6 +54,19 @@ func CanonicalizeDatasetRef(r Repo, ref *reporef.DatasetRef) error {
 	}
 
 	got, err := r.GetRef(*ref)
+	if err == ErrNotFound {
+		// aliases resolve to the dataset they name, so results carry the
+		// dataset's real name
+		if target, ok := AliasTarget(r, ref.Peername, ref.Name); ok {
+			ref.Name = target
+			got, err = r.GetRef(*ref)
+		}
+	}
+	if err == ErrNotFound {
+		// exact matches take priority, only fall back to ignoring case once an
+		// exact lookup has failed
+		got, err = getRefFold(r, *ref)
+	}
 	if err != nil {
 		return err
 	}
@@ -56,7 +78,7 @@ func CanonicalizeDatasetRef(r Repo, ref *reporef.DatasetRef) error {
 	if ref.ProfileID == "" {
 		ref.ProfileID = got.ProfileID
 	}
-	if ref.Name == "" {
+	if ref.Name == "" || strings.EqualFold(ref.Name, got.Name) {
 		ref.Name = got.Name
 	}
 	if ref.Peername == "" || ref.Peername != got.Peername {
@@ -77,6 +99,65 @@ func CanonicalizeDatasetRef(r Repo, ref *reporef.DatasetRef) error {
 	return nil
 }
 
+// getRefFold looks up a reference by peername & name, ignoring case. If no
+// reference matches, an *ErrRefNotFound is returned with suggestions of
+// similarly-named references. Matching more than one reference is an error
+func getRefFold(r Repo, ref reporef.DatasetRef) (reporef.DatasetRef, error) {
+	if ref.Name == "" || ref.Path != "" {
+		return reporef.DatasetRef{}, ErrNotFound
+	}
+
+	matches, refs, err := refsFold(r, ref)
+	if err != nil {
+		return reporef.DatasetRef{}, err
+	}
+
+	switch len(matches) {
+	case 0:
+		return reporef.DatasetRef{}, &ErrRefNotFound{
+			Ref:         ref.AliasString(),
+			Suggestions: suggestRefs(ref, refs),
+		}
+	case 1:
+		return matches[0], nil
+	default:
+		aliases := make([]string, len(matches))
+		for i, m := range matches {
+			aliases[i] = m.AliasString()
+		}
+		return reporef.DatasetRef{}, fmt.Errorf("repo: reference %s is ambiguous, it matches references that differ only by case: %s", ref.AliasString(), strings.Join(aliases, ", "))
+	}
+}
+
+// refsFold finds references that match ref ignoring case, along with the
+// references suggestions are drawn from when nothing matches. Repos with a
+// FoldRefstore only read references made by the same peer
+func refsFold(r Repo, ref reporef.DatasetRef) (matches, candidates []reporef.DatasetRef, err error) {
+	if fr, ok := r.(FoldRefstore); ok && ref.Peername != "" {
+		if matches, err = fr.RefsFold(ref.Peername, ref.Name); err != nil || len(matches) > 0 {
+			return matches, nil, err
+		}
+		candidates, err = fr.RefsFold(ref.Peername, "")
+		return nil, candidates, err
+	}
+
+	count, err := r.RefCount()
+	if err != nil {
+		return nil, nil, err
+	}
+	if candidates, err = r.References(0, count); err != nil {
+		return nil, nil, err
+	}
+	for _, stored := range candidates {
+		sameAuthor := (ref.ProfileID != "" && ref.ProfileID == stored.ProfileID) ||
+			(ref.Peername != "" && strings.EqualFold(ref.Peername, stored.Peername))
+		if sameAuthor && strings.EqualFold(ref.Name, stored.Name) {
+			matches = append(matches, stored)
+		}
+	}
+	return matches, candidates, nil
+}
+
 // CanonicalizeProfile populates dataset reporef.DatasetRef ProfileID and Peername properties,
 // changing aliases to known names, and adding ProfileID from a peerstore
 func CanonicalizeProfile(r Repo, ref *reporef.DatasetRef) error {
@@ -408,6 +408,71 @@ func TestCanonicalizeDatasetRef(t *testing.T) {
 	}
 }
 
+func TestCanonicalizeDatasetRefIgnoreCase(t *testing.T) {
+	lucille := &profile.Profile{ID: profile.IDRawByteString("a"), Peername: "lucille", PrivKey: privKey}
+	carla := &profile.Profile{ID: profile.IDRawByteString("b"), Peername: "carla"}
+
+	store := cafs.NewMapstore()
+	memRepo, err := NewMemRepo(lucille, store, qfs.NewMemFS(), profile.NewMemStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := memRepo.Profiles().PutProfile(carla); err != nil {
+		t.Fatal(err)
+	}
+	rs := memRepo.MemRefstore
+	for _, r := range []reporef.DatasetRef{
+		{ProfileID: lucille.ID, Peername: "lucille", Name: "world_bank_population", Path: "/ipfs/QmTest"},
+		{ProfileID: lucille.ID, Peername: "lucille", Name: "foo", Path: "/ipfs/QmFoo"},
+		{ProfileID: lucille.ID, Peername: "lucille", Name: "Foo", Path: "/ipfs/QmFooUpper"},
+		{ProfileID: carla.ID, Peername: carla.Peername, Name: "hockey_stats", Path: "/ipfs/QmTest2"},
+	} {
+		if err := rs.PutRef(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		input  string
+		expect string
+		err    string
+	}{
+		{"me/World_Bank_Population", "lucille/world_bank_population@/ipfs/QmTest", ""},
+		{"Carla/Hockey_Stats", "carla/hockey_stats@/ipfs/QmTest2", ""},
+		// exact matches win over case-insensitive ones
+		{"me/foo", "lucille/foo@/ipfs/QmFoo", ""},
+		{"me/Foo", "lucille/Foo@/ipfs/QmFooUpper", ""},
+		{"me/FOO", "", "repo: reference lucille/FOO is ambiguous, it matches references that differ only by case: lucille/Foo, lucille/foo"},
+		{"me/world_bank_populaton", "", "repo: not found. did you mean lucille/world_bank_population?"},
+		{"carla/hocky_stats", "", "repo: not found. did you mean carla/hockey_stats?"},
+		{"me/unrelated", "", "repo: not found"},
+	}
+
+	for _, c := range cases {
+		ref, err := ParseDatasetRef(c.input)
+		if err != nil {
+			t.Errorf("case %q unexpected dataset ref parse error: %s", c.input, err)
+			continue
+		}
+
+		err = CanonicalizeDatasetRef(memRepo, &ref)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %q error mismatch. expected: '%s', got: '%s'", c.input, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if ref.String() != c.expect {
+			t.Errorf("case %q expected: %s, got: %s", c.input, c.expect, ref)
+		}
+	}
+
+	if err := CanonicalizeDatasetRef(memRepo, &reporef.DatasetRef{Peername: "me", Name: "bar"}); !IsNotFound(err) {
+		t.Errorf("expected IsNotFound to report unresolved references, got: %v", err)
+	}
+}
+
 func TestCanonicalizeDatasetRefFSI(t *testing.T) {
 	peer := "lucille"
 	prof := &profile.Profile{ID: profile.IDRawByteString("a"), Peername: peer, PrivKey: privKey}
@@ -0,0 +1,121 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// maxSuggestions caps the number of suggestions an ErrRefNotFound carries
+const maxSuggestions = 3
+
+// ErrRefNotFound is returned when a reference can't be resolved against the
+// local repo. Suggestions lists aliases of similarly-named references, closest
+// match first
+type ErrRefNotFound struct {
+	Ref         string
+	Suggestions []string
+}
+
+// Error satisfies the error interface
+func (e *ErrRefNotFound) Error() string {
+	if hint := e.DidYouMean(); hint != "" {
+		return fmt.Sprintf("%s. %s", ErrNotFound.Error(), hint)
+	}
+	return ErrNotFound.Error()
+}
+
+// Is lets errors.Is match an *ErrRefNotFound with ErrNotFound
+func (e *ErrRefNotFound) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// DidYouMean gives a user-facing hint listing suggestions, returning the
+// empty string if there are none
+func (e *ErrRefNotFound) DidYouMean() string {
+	if len(e.Suggestions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("did you mean %s?", strings.Join(e.Suggestions, " or "))
+}
+
+// IsNotFound returns true if err is or wraps ErrNotFound or an
+// *ErrRefNotFound
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// suggestRefs finds aliases in refs that are a small number of edits away from
+// the alias of ref, ignoring case
+func suggestRefs(ref reporef.DatasetRef, refs []reporef.DatasetRef) []string {
+	type candidate struct {
+		alias string
+		dist  int
+	}
+
+	target := strings.ToLower(ref.Name)
+	// allow roughly one typo for every four characters
+	maxDist := len(target)/4 + 1
+
+	var cands []candidate
+	for _, stored := range refs {
+		if ref.Peername != "" && !strings.EqualFold(ref.Peername, stored.Peername) && ref.ProfileID != stored.ProfileID {
+			continue
+		}
+		if d := editDistance(target, strings.ToLower(stored.Name)); d <= maxDist {
+			cands = append(cands, candidate{alias: stored.AliasString(), dist: d})
+		}
+	}
+
+	sort.SliceStable(cands, func(i, j int) bool {
+		if cands[i].dist == cands[j].dist {
+			return cands[i].alias < cands[j].alias
+		}
+		return cands[i].dist < cands[j].dist
+	})
+	if len(cands) > maxSuggestions {
+		cands = cands[:maxSuggestions]
+	}
+
+	suggestions := make([]string, len(cands))
+	for i, c := range cands {
+		suggestions[i] = c.alias
+	}
+	return suggestions
+}
+
+// editDistance calculates the levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(br)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
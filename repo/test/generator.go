@@ -25,8 +25,10 @@ func init() {
 	defaultCryptoGenerator = NewTestCrypto()
 }
 
+// GeneratePrivateKeyAndPeerID hands out predefined test peers in order,
+// starting over once all of them have been used
 func (g *testCryptoGenerator) GeneratePrivateKeyAndPeerID() (string, string) {
-	info := cfgtest.GetTestPeerInfo(g.count)
+	info := cfgtest.GetTestPeerInfo(g.count % cfgtest.NumTestPeers())
 	g.count++
 	return info.EncodedPrivKey, info.EncodedPeerID
 }
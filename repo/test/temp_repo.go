@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -14,6 +15,7 @@ import (
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/buildrepo"
+	fsrepo "github.com/qri-io/qri/repo/fs"
 	"github.com/qri-io/qri/repo/gen"
 )
 
@@ -99,6 +101,9 @@ func (r *TempRepo) Repo() (repo.Repo, error) {
 
 // Delete removes the test repo on disk.
 func (r *TempRepo) Delete() {
+	if c, ok := r.repo.(io.Closer); ok {
+		c.Close()
+	}
 	os.RemoveAll(r.RootPath)
 }
 
@@ -123,14 +128,13 @@ func (r *TempRepo) GetOutput() string {
 
 // GetPathForDataset returns the path to where the index'th dataset is stored on CAFS.
 func (r *TempRepo) GetPathForDataset(index int) (string, error) {
-	dsRefs := filepath.Join(r.QriPath, "refs.fbs")
-
-	data, err := ioutil.ReadFile(dsRefs)
+	rs, err := fsrepo.NewIndexedRefstore(r.QriPath)
 	if err != nil {
 		return "", err
 	}
+	defer rs.Close()
 
-	refs, err := repo.UnmarshalRefsFlatbuffer(data)
+	refs, err := rs.References(index, 1)
 	if err != nil {
 		return "", err
 	}
@@ -140,7 +144,7 @@ func (r *TempRepo) GetPathForDataset(index int) (string, error) {
 		return "", err
 	}
 
-	return refs[index].Path, nil
+	return refs[0].Path, nil
 }
 
 // ReadBodyFromIPFS reads the body of the dataset at the given keyPath stored
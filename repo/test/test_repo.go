@@ -259,7 +259,7 @@ func createDataset(r repo.Repo, tc dstest.TestCase) (ref reporef.DatasetRef, err
 	// references to files in a store that won't exist after this function call
 	// TODO (b5): this should be replaced with a call to OpenDataset with a qfs that
 	// knows about the store
-	if resBody, err = r.Store().Get(ctx, ref.Dataset.BodyPath); err != nil {
+	if resBody, err = dsfs.LoadBody(ctx, r.Store(), ref.Dataset); err != nil {
 		return ref, err
 	}
 	ref.Dataset.SetBodyFile(resBody)
@@ -0,0 +1,126 @@
+package repo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// DefaultTrashRetention is how long removed datasets stay in the trash when
+// no retention period is configured
+const DefaultTrashRetention = 7 * 24 * time.Hour
+
+// TrashEntry is a removed dataset reference waiting in the trash
+type TrashEntry struct {
+	// Ref is the reference as it was when removed, including the working
+	// directory it was linked to & whether it was published
+	Ref reporef.DatasetRef `json:"ref"`
+	// Removed is when the dataset was moved to the trash
+	Removed time.Time `json:"removed"`
+}
+
+// Trasher is an opt-in interface for repos that keep removed dataset
+// references in a trash for a while, instead of deleting them immediately
+type Trasher interface {
+	Trash() *Trash
+}
+
+// Trash is a list of removed dataset references. Entries are keyed by
+// peername & name, only one dataset of a name can be in the trash
+type Trash struct {
+	lk      sync.Mutex
+	path    string
+	entries []TrashEntry
+}
+
+// NewTrash creates a trash that persists to a JSON file at path. An empty
+// path keeps the trash in memory
+func NewTrash(path string) (*Trash, error) {
+	t := &Trash{path: path}
+	if path == "" {
+		return t, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return t, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &t.entries); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Put adds an entry to the trash, returning ErrAlreadyExists if the trash
+// holds a dataset with the same peername & name. Entries aren't replaced, the
+// versions of the dataset already in the trash would stay pinned
+func (t *Trash) Put(e TrashEntry) error {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	if t.index(e.Ref) >= 0 {
+		return ErrAlreadyExists
+	}
+	t.entries = append(t.entries, e)
+	return t.save()
+}
+
+// Get finds the trash entry for a reference by peername & name
+func (t *Trash) Get(ref reporef.DatasetRef) (TrashEntry, error) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	i := t.index(ref)
+	if i < 0 {
+		return TrashEntry{}, ErrNotFound
+	}
+	return t.entries[i], nil
+}
+
+// List returns all trash entries, most recently removed first
+func (t *Trash) List() []TrashEntry {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	entries := make([]TrashEntry, len(t.entries))
+	copy(entries, t.entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Removed.After(entries[j].Removed)
+	})
+	return entries
+}
+
+// Delete drops the trash entry for a reference
+func (t *Trash) Delete(ref reporef.DatasetRef) error {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	i := t.index(ref)
+	if i < 0 {
+		return ErrNotFound
+	}
+	t.entries = append(t.entries[:i], t.entries[i+1:]...)
+	return t.save()
+}
+
+func (t *Trash) index(ref reporef.DatasetRef) int {
+	for i, e := range t.entries {
+		if e.Ref.Peername == ref.Peername && e.Ref.Name == ref.Name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t *Trash) save() error {
+	if t.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(t.entries)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(t.path, data, os.ModePerm)
+}
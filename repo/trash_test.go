@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestTrash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestTrash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "trash.json")
+
+	trash, err := NewTrash(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash.List()) != 0 {
+		t.Fatal("expected a new trash to be empty")
+	}
+
+	removed := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := TrashEntry{Ref: reporef.DatasetRef{Peername: "peer", Name: "a", Path: "/map/QmA", Published: true}, Removed: removed}
+	b := TrashEntry{Ref: reporef.DatasetRef{Peername: "peer", Name: "b", Path: "/map/QmB", FSIPath: "/tmp/b"}, Removed: removed.Add(time.Hour)}
+	for _, e := range []TrashEntry{a, b} {
+		if err := trash.Put(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// trashing a dataset with the same name doesn't replace the entry
+	a.Ref.Path = "/map/QmA2"
+	if err := trash.Put(a); err != ErrAlreadyExists {
+		t.Errorf("expected ErrAlreadyExists trashing a name that's in the trash, got: %v", err)
+	}
+
+	reloaded, err := NewTrash(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := reloaded.List()
+	if len(list) != 2 {
+		t.Fatalf("expected 2 entries, got: %d", len(list))
+	}
+	if list[0].Ref.Name != "b" || list[1].Ref.Name != "a" {
+		t.Errorf("expected most recently removed first, got: %s, %s", list[0].Ref.Name, list[1].Ref.Name)
+	}
+	if list[0].Ref.FSIPath != "/tmp/b" || !list[1].Ref.Published || list[1].Ref.Path != "/map/QmA" {
+		t.Errorf("entries didn't round trip: %v", list)
+	}
+
+	if _, err := reloaded.Get(reporef.DatasetRef{Peername: "peer", Name: "a"}); err != nil {
+		t.Errorf("expected to get entry by name, got: %s", err)
+	}
+	if err := reloaded.Delete(reporef.DatasetRef{Peername: "peer", Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reloaded.Get(reporef.DatasetRef{Peername: "peer", Name: "a"}); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound getting a deleted entry, got: %v", err)
+	}
+	if err := reloaded.Delete(reporef.DatasetRef{Peername: "peer", Name: "a"}); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound deleting a missing entry, got: %v", err)
+	}
+}
@@ -353,6 +353,11 @@ func (t *transform) loadDataset(ctx context.Context, refstr string) (*dataset.Da
 		if err = ds.OpenBodyFile(ctx, t.repo.Filesystem()); err != nil {
 			return nil, err
 		}
+		body, err := dsfs.DecompressBody(ds.BodyFile(), ds.Structure)
+		if err != nil {
+			return nil, err
+		}
+		ds.SetBodyFile(body)
 	}
 
 	if t.next.Transform.Resources == nil {
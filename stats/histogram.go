@@ -0,0 +1,94 @@
+package stats
+
+import "math"
+
+// streamHistogram is a fixed-bucket-count histogram that can be built in a
+// single pass without knowing the range of values ahead of time. When a value
+// falls outside the current range bucket width doubles, merging neighbouring
+// buckets. Counts are always exact, but bucket edges depend on the order
+// values were written
+type streamHistogram struct {
+	lo     float64
+	width  float64
+	counts []float64
+}
+
+// newStreamHistogram creates a histogram with n buckets spanning [lo, hi)
+func newStreamHistogram(n int, lo, hi float64) *streamHistogram {
+	width := (hi - lo) / float64(n)
+	if width <= 0 || math.IsInf(width, 0) || math.IsNaN(width) {
+		width = 1
+	}
+	return &streamHistogram{
+		lo:     lo,
+		width:  width,
+		counts: make([]float64, n),
+	}
+}
+
+func (h *streamHistogram) hi() float64 {
+	return h.lo + h.width*float64(len(h.counts))
+}
+
+// Add places a value in the histogram, growing buckets as necessary
+func (h *streamHistogram) Add(v float64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+	for v >= h.hi() {
+		h.grow(false)
+	}
+	for v < h.lo {
+		h.grow(true)
+	}
+	i := int((v - h.lo) / h.width)
+	if i >= len(h.counts) {
+		// guard against float rounding at the upper edge
+		i = len(h.counts) - 1
+	}
+	h.counts[i]++
+}
+
+// grow doubles bucket width, extending the range either left or right. bucket
+// edges stay aligned, so every old bucket lands inside exactly one new bucket
+func (h *streamHistogram) grow(left bool) {
+	n := len(h.counts)
+	merged := make([]float64, n)
+	offset := 0
+	if left {
+		offset = n
+		h.lo -= h.width * float64(n)
+	}
+	for i, c := range h.counts {
+		merged[(i+offset)/2] += c
+	}
+	h.width *= 2
+	h.counts = merged
+}
+
+// Dividers returns the n+1 bucket edges
+func (h *streamHistogram) Dividers() []float64 {
+	divs := make([]float64, len(h.counts)+1)
+	for i := range divs {
+		divs[i] = h.lo + h.width*float64(i)
+	}
+	return divs
+}
+
+// Quantile approximates the value at quantile q (0-1) by linear interpolation
+// within the bucket containing the target rank
+func (h *streamHistogram) Quantile(q float64) float64 {
+	total := 0.0
+	for _, c := range h.counts {
+		total += c
+	}
+	target := total * q
+	cum := 0.0
+	for i, c := range h.counts {
+		if c > 0 && cum+c >= target {
+			return h.lo + h.width*(float64(i)+(target-cum)/c)
+		}
+		cum += c
+	}
+	return h.hi()
+}
@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// hllPrecision sets the number of registers a hyperLogLog uses to 2^p. 12 bits
+// gives a standard error of ~1.6% in 4KB of memory
+const hllPrecision = 12
+
+// hyperLogLog is a constant-memory cardinality estimator, used to approximate
+// distinct value counts when keeping exact frequencies would be too expensive
+// see http://algo.inria.fr/flajolet/Publications/FlFuGaMe07.pdf
+type hyperLogLog struct {
+	registers []uint8
+}
+
+func newHyperLogLog() *hyperLogLog {
+	return &hyperLogLog{registers: make([]uint8, 1<<hllPrecision)}
+}
+
+// AddString adds a string value to the estimator
+func (h *hyperLogLog) AddString(s string) {
+	hsh := fnv.New64a()
+	hsh.Write([]byte(s))
+	h.add(hsh.Sum64())
+}
+
+// AddFloat adds a numeric value to the estimator
+func (h *hyperLogLog) AddFloat(f float64) {
+	if f == 0 {
+		// normalize negative zero
+		f = 0
+	}
+	h.add(mix64(math.Float64bits(f)))
+}
+
+func (h *hyperLogLog) add(x uint64) {
+	// FNV has weak avalanche in the high bits, so always finalize
+	x = mix64(x)
+	idx := x >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1))) + 1
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+// Count estimates the number of distinct values added
+func (h *hyperLogLog) Count() int {
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	est := alpha * m * m / sum
+
+	// small range correction, use linear counting
+	if est <= 2.5*m && zeros > 0 {
+		est = m * math.Log(m/float64(zeros))
+	}
+	return int(est + 0.5)
+}
+
+// mix64 is the murmur3 64 bit finalizer
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
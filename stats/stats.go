@@ -22,10 +22,34 @@ var (
 	// unweildly memory consumption
 	StopFreqCountThreshold = 10000
 
+	// StopExactNumericThreshold is the number of values a numeric accumulator
+	// will hold in memory to calculate exact medians & histograms. Past this
+	// threshold numeric stats switch to constant-memory approximations
+	StopExactNumericThreshold = 10000
+
+	// DefaultHistogramBins is the number of buckets numeric histograms use if
+	// no count is specified
+	DefaultHistogramBins = 10
+
+	// ErrNoBody indicates a dataset has no body to calculate stats from
+	ErrNoBody = fmt.Errorf("stats: dataset has no body file")
+
 	// package logger
 	log = logger.Logger("stats")
 )
 
+// ParseError describes a body that couldn't be read while calculating stats
+type ParseError struct {
+	// zero-indexed entry the body failed to parse at
+	Row int
+	Err error
+}
+
+// Error implements the error interface
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parsing body at row %d: %s", e.Row, e.Err)
+}
+
 // Stats can generate an array of statistical info for a dataset
 type Stats struct {
 	cache Cache
@@ -43,8 +67,37 @@ func New(cache Cache) *Stats {
 	}
 }
 
-// JSON gets stats data as reader of JSON-formatted bytes
-func (s *Stats) JSON(ctx context.Context, ds *dataset.Dataset) (r io.Reader, err error) {
+// Options configures stats calculation
+type Options struct {
+	// HistogramBins sets the number of buckets in numeric histograms
+	HistogramBins int
+}
+
+// OptHistogramBins sets the number of buckets numeric histograms use. values
+// less than one are ignored
+func OptHistogramBins(n int) func(*Options) {
+	return func(o *Options) {
+		if n > 0 {
+			o.HistogramBins = n
+		}
+	}
+}
+
+func newOptions(opts []func(*Options)) *Options {
+	o := &Options{HistogramBins: DefaultHistogramBins}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// JSON gets stats data as reader of JSON-formatted bytes. Stats are calculated
+// in a single pass over the body in bounded memory
+func (s *Stats) JSON(ctx context.Context, ds *dataset.Dataset, opts ...func(*Options)) (r io.Reader, err error) {
+	o := newOptions(opts)
+	// only default-configured stats are cached
+	useCache := ds.Path != "" && o.HistogramBins == DefaultHistogramBins
+
 	// check cache if there is a Path
 	// TODO (ramfox): when we are calculating stats on fsi linked
 	// datasets, we need a different metric other the `dataset.Path` to
@@ -52,7 +105,7 @@ func (s *Stats) JSON(ctx context.Context, ds *dataset.Dataset) (r io.Reader, err
 	// a `dataset.Path`. This metric should perhaps come out of the
 	// `dataset.BodyFile()` since we must have a bodyFile in order to
 	// calculate the stats
-	if ds.Path != "" {
+	if useCache {
 		if r, err := s.cache.JSON(ctx, ds.Path); err == nil {
 			return r, nil
 		}
@@ -60,7 +113,7 @@ func (s *Stats) JSON(ctx context.Context, ds *dataset.Dataset) (r io.Reader, err
 
 	body := ds.BodyFile()
 	if body == nil {
-		return nil, fmt.Errorf("stats: dataset has no body file")
+		return nil, ErrNoBody
 	}
 	if ds.Structure == nil {
 		return nil, fmt.Errorf("stats: dataset is missing structure")
@@ -68,16 +121,16 @@ func (s *Stats) JSON(ctx context.Context, ds *dataset.Dataset) (r io.Reader, err
 
 	rdr, err := dsio.NewEntryReader(ds.Structure, ds.BodyFile())
 	if err != nil {
-		return nil, err
+		return nil, &ParseError{Row: 0, Err: err}
 	}
 
-	acc := NewAccumulator(rdr)
-	for {
+	acc := NewAccumulator(rdr, opts...)
+	for row := 0; ; row++ {
 		if _, err := acc.ReadEntry(); err != nil {
 			if err.Error() == "EOF" {
 				break
 			}
-			return nil, err
+			return nil, &ParseError{Row: row, Err: err}
 		}
 	}
 	acc.Close()
@@ -87,7 +140,7 @@ func (s *Stats) JSON(ctx context.Context, ds *dataset.Dataset) (r io.Reader, err
 		return nil, err
 	}
 
-	if ds.Path != "" {
+	if useCache {
 		go func() {
 			if err := s.cache.PutJSON(context.Background(), ds.Path, bytes.NewReader(data)); err != nil {
 				log.Debugf("putting stats in cache: %v", err.Error())
@@ -132,6 +185,7 @@ type Stat interface {
 // after a call to Close
 type Accumulator struct {
 	r     dsio.EntryReader
+	opts  *Options
 	stats accumulator
 }
 
@@ -142,8 +196,8 @@ var (
 )
 
 // NewAccumulator wraps an entry reader to create a stat accumulator
-func NewAccumulator(r dsio.EntryReader) *Accumulator {
-	return &Accumulator{r: r}
+func NewAccumulator(r dsio.EntryReader, opts ...func(*Options)) *Accumulator {
+	return &Accumulator{r: r, opts: newOptions(opts)}
 }
 
 // Stats gets the statistics created by the accumulator
@@ -169,7 +223,7 @@ func (r *Accumulator) ReadEntry() (dsio.Entry, error) {
 		return ent, err
 	}
 	if r.stats == nil {
-		r.stats = newAccumulator(ent.Value)
+		r.stats = newAccumulator(ent.Value, r.opts)
 	}
 	r.stats.Write(ent)
 	return ent, nil
@@ -177,7 +231,9 @@ func (r *Accumulator) ReadEntry() (dsio.Entry, error) {
 
 // Close finalizes the Reader
 func (r *Accumulator) Close() error {
-	r.stats.Close()
+	if r.stats != nil {
+		r.stats.Close()
+	}
 	return r.r.Close()
 }
 
@@ -190,26 +246,48 @@ type accumulator interface {
 	Close()
 }
 
-func newAccumulator(val interface{}) accumulator {
+func newAccumulator(val interface{}, opts *Options) accumulator {
 	switch val.(type) {
 	default:
 		return &nullAcc{}
 	case float64, float32:
-		return newNumericAcc("number")
+		return newNumericAcc("number", opts.HistogramBins)
 	case int, int32, int64:
-		return newNumericAcc("integer")
+		return newNumericAcc("integer", opts.HistogramBins)
 	case string:
 		return newStringAcc()
 	case bool:
 		return &boolAcc{}
 	case map[string]interface{}:
-		return &objectAcc{children: map[string]accumulator{}}
+		return &objectAcc{opts: opts, children: map[string]accumulator{}}
 	case []interface{}:
-		return &arrayAcc{}
+		return &arrayAcc{opts: opts}
 	}
 }
 
+// writeChild writes an entry to a child accumulator, creating or replacing the
+// child as needed. children that have only seen nulls are swapped for a typed
+// accumulator on the first non-null value, carrying the null count forward
+func writeChild(child accumulator, e dsio.Entry, opts *Options) accumulator {
+	if child == nil {
+		child = newAccumulator(e.Value, opts)
+	} else if na, ok := child.(*nullAcc); ok && e.Value != nil {
+		child = newAccumulator(e.Value, opts)
+		if nw, ok := child.(nullWriter); ok {
+			nw.addNulls(na.count)
+		}
+	}
+	child.Write(e)
+	return child
+}
+
+// nullWriter is implemented by accumulators that keep a count of null values
+type nullWriter interface {
+	addNulls(n int)
+}
+
 type objectAcc struct {
+	opts     *Options
 	children map[string]accumulator
 }
 
@@ -241,10 +319,7 @@ func (acc *objectAcc) Type() string { return "object" }
 func (acc *objectAcc) Write(e dsio.Entry) {
 	if mapEntry, ok := e.Value.(map[string]interface{}); ok {
 		for key, val := range mapEntry {
-			if _, ok := acc.children[key]; !ok {
-				acc.children[key] = newAccumulator(val)
-			}
-			acc.children[key].Write(dsio.Entry{Key: key, Value: val})
+			acc.children[key] = writeChild(acc.children[key], dsio.Entry{Key: key, Value: val}, acc.opts)
 		}
 	}
 }
@@ -266,6 +341,7 @@ func (acc *objectAcc) Close() {
 }
 
 type arrayAcc struct {
+	opts     *Options
 	children []accumulator
 }
 
@@ -291,9 +367,9 @@ func (acc *arrayAcc) Write(e dsio.Entry) {
 	if arrayEntry, ok := e.Value.([]interface{}); ok {
 		for i, val := range arrayEntry {
 			if len(acc.children) == i {
-				acc.children = append(acc.children, newAccumulator(val))
+				acc.children = append(acc.children, nil)
 			}
-			acc.children[i].Write(dsio.Entry{Index: i, Value: val})
+			acc.children[i] = writeChild(acc.children[i], dsio.Entry{Index: i, Value: val}, acc.opts)
 		}
 	}
 }
@@ -322,33 +398,58 @@ const (
 	minInt   = -maxInt - 1
 )
 
+// numericAcc accumulates stats for numbers. count, nulls, min, max & mean are
+// always exact. Up to StopExactNumericThreshold values are kept in memory to
+// calculate exact medians, histograms, and distinct counts. Past that the
+// accumulator switches to a streaming histogram & hyperLogLog sketch, and
+// reports which figures are approximate
 type numericAcc struct {
-	typ       string
-	count     int
-	min       float64
-	max       float64
-	mean      float64
-	median    float64
-	dividers  []float64
-	histogram []float64
+	typ      string
+	bins     int
+	count    int
+	nulls    int
+	min      float64
+	max      float64
+	mean     float64
+	median   float64
+	distinct int
+
+	// values holds raw values while count is below StopExactNumericThreshold
+	values []float64
+	// hist & hll are used once values exceeds the exact threshold
+	hist *streamHistogram
+	hll  *hyperLogLog
+
+	dividers    []float64
+	frequencies []float64
 }
 
-var _ accumulator = (*numericAcc)(nil)
+var (
+	_ accumulator = (*numericAcc)(nil)
+	_ nullWriter  = (*numericAcc)(nil)
+)
 
-func newNumericAcc(typ string) *numericAcc {
+func newNumericAcc(typ string, bins int) *numericAcc {
+	if bins < 1 {
+		bins = DefaultHistogramBins
+	}
 	return &numericAcc{
 		typ:    typ,
+		bins:   bins,
 		max:    float64(minInt),
 		min:    float64(maxInt),
 		median: maxFloat,
-		// use histogram to accumulate values
-		histogram: make([]float64, 0, StopFreqCountThreshold*100),
 	}
 }
 
 // Type indicates this stat accumulator kind
 func (acc *numericAcc) Type() string { return "numeric" }
 
+func (acc *numericAcc) addNulls(n int) { acc.nulls += n }
+
+// approximate reports weather accumulation has switched to sketches
+func (acc *numericAcc) approximate() bool { return acc.hist != nil }
+
 // Write adds an entry to the stat accumulator
 func (acc *numericAcc) Write(e dsio.Entry) {
 	var v float64
@@ -363,17 +464,13 @@ func (acc *numericAcc) Write(e dsio.Entry) {
 		v = float64(x)
 	case float64:
 		v = x
+	case nil:
+		acc.nulls++
+		return
 	default:
 		return
 	}
 
-	if acc.histogram != nil {
-		acc.histogram = append(acc.histogram, v)
-		if len(acc.histogram) == StopFreqCountThreshold*100 {
-			acc.histogram = nil
-		}
-	}
-
 	acc.mean += v
 	acc.count++
 	if v > acc.max {
@@ -382,6 +479,24 @@ func (acc *numericAcc) Write(e dsio.Entry) {
 	if v < acc.min {
 		acc.min = v
 	}
+
+	if acc.approximate() {
+		acc.hist.Add(v)
+		acc.hll.AddFloat(v)
+		return
+	}
+
+	acc.values = append(acc.values, v)
+	if len(acc.values) > StopExactNumericThreshold {
+		// switch to sketches, seeding them with everything seen so far
+		acc.hist = newStreamHistogram(acc.bins, acc.min, acc.max+1)
+		acc.hll = newHyperLogLog()
+		for _, val := range acc.values {
+			acc.hist.Add(val)
+			acc.hll.AddFloat(val)
+		}
+		acc.values = nil
+	}
 }
 
 // Map formats stat values as a map
@@ -389,64 +504,101 @@ func (acc *numericAcc) Map() map[string]interface{} {
 	if acc.count == 0 {
 		// avoid reporting default max/min figures, if count is above 0
 		// at least one entry has been checked
-		return map[string]interface{}{"count": 0}
+		m := map[string]interface{}{"count": 0}
+		if acc.nulls != 0 {
+			m["nulls"] = acc.nulls
+		}
+		return m
 	}
 	m := map[string]interface{}{
-		"mean":  acc.mean,
-		"count": acc.count,
-		"min":   acc.min,
-		"max":   acc.max,
+		"mean":     acc.mean,
+		"count":    acc.count,
+		"min":      acc.min,
+		"max":      acc.max,
+		"distinct": acc.distinct,
+	}
+
+	if acc.nulls != 0 {
+		m["nulls"] = acc.nulls
 	}
 
 	if acc.median != maxFloat {
 		m["median"] = acc.median
 	}
 
-	if acc.histogram != nil {
+	if acc.frequencies != nil {
 		m["histogram"] = map[string][]float64{
 			"bins":        acc.dividers,
-			"frequencies": acc.histogram,
+			"frequencies": acc.frequencies,
 		}
 	}
 
+	if acc.approximate() {
+		m["approximate"] = []string{"distinct", "histogram", "median"}
+	}
+
 	return m
 }
 
 // Close finalizes the accumulator
 func (acc *numericAcc) Close() {
+	if acc.count == 0 {
+		return
+	}
 	// finalize avg
 	acc.mean = acc.mean / float64(acc.count)
 
-	if len(acc.histogram) > 0 {
-		sort.Float64Slice(acc.histogram).Sort()
+	if acc.approximate() {
+		acc.median = acc.hist.Quantile(0.5)
+		acc.distinct = acc.hll.Count()
+		acc.dividers = acc.hist.Dividers()
+		acc.frequencies = acc.hist.counts
+		return
+	}
 
-		if len(acc.histogram)%2 == 0 && len(acc.histogram) > 1 {
-			acc.median = (acc.histogram[len(acc.histogram)/2-1] + acc.histogram[len(acc.histogram)/2]) / float64(2)
-			// acc.median = (acc.histogram[len(acc.histogram)/2] + acc.histogram[len(acc.histogram)/2+1]) / float64(2)
+	if len(acc.values) > 0 {
+		sort.Float64Slice(acc.values).Sort()
+
+		if len(acc.values)%2 == 0 && len(acc.values) > 1 {
+			acc.median = (acc.values[len(acc.values)/2-1] + acc.values[len(acc.values)/2]) / float64(2)
 		} else {
-			acc.median = acc.histogram[len(acc.histogram)/2]
+			acc.median = acc.values[len(acc.values)/2]
+		}
+
+		for i, v := range acc.values {
+			if i == 0 || v != acc.values[i-1] {
+				acc.distinct++
+			}
 		}
 
 		// turn values into a histogram
-		nBins := 10
-		acc.dividers = make([]float64, nBins+1)
+		acc.dividers = make([]float64, acc.bins+1)
 		// Increase the maximum divider so that the maximum value of x is contained
 		// within the last bucket.
 		gonumfloats.Span(acc.dividers, acc.min, acc.max+1)
-		// Span includes the min and the max. Trim the dividers to create 10 buckets
-		acc.histogram = gonumstat.Histogram(nil, acc.dividers, acc.histogram, nil)
+		acc.frequencies = gonumstat.Histogram(nil, acc.dividers, acc.values, nil)
+		acc.values = nil
 	}
 }
 
+// stringAcc accumulates stats for strings. frequencies are kept until
+// StopFreqCountThreshold unique values are seen, after which distinct counts
+// are approximated with a hyperLogLog sketch
 type stringAcc struct {
 	count       int
+	nulls       int
 	minLength   int
 	maxLength   int
 	unique      int
+	distinct    int
 	frequencies map[string]int
+	hll         *hyperLogLog
 }
 
-var _ accumulator = (*stringAcc)(nil)
+var (
+	_ accumulator = (*stringAcc)(nil)
+	_ nullWriter  = (*stringAcc)(nil)
+)
 
 func newStringAcc() *stringAcc {
 	return &stringAcc{
@@ -459,16 +611,29 @@ func newStringAcc() *stringAcc {
 // Type indicates this stat accumulator kind
 func (acc *stringAcc) Type() string { return "string" }
 
+func (acc *stringAcc) addNulls(n int) { acc.nulls += n }
+
 // Write adds an entry to the stat accumulator
 func (acc *stringAcc) Write(e dsio.Entry) {
+	if e.Value == nil {
+		acc.nulls++
+		return
+	}
 	if str, ok := e.Value.(string); ok {
 		acc.count++
 
 		if acc.frequencies != nil {
 			acc.frequencies[str]++
 			if len(acc.frequencies) >= StopFreqCountThreshold {
+				// switch to a sketch, seeding with all values seen so far
+				acc.hll = newHyperLogLog()
+				for key := range acc.frequencies {
+					acc.hll.AddString(key)
+				}
 				acc.frequencies = nil
 			}
+		} else {
+			acc.hll.AddString(str)
 		}
 
 		if len(str) < acc.minLength {
@@ -485,28 +650,44 @@ func (acc *stringAcc) Map() map[string]interface{} {
 	if acc.count == 0 {
 		// avoid reporting default max/min figures, if count is above 0
 		// at least one entry has been checked
-		return map[string]interface{}{"count": 0}
+		m := map[string]interface{}{"count": 0}
+		if acc.nulls != 0 {
+			m["nulls"] = acc.nulls
+		}
+		return m
 	}
 
 	m := map[string]interface{}{
 		"count":     acc.count,
 		"minLength": acc.minLength,
 		"maxLength": acc.maxLength,
+		"distinct":  acc.distinct,
 	}
 
+	if acc.nulls != 0 {
+		m["nulls"] = acc.nulls
+	}
 	if acc.unique != 0 {
 		m["unique"] = acc.unique
 	}
 	if acc.frequencies != nil {
 		m["frequencies"] = acc.frequencies
 	}
+	if acc.hll != nil {
+		m["approximate"] = []string{"distinct"}
+	}
 
 	return m
 }
 
 // Close finalizes the accumulator
 func (acc *stringAcc) Close() {
+	if acc.hll != nil {
+		acc.distinct = acc.hll.Count()
+		return
+	}
 	if acc.frequencies != nil {
+		acc.distinct = len(acc.frequencies)
 		// determine unique values
 		for key, freq := range acc.frequencies {
 			if freq == 1 {
@@ -522,17 +703,27 @@ func (acc *stringAcc) Close() {
 
 type boolAcc struct {
 	count      int
+	nulls      int
 	trueCount  int
 	falseCount int
 }
 
-var _ accumulator = (*boolAcc)(nil)
+var (
+	_ accumulator = (*boolAcc)(nil)
+	_ nullWriter  = (*boolAcc)(nil)
+)
 
 // Type indicates this stat accumulator kind
 func (acc *boolAcc) Type() string { return "boolean" }
 
+func (acc *boolAcc) addNulls(n int) { acc.nulls += n }
+
 // Write adds an entry to the stat accumulator
 func (acc *boolAcc) Write(e dsio.Entry) {
+	if e.Value == nil {
+		acc.nulls++
+		return
+	}
 	if b, ok := e.Value.(bool); ok {
 		acc.count++
 		if b {
@@ -545,11 +736,15 @@ func (acc *boolAcc) Write(e dsio.Entry) {
 
 // Map formats stat values as a map
 func (acc *boolAcc) Map() map[string]interface{} {
-	return map[string]interface{}{
+	m := map[string]interface{}{
 		"count":      acc.count,
 		"trueCount":  acc.trueCount,
 		"falseCount": acc.falseCount,
 	}
+	if acc.nulls != 0 {
+		m["nulls"] = acc.nulls
+	}
+	return m
 }
 
 // Close finalizes the accumulator
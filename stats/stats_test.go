@@ -1,8 +1,10 @@
 package stats
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"strings"
 	"testing"
@@ -29,6 +31,7 @@ func TestStrings(t *testing.T) {
 			{
 				"type":        "string",
 				"count":       5,
+				"distinct":    4,
 				"minLength":   1,
 				"maxLength":   4,
 				"unique":      3,
@@ -60,26 +63,28 @@ func TestAllTypesIdentitySchemaArray(t *testing.T) {
 				"type":       "boolean",
 			},
 			{
-				"key":    "float",
-				"count":  5,
-				"min":    float64(1.1),
-				"max":    float64(5.5),
-				"mean":   float64(3.08),
-				"median": float64(3.3),
-				"type":   "numeric",
+				"key":      "float",
+				"count":    5,
+				"distinct": 4,
+				"min":      float64(1.1),
+				"max":      float64(5.5),
+				"mean":     float64(3.08),
+				"median":   float64(3.3),
+				"type":     "numeric",
 				"histogram": map[string][]float64{
 					"bins":        {1.1, 1.6400000000000001, 2.18, 2.72, 3.2600000000000002, 3.8000000000000003, 4.34, 4.880000000000001, 5.42, 5.960000000000001, 6.5},
 					"frequencies": {2, 0, 0, 0, 1, 0, 1, 0, 1, 0},
 				},
 			},
 			{
-				"key":    "int",
-				"count":  5,
-				"min":    float64(1),
-				"max":    float64(5),
-				"mean":   float64(2.8),
-				"median": float64(3),
-				"type":   "numeric",
+				"key":      "int",
+				"count":    5,
+				"distinct": 4,
+				"min":      float64(1),
+				"max":      float64(5),
+				"mean":     float64(2.8),
+				"median":   float64(3),
+				"type":     "numeric",
 				"histogram": map[string][]float64{
 					"bins":        {1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5, 6},
 					"frequencies": {2, 0, 0, 0, 1, 0, 1, 0, 1, 0},
@@ -93,6 +98,7 @@ func TestAllTypesIdentitySchemaArray(t *testing.T) {
 			{
 				"key":         "string",
 				"count":       5,
+				"distinct":    4,
 				"minLength":   1,
 				"maxLength":   5,
 				"type":        "string",
@@ -111,12 +117,13 @@ func TestAllTypesIdentitySchemaArray(t *testing.T) {
 		]`,
 		[]map[string]interface{}{
 			{
-				"count":  2,
-				"min":    float64(1),
-				"max":    float64(2),
-				"mean":   float64(1.5),
-				"median": float64(1.5),
-				"type":   "numeric",
+				"count":    2,
+				"distinct": 2,
+				"min":      float64(1),
+				"max":      float64(2),
+				"mean":     float64(1.5),
+				"median":   float64(1.5),
+				"type":     "numeric",
 				"histogram": map[string][]float64{
 					"bins":        {1, 1.2, 1.4, 1.6, 1.8, 2, 2.2, 2.4000000000000004, 2.6, 2.8, 3},
 					"frequencies": {1, 0, 0, 0, 0, 1, 0, 0, 0, 0},
@@ -141,24 +148,26 @@ func TestAllTypesIdentitySchemaObject(t *testing.T) {
 		}`,
 		[]map[string]interface{}{
 			{
-				"count":  5,
-				"min":    float64(1),
-				"max":    float64(5),
-				"mean":   float64(2.8),
-				"median": float64(3),
-				"type":   "numeric",
+				"count":    5,
+				"distinct": 4,
+				"min":      float64(1),
+				"max":      float64(5),
+				"mean":     float64(2.8),
+				"median":   float64(3),
+				"type":     "numeric",
 				"histogram": map[string][]float64{
 					"bins":        {1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5, 6},
 					"frequencies": {2, 0, 0, 0, 1, 0, 1, 0, 1, 0},
 				},
 			},
 			{
-				"count":  5,
-				"min":    float64(1.1),
-				"max":    float64(5.5),
-				"mean":   float64(3.08),
-				"median": float64(2.2),
-				"type":   "numeric",
+				"count":    5,
+				"distinct": 4,
+				"min":      float64(1.1),
+				"max":      float64(5.5),
+				"mean":     float64(3.08),
+				"median":   float64(2.2),
+				"type":     "numeric",
 				"histogram": map[string][]float64{
 					"bins":        {1.1, 1.6400000000000001, 2.18, 2.72, 3.2600000000000002, 3.8000000000000003, 4.34, 4.880000000000001, 5.42, 5.960000000000001, 6.5},
 					"frequencies": {1, 0, 2, 0, 0, 0, 1, 0, 1, 0},
@@ -176,6 +185,7 @@ func TestAllTypesIdentitySchemaObject(t *testing.T) {
 			},
 			{
 				"count":       5,
+				"distinct":    4,
 				"minLength":   1,
 				"maxLength":   5,
 				"type":        "string",
@@ -206,17 +216,19 @@ func TestFreqThreshold(t *testing.T) {
 		[]map[string]interface{}{
 			{
 				"count":       5,
+				"distinct":    1,
 				"minLength":   11,
 				"maxLength":   11,
 				"type":        "string",
 				"frequencies": map[string]int{"abcdefghijk": 5},
 			},
 			{
-				"count":  5,
-				"min":    float64(1),
-				"max":    float64(1),
-				"mean":   float64(1),
-				"median": float64(1),
+				"count":    5,
+				"distinct": 1,
+				"min":      float64(1),
+				"max":      float64(1),
+				"mean":     float64(1),
+				"median":   float64(1),
 				// currently we're calculating historams at 100x the stop threshold, so this shows up
 				"histogram": map[string][]float64{
 					"bins":        {1, 1.1, 1.2, 1.3, 1.4, 1.5, 1.6, 1.7000000000000002, 1.8, 1.9, 2},
@@ -239,17 +251,20 @@ func TestFreqThreshold(t *testing.T) {
 		]`,
 		[]map[string]interface{}{
 			{
-				"count":     5,
-				"minLength": 1,
-				"maxLength": 1,
-				"type":      "string",
+				"count":       5,
+				"distinct":    5,
+				"approximate": []string{"distinct"},
+				"minLength":   1,
+				"maxLength":   1,
+				"type":        "string",
 			},
 			{
-				"count":  5,
-				"min":    float64(1),
-				"max":    float64(5),
-				"mean":   float64(3),
-				"median": float64(3),
+				"count":    5,
+				"distinct": 5,
+				"min":      float64(1),
+				"max":      float64(5),
+				"mean":     float64(3),
+				"median":   float64(3),
 				// currently we're calculating historams at 100x the stop threshold, so this shows up
 				"histogram": map[string][]float64{
 					"bins":        {1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5, 6},
@@ -353,7 +368,7 @@ func TestJSON(t *testing.T) {
 	}{
 		{"no body", &dataset.Dataset{Path: "path"}, "stats: dataset has no body file"},
 		{"no structure", dsWithBody, "stats: dataset is missing structure"},
-		{"reader error", dsWithStructure, "Expected: separator ','"},
+		{"reader error", dsWithStructure, "parsing body at row 1: Expected: separator ','"},
 	}
 
 	for _, c := range badCases {
@@ -376,7 +391,7 @@ func TestJSON(t *testing.T) {
 			"json",
 			`{"type":"array"}`,
 			`["a","a","bb","ccc","dddd"]`,
-			[]byte(`[{"count":5,"frequencies":{"a":2},"maxLength":4,"minLength":1,"type":"string","unique":3}]`),
+			[]byte(`[{"count":5,"distinct":4,"frequencies":{"a":2},"maxLength":4,"minLength":1,"type":"string","unique":3}]`),
 		}, {
 			"json: all types identity schema array of object entries",
 			"json",
@@ -388,13 +403,13 @@ func TestJSON(t *testing.T) {
 				{"int": 4, "float": 4.4, "nil": null, "bool": true, "string": "aaa"},
 				{"int": 5, "float": 5.5, "nil": null, "bool": false, "string": "aaaaa"}
 			]`,
-			[]byte(`[{"count":5,"falseCount":3,"key":"bool","trueCount":2,"type":"boolean"},{"count":5,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"key":"float","max":5.5,"mean":3.08,"median":3.3,"min":1.1,"type":"numeric"},{"count":5,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"key":"int","max":5,"mean":2.8,"median":3,"min":1,"type":"numeric"},{"count":5,"key":"nil","type":"null"},{"count":5,"frequencies":{"aaa":2},"key":"string","maxLength":5,"minLength":1,"type":"string","unique":3}]`),
+			[]byte(`[{"count":5,"falseCount":3,"key":"bool","trueCount":2,"type":"boolean"},{"count":5,"distinct":4,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"key":"float","max":5.5,"mean":3.08,"median":3.3,"min":1.1,"type":"numeric"},{"count":5,"distinct":4,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"key":"int","max":5,"mean":2.8,"median":3,"min":1,"type":"numeric"},{"count":5,"key":"nil","type":"null"},{"count":5,"distinct":4,"frequencies":{"aaa":2},"key":"string","maxLength":5,"minLength":1,"type":"string","unique":3}]`),
 		}, {
 			"csv: an array of strings",
 			"csv",
 			`{"type":"array"}`,
 			"a\na\nbb\nccc\ndddd",
-			[]byte(`[{"count":5,"frequencies":{"a":2},"maxLength":4,"minLength":1,"type":"string","unique":3}]`),
+			[]byte(`[{"count":5,"distinct":4,"frequencies":{"a":2},"maxLength":4,"minLength":1,"type":"string","unique":3}]`),
 		}, {
 			"csv: all types identity schema array of object entries",
 			"csv",
@@ -427,7 +442,7 @@ func TestJSON(t *testing.T) {
 				"type": "array"
 			 }`,
 			"1,1.1,,false,a\n1,1.1,,true,aa\n3,3.3,,false,aaa\n4,4.4,,true,aaa\n5,5.5,,false,aaaaa",
-			[]byte(`[{"count":5,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5,"mean":2.8,"median":3,"min":1,"type":"numeric"},{"count":5,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5.5,"mean":3.08,"median":3.3,"min":1.1,"type":"numeric"},{"count":5,"type":"null"},{"count":5,"falseCount":3,"trueCount":2,"type":"boolean"},{"count":5,"frequencies":{"aaa":2},"maxLength":5,"minLength":1,"type":"string","unique":3}]`),
+			[]byte(`[{"count":5,"distinct":4,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5,"mean":2.8,"median":3,"min":1,"type":"numeric"},{"count":5,"distinct":4,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5.5,"mean":3.08,"median":3.3,"min":1.1,"type":"numeric"},{"count":5,"type":"null"},{"count":5,"falseCount":3,"trueCount":2,"type":"boolean"},{"count":5,"distinct":4,"frequencies":{"aaa":2},"maxLength":5,"minLength":1,"type":"string","unique":3}]`),
 		}, {
 			"json: all types identity schema object of array entries",
 			"json",
@@ -439,7 +454,7 @@ func TestJSON(t *testing.T) {
 					"d" : [4,4.4,null,true,"aaa"],
 					"e" : [5,5.5,null,false,"aaaaa"]
 				}`,
-			[]byte(`[{"count":5,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5,"mean":2.8,"median":3,"min":1,"type":"numeric"},{"count":5,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[1,0,2,0,0,0,1,0,1,0]},"max":5.5,"mean":3.08,"median":2.2,"min":1.1,"type":"numeric"},{"count":5,"type":"null"},{"count":5,"falseCount":3,"trueCount":2,"type":"boolean"},{"count":5,"frequencies":{"aaa":2},"maxLength":5,"minLength":1,"type":"string","unique":3}]`),
+			[]byte(`[{"count":5,"distinct":4,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5,"mean":2.8,"median":3,"min":1,"type":"numeric"},{"count":5,"distinct":4,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[1,0,2,0,0,0,1,0,1,0]},"max":5.5,"mean":3.08,"median":2.2,"min":1.1,"type":"numeric"},{"count":5,"type":"null"},{"count":5,"falseCount":3,"trueCount":2,"type":"boolean"},{"count":5,"distinct":4,"frequencies":{"aaa":2},"maxLength":5,"minLength":1,"type":"string","unique":3}]`),
 		}, {
 			"json: array of object of array of strings",
 			"json",
@@ -449,7 +464,7 @@ func TestJSON(t *testing.T) {
 					{"ids": [1,2,3,4,5,6] },
 					{"ids": ["b",20,"c"] }
 				]`,
-			[]byte(`[{"key":"ids","type":"array","values":[{"count":2,"maxLength":1,"minLength":1,"unique":2},{"count":1,"maxLength":1,"minLength":1,"unique":1},{"count":2,"frequencies":{"c":2},"maxLength":1,"minLength":1},{"count":1,"histogram":{"bins":[4,4.1,4.2,4.3,4.4,4.5,4.6,4.7,4.8,4.9,5],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":4,"mean":4,"median":4,"min":4},{"count":1,"histogram":{"bins":[5,5.1,5.2,5.3,5.4,5.5,5.6,5.7,5.8,5.9,6],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":5,"mean":5,"median":5,"min":5},{"count":1,"histogram":{"bins":[6,6.1,6.2,6.3,6.4,6.5,6.6,6.7,6.8,6.9,7],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":6,"mean":6,"median":6,"min":6}]},{"count":1,"falseCount":0,"key":"is_great","trueCount":1,"type":"boolean"}]`),
+			[]byte(`[{"key":"ids","type":"array","values":[{"count":2,"distinct":2,"maxLength":1,"minLength":1,"unique":2},{"count":1,"distinct":1,"maxLength":1,"minLength":1,"unique":1},{"count":2,"distinct":1,"frequencies":{"c":2},"maxLength":1,"minLength":1},{"count":1,"distinct":1,"histogram":{"bins":[4,4.1,4.2,4.3,4.4,4.5,4.6,4.7,4.8,4.9,5],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":4,"mean":4,"median":4,"min":4},{"count":1,"distinct":1,"histogram":{"bins":[5,5.1,5.2,5.3,5.4,5.5,5.6,5.7,5.8,5.9,6],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":5,"mean":5,"median":5,"min":5},{"count":1,"distinct":1,"histogram":{"bins":[6,6.1,6.2,6.3,6.4,6.5,6.6,6.7,6.8,6.9,7],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":6,"mean":6,"median":6,"min":6}]},{"count":1,"falseCount":0,"key":"is_great","trueCount":1,"type":"boolean"}]`),
 		},
 	}
 	for i, c := range goodCases {
@@ -480,3 +495,196 @@ func TestJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestNullsBeforeValues(t *testing.T) {
+	c := TestCase{
+		"leading nulls are counted once a column type is known",
+		`{"type":"array"}`,
+		`[
+			{"a": null, "b": "x"},
+			{"a": 2, "b": null},
+			{"a": null, "b": "y"}
+		]`,
+		[]map[string]interface{}{
+			{
+				"key":       "a",
+				"count":     1,
+				"distinct":  1,
+				"nulls":     2,
+				"min":       float64(2),
+				"max":       float64(2),
+				"mean":      float64(2),
+				"median":    float64(2),
+				"type":      "numeric",
+				"histogram": map[string][]float64{"bins": {2, 2.5, 3}, "frequencies": {1, 0}},
+			},
+			{
+				"key":       "b",
+				"count":     2,
+				"distinct":  2,
+				"nulls":     1,
+				"minLength": 1,
+				"maxLength": 1,
+				"unique":    2,
+				"type":      "string",
+			},
+		},
+	}
+
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	r, err := dsio.NewJSONReader(st, strings.NewReader(c.JSONInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc := NewAccumulator(r, OptHistogramBins(2))
+	if err := ReadAllDiscard(acc); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(c.Expect, ToMap(acc)); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStreamingApproximations(t *testing.T) {
+	prev := StopExactNumericThreshold
+	StopExactNumericThreshold = 100
+	defer func() { StopExactNumericThreshold = prev }()
+
+	const rows = 5000
+	acc := newNumericAcc("integer", 10)
+	// write values out of order, forcing the histogram to grow in both directions
+	for i := 0; i < rows; i++ {
+		v := i
+		if i%2 == 1 {
+			v = -i
+		}
+		acc.Write(dsio.Entry{Value: v})
+	}
+	acc.Write(dsio.Entry{Value: nil})
+	acc.Close()
+
+	m := acc.Map()
+	if m["count"] != rows {
+		t.Errorf("count mismatch. expected: %d, got: %v", rows, m["count"])
+	}
+	if m["min"] != float64(-(rows-1)) || m["max"] != float64(rows-2) {
+		t.Errorf("min/max must be exact. got min: %v max: %v", m["min"], m["max"])
+	}
+	if m["nulls"] != 1 {
+		t.Errorf("expected 1 null, got: %v", m["nulls"])
+	}
+	if diff := cmp.Diff([]string{"distinct", "histogram", "median"}, m["approximate"]); diff != "" {
+		t.Errorf("approximate mismatch (-want +got):\n%s", diff)
+	}
+	if d := m["distinct"].(int); d < rows*95/100 || d > rows*105/100 {
+		t.Errorf("distinct estimate out of range. expected ~%d, got: %d", rows, d)
+	}
+
+	hist := m["histogram"].(map[string][]float64)
+	if len(hist["frequencies"]) != 10 || len(hist["bins"]) != 11 {
+		t.Errorf("expected 10 histogram buckets, got: %d", len(hist["frequencies"]))
+	}
+	total := 0.0
+	for _, f := range hist["frequencies"] {
+		total += f
+	}
+	if total != rows {
+		t.Errorf("histogram frequencies must sum to count. expected: %d, got: %f", rows, total)
+	}
+	if bins := hist["bins"]; bins[0] > m["min"].(float64) || bins[len(bins)-1] <= m["max"].(float64) {
+		t.Errorf("histogram bins %v must contain min & max", bins)
+	}
+}
+
+// wideTallBody generates a CSV body of rows x cols, alternating numeric, string
+// and boolean columns
+func wideTallBody(rows, cols int) ([]byte, *dataset.Structure) {
+	items := make([]interface{}, cols)
+	buf := &bytes.Buffer{}
+	for c := 0; c < cols; c++ {
+		typ := []string{"integer", "string", "boolean"}[c%3]
+		items[c] = map[string]interface{}{"title": fmt.Sprintf("col_%d", c), "type": typ}
+	}
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if c > 0 {
+				buf.WriteByte(',')
+			}
+			switch c % 3 {
+			case 0:
+				fmt.Fprintf(buf, "%d", (r*7+c)%1000)
+			case 1:
+				fmt.Fprintf(buf, "s%d", r%50)
+			case 2:
+				fmt.Fprintf(buf, "%t", r%2 == 0)
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	st := &dataset.Structure{
+		Format: "csv",
+		Schema: map[string]interface{}{
+			"type":  "array",
+			"items": map[string]interface{}{"type": "array", "items": items},
+		},
+	}
+	return buf.Bytes(), st
+}
+
+func TestWideTallBody(t *testing.T) {
+	ctx := context.Background()
+	prev := StopExactNumericThreshold
+	StopExactNumericThreshold = 500
+	defer func() { StopExactNumericThreshold = prev }()
+
+	const rows, cols = 2000, 30
+	body, st := wideTallBody(rows, cols)
+	ds := &dataset.Dataset{Structure: st}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.csv", body))
+
+	r, err := New(nil).JSON(ctx, ds, OptHistogramBins(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != cols {
+		t.Fatalf("expected %d column stats, got: %d", cols, len(got))
+	}
+	for i, col := range got {
+		if col["count"] != float64(rows) {
+			t.Errorf("column %d count mismatch. expected: %d, got: %v", i, rows, col["count"])
+		}
+		switch col["type"] {
+		case "numeric":
+			hist := col["histogram"].(map[string]interface{})
+			if l := len(hist["frequencies"].([]interface{})); l != 4 {
+				t.Errorf("column %d expected 4 histogram bins, got: %d", i, l)
+			}
+			if col["approximate"] == nil {
+				t.Errorf("column %d expected approximate figures to be noted", i)
+			}
+		case "string":
+			if col["distinct"] != float64(50) {
+				t.Errorf("column %d expected 50 distinct strings, got: %v", i, col["distinct"])
+			}
+		}
+	}
+}
+
+func BenchmarkWideTallBody(b *testing.B) {
+	ctx := context.Background()
+	body, st := wideTallBody(20000, 30)
+	s := New(nil)
+	b.SetBytes(int64(len(body)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ds := &dataset.Dataset{Structure: st}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.csv", body))
+		if _, err := s.JSON(ctx, ds); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -4,9 +4,12 @@ package cron
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"sort"
+	"sync"
 	"time"
 
 	golog "github.com/ipfs/go-log"
@@ -20,6 +23,10 @@ var (
 	// not running updates more than once an hour for performance and storage
 	// consumption reasons, making a check every minute a reasonable default
 	DefaultCheckInterval = time.Minute
+	// ErrNotRunning is the error for cancelling a job run that isn't executing
+	ErrNotRunning = fmt.Errorf("no running update with that id")
+	// ErrCancelled is the run error of a job that was cancelled mid-run
+	ErrCancelled = fmt.Errorf("update cancelled")
 )
 
 // Scheduler is the generic interface for the Cron Scheduler, it's implemented
@@ -41,6 +48,29 @@ type Scheduler interface {
 	Log(ctx context.Context, logName string) (*Job, error)
 	// JobLogFile returns a reader for a file at the given name
 	LogFile(ctx context.Context, logName string) (io.ReadCloser, error)
+
+	// ListRunning lists jobs that are currently executing
+	ListRunning(ctx context.Context) ([]RunningJob, error)
+	// Cancel stops a running job by RunningJob.ID
+	Cancel(ctx context.Context, id string) error
+}
+
+// RunningJob describes a job that's currently executing
+type RunningJob struct {
+	// ID identifies this run of the job, and is the name the run is logged
+	// under once it finishes
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Type      JobType   `json:"type"`
+	RunNumber int64     `json:"runNumber"`
+	RunStart  time.Time `json:"runStart"`
+}
+
+// runningJob pairs a running job with the func that cancels it
+type runningJob struct {
+	RunningJob
+	cancel    context.CancelFunc
+	cancelled bool
 }
 
 // RunJobFunc is a function for executing a job. Cron takes care of scheduling
@@ -74,6 +104,9 @@ type Cron struct {
 	log      JobStore
 	interval time.Duration
 	factory  RunJobFactory
+
+	lk      sync.Mutex
+	running map[string]*runningJob
 }
 
 // assert Cron is a Scheduler at compile time
@@ -133,7 +166,8 @@ func (c *Cron) Start(ctx context.Context) error {
 
 		run := []*Job{}
 		for _, job := range jobs {
-			if now.After(job.NextExec()) {
+			// a job that's still executing from an earlier check isn't run again
+			if now.After(job.NextExec()) && !c.isRunning(job.Name) {
 				run = append(run, job)
 			}
 		}
@@ -176,7 +210,18 @@ func (c *Cron) runJob(ctx context.Context, job *Job, runner RunJobFunc) {
 		}
 	}
 
-	if err := runner(ctx, streams, job); err != nil {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	id := c.startRunning(job, cancel)
+
+	err := job.FailurePolicy.Run(ctx, func() error {
+		return runner(ctx, streams, job)
+	})
+	cancelled := c.finishRunning(id)
+	if cancelled {
+		err = ErrCancelled
+	}
+	if err != nil {
 		log.Errorf("run job: %s error: %s", job.Name, err.Error())
 		job.RunError = err.Error()
 	} else {
@@ -186,6 +231,13 @@ func (c *Cron) runJob(ctx context.Context, job *Job, runner RunJobFunc) {
 	job.RunStop = time.Now().In(time.UTC)
 	job.RunNumber++
 
+	// runs that still fail once retries are spent are reported
+	if err != nil && !cancelled {
+		if err := job.FailurePolicy.Notify(ctx, job); err != nil {
+			log.Errorf("run job: %s notify: %s", job.Name, err)
+		}
+	}
+
 	// the updated job that goes to the schedule store shouldn't have a log path
 	scheduleJob := job.Copy()
 	scheduleJob.LogFilePath = ""
@@ -202,6 +254,85 @@ func (c *Cron) runJob(ctx context.Context, job *Job, runner RunJobFunc) {
 	}
 }
 
+// ListRunning lists jobs that are currently executing, oldest first
+func (c *Cron) ListRunning(ctx context.Context) ([]RunningJob, error) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	res := make([]RunningJob, 0, len(c.running))
+	for _, rj := range c.running {
+		res = append(res, rj.RunningJob)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].RunStart.Equal(res[j].RunStart) {
+			return res[i].ID < res[j].ID
+		}
+		return res[i].RunStart.Before(res[j].RunStart)
+	})
+	return res, nil
+}
+
+// Cancel stops a running job. The run is logged with ErrCancelled as its run
+// error. Cancel returns ErrNotRunning if no run has the given id
+func (c *Cron) Cancel(ctx context.Context, id string) error {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	rj, ok := c.running[id]
+	if !ok {
+		return ErrNotRunning
+	}
+	rj.cancelled = true
+	rj.cancel()
+	return nil
+}
+
+// startRunning records a job as executing, returning the id of the run
+func (c *Cron) startRunning(job *Job, cancel context.CancelFunc) string {
+	// runs are logged under the name of the job with it's next run number
+	next := job.Copy()
+	next.RunNumber++
+	id := next.LogName()
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	if c.running == nil {
+		c.running = map[string]*runningJob{}
+	}
+	c.running[id] = &runningJob{
+		RunningJob: RunningJob{
+			ID:        id,
+			Name:      job.Name,
+			Type:      job.Type,
+			RunNumber: next.RunNumber,
+			RunStart:  job.RunStart,
+		},
+		cancel: cancel,
+	}
+	return id
+}
+
+// finishRunning removes a run from the running jobs, returning true if the
+// run was cancelled
+func (c *Cron) finishRunning(id string) (cancelled bool) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	if rj, ok := c.running[id]; ok {
+		cancelled = rj.cancelled
+		delete(c.running, id)
+	}
+	return cancelled
+}
+
+func (c *Cron) isRunning(name string) bool {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	for _, rj := range c.running {
+		if rj.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 // Schedule adds a job to the cron scheduler
 func (c *Cron) Schedule(ctx context.Context, job *Job) error {
 	if err := job.Validate(); err != nil {
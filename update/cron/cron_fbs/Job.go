@@ -143,8 +143,21 @@ func (rcv *Job) RepoPath() []byte {
 	return nil
 }
 
+func (rcv *Job) FailurePolicy(obj *FailurePolicy) *FailurePolicy {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(30))
+	if o != 0 {
+		x := rcv._tab.Indirect(o + rcv._tab.Pos)
+		if obj == nil {
+			obj = new(FailurePolicy)
+		}
+		obj.Init(rcv._tab.Bytes, x)
+		return obj
+	}
+	return nil
+}
+
 func JobStart(builder *flatbuffers.Builder) {
-	builder.StartObject(13)
+	builder.StartObject(14)
 }
 func JobAddName(builder *flatbuffers.Builder, name flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(name), 0)
@@ -185,6 +198,9 @@ func JobAddOptions(builder *flatbuffers.Builder, options flatbuffers.UOffsetT) {
 func JobAddRepoPath(builder *flatbuffers.Builder, repoPath flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(12, flatbuffers.UOffsetT(repoPath), 0)
 }
+func JobAddFailurePolicy(builder *flatbuffers.Builder, failurePolicy flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(13, flatbuffers.UOffsetT(failurePolicy), 0)
+}
 func JobEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
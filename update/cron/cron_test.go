@@ -2,6 +2,10 @@ package cron
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -152,3 +156,127 @@ func TestCronShellScript(t *testing.T) {
 		t.Errorf("log job mismatch: %s", err)
 	}
 }
+
+func TestCronCancel(t *testing.T) {
+	started := make(chan struct{}, 10)
+	factory := func(outer context.Context) RunJobFunc {
+		return func(ctx context.Context, streams ioes.IOStreams, job *Job) error {
+			// a hanging update, only returning once cancelled
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+
+	logJobStore := &MemJobStore{}
+	cron := NewCronInterval(&MemJobStore{}, logJobStore, factory, time.Millisecond*20)
+	job := &Job{
+		Name:        "b5/slow_transform",
+		Type:        JTDataset,
+		Periodicity: mustRepeatingInterval("R/P1W"),
+	}
+	if err := cron.Schedule(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	go cron.Start(ctx)
+
+	select {
+	case <-started:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for job to start")
+	}
+	// let a few checks pass, a running job isn't started again
+	time.Sleep(time.Millisecond * 100)
+	if len(started) != 0 {
+		t.Errorf("expected running job not to be started again")
+	}
+
+	running, err := cron.ListRunning(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(running) != 1 || running[0].ID != "1-slow_transform" || running[0].Name != job.Name {
+		t.Fatalf("expected job to be listed as running, got: %v", running)
+	}
+
+	if err := cron.Cancel(ctx, "1-not_a_job"); err != ErrNotRunning {
+		t.Errorf("expected cancelling an unknown run to return ErrNotRunning, got: %v", err)
+	}
+	if err := cron.Cancel(ctx, running[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		logged, err := logJobStore.Job(ctx, running[0].ID)
+		if err == nil {
+			if logged.RunError != ErrCancelled.Error() {
+				t.Errorf("expected cancelled run to be logged with a cancelled error, got: %q", logged.RunError)
+			}
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for cancelled job to be logged")
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+
+	if running, _ = cron.ListRunning(ctx); len(running) != 0 {
+		t.Errorf("expected no running jobs after cancelling, got: %v", running)
+	}
+}
+
+func TestCronFailurePolicy(t *testing.T) {
+	runs := 0
+	factory := func(outer context.Context) RunJobFunc {
+		return func(ctx context.Context, streams ioes.IOStreams, job *Job) error {
+			runs++
+			return fmt.Errorf("source unreachable")
+		}
+	}
+
+	notified := make(chan *Job, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		job := &Job{}
+		json.NewDecoder(r.Body).Decode(job)
+		notified <- job
+	}))
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+
+	cron := NewCronInterval(&MemJobStore{}, &MemJobStore{}, factory, time.Millisecond*20)
+	job := &Job{
+		Name:        "b5/nightly_refresh",
+		Type:        JTDataset,
+		Periodicity: mustRepeatingInterval("R/P1W"),
+		FailurePolicy: &FailurePolicy{
+			Retries:   2,
+			Backoff:   time.Millisecond,
+			NotifyURL: s.URL,
+		},
+	}
+	if err := cron.Schedule(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	go cron.Start(ctx)
+
+	select {
+	case got := <-notified:
+		if got.RunError != "source unreachable" {
+			t.Errorf("expected notification to include run error, got: %q", got.RunError)
+		}
+		if got.RunNumber != 1 {
+			t.Errorf("expected notification run number to be 1, got: %d", got.RunNumber)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for failure notification")
+	}
+	if runs != 3 {
+		t.Errorf("expected failed job to be run 3 times, got: %d", runs)
+	}
+}
@@ -3,10 +3,12 @@ package cron
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 
 	flatbuffers "github.com/google/flatbuffers/go"
@@ -132,6 +134,36 @@ func (c HTTPClient) LogFile(ctx context.Context, logName string) (io.ReadCloser,
 	return nil, maybeErrorResponse(res)
 }
 
+// ListRunning lists jobs that are currently executing
+func (c HTTPClient) ListRunning(ctx context.Context) ([]RunningJob, error) {
+	res, err := http.Get(fmt.Sprintf("http://%s/running", c.Addr))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, maybeErrorResponse(res)
+	}
+
+	running := []RunningJob{}
+	err = json.NewDecoder(res.Body).Decode(&running)
+	return running, err
+}
+
+// Cancel stops a running job by RunningJob.ID
+func (c HTTPClient) Cancel(ctx context.Context, id string) error {
+	res, err := http.Post(fmt.Sprintf("http://%s/cancel?id=%s", c.Addr, url.QueryEscape(id)), "", nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return ErrNotRunning
+	}
+	return maybeErrorResponse(res)
+}
+
 func (c HTTPClient) postJob(job *Job) error {
 	builder := flatbuffers.NewBuilder(0)
 	off := job.MarshalFlatbuffer(builder)
@@ -214,6 +246,8 @@ func newCronRoutes(c *Cron) http.Handler {
 	m.HandleFunc("/log", c.loggedJobHandler)
 	m.HandleFunc("/log/output", c.loggedJobFileHandler)
 	m.HandleFunc("/run", c.runHandler)
+	m.HandleFunc("/running", c.runningHandler)
+	m.HandleFunc("/cancel", c.cancelHandler)
 
 	return m
 }
@@ -329,6 +363,34 @@ func (c *Cron) loggedJobFileHandler(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+func (c *Cron) runningHandler(w http.ResponseWriter, r *http.Request) {
+	running, err := c.ListRunning(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	json.NewEncoder(w).Encode(running)
+}
+
+func (c *Cron) cancelHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := c.Cancel(r.Context(), r.FormValue("id")); err != nil {
+		if err == ErrNotRunning {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte(err.Error()))
+		return
+	}
+}
+
 func (c *Cron) runHandler(w http.ResponseWriter, r *http.Request) {
 	// TODO (b5): implement an HTTP run handler
 	w.WriteHeader(http.StatusInternalServerError)
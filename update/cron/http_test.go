@@ -41,6 +41,17 @@ func TestCronHTTP(t *testing.T) {
 		t.Error("expected 0 jobs")
 	}
 
+	running, err := cli.ListRunning(cliCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(running) != 0 {
+		t.Error("expected 0 running jobs")
+	}
+	if err := cli.Cancel(cliCtx, "1-not_a_job"); err != ErrNotRunning {
+		t.Errorf("expected cancelling an unknown run to return ErrNotRunning, got: %v", err)
+	}
+
 	dsJob := &Job{
 		Name:        "b5/libp2p_node_count",
 		Type:        JTDataset,
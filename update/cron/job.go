@@ -62,6 +62,9 @@ type Job struct {
 	RepoPath string `json:"repoPath,omitempty"`
 
 	Options Options `json:"options,omitempty"`
+	// FailurePolicy configures retrying failed runs & notification of runs
+	// that still fail
+	FailurePolicy *FailurePolicy `json:"failurePolicy,omitempty"`
 }
 
 // zero is a "constant" representing an empty repeating interval
@@ -80,7 +83,7 @@ func (job *Job) Validate() error {
 	if job.Type != JTDataset && job.Type != JTShellScript {
 		return fmt.Errorf("invalid job type: %s", job.Type)
 	}
-	return nil
+	return job.FailurePolicy.Validate()
 }
 
 // NextExec returns the next time execution horizon. If job periodicity is
@@ -110,6 +113,8 @@ func (job *Job) Copy() *Job {
 		RunError:    job.RunError,
 		LogFilePath: job.LogFilePath,
 		RepoPath:    job.RepoPath,
+
+		FailurePolicy: job.FailurePolicy.Copy(),
 	}
 
 	if job.Options != nil {
@@ -144,6 +149,10 @@ func (job *Job) MarshalFlatbuffer(builder *flatbuffers.Builder) flatbuffers.UOff
 	if job.Options != nil {
 		opts = job.Options.MarshalFlatbuffer(builder)
 	}
+	var policy flatbuffers.UOffsetT
+	if job.FailurePolicy != nil {
+		policy = job.FailurePolicy.MarshalFlatbuffer(builder)
+	}
 
 	cronfb.JobStart(builder)
 	cronfb.JobAddName(builder, name)
@@ -163,6 +172,9 @@ func (job *Job) MarshalFlatbuffer(builder *flatbuffers.Builder) flatbuffers.UOff
 	if opts != 0 {
 		cronfb.JobAddOptions(builder, opts)
 	}
+	if policy != 0 {
+		cronfb.JobAddFailurePolicy(builder, policy)
+	}
 	return cronfb.JobEnd(builder)
 }
 
@@ -221,6 +233,11 @@ func (job *Job) UnmarshalFlatbuffer(j *cronfb.Job) error {
 		}
 	}
 
+	if fbp := j.FailurePolicy(nil); fbp != nil {
+		job.FailurePolicy = &FailurePolicy{}
+		job.FailurePolicy.UnmarshalFlatbuffer(fbp)
+	}
+
 	return nil
 }
 
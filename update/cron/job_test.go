@@ -60,6 +60,7 @@ func TestJobCopy(t *testing.T) {
 		Options: &DatasetOptions{
 			FilePaths: []string{"the", "file", "paths"},
 		},
+		FailurePolicy: &FailurePolicy{Retries: 2, Backoff: time.Minute, NotifyURL: "https://example.com"},
 	}
 
 	if err := CompareJobs(a, a.Copy()); err != nil {
@@ -108,6 +109,12 @@ func CompareJobs(a, b *Job) error {
 		return fmt.Errorf("Options: %s", err)
 	}
 
+	if a.FailurePolicy == nil && b.FailurePolicy != nil || a.FailurePolicy != nil && b.FailurePolicy == nil {
+		return fmt.Errorf("FailurePolicy nil mismatch: %v != %v", a.FailurePolicy, b.FailurePolicy)
+	} else if a.FailurePolicy != nil && *a.FailurePolicy != *b.FailurePolicy {
+		return fmt.Errorf("FailurePolicy mismatch: %v != %v", *a.FailurePolicy, *b.FailurePolicy)
+	}
+
 	return nil
 }
 
@@ -3,6 +3,7 @@ package cron
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	flatbuffers "github.com/google/flatbuffers/go"
 	cronfb "github.com/qri-io/qri/update/cron/cron_fbs"
@@ -29,6 +30,11 @@ func TestJobsFlatbuffer(t *testing.T) {
 			Periodicity: mustRepeatingInterval("R/PT1H"),
 			Type:        JTDataset,
 			Options:     &DatasetOptions{Title: "Yus"},
+			FailurePolicy: &FailurePolicy{
+				Retries:   3,
+				Backoff:   time.Minute,
+				NotifyURL: "https://example.com/hook",
+			},
 		},
 		&Job{
 			Name:        "job_two",
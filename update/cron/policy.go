@@ -0,0 +1,135 @@
+package cron
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	cronfb "github.com/qri-io/qri/update/cron/cron_fbs"
+)
+
+// FailurePolicy configures how a job recovers from failed runs. Failed runs
+// are retried, and if the last retry fails too a notification is sent
+type FailurePolicy struct {
+	// Retries is the number of times a failed run is tried again
+	Retries int `json:"retries,omitempty"`
+	// Backoff is the wait before the first retry, doubling before each retry
+	// after that
+	Backoff time.Duration `json:"backoff,omitempty"`
+	// NotifyURL is a webhook that's sent a POST request with the failed job as
+	// a JSON body when a run fails after all retries
+	NotifyURL string `json:"notifyURL,omitempty"`
+}
+
+// Validate confirms a policy is usable
+func (p *FailurePolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.Retries < 0 {
+		return fmt.Errorf("retries can't be negative")
+	}
+	if p.Backoff < 0 {
+		return fmt.Errorf("retry backoff can't be negative")
+	}
+	if p.NotifyURL != "" {
+		u, err := url.Parse(p.NotifyURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("notify url must be an http or https url, got: %q", p.NotifyURL)
+		}
+	}
+	return nil
+}
+
+// RetryDelay is the wait before a retry. the first retry is 1
+func (p *FailurePolicy) RetryDelay(retry int) time.Duration {
+	if p == nil || retry < 1 {
+		return 0
+	}
+	return p.Backoff << uint(retry-1)
+}
+
+// Run calls run, trying again as many times as the policy allows while run
+// returns an error. Run returns the error of the last try, or ctx's error if
+// ctx is cancelled while waiting to retry. A nil policy calls run once
+func (p *FailurePolicy) Run(ctx context.Context, run func() error) (err error) {
+	retries := 0
+	if p != nil {
+		retries = p.Retries
+	}
+
+	for try := 0; ; try++ {
+		if err = run(); err == nil || try == retries || ctx.Err() != nil {
+			return err
+		}
+		log.Infof("run failed, retrying (%d/%d): %s", try+1, retries, err)
+
+		t := time.NewTimer(p.RetryDelay(try + 1))
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Notify sends a failed job to the policy's webhook. Notify is a no-op when
+// no webhook is configured
+func (p *FailurePolicy) Notify(ctx context.Context, job *Job) error {
+	if p == nil || p.NotifyURL == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", p.NotifyURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("notifying %s of failure: unexpected status: %d", p.NotifyURL, res.StatusCode)
+	}
+	return nil
+}
+
+// Copy creates a copy of a policy
+func (p *FailurePolicy) Copy() *FailurePolicy {
+	if p == nil {
+		return nil
+	}
+	cp := *p
+	return &cp
+}
+
+// MarshalFlatbuffer writes a policy to a builder
+func (p *FailurePolicy) MarshalFlatbuffer(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	notifyURL := builder.CreateString(p.NotifyURL)
+
+	cronfb.FailurePolicyStart(builder)
+	cronfb.FailurePolicyAddRetries(builder, int32(p.Retries))
+	cronfb.FailurePolicyAddBackoff(builder, int64(p.Backoff))
+	cronfb.FailurePolicyAddNotifyURL(builder, notifyURL)
+	return cronfb.FailurePolicyEnd(builder)
+}
+
+// UnmarshalFlatbuffer reads flatbuffer data into a policy
+func (p *FailurePolicy) UnmarshalFlatbuffer(fbp *cronfb.FailurePolicy) {
+	p.Retries = int(fbp.Retries())
+	p.Backoff = time.Duration(fbp.Backoff())
+	p.NotifyURL = string(fbp.NotifyURL())
+}
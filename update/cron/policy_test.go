@@ -0,0 +1,114 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFailurePolicyValidate(t *testing.T) {
+	good := []*FailurePolicy{
+		nil,
+		{},
+		{Retries: 3, Backoff: time.Minute, NotifyURL: "https://example.com/hook"},
+	}
+	for i, p := range good {
+		if err := p.Validate(); err != nil {
+			t.Errorf("case %d: unexpected error: %s", i, err)
+		}
+	}
+
+	bad := []*FailurePolicy{
+		{Retries: -1},
+		{Backoff: -time.Second},
+		{NotifyURL: "mailto:me@example.com"},
+		{NotifyURL: "example.com/hook"},
+	}
+	for i, p := range bad {
+		if err := p.Validate(); err == nil {
+			t.Errorf("case %d: expected error, got nil", i)
+		}
+	}
+}
+
+func TestFailurePolicyRun(t *testing.T) {
+	ctx := context.Background()
+	failing := func(calls *int) func() error {
+		return func() error {
+			*calls++
+			return fmt.Errorf("oh noes")
+		}
+	}
+
+	calls := 0
+	var p *FailurePolicy
+	if err := p.Run(ctx, failing(&calls)); err == nil || calls != 1 {
+		t.Errorf("expected nil policy to run once & fail. runs: %d, error: %v", calls, err)
+	}
+
+	calls = 0
+	p = &FailurePolicy{Retries: 2, Backoff: time.Millisecond}
+	if err := p.Run(ctx, failing(&calls)); err == nil || calls != 3 {
+		t.Errorf("expected run to be tried 3 times & fail. runs: %d, error: %v", calls, err)
+	}
+
+	calls = 0
+	err := p.Run(ctx, func() error {
+		if calls++; calls < 2 {
+			return fmt.Errorf("flaky")
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("expected run to succeed on retry. runs: %d, error: %v", calls, err)
+	}
+
+	calls = 0
+	p = &FailurePolicy{Retries: 2, Backoff: time.Hour}
+	ctx, cancel := context.WithTimeout(ctx, time.Millisecond*20)
+	defer cancel()
+	if err := p.Run(ctx, failing(&calls)); err != context.DeadlineExceeded || calls != 1 {
+		t.Errorf("expected cancelled context to stop retries. runs: %d, error: %v", calls, err)
+	}
+}
+
+func TestFailurePolicyRetryDelay(t *testing.T) {
+	p := &FailurePolicy{Backoff: time.Second}
+	expect := []time.Duration{0, time.Second, 2 * time.Second, 4 * time.Second}
+	for i, e := range expect {
+		if got := p.RetryDelay(i); got != e {
+			t.Errorf("retry %d delay mismatch. expected: %s, got: %s", i, e, got)
+		}
+	}
+}
+
+func TestFailurePolicyNotify(t *testing.T) {
+	var got *Job
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = &Job{}
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	job := &Job{Name: "b5/nightly", Type: JTDataset, RunError: "transform failed"}
+	p := &FailurePolicy{NotifyURL: s.URL}
+	if err := p.Notify(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Name != job.Name || got.RunError != job.RunError {
+		t.Errorf("expected webhook to receive failed job, got: %v", got)
+	}
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	p = &FailurePolicy{NotifyURL: missing.URL}
+	if err := p.Notify(context.Background(), job); err == nil {
+		t.Error("expected error when webhook doesn't accept notification")
+	}
+}
@@ -109,7 +109,21 @@ func Factory(context.Context) cron.RunJobFunc {
 			return fmt.Errorf("unrecognized update type: %s", job.Type)
 		}
 
-		err := cmd.Run()
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+		// kill the command if the job is cancelled
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				cmd.Process.Kill()
+			case <-done:
+			}
+		}()
+
+		err := cmd.Wait()
 		return processJobError(job, errBuf, err)
 	}
 }
@@ -0,0 +1,152 @@
+package watchfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Action is work to perform when files in a watched dataset directory change.
+// An action runs a shell command, POSTs the event to a URL, calls a function,
+// or any combination of the three
+type Action struct {
+	Username string
+	Dsname   string
+	// Command is run by the system shell from within the changed directory
+	Command string
+	// URL is sent the filesystem event as a JSON body
+	URL string
+	// Func is called with the filesystem event
+	Func func(e FilesysEvent) error
+	// Debounce is how long to wait for events to stop arriving before running.
+	// Bursts of events, like an editor saving several files, run the action once
+	Debounce time.Duration
+}
+
+// ActionRunner performs actions in response to filesystem events
+type ActionRunner struct {
+	lk      sync.Mutex
+	actions []*pendingAction
+	client  *http.Client
+}
+
+// pendingAction tracks the debounce timer & most recent event for an action
+type pendingAction struct {
+	Action
+	timer *time.Timer
+	last  FilesysEvent
+	// gen increments with each event, letting timers that fired while a newer
+	// event was being handled know they've been superseded
+	gen int
+}
+
+// NewActionRunner creates an ActionRunner for a set of actions
+func NewActionRunner(actions []Action) *ActionRunner {
+	r := &ActionRunner{
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, a := range actions {
+		r.actions = append(r.actions, &pendingAction{Action: a})
+	}
+	return r
+}
+
+// Handle schedules any actions that apply to a filesystem event
+func (r *ActionRunner) Handle(e FilesysEvent) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	for _, pa := range r.actions {
+		if pa.Username != e.Username || pa.Dsname != e.Dsname {
+			continue
+		}
+		pa.last = e
+		pa.gen++
+		if pa.timer != nil {
+			pa.timer.Stop()
+		}
+		pa, gen := pa, pa.gen
+		pa.timer = time.AfterFunc(pa.Debounce, func() {
+			r.lk.Lock()
+			if pa.gen != gen {
+				r.lk.Unlock()
+				return
+			}
+			e := pa.last
+			pa.timer = nil
+			r.lk.Unlock()
+			if err := r.run(pa.Action, e); err != nil {
+				log.Errorf("running watch action for %s/%s: %s", pa.Username, pa.Dsname, err)
+			}
+		})
+	}
+}
+
+// Stop cancels any actions waiting to run
+func (r *ActionRunner) Stop() {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	for _, pa := range r.actions {
+		if pa.timer != nil {
+			pa.timer.Stop()
+			pa.timer = nil
+			pa.gen++
+		}
+	}
+}
+
+func (r *ActionRunner) run(a Action, e FilesysEvent) error {
+	if a.Command != "" {
+		if err := runCommand(a.Command, e); err != nil {
+			return err
+		}
+	}
+	if a.URL != "" {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		res, err := r.client.Post(a.URL, "application/json", bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if res.StatusCode >= 300 {
+			return fmt.Errorf("posting event to %s: unexpected status %s", a.URL, res.Status)
+		}
+	}
+	if a.Func != nil {
+		return a.Func(e)
+	}
+	return nil
+}
+
+// runCommand executes a command with details of the triggering event set as
+// environment variables
+func runCommand(command string, e FilesysEvent) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Dir = filepath.Dir(e.Source)
+	cmd.Env = append(os.Environ(),
+		"QRI_EVENT_TYPE="+string(e.Type),
+		"QRI_EVENT_SOURCE="+e.Source,
+		fmt.Sprintf("QRI_DATASET=%s/%s", e.Username, e.Dsname),
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("command %q: %s: %s", command, err, out)
+	}
+	log.Debugf("watch action %q output: %s", command, out)
+	return nil
+}
@@ -0,0 +1,108 @@
+package watchfs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActionRunnerURL(t *testing.T) {
+	received := make(chan FilesysEvent, 10)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e := FilesysEvent{}
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Error(err)
+		}
+		received <- e
+	}))
+	defer s.Close()
+
+	r := NewActionRunner([]Action{
+		{Username: "test_peer", Dsname: "ds_name", URL: s.URL, Debounce: 50 * time.Millisecond},
+	})
+	defer r.Stop()
+
+	// events for other datasets are ignored, a burst of events runs once with
+	// the last event
+	r.Handle(FilesysEvent{Type: ModifyFileEvent, Username: "test_peer", Dsname: "other", Source: "/other/body.csv"})
+	r.Handle(FilesysEvent{Type: CreateNewFileEvent, Username: "test_peer", Dsname: "ds_name", Source: "/ds/body.csv"})
+	r.Handle(FilesysEvent{Type: ModifyFileEvent, Username: "test_peer", Dsname: "ds_name", Source: "/ds/meta.json"})
+
+	select {
+	case e := <-received:
+		if e.Type != ModifyFileEvent || e.Source != "/ds/meta.json" {
+			t.Errorf("expected last event to be sent, got: %v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for action")
+	}
+
+	select {
+	case e := <-received:
+		t.Errorf("expected burst of events to run action once, got additional event: %v", e)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestActionRunnerFunc(t *testing.T) {
+	called := make(chan FilesysEvent, 10)
+	r := NewActionRunner([]Action{
+		{Username: "test_peer", Dsname: "ds_name", Debounce: 50 * time.Millisecond, Func: func(e FilesysEvent) error {
+			called <- e
+			return nil
+		}},
+	})
+	defer r.Stop()
+	r.Handle(FilesysEvent{Type: ModifyFileEvent, Username: "test_peer", Dsname: "ds_name", Source: "/ds/body.csv"})
+
+	select {
+	case e := <-called:
+		if e.Source != "/ds/body.csv" {
+			t.Errorf("expected func to be called with the event, got: %v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for action")
+	}
+}
+
+func TestActionRunnerCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("command test uses a posix shell")
+	}
+
+	dir, err := ioutil.TempDir("", "watchfs_actions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := NewActionRunner([]Action{
+		{Username: "test_peer", Dsname: "ds_name", Command: `echo "$QRI_DATASET $QRI_EVENT_TYPE" > out.txt`},
+	})
+	defer r.Stop()
+	r.Handle(FilesysEvent{Type: ModifyFileEvent, Username: "test_peer", Dsname: "ds_name", Source: filepath.Join(dir, "body.csv")})
+
+	out := filepath.Join(dir, "out.txt")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, err := ioutil.ReadFile(out)
+		if err == nil && len(data) > 0 {
+			expect := "test_peer/ds_name modify"
+			if got := strings.TrimSpace(string(data)); got != expect {
+				t.Errorf("command output mismatch. expected: %q, got: %q", expect, got)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for command to run")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}